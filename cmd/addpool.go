@@ -0,0 +1,313 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/engine"
+	"github.com/Azure/aks-engine/pkg/engine/transform"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	addPoolName             = "addpool"
+	addPoolShortDescription = "Add a node pool to an existing Kubernetes cluster"
+	addPoolLongDescription  = "Add a node pool to an existing Kubernetes cluster by deploying only the resources of a new agent pool definition, then adding the agent pool to the api model"
+)
+
+type addPoolCmd struct {
+	authArgs
+
+	// user input
+	apiModelPath      string
+	resourceGroupName string
+	location          string
+	nodePoolPath      string
+
+	// derived
+	containerService *api.ContainerService
+	apiVersion       string
+	agentPool        *api.AgentPoolProfile
+	agentPoolIndex   int
+	client           armhelpers.AKSEngineClient
+	locale           *gotext.Locale
+	logger           *log.Entry
+}
+
+func newAddPoolCmd() *cobra.Command {
+	apc := addPoolCmd{}
+
+	command := &cobra.Command{
+		Use:   addPoolName,
+		Short: addPoolShortDescription,
+		Long:  addPoolLongDescription,
+		RunE:  apc.run,
+	}
+
+	f := command.Flags()
+	f.StringVarP(&apc.location, "location", "l", "", "location the cluster is deployed in (required)")
+	f.StringVarP(&apc.resourceGroupName, "resource-group", "g", "", "the resource group where the cluster is deployed (required)")
+	f.StringVarP(&apc.apiModelPath, "api-model", "m", "", "path to the generated apimodel.json file (required)")
+	f.StringVarP(&apc.nodePoolPath, "node-pool", "p", "", "path to a JSON file with the agent pool profile to add, in the api version of the api model (required)")
+
+	addAuthFlags(&apc.authArgs, f)
+
+	return command
+}
+
+func (apc *addPoolCmd) validate(cmd *cobra.Command) error {
+	log.Debugln("validating addpool command line arguments...")
+	var err error
+
+	apc.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if apc.resourceGroupName == "" {
+		cmd.Usage()
+		return errors.New("--resource-group must be specified")
+	}
+
+	if apc.location == "" {
+		cmd.Usage()
+		return errors.New("--location must be specified")
+	}
+	apc.location = helpers.NormalizeAzureRegion(apc.location)
+
+	if apc.apiModelPath == "" {
+		cmd.Usage()
+		return errors.New("--api-model must be specified")
+	}
+
+	if apc.nodePoolPath == "" {
+		cmd.Usage()
+		return errors.New("--node-pool must be specified")
+	}
+
+	return nil
+}
+
+func (apc *addPoolCmd) load() error {
+	apc.logger = log.NewEntry(log.New())
+	var err error
+
+	if _, err = os.Stat(apc.apiModelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", apc.apiModelPath)
+	}
+	apiModel, err := ioutil.ReadFile(apc.apiModelPath)
+	if err != nil {
+		return errors.Wrapf(err, "reading the api model %s", apc.apiModelPath)
+	}
+	nodePool, err := ioutil.ReadFile(apc.nodePoolPath)
+	if err != nil {
+		return errors.Wrapf(err, "reading the node pool %s", apc.nodePoolPath)
+	}
+	contents, err := addAgentPoolToAPIModel(apiModel, nodePool)
+	if err != nil {
+		return err
+	}
+
+	// the api model is validated with the new agent pool, so the same checks apply to it as to a new cluster
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: apc.locale,
+		},
+	}
+	apc.containerService, apc.apiVersion, err = apiloader.DeserializeContainerService(contents, true, true, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model with the new node pool")
+	}
+
+	if apc.containerService.Properties.IsAzureStackCloud() {
+		writeCustomCloudProfile(apc.containerService)
+		if err = apc.containerService.Properties.SetAzureStackCloudSpec(); err != nil {
+			return errors.Wrap(err, "error parsing the api model")
+		}
+	}
+
+	if apc.containerService.Location == "" {
+		apc.containerService.Location = apc.location
+	} else if apc.containerService.Location != apc.location {
+		return errors.New("--location does not match api model location")
+	}
+
+	apc.agentPoolIndex = len(apc.containerService.Properties.AgentPoolProfiles) - 1
+	apc.agentPool = apc.containerService.Properties.AgentPoolProfiles[apc.agentPoolIndex]
+
+	if err = apc.authArgs.validateAuthArgs(); err != nil {
+		return err
+	}
+
+	if apc.client, err = apc.authArgs.getClient(); err != nil {
+		return errors.Wrap(err, "failed to get client")
+	}
+	return nil
+}
+
+// addAgentPoolToAPIModel returns the api model with the agent pool profile nodePool appended to its agent pools
+func addAgentPoolToAPIModel(apiModel, nodePool []byte) ([]byte, error) {
+	model := map[string]interface{}{}
+	if err := json.Unmarshal(apiModel, &model); err != nil {
+		return nil, errors.Wrap(err, "error parsing the api model")
+	}
+	pool := map[string]interface{}{}
+	if err := json.Unmarshal(nodePool, &pool); err != nil {
+		return nil, errors.Wrap(err, "error parsing the node pool")
+	}
+	name, _ := pool["name"].(string)
+	if name == "" {
+		return nil, errors.New("the node pool has no name")
+	}
+
+	properties, ok := model["properties"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the api model has no properties")
+	}
+	pools, _ := properties["agentPoolProfiles"].([]interface{})
+	for _, p := range pools {
+		if existing, ok := p.(map[string]interface{}); ok && existing["name"] == name {
+			return nil, errors.Errorf("node pool %s already exists in the api model", name)
+		}
+	}
+	properties["agentPoolProfiles"] = append(pools, pool)
+	return json.Marshal(model)
+}
+
+func (apc *addPoolCmd) run(cmd *cobra.Command, args []string) error {
+	if err := apc.validate(cmd); err != nil {
+		return errors.Wrap(err, "failed to validate addpool command")
+	}
+	if err := apc.load(); err != nil {
+		return errors.Wrap(err, "failed to load existing container service")
+	}
+
+	templateJSON, parametersJSON, err := apc.generateTemplate()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	deploymentSuffix := random.Int31()
+
+	apc.logger.Infof("Deploying node pool %s with %d nodes", apc.agentPool.Name, apc.agentPool.Count)
+	_, err = apc.client.DeployTemplate(
+		ctx,
+		apc.resourceGroupName,
+		fmt.Sprintf("%s-%d", apc.resourceGroupName, deploymentSuffix),
+		templateJSON,
+		parametersJSON)
+	if err != nil {
+		return err
+	}
+	apc.logger.Infof("Node pool %s was added to the cluster", apc.agentPool.Name)
+
+	return apc.saveAPIModel()
+}
+
+// generateTemplate generates the template and parameters deploying the resources of the new agent pool only
+func (apc *addPoolCmd) generateTemplate() (map[string]interface{}, map[string]interface{}, error) {
+	translator := engine.Context{
+		Translator: &i18n.Translator{
+			Locale: apc.locale,
+		},
+	}
+	templateGenerator, err := engine.InitializeTemplateGenerator(translator)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to initialize template generator")
+	}
+
+	// Only the resources of the new agent pool are generated, the existing agent pools are left as they are
+	apc.containerService.Properties.AgentPoolProfiles = []*api.AgentPoolProfile{apc.agentPool}
+
+	_, err = apc.containerService.SetPropertiesDefaults(false, true)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "error in SetPropertiesDefaults template %s", apc.apiModelPath)
+	}
+	template, parameters, err := templateGenerator.GenerateTemplateV2(apc.containerService, engine.DefaultGeneratorCode, BuildTag)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "error generating template %s", apc.apiModelPath)
+	}
+
+	if template, err = transform.PrettyPrintArmTemplate(template); err != nil {
+		return nil, nil, errors.Wrap(err, "error pretty printing template")
+	}
+
+	templateJSON := make(map[string]interface{})
+	parametersJSON := make(map[string]interface{})
+
+	err = json.Unmarshal([]byte(template), &templateJSON)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error unmarshaling template")
+	}
+
+	err = json.Unmarshal([]byte(parameters), &parametersJSON)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error unmarshaling parameters")
+	}
+
+	transformer := transform.Transformer{Translator: translator.Translator}
+
+	// The agent pool is set to index 0 for the template, we need to overwrite the template variables that rely on pool index.
+	if apc.agentPool.IsWindows() {
+		templateJSON["variables"].(map[string]interface{})[apc.agentPool.Name+"Index"] = apc.agentPoolIndex
+		templateJSON["variables"].(map[string]interface{})[apc.agentPool.Name+"VMNamePrefix"] = apc.containerService.Properties.GetAgentVMPrefix(apc.agentPool, apc.agentPoolIndex)
+	}
+	orchestratorInfo := apc.containerService.Properties.OrchestratorProfile
+	if orchestratorInfo.KubernetesConfig != nil && orchestratorInfo.KubernetesConfig.LoadBalancerSku == api.StandardLoadBalancerSku {
+		err = transformer.NormalizeForK8sSLBScalingOrUpgrade(apc.logger, templateJSON)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error transforming the template for adding a node pool with SLB %s", apc.apiModelPath)
+		}
+	}
+	err = transformer.NormalizeForK8sAddAgentPool(apc.logger, templateJSON, apc.agentPool.Name)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "error transforming the template for adding a node pool %s", apc.apiModelPath)
+	}
+	return templateJSON, parametersJSON, nil
+}
+
+// saveAPIModel saves the api model with the new agent pool appended to its agent pools
+func (apc *addPoolCmd) saveAPIModel() error {
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: apc.locale,
+		},
+	}
+	cs, apiVersion, err := apiloader.LoadContainerServiceFromFile(apc.apiModelPath, false, true, nil)
+	if err != nil {
+		return err
+	}
+	cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, apc.agentPool)
+
+	b, err := apiloader.SerializeContainerService(cs, apiVersion)
+	if err != nil {
+		return err
+	}
+
+	f := helpers.FileSaver{
+		Translator: &i18n.Translator{
+			Locale: apc.locale,
+		},
+	}
+	dir, file := filepath.Split(apc.apiModelPath)
+	return f.SaveFile(dir, file, b)
+}
@@ -0,0 +1,164 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func TestNewAddPoolCmd(t *testing.T) {
+	command := newAddPoolCmd()
+	if command.Use != addPoolName || command.Short != addPoolShortDescription || command.Long != addPoolLongDescription {
+		t.Fatalf("addpool command should have use %s equal %s, short %s equal %s and long %s equal to %s", command.Use, addPoolName, command.Short, addPoolShortDescription, command.Long, addPoolLongDescription)
+	}
+
+	expectedFlags := []string{"location", "resource-group", "api-model", "node-pool"}
+	for _, f := range expectedFlags {
+		if command.Flags().Lookup(f) == nil {
+			t.Fatalf("addpool command should have flag %s", f)
+		}
+	}
+
+	command.SetArgs([]string{})
+	if err := command.Execute(); err == nil {
+		t.Fatalf("expected an error when calling addpool with no arguments")
+	}
+}
+
+func TestAddPoolCmdValidate(t *testing.T) {
+	cases := []struct {
+		apc         *addPoolCmd
+		expectedErr string
+	}{
+		{
+			apc:         &addPoolCmd{location: "centralus", apiModelPath: "apimodel.json", nodePoolPath: "pool.json"},
+			expectedErr: "--resource-group must be specified",
+		},
+		{
+			apc:         &addPoolCmd{resourceGroupName: "testRG", apiModelPath: "apimodel.json", nodePoolPath: "pool.json"},
+			expectedErr: "--location must be specified",
+		},
+		{
+			apc:         &addPoolCmd{resourceGroupName: "testRG", location: "centralus", nodePoolPath: "pool.json"},
+			expectedErr: "--api-model must be specified",
+		},
+		{
+			apc:         &addPoolCmd{resourceGroupName: "testRG", location: "centralus", apiModelPath: "apimodel.json"},
+			expectedErr: "--node-pool must be specified",
+		},
+		{
+			apc: &addPoolCmd{resourceGroupName: "testRG", location: "Central US", apiModelPath: "apimodel.json", nodePoolPath: "pool.json"},
+		},
+	}
+
+	for _, c := range cases {
+		err := c.apc.validate(&cobra.Command{})
+		if c.expectedErr == "" {
+			if err != nil {
+				t.Fatalf("expected validate addpool command to return no error, but instead got %s", err.Error())
+			}
+			if c.apc.location != "centralus" {
+				t.Fatalf("expected validate addpool command to normalize the location, but instead got %s", c.apc.location)
+			}
+		} else if err == nil || err.Error() != c.expectedErr {
+			t.Fatalf("expected validate addpool command to return error %s, but instead got %v", c.expectedErr, err)
+		}
+	}
+}
+
+func TestAddAgentPoolToAPIModel(t *testing.T) {
+	apiModel, err := ioutil.ReadFile("../pkg/engine/testdata/simple/kubernetes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := addAgentPoolToAPIModel(apiModel, []byte(`{"name": "gpupool", "count": 2, "vmSize": "Standard_NC6", "availabilityProfile": "AvailabilitySet"}`))
+	if err != nil {
+		t.Fatalf("unexpected error adding a node pool to the api model: %s", err)
+	}
+	model := struct {
+		Properties struct {
+			AgentPoolProfiles []struct {
+				Name string `json:"name"`
+			} `json:"agentPoolProfiles"`
+		} `json:"properties"`
+	}{}
+	if err = json.Unmarshal(contents, &model); err != nil {
+		t.Fatal(err)
+	}
+	pools := model.Properties.AgentPoolProfiles
+	if len(pools) != 3 || pools[2].Name != "gpupool" {
+		t.Fatalf("expected the node pool to be appended to the agent pools of the api model, but instead got %v", pools)
+	}
+
+	cases := []struct {
+		nodePool    string
+		expectedErr string
+	}{
+		{nodePool: `{"name": "agentpool2", "count": 2}`, expectedErr: "node pool agentpool2 already exists in the api model"},
+		{nodePool: `{"count": 2}`, expectedErr: "the node pool has no name"},
+		{nodePool: `[]`, expectedErr: "error parsing the node pool"},
+	}
+	for _, c := range cases {
+		if _, err = addAgentPoolToAPIModel(apiModel, []byte(c.nodePool)); err == nil || !strings.HasPrefix(err.Error(), c.expectedErr) {
+			t.Fatalf("expected adding node pool %s to return error %s, but instead got %v", c.nodePool, c.expectedErr, err)
+		}
+	}
+}
+
+func TestAddPoolGenerateTemplate(t *testing.T) {
+	apiModel, err := ioutil.ReadFile("../pkg/engine/testdata/simple/kubernetes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, err := addAgentPoolToAPIModel(apiModel, []byte(`{"name": "gpupool", "count": 2, "vmSize": "Standard_NC6", "availabilityProfile": "AvailabilitySet"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiloader := &api.Apiloader{Translator: &i18n.Translator{}}
+	cs, _, err := apiloader.DeserializeContainerService(contents, true, true, nil)
+	if err != nil {
+		t.Fatalf("unexpected error deserializing the api model with the new node pool: %s", err)
+	}
+	cs.Location = "westus2"
+	apc := &addPoolCmd{
+		apiModelPath:     "apimodel.json",
+		containerService: cs,
+		agentPoolIndex:   2,
+		agentPool:        cs.Properties.AgentPoolProfiles[2],
+		logger:           log.NewEntry(log.New()),
+	}
+
+	template, _, err := apc.generateTemplate()
+	if err != nil {
+		t.Fatalf("unexpected error generating the template of the new node pool: %s", err)
+	}
+	for _, resource := range template["resources"].([]interface{}) {
+		name := resource.(map[string]interface{})["name"].(string)
+		for _, existing := range []string{"agentpool1", "agentpool2", "masterAvailabilitySet", "vnetName", "nsgName"} {
+			if strings.Contains(name, existing) {
+				t.Fatalf("expected the template to deploy only the resources of the new node pool, but it has resource %s", name)
+			}
+		}
+	}
+	if !strings.Contains(mustMarshal(t, template), "variables('gpupoolAvailabilitySet')") {
+		t.Fatalf("expected the template to deploy the availability set of the new node pool")
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,239 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/engine"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/operations/cis"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	checkCISName             = "check-cis"
+	checkCISShortDescription = "Audit an existing Kubernetes cluster against the CIS Kubernetes Benchmark"
+	checkCISLongDescription  = "Run CIS Kubernetes Benchmark checks on the master and Linux agent nodes of a cluster built with AKS Engine over SSH, and report the score of every node. Failed checks point to the api model setting to change, and settings that differ on the node from the api model are reported as drift."
+)
+
+type checkCISCmd struct {
+	authProvider
+
+	// user input
+	apiModelPath string
+	sshFilepath  string
+	masterFQDN   string
+	location     string
+	outputFormat string
+	minScore     float64
+
+	// derived
+	containerService   *api.ContainerService
+	apiVersion         string
+	locale             *gotext.Locale
+	client             armhelpers.AKSEngineClient
+	nodes              []cis.Node
+	sshConfig          *ssh.ClientConfig
+	sshCommandExecuter func(command, masterFQDN, hostname string, port string, config *ssh.ClientConfig) (string, error)
+	out                io.Writer
+}
+
+func newCheckCISCmd() *cobra.Command {
+	ccc := checkCISCmd{
+		authProvider:       &authArgs{},
+		sshCommandExecuter: executeCmd,
+		out:                os.Stdout,
+	}
+
+	command := &cobra.Command{
+		Use:   checkCISName,
+		Short: checkCISShortDescription,
+		Long:  checkCISLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ccc.validate(cmd); err != nil {
+				return errors.Wrap(err, "validating check-cis args")
+			}
+			if err := ccc.loadAPIModel(); err != nil {
+				return errors.Wrap(err, "loading api model")
+			}
+			return ccc.run()
+		},
+	}
+
+	f := command.Flags()
+	f.StringVarP(&ccc.apiModelPath, "api-model", "m", "", "path to the generated apimodel.json file (required)")
+	f.StringVarP(&ccc.sshFilepath, "ssh", "", "", "the filepath of a valid private ssh key to access the cluster's nodes (required)")
+	f.StringVar(&ccc.masterFQDN, "apiserver", "", "apiserver endpoint (derived from the api model if absent)")
+	f.StringVarP(&ccc.location, "location", "l", "", "location the cluster is deployed in (derived from the api model if absent)")
+	f.StringVarP(&ccc.outputFormat, "output", "o", "human", fmt.Sprintf("Output format. Allowed values: %s", strings.Join(outputFormatOptions, ", ")))
+	f.Float64Var(&ccc.minScore, "min-score", 0, "fail if the score of the cluster is lower, in percent")
+
+	addAuthFlags(ccc.getAuthArgs(), f)
+
+	return command
+}
+
+func (ccc *checkCISCmd) validate(cmd *cobra.Command) error {
+	var err error
+
+	ccc.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if ccc.apiModelPath == "" {
+		cmd.Usage()
+		return errors.New("--api-model must be specified")
+	}
+
+	if _, err = os.Stat(ccc.apiModelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", ccc.apiModelPath)
+	}
+
+	if ccc.sshFilepath == "" {
+		cmd.Usage()
+		return errors.New("--ssh must be specified")
+	}
+
+	if _, err = os.Stat(ccc.sshFilepath); os.IsNotExist(err) {
+		return errors.Errorf("specified ssh filepath does not exist (%s)", ccc.sshFilepath)
+	}
+
+	if ccc.outputFormat != "human" && ccc.outputFormat != "json" {
+		return errors.Errorf(`output format "%s" is not supported`, ccc.outputFormat)
+	}
+
+	if err = ccc.getAuthArgs().validateAuthArgs(); err != nil {
+		return errors.Wrap(err, "failed to get validate auth args")
+	}
+
+	return nil
+}
+
+func (ccc *checkCISCmd) loadAPIModel() error {
+	var err error
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: ccc.locale,
+		},
+	}
+	ccc.containerService, ccc.apiVersion, err = apiloader.LoadContainerServiceFromFile(ccc.apiModelPath, true, true, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+
+	if ccc.containerService.Properties.MasterProfile == nil {
+		return errors.New("checking a cluster requires a masterProfile in the api model")
+	}
+
+	if ccc.location == "" {
+		ccc.location = ccc.containerService.Location
+	}
+	ccc.location = helpers.NormalizeAzureRegion(ccc.location)
+
+	if ccc.masterFQDN == "" {
+		ccc.masterFQDN = ccc.containerService.Properties.MasterProfile.FQDN
+	}
+	if ccc.masterFQDN == "" {
+		return errors.New("--apiserver must be specified when the api model has no master FQDN")
+	}
+
+	return nil
+}
+
+func (ccc *checkCISCmd) run() error {
+	var err error
+
+	if ccc.client, err = ccc.authProvider.getClient(); err != nil {
+		return errors.Wrap(err, "failed to get client")
+	}
+
+	log.Debugf("Getting cluster nodes")
+	if err = ccc.getClusterNodes(); err != nil {
+		return errors.Wrap(err, "listing cluster nodes")
+	}
+
+	ccc.sshConfig = &ssh.ClientConfig{
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		User:            ccc.containerService.Properties.LinuxProfile.AdminUsername,
+		Auth: []ssh.AuthMethod{
+			publicKeyFile(ccc.sshFilepath),
+		},
+	}
+
+	log.Infof("Running %s checks on %d nodes", cis.Benchmark, len(ccc.nodes))
+	report, err := cis.Run(ccc.containerService, ccc.nodes, cis.Checks(), ccc.runAudit)
+	if err != nil {
+		return errors.Wrap(err, "running checks")
+	}
+
+	switch ccc.outputFormat {
+	case "json":
+		b, err := helpers.JSONMarshalIndent(report, "", "  ", false)
+		if err != nil {
+			return errors.Wrap(err, "error encoding report to json")
+		}
+		fmt.Fprintln(ccc.out, string(b))
+	default:
+		if err = report.WriteText(ccc.out); err != nil {
+			return errors.Wrap(err, "writing report")
+		}
+	}
+
+	if report.Score < ccc.minScore {
+		return errors.Errorf("cluster score %.1f%% is lower than --min-score %.1f%%", report.Score, ccc.minScore)
+	}
+	return nil
+}
+
+// runAudit runs an audit command on a node through the master load balancer and returns its output
+func (ccc *checkCISCmd) runAudit(hostname, command string) (string, error) {
+	out, err := ccc.sshCommandExecuter(command, ccc.masterFQDN, hostname, "22", ccc.sshConfig)
+	if err != nil {
+		log.Printf("Command %s output: %s\n", command, out)
+		return "", err
+	}
+	// executeCmd prefixes the output with the hostname
+	return strings.TrimPrefix(out, fmt.Sprintf("%s -> ", hostname)), nil
+}
+
+func (ccc *checkCISCmd) getClusterNodes() error {
+	kubeconfig, err := engine.GenerateKubeConfig(ccc.containerService.Properties, ccc.location)
+	if err != nil {
+		return errors.Wrap(err, "generating kubeconfig")
+	}
+	kubeClient, err := ccc.client.GetKubernetesClient("", kubeconfig, time.Second*1, time.Duration(60)*time.Minute)
+	if err != nil {
+		return errors.Wrap(err, "failed to get a Kubernetes client")
+	}
+	nodeList, err := kubeClient.ListNodes()
+	if err != nil {
+		return errors.Wrap(err, "failed to get cluster nodes")
+	}
+	for _, node := range nodeList.Items {
+		n, ok := cis.NodeForName(ccc.containerService, node.Name)
+		if !ok {
+			log.Warnf("Skipping node %s, checks only run on master and Linux agent nodes", node.Name)
+			continue
+		}
+		ccc.nodes = append(ccc.nodes, n)
+	}
+	if len(ccc.nodes) == 0 {
+		return errors.New("no master or Linux agent nodes found")
+	}
+	return nil
+}
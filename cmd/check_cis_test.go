@@ -0,0 +1,138 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/operations/cis"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewCheckCISCmd(t *testing.T) {
+	output := newCheckCISCmd()
+	if output.Use != checkCISName || output.Short != checkCISShortDescription || output.Long != checkCISLongDescription {
+		t.Fatalf("check-cis command should have use %s equal %s, short %s equal %s and long %s equal to %s", output.Use, checkCISName, output.Short, checkCISShortDescription, output.Long, checkCISLongDescription)
+	}
+
+	expectedFlags := []string{"api-model", "ssh", "apiserver", "location", "output", "min-score"}
+	for _, f := range expectedFlags {
+		if output.Flags().Lookup(f) == nil {
+			t.Fatalf("check-cis command should have flag %s", f)
+		}
+	}
+}
+
+func TestCheckCISCmdValidate(t *testing.T) {
+	cases := []struct {
+		ccc         checkCISCmd
+		expectedErr string
+	}{
+		{
+			ccc:         checkCISCmd{authProvider: &authArgs{}},
+			expectedErr: "--api-model must be specified",
+		},
+		{
+			ccc:         checkCISCmd{authProvider: &authArgs{}, apiModelPath: "../pkg/engine/testdata/simple/kubernetes.json"},
+			expectedErr: "--ssh must be specified",
+		},
+		{
+			ccc:         checkCISCmd{authProvider: &authArgs{}, apiModelPath: "../pkg/engine/testdata/simple/kubernetes.json", sshFilepath: "../pkg/engine/testdata/simple/kubernetes.json", outputFormat: "yaml"},
+			expectedErr: `output format "yaml" is not supported`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		err := c.ccc.validate(&cobra.Command{})
+		if err == nil || err.Error() != c.expectedErr {
+			t.Errorf("expected error %q, got %v", c.expectedErr, err)
+		}
+	}
+}
+
+func TestCheckCISGetClusterNodes(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 1, 1, false)
+	p := cs.Properties
+	mockClient := &armhelpers.MockAKSEngineClient{MockKubernetesClient: &armhelpers.MockKubernetesClient{}}
+	mockClient.MockKubernetesClient.NodeList = &v1.NodeList{
+		Items: []v1.Node{
+			{ObjectMeta: metav1.ObjectMeta{Name: p.GetMasterVMPrefix() + "0"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: p.GetAgentVMPrefix(p.AgentPoolProfiles[0], 0) + "0"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "1234k8s000"}},
+		},
+	}
+	ccc := checkCISCmd{
+		client:           mockClient,
+		containerService: cs,
+		location:         "westus",
+	}
+
+	err := ccc.getClusterNodes()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ccc.nodes).To(HaveLen(2))
+	g.Expect(ccc.nodes[0].Role).To(Equal(cis.RoleMaster))
+	g.Expect(ccc.nodes[1].Pool).To(Equal(p.AgentPoolProfiles[0]))
+
+	mockClient.MockKubernetesClient.FailListNodes = true
+	err = ccc.getClusterNodes()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("failed to get cluster nodes"))
+}
+
+func TestCheckCISCmdRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 1, 1, false)
+	mockClient := &armhelpers.MockAKSEngineClient{MockKubernetesClient: &armhelpers.MockKubernetesClient{}}
+	mockClient.MockKubernetesClient.NodeList = &v1.NodeList{
+		Items: []v1.Node{
+			{ObjectMeta: metav1.ObjectMeta{Name: cs.Properties.GetMasterVMPrefix() + "0"}},
+		},
+	}
+	var out bytes.Buffer
+	ccc := checkCISCmd{
+		authProvider: &mockAuthProvider{
+			authArgs:      &authArgs{},
+			getClientMock: mockClient,
+		},
+		containerService: cs,
+		location:         "westus",
+		masterFQDN:       "valid",
+		outputFormat:     "json",
+		out:              &out,
+		sshCommandExecuter: func(command, masterFQDN, hostname string, port string, config *ssh.ClientConfig) (string, error) {
+			if strings.Contains(command, "stat") {
+				return hostname + " -> 644 root:root\n", nil
+			}
+			return hostname + " -> /usr/local/bin/kubelet --anonymous-auth=false\n", nil
+		},
+	}
+
+	err := ccc.run()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out.String()).To(ContainSubstring(`"benchmark": "` + cis.Benchmark + `"`))
+	g.Expect(out.String()).To(ContainSubstring(`"actual": "false"`))
+
+	ccc.nodes = nil
+	ccc.minScore = 100
+	err = ccc.run()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("is lower than --min-score"))
+
+	ccc.nodes = nil
+	ccc.sshCommandExecuter = mockExecuteCmd
+	ccc.masterFQDN = "invalid"
+	err = ccc.run()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("running checks"))
+}
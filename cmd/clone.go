@@ -0,0 +1,284 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/engine/transform"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cloneName             = "clone"
+	cloneShortDescription = "Derive a new cluster definition from an existing cluster"
+	cloneLongDescription  = "Derive a new cluster definition from the generated apimodel.json of an existing cluster. Certificates, the etcd encryption key, DNS names and custom VNET references are reset so the result can be deployed as an identical cluster into another region, resource group or subscription. The cluster and service IP ranges are copied unless --cluster-subnet or --service-cidr give new ones."
+)
+
+type cloneCmd struct {
+	// user input
+	apimodelPath    string
+	dnsPrefix       string
+	location        string
+	outputDirectory string
+	forceOverwrite  bool
+	set             []string
+	clusterSubnet   string
+	serviceCIDR     string
+
+	// derived
+	containerService *api.ContainerService
+	apiVersion       string
+	locale           *gotext.Locale
+}
+
+func newCloneCmd() *cobra.Command {
+	cc := cloneCmd{}
+
+	command := &cobra.Command{
+		Use:   cloneName,
+		Short: cloneShortDescription,
+		Long:  cloneLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cc.validate(cmd); err != nil {
+				return errors.Wrap(err, "validating clone args")
+			}
+			if err := cc.loadAPIModel(); err != nil {
+				return errors.Wrap(err, "loading api model")
+			}
+			return cc.run()
+		},
+	}
+
+	f := command.Flags()
+	f.StringVarP(&cc.apimodelPath, "api-model", "m", "", "path to the generated apimodel.json file of the existing cluster (required)")
+	f.StringVarP(&cc.dnsPrefix, "dns-prefix", "p", "", "dns prefix of the new cluster (required)")
+	f.StringVarP(&cc.location, "location", "l", "", "location of the new cluster (defaults to the location of the existing cluster)")
+	f.StringVarP(&cc.outputDirectory, "output-directory", "o", "", "output directory (derived from the new dns prefix if absent)")
+	f.BoolVarP(&cc.forceOverwrite, "force-overwrite", "f", false, "automatically overwrite an existing apimodel.json in the output directory")
+	f.StringVar(&cc.clusterSubnet, "cluster-subnet", "", "pod IP range of the new cluster, the IP range of its nodes too with Azure CNI (defaults to the one of the existing cluster)")
+	f.StringVar(&cc.serviceCIDR, "service-cidr", "", "service IP range of the new cluster, its DNS service gets the 10th address of the range (defaults to the one of the existing cluster)")
+	f.StringArrayVar(&cc.set, "set", []string{}, "set values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
+
+	return command
+}
+
+func (cc *cloneCmd) validate(cmd *cobra.Command) error {
+	var err error
+
+	cc.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if cc.apimodelPath == "" {
+		cmd.Usage()
+		return errors.New("--api-model must be specified")
+	}
+
+	if _, err = os.Stat(cc.apimodelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", cc.apimodelPath)
+	}
+
+	if cc.dnsPrefix == "" {
+		cmd.Usage()
+		return errors.New("--dns-prefix must be specified")
+	}
+
+	if cc.clusterSubnet != "" {
+		for _, subnet := range strings.Split(cc.clusterSubnet, ",") {
+			if _, _, err = net.ParseCIDR(subnet); err != nil {
+				return errors.Errorf("--cluster-subnet '%s' is an invalid CIDR", subnet)
+			}
+		}
+	}
+
+	if cc.serviceCIDR != "" {
+		_, serviceCIDR, err := net.ParseCIDR(cc.serviceCIDR)
+		if err != nil || serviceCIDR.IP.To4() == nil {
+			return errors.Errorf("--service-cidr '%s' is not a valid IPv4 CIDR", cc.serviceCIDR)
+		}
+		if ones, _ := serviceCIDR.Mask.Size(); ones > 28 {
+			return errors.Errorf("--service-cidr '%s' must be a /28 or larger range", cc.serviceCIDR)
+		}
+	}
+
+	cc.location = helpers.NormalizeAzureRegion(cc.location)
+
+	if cc.outputDirectory == "" {
+		cc.outputDirectory = path.Join("_output", cc.dnsPrefix)
+	}
+
+	return nil
+}
+
+func (cc *cloneCmd) loadAPIModel() error {
+	var err error
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: cc.locale,
+		},
+	}
+	cc.containerService, cc.apiVersion, err = apiloader.LoadContainerServiceFromFile(cc.apimodelPath, false, false, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+
+	if cc.containerService.Properties.MasterProfile == nil {
+		return errors.New("cloning a cluster requires a masterProfile in the api model")
+	}
+
+	if cc.containerService.Properties.MasterProfile.DNSPrefix == cc.dnsPrefix {
+		return errors.Errorf("--dns-prefix must be different from the dns prefix of the existing cluster (%s)", cc.dnsPrefix)
+	}
+
+	return nil
+}
+
+func (cc *cloneCmd) run() error {
+	cloneContainerService(cc.containerService, cc.dnsPrefix, cc.location)
+	cloneNetworkRanges(cc.containerService, cc.clusterSubnet, cc.serviceCIDR)
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: cc.locale,
+		},
+	}
+	b, err := apiloader.SerializeContainerService(cc.containerService, cc.apiVersion)
+	if err != nil {
+		return errors.Wrap(err, "serializing the cloned api model")
+	}
+
+	apimodelPath := filepath.Join(cc.outputDirectory, "apimodel.json")
+	if _, err = os.Stat(apimodelPath); err == nil && !cc.forceOverwrite {
+		return errors.Errorf("%s already exists, use --force-overwrite to replace it", apimodelPath)
+	}
+
+	f := helpers.FileSaver{
+		Translator: &i18n.Translator{
+			Locale: cc.locale,
+		},
+	}
+	if err = f.SaveFile(cc.outputDirectory, "apimodel.json", b); err != nil {
+		return errors.Wrap(err, "saving the cloned api model")
+	}
+
+	// --set values apply to the cloned api model, not to the existing cluster's
+	if len(cc.set) > 0 {
+		m := make(map[string]transform.APIModelValue)
+		transform.MapValues(m, cc.set)
+		mergedPath, err := transform.MergeValuesWithAPIModel(apimodelPath, m)
+		if err != nil {
+			return errors.Wrap(err, "error merging --set values with the api model")
+		}
+		if b, err = ioutil.ReadFile(mergedPath); err != nil {
+			return errors.Wrap(err, "reading the merged api model")
+		}
+		if err = f.SaveFile(cc.outputDirectory, "apimodel.json", b); err != nil {
+			return errors.Wrap(err, "saving the merged api model")
+		}
+	}
+
+	log.Infoln(fmt.Sprintf("cloned api model has been written to %s, deploy it with 'aks-engine deploy --api-model %s'", apimodelPath, apimodelPath))
+	return nil
+}
+
+// cloneContainerService resets everything in cs that identifies the existing cluster so that, once
+// defaults are applied again by generate or deploy, it describes a new cluster with the same shape
+func cloneContainerService(cs *api.ContainerService, dnsPrefix, location string) {
+	if location != "" {
+		cs.Location = location
+	}
+
+	p := cs.Properties
+	p.ClusterID = ""
+	// generate and deploy create new certificates when the profile is empty
+	p.CertificateProfile = &api.CertificateProfile{}
+	if p.OrchestratorProfile != nil && p.OrchestratorProfile.KubernetesConfig != nil {
+		p.OrchestratorProfile.KubernetesConfig.EtcdEncryptionKey = ""
+	}
+
+	p.MasterProfile.DNSPrefix = dnsPrefix
+	p.MasterProfile.FQDN = ""
+	if p.MasterProfile.IsCustomVNET() {
+		log.Warnf("the existing cluster is deployed into custom VNET subnet %s, the clone will create its own VNET instead. "+
+			"Use --set to point it to a different custom VNET", p.MasterProfile.VnetSubnetID)
+		p.MasterProfile.VnetSubnetID = ""
+		p.MasterProfile.AgentVnetSubnetID = ""
+		p.MasterProfile.Subnet = ""
+		p.MasterProfile.AgentSubnet = ""
+	}
+	p.MasterProfile.FirstConsecutiveStaticIP = ""
+	p.MasterProfile.VnetCidr = ""
+
+	for _, pool := range p.AgentPoolProfiles {
+		pool.FQDN = ""
+		if pool.DNSPrefix != "" {
+			pool.DNSPrefix = fmt.Sprintf("%s-%s", dnsPrefix, pool.Name)
+		}
+		if pool.IsCustomVNET() {
+			pool.VnetSubnetID = ""
+			pool.Subnet = ""
+			pool.VnetCidrs = nil
+		}
+	}
+}
+
+// cloneNetworkRanges replaces the cluster and service IP ranges of cs, if given, and empties the settings derived from
+// them so they are defaulted again
+func cloneNetworkRanges(cs *api.ContainerService, clusterSubnet, serviceCIDR string) {
+	p := cs.Properties
+	if p.OrchestratorProfile == nil || p.OrchestratorProfile.KubernetesConfig == nil {
+		return
+	}
+	k := p.OrchestratorProfile.KubernetesConfig
+
+	if clusterSubnet != "" {
+		kubeletConfigs := []map[string]string{k.KubeletConfig}
+		if p.MasterProfile.KubernetesConfig != nil {
+			kubeletConfigs = append(kubeletConfigs, p.MasterProfile.KubernetesConfig.KubeletConfig)
+		}
+		for _, pool := range p.AgentPoolProfiles {
+			if pool.KubernetesConfig != nil {
+				kubeletConfigs = append(kubeletConfigs, pool.KubernetesConfig.KubeletConfig)
+			}
+		}
+		for _, kubeletConfig := range kubeletConfigs {
+			if kubeletConfig["--non-masquerade-cidr"] == k.ClusterSubnet {
+				delete(kubeletConfig, "--non-masquerade-cidr")
+			}
+		}
+		k.ClusterSubnet = clusterSubnet
+		// with Azure CNI the masters and agents are in the cluster subnet
+		if !p.MasterProfile.IsCustomVNET() {
+			p.MasterProfile.Subnet = ""
+			p.MasterProfile.AgentSubnet = ""
+			for _, pool := range p.AgentPoolProfiles {
+				pool.Subnet = ""
+			}
+		}
+	}
+
+	if serviceCIDR != "" {
+		_, ipNet, _ := net.ParseCIDR(serviceCIDR)
+		dnsServiceIP := make(net.IP, net.IPv4len)
+		copy(dnsServiceIP, ipNet.IP.To4())
+		dnsServiceIP[3] += 10
+		k.ServiceCIDR = ipNet.String()
+		k.DNSServiceIP = dnsServiceIP.String()
+	}
+}
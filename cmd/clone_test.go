@@ -0,0 +1,181 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+func TestNewCloneCmd(t *testing.T) {
+	output := newCloneCmd()
+	if output.Use != cloneName || output.Short != cloneShortDescription || output.Long != cloneLongDescription {
+		t.Fatalf("clone command should have use %s equal %s, short %s equal %s and long %s equal to %s", output.Use, cloneName, output.Short, cloneShortDescription, output.Long, cloneLongDescription)
+	}
+
+	expectedFlags := []string{"api-model", "dns-prefix", "location", "output-directory", "force-overwrite", "set", "cluster-subnet", "service-cidr"}
+	for _, f := range expectedFlags {
+		if output.Flags().Lookup(f) == nil {
+			t.Fatalf("clone command should have flag %s", f)
+		}
+	}
+}
+
+func TestCloneCmdValidate(t *testing.T) {
+	cases := []struct {
+		name        string
+		cc          *cloneCmd
+		expectedErr string
+	}{
+		{
+			name:        "missing api model",
+			cc:          &cloneCmd{dnsPrefix: "clone"},
+			expectedErr: "--api-model must be specified",
+		},
+		{
+			name:        "api model does not exist",
+			cc:          &cloneCmd{apimodelPath: "./does-not-exist.json", dnsPrefix: "clone"},
+			expectedErr: "specified api model does not exist (./does-not-exist.json)",
+		},
+		{
+			name:        "missing dns prefix",
+			cc:          &cloneCmd{apimodelPath: "../pkg/engine/testdata/vnet/kubernetesvnet.json"},
+			expectedErr: "--dns-prefix must be specified",
+		},
+		{
+			name:        "invalid cluster subnet",
+			cc:          &cloneCmd{apimodelPath: "../pkg/engine/testdata/vnet/kubernetesvnet.json", dnsPrefix: "clone", clusterSubnet: "10.244.0.0"},
+			expectedErr: "--cluster-subnet '10.244.0.0' is an invalid CIDR",
+		},
+		{
+			name:        "ipv6 service cidr",
+			cc:          &cloneCmd{apimodelPath: "../pkg/engine/testdata/vnet/kubernetesvnet.json", dnsPrefix: "clone", serviceCIDR: "fd00::/108"},
+			expectedErr: "--service-cidr 'fd00::/108' is not a valid IPv4 CIDR",
+		},
+		{
+			name:        "service cidr too small",
+			cc:          &cloneCmd{apimodelPath: "../pkg/engine/testdata/vnet/kubernetesvnet.json", dnsPrefix: "clone", serviceCIDR: "10.1.0.0/29"},
+			expectedErr: "--service-cidr '10.1.0.0/29' must be a /28 or larger range",
+		},
+		{
+			name: "valid",
+			cc:   &cloneCmd{apimodelPath: "../pkg/engine/testdata/vnet/kubernetesvnet.json", dnsPrefix: "clone", location: "West US 2"},
+		},
+		{
+			name: "valid with new ip ranges",
+			cc:   &cloneCmd{apimodelPath: "../pkg/engine/testdata/vnet/kubernetesvnet.json", dnsPrefix: "clone", location: "West US 2", clusterSubnet: "10.100.0.0/16", serviceCIDR: "10.1.0.0/16"},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			err := c.cc.validate(&cobra.Command{})
+			if c.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, but got %s", err)
+				}
+				if c.cc.outputDirectory != filepath.Join("_output", "clone") {
+					t.Fatalf("expected output directory to be derived from the dns prefix, got %s", c.cc.outputDirectory)
+				}
+				if c.cc.location != "westus2" {
+					t.Fatalf("expected location to be normalized, got %s", c.cc.location)
+				}
+			} else if err == nil || err.Error() != c.expectedErr {
+				t.Fatalf("expected error %s, but got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestCloneCmdRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	outputDirectory, err := ioutil.TempDir("", "clone")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(outputDirectory)
+
+	cc := &cloneCmd{
+		apimodelPath:    "../pkg/engine/testdata/vnet/kubernetesvnet.json",
+		dnsPrefix:       "clone",
+		location:        "westeurope",
+		outputDirectory: outputDirectory,
+		set:             []string{"masterProfile.count=3"},
+	}
+	g.Expect(cc.validate(&cobra.Command{})).To(Succeed())
+	g.Expect(cc.loadAPIModel()).To(Succeed())
+	g.Expect(cc.run()).To(Succeed())
+
+	// the output directory now holds an apimodel.json, which is not overwritten by default
+	g.Expect(cc.run()).NotTo(Succeed())
+	cc.forceOverwrite = true
+	g.Expect(cc.run()).To(Succeed())
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: cc.locale,
+		},
+	}
+	cs, _, err := apiloader.LoadContainerServiceFromFile(filepath.Join(outputDirectory, "apimodel.json"), false, false, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	p := cs.Properties
+	g.Expect(cs.Location).To(Equal("westeurope"))
+	g.Expect(p.MasterProfile.DNSPrefix).To(Equal("clone"))
+	g.Expect(p.MasterProfile.Count).To(Equal(3))
+	g.Expect(p.MasterProfile.VnetSubnetID).To(BeEmpty())
+	g.Expect(p.MasterProfile.FirstConsecutiveStaticIP).To(BeEmpty())
+	g.Expect(p.CertificateProfile.CaCertificate).To(BeEmpty())
+	g.Expect(p.CertificateProfile.APIServerPrivateKey).To(BeEmpty())
+	for _, pool := range p.AgentPoolProfiles {
+		g.Expect(pool.VnetSubnetID).To(BeEmpty())
+	}
+	g.Expect(p.OrchestratorProfile.KubernetesConfig.ClusterSubnet).To(Equal("172.40.0.0/16"))
+	g.Expect(p.ServicePrincipalProfile.ClientID).To(Equal("ServicePrincipalClientID"))
+}
+
+func TestCloneNetworkRanges(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cs := api.CreateMockContainerService("testcluster", "1.13.0", 3, 2, false)
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+	k.KubeletConfig = map[string]string{"--non-masquerade-cidr": k.ClusterSubnet, "--max-pods": "30"}
+	cs.Properties.MasterProfile.Subnet = k.ClusterSubnet
+	cs.Properties.AgentPoolProfiles[0].Subnet = k.ClusterSubnet
+
+	// the ranges of the existing cluster are copied by default
+	cloneNetworkRanges(cs, "", "")
+	g.Expect(k.ClusterSubnet).To(Equal(api.DefaultKubernetesSubnet))
+	g.Expect(k.ServiceCIDR).To(Equal(api.DefaultKubernetesServiceCIDR))
+	g.Expect(k.DNSServiceIP).To(Equal(api.DefaultKubernetesDNSServiceIP))
+	g.Expect(k.KubeletConfig).To(HaveKey("--non-masquerade-cidr"))
+
+	cloneNetworkRanges(cs, "10.100.0.0/16", "10.1.0.0/16")
+	g.Expect(k.ClusterSubnet).To(Equal("10.100.0.0/16"))
+	g.Expect(k.ServiceCIDR).To(Equal("10.1.0.0/16"))
+	g.Expect(k.DNSServiceIP).To(Equal("10.1.0.10"))
+	g.Expect(k.KubeletConfig).NotTo(HaveKey("--non-masquerade-cidr"))
+	g.Expect(k.KubeletConfig).To(HaveKeyWithValue("--max-pods", "30"))
+	g.Expect(cs.Properties.MasterProfile.Subnet).To(BeEmpty())
+	g.Expect(cs.Properties.AgentPoolProfiles[0].Subnet).To(BeEmpty())
+}
+
+func TestCloneCmdLoadAPIModelRequiresNewDNSPrefix(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cc := &cloneCmd{
+		apimodelPath: "../pkg/engine/testdata/vnet/kubernetesvnet.json",
+		dnsPrefix:    "masterdns1",
+	}
+	g.Expect(cc.validate(&cobra.Command{})).To(Succeed())
+	g.Expect(cc.loadAPIModel()).NotTo(Succeed())
+}
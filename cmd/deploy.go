@@ -7,6 +7,7 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/rand"
 	"os"
@@ -50,6 +51,15 @@ type deployCmd struct {
 	caPrivateKeyPath  string
 	parametersOnly    bool
 	set               []string
+	dryRun            bool
+	// skuPreferences are the VM sizes the preflight suggests a profile falls back to, in order of preference
+	skuPreferences   []string
+	allowSKUFallback bool
+	// checkCustomVNET checks the subnets of a custom VNET against their state in Azure before deploying
+	checkCustomVNET bool
+	// validate runs the smoke checks of the cluster once the deployment succeeds
+	validate        bool
+	validateTimeout time.Duration
 
 	// derived
 	containerService *api.ContainerService
@@ -60,6 +70,9 @@ type deployCmd struct {
 	resourceGroup string
 	random        *rand.Rand
 	location      string
+
+	// createdServicePrincipal is set when the service principal was created by this deployment
+	createdServicePrincipal bool
 }
 
 func newDeployCmd() *cobra.Command {
@@ -103,6 +116,12 @@ func newDeployCmd() *cobra.Command {
 	f.StringVarP(&dc.location, "location", "l", "", "location to deploy to (required)")
 	f.BoolVarP(&dc.forceOverwrite, "force-overwrite", "f", false, "automatically overwrite existing files in the output directory")
 	f.StringArrayVar(&dc.set, "set", []string{}, "set values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
+	f.BoolVar(&dc.dryRun, "dry-run", false, "generate the template and print the changes deploying it would make to the existing resource group, without deploying it")
+	f.StringSliceVar(&dc.skuPreferences, "sku-preferences", nil, "VM sizes, in order of preference, to check the VM sizes of the cluster against before deploying: the preflight fails on a VM size the location or the vCPU quota of the subscription does not allow, suggesting the first equivalent VM size of the list that is allowed")
+	f.BoolVar(&dc.allowSKUFallback, "allow-sku-fallback", false, "deploy the profiles whose VM size the preflight of --sku-preferences finds not allowed with the VM size it suggests")
+	f.BoolVar(&dc.checkCustomVNET, "check-custom-vnet", false, "check the subnets of the custom VNET of the cluster before deploying: that they exist, have enough free IP addresses for the nodes and, with Azure CNI, their pods, and that their route table and network security group are compatible with the cluster")
+	f.BoolVar(&dc.validate, "validate", false, "once the deployment succeeds, check that the nodes and the core addon pods are ready, and that pods resolve DNS names and reach the internet, with kubectl, failing if the cluster is unhealthy")
+	f.DurationVar(&dc.validateTimeout, "validate-timeout", defaultSmokeChecksTimeout, "how long to wait for each check of --validate to pass")
 
 	addAuthFlags(dc.getAuthArgs(), f)
 
@@ -137,6 +156,17 @@ func (dc *deployCmd) validateArgs(cmd *cobra.Command, args []string) error {
 	}
 	dc.location = helpers.NormalizeAzureRegion(dc.location)
 
+	if dc.allowSKUFallback && len(dc.skuPreferences) == 0 {
+		return errors.New("--allow-sku-fallback requires --sku-preferences")
+	}
+
+	if dc.validate && dc.dryRun {
+		return errors.New("--validate cannot be used with --dry-run")
+	}
+	if dc.validate && dc.validateTimeout <= 0 {
+		return errors.New("--validate-timeout must be greater than 0")
+	}
+
 	return nil
 }
 
@@ -309,9 +339,12 @@ func autofillApimodel(dc *deployCmd) error {
 
 	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
 	defer cancel()
-	_, err := dc.client.EnsureResourceGroup(ctx, dc.resourceGroup, dc.location, nil)
-	if err != nil {
-		return err
+	var err error
+	// a dry run does not change anything, the What-If of the deployment needs the resource group to exist already
+	if !dc.dryRun {
+		if _, err = dc.client.EnsureResourceGroup(ctx, dc.resourceGroup, dc.location, nil); err != nil {
+			return err
+		}
 	}
 
 	k8sConfig := dc.containerService.Properties.OrchestratorProfile.KubernetesConfig
@@ -321,6 +354,9 @@ func autofillApimodel(dc *deployCmd) error {
 	if !useManagedIdentity {
 		spp := dc.containerService.Properties.ServicePrincipalProfile
 		if spp != nil && spp.ClientID == "" && spp.Secret == "" && spp.KeyvaultSecretRef == nil && (dc.getAuthArgs().ClientID.String() == "" || dc.getAuthArgs().ClientID.String() == "00000000-0000-0000-0000-000000000000") && dc.getAuthArgs().ClientSecret == "" {
+			if dc.dryRun {
+				return errors.New("apimodel: ServicePrincipalProfile was missing or empty, --dry-run does not create an application, specify a service principal in the apimodel or with --client-id and --client-secret")
+			}
 			log.Warnln("apimodel: ServicePrincipalProfile was missing or empty, creating application...")
 
 			// TODO: consider caching the creds here so they persist between subsequent runs of 'deploy'
@@ -348,6 +384,7 @@ func autofillApimodel(dc *deployCmd) error {
 				Secret:   secret,
 				ObjectID: servicePrincipalObjectID,
 			}
+			dc.createdServicePrincipal = true
 		} else if (dc.containerService.Properties.ServicePrincipalProfile == nil || ((dc.containerService.Properties.ServicePrincipalProfile.ClientID == "" || dc.containerService.Properties.ServicePrincipalProfile.ClientID == "00000000-0000-0000-0000-000000000000") && dc.containerService.Properties.ServicePrincipalProfile.Secret == "")) && dc.getAuthArgs().ClientID.String() != "" && dc.getAuthArgs().ClientSecret != "" {
 			dc.containerService.Properties.ServicePrincipalProfile = &api.ServicePrincipalProfile{
 				ClientID: dc.getAuthArgs().ClientID.String(),
@@ -384,11 +421,38 @@ func (dc *deployCmd) run() error {
 		return errors.Wrap(err, "initializing template generator")
 	}
 
+	if len(dc.skuPreferences) > 0 {
+		if err = dc.runSKUPreflight(); err != nil {
+			return errors.Wrap(err, "checking the VM sizes of the cluster")
+		}
+	}
+
 	certsgenerated, err := dc.containerService.SetPropertiesDefaults(false, false)
 	if err != nil {
 		return errors.Wrapf(err, "in SetPropertiesDefaults template %s", dc.apimodelPath)
 	}
 
+	if err = dc.validateCustomVNETCIDROverlaps(); err != nil {
+		return errors.Wrap(err, "validating custom VNET address space")
+	}
+
+	if dc.checkCustomVNET {
+		if err = dc.runCustomVNETPreflight(); err != nil {
+			return errors.Wrap(err, "checking the custom VNET of the cluster")
+		}
+	}
+
+	// a service principal created by this deployment may not have replicated yet, and is known to be valid
+	if !dc.createdServicePrincipal {
+		if err = validateServicePrincipal(dc.client, dc.containerService, dc.getAuthArgs().SubscriptionID.String(), dc.resourceGroup); err != nil {
+			return errors.Wrap(err, "validating service principal")
+		}
+	}
+
+	if err = engine.ResolveCustomManifests(dc.containerService); err != nil {
+		return errors.Wrap(err, "resolving custom manifests")
+	}
+
 	template, parameters, err := templateGenerator.GenerateTemplateV2(dc.containerService, engine.DefaultGeneratorCode, BuildTag)
 	if err != nil {
 		return errors.Wrapf(err, "generating template %s", dc.apimodelPath)
@@ -426,6 +490,20 @@ func (dc *deployCmd) run() error {
 	cx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
 	defer cancel()
 
+	if dc.dryRun {
+		deploymentName := fmt.Sprintf("%s-%d", dc.resourceGroup, deploymentSuffix)
+		result, err := dc.client.WhatIfDeployment(cx, dc.resourceGroup, deploymentName, templateJSON, parametersJSON)
+		if err != nil {
+			return errors.Wrapf(err, "getting the changes of deployment %s in resource group %s", deploymentName, dc.resourceGroup)
+		}
+		var changes []armhelpers.WhatIfChange
+		if result.Properties != nil {
+			changes = result.Properties.Changes
+		}
+		printWhatIfChanges(os.Stdout, changes)
+		return nil
+	}
+
 	if res, err := dc.client.DeployTemplate(
 		cx,
 		dc.resourceGroup,
@@ -441,9 +519,80 @@ func (dc *deployCmd) run() error {
 		return err
 	}
 
+	if dc.validate {
+		if err = dc.runSmokeChecks(); err != nil {
+			return errors.Wrap(err, "validating the deployed cluster")
+		}
+	}
+
 	return nil
 }
 
+// runSKUPreflight checks the VM sizes of the cluster against the resource SKUs and the compute quotas of the
+// subscription in the cluster location. It fails on the problems found, unless --allow-sku-fallback is set and every
+// profile with a problem can fall back to a VM size of --sku-preferences.
+func (dc *deployCmd) runSKUPreflight() error {
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	skus, err := dc.client.ListResourceSkus(ctx)
+	if err != nil {
+		return errors.Wrap(err, "listing the resource SKUs of the subscription")
+	}
+	usages, err := dc.client.ListComputeUsages(ctx, dc.location)
+	if err != nil {
+		return errors.Wrapf(err, "listing the compute usages of the subscription in location %s", dc.location)
+	}
+
+	problems, err := newSKUPreflight(skus, usages, dc.location).check(dc.containerService, dc.skuPreferences)
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	for _, problem := range problems {
+		log.Warnf("Preflight: %s", problem)
+	}
+	if !dc.allowSKUFallback {
+		return errors.Errorf("the preflight found %d problems, set --allow-sku-fallback to deploy with the VM sizes it suggests", len(problems))
+	}
+	if err = applySKUFallbacks(dc.containerService, problems); err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		log.Infof("Deploying %s with VM size %s instead of %s", problem.Profile, problem.Fallback, problem.VMSize)
+	}
+	return nil
+}
+
+// runCustomVNETPreflight checks the subnets of the custom VNET of the cluster against their state in Azure, failing on
+// the problems found
+func (dc *deployCmd) runCustomVNETPreflight() error {
+	p := dc.containerService.Properties
+	if p.MasterProfile == nil || !p.MasterProfile.IsCustomVNET() {
+		log.Warnf("--check-custom-vnet has no effect, the cluster does not use a custom VNET")
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	problems, err := checkCustomVNET(ctx, dc.client, dc.containerService, dc.resourceGroup)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("the custom VNET is not ready for the cluster: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validateCustomVNETCIDROverlaps ensures the address space of a pre-existing custom VNET
+// does not overlap the cluster, service or docker bridge networks
+func (dc *deployCmd) validateCustomVNETCIDROverlaps() error {
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	return checkCustomVNETCIDROverlaps(ctx, dc.client, dc.containerService)
+}
+
 // configure api model addon config with container monitoring addon
 func (dc *deployCmd) configureContainerMonitoringAddon(ctx context.Context, k8sConfig *api.KubernetesConfig) error {
 	log.Infoln("configuring container monitoring addon info")
@@ -454,6 +603,9 @@ func (dc *deployCmd) configureContainerMonitoringAddon(ctx context.Context, k8sC
 	var err error
 	addon := k8sConfig.GetAddonByName("container-monitoring")
 	if addon.Config == nil || len(addon.Config) == 0 || addon.Config["logAnalyticsWorkspaceResourceId"] != "" {
+		if dc.dryRun {
+			return errors.New("--dry-run does not configure a log analytics workspace, specify the workspaceGuid and workspaceKey of the container monitoring addon")
+		}
 		workspaceResourceID = strings.TrimSpace(addon.Config["logAnalyticsWorkspaceResourceId"])
 		if workspaceResourceID != "" {
 			log.Infoln("using provided log analytics workspace resource id:", workspaceResourceID)
@@ -509,3 +661,74 @@ func (dc *deployCmd) configureContainerMonitoringAddon(ctx context.Context, k8sC
 	}
 	return nil
 }
+
+// whatIfChangeSymbols are the symbols of the changes to resources and properties, in the order they are summarized
+var whatIfChangeSymbols = []struct {
+	changeType armhelpers.WhatIfChangeType
+	symbol     string
+	summary    string
+}{
+	{armhelpers.WhatIfChangeTypeCreate, "+", "to create"},
+	{armhelpers.WhatIfChangeTypeModify, "~", "to modify"},
+	{armhelpers.WhatIfChangeTypeDelete, "-", "to delete"},
+	{armhelpers.WhatIfChangeTypeDeploy, "!", "to deploy"},
+	{armhelpers.WhatIfChangeTypeNoChange, "=", "no change"},
+	{armhelpers.WhatIfChangeTypeIgnore, "*", "to ignore"},
+}
+
+// printWhatIfChanges prints the changes a deployment would make to the resources of the resource group and their properties
+func printWhatIfChanges(w io.Writer, changes []armhelpers.WhatIfChange) {
+	symbols := map[armhelpers.WhatIfChangeType]string{}
+	fmt.Fprintln(w, "Resource and property changes are indicated with these symbols:")
+	for _, s := range whatIfChangeSymbols {
+		symbols[s.changeType] = s.symbol
+		fmt.Fprintf(w, "  %s %s\n", s.symbol, s.changeType)
+	}
+	fmt.Fprintln(w)
+
+	counts := map[armhelpers.WhatIfChangeType]int{}
+	for _, change := range changes {
+		counts[change.ChangeType]++
+		fmt.Fprintf(w, "  %s %s\n", symbols[change.ChangeType], change.ResourceID)
+		printWhatIfPropertyChanges(w, change.Delta, "      ")
+	}
+	if len(changes) > 0 {
+		fmt.Fprintln(w)
+	}
+
+	var summary []string
+	for _, s := range whatIfChangeSymbols {
+		if counts[s.changeType] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[s.changeType], s.summary))
+		}
+	}
+	if len(summary) == 0 {
+		summary = append(summary, "none")
+	}
+	fmt.Fprintf(w, "Resource changes: %s.\n", strings.Join(summary, ", "))
+}
+
+func printWhatIfPropertyChanges(w io.Writer, changes []armhelpers.WhatIfPropertyChange, indent string) {
+	for _, change := range changes {
+		switch change.PropertyChangeType {
+		case armhelpers.WhatIfPropertyChangeTypeCreate:
+			fmt.Fprintf(w, "%s+ %s: %s\n", indent, change.Path, whatIfValue(change.After))
+		case armhelpers.WhatIfPropertyChangeTypeDelete:
+			fmt.Fprintf(w, "%s- %s: %s\n", indent, change.Path, whatIfValue(change.Before))
+		case armhelpers.WhatIfPropertyChangeTypeArray:
+			fmt.Fprintf(w, "%s~ %s: [\n", indent, change.Path)
+			printWhatIfPropertyChanges(w, change.Children, indent+"  ")
+			fmt.Fprintf(w, "%s  ]\n", indent)
+		default:
+			fmt.Fprintf(w, "%s~ %s: %s => %s\n", indent, change.Path, whatIfValue(change.Before), whatIfValue(change.After))
+		}
+	}
+}
+
+func whatIfValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
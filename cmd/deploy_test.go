@@ -135,7 +135,7 @@ func TestNewDeployCmd(t *testing.T) {
 		t.Fatalf("deploy command should have use %s equal %s, short %s equal %s and long %s equal to %s", command.Use, deployName, command.Short, deployShortDescription, command.Long, versionLongDescription)
 	}
 
-	expectedFlags := []string{"api-model", "dns-prefix", "auto-suffix", "output-directory", "ca-private-key-path", "resource-group", "location", "force-overwrite"}
+	expectedFlags := []string{"api-model", "dns-prefix", "auto-suffix", "output-directory", "ca-private-key-path", "resource-group", "location", "force-overwrite", "validate", "validate-timeout"}
 	for _, f := range expectedFlags {
 		if command.Flags().Lookup(f) == nil {
 			t.Fatalf("deploy command should have flag %s", f)
@@ -233,6 +233,38 @@ func TestValidate(t *testing.T) {
 			args:        []string{},
 			expectedErr: nil,
 		},
+		{
+			dc: &deployCmd{
+				apimodelPath:      apimodelPath,
+				dnsPrefix:         "test",
+				outputDirectory:   "output/test",
+				caCertificatePath: "test",
+				caPrivateKeyPath:  "test",
+				location:          "canadaeast",
+				allowSKUFallback:  true,
+			},
+			args:        []string{},
+			expectedErr: errors.New("--allow-sku-fallback requires --sku-preferences"),
+		},
+		{
+			dc: &deployCmd{
+				apimodelPath: apimodelPath,
+				location:     "canadaeast",
+				validate:     true,
+				dryRun:       true,
+			},
+			args:        []string{},
+			expectedErr: errors.New("--validate cannot be used with --dry-run"),
+		},
+		{
+			dc: &deployCmd{
+				apimodelPath: apimodelPath,
+				location:     "canadaeast",
+				validate:     true,
+			},
+			args:        []string{},
+			expectedErr: errors.New("--validate-timeout must be greater than 0"),
+		},
 	}
 
 	for _, c := range cases {
@@ -624,6 +656,151 @@ func TestDeployCmdRun(t *testing.T) {
 	}
 }
 
+func TestDeployCmdDryRun(t *testing.T) {
+	for _, failWhatIf := range []bool{false, true} {
+		// a dry run neither creates the resource group nor deploys the template
+		client := &armhelpers.MockAKSEngineClient{
+			FailEnsureResourceGroup: true,
+			FailDeployTemplate:      true,
+			FailWhatIfDeployment:    failWhatIf,
+		}
+		d := &deployCmd{
+			authProvider: &mockAuthProvider{
+				authArgs:      &authArgs{},
+				getClientMock: client,
+			},
+			apimodelPath:    "../pkg/engine/testdata/simple/kubernetes.json",
+			outputDirectory: "_test_output",
+			forceOverwrite:  true,
+			location:        "westus",
+			dryRun:          true,
+		}
+
+		r := &cobra.Command{}
+		addAuthFlags(d.getAuthArgs(), r.Flags())
+
+		fakeRawSubscriptionID := "6dc93fae-9a76-421f-bbe5-cc6460ea81cb"
+		fakeSubscriptionID, err := uuid.FromString(fakeRawSubscriptionID)
+		if err != nil {
+			t.Fatalf("Invalid SubscriptionId in Test: %s", err)
+		}
+		d.getAuthArgs().SubscriptionID = fakeSubscriptionID
+		d.getAuthArgs().rawSubscriptionID = fakeRawSubscriptionID
+		d.getAuthArgs().rawClientID = "b829b379-ca1f-4f1d-91a2-0d26b244680d"
+		d.getAuthArgs().ClientSecret = "0se43bie-3zs5-303e-aav5-dcf231vb82ds"
+
+		if err = d.loadAPIModel(); err != nil {
+			t.Fatalf("Failed to call LoadAPIModel: %s", err)
+		}
+		err = d.run()
+		os.RemoveAll(d.outputDirectory)
+		if failWhatIf {
+			if err == nil || !strings.Contains(err.Error(), "WhatIfDeployment failed") {
+				t.Errorf("expected the What-If error, got %v", err)
+			}
+		} else if err != nil {
+			t.Errorf("unexpected error in a dry run: %s", err)
+		}
+	}
+}
+
+func TestAutofillApimodelDryRunDoesNotCreateCreds(t *testing.T) {
+	apiloader := &api.Apiloader{
+		Translator: nil,
+	}
+
+	apimodel := getExampleAPIModel(false, "", "")
+	cs, ver, err := apiloader.DeserializeContainerService([]byte(apimodel), false, false, nil)
+	if err != nil {
+		t.Fatalf("unexpected error deserializing the example apimodel: %s", err)
+	}
+	deployCmd := &deployCmd{
+		apimodelPath:     "./this/is/unused.json",
+		dnsPrefix:        "dnsPrefix1",
+		outputDirectory:  "_test_output",
+		forceOverwrite:   true,
+		location:         "westus",
+		dryRun:           true,
+		containerService: cs,
+		apiVersion:       ver,
+
+		client: &armhelpers.MockAKSEngineClient{},
+		authProvider: &mockAuthProvider{
+			authArgs: &authArgs{},
+		},
+	}
+	defer os.RemoveAll(deployCmd.outputDirectory)
+
+	err = autofillApimodel(deployCmd)
+	if err == nil || !strings.Contains(err.Error(), "--dry-run does not create an application") {
+		t.Fatalf("expected an error as a dry run does not create a service principal, got %v", err)
+	}
+}
+
+func TestPrintWhatIfChanges(t *testing.T) {
+	changes := []armhelpers.WhatIfChange{
+		{
+			ResourceID: "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/k8s-vnet",
+			ChangeType: armhelpers.WhatIfChangeTypeModify,
+			Delta: []armhelpers.WhatIfPropertyChange{
+				{
+					Path:               "properties.addressSpace.addressPrefixes",
+					PropertyChangeType: armhelpers.WhatIfPropertyChangeTypeArray,
+					Children: []armhelpers.WhatIfPropertyChange{
+						{Path: "0", PropertyChangeType: armhelpers.WhatIfPropertyChangeTypeModify, Before: "10.0.0.0/8", After: "10.239.0.0/16"},
+					},
+				},
+				{Path: "tags.owner", PropertyChangeType: armhelpers.WhatIfPropertyChangeTypeCreate, After: "me"},
+				{Path: "tags.creationSource", PropertyChangeType: armhelpers.WhatIfPropertyChangeTypeDelete, Before: "aks-engine"},
+			},
+		},
+		{
+			ResourceID: "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/k8s-master-0",
+			ChangeType: armhelpers.WhatIfChangeTypeCreate,
+		},
+		{
+			ResourceID: "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/k8s-master-1",
+			ChangeType: armhelpers.WhatIfChangeTypeCreate,
+		},
+		{
+			ResourceID: "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/disks/orphan",
+			ChangeType: armhelpers.WhatIfChangeTypeIgnore,
+		},
+	}
+
+	var b strings.Builder
+	printWhatIfChanges(&b, changes)
+	expected := `Resource and property changes are indicated with these symbols:
+  + Create
+  ~ Modify
+  - Delete
+  ! Deploy
+  = NoChange
+  * Ignore
+
+  ~ /subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/k8s-vnet
+      ~ properties.addressSpace.addressPrefixes: [
+        ~ 0: "10.0.0.0/8" => "10.239.0.0/16"
+        ]
+      + tags.owner: "me"
+      - tags.creationSource: "aks-engine"
+  + /subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/k8s-master-0
+  + /subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/k8s-master-1
+  * /subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/disks/orphan
+
+Resource changes: 2 to create, 1 to modify, 1 to ignore.
+`
+	if b.String() != expected {
+		t.Errorf("unexpected What-If output:\n%s\nexpected:\n%s", b.String(), expected)
+	}
+
+	b.Reset()
+	printWhatIfChanges(&b, nil)
+	if !strings.HasSuffix(b.String(), "* Ignore\n\nResource changes: none.\n") {
+		t.Errorf("unexpected What-If output without changes:\n%s", b.String())
+	}
+}
+
 func TestOutputDirectoryWithDNSPrefix(t *testing.T) {
 	apiloader := &api.Apiloader{
 		Translator: nil,
@@ -903,3 +1080,58 @@ func TestAPIModelWithContainerMonitoringAddonWithWorkspaceGuidAndKeyConfigInCmd(
 		t.Fatalf("expected workspaceKey : %s but got : %s", expectedWorkspaceKeyInBase64, addon.Config["workspaceKey"])
 	}
 }
+
+func TestValidateCustomVNETCIDROverlaps(t *testing.T) {
+	vnetSubnetID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME"
+	cases := []struct {
+		name          string
+		vnetPrefixes  []string
+		networkPlugin string
+		expectedErr   string
+	}{
+		{
+			name:          "no overlaps",
+			vnetPrefixes:  []string{"10.239.0.0/16"},
+			networkPlugin: api.NetworkPluginKubenet,
+		},
+		{
+			name:          "service cidr overlaps vnet",
+			vnetPrefixes:  []string{"10.0.0.0/8"},
+			networkPlugin: api.NetworkPluginAzure,
+			expectedErr:   "networking CIDR ranges must not overlap: VNET VNET_NAME address prefix '10.0.0.0/8' overlaps with ServiceCidr '10.0.0.0/16'",
+		},
+		{
+			name:          "cluster subnet overlaps vnet with kubenet",
+			vnetPrefixes:  []string{"10.239.0.0/16", "10.244.0.0/24"},
+			networkPlugin: api.NetworkPluginKubenet,
+			expectedErr:   "networking CIDR ranges must not overlap: VNET VNET_NAME address prefix '10.244.0.0/24' overlaps with ClusterSubnet '10.244.0.0/16'",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			cs := api.CreateMockContainerService("testcluster", "1.13.0", 3, 2, false)
+			cs.Properties.MasterProfile.VnetSubnetID = vnetSubnetID
+			cs.Properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin = c.networkPlugin
+			cs.Properties.OrchestratorProfile.KubernetesConfig.ServiceCIDR = "10.0.0.0/16"
+			cs.Properties.OrchestratorProfile.KubernetesConfig.ClusterSubnet = "10.244.0.0/16"
+			cs.Properties.OrchestratorProfile.KubernetesConfig.DockerBridgeSubnet = "172.17.0.1/16"
+			dc := &deployCmd{
+				containerService: cs,
+				client: &armhelpers.MockAKSEngineClient{
+					FakeVirtualNetworkAddressPrefixes: c.vnetPrefixes,
+				},
+			}
+			err := dc.validateCustomVNETCIDROverlaps()
+			if c.expectedErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, but got %s", err)
+				}
+			} else if err == nil || err.Error() != c.expectedErr {
+				t.Errorf("expected error %s, but got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
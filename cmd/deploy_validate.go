@@ -0,0 +1,237 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/common"
+	"github.com/Azure/aks-engine/pkg/engine"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/dns"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/node"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/pod"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultSmokeChecksTimeout = 20 * time.Minute
+	smokeChecksPollInterval   = 10 * time.Second
+	// smokeCheckImage runs the DNS and outbound connectivity checks, its nslookup and wget are used by the e2e helpers
+	smokeCheckImage = "busybox"
+)
+
+// smokeCheck is one of the checks run by deploy --validate
+type smokeCheck struct {
+	name string
+	run  func() error
+}
+
+// runSmokeChecks runs the smoke checks of the deployed cluster with the kubectl helpers of the e2e framework, which
+// call kubectl as `k` with the KUBECONFIG of their environment
+func (dc *deployCmd) runSmokeChecks() error {
+	dir, err := ioutil.TempDir("", "aks-engine-validate")
+	if err != nil {
+		return errors.Wrap(err, "creating a temporary directory")
+	}
+	defer os.RemoveAll(dir)
+
+	kubeconfig, err := engine.GenerateKubeConfig(dc.containerService.Properties, dc.location)
+	if err != nil {
+		return errors.Wrap(err, "generating the kubeconfig of the cluster")
+	}
+	kubeconfigPath := filepath.Join(dir, "kubeconfig.json")
+	if err = ioutil.WriteFile(kubeconfigPath, []byte(kubeconfig), 0600); err != nil {
+		return errors.Wrap(err, "writing the kubeconfig of the cluster")
+	}
+	if err = installKubectlShim(dir); err != nil {
+		return err
+	}
+
+	restore := setEnv(map[string]string{
+		"KUBECONFIG": kubeconfigPath,
+		"PATH":       dir + string(os.PathListSeparator) + os.Getenv("PATH"),
+	})
+	defer restore()
+
+	checks, cleanup := dc.smokeChecks(dc.validateTimeout)
+	defer cleanup()
+	return runChecks(checks)
+}
+
+// smokeChecks returns the checks of the cluster: all the nodes are Ready, the core addon pods are Ready, and a Linux
+// pod resolves cluster and external DNS names and reaches the internet. cleanup deletes the pod the checks create.
+func (dc *deployCmd) smokeChecks(timeout time.Duration) (checks []smokeCheck, cleanup func()) {
+	properties := dc.containerService.Properties
+	cleanup = func() {}
+	checks = []smokeCheck{
+		{
+			name: "nodes are Ready",
+			run: func() error {
+				if !node.WaitOnReady(properties.TotalNodes(), smokeChecksPollInterval, timeout) {
+					return errors.Errorf("%d nodes were not Ready after %s", properties.TotalNodes(), timeout)
+				}
+				return nil
+			},
+		},
+	}
+	for _, name := range coreAddonPods(properties) {
+		name := name
+		checks = append(checks, smokeCheck{
+			name: fmt.Sprintf("%s pods are Ready", name),
+			run: func() error {
+				ready, err := pod.WaitOnReady(name, "kube-system", 1, smokeChecksPollInterval, timeout)
+				if err == nil && !ready {
+					err = errors.Errorf("%s pods were not Ready after %s", name, timeout)
+				}
+				return err
+			},
+		})
+	}
+	if !properties.AnyAgentIsLinux() {
+		log.Warnf("The cluster has no Linux node pool, skipping the DNS and outbound connectivity checks")
+		return checks, cleanup
+	}
+
+	var p *pod.Pod
+	podName := fmt.Sprintf("aks-engine-validate-%d", dc.random.Int31())
+	cleanup = func() {
+		if p == nil {
+			return
+		}
+		if err := p.Delete(util.DefaultDeleteRetries); err != nil {
+			log.Warnf("Failed to delete pod %s: %s", podName, err)
+		}
+	}
+	checks = append(checks,
+		smokeCheck{
+			name: "a Linux pod is Ready",
+			run: func() error {
+				var err error
+				if p, err = pod.RunLinuxPod(smokeCheckImage, podName, "default", "sleep 3600", false, smokeChecksPollInterval, timeout, timeout); err != nil {
+					return err
+				}
+				ready, err := p.WaitOnReady(smokeChecksPollInterval, timeout)
+				if err == nil && !ready {
+					err = errors.Errorf("pod %s was not Ready after %s", podName, timeout)
+				}
+				return err
+			},
+		},
+		smokeCheck{
+			name: "DNS names resolve",
+			run: func() error {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+				lookups := append(dns.ClusterLookups(clusterDomain(properties)), dns.ExternalLookups()...)
+				_, err := dns.Validate(ctx, p, api.Linux, lookups, util.DefaultRetrier().WithConstantBackoff(5*time.Second))
+				return err
+			},
+		},
+		smokeCheck{
+			name: "pods reach the internet",
+			run: func() error {
+				ok, err := p.CheckLinuxOutboundConnection(5*time.Second, timeout)
+				if err == nil && !ok {
+					err = errors.New("no outbound internet connection")
+				}
+				return err
+			},
+		},
+	)
+	return checks, cleanup
+}
+
+// runChecks runs the checks in order, stopping at the first failure since the later checks depend on the earlier ones
+func runChecks(checks []smokeCheck) error {
+	for _, c := range checks {
+		log.Infof("Checking that %s", c.name)
+		start := time.Now()
+		if err := c.run(); err != nil {
+			return errors.Wrapf(err, "checking that %s", c.name)
+		}
+		log.Infof("Checked that %s in %s", c.name, time.Since(start).Round(time.Second))
+	}
+	return nil
+}
+
+// coreAddonPods returns the prefixes of the names of the kube-system pods every cluster runs
+func coreAddonPods(properties *api.Properties) []string {
+	k := properties.OrchestratorProfile.KubernetesConfig
+	var pods []string
+	if !k.IsKubeProxyReplaced() {
+		pods = append(pods, "kube-proxy")
+	}
+	pods = append(pods, "kube-addon-manager", "kube-apiserver", "kube-controller-manager", "kube-scheduler")
+	if common.IsKubernetesVersionGe(properties.OrchestratorProfile.OrchestratorVersion, "1.12.0") {
+		pods = append(pods, api.CoreDNSAddonName)
+	} else {
+		pods = append(pods, "kube-dns")
+	}
+	if k.IsAddonEnabled(api.MetricsServerAddonName) {
+		pods = append(pods, api.MetricsServerAddonName)
+	}
+	// the pods of the network plugin, without which no other pod gets an IP address
+	if k.IsAddonEnabled(api.CiliumAddonName) {
+		pods = append(pods, "cilium-operator", "cilium")
+	}
+	if k.IsAddonEnabled(api.AntreaAddonName) {
+		pods = append(pods, "antrea-controller", "antrea-agent")
+	}
+	return pods
+}
+
+func clusterDomain(properties *api.Properties) string {
+	if d := properties.OrchestratorProfile.KubernetesConfig.KubeletConfig["--cluster-domain"]; d != "" {
+		return d
+	}
+	return api.DefaultKubernetesClusterDomain
+}
+
+// installKubectlShim links kubectl as `k` in dir, unless `k` is already in the PATH
+func installKubectlShim(dir string) error {
+	if _, err := exec.LookPath("k"); err == nil {
+		return nil
+	}
+	kubectl, err := exec.LookPath("kubectl")
+	if err != nil {
+		return errors.New("--validate requires kubectl in the PATH")
+	}
+	name := "k"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return errors.Wrap(os.Symlink(kubectl, filepath.Join(dir, name)), "linking kubectl")
+}
+
+// setEnv sets the environment variables and returns a function restoring their previous values
+func setEnv(env map[string]string) func() {
+	previous := map[string]*string{}
+	for k, v := range env {
+		if old, ok := os.LookupEnv(k); ok {
+			previous[k] = &old
+		} else {
+			previous[k] = nil
+		}
+		os.Setenv(k, v)
+	}
+	return func() {
+		for k, v := range previous {
+			if v == nil {
+				os.Unsetenv(k)
+			} else {
+				os.Setenv(k, *v)
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/pkg/errors"
+)
+
+func TestRunChecks(t *testing.T) {
+	var ran []string
+	check := func(name string, err error) smokeCheck {
+		return smokeCheck{name: name, run: func() error {
+			ran = append(ran, name)
+			return err
+		}}
+	}
+
+	if err := runChecks([]smokeCheck{check("nodes are Ready", nil), check("coredns pods are Ready", nil)}); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	ran = nil
+	err := runChecks([]smokeCheck{check("nodes are Ready", nil), check("coredns pods are Ready", errors.New("timed out")), check("DNS names resolve", nil)})
+	if err == nil || err.Error() != "checking that coredns pods are Ready: timed out" {
+		t.Fatalf("expected the coredns check to fail, got %v", err)
+	}
+	if !reflect.DeepEqual(ran, []string{"nodes are Ready", "coredns pods are Ready"}) {
+		t.Fatalf("expected the checks to stop at the first failure, ran %v", ran)
+	}
+}
+
+func TestCoreAddonPods(t *testing.T) {
+	newProperties := func(version string, addons ...api.KubernetesAddon) *api.Properties {
+		return &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorVersion: version,
+				KubernetesConfig:    &api.KubernetesConfig{Addons: addons},
+			},
+		}
+	}
+	cilium := func(kubeProxyReplacement string) *api.Properties {
+		p := newProperties("1.16.1", api.KubernetesAddon{
+			Name:    api.CiliumAddonName,
+			Enabled: &[]bool{true}[0],
+			Config:  map[string]string{"kube-proxy-replacement": kubeProxyReplacement},
+		})
+		p.OrchestratorProfile.KubernetesConfig.NetworkPlugin = api.NetworkPluginCilium
+		return p
+	}
+	core := []string{"kube-proxy", "kube-addon-manager", "kube-apiserver", "kube-controller-manager", "kube-scheduler"}
+
+	cases := []struct {
+		name       string
+		properties *api.Properties
+		expected   []string
+	}{
+		{
+			name:       "kube-dns before 1.12",
+			properties: newProperties("1.11.10"),
+			expected:   append(core, "kube-dns"),
+		},
+		{
+			name:       "coredns",
+			properties: newProperties("1.15.3"),
+			expected:   append(core, "coredns"),
+		},
+		{
+			name:       "metrics-server",
+			properties: newProperties("1.15.3", api.KubernetesAddon{Name: api.MetricsServerAddonName, Enabled: &[]bool{true}[0]}),
+			expected:   append(core, "coredns", "metrics-server"),
+		},
+		{
+			name:       "cilium",
+			properties: cilium(api.CiliumKubeProxyReplacementDisabled),
+			expected:   append(core, "coredns", "cilium-operator", "cilium"),
+		},
+		{
+			name:       "cilium replacing kube-proxy",
+			properties: cilium(api.CiliumKubeProxyReplacementStrict),
+			expected:   append(core[1:], "coredns", "cilium-operator", "cilium"),
+		},
+		{
+			name:       "antrea",
+			properties: newProperties("1.16.1", api.KubernetesAddon{Name: api.AntreaAddonName, Enabled: &[]bool{true}[0]}),
+			expected:   append(core, "coredns", "antrea-controller", "antrea-agent"),
+		},
+	}
+	for _, tc := range cases {
+		c := tc
+		t.Run(c.name, func(t *testing.T) {
+			if actual := coreAddonPods(c.properties); !reflect.DeepEqual(actual, c.expected) {
+				t.Fatalf("expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestInstallKubectlShim(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake kubectl is a shell script")
+	}
+	bin, err := ioutil.TempDir("", "kubectl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(bin)
+	dir, err := ioutil.TempDir("", "shim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	restore := setEnv(map[string]string{"PATH": bin})
+	defer restore()
+
+	if err = installKubectlShim(dir); err == nil || err.Error() != "--validate requires kubectl in the PATH" {
+		t.Fatalf("expected an error without kubectl in the PATH, got %v", err)
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(bin, "kubectl"), []byte("#!/bin/sh\necho kubectl \"$@\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = installKubectlShim(dir); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	out, err := exec.Command(filepath.Join(dir, "k"), "get", "nodes").Output()
+	if err != nil || string(out) != "kubectl get nodes\n" {
+		t.Fatalf("expected k to run kubectl, got %q, %v", out, err)
+	}
+}
+
+func TestSetEnv(t *testing.T) {
+	os.Setenv("AKS_ENGINE_TEST_SET", "before")
+	os.Unsetenv("AKS_ENGINE_TEST_UNSET")
+	defer os.Unsetenv("AKS_ENGINE_TEST_SET")
+
+	restore := setEnv(map[string]string{"AKS_ENGINE_TEST_SET": "during", "AKS_ENGINE_TEST_UNSET": "during"})
+	if os.Getenv("AKS_ENGINE_TEST_SET") != "during" || os.Getenv("AKS_ENGINE_TEST_UNSET") != "during" {
+		t.Fatalf("expected the variables to be set")
+	}
+	restore()
+	if os.Getenv("AKS_ENGINE_TEST_SET") != "before" {
+		t.Fatalf("expected AKS_ENGINE_TEST_SET to be restored, got %s", os.Getenv("AKS_ENGINE_TEST_SET"))
+	}
+	if _, ok := os.LookupEnv("AKS_ENGINE_TEST_UNSET"); ok {
+		t.Fatalf("expected AKS_ENGINE_TEST_UNSET to be unset")
+	}
+}
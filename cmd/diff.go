@@ -0,0 +1,171 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/vlabs"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/operations/diff"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	diffName             = "diff"
+	diffShortDescription = "Compare two api models or two generated ARM templates"
+	diffLongDescription  = "Semantically compares two api models, or the ARM templates generated for a cluster before and after a change such as an upgrade, and reports the changed fields, the added and removed agent pools and the impacted ARM resources, to review configuration drift before applying it."
+)
+
+type diffCmd struct {
+	// user input
+	oldPath      string
+	newPath      string
+	outputFormat string
+	// exitCode makes the command fail if the documents differ
+	exitCode bool
+
+	// derived
+	locale *gotext.Locale
+	out    io.Writer
+}
+
+func newDiffCmd() *cobra.Command {
+	dc := diffCmd{
+		out: os.Stdout,
+	}
+
+	command := &cobra.Command{
+		Use:   diffName + " <old> <new>",
+		Short: diffShortDescription,
+		Long:  diffLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := dc.validate(cmd, args); err != nil {
+				return errors.Wrap(err, "validating diff args")
+			}
+			return dc.run()
+		},
+	}
+
+	f := command.Flags()
+	f.StringVarP(&dc.outputFormat, "output", "o", "human", fmt.Sprintf("Output format. Allowed values: %s", strings.Join(outputFormatOptions, ", ")))
+	f.BoolVar(&dc.exitCode, "exit-code", false, "fail if the documents differ")
+
+	return command
+}
+
+func (dc *diffCmd) validate(cmd *cobra.Command, args []string) error {
+	var err error
+
+	dc.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if len(args) != 2 {
+		cmd.Usage()
+		return errors.New("the old and new api models or templates must be specified as positional arguments")
+	}
+	dc.oldPath, dc.newPath = args[0], args[1]
+
+	for _, path := range args {
+		if _, err = os.Stat(path); os.IsNotExist(err) {
+			return errors.Errorf("specified file does not exist (%s)", path)
+		}
+	}
+
+	if dc.outputFormat != "human" && dc.outputFormat != "json" {
+		return errors.Errorf(`output format "%s" is not supported`, dc.outputFormat)
+	}
+
+	return nil
+}
+
+func (dc *diffCmd) run() error {
+	oldDocument, oldKind, err := dc.load(dc.oldPath)
+	if err != nil {
+		return err
+	}
+	newDocument, newKind, err := dc.load(dc.newPath)
+	if err != nil {
+		return err
+	}
+	if oldKind != newKind {
+		return errors.Errorf("cannot compare the %s %s with the %s %s", oldKind, dc.oldPath, newKind, dc.newPath)
+	}
+
+	var report *diff.Report
+	if oldKind == diff.DocumentAPIModel {
+		report, err = diff.APIModels(oldDocument.(*api.ContainerService), newDocument.(*api.ContainerService))
+		if err != nil {
+			return errors.Wrap(err, "comparing the api models")
+		}
+	} else {
+		report = diff.Templates(oldDocument.(map[string]interface{}), newDocument.(map[string]interface{}))
+	}
+
+	switch dc.outputFormat {
+	case "json":
+		if report.Changes == nil {
+			report.Changes = []diff.Change{}
+		}
+		b, err := helpers.JSONMarshalIndent(report, "", "  ", false)
+		if err != nil {
+			return errors.Wrap(err, "error encoding report to json")
+		}
+		fmt.Fprintln(dc.out, string(b))
+	default:
+		if err = report.WriteText(dc.out); err != nil {
+			return errors.Wrap(err, "writing report")
+		}
+	}
+
+	if dc.exitCode && !report.Empty() {
+		return errors.Errorf("%s and %s differ", dc.oldPath, dc.newPath)
+	}
+	return nil
+}
+
+// load reads an api model, with its deprecated fields migrated, or an ARM template
+func (dc *diffCmd) load(path string) (interface{}, diff.Document, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "reading %s", path)
+	}
+	var document map[string]interface{}
+	if err = json.Unmarshal(contents, &document); err != nil {
+		return nil, "", errors.Wrapf(err, "parsing %s", path)
+	}
+	if _, ok := document["resources"]; ok {
+		return document, diff.DocumentTemplate, nil
+	}
+	if _, ok := document["properties"]; !ok {
+		return nil, "", errors.Errorf("%s is neither an api model nor an ARM template", path)
+	}
+
+	if document["apiVersion"] == vlabs.APIVersion {
+		if contents, _, err = vlabs.MigrateAPIModel(contents); err != nil {
+			return nil, "", errors.Wrapf(err, "migrating the deprecated fields of %s", path)
+		}
+	}
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: dc.locale,
+		},
+	}
+	cs, _, err := apiloader.DeserializeContainerService(contents, false, false, nil)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "parsing the api model %s", path)
+	}
+	return cs, diff.DocumentAPIModel, nil
+}
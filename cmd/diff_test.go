@@ -0,0 +1,105 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/operations/diff"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+func TestNewDiffCmd(t *testing.T) {
+	output := newDiffCmd()
+	if output.Name() != diffName || output.Short != diffShortDescription || output.Long != diffLongDescription {
+		t.Fatalf("diff command should have name %s equal %s, short %s equal %s and long %s equal to %s", output.Name(), diffName, output.Short, diffShortDescription, output.Long, diffLongDescription)
+	}
+
+	expectedFlags := []string{"output", "exit-code"}
+	for _, f := range expectedFlags {
+		if output.Flags().Lookup(f) == nil {
+			t.Fatalf("diff command should have flag %s", f)
+		}
+	}
+}
+
+func TestDiffCmdValidate(t *testing.T) {
+	cases := []struct {
+		dc          diffCmd
+		args        []string
+		expectedErr string
+	}{
+		{
+			args:        []string{"../pkg/engine/testdata/simple/kubernetes.json"},
+			expectedErr: "the old and new api models or templates must be specified as positional arguments",
+		},
+		{
+			args:        []string{"../pkg/engine/testdata/simple/kubernetes.json", "../pkg/engine/testdata/simple/missing.json"},
+			expectedErr: "specified file does not exist (../pkg/engine/testdata/simple/missing.json)",
+		},
+		{
+			dc:          diffCmd{outputFormat: "yaml"},
+			args:        []string{"../pkg/engine/testdata/simple/kubernetes.json", "../pkg/engine/testdata/vnet/kubernetesvnet.json"},
+			expectedErr: `output format "yaml" is not supported`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		err := c.dc.validate(&cobra.Command{}, c.args)
+		if err == nil || err.Error() != c.expectedErr {
+			t.Errorf("expected error %q, got %v", c.expectedErr, err)
+		}
+	}
+}
+
+func TestDiffCmdRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "diff")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	old := "../pkg/engine/testdata/simple/kubernetes.json"
+	var m map[string]interface{}
+	contents, err := ioutil.ReadFile(old)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(json.Unmarshal(contents, &m)).To(Succeed())
+	properties := m["properties"].(map[string]interface{})
+	pools := properties["agentPoolProfiles"].([]interface{})
+	properties["agentPoolProfiles"] = append(pools[1:], map[string]interface{}{"name": "pool3", "count": 1, "vmSize": "Standard_D2_v2"})
+	contents, err = json.Marshal(m)
+	g.Expect(err).NotTo(HaveOccurred())
+	new := filepath.Join(dir, "kubernetes.json")
+	g.Expect(ioutil.WriteFile(new, contents, 0644)).To(Succeed())
+
+	out := &bytes.Buffer{}
+	dc := &diffCmd{outputFormat: "json", exitCode: true, out: out}
+	g.Expect(dc.validate(&cobra.Command{}, []string{old, new})).To(Succeed())
+	g.Expect(dc.run()).To(MatchError(old + " and " + new + " differ"))
+
+	var report diff.Report
+	g.Expect(json.Unmarshal(out.Bytes(), &report)).To(Succeed())
+	g.Expect(report.Document).To(Equal(diff.DocumentAPIModel))
+	g.Expect(report.AddedAgentPools).To(Equal([]string{"pool3"}))
+	g.Expect(report.RemovedAgentPools).To(Equal([]string{"agentpool1"}))
+
+	out.Reset()
+	dc = &diffCmd{outputFormat: "human", exitCode: true, out: out}
+	g.Expect(dc.validate(&cobra.Command{}, []string{old, old})).To(Succeed())
+	g.Expect(dc.run()).To(Succeed())
+	g.Expect(out.String()).To(Equal("The api models do not differ\n"))
+
+	template := filepath.Join(dir, "azuredeploy.json")
+	g.Expect(ioutil.WriteFile(template, []byte(`{"resources": []}`), 0644)).To(Succeed())
+	dc = &diffCmd{outputFormat: "human", out: out}
+	g.Expect(dc.validate(&cobra.Command{}, []string{old, template})).To(Succeed())
+	g.Expect(dc.run()).To(MatchError("cannot compare the apimodel " + old + " with the template " + template))
+}
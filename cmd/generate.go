@@ -4,12 +4,17 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
 
 	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/persona"
+	"github.com/Azure/aks-engine/pkg/api/vlabs"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
 	"github.com/Azure/aks-engine/pkg/engine"
 	"github.com/Azure/aks-engine/pkg/engine/transform"
 	"github.com/Azure/aks-engine/pkg/i18n"
@@ -26,6 +31,12 @@ const (
 	generateLongDescription  = "Generates an Azure Resource Manager template, parameters file and other assets for a cluster"
 )
 
+const (
+	outputFormatARM       = "arm"
+	outputFormatTerraform = "terraform"
+	outputFormatBicep     = "bicep"
+)
+
 type generateCmd struct {
 	apimodelPath      string
 	outputDirectory   string // can be auto-determined from clusterDefinition
@@ -34,13 +45,30 @@ type generateCmd struct {
 	noPrettyPrint     bool
 	parametersOnly    bool
 	set               []string
+	offline           bool
+	capabilitiesPath  string
+	// deprecationReportPath is where the deprecations found in the api model are written as JSON, if set
+	deprecationReportPath string
+	// systemComponentsReportPath is where the findings of the audit of the system addons are written as JSON, if set
+	systemComponentsReportPath string
+	// migrateAPIModel writes the api model back to apimodelPath with its deprecated fields migrated
+	migrateAPIModel bool
+	// outputFormat is the format of the generated deployment, outputFormatTerraform adds a Terraform configuration to the ARM template
+	// and outputFormatBicep the Bicep equivalent of the ARM template
+	outputFormat string
+	// checkCustomVNET checks the address space of a custom VNET, read from Azure, against the networks of the cluster
+	checkCustomVNET  bool
+	azureEnvironment string
+	authMethod       string
 
 	// derived
 	containerService *api.ContainerService
 	apiVersion       string
 	locale           *gotext.Locale
 
-	rawClientID string
+	rawClientID       string
+	rawSubscriptionID string
+	client            armhelpers.AKSEngineClient
 
 	ClientID     uuid.UUID
 	ClientSecret string
@@ -58,6 +86,14 @@ func newGenerateCmd() *cobra.Command {
 				return errors.Wrap(err, "validating generateCmd")
 			}
 
+			if gc.offline {
+				previous := persona.Current()
+				if err := gc.setOfflinePersona(); err != nil {
+					return errors.Wrap(err, "setting up offline mode in generateCmd")
+				}
+				defer persona.Set(previous)
+			}
+
 			if err := gc.mergeAPIModel(); err != nil {
 				return errors.Wrap(err, "merging API model in generateCmd")
 			}
@@ -72,6 +108,9 @@ func newGenerateCmd() *cobra.Command {
 			} else {
 				log.Warnf("API model validation is only available for \"apiVersion\": \"vlabs\", skipping validation...")
 			}
+			if err := gc.containerService.ValidateCapabilities(persona.Current()); err != nil {
+				return errors.Wrapf(err, "validating API model against the %s cloud capabilities", persona.Current().Name())
+			}
 			return gc.run()
 		},
 	}
@@ -86,6 +125,16 @@ func newGenerateCmd() *cobra.Command {
 	f.BoolVar(&gc.parametersOnly, "parameters-only", false, "only output parameters files")
 	f.StringVar(&gc.rawClientID, "client-id", "", "client id")
 	f.StringVar(&gc.ClientSecret, "client-secret", "", "client secret")
+	f.BoolVar(&gc.offline, "offline", false, "generate without any network access, cloud lookups are answered from the capabilities file")
+	f.StringVar(&gc.capabilitiesPath, "capabilities-file", "", "path to the capabilities file used in offline mode (defaults to the capabilities aks-engine is built with)")
+	f.StringVar(&gc.deprecationReportPath, "deprecation-report", "", "path to write a JSON report of the deprecated fields found in the api model to")
+	f.StringVar(&gc.systemComponentsReportPath, "system-components-report", "", "path to write a JSON report of the system addons missing a system priority class or resource requests to")
+	f.BoolVar(&gc.migrateAPIModel, "migrate-api-model", false, "write the api model back to its file with its deprecated fields migrated to their supported equivalents")
+	f.BoolVar(&gc.checkCustomVNET, "check-custom-vnet", false, "check that the address space of the custom VNET of the cluster, read from Azure, does not overlap its cluster, service or docker bridge networks")
+	f.StringVarP(&gc.rawSubscriptionID, "subscription-id", "s", "", "azure subscription id (used with --check-custom-vnet)")
+	f.StringVar(&gc.azureEnvironment, "azure-env", "AzurePublicCloud", "the target Azure cloud (used with --check-custom-vnet)")
+	f.StringVar(&gc.authMethod, "auth-method", "client_secret", "auth method (default:`client_secret`, `cli`, `device`) (used with --check-custom-vnet)")
+	f.StringVar(&gc.outputFormat, "output-format", outputFormatARM, fmt.Sprintf("format of the generated deployment, %q for an ARM template, %q for a Terraform configuration deploying it or %q for its Bicep equivalent", outputFormatARM, outputFormatTerraform, outputFormatBicep))
 	return generateCmd
 }
 
@@ -113,11 +162,57 @@ func (gc *generateCmd) validate(cmd *cobra.Command, args []string) error {
 		return errors.Errorf("specified api model does not exist (%s)", gc.apimodelPath)
 	}
 
+	if gc.capabilitiesPath != "" {
+		if !gc.offline {
+			return errors.New("--capabilities-file can only be used with --offline")
+		}
+		if _, err := os.Stat(gc.capabilitiesPath); os.IsNotExist(err) {
+			return errors.Errorf("specified capabilities file does not exist (%s)", gc.capabilitiesPath)
+		}
+	}
+
+	if gc.checkCustomVNET && gc.offline {
+		return errors.New("--check-custom-vnet reads the custom VNET from Azure and cannot be used with --offline")
+	}
+
+	if gc.migrateAPIModel && len(gc.set) > 0 {
+		return errors.New("--migrate-api-model cannot be used with --set, the --set values would be written to the api model")
+	}
+
+	switch gc.outputFormat {
+	case "", outputFormatARM:
+	case outputFormatTerraform:
+		if gc.parametersOnly {
+			return errors.New("--parameters-only cannot be used with --output-format terraform, the Terraform configuration deploys the ARM template")
+		}
+	case outputFormatBicep:
+		if gc.parametersOnly {
+			return errors.New("--parameters-only cannot be used with --output-format bicep, the Bicep file is converted from the ARM template")
+		}
+	default:
+		return errors.Errorf("--output-format must be %q, %q or %q, got %q", outputFormatARM, outputFormatTerraform, outputFormatBicep, gc.outputFormat)
+	}
+
 	gc.ClientID, _ = uuid.FromString(gc.rawClientID)
 
 	return nil
 }
 
+// setOfflinePersona answers the cloud lookups of template generation from the capabilities file,
+// or from the capabilities aks-engine is built with if there is none
+func (gc *generateCmd) setOfflinePersona() error {
+	capabilities := persona.BundledCapabilities()
+	if gc.capabilitiesPath != "" {
+		var err error
+		if capabilities, err = persona.LoadCapabilitiesFile(gc.capabilitiesPath); err != nil {
+			return err
+		}
+	}
+	persona.Set(persona.NewOffline(capabilities))
+	log.Infoln("Generating in offline mode, cloud lookups are answered from the capabilities file")
+	return nil
+}
+
 func (gc *generateCmd) mergeAPIModel() error {
 	var err error
 	// if --set flag has been used
@@ -147,7 +242,11 @@ func (gc *generateCmd) loadAPIModel() error {
 			Locale: gc.locale,
 		},
 	}
-	gc.containerService, gc.apiVersion, err = apiloader.LoadContainerServiceFromFile(gc.apimodelPath, false, false, nil)
+	contents, err := gc.migrateDeprecatedFields()
+	if err != nil {
+		return errors.Wrap(err, "error migrating the deprecated fields of the api model")
+	}
+	gc.containerService, gc.apiVersion, err = apiloader.DeserializeContainerService(contents, false, false, nil)
 	if err != nil {
 		return errors.Wrap(err, "error parsing the api model")
 	}
@@ -187,6 +286,93 @@ func (gc *generateCmd) loadAPIModel() error {
 	return nil
 }
 
+// deprecationReport is the JSON report of the deprecated fields of an api model written to --deprecation-report
+type deprecationReport struct {
+	APIModel     string              `json:"apiModel"`
+	Migrated     bool                `json:"migrated"` // Migrated is true if the api model was written back with the deprecated fields migrated
+	Deprecations []vlabs.Deprecation `json:"deprecations"`
+}
+
+// migrateDeprecatedFields returns the contents of the api model with its deprecated fields migrated to their supported equivalents,
+// warning about each of them. Only vlabs api models are migrated.
+func (gc *generateCmd) migrateDeprecatedFields() ([]byte, error) {
+	contents, err := ioutil.ReadFile(gc.apimodelPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the api model %s", gc.apimodelPath)
+	}
+	m := &api.TypeMeta{}
+	if err = json.Unmarshal(contents, m); err != nil {
+		return nil, err
+	}
+	deprecations := []vlabs.Deprecation{}
+	if m.APIVersion == vlabs.APIVersion {
+		var found []vlabs.Deprecation
+		if contents, found, err = vlabs.MigrateAPIModel(contents); err != nil {
+			return nil, err
+		}
+		deprecations = append(deprecations, found...)
+	}
+	for _, d := range deprecations {
+		log.Warnf("Deprecated field in the api model: %s", d)
+	}
+
+	migrated := false
+	if gc.migrateAPIModel && len(deprecations) > 0 {
+		info, err := os.Stat(gc.apimodelPath)
+		if err != nil {
+			return nil, err
+		}
+		if err = ioutil.WriteFile(gc.apimodelPath, contents, info.Mode()); err != nil {
+			return nil, errors.Wrapf(err, "writing the migrated api model to %s", gc.apimodelPath)
+		}
+		migrated = true
+		log.Infof("Wrote the api model with its %d deprecated fields migrated to %s", len(deprecations), gc.apimodelPath)
+	}
+	if gc.deprecationReportPath != "" {
+		report, err := json.MarshalIndent(deprecationReport{APIModel: gc.apimodelPath, Migrated: migrated, Deprecations: deprecations}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err = ioutil.WriteFile(gc.deprecationReportPath, report, 0644); err != nil {
+			return nil, errors.Wrapf(err, "writing the deprecation report to %s", gc.deprecationReportPath)
+		}
+	}
+	return contents, nil
+}
+
+// systemComponentsReport is the JSON report of the audit of the system addons written to --system-components-report
+type systemComponentsReport struct {
+	APIModel string                          `json:"apiModel"`
+	Findings []engine.SystemComponentFinding `json:"findings"`
+}
+
+// auditSystemComponents warns about the system addons user workloads can starve that the generated manifests do not
+// correct, e.g. containers without resource requests
+func (gc *generateCmd) auditSystemComponents() error {
+	findings, err := engine.AuditSystemComponents(gc.containerService)
+	if err != nil {
+		return errors.Wrap(err, "auditing the system addons")
+	}
+	for _, f := range findings {
+		if !f.Fixed {
+			log.Warnf("System addon not protected from user workloads: %s", f)
+		}
+	}
+	if gc.systemComponentsReportPath != "" {
+		if findings == nil {
+			findings = []engine.SystemComponentFinding{}
+		}
+		report, err := json.MarshalIndent(systemComponentsReport{APIModel: gc.apimodelPath, Findings: findings}, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err = ioutil.WriteFile(gc.systemComponentsReportPath, report, 0644); err != nil {
+			return errors.Wrapf(err, "writing the system components report to %s", gc.systemComponentsReportPath)
+		}
+	}
+	return nil
+}
+
 func (gc *generateCmd) autofillApimodel() error {
 	// set the client id and client secret by command flags
 	k8sConfig := gc.containerService.Properties.OrchestratorProfile.KubernetesConfig
@@ -207,6 +393,43 @@ func (gc *generateCmd) validateAPIModelAsVLabs() error {
 	return api.ConvertContainerServiceToVLabs(gc.containerService).Validate(false)
 }
 
+// getClient returns the client --check-custom-vnet reads the custom VNET with
+func (gc *generateCmd) getClient() (armhelpers.AKSEngineClient, error) {
+	if gc.client != nil {
+		return gc.client, nil
+	}
+	a := &authArgs{
+		RawAzureEnvironment: gc.azureEnvironment,
+		rawSubscriptionID:   gc.rawSubscriptionID,
+		AuthMethod:          gc.authMethod,
+		rawClientID:         gc.rawClientID,
+		ClientSecret:        gc.ClientSecret,
+		IdentitySystem:      "azure_ad",
+		language:            "en-us",
+	}
+	if err := a.validateAuthArgs(); err != nil {
+		return nil, err
+	}
+	return a.getClient()
+}
+
+// validateCustomVNETCIDROverlaps ensures the address space of a pre-existing custom VNET
+// does not overlap the cluster, service or docker bridge networks
+func (gc *generateCmd) validateCustomVNETCIDROverlaps() error {
+	p := gc.containerService.Properties
+	if p.MasterProfile == nil || !p.MasterProfile.IsCustomVNET() {
+		log.Warnf("--check-custom-vnet has no effect, the cluster does not use a custom VNET")
+		return nil
+	}
+	client, err := gc.getClient()
+	if err != nil {
+		return errors.Wrap(err, "getting a client to read the custom VNET")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	return checkCustomVNETCIDROverlaps(ctx, client, gc.containerService)
+}
+
 func (gc *generateCmd) run() error {
 	log.Infoln(fmt.Sprintf("Generating assets into %s...", gc.outputDirectory))
 
@@ -225,6 +448,16 @@ func (gc *generateCmd) run() error {
 		return errors.Wrapf(err, "in SetPropertiesDefaults template %s", gc.apimodelPath)
 	}
 
+	if gc.checkCustomVNET {
+		if err = gc.validateCustomVNETCIDROverlaps(); err != nil {
+			return errors.Wrap(err, "validating custom VNET address space")
+		}
+	}
+
+	if err = engine.ResolveCustomManifests(gc.containerService); err != nil {
+		return errors.Wrap(err, "resolving custom manifests")
+	}
+
 	//TODO remove these debug statements when we're new template generation implementation is enabled!
 	//bts, _ := json.Marshal(gc.containerService)
 	//log.Info(string(bts))
@@ -234,6 +467,10 @@ func (gc *generateCmd) run() error {
 		return errors.Wrapf(err, "generating template %s", gc.apimodelPath)
 	}
 
+	if err = gc.auditSystemComponents(); err != nil {
+		return err
+	}
+
 	if !gc.noPrettyPrint {
 		if template, err = transform.PrettyPrintArmTemplate(template); err != nil {
 			return errors.Wrap(err, "pretty-printing template")
@@ -252,5 +489,23 @@ func (gc *generateCmd) run() error {
 		return errors.Wrap(err, "writing artifacts")
 	}
 
+	// the mirrors and imageRegistry of an air-gapped cluster must serve these before it is deployed
+	if k := gc.containerService.Properties.OrchestratorProfile.KubernetesConfig; k != nil && k.IsAirGapped() && !gc.parametersOnly {
+		if err = writer.WriteAirGapArtifacts(gc.containerService, gc.outputDirectory); err != nil {
+			return errors.Wrap(err, "writing air gap artifacts")
+		}
+	}
+
+	switch gc.outputFormat {
+	case outputFormatTerraform:
+		if err = writer.WriteTerraformArtifacts(gc.containerService, template, parameters, gc.outputDirectory); err != nil {
+			return errors.Wrap(err, "writing Terraform artifacts")
+		}
+	case outputFormatBicep:
+		if err = writer.WriteBicepArtifacts(template, gc.outputDirectory); err != nil {
+			return errors.Wrap(err, "writing Bicep artifacts")
+		}
+	}
+
 	return nil
 }
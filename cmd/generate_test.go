@@ -4,10 +4,17 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/persona"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
 	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -19,7 +26,7 @@ func TestNewGenerateCmd(t *testing.T) {
 		t.Fatalf("generate command should have use %s equal %s, short %s equal %s and long %s equal to %s", command.Use, generateName, command.Short, generateShortDescription, command.Long, generateLongDescription)
 	}
 
-	expectedFlags := []string{"api-model", "output-directory", "ca-certificate-path", "ca-private-key-path", "set", "no-pretty-print", "parameters-only", "client-id", "client-secret"}
+	expectedFlags := []string{"api-model", "output-directory", "ca-certificate-path", "ca-private-key-path", "set", "no-pretty-print", "parameters-only", "client-id", "client-secret", "offline", "capabilities-file", "deprecation-report", "migrate-api-model", "output-format", "check-custom-vnet", "subscription-id", "azure-env", "auth-method"}
 	for _, f := range expectedFlags {
 		if command.Flags().Lookup(f) == nil {
 			t.Fatalf("generate command should have flag %s", f)
@@ -60,6 +67,79 @@ func TestGenerateCmdValidate(t *testing.T) {
 		t.Fatalf("expected error validating multiple args")
 	}
 
+	g = &generateCmd{capabilitiesPath: "../pkg/engine/testdata/simple/kubernetes.json"}
+
+	// validate cmd with a capabilities file but without offline mode
+	err = g.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json"})
+	if err == nil {
+		t.Fatalf("expected error validating --capabilities-file without --offline")
+	}
+
+	g = &generateCmd{offline: true, capabilitiesPath: "does-not-exist.json"}
+
+	// validate cmd with a missing capabilities file
+	err = g.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json"})
+	if err == nil {
+		t.Fatalf("expected error validating a capabilities file that does not exist")
+	}
+
+	g = &generateCmd{outputFormat: "pulumi"}
+
+	// validate cmd with an unknown output format
+	err = g.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json"})
+	if err == nil {
+		t.Fatalf("expected error validating an unknown output format")
+	}
+
+	g = &generateCmd{outputFormat: outputFormatTerraform, parametersOnly: true}
+
+	// validate cmd with the terraform output format and only the parameters
+	err = g.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json"})
+	if err == nil {
+		t.Fatalf("expected error validating --parameters-only with the terraform output format")
+	}
+
+	g = &generateCmd{outputFormat: outputFormatTerraform}
+
+	// validate cmd with the terraform output format
+	err = g.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json"})
+	if err != nil {
+		t.Fatalf("unexpected error validating the terraform output format: %s", err.Error())
+	}
+
+	g = &generateCmd{outputFormat: outputFormatBicep, parametersOnly: true}
+
+	// validate cmd with the bicep output format and only the parameters
+	err = g.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json"})
+	if err == nil {
+		t.Fatalf("expected error validating --parameters-only with the bicep output format")
+	}
+
+	g = &generateCmd{checkCustomVNET: true, offline: true}
+
+	// validate cmd checking the custom VNET in offline mode
+	err = g.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json"})
+	if err == nil {
+		t.Fatalf("expected error validating --check-custom-vnet with --offline")
+	}
+}
+
+func TestGenerateCmdSetOfflinePersona(t *testing.T) {
+	previous := persona.Current()
+	defer persona.Set(previous)
+
+	g := &generateCmd{offline: true}
+	if err := g.setOfflinePersona(); err != nil {
+		t.Fatalf("unexpected error setting the offline persona: %s", err.Error())
+	}
+	if persona.Current().Name() != "offline" {
+		t.Fatalf("expected the offline persona, got %s", persona.Current().Name())
+	}
+
+	g = &generateCmd{offline: true, capabilitiesPath: "generate.go"}
+	if err := g.setOfflinePersona(); err == nil {
+		t.Fatalf("expected error setting the offline persona from an invalid capabilities file")
+	}
 }
 
 func TestGenerateCmdMergeAPIModel(t *testing.T) {
@@ -128,6 +208,171 @@ func TestGenerateCmdMLoadAPIModel(t *testing.T) {
 	}
 }
 
+func TestGenerateCmdRunTerraform(t *testing.T) {
+	outputDirectory, err := ioutil.TempDir("", "aks-engine-generate-terraform")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outputDirectory)
+
+	g := &generateCmd{
+		outputDirectory: outputDirectory,
+		outputFormat:    outputFormatTerraform,
+	}
+	r := &cobra.Command{}
+	if err = g.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json"}); err != nil {
+		t.Fatalf("unexpected error validating: %s", err.Error())
+	}
+	if err = g.loadAPIModel(); err != nil {
+		t.Fatalf("unexpected error loading api model: %s", err.Error())
+	}
+	if err = g.run(); err != nil {
+		t.Fatalf("unexpected error generating the terraform configuration: %s", err.Error())
+	}
+
+	for _, file := range []string{"azuredeploy.json", "azuredeploy.parameters.json", "main.tf.json"} {
+		b, err := ioutil.ReadFile(filepath.Join(outputDirectory, file))
+		if err != nil {
+			t.Fatalf("expected %s to be generated: %s", file, err.Error())
+		}
+		if !json.Valid(b) {
+			t.Errorf("expected %s to be valid JSON", file)
+		}
+	}
+}
+
+func TestGenerateCmdRunBicep(t *testing.T) {
+	outputDirectory, err := ioutil.TempDir("", "aks-engine-generate-bicep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outputDirectory)
+
+	g := &generateCmd{
+		outputDirectory: outputDirectory,
+		outputFormat:    outputFormatBicep,
+	}
+	r := &cobra.Command{}
+	if err = g.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json"}); err != nil {
+		t.Fatalf("unexpected error validating: %s", err.Error())
+	}
+	if err = g.loadAPIModel(); err != nil {
+		t.Fatalf("unexpected error loading api model: %s", err.Error())
+	}
+	if err = g.run(); err != nil {
+		t.Fatalf("unexpected error generating the bicep file: %s", err.Error())
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(outputDirectory, "azuredeploy.bicep"))
+	if err != nil {
+		t.Fatalf("expected azuredeploy.bicep to be generated: %s", err.Error())
+	}
+	if !strings.Contains(string(b), "resource ") {
+		t.Errorf("expected azuredeploy.bicep to declare the resources of the template")
+	}
+}
+
+func TestGenerateCmdValidateCustomVNETCIDROverlaps(t *testing.T) {
+	cases := []struct {
+		name         string
+		vnetPrefixes []string
+		expectedErr  string
+	}{
+		{
+			name:         "no overlaps",
+			vnetPrefixes: []string{"10.239.0.0/16"},
+		},
+		{
+			name:         "service cidr overlaps vnet",
+			vnetPrefixes: []string{"10.0.0.0/8"},
+			expectedErr:  "networking CIDR ranges must not overlap: VNET VNET_NAME address prefix '10.0.0.0/8' overlaps with ServiceCidr '10.0.0.0/16'",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			cs := api.CreateMockContainerService("testcluster", "1.13.0", 3, 2, false)
+			cs.Properties.MasterProfile.VnetSubnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME"
+			cs.Properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin = api.NetworkPluginAzure
+			cs.Properties.OrchestratorProfile.KubernetesConfig.ServiceCIDR = "10.0.0.0/16"
+			cs.Properties.OrchestratorProfile.KubernetesConfig.DockerBridgeSubnet = "172.17.0.1/16"
+			g := &generateCmd{
+				checkCustomVNET:  true,
+				containerService: cs,
+				client: &armhelpers.MockAKSEngineClient{
+					FakeVirtualNetworkAddressPrefixes: c.vnetPrefixes,
+				},
+			}
+			err := g.validateCustomVNETCIDROverlaps()
+			if c.expectedErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, but got %s", err)
+				}
+			} else if err == nil || err.Error() != c.expectedErr {
+				t.Errorf("expected error %s, but got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerateCmdMigrateDeprecatedFields(t *testing.T) {
+	dir, err := ioutil.TempDir("", "generate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	contents, err := ioutil.ReadFile("../pkg/engine/testdata/simple/kubernetes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	apimodelPath := filepath.Join(dir, "kubernetes.json")
+	var m map[string]interface{}
+	if err = json.Unmarshal(contents, &m); err != nil {
+		t.Fatal(err)
+	}
+	m["properties"].(map[string]interface{})["masterProfile"].(map[string]interface{})["distro"] = "aks"
+	if contents, err = json.Marshal(m); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(apimodelPath, contents, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := &generateCmd{
+		apimodelPath:          apimodelPath,
+		deprecationReportPath: filepath.Join(dir, "deprecations.json"),
+		migrateAPIModel:       true,
+	}
+	if err = g.loadAPIModel(); err != nil {
+		t.Fatalf("unexpected error loading an api model with deprecated fields: %s", err)
+	}
+	if distro := g.containerService.Properties.MasterProfile.Distro; distro != api.AKSUbuntu1604 {
+		t.Errorf("expected the deprecated aks distro to be migrated to %s, got %s", api.AKSUbuntu1604, distro)
+	}
+
+	var report deprecationReport
+	data, err := ioutil.ReadFile(g.deprecationReportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if !report.Migrated || len(report.Deprecations) != 1 || report.Deprecations[0].Path != "properties.masterProfile.distro" {
+		t.Errorf("expected a report of the migrated distro, got %+v", report)
+	}
+	if migrated, _ := ioutil.ReadFile(apimodelPath); !bytes.Contains(migrated, []byte(`"distro": "aks-ubuntu-16.04"`)) {
+		t.Errorf("expected the migrated api model to be written back, got %s", migrated)
+	}
+
+	g = &generateCmd{migrateAPIModel: true, set: []string{"agentPoolProfiles[0].count=1"}}
+	if err = g.validate(&cobra.Command{}, []string{apimodelPath}); err == nil {
+		t.Errorf("expected an error validating --migrate-api-model with --set")
+	}
+}
+
 func TestAPIModelWithoutServicePrincipalProfileAndClientIdAndSecretInGenerateCmd(t *testing.T) {
 	apiloader := &api.Apiloader{
 		Translator: nil,
@@ -284,6 +529,11 @@ func TestExampleAPIModels(t *testing.T) {
 			apiModelPath: "../examples/addons/cluster-autoscaler/kubernetes-cluster-autoscaler.json",
 			setArgs:      defaultSet,
 		},
+		{
+			name:         "cluster-autoscaler multiple pools",
+			apiModelPath: "../examples/addons/cluster-autoscaler/kubernetes-cluster-autoscaler-multipool.json",
+			setArgs:      defaultSet,
+		},
 		{
 			name:         "container-monitoring",
 			apiModelPath: "../examples/addons/container-monitoring/kubernetes-container-monitoring.json",
@@ -464,6 +714,11 @@ func TestExampleAPIModels(t *testing.T) {
 			apiModelPath: "../examples/kubernetes-config/kubernetes-private-cluster.json",
 			setArgs:      []string{"orchestratorProfile.kubernetesConfig.privateCluster.jumpboxProfile.publicKey=\"ssh-rsa AAAAB3NO8b9== azureuser@cluster.local\",masterProfile.dnsPrefix=my-cluster,linuxProfile.ssh.publicKeys[0].keyData=\"ssh-rsa AAAAB3NO8b9== azureuser@cluster.local\",servicePrincipalProfile.clientId=\"123a4321-c6eb-4b61-9d6f-7db123e14a7a\",servicePrincipalProfile.secret=\"=#msRock5!t=\""},
 		},
+		{
+			name:         "private cluster with private link",
+			apiModelPath: "../examples/kubernetes-config/kubernetes-private-cluster-private-link.json",
+			setArgs:      []string{"orchestratorProfile.kubernetesConfig.privateCluster.jumpboxProfile.publicKey=\"ssh-rsa AAAAB3NO8b9== azureuser@cluster.local\",masterProfile.dnsPrefix=my-cluster,linuxProfile.ssh.publicKeys[0].keyData=\"ssh-rsa AAAAB3NO8b9== azureuser@cluster.local\",servicePrincipalProfile.clientId=\"123a4321-c6eb-4b61-9d6f-7db123e14a7a\",servicePrincipalProfile.secret=\"=#msRock5!t=\""},
+		},
 		{
 			name:         "rescheduler addon",
 			apiModelPath: "../examples/kubernetes-config/kubernetes-rescheduler.json",
@@ -474,6 +729,16 @@ func TestExampleAPIModels(t *testing.T) {
 			apiModelPath: "../examples/kubernetes-config/kubernetes-standardlb.json",
 			setArgs:      defaultSet,
 		},
+		{
+			name:         "NAT gateway outbound type",
+			apiModelPath: "../examples/kubernetes-config/kubernetes-nat-gateway.json",
+			setArgs:      defaultSet,
+		},
+		{
+			name:         "cloudProviderConfig",
+			apiModelPath: "../examples/kubernetes-config/kubernetes-cloudprovider-config.json",
+			setArgs:      defaultSet,
+		},
 		{
 			name:         "gpu",
 			apiModelPath: "../examples/kubernetes-gpu/kubernetes.json",
@@ -494,6 +759,11 @@ func TestExampleAPIModels(t *testing.T) {
 			apiModelPath: "../examples/kubernetes-msi-userassigned/kube-vmss.json",
 			setArgs:      defaultSet,
 		},
+		{
+			name:         "msi user-assigned agent pool identities",
+			apiModelPath: "../examples/kubernetes-msi-userassigned/kube-agentpool-identities.json",
+			setArgs:      defaultSet,
+		},
 		{
 			name:         "1.10 example",
 			apiModelPath: "../examples/kubernetes-releases/kubernetes1.10.json",
@@ -534,6 +804,26 @@ func TestExampleAPIModels(t *testing.T) {
 			apiModelPath: "../examples/kubernetes-vmss-low-priority/kubernetes.json",
 			setArgs:      defaultSet,
 		},
+		{
+			name:         "vmss spot",
+			apiModelPath: "../examples/kubernetes-vmss-spot/kubernetes.json",
+			setArgs:      defaultSet,
+		},
+		{
+			name:         "proximity placement group",
+			apiModelPath: "../examples/kubernetes-proximity-placement-group/kubernetes.json",
+			setArgs:      defaultSet,
+		},
+		{
+			name:         "dedicated hosts",
+			apiModelPath: "../examples/kubernetes-dedicated-hosts/kubernetes.json",
+			setArgs:      defaultSet,
+		},
+		{
+			name:         "ultra ssd",
+			apiModelPath: "../examples/kubernetes-ultra-ssd/kubernetes.json",
+			setArgs:      defaultSet,
+		},
 		{
 			name:         "vmss master",
 			apiModelPath: "../examples/kubernetes-vmss-master/kubernetes.json",
@@ -594,6 +884,11 @@ func TestExampleAPIModels(t *testing.T) {
 			apiModelPath: "../examples/networkpolicy/kubernetes-cilium.json",
 			setArgs:      defaultSet,
 		},
+		{
+			name:         "antrea network policy",
+			apiModelPath: "../examples/networkpolicy/kubernetes-antrea.json",
+			setArgs:      defaultSet,
+		},
 		{
 			name:         "istio",
 			apiModelPath: "../examples/service-mesh/istio.json",
@@ -619,6 +914,11 @@ func TestExampleAPIModels(t *testing.T) {
 			apiModelPath: "../examples/vnet/kubernetesvnet-azure-cni.json",
 			setArgs:      defaultSet,
 		},
+		{
+			name:         "Azure CNI pod subnet custom vnet",
+			apiModelPath: "../examples/vnet/kubernetesvnet-azure-cni-pod-subnet.json",
+			setArgs:      defaultSet,
+		},
 		{
 			name:         "master vmss custom vnet",
 			apiModelPath: "../examples/vnet/kubernetes-master-vmss.json",
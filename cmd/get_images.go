@@ -0,0 +1,171 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/vlabs"
+	"github.com/Azure/aks-engine/pkg/engine"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	getImagesName             = "get-images"
+	getImagesShortDescription = "List the container images a cluster pulls"
+	getImagesLongDescription  = "Lists the container images the Linux nodes of a cluster pull, for the Kubernetes components, the enabled addons and the custom manifests of its api model, e.g. to import them in the registry of an imageRegistry override before deploying the cluster."
+)
+
+type getImagesCmd struct {
+	// user input
+	apimodelPath string
+	outputFormat string
+
+	// derived
+	containerService *api.ContainerService
+	apiVersion       string
+	locale           *gotext.Locale
+	out              io.Writer
+}
+
+// getImagesReport is the JSON output of get-images
+type getImagesReport struct {
+	APIModel string                  `json:"apiModel"`
+	Images   []engine.ContainerImage `json:"images"`
+}
+
+func newGetImagesCmd() *cobra.Command {
+	gic := getImagesCmd{
+		out: os.Stdout,
+	}
+
+	command := &cobra.Command{
+		Use:   getImagesName,
+		Short: getImagesShortDescription,
+		Long:  getImagesLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := gic.validate(cmd, args); err != nil {
+				return errors.Wrap(err, "validating get-images args")
+			}
+			if err := gic.loadAPIModel(); err != nil {
+				return errors.Wrap(err, "loading api model")
+			}
+			return gic.run()
+		},
+	}
+
+	f := command.Flags()
+	f.StringVarP(&gic.apimodelPath, "api-model", "m", "", "path to your cluster definition file")
+	f.StringVarP(&gic.outputFormat, "output", "o", "human", fmt.Sprintf("Output format. Allowed values: %s", strings.Join(outputFormatOptions, ", ")))
+
+	return command
+}
+
+func (gic *getImagesCmd) validate(cmd *cobra.Command, args []string) error {
+	var err error
+
+	gic.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if gic.apimodelPath == "" {
+		if len(args) == 1 {
+			gic.apimodelPath = args[0]
+		} else if len(args) > 1 {
+			cmd.Usage()
+			return errors.New("too many arguments were provided to 'get-images'")
+		} else {
+			cmd.Usage()
+			return errors.New("--api-model was not supplied, nor was one specified as a positional argument")
+		}
+	}
+
+	if _, err = os.Stat(gic.apimodelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", gic.apimodelPath)
+	}
+
+	if gic.outputFormat != "human" && gic.outputFormat != "json" {
+		return errors.Errorf(`output format "%s" is not supported`, gic.outputFormat)
+	}
+
+	return nil
+}
+
+func (gic *getImagesCmd) loadAPIModel() error {
+	contents, err := ioutil.ReadFile(gic.apimodelPath)
+	if err != nil {
+		return errors.Wrapf(err, "reading the api model %s", gic.apimodelPath)
+	}
+	m := &api.TypeMeta{}
+	if err = json.Unmarshal(contents, m); err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+	if m.APIVersion == vlabs.APIVersion {
+		var deprecations []vlabs.Deprecation
+		if contents, deprecations, err = vlabs.MigrateAPIModel(contents); err != nil {
+			return errors.Wrap(err, "error migrating the deprecated fields of the api model")
+		}
+		for _, d := range deprecations {
+			log.Warnf("Deprecated field in the api model: %s", d)
+		}
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: gic.locale,
+		},
+	}
+	gic.containerService, gic.apiVersion, err = apiloader.DeserializeContainerService(contents, true, false, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+	if gic.containerService.Properties == nil || gic.containerService.Properties.OrchestratorProfile == nil || !gic.containerService.Properties.OrchestratorProfile.IsKubernetes() {
+		return errors.New("listing the images of a cluster requires a Kubernetes orchestratorProfile in the api model")
+	}
+	return nil
+}
+
+func (gic *getImagesCmd) run() error {
+	// the images are those of the template generate would write for the api model
+	if _, err := gic.containerService.SetPropertiesDefaults(false, false); err != nil {
+		return errors.Wrapf(err, "in SetPropertiesDefaults template %s", gic.apimodelPath)
+	}
+	if err := engine.ResolveCustomManifests(gic.containerService); err != nil {
+		return errors.Wrap(err, "resolving custom manifests")
+	}
+	images, err := engine.GetImages(gic.containerService)
+	if err != nil {
+		return errors.Wrap(err, "listing the images of the api model")
+	}
+
+	switch gic.outputFormat {
+	case "json":
+		report := getImagesReport{APIModel: gic.apimodelPath, Images: images}
+		if report.Images == nil {
+			report.Images = []engine.ContainerImage{}
+		}
+		b, err := helpers.JSONMarshalIndent(report, "", "  ", false)
+		if err != nil {
+			return errors.Wrap(err, "error encoding report to json")
+		}
+		fmt.Fprintln(gic.out, string(b))
+	default:
+		for _, image := range images {
+			fmt.Fprintln(gic.out, image.Image)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+func TestNewGetImagesCmd(t *testing.T) {
+	output := newGetImagesCmd()
+	if output.Use != getImagesName || output.Short != getImagesShortDescription || output.Long != getImagesLongDescription {
+		t.Fatalf("get-images command should have use %s equal %s, short %s equal %s and long %s equal to %s", output.Use, getImagesName, output.Short, getImagesShortDescription, output.Long, getImagesLongDescription)
+	}
+
+	expectedFlags := []string{"api-model", "output"}
+	for _, f := range expectedFlags {
+		if output.Flags().Lookup(f) == nil {
+			t.Fatalf("get-images command should have flag %s", f)
+		}
+	}
+}
+
+func TestGetImagesCmdValidate(t *testing.T) {
+	cases := []struct {
+		gic         getImagesCmd
+		args        []string
+		expectedErr string
+	}{
+		{
+			expectedErr: "--api-model was not supplied, nor was one specified as a positional argument",
+		},
+		{
+			args:        []string{"../pkg/engine/testdata/simple/kubernetes.json", "../pkg/engine/testdata/vnet/kubernetesvnet.json"},
+			expectedErr: "too many arguments were provided to 'get-images'",
+		},
+		{
+			args:        []string{"../pkg/engine/testdata/simple/missing.json"},
+			expectedErr: "specified api model does not exist (../pkg/engine/testdata/simple/missing.json)",
+		},
+		{
+			gic:         getImagesCmd{apimodelPath: "../pkg/engine/testdata/simple/kubernetes.json", outputFormat: "yaml"},
+			expectedErr: `output format "yaml" is not supported`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		err := c.gic.validate(&cobra.Command{}, c.args)
+		if err == nil || err.Error() != c.expectedErr {
+			t.Errorf("expected error %q, got %v", c.expectedErr, err)
+		}
+	}
+}
+
+func TestGetImagesCmdRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	gic := &getImagesCmd{outputFormat: "human"}
+	out := &bytes.Buffer{}
+	gic.out = out
+	g.Expect(gic.validate(&cobra.Command{}, []string{"../pkg/engine/testdata/simple/kubernetes.json"})).To(Succeed())
+	g.Expect(gic.loadAPIModel()).To(Succeed())
+	g.Expect(gic.run()).To(Succeed())
+	images := strings.Split(strings.TrimSpace(out.String()), "\n")
+	g.Expect(images).To(ContainElement(ContainSubstring("hyperkube")))
+	g.Expect(images).To(ContainElement(ContainSubstring("coredns")))
+
+	gic = &getImagesCmd{apimodelPath: "../pkg/engine/testdata/simple/kubernetes.json", outputFormat: "json"}
+	out = &bytes.Buffer{}
+	gic.out = out
+	g.Expect(gic.validate(&cobra.Command{}, nil)).To(Succeed())
+	g.Expect(gic.loadAPIModel()).To(Succeed())
+	gic.containerService.Properties.OrchestratorProfile.KubernetesConfig.ImageRegistry = "contoso.azurecr.io"
+	g.Expect(gic.run()).To(Succeed())
+
+	var report getImagesReport
+	g.Expect(json.Unmarshal(out.Bytes(), &report)).To(Succeed())
+	g.Expect(report.APIModel).To(Equal("../pkg/engine/testdata/simple/kubernetes.json"))
+	g.Expect(report.Images).To(HaveLen(len(images)))
+	for _, image := range report.Images {
+		g.Expect(image.Image).To(HavePrefix("contoso.azurecr.io/"))
+		g.Expect(images).To(ContainElement(image.Source))
+	}
+}
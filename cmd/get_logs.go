@@ -0,0 +1,342 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/engine"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	getLogsName             = "get-logs"
+	getLogsShortDescription = "Collect logs and current cluster nodes configuration."
+	getLogsLongDescription  = "Collect the provisioning logs, the logs of the Kubernetes components and the configuration of the Linux nodes of a cluster built with AKS Engine, optionally uploading the archive to an Azure Storage container and printing a time-limited SAS URL to download it"
+)
+
+const (
+	defaultLogsStorageContainerName = "aks-engine-logs"
+	defaultLogsSASExpiry            = 24 * time.Hour
+	maxLogsSASExpiry                = 7 * 24 * time.Hour
+	// logsBlockSize is the size of the blocks the logs archive is uploaded in, so that its size is not capped by the
+	// size of a single Put Blob request
+	logsBlockSize = 4 * 1024 * 1024
+)
+
+// collectLinuxLogsCmd collects the logs of a Linux node and writes them to stdout as a base64 encoded tar.gz archive
+const collectLinuxLogsCmd = `sudo bash -c 'D=$(mktemp -d) && cd $D && ` +
+	`for u in kubelet docker containerd etcd; do journalctl --no-pager -u $u > $u.log 2>/dev/null; done; ` +
+	`cp -r /var/log/azure /etc/kubernetes/manifests /etc/kubernetes/addons . 2>/dev/null; ` +
+	`cp /var/log/cloud-init.log /var/log/cloud-init-output.log /var/log/syslog /etc/default/kubelet . 2>/dev/null; ` +
+	`tar -czf - --exclude=*.key --exclude=*.pem . | base64 -w 0; rm -rf $D'`
+
+type getLogsCmd struct {
+	authArgs
+
+	// user input
+	resourceGroupName           string
+	location                    string
+	apiModelPath                string
+	sshFilepath                 string
+	masterFQDN                  string
+	outputDirectory             string
+	storageAccountName          string
+	storageAccountResourceGroup string
+	storageContainerName        string
+	sasExpiry                   time.Duration
+
+	// derived
+	containerService   *api.ContainerService
+	apiVersion         string
+	locale             *gotext.Locale
+	client             armhelpers.AKSEngineClient
+	kubeconfig         string
+	sshConfig          *ssh.ClientConfig
+	sshCommandExecuter func(command, masterFQDN, hostname string, port string, config *ssh.ClientConfig) (string, error)
+	out                io.Writer
+}
+
+func newGetLogsCmd() *cobra.Command {
+	glc := getLogsCmd{
+		sshCommandExecuter: executeCmd,
+		out:                os.Stdout,
+	}
+
+	command := &cobra.Command{
+		Use:   getLogsName,
+		Short: getLogsShortDescription,
+		Long:  getLogsLongDescription,
+		RunE:  glc.run,
+	}
+
+	f := command.Flags()
+	f.StringVarP(&glc.location, "location", "l", "", "location the cluster is deployed in (required)")
+	f.StringVarP(&glc.resourceGroupName, "resource-group", "g", "", "the resource group where the cluster is deployed (required)")
+	f.StringVarP(&glc.apiModelPath, "api-model", "m", "", "path to the generated apimodel.json file (required)")
+	f.StringVar(&glc.sshFilepath, "ssh", "", "the filepath of a valid private ssh key to access the cluster's nodes (required)")
+	f.StringVar(&glc.masterFQDN, "apiserver", "", "apiserver endpoint (required)")
+	f.StringVarP(&glc.outputDirectory, "output-directory", "o", "", "output directory where the logs archive will be saved (derived from DNS prefix if absent)")
+	f.StringVar(&glc.storageAccountName, "storage-account-name", "", "name of an Azure Storage account to upload the logs archive to")
+	f.StringVar(&glc.storageAccountResourceGroup, "storage-account-resource-group", "", "the resource group of the storage account (defaults to --resource-group)")
+	f.StringVar(&glc.storageContainerName, "storage-container-name", defaultLogsStorageContainerName, "the storage container to upload the logs archive to, created if it does not exist")
+	f.DurationVar(&glc.sasExpiry, "sas-expiry", defaultLogsSASExpiry, "how long the SAS URL of the uploaded logs archive is valid for, at most 168h")
+
+	addAuthFlags(&glc.authArgs, f)
+
+	return command
+}
+
+func (glc *getLogsCmd) validate(cmd *cobra.Command) error {
+	log.Debugln("validating get-logs command line arguments...")
+	var err error
+
+	glc.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if glc.resourceGroupName == "" {
+		cmd.Usage()
+		return errors.New("--resource-group must be specified")
+	}
+
+	if glc.location == "" {
+		cmd.Usage()
+		return errors.New("--location must be specified")
+	}
+	glc.location = helpers.NormalizeAzureRegion(glc.location)
+
+	if glc.apiModelPath == "" {
+		cmd.Usage()
+		return errors.New("--api-model must be specified")
+	}
+
+	if glc.sshFilepath == "" {
+		cmd.Usage()
+		return errors.New("--ssh must be specified")
+	}
+
+	if glc.masterFQDN == "" {
+		cmd.Usage()
+		return errors.New("--apiserver must be specified")
+	}
+	glc.masterFQDN = strings.TrimPrefix(glc.masterFQDN, "https://")
+
+	if glc.storageAccountName == "" {
+		if glc.storageAccountResourceGroup != "" {
+			return errors.New("--storage-account-resource-group requires --storage-account-name")
+		}
+		return nil
+	}
+	if glc.storageContainerName == "" {
+		return errors.New("--storage-container-name must not be empty when uploading the logs")
+	}
+	if glc.sasExpiry <= 0 || glc.sasExpiry > maxLogsSASExpiry {
+		return errors.Errorf("--sas-expiry must be greater than 0 and at most %s", maxLogsSASExpiry)
+	}
+	if glc.storageAccountResourceGroup == "" {
+		glc.storageAccountResourceGroup = glc.resourceGroupName
+	}
+	return nil
+}
+
+func (glc *getLogsCmd) load() error {
+	var err error
+
+	if _, err = os.Stat(glc.apiModelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", glc.apiModelPath)
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: glc.locale,
+		},
+	}
+	glc.containerService, glc.apiVersion, err = apiloader.LoadContainerServiceFromFile(glc.apiModelPath, true, false, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+
+	if glc.containerService.Properties.MasterProfile == nil {
+		return errors.New("get-logs requires a cluster with a master profile")
+	}
+
+	if glc.outputDirectory == "" {
+		glc.outputDirectory = path.Join("_output", glc.containerService.Properties.MasterProfile.DNSPrefix)
+	}
+
+	glc.kubeconfig, err = engine.GenerateKubeConfig(glc.containerService.Properties, glc.location)
+	if err != nil {
+		return errors.New("Unable to derive kubeconfig from api model")
+	}
+
+	if _, err = os.Stat(glc.sshFilepath); os.IsNotExist(err) {
+		return errors.Errorf("specified ssh filepath does not exist (%s)", glc.sshFilepath)
+	}
+	glc.sshConfig = &ssh.ClientConfig{
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		User:            glc.containerService.Properties.LinuxProfile.AdminUsername,
+		Auth: []ssh.AuthMethod{
+			publicKeyFile(glc.sshFilepath),
+		},
+	}
+
+	if err = glc.authArgs.validateAuthArgs(); err != nil {
+		return err
+	}
+
+	if glc.client, err = glc.authArgs.getClient(); err != nil {
+		return errors.Wrap(err, "failed to get client")
+	}
+	return nil
+}
+
+func (glc *getLogsCmd) run(cmd *cobra.Command, args []string) error {
+	if err := glc.validate(cmd); err != nil {
+		return errors.Wrap(err, "failed to validate get-logs command")
+	}
+	if err := glc.load(); err != nil {
+		return errors.Wrap(err, "failed to load existing container service")
+	}
+
+	archive, err := glc.collectLogs()
+	if err != nil {
+		return errors.Wrap(err, "collecting the logs")
+	}
+
+	archiveName := fmt.Sprintf("%s-logs-%s.tar.gz", glc.resourceGroupName, time.Now().UTC().Format("20060102150405"))
+	if err = os.MkdirAll(glc.outputDirectory, 0700); err != nil {
+		return errors.Wrapf(err, "creating output directory %s", glc.outputDirectory)
+	}
+	archivePath := filepath.Join(glc.outputDirectory, archiveName)
+	if err = ioutil.WriteFile(archivePath, archive, 0600); err != nil {
+		return errors.Wrapf(err, "writing the logs archive %s", archivePath)
+	}
+	log.Infof("Logs archive saved to %s", archivePath)
+
+	if glc.storageAccountName == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	return errors.Wrap(glc.uploadLogs(ctx, archiveName, archive), "uploading the logs archive")
+}
+
+// collectLogs collects the logs of the Linux nodes of the cluster and returns a tar.gz archive holding the archive of
+// each node. The Windows nodes are skipped.
+func (glc *getLogsCmd) collectLogs() ([]byte, error) {
+	kubeClient, err := glc.client.GetKubernetesClient("", glc.kubeconfig, time.Second*1, time.Duration(60)*time.Minute)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get a Kubernetes client")
+	}
+	nodeList, err := kubeClient.ListNodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cluster nodes")
+	}
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, node := range nodeList.Items {
+		if isWindowsNode(node) {
+			log.Warnf("Skipping Windows node %s, collecting the logs of Windows nodes is not supported", node.Name)
+			continue
+		}
+		log.Infof("Collecting the logs of node %s", node.Name)
+		out, err := glc.sshCommandExecuter(collectLinuxLogsCmd, glc.masterFQDN, node.Name, "22", glc.sshConfig)
+		if err != nil {
+			log.Printf("Command output: %s\n", out)
+			return nil, errors.Wrapf(err, "failed to collect the logs of node %s", node.Name)
+		}
+		nodeArchive, err := base64.StdEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(out, node.Name+" -> ")))
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding the logs archive of node %s", node.Name)
+		}
+		header := &tar.Header{
+			Name:    node.Name + ".tar.gz",
+			Mode:    0600,
+			Size:    int64(len(nodeArchive)),
+			ModTime: time.Now(),
+		}
+		if err = tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err = tw.Write(nodeArchive); err != nil {
+			return nil, err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return nil, err
+	}
+	if err = gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// uploadLogs uploads the logs archive to the storage container and prints a read-only SAS URL of the archive
+func (glc *getLogsCmd) uploadLogs(ctx context.Context, blobName string, archive []byte) error {
+	storageClient, err := glc.client.GetStorageClient(ctx, glc.storageAccountResourceGroup, glc.storageAccountName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get a client for storage account %s", glc.storageAccountName)
+	}
+	if _, err = storageClient.CreateContainer(glc.storageContainerName, nil); err != nil {
+		return errors.Wrapf(err, "failed to create storage container %s", glc.storageContainerName)
+	}
+	if err = uploadBlockBlob(storageClient, glc.storageContainerName, blobName, archive, logsBlockSize); err != nil {
+		return errors.Wrapf(err, "failed to upload blob %s", blobName)
+	}
+	sasURI, err := storageClient.GetBlobSASURI(glc.storageContainerName, blobName, time.Now().Add(glc.sasExpiry))
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate a SAS URL for blob %s", blobName)
+	}
+	log.Infof("Logs archive uploaded to container %s of storage account %s, the SAS URL below expires in %s", glc.storageContainerName, glc.storageAccountName, glc.sasExpiry)
+	fmt.Fprintln(glc.out, sasURI)
+	return nil
+}
+
+// uploadBlockBlob uploads b to a block blob in blocks of blockSize bytes, and commits them
+func uploadBlockBlob(storageClient armhelpers.AKSStorageClient, containerName, blobName string, b []byte, blockSize int) error {
+	var blockIDs []string
+	for offset := 0; offset < len(b); offset += blockSize {
+		end := offset + blockSize
+		if end > len(b) {
+			end = len(b)
+		}
+		// the IDs of the blocks of a blob must be base64 encoded strings of the same length
+		blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%08d", len(blockIDs))))
+		if err := storageClient.PutBlock(containerName, blobName, blockID, b[offset:end]); err != nil {
+			return errors.Wrapf(err, "uploading block %d", len(blockIDs))
+		}
+		blockIDs = append(blockIDs, blockID)
+	}
+	return storageClient.PutBlockList(containerName, blobName, blockIDs)
+}
+
+func isWindowsNode(node v1.Node) bool {
+	return strings.EqualFold(node.Status.NodeInfo.OperatingSystem, "windows") || strings.EqualFold(node.Labels["beta.kubernetes.io/os"], "windows")
+}
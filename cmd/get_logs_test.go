@@ -0,0 +1,245 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestNewGetLogsCmd(t *testing.T) {
+	command := newGetLogsCmd()
+	if command.Use != getLogsName || command.Short != getLogsShortDescription || command.Long != getLogsLongDescription {
+		t.Fatalf("get-logs command should have use %s equal %s, short %s equal %s and long %s equal to %s", command.Use, getLogsName, command.Short, getLogsShortDescription, command.Long, getLogsLongDescription)
+	}
+
+	expectedFlags := []string{"location", "resource-group", "api-model", "ssh", "apiserver", "output-directory", "storage-account-name", "storage-account-resource-group", "storage-container-name", "sas-expiry"}
+	for _, f := range expectedFlags {
+		if command.Flags().Lookup(f) == nil {
+			t.Fatalf("get-logs command should have flag %s", f)
+		}
+	}
+
+	command.SetArgs([]string{})
+	if err := command.Execute(); err == nil {
+		t.Fatalf("expected an error when calling get-logs with no arguments")
+	}
+}
+
+func TestGetLogsCmdValidate(t *testing.T) {
+	newGetLogs := func() *getLogsCmd {
+		return &getLogsCmd{
+			resourceGroupName:    "testRG",
+			location:             "centralus",
+			apiModelPath:         "apimodel.json",
+			sshFilepath:          "id_rsa",
+			masterFQDN:           "example.com",
+			storageContainerName: defaultLogsStorageContainerName,
+			sasExpiry:            defaultLogsSASExpiry,
+		}
+	}
+	cases := []struct {
+		name               string
+		setup              func(*getLogsCmd)
+		expectedErr        string
+		expectedMasterFQDN string
+		expectedStorageRG  string
+	}{
+		{name: "no resource group", setup: func(glc *getLogsCmd) { glc.resourceGroupName = "" }, expectedErr: "--resource-group must be specified"},
+		{name: "no location", setup: func(glc *getLogsCmd) { glc.location = "" }, expectedErr: "--location must be specified"},
+		{name: "no api model", setup: func(glc *getLogsCmd) { glc.apiModelPath = "" }, expectedErr: "--api-model must be specified"},
+		{name: "no ssh key", setup: func(glc *getLogsCmd) { glc.sshFilepath = "" }, expectedErr: "--ssh must be specified"},
+		{name: "no apiserver", setup: func(glc *getLogsCmd) { glc.masterFQDN = "" }, expectedErr: "--apiserver must be specified"},
+		{
+			name:        "storage resource group without storage account",
+			setup:       func(glc *getLogsCmd) { glc.storageAccountResourceGroup = "logsRG" },
+			expectedErr: "--storage-account-resource-group requires --storage-account-name",
+		},
+		{
+			name: "no storage container",
+			setup: func(glc *getLogsCmd) {
+				glc.storageAccountName = "logs"
+				glc.storageContainerName = ""
+			},
+			expectedErr: "--storage-container-name must not be empty when uploading the logs",
+		},
+		{
+			name: "SAS expiry too long",
+			setup: func(glc *getLogsCmd) {
+				glc.storageAccountName = "logs"
+				glc.sasExpiry = 30 * 24 * time.Hour
+			},
+			expectedErr: "--sas-expiry must be greater than 0 and at most 168h0m0s",
+		},
+		{name: "no upload", expectedMasterFQDN: "example.com"},
+		{
+			name:               "upload to the cluster resource group",
+			setup:              func(glc *getLogsCmd) { glc.storageAccountName = "logs"; glc.masterFQDN = "https://example.com" },
+			expectedMasterFQDN: "example.com",
+			expectedStorageRG:  "testRG",
+		},
+		{
+			name:               "upload to another resource group",
+			setup:              func(glc *getLogsCmd) { glc.storageAccountName = "logs"; glc.storageAccountResourceGroup = "logsRG" },
+			expectedMasterFQDN: "example.com",
+			expectedStorageRG:  "logsRG",
+		},
+	}
+	for _, c := range cases {
+		glc := newGetLogs()
+		if c.setup != nil {
+			c.setup(glc)
+		}
+		err := glc.validate(&cobra.Command{})
+		if c.expectedErr == "" {
+			if err != nil {
+				t.Fatalf("%s: expected validate get-logs command to return no error, but instead got %s", c.name, err.Error())
+			}
+			if glc.masterFQDN != c.expectedMasterFQDN || glc.storageAccountResourceGroup != c.expectedStorageRG {
+				t.Fatalf("%s: expected validate get-logs command to set the apiserver %s and storage resource group %s, but instead got %s and %s", c.name, c.expectedMasterFQDN, c.expectedStorageRG, glc.masterFQDN, glc.storageAccountResourceGroup)
+			}
+		} else if err == nil || err.Error() != c.expectedErr {
+			t.Fatalf("%s: expected validate get-logs command to return error %s, but instead got %v", c.name, c.expectedErr, err)
+		}
+	}
+}
+
+func TestGetLogsCollectLogs(t *testing.T) {
+	windowsNode := v1.Node{}
+	windowsNode.Name = "2080k8s000"
+	windowsNode.Status.NodeInfo.OperatingSystem = "windows"
+	linuxNode := v1.Node{}
+	linuxNode.Name = "k8s-agentpool1-12345678-0"
+	linuxNode.Status.NodeInfo.OperatingSystem = "linux"
+	client := &armhelpers.MockAKSEngineClient{MockKubernetesClient: &armhelpers.MockKubernetesClient{
+		NodeList: &v1.NodeList{Items: []v1.Node{linuxNode, windowsNode}},
+	}}
+
+	var collected []string
+	glc := &getLogsCmd{
+		client:     client,
+		masterFQDN: "valid",
+		sshCommandExecuter: func(command, masterFQDN, hostname string, port string, config *ssh.ClientConfig) (string, error) {
+			if masterFQDN != "valid" {
+				return "", errors.New("executeCmd failed")
+			}
+			collected = append(collected, hostname)
+			return hostname + " -> " + base64.StdEncoding.EncodeToString([]byte("logs of "+hostname)), nil
+		},
+	}
+	archive, err := glc.collectLogs()
+	if err != nil {
+		t.Fatalf("expected collecting the logs to return no error, but instead got %s", err)
+	}
+	if len(collected) != 1 || collected[0] != linuxNode.Name {
+		t.Fatalf("expected the logs of the Linux node only to be collected, but instead got %v", collected)
+	}
+
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	header, err := tr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contents bytes.Buffer
+	if _, err = io.Copy(&contents, tr); err != nil {
+		t.Fatal(err)
+	}
+	if header.Name != linuxNode.Name+".tar.gz" || contents.String() != "logs of "+linuxNode.Name {
+		t.Fatalf("expected the archive to hold the logs of node %s, but instead got %s: %s", linuxNode.Name, header.Name, contents.String())
+	}
+	if _, err = tr.Next(); err != io.EOF {
+		t.Fatalf("expected the archive to hold the logs of a single node")
+	}
+
+	glc.masterFQDN = "invalid"
+	if _, err = glc.collectLogs(); err == nil || !strings.Contains(err.Error(), "failed to collect the logs of node "+linuxNode.Name) {
+		t.Fatalf("expected collecting the logs to fail when the node is unreachable, but instead got %v", err)
+	}
+
+	client.MockKubernetesClient.FailListNodes = true
+	if _, err = glc.collectLogs(); err == nil {
+		t.Fatalf("expected collecting the logs to fail when the nodes cannot be listed")
+	}
+}
+
+func TestGetLogsUploadLogs(t *testing.T) {
+	storageClient := &armhelpers.MockStorageClient{}
+	client := &armhelpers.MockAKSEngineClient{MockStorageClient: storageClient}
+	var out bytes.Buffer
+	glc := &getLogsCmd{
+		client:                      client,
+		storageAccountName:          "logs",
+		storageAccountResourceGroup: "testRG",
+		storageContainerName:        defaultLogsStorageContainerName,
+		sasExpiry:                   time.Hour,
+		out:                         &out,
+	}
+	if err := glc.uploadLogs(context.Background(), "testRG-logs.tar.gz", []byte("logs")); err != nil {
+		t.Fatalf("expected uploading the logs to return no error, but instead got %s", err)
+	}
+	sasURI := out.String()
+	if !strings.HasPrefix(sasURI, "https://") || !strings.Contains(sasURI, "/"+defaultLogsStorageContainerName+"/testRG-logs.tar.gz?") || !strings.HasSuffix(sasURI, "\n") {
+		t.Fatalf("expected the SAS URL of the uploaded logs archive to be printed, but instead got %s", sasURI)
+	}
+	if blob := storageClient.Blobs[defaultLogsStorageContainerName+"/testRG-logs.tar.gz"]; string(blob) != "logs" {
+		t.Fatalf("expected the logs archive to be uploaded, but instead got %q", string(blob))
+	}
+
+	storageClient.FailPutBlock = true
+	if err := glc.uploadLogs(context.Background(), "testRG-logs.tar.gz", []byte("logs")); err == nil {
+		t.Fatalf("expected uploading the logs to fail when a block cannot be uploaded")
+	}
+
+	client.FailGetStorageClient = true
+	if err := glc.uploadLogs(context.Background(), "testRG-logs.tar.gz", []byte("logs")); err == nil {
+		t.Fatalf("expected uploading the logs to fail when the storage account client cannot be created")
+	}
+}
+
+func TestUploadBlockBlob(t *testing.T) {
+	cases := []struct {
+		name           string
+		content        string
+		expectedBlocks int
+	}{
+		{name: "empty", content: "", expectedBlocks: 0},
+		{name: "single block", content: "abc", expectedBlocks: 1},
+		{name: "full blocks", content: "abcdef", expectedBlocks: 2},
+		{name: "last block partial", content: "abcdefg", expectedBlocks: 3},
+	}
+	for _, c := range cases {
+		storageClient := &armhelpers.MockStorageClient{}
+		if err := uploadBlockBlob(storageClient, "logs", "archive.tar.gz", []byte(c.content), 3); err != nil {
+			t.Fatalf("%s: expected uploading the blob to return no error, but instead got %s", c.name, err)
+		}
+		if len(storageClient.Blocks) != c.expectedBlocks {
+			t.Fatalf("%s: expected the blob to be uploaded in %d blocks, but instead got %d", c.name, c.expectedBlocks, len(storageClient.Blocks))
+		}
+		if blob, ok := storageClient.Blobs["logs/archive.tar.gz"]; !ok || string(blob) != c.content {
+			t.Fatalf("%s: expected the blob %q to be committed, but instead got %q", c.name, c.content, string(blob))
+		}
+	}
+
+	storageClient := &armhelpers.MockStorageClient{FailPutBlockList: true}
+	if err := uploadBlockBlob(storageClient, "logs", "archive.tar.gz", []byte("abc"), 3); err == nil {
+		t.Fatalf("expected uploading the blob to fail when the blocks cannot be committed")
+	}
+}
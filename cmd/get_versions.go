@@ -12,7 +12,9 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/vlabs"
 	"github.com/Azure/aks-engine/pkg/helpers"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -28,6 +30,20 @@ type getVersionsCmd struct {
 	version      string
 	windows      bool
 	output       string
+	azureEnv     string
+	imageBase    string
+}
+
+// cloudVersionProfileList is the JSON output of get-versions for a cloud environment
+type cloudVersionProfileList struct {
+	CloudName     string                 `json:"cloudName"`
+	Orchestrators []*cloudVersionProfile `json:"orchestrators"`
+}
+
+// cloudVersionProfile is a Kubernetes version available in a cloud environment
+type cloudVersionProfile struct {
+	*vlabs.OrchestratorVersionProfile
+	Availability *api.KubernetesVersionAvailability `json:"availability"`
 }
 
 func newGetVersionsCmd() *cobra.Command {
@@ -47,6 +63,8 @@ func newGetVersionsCmd() *cobra.Command {
 	getVersionsCmdDescription := fmt.Sprintf("Output format. Allowed values: %s",
 		strings.Join(outputFormatOptions, ", "))
 	f.StringVarP(&gvc.output, "output", "o", "human", getVersionsCmdDescription)
+	f.StringVar(&gvc.azureEnv, "azure-env", "", fmt.Sprintf("only list the versions whose images are available in the cloud environment, one of %s (optional)", strings.Join(api.GetCloudNames(), ", ")))
+	f.StringVar(&gvc.imageBase, "kubernetes-image-base", "", "the registry to look up the hyperkube images in, instead of the default of --azure-env (optional)")
 
 	return command
 }
@@ -57,9 +75,23 @@ func (gvc *getVersionsCmd) run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	var cloudOrchs *cloudVersionProfileList
+	if gvc.azureEnv != "" {
+		if cloudOrchs, err = gvc.filterByCloud(orchs); err != nil {
+			return err
+		}
+	} else if gvc.imageBase != "" {
+		return errors.New("--kubernetes-image-base requires --azure-env")
+	}
+
 	switch gvc.output {
 	case "json":
-		data, err := helpers.JSONMarshalIndent(orchs, "", "  ", false)
+		var data []byte
+		if cloudOrchs != nil {
+			data, err = helpers.JSONMarshalIndent(cloudOrchs, "", "  ", false)
+		} else {
+			data, err = helpers.JSONMarshalIndent(orchs, "", "  ", false)
+		}
 		if err != nil {
 			return err
 		}
@@ -89,3 +121,52 @@ func (gvc *getVersionsCmd) run(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// filterByCloud removes the versions and the upgrades whose hyperkube image is not available in the cloud environment
+// from orchs, and returns the remaining versions with their availability
+func (gvc *getVersionsCmd) filterByCloud(orchs *vlabs.OrchestratorVersionProfileList) (*cloudVersionProfileList, error) {
+	versions := []string{}
+	seen := map[string]bool{}
+	for _, o := range orchs.Orchestrators {
+		for _, v := range append([]*vlabs.OrchestratorProfile{&o.OrchestratorProfile}, o.Upgrades...) {
+			if !seen[v.OrchestratorVersion] {
+				seen[v.OrchestratorVersion] = true
+				versions = append(versions, v.OrchestratorVersion)
+			}
+		}
+	}
+	availability, err := api.GetKubernetesVersionsAvailability(gvc.azureEnv, gvc.imageBase, versions, gvc.windows)
+	if err != nil {
+		return nil, err
+	}
+
+	cloudOrchs := &cloudVersionProfileList{
+		CloudName:     gvc.azureEnv,
+		Orchestrators: []*cloudVersionProfile{},
+	}
+	filtered := []*vlabs.OrchestratorVersionProfile{}
+	for _, o := range orchs.Orchestrators {
+		a := availability[o.OrchestratorVersion]
+		if !a.HyperkubeImageAvailable {
+			log.Warnf("Kubernetes %s is not available in %s, image %s was not found", o.OrchestratorVersion, gvc.azureEnv, a.HyperkubeImage)
+			continue
+		}
+		upgrades := []*vlabs.OrchestratorProfile{}
+		for _, u := range o.Upgrades {
+			if availability[u.OrchestratorVersion].HyperkubeImageAvailable {
+				upgrades = append(upgrades, u)
+			}
+		}
+		o.Upgrades = upgrades
+		filtered = append(filtered, o)
+		cloudOrchs.Orchestrators = append(cloudOrchs.Orchestrators, &cloudVersionProfile{
+			OrchestratorVersionProfile: o,
+			Availability:               a,
+		})
+	}
+	if len(filtered) == 0 {
+		return nil, errors.Errorf("no Kubernetes version is available in %s, check that the registry of the hyperkube images is reachable", gvc.azureEnv)
+	}
+	orchs.Orchestrators = filtered
+	return cloudOrchs, nil
+}
@@ -4,6 +4,10 @@
 package cmd
 
 import (
+	"encoding/json"
+
+	"github.com/Azure/aks-engine/pkg/api/persona"
+	"github.com/Azure/aks-engine/pkg/api/vlabs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -53,4 +57,101 @@ var _ = Describe("The get-versions command", func() {
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(Equal("output format \"yaml\" is not supported"))
 	})
+
+	Context("with an Azure environment", func() {
+		var current persona.Persona
+
+		BeforeEach(func() {
+			current = persona.Current()
+			persona.Set(persona.NewOffline(&persona.CapabilitiesFile{
+				Documents: map[string]json.RawMessage{
+					"https://k8s.gcr.io/v2/hyperkube-amd64/manifests/v1.13.3":  json.RawMessage(`{}`),
+					"https://k8s.gcr.io/v2/hyperkube-amd64/manifests/v1.14.6":  json.RawMessage(`{}`),
+					"https://k8s.gcr.io/v2/hyperkube-amd64/manifests/v1.13.10": json.RawMessage(`{}`),
+				},
+			}))
+		})
+
+		AfterEach(func() {
+			persona.Set(current)
+		})
+
+		It("should only list the versions and upgrades available in the cloud", func() {
+			command := &getVersionsCmd{
+				orchestrator: "kubernetes",
+				version:      "1.13.3",
+				azureEnv:     "AzurePublicCloud",
+			}
+			orchs := &vlabs.OrchestratorVersionProfileList{
+				Orchestrators: []*vlabs.OrchestratorVersionProfile{
+					{
+						OrchestratorProfile: vlabs.OrchestratorProfile{OrchestratorVersion: "1.13.3"},
+						Upgrades: []*vlabs.OrchestratorProfile{
+							{OrchestratorVersion: "1.13.10"},
+							{OrchestratorVersion: "1.13.9"},
+							{OrchestratorVersion: "1.14.6"},
+						},
+					},
+					{
+						OrchestratorProfile: vlabs.OrchestratorProfile{OrchestratorVersion: "1.13.9"},
+					},
+				},
+			}
+			cloudOrchs, err := command.filterByCloud(orchs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cloudOrchs.CloudName).To(Equal("AzurePublicCloud"))
+			Expect(cloudOrchs.Orchestrators).To(HaveLen(1))
+			Expect(cloudOrchs.Orchestrators[0].Availability.HyperkubeImage).To(Equal("k8s.gcr.io/hyperkube-amd64:v1.13.3"))
+			Expect(orchs.Orchestrators).To(HaveLen(1))
+			Expect(orchs.Orchestrators[0].Upgrades).To(HaveLen(2))
+			Expect(orchs.Orchestrators[0].Upgrades[0].OrchestratorVersion).To(Equal("1.13.10"))
+			Expect(orchs.Orchestrators[0].Upgrades[1].OrchestratorVersion).To(Equal("1.14.6"))
+		})
+
+		It("should support JSON output", func() {
+			command := &getVersionsCmd{
+				orchestrator: "kubernetes",
+				version:      "1.13.3",
+				output:       "json",
+				azureEnv:     "AzurePublicCloud",
+			}
+			err := command.run(nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should error when no version is available in the cloud", func() {
+			command := &getVersionsCmd{
+				orchestrator: "kubernetes",
+				version:      "1.13.3",
+				output:       "human",
+				azureEnv:     "AzureChinaCloud",
+			}
+			err := command.run(nil, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no Kubernetes version is available in AzureChinaCloud"))
+		})
+
+		It("should error on an unknown cloud", func() {
+			command := &getVersionsCmd{
+				orchestrator: "kubernetes",
+				version:      "1.13.3",
+				output:       "human",
+				azureEnv:     "AzureMoonCloud",
+			}
+			err := command.run(nil, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unknown cloud environment AzureMoonCloud"))
+		})
+
+		It("should require an Azure environment for the image base", func() {
+			command := &getVersionsCmd{
+				orchestrator: "kubernetes",
+				output:       "human",
+				imageBase:    "myregistry.azurecr.io/",
+			}
+			err := command.run(nil, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("--kubernetes-image-base requires --azure-env"))
+		})
+	})
 })
@@ -0,0 +1,315 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/persona"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/pkg/errors"
+)
+
+// regionalCoresQuota is the name of the compute usage of the vCPUs of all the VM families of a location
+const regionalCoresQuota = "cores"
+
+// preflightProblem is a profile of the cluster the subscription cannot deploy as it is, because its VM size is not
+// offered in the location or its availability zones, its VM size cannot hold its ephemeral OS disk, or the vCPU quota
+// left for the VM family is too low
+type preflightProblem struct {
+	// Path is the JSON path of the profile in the api model, empty for a problem of the whole cluster
+	Path    string
+	Profile string
+	VMSize  string
+	Reason  string
+	// Fallback is the first VM size of the preferences, equivalent to VMSize, the profile can be deployed with,
+	// empty if there is none
+	Fallback string
+	// quotaFamily is the VM family whose quota is exceeded, empty if the VM size is unavailable
+	quotaFamily string
+}
+
+func (p preflightProblem) String() string {
+	s := fmt.Sprintf("%s: %s", p.Profile, p.Reason)
+	if p.Fallback != "" {
+		s += fmt.Sprintf(", VM size %s can be used instead", p.Fallback)
+	}
+	return s
+}
+
+// vmSizeInfo are the capabilities of a VM size that matter to pick an equivalent size and to count its quota
+type vmSizeInfo struct {
+	family    string
+	vCPUs     int64
+	memoryGB  float64
+	premiumIO bool
+}
+
+// preflightProfile is a profile of the cluster with a VM size
+type preflightProfile struct {
+	path    string
+	name    string
+	vmSize  string
+	zones   []string
+	count   int
+	setSize func(string)
+	// ephemeralOSDiskGB is the size of the ephemeral OS disk of the profile, 0 if its OS disk is not ephemeral
+	ephemeralOSDiskGB int
+}
+
+// skuPreflight checks the VM sizes of a cluster against the resource SKUs and the compute quotas of a subscription in
+// a location
+type skuPreflight struct {
+	location     string
+	capabilities persona.LocationCapabilities
+	sizes        map[string]vmSizeInfo
+	// available is the quota left, by lower case compute usage name
+	available map[string]int64
+}
+
+func newSKUPreflight(skus []compute.ResourceSku, usages []compute.Usage, location string) *skuPreflight {
+	p := &skuPreflight{
+		location:     location,
+		capabilities: locationCapabilitiesOf(skus, location),
+		sizes:        map[string]vmSizeInfo{},
+		available:    map[string]int64{},
+	}
+	for _, sku := range skus {
+		if sku.ResourceType == nil || *sku.ResourceType != "virtualMachines" || sku.Name == nil {
+			continue
+		}
+		info := vmSizeInfo{}
+		if sku.Family != nil {
+			info.family = strings.ToLower(*sku.Family)
+		}
+		if sku.Capabilities != nil {
+			for _, c := range *sku.Capabilities {
+				if c.Name == nil || c.Value == nil {
+					continue
+				}
+				switch *c.Name {
+				case "vCPUs":
+					info.vCPUs, _ = strconv.ParseInt(*c.Value, 10, 64)
+				case "MemoryGB":
+					info.memoryGB, _ = strconv.ParseFloat(*c.Value, 64)
+				case "PremiumIO":
+					info.premiumIO = strings.EqualFold(*c.Value, "True")
+				}
+			}
+		}
+		p.sizes[strings.ToLower(*sku.Name)] = info
+	}
+	for _, u := range usages {
+		if u.Name == nil || u.Name.Value == nil || u.Limit == nil {
+			continue
+		}
+		var current int64
+		if u.CurrentValue != nil {
+			current = int64(*u.CurrentValue)
+		}
+		p.available[strings.ToLower(*u.Name.Value)] = *u.Limit - current
+	}
+	return p
+}
+
+// check returns the problems of the profiles of cs, in the order of the profiles in the api model, with the first
+// equivalent VM size of preferences each profile can fall back to
+func (p *skuPreflight) check(cs *api.ContainerService, preferences []string) ([]preflightProblem, error) {
+	profiles := preflightProfiles(cs)
+
+	capabilityErrors, err := cs.CapabilityErrors(persona.NewOffline(&persona.CapabilitiesFile{
+		Locations: map[string]persona.LocationCapabilities{p.location: p.capabilities},
+	}))
+	if err != nil {
+		return nil, errors.Wrap(err, "validating the VM sizes against the resource SKUs of the location")
+	}
+	unavailable := map[string]string{}
+	for _, e := range capabilityErrors {
+		unavailable[e.Path] = e.Err.Error()
+	}
+
+	// demand is the vCPUs the cluster needs, by lower case compute usage name
+	demand := map[string]int64{}
+	for _, profile := range profiles {
+		p.addDemand(demand, profile.vmSize, profile.count)
+	}
+
+	var problems []preflightProblem
+	for _, profile := range profiles {
+		reason, ok := unavailable[profile.path]
+		if !ok {
+			info, known := p.sizes[strings.ToLower(profile.vmSize)]
+			if !known || !p.exceedsQuota(demand, info.family) {
+				continue
+			}
+			reason = fmt.Sprintf("VM size %s needs %d vCPUs of VM family %s for the cluster, %d are left of the quota in location %s",
+				profile.vmSize, demand[info.family], info.family, p.available[info.family], p.location)
+		}
+		problem := preflightProblem{Path: profile.path, Profile: profile.name, VMSize: profile.vmSize, Reason: reason}
+		if !ok {
+			problem.quotaFamily = p.sizes[strings.ToLower(profile.vmSize)].family
+		}
+		if fallback := p.fallback(profile, preferences, demand); fallback != "" {
+			problem.Fallback = fallback
+			// the profiles left are checked with the demand of the fallback, which may leave enough quota to their family
+			p.addDemand(demand, profile.vmSize, -profile.count)
+			p.addDemand(demand, fallback, profile.count)
+		}
+		problems = append(problems, problem)
+	}
+
+	// the fallbacks of later profiles may have left enough quota to the family of an earlier profile
+	resolved := problems[:0]
+	for _, problem := range problems {
+		if problem.quotaFamily == "" || problem.Fallback != "" || p.exceedsQuota(demand, problem.quotaFamily) {
+			resolved = append(resolved, problem)
+		}
+	}
+	problems = resolved
+
+	if p.exceedsQuota(demand, regionalCoresQuota) {
+		problems = append(problems, preflightProblem{
+			Profile: "cluster",
+			Reason:  fmt.Sprintf("the cluster needs %d vCPUs, %d are left of the regional vCPU quota in location %s", demand[regionalCoresQuota], p.available[regionalCoresQuota], p.location),
+		})
+	}
+	return problems, nil
+}
+
+// fallback returns the first VM size of preferences with at least the vCPUs, memory and premium storage support of
+// the VM size of profile, offered in the location and the availability zones of profile, with a cache large enough
+// for the ephemeral OS disk of profile, and within the quota left
+func (p *skuPreflight) fallback(profile preflightProfile, preferences []string, demand map[string]int64) string {
+	current, known := p.sizes[strings.ToLower(profile.vmSize)]
+	for _, candidate := range preferences {
+		if strings.EqualFold(candidate, profile.vmSize) || !p.capabilities.HasVMSize(candidate) {
+			continue
+		}
+		info, ok := p.sizes[strings.ToLower(candidate)]
+		if !ok {
+			continue
+		}
+		if known && (info.vCPUs < current.vCPUs || info.memoryGB < current.memoryGB || (current.premiumIO && !info.premiumIO)) {
+			continue
+		}
+		if !p.offeredInZones(candidate, profile.zones) {
+			continue
+		}
+		if cacheGB, ok := p.capabilities.EphemeralOSDiskCacheGBOf(candidate); ok && cacheGB < profile.ephemeralOSDiskGB {
+			continue
+		}
+		candidateDemand := map[string]int64{}
+		for k, v := range demand {
+			candidateDemand[k] = v
+		}
+		p.addDemand(candidateDemand, profile.vmSize, -profile.count)
+		p.addDemand(candidateDemand, candidate, profile.count)
+		if p.exceedsQuota(candidateDemand, info.family) || p.exceedsQuota(candidateDemand, regionalCoresQuota) {
+			continue
+		}
+		return candidate
+	}
+	return ""
+}
+
+func (p *skuPreflight) offeredInZones(vmSize string, zones []string) bool {
+	available, ok := p.capabilities.ZonesOf(vmSize)
+	if !ok {
+		return true
+	}
+	for _, zone := range zones {
+		found := false
+		for _, z := range available {
+			if z == zone {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// addDemand adds the vCPUs of count VMs of vmSize to the demand of its family and to the regional demand
+func (p *skuPreflight) addDemand(demand map[string]int64, vmSize string, count int) {
+	info, ok := p.sizes[strings.ToLower(vmSize)]
+	if !ok {
+		return
+	}
+	vCPUs := info.vCPUs * int64(count)
+	if info.family != "" {
+		demand[info.family] += vCPUs
+	}
+	demand[regionalCoresQuota] += vCPUs
+}
+
+// exceedsQuota returns true if the demand of a compute usage is more than the quota left, quotas that the
+// subscription does not report are not checked
+func (p *skuPreflight) exceedsQuota(demand map[string]int64, usage string) bool {
+	available, ok := p.available[usage]
+	return ok && demand[usage] > available
+}
+
+func preflightProfiles(cs *api.ContainerService) []preflightProfile {
+	var profiles []preflightProfile
+	if m := cs.Properties.MasterProfile; m != nil && m.VMSize != "" {
+		profiles = append(profiles, preflightProfile{
+			path:    "properties.masterProfile",
+			name:    "masterProfile",
+			vmSize:  m.VMSize,
+			zones:   m.AvailabilityZones,
+			count:   m.Count,
+			setSize: func(s string) { m.VMSize = s },
+		})
+	}
+	for i, a := range cs.Properties.AgentPoolProfiles {
+		if a.VMSize == "" {
+			continue
+		}
+		a := a
+		ephemeralOSDiskGB := 0
+		if a.IsEphemeral() {
+			ephemeralOSDiskGB = a.OSDiskSizeGB
+			if ephemeralOSDiskGB == 0 {
+				ephemeralOSDiskGB = api.VHDDiskSizeAKS
+			}
+		}
+		profiles = append(profiles, preflightProfile{
+			path:              fmt.Sprintf("properties.agentPoolProfiles[%d]", i),
+			name:              "agentPoolProfile " + a.Name,
+			vmSize:            a.VMSize,
+			zones:             a.AvailabilityZones,
+			count:             a.Count,
+			ephemeralOSDiskGB: ephemeralOSDiskGB,
+			setSize:           func(s string) { a.VMSize = s },
+		})
+	}
+	return profiles
+}
+
+// applySKUFallbacks sets the VM sizes of the profiles of cs with a problem to their fallback, and returns an error
+// listing the problems without a fallback
+func applySKUFallbacks(cs *api.ContainerService, problems []preflightProblem) error {
+	setters := map[string]func(string){}
+	for _, profile := range preflightProfiles(cs) {
+		setters[profile.path] = profile.setSize
+	}
+	var unresolved []string
+	for _, problem := range problems {
+		if set, ok := setters[problem.Path]; ok && problem.Fallback != "" {
+			set(problem.Fallback)
+			continue
+		}
+		unresolved = append(unresolved, problem.String())
+	}
+	if len(unresolved) > 0 {
+		return errors.Errorf("no VM size of --sku-preferences can be used instead: %s", strings.Join(unresolved, "; "))
+	}
+	return nil
+}
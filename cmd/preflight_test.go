@@ -0,0 +1,142 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+)
+
+func getPreflightTestSKU(name, family, vCPUs, memoryGB, premiumIO string, restricted bool) compute.ResourceSku {
+	sku := compute.ResourceSku{
+		ResourceType: to.StringPtr("virtualMachines"),
+		Name:         to.StringPtr(name),
+		Family:       to.StringPtr(family),
+		LocationInfo: &[]compute.ResourceSkuLocationInfo{{Location: to.StringPtr("eastus")}},
+		Capabilities: &[]compute.ResourceSkuCapabilities{
+			{Name: to.StringPtr("vCPUs"), Value: to.StringPtr(vCPUs)},
+			{Name: to.StringPtr("MemoryGB"), Value: to.StringPtr(memoryGB)},
+			{Name: to.StringPtr("PremiumIO"), Value: to.StringPtr(premiumIO)},
+		},
+	}
+	if restricted {
+		sku.Restrictions = &[]compute.ResourceSkuRestrictions{
+			{Type: compute.Location, RestrictionInfo: &compute.ResourceSkuRestrictionInfo{Locations: &[]string{"eastus"}}},
+		}
+	}
+	return sku
+}
+
+func getPreflightTestSKUs() []compute.ResourceSku {
+	return []compute.ResourceSku{
+		getPreflightTestSKU("Standard_B1s", "standardBSFamily", "1", "1", "True", false),
+		getPreflightTestSKU("Standard_D2_v2", "standardDv2Family", "2", "7", "False", false),
+		getPreflightTestSKU("Standard_DS2_v2", "standardDSv2Family", "2", "7", "True", true),
+		getPreflightTestSKU("Standard_D2s_v3", "standardDSv3Family", "2", "8", "True", false),
+	}
+}
+
+func getPreflightTestUsage(name string, current int32, limit int64) compute.Usage {
+	return compute.Usage{
+		Name:         &compute.UsageName{Value: to.StringPtr(name)},
+		CurrentValue: to.Int32Ptr(current),
+		Limit:        to.Int64Ptr(limit),
+	}
+}
+
+func TestSKUPreflightUnavailableVMSize(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false)
+	cs.Properties.AgentPoolProfiles[0].VMSize = "Standard_DS2_v2"
+
+	preflight := newSKUPreflight(getPreflightTestSKUs(), nil, "eastus")
+	problems, err := preflight.check(cs, []string{"Standard_B1s", "Standard_D2_v2", "Standard_D2s_v3"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(HaveLen(1))
+	g.Expect(problems[0].Path).To(Equal("properties.agentPoolProfiles[0]"))
+	g.Expect(problems[0].Reason).To(ContainSubstring("VM size Standard_DS2_v2 is not available in location eastus"))
+	// Standard_B1s has less vCPUs and Standard_D2_v2 does not support premium storage
+	g.Expect(problems[0].Fallback).To(Equal("Standard_D2s_v3"))
+
+	g.Expect(applySKUFallbacks(cs, problems)).To(Succeed())
+	g.Expect(cs.Properties.AgentPoolProfiles[0].VMSize).To(Equal("Standard_D2s_v3"))
+	g.Expect(cs.Properties.MasterProfile.VMSize).To(Equal("Standard_D2_v2"))
+
+	problems, err = preflight.check(cs, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(BeEmpty())
+}
+
+func TestSKUPreflightFamilyQuota(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false)
+	usages := []compute.Usage{
+		getPreflightTestUsage("cores", 10, 100),
+		getPreflightTestUsage("standardDv2Family", 4, 10),
+		getPreflightTestUsage("standardDSv3Family", 0, 20),
+	}
+
+	// the 10 vCPUs of the cluster exceed the 6 left of the family, the masters falling back leaves enough for the agents
+	problems, err := newSKUPreflight(getPreflightTestSKUs(), usages, "eastus").check(cs, []string{"Standard_D2s_v3"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(HaveLen(1))
+	g.Expect(problems[0].Profile).To(Equal("masterProfile"))
+	g.Expect(problems[0].Reason).To(Equal("VM size Standard_D2_v2 needs 10 vCPUs of VM family standarddv2family for the cluster, 6 are left of the quota in location eastus"))
+	g.Expect(problems[0].Fallback).To(Equal("Standard_D2s_v3"))
+
+	problems, err = newSKUPreflight(getPreflightTestSKUs(), usages, "eastus").check(cs, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(HaveLen(2))
+	g.Expect(problems[1].Fallback).To(BeEmpty())
+	err = applySKUFallbacks(cs, problems)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(HavePrefix("no VM size of --sku-preferences can be used instead: masterProfile: VM size Standard_D2_v2 needs 10 vCPUs"))
+}
+
+func TestSKUPreflightRegionalQuota(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false)
+	usages := []compute.Usage{
+		getPreflightTestUsage("cores", 92, 100),
+	}
+
+	problems, err := newSKUPreflight(getPreflightTestSKUs(), usages, "eastus").check(cs, []string{"Standard_B1s", "Standard_D2s_v3"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(HaveLen(1))
+	g.Expect(problems[0].Path).To(BeEmpty())
+	g.Expect(problems[0].String()).To(Equal("cluster: the cluster needs 10 vCPUs, 8 are left of the regional vCPU quota in location eastus"))
+	g.Expect(applySKUFallbacks(cs, problems)).NotTo(Succeed())
+}
+
+func TestSKUPreflightEphemeralOSDisk(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false)
+	cs.Properties.AgentPoolProfiles[0].OSDiskType = api.EphemeralOSDisk
+	cs.Properties.AgentPoolProfiles[0].OSDiskSizeGB = 60
+
+	withCache := func(sku compute.ResourceSku, supported, cachedDiskBytes string) compute.ResourceSku {
+		capabilities := append(*sku.Capabilities,
+			compute.ResourceSkuCapabilities{Name: to.StringPtr("EphemeralOSDiskSupported"), Value: to.StringPtr(supported)},
+			compute.ResourceSkuCapabilities{Name: to.StringPtr("CachedDiskBytes"), Value: to.StringPtr(cachedDiskBytes)},
+		)
+		sku.Capabilities = &capabilities
+		return sku
+	}
+	skus := []compute.ResourceSku{
+		withCache(getPreflightTestSKU("Standard_D2_v2", "standardDv2Family", "2", "7", "False", false), "False", "0"),
+		withCache(getPreflightTestSKU("Standard_D2s_v3", "standardDSv3Family", "2", "8", "True", false), "True", "53687091200"),
+		withCache(getPreflightTestSKU("Standard_D4s_v3", "standardDSv3Family", "4", "16", "True", false), "True", "107374182400"),
+	}
+
+	// the cache of Standard_D2s_v3 is too small for the 60 GiB OS disk
+	problems, err := newSKUPreflight(skus, nil, "eastus").check(cs, []string{"Standard_D2s_v3", "Standard_D4s_v3"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(HaveLen(1))
+	g.Expect(problems[0].Reason).To(Equal("agentPoolProfile agentpool1 VM size Standard_D2_v2 does not support ephemeral OS disks"))
+	g.Expect(problems[0].Fallback).To(Equal("Standard_D4s_v3"))
+}
@@ -0,0 +1,431 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/engine"
+	"github.com/Azure/aks-engine/pkg/engine/transform"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/operations/reconcile"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	reconcileName             = "reconcile"
+	reconcileShortDescription = "Bring an existing Kubernetes cluster to the state of its api model"
+	reconcileLongDescription  = "Bring an existing Kubernetes cluster to the state of its api model by generating the template of the api model, deploying only the ARM resources that differ from the deployed template with an incremental deployment, and re-applying the addon manifests that differ from the manifests on the masters. Changes which cannot be applied safely, e.g. to the VMs, are reported and left to aks-engine upgrade and scale."
+	addonsDirectory           = "/etc/kubernetes/addons"
+)
+
+type reconcileCmd struct {
+	authArgs
+
+	// user input
+	apiModelPath         string
+	resourceGroupName    string
+	location             string
+	deployedTemplatePath string
+	masterFQDN           string
+	sshFilepath          string
+	dryRun               bool
+
+	// derived
+	containerService   *api.ContainerService
+	apiVersion         string
+	client             armhelpers.AKSEngineClient
+	locale             *gotext.Locale
+	nameSuffix         string
+	logger             *log.Entry
+	apiserverURL       string
+	masterNodes        []string
+	sshConfig          *ssh.ClientConfig
+	sshCommandExecuter func(command, masterFQDN, hostname string, port string, config *ssh.ClientConfig) (string, error)
+}
+
+func newReconcileCmd() *cobra.Command {
+	rc := reconcileCmd{
+		sshCommandExecuter: executeCmd,
+	}
+
+	command := &cobra.Command{
+		Use:   reconcileName,
+		Short: reconcileShortDescription,
+		Long:  reconcileLongDescription,
+		RunE:  rc.run,
+	}
+
+	f := command.Flags()
+	f.StringVarP(&rc.location, "location", "l", "", "location the cluster is deployed in (required)")
+	f.StringVarP(&rc.resourceGroupName, "resource-group", "g", "", "the resource group where the cluster is deployed (required)")
+	f.StringVarP(&rc.apiModelPath, "api-model", "m", "", "path to the generated apimodel.json file (required)")
+	f.StringVar(&rc.deployedTemplatePath, "deployed-template", "", "path to the deployed azuredeploy.json, its parameters are read from azuredeploy.parameters.json in the same directory (defaults to the directory of the api model)")
+	f.StringVar(&rc.masterFQDN, "apiserver", "", "apiserver endpoint (required with --ssh)")
+	f.StringVar(&rc.sshFilepath, "ssh", "", "the filepath of a valid private ssh key to access the masters, the addons are only reconciled when it is set")
+	f.BoolVar(&rc.dryRun, "dry-run", false, "print the changes without applying them")
+
+	addAuthFlags(&rc.authArgs, f)
+
+	return command
+}
+
+func (rc *reconcileCmd) validate(cmd *cobra.Command) error {
+	log.Debugln("validating reconcile command line arguments...")
+	var err error
+
+	rc.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if rc.resourceGroupName == "" {
+		cmd.Usage()
+		return errors.New("--resource-group must be specified")
+	}
+
+	if rc.location == "" {
+		cmd.Usage()
+		return errors.New("--location must be specified")
+	}
+	rc.location = helpers.NormalizeAzureRegion(rc.location)
+
+	if rc.apiModelPath == "" {
+		cmd.Usage()
+		return errors.New("--api-model must be specified")
+	}
+
+	if rc.deployedTemplatePath == "" {
+		rc.deployedTemplatePath = filepath.Join(filepath.Dir(rc.apiModelPath), "azuredeploy.json")
+	}
+
+	if rc.sshFilepath != "" {
+		if rc.masterFQDN == "" {
+			cmd.Usage()
+			return errors.New("--apiserver must be specified with --ssh")
+		}
+		if strings.HasPrefix(rc.masterFQDN, "https://") {
+			rc.apiserverURL = rc.masterFQDN
+		} else if strings.HasPrefix(rc.masterFQDN, "http://") {
+			return errors.New("apiserver URL cannot be insecure http://")
+		} else {
+			rc.apiserverURL = fmt.Sprintf("https://%s", rc.masterFQDN)
+		}
+	}
+
+	return nil
+}
+
+func (rc *reconcileCmd) load() error {
+	rc.logger = log.NewEntry(log.New())
+	var err error
+
+	if _, err = os.Stat(rc.apiModelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", rc.apiModelPath)
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: rc.locale,
+		},
+	}
+	rc.containerService, rc.apiVersion, err = apiloader.LoadContainerServiceFromFile(rc.apiModelPath, true, true, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+
+	if rc.containerService.Properties.IsAzureStackCloud() {
+		writeCustomCloudProfile(rc.containerService)
+		if err = rc.containerService.Properties.SetAzureStackCloudSpec(); err != nil {
+			return errors.Wrap(err, "error parsing the api model")
+		}
+	}
+
+	if rc.containerService.Location == "" {
+		rc.containerService.Location = rc.location
+	} else if rc.containerService.Location != rc.location {
+		return errors.New("--location does not match api model location")
+	}
+
+	//allows to identify VMs in the resource group that belong to this cluster.
+	rc.nameSuffix = rc.containerService.Properties.GetClusterID()
+	log.Debugf("Cluster ID used in all agent pools: %s", rc.nameSuffix)
+
+	if err = rc.authArgs.validateAuthArgs(); err != nil {
+		return err
+	}
+
+	if rc.client, err = rc.authArgs.getClient(); err != nil {
+		return errors.Wrap(err, "failed to get client")
+	}
+	return nil
+}
+
+func (rc *reconcileCmd) run(cmd *cobra.Command, args []string) error {
+	if err := rc.validate(cmd); err != nil {
+		return errors.Wrap(err, "failed to validate reconcile command")
+	}
+	if err := rc.load(); err != nil {
+		return errors.Wrap(err, "failed to load existing container service")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+
+	if err := rc.setScaleSetCounts(ctx); err != nil {
+		return err
+	}
+	template, parameters, err := rc.generateTemplate()
+	if err != nil {
+		return err
+	}
+	templateJSON, parametersJSON, err := readTemplate(template, parameters)
+	if err != nil {
+		return err
+	}
+	deployedTemplate, err := ioutil.ReadFile(rc.deployedTemplatePath)
+	if err != nil {
+		return errors.Wrapf(err, "reading the deployed template %s", rc.deployedTemplatePath)
+	}
+	deployedParametersPath := filepath.Join(filepath.Dir(rc.deployedTemplatePath), "azuredeploy.parameters.json")
+	deployedParameters, err := ioutil.ReadFile(deployedParametersPath)
+	if err != nil {
+		return errors.Wrapf(err, "reading the deployed template parameters %s", deployedParametersPath)
+	}
+	deployedTemplateJSON, deployedParametersJSON, err := readTemplate(string(deployedTemplate), string(deployedParameters))
+	if err != nil {
+		return err
+	}
+
+	plan := reconcile.NewPlan(deployedTemplateJSON, templateJSON, deployedParametersJSON, parametersJSON)
+	if rc.sshFilepath != "" {
+		if err = rc.planAddons(plan); err != nil {
+			return err
+		}
+	} else {
+		rc.logger.Warnf("The addons are not reconciled as --ssh is not set")
+	}
+	if err = plan.WriteText(os.Stdout); err != nil {
+		return err
+	}
+	if rc.dryRun {
+		return nil
+	}
+
+	if len(plan.Resources) > 0 {
+		random := rand.New(rand.NewSource(time.Now().UnixNano()))
+		deploymentSuffix := random.Int31()
+		rc.logger.Infof("Deploying %d ARM resources", len(plan.Resources))
+		_, err = rc.client.DeployTemplate(
+			ctx,
+			rc.resourceGroupName,
+			fmt.Sprintf("%s-%d", rc.resourceGroupName, deploymentSuffix),
+			plan.Template,
+			parametersJSON)
+		if err != nil {
+			return err
+		}
+	}
+	if err = rc.applyAddons(plan.Addons); err != nil {
+		return err
+	}
+
+	// the skipped changes are reported until they are applied, e.g. by an upgrade, so the deployed template is only
+	// updated once the cluster is up to date with the api model
+	if len(plan.Skipped) > 0 {
+		rc.logger.Warnf("%d changes were not applied, the deployed template %s is left as it is", len(plan.Skipped), rc.deployedTemplatePath)
+		return nil
+	}
+	return rc.saveDeployedTemplate(template, parameters)
+}
+
+// setScaleSetCounts sets the count of the VM scale set agent pools to the capacity of their scale sets, which
+// the cluster autoscaler or aks-engine scale may have changed since the cluster was deployed
+func (rc *reconcileCmd) setScaleSetCounts(ctx context.Context) error {
+	var pools []*api.AgentPoolProfile
+	for _, pool := range rc.containerService.Properties.AgentPoolProfiles {
+		if pool.IsVirtualMachineScaleSets() {
+			pools = append(pools, pool)
+		}
+	}
+	if len(pools) == 0 {
+		return nil
+	}
+	for page, err := rc.client.ListVirtualMachineScaleSets(ctx, rc.resourceGroupName); page.NotDone(); err = page.NextWithContext(ctx) {
+		if err != nil {
+			return errors.Wrap(err, "failed to get VMSS list in the resource group")
+		}
+		for _, vmss := range page.Values() {
+			if vmss.Sku == nil || vmss.Sku.Capacity == nil {
+				continue
+			}
+			for _, pool := range pools {
+				if !resourceInAgentPool(*vmss.Name, vmss.Tags, pool.Name, rc.nameSuffix) {
+					continue
+				}
+				if capacity := int(*vmss.Sku.Capacity); capacity != pool.Count {
+					rc.logger.Warnf("Node pool %s has %d nodes rather than the %d of the api model, use aks-engine scale to change its count", pool.Name, capacity, pool.Count)
+					pool.Count = capacity
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// generateTemplate generates the template and parameters of the api model, as written by aks-engine generate
+func (rc *reconcileCmd) generateTemplate() (string, string, error) {
+	translator := engine.Context{
+		Translator: &i18n.Translator{
+			Locale: rc.locale,
+		},
+	}
+	templateGenerator, err := engine.InitializeTemplateGenerator(translator)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to initialize template generator")
+	}
+
+	_, err = rc.containerService.SetPropertiesDefaults(false, false)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "error in SetPropertiesDefaults template %s", rc.apiModelPath)
+	}
+	template, parameters, err := templateGenerator.GenerateTemplateV2(rc.containerService, engine.DefaultGeneratorCode, BuildTag)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "error generating template %s", rc.apiModelPath)
+	}
+
+	if template, err = transform.PrettyPrintArmTemplate(template); err != nil {
+		return "", "", errors.Wrap(err, "error pretty printing template")
+	}
+	if parameters, err = transform.BuildAzureParametersFile(parameters); err != nil {
+		return "", "", errors.Wrap(err, "error pretty printing template parameters")
+	}
+	return template, parameters, nil
+}
+
+// readTemplate unmarshals a template and the parameters of its parameters file
+func readTemplate(template, parameters string) (map[string]interface{}, map[string]interface{}, error) {
+	templateJSON := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(template), &templateJSON); err != nil {
+		return nil, nil, errors.Wrap(err, "error unmarshaling template")
+	}
+	parametersFile := struct {
+		Parameters map[string]interface{} `json:"parameters"`
+	}{}
+	if err := json.Unmarshal([]byte(parameters), &parametersFile); err != nil {
+		return nil, nil, errors.Wrap(err, "error unmarshaling parameters")
+	}
+	return templateJSON, parametersFile.Parameters, nil
+}
+
+// planAddons adds the addons whose manifest differs from the manifest on the first master to the plan
+func (rc *reconcileCmd) planAddons(plan *reconcile.Plan) error {
+	if _, err := os.Stat(rc.sshFilepath); os.IsNotExist(err) {
+		return errors.Errorf("specified ssh filepath does not exist (%s)", rc.sshFilepath)
+	}
+	rc.setSSHConfig()
+	if err := rc.getMasterNodes(); err != nil {
+		return errors.Wrap(err, "listing the masters")
+	}
+	if len(rc.masterNodes) == 0 {
+		return errors.New("no masters were found in the cluster")
+	}
+
+	addons, err := engine.GetContainerAddonManifests(rc.containerService)
+	if err != nil {
+		return errors.Wrap(err, "rendering the addon manifests")
+	}
+	master := rc.masterNodes[0]
+	return plan.PlanAddons(addons, func(destinationFile string) (string, error) {
+		// a missing addon reads as an empty manifest
+		cmd := fmt.Sprintf("sudo cat %s 2>/dev/null || true", path.Join(addonsDirectory, destinationFile))
+		out, err := rc.sshCommandExecuter(cmd, rc.masterFQDN, master, "22", rc.sshConfig)
+		if err != nil {
+			return "", errors.Wrapf(err, "reading addon %s on master %s", destinationFile, master)
+		}
+		return strings.TrimPrefix(out, master+" -> "), nil
+	})
+}
+
+// applyAddons writes the addon manifests on all the masters, where the addon manager applies them to the cluster
+func (rc *reconcileCmd) applyAddons(addons []engine.AddonManifest) error {
+	for _, addon := range addons {
+		rc.logger.Infof("Re-applying addon %s", addon.Name)
+		cmd := fmt.Sprintf("echo %s | base64 -d | sudo tee %s > /dev/null", base64.StdEncoding.EncodeToString([]byte(addon.Manifest)), path.Join(addonsDirectory, addon.DestinationFile))
+		for _, master := range rc.masterNodes {
+			out, err := rc.sshCommandExecuter(cmd, rc.masterFQDN, master, "22", rc.sshConfig)
+			if err != nil {
+				log.Printf("Command output: %s\n", out)
+				return errors.Wrapf(err, "writing addon %s on master %s", addon.Name, master)
+			}
+		}
+	}
+	return nil
+}
+
+func (rc *reconcileCmd) getMasterNodes() error {
+	kubeconfig, err := engine.GenerateKubeConfig(rc.containerService.Properties, rc.location)
+	if err != nil {
+		return errors.Wrap(err, "generating kubeconfig")
+	}
+	kubeClient, err := rc.client.GetKubernetesClient(rc.apiserverURL, kubeconfig, time.Duration(5)*time.Second, time.Duration(60)*time.Minute)
+	if err != nil {
+		return errors.Wrap(err, "failed to get a Kubernetes client")
+	}
+	nodeList, err := kubeClient.ListNodes()
+	if err != nil {
+		return errors.Wrap(err, "failed to get cluster nodes")
+	}
+	for _, node := range nodeList.Items {
+		if strings.Contains(node.Name, "master") {
+			rc.masterNodes = append(rc.masterNodes, node.Name)
+		}
+	}
+	return nil
+}
+
+func (rc *reconcileCmd) setSSHConfig() {
+	user := "azureuser"
+	if rc.containerService.Properties.LinuxProfile != nil && rc.containerService.Properties.LinuxProfile.AdminUsername != "" {
+		user = rc.containerService.Properties.LinuxProfile.AdminUsername
+	}
+	rc.sshConfig = &ssh.ClientConfig{
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		User:            user,
+		Auth: []ssh.AuthMethod{
+			publicKeyFile(rc.sshFilepath),
+		},
+	}
+}
+
+// saveDeployedTemplate saves the generated template and parameters as the deployed template
+func (rc *reconcileCmd) saveDeployedTemplate(template, parameters string) error {
+	f := helpers.FileSaver{
+		Translator: &i18n.Translator{
+			Locale: rc.locale,
+		},
+	}
+	dir, file := filepath.Split(rc.deployedTemplatePath)
+	if err := f.SaveFileString(dir, file, template); err != nil {
+		return err
+	}
+	return f.SaveFileString(dir, "azuredeploy.parameters.json", parameters)
+}
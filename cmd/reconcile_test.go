@@ -0,0 +1,169 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/engine"
+	"github.com/Azure/aks-engine/pkg/operations/reconcile"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestNewReconcileCmd(t *testing.T) {
+	command := newReconcileCmd()
+	if command.Use != reconcileName || command.Short != reconcileShortDescription || command.Long != reconcileLongDescription {
+		t.Fatalf("reconcile command should have use %s equal %s, short %s equal %s and long %s equal to %s", command.Use, reconcileName, command.Short, reconcileShortDescription, command.Long, reconcileLongDescription)
+	}
+
+	expectedFlags := []string{"location", "resource-group", "api-model", "deployed-template", "apiserver", "ssh", "dry-run"}
+	for _, f := range expectedFlags {
+		if command.Flags().Lookup(f) == nil {
+			t.Fatalf("reconcile command should have flag %s", f)
+		}
+	}
+
+	command.SetArgs([]string{})
+	if err := command.Execute(); err == nil {
+		t.Fatalf("expected an error when calling reconcile with no arguments")
+	}
+}
+
+func TestReconcileCmdValidate(t *testing.T) {
+	cases := []struct {
+		rc                       *reconcileCmd
+		expectedErr              string
+		expectedDeployedTemplate string
+	}{
+		{
+			rc:          &reconcileCmd{location: "centralus", apiModelPath: "_output/test/apimodel.json"},
+			expectedErr: "--resource-group must be specified",
+		},
+		{
+			rc:          &reconcileCmd{resourceGroupName: "testRG", apiModelPath: "_output/test/apimodel.json"},
+			expectedErr: "--location must be specified",
+		},
+		{
+			rc:          &reconcileCmd{resourceGroupName: "testRG", location: "centralus"},
+			expectedErr: "--api-model must be specified",
+		},
+		{
+			rc:          &reconcileCmd{resourceGroupName: "testRG", location: "centralus", apiModelPath: "_output/test/apimodel.json", sshFilepath: "id_rsa"},
+			expectedErr: "--apiserver must be specified with --ssh",
+		},
+		{
+			rc:          &reconcileCmd{resourceGroupName: "testRG", location: "centralus", apiModelPath: "_output/test/apimodel.json", sshFilepath: "id_rsa", masterFQDN: "http://example.com"},
+			expectedErr: "apiserver URL cannot be insecure http://",
+		},
+		{
+			rc:                       &reconcileCmd{resourceGroupName: "testRG", location: "centralus", apiModelPath: "_output/test/apimodel.json"},
+			expectedDeployedTemplate: "_output/test/azuredeploy.json",
+		},
+		{
+			rc:                       &reconcileCmd{resourceGroupName: "testRG", location: "centralus", apiModelPath: "_output/test/apimodel.json", deployedTemplatePath: "deployed.json"},
+			expectedDeployedTemplate: "deployed.json",
+		},
+	}
+
+	for _, c := range cases {
+		err := c.rc.validate(&cobra.Command{})
+		if c.expectedErr == "" {
+			if err != nil {
+				t.Fatalf("expected validate reconcile command to return no error, but instead got %s", err.Error())
+			}
+			if c.rc.deployedTemplatePath != c.expectedDeployedTemplate {
+				t.Fatalf("expected validate reconcile command to set the deployed template %s, but instead got %s", c.expectedDeployedTemplate, c.rc.deployedTemplatePath)
+			}
+		} else if err == nil || err.Error() != c.expectedErr {
+			t.Fatalf("expected validate reconcile command to return error %s, but instead got %v", c.expectedErr, err)
+		}
+	}
+}
+
+func TestReconcileSetScaleSetCounts(t *testing.T) {
+	client := &armhelpers.MockAKSEngineClient{}
+	client.FakeListVirtualMachineScaleSetsResult = func() []compute.VirtualMachineScaleSet {
+		return []compute.VirtualMachineScaleSet{{
+			Name: to.StringPtr("k8s-agentpool1-12345678-vmss"),
+			Sku:  &compute.Sku{Capacity: to.Int64Ptr(5)},
+			Tags: map[string]*string{"poolName": to.StringPtr("agentpool1"), "resourceNameSuffix": to.StringPtr("12345678")},
+		}}
+	}
+	cs := api.CreateMockContainerService("testcluster", "1.15.7", 3, 2, false)
+	cs.Properties.AgentPoolProfiles = []*api.AgentPoolProfile{
+		{Name: "agentpool1", Count: 2, AvailabilityProfile: api.VirtualMachineScaleSets},
+		{Name: "agentpool2", Count: 2, AvailabilityProfile: api.AvailabilitySet},
+	}
+	rc := &reconcileCmd{
+		resourceGroupName: "testRG",
+		containerService:  cs,
+		client:            client,
+		nameSuffix:        "12345678",
+		logger:            log.NewEntry(log.New()),
+	}
+	if err := rc.setScaleSetCounts(context.Background()); err != nil {
+		t.Fatalf("unexpected error setting the counts of the scale sets: %s", err)
+	}
+	if pools := cs.Properties.AgentPoolProfiles; pools[0].Count != 5 || pools[1].Count != 2 {
+		t.Fatalf("expected the count of the scale set pool to be its capacity, but instead got %d and %d", pools[0].Count, pools[1].Count)
+	}
+}
+
+func TestReconcileReadTemplate(t *testing.T) {
+	template, parameters, err := readTemplate(`{"resources": []}`, `{"parameters": {"location": {"value": "westus2"}}}`)
+	if err != nil {
+		t.Fatalf("unexpected error reading the template: %s", err)
+	}
+	if _, ok := template["resources"]; !ok || parameters["location"] == nil {
+		t.Fatalf("expected the template and the parameters of the parameters file, but instead got %v and %v", template, parameters)
+	}
+	if _, _, err = readTemplate(`{`, `{}`); err == nil {
+		t.Fatalf("expected an error reading an invalid template")
+	}
+}
+
+func TestReconcileAddons(t *testing.T) {
+	var commands []string
+	rc := &reconcileCmd{
+		masterFQDN:  "example.com",
+		masterNodes: []string{"k8s-master-12345678-0", "k8s-master-12345678-1"},
+		logger:      log.NewEntry(log.New()),
+		sshCommandExecuter: func(command, masterFQDN, hostname string, port string, config *ssh.ClientConfig) (string, error) {
+			commands = append(commands, hostname+": "+command)
+			if strings.Contains(command, "fail.yaml") {
+				return "", errors.New("permission denied")
+			}
+			return hostname + " -> kind: Deployment", nil
+		},
+	}
+	err := rc.applyAddons([]engine.AddonManifest{{Name: "coredns", DestinationFile: "coredns.yaml", Manifest: "kind: Deployment"}})
+	if err != nil {
+		t.Fatalf("unexpected error applying the addons: %s", err)
+	}
+	if len(commands) != 2 || !strings.HasPrefix(commands[1], "k8s-master-12345678-1: echo a2luZDogRGVwbG95bWVudA== | base64 -d | sudo tee /etc/kubernetes/addons/coredns.yaml") {
+		t.Fatalf("expected the addon to be written on all the masters, but instead got %v", commands)
+	}
+	if err = rc.applyAddons([]engine.AddonManifest{{Name: "fail", DestinationFile: "fail.yaml"}}); err == nil {
+		t.Fatalf("expected an error when an addon cannot be written")
+	}
+
+	plan := &reconcile.Plan{}
+	master := rc.masterNodes[0]
+	err = plan.PlanAddons([]engine.AddonManifest{{Name: "coredns", DestinationFile: "coredns.yaml", Manifest: "kind: Deployment"}}, func(destinationFile string) (string, error) {
+		out, err := rc.sshCommandExecuter("sudo cat "+destinationFile, rc.masterFQDN, master, "22", nil)
+		return strings.TrimPrefix(out, master+" -> "), err
+	})
+	if err != nil || len(plan.Addons) != 0 {
+		t.Fatalf("expected the addon identical to the manifest on the masters not to be re-applied, but instead got %v, %v", plan.Addons, err)
+	}
+}
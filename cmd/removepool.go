@@ -0,0 +1,383 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/engine"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/operations"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	removePoolName             = "removepool"
+	removePoolShortDescription = "Remove a node pool from an existing Kubernetes cluster"
+	removePoolLongDescription  = "Remove a node pool from an existing Kubernetes cluster by cordoning and draining its nodes, deleting its VMs or VM scale set, removing its nodes from the cluster, then removing the agent pool from the api model"
+)
+
+type removePoolCmd struct {
+	authArgs
+
+	// user input
+	apiModelPath      string
+	resourceGroupName string
+	location          string
+	agentPoolToRemove string
+	masterFQDN        string
+	force             bool
+
+	// derived
+	containerService *api.ContainerService
+	apiVersion       string
+	agentPool        *api.AgentPoolProfile
+	client           armhelpers.AKSEngineClient
+	locale           *gotext.Locale
+	nameSuffix       string
+	logger           *log.Entry
+	apiserverURL     string
+	kubeconfig       string
+}
+
+func newRemovePoolCmd() *cobra.Command {
+	rpc := removePoolCmd{}
+
+	command := &cobra.Command{
+		Use:   removePoolName,
+		Short: removePoolShortDescription,
+		Long:  removePoolLongDescription,
+		RunE:  rpc.run,
+	}
+
+	f := command.Flags()
+	f.StringVarP(&rpc.location, "location", "l", "", "location the cluster is deployed in (required)")
+	f.StringVarP(&rpc.resourceGroupName, "resource-group", "g", "", "the resource group where the cluster is deployed (required)")
+	f.StringVarP(&rpc.apiModelPath, "api-model", "m", "", "path to the generated apimodel.json file (required)")
+	f.StringVar(&rpc.agentPoolToRemove, "node-pool", "", "node pool to remove (required)")
+	f.StringVar(&rpc.masterFQDN, "apiserver", "", "apiserver endpoint (required to cordon, drain and delete the nodes)")
+	f.BoolVar(&rpc.force, "force", false, "delete the VMs of the node pool without cordoning and draining its nodes")
+
+	addAuthFlags(&rpc.authArgs, f)
+
+	return command
+}
+
+func (rpc *removePoolCmd) validate(cmd *cobra.Command) error {
+	log.Debugln("validating removepool command line arguments...")
+	var err error
+
+	rpc.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if rpc.resourceGroupName == "" {
+		cmd.Usage()
+		return errors.New("--resource-group must be specified")
+	}
+
+	if rpc.location == "" {
+		cmd.Usage()
+		return errors.New("--location must be specified")
+	}
+	rpc.location = helpers.NormalizeAzureRegion(rpc.location)
+
+	if rpc.apiModelPath == "" {
+		cmd.Usage()
+		return errors.New("--api-model must be specified")
+	}
+
+	if rpc.agentPoolToRemove == "" {
+		cmd.Usage()
+		return errors.New("--node-pool must be specified")
+	}
+
+	// the nodes are deleted from the cluster even if they are not drained
+	if rpc.masterFQDN == "" {
+		cmd.Usage()
+		return errors.New("--apiserver must be specified")
+	}
+	if strings.HasPrefix(rpc.masterFQDN, "https://") {
+		rpc.apiserverURL = rpc.masterFQDN
+	} else if strings.HasPrefix(rpc.masterFQDN, "http://") {
+		return errors.New("apiserver URL cannot be insecure http://")
+	} else {
+		rpc.apiserverURL = fmt.Sprintf("https://%s", rpc.masterFQDN)
+	}
+
+	return nil
+}
+
+func (rpc *removePoolCmd) load() error {
+	rpc.logger = log.NewEntry(log.New())
+	var err error
+
+	if _, err = os.Stat(rpc.apiModelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", rpc.apiModelPath)
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: rpc.locale,
+		},
+	}
+	rpc.containerService, rpc.apiVersion, err = apiloader.LoadContainerServiceFromFile(rpc.apiModelPath, true, true, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+
+	if rpc.containerService.Properties.IsAzureStackCloud() {
+		writeCustomCloudProfile(rpc.containerService)
+		if err = rpc.containerService.Properties.SetAzureStackCloudSpec(); err != nil {
+			return errors.Wrap(err, "error parsing the api model")
+		}
+	}
+
+	if rpc.containerService.Location == "" {
+		rpc.containerService.Location = rpc.location
+	} else if rpc.containerService.Location != rpc.location {
+		return errors.New("--location does not match api model location")
+	}
+
+	if err = rpc.setAgentPool(); err != nil {
+		return err
+	}
+
+	//allows to identify VMs in the resource group that belong to this cluster.
+	rpc.nameSuffix = rpc.containerService.Properties.GetClusterID()
+	log.Debugf("Cluster ID used in all agent pools: %s", rpc.nameSuffix)
+
+	rpc.kubeconfig, err = engine.GenerateKubeConfig(rpc.containerService.Properties, rpc.location)
+	if err != nil {
+		return errors.New("Unable to derive kubeconfig from api model")
+	}
+
+	if err = rpc.authArgs.validateAuthArgs(); err != nil {
+		return err
+	}
+
+	if rpc.client, err = rpc.authArgs.getClient(); err != nil {
+		return errors.Wrap(err, "failed to get client")
+	}
+	return nil
+}
+
+// setAgentPool sets the agent pool to remove, which must not be the last agent pool of the cluster
+func (rpc *removePoolCmd) setAgentPool() error {
+	for _, pool := range rpc.containerService.Properties.AgentPoolProfiles {
+		if strings.EqualFold(pool.Name, rpc.agentPoolToRemove) {
+			rpc.agentPool = pool
+		}
+	}
+	if rpc.agentPool == nil {
+		return errors.Errorf("node pool %s was not found in the deployed api model", rpc.agentPoolToRemove)
+	}
+	if len(rpc.containerService.Properties.AgentPoolProfiles) == 1 {
+		return errors.Errorf("node pool %s is the only agent pool of the cluster and cannot be removed", rpc.agentPool.Name)
+	}
+	return nil
+}
+
+func (rpc *removePoolCmd) run(cmd *cobra.Command, args []string) error {
+	if err := rpc.validate(cmd); err != nil {
+		return errors.Wrap(err, "failed to validate removepool command")
+	}
+	if err := rpc.load(); err != nil {
+		return errors.Wrap(err, "failed to load existing container service")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	if err := rpc.removeAgentPool(ctx); err != nil {
+		return err
+	}
+	return rpc.saveAPIModel()
+}
+
+// removeAgentPool drains the nodes of the agent pool unless --force is set, deletes its VMs or its VM scale set, then
+// deletes its nodes from the cluster
+func (rpc *removePoolCmd) removeAgentPool(ctx context.Context) error {
+	var nodes, vmsToDelete, scaleSetsToDelete []string
+	var err error
+	if rpc.agentPool.IsVirtualMachineScaleSets() {
+		if scaleSetsToDelete, nodes, err = rpc.listScaleSets(ctx); err != nil {
+			return err
+		}
+	} else {
+		if vmsToDelete, err = rpc.listVMs(ctx); err != nil {
+			return err
+		}
+		nodes = vmsToDelete
+	}
+	if len(nodes) == 0 && len(scaleSetsToDelete) == 0 {
+		rpc.logger.Warnf("No VMs of node pool %s were found in the resource group %s", rpc.agentPool.Name, rpc.resourceGroupName)
+	}
+
+	client, err := rpc.client.GetKubernetesClient(rpc.apiserverURL, rpc.kubeconfig, time.Duration(5)*time.Second, time.Duration(60)*time.Minute)
+	if err != nil {
+		return errors.Wrap(err, "failed to get a Kubernetes client")
+	}
+
+	if rpc.force {
+		rpc.logger.Warnf("The nodes of node pool %s will be deleted without being drained", rpc.agentPool.Name)
+	} else {
+		for _, node := range nodes {
+			rpc.logger.Infof("Node %s will be cordoned and drained\n", node)
+		}
+		if err = rpc.drainNodes(client, nodes); err != nil {
+			return errors.Wrap(err, "Got error while draining the nodes to be deleted")
+		}
+	}
+
+	if len(vmsToDelete) > 0 {
+		errList := operations.ScaleDownVMs(rpc.client, rpc.logger, rpc.SubscriptionID.String(), rpc.resourceGroupName, vmsToDelete...)
+		if errList != nil {
+			var err error
+			format := "Node '%s' failed to delete with error: '%s'"
+			for element := errList.Front(); element != nil; element = element.Next() {
+				vmError, ok := element.Value.(*operations.VMScalingErrorDetails)
+				if ok {
+					if err == nil {
+						err = errors.Errorf(format, vmError.Name, vmError.Error.Error())
+					} else {
+						err = errors.Wrapf(err, format, vmError.Name, vmError.Error.Error())
+					}
+				}
+			}
+			return err
+		}
+	}
+	for _, vmss := range scaleSetsToDelete {
+		rpc.logger.Infof("Deleting VMSS %s in resource group %s ...", vmss, rpc.resourceGroupName)
+		if err = rpc.client.DeleteVirtualMachineScaleSet(ctx, rpc.resourceGroupName, vmss); err != nil {
+			return errors.Wrapf(err, "failed to delete VMSS %s", vmss)
+		}
+	}
+
+	for _, node := range nodes {
+		rpc.logger.Infof("Deleting node %s from the cluster", node)
+		if err = client.DeleteNode(node); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete node %s", node)
+		}
+	}
+	rpc.logger.Infof("Node pool %s was removed from the cluster", rpc.agentPool.Name)
+	return nil
+}
+
+// listVMs returns the names of the VMs of the availability set agent pool
+func (rpc *removePoolCmd) listVMs(ctx context.Context) ([]string, error) {
+	var vms []string
+	for page, err := rpc.client.ListVirtualMachines(ctx, rpc.resourceGroupName); page.NotDone(); err = page.Next() {
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get VMs in the resource group")
+		}
+		for _, vm := range page.Values() {
+			if resourceInAgentPool(*vm.Name, vm.Tags, rpc.agentPool.Name, rpc.nameSuffix) {
+				vms = append(vms, *vm.Name)
+			}
+		}
+	}
+	return vms, nil
+}
+
+// listScaleSets returns the names of the VM scale sets of the agent pool and the node names of their VMs
+func (rpc *removePoolCmd) listScaleSets(ctx context.Context) ([]string, []string, error) {
+	var scaleSets, nodes []string
+	for page, err := rpc.client.ListVirtualMachineScaleSets(ctx, rpc.resourceGroupName); page.NotDone(); err = page.NextWithContext(ctx) {
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to get VMSS list in the resource group")
+		}
+		for _, vmss := range page.Values() {
+			if !resourceInAgentPool(*vmss.Name, vmss.Tags, rpc.agentPool.Name, rpc.nameSuffix) {
+				continue
+			}
+			scaleSets = append(scaleSets, *vmss.Name)
+			for vmPage, err := rpc.client.ListVirtualMachineScaleSetVMs(ctx, rpc.resourceGroupName, *vmss.Name); vmPage.NotDone(); err = vmPage.NextWithContext(ctx) {
+				if err != nil {
+					return nil, nil, errors.Wrapf(err, "failed to get the VMs of VMSS %s", *vmss.Name)
+				}
+				for _, vm := range vmPage.Values() {
+					if vm.VirtualMachineScaleSetVMProperties == nil || vm.OsProfile == nil || vm.OsProfile.ComputerName == nil {
+						continue
+					}
+					nodes = append(nodes, strings.ToLower(*vm.OsProfile.ComputerName))
+				}
+			}
+		}
+	}
+	return scaleSets, nodes, nil
+}
+
+func (rpc *removePoolCmd) drainNodes(client armhelpers.KubernetesClient, nodes []string) error {
+	errChan := make(chan *operations.VMScalingErrorDetails, len(nodes))
+	defer close(errChan)
+	for _, node := range nodes {
+		go func(node string) {
+			err := operations.SafelyDrainNodeWithClient(client, rpc.logger, node, time.Duration(60)*time.Minute)
+			if err != nil {
+				log.Errorf("Failed to drain node %s, got error %v", node, err)
+				errChan <- &operations.VMScalingErrorDetails{Error: err, Name: node}
+				return
+			}
+			errChan <- nil
+		}(node)
+	}
+
+	var drainErr *operations.VMScalingErrorDetails
+	for range nodes {
+		if errDetails := <-errChan; errDetails != nil && drainErr == nil {
+			drainErr = errDetails
+		}
+	}
+	if drainErr != nil {
+		return errors.Wrapf(drainErr.Error, "Node %q failed to drain with error", drainErr.Name)
+	}
+	return nil
+}
+
+// saveAPIModel saves the api model without the removed agent pool
+func (rpc *removePoolCmd) saveAPIModel() error {
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: rpc.locale,
+		},
+	}
+	cs, apiVersion, err := apiloader.LoadContainerServiceFromFile(rpc.apiModelPath, false, true, nil)
+	if err != nil {
+		return err
+	}
+	pools := []*api.AgentPoolProfile{}
+	for _, pool := range cs.Properties.AgentPoolProfiles {
+		if pool.Name != rpc.agentPool.Name {
+			pools = append(pools, pool)
+		}
+	}
+	cs.Properties.AgentPoolProfiles = pools
+
+	b, err := apiloader.SerializeContainerService(cs, apiVersion)
+	if err != nil {
+		return err
+	}
+
+	f := helpers.FileSaver{
+		Translator: &i18n.Translator{
+			Locale: rpc.locale,
+		},
+	}
+	dir, file := filepath.Split(rpc.apiModelPath)
+	return f.SaveFile(dir, file, b)
+}
@@ -0,0 +1,225 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func TestNewRemovePoolCmd(t *testing.T) {
+	command := newRemovePoolCmd()
+	if command.Use != removePoolName || command.Short != removePoolShortDescription || command.Long != removePoolLongDescription {
+		t.Fatalf("removepool command should have use %s equal %s, short %s equal %s and long %s equal to %s", command.Use, removePoolName, command.Short, removePoolShortDescription, command.Long, removePoolLongDescription)
+	}
+
+	expectedFlags := []string{"location", "resource-group", "api-model", "node-pool", "apiserver", "force"}
+	for _, f := range expectedFlags {
+		if command.Flags().Lookup(f) == nil {
+			t.Fatalf("removepool command should have flag %s", f)
+		}
+	}
+
+	command.SetArgs([]string{})
+	if err := command.Execute(); err == nil {
+		t.Fatalf("expected an error when calling removepool with no arguments")
+	}
+}
+
+func TestRemovePoolCmdValidate(t *testing.T) {
+	cases := []struct {
+		rpc                  *removePoolCmd
+		expectedErr          string
+		expectedAPIServerURL string
+	}{
+		{
+			rpc:         &removePoolCmd{location: "centralus", apiModelPath: "apimodel.json", agentPoolToRemove: "agentpool1", masterFQDN: "example.com"},
+			expectedErr: "--resource-group must be specified",
+		},
+		{
+			rpc:         &removePoolCmd{resourceGroupName: "testRG", apiModelPath: "apimodel.json", agentPoolToRemove: "agentpool1", masterFQDN: "example.com"},
+			expectedErr: "--location must be specified",
+		},
+		{
+			rpc:         &removePoolCmd{resourceGroupName: "testRG", location: "centralus", agentPoolToRemove: "agentpool1", masterFQDN: "example.com"},
+			expectedErr: "--api-model must be specified",
+		},
+		{
+			rpc:         &removePoolCmd{resourceGroupName: "testRG", location: "centralus", apiModelPath: "apimodel.json", masterFQDN: "example.com"},
+			expectedErr: "--node-pool must be specified",
+		},
+		{
+			rpc:         &removePoolCmd{resourceGroupName: "testRG", location: "centralus", apiModelPath: "apimodel.json", agentPoolToRemove: "agentpool1", force: true},
+			expectedErr: "--apiserver must be specified",
+		},
+		{
+			rpc:         &removePoolCmd{resourceGroupName: "testRG", location: "centralus", apiModelPath: "apimodel.json", agentPoolToRemove: "agentpool1", masterFQDN: "http://example.com"},
+			expectedErr: "apiserver URL cannot be insecure http://",
+		},
+		{
+			rpc:                  &removePoolCmd{resourceGroupName: "testRG", location: "centralus", apiModelPath: "apimodel.json", agentPoolToRemove: "agentpool1", masterFQDN: "example.com"},
+			expectedAPIServerURL: "https://example.com",
+		},
+		{
+			rpc:                  &removePoolCmd{resourceGroupName: "testRG", location: "centralus", apiModelPath: "apimodel.json", agentPoolToRemove: "agentpool1", masterFQDN: "https://example.com"},
+			expectedAPIServerURL: "https://example.com",
+		},
+	}
+
+	for _, c := range cases {
+		err := c.rpc.validate(&cobra.Command{})
+		if c.expectedErr == "" {
+			if err != nil {
+				t.Fatalf("expected validate removepool command to return no error, but instead got %s", err.Error())
+			}
+			if c.rpc.apiserverURL != c.expectedAPIServerURL {
+				t.Fatalf("expected validate removepool command to set the apiserver URL %s, but instead got %s", c.expectedAPIServerURL, c.rpc.apiserverURL)
+			}
+		} else if err == nil || err.Error() != c.expectedErr {
+			t.Fatalf("expected validate removepool command to return error %s, but instead got %v", c.expectedErr, err)
+		}
+	}
+}
+
+func TestRemovePoolSetAgentPool(t *testing.T) {
+	cases := []struct {
+		pools       []string
+		expectedErr string
+	}{
+		{pools: []string{"agentpool1", "agentpool2"}},
+		{pools: []string{"agentpool2"}, expectedErr: "node pool agentpool1 was not found in the deployed api model"},
+		{pools: []string{"agentpool1"}, expectedErr: "node pool agentpool1 is the only agent pool of the cluster and cannot be removed"},
+	}
+	for _, c := range cases {
+		cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 1, false)
+		cs.Properties.AgentPoolProfiles = nil
+		for _, name := range c.pools {
+			cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &api.AgentPoolProfile{Name: name})
+		}
+		rpc := &removePoolCmd{containerService: cs, agentPoolToRemove: "agentpool1"}
+		err := rpc.setAgentPool()
+		if c.expectedErr == "" {
+			if err != nil || rpc.agentPool.Name != "agentpool1" {
+				t.Fatalf("expected the agent pool agentpool1 to be removed, but instead got error %v", err)
+			}
+		} else if err == nil || err.Error() != c.expectedErr {
+			t.Fatalf("expected setting the agent pool to remove to return error %s, but instead got %v", c.expectedErr, err)
+		}
+	}
+}
+
+func TestRemoveAgentPool(t *testing.T) {
+	newRemovePool := func(availabilityProfile string, force bool) (*removePoolCmd, *armhelpers.MockAKSEngineClient) {
+		client := &armhelpers.MockAKSEngineClient{MockKubernetesClient: &armhelpers.MockKubernetesClient{}}
+		return &removePoolCmd{
+			resourceGroupName: "testRG",
+			force:             force,
+			agentPool:         &api.AgentPoolProfile{Name: "agentpool1", AvailabilityProfile: availabilityProfile},
+			client:            client,
+			nameSuffix:        "12345678",
+			logger:            log.NewEntry(log.New()),
+		}, client
+	}
+	vmss := func(client *armhelpers.MockAKSEngineClient) {
+		client.FakeListVirtualMachineScaleSetsResult = func() []compute.VirtualMachineScaleSet {
+			return []compute.VirtualMachineScaleSet{{
+				Name: to.StringPtr("k8s-agentpool1-12345678-vmss"),
+				Tags: map[string]*string{"poolName": to.StringPtr("agentpool1"), "resourceNameSuffix": to.StringPtr("12345678")},
+			}}
+		}
+	}
+
+	cases := []struct {
+		name                string
+		availabilityProfile string
+		force               bool
+		setup               func(*armhelpers.MockAKSEngineClient)
+		expectedErr         bool
+	}{
+		{name: "availability set", availabilityProfile: api.AvailabilitySet},
+		{name: "availability set without draining", availabilityProfile: api.AvailabilitySet, force: true},
+		{name: "scale set", availabilityProfile: api.VirtualMachineScaleSets, setup: vmss},
+		{
+			name:                "VM deletion failure",
+			availabilityProfile: api.AvailabilitySet,
+			setup:               func(c *armhelpers.MockAKSEngineClient) { c.FailDeleteVirtualMachine = true },
+			expectedErr:         true,
+		},
+		{
+			name:                "scale set deletion failure",
+			availabilityProfile: api.VirtualMachineScaleSets,
+			setup: func(c *armhelpers.MockAKSEngineClient) {
+				vmss(c)
+				c.FailDeleteVirtualMachineScaleSet = true
+			},
+			expectedErr: true,
+		},
+		{
+			name:                "node deletion failure",
+			availabilityProfile: api.AvailabilitySet,
+			setup:               func(c *armhelpers.MockAKSEngineClient) { c.MockKubernetesClient.FailDeleteNode = true },
+			expectedErr:         true,
+		},
+		{
+			name:                "drain failure",
+			availabilityProfile: api.AvailabilitySet,
+			setup:               func(c *armhelpers.MockAKSEngineClient) { c.MockKubernetesClient.FailGetNode = true },
+			expectedErr:         true,
+		},
+	}
+	for _, c := range cases {
+		rpc, client := newRemovePool(c.availabilityProfile, c.force)
+		if c.setup != nil {
+			c.setup(client)
+		}
+		err := rpc.removeAgentPool(context.Background())
+		if c.expectedErr && err == nil {
+			t.Fatalf("%s: expected removing the agent pool to return an error", c.name)
+		} else if !c.expectedErr && err != nil {
+			t.Fatalf("%s: expected removing the agent pool to return no error, but instead got %s", c.name, err)
+		}
+	}
+}
+
+func TestRemovePoolSaveAPIModel(t *testing.T) {
+	apiModel, err := ioutil.ReadFile("../pkg/engine/testdata/simple/kubernetes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "removepool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	apiModelPath := filepath.Join(dir, "apimodel.json")
+	if err = ioutil.WriteFile(apiModelPath, apiModel, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	rpc := &removePoolCmd{apiModelPath: apiModelPath, agentPool: &api.AgentPoolProfile{Name: "agentpool2"}}
+	if err = rpc.saveAPIModel(); err != nil {
+		t.Fatalf("unexpected error saving the api model: %s", err)
+	}
+
+	apiloader := &api.Apiloader{Translator: &i18n.Translator{}}
+	cs, _, err := apiloader.LoadContainerServiceFromFile(apiModelPath, false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pools := cs.Properties.AgentPoolProfiles
+	if len(pools) != 1 || pools[0].Name != "agentpool1" {
+		t.Fatalf("expected the agent pool agentpool2 to be removed from the api model, but instead got %d agent pools", len(pools))
+	}
+}
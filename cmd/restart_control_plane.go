@@ -0,0 +1,372 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/engine"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	restartControlPlaneName             = "restart-control-plane"
+	restartControlPlaneShortDescription = "Restart the control plane components of an existing Kubernetes cluster"
+	restartControlPlaneLongDescription  = "Restart etcd, the apiserver, the controller manager and the scheduler of a cluster built with AKS Engine, one master at a time, waiting for the etcd cluster to be healthy and the components to be Ready before restarting the next one"
+)
+
+// The control plane components, in the order they are restarted on each master
+const (
+	controlPlaneEtcd              = "etcd"
+	controlPlaneAPIServer         = "kube-apiserver"
+	controlPlaneControllerManager = "kube-controller-manager"
+	controlPlaneScheduler         = "kube-scheduler"
+)
+
+var controlPlaneComponents = []string{controlPlaneEtcd, controlPlaneAPIServer, controlPlaneControllerManager, controlPlaneScheduler}
+
+// etcdClusterHealthCmd succeeds if all the members of the etcd cluster are healthy
+const etcdClusterHealthCmd = "sudo bash -c 'ETCDCTL_API=3 etcdctl --command-timeout=10s --cert=/etc/kubernetes/certs/etcdclient.crt --key=/etc/kubernetes/certs/etcdclient.key --cacert=/etc/kubernetes/certs/ca.crt --endpoints=https://127.0.0.1:2379 endpoint health --cluster'"
+
+const (
+	defaultRestartControlPlaneTimeout = 10 * time.Minute
+	restartControlPlanePollInterval   = 10 * time.Second
+)
+
+type restartControlPlaneCmd struct {
+	authArgs
+
+	// user input
+	resourceGroupName string
+	location          string
+	apiModelPath      string
+	sshFilepath       string
+	masterFQDN        string
+	components        []string
+	timeout           time.Duration
+
+	// derived
+	containerService   *api.ContainerService
+	apiVersion         string
+	locale             *gotext.Locale
+	client             armhelpers.AKSEngineClient
+	kubeClient         armhelpers.KubernetesClient
+	sshConfig          *ssh.ClientConfig
+	sshCommandExecuter func(command, masterFQDN, hostname string, port string, config *ssh.ClientConfig) (string, error)
+	pollInterval       time.Duration
+}
+
+func newRestartControlPlaneCmd() *cobra.Command {
+	rcp := restartControlPlaneCmd{
+		sshCommandExecuter: executeCmd,
+		pollInterval:       restartControlPlanePollInterval,
+	}
+
+	command := &cobra.Command{
+		Use:   restartControlPlaneName,
+		Short: restartControlPlaneShortDescription,
+		Long:  restartControlPlaneLongDescription,
+		RunE:  rcp.run,
+	}
+
+	f := command.Flags()
+	f.StringVarP(&rcp.location, "location", "l", "", "location the cluster is deployed in (required)")
+	f.StringVarP(&rcp.resourceGroupName, "resource-group", "g", "", "the resource group where the cluster is deployed (required)")
+	f.StringVarP(&rcp.apiModelPath, "api-model", "m", "", "path to the generated apimodel.json file (required)")
+	f.StringVar(&rcp.sshFilepath, "ssh", "", "the filepath of a valid private ssh key to access the cluster's masters (required)")
+	f.StringVar(&rcp.masterFQDN, "apiserver", "", "apiserver endpoint (required)")
+	f.StringSliceVar(&rcp.components, "components", controlPlaneComponents, fmt.Sprintf("the control plane components to restart, any of %s", strings.Join(controlPlaneComponents, ", ")))
+	f.DurationVar(&rcp.timeout, "timeout", defaultRestartControlPlaneTimeout, "how long to wait for each component to be healthy after its restart")
+
+	addAuthFlags(&rcp.authArgs, f)
+
+	return command
+}
+
+func (rcp *restartControlPlaneCmd) validate(cmd *cobra.Command) error {
+	log.Debugln("validating restart-control-plane command line arguments...")
+	var err error
+
+	rcp.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if rcp.resourceGroupName == "" {
+		cmd.Usage()
+		return errors.New("--resource-group must be specified")
+	}
+
+	if rcp.location == "" {
+		cmd.Usage()
+		return errors.New("--location must be specified")
+	}
+	rcp.location = helpers.NormalizeAzureRegion(rcp.location)
+
+	if rcp.apiModelPath == "" {
+		cmd.Usage()
+		return errors.New("--api-model must be specified")
+	}
+
+	if rcp.sshFilepath == "" {
+		cmd.Usage()
+		return errors.New("--ssh must be specified")
+	}
+
+	if rcp.masterFQDN == "" {
+		cmd.Usage()
+		return errors.New("--apiserver must be specified")
+	}
+	rcp.masterFQDN = strings.TrimPrefix(rcp.masterFQDN, "https://")
+
+	if len(rcp.components) == 0 {
+		return errors.New("--components must not be empty")
+	}
+	for _, component := range rcp.components {
+		if !isControlPlaneComponent(component) {
+			return errors.Errorf("unknown control plane component %s, expected any of %s", component, strings.Join(controlPlaneComponents, ", "))
+		}
+	}
+
+	if rcp.timeout <= 0 {
+		return errors.New("--timeout must be greater than 0")
+	}
+	return nil
+}
+
+func (rcp *restartControlPlaneCmd) load() error {
+	var err error
+
+	if _, err = os.Stat(rcp.apiModelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", rcp.apiModelPath)
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: rcp.locale,
+		},
+	}
+	rcp.containerService, rcp.apiVersion, err = apiloader.LoadContainerServiceFromFile(rcp.apiModelPath, true, false, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+
+	if rcp.containerService.Properties.MasterProfile == nil {
+		return errors.New("restart-control-plane requires a cluster with a master profile")
+	}
+
+	kubeconfig, err := engine.GenerateKubeConfig(rcp.containerService.Properties, rcp.location)
+	if err != nil {
+		return errors.New("Unable to derive kubeconfig from api model")
+	}
+
+	if _, err = os.Stat(rcp.sshFilepath); os.IsNotExist(err) {
+		return errors.Errorf("specified ssh filepath does not exist (%s)", rcp.sshFilepath)
+	}
+	rcp.sshConfig = &ssh.ClientConfig{
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		User:            rcp.containerService.Properties.LinuxProfile.AdminUsername,
+		Auth: []ssh.AuthMethod{
+			publicKeyFile(rcp.sshFilepath),
+		},
+	}
+
+	if err = rcp.authArgs.validateAuthArgs(); err != nil {
+		return err
+	}
+
+	if rcp.client, err = rcp.authArgs.getClient(); err != nil {
+		return errors.Wrap(err, "failed to get client")
+	}
+
+	if rcp.kubeClient, err = rcp.client.GetKubernetesClient("", kubeconfig, time.Second*1, rcp.timeout); err != nil {
+		return errors.Wrap(err, "failed to get a Kubernetes client")
+	}
+	return nil
+}
+
+func (rcp *restartControlPlaneCmd) run(cmd *cobra.Command, args []string) error {
+	if err := rcp.validate(cmd); err != nil {
+		return errors.Wrap(err, "failed to validate restart-control-plane command")
+	}
+	if err := rcp.load(); err != nil {
+		return errors.Wrap(err, "failed to load existing container service")
+	}
+
+	masters, err := rcp.listMasters()
+	if err != nil {
+		return err
+	}
+	if err = rcp.restartControlPlane(masters); err != nil {
+		return err
+	}
+	log.Infof("Successfully restarted %s on %d masters", strings.Join(rcp.components, ", "), len(masters))
+	return nil
+}
+
+// listMasters returns the master nodes of the cluster sorted by name
+func (rcp *restartControlPlaneCmd) listMasters() ([]v1.Node, error) {
+	nodeList, err := rcp.kubeClient.ListNodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cluster nodes")
+	}
+	var masters []v1.Node
+	for _, node := range nodeList.Items {
+		if strings.Contains(node.Name, "master") {
+			masters = append(masters, node)
+		}
+	}
+	if len(masters) == 0 {
+		return nil, errors.New("no master nodes were found in the cluster")
+	}
+	sort.Slice(masters, func(i, j int) bool { return masters[i].Name < masters[j].Name })
+	return masters, nil
+}
+
+// restartControlPlane restarts the components on one master at a time. etcd is restarted first and must be healthy
+// across the cluster before the next component or master, so that the etcd cluster never loses more than one member.
+func (rcp *restartControlPlaneCmd) restartControlPlane(masters []v1.Node) error {
+	for i := range masters {
+		master := &masters[i]
+		for _, component := range controlPlaneComponents {
+			if !rcp.restarts(component) {
+				continue
+			}
+			log.Infof("Restarting %s on master %s", component, master.Name)
+			var err error
+			if component == controlPlaneEtcd {
+				err = rcp.restartEtcd(master)
+			} else {
+				err = rcp.restartStaticPod(master, component)
+			}
+			if err != nil {
+				return errors.Wrapf(err, "restarting %s on master %s", component, master.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// restartEtcd restarts etcd on the master and waits for all the members of the etcd cluster to be healthy
+func (rcp *restartControlPlaneCmd) restartEtcd(master *v1.Node) error {
+	if out, err := rcp.sshCommandExecuter("sudo systemctl restart etcd", rcp.masterFQDN, master.Name, "22", rcp.sshConfig); err != nil {
+		log.Printf("Command `sudo systemctl restart etcd` output: %s\n", out)
+		return errors.Wrap(err, "failed to restart etcd")
+	}
+	return rcp.waitFor("the etcd cluster to be healthy", func() (bool, error) {
+		out, err := rcp.sshCommandExecuter(etcdClusterHealthCmd, rcp.masterFQDN, master.Name, "22", rcp.sshConfig)
+		if err != nil {
+			log.Debugf("etcd cluster is not healthy yet: %s", out)
+			return false, nil
+		}
+		return true, nil
+	})
+}
+
+// restartStaticPod restarts the static pod of the component on the master and waits for the new pod to be Ready, which
+// also requires the apiserver to answer
+func (rcp *restartControlPlaneCmd) restartStaticPod(master *v1.Node, component string) error {
+	previous, err := rcp.getControlPlanePod(master, component)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the %s pod", component)
+	}
+	var previousUID types.UID
+	if previous != nil {
+		previousUID = previous.UID
+	}
+
+	cmd := fmt.Sprintf(restartStaticPodCmd, component)
+	if out, err := rcp.sshCommandExecuter(cmd, rcp.masterFQDN, master.Name, "22", rcp.sshConfig); err != nil {
+		log.Printf("Command %s output: %s\n", cmd, out)
+		return errors.Wrapf(err, "failed to restart %s", component)
+	}
+
+	return rcp.waitFor(fmt.Sprintf("%s to be Ready", component), func() (bool, error) {
+		pod, err := rcp.getControlPlanePod(master, component)
+		if err != nil {
+			log.Debugf("the apiserver is not available yet: %s", err)
+			return false, nil
+		}
+		return pod != nil && pod.UID != previousUID && isPodReady(pod), nil
+	})
+}
+
+// getControlPlanePod returns the mirror pod of the static pod of the component on the master, or nil if there is none
+func (rcp *restartControlPlaneCmd) getControlPlanePod(master *v1.Node, component string) (*v1.Pod, error) {
+	pods, err := rcp.kubeClient.ListPods(master)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Namespace == kubeSystemNamespace && pod.Name == component+"-"+master.Name {
+			return pod, nil
+		}
+	}
+	return nil, nil
+}
+
+// waitFor polls the condition until it is met or the timeout expires
+func (rcp *restartControlPlaneCmd) waitFor(description string, condition func() (bool, error)) error {
+	log.Infof("Waiting for %s", description)
+	deadline := time.Now().Add(rcp.timeout)
+	for {
+		done, err := condition()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("timed out after %s waiting for %s", rcp.timeout, description)
+		}
+		time.Sleep(rcp.pollInterval)
+	}
+}
+
+// restarts returns true if the component is to be restarted
+func (rcp *restartControlPlaneCmd) restarts(component string) bool {
+	for _, c := range rcp.components {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
+
+func isControlPlaneComponent(component string) bool {
+	for _, c := range controlPlaneComponents {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
+
+func isPodReady(pod *v1.Pod) bool {
+	if pod.Status.Phase != v1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
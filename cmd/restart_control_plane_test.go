@@ -0,0 +1,254 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestNewRestartControlPlaneCmd(t *testing.T) {
+	command := newRestartControlPlaneCmd()
+	if command.Use != restartControlPlaneName || command.Short != restartControlPlaneShortDescription || command.Long != restartControlPlaneLongDescription {
+		t.Fatalf("restart-control-plane command should have use %s equal %s, short %s equal %s and long %s equal to %s", command.Use, restartControlPlaneName, command.Short, restartControlPlaneShortDescription, command.Long, restartControlPlaneLongDescription)
+	}
+
+	expectedFlags := []string{"location", "resource-group", "api-model", "ssh", "apiserver", "components", "timeout"}
+	for _, f := range expectedFlags {
+		if command.Flags().Lookup(f) == nil {
+			t.Fatalf("restart-control-plane command should have flag %s", f)
+		}
+	}
+
+	command.SetArgs([]string{})
+	if err := command.Execute(); err == nil {
+		t.Fatalf("expected an error when calling restart-control-plane with no arguments")
+	}
+}
+
+func TestRestartControlPlaneCmdValidate(t *testing.T) {
+	newRestartControlPlane := func() *restartControlPlaneCmd {
+		return &restartControlPlaneCmd{
+			resourceGroupName: "testRG",
+			location:          "centralus",
+			apiModelPath:      "apimodel.json",
+			sshFilepath:       "id_rsa",
+			masterFQDN:        "https://example.com",
+			components:        controlPlaneComponents,
+			timeout:           defaultRestartControlPlaneTimeout,
+		}
+	}
+	cases := []struct {
+		name        string
+		setup       func(*restartControlPlaneCmd)
+		expectedErr string
+	}{
+		{
+			name:  "valid",
+			setup: func(rcp *restartControlPlaneCmd) {},
+		},
+		{
+			name:        "missing resource group",
+			setup:       func(rcp *restartControlPlaneCmd) { rcp.resourceGroupName = "" },
+			expectedErr: "--resource-group must be specified",
+		},
+		{
+			name:        "missing ssh key",
+			setup:       func(rcp *restartControlPlaneCmd) { rcp.sshFilepath = "" },
+			expectedErr: "--ssh must be specified",
+		},
+		{
+			name:        "missing apiserver",
+			setup:       func(rcp *restartControlPlaneCmd) { rcp.masterFQDN = "" },
+			expectedErr: "--apiserver must be specified",
+		},
+		{
+			name:        "unknown component",
+			setup:       func(rcp *restartControlPlaneCmd) { rcp.components = []string{"etcd", "kube-proxy"} },
+			expectedErr: "unknown control plane component kube-proxy, expected any of etcd, kube-apiserver, kube-controller-manager, kube-scheduler",
+		},
+		{
+			name:        "no components",
+			setup:       func(rcp *restartControlPlaneCmd) { rcp.components = nil },
+			expectedErr: "--components must not be empty",
+		},
+		{
+			name:        "zero timeout",
+			setup:       func(rcp *restartControlPlaneCmd) { rcp.timeout = 0 },
+			expectedErr: "--timeout must be greater than 0",
+		},
+	}
+	for _, tc := range cases {
+		c := tc
+		t.Run(c.name, func(t *testing.T) {
+			rcp := newRestartControlPlane()
+			c.setup(rcp)
+			err := rcp.validate(&cobra.Command{})
+			if c.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %s", err)
+				}
+				if rcp.masterFQDN != "example.com" {
+					t.Fatalf("expected the apiserver scheme to be trimmed, got %s", rcp.masterFQDN)
+				}
+				return
+			}
+			if err == nil || err.Error() != c.expectedErr {
+				t.Fatalf("expected error %q, got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+// fakeControlPlane records the commands run on the masters and recreates the mirror pod of a static pod when it is
+// restarted, as the kubelet does
+type fakeControlPlane struct {
+	kubeClient   *armhelpers.MockKubernetesClient
+	commands     []string
+	etcdHealthy  bool
+	failRestarts bool
+}
+
+func newFakeControlPlane(masters ...string) *fakeControlPlane {
+	f := &fakeControlPlane{
+		kubeClient:  &armhelpers.MockKubernetesClient{NodeList: &v1.NodeList{}, PodsList: &v1.PodList{}},
+		etcdHealthy: true,
+	}
+	f.kubeClient.NodeList.Items = append(f.kubeClient.NodeList.Items, v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "k8s-agentpool1-12345678-vmss000000"}})
+	for _, master := range masters {
+		f.kubeClient.NodeList.Items = append(f.kubeClient.NodeList.Items, v1.Node{ObjectMeta: metav1.ObjectMeta{Name: master}})
+		for _, component := range controlPlaneComponents[1:] {
+			f.kubeClient.PodsList.Items = append(f.kubeClient.PodsList.Items, readyPod(component, master, "0"))
+		}
+	}
+	return f
+}
+
+func readyPod(component, node, uid string) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: component + "-" + node, Namespace: kubeSystemNamespace, UID: types.UID(uid)},
+		Status: v1.PodStatus{
+			Phase:      v1.PodRunning,
+			Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+		},
+	}
+}
+
+func (f *fakeControlPlane) executeCmd(command, masterFQDN, hostname string, port string, config *ssh.ClientConfig) (string, error) {
+	f.commands = append(f.commands, hostname+": "+command)
+	if command == etcdClusterHealthCmd {
+		if !f.etcdHealthy {
+			return "unhealthy", errors.New("exit status 1")
+		}
+		return "healthy", nil
+	}
+	if f.failRestarts {
+		return "", errors.New("exit status 1")
+	}
+	for _, component := range controlPlaneComponents[1:] {
+		if command == fmt.Sprintf(restartStaticPodCmd, component) {
+			for i, pod := range f.kubeClient.PodsList.Items {
+				if pod.Name == component+"-"+hostname {
+					f.kubeClient.PodsList.Items[i] = readyPod(component, hostname, "1")
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+func TestRestartControlPlane(t *testing.T) {
+	masters := []string{"k8s-master-12345678-1", "k8s-master-12345678-0"}
+	newRestartControlPlane := func(f *fakeControlPlane, components []string) *restartControlPlaneCmd {
+		return &restartControlPlaneCmd{
+			masterFQDN:         "valid",
+			components:         components,
+			timeout:            50 * time.Millisecond,
+			pollInterval:       time.Millisecond,
+			kubeClient:         f.kubeClient,
+			sshCommandExecuter: f.executeCmd,
+		}
+	}
+
+	t.Run("restarts the masters one at a time in order", func(t *testing.T) {
+		f := newFakeControlPlane(masters...)
+		rcp := newRestartControlPlane(f, []string{controlPlaneScheduler, controlPlaneEtcd})
+		nodes, err := rcp.listMasters()
+		if err != nil {
+			t.Fatalf("unexpected error listing the masters: %s", err)
+		}
+		if err = rcp.restartControlPlane(nodes); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		expected := []string{
+			"k8s-master-12345678-0: sudo systemctl restart etcd",
+			"k8s-master-12345678-0: " + etcdClusterHealthCmd,
+			"k8s-master-12345678-0: " + fmt.Sprintf(restartStaticPodCmd, controlPlaneScheduler),
+			"k8s-master-12345678-1: sudo systemctl restart etcd",
+			"k8s-master-12345678-1: " + etcdClusterHealthCmd,
+			"k8s-master-12345678-1: " + fmt.Sprintf(restartStaticPodCmd, controlPlaneScheduler),
+		}
+		if strings.Join(f.commands, "\n") != strings.Join(expected, "\n") {
+			t.Fatalf("expected commands\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(f.commands, "\n"))
+		}
+	})
+
+	t.Run("stops when the etcd cluster does not recover", func(t *testing.T) {
+		f := newFakeControlPlane(masters...)
+		f.etcdHealthy = false
+		rcp := newRestartControlPlane(f, controlPlaneComponents)
+		nodes, _ := rcp.listMasters()
+		err := rcp.restartControlPlane(nodes)
+		if err == nil || !strings.Contains(err.Error(), "restarting etcd on master k8s-master-12345678-0: timed out") {
+			t.Fatalf("expected an etcd timeout error, got %v", err)
+		}
+		for _, c := range f.commands {
+			if strings.HasPrefix(c, "k8s-master-12345678-1") {
+				t.Fatalf("expected the second master not to be restarted, got command %s", c)
+			}
+		}
+	})
+
+	t.Run("times out when the pod is not recreated", func(t *testing.T) {
+		f := newFakeControlPlane(masters...)
+		rcp := newRestartControlPlane(f, []string{controlPlaneAPIServer})
+		rcp.sshCommandExecuter = func(command, masterFQDN, hostname string, port string, config *ssh.ClientConfig) (string, error) {
+			return "", nil
+		}
+		nodes, _ := rcp.listMasters()
+		err := rcp.restartControlPlane(nodes)
+		if err == nil || !strings.Contains(err.Error(), "waiting for kube-apiserver to be Ready") {
+			t.Fatalf("expected a kube-apiserver timeout error, got %v", err)
+		}
+	})
+
+	t.Run("fails when the restart fails", func(t *testing.T) {
+		f := newFakeControlPlane(masters...)
+		f.failRestarts = true
+		rcp := newRestartControlPlane(f, []string{controlPlaneControllerManager})
+		nodes, _ := rcp.listMasters()
+		err := rcp.restartControlPlane(nodes)
+		if err == nil || !strings.Contains(err.Error(), "failed to restart kube-controller-manager") {
+			t.Fatalf("expected a restart error, got %v", err)
+		}
+	})
+
+	t.Run("fails without masters", func(t *testing.T) {
+		f := newFakeControlPlane()
+		rcp := newRestartControlPlane(f, controlPlaneComponents)
+		if _, err := rcp.listMasters(); err == nil {
+			t.Fatalf("expected an error when the cluster has no masters")
+		}
+	})
+}
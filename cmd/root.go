@@ -69,7 +69,17 @@ func NewRootCmd() *cobra.Command {
 	rootCmd.AddCommand(newOrchestratorsCmd())
 	rootCmd.AddCommand(newUpgradeCmd())
 	rootCmd.AddCommand(newScaleCmd())
+	rootCmd.AddCommand(newAddPoolCmd())
+	rootCmd.AddCommand(newRemovePoolCmd())
+	rootCmd.AddCommand(newRestartControlPlaneCmd())
+	rootCmd.AddCommand(newReconcileCmd())
 	rootCmd.AddCommand(newRotateCertsCmd())
+	rootCmd.AddCommand(newGetLogsCmd())
+	rootCmd.AddCommand(newCloneCmd())
+	rootCmd.AddCommand(newCheckCISCmd())
+	rootCmd.AddCommand(newValidateCmd())
+	rootCmd.AddCommand(newDiffCmd())
+	rootCmd.AddCommand(newGetImagesCmd())
 	rootCmd.AddCommand(getCompletionCmd(rootCmd))
 
 	return rootCmd
@@ -111,8 +121,13 @@ type authArgs struct {
 	PrivateKeyPath  string
 	IdentitySystem  string
 	language        string
+	// AuxiliaryTenantIDs are the tenants of resources of other tenants the cluster uses, such as shared image galleries
+	AuxiliaryTenantIDs []string
 }
 
+// maxAuxiliaryTenants is the number of auxiliary tenants ARM accepts the tokens of in a request
+const maxAuxiliaryTenants = 3
+
 func addAuthFlags(authArgs *authArgs, f *flag.FlagSet) {
 	f.StringVar(&authArgs.RawAzureEnvironment, "azure-env", "AzurePublicCloud", "the target Azure cloud")
 	f.StringVarP(&authArgs.rawSubscriptionID, "subscription-id", "s", "", "azure subscription id (required)")
@@ -123,6 +138,7 @@ func addAuthFlags(authArgs *authArgs, f *flag.FlagSet) {
 	f.StringVar(&authArgs.PrivateKeyPath, "private-key-path", "", "path to private key (used with --auth-method=client_certificate)")
 	f.StringVar(&authArgs.IdentitySystem, "identity-system", "azure_ad", "identity system (default:`azure_ad`, `adfs`)")
 	f.StringVar(&authArgs.language, "language", "en-us", "language to return error messages in")
+	f.StringSliceVar(&authArgs.AuxiliaryTenantIDs, "auxiliary-tenant-ids", nil, "ids of up to 3 other tenants the service principal authenticates to, to use resources such as shared image galleries in these tenants (used with --auth-method=[client_secret|client_certificate])")
 }
 
 //this allows the authArgs to be stubbed behind the authProvider interface, and be its own provider when not in tests.
@@ -158,6 +174,23 @@ func (authArgs *authArgs) validateAuthArgs() error {
 		authArgs.SubscriptionID = subID
 	}
 
+	if len(authArgs.AuxiliaryTenantIDs) > 0 {
+		if authArgs.AuthMethod != "client_secret" && authArgs.AuthMethod != "client_certificate" {
+			return errors.New(`--auxiliary-tenant-ids can only be specified when --auth-method="client_secret" or --auth-method="client_certificate"`)
+		}
+		if authArgs.isAzureStackCloud() {
+			return errors.New("--auxiliary-tenant-ids is not supported on Azure Stack")
+		}
+		if len(authArgs.AuxiliaryTenantIDs) > maxAuxiliaryTenants {
+			return errors.Errorf("--auxiliary-tenant-ids accepts at most %d tenants", maxAuxiliaryTenants)
+		}
+		for _, tenantID := range authArgs.AuxiliaryTenantIDs {
+			if _, err := uuid.FromString(tenantID); err != nil {
+				return errors.Errorf("--auxiliary-tenant-ids: %q is not a valid UUID", tenantID)
+			}
+		}
+	}
+
 	_, err := azure.EnvironmentFromName(authArgs.RawAzureEnvironment)
 	if err != nil {
 		return errors.New("failed to parse --azure-env as a valid target Azure cloud environment")
@@ -237,6 +270,18 @@ func (authArgs *authArgs) getAzureClient() (armhelpers.AKSEngineClient, error) {
 		return nil, err
 	}
 	client.AddAcceptLanguages([]string{authArgs.language})
+	if len(authArgs.AuxiliaryTenantIDs) > 0 {
+		var tokens []string
+		if authArgs.AuthMethod == "client_certificate" {
+			tokens, err = armhelpers.GetAuxiliaryTokensWithClientCertificateFile(env, authArgs.ClientID.String(), authArgs.CertificatePath, authArgs.PrivateKeyPath, authArgs.AuxiliaryTenantIDs)
+		} else {
+			tokens, err = armhelpers.GetAuxiliaryTokensWithClientSecret(env, authArgs.ClientID.String(), authArgs.ClientSecret, authArgs.AuxiliaryTenantIDs)
+		}
+		if err != nil {
+			return nil, err
+		}
+		client.AddAuxiliaryTokens(tokens)
+	}
 	return client, nil
 }
 
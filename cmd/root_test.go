@@ -42,7 +42,7 @@ func TestNewRootCmd(t *testing.T) {
 	if command.Use != rootName || command.Short != rootShortDescription || command.Long != rootLongDescription {
 		t.Fatalf("root command should have use %s equal %s, short %s equal %s and long %s equal to %s", command.Use, rootName, command.Short, rootShortDescription, command.Long, rootLongDescription)
 	}
-	expectedCommands := []*cobra.Command{getCompletionCmd(command), newDeployCmd(), newGenerateCmd(), newGetVersionsCmd(), newOrchestratorsCmd(), newRotateCertsCmd(), newScaleCmd(), newUpgradeCmd(), newVersionCmd()}
+	expectedCommands := []*cobra.Command{newAddPoolCmd(), newCheckCISCmd(), newCloneCmd(), getCompletionCmd(command), newDeployCmd(), newDiffCmd(), newGenerateCmd(), newGetImagesCmd(), newGetLogsCmd(), newGetVersionsCmd(), newOrchestratorsCmd(), newReconcileCmd(), newRemovePoolCmd(), newRestartControlPlaneCmd(), newRotateCertsCmd(), newScaleCmd(), newUpgradeCmd(), newValidateCmd(), newVersionCmd()}
 	rc := command.Commands()
 	for i, c := range expectedCommands {
 		if rc[i].Use != c.Use {
@@ -200,6 +200,61 @@ func TestWriteCustomCloudProfile(t *testing.T) {
 	}
 }
 
+func TestValidateAuthArgsAuxiliaryTenantIDs(t *testing.T) {
+	const (
+		subscriptionID = "cc6b141e-6afc-4786-9bf6-e3b9a5601460"
+		tenantID       = "72f988bf-86f1-41af-91ab-2d7cd011db47"
+	)
+	for _, test := range []struct {
+		desc          string
+		authArgs      authArgs
+		expectedError string
+	}{
+		{
+			"client_secret with auxiliary tenants is valid",
+			authArgs{AuthMethod: "client_secret", rawClientID: subscriptionID, ClientSecret: "secret", AuxiliaryTenantIDs: []string{tenantID, tenantID}},
+			"",
+		},
+		{
+			"cli auth with auxiliary tenants is invalid",
+			authArgs{AuthMethod: "cli", AuxiliaryTenantIDs: []string{tenantID}},
+			`--auxiliary-tenant-ids can only be specified when --auth-method="client_secret" or --auth-method="client_certificate"`,
+		},
+		{
+			"auxiliary tenants on Azure Stack are invalid",
+			authArgs{AuthMethod: "client_secret", rawClientID: subscriptionID, ClientSecret: "secret", RawAzureEnvironment: api.AzureStackCloud, AuxiliaryTenantIDs: []string{tenantID}},
+			"--auxiliary-tenant-ids is not supported on Azure Stack",
+		},
+		{
+			"more than 3 auxiliary tenants are invalid",
+			authArgs{AuthMethod: "client_secret", rawClientID: subscriptionID, ClientSecret: "secret", AuxiliaryTenantIDs: []string{tenantID, tenantID, tenantID, tenantID}},
+			"--auxiliary-tenant-ids accepts at most 3 tenants",
+		},
+		{
+			"auxiliary tenants must be UUIDs",
+			authArgs{AuthMethod: "client_secret", rawClientID: subscriptionID, ClientSecret: "secret", AuxiliaryTenantIDs: []string{"contoso.onmicrosoft.com"}},
+			`--auxiliary-tenant-ids: "contoso.onmicrosoft.com" is not a valid UUID`,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			test.authArgs.rawSubscriptionID = subscriptionID
+			if test.authArgs.RawAzureEnvironment == "" {
+				test.authArgs.RawAzureEnvironment = "AzurePublicCloud"
+			}
+			err := test.authArgs.validateAuthArgs()
+			if test.expectedError == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedError {
+				t.Fatalf("expected error %q, got %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
 func TestGetAzureStackClientWithClientSecret(t *testing.T) {
 	cs := prepareCustomCloudProfile()
 	subscriptionID, _ := uuid.FromString("cc6b141e-6afc-4786-9bf6-e3b9a5601460")
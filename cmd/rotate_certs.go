@@ -18,6 +18,7 @@ import (
 	"github.com/Azure/aks-engine/pkg/armhelpers"
 	"github.com/Azure/aks-engine/pkg/engine"
 	"github.com/Azure/aks-engine/pkg/engine/transform"
+	"github.com/Azure/aks-engine/pkg/helpers"
 	"github.com/Azure/aks-engine/pkg/i18n"
 	"github.com/leonelquinteros/gotext"
 	"github.com/pkg/errors"
@@ -34,6 +35,28 @@ const (
 	kubeSystemNamespace         = "kube-system"
 )
 
+// The scopes of the certificate rotation. Rotating all the certificates rotates the certificate authority and reboots
+// all the nodes, the certificates of the other scopes are signed by the current certificate authority and only the
+// components using them are restarted.
+const (
+	certRotationScopeAll        = "all"
+	certRotationScopeEtcd       = "etcd"
+	certRotationScopeAPIServer  = "apiserver"
+	certRotationScopeKubelet    = "kubelet"
+	certRotationScopeFrontProxy = "front-proxy"
+	certRotationScopeSAKeys     = "sa-keys"
+)
+
+var certRotationScopes = []string{certRotationScopeAll, certRotationScopeEtcd, certRotationScopeAPIServer, certRotationScopeKubelet, certRotationScopeFrontProxy, certRotationScopeSAKeys}
+
+// restartStaticPodCmd restarts a static pod of the masters by moving its manifest out of the manifests directory
+// until the kubelet stops the pod
+const restartStaticPodCmd = "sudo bash -c 'mv /etc/kubernetes/manifests/%[1]s.yaml /etc/kubernetes/%[1]s.yaml && sleep 20 && mv /etc/kubernetes/%[1]s.yaml /etc/kubernetes/manifests/%[1]s.yaml'"
+
+// frontProxyEtcdCmd stores a front proxy file in etcd, where the masters read it from when they are provisioned. The
+// values start with a space, as written by generateproxycerts.sh.
+const frontProxyEtcdCmd = "sudo bash -c 'sed \"1s/^/ /\" %s | ETCDCTL_API=3 etcdctl --command-timeout=30s --cert=/etc/kubernetes/certs/etcdclient.crt --key=/etc/kubernetes/certs/etcdclient.key --cacert=/etc/kubernetes/certs/ca.crt --endpoints=https://127.0.0.1:2379 put %s > /dev/null'"
+
 type rotateCertsCmd struct {
 	authProvider
 
@@ -44,6 +67,7 @@ type rotateCertsCmd struct {
 	location          string
 	apiModelPath      string
 	outputDirectory   string
+	scope             string
 
 	// derived
 	containerService   *api.ContainerService
@@ -77,6 +101,7 @@ func newRotateCertsCmd() *cobra.Command {
 	f.StringVar(&rcc.masterFQDN, "master-FQDN", "", "FQDN for the master load balancer")
 	f.StringVar(&rcc.masterFQDN, "apiserver", "", "apiserver endpoint (required)")
 	f.StringVarP(&rcc.outputDirectory, "output-directory", "o", "", "output directory where generated TLS artifacts will be saved (derived from DNS prefix if absent)")
+	f.StringVar(&rcc.scope, "scope", certRotationScopeAll, fmt.Sprintf("the certificates to rotate, one of %s", strings.Join(certRotationScopes, ", ")))
 
 	f.MarkDeprecated("master-FQDN", "--apiserver is preferred")
 
@@ -91,6 +116,10 @@ func (rcc *rotateCertsCmd) run(cmd *cobra.Command, args []string) error {
 
 	var err error
 
+	if err = rcc.validateScope(); err != nil {
+		return err
+	}
+
 	if err = rcc.getAuthArgs().validateAuthArgs(); err != nil {
 		return errors.Wrap(err, "failed to get validate auth args")
 	}
@@ -143,6 +172,19 @@ func (rcc *rotateCertsCmd) run(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "listing cluster nodes")
 	}
 
+	if _, err = os.Stat(rcc.sshFilepath); os.IsNotExist(err) {
+		return errors.Errorf("specified ssh filepath does not exist (%s)", rcc.sshFilepath)
+	}
+	rcc.setSSHConfig()
+
+	if rcc.scope != certRotationScopeAll {
+		if err = rcc.rotateScope(); err != nil {
+			return errors.Wrapf(err, "rotating the %s certificates", rcc.scope)
+		}
+		log.Infof("Successfully rotated the %s certificates.", rcc.scope)
+		return nil
+	}
+
 	log.Infoln("Generating new certificates")
 
 	// reset the certificateProfile and use the exisiting certificate generation code to generate new certificates.
@@ -152,11 +194,6 @@ func (rcc *rotateCertsCmd) run(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "generating new certificates")
 	}
 
-	if _, err = os.Stat(rcc.sshFilepath); os.IsNotExist(err) {
-		return errors.Errorf("specified ssh filepath does not exist (%s)", rcc.sshFilepath)
-	}
-	rcc.setSSHConfig()
-
 	log.Infoln("Rotating apiserver certificate")
 
 	err = rcc.rotateApiserver()
@@ -206,6 +243,164 @@ func (rcc *rotateCertsCmd) run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// validateScope validates the scope of the rotation, which defaults to all the certificates
+func (rcc *rotateCertsCmd) validateScope() error {
+	if rcc.scope == "" {
+		rcc.scope = certRotationScopeAll
+	}
+	for _, scope := range certRotationScopes {
+		if rcc.scope == scope {
+			return nil
+		}
+	}
+	return errors.Errorf("--scope must be one of %s", strings.Join(certRotationScopes, ", "))
+}
+
+// rotateScope replaces the certificates of the scope with new certificates signed by the current certificate
+// authority, then restarts the components using them
+func (rcc *rotateCertsCmd) rotateScope() error {
+	if len(rcc.masterNodes) == 0 {
+		return errors.New("no master nodes were found in the cluster")
+	}
+	if rcc.scope == certRotationScopeFrontProxy {
+		return rcc.rotateFrontProxy()
+	}
+
+	cs := rcc.containerService
+	if cs.Properties.CertificateProfile == nil || cs.Properties.CertificateProfile.CaPrivateKey == "" {
+		return errors.New("the api model has no certificate authority private key to sign the new certificates")
+	}
+	switch rcc.scope {
+	case certRotationScopeEtcd:
+		log.Infoln("Rotating etcd certificates")
+		if err := cs.RenewEtcdCerts(); err != nil {
+			return errors.Wrap(err, "generating new etcd certificates")
+		}
+		if err := rcc.writeEtcdCerts(); err != nil {
+			return err
+		}
+		if err := rcc.restartEtcd(); err != nil {
+			return err
+		}
+		// the apiserver connects to etcd with the etcd client certificate
+		if err := rcc.restartStaticPods("kube-apiserver"); err != nil {
+			return err
+		}
+	case certRotationScopeAPIServer, certRotationScopeSAKeys:
+		newKey := rcc.scope == certRotationScopeSAKeys
+		log.Infoln("Rotating apiserver certificate")
+		if err := cs.RenewAPIServerCert(newKey); err != nil {
+			return errors.Wrap(err, "generating a new apiserver certificate")
+		}
+		if err := rcc.rotateApiserver(); err != nil {
+			return errors.Wrap(err, "rotating apiserver")
+		}
+		if !newKey {
+			if err := rcc.restartStaticPods("kube-apiserver"); err != nil {
+				return err
+			}
+			break
+		}
+		// the controller manager signs the service account tokens with the apiserver private key
+		if err := rcc.restartStaticPods("kube-apiserver", "kube-controller-manager"); err != nil {
+			return err
+		}
+		log.Debugf("Deleting Service Accounts")
+		if err := rcc.deleteServiceAccounts(); err != nil {
+			return errors.Wrap(err, "deleting service accounts")
+		}
+		log.Debugf("Deleting all pods")
+		if err := rcc.deleteAllPods(); err != nil {
+			return errors.Wrap(err, "deleting all the pods")
+		}
+	case certRotationScopeKubelet:
+		log.Infoln("Rotating kubelet certificate")
+		if err := cs.RenewKubeletCert(); err != nil {
+			return errors.Wrap(err, "generating a new kubelet certificate")
+		}
+		if err := rcc.rotateKubelet(); err != nil {
+			return errors.Wrap(err, "rotating kubelet")
+		}
+		for _, host := range append(rcc.masterNodes, rcc.agentNodes...) {
+			log.Debugf("Restarting kubelet on node %s", host.Name)
+			out, err := rcc.sshCommandExecuter("sudo systemctl restart kubelet", rcc.masterFQDN, host.Name, "22", rcc.sshConfig)
+			if err != nil {
+				log.Printf("Command `sudo systemctl restart kubelet` output: %s\n", out)
+				return errors.Wrap(err, "failed to restart kubelet")
+			}
+		}
+	}
+	return rcc.writeArtifacts()
+}
+
+// rotateFrontProxy replaces the front proxy CA and client certificates of the aggregated APIs, which the masters
+// generate when they are provisioned rather than the api model holding them
+func (rcc *rotateCertsCmd) rotateFrontProxy() error {
+	k := rcc.containerService.Properties.OrchestratorProfile.KubernetesConfig
+	if k == nil || !k.EnableAggregatedAPIs {
+		return errors.New("the front proxy certificates are only used by clusters with enableAggregatedAPIs")
+	}
+	log.Infoln("Rotating front proxy certificates")
+	caPair, clientPair, err := helpers.CreateFrontProxyPki()
+	if err != nil {
+		return errors.Wrap(err, "generating new front proxy certificates")
+	}
+	files := []struct {
+		path     string
+		contents string
+		etcdKey  string
+	}{
+		{"/etc/kubernetes/certs/proxy-ca.crt", caPair.CertificatePem, "/proxycerts/requestheader-client-ca-file"},
+		{"/etc/kubernetes/certs/proxy.crt", clientPair.CertificatePem, "/proxycerts/proxy-client-cert-file"},
+		{"/etc/kubernetes/certs/proxy.key", clientPair.PrivateKeyPem, "/proxycerts/proxy-client-key-file"},
+	}
+
+	for _, host := range rcc.masterNodes {
+		log.Debugf("Ranging over node: %s\n", host.Name)
+		for _, file := range files {
+			cmd := "sudo bash -c \"cat > " + file.path + " << EOL \n" + file.contents + "EOL\""
+			out, err := rcc.sshCommandExecuter(cmd, rcc.masterFQDN, host.Name, "22", rcc.sshConfig)
+			if err != nil {
+				log.Printf("Command %s output: %s\n", cmd, out)
+				return errors.Wrap(err, "failed replacing certificate file")
+			}
+		}
+		if out, err := rcc.sshCommandExecuter("sudo chmod 600 /etc/kubernetes/certs/proxy.key", rcc.masterFQDN, host.Name, "22", rcc.sshConfig); err != nil {
+			log.Printf("Command `sudo chmod 600 /etc/kubernetes/certs/proxy.key` output: %s\n", out)
+			return errors.Wrap(err, "failed setting the mode of the front proxy key")
+		}
+	}
+
+	// the masters provisioned later, e.g. by an upgrade, read the front proxy certificates from etcd
+	for _, file := range files {
+		cmd := fmt.Sprintf(frontProxyEtcdCmd, file.path, file.etcdKey)
+		out, err := rcc.sshCommandExecuter(cmd, rcc.masterFQDN, rcc.masterNodes[0].Name, "22", rcc.sshConfig)
+		if err != nil {
+			log.Printf("Command %s output: %s\n", cmd, out)
+			return errors.Wrapf(err, "failed storing %s in etcd", file.etcdKey)
+		}
+	}
+
+	return rcc.restartStaticPods("kube-apiserver")
+}
+
+// restartStaticPods restarts the static pods in the master nodes, one master at a time so that the apiserver stays
+// available
+func (rcc *rotateCertsCmd) restartStaticPods(names ...string) error {
+	for _, host := range rcc.masterNodes {
+		for _, name := range names {
+			log.Debugf("Restarting %s on node %s", name, host.Name)
+			cmd := fmt.Sprintf(restartStaticPodCmd, name)
+			out, err := rcc.sshCommandExecuter(cmd, rcc.masterFQDN, host.Name, "22", rcc.sshConfig)
+			if err != nil {
+				log.Printf("Command %s output: %s\n", cmd, out)
+				return errors.Wrapf(err, "failed to restart %s", name)
+			}
+		}
+	}
+	return nil
+}
+
 func (rcc *rotateCertsCmd) writeArtifacts() error {
 	ctx := engine.Context{
 		Translator: &i18n.Translator{
@@ -290,7 +485,7 @@ func (rcc *rotateCertsCmd) deleteAllPods() error {
 	}
 	for _, pod := range pods.Items {
 		log.Debugf("Deleting pod %s", pod.Name)
-		err = kubeClient.DeletePod(&pod)
+		err = kubeClient.DeletePod(&pod, nil)
 		if err != nil {
 			return errors.Wrap(err, "failed to delete pod "+pod.Name)
 		}
@@ -357,16 +552,9 @@ func (rcc *rotateCertsCmd) getKubeClient() (armhelpers.KubernetesClient, error)
 func (rcc *rotateCertsCmd) rotateEtcd(ctx context.Context) error {
 	caPrivateKeyCmd := "sudo bash -c \"cat > /etc/kubernetes/certs/ca.key << EOL \n" + rcc.containerService.Properties.CertificateProfile.CaPrivateKey + "EOL\""
 	caCertificateCmd := "sudo bash -c \"cat > /etc/kubernetes/certs/ca.crt << EOL \n" + rcc.containerService.Properties.CertificateProfile.CaCertificate + "EOL\""
-	etcdServerPrivateKeyCmd := "sudo bash -c \"cat > /etc/kubernetes/certs/etcdserver.key << EOL \n" + rcc.containerService.Properties.CertificateProfile.EtcdServerPrivateKey + "EOL\""
-	etcdServerCertificateCmd := "sudo bash -c \"cat > /etc/kubernetes/certs/etcdserver.crt << EOL \n" + rcc.containerService.Properties.CertificateProfile.EtcdServerCertificate + "EOL\""
-	etcdClientPrivateKeyCmd := "sudo bash -c \"cat > /etc/kubernetes/certs/etcdclient.key << EOL \n" + rcc.containerService.Properties.CertificateProfile.EtcdClientPrivateKey + "EOL\""
-	etcdClientCertificateCmd := "sudo bash -c \"cat > /etc/kubernetes/certs/etcdclient.crt << EOL \n" + rcc.containerService.Properties.CertificateProfile.EtcdClientCertificate + "EOL\""
 
-	for i, host := range rcc.masterNodes {
+	for _, host := range rcc.masterNodes {
 		log.Debugf("Ranging over node: %s\n", host.Name)
-		etcdPeerPrivateKeyCmd := "sudo bash -c \"cat > /etc/kubernetes/certs/etcdpeer" + strconv.Itoa(i) + ".key << EOL \n" + rcc.containerService.Properties.CertificateProfile.EtcdPeerPrivateKeys[i] + "EOL\""
-		etcdPeerCertificateCmd := "sudo bash -c \"cat > /etc/kubernetes/certs/etcdpeer" + strconv.Itoa(i) + ".crt << EOL \n" + rcc.containerService.Properties.CertificateProfile.EtcdPeerCertificates[i] + "EOL\""
-
 		for _, cmd := range []string{caPrivateKeyCmd, caCertificateCmd} {
 			out, err := rcc.sshCommandExecuter(cmd, rcc.masterFQDN, host.Name, "22", rcc.sshConfig)
 			if err != nil {
@@ -374,6 +562,32 @@ func (rcc *rotateCertsCmd) rotateEtcd(ctx context.Context) error {
 				return errors.Wrap(err, "failed replacing certificate file")
 			}
 		}
+	}
+
+	if err := rcc.writeEtcdCerts(); err != nil {
+		return err
+	}
+
+	log.Infoln("Rebooting all nodes... This might take a few minutes")
+	err := rcc.rebootAllNodes(ctx)
+	if err != nil {
+		return errors.Wrap(err, "rebooting the nodes")
+	}
+
+	return rcc.restartEtcd()
+}
+
+// writeEtcdCerts writes the etcd server, client and peer certificates in all of the master nodes
+func (rcc *rotateCertsCmd) writeEtcdCerts() error {
+	etcdServerPrivateKeyCmd := "sudo bash -c \"cat > /etc/kubernetes/certs/etcdserver.key << EOL \n" + rcc.containerService.Properties.CertificateProfile.EtcdServerPrivateKey + "EOL\""
+	etcdServerCertificateCmd := "sudo bash -c \"cat > /etc/kubernetes/certs/etcdserver.crt << EOL \n" + rcc.containerService.Properties.CertificateProfile.EtcdServerCertificate + "EOL\""
+	etcdClientPrivateKeyCmd := "sudo bash -c \"cat > /etc/kubernetes/certs/etcdclient.key << EOL \n" + rcc.containerService.Properties.CertificateProfile.EtcdClientPrivateKey + "EOL\""
+	etcdClientCertificateCmd := "sudo bash -c \"cat > /etc/kubernetes/certs/etcdclient.crt << EOL \n" + rcc.containerService.Properties.CertificateProfile.EtcdClientCertificate + "EOL\""
+
+	for i, host := range rcc.masterNodes {
+		log.Debugf("Ranging over node: %s\n", host.Name)
+		etcdPeerPrivateKeyCmd := "sudo bash -c \"cat > /etc/kubernetes/certs/etcdpeer" + strconv.Itoa(i) + ".key << EOL \n" + rcc.containerService.Properties.CertificateProfile.EtcdPeerPrivateKeys[i] + "EOL\""
+		etcdPeerCertificateCmd := "sudo bash -c \"cat > /etc/kubernetes/certs/etcdpeer" + strconv.Itoa(i) + ".crt << EOL \n" + rcc.containerService.Properties.CertificateProfile.EtcdPeerCertificates[i] + "EOL\""
 
 		for _, cmd := range []string{etcdServerPrivateKeyCmd, etcdServerCertificateCmd, etcdClientPrivateKeyCmd, etcdClientCertificateCmd, etcdPeerPrivateKeyCmd, etcdPeerCertificateCmd} {
 			out, err := rcc.sshCommandExecuter(cmd, rcc.masterFQDN, host.Name, "22", rcc.sshConfig)
@@ -383,13 +597,11 @@ func (rcc *rotateCertsCmd) rotateEtcd(ctx context.Context) error {
 			}
 		}
 	}
+	return nil
+}
 
-	log.Infoln("Rebooting all nodes... This might take a few minutes")
-	err := rcc.rebootAllNodes(ctx)
-	if err != nil {
-		return errors.Wrap(err, "rebooting the nodes")
-	}
-
+// restartEtcd restarts etcd in all of the master nodes
+func (rcc *rotateCertsCmd) restartEtcd() error {
 	for _, host := range rcc.masterNodes {
 		log.Debugf("Restarting etcd on node %s", host.Name)
 		out, err := rcc.sshCommandExecuter("sudo systemctl restart etcd", rcc.masterFQDN, host.Name, "22", rcc.sshConfig)
@@ -356,3 +356,99 @@ func TestRotateCerts(t *testing.T) {
 	err = rcc.rotateKubelet()
 	g.Expect(err).To(HaveOccurred())
 }
+
+func TestRotateCertsScope(t *testing.T) {
+	g := NewGomegaWithT(t)
+	rcc := rotateCertsCmd{scope: "ca"}
+	err := rcc.validateScope()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("--scope must be one of"))
+
+	rcc.scope = ""
+	err = rcc.validateScope()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rcc.scope).To(Equal(certRotationScopeAll))
+
+	cs := api.CreateMockContainerService("testcluster", "1.10.13", 3, 2, false)
+	cs.SetPropertiesDefaults(false, false)
+	rcc = rotateCertsCmd{
+		authProvider:       &authArgs{},
+		containerService:   cs,
+		apiVersion:         "vlabs",
+		outputDirectory:    "_test_output",
+		sshCommandExecuter: mockExecuteCmd,
+		masterFQDN:         "valid",
+		client:             &armhelpers.MockAKSEngineClient{MockKubernetesClient: &armhelpers.MockKubernetesClient{}},
+		masterNodes: []v1.Node{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "k8s-master-1234-0",
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "k8s-master-1234-1",
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "k8s-master-1234-2",
+				},
+			},
+		},
+		agentNodes: []v1.Node{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "k8s-agents-1234-0",
+				},
+			},
+		},
+	}
+	defer os.RemoveAll(rcc.outputDirectory)
+
+	caCertificate := cs.Properties.CertificateProfile.CaCertificate
+	apiServerPrivateKey := cs.Properties.CertificateProfile.APIServerPrivateKey
+	etcdServerCertificate := cs.Properties.CertificateProfile.EtcdServerCertificate
+	clientCertificate := cs.Properties.CertificateProfile.ClientCertificate
+
+	rcc.scope = certRotationScopeEtcd
+	err = rcc.rotateScope()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cs.Properties.CertificateProfile.EtcdServerCertificate).NotTo(Equal(etcdServerCertificate))
+	g.Expect(cs.Properties.CertificateProfile.EtcdPeerCertificates).To(HaveLen(3))
+
+	rcc.scope = certRotationScopeAPIServer
+	err = rcc.rotateScope()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cs.Properties.CertificateProfile.APIServerPrivateKey).To(Equal(apiServerPrivateKey))
+
+	rcc.scope = certRotationScopeSAKeys
+	err = rcc.rotateScope()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cs.Properties.CertificateProfile.APIServerPrivateKey).NotTo(Equal(apiServerPrivateKey))
+
+	rcc.scope = certRotationScopeKubelet
+	err = rcc.rotateScope()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cs.Properties.CertificateProfile.ClientCertificate).NotTo(Equal(clientCertificate))
+	g.Expect(cs.Properties.CertificateProfile.CaCertificate).To(Equal(caCertificate))
+
+	rcc.scope = certRotationScopeFrontProxy
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EnableAggregatedAPIs = false
+	err = rcc.rotateScope()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("enableAggregatedAPIs"))
+
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EnableAggregatedAPIs = true
+	err = rcc.rotateScope()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	rcc.masterFQDN = "invalid"
+	err = rcc.rotateScope()
+	g.Expect(err).To(HaveOccurred())
+
+	rcc.masterNodes = nil
+	err = rcc.rotateScope()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("no master nodes"))
+}
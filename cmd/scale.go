@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -39,17 +40,19 @@ type scaleCmd struct {
 	newDesiredAgentCount int
 	deploymentDirectory  string
 	location             string
-	agentPoolToScale     string
+	nodePools            []string
 	masterFQDN           string
 
 	// derived
 	containerService *api.ContainerService
 	apiVersion       string
+	poolScales       []nodePoolScale
+	agentPoolToScale string
 	agentPool        *api.AgentPoolProfile
+	currentNodeCount int
 	client           armhelpers.AKSEngineClient
 	locale           *gotext.Locale
 	nameSuffix       string
-	agentPoolIndex   int
 	logger           *log.Entry
 	apiserverURL     string
 	kubeconfig       string
@@ -61,8 +64,16 @@ const (
 	scaleShortDescription = "Scale an existing Kubernetes cluster"
 	scaleLongDescription  = "Scale an existing Kubernetes cluster by specifying increasing or decreasing the node count of an agentpool"
 	apiModelFilename      = "apimodel.json"
+	// maxDeploymentNameLength is the maximum length of the name of an ARM deployment
+	maxDeploymentNameLength = 64
 )
 
+// nodePoolScale is an agent pool to scale and its desired node count
+type nodePoolScale struct {
+	name  string
+	count int
+}
+
 // NewScaleCmd run a command to upgrade a Kubernetes cluster
 func newScaleCmd() *cobra.Command {
 	sc := scaleCmd{}
@@ -80,7 +91,7 @@ func newScaleCmd() *cobra.Command {
 	f.StringVarP(&sc.apiModelPath, "api-model", "m", "", "path to the generated apimodel.json file")
 	f.StringVar(&sc.deploymentDirectory, "deployment-dir", "", "the location of the output from `generate`")
 	f.IntVarP(&sc.newDesiredAgentCount, "new-node-count", "c", 0, "desired number of nodes")
-	f.StringVar(&sc.agentPoolToScale, "node-pool", "", "node pool to scale")
+	f.StringArrayVar(&sc.nodePools, "node-pool", []string{}, "node pool to scale, as name to scale it to --new-node-count or name=count, can be repeated to scale several node pools concurrently")
 	f.StringVar(&sc.masterFQDN, "master-FQDN", "", "FQDN for the master load balancer that maps to the apiserver endpoint")
 	f.StringVar(&sc.masterFQDN, "apiserver", "", "apiserver endpoint (required to cordon and drain nodes)")
 
@@ -113,7 +124,32 @@ func (sc *scaleCmd) validate(cmd *cobra.Command) error {
 
 	sc.location = helpers.NormalizeAzureRegion(sc.location)
 
-	if sc.newDesiredAgentCount == 0 {
+	sc.poolScales = nil
+	for _, nodePool := range sc.nodePools {
+		target := nodePoolScale{name: nodePool, count: sc.newDesiredAgentCount}
+		if i := strings.Index(nodePool, "="); i != -1 {
+			count, err := strconv.Atoi(nodePool[i+1:])
+			if err != nil || count < 1 {
+				return errors.Errorf("invalid --node-pool %s, the count of name=count must be a positive integer", nodePool)
+			}
+			target = nodePoolScale{name: nodePool[:i], count: count}
+		}
+		if target.name == "" {
+			return errors.Errorf("invalid --node-pool %s, the node pool name is empty", nodePool)
+		}
+		for _, existing := range sc.poolScales {
+			if existing.name == target.name {
+				return errors.Errorf("node pool %s is specified more than once", target.name)
+			}
+		}
+		if target.count == 0 {
+			cmd.Usage()
+			return errors.New("--new-node-count must be specified")
+		}
+		sc.poolScales = append(sc.poolScales, target)
+	}
+
+	if len(sc.poolScales) == 0 && sc.newDesiredAgentCount == 0 {
 		cmd.Usage()
 		return errors.New("--new-node-count must be specified")
 	}
@@ -148,22 +184,8 @@ func (sc *scaleCmd) load() error {
 		return errors.Errorf("specified api model does not exist (%s)", sc.apiModelPath)
 	}
 
-	apiloader := &api.Apiloader{
-		Translator: &i18n.Translator{
-			Locale: sc.locale,
-		},
-	}
-	sc.containerService, sc.apiVersion, err = apiloader.LoadContainerServiceFromFile(sc.apiModelPath, true, true, nil)
-	if err != nil {
-		return errors.Wrap(err, "error parsing the api model")
-	}
-
-	if sc.containerService.Properties.IsAzureStackCloud() {
-		writeCustomCloudProfile(sc.containerService)
-		err = sc.containerService.Properties.SetAzureStackCloudSpec()
-		if err != nil {
-			return errors.Wrap(err, "error parsing the api model")
-		}
+	if sc.containerService, sc.apiVersion, err = sc.loadContainerService(); err != nil {
+		return err
 	}
 
 	if err = sc.authArgs.validateAuthArgs(); err != nil {
@@ -185,28 +207,22 @@ func (sc *scaleCmd) load() error {
 		return errors.New("--location does not match api model location")
 	}
 
-	if sc.agentPoolToScale == "" {
-		agentPoolCount := len(sc.containerService.Properties.AgentPoolProfiles)
-		if agentPoolCount > 1 {
-			return errors.New("--node-pool is required if more than one agent pool is defined in the container service")
-		} else if agentPoolCount == 1 {
-			sc.agentPool = sc.containerService.Properties.AgentPoolProfiles[0]
-			sc.agentPoolIndex = 0
-			sc.agentPoolToScale = sc.containerService.Properties.AgentPoolProfiles[0].Name
-		} else {
-			return errors.New("No node pools found to scale")
-		}
-	} else {
-		agentPoolIndex := -1
-		for i, pool := range sc.containerService.Properties.AgentPoolProfiles {
-			if pool.Name == sc.agentPoolToScale {
-				agentPoolIndex = i
-				sc.agentPool = pool
-				sc.agentPoolIndex = i
+	if len(sc.poolScales) == 0 {
+		if sc.agentPoolToScale == "" {
+			agentPoolCount := len(sc.containerService.Properties.AgentPoolProfiles)
+			if agentPoolCount > 1 {
+				return errors.New("--node-pool is required if more than one agent pool is defined in the container service")
+			} else if agentPoolCount == 1 {
+				sc.agentPoolToScale = sc.containerService.Properties.AgentPoolProfiles[0].Name
+			} else {
+				return errors.New("No node pools found to scale")
 			}
 		}
-		if agentPoolIndex == -1 {
-			return errors.Errorf("node pool %s was not found in the deployed api model", sc.agentPoolToScale)
+		sc.poolScales = []nodePoolScale{{name: sc.agentPoolToScale, count: sc.newDesiredAgentCount}}
+	}
+	for _, target := range sc.poolScales {
+		if err = sc.setAgentPool(target); err != nil {
+			return err
 		}
 	}
 
@@ -239,6 +255,154 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "failed to load existing container service")
 	}
 
+	if len(sc.poolScales) > 1 {
+		return sc.scaleAgentPools(cmd)
+	}
+	if err := sc.scaleAgentPool(cmd); err != nil {
+		return err
+	}
+	return sc.saveAPIModel(map[string]int{sc.agentPoolToScale: sc.newDesiredAgentCount})
+}
+
+// loadContainerService parses the api model of the cluster
+func (sc *scaleCmd) loadContainerService() (*api.ContainerService, string, error) {
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: sc.locale,
+		},
+	}
+	cs, apiVersion, err := apiloader.LoadContainerServiceFromFile(sc.apiModelPath, true, true, nil)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "error parsing the api model")
+	}
+
+	if cs.Properties.IsAzureStackCloud() {
+		writeCustomCloudProfile(cs)
+		err = cs.Properties.SetAzureStackCloudSpec()
+		if err != nil {
+			return nil, "", errors.Wrap(err, "error parsing the api model")
+		}
+	}
+	return cs, apiVersion, nil
+}
+
+// setAgentPool sets the agent pool of the container service that sc scales, and its desired node count
+func (sc *scaleCmd) setAgentPool(target nodePoolScale) error {
+	for _, pool := range sc.containerService.Properties.AgentPoolProfiles {
+		if pool.Name == target.name {
+			sc.agentPool = pool
+			sc.agentPoolToScale = pool.Name
+			sc.newDesiredAgentCount = target.count
+			return nil
+		}
+	}
+	return errors.Errorf("node pool %s was not found in the deployed api model", target.name)
+}
+
+// forPool returns a scale command scaling the agent pool of target, with its own copy of the container service since
+// scaling an agent pool changes it, and a logger reporting the progress of the pool
+func (sc *scaleCmd) forPool(target nodePoolScale) (*scaleCmd, error) {
+	pool := *sc
+	pool.nodes = nil
+	pool.currentNodeCount = 0
+	pool.logger = sc.logger.WithField("pool", target.name)
+	var err error
+	if pool.containerService, _, err = sc.loadContainerService(); err != nil {
+		return nil, err
+	}
+	if pool.containerService.Location == "" {
+		pool.containerService.Location = sc.location
+	}
+	if err = pool.setAgentPool(target); err != nil {
+		return nil, err
+	}
+	return &pool, nil
+}
+
+// scaleAgentPools scales the agent pools of sc.poolScales concurrently and saves their node counts to the api model.
+// If some of the pools fail to scale, the pools that were scaled are scaled back to their previous node count.
+func (sc *scaleCmd) scaleAgentPools(cmd *cobra.Command) error {
+	pools := make([]*scaleCmd, 0, len(sc.poolScales))
+	for _, target := range sc.poolScales {
+		pool, err := sc.forPool(target)
+		if err != nil {
+			return err
+		}
+		pools = append(pools, pool)
+	}
+
+	counts := map[string]int{}
+	var problems []string
+	var scaled []*scaleCmd
+	for i, err := range scaleConcurrently(cmd, pools) {
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("node pool %s: %v", pools[i].agentPoolToScale, err))
+			continue
+		}
+		counts[pools[i].agentPoolToScale] = pools[i].newDesiredAgentCount
+		scaled = append(scaled, pools[i])
+	}
+	if len(problems) == 0 {
+		return sc.saveAPIModel(counts)
+	}
+	failed := len(problems)
+
+	var rollbacks []*scaleCmd
+	for _, pool := range scaled {
+		if pool.currentNodeCount == pool.newDesiredAgentCount {
+			continue
+		}
+		if pool.currentNodeCount < 1 {
+			pool.logger.Warnf("Not rolling back node pool %s, it had no nodes before scaling", pool.agentPoolToScale)
+			continue
+		}
+		rollback, err := sc.forPool(nodePoolScale{name: pool.agentPoolToScale, count: pool.currentNodeCount})
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("rolling back node pool %s: %v", pool.agentPoolToScale, err))
+			continue
+		}
+		rollback.logger.Warnf("Rolling back node pool %s from %d to %d nodes", pool.agentPoolToScale, pool.newDesiredAgentCount, pool.currentNodeCount)
+		rollbacks = append(rollbacks, rollback)
+	}
+	for i, err := range scaleConcurrently(cmd, rollbacks) {
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("rolling back node pool %s to %d nodes: %v", rollbacks[i].agentPoolToScale, rollbacks[i].newDesiredAgentCount, err))
+			continue
+		}
+		counts[rollbacks[i].agentPoolToScale] = rollbacks[i].newDesiredAgentCount
+	}
+
+	// the api model keeps the node counts of the pools that failed to scale, and of those still scaled
+	if err := sc.saveAPIModel(counts); err != nil {
+		problems = append(problems, fmt.Sprintf("saving the api model: %v", err))
+	}
+	return errors.Errorf("failed to scale %d of the %d node pools: %s", failed, len(pools), strings.Join(problems, "; "))
+}
+
+// scaleConcurrently scales the agent pools of pools at the same time, and returns the error of each pool
+func scaleConcurrently(cmd *cobra.Command, pools []*scaleCmd) []error {
+	errs := make([]error, len(pools))
+	done := make(chan struct{}, len(pools))
+	defer close(done)
+	for i, pool := range pools {
+		go func(i int, pool *scaleCmd) {
+			pool.logger.Infof("Scaling node pool %s to %d nodes", pool.agentPoolToScale, pool.newDesiredAgentCount)
+			if errs[i] = pool.scaleAgentPool(cmd); errs[i] != nil {
+				pool.logger.Errorf("Failed to scale node pool %s: %v", pool.agentPoolToScale, errs[i])
+			} else {
+				pool.logger.Infof("Node pool %s is at %d nodes", pool.agentPoolToScale, pool.newDesiredAgentCount)
+			}
+			done <- struct{}{}
+		}(i, pool)
+	}
+	for range pools {
+		<-done
+	}
+	return errs
+}
+
+// scaleAgentPool scales sc.agentPool to sc.newDesiredAgentCount nodes, without saving the api model
+func (sc *scaleCmd) scaleAgentPool(cmd *cobra.Command) error {
 	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
 	defer cancel()
 	orchestratorInfo := sc.containerService.Properties.OrchestratorProfile
@@ -292,6 +456,7 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 		sortedIndexes.Sort()
 		indexes = []int(sortedIndexes)
 		currentNodeCount = len(indexes)
+		sc.currentNodeCount = currentNodeCount
 
 		if currentNodeCount == sc.newDesiredAgentCount {
 			sc.printScaleTargetEqualsExisting(currentNodeCount)
@@ -379,7 +544,7 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 				}
 			}
 
-			return sc.saveAPIModel()
+			return nil
 		}
 	} else {
 		for vmssListPage, err := sc.client.ListVirtualMachineScaleSets(ctx, sc.resourceGroupName); vmssListPage.NotDone(); err = vmssListPage.NextWithContext(ctx) {
@@ -394,11 +559,12 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 
 				if vmss.Sku != nil {
 					currentNodeCount = int(*vmss.Sku.Capacity)
+					sc.currentNodeCount = currentNodeCount
 					if int(*vmss.Sku.Capacity) == sc.newDesiredAgentCount {
 						sc.printScaleTargetEqualsExisting(currentNodeCount)
 						return nil
 					} else if int(*vmss.Sku.Capacity) > sc.newDesiredAgentCount {
-						log.Warnf("VMSS scale down is an alpha feature: VMSS VM nodes will not be cordoned and drained before scaling down!")
+						sc.logger.Warnf("VMSS scale down is an alpha feature: VMSS VM nodes will not be cordoned and drained before scaling down!")
 					}
 				}
 
@@ -494,7 +660,7 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 	_, err = sc.client.DeployTemplate(
 		ctx,
 		sc.resourceGroupName,
-		fmt.Sprintf("%s-%d", sc.resourceGroupName, deploymentSuffix),
+		scaleDeploymentName(sc.resourceGroupName, sc.agentPoolToScale, deploymentSuffix),
 		templateJSON,
 		parametersJSON)
 	if err != nil {
@@ -511,10 +677,11 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	return sc.saveAPIModel()
+	return nil
 }
 
-func (sc *scaleCmd) saveAPIModel() error {
+// saveAPIModel saves the api model with the node counts of the agent pools scaled, by pool name
+func (sc *scaleCmd) saveAPIModel(counts map[string]int) error {
 	var err error
 	apiloader := &api.Apiloader{
 		Translator: &i18n.Translator{
@@ -526,7 +693,11 @@ func (sc *scaleCmd) saveAPIModel() error {
 	if err != nil {
 		return err
 	}
-	sc.containerService.Properties.AgentPoolProfiles[sc.agentPoolIndex].Count = sc.newDesiredAgentCount
+	for _, pool := range sc.containerService.Properties.AgentPoolProfiles {
+		if count, ok := counts[pool.Name]; ok {
+			pool.Count = count
+		}
+	}
 
 	b, err := apiloader.SerializeContainerService(sc.containerService, apiVersion)
 
@@ -544,13 +715,19 @@ func (sc *scaleCmd) saveAPIModel() error {
 }
 
 func (sc *scaleCmd) vmInAgentPool(vmName string, tags map[string]*string) bool {
+	return resourceInAgentPool(vmName, tags, sc.agentPoolToScale, sc.nameSuffix)
+}
+
+// resourceInAgentPool returns true if the VM or scale set named vmName belongs to the agent pool agentPoolName of the
+// cluster whose resource names have the suffix nameSuffix
+func resourceInAgentPool(vmName string, tags map[string]*string, agentPoolName, nameSuffix string) bool {
 	// Try to locate the VM's agent pool by expected tags.
 	if tags != nil {
 		if poolName, ok := tags["poolName"]; ok {
-			if nameSuffix, ok := tags["resourceNameSuffix"]; ok {
+			if resourceNameSuffix, ok := tags["resourceNameSuffix"]; ok {
 				// Use strings.Contains for the nameSuffix as the Windows Agent Pools use only
 				// a substring of the first 5 characters of the entire nameSuffix.
-				if strings.EqualFold(*poolName, sc.agentPoolToScale) && strings.Contains(sc.nameSuffix, *nameSuffix) {
+				if strings.EqualFold(*poolName, agentPoolName) && strings.Contains(nameSuffix, *resourceNameSuffix) {
 					return true
 				}
 			}
@@ -558,7 +735,18 @@ func (sc *scaleCmd) vmInAgentPool(vmName string, tags map[string]*string) bool {
 	}
 
 	// Fall back to checking the VM name to see if it fits the naming pattern.
-	return strings.Contains(vmName, sc.nameSuffix[:5]) && strings.Contains(vmName, sc.agentPoolToScale)
+	return strings.Contains(vmName, nameSuffix[:5]) && strings.Contains(vmName, agentPoolName)
+}
+
+// scaleDeploymentName returns the name of the deployment scaling agentPoolName. Pools scaled concurrently deploy at the
+// same time, the pool name keeps their deployment names apart, so the resource group name is truncated rather than the
+// pool name when the name would be too long for ARM.
+func scaleDeploymentName(resourceGroupName, agentPoolName string, deploymentSuffix int32) string {
+	suffix := fmt.Sprintf("-%s-%d", agentPoolName, deploymentSuffix)
+	if len(resourceGroupName)+len(suffix) > maxDeploymentNameLength {
+		resourceGroupName = resourceGroupName[:maxDeploymentNameLength-len(suffix)]
+	}
+	return resourceGroupName + suffix
 }
 
 type paramsMap map[string]interface{}
@@ -603,7 +791,7 @@ func (sc *scaleCmd) printScaleTargetEqualsExisting(currentNodeCount int) {
 		printNodes = true
 		trailingChar = ":"
 	}
-	log.Infof("Node pool %s is already at the desired count %d%s", sc.agentPoolToScale, sc.newDesiredAgentCount, trailingChar)
+	sc.logger.Infof("Node pool %s is already at the desired count %d%s", sc.agentPoolToScale, sc.newDesiredAgentCount, trailingChar)
 	if printNodes {
 		operations.PrintNodes(sc.nodes)
 	}
@@ -4,9 +4,20 @@
 package cmd
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -107,6 +118,43 @@ func TestScaleCmdValidate(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			sc: &scaleCmd{
+				apiModelPath:      "./not/used",
+				location:          "centralus",
+				resourceGroupName: "testRG",
+				nodePools:         []string{"agentpool1=3", "agentpool2=three"},
+			},
+			expectedErr: errors.New("invalid --node-pool agentpool2=three, the count of name=count must be a positive integer"),
+		},
+		{
+			sc: &scaleCmd{
+				apiModelPath:      "./not/used",
+				location:          "centralus",
+				resourceGroupName: "testRG",
+				nodePools:         []string{"=3"},
+			},
+			expectedErr: errors.New("invalid --node-pool =3, the node pool name is empty"),
+		},
+		{
+			sc: &scaleCmd{
+				apiModelPath:         "./not/used",
+				location:             "centralus",
+				resourceGroupName:    "testRG",
+				nodePools:            []string{"agentpool1=3", "agentpool1"},
+				newDesiredAgentCount: 5,
+			},
+			expectedErr: errors.New("node pool agentpool1 is specified more than once"),
+		},
+		{
+			sc: &scaleCmd{
+				apiModelPath:      "./not/used",
+				location:          "centralus",
+				resourceGroupName: "testRG",
+				nodePools:         []string{"agentpool1=3", "agentpool2"},
+			},
+			expectedErr: errors.New("--new-node-count must be specified"),
+		},
 	}
 
 	for _, c := range cases {
@@ -124,3 +172,121 @@ func TestScaleCmdValidate(t *testing.T) {
 		}
 	}
 }
+
+func TestScaleCmdValidateNodePools(t *testing.T) {
+	sc := &scaleCmd{
+		apiModelPath:         "./not/used",
+		location:             "centralus",
+		resourceGroupName:    "testRG",
+		nodePools:            []string{"agentpool1=3", "agentpool2"},
+		newDesiredAgentCount: 5,
+	}
+	if err := sc.validate(&cobra.Command{}); err != nil {
+		t.Fatalf("expected validate scale command to return no error, but instead got %s", err.Error())
+	}
+	expected := []nodePoolScale{{name: "agentpool1", count: 3}, {name: "agentpool2", count: 5}}
+	if !reflect.DeepEqual(sc.poolScales, expected) {
+		t.Fatalf("expected the node pools to scale to be %v, but instead got %v", expected, sc.poolScales)
+	}
+}
+
+func TestScaleCmdScaleAgentPoolsConcurrently(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aks-engine-scale")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	b, err := ioutil.ReadFile("../pkg/engine/testdata/disks-managed/kubernetes-vmss.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiModelPath := filepath.Join(dir, "apimodel.json")
+	if err = ioutil.WriteFile(apiModelPath, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var deployments []string
+	client := &armhelpers.MockAKSEngineClient{
+		FakeListVirtualMachineScaleSetsResult: func() []compute.VirtualMachineScaleSet {
+			return []compute.VirtualMachineScaleSet{}
+		},
+		DeployTemplateFunc: func(resourceGroup, name string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			deployments = append(deployments, name)
+			return nil
+		},
+	}
+	sc := &scaleCmd{
+		apiModelPath:      apiModelPath,
+		resourceGroupName: "testRG",
+		location:          "westus2",
+		client:            client,
+		logger:            log.NewEntry(log.New()),
+		poolScales:        []nodePoolScale{{name: "agentpool1", count: 5}, {name: "agentpool2", count: 4}},
+	}
+	if sc.containerService, sc.apiVersion, err = sc.loadContainerService(); err != nil {
+		t.Fatalf("unexpected error loading the api model: %s", err)
+	}
+	if err = sc.scaleAgentPools(&cobra.Command{}); err != nil {
+		t.Fatalf("unexpected error scaling the node pools: %s", err)
+	}
+
+	if len(deployments) != 2 {
+		t.Fatalf("expected a deployment per node pool, got %v", deployments)
+	}
+	if deployments[0] == deployments[1] {
+		t.Fatalf("expected the node pools to be deployed under different names, got %v", deployments)
+	}
+	for _, pool := range []string{"agentpool1", "agentpool2"} {
+		found := false
+		for _, name := range deployments {
+			found = found || strings.HasPrefix(name, "testRG-"+pool+"-")
+		}
+		if !found {
+			t.Errorf("expected a deployment named after node pool %s, got %v", pool, deployments)
+		}
+	}
+
+	apiloader := &api.Apiloader{Translator: &i18n.Translator{}}
+	cs, _, err := apiloader.LoadContainerServiceFromFile(apiModelPath, false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, pool := range cs.Properties.AgentPoolProfiles {
+		counts[pool.Name] = pool.Count
+	}
+	if expected := map[string]int{"agentpool1": 5, "agentpool2": 4}; !reflect.DeepEqual(counts, expected) {
+		t.Errorf("expected the api model to have the node counts %v, got %v", expected, counts)
+	}
+}
+
+func TestScaleDeploymentName(t *testing.T) {
+	cases := []struct {
+		name              string
+		resourceGroupName string
+		expected          string
+	}{
+		{
+			name:              "short resource group",
+			resourceGroupName: "testRG",
+			expected:          "testRG-agentpool1-1234567890",
+		},
+		{
+			name:              "long resource group",
+			resourceGroupName: strings.Repeat("a", 90),
+			expected:          strings.Repeat("a", 42) + "-agentpool1-1234567890",
+		},
+	}
+	for _, c := range cases {
+		actual := scaleDeploymentName(c.resourceGroupName, "agentpool1", 1234567890)
+		if actual != c.expected {
+			t.Errorf("%s: expected deployment name %s, got %s", c.name, c.expected, actual)
+		}
+		if len(actual) > maxDeploymentNameLength {
+			t.Errorf("%s: expected the deployment name to be at most %d characters, got %d", c.name, maxDeploymentNameLength, len(actual))
+		}
+	}
+}
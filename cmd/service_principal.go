@@ -0,0 +1,142 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/common"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/graphrbac/1.6/graphrbac"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// servicePrincipalSecretExpiryWarning is how far ahead of its expiry date a service principal secret is reported as expiring
+	servicePrincipalSecretExpiryWarning = 30 * 24 * time.Hour
+	vnetScopeTemplate                   = "/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/virtualNetworks/%s"
+	contributorRole                     = "Contributor"
+	networkContributorRole              = "Network Contributor"
+)
+
+// validateServicePrincipal ensures the cluster service principal can authenticate, that its secret is not expired or about
+// to expire, and that it holds a role assignment on the cluster resource group and on a pre-existing custom VNET.
+// Problems that this tool is not allowed to inspect are logged as warnings rather than failing the operation.
+func validateServicePrincipal(client armhelpers.AKSEngineClient, cs *api.ContainerService, subscriptionID, resourceGroup string) error {
+	p := cs.Properties
+	if p.IsAzureStackCloud() || p.OrchestratorProfile == nil || !p.OrchestratorProfile.IsKubernetes() {
+		return nil
+	}
+	if p.OrchestratorProfile.KubernetesConfig != nil && p.OrchestratorProfile.KubernetesConfig.UseManagedIdentity {
+		return nil
+	}
+	spp := p.ServicePrincipalProfile
+	if spp == nil || spp.ClientID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+
+	if spp.Secret != "" && spp.KeyvaultSecretRef == nil {
+		if err := client.ValidateServicePrincipalSecret(ctx, spp.ClientID, spp.Secret); err != nil {
+			return errors.Wrapf(err, "service principal %s failed to authenticate with servicePrincipalProfile.secret. "+
+				"If the secret has expired, create a new one with 'az ad sp credential reset --name %s' and update the api model", spp.ClientID, spp.ClientID)
+		}
+	}
+
+	objectID, credentials, err := client.GetServicePrincipalPasswordCredentials(ctx, spp.ClientID)
+	if err != nil {
+		log.Warnf("unable to look up service principal %s, skipping secret expiration and role assignment checks: %s", spp.ClientID, err)
+		return nil
+	}
+
+	if err = validatePasswordCredentialsExpiry(spp.ClientID, credentials, time.Now()); err != nil {
+		return err
+	}
+
+	scope := fmt.Sprintf(armhelpers.AADRoleResourceGroupScopeTemplate, subscriptionID, resourceGroup)
+	if err = validateRoleAssignment(ctx, client, spp.ClientID, objectID, scope, contributorRole); err != nil {
+		return err
+	}
+
+	if p.MasterProfile != nil && p.MasterProfile.IsCustomVNET() {
+		vnetSubscriptionID, vnetResourceGroup, vnetName, _, err := common.GetVNETSubnetIDComponents(p.MasterProfile.VnetSubnetID)
+		if err != nil {
+			return err
+		}
+		scope = fmt.Sprintf(vnetScopeTemplate, vnetSubscriptionID, vnetResourceGroup, vnetName)
+		if err = validateRoleAssignment(ctx, client, spp.ClientID, objectID, scope, networkContributorRole); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validatePasswordCredentialsExpiry fails if every password credential of the service principal has expired,
+// and warns if none of them is valid beyond servicePrincipalSecretExpiryWarning
+func validatePasswordCredentialsExpiry(clientID string, credentials []graphrbac.PasswordCredential, now time.Time) error {
+	var latest time.Time
+	for _, c := range credentials {
+		if c.EndDate != nil && c.EndDate.After(latest) {
+			latest = c.EndDate.Time
+		}
+	}
+	if latest.IsZero() {
+		return nil
+	}
+	if latest.Before(now) {
+		return errors.Errorf("all secrets of service principal %s expired on or before %s. "+
+			"Create a new secret with 'az ad sp credential reset --name %s' and update servicePrincipalProfile.secret in the api model",
+			clientID, latest.Format(time.RFC3339), clientID)
+	}
+	if latest.Before(now.Add(servicePrincipalSecretExpiryWarning)) {
+		log.Warnf("the secrets of service principal %s expire on %s, the cloud provider will stop working after that date. "+
+			"Create a new secret with 'az ad sp credential reset --name %s' and update the cluster before then",
+			clientID, latest.Format(time.RFC3339), clientID)
+	}
+	return nil
+}
+
+// validateRoleAssignment fails if the service principal has no role assignment that applies to scope,
+// and warns if none of its assignments is Owner or suggestedRole
+func validateRoleAssignment(ctx context.Context, client armhelpers.AKSEngineClient, clientID, objectID, scope, suggestedRole string) error {
+	sufficientRoleIDs := []string{armhelpers.AADOwnerRoleID, armhelpers.AADContributorRoleID}
+	if suggestedRole == networkContributorRole {
+		sufficientRoleIDs = append(sufficientRoleIDs, armhelpers.AADNetworkContributorRoleID)
+	}
+	var assignments int
+	page, err := client.ListRoleAssignmentsForPrincipal(ctx, scope, objectID)
+	for ; err == nil && page.NotDone(); err = page.Next() {
+		for _, ra := range page.Values() {
+			if ra.Properties == nil {
+				continue
+			}
+			assignments++
+			for _, roleID := range sufficientRoleIDs {
+				if strings.HasSuffix(strings.ToLower(to.String(ra.Properties.RoleDefinitionID)), "/"+roleID) {
+					return nil
+				}
+			}
+		}
+	}
+	if err != nil {
+		log.Warnf("unable to list role assignments of service principal %s on %s, skipping role assignment check: %s", clientID, scope, err)
+		return nil
+	}
+	if assignments == 0 {
+		return errors.Errorf("service principal %s has no role assignments on %s. "+
+			"Grant it access with 'az role assignment create --assignee %s --role \"%s\" --scope %s'",
+			clientID, scope, clientID, suggestedRole, scope)
+	}
+	log.Warnf("service principal %s is not assigned the Owner or %s role on %s, make sure its custom roles grant enough permissions",
+		clientID, suggestedRole, scope)
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/graphrbac/1.6/graphrbac"
+	"github.com/Azure/go-autorest/autorest/date"
+)
+
+func TestValidateServicePrincipal(t *testing.T) {
+	cases := []struct {
+		name               string
+		client             *armhelpers.MockAKSEngineClient
+		useManagedIdentity bool
+		keyvaultSecret     bool
+		customVNET         bool
+		expectedErr        string
+	}{
+		{
+			name:   "valid service principal",
+			client: &armhelpers.MockAKSEngineClient{},
+		},
+		{
+			name:       "valid service principal with custom vnet",
+			client:     &armhelpers.MockAKSEngineClient{},
+			customVNET: true,
+		},
+		{
+			name:        "invalid secret",
+			client:      &armhelpers.MockAKSEngineClient{FailValidateServicePrincipalSecret: true},
+			expectedErr: "service principal DEC923E3-1EF1-4745-9516-37906D56DEC4 failed to authenticate with servicePrincipalProfile.secret",
+		},
+		{
+			name:           "keyvault secret is not validated",
+			client:         &armhelpers.MockAKSEngineClient{FailValidateServicePrincipalSecret: true},
+			keyvaultSecret: true,
+		},
+		{
+			name:               "managed identity is not validated",
+			client:             &armhelpers.MockAKSEngineClient{FailValidateServicePrincipalSecret: true},
+			useManagedIdentity: true,
+		},
+		{
+			name:        "expired secret",
+			client:      &armhelpers.MockAKSEngineClient{FakeServicePrincipalSecretEndDate: time.Now().AddDate(0, 0, -1)},
+			expectedErr: "all secrets of service principal DEC923E3-1EF1-4745-9516-37906D56DEC4 expired",
+		},
+		{
+			name:   "service principal lookup failure is not fatal",
+			client: &armhelpers.MockAKSEngineClient{FailGetServicePrincipalCredentials: true, MissingServicePrincipalRoleAssignments: true},
+		},
+		{
+			name:        "missing role assignments",
+			client:      &armhelpers.MockAKSEngineClient{MissingServicePrincipalRoleAssignments: true},
+			expectedErr: "service principal DEC923E3-1EF1-4745-9516-37906D56DEC4 has no role assignments on /subscriptions/SUB_ID/resourceGroups/RG_NAME",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			cs := api.CreateMockContainerService("testcluster", "1.13.0", 3, 2, false)
+			cs.Properties.OrchestratorProfile.KubernetesConfig.UseManagedIdentity = c.useManagedIdentity
+			if c.keyvaultSecret {
+				cs.Properties.ServicePrincipalProfile.Secret = ""
+				cs.Properties.ServicePrincipalProfile.KeyvaultSecretRef = &api.KeyvaultSecretRef{
+					VaultID:    "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.KeyVault/vaults/KV_NAME",
+					SecretName: "secret",
+				}
+			}
+			if c.customVNET {
+				cs.Properties.MasterProfile.VnetSubnetID = "/subscriptions/SUB_ID/resourceGroups/VNET_RG/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME"
+			}
+			err := validateServicePrincipal(c.client, cs, "SUB_ID", "RG_NAME")
+			if c.expectedErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, but got %s", err)
+				}
+			} else if err == nil || !strings.HasPrefix(err.Error(), c.expectedErr) {
+				t.Errorf("expected error starting with %s, but got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidatePasswordCredentialsExpiry(t *testing.T) {
+	now := time.Now()
+	credential := func(endDate time.Time) graphrbac.PasswordCredential {
+		return graphrbac.PasswordCredential{EndDate: &date.Time{Time: endDate}}
+	}
+	cases := []struct {
+		name        string
+		credentials []graphrbac.PasswordCredential
+		expectErr   bool
+	}{
+		{
+			name: "no credentials",
+		},
+		{
+			name:        "valid credential",
+			credentials: []graphrbac.PasswordCredential{credential(now.AddDate(1, 0, 0))},
+		},
+		{
+			name:        "expiring credential",
+			credentials: []graphrbac.PasswordCredential{credential(now.AddDate(0, 0, 7))},
+		},
+		{
+			name:        "one of several credentials expired",
+			credentials: []graphrbac.PasswordCredential{credential(now.AddDate(0, 0, -7)), credential(now.AddDate(1, 0, 0))},
+		},
+		{
+			name:        "all credentials expired",
+			credentials: []graphrbac.PasswordCredential{credential(now.AddDate(0, 0, -7)), credential(now.AddDate(0, 0, -1))},
+			expectErr:   true,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			err := validatePasswordCredentialsExpiry("client-id", c.credentials, now)
+			if c.expectErr != (err != nil) {
+				t.Errorf("expected error %t, but got %v", c.expectErr, err)
+			}
+		})
+	}
+}
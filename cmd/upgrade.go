@@ -6,8 +6,10 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Azure/aks-engine/pkg/api"
@@ -15,18 +17,24 @@ import (
 	"github.com/Azure/aks-engine/pkg/engine"
 	"github.com/Azure/aks-engine/pkg/helpers"
 	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/operations"
 	"github.com/Azure/aks-engine/pkg/operations/kubernetesupgrade"
 	"github.com/leonelquinteros/gotext"
 	"github.com/pkg/errors"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	v1 "k8s.io/api/core/v1"
 )
 
 const (
 	upgradeName             = "upgrade"
 	upgradeShortDescription = "Upgrade an existing Kubernetes cluster"
 	upgradeLongDescription  = "Upgrade an existing Kubernetes cluster, one minor version at a time"
+
+	upgradeHealthGateTimeout  = 20 * time.Minute
+	upgradeHealthGateInterval = 10 * time.Second
 )
 
 type upgradeCmd struct {
@@ -37,10 +45,19 @@ type upgradeCmd struct {
 	apiModelPath                string
 	deploymentDirectory         string
 	upgradeVersion              string
+	upgradeTarget               string
+	dryRun                      bool
 	location                    string
 	timeoutInMinutes            int
 	cordonDrainTimeoutInMinutes int
 	force                       bool
+	resume                      bool
+	drainGracePeriodSeconds     int
+	drainIgnoreDaemonSets       bool
+	drainDeleteEmptyDirData     bool
+	forceAfterTimeout           bool
+	vmssMaxSurge                int
+	vmssMaxUnavailable          int
 
 	// derived
 	containerService    *api.ContainerService
@@ -51,11 +68,20 @@ type upgradeCmd struct {
 	agentPoolsToUpgrade map[string]bool
 	timeout             *time.Duration
 	cordonDrainTimeout  *time.Duration
+	drainOptions        *operations.DrainOptions
+	surgeOptions        *kubernetesupgrade.SurgeOptions
+	upgradePath         []string
+	// planErrors are the validation errors a dry run reports rather than failing on
+	planErrors []string
+	// checkpoint records the progress of the upgrade to the current version of the upgrade path
+	checkpoint *kubernetesupgrade.Checkpoint
+	out        io.Writer
 }
 
 func newUpgradeCmd() *cobra.Command {
 	uc := upgradeCmd{
 		authProvider: &authArgs{},
+		out:          os.Stdout,
 	}
 
 	upgradeCmd := &cobra.Command{
@@ -70,10 +96,19 @@ func newUpgradeCmd() *cobra.Command {
 	f.StringVarP(&uc.resourceGroupName, "resource-group", "g", "", "the resource group where the cluster is deployed (required)")
 	f.StringVarP(&uc.apiModelPath, "api-model", "m", "", "path to the generated apimodel.json file")
 	f.StringVar(&uc.deploymentDirectory, "deployment-dir", "", "the location of the output from `generate`")
-	f.StringVarP(&uc.upgradeVersion, "upgrade-version", "k", "", "desired kubernetes version (required)")
+	f.StringVarP(&uc.upgradeVersion, "upgrade-version", "k", "", "desired kubernetes version (required unless --to is specified)")
+	f.StringVar(&uc.upgradeTarget, "to", "", "desired kubernetes version, reached by upgrading through each intermediate minor version in turn")
+	f.BoolVar(&uc.dryRun, "dry-run", false, "print the VMs the upgrade replaces, in order, and the errors blocking it without upgrading the cluster")
 	f.IntVar(&uc.timeoutInMinutes, "vm-timeout", -1, "how long to wait for each vm to be upgraded in minutes")
 	f.IntVar(&uc.cordonDrainTimeoutInMinutes, "cordon-drain-timeout", -1, "how long to wait for each vm to be cordoned in minutes")
 	f.BoolVarP(&uc.force, "force", "f", false, "force upgrading the cluster to desired version. Allows same version upgrades and downgrades.")
+	f.IntVar(&uc.drainGracePeriodSeconds, "drain-grace-period", -1, "seconds the pods evicted by the drain of each vm are given to terminate, instead of their own termination grace period")
+	f.BoolVar(&uc.drainIgnoreDaemonSets, "drain-ignore-daemonsets", true, "leave the DaemonSet pods on the drained vms, rather than failing the drain")
+	f.BoolVar(&uc.drainDeleteEmptyDirData, "drain-delete-emptydir-data", true, "evict the pods with emptyDir volumes, whose data is lost, rather than failing the drain")
+	f.BoolVar(&uc.forceAfterTimeout, "force-after-timeout", false, "delete the pods left on a vm once --cordon-drain-timeout elapses, such as those a PodDisruptionBudget does not allow evicting, rather than failing the upgrade")
+	f.IntVar(&uc.vmssMaxSurge, "vmss-max-surge", 0, "upgrade the VMSS agent pools by scaling each scale set out by up to this number of VMs, which must be Ready before the VMs they replace are drained and deleted, rather than by swapping one VM at a time")
+	f.IntVar(&uc.vmssMaxUnavailable, "vmss-max-unavailable", 1, "number of the VMs replaced by a surge of --vmss-max-surge VMs drained at the same time")
+	f.BoolVar(&uc.resume, "resume", false, "resume an upgrade that did not complete, skipping the VMs its checkpoint next to the api model records as upgraded")
 	addAuthFlags(uc.getAuthArgs(), f)
 
 	f.MarkDeprecated("deployment-dir", "deployment-dir is no longer required for scale or upgrade. Please use --api-model.")
@@ -110,11 +145,21 @@ func (uc *upgradeCmd) validate(cmd *cobra.Command) error {
 		uc.cordonDrainTimeout = &cordonDrainTimeout
 	}
 
-	if uc.upgradeVersion == "" {
+	if uc.upgradeVersion == "" && uc.upgradeTarget == "" {
 		cmd.Usage()
 		return errors.New("--upgrade-version must be specified")
 	}
 
+	if uc.upgradeVersion != "" && uc.upgradeTarget != "" {
+		cmd.Usage()
+		return errors.New("ambiguous, please specify only one of --upgrade-version and --to")
+	}
+
+	if uc.upgradeTarget != "" && uc.force {
+		cmd.Usage()
+		return errors.New("--force cannot be used with --to")
+	}
+
 	if uc.apiModelPath == "" && uc.deploymentDirectory == "" {
 		cmd.Usage()
 		return errors.New("--api-model must be specified")
@@ -125,6 +170,18 @@ func (uc *upgradeCmd) validate(cmd *cobra.Command) error {
 		return errors.New("ambiguous, please specify only one of --api-model and --deployment-dir")
 	}
 
+	if uc.vmssMaxSurge != 0 {
+		surgeOptions := &kubernetesupgrade.SurgeOptions{
+			MaxSurge:       uc.vmssMaxSurge,
+			MaxUnavailable: uc.vmssMaxUnavailable,
+		}
+		if err = surgeOptions.Validate(); err != nil {
+			cmd.Usage()
+			return errors.Wrap(err, "validating --vmss-max-surge and --vmss-max-unavailable")
+		}
+		uc.surgeOptions = surgeOptions
+	}
+
 	return nil
 }
 
@@ -171,9 +228,12 @@ func (uc *upgradeCmd) loadCluster() error {
 		return errors.Wrap(err, "failed to get client")
 	}
 
-	_, err = uc.client.EnsureResourceGroup(ctx, uc.resourceGroupName, uc.location, nil)
-	if err != nil {
-		return errors.Wrap(err, "error ensuring resource group")
+	// a dry run does not change anything, not even the resource group
+	if !uc.dryRun {
+		_, err = uc.client.EnsureResourceGroup(ctx, uc.resourceGroupName, uc.location, nil)
+		if err != nil {
+			return errors.Wrap(err, "error ensuring resource group")
+		}
 	}
 
 	err = uc.initialize()
@@ -210,13 +270,28 @@ func (uc *upgradeCmd) initialize() error {
 		return errors.New("--location does not match api model location")
 	}
 
-	if !uc.force {
-		err := uc.validateTargetVersion()
+	if uc.upgradeTarget != "" {
+		currentVersion := uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion
+		path, err := api.GetKubernetesUpgradePath(uc.containerService.Properties.OrchestratorProfile, uc.upgradeTarget, uc.containerService.Properties.HasWindows())
 		if err != nil {
-			return errors.Wrap(err, "Invalid upgrade target version. Consider using --force if you really want to proceed")
+			return errors.Wrap(err, "Invalid upgrade target version")
 		}
+		log.Infof("Upgrade path from Kubernetes version %s to %s: %s", currentVersion, uc.upgradeTarget, strings.Join(path, " -> "))
+		uc.upgradePath = path
+	} else {
+		if !uc.force {
+			err := uc.validateTargetVersion()
+			if err != nil {
+				err = errors.Wrap(err, "Invalid upgrade target version. Consider using --force if you really want to proceed")
+				if !uc.dryRun {
+					return err
+				}
+				uc.planErrors = append(uc.planErrors, err.Error())
+			}
+		}
+		uc.upgradePath = []string{uc.upgradeVersion}
 	}
-	uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion = uc.upgradeVersion
+	uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion = uc.upgradePath[0]
 
 	//allows to identify VMs in the resource group that belong to this cluster.
 	uc.nameSuffix = uc.containerService.Properties.GetClusterID()
@@ -242,7 +317,75 @@ func (uc *upgradeCmd) run(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "loading existing cluster")
 	}
 
-	upgradeCluster := kubernetesupgrade.UpgradeCluster{
+	if err = uc.setDrainOptions(cmd.Flags()); err != nil {
+		return errors.Wrap(err, "validating drain options")
+	}
+
+	if err = validateServicePrincipal(uc.client, uc.containerService, uc.getAuthArgs().SubscriptionID.String(), uc.resourceGroupName); err != nil {
+		err = errors.Wrap(err, "validating service principal")
+		if !uc.dryRun {
+			return err
+		}
+		uc.planErrors = append(uc.planErrors, err.Error())
+	}
+
+	kubeConfig, err := engine.GenerateKubeConfig(uc.containerService.Properties, uc.location)
+	if err != nil {
+		return errors.Wrap(err, "generating kubeconfig")
+	}
+
+	if uc.resume {
+		if err = uc.loadCheckpoint(); err != nil {
+			return errors.Wrap(err, "resuming upgrade")
+		}
+	}
+
+	if uc.dryRun {
+		return uc.printUpgradePlan(kubeConfig)
+	}
+
+	for i, version := range uc.upgradePath {
+		if i > 0 {
+			// do not start the next hop until the cluster has settled on the previous version
+			if err = uc.waitForHealthyCluster(kubeConfig, uc.upgradePath[i-1]); err != nil {
+				return errors.Wrapf(err, "cluster is not healthy after upgrading to Kubernetes version %s", uc.upgradePath[i-1])
+			}
+			uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion = version
+		}
+		if err = uc.upgradeCluster(kubeConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printUpgradePlan prints the VMs each hop of the upgrade path replaces, and fails if validation errors block the upgrade
+func (uc *upgradeCmd) printUpgradePlan(kubeConfig string) error {
+	plan, err := uc.newUpgradeCluster().Plan(uc.client, kubeConfig)
+	if err != nil {
+		return errors.Wrap(err, "planning upgrade")
+	}
+	plan.Errors = append(uc.planErrors, plan.Errors...)
+	blocked := len(plan.Errors) > 0
+
+	for i, version := range uc.upgradePath {
+		if i > 0 {
+			plan = plan.Next(version)
+			fmt.Fprintln(uc.out)
+		}
+		if err = plan.WriteText(uc.out); err != nil {
+			return errors.Wrap(err, "writing upgrade plan")
+		}
+	}
+
+	if blocked {
+		return errors.New("the upgrade is blocked by the validation errors of the plan")
+	}
+	return nil
+}
+
+func (uc *upgradeCmd) newUpgradeCluster() *kubernetesupgrade.UpgradeCluster {
+	upgradeCluster := &kubernetesupgrade.UpgradeCluster{
 		Translator: &i18n.Translator{
 			Locale: uc.locale,
 		},
@@ -259,15 +402,77 @@ func (uc *upgradeCmd) run(cmd *cobra.Command, args []string) error {
 	upgradeCluster.NameSuffix = uc.nameSuffix
 	upgradeCluster.AgentPoolsToUpgrade = uc.agentPoolsToUpgrade
 	upgradeCluster.Force = uc.force
+	upgradeCluster.Checkpoint = uc.checkpoint
+	upgradeCluster.DrainOptions = uc.drainOptions
+	upgradeCluster.SurgeOptions = uc.surgeOptions
+	return upgradeCluster
+}
 
-	kubeConfig, err := engine.GenerateKubeConfig(uc.containerService.Properties, uc.location)
+// setDrainOptions sets the options the nodes are drained with: those of the drain profile of the api model,
+// overridden by the drain flags set
+func (uc *upgradeCmd) setDrainOptions(flags *flag.FlagSet) error {
+	var profile *api.DrainProfile
+	if o := uc.containerService.Properties.OrchestratorProfile; o != nil && o.KubernetesConfig != nil {
+		profile = o.KubernetesConfig.DrainProfile
+	}
+	options := kubernetesupgrade.DrainOptionsFromProfile(profile)
+	if flags.Changed("drain-grace-period") {
+		if uc.drainGracePeriodSeconds < 0 {
+			return errors.Errorf("--drain-grace-period %d must not be negative", uc.drainGracePeriodSeconds)
+		}
+		gracePeriod := int64(uc.drainGracePeriodSeconds)
+		options.GracePeriodSeconds = &gracePeriod
+	}
+	if flags.Changed("drain-ignore-daemonsets") {
+		options.IgnoreDaemonSets = uc.drainIgnoreDaemonSets
+	}
+	if flags.Changed("drain-delete-emptydir-data") {
+		options.DeleteEmptyDirData = uc.drainDeleteEmptyDirData
+	}
+	if flags.Changed("force-after-timeout") {
+		options.ForceAfterTimeout = uc.forceAfterTimeout
+	}
+	uc.drainOptions = &options
+	return nil
+}
+
+// loadCheckpoint loads the checkpoint of the upgrade to resume, which must be of the first version of the upgrade path
+func (uc *upgradeCmd) loadCheckpoint() error {
+	checkpoint, err := kubernetesupgrade.LoadCheckpoint(uc.checkpointPath())
 	if err != nil {
-		return errors.Wrap(err, "generating kubeconfig")
+		return err
+	}
+	if checkpoint.TargetVersion != uc.upgradePath[0] {
+		return errors.Errorf("the upgrade checkpoint %s is of an upgrade to Kubernetes version %s, not %s", uc.checkpointPath(), checkpoint.TargetVersion, uc.upgradePath[0])
 	}
+	uc.checkpoint = checkpoint
+	return nil
+}
 
-	if err = upgradeCluster.UpgradeCluster(uc.client, kubeConfig, BuildTag); err != nil {
+// checkpointPath returns the path the progress of the upgrade is saved to, next to the api model
+func (uc *upgradeCmd) checkpointPath() string {
+	return filepath.Join(filepath.Dir(uc.apiModelPath), kubernetesupgrade.CheckpointFilename)
+}
+
+// upgradeCluster upgrades the cluster to the orchestrator version in the container service, saving its progress to the
+// upgrade checkpoint until it completes, and saves the new apimodel
+func (uc *upgradeCmd) upgradeCluster(kubeConfig string) error {
+	version := uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion
+	if uc.checkpoint == nil || uc.checkpoint.TargetVersion != version {
+		checkpoint, err := kubernetesupgrade.NewCheckpoint(uc.checkpointPath(), version)
+		if err != nil {
+			return err
+		}
+		uc.checkpoint = checkpoint
+	}
+
+	if err := uc.newUpgradeCluster().UpgradeCluster(uc.client, kubeConfig, BuildTag); err != nil {
+		log.Infof("The progress of the upgrade is saved in %s, run the upgrade again with --resume to skip the VMs already upgraded", uc.checkpoint.Path())
 		return errors.Wrap(err, "upgrading cluster")
 	}
+	if err := uc.checkpoint.Remove(); err != nil {
+		log.Warnf("Failed to remove the upgrade checkpoint %s: %v", uc.checkpoint.Path(), err)
+	}
 
 	// Save the new apimodel to reflect the cluster's state.
 	apiloader := &api.Apiloader{
@@ -288,3 +493,53 @@ func (uc *upgradeCmd) run(cmd *cobra.Command, args []string) error {
 	dir, file := filepath.Split(uc.apiModelPath)
 	return f.SaveFile(dir, file, b)
 }
+
+// waitForHealthyCluster blocks until every node in the cluster is Ready and running the given Kubernetes version
+func (uc *upgradeCmd) waitForHealthyCluster(kubeConfig, version string) error {
+	timeout := upgradeHealthGateTimeout
+	if uc.timeout != nil {
+		timeout = *uc.timeout
+	}
+	kubeClient, err := uc.client.GetKubernetesClient("", kubeConfig, upgradeHealthGateInterval, timeout)
+	if err != nil {
+		return errors.Wrap(err, "failed to get a Kubernetes client")
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err = checkClusterHealth(kubeClient, version)
+		if err == nil {
+			log.Infof("All nodes are ready and running Kubernetes version %s", version)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Wrapf(err, "cluster was not healthy within %s", timeout)
+		}
+		log.Infof("Waiting for the cluster to become healthy: %s", err)
+		time.Sleep(upgradeHealthGateInterval)
+	}
+}
+
+// checkClusterHealth returns an error describing the first node that is not Ready or not running the given Kubernetes version
+func checkClusterHealth(kubeClient armhelpers.KubernetesClient, version string) error {
+	nodeList, err := kubeClient.ListNodes()
+	if err != nil {
+		return errors.Wrap(err, "failed to list cluster nodes")
+	}
+	for _, node := range nodeList.Items {
+		ready := false
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == v1.NodeReady && condition.Status == v1.ConditionTrue {
+				ready = true
+				break
+			}
+		}
+		if !ready {
+			return errors.Errorf("node %s is not ready", node.Name)
+		}
+		if kubeletVersion := strings.TrimPrefix(node.Status.NodeInfo.KubeletVersion, "v"); kubeletVersion != version {
+			return errors.Errorf("node %s is running Kubernetes version %s, expected %s", node.Name, kubeletVersion, version)
+		}
+	}
+	return nil
+}
@@ -4,16 +4,25 @@
 package cmd
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/Azure/aks-engine/pkg/api/common"
 
 	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/operations"
+	"github.com/Azure/aks-engine/pkg/operations/kubernetesupgrade"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
 
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
 )
 
 var validVersionsBackup map[string]bool
@@ -95,6 +104,58 @@ func TestUpgradeCommandShouldBeValidated(t *testing.T) {
 			},
 			expectedErr: errors.New("ambiguous, please specify only one of --api-model and --deployment-dir"),
 		},
+		{
+			uc: &upgradeCmd{
+				resourceGroupName: "test",
+				apiModelPath:      "./not/used",
+				upgradeVersion:    "1.9.0",
+				upgradeTarget:     "1.11.0",
+				location:          "southcentralus",
+			},
+			expectedErr: errors.New("ambiguous, please specify only one of --upgrade-version and --to"),
+		},
+		{
+			uc: &upgradeCmd{
+				resourceGroupName: "test",
+				apiModelPath:      "./not/used",
+				upgradeTarget:     "1.11.0",
+				location:          "southcentralus",
+				force:             true,
+			},
+			expectedErr: errors.New("--force cannot be used with --to"),
+		},
+		{
+			uc: &upgradeCmd{
+				resourceGroupName:  "test",
+				apiModelPath:       "./not/used",
+				upgradeVersion:     "1.9.0",
+				location:           "southcentralus",
+				vmssMaxSurge:       2,
+				vmssMaxUnavailable: 0,
+			},
+			expectedErr: errors.New("validating --vmss-max-surge and --vmss-max-unavailable: max unavailable 0 must be at least 1"),
+		},
+		{
+			uc: &upgradeCmd{
+				resourceGroupName:  "test",
+				apiModelPath:       "./not/used",
+				upgradeVersion:     "1.9.0",
+				location:           "southcentralus",
+				vmssMaxSurge:       -1,
+				vmssMaxUnavailable: 1,
+			},
+			expectedErr: errors.New("validating --vmss-max-surge and --vmss-max-unavailable: max surge -1 must be at least 1"),
+		},
+		{
+			uc: &upgradeCmd{
+				resourceGroupName: "test",
+				apiModelPath:      "./not/used",
+				upgradeVersion:    "1.9.0",
+				location:          "southcentralus",
+				dryRun:            true,
+			},
+			expectedErr: nil,
+		},
 		{
 			uc: &upgradeCmd{
 				resourceGroupName:   "test",
@@ -105,6 +166,16 @@ func TestUpgradeCommandShouldBeValidated(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			uc: &upgradeCmd{
+				resourceGroupName: "test",
+				apiModelPath:      "./not/used",
+				upgradeTarget:     "1.11.0",
+				location:          "southcentralus",
+				dryRun:            true,
+			},
+			expectedErr: nil,
+		},
 	}
 
 	for _, c := range cases {
@@ -129,6 +200,8 @@ func TestCreateUpgradeCommand(t *testing.T) {
 	g.Expect(command.Flags().Lookup("resource-group")).NotTo(BeNil())
 	g.Expect(command.Flags().Lookup("api-model")).NotTo(BeNil())
 	g.Expect(command.Flags().Lookup("upgrade-version")).NotTo(BeNil())
+	g.Expect(command.Flags().Lookup("to")).NotTo(BeNil())
+	g.Expect(command.Flags().Lookup("dry-run")).NotTo(BeNil())
 
 	command.SetArgs([]string{})
 	if err := command.Execute(); err == nil {
@@ -282,3 +355,212 @@ func TestUpgradeForceDowngradeShouldSetVersionOnContainerService(t *testing.T) {
 	g.Expect(upgradeCmd.containerService.Properties.OrchestratorProfile.OrchestratorVersion).To(Equal("1.10.12"))
 	resetValidVersions()
 }
+
+func TestUpgradeDryRunShouldReportUnsupportedVersion(t *testing.T) {
+	setupValidVersions(map[string]bool{
+		"1.10.13": false,
+		"1.10.12": true,
+	})
+	g := NewGomegaWithT(t)
+	upgradeCmd := &upgradeCmd{
+		resourceGroupName: "rg",
+		apiModelPath:      "./not/used",
+		upgradeVersion:    "1.10.13",
+		location:          "centralus",
+		dryRun:            true,
+
+		client: &armhelpers.MockAKSEngineClient{},
+	}
+
+	containerServiceMock := api.CreateMockContainerService("testcluster", "1.10.12", 3, 2, false)
+	containerServiceMock.Location = "centralus"
+	upgradeCmd.containerService = containerServiceMock
+	err := upgradeCmd.initialize()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(upgradeCmd.planErrors).To(HaveLen(1))
+	g.Expect(upgradeCmd.planErrors[0]).To(ContainSubstring("upgrading from Kubernetes version 1.10.12 to version 1.10.13 is not supported"))
+	resetValidVersions()
+}
+
+func TestUpgradeDryRunShouldPrintPlan(t *testing.T) {
+	setupValidVersions(map[string]bool{
+		"1.10.12": true,
+		"1.10.13": true,
+	})
+	g := NewGomegaWithT(t)
+	var out bytes.Buffer
+	mockClient := &armhelpers.MockAKSEngineClient{}
+	upgradeCmd := &upgradeCmd{
+		authProvider:      &authArgs{},
+		resourceGroupName: "rg",
+		apiModelPath:      "./not/used",
+		upgradeVersion:    "1.10.13",
+		location:          "centralus",
+		dryRun:            true,
+		planErrors:        []string{"validating service principal: secret expired"},
+		out:               &out,
+
+		client: mockClient,
+	}
+	mockClient.FakeListVirtualMachineResult = func() []compute.VirtualMachine {
+		return []compute.VirtualMachine{
+			mockClient.MakeFakeVirtualMachine("k8s-agentpool1-12345678-0", "Kubernetes:1.10.12"),
+		}
+	}
+
+	containerServiceMock := api.CreateMockContainerService("testcluster", "1.10.12", 3, 2, false)
+	containerServiceMock.Location = "centralus"
+	upgradeCmd.containerService = containerServiceMock
+	g.Expect(upgradeCmd.initialize()).To(Succeed())
+	upgradeCmd.nameSuffix = "12345678"
+
+	err := upgradeCmd.printUpgradePlan("kubeConfig")
+	g.Expect(err).To(MatchError("the upgrade is blocked by the validation errors of the plan"))
+	g.Expect(out.String()).To(ContainSubstring("VMs to upgrade to Kubernetes version 1.10.13, in order:"))
+	g.Expect(out.String()).To(ContainSubstring("k8s-agentpool1-12345678-0"))
+	g.Expect(out.String()).To(ContainSubstring("Blocking errors:\n  validating service principal: secret expired\n"))
+	resetValidVersions()
+}
+
+func TestUpgradeToShouldComputeUpgradePath(t *testing.T) {
+	setupValidVersions(map[string]bool{
+		"1.10.12": true,
+		"1.10.13": true,
+		"1.11.9":  true,
+		"1.11.10": true,
+		"1.12.7":  true,
+		"1.12.8":  true,
+	})
+	g := NewGomegaWithT(t)
+	upgradeCmd := &upgradeCmd{
+		resourceGroupName:           "rg",
+		apiModelPath:                "./not/used",
+		upgradeTarget:               "1.12.7",
+		location:                    "centralus",
+		timeoutInMinutes:            60,
+		cordonDrainTimeoutInMinutes: 60,
+
+		client: &armhelpers.MockAKSEngineClient{},
+	}
+
+	containerServiceMock := api.CreateMockContainerService("testcluster", "1.10.12", 3, 2, false)
+	containerServiceMock.Location = "centralus"
+	upgradeCmd.containerService = containerServiceMock
+	err := upgradeCmd.initialize()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(upgradeCmd.upgradePath).To(Equal([]string{"1.11.10", "1.12.7"}))
+	g.Expect(upgradeCmd.containerService.Properties.OrchestratorProfile.OrchestratorVersion).To(Equal("1.11.10"))
+	resetValidVersions()
+}
+
+func TestUpgradeToShouldFailForUnsupportedVersion(t *testing.T) {
+	setupValidVersions(map[string]bool{
+		"1.10.12": true,
+		"1.11.10": true,
+	})
+	g := NewGomegaWithT(t)
+	upgradeCmd := &upgradeCmd{
+		resourceGroupName: "rg",
+		apiModelPath:      "./not/used",
+		upgradeTarget:     "1.12.7",
+		location:          "centralus",
+
+		client: &armhelpers.MockAKSEngineClient{},
+	}
+
+	containerServiceMock := api.CreateMockContainerService("testcluster", "1.10.12", 3, 2, false)
+	containerServiceMock.Location = "centralus"
+	upgradeCmd.containerService = containerServiceMock
+	err := upgradeCmd.initialize()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("Kubernetes version 1.12.7 is not supported"))
+	resetValidVersions()
+}
+
+func TestUpgradeResumeShouldLoadCheckpoint(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "upgrade-checkpoint")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+	upgradeCmd := &upgradeCmd{
+		authProvider: &authArgs{},
+		apiModelPath: filepath.Join(dir, "apimodel.json"),
+		upgradePath:  []string{"1.10.13", "1.11.10"},
+		resume:       true,
+	}
+
+	err = upgradeCmd.loadCheckpoint()
+	g.Expect(err).To(MatchError("no upgrade checkpoint found at " + filepath.Join(dir, kubernetesupgrade.CheckpointFilename)))
+
+	_, err = kubernetesupgrade.NewCheckpoint(upgradeCmd.checkpointPath(), "1.11.10")
+	g.Expect(err).NotTo(HaveOccurred())
+	err = upgradeCmd.loadCheckpoint()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("is of an upgrade to Kubernetes version 1.11.10, not 1.10.13"))
+
+	checkpoint, err := kubernetesupgrade.NewCheckpoint(upgradeCmd.checkpointPath(), "1.10.13")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(checkpoint.SetState("agentpool1", "k8s-agentpool1-12345678-0", kubernetesupgrade.VMUpgraded)).To(Succeed())
+	g.Expect(upgradeCmd.loadCheckpoint()).To(Succeed())
+	g.Expect(upgradeCmd.newUpgradeCluster().Checkpoint.IsUpgraded("k8s-agentpool1-12345678-0")).To(BeTrue())
+}
+
+func TestUpgradeShouldMergeDrainFlagsOverDrainProfile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	command := newUpgradeCmd()
+	upgradeCmd := &upgradeCmd{
+		authProvider:     &authArgs{},
+		containerService: api.CreateMockContainerService("testcluster", "1.10.13", 3, 2, false),
+	}
+	upgradeCmd.containerService.Properties.OrchestratorProfile.KubernetesConfig.DrainProfile = &api.DrainProfile{
+		GracePeriodSeconds: to.IntPtr(60),
+		IgnoreDaemonSets:   to.BoolPtr(false),
+	}
+
+	g.Expect(command.Flags().Parse([]string{"--force-after-timeout", "--drain-ignore-daemonsets=true"})).To(Succeed())
+	upgradeCmd.forceAfterTimeout = true
+	upgradeCmd.drainIgnoreDaemonSets = true
+	g.Expect(upgradeCmd.setDrainOptions(command.Flags())).To(Succeed())
+	g.Expect(upgradeCmd.newUpgradeCluster().DrainOptions).To(Equal(&operations.DrainOptions{
+		GracePeriodSeconds: to.Int64Ptr(60),
+		IgnoreDaemonSets:   true,
+		DeleteEmptyDirData: true,
+		ForceAfterTimeout:  true,
+	}))
+
+	command = newUpgradeCmd()
+	g.Expect(command.Flags().Parse([]string{"--drain-grace-period", "-5"})).To(Succeed())
+	upgradeCmd.drainGracePeriodSeconds = -5
+	g.Expect(upgradeCmd.setDrainOptions(command.Flags())).To(MatchError("--drain-grace-period -5 must not be negative"))
+}
+
+func TestCheckClusterHealth(t *testing.T) {
+	g := NewGomegaWithT(t)
+	node := func(name, version string, ready v1.ConditionStatus) v1.Node {
+		n := v1.Node{}
+		n.Name = name
+		n.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: ready}}
+		n.Status.NodeInfo.KubeletVersion = version
+		return n
+	}
+
+	kubeClient := &armhelpers.MockKubernetesClient{
+		NodeList: &v1.NodeList{Items: []v1.Node{
+			node("k8s-master-1234", "v1.11.10", v1.ConditionTrue),
+			node("k8s-agentpool1-1234", "v1.11.10", v1.ConditionTrue),
+		}},
+	}
+	g.Expect(checkClusterHealth(kubeClient, "1.11.10")).To(Succeed())
+
+	err := checkClusterHealth(kubeClient, "1.12.7")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("node k8s-master-1234 is running Kubernetes version 1.11.10, expected 1.12.7"))
+
+	kubeClient.NodeList.Items[1] = node("k8s-agentpool1-1234", "v1.11.10", v1.ConditionFalse)
+	err = checkClusterHealth(kubeClient, "1.11.10")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("node k8s-agentpool1-1234 is not ready"))
+
+	kubeClient.FailListNodes = true
+	g.Expect(checkClusterHealth(kubeClient, "1.11.10")).NotTo(Succeed())
+}
@@ -0,0 +1,363 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/persona"
+	"github.com/Azure/aks-engine/pkg/api/vlabs"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	validateName             = "validate"
+	validateShortDescription = "Validate an api model without generating anything"
+	validateLongDescription  = "Runs the api model validations of generate and checks the VM sizes and availability zones of the cluster against the capabilities of its location, without generating any assets. Every error is reported with the JSON path of the api model field it is about, and the command fails if there is any."
+)
+
+type validateCmd struct {
+	authProvider
+
+	// user input
+	apimodelPath     string
+	capabilitiesPath string
+	outputFormat     string
+	// azure looks up the VM sizes and availability zones of the location in the subscription instead of the capabilities file
+	azure bool
+
+	// derived
+	containerService *api.ContainerService
+	apiVersion       string
+	locale           *gotext.Locale
+	client           armhelpers.AKSEngineClient
+	out              io.Writer
+}
+
+// validateReport is the JSON output of validate
+type validateReport struct {
+	APIModel string                  `json:"apiModel"`
+	Valid    bool                    `json:"valid"`
+	Errors   []vlabs.ValidationError `json:"errors"`
+}
+
+func newValidateCmd() *cobra.Command {
+	vc := validateCmd{
+		authProvider: &authArgs{},
+		out:          os.Stdout,
+	}
+
+	command := &cobra.Command{
+		Use:   validateName,
+		Short: validateShortDescription,
+		Long:  validateLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := vc.validate(cmd, args); err != nil {
+				return errors.Wrap(err, "validating validate args")
+			}
+			if err := vc.loadAPIModel(); err != nil {
+				return errors.Wrap(err, "loading api model")
+			}
+			return vc.run()
+		},
+	}
+
+	f := command.Flags()
+	f.StringVarP(&vc.apimodelPath, "api-model", "m", "", "path to your cluster definition file")
+	f.StringVar(&vc.capabilitiesPath, "capabilities-file", "", "path to the capabilities file the VM sizes and availability zones are checked against (defaults to the capabilities aks-engine is built with)")
+	f.StringVarP(&vc.outputFormat, "output", "o", "human", fmt.Sprintf("Output format. Allowed values: %s", strings.Join(outputFormatOptions, ", ")))
+	f.BoolVar(&vc.azure, "azure", false, "check the VM sizes and availability zones against the resource SKUs the subscription can deploy in the cluster location")
+
+	addAuthFlags(vc.getAuthArgs(), f)
+
+	return command
+}
+
+func (vc *validateCmd) validate(cmd *cobra.Command, args []string) error {
+	var err error
+
+	vc.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if vc.apimodelPath == "" {
+		if len(args) == 1 {
+			vc.apimodelPath = args[0]
+		} else if len(args) > 1 {
+			cmd.Usage()
+			return errors.New("too many arguments were provided to 'validate'")
+		} else {
+			cmd.Usage()
+			return errors.New("--api-model was not supplied, nor was one specified as a positional argument")
+		}
+	}
+
+	if _, err = os.Stat(vc.apimodelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", vc.apimodelPath)
+	}
+
+	if vc.outputFormat != "human" && vc.outputFormat != "json" {
+		return errors.Errorf(`output format "%s" is not supported`, vc.outputFormat)
+	}
+
+	if vc.capabilitiesPath != "" {
+		if vc.azure {
+			return errors.New("--capabilities-file cannot be used with --azure")
+		}
+		if _, err = os.Stat(vc.capabilitiesPath); os.IsNotExist(err) {
+			return errors.Errorf("specified capabilities file does not exist (%s)", vc.capabilitiesPath)
+		}
+	}
+
+	if vc.azure {
+		if err = vc.getAuthArgs().validateAuthArgs(); err != nil {
+			return errors.Wrap(err, "failed to get validate auth args")
+		}
+	}
+
+	return nil
+}
+
+func (vc *validateCmd) loadAPIModel() error {
+	contents, err := ioutil.ReadFile(vc.apimodelPath)
+	if err != nil {
+		return errors.Wrapf(err, "reading the api model %s", vc.apimodelPath)
+	}
+	m := &api.TypeMeta{}
+	if err = json.Unmarshal(contents, m); err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+	// the deprecated fields are validated as generate would, once migrated to their supported equivalents
+	if m.APIVersion == vlabs.APIVersion {
+		var deprecations []vlabs.Deprecation
+		if contents, deprecations, err = vlabs.MigrateAPIModel(contents); err != nil {
+			return errors.Wrap(err, "error migrating the deprecated fields of the api model")
+		}
+		for _, d := range deprecations {
+			log.Warnf("Deprecated field in the api model: %s", d)
+		}
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: vc.locale,
+		},
+	}
+	vc.containerService, vc.apiVersion, err = apiloader.DeserializeContainerService(contents, false, false, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+	return nil
+}
+
+func (vc *validateCmd) run() error {
+	var validationErrors []vlabs.ValidationError
+	if vc.apiVersion == vlabs.APIVersion {
+		validationErrors = api.ConvertContainerServiceToVLabs(vc.containerService).ValidateAll(false)
+	} else {
+		log.Warnf("API model validation is only available for \"apiVersion\": \"vlabs\", only checking the capabilities of the location...")
+	}
+
+	if vc.containerService.Properties != nil {
+		p, err := vc.getPersona()
+		if err != nil {
+			return err
+		}
+		capabilityErrors, err := vc.containerService.CapabilityErrors(p)
+		if err != nil {
+			return errors.Wrap(err, "validating the api model against the capabilities of its location")
+		}
+		for _, e := range capabilityErrors {
+			validationErrors = append(validationErrors, vlabs.ValidationError{Path: e.Path, Message: e.Err.Error()})
+		}
+	}
+
+	switch vc.outputFormat {
+	case "json":
+		report := validateReport{APIModel: vc.apimodelPath, Valid: len(validationErrors) == 0, Errors: validationErrors}
+		if report.Errors == nil {
+			report.Errors = []vlabs.ValidationError{}
+		}
+		b, err := helpers.JSONMarshalIndent(report, "", "  ", false)
+		if err != nil {
+			return errors.Wrap(err, "error encoding report to json")
+		}
+		fmt.Fprintln(vc.out, string(b))
+	default:
+		for _, e := range validationErrors {
+			fmt.Fprintln(vc.out, e)
+		}
+		if len(validationErrors) == 0 {
+			fmt.Fprintf(vc.out, "The api model %s is valid\n", vc.apimodelPath)
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return errors.Errorf("the api model %s has %d validation errors", vc.apimodelPath, len(validationErrors))
+	}
+	return nil
+}
+
+// getPersona returns the persona answering the capabilities of the cluster location: the resource SKUs of the subscription
+// with --azure, else the capabilities file
+func (vc *validateCmd) getPersona() (persona.Persona, error) {
+	if !vc.azure {
+		if vc.capabilitiesPath != "" {
+			capabilities, err := persona.LoadCapabilitiesFile(vc.capabilitiesPath)
+			if err != nil {
+				return nil, err
+			}
+			return persona.NewOffline(capabilities), nil
+		}
+		return persona.NewOffline(persona.BundledCapabilities()), nil
+	}
+
+	var err error
+	if vc.client == nil {
+		if vc.client, err = vc.authProvider.getClient(); err != nil {
+			return nil, errors.Wrap(err, "failed to get client")
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	skus, err := vc.client.ListResourceSkus(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing the resource SKUs of the subscription")
+	}
+	location := helpers.NormalizeAzureRegion(vc.containerService.Location)
+	return persona.NewOffline(&persona.CapabilitiesFile{
+		Locations: map[string]persona.LocationCapabilities{
+			location: locationCapabilitiesOf(skus, location),
+		},
+	}), nil
+}
+
+// locationCapabilitiesOf returns the VM sizes the resource SKUs offer in location, with the availability zones
+// they are not restricted from and their ephemeral OS disk cache sizes, and the availability zones offering Ultra SSDs
+func locationCapabilitiesOf(skus []compute.ResourceSku, location string) persona.LocationCapabilities {
+	capabilities := persona.LocationCapabilities{
+		Zones:                  map[string][]string{},
+		EphemeralOSDiskCacheGB: map[string]int{},
+	}
+	ultraSSDZones := []string{}
+	for _, sku := range skus {
+		if sku.ResourceType == nil || sku.Name == nil || sku.LocationInfo == nil {
+			continue
+		}
+		switch {
+		case *sku.ResourceType == "disks" && *sku.Name == "UltraSSD_LRS":
+			if available, offered := offeredZonesOf(sku, location); offered {
+				ultraSSDZones = available
+			}
+		case *sku.ResourceType == "virtualMachines":
+			available, offered := offeredZonesOf(sku, location)
+			if !offered {
+				continue
+			}
+			capabilities.VMSizes = append(capabilities.VMSizes, *sku.Name)
+			capabilities.Zones[*sku.Name] = available
+			if cacheGB, ok := ephemeralOSDiskCacheGBOf(sku); ok {
+				capabilities.EphemeralOSDiskCacheGB[*sku.Name] = cacheGB
+			}
+		}
+	}
+	capabilities.UltraSSDZones = &ultraSSDZones
+	return capabilities
+}
+
+// offeredZonesOf returns the availability zones a resource SKU is not restricted from in location, and false if the
+// SKU is not offered in location
+func offeredZonesOf(sku compute.ResourceSku, location string) ([]string, bool) {
+	var zones []string
+	offered := false
+	for _, info := range *sku.LocationInfo {
+		if info.Location != nil && helpers.NormalizeAzureRegion(*info.Location) == location {
+			offered = true
+			if info.Zones != nil {
+				zones = *info.Zones
+			}
+		}
+	}
+	restrictedZones := map[string]bool{}
+	if sku.Restrictions != nil {
+		for _, r := range *sku.Restrictions {
+			if r.RestrictionInfo == nil || !hasLocation(r.RestrictionInfo.Locations, location) {
+				continue
+			}
+			switch r.Type {
+			case compute.Location:
+				offered = false
+			case compute.Zone:
+				if r.RestrictionInfo.Zones != nil {
+					for _, z := range *r.RestrictionInfo.Zones {
+						restrictedZones[z] = true
+					}
+				}
+			}
+		}
+	}
+	if !offered {
+		return nil, false
+	}
+	available := []string{}
+	for _, z := range zones {
+		if !restrictedZones[z] {
+			available = append(available, z)
+		}
+	}
+	return available, true
+}
+
+// ephemeralOSDiskCacheGBOf returns the cache size in GiB of a VM size SKU, 0 if it does not support ephemeral OS
+// disks, and false if the SKU does not report its support
+func ephemeralOSDiskCacheGBOf(sku compute.ResourceSku) (int, bool) {
+	if sku.Capabilities == nil {
+		return 0, false
+	}
+	var supported, known bool
+	var cachedDiskBytes int64
+	for _, c := range *sku.Capabilities {
+		if c.Name == nil || c.Value == nil {
+			continue
+		}
+		switch *c.Name {
+		case "EphemeralOSDiskSupported":
+			supported, known = strings.EqualFold(*c.Value, "True"), true
+		case "CachedDiskBytes":
+			cachedDiskBytes, _ = strconv.ParseInt(*c.Value, 10, 64)
+		}
+	}
+	if !supported {
+		return 0, known
+	}
+	return int(cachedDiskBytes >> 30), true
+}
+
+func hasLocation(locations *[]string, location string) bool {
+	if locations == nil {
+		return false
+	}
+	for _, l := range *locations {
+		if helpers.NormalizeAzureRegion(l) == location {
+			return true
+		}
+	}
+	return false
+}
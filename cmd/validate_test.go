@@ -0,0 +1,212 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api/vlabs"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+func TestNewValidateCmd(t *testing.T) {
+	output := newValidateCmd()
+	if output.Use != validateName || output.Short != validateShortDescription || output.Long != validateLongDescription {
+		t.Fatalf("validate command should have use %s equal %s, short %s equal %s and long %s equal to %s", output.Use, validateName, output.Short, validateShortDescription, output.Long, validateLongDescription)
+	}
+
+	expectedFlags := []string{"api-model", "capabilities-file", "output", "azure", "subscription-id"}
+	for _, f := range expectedFlags {
+		if output.Flags().Lookup(f) == nil {
+			t.Fatalf("validate command should have flag %s", f)
+		}
+	}
+}
+
+func TestValidateCmdValidate(t *testing.T) {
+	cases := []struct {
+		vc          validateCmd
+		args        []string
+		expectedErr string
+	}{
+		{
+			vc:          validateCmd{authProvider: &authArgs{}},
+			expectedErr: "--api-model was not supplied, nor was one specified as a positional argument",
+		},
+		{
+			vc:          validateCmd{authProvider: &authArgs{}},
+			args:        []string{"../pkg/engine/testdata/simple/kubernetes.json", "../pkg/engine/testdata/vnet/kubernetesvnet.json"},
+			expectedErr: "too many arguments were provided to 'validate'",
+		},
+		{
+			vc:          validateCmd{authProvider: &authArgs{}, apimodelPath: "../pkg/engine/testdata/simple/kubernetes.json", outputFormat: "yaml"},
+			expectedErr: `output format "yaml" is not supported`,
+		},
+		{
+			vc:          validateCmd{authProvider: &authArgs{}, apimodelPath: "../pkg/engine/testdata/simple/kubernetes.json", outputFormat: "json", capabilitiesPath: "../pkg/engine/testdata/simple/kubernetes.json", azure: true},
+			expectedErr: "--capabilities-file cannot be used with --azure",
+		},
+		{
+			vc:          validateCmd{authProvider: &authArgs{AuthMethod: "client_secret"}, apimodelPath: "../pkg/engine/testdata/simple/kubernetes.json", outputFormat: "human", azure: true},
+			expectedErr: `failed to get validate auth args: --client-id and --client-secret must be specified when --auth-method="client_secret"`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		err := c.vc.validate(&cobra.Command{}, c.args)
+		if err == nil || err.Error() != c.expectedErr {
+			t.Errorf("expected error %q, got %v", c.expectedErr, err)
+		}
+	}
+}
+
+func TestValidateCmdRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "validate")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	vc := &validateCmd{
+		authProvider: &authArgs{},
+		apimodelPath: "../pkg/engine/testdata/simple/kubernetes.json",
+		outputFormat: "human",
+	}
+	out := &bytes.Buffer{}
+	vc.out = out
+	g.Expect(vc.validate(&cobra.Command{}, nil)).To(Succeed())
+	g.Expect(vc.loadAPIModel()).To(Succeed())
+	g.Expect(vc.run()).To(Succeed())
+	g.Expect(out.String()).To(Equal("The api model ../pkg/engine/testdata/simple/kubernetes.json is valid\n"))
+
+	var m map[string]interface{}
+	contents, err := ioutil.ReadFile(vc.apimodelPath)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(json.Unmarshal(contents, &m)).To(Succeed())
+	m["location"] = "westus2"
+	properties := m["properties"].(map[string]interface{})
+	properties["aadProfile"] = map[string]interface{}{"clientAppID": "1"}
+	properties["agentPoolProfiles"].([]interface{})[0].(map[string]interface{})["vmSize"] = "Standard_Unknown"
+	contents, err = json.Marshal(m)
+	g.Expect(err).NotTo(HaveOccurred())
+	invalid := filepath.Join(dir, "kubernetes.json")
+	g.Expect(ioutil.WriteFile(invalid, contents, 0644)).To(Succeed())
+
+	vc = &validateCmd{
+		authProvider: &authArgs{},
+		outputFormat: "json",
+	}
+	out = &bytes.Buffer{}
+	vc.out = out
+	g.Expect(vc.validate(&cobra.Command{}, []string{invalid})).To(Succeed())
+	g.Expect(vc.loadAPIModel()).To(Succeed())
+	g.Expect(vc.run()).To(MatchError("the api model " + invalid + " has 2 validation errors"))
+
+	var report validateReport
+	g.Expect(json.Unmarshal(out.Bytes(), &report)).To(Succeed())
+	g.Expect(report.Valid).To(BeFalse())
+	g.Expect(report.Errors).To(Equal([]vlabs.ValidationError{
+		{Path: "properties.aadProfile", Message: "clientAppID '1' is invalid"},
+		{Path: "properties.agentPoolProfiles[0]", Message: "agentPoolProfile agentpool1 VM size Standard_Unknown is not available in location westus2"},
+	}))
+}
+
+func TestValidateCmdRunAzure(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	vc := &validateCmd{
+		authProvider: &authArgs{},
+		apimodelPath: "../pkg/engine/testdata/simple/kubernetes.json",
+		outputFormat: "human",
+		azure:        true,
+		client: &armhelpers.MockAKSEngineClient{
+			FakeResourceSkus: []compute.ResourceSku{
+				{
+					ResourceType: to.StringPtr("virtualMachines"),
+					Name:         to.StringPtr("Standard_D2_v2"),
+					LocationInfo: &[]compute.ResourceSkuLocationInfo{{Location: to.StringPtr("westus2")}},
+				},
+			},
+		},
+	}
+	out := &bytes.Buffer{}
+	vc.out = out
+	g.Expect(vc.loadAPIModel()).To(Succeed())
+	vc.containerService.Location = "westus2"
+	vc.containerService.Properties.MasterProfile.VMSize = "Standard_D2_v3"
+	g.Expect(vc.run()).To(MatchError("the api model ../pkg/engine/testdata/simple/kubernetes.json has 1 validation errors"))
+	g.Expect(out.String()).To(Equal("properties.masterProfile: masterProfile VM size Standard_D2_v3 is not available in location westus2\n"))
+
+	vc.client = &armhelpers.MockAKSEngineClient{FailListResourceSkus: true}
+	g.Expect(vc.run()).To(MatchError("listing the resource SKUs of the subscription: ListResourceSkus failed"))
+}
+
+func TestLocationCapabilitiesOf(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	skus := []compute.ResourceSku{
+		{
+			ResourceType: to.StringPtr("virtualMachines"),
+			Name:         to.StringPtr("Standard_D2_v3"),
+			LocationInfo: &[]compute.ResourceSkuLocationInfo{{Location: to.StringPtr("WestUS2"), Zones: &[]string{"1", "2", "3"}}},
+			Restrictions: &[]compute.ResourceSkuRestrictions{
+				{Type: compute.Zone, RestrictionInfo: &compute.ResourceSkuRestrictionInfo{Locations: &[]string{"westus2"}, Zones: &[]string{"3"}}},
+			},
+			Capabilities: &[]compute.ResourceSkuCapabilities{
+				{Name: to.StringPtr("EphemeralOSDiskSupported"), Value: to.StringPtr("True")},
+				{Name: to.StringPtr("CachedDiskBytes"), Value: to.StringPtr("53687091200")},
+			},
+		},
+		{
+			ResourceType: to.StringPtr("virtualMachines"),
+			Name:         to.StringPtr("Standard_A2_v2"),
+			LocationInfo: &[]compute.ResourceSkuLocationInfo{{Location: to.StringPtr("westus2")}},
+			Capabilities: &[]compute.ResourceSkuCapabilities{
+				{Name: to.StringPtr("EphemeralOSDiskSupported"), Value: to.StringPtr("False")},
+			},
+		},
+		{
+			ResourceType: to.StringPtr("virtualMachines"),
+			Name:         to.StringPtr("Standard_NC6"),
+			LocationInfo: &[]compute.ResourceSkuLocationInfo{{Location: to.StringPtr("westus2")}},
+			Restrictions: &[]compute.ResourceSkuRestrictions{
+				{Type: compute.Location, RestrictionInfo: &compute.ResourceSkuRestrictionInfo{Locations: &[]string{"westus2"}}},
+			},
+		},
+		{
+			ResourceType: to.StringPtr("virtualMachines"),
+			Name:         to.StringPtr("Standard_D2_v2"),
+			LocationInfo: &[]compute.ResourceSkuLocationInfo{{Location: to.StringPtr("eastus")}},
+		},
+		{
+			ResourceType: to.StringPtr("disks"),
+			Name:         to.StringPtr("Premium_LRS"),
+			LocationInfo: &[]compute.ResourceSkuLocationInfo{{Location: to.StringPtr("westus2")}},
+		},
+		{
+			ResourceType: to.StringPtr("disks"),
+			Name:         to.StringPtr("UltraSSD_LRS"),
+			LocationInfo: &[]compute.ResourceSkuLocationInfo{{Location: to.StringPtr("westus2"), Zones: &[]string{"1", "3"}}},
+			Restrictions: &[]compute.ResourceSkuRestrictions{
+				{Type: compute.Zone, RestrictionInfo: &compute.ResourceSkuRestrictionInfo{Locations: &[]string{"westus2"}, Zones: &[]string{"3"}}},
+			},
+		},
+	}
+	capabilities := locationCapabilitiesOf(skus, "westus2")
+	g.Expect(capabilities.VMSizes).To(Equal([]string{"Standard_D2_v3", "Standard_A2_v2"}))
+	g.Expect(capabilities.Zones).To(Equal(map[string][]string{"Standard_D2_v3": {"1", "2"}, "Standard_A2_v2": {}}))
+	g.Expect(capabilities.EphemeralOSDiskCacheGB).To(Equal(map[string]int{"Standard_D2_v3": 50, "Standard_A2_v2": 0}))
+	g.Expect(capabilities.UltraSSDZones).To(Equal(&[]string{"1"}))
+	g.Expect(locationCapabilitiesOf(skus, "eastus").UltraSSDZones).To(Equal(&[]string{}))
+}
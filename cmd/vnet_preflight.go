@@ -0,0 +1,409 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/common"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// azureReservedSubnetIPs is the number of IP addresses Azure reserves in every subnet
+const azureReservedSubnetIPs = 5
+
+// vnetSubnetDemand is a subnet of a custom VNET and the profiles of the cluster deployed in it
+type vnetSubnetDemand struct {
+	id       string
+	profiles []string
+	// ips is the number of IP addresses of the subnet the profiles allocate
+	ips    int
+	master bool
+}
+
+// vnetTrafficProbe is inbound traffic the nodes of a subnet must accept for the cluster to work
+type vnetTrafficProbe struct {
+	description string
+	// source is the service tag of the source of the traffic
+	source   string
+	protocol network.SecurityRuleProtocol
+	port     int
+}
+
+// customVNETSubnetDemands returns the subnets of the custom VNET of cs, the subnet of the masters first, with the IP
+// addresses the nodes deployed in them allocate: one per node, and with Azure CNI one per pod a node can run
+func customVNETSubnetDemands(cs *api.ContainerService) []*vnetSubnetDemand {
+	var demands []*vnetSubnetDemand
+	add := func(id, profile string, ips int, master bool) {
+		for _, d := range demands {
+			if strings.EqualFold(d.id, id) {
+				d.profiles = append(d.profiles, profile)
+				d.ips += ips
+				d.master = d.master || master
+				return
+			}
+		}
+		demands = append(demands, &vnetSubnetDemand{id: id, profiles: []string{profile}, ips: ips, master: master})
+	}
+
+	p := cs.Properties
+	if p.MasterProfile != nil && p.MasterProfile.IsCustomVNET() {
+		ips := p.MasterProfile.Count * p.MasterProfile.IPAddressCount
+		// the internal load balancer of the masters gets an IP address of the subnet too
+		if p.MasterProfile.HasMultipleNodes() {
+			ips++
+		}
+		add(p.MasterProfile.VnetSubnetID, "masterProfile", ips, true)
+	}
+	for _, pool := range p.AgentPoolProfiles {
+		if pool.IsCustomVNET() {
+			add(pool.VnetSubnetID, fmt.Sprintf("agent pool %s", pool.Name), pool.Count*pool.IPAddressCount, false)
+		}
+	}
+	return demands
+}
+
+// checkCustomVNET checks the subnets of the custom VNET of cs against their state in Azure: that they exist, that they
+// have enough free IP addresses for the nodes, that their route table is the one kubenet writes the pod routes to, and
+// that their network security group accepts the traffic of the cluster. It returns the problems found.
+func checkCustomVNET(ctx context.Context, client armhelpers.AKSEngineClient, cs *api.ContainerService, resourceGroup string) ([]string, error) {
+	var problems []string
+	vnets := map[string]network.VirtualNetwork{}
+	nsgs := map[string]*network.SecurityGroup{}
+	for _, d := range customVNETSubnetDemands(cs) {
+		_, vnetResourceGroup, vnetName, subnetName, err := common.GetVNETSubnetIDComponents(d.id)
+		if err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(vnetResourceGroup + "/" + vnetName)
+		vnet, ok := vnets[key]
+		if !ok {
+			if vnet, err = client.GetVirtualNetwork(ctx, vnetResourceGroup, vnetName); err != nil {
+				return nil, errors.Wrapf(err, "getting VNET %s in resource group %s", vnetName, vnetResourceGroup)
+			}
+			vnets[key] = vnet
+		}
+
+		subnet := findSubnet(vnet, subnetName)
+		if subnet == nil {
+			problems = append(problems, fmt.Sprintf("subnet %s of %s does not exist in VNET %s of resource group %s, create it before deploying",
+				subnetName, strings.Join(d.profiles, ", "), vnetName, vnetResourceGroup))
+			continue
+		}
+		problems = append(problems, checkSubnetCapacity(cs, d, subnetName, subnet)...)
+		problems = append(problems, checkSubnetRouteTable(cs, subnetName, subnet, resourceGroup)...)
+
+		if subnet.NetworkSecurityGroup == nil || subnet.NetworkSecurityGroup.ID == nil {
+			continue
+		}
+		nsgID := *subnet.NetworkSecurityGroup.ID
+		nsg, ok := nsgs[strings.ToLower(nsgID)]
+		if !ok {
+			if nsg, err = getNetworkSecurityGroup(ctx, client, nsgID); err != nil {
+				log.Warnf("unable to get network security group %s of subnet %s, skipping its rules check: %s", nsgID, subnetName, err)
+			}
+			nsgs[strings.ToLower(nsgID)] = nsg
+		}
+		if nsg != nil {
+			problems = append(problems, checkSubnetSecurityRules(cs, d, subnetName, subnet, nsg)...)
+		}
+	}
+	return problems, nil
+}
+
+func findSubnet(vnet network.VirtualNetwork, name string) *network.Subnet {
+	if vnet.VirtualNetworkPropertiesFormat == nil || vnet.Subnets == nil {
+		return nil
+	}
+	for i, subnet := range *vnet.Subnets {
+		if strings.EqualFold(to.String(subnet.Name), name) && subnet.SubnetPropertiesFormat != nil {
+			return &(*vnet.Subnets)[i]
+		}
+	}
+	return nil
+}
+
+// subnetPrefixes returns the IPv4 address prefixes of a subnet
+func subnetPrefixes(subnet *network.Subnet) []*net.IPNet {
+	var prefixes []string
+	if subnet.AddressPrefix != nil {
+		prefixes = append(prefixes, *subnet.AddressPrefix)
+	}
+	if subnet.AddressPrefixes != nil {
+		prefixes = append(prefixes, *subnet.AddressPrefixes...)
+	}
+	var nets []*net.IPNet
+	for _, prefix := range prefixes {
+		if _, ipNet, err := net.ParseCIDR(prefix); err == nil && ipNet.IP.To4() != nil {
+			nets = append(nets, ipNet)
+		}
+	}
+	return nets
+}
+
+// checkSubnetCapacity returns a problem if the free IP addresses of subnet are fewer than the nodes of d allocate
+func checkSubnetCapacity(cs *api.ContainerService, d *vnetSubnetDemand, subnetName string, subnet *network.Subnet) []string {
+	prefixes := subnetPrefixes(subnet)
+	if len(prefixes) == 0 {
+		return nil
+	}
+	size := 0
+	for _, prefix := range prefixes {
+		ones, bits := prefix.Mask.Size()
+		size += 1<<uint(bits-ones) - azureReservedSubnetIPs
+	}
+	used := 0
+	if subnet.IPConfigurations != nil {
+		used = len(*subnet.IPConfigurations)
+	}
+	free := size - used
+	if free >= d.ips {
+		return nil
+	}
+	advice := "use a larger subnet or fewer nodes"
+	if cs.Properties.OrchestratorProfile.IsAzureCNI() {
+		advice = "use a larger subnet, fewer nodes or a lower --max-pods, Azure CNI allocates an IP address of the subnet to every pod a node can run"
+	}
+	return []string{fmt.Sprintf("subnet %s has %d free IP addresses, the nodes of %s need %d: %s",
+		subnetName, free, strings.Join(d.profiles, ", "), d.ips, advice)}
+}
+
+// checkSubnetRouteTable returns a problem if kubenet routes the pod traffic of the cluster and subnet is associated
+// with a route table other than the one of the cluster, which the cloud provider writes the pod routes to
+func checkSubnetRouteTable(cs *api.ContainerService, subnetName string, subnet *network.Subnet, resourceGroup string) []string {
+	if !cs.Properties.OrchestratorProfile.RequireRouteTable() || subnet.RouteTable == nil || subnet.RouteTable.ID == nil {
+		return nil
+	}
+	routeTableResourceGroup, routeTableName := resourceIDComponents(*subnet.RouteTable.ID)
+	clusterRouteTableName := cs.Properties.GetRouteTableName()
+	if strings.EqualFold(routeTableName, clusterRouteTableName) && strings.EqualFold(routeTableResourceGroup, resourceGroup) {
+		return nil
+	}
+	return []string{fmt.Sprintf("subnet %s is associated with route table %s of resource group %s, but the cloud provider writes the pod routes of kubenet to route table %s of resource group %s: "+
+		"dissociate route table %s from the subnet and associate route table %s with it once the cluster is deployed, or use Azure CNI",
+		subnetName, routeTableName, routeTableResourceGroup, clusterRouteTableName, resourceGroup, routeTableName, clusterRouteTableName)}
+}
+
+// checkSubnetSecurityRules returns a problem for each inbound traffic of the cluster the network security group of
+// subnet denies
+func checkSubnetSecurityRules(cs *api.ContainerService, d *vnetSubnetDemand, subnetName string, subnet *network.Subnet, nsg *network.SecurityGroup) []string {
+	probes := []vnetTrafficProbe{
+		{description: "the kubelet from the masters", source: "VirtualNetwork", protocol: network.SecurityRuleProtocolTCP, port: 10250},
+		{description: "the DNS queries of the pods", source: "VirtualNetwork", protocol: network.SecurityRuleProtocolUDP, port: 53},
+	}
+	if d.master {
+		probes = append(probes, vnetTrafficProbe{description: "the API server from the nodes", source: "VirtualNetwork", protocol: network.SecurityRuleProtocolTCP, port: 443})
+		if !cs.Properties.OrchestratorProfile.IsPrivateCluster() {
+			probes = append(probes,
+				vnetTrafficProbe{description: "the API server from the internet", source: "Internet", protocol: network.SecurityRuleProtocolTCP, port: 443},
+				vnetTrafficProbe{description: "the health probes of the API server load balancer", source: "AzureLoadBalancer", protocol: network.SecurityRuleProtocolTCP, port: 443})
+		}
+	}
+
+	rules := inboundSecurityRules(nsg)
+	prefixes := subnetPrefixes(subnet)
+	var problems []string
+	for _, probe := range probes {
+		for _, rule := range rules {
+			if !securityRuleCovers(rule, probe, prefixes) {
+				continue
+			}
+			if rule.Access == network.SecurityRuleAccessDeny {
+				problems = append(problems, fmt.Sprintf("network security group %s of subnet %s denies %s (%s port %d from %s) with rule %s, add a rule allowing it with a lower priority number than %d",
+					to.String(nsg.Name), subnetName, probe.description, probe.protocol, probe.port, probe.source, to.String(rule.Name), to.Int32(rule.Priority)))
+			}
+			break
+		}
+	}
+	return problems
+}
+
+// inboundSecurityRules returns the inbound rules of nsg, its default rules included, in the order Azure evaluates them
+func inboundSecurityRules(nsg *network.SecurityGroup) []network.SecurityRule {
+	var rules []network.SecurityRule
+	if nsg.SecurityGroupPropertiesFormat == nil {
+		return rules
+	}
+	for _, list := range []*[]network.SecurityRule{nsg.SecurityRules, nsg.DefaultSecurityRules} {
+		if list == nil {
+			continue
+		}
+		for _, rule := range *list {
+			if rule.SecurityRulePropertiesFormat != nil && rule.Direction == network.SecurityRuleDirectionInbound {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return to.Int32(rules[i].Priority) < to.Int32(rules[j].Priority)
+	})
+	return rules
+}
+
+// securityRuleCovers returns true if rule applies to the traffic of probe to a subnet with prefixes
+func securityRuleCovers(rule network.SecurityRule, probe vnetTrafficProbe, prefixes []*net.IPNet) bool {
+	if rule.Protocol != network.SecurityRuleProtocolAsterisk && !strings.EqualFold(string(rule.Protocol), string(probe.protocol)) {
+		return false
+	}
+	coversPort := false
+	for _, portRange := range ruleValues(rule.DestinationPortRange, rule.DestinationPortRanges) {
+		if portRangeContains(portRange, probe.port) {
+			coversPort = true
+			break
+		}
+	}
+	if !coversPort {
+		return false
+	}
+	coversSource := false
+	for _, source := range ruleValues(rule.SourceAddressPrefix, rule.SourceAddressPrefixes) {
+		// the VNET traffic comes from the subnets of the cluster, a CIDR containing the subnet stands for it
+		if source == "*" || strings.EqualFold(source, probe.source) || (probe.source == "VirtualNetwork" && cidrContainsAll(source, prefixes)) {
+			coversSource = true
+			break
+		}
+	}
+	if !coversSource {
+		return false
+	}
+	for _, destination := range ruleValues(rule.DestinationAddressPrefix, rule.DestinationAddressPrefixes) {
+		if destination == "*" || strings.EqualFold(destination, "VirtualNetwork") || cidrContainsAll(destination, prefixes) {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleValues returns the single value of a security rule property, or else its list of values
+func ruleValues(value *string, values *[]string) []string {
+	if value != nil && *value != "" {
+		return []string{*value}
+	}
+	if values != nil {
+		return *values
+	}
+	return nil
+}
+
+// cidrContainsAll returns true if cidr is a CIDR containing every prefix of prefixes
+func cidrContainsAll(cidr string, prefixes []*net.IPNet) bool {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil || len(prefixes) == 0 {
+		return false
+	}
+	ones, _ := ipNet.Mask.Size()
+	for _, prefix := range prefixes {
+		prefixOnes, _ := prefix.Mask.Size()
+		if !ipNet.Contains(prefix.IP) || prefixOnes < ones {
+			return false
+		}
+	}
+	return true
+}
+
+// portRangeContains returns true if port is in portRange, which is "*", a single port or a range like "100-400"
+func portRangeContains(portRange string, port int) bool {
+	if portRange == "*" {
+		return true
+	}
+	bounds := strings.SplitN(portRange, "-", 2)
+	low, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	if err != nil {
+		return false
+	}
+	high := low
+	if len(bounds) == 2 {
+		if high, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil {
+			return false
+		}
+	}
+	return port >= low && port <= high
+}
+
+// resourceIDComponents returns the resource group and the name of the resource of an Azure resource ID
+func resourceIDComponents(id string) (string, string) {
+	parts := strings.Split(strings.Trim(id, "/"), "/")
+	var resourceGroup string
+	for i := 0; i < len(parts)-1; i++ {
+		if strings.EqualFold(parts[i], "resourceGroups") {
+			resourceGroup = parts[i+1]
+			break
+		}
+	}
+	return resourceGroup, parts[len(parts)-1]
+}
+
+// getNetworkSecurityGroup returns the network security group of an Azure resource ID
+func getNetworkSecurityGroup(ctx context.Context, client armhelpers.AKSEngineClient, id string) (*network.SecurityGroup, error) {
+	resourceGroup, name := resourceIDComponents(id)
+	nsgs, err := client.ListNetworkSecurityGroups(ctx, resourceGroup)
+	if err != nil {
+		return nil, err
+	}
+	for i := range nsgs {
+		if strings.EqualFold(to.String(nsgs[i].Name), name) {
+			return &nsgs[i], nil
+		}
+	}
+	return nil, errors.Errorf("network security group %s not found in resource group %s", name, resourceGroup)
+}
+
+// checkCustomVNETCIDROverlaps ensures the address space of the custom VNET of cs, read from Azure, does not overlap
+// the cluster, service or docker bridge networks
+func checkCustomVNETCIDROverlaps(ctx context.Context, client armhelpers.AKSEngineClient, cs *api.ContainerService) error {
+	p := cs.Properties
+	if !p.OrchestratorProfile.IsKubernetes() || p.OrchestratorProfile.KubernetesConfig == nil || p.MasterProfile == nil || !p.MasterProfile.IsCustomVNET() {
+		return nil
+	}
+	_, resourceGroup, vnetName, _, err := common.GetVNETSubnetIDComponents(p.MasterProfile.VnetSubnetID)
+	if err != nil {
+		return err
+	}
+
+	vnet, err := client.GetVirtualNetwork(ctx, resourceGroup, vnetName)
+	if err != nil {
+		return errors.Wrapf(err, "getting VNET %s in resource group %s", vnetName, resourceGroup)
+	}
+
+	var vnetCIDRs []common.NamedCIDR
+	if vnet.VirtualNetworkPropertiesFormat != nil && vnet.AddressSpace != nil && vnet.AddressSpace.AddressPrefixes != nil {
+		for _, prefix := range *vnet.AddressSpace.AddressPrefixes {
+			vnetCIDRs = append(vnetCIDRs, common.NamedCIDR{Name: fmt.Sprintf("VNET %s address prefix", vnetName), CIDR: prefix})
+		}
+	}
+
+	k := p.OrchestratorProfile.KubernetesConfig
+	var clusterCIDRs []common.NamedCIDR
+	if k.ServiceCIDR != "" {
+		clusterCIDRs = append(clusterCIDRs, common.NamedCIDR{Name: "ServiceCidr", CIDR: k.ServiceCIDR})
+	}
+	if k.DockerBridgeSubnet != "" {
+		clusterCIDRs = append(clusterCIDRs, common.NamedCIDR{Name: "DockerBridgeSubnet", CIDR: k.DockerBridgeSubnet})
+	}
+	// With Azure CNI pods are allocated IPs from the VNET, so the ClusterSubnet is expected to be part of it
+	if k.NetworkPlugin != api.NetworkPluginAzure && k.ClusterSubnet != "" {
+		for _, clusterSubnet := range strings.Split(k.ClusterSubnet, ",") {
+			clusterCIDRs = append(clusterCIDRs, common.NamedCIDR{Name: "ClusterSubnet", CIDR: clusterSubnet})
+		}
+	}
+
+	overlaps, err := common.GetCIDROverlaps(vnetCIDRs, clusterCIDRs)
+	if err != nil {
+		return err
+	}
+	if len(overlaps) > 0 {
+		return errors.Errorf("networking CIDR ranges must not overlap: %s", strings.Join(overlaps, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+)
+
+const vnetPreflightTestVNETID = "/subscriptions/SUB_ID/resourceGroups/vnet-rg/providers/Microsoft.Network/virtualNetworks/clustervnet"
+
+func getVNETPreflightTestContainerService(t *testing.T, networkPlugin string) *api.ContainerService {
+	cs := api.CreateMockContainerService("testcluster", "1.16.1", 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin = networkPlugin
+	cs.Properties.MasterProfile.VnetSubnetID = vnetPreflightTestVNETID + "/subnets/masters"
+	cs.Properties.MasterProfile.FirstConsecutiveStaticIP = "10.240.255.5"
+	cs.Properties.MasterProfile.VnetCidr = "10.240.0.0/16"
+	for _, pool := range cs.Properties.AgentPoolProfiles {
+		pool.VnetSubnetID = vnetPreflightTestVNETID + "/subnets/agents"
+	}
+	if _, err := cs.SetPropertiesDefaults(false, false); err != nil {
+		t.Fatal(err)
+	}
+	return cs
+}
+
+func getVNETPreflightTestSubnet(name, prefix string, usedIPs int) network.Subnet {
+	ipConfigurations := make([]network.IPConfiguration, usedIPs)
+	return network.Subnet{
+		Name: to.StringPtr(name),
+		SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+			AddressPrefix:    to.StringPtr(prefix),
+			IPConfigurations: &ipConfigurations,
+		},
+	}
+}
+
+func getVNETPreflightTestRule(name string, priority int32, access network.SecurityRuleAccess, protocol network.SecurityRuleProtocol, port, source string) network.SecurityRule {
+	return network.SecurityRule{
+		Name: to.StringPtr(name),
+		SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+			Priority:                 to.Int32Ptr(priority),
+			Direction:                network.SecurityRuleDirectionInbound,
+			Access:                   access,
+			Protocol:                 protocol,
+			DestinationPortRange:     to.StringPtr(port),
+			SourceAddressPrefix:      to.StringPtr(source),
+			DestinationAddressPrefix: to.StringPtr("*"),
+		},
+	}
+}
+
+func getVNETPreflightTestNSG(rules ...network.SecurityRule) network.SecurityGroup {
+	return network.SecurityGroup{
+		ID:   to.StringPtr("/subscriptions/SUB_ID/resourceGroups/vnet-rg/providers/Microsoft.Network/networkSecurityGroups/subnet-nsg"),
+		Name: to.StringPtr("subnet-nsg"),
+		SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
+			SecurityRules: &rules,
+			DefaultSecurityRules: &[]network.SecurityRule{
+				getVNETPreflightTestRule("AllowVnetInBound", 65000, network.SecurityRuleAccessAllow, network.SecurityRuleProtocolAsterisk, "*", "VirtualNetwork"),
+				getVNETPreflightTestRule("AllowAzureLoadBalancerInBound", 65001, network.SecurityRuleAccessAllow, network.SecurityRuleProtocolAsterisk, "*", "AzureLoadBalancer"),
+				getVNETPreflightTestRule("DenyAllInBound", 65500, network.SecurityRuleAccessDeny, network.SecurityRuleProtocolAsterisk, "*", "*"),
+			},
+		},
+	}
+}
+
+func TestCheckCustomVNET(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cs := getVNETPreflightTestContainerService(t, api.NetworkPluginAzure)
+	client := &armhelpers.MockAKSEngineClient{
+		FakeVirtualNetworkSubnets: []network.Subnet{
+			getVNETPreflightTestSubnet("masters", "10.240.255.0/24", 0),
+			getVNETPreflightTestSubnet("agents", "10.240.0.0/20", 10),
+		},
+	}
+
+	problems, err := checkCustomVNET(context.Background(), client, cs, "cluster-rg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(BeEmpty())
+
+	client.FailGetVirtualNetwork = true
+	_, err = checkCustomVNET(context.Background(), client, cs, "cluster-rg")
+	g.Expect(err).To(MatchError("getting VNET clustervnet in resource group vnet-rg: GetVirtualNetwork failed"))
+}
+
+func TestCheckCustomVNETMissingSubnet(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cs := getVNETPreflightTestContainerService(t, api.NetworkPluginKubenet)
+	client := &armhelpers.MockAKSEngineClient{
+		FakeVirtualNetworkSubnets: []network.Subnet{
+			getVNETPreflightTestSubnet("masters", "10.240.255.0/24", 0),
+		},
+	}
+
+	problems, err := checkCustomVNET(context.Background(), client, cs, "cluster-rg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(ConsistOf("subnet agents of agent pool agentpool1 does not exist in VNET clustervnet of resource group vnet-rg, create it before deploying"))
+}
+
+func TestCheckCustomVNETCapacity(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cs := getVNETPreflightTestContainerService(t, api.NetworkPluginAzure)
+	// 3 masters and the internal load balancer of the masters, 2 agents, with 31 IP addresses each with Azure CNI
+	g.Expect(cs.Properties.AgentPoolProfiles[0].IPAddressCount).To(Equal(31))
+	client := &armhelpers.MockAKSEngineClient{
+		FakeVirtualNetworkSubnets: []network.Subnet{
+			getVNETPreflightTestSubnet("masters", "10.240.255.0/24", 0),
+			getVNETPreflightTestSubnet("agents", "10.240.0.0/26", 2),
+		},
+	}
+
+	problems, err := checkCustomVNET(context.Background(), client, cs, "cluster-rg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(ConsistOf("subnet agents has 57 free IP addresses, the nodes of agent pool agentpool1 need 62: " +
+		"use a larger subnet, fewer nodes or a lower --max-pods, Azure CNI allocates an IP address of the subnet to every pod a node can run"))
+
+	cs.Properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin = api.NetworkPluginKubenet
+	cs.Properties.AgentPoolProfiles[0].IPAddressCount = 1
+	problems, err = checkCustomVNET(context.Background(), client, cs, "cluster-rg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(BeEmpty())
+}
+
+func TestCheckCustomVNETRouteTable(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cs := getVNETPreflightTestContainerService(t, api.NetworkPluginKubenet)
+	routeTableName := cs.Properties.GetRouteTableName()
+	masters := getVNETPreflightTestSubnet("masters", "10.240.255.0/24", 0)
+	masters.RouteTable = &network.RouteTable{ID: to.StringPtr("/subscriptions/SUB_ID/resourceGroups/cluster-rg/providers/Microsoft.Network/routeTables/" + routeTableName)}
+	agents := getVNETPreflightTestSubnet("agents", "10.240.0.0/20", 0)
+	agents.RouteTable = &network.RouteTable{ID: to.StringPtr("/subscriptions/SUB_ID/resourceGroups/vnet-rg/providers/Microsoft.Network/routeTables/firewall")}
+	client := &armhelpers.MockAKSEngineClient{
+		FakeVirtualNetworkSubnets: []network.Subnet{masters, agents},
+	}
+
+	problems, err := checkCustomVNET(context.Background(), client, cs, "cluster-rg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(ConsistOf("subnet agents is associated with route table firewall of resource group vnet-rg, but the cloud provider writes the pod routes of kubenet to route table " +
+		routeTableName + " of resource group cluster-rg: dissociate route table firewall from the subnet and associate route table " + routeTableName + " with it once the cluster is deployed, or use Azure CNI"))
+
+	// Azure CNI does not route the pod traffic with a route table
+	cs.Properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin = api.NetworkPluginAzure
+	problems, err = checkCustomVNET(context.Background(), client, cs, "cluster-rg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(BeEmpty())
+}
+
+func TestCheckCustomVNETSecurityRules(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cs := getVNETPreflightTestContainerService(t, api.NetworkPluginKubenet)
+	nsg := getVNETPreflightTestNSG(
+		getVNETPreflightTestRule("allow_https", 100, network.SecurityRuleAccessAllow, network.SecurityRuleProtocolTCP, "443", "*"),
+		getVNETPreflightTestRule("deny_kubelet", 200, network.SecurityRuleAccessDeny, network.SecurityRuleProtocolTCP, "10000-11000", "*"),
+		getVNETPreflightTestRule("allow_kubelet", 300, network.SecurityRuleAccessAllow, network.SecurityRuleProtocolTCP, "10250", "10.240.0.0/16"),
+	)
+	masters := getVNETPreflightTestSubnet("masters", "10.240.255.0/24", 0)
+	masters.NetworkSecurityGroup = &network.SecurityGroup{ID: nsg.ID}
+	agents := getVNETPreflightTestSubnet("agents", "10.240.0.0/20", 0)
+	agents.NetworkSecurityGroup = &network.SecurityGroup{ID: nsg.ID}
+	client := &armhelpers.MockAKSEngineClient{
+		FakeVirtualNetworkSubnets: []network.Subnet{masters, agents},
+		FakeNetworkSecurityGroups: []network.SecurityGroup{nsg},
+	}
+
+	problems, err := checkCustomVNET(context.Background(), client, cs, "cluster-rg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(ConsistOf(
+		"network security group subnet-nsg of subnet masters denies the kubelet from the masters (Tcp port 10250 from VirtualNetwork) with rule deny_kubelet, add a rule allowing it with a lower priority number than 200",
+		"network security group subnet-nsg of subnet agents denies the kubelet from the masters (Tcp port 10250 from VirtualNetwork) with rule deny_kubelet, add a rule allowing it with a lower priority number than 200",
+	))
+
+	// without allow_https, the API server is only reachable from the VNET, which is what a private cluster needs
+	rules := (*nsg.SecurityRules)[1:]
+	(*rules[1].Priority) = 150
+	nsg.SecurityRules = &rules
+	client.FakeNetworkSecurityGroups = []network.SecurityGroup{nsg}
+	problems, err = checkCustomVNET(context.Background(), client, cs, "cluster-rg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(ConsistOf(
+		"network security group subnet-nsg of subnet masters denies the API server from the internet (Tcp port 443 from Internet) with rule DenyAllInBound, add a rule allowing it with a lower priority number than 65500",
+	))
+
+	cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster = &api.PrivateCluster{Enabled: to.BoolPtr(true)}
+	problems, err = checkCustomVNET(context.Background(), client, cs, "cluster-rg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(BeEmpty())
+
+	// a network security group that cannot be read is not checked
+	client.FailListNetworkSecurityGroups = true
+	problems, err = checkCustomVNET(context.Background(), client, cs, "cluster-rg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(problems).To(BeEmpty())
+}
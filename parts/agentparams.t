@@ -14,16 +14,17 @@
       "type": "int"
     },
 {{end}}
-    {{if .IsLowPriorityScaleSet}}
+    {{if or .IsLowPriorityScaleSet .IsSpotScaleSet}}
     "{{.Name}}ScaleSetPriority": {
       "allowedValues":[
         "Low",
+        "Spot",
         "Regular",
         ""
       ],
       "defaultValue": "{{.ScaleSetPriority}}",
       "metadata": {
-        "description": "The priority for the VM Scale Set. This value can be Low or Regular."
+        "description": "The priority for the VM Scale Set. This value can be Low, Spot or Regular."
       },
       "type": "string"
     },
@@ -35,7 +36,7 @@
       ],
       "defaultValue": "{{.ScaleSetEvictionPolicy}}",
       "metadata": {
-        "description": "The Eviction Policy for a Low-priority VM Scale Set."
+        "description": "The Eviction Policy for a Low-priority or Spot VM Scale Set."
       },
       "type": "string"
     },
@@ -105,6 +106,14 @@
       },
       "type": "string"
     }
+{{if .HasPodSubnet}}
+  ,"{{.Name}}PodSubnetID": {
+      "metadata": {
+        "description": "Sets the subnet of the pod NICs of agent pool '{{.Name}}'."
+      },
+      "type": "string"
+    }
+{{end}}
 {{else}}
     "{{.Name}}Subnet": {
       "defaultValue": "{{.Subnet}}",
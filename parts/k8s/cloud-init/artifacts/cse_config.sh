@@ -8,6 +8,13 @@ else
 fi
 ETCD_PEER_URL="https://${PRIVATE_IP}:2380"
 ETCD_CLIENT_URL="https://${PRIVATE_IP}:2379"
+if [[ -n "${ETCD_FIRST_CONSECUTIVE_STATIC_IP}" ]]; then
+    # etcd uses the second NIC of the master, on the dedicated etcd subnet
+    ETCD_NIC=eth1
+    ETCD_PRIVATE_IP=$(echo ${ETCD_FIRST_CONSECUTIVE_STATIC_IP} | awk -F. -v i=${NODE_INDEX} '{print $1"."$2"."$3"."$4+i}')
+    ETCD_PEER_URL="https://${ETCD_PRIVATE_IP}:2380"
+    ETCD_CLIENT_URL="https://${ETCD_PRIVATE_IP}:2379"
+fi
 
 systemctlEnableAndStart() {
     systemctl_restart 100 5 30 $1
@@ -96,6 +103,38 @@ configureSecrets(){
     echo "${ETCD_PEER_CERT}" | base64 --decode > "${ETCD_PEER_CERTIFICATE_PATH}"
 }
 
+ensureEtcdNIC() {
+    if ip -4 addr show ${ETCD_NIC} | grep -q "inet ${ETCD_PRIVATE_IP}/"; then
+        return
+    fi
+    # no gateway, only the routes of the etcd subnet go through the etcd NIC
+    if [[ -d /etc/netplan ]]; then
+        cat << EOF > /etc/netplan/60-etcd-nic.yaml
+network:
+  version: 2
+  ethernets:
+    ${ETCD_NIC}:
+      dhcp4: false
+      addresses: [${ETCD_PRIVATE_IP}/${ETCD_SUBNET#*/}]
+EOF
+        retrycmd_if_failure 10 5 30 netplan apply || exit $ERR_ETCD_NIC_CONFIG_FAIL
+    else
+        cat << EOF > /etc/network/interfaces.d/60-etcd-nic.cfg
+auto ${ETCD_NIC}
+iface ${ETCD_NIC} inet static
+    address ${ETCD_PRIVATE_IP}/${ETCD_SUBNET#*/}
+EOF
+        retrycmd_if_failure 10 5 30 ifup ${ETCD_NIC} || exit $ERR_ETCD_NIC_CONFIG_FAIL
+    fi
+    for i in $(seq 1 60); do
+        if ip -4 addr show ${ETCD_NIC} | grep -q "inet ${ETCD_PRIVATE_IP}/"; then
+            return
+        fi
+        sleep 1
+    done
+    exit $ERR_ETCD_NIC_CONFIG_FAIL
+}
+
 configureEtcd() {
     set -x
 
@@ -209,6 +248,12 @@ configureK8s() {
     "providerKeyVersion": ""
 }
 EOF
+    CLOUD_PROVIDER_CONFIG_PATH="/etc/kubernetes/azure-cloud-provider-config.json"
+    if [[ -f "${CLOUD_PROVIDER_CONFIG_PATH}" ]]; then
+        # merge the cloudProviderConfig settings of the api model, which have no variable of their own
+        jq -s '.[0] * .[1]' "${AZURE_JSON_PATH}" "${CLOUD_PROVIDER_CONFIG_PATH}" > "${AZURE_JSON_PATH}.tmp" && mv "${AZURE_JSON_PATH}.tmp" "${AZURE_JSON_PATH}"
+        chmod 0600 "${AZURE_JSON_PATH}"
+    fi
     set -x
     if [[ -n "${MASTER_NODE}" ]]; then
         if [[ "${ENABLE_AGGREGATED_APIS}" = True ]]; then
@@ -229,6 +274,11 @@ configureCNI() {
         systemctl restart sys-fs-bpf.mount
         REBOOTREQUIRED=true
     fi
+    if [[ "${NETWORK_PLUGIN}" = "antrea" ]]; then
+        # the antrea-ovs container runs the Open vSwitch daemons of the node on its kernel datapath
+        retrycmd_if_failure 120 5 25 modprobe openvswitch || exit $ERR_MODPROBE_FAIL
+        echo -n "openvswitch" > /etc/modules-load.d/openvswitch.conf
+    fi
 
     if [[ "${TARGET_ENVIRONMENT,,}" == "${AZURE_STACK_ENV}"  ]] && [[ "${NETWORK_PLUGIN}" = "azure" ]]; then
         # set environment to mas when using Azure CNI on Azure Stack
@@ -267,6 +317,9 @@ setupContainerd() {
         echo "[plugins.cri.containerd.default_runtime]"
         echo "runtime_type = 'io.containerd.runtime.v1.linux'"
         echo "runtime_engine = '/usr/local/sbin/runc'"
+        if [ -f /etc/containerd/registry-mirrors.toml ]; then
+            cat /etc/containerd/registry-mirrors.toml
+        fi
     } > "$CRI_CONTAINERD_CONFIG"
 }
 
@@ -276,6 +329,18 @@ ensureContainerd() {
     systemctlEnableAndStart containerd || exit $ERR_SYSTEMCTL_START_FAIL
 }
 
+configureNodeSearchDomains() {
+    NODE_SEARCH_DOMAINS=$(cat $NODE_SEARCH_DOMAINS_FILE)
+    if systemctl is-active --quiet systemd-resolved; then
+        mkdir -p /etc/systemd/resolved.conf.d
+        echo -e "[Resolve]\nDomains=${NODE_SEARCH_DOMAINS}" > /etc/systemd/resolved.conf.d/search-domains.conf || exit $ERR_CUSTOM_SEARCH_DOMAINS_FAIL
+        systemctl restart systemd-resolved || exit $ERR_CUSTOM_SEARCH_DOMAINS_FAIL
+    else
+        echo "search ${NODE_SEARCH_DOMAINS}" >> /etc/resolvconf/resolv.conf.d/base || exit $ERR_CUSTOM_SEARCH_DOMAINS_FAIL
+        resolvconf -u || exit $ERR_CUSTOM_SEARCH_DOMAINS_FAIL
+    fi
+}
+
 ensureDocker() {
     DOCKER_SERVICE_EXEC_START_FILE=/etc/systemd/system/docker.service.d/exec_start.conf
     wait_for_file 1200 1 $DOCKER_SERVICE_EXEC_START_FILE || exit $ERR_FILE_WATCH_TIMEOUT
@@ -330,6 +395,14 @@ ensureLabelNodes() {
     systemctlEnableAndStart label-nodes || exit $ERR_SYSTEMCTL_START_FAIL
 }
 
+ensureScheduledEventsHandler() {
+    SCHEDULED_EVENTS_HANDLER_SCRIPT_FILE=/opt/azure/containers/scheduled-events-handler.sh
+    wait_for_file 1200 1 $SCHEDULED_EVENTS_HANDLER_SCRIPT_FILE || exit $ERR_FILE_WATCH_TIMEOUT
+    SCHEDULED_EVENTS_HANDLER_SYSTEMD_FILE=/etc/systemd/system/scheduled-events-handler.service
+    wait_for_file 1200 1 $SCHEDULED_EVENTS_HANDLER_SYSTEMD_FILE || exit $ERR_FILE_WATCH_TIMEOUT
+    systemctlEnableAndStart scheduled-events-handler || exit $ERR_SYSTEMCTL_START_FAIL
+}
+
 ensureJournal() {
     {
         echo "Storage=persistent"
@@ -347,6 +420,17 @@ ensureK8sControlPlane() {
     retrycmd_if_failure 120 5 25 $KUBECTL 2>/dev/null cluster-info || exit $ERR_K8S_RUNNING_TIMEOUT
 }
 
+ensureOpenServiceMeshNamespaces() {
+    if $REBOOTREQUIRED || [ "$NO_OUTBOUND" = "true" ]; then
+        return
+    fi
+    # the addon manager creates the namespaces missing, the existing ones are enrolled here
+    for NAMESPACE in ${OSM_NAMESPACES//,/ }; do
+        retrycmd_if_failure 120 5 25 $KUBECTL label namespace $NAMESPACE --overwrite openservicemesh.io/monitored-by=osm || exit $ERR_OSM_NAMESPACES_FAIL
+        retrycmd_if_failure 120 5 25 $KUBECTL annotate namespace $NAMESPACE --overwrite openservicemesh.io/sidecar-injection=enabled || exit $ERR_OSM_NAMESPACES_FAIL
+    done
+}
+
 ensureEtcd() {
     retrycmd_if_failure 120 5 25 curl --cacert /etc/kubernetes/certs/ca.crt --cert /etc/kubernetes/certs/etcdclient.crt --key /etc/kubernetes/certs/etcdclient.key ${ETCD_CLIENT_URL}/v2/machines || exit $ERR_ETCD_RUNNING_TIMEOUT
 }
@@ -413,7 +497,7 @@ configClusterAutoscalerAddon() {
     sed -i "s|<tenantID>|$(echo $TENANT_ID | base64)|g" $CLUSTER_AUTOSCALER_ADDON_FILE
     sed -i "s|<rg>|$(echo $RESOURCE_GROUP | base64)|g" $CLUSTER_AUTOSCALER_ADDON_FILE
     sed -i "s|<vmType>|$(echo $VM_TYPE | base64)|g" $CLUSTER_AUTOSCALER_ADDON_FILE
-    sed -i "s|<vmssName>|$PRIMARY_SCALE_SET|g" $CLUSTER_AUTOSCALER_ADDON_FILE
+    sed -i "s|<userAssignedIdentityID>|$USER_ASSIGNED_IDENTITY_ID|g" $CLUSTER_AUTOSCALER_ADDON_FILE
 }
 
 configACIConnectorAddon() {
@@ -431,6 +515,22 @@ configACIConnectorAddon() {
     sed -i "s|<key>|$ACI_CONNECTOR_KEY|g" $ACI_CONNECTOR_ADDON_FILE
 }
 
+configAppGwIngressAddon() {
+    APPGW_INGRESS_ADDON_FILE=/etc/kubernetes/addons/appgw-ingress-deployment.yaml
+    wait_for_file 1200 1 $APPGW_INGRESS_ADDON_FILE || exit $ERR_FILE_WATCH_TIMEOUT
+    sed -i "s|<subscriptionId>|$SUBSCRIPTION_ID|g" $APPGW_INGRESS_ADDON_FILE
+    sed -i "s|<resourceGroup>|$RESOURCE_GROUP|g" $APPGW_INGRESS_ADDON_FILE
+    sed -i "s|<appGwName>|$APPGW_NAME|g" $APPGW_INGRESS_ADDON_FILE
+    sed -i "s|<identityResourceId>|$APPGW_IDENTITY_RESOURCE_ID|g" $APPGW_INGRESS_ADDON_FILE
+    sed -i "s|<identityClientId>|$APPGW_IDENTITY_CLIENT_ID|g" $APPGW_INGRESS_ADDON_FILE
+}
+
+configAzurePolicyAddon() {
+    AZURE_POLICY_ADDON_FILE=/etc/kubernetes/addons/azure-policy-deployment.yaml
+    wait_for_file 1200 1 $AZURE_POLICY_ADDON_FILE || exit $ERR_FILE_WATCH_TIMEOUT
+    sed -i "s|<resourceId>|/subscriptions/$SUBSCRIPTION_ID/resourceGroups/$RESOURCE_GROUP|g" $AZURE_POLICY_ADDON_FILE
+}
+
 configAddons() {
     if [[ "${CLUSTER_AUTOSCALER_ADDON}" = True ]]; then
         configClusterAutoscalerAddon
@@ -439,6 +539,14 @@ configAddons() {
     if [[ "${ACI_CONNECTOR_ADDON}" = True ]]; then
         configACIConnectorAddon
     fi
+
+    if [[ "${APPGW_INGRESS_ADDON}" = true ]]; then
+        configAppGwIngressAddon
+    fi
+
+    if [[ "${AZURE_POLICY_ADDON}" = true ]]; then
+        configAzurePolicyAddon
+    fi
 }
 
 configGPUDrivers() {
@@ -13,6 +13,7 @@ ERR_ETCD_DOWNLOAD_TIMEOUT=12 # Timeout waiting for etcd to download
 ERR_ETCD_VOL_MOUNT_FAIL=13 # Unable to mount etcd disk volume
 ERR_ETCD_START_TIMEOUT=14 # Unable to start etcd runtime
 ERR_ETCD_CONFIG_FAIL=15 # Unable to configure etcd cluster
+ERR_ETCD_NIC_CONFIG_FAIL=16 # Unable to configure the NIC of the dedicated etcd subnet
 ERR_DOCKER_INSTALL_TIMEOUT=20 # Timeout waiting for docker install
 ERR_DOCKER_DOWNLOAD_TIMEOUT=21 # Timout waiting for docker download(s)
 ERR_DOCKER_KEY_DOWNLOAD_TIMEOUT=22 # Timeout waiting to download docker repo key
@@ -27,6 +28,7 @@ ERR_KUBECTL_NOT_FOUND=32 # kubectl client binary not found on local disk
 ERR_IMG_DOWNLOAD_TIMEOUT=33 # Timeout waiting for img download
 ERR_KUBELET_START_FAIL=34 # kubelet could not be started by systemctl
 ERR_CONTAINER_IMG_PULL_TIMEOUT=35 # Timeout trying to pull a container image
+ERR_OSM_NAMESPACES_FAIL=36 # Unable to enroll the namespaces of the open-service-mesh addon in the mesh
 ERR_CNI_DOWNLOAD_TIMEOUT=41 # Timeout waiting for CNI download(s)
 ERR_MS_PROD_DEB_DOWNLOAD_TIMEOUT=42 # Timeout waiting for https://packages.microsoft.com/config/ubuntu/16.04/packages-microsoft-prod.deb
 ERR_MS_PROD_DEB_PKG_ADD_FAIL=43 # Failed to add repo pkg file
@@ -49,6 +51,7 @@ ERR_CSE_PROVISION_SCRIPT_NOT_READY_TIMEOUT=100 # Timeout waiting for cloud-init
 ERR_APT_DIST_UPGRADE_TIMEOUT=101 # Timeout waiting for apt-get dist-upgrade to complete
 ERR_APT_PURGE_FAIL=102 # Error purging distro packages
 ERR_SYSCTL_RELOAD=103 # Error reloading sysctl config
+ERR_APT_MIRROR_CONFIG_FAIL=104 # Error configuring the apt mirror of an air-gapped cluster
 ERR_CIS_ASSIGN_ROOT_PW=111 # Error assigning root password in CIS enforcement
 ERR_CIS_ASSIGN_FILE_PERMISSION=112 # Error assigning permission to a file in CIS enforcement
 ERR_PACKER_COPY_FILE=113 # Error writing a file to disk during VHD CI
@@ -33,6 +33,7 @@ if [[ "${TARGET_ENVIRONMENT,,}" == "${AZURE_STACK_ENV}"  ]]; then
 fi
 
 CUSTOM_SEARCH_DOMAIN_SCRIPT=/opt/azure/containers/setup-custom-search-domains.sh
+NODE_SEARCH_DOMAINS_FILE=/opt/azure/containers/node-search-domains
 
 set +x
 ETCD_PEER_CERT=$(echo ${ETCD_PEER_CERTIFICATES} | cut -d'[' -f 2 | cut -d']' -f 1 | cut -d',' -f $((${NODE_INDEX}+1)))
@@ -60,6 +61,10 @@ else
     FULL_INSTALL_REQUIRED=true
 fi
 
+if [[ $OS == $UBUNTU_OS_NAME ]] && [[ -n "${PACKAGES_URL}" ]]; then
+    configureAptMirror
+fi
+
 if [[ $OS == $UBUNTU_OS_NAME ]] && [ "$FULL_INSTALL_REQUIRED" = "true" ]; then
     installDeps
 else
@@ -109,6 +114,9 @@ if [[ -n "${MASTER_NODE}" ]]; then
 fi
 # configure etcd if we are configured for etcd
 if [[ -n "${MASTER_NODE}" ]] && [[ -z "${COSMOS_URI}" ]]; then
+    if [[ -n "${ETCD_FIRST_CONSECUTIVE_STATIC_IP}" ]]; then
+        ensureEtcdNIC
+    fi
     configureEtcd
 else
     removeEtcd
@@ -119,6 +127,10 @@ if [ -f $CUSTOM_SEARCH_DOMAIN_SCRIPT ]; then
     $CUSTOM_SEARCH_DOMAIN_SCRIPT > /opt/azure/containers/setup-custom-search-domain.log 2>&1 || exit $ERR_CUSTOM_SEARCH_DOMAINS_FAIL
 fi
 
+if [ -f $NODE_SEARCH_DOMAINS_FILE ]; then
+    configureNodeSearchDomains
+fi
+
 if [[ "$CONTAINER_RUNTIME" == "docker" ]]; then
     ensureDocker
 elif [[ "$CONTAINER_RUNTIME" == "kata-containers" ]]; then
@@ -164,6 +176,10 @@ fi
 ensureKubelet
 ensureJournal
 
+if [[ "${SCHEDULED_EVENTS_HANDLER}" == true ]]; then
+    ensureScheduledEventsHandler
+fi
+
 if [[ -n "${MASTER_NODE}" ]]; then
     if version_gte ${KUBERNETES_VERSION} 1.16; then
       ensureLabelNodes
@@ -173,6 +189,9 @@ if [[ -n "${MASTER_NODE}" ]]; then
       ensureEtcd
     fi
     ensureK8sControlPlane
+    if [[ -n "${OSM_NAMESPACES}" ]]; then
+        ensureOpenServiceMeshNamespaces
+    fi
 fi
 
 if $FULL_INSTALL_REQUIRED; then
@@ -0,0 +1,45 @@
+#!/bin/bash
+
+# Generates the certificates of the konnectivity-server static pod, signed by the cluster CA:
+# a server certificate valid for the names of the API server, which the konnectivity-agents of the nodes verify,
+# and the client certificate the API server authenticates to the konnectivity-server with.
+
+K8S_CA_CRT_FILEPATH="${K8S_CA_CRT_FILEPATH:=/etc/kubernetes/certs/ca.crt}"
+K8S_CA_KEY_FILEPATH="${K8S_CA_KEY_FILEPATH:=/etc/kubernetes/certs/ca.key}"
+K8S_APISERVER_CRT_FILEPATH="${K8S_APISERVER_CRT_FILEPATH:=/etc/kubernetes/certs/apiserver.crt}"
+KONNECTIVITY_SERVER_KEY="${KONNECTIVITY_SERVER_KEY:=/etc/kubernetes/certs/konnectivity-server.key}"
+KONNECTIVITY_SERVER_CRT="${KONNECTIVITY_SERVER_CRT:=/etc/kubernetes/certs/konnectivity-server.crt}"
+KONNECTIVITY_CLIENT_KEY="${KONNECTIVITY_CLIENT_KEY:=/etc/kubernetes/certs/konnectivity-client.key}"
+KONNECTIVITY_CLIENT_CRT="${KONNECTIVITY_CLIENT_CRT:=/etc/kubernetes/certs/konnectivity-client.crt}"
+
+if [[ -s $KONNECTIVITY_SERVER_CRT && -s $KONNECTIVITY_CLIENT_CRT ]]; then
+    echo "found konnectivity certs, not creating them"
+    exit 0
+fi
+
+RANDFILE=$(mktemp)
+export RANDFILE
+EXTFILE=$(mktemp)
+CSR=$(mktemp)
+
+# the agents connect to the address of the API server, so the server certificate gets the same names
+SUBJECT_ALT_NAME=$(openssl x509 -in $K8S_APISERVER_CRT_FILEPATH -noout -text | grep -A1 "Subject Alternative Name" | tail -n1 | sed 's/IP Address:/IP:/g; s/ //g')
+
+generate_cert() {
+    KEY=$1
+    CRT=$2
+    SUBJECT=$3
+    EXTENSIONS=$4
+    echo "$EXTENSIONS" > $EXTFILE
+    openssl genrsa -out $KEY 2048
+    chmod 600 $KEY
+    openssl req -new -key $KEY -out $CSR -subj "$SUBJECT"
+    openssl x509 -req -days 730 -in $CSR -CA $K8S_CA_CRT_FILEPATH -CAkey $K8S_CA_KEY_FILEPATH -set_serial "0x$(openssl rand -hex 16)" -extfile $EXTFILE -out $CRT
+}
+
+generate_cert $KONNECTIVITY_SERVER_KEY $KONNECTIVITY_SERVER_CRT "/CN=konnectivity-server" "extendedKeyUsage=serverAuth
+subjectAltName=${SUBJECT_ALT_NAME}"
+generate_cert $KONNECTIVITY_CLIENT_KEY $KONNECTIVITY_CLIENT_CRT "/CN=kube-apiserver-konnectivity-client" "extendedKeyUsage=clientAuth"
+
+rm -f $RANDFILE $EXTFILE $CSR
+#EOF
@@ -0,0 +1,67 @@
+#!/usr/bin/env bash
+
+# Cordons and drains this node when Azure schedules the preemption or the deletion of its VM, then starts the event
+# so that the VM does not wait for the end of its notice once the node is drained.
+#
+# Azure schedules Preempt events for the VMs of a low-priority scale set, and Terminate events for the VMs of a
+# scale set with terminate notifications enabled, e.g. when it is scaled in.
+
+set -o nounset
+set -o pipefail
+
+SCHEDULED_EVENTS_URL="http://169.254.169.254/metadata/scheduledevents?api-version=2019-08-01"
+INSTANCE_NAME_URL="http://169.254.169.254/metadata/instance/compute/name?api-version=2019-08-01&format=text"
+KUBECONFIG=/var/lib/kubelet/kubeconfig
+POLL_SECONDS=${POLL_SECONDS:-5}
+# the drain must finish within the 30 second notice of a preemption
+DRAIN_TIMEOUT_PREEMPT=25s
+DRAIN_TIMEOUT_TERMINATE=${DRAIN_TIMEOUT_TERMINATE:-10m}
+
+KUBECTL=kubectl
+if [[ -x /opt/kubectl ]]; then
+  KUBECTL=/opt/kubectl
+fi
+NODE_NAME=$(hostname | tr '[:upper:]' '[:lower:]')
+
+until INSTANCE_NAME=$(curl -fsS -H Metadata:true "${INSTANCE_NAME_URL}"); do
+  echo "The name of this instance could not be retrieved from the instance metadata service, trying again in ${POLL_SECONDS} seconds"
+  sleep "${POLL_SECONDS}"
+done
+
+drain() {
+  local timeout=$1
+  ${KUBECTL} --kubeconfig "${KUBECONFIG}" cordon "${NODE_NAME}"
+  ${KUBECTL} --kubeconfig "${KUBECONFIG}" drain "${NODE_NAME}" --ignore-daemonsets --delete-local-data --force --grace-period=-1 --timeout="${timeout}"
+}
+
+start_event() {
+  local event_id=$1
+  curl -fsS -H Metadata:true -X POST -d "{\"StartRequests\": [{\"EventId\": \"${event_id}\"}]}" "${SCHEDULED_EVENTS_URL}"
+}
+
+HANDLED=""
+while true; do
+  if events=$(curl -fsS -H Metadata:true "${SCHEDULED_EVENTS_URL}"); then
+    # the events scheduled for this instance that are about to remove it
+    echo "${events}" | jq -r --arg instance "${INSTANCE_NAME}" \
+      '.Events[] | select((.EventType == "Preempt" or .EventType == "Terminate") and (.Resources | index($instance))) | "\(.EventId) \(.EventType)"' |
+    while read -r event_id event_type; do
+      if [[ " ${HANDLED} " == *" ${event_id} "* ]]; then
+        continue
+      fi
+      echo "Azure scheduled the ${event_type} event ${event_id} for ${INSTANCE_NAME}, draining node ${NODE_NAME}"
+      timeout=${DRAIN_TIMEOUT_TERMINATE}
+      if [[ "${event_type}" == "Preempt" ]]; then
+        timeout=${DRAIN_TIMEOUT_PREEMPT}
+      fi
+      drain "${timeout}" || echo "Node ${NODE_NAME} could not be drained before the ${event_type} event, starting it anyway"
+      start_event "${event_id}" && echo "Started the ${event_type} event ${event_id}"
+      echo "${event_id}" >> /var/run/scheduled-events-handled
+    done
+    HANDLED=$(cat /var/run/scheduled-events-handled 2>/dev/null)
+  else
+    echo "The scheduled events could not be retrieved from the instance metadata service"
+  fi
+  sleep "${POLL_SECONDS}"
+done
+#EOF
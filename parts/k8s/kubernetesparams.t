@@ -351,27 +351,29 @@
     "networkPolicy": {
       "defaultValue": "{{.OrchestratorProfile.KubernetesConfig.NetworkPolicy}}",
       "metadata": {
-        "description": "The network policy enforcement to use (calico|cilium); 'none' and 'azure' here for backwards compatibility"
+        "description": "The network policy enforcement to use (calico|cilium|antrea); 'none' and 'azure' here for backwards compatibility"
       },
       "allowedValues": [
         "",
         "none",
         "azure",
         "calico",
-        "cilium"
+        "cilium",
+        "antrea"
       ],
       "type": "string"
     },
     "networkPlugin": {
       "defaultValue": "{{.OrchestratorProfile.KubernetesConfig.NetworkPlugin}}",
       "metadata": {
-        "description": "The network plugin to use for Kubernetes (kubenet|azure|flannel|cilium)"
+        "description": "The network plugin to use for Kubernetes (kubenet|azure|flannel|cilium|antrea)"
       },
       "allowedValues": [
         "kubenet",
         "azure",
         "flannel",
-        "cilium"
+        "cilium",
+        "antrea"
       ],
       "type": "string"
     },
@@ -388,19 +390,19 @@
       "type": "string"
     },
     "containerdDownloadURLBase": {
-      "defaultValue": "https://storage.googleapis.com/cri-containerd-release/",
+      "defaultValue": "{{GetAirGapMirrorURL "https://storage.googleapis.com/cri-containerd-release/"}}",
       "type": "string"
     },
     "cniPluginsURL": {
-      "defaultValue": "https://acs-mirror.azureedge.net/cni/cni-plugins-amd64-latest.tgz",
+      "defaultValue": "{{GetAirGapMirrorURL "https://acs-mirror.azureedge.net/cni/cni-plugins-amd64-latest.tgz"}}",
       "type": "string"
     },
     "vnetCniLinuxPluginsURL": {
-      "defaultValue": "https://acs-mirror.azureedge.net/cni/azure-vnet-cni-linux-amd64-latest.tgz",
+      "defaultValue": "{{GetAirGapMirrorURL "https://acs-mirror.azureedge.net/cni/azure-vnet-cni-linux-amd64-latest.tgz"}}",
       "type": "string"
     },
     "vnetCniWindowsPluginsURL": {
-      "defaultValue": "https://acs-mirror.azureedge.net/cni/azure-vnet-cni-windows-amd64-latest.zip",
+      "defaultValue": "{{GetAirGapMirrorURL "https://acs-mirror.azureedge.net/cni/azure-vnet-cni-windows-amd64-latest.zip"}}",
       "type": "string"
     },
     "maxPods": {
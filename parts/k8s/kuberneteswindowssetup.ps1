@@ -71,6 +71,11 @@ $global:KubeBinariesVersion = "{{WrapAsParameter "kubeBinariesVersion"}}"
 ## Docker Version
 $global:DockerVersion = "{{WrapAsParameter "windowsDockerVersion"}}"
 
+## Container runtime, docker or containerd
+$global:ContainerRuntime = "{{WrapAsParameter "containerRuntime"}}"
+$global:ContainerdURL = "{{WrapAsParameter "windowsContainerdURL"}}"
+$global:ContainerdRegistryMirrors = "{{GetBase64EncodedContainerdRegistryMirrors}}"
+
 ## VM configuration passed by Azure
 $global:WindowsTelemetryGUID = "{{WrapAsParameter "windowsTelemetryGUID"}}"
 {{if eq GetIdentitySystem "adfs"}}
@@ -105,6 +110,7 @@ $global:UseInstanceMetadata = "{{WrapAsVariable "useInstanceMetadata"}}"
 
 $global:LoadBalancerSku = "{{WrapAsVariable "loadBalancerSku"}}"
 $global:ExcludeMasterFromStandardLB = "{{WrapAsVariable "excludeMasterFromStandardLB"}}"
+$global:CloudProviderConfig = "{{GetBase64EncodedCloudProviderConfig}}"
 
 
 # Windows defaults, not changed by aks-engine
@@ -148,7 +154,7 @@ Update-ServiceFailureActions()
 {
     sc.exe failure "kubelet" actions= restart/60000/restart/60000/restart/60000 reset= 900
     sc.exe failure "kubeproxy" actions= restart/60000/restart/60000/restart/60000 reset= 900
-    sc.exe failure "docker" actions= restart/60000/restart/60000/restart/60000 reset= 900
+    sc.exe failure $global:ContainerRuntime actions= restart/60000/restart/60000/restart/60000 reset= 900
 }
 
 try
@@ -172,8 +178,25 @@ try
         Write-Log "Create required data directories as needed"
         Initialize-DataDirectories
 
-        Write-Log "Install docker"
-        Install-Docker -DockerVersion $global:DockerVersion
+        if ($global:ContainerRuntime -eq "containerd") {
+            Write-Log "Install containerd"
+            $pauseImage = ($global:KubeletConfigArgs | Where-Object { $_ -like "--pod-infra-container-image=*" }) -replace "--pod-infra-container-image=", ""
+            if ($global:NetworkPlugin -eq "azure") {
+                $cniBinDir = $global:AzureCNIBinDir
+                $cniConfDir = $global:AzureCNIConfDir
+            } else {
+                $cniBinDir = $global:CNIPath
+                $cniConfDir = $global:CNIConfigPath
+            }
+            Install-Containerd -ContainerdURL $global:ContainerdURL `
+                               -CNIBinDir $cniBinDir `
+                               -CNIConfDir $cniConfDir `
+                               -PauseImage $pauseImage `
+                               -RegistryMirrors $global:ContainerdRegistryMirrors
+        } else {
+            Write-Log "Install docker"
+            Install-Docker -DockerVersion $global:DockerVersion
+        }
 
         Write-Log "Download kubelet binaries and unzip"
         Get-KubePackage -KubeBinariesSASURL $global:KubeBinariesPackageSASURL
@@ -209,6 +232,7 @@ try
             -UseInstanceMetadata $global:UseInstanceMetadata `
             -LoadBalancerSku $global:LoadBalancerSku `
             -ExcludeMasterFromStandardLB $global:ExcludeMasterFromStandardLB `
+            -CloudProviderConfig $global:CloudProviderConfig `
             -TargetEnvironment $TargetEnvironment
 
         {{if IsAzureStackCloud}}
@@ -230,8 +254,10 @@ try
                          -AgentCertificate $global:AgentCertificate
 
 
-        Write-Log "Create the Pause Container kubletwin/pause"
-        New-InfraContainer -KubeDir $global:KubeDir
+        if ($global:ContainerRuntime -ne "containerd") {
+            Write-Log "Create the Pause Container kubletwin/pause"
+            New-InfraContainer -KubeDir $global:KubeDir
+        }
 
         Write-Log "Configuring networking with NetworkPlugin:$global:NetworkPlugin"
 
@@ -283,7 +309,8 @@ try
             -KubeClusterCIDR $global:KubeClusterCIDR `
             -KubeServiceCIDR $global:KubeServiceCIDR `
             -HNSModule $global:HNSModule `
-            -KubeletNodeLabels $global:KubeletNodeLabels
+            -KubeletNodeLabels $global:KubeletNodeLabels `
+            -ContainerRuntime $global:ContainerRuntime
 
         # Install OpenSSH if SSH enabled
         $sshEnabled = [System.Convert]::ToBoolean("{{ WindowsSSHEnabled }}")
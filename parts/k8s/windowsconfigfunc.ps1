@@ -82,6 +82,66 @@ function Install-Docker
     }
 }
 
+# Installs containerd from a package holding containerd.exe, ctr.exe and containerd-shim-runhcs-v1.exe, and registers it as a service
+function Install-Containerd
+{
+    Param(
+        [Parameter(Mandatory=$true)][string]
+        $ContainerdURL,
+        [Parameter(Mandatory=$true)][string]
+        $CNIBinDir,
+        [Parameter(Mandatory=$true)][string]
+        $CNIConfDir,
+        [Parameter(Mandatory=$true)][string]
+        $PauseImage,
+        [string]
+        $RegistryMirrors # base64 encoded TOML
+    )
+
+    $containerdDir = [Io.path]::Combine($env:ProgramFiles, "containerd")
+    $tempdir = New-TemporaryDirectory
+    $package = [Io.path]::Combine($tempdir, "containerd.tar.gz")
+    for ($i = 0; $i -le 10; $i++) {
+        DownloadFileOverHttp -Url $ContainerdURL -DestinationPath $package
+        if ($?) {
+            break
+        }
+        else {
+            Write-Log $Error[0].Exception.Message
+        }
+    }
+    tar -xzf $package -C $tempdir
+    mkdir $containerdDir -Force
+    # the binaries may be at the root of the package or in a bin directory
+    Get-ChildItem -Path $tempdir -Recurse -Include *.exe | Copy-Item -Destination $containerdDir -Force
+    del $tempdir -Recurse
+
+    $configFile = [Io.path]::Combine($containerdDir, "config.toml")
+    $config = @"
+[plugins.cri]
+  sandbox_image = "$PauseImage"
+  [plugins.cri.containerd]
+    snapshotter = "windows"
+    [plugins.cri.containerd.default_runtime]
+      runtime_type = "io.containerd.runhcs.v1"
+  [plugins.cri.cni]
+    bin_dir = "$($CNIBinDir.Replace("\", "/"))"
+    conf_dir = "$($CNIConfDir.Replace("\", "/"))"
+"@
+    if ($RegistryMirrors) {
+        $config += "`n" + [System.Text.Encoding]::UTF8.GetString([System.Convert]::FromBase64String($RegistryMirrors))
+    }
+    $config | Out-File -encoding ASCII -filepath $configFile
+
+    $machinePath = [System.Environment]::GetEnvironmentVariable("Path", [System.EnvironmentVariableTarget]::Machine)
+    [System.Environment]::SetEnvironmentVariable("Path", "$machinePath;$containerdDir", [System.EnvironmentVariableTarget]::Machine)
+    $env:Path += ";$containerdDir"
+
+    & "$containerdDir\containerd.exe" --register-service --config $configFile
+    Start-Service containerd
+    Write-Log "Installed containerd from $ContainerdURL"
+}
+
 # Pagefile adjustments
 function Adjust-PageFileSize()
 {
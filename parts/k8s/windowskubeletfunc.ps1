@@ -38,6 +38,8 @@ Write-AzureConfig {
         $LoadBalancerSku,
         [Parameter(Mandatory = $true)][string]
         $ExcludeMasterFromStandardLB,
+        [string]
+        $CloudProviderConfig,
         [Parameter(Mandatory = $true)][string]
         $KubeDir,
         [Parameter(Mandatory = $true)][string]
@@ -67,13 +69,23 @@ Write-AzureConfig {
     "primaryAvailabilitySetName": "$PrimaryAvailabilitySetName",
     "primaryScaleSetName": "$PrimaryScaleSetName",
     "useManagedIdentityExtension": $UseManagedIdentityExtension,
-    "userAssignedIdentityID": $UserAssignedClientID,
+    "userAssignedIdentityID": "$UserAssignedClientID",
     "useInstanceMetadata": $UseInstanceMetadata,
     "loadBalancerSku": "$LoadBalancerSku",
     "excludeMasterFromStandardLB": $ExcludeMasterFromStandardLB
 }
 "@
 
+    # merge the cloudProviderConfig settings of the api model, which have no parameter of their own
+    if ($CloudProviderConfig) {
+        $config = $azureConfig | ConvertFrom-Json
+        $settings = [System.Text.Encoding]::UTF8.GetString([System.Convert]::FromBase64String($CloudProviderConfig)) | ConvertFrom-Json
+        foreach ($setting in $settings.PSObject.Properties) {
+            $config | Add-Member -MemberType NoteProperty -Name $setting.Name -Value $setting.Value -Force
+        }
+        $azureConfig = $config | ConvertTo-Json -Depth 10
+    }
+
     $azureConfig | Out-File -encoding ASCII -filepath "$azureConfigFile"
 }
 
@@ -238,7 +250,9 @@ New-NSSMService {
         $KubeletStartFile,
         [string]
         [Parameter(Mandatory = $true)]
-        $KubeProxyStartFile
+        $KubeProxyStartFile,
+        [string]
+        $ContainerRuntime = "docker"
     )
 
     # setup kubelet
@@ -247,7 +261,7 @@ New-NSSMService {
     & "$KubeDir\nssm.exe" set Kubelet AppParameters $KubeletStartFile
     & "$KubeDir\nssm.exe" set Kubelet DisplayName Kubelet
     & "$KubeDir\nssm.exe" set Kubelet AppRestartDelay 5000
-    & "$KubeDir\nssm.exe" set Kubelet DependOnService docker
+    & "$KubeDir\nssm.exe" set Kubelet DependOnService $ContainerRuntime
     & "$KubeDir\nssm.exe" set Kubelet Description Kubelet
     & "$KubeDir\nssm.exe" set Kubelet Start SERVICE_AUTO_START
     & "$KubeDir\nssm.exe" set Kubelet ObjectName LocalSystem
@@ -318,7 +332,9 @@ Install-KubernetesServices {
         [Parameter(Mandatory = $true)][string]
         $HNSModule,
         [Parameter(Mandatory = $true)][string]
-        $KubeletNodeLabels
+        $KubeletNodeLabels,
+        [string]
+        $ContainerRuntime = "docker"
     )
 
     # Calculate some local paths
@@ -386,6 +402,7 @@ Install-KubernetesServices {
 `$global:VolumePluginDir = "$VolumePluginDir"
 `$global:NetworkPlugin="$NetworkPlugin"
 `$global:KubeletNodeLabels="$KubeletNodeLabels"
+`$global:ContainerRuntime="$ContainerRuntime"
 
 "@
 
@@ -416,8 +433,10 @@ if (!`$hnsNetwork)
 `$hnsNetwork = Get-HnsNetwork | ? Name -EQ $KubeNetwork
 if (`$hnsNetwork)
 {
-    # Cleanup all containers
-    docker ps -q | foreach {docker rm `$_ -f}
+    # Cleanup all containers, containerd removes the pod sandboxes of the previous boot itself
+    if (`$global:ContainerRuntime -eq "docker") {
+        docker ps -q | foreach {docker rm `$_ -f}
+    }
 
     Write-Host "Cleaning up old HNS network found"
     Remove-HnsNetwork `$hnsNetwork
@@ -562,8 +581,10 @@ try
     if (`$hnsNetwork)
     {
         # Kubelet has been restarted with existing network.
-        # Cleanup all containers
-        docker ps -q | foreach {docker rm `$_ -f}
+        # Cleanup all containers, containerd removes the pod sandboxes of the previous boot itself
+        if (`$global:ContainerRuntime -eq "docker") {
+            docker ps -q | foreach {docker rm `$_ -f}
+        }
         # cleanup network
         Write-Host "Cleaning up old HNS network found"
         Remove-HnsNetwork `$hnsNetwork
@@ -619,5 +640,6 @@ $KubeDir\kube-proxy.exe --v=3 --proxy-mode=kernelspace --hostname-override=$env:
 
     New-NSSMService -KubeDir $KubeDir `
         -KubeletStartFile $KubeletStartFile `
-        -KubeProxyStartFile $KubeProxyStartFile
+        -KubeProxyStartFile $KubeProxyStartFile `
+        -ContainerRuntime $ContainerRuntime
 }
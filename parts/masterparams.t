@@ -102,6 +102,30 @@
       },
       "type": "string"
     },
+    {{if .MasterProfile.HasDedicatedEtcdSubnet}}
+    "etcdSubnet": {
+      "defaultValue": "{{.MasterProfile.EtcdSubnet}}",
+      "metadata": {
+        "description": "Sets the subnet of the etcd NICs of the master node(s)."
+      },
+      "type": "string"
+    },
+    {{if .MasterProfile.IsCustomVNET}}
+    "etcdVnetSubnetID": {
+      "metadata": {
+        "description": "Sets the vnet subnet of the etcd NICs of the master node(s)."
+      },
+      "type": "string"
+    },
+    {{end}}
+    "etcdFirstConsecutiveStaticIP": {
+      "defaultValue": "{{.MasterProfile.EtcdFirstConsecutiveStaticIP}}",
+      "metadata": {
+        "description": "Sets the static IP of the etcd NIC of the first master"
+      },
+      "type": "string"
+    },
+    {{end}}
     "masterVMSize": {
       {{GetMasterAllowedSizes}}
       "metadata": {
@@ -97,4 +97,11 @@
         "description": "The version of Docker to be installed on Windows Nodes"
       },
       "type": "string"
+    },
+    "windowsContainerdURL": {
+      "defaultValue": "",
+      "metadata": {
+        "description": "The URL of the containerd package to be installed on Windows Nodes with the containerd container runtime"
+      },
+      "type": "string"
     }
@@ -86,9 +86,14 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 		Name:    ClusterAutoscalerAddonName,
 		Enabled: to.BoolPtr(DefaultClusterAutoscalerAddonEnabled && !cs.Properties.IsAzureStackCloud()),
 		Config: map[string]string{
-			"min-nodes":     "1",
-			"max-nodes":     "5",
-			"scan-interval": "10s",
+			"min-nodes":                        "1",
+			"max-nodes":                        "5",
+			"scan-interval":                    "10s",
+			"scale-down-unneeded-time":         "10m",
+			"scale-down-delay-after-add":       "10m",
+			"scale-down-utilization-threshold": "0.5",
+			"expander":                         ClusterAutoscalerExpanderRandom,
+			"auto-discovery":                   "false",
 		},
 		Containers: []KubernetesContainerSpec{
 			{
@@ -182,8 +187,10 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 		Enabled: to.BoolPtr(DefaultMetricsServerAddonEnabled && common.IsKubernetesVersionGe(o.OrchestratorVersion, "1.9.0")),
 		Containers: []KubernetesContainerSpec{
 			{
-				Name:  MetricsServerAddonName,
-				Image: specConfig.KubernetesImageBase + k8sComponents[MetricsServerAddonName],
+				Name:           MetricsServerAddonName,
+				Image:          specConfig.KubernetesImageBase + k8sComponents[MetricsServerAddonName],
+				CPURequests:    "50m",
+				MemoryRequests: "100Mi",
 			},
 		},
 	}
@@ -227,7 +234,7 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 
 	defaultIPMasqAgentAddonsConfig := KubernetesAddon{
 		Name:    IPMASQAgentAddonName,
-		Enabled: to.BoolPtr(DefaultIPMasqAgentAddonEnabled && o.KubernetesConfig.NetworkPlugin != NetworkPluginCilium),
+		Enabled: to.BoolPtr(DefaultIPMasqAgentAddonEnabled && o.KubernetesConfig.NetworkPlugin != NetworkPluginCilium && o.KubernetesConfig.NetworkPlugin != NetworkPluginAntrea),
 		Containers: []KubernetesContainerSpec{
 			{
 				Name:           IPMASQAgentAddonName,
@@ -249,8 +256,10 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 		Enabled: to.BoolPtr(o.IsAzureCNI() && o.KubernetesConfig.NetworkPolicy != NetworkPolicyCalico),
 		Containers: []KubernetesContainerSpec{
 			{
-				Name:  AzureCNINetworkMonitoringAddonName,
-				Image: specConfig.AzureCNIImageBase + k8sComponents[AzureCNINetworkMonitoringAddonName],
+				Name:           AzureCNINetworkMonitoringAddonName,
+				Image:          specConfig.AzureCNIImageBase + k8sComponents[AzureCNINetworkMonitoringAddonName],
+				CPURequests:    "30m",
+				MemoryRequests: "25Mi",
 			},
 		},
 	}
@@ -260,12 +269,16 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 		Enabled: to.BoolPtr(o.KubernetesConfig.NetworkPlugin == NetworkPluginAzure && o.KubernetesConfig.NetworkPolicy == NetworkPolicyAzure),
 		Containers: []KubernetesContainerSpec{
 			{
-				Name:  AzureNetworkPolicyAddonName,
-				Image: "mcr.microsoft.com/containernetworking/azure-npm:v1.0.25",
+				Name:           AzureNetworkPolicyAddonName,
+				Image:          "mcr.microsoft.com/containernetworking/azure-npm:v1.0.25",
+				CPURequests:    "250m",
+				MemoryRequests: "300Mi",
 			},
 			{
-				Name:  AzureVnetTelemetryAddonName,
-				Image: "mcr.microsoft.com/containernetworking/azure-vnet-telemetry:v1.0.25",
+				Name:           AzureVnetTelemetryAddonName,
+				Image:          "mcr.microsoft.com/containernetworking/azure-vnet-telemetry:v1.0.25",
+				CPURequests:    "50m",
+				MemoryRequests: "50Mi",
 			},
 		},
 	}
@@ -312,9 +325,10 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 		},
 	}
 
+	// The appgw-ingress addon gives its ingress controller the identity of the Application Gateway with aad-pod-identity
 	defaultsAADPodIdentityAddonsConfig := KubernetesAddon{
 		Name:    AADPodIdentityAddonName,
-		Enabled: to.BoolPtr(DefaultAADPodIdentityAddonEnabled && !cs.Properties.IsAzureStackCloud()),
+		Enabled: to.BoolPtr((DefaultAADPodIdentityAddonEnabled || o.KubernetesConfig.IsAddonEnabled(AppGwIngressAddonName)) && !cs.Properties.IsAzureStackCloud()),
 		Containers: []KubernetesContainerSpec{
 			{
 				Name:           "nmi",
@@ -343,6 +357,300 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 			"appgw-sku":        "WAF_v2",
 			"appgw-private-ip": "",
 		},
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           AppGwIngressControllerContainerName,
+				CPURequests:    "100m",
+				MemoryRequests: "200Mi",
+				CPULimits:      "200m",
+				MemoryLimits:   "300Mi",
+				Image:          "mcr.microsoft.com/azure-application-gateway/kubernetes-ingress:1.0.0",
+			},
+		},
+	}
+
+	defaultNginxIngressAddonsConfig := KubernetesAddon{
+		Name:    NginxIngressAddonName,
+		Enabled: to.BoolPtr(DefaultNginxIngressAddonEnabled),
+		Config: map[string]string{
+			"replicas":    DefaultNginxIngressReplicas,
+			"serviceType": "LoadBalancer",
+		},
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           NginxIngressAddonName,
+				CPURequests:    "100m",
+				MemoryRequests: "90Mi",
+				CPULimits:      "500m",
+				MemoryLimits:   "256Mi",
+				Image:          "quay.io/kubernetes-ingress-controller/nginx-ingress-controller:0.25.1",
+			},
+		},
+	}
+
+	defaultAzurePolicyAddonsConfig := KubernetesAddon{
+		Name:    AzurePolicyAddonName,
+		Enabled: to.BoolPtr(DefaultAzurePolicyAddonEnabled && !cs.Properties.IsAzureStackCloud()),
+		Config: map[string]string{
+			"auditInterval":             DefaultAzurePolicyAuditInterval,
+			"constraintViolationsLimit": DefaultAzurePolicyConstraintViolationsLimit,
+			"syncOnly":                  "",
+		},
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           AzurePolicyAddonName,
+				CPURequests:    "30m",
+				MemoryRequests: "50Mi",
+				CPULimits:      "100m",
+				MemoryLimits:   "200Mi",
+				Image:          "mcr.microsoft.com/azure-policy/policy-kubernetes-addon-prod:prod_20191011.1",
+			},
+			{
+				Name:           GatekeeperContainerName,
+				CPURequests:    "100m",
+				MemoryRequests: "256Mi",
+				CPULimits:      "1000m",
+				MemoryLimits:   "512Mi",
+				Image:          "quay.io/open-policy-agent/gatekeeper:v3.1.0-beta.2",
+			},
+		},
+	}
+
+	// The images of the keda addon are those of the KEDA release of its config
+	kedaVersion := DefaultKEDAVersion
+	if i := getAddonsIndexByName(o.KubernetesConfig.Addons, KEDAAddonName); i > -1 && o.KubernetesConfig.Addons[i].Config["version"] != "" {
+		kedaVersion = o.KubernetesConfig.Addons[i].Config["version"]
+	}
+	defaultKEDAAddonsConfig := KubernetesAddon{
+		Name:    KEDAAddonName,
+		Enabled: to.BoolPtr(DefaultKEDAAddonEnabled),
+		Config: map[string]string{
+			"version": kedaVersion,
+		},
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           KEDAOperatorContainerName,
+				CPURequests:    "100m",
+				MemoryRequests: "100Mi",
+				CPULimits:      "500m",
+				MemoryLimits:   "500Mi",
+				Image:          "kedacore/keda:" + kedaVersion,
+			},
+			{
+				Name:           KEDAMetricsAPIServerContainerName,
+				CPURequests:    "100m",
+				MemoryRequests: "100Mi",
+				CPULimits:      "500m",
+				MemoryLimits:   "500Mi",
+				Image:          "kedacore/keda-metrics-adapter:" + kedaVersion,
+			},
+		},
+	}
+
+	defaultOpenServiceMeshAddonsConfig := KubernetesAddon{
+		Name:    OpenServiceMeshAddonName,
+		Enabled: to.BoolPtr(DefaultOpenServiceMeshAddonEnabled),
+		Config: map[string]string{
+			"namespaces":                  "",
+			"permissiveTrafficPolicyMode": "true",
+			"envoyLogLevel":               "error",
+			"envoyImage":                  "envoyproxy/envoy-alpine:v1.17.2",
+			"initImage":                   "openservicemesh/init:v0.7.0",
+		},
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           OpenServiceMeshControllerContainerName,
+				CPURequests:    "100m",
+				MemoryRequests: "128Mi",
+				CPULimits:      "1",
+				MemoryLimits:   "512Mi",
+				Image:          "openservicemesh/osm-controller:v0.7.0",
+			},
+		},
+	}
+
+	defaultRegistryCacheAddonsConfig := KubernetesAddon{
+		Name:    RegistryCacheAddonName,
+		Enabled: to.BoolPtr(DefaultRegistryCacheAddonEnabled),
+		Config: map[string]string{
+			"nodePort":  DefaultRegistryCacheNodePort,
+			"remoteURL": "https://registry-1.docker.io",
+		},
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           RegistryCacheAddonName,
+				CPURequests:    "100m",
+				MemoryRequests: "200Mi",
+				CPULimits:      "1",
+				MemoryLimits:   "1Gi",
+				Image:          "registry:2.7.1",
+			},
+		},
+	}
+
+	defaultAzureWorkloadIdentityAddonsConfig := KubernetesAddon{
+		Name:    AzureWorkloadIdentityAddonName,
+		Enabled: to.BoolPtr(DefaultAzureWorkloadIdentityAddonEnabled),
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           AzureWorkloadIdentityAddonName,
+				CPURequests:    "100m",
+				MemoryRequests: "20Mi",
+				CPULimits:      "100m",
+				MemoryLimits:   "30Mi",
+				Image:          "mcr.microsoft.com/oss/azure/workload-identity/webhook:v0.6.0",
+			},
+		},
+	}
+
+	defaultFluentBitAddonsConfig := KubernetesAddon{
+		Name:    FluentBitAddonName,
+		Enabled: to.BoolPtr(DefaultFluentBitAddonEnabled),
+		Config: map[string]string{
+			"output":           FluentBitOutputLogAnalytics,
+			"logType":          "KubernetesContainerLogs",
+			"storageContainer": "kubernetes-logs",
+		},
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           FluentBitAddonName,
+				CPURequests:    "50m",
+				MemoryRequests: "64Mi",
+				CPULimits:      "200m",
+				MemoryLimits:   "256Mi",
+				Image:          "fluent/fluent-bit:1.8.12",
+			},
+			{
+				Name:           FluentBitWindowsContainerName,
+				CPURequests:    "50m",
+				MemoryRequests: "64Mi",
+				CPULimits:      "200m",
+				MemoryLimits:   "256Mi",
+				Image:          "fluent/fluent-bit:windows-2019-1.8.12",
+			},
+		},
+	}
+
+	defaultNodeProblemDetectorAddonsConfig := KubernetesAddon{
+		Name:    NodeProblemDetectorAddonName,
+		Enabled: to.BoolPtr(DefaultNodeProblemDetectorAddonEnabled),
+		Config: map[string]string{
+			"systemLogMonitors":    DefaultNodeProblemDetectorSystemLogMonitors,
+			"customPluginMonitors": "",
+		},
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           NodeProblemDetectorAddonName,
+				CPURequests:    "20m",
+				MemoryRequests: "20Mi",
+				CPULimits:      "200m",
+				MemoryLimits:   "100Mi",
+				Image:          specConfig.KubernetesImageBase + "node-problem-detector:v0.8.1",
+			},
+		},
+	}
+
+	defaultCSISecretsStoreAddonsConfig := KubernetesAddon{
+		Name:    CSISecretsStoreAddonName,
+		Enabled: to.BoolPtr(DefaultCSISecretsStoreAddonEnabled),
+		Config: map[string]string{
+			"enableSecretRotation": "false",
+			"rotationPollInterval": DefaultCSISecretsStoreRotationPollInterval,
+		},
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           CSISecretsStoreDriverContainerName,
+				CPURequests:    "50m",
+				MemoryRequests: "100Mi",
+				CPULimits:      "200m",
+				MemoryLimits:   "200Mi",
+				Image:          "mcr.microsoft.com/oss/kubernetes-csi/secrets-store/driver:v0.0.19",
+			},
+			{
+				Name:           "node-driver-registrar",
+				CPURequests:    "10m",
+				MemoryRequests: "20Mi",
+				CPULimits:      "100m",
+				MemoryLimits:   "100Mi",
+				Image:          "mcr.microsoft.com/oss/kubernetes-csi/csi-node-driver-registrar:v2.1.0",
+			},
+			{
+				Name:           "liveness-probe",
+				CPURequests:    "10m",
+				MemoryRequests: "20Mi",
+				CPULimits:      "100m",
+				MemoryLimits:   "100Mi",
+				Image:          "mcr.microsoft.com/oss/kubernetes-csi/livenessprobe:v2.2.0",
+			},
+			{
+				Name:           CSISecretsStoreProviderAzureContainerName,
+				CPURequests:    "50m",
+				MemoryRequests: "100Mi",
+				CPULimits:      "200m",
+				MemoryLimits:   "100Mi",
+				Image:          "mcr.microsoft.com/oss/azure/secrets-store/provider-azure:0.0.12",
+			},
+		},
+	}
+
+	defaultKonnectivityAgentAddonsConfig := KubernetesAddon{
+		Name:    KonnectivityAgentAddonName,
+		Enabled: to.BoolPtr(o.KubernetesConfig.IsKonnectivityEnabled()),
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           KonnectivityAgentAddonName,
+				CPURequests:    "20m",
+				MemoryRequests: "30Mi",
+				CPULimits:      "100m",
+				MemoryLimits:   "100Mi",
+				Image:          "k8s.gcr.io/kas-network-proxy/proxy-agent:v0.0.12",
+			},
+		},
+	}
+
+	// The cilium addon keeps kube-proxy unless its kube-proxy-replacement is strict
+	defaultCiliumAddonsConfig := KubernetesAddon{
+		Name:    CiliumAddonName,
+		Enabled: to.BoolPtr(o.KubernetesConfig.NetworkPlugin == NetworkPluginCilium),
+		Config: map[string]string{
+			"kube-proxy-replacement": CiliumKubeProxyReplacementDisabled,
+		},
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           "cilium-agent",
+				CPURequests:    "100m",
+				MemoryRequests: "128Mi",
+				Image:          "quay.io/cilium/cilium:v1.8.4",
+			},
+			{
+				Name:           "cilium-operator",
+				CPURequests:    "50m",
+				MemoryRequests: "64Mi",
+				Image:          "quay.io/cilium/operator-generic:v1.8.4",
+			},
+		},
+	}
+
+	defaultAntreaAddonsConfig := KubernetesAddon{
+		Name:    AntreaAddonName,
+		Enabled: to.BoolPtr(o.KubernetesConfig.NetworkPlugin == NetworkPluginAntrea),
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:        "antrea-agent",
+				CPURequests: "200m",
+				Image:       "projects.registry.vmware.com/antrea/antrea-ubuntu:v0.11.1",
+			},
+			{
+				Name:        "antrea-ovs",
+				CPURequests: "200m",
+				Image:       "projects.registry.vmware.com/antrea/antrea-ubuntu:v0.11.1",
+			},
+			{
+				Name:        "antrea-controller",
+				CPURequests: "200m",
+				Image:       "projects.registry.vmware.com/antrea/antrea-ubuntu:v0.11.1",
+			},
+		},
 	}
 
 	defaultAddons := []KubernetesAddon{
@@ -363,9 +671,24 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 		defaultIPMasqAgentAddonsConfig,
 		defaultDNSAutoScalerAddonsConfig,
 		defaultsCalicoDaemonSetAddonsConfig,
+		defaultCiliumAddonsConfig,
+		defaultAntreaAddonsConfig,
 		defaultsAADPodIdentityAddonsConfig,
 		defaultAppGwAddonsConfig,
-	}
+		defaultNginxIngressAddonsConfig,
+		defaultAzurePolicyAddonsConfig,
+		defaultKEDAAddonsConfig,
+		defaultOpenServiceMeshAddonsConfig,
+		defaultRegistryCacheAddonsConfig,
+		defaultAzureWorkloadIdentityAddonsConfig,
+		defaultFluentBitAddonsConfig,
+		defaultNodeProblemDetectorAddonsConfig,
+		defaultCSISecretsStoreAddonsConfig,
+		defaultKonnectivityAgentAddonsConfig,
+	}
+	// Size the default resources of the addons for the cluster
+	cs.Properties.applySizingProfile(defaultAddons)
+
 	// Add default addons specification, if no user-provided spec exists
 	if o.KubernetesConfig.Addons == nil {
 		o.KubernetesConfig.Addons = defaultAddons
@@ -389,6 +712,11 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 		}
 	}
 
+	// The cluster-autoscaler addon scales the pools of its config, each of them within its own node bounds
+	if i := getAddonsIndexByName(o.KubernetesConfig.Addons, ClusterAutoscalerAddonName); i > -1 && to.Bool(o.KubernetesConfig.Addons[i].Enabled) {
+		cs.setClusterAutoscalerPoolsConfig(&o.KubernetesConfig.Addons[i])
+	}
+
 	// Specific back-compat business logic for calico addon
 	// Ensure addon is set to Enabled w/ proper containers config no matter what if NetworkPolicy == calico
 	i := getAddonsIndexByName(o.KubernetesConfig.Addons, CalicoAddonName)
@@ -401,6 +729,25 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 	}
 }
 
+// setClusterAutoscalerPoolsConfig sets the pools the cluster-autoscaler addon scales: the first agent pool when it has
+// none, for the clusters configured before the addon had pools, and the node bounds of the addon for the pools without
+// their own
+func (cs *ContainerService) setClusterAutoscalerPoolsConfig(addon *KubernetesAddon) {
+	if len(addon.Pools) == 0 && len(cs.Properties.AgentPoolProfiles) > 0 {
+		addon.Pools = []AddonNodePoolsConfig{{Name: cs.Properties.AgentPoolProfiles[0].Name}}
+	}
+	for i := range addon.Pools {
+		if addon.Pools[i].Config == nil {
+			addon.Pools[i].Config = map[string]string{}
+		}
+		for _, key := range []string{"min-nodes", "max-nodes"} {
+			if addon.Pools[i].Config[key] == "" {
+				addon.Pools[i].Config[key] = addon.Config[key]
+			}
+		}
+	}
+}
+
 func appendAddonIfNotPresent(addons []KubernetesAddon, addon KubernetesAddon) []KubernetesAddon {
 	i := getAddonsIndexByName(addons, addon.Name)
 	if i < 0 {
@@ -435,6 +782,10 @@ func assignDefaultAddonVals(addon, defaults KubernetesAddon, isUpdate bool) Kube
 			addon.Containers = append(addon.Containers, defaults.Containers[i])
 		} else {
 			if addon.Containers[c].Image == "" || isUpdate {
+				// the digest pins the image it was set for, not the image an upgrade moves the container to
+				if addon.Containers[c].Image != "" && addon.Containers[c].Image != defaults.Containers[i].Image {
+					addon.Containers[c].ImageDigest = ""
+				}
 				addon.Containers[c].Image = defaults.Containers[i].Image
 			}
 			if addon.Containers[c].CPURequests == "" {
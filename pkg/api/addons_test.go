@@ -6,6 +6,7 @@ package api
 import (
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/Azure/go-autorest/autorest/azure"
@@ -175,6 +176,73 @@ func TestDisabledAddons(t *testing.T) {
 
 }
 
+func TestAssignDefaultAddonValsImageDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	defaultAddon := KubernetesAddon{
+		Name:    "mockAddon",
+		Enabled: to.BoolPtr(true),
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:  "mockAddon",
+				Image: "mockImage:v2",
+			},
+		},
+	}
+
+	cases := []struct {
+		name           string
+		container      KubernetesContainerSpec
+		isUpdate       bool
+		expectedImage  string
+		expectedDigest string
+	}{
+		{
+			name:           "digest of the default image",
+			container:      KubernetesContainerSpec{Name: "mockAddon", ImageDigest: digest},
+			expectedImage:  "mockImage:v2",
+			expectedDigest: digest,
+		},
+		{
+			name:           "digest of a user-provided image",
+			container:      KubernetesContainerSpec{Name: "mockAddon", Image: "myImage:v1", ImageDigest: digest},
+			expectedImage:  "myImage:v1",
+			expectedDigest: digest,
+		},
+		{
+			name:           "upgrade to the same image",
+			container:      KubernetesContainerSpec{Name: "mockAddon", Image: "mockImage:v2", ImageDigest: digest},
+			isUpdate:       true,
+			expectedImage:  "mockImage:v2",
+			expectedDigest: digest,
+		},
+		{
+			name:          "upgrade to another image",
+			container:     KubernetesContainerSpec{Name: "mockAddon", Image: "mockImage:v1", ImageDigest: digest},
+			isUpdate:      true,
+			expectedImage: "mockImage:v2",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			addon := KubernetesAddon{
+				Name:       "mockAddon",
+				Enabled:    to.BoolPtr(true),
+				Containers: []KubernetesContainerSpec{c.container},
+			}
+			result := assignDefaultAddonVals(addon, defaultAddon, c.isUpdate)
+			if result.Containers[0].Image != c.expectedImage {
+				t.Fatalf("expected image %s, instead got %s", c.expectedImage, result.Containers[0].Image)
+			}
+			if result.Containers[0].ImageDigest != c.expectedDigest {
+				t.Fatalf("expected image digest %q, instead got %q", c.expectedDigest, result.Containers[0].ImageDigest)
+			}
+		})
+	}
+}
+
 func TestSetAddonsConfig(t *testing.T) {
 	specConfig := AzureCloudSpecEnvMap["AzurePublicCloud"].KubernetesSpecConfig
 	azureStackCloudSpec := AzureEnvironmentSpecConfig{
@@ -308,8 +376,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -344,8 +414,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  AzureCNINetworkMonitoringAddonName,
-							Image: specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							Name:           AzureCNINetworkMonitoringAddonName,
+							Image:          specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							CPURequests:    "30m",
+							MemoryRequests: "25Mi",
 						},
 					},
 				},
@@ -489,8 +561,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -525,8 +599,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  AzureCNINetworkMonitoringAddonName,
-							Image: specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							Name:           AzureCNINetworkMonitoringAddonName,
+							Image:          specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							CPURequests:    "30m",
+							MemoryRequests: "25Mi",
 						},
 					},
 				},
@@ -673,8 +749,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -709,8 +787,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  AzureCNINetworkMonitoringAddonName,
-							Image: specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							Name:           AzureCNINetworkMonitoringAddonName,
+							Image:          specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							CPURequests:    "30m",
+							MemoryRequests: "25Mi",
 						},
 					},
 				},
@@ -856,8 +936,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -892,8 +974,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  AzureCNINetworkMonitoringAddonName,
-							Image: specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							Name:           AzureCNINetworkMonitoringAddonName,
+							Image:          specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							CPURequests:    "30m",
+							MemoryRequests: "25Mi",
 						},
 					},
 				},
@@ -1034,8 +1118,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -1070,8 +1156,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  AzureCNINetworkMonitoringAddonName,
-							Image: specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							Name:           AzureCNINetworkMonitoringAddonName,
+							Image:          specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							CPURequests:    "30m",
+							MemoryRequests: "25Mi",
 						},
 					},
 				},
@@ -1212,8 +1300,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -1248,8 +1338,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  AzureCNINetworkMonitoringAddonName,
-							Image: specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							Name:           AzureCNINetworkMonitoringAddonName,
+							Image:          specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							CPURequests:    "30m",
+							MemoryRequests: "25Mi",
 						},
 					},
 				},
@@ -1379,8 +1471,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -1425,8 +1519,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  AzureCNINetworkMonitoringAddonName,
-							Image: specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							Name:           AzureCNINetworkMonitoringAddonName,
+							Image:          specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							CPURequests:    "30m",
+							MemoryRequests: "25Mi",
 						},
 					},
 				},
@@ -1557,8 +1653,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -1609,8 +1707,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  AzureCNINetworkMonitoringAddonName,
-							Image: specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							Name:           AzureCNINetworkMonitoringAddonName,
+							Image:          specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							CPURequests:    "30m",
+							MemoryRequests: "25Mi",
 						},
 					},
 				},
@@ -1736,8 +1836,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -1772,8 +1874,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  AzureCNINetworkMonitoringAddonName,
-							Image: specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							Name:           AzureCNINetworkMonitoringAddonName,
+							Image:          specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							CPURequests:    "30m",
+							MemoryRequests: "25Mi",
 						},
 					},
 				},
@@ -1782,12 +1886,16 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  AzureNetworkPolicyAddonName,
-							Image: "mcr.microsoft.com/containernetworking/azure-npm:v1.0.25",
+							Name:           AzureNetworkPolicyAddonName,
+							Image:          "mcr.microsoft.com/containernetworking/azure-npm:v1.0.25",
+							CPURequests:    "250m",
+							MemoryRequests: "300Mi",
 						},
 						{
-							Name:  AzureVnetTelemetryAddonName,
-							Image: "mcr.microsoft.com/containernetworking/azure-vnet-telemetry:v1.0.25",
+							Name:           AzureVnetTelemetryAddonName,
+							Image:          "mcr.microsoft.com/containernetworking/azure-vnet-telemetry:v1.0.25",
+							CPURequests:    "50m",
+							MemoryRequests: "50Mi",
 						},
 					},
 				},
@@ -1914,8 +2022,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -1950,8 +2060,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  AzureCNINetworkMonitoringAddonName,
-							Image: specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							Name:           AzureCNINetworkMonitoringAddonName,
+							Image:          specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							CPURequests:    "30m",
+							MemoryRequests: "25Mi",
 						},
 					},
 				},
@@ -2085,8 +2197,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -2292,8 +2406,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -2476,8 +2592,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -2512,8 +2630,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  AzureCNINetworkMonitoringAddonName,
-							Image: specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							Name:           AzureCNINetworkMonitoringAddonName,
+							Image:          specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.12.8"][AzureCNINetworkMonitoringAddonName],
+							CPURequests:    "30m",
+							MemoryRequests: "25Mi",
 						},
 					},
 				},
@@ -2656,8 +2776,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.12.8"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -2780,8 +2902,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.13.0"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.13.0"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -2816,8 +2940,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  AzureCNINetworkMonitoringAddonName,
-							Image: specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.13.0"][AzureCNINetworkMonitoringAddonName],
+							Name:           AzureCNINetworkMonitoringAddonName,
+							Image:          specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.13.0"][AzureCNINetworkMonitoringAddonName],
+							CPURequests:    "30m",
+							MemoryRequests: "25Mi",
 						},
 					},
 				},
@@ -2914,8 +3040,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: "KubernetesImageBase" + K8sComponentsByVersionMap["1.14.0"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          "KubernetesImageBase" + K8sComponentsByVersionMap["1.14.0"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -2950,8 +3078,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  AzureCNINetworkMonitoringAddonName,
-							Image: "AzureCNIImageBase" + K8sComponentsByVersionMap["1.14.0"][AzureCNINetworkMonitoringAddonName],
+							Name:           AzureCNINetworkMonitoringAddonName,
+							Image:          "AzureCNIImageBase" + K8sComponentsByVersionMap["1.14.0"][AzureCNINetworkMonitoringAddonName],
+							CPURequests:    "30m",
+							MemoryRequests: "25Mi",
 						},
 					},
 				},
@@ -3044,8 +3174,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  MetricsServerAddonName,
-							Image: specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.13.0"][MetricsServerAddonName],
+							Name:           MetricsServerAddonName,
+							Image:          specConfig.KubernetesImageBase + K8sComponentsByVersionMap["1.13.0"][MetricsServerAddonName],
+							CPURequests:    "50m",
+							MemoryRequests: "100Mi",
 						},
 					},
 				},
@@ -3080,8 +3212,10 @@ func TestSetAddonsConfig(t *testing.T) {
 					Enabled: to.BoolPtr(true),
 					Containers: []KubernetesContainerSpec{
 						{
-							Name:  AzureCNINetworkMonitoringAddonName,
-							Image: specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.13.0"][AzureCNINetworkMonitoringAddonName],
+							Name:           AzureCNINetworkMonitoringAddonName,
+							Image:          specConfig.AzureCNIImageBase + K8sComponentsByVersionMap["1.13.0"][AzureCNINetworkMonitoringAddonName],
+							CPURequests:    "30m",
+							MemoryRequests: "25Mi",
 						},
 					},
 				},
@@ -3171,3 +3305,201 @@ func TestSetAddonsConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestSetAddonsConfigAppGwIngressAADPodIdentity(t *testing.T) {
+	cases := []struct {
+		name                   string
+		addons                 []KubernetesAddon
+		expectedAADPodIdentity bool
+	}{
+		{
+			name:                   "appgw-ingress disabled",
+			expectedAADPodIdentity: false,
+		},
+		{
+			name: "appgw-ingress enabled",
+			addons: []KubernetesAddon{
+				{Name: AppGwIngressAddonName, Enabled: to.BoolPtr(true)},
+			},
+			expectedAADPodIdentity: true,
+		},
+		{
+			name: "appgw-ingress enabled and aad-pod-identity disabled",
+			addons: []KubernetesAddon{
+				{Name: AppGwIngressAddonName, Enabled: to.BoolPtr(true)},
+				{Name: AADPodIdentityAddonName, Enabled: to.BoolPtr(false)},
+			},
+			expectedAADPodIdentity: false,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			cs := &ContainerService{
+				Properties: &Properties{
+					OrchestratorProfile: &OrchestratorProfile{
+						OrchestratorVersion: "1.15.3",
+						KubernetesConfig: &KubernetesConfig{
+							NetworkPlugin: NetworkPluginAzure,
+							Addons:        c.addons,
+						},
+					},
+				},
+			}
+			cs.setAddonsConfig(false)
+			if actual := cs.Properties.OrchestratorProfile.KubernetesConfig.IsAddonEnabled(AADPodIdentityAddonName); actual != c.expectedAADPodIdentity {
+				t.Fatalf("expected aad-pod-identity addon to have Enabled value %t, instead got %t", c.expectedAADPodIdentity, actual)
+			}
+		})
+	}
+}
+
+func TestSetAddonsConfigKEDAVersion(t *testing.T) {
+	cases := []struct {
+		name            string
+		addon           KubernetesAddon
+		isUpdate        bool
+		expectedVersion string
+		expectedImages  map[string]string
+	}{
+		{
+			name:            "default version",
+			addon:           KubernetesAddon{Name: KEDAAddonName, Enabled: to.BoolPtr(true)},
+			expectedVersion: DefaultKEDAVersion,
+			expectedImages: map[string]string{
+				KEDAOperatorContainerName:         "kedacore/keda:" + DefaultKEDAVersion,
+				KEDAMetricsAPIServerContainerName: "kedacore/keda-metrics-adapter:" + DefaultKEDAVersion,
+			},
+		},
+		{
+			name: "user-configured version",
+			addon: KubernetesAddon{
+				Name:    KEDAAddonName,
+				Enabled: to.BoolPtr(true),
+				Config:  map[string]string{"version": "1.2.0"},
+			},
+			expectedVersion: "1.2.0",
+			expectedImages: map[string]string{
+				KEDAOperatorContainerName:         "kedacore/keda:1.2.0",
+				KEDAMetricsAPIServerContainerName: "kedacore/keda-metrics-adapter:1.2.0",
+			},
+		},
+		{
+			name: "user-configured version and image",
+			addon: KubernetesAddon{
+				Name:    KEDAAddonName,
+				Enabled: to.BoolPtr(true),
+				Config:  map[string]string{"version": "1.2.0"},
+				Containers: []KubernetesContainerSpec{
+					{Name: KEDAOperatorContainerName, Image: "myregistry/keda:1.2.0-custom"},
+				},
+			},
+			expectedVersion: "1.2.0",
+			expectedImages: map[string]string{
+				KEDAOperatorContainerName:         "myregistry/keda:1.2.0-custom",
+				KEDAMetricsAPIServerContainerName: "kedacore/keda-metrics-adapter:1.2.0",
+			},
+		},
+		{
+			name: "upgrade keeps the user-configured version",
+			addon: KubernetesAddon{
+				Name:    KEDAAddonName,
+				Enabled: to.BoolPtr(true),
+				Config:  map[string]string{"version": "1.2.0"},
+				Containers: []KubernetesContainerSpec{
+					{Name: KEDAOperatorContainerName, Image: "kedacore/keda:0.9.0"},
+				},
+			},
+			isUpdate:        true,
+			expectedVersion: "1.2.0",
+			expectedImages: map[string]string{
+				KEDAOperatorContainerName:         "kedacore/keda:1.2.0",
+				KEDAMetricsAPIServerContainerName: "kedacore/keda-metrics-adapter:1.2.0",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			cs := &ContainerService{
+				Properties: &Properties{
+					OrchestratorProfile: &OrchestratorProfile{
+						OrchestratorVersion: "1.15.3",
+						KubernetesConfig: &KubernetesConfig{
+							NetworkPlugin: NetworkPluginAzure,
+							Addons:        []KubernetesAddon{c.addon},
+						},
+					},
+				},
+			}
+			cs.setAddonsConfig(c.isUpdate)
+			addon := cs.Properties.OrchestratorProfile.KubernetesConfig.GetAddonByName(KEDAAddonName)
+			if addon.Config["version"] != c.expectedVersion {
+				t.Fatalf("expected keda addon version %s, instead got %s", c.expectedVersion, addon.Config["version"])
+			}
+			for name, image := range c.expectedImages {
+				i := addon.GetAddonContainersIndexByName(name)
+				if i < 0 {
+					t.Fatalf("expected keda addon to have a %s container", name)
+				}
+				if addon.Containers[i].Image != image {
+					t.Fatalf("expected %s container image %s, instead got %s", name, image, addon.Containers[i].Image)
+				}
+			}
+		})
+	}
+}
+
+func TestSetClusterAutoscalerPoolsConfig(t *testing.T) {
+	cases := []struct {
+		name     string
+		pools    []AddonNodePoolsConfig
+		expected []AddonNodePoolsConfig
+	}{
+		{
+			name: "no pools",
+			expected: []AddonNodePoolsConfig{
+				{Name: "pool1", Config: map[string]string{"min-nodes": "1", "max-nodes": "5"}},
+			},
+		},
+		{
+			name: "pools with and without node bounds",
+			pools: []AddonNodePoolsConfig{
+				{Name: "pool1"},
+				{Name: "pool2", Config: map[string]string{"min-nodes": "0", "max-nodes": "20", "priority": "10"}},
+				{Name: "pool3", Config: map[string]string{"max-nodes": "3"}},
+			},
+			expected: []AddonNodePoolsConfig{
+				{Name: "pool1", Config: map[string]string{"min-nodes": "1", "max-nodes": "5"}},
+				{Name: "pool2", Config: map[string]string{"min-nodes": "0", "max-nodes": "20", "priority": "10"}},
+				{Name: "pool3", Config: map[string]string{"min-nodes": "1", "max-nodes": "3"}},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			cs := &ContainerService{
+				Properties: &Properties{
+					AgentPoolProfiles: []*AgentPoolProfile{{Name: "pool1"}, {Name: "pool2"}, {Name: "pool3"}},
+				},
+			}
+			addon := KubernetesAddon{
+				Name:    ClusterAutoscalerAddonName,
+				Enabled: to.BoolPtr(true),
+				Config:  map[string]string{"min-nodes": "1", "max-nodes": "5"},
+				Pools:   c.pools,
+			}
+			cs.setClusterAutoscalerPoolsConfig(&addon)
+			if !reflect.DeepEqual(addon.Pools, c.expected) {
+				t.Errorf("expected pools %v, got %v", c.expected, addon.Pools)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"fmt"
+
+	"github.com/Azure/aks-engine/pkg/api/persona"
+	"github.com/pkg/errors"
+)
+
+// CapabilityError is a profile of the cluster the capabilities of its location do not support
+type CapabilityError struct {
+	// Path is the JSON path of the profile in the api model, e.g. properties.agentPoolProfiles[0]
+	Path string
+	Err  error
+}
+
+// ValidateCapabilities validates the VM sizes and availability zones of the cluster against the capabilities
+// p knows for the cluster location. Profiles are not validated against capabilities p does not know.
+func (cs *ContainerService) ValidateCapabilities(p persona.Persona) error {
+	capabilityErrors, err := cs.CapabilityErrors(p)
+	if err != nil {
+		return err
+	}
+	if len(capabilityErrors) > 0 {
+		return capabilityErrors[0].Err
+	}
+	return nil
+}
+
+// CapabilityErrors returns an error for every profile of the cluster the capabilities p knows for the cluster location
+// do not support, in the order of the profiles in the api model
+func (cs *ContainerService) CapabilityErrors(p persona.Persona) ([]CapabilityError, error) {
+	capabilities, err := p.Capabilities(cs.Location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "looking up the capabilities of location %s", cs.Location)
+	}
+	if capabilities == nil {
+		return nil, nil
+	}
+
+	var capabilityErrors []CapabilityError
+	if m := cs.Properties.MasterProfile; m != nil {
+		err := validateProfileCapabilities(capabilities, cs.Location, "masterProfile", m.VMSize, m.AvailabilityZones)
+		if err == nil && m.HasUltraSSD() {
+			err = validateUltraSSDCapabilities(capabilities, cs.Location, "masterProfile", m.AvailabilityZones)
+		}
+		if err != nil {
+			capabilityErrors = append(capabilityErrors, CapabilityError{Path: "properties.masterProfile", Err: err})
+		}
+	}
+	for i, a := range cs.Properties.AgentPoolProfiles {
+		err := validateProfileCapabilities(capabilities, cs.Location, "agentPoolProfile "+a.Name, a.VMSize, a.AvailabilityZones)
+		if err == nil && a.IsEphemeral() {
+			err = validateEphemeralOSDiskCapabilities(capabilities, "agentPoolProfile "+a.Name, a.VMSize, a.OSDiskSizeGB)
+		}
+		if err == nil && a.HasUltraSSD() {
+			err = validateUltraSSDCapabilities(capabilities, cs.Location, "agentPoolProfile "+a.Name, a.AvailabilityZones)
+		}
+		if err != nil {
+			capabilityErrors = append(capabilityErrors, CapabilityError{Path: fmt.Sprintf("properties.agentPoolProfiles[%d]", i), Err: err})
+		}
+	}
+	return capabilityErrors, nil
+}
+
+func validateProfileCapabilities(capabilities *persona.LocationCapabilities, location, profile, vmSize string, zones []string) error {
+	if vmSize == "" {
+		return nil
+	}
+	if len(capabilities.VMSizes) > 0 && !capabilities.HasVMSize(vmSize) {
+		return errors.Errorf("%s VM size %s is not available in location %s", profile, vmSize, location)
+	}
+	available, ok := capabilities.ZonesOf(vmSize)
+	if !ok {
+		return nil
+	}
+	for _, zone := range zones {
+		found := false
+		for _, z := range available {
+			if z == zone {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("%s VM size %s is not available in availability zone %s of location %s, available zones are %v", profile, vmSize, zone, location, available)
+		}
+	}
+	return nil
+}
+
+// validateEphemeralOSDiskCapabilities validates that the cache of vmSize can hold an ephemeral OS disk of osDiskSizeGB,
+// the size of the OS image if 0
+func validateEphemeralOSDiskCapabilities(capabilities *persona.LocationCapabilities, profile, vmSize string, osDiskSizeGB int) error {
+	cacheGB, ok := capabilities.EphemeralOSDiskCacheGBOf(vmSize)
+	if !ok || vmSize == "" {
+		return nil
+	}
+	if cacheGB == 0 {
+		return errors.Errorf("%s VM size %s does not support ephemeral OS disks", profile, vmSize)
+	}
+	if osDiskSizeGB == 0 {
+		// the OS images of the nodes have 30 GiB OS disks
+		osDiskSizeGB = VHDDiskSizeAKS
+	}
+	if osDiskSizeGB > cacheGB {
+		return errors.Errorf("%s has an ephemeral OS disk of %d GiB, larger than the %d GiB cache of VM size %s", profile, osDiskSizeGB, cacheGB, vmSize)
+	}
+	return nil
+}
+
+// validateUltraSSDCapabilities validates that Ultra SSD data disks are offered in the availability zones of a profile
+func validateUltraSSDCapabilities(capabilities *persona.LocationCapabilities, location, profile string, zones []string) error {
+	available, ok := capabilities.GetUltraSSDZones()
+	if !ok {
+		return nil
+	}
+	if len(available) == 0 {
+		return errors.Errorf("%s has %s data disks, which are not offered in location %s", profile, UltraSSDDataDisk, location)
+	}
+	for _, zone := range zones {
+		found := false
+		for _, z := range available {
+			if z == zone {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("%s has %s data disks, which are not offered in availability zone %s of location %s, available zones are %v", profile, UltraSSDDataDisk, zone, location, available)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api/persona"
+)
+
+func TestValidateCapabilities(t *testing.T) {
+	capabilities := &persona.CapabilitiesFile{
+		Locations: map[string]persona.LocationCapabilities{
+			"westus2": {
+				VMSizes:                []string{"Standard_D2_v2", "Standard_D2_v3"},
+				Zones:                  map[string][]string{"Standard_D2_v3": {"1", "2"}},
+				EphemeralOSDiskCacheGB: map[string]int{"Standard_D2_v2": 0, "Standard_D2_v3": 50},
+				UltraSSDZones:          &[]string{"1"},
+			},
+			"westus": {
+				VMSizes:       []string{"Standard_D2_v2", "Standard_D2_v3"},
+				Zones:         map[string][]string{"Standard_D2_v3": {"1", "2"}},
+				UltraSSDZones: &[]string{},
+			},
+		},
+	}
+	cases := []struct {
+		name        string
+		location    string
+		setup       func(cs *ContainerService)
+		expectedErr string
+	}{
+		{
+			name:     "available VM sizes",
+			location: "westus2",
+		},
+		{
+			name:     "location without capabilities",
+			location: "eastus",
+			setup: func(cs *ContainerService) {
+				cs.Properties.AgentPoolProfiles[0].VMSize = "Standard_Unknown"
+			},
+		},
+		{
+			name:     "unavailable VM size",
+			location: "westus2",
+			setup: func(cs *ContainerService) {
+				cs.Properties.AgentPoolProfiles[0].VMSize = "Standard_Unknown"
+			},
+			expectedErr: "agentPoolProfile agentpool1 VM size Standard_Unknown is not available in location westus2",
+		},
+		{
+			name:     "available zones",
+			location: "westus2",
+			setup: func(cs *ContainerService) {
+				cs.Properties.MasterProfile.VMSize = "Standard_D2_v3"
+				cs.Properties.MasterProfile.AvailabilityZones = []string{"1", "2"}
+			},
+		},
+		{
+			name:     "unavailable zone",
+			location: "westus2",
+			setup: func(cs *ContainerService) {
+				cs.Properties.MasterProfile.VMSize = "Standard_D2_v3"
+				cs.Properties.MasterProfile.AvailabilityZones = []string{"3"}
+			},
+			expectedErr: "masterProfile VM size Standard_D2_v3 is not available in availability zone 3 of location westus2, available zones are [1 2]",
+		},
+		{
+			name:     "ephemeral OS disk of the image size",
+			location: "westus2",
+			setup: func(cs *ContainerService) {
+				cs.Properties.AgentPoolProfiles[0].VMSize = "Standard_D2_v3"
+				cs.Properties.AgentPoolProfiles[0].OSDiskType = EphemeralOSDisk
+			},
+		},
+		{
+			name:     "ephemeral OS disk larger than the cache",
+			location: "westus2",
+			setup: func(cs *ContainerService) {
+				cs.Properties.AgentPoolProfiles[0].VMSize = "Standard_D2_v3"
+				cs.Properties.AgentPoolProfiles[0].OSDiskType = EphemeralOSDisk
+				cs.Properties.AgentPoolProfiles[0].OSDiskSizeGB = 100
+			},
+			expectedErr: "agentPoolProfile agentpool1 has an ephemeral OS disk of 100 GiB, larger than the 50 GiB cache of VM size Standard_D2_v3",
+		},
+		{
+			name:     "ephemeral OS disk on a VM size without cache",
+			location: "westus2",
+			setup: func(cs *ContainerService) {
+				cs.Properties.AgentPoolProfiles[0].StorageProfile = Ephemeral
+			},
+			expectedErr: "agentPoolProfile agentpool1 VM size Standard_D2_v2 does not support ephemeral OS disks",
+		},
+		{
+			name:     "Ultra SSD data disks in an available zone",
+			location: "westus2",
+			setup: func(cs *ContainerService) {
+				cs.Properties.AgentPoolProfiles[0].VMSize = "Standard_D2_v3"
+				cs.Properties.AgentPoolProfiles[0].AvailabilityZones = []string{"1"}
+				cs.Properties.AgentPoolProfiles[0].DiskSizesGB = []int{128}
+				cs.Properties.AgentPoolProfiles[0].DataDiskStorageAccountType = UltraSSDDataDisk
+			},
+		},
+		{
+			name:     "Ultra SSD data disks in an unavailable zone",
+			location: "westus2",
+			setup: func(cs *ContainerService) {
+				cs.Properties.AgentPoolProfiles[0].VMSize = "Standard_D2_v3"
+				cs.Properties.AgentPoolProfiles[0].AvailabilityZones = []string{"2"}
+				cs.Properties.AgentPoolProfiles[0].DiskSizesGB = []int{128}
+				cs.Properties.AgentPoolProfiles[0].DataDiskStorageAccountType = UltraSSDDataDisk
+			},
+			expectedErr: "agentPoolProfile agentpool1 has UltraSSD_LRS data disks, which are not offered in availability zone 2 of location westus2, available zones are [1]",
+		},
+		{
+			name:     "Ultra SSD data disks in a location without them",
+			location: "westus",
+			setup: func(cs *ContainerService) {
+				cs.Properties.MasterProfile.VMSize = "Standard_D2_v3"
+				cs.Properties.MasterProfile.AvailabilityZones = []string{"1"}
+				cs.Properties.MasterProfile.DataDiskStorageAccountType = UltraSSDDataDisk
+			},
+			expectedErr: "masterProfile has UltraSSD_LRS data disks, which are not offered in location westus",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			cs := CreateMockContainerService("testcluster", "1.13.5", 1, 1, false)
+			cs.Location = c.location
+			cs.Properties.MasterProfile.VMSize = "Standard_D2_v2"
+			cs.Properties.AgentPoolProfiles[0].VMSize = "Standard_D2_v2"
+			if c.setup != nil {
+				c.setup(cs)
+			}
+			err := cs.ValidateCapabilities(persona.NewOffline(capabilities))
+			if c.expectedErr == "" && err != nil {
+				t.Errorf("expected no error, got %s", err)
+			}
+			if c.expectedErr != "" && (err == nil || err.Error() != c.expectedErr) {
+				t.Errorf("expected error %q, got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestCapabilityErrors(t *testing.T) {
+	capabilities := &persona.CapabilitiesFile{
+		Locations: map[string]persona.LocationCapabilities{
+			"westus2": {
+				VMSizes: []string{"Standard_D2_v2", "Standard_D2_v3"},
+				Zones:   map[string][]string{"Standard_D2_v3": {"1", "2"}},
+			},
+		},
+	}
+	cs := CreateMockContainerService("testcluster", "1.13.5", 1, 2, false)
+	cs.Location = "westus2"
+	cs.Properties.MasterProfile.VMSize = "Standard_D2_v3"
+	cs.Properties.MasterProfile.AvailabilityZones = []string{"3"}
+	cs.Properties.AgentPoolProfiles[0].VMSize = "Standard_D2_v2"
+	cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &AgentPoolProfile{Name: "agentpool2", VMSize: "Standard_Unknown"})
+
+	capabilityErrors, err := cs.CapabilityErrors(persona.NewOffline(capabilities))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(capabilityErrors) != 2 {
+		t.Fatalf("expected an error for the master profile and the second agent pool, got %v", capabilityErrors)
+	}
+	if capabilityErrors[0].Path != "properties.masterProfile" {
+		t.Errorf("expected the path of the first error to be properties.masterProfile, got %s", capabilityErrors[0].Path)
+	}
+	expected := "agentPoolProfile agentpool2 VM size Standard_Unknown is not available in location westus2"
+	if capabilityErrors[1].Path != "properties.agentPoolProfiles[1]" || capabilityErrors[1].Err.Error() != expected {
+		t.Errorf("expected error %q at properties.agentPoolProfiles[1], got %q at %s", expected, capabilityErrors[1].Err, capabilityErrors[1].Path)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Azure/aks-engine/pkg/api/persona"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultAzureStackKubernetesImageBase is the registry the Azure Stack hyperkube images are mirrored to
+	DefaultAzureStackKubernetesImageBase = "mcr.microsoft.com/k8s/azurestack/core/"
+	// azureStackHyperkubeSuffix is appended to the tag of the Azure Stack hyperkube images
+	azureStackHyperkubeSuffix = "-azs"
+	// maxConcurrentImageChecks bounds the registry requests made at the same time
+	maxConcurrentImageChecks = 8
+)
+
+// KubernetesVersionAvailability describes what a cloud environment offers to deploy a Kubernetes version
+type KubernetesVersionAvailability struct {
+	OrchestratorVersion     string              `json:"orchestratorVersion"`
+	HyperkubeImage          string              `json:"hyperkubeImage"`
+	HyperkubeImageAvailable bool                `json:"hyperkubeImageAvailable"`
+	WindowsBinariesURL      string              `json:"windowsBinariesURL,omitempty"`
+	VHD                     *AzureOSImageConfig `json:"vhd,omitempty"`
+	VHDAvailable            bool                `json:"vhdAvailable"`
+}
+
+// GetCloudSpecConfigByName returns the cloud spec of a cloud environment, the Azure Stack cloud spec being the Azure
+// public cloud spec with its images pulled from the Azure Stack registry
+func GetCloudSpecConfigByName(cloudName string) (AzureEnvironmentSpecConfig, error) {
+	if strings.EqualFold(cloudName, AzureStackCloud) {
+		spec := AzureCloudSpecEnvMap[AzurePublicCloud]
+		spec.CloudName = AzureStackCloud
+		spec.KubernetesSpecConfig.KubernetesImageBase = DefaultAzureStackKubernetesImageBase
+		return spec, nil
+	}
+	for name, spec := range AzureCloudSpecEnvMap {
+		if strings.EqualFold(name, cloudName) {
+			return spec, nil
+		}
+	}
+	return AzureEnvironmentSpecConfig{}, errors.Errorf("unknown cloud environment %s, expected one of %s", cloudName, strings.Join(GetCloudNames(), ", "))
+}
+
+// GetCloudNames returns the names of the cloud environments Kubernetes versions can be looked up in
+func GetCloudNames() []string {
+	return []string{AzurePublicCloud, AzureChinaCloud, AzureGermanCloud, AzureUSGovernmentCloud, AzureStackCloud}
+}
+
+// GetKubernetesVersionsAvailability looks up the hyperkube image of each Kubernetes version in the registry of the cloud
+// environment, or in kubernetesImageBase if it is not empty, and returns the availability of each version. The
+// registry is queried with the current persona, so that offline mode answers from the capabilities file.
+func GetKubernetesVersionsAvailability(cloudName, kubernetesImageBase string, versions []string, hasWindows bool) (map[string]*KubernetesVersionAvailability, error) {
+	spec, err := GetCloudSpecConfigByName(cloudName)
+	if err != nil {
+		return nil, err
+	}
+	if kubernetesImageBase == "" {
+		kubernetesImageBase = spec.KubernetesSpecConfig.KubernetesImageBase
+	}
+
+	vhd, vhdAvailable := spec.OSImageConfig[AKSUbuntu1604]
+	// the clouds without the AKS VHD fall back to the Ubuntu marketplace image, installing the components at provisioning
+	vhdAvailable = vhdAvailable && vhd.ImagePublisher == AKSUbuntu1604OSImageConfig.ImagePublisher
+
+	var (
+		wg           sync.WaitGroup
+		semaphore    = make(chan struct{}, maxConcurrentImageChecks)
+		availability = map[string]*KubernetesVersionAvailability{}
+	)
+	for _, version := range versions {
+		components, ok := K8sComponentsByVersionMap[version]
+		if !ok {
+			wg.Wait()
+			return nil, errors.Errorf("Kubernetes version %s is not supported", version)
+		}
+		a := &KubernetesVersionAvailability{
+			OrchestratorVersion: version,
+			HyperkubeImage:      kubernetesImageBase + components["hyperkube"],
+			VHDAvailable:        vhdAvailable,
+		}
+		if spec.CloudName == AzureStackCloud {
+			a.HyperkubeImage += azureStackHyperkubeSuffix
+		}
+		if vhdAvailable {
+			vhd := vhd
+			a.VHD = &vhd
+		}
+		if hasWindows {
+			a.WindowsBinariesURL = spec.KubernetesSpecConfig.KubeBinariesSASURLBase + components["windowszip"]
+		}
+		availability[version] = a
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			var err error
+			if a.HyperkubeImageAvailable, err = isImageAvailable(a.HyperkubeImage); err != nil {
+				log.Debugf("hyperkube image %s is not available: %s", a.HyperkubeImage, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return availability, nil
+}
+
+// isImageAvailable returns true if the manifest of the image can be retrieved anonymously from its registry
+func isImageAvailable(image string) (bool, error) {
+	url, err := imageManifestURL(image)
+	if err != nil {
+		return false, err
+	}
+	if _, err = persona.Current().Get(url); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// imageManifestURL returns the Docker registry API URL of the manifest of an image, e.g.
+// https://k8s.gcr.io/v2/hyperkube-amd64/manifests/v1.15.3 for k8s.gcr.io/hyperkube-amd64:v1.15.3
+func imageManifestURL(image string) (string, error) {
+	i := strings.Index(image, "/")
+	j := strings.LastIndex(image, ":")
+	if i <= 0 || j < i {
+		return "", errors.Errorf("image %s must be of the form registry/repository:tag", image)
+	}
+	return fmt.Sprintf("https://%s/v2/%s/manifests/%s", image[:i], image[i+1:j], image[j+1:]), nil
+}
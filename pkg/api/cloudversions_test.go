@@ -0,0 +1,91 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api/persona"
+)
+
+func TestGetCloudSpecConfigByName(t *testing.T) {
+	for _, name := range GetCloudNames() {
+		spec, err := GetCloudSpecConfigByName(name)
+		if err != nil {
+			t.Fatalf("expected cloud %s to have a cloud spec, but got error %s", name, err)
+		}
+		if spec.CloudName != name {
+			t.Errorf("expected the cloud spec of %s, but got the cloud spec of %s", name, spec.CloudName)
+		}
+	}
+
+	spec, err := GetCloudSpecConfigByName("azurestackcloud")
+	if err != nil || spec.KubernetesSpecConfig.KubernetesImageBase != DefaultAzureStackKubernetesImageBase {
+		t.Errorf("expected the Azure Stack cloud spec to pull the images from %s, got %s", DefaultAzureStackKubernetesImageBase, spec.KubernetesSpecConfig.KubernetesImageBase)
+	}
+	if AzureCloudSpec.KubernetesSpecConfig.KubernetesImageBase == DefaultAzureStackKubernetesImageBase {
+		t.Errorf("expected the Azure Stack cloud spec not to modify the Azure public cloud spec")
+	}
+
+	if _, err = GetCloudSpecConfigByName("AzureMoonCloud"); err == nil {
+		t.Errorf("expected an error for an unknown cloud")
+	}
+}
+
+func TestGetKubernetesVersionsAvailability(t *testing.T) {
+	defer persona.Set(persona.Current())
+	persona.Set(persona.NewOffline(&persona.CapabilitiesFile{
+		Documents: map[string]json.RawMessage{
+			"https://k8s.gcr.io/v2/hyperkube-amd64/manifests/v1.15.3":                                json.RawMessage(`{}`),
+			"https://mcr.microsoft.com/v2/k8s/azurestack/core/hyperkube-amd64/manifests/v1.15.3-azs": json.RawMessage(`{}`),
+		},
+	}))
+
+	cases := []struct {
+		cloudName         string
+		imageBase         string
+		expectedImage     string
+		expectedAvailable bool
+		expectedVHD       bool
+	}{
+		{AzurePublicCloud, "", "k8s.gcr.io/hyperkube-amd64:v1.15.3", true, true},
+		{AzureChinaCloud, "", "gcr.azk8s.cn/google_containers/hyperkube-amd64:v1.15.3", false, true},
+		{AzureGermanCloud, "", "k8s.gcr.io/hyperkube-amd64:v1.15.3", true, false},
+		{AzureStackCloud, "", "mcr.microsoft.com/k8s/azurestack/core/hyperkube-amd64:v1.15.3-azs", true, true},
+		{AzurePublicCloud, "myregistry.azurecr.io/", "myregistry.azurecr.io/hyperkube-amd64:v1.15.3", false, true},
+	}
+	for _, c := range cases {
+		availability, err := GetKubernetesVersionsAvailability(c.cloudName, c.imageBase, []string{"1.15.3"}, true)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", c.cloudName, err)
+		}
+		a := availability["1.15.3"]
+		if a.HyperkubeImage != c.expectedImage || a.HyperkubeImageAvailable != c.expectedAvailable {
+			t.Errorf("%s: expected image %s available %t, got image %s available %t", c.cloudName, c.expectedImage, c.expectedAvailable, a.HyperkubeImage, a.HyperkubeImageAvailable)
+		}
+		if a.VHDAvailable != c.expectedVHD || (a.VHD != nil) != c.expectedVHD {
+			t.Errorf("%s: expected VHD available %t, got %t", c.cloudName, c.expectedVHD, a.VHDAvailable)
+		}
+		if a.WindowsBinariesURL == "" {
+			t.Errorf("%s: expected the URL of the Windows binaries", c.cloudName)
+		}
+	}
+
+	if _, err := GetKubernetesVersionsAvailability(AzurePublicCloud, "", []string{"1.2.3"}, false); err == nil {
+		t.Errorf("expected an error for an unsupported version")
+	}
+}
+
+func TestImageManifestURL(t *testing.T) {
+	url, err := imageManifestURL("gcr.azk8s.cn/google_containers/hyperkube-amd64:v1.15.3")
+	if err != nil || url != "https://gcr.azk8s.cn/v2/google_containers/hyperkube-amd64/manifests/v1.15.3" {
+		t.Errorf("unexpected manifest URL %s, error %v", url, err)
+	}
+	for _, image := range []string{"hyperkube-amd64:v1.15.3", "k8s.gcr.io/hyperkube-amd64"} {
+		if _, err = imageManifestURL(image); err == nil {
+			t.Errorf("expected an error for image %s", image)
+		}
+	}
+}
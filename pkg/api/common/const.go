@@ -33,6 +33,18 @@ const (
 	MinDiskSizeGB = 1
 	// MaxDiskSizeGB specifies the maximum attached disk size
 	MaxDiskSizeGB = 1023
+	// MinUltraSSDIOPS specifies the minimum IOPS of an Ultra SSD data disk
+	MinUltraSSDIOPS = 100
+	// MaxUltraSSDIOPS specifies the maximum IOPS of an Ultra SSD data disk
+	MaxUltraSSDIOPS = 160000
+	// MaxUltraSSDIOPSPerGB specifies the maximum IOPS of an Ultra SSD data disk per GiB of its size
+	MaxUltraSSDIOPSPerGB = 300
+	// MinUltraSSDMBps specifies the minimum throughput in MB/s of an Ultra SSD data disk
+	MinUltraSSDMBps = 1
+	// MaxUltraSSDMBps specifies the maximum throughput in MB/s of an Ultra SSD data disk
+	MaxUltraSSDMBps = 2000
+	// MinSharedDiskSizeGB specifies the minimum size of a shared data disk, the one of a P15 Premium SSD
+	MinSharedDiskSizeGB = 256
 	// MinIPAddressCount specifies the minimum number of IP addresses per network interface
 	MinIPAddressCount = 1
 	// MaxIPAddressCount specifies the maximum number of IP addresses per network interface
@@ -59,6 +71,35 @@ const (
 	Ephemeral = "Ephemeral"
 )
 
+// OS disk types
+const (
+	// ManagedOSDisk means that the node's os disk is stored as set by the storage profile
+	ManagedOSDisk = "Managed"
+	// EphemeralOSDisk means that the node's os disk is created on the cache of the VM, the storage profile applying
+	// to its attached volumes only
+	EphemeralOSDisk = "Ephemeral"
+)
+
+// data disk storage account types
+const (
+	// StandardHDDDataDisk means that the node's data disks are Standard HDDs
+	StandardHDDDataDisk = "Standard_LRS"
+	// StandardSSDDataDisk means that the node's data disks are Standard SSDs
+	StandardSSDDataDisk = "StandardSSD_LRS"
+	// PremiumSSDDataDisk means that the node's data disks are Premium SSDs, the only ones which may be shared
+	PremiumSSDDataDisk = "Premium_LRS"
+	// UltraSSDDataDisk means that the node's data disks are Ultra SSDs, whose IOPS and throughput may be set
+	UltraSSDDataDisk = "UltraSSD_LRS"
+)
+
+// zone balance strategies
+const (
+	// BestEffortZoneBalance means that the scale set spreads its VMs across its availability zones as evenly as it can
+	BestEffortZoneBalance = "BestEffort"
+	// StrictZoneBalance means that the scale set fails to scale rather than spread its VMs unevenly across its zones
+	StrictZoneBalance = "Strict"
+)
+
 const (
 	// KubernetesDefaultRelease is the default Kubernetes release
 	KubernetesDefaultRelease string = "1.13"
@@ -47,6 +47,12 @@ func HandleValidationErrors(e validator.ValidationErrors) error {
 				return errors.Errorf("AgentPoolProfile Ports must be in the range[%d, %d]", MinPort, MaxPort)
 			case strings.HasSuffix(ns, ".StorageProfile"):
 				return errors.Errorf("Unknown storageProfile '%s'. Specify %s, %s, or %s", err.Value().(string), StorageAccount, ManagedDisks, Ephemeral)
+			case strings.HasSuffix(ns, ".OSDiskType"):
+				return errors.Errorf("Unknown osDiskType '%s'. Specify either %s or %s", err.Value().(string), ManagedOSDisk, EphemeralOSDisk)
+			case strings.HasSuffix(ns, ".DataDiskStorageAccountType"):
+				return errors.Errorf("Unknown dataDiskStorageAccountType '%s'. Specify %s, %s, %s, or %s", err.Value().(string), StandardHDDDataDisk, StandardSSDDataDisk, PremiumSSDDataDisk, UltraSSDDataDisk)
+			case strings.HasSuffix(ns, ".ZoneBalanceStrategy"):
+				return errors.Errorf("Unknown zoneBalanceStrategy '%s'. Specify either %s or %s", err.Value().(string), BestEffortZoneBalance, StrictZoneBalance)
 			case strings.Contains(ns, ".DiskSizesGB"):
 				return errors.Errorf("A maximum of %d disks may be specified, The range of valid disk size values are [%d, %d]", MaxDisks, MinDiskSizeGB, MaxDiskSizeGB)
 			case strings.HasSuffix(ns, ".IPAddressCount"):
@@ -263,6 +269,20 @@ func GetStorageAccountType(sizeName string) (string, error) {
 	return "Standard_LRS", nil
 }
 
+// GetSharedDiskMaxShares returns the number of VMs a shared Premium SSD data disk of diskSizeGB can be attached to
+func GetSharedDiskMaxShares(diskSizeGB int) int {
+	switch {
+	case diskSizeGB <= 512:
+		// P15 and P20
+		return 2
+	case diskSizeGB <= 4096:
+		// P30 to P50
+		return 5
+	default:
+		return 10
+	}
+}
+
 // GetOrderedEscapedKeyValsString returns an ordered string of escaped, quoted key=val
 func GetOrderedEscapedKeyValsString(config map[string]string) string {
 	keys := []string{}
@@ -258,6 +258,22 @@ func TestGetStorageAccountType(t *testing.T) {
 	}
 }
 
+func TestGetSharedDiskMaxShares(t *testing.T) {
+	cases := map[int]int{
+		256:   2,
+		512:   2,
+		1024:  5,
+		4096:  5,
+		8192:  10,
+		32767: 10,
+	}
+	for size, expected := range cases {
+		if actual := GetSharedDiskMaxShares(size); actual != expected {
+			t.Errorf("GetSharedDiskMaxShares(%d) = %d, want %d", size, actual, expected)
+		}
+	}
+}
+
 func TestSliceIntIsNonEmpty(t *testing.T) {
 	cases := []struct {
 		name     string
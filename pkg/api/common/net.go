@@ -4,6 +4,7 @@
 package common
 
 import (
+	"fmt"
 	"net"
 	"regexp"
 
@@ -59,3 +60,31 @@ func GetVNETSubnetIDComponents(vnetSubnetID string) (string, string, string, str
 	}
 	return submatches[1], submatches[2], submatches[3], submatches[4], nil
 }
+
+// NamedCIDR is a CIDR block paired with the name of the configuration property it was read from
+type NamedCIDR struct {
+	Name string
+	CIDR string
+}
+
+// GetCIDROverlaps compares every CIDR in a against every CIDR in b and returns a description of each overlapping pair.
+// Returns an error if any of the provided CIDR strings cannot be parsed.
+func GetCIDROverlaps(a, b []NamedCIDR) ([]string, error) {
+	var overlaps []string
+	for _, x := range a {
+		_, xNet, err := net.ParseCIDR(x.CIDR)
+		if err != nil {
+			return nil, errors.Errorf("%s '%s' is an invalid CIDR", x.Name, x.CIDR)
+		}
+		for _, y := range b {
+			_, yNet, err := net.ParseCIDR(y.CIDR)
+			if err != nil {
+				return nil, errors.Errorf("%s '%s' is an invalid CIDR", y.Name, y.CIDR)
+			}
+			if xNet.Contains(yNet.IP) || yNet.Contains(xNet.IP) {
+				overlaps = append(overlaps, fmt.Sprintf("%s '%s' overlaps with %s '%s'", x.Name, x.CIDR, y.Name, y.CIDR))
+			}
+		}
+	}
+	return overlaps, nil
+}
@@ -92,3 +92,63 @@ func Test_GetVNETSubnetIDComponents(t *testing.T) {
 		}
 	}
 }
+
+func Test_GetCIDROverlaps(t *testing.T) {
+	cases := []struct {
+		name             string
+		a                []NamedCIDR
+		b                []NamedCIDR
+		expectedOverlaps []string
+		expectErr        bool
+	}{
+		{
+			name:             "disjoint",
+			a:                []NamedCIDR{{Name: "ServiceCidr", CIDR: "10.0.0.0/16"}},
+			b:                []NamedCIDR{{Name: "ClusterSubnet", CIDR: "10.244.0.0/16"}},
+			expectedOverlaps: nil,
+		},
+		{
+			name: "b contained in a",
+			a:    []NamedCIDR{{Name: "VnetCidr", CIDR: "10.0.0.0/8"}},
+			b:    []NamedCIDR{{Name: "ServiceCidr", CIDR: "10.0.0.0/16"}, {Name: "DockerBridgeSubnet", CIDR: "172.17.0.1/16"}},
+			expectedOverlaps: []string{
+				"VnetCidr '10.0.0.0/8' overlaps with ServiceCidr '10.0.0.0/16'",
+			},
+		},
+		{
+			name: "a contained in b",
+			a:    []NamedCIDR{{Name: "DockerBridgeSubnet", CIDR: "172.17.0.1/16"}},
+			b:    []NamedCIDR{{Name: "ClusterSubnet", CIDR: "172.16.0.0/12"}},
+			expectedOverlaps: []string{
+				"DockerBridgeSubnet '172.17.0.1/16' overlaps with ClusterSubnet '172.16.0.0/12'",
+			},
+		},
+		{
+			name:      "invalid cidr",
+			a:         []NamedCIDR{{Name: "ServiceCidr", CIDR: "10.0.0.0"}},
+			b:         []NamedCIDR{{Name: "ClusterSubnet", CIDR: "10.244.0.0/16"}},
+			expectErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		overlaps, err := GetCIDROverlaps(c.a, c.b)
+		if c.expectErr {
+			if err == nil {
+				t.Errorf("%s: expected error but got none", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", c.name, err)
+		}
+		if len(overlaps) != len(c.expectedOverlaps) {
+			t.Fatalf("%s: expected overlaps %v but got %v", c.name, c.expectedOverlaps, overlaps)
+		}
+		for i := range overlaps {
+			if overlaps[i] != c.expectedOverlaps[i] {
+				t.Errorf("%s: expected overlap %s but got %s", c.name, c.expectedOverlaps[i], overlaps[i])
+			}
+		}
+	}
+}
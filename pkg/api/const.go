@@ -98,10 +98,20 @@ const (
 	ScaleSetPriorityRegular = "Regular"
 	// ScaleSetPriorityLow means the ScaleSet will use Low-priority VMs
 	ScaleSetPriorityLow = "Low"
-	// ScaleSetEvictionPolicyDelete is the default Eviction Policy for Low-priority VM ScaleSets
+	// ScaleSetPrioritySpot means the ScaleSet will use Spot VMs
+	ScaleSetPrioritySpot = "Spot"
+	// DefaultSpotMaxPrice means the Spot VMs are only evicted for capacity, paying up to the pay-as-you-go price of their size
+	DefaultSpotMaxPrice = -1
+	// ScaleSetPriorityLabelKey is the key of the label and of the taint of the nodes of Spot VM ScaleSets
+	ScaleSetPriorityLabelKey = "kubernetes.azure.com/scalesetpriority"
+	// ScaleSetPrioritySpotLabelValue is the value of the label and of the taint of the nodes of Spot VM ScaleSets
+	ScaleSetPrioritySpotLabelValue = "spot"
+	// ScaleSetEvictionPolicyDelete is the default Eviction Policy for Low-priority and Spot VM ScaleSets
 	ScaleSetEvictionPolicyDelete = "Delete"
 	// ScaleSetEvictionPolicyDeallocate means a Low-priority VM ScaleSet will deallocate, rather than delete, VMs.
 	ScaleSetEvictionPolicyDeallocate = "Deallocate"
+	// DefaultHostGroupFaultDomainCount is the fault domain count of the VM ScaleSets placed on dedicated hosts
+	DefaultHostGroupFaultDomainCount = 1
 )
 
 // Supported container runtimes
@@ -111,6 +121,9 @@ const (
 	Containerd     = "containerd"
 )
 
+// DockerHubRegistry is the registry name used for Docker Hub in registryMirrors
+const DockerHubRegistry = "docker.io"
+
 // storage profiles
 const (
 	// StorageAccount means that the nodes use raw storage accounts for their os and attached volumes
@@ -121,6 +134,35 @@ const (
 	Ephemeral = "Ephemeral"
 )
 
+// OS disk types
+const (
+	// ManagedOSDisk means that the node's os disk is stored as set by the storage profile
+	ManagedOSDisk = "Managed"
+	// EphemeralOSDisk means that the node's os disk is created on the cache of the VM, the storage profile applying
+	// to its attached volumes only
+	EphemeralOSDisk = "Ephemeral"
+)
+
+// data disk storage account types
+const (
+	// StandardHDDDataDisk means that the node's data disks are Standard HDDs
+	StandardHDDDataDisk = "Standard_LRS"
+	// StandardSSDDataDisk means that the node's data disks are Standard SSDs
+	StandardSSDDataDisk = "StandardSSD_LRS"
+	// PremiumSSDDataDisk means that the node's data disks are Premium SSDs, the only ones which may be shared
+	PremiumSSDDataDisk = "Premium_LRS"
+	// UltraSSDDataDisk means that the node's data disks are Ultra SSDs, whose IOPS and throughput may be set
+	UltraSSDDataDisk = "UltraSSD_LRS"
+)
+
+// zone balance strategies
+const (
+	// BestEffortZoneBalance means that the scale set spreads its VMs across its availability zones as evenly as it can
+	BestEffortZoneBalance = "BestEffort"
+	// StrictZoneBalance means that the scale set fails to scale rather than spread its VMs unevenly across its zones
+	StrictZoneBalance = "Strict"
+)
+
 // To identify programmatically generated public agent pools
 const publicAgentPoolSuffix = "-public"
 
@@ -137,6 +179,10 @@ const (
 	DefaultAppGwIngressAddonEnabled = false
 	// DefaultClusterAutoscalerAddonEnabled determines the aks-engine provided default for enabling cluster autoscaler addon
 	DefaultClusterAutoscalerAddonEnabled = false
+	// ClusterAutoscalerExpanderRandom is the default expander of the cluster-autoscaler addon, which picks a random pool to scale up
+	ClusterAutoscalerExpanderRandom = "random"
+	// ClusterAutoscalerExpanderPriority is the expander of the cluster-autoscaler addon which scales up the pool of the highest priority
+	ClusterAutoscalerExpanderPriority = "priority"
 	// DefaultBlobfuseFlexVolumeAddonEnabled determines the aks-engine provided default for enabling blobfuse flexvolume addon
 	DefaultBlobfuseFlexVolumeAddonEnabled = true
 	// DefaultSMBFlexVolumeAddonEnabled determines the aks-engine provided default for enabling smb flexvolume addon
@@ -173,6 +219,44 @@ const (
 	DefaultDNSAutoscalerAddonEnabled = false
 	// DefaultIPMasqAgentAddonEnabled enables the ip-masq-agent addon
 	DefaultIPMasqAgentAddonEnabled = true
+	// DefaultRegistryCacheAddonEnabled determines the aks-engine provided default for enabling the registry-cache addon
+	DefaultRegistryCacheAddonEnabled = false
+	// DefaultRegistryCacheNodePort is the node port nodes pull Docker Hub images through when the registry-cache addon is enabled
+	DefaultRegistryCacheNodePort = "30500"
+	// DefaultAzureWorkloadIdentityAddonEnabled determines the aks-engine provided default for enabling the azure-workload-identity-webhook addon
+	DefaultAzureWorkloadIdentityAddonEnabled = false
+	// DefaultFluentBitAddonEnabled determines the aks-engine provided default for enabling the fluent-bit log forwarding addon
+	DefaultFluentBitAddonEnabled = false
+	// FluentBitOutputLogAnalytics forwards the container logs of the fluent-bit addon to an Azure Log Analytics workspace
+	FluentBitOutputLogAnalytics = "log-analytics"
+	// FluentBitOutputStorage forwards the container logs of the fluent-bit addon to append blobs of an Azure storage account
+	FluentBitOutputStorage = "storage"
+	// DefaultNodeProblemDetectorAddonEnabled determines the aks-engine provided default for enabling the node-problem-detector addon
+	DefaultNodeProblemDetectorAddonEnabled = false
+	// DefaultNodeProblemDetectorSystemLogMonitors are the monitor configs of the node-problem-detector image watching the kernel and docker logs
+	DefaultNodeProblemDetectorSystemLogMonitors = "/config/kernel-monitor.json,/config/docker-monitor.json"
+	// DefaultCSISecretsStoreAddonEnabled determines the aks-engine provided default for enabling the csi-secrets-store addon
+	DefaultCSISecretsStoreAddonEnabled = false
+	// DefaultCSISecretsStoreRotationPollInterval is how often the csi-secrets-store addon refreshes the mounted secrets when their rotation is enabled
+	DefaultCSISecretsStoreRotationPollInterval = "2m"
+	// DefaultNginxIngressAddonEnabled determines the aks-engine provided default for enabling the nginx-ingress addon
+	DefaultNginxIngressAddonEnabled = false
+	// DefaultNginxIngressReplicas is the default number of replicas of the ingress controller of the nginx-ingress addon
+	DefaultNginxIngressReplicas = "2"
+	// DefaultAzurePolicyAddonEnabled determines the aks-engine provided default for enabling the azure-policy addon
+	DefaultAzurePolicyAddonEnabled = false
+	// DefaultAzurePolicyAuditInterval is the default interval in seconds of the audits of the existing resources by gatekeeper
+	DefaultAzurePolicyAuditInterval = "60"
+	// DefaultAzurePolicyConstraintViolationsLimit is the default number of the violations gatekeeper reports in the status of a constraint
+	DefaultAzurePolicyConstraintViolationsLimit = "20"
+	// DefaultKEDAAddonEnabled determines the aks-engine provided default for enabling the keda addon
+	DefaultKEDAAddonEnabled = false
+	// DefaultKEDAVersion is the default release of KEDA, the tag of the default images of the keda addon
+	DefaultKEDAVersion = "1.1.0"
+	// DefaultOpenServiceMeshAddonEnabled determines the aks-engine provided default for enabling the open-service-mesh addon
+	DefaultOpenServiceMeshAddonEnabled = false
+	// DefaultKonnectivityServerImage is the image of the konnectivity-server static pod of the masters
+	DefaultKonnectivityServerImage = "k8s.gcr.io/kas-network-proxy/proxy-server:v0.0.12"
 	// HeapsterAddonName is the name of the heapster addon
 	HeapsterAddonName = "heapster"
 	// TillerAddonName is the name of the tiller addon deployment
@@ -203,8 +287,68 @@ const (
 	ContainerMonitoringAddonName = "container-monitoring"
 	// CalicoAddonName is the name of calico daemonset addon
 	CalicoAddonName = "calico-daemonset"
+	// CiliumAddonName is the name of the cilium addon daemonset and operator of the cilium network plugin
+	CiliumAddonName = "cilium-daemonset"
+	// CiliumKubeProxyReplacementStrict is the kube-proxy-replacement config of the cilium addon replacing kube-proxy entirely with eBPF
+	CiliumKubeProxyReplacementStrict = "strict"
+	// CiliumKubeProxyReplacementDisabled is the kube-proxy-replacement config of the cilium addon running along kube-proxy
+	CiliumKubeProxyReplacementDisabled = "disabled"
+	// AntreaAddonName is the name of the antrea addon daemonset and controller of the antrea network plugin
+	AntreaAddonName = "antrea"
 	// IPMASQAgentAddonName is the name of the ip masq agent addon
 	IPMASQAgentAddonName = "ip-masq-agent"
+	// RegistryCacheAddonName is the name of the Docker Hub pull-through cache addon deployment
+	RegistryCacheAddonName = "registry-cache"
+	// AzureWorkloadIdentityAddonName is the name of the azure-workload-identity mutating webhook addon deployment
+	AzureWorkloadIdentityAddonName = "azure-workload-identity-webhook"
+	// FluentBitAddonName is the name of the fluent-bit log forwarding addon daemonsets
+	FluentBitAddonName = "fluent-bit"
+	// FluentBitWindowsContainerName is the name of the container of the fluent-bit addon daemonset running on Windows nodes
+	FluentBitWindowsContainerName = "fluent-bit-windows"
+	// NodeProblemDetectorAddonName is the name of the node-problem-detector addon daemonset
+	NodeProblemDetectorAddonName = "node-problem-detector"
+	// CSISecretsStoreAddonName is the name of the secrets-store-csi-driver addon daemonsets mounting Azure Key Vault objects as volumes
+	CSISecretsStoreAddonName = "csi-secrets-store"
+	// CSISecretsStoreDriverContainerName is the name of the secrets-store-csi-driver container of the csi-secrets-store addon
+	CSISecretsStoreDriverContainerName = "secrets-store"
+	// CSISecretsStoreProviderAzureContainerName is the name of the Azure Key Vault provider container of the csi-secrets-store addon
+	CSISecretsStoreProviderAzureContainerName = "provider-azure-installer"
+	// NginxIngressAddonName is the name of the nginx-ingress addon deployment
+	NginxIngressAddonName = "nginx-ingress"
+	// AppGwIngressControllerContainerName is the name of the Application Gateway Ingress Controller container of the appgw-ingress addon
+	AppGwIngressControllerContainerName = "appgw-ingress-controller"
+	// AzurePolicyAddonName is the name of the azure-policy addon deployment syncing the Azure Policy assignments to gatekeeper
+	AzurePolicyAddonName = "azure-policy"
+	// GatekeeperContainerName is the name of the gatekeeper container of the azure-policy addon
+	GatekeeperContainerName = "gatekeeper"
+	// KEDAAddonName is the name of the keda addon deployments of the KEDA operator and its external metrics server
+	KEDAAddonName = "keda"
+	// KEDAOperatorContainerName is the name of the KEDA operator container of the keda addon
+	KEDAOperatorContainerName = "keda-operator"
+	// KEDAMetricsAPIServerContainerName is the name of the external metrics server container of the keda addon
+	KEDAMetricsAPIServerContainerName = "keda-metrics-apiserver"
+	// OpenServiceMeshAddonName is the name of the open-service-mesh addon deployment of the Open Service Mesh control plane
+	OpenServiceMeshAddonName = "open-service-mesh"
+	// OpenServiceMeshControllerContainerName is the name of the osm-controller container of the open-service-mesh addon
+	OpenServiceMeshControllerContainerName = "osm-controller"
+	// OpenServiceMeshNamespace is the namespace of the control plane of the open-service-mesh addon
+	OpenServiceMeshNamespace = "osm-system"
+	// KonnectivityAgentAddonName is the name of the konnectivity-agent addon daemonset tunneling the API server traffic to the nodes
+	KonnectivityAgentAddonName = "konnectivity-agent"
+	// KubeAPIServerComponentName is the name of the kube-apiserver static pod, for its manifest patches
+	KubeAPIServerComponentName = "kube-apiserver"
+	// KubeControllerManagerComponentName is the name of the kube-controller-manager static pod, for its manifest patches
+	KubeControllerManagerComponentName = "kube-controller-manager"
+	// KubeSchedulerComponentName is the name of the kube-scheduler static pod, for its manifest patches
+	KubeSchedulerComponentName = "kube-scheduler"
+	// CloudControllerManagerComponentName is the name of the cloud-controller-manager static pod, for its manifest patches
+	CloudControllerManagerComponentName = "cloud-controller-manager"
+	// KubeAddonManagerComponentName is the name of the kube-addon-manager static pod, for its manifest patches
+	KubeAddonManagerComponentName = "kube-addon-manager"
+	// AddonManagerModeReconcile is the addon manager mode of the objects it keeps as they are in the addon manifests
+	AddonManagerModeReconcile = "Reconcile"
+	// AddonManagerModeEnsureExists is the addon manager mode of the objects it only creates if they are missing
+	AddonManagerModeEnsureExists = "EnsureExists"
 	// PodSecurityPolicyAddonName is the name of the PodSecurityPolicy addon
 	PodSecurityPolicyAddonName = "pod-security-policy"
 	// DefaultPrivateClusterEnabled determines the aks-engine provided default for enabling kubernetes Private Cluster
@@ -232,8 +376,26 @@ const (
 	DefaultVMSSOverProvisioningEnabled = false
 	// DefaultAuditDEnabled determines the aks-engine provided default for enabling auditd
 	DefaultAuditDEnabled = false
+	// DefaultTerminateNotificationTimeoutMinutes is how long the deletion of a VM of a scale set waits for its node to be drained by default
+	DefaultTerminateNotificationTimeoutMinutes = 5
 	// DNSAutoscalerAddonName is the name of the dns-autoscaler addon
 	DNSAutoscalerAddonName = "dns-autoscaler"
+	// CoreDNSAddonName is the name of the coredns addon
+	CoreDNSAddonName = "coredns"
+	// SizingProfileSmall sizes the default resources of addons and control plane components for clusters of up to 10 nodes
+	SizingProfileSmall = "small"
+	// SizingProfileMedium sizes the default resources of addons and control plane components for clusters of up to 100 nodes
+	SizingProfileMedium = "medium"
+	// SizingProfileLarge sizes the default resources of addons and control plane components for clusters of more than 100 nodes
+	SizingProfileLarge = "large"
+	// OutboundTypeLoadBalancer sends the egress traffic of the agents through the outbound rule of the agent standard load balancer
+	OutboundTypeLoadBalancer = "loadBalancer"
+	// OutboundTypeNATGateway sends the egress traffic of the cluster through a NAT gateway attached to the subnets of the VNET
+	OutboundTypeNATGateway = "natGateway"
+	// OutboundTypeUserDefinedRouting leaves the egress traffic of the cluster to the route table of the custom VNET
+	OutboundTypeUserDefinedRouting = "userDefinedRouting"
+	// DefaultOutboundType determines the aks-engine provided default for the outbound type of a standard load balancer cluster
+	DefaultOutboundType = OutboundTypeLoadBalancer
 	// DefaultUseCosmos determines if the cluster will use cosmos as etcd storage
 	DefaultUseCosmos = false
 	// etcdEndpointURIFmt is the name format for a typical etcd account uri
@@ -391,6 +553,10 @@ const (
 	NetworkPolicyCilium = "cilium"
 	// NetworkPluginCilium is the string expression for cilium network plugin config option
 	NetworkPluginCilium = NetworkPolicyCilium
+	// NetworkPolicyAntrea is the string expression for antrea network policy config option
+	NetworkPolicyAntrea = "antrea"
+	// NetworkPluginAntrea is the string expression for antrea network plugin config option
+	NetworkPluginAntrea = NetworkPolicyAntrea
 	// NetworkPluginFlannel is the string expression for flannel network policy config option
 	NetworkPluginFlannel = "flannel"
 	// DefaultNetworkPlugin defines the network plugin to use by default
@@ -469,6 +635,12 @@ const (
 	DefaultMobyVersion = "3.0.6"
 	// DefaultContainerdVersion specifies the default containerd version to install.
 	DefaultContainerdVersion = "1.1.5"
+	// DefaultContainerdLinuxEndpoint is the CRI endpoint of containerd the kubelet of Linux nodes connects to.
+	DefaultContainerdLinuxEndpoint = "unix:///run/containerd/containerd.sock"
+	// DefaultContainerdWindowsEndpoint is the CRI endpoint of containerd the kubelet of Windows nodes connects to.
+	DefaultContainerdWindowsEndpoint = "npipe:////./pipe/containerd-containerd"
+	// DefaultWindowsPauseImage is the pause image of the pods of Windows nodes running containerd.
+	DefaultWindowsPauseImage = "mcr.microsoft.com/k8s/core/pause:1.2.0"
 	// DefaultDockerBridgeSubnet specifies the default subnet for the docker bridge network for masters and agents.
 	DefaultDockerBridgeSubnet = "172.17.0.1/16"
 	// DefaultKubernetesMaxPodsKubenet is the maximum number of pods to run on a node for Kubenet.
@@ -490,6 +662,8 @@ const (
 const (
 	//DefaultExtensionsRootURL  Root URL for extensions
 	DefaultExtensionsRootURL = "https://raw.githubusercontent.com/Azure/aks-engine/master/"
+	// DefaultVMExtensionAutoUpgradeMinorVersion is the default autoUpgradeMinorVersion of the vmExtensions of a profile
+	DefaultVMExtensionAutoUpgradeMinorVersion = true
 )
 
 const (
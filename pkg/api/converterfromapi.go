@@ -539,6 +539,25 @@ func convertExtensionToVLabs(api *Extension, vlabs *vlabs.Extension) {
 	vlabs.Template = api.Template
 }
 
+func convertVMExtensionsToVLabs(api []VMExtension) []vlabs.VMExtension {
+	if api == nil {
+		return nil
+	}
+	v := make([]vlabs.VMExtension, 0, len(api))
+	for _, e := range api {
+		v = append(v, vlabs.VMExtension{
+			Name:                    e.Name,
+			Publisher:               e.Publisher,
+			Type:                    e.Type,
+			TypeHandlerVersion:      e.TypeHandlerVersion,
+			AutoUpgradeMinorVersion: e.AutoUpgradeMinorVersion,
+			Settings:                e.Settings,
+			ProtectedSettings:       e.ProtectedSettings,
+		})
+	}
+	return v
+}
+
 func convertLinuxProfileToV20170701(api *LinuxProfile, obj *v20170701.LinuxProfile) {
 	obj.AdminUsername = api.AdminUsername
 	obj.SSH.PublicKeys = []v20170701.PublicKey{}
@@ -613,6 +632,7 @@ func convertWindowsProfileToVLabs(api *WindowsProfile, vlabsProfile *vlabs.Windo
 	vlabsProfile.WindowsOffer = api.WindowsOffer
 	vlabsProfile.WindowsSku = api.WindowsSku
 	vlabsProfile.WindowsDockerVersion = api.WindowsDockerVersion
+	vlabsProfile.WindowsContainerdURL = api.WindowsContainerdURL
 	vlabsProfile.Secrets = []vlabs.KeyVaultSecrets{}
 	for _, s := range api.Secrets {
 		secret := &vlabs.KeyVaultSecrets{}
@@ -761,6 +781,11 @@ func convertKubernetesConfigToVLabs(apiCfg *KubernetesConfig, vlabsCfg *vlabs.Ku
 	vlabsCfg.ProxyMode = vlabs.KubeProxyMode(apiCfg.ProxyMode)
 	vlabsCfg.PrivateAzureRegistryServer = apiCfg.PrivateAzureRegistryServer
 	vlabsCfg.OutboundRuleIdleTimeoutInMinutes = apiCfg.OutboundRuleIdleTimeoutInMinutes
+	vlabsCfg.OutboundRuleAllocatedPorts = apiCfg.OutboundRuleAllocatedPorts
+	vlabsCfg.OutboundType = apiCfg.OutboundType
+	vlabsCfg.SizingProfile = apiCfg.SizingProfile
+	vlabsCfg.ImageRegistry = apiCfg.ImageRegistry
+	convertAirGapProfileToVlabs(apiCfg, vlabsCfg)
 	convertAddonsToVlabs(apiCfg, vlabsCfg)
 	convertKubeletConfigToVlabs(apiCfg, vlabsCfg)
 	convertControllerManagerConfigToVlabs(apiCfg, vlabsCfg)
@@ -769,6 +794,13 @@ func convertKubernetesConfigToVLabs(apiCfg *KubernetesConfig, vlabsCfg *vlabs.Ku
 	convertSchedulerConfigToVlabs(apiCfg, vlabsCfg)
 	convertPrivateClusterToVlabs(apiCfg, vlabsCfg)
 	convertPodSecurityPolicyConfigToVlabs(apiCfg, vlabsCfg)
+	convertRegistryMirrorsToVlabs(apiCfg, vlabsCfg)
+	convertOIDCIssuerProfileToVlabs(apiCfg, vlabsCfg)
+	convertManifestPatchesToVlabs(apiCfg, vlabsCfg)
+	convertKonnectivityProfileToVlabs(apiCfg, vlabsCfg)
+	convertDrainProfileToVlabs(apiCfg, vlabsCfg)
+	convertCloudProviderConfigToVlabs(apiCfg, vlabsCfg)
+	convertCustomManifestsToVlabs(apiCfg, vlabsCfg)
 }
 
 func convertKubeletConfigToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
@@ -825,6 +857,97 @@ func convertPodSecurityPolicyConfigToVlabs(a *KubernetesConfig, v *vlabs.Kuberne
 	}
 }
 
+func convertRegistryMirrorsToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	for _, mirror := range a.RegistryMirrors {
+		v.RegistryMirrors = append(v.RegistryMirrors, vlabs.RegistryMirror{
+			Registry:  mirror.Registry,
+			Endpoints: append([]string{}, mirror.Endpoints...),
+		})
+	}
+}
+
+func convertOIDCIssuerProfileToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.OIDCIssuerProfile != nil {
+		v.OIDCIssuerProfile = &vlabs.OIDCIssuerProfile{
+			Enabled:            a.OIDCIssuerProfile.Enabled,
+			StorageAccountName: a.OIDCIssuerProfile.StorageAccountName,
+			ContainerName:      a.OIDCIssuerProfile.ContainerName,
+			IssuerURL:          a.OIDCIssuerProfile.IssuerURL,
+		}
+	}
+}
+
+func convertKonnectivityProfileToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.KonnectivityProfile != nil {
+		v.KonnectivityProfile = &vlabs.KonnectivityProfile{
+			Enabled:     a.KonnectivityProfile.Enabled,
+			ServerImage: a.KonnectivityProfile.ServerImage,
+		}
+	}
+}
+
+func convertAirGapProfileToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.AirGapProfile != nil {
+		v.AirGapProfile = &vlabs.AirGapProfile{
+			Enabled:     a.AirGapProfile.Enabled,
+			BinariesURL: a.AirGapProfile.BinariesURL,
+			PackagesURL: a.AirGapProfile.PackagesURL,
+		}
+	}
+}
+
+func convertDrainProfileToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.DrainProfile != nil {
+		v.DrainProfile = &vlabs.DrainProfile{
+			TimeoutInMinutes:   a.DrainProfile.TimeoutInMinutes,
+			GracePeriodSeconds: a.DrainProfile.GracePeriodSeconds,
+			IgnoreDaemonSets:   a.DrainProfile.IgnoreDaemonSets,
+			DeleteEmptyDirData: a.DrainProfile.DeleteEmptyDirData,
+			ForceAfterTimeout:  a.DrainProfile.ForceAfterTimeout,
+		}
+	}
+}
+
+func convertCloudProviderConfigToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.CloudProviderConfig == nil {
+		return
+	}
+	v.CloudProviderConfig = &vlabs.AzureCloudConfig{
+		VMSSCacheTTLInSeconds:                 a.CloudProviderConfig.VMSSCacheTTLInSeconds,
+		VMSSVirtualMachinesCacheTTLInSeconds:  a.CloudProviderConfig.VMSSVirtualMachinesCacheTTLInSeconds,
+		VMCacheTTLInSeconds:                   a.CloudProviderConfig.VMCacheTTLInSeconds,
+		AvailabilitySetNodesCacheTTLInSeconds: a.CloudProviderConfig.AvailabilitySetNodesCacheTTLInSeconds,
+		LoadBalancerCacheTTLInSeconds:         a.CloudProviderConfig.LoadBalancerCacheTTLInSeconds,
+		NSGCacheTTLInSeconds:                  a.CloudProviderConfig.NSGCacheTTLInSeconds,
+		RouteTableCacheTTLInSeconds:           a.CloudProviderConfig.RouteTableCacheTTLInSeconds,
+		Tags:                                  a.CloudProviderConfig.Tags,
+	}
+	if a.CloudProviderConfig.RateLimits != nil {
+		v.CloudProviderConfig.RateLimits = map[string]vlabs.CloudProviderRateLimit{}
+		for client, rateLimit := range a.CloudProviderConfig.RateLimits {
+			v.CloudProviderConfig.RateLimits[client] = vlabs.CloudProviderRateLimit(rateLimit)
+		}
+	}
+}
+
+func convertCustomManifestsToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	for _, manifest := range a.CustomManifests {
+		m := vlabs.CustomManifest(manifest)
+		m.DependsOn = append([]string(nil), manifest.DependsOn...)
+		v.CustomManifests = append(v.CustomManifests, m)
+	}
+}
+
+func convertManifestPatchesToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	for _, patch := range a.ManifestPatches {
+		v.ManifestPatches = append(v.ManifestPatches, vlabs.ManifestPatch{
+			Component: patch.Component,
+			ExtraArgs: patch.ExtraArgs,
+			Patch:     patch.Patch,
+		})
+	}
+}
+
 func convertPrivateClusterToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
 	if a.PrivateCluster != nil {
 		v.PrivateCluster = &vlabs.PrivateCluster{}
@@ -833,6 +956,12 @@ func convertPrivateClusterToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig
 			v.PrivateCluster.JumpboxProfile = &vlabs.PrivateJumpboxProfile{}
 			convertPrivateJumpboxProfileToVlabs(a.PrivateCluster.JumpboxProfile, v.PrivateCluster.JumpboxProfile)
 		}
+		if a.PrivateCluster.PrivateLink != nil {
+			v.PrivateCluster.PrivateLink = &vlabs.PrivateLinkProfile{
+				Enabled:                 a.PrivateCluster.PrivateLink.Enabled,
+				PrivateEndpointSubnetID: a.PrivateCluster.PrivateLink.PrivateEndpointSubnetID,
+			}
+		}
 	}
 }
 
@@ -858,6 +987,7 @@ func convertAddonsToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
 			v.Addons[i].Containers = append(v.Addons[i].Containers, vlabs.KubernetesContainerSpec{
 				Name:           a.Addons[i].Containers[j].Name,
 				Image:          a.Addons[i].Containers[j].Image,
+				ImageDigest:    a.Addons[i].Containers[j].ImageDigest,
 				CPURequests:    a.Addons[i].Containers[j].CPURequests,
 				MemoryRequests: a.Addons[i].Containers[j].MemoryRequests,
 				CPULimits:      a.Addons[i].Containers[j].CPULimits,
@@ -870,6 +1000,17 @@ func convertAddonsToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
 				v.Addons[i].Config[key] = val
 			}
 		}
+
+		for j := range a.Addons[i].Pools {
+			pool := vlabs.AddonNodePoolsConfig{
+				Name:   a.Addons[i].Pools[j].Name,
+				Config: map[string]string{},
+			}
+			for key, val := range a.Addons[i].Pools[j].Config {
+				pool.Config[key] = val
+			}
+			v.Addons[i].Pools = append(v.Addons[i].Pools, pool)
+		}
 	}
 }
 
@@ -906,6 +1047,26 @@ func convertMasterProfileToV20170701(api *MasterProfile, v20170701Profile *v2017
 	v20170701Profile.StorageProfile = api.StorageProfile
 }
 
+func convertScheduledEventsProfileToVlabs(a *ScheduledEventsProfile) *vlabs.ScheduledEventsProfile {
+	if a == nil {
+		return nil
+	}
+	return &vlabs.ScheduledEventsProfile{
+		Enabled:                             a.Enabled,
+		TerminateNotificationTimeoutMinutes: a.TerminateNotificationTimeoutMinutes,
+	}
+}
+
+func convertNodeDNSConfigToVlabs(a *NodeDNSConfig) *vlabs.NodeDNSConfig {
+	if a == nil {
+		return nil
+	}
+	return &vlabs.NodeDNSConfig{
+		DNSServers:    a.DNSServers,
+		SearchDomains: a.SearchDomains,
+	}
+}
+
 func convertMasterProfileToVLabs(api *MasterProfile, vlabsProfile *vlabs.MasterProfile) {
 	vlabsProfile.Count = api.Count
 	vlabsProfile.DNSPrefix = api.DNSPrefix
@@ -916,11 +1077,19 @@ func convertMasterProfileToVLabs(api *MasterProfile, vlabsProfile *vlabs.MasterP
 	vlabsProfile.VnetSubnetID = api.VnetSubnetID
 	vlabsProfile.AgentVnetSubnetID = api.AgentVnetSubnetID
 	vlabsProfile.FirstConsecutiveStaticIP = api.FirstConsecutiveStaticIP
+	vlabsProfile.EtcdSubnet = api.EtcdSubnet
+	vlabsProfile.EtcdVnetSubnetID = api.EtcdVnetSubnetID
+	vlabsProfile.EtcdFirstConsecutiveStaticIP = api.EtcdFirstConsecutiveStaticIP
 	vlabsProfile.VnetCidr = api.VnetCidr
+	vlabsProfile.VnetDNSServers = api.VnetDNSServers
+	vlabsProfile.DNSConfig = convertNodeDNSConfigToVlabs(api.DNSConfig)
 	vlabsProfile.SetSubnet(api.Subnet)
 	vlabsProfile.SetSubnetIPv6(api.SubnetIPv6)
 	vlabsProfile.FQDN = api.FQDN
 	vlabsProfile.StorageProfile = api.StorageProfile
+	vlabsProfile.DataDiskStorageAccountType = api.DataDiskStorageAccountType
+	vlabsProfile.DataDiskIOPSReadWrite = api.DataDiskIOPSReadWrite
+	vlabsProfile.DataDiskMBpsReadWrite = api.DataDiskMBpsReadWrite
 	if api.PreprovisionExtension != nil {
 		vlabsExtension := &vlabs.Extension{}
 		convertExtensionToVLabs(api.PreprovisionExtension, vlabsExtension)
@@ -932,6 +1101,7 @@ func convertMasterProfileToVLabs(api *MasterProfile, vlabsProfile *vlabs.MasterP
 		convertExtensionToVLabs(&extension, vlabsExtension)
 		vlabsProfile.Extensions = append(vlabsProfile.Extensions, *vlabsExtension)
 	}
+	vlabsProfile.VMExtensions = convertVMExtensionsToVLabs(api.VMExtensions)
 	vlabsProfile.Distro = vlabs.Distro(api.Distro)
 	if api.KubernetesConfig != nil {
 		vlabsProfile.KubernetesConfig = &vlabs.KubernetesConfig{}
@@ -949,6 +1119,8 @@ func convertMasterProfileToVLabs(api *MasterProfile, vlabsProfile *vlabs.MasterP
 	vlabsProfile.AgentSubnet = api.AgentSubnet
 	vlabsProfile.AvailabilityZones = api.AvailabilityZones
 	vlabsProfile.SinglePlacementGroup = api.SinglePlacementGroup
+	vlabsProfile.ProximityPlacementGroupID = api.ProximityPlacementGroupID
+	vlabsProfile.CreateProximityPlacementGroup = api.CreateProximityPlacementGroup
 	vlabsProfile.CosmosEtcd = api.CosmosEtcd
 	vlabsProfile.AuditDEnabled = api.AuditDEnabled
 	convertCustomFilesToVlabs(api, vlabsProfile)
@@ -1015,6 +1187,8 @@ func convertAgentPoolProfileToVLabs(api *AgentPoolProfile, p *vlabs.AgentPoolPro
 	p.Count = api.Count
 	p.VMSize = api.VMSize
 	p.CustomVMTags = api.CustomVMTags
+	p.DNSConfig = convertNodeDNSConfigToVlabs(api.DNSConfig)
+	p.ScheduledEventsProfile = convertScheduledEventsProfileToVlabs(api.ScheduledEventsProfile)
 	p.OSDiskSizeGB = api.OSDiskSizeGB
 	p.DNSPrefix = api.DNSPrefix
 	p.OSType = vlabs.OSType(api.OSType)
@@ -1023,10 +1197,17 @@ func convertAgentPoolProfileToVLabs(api *AgentPoolProfile, p *vlabs.AgentPoolPro
 	p.AvailabilityProfile = api.AvailabilityProfile
 	p.ScaleSetPriority = api.ScaleSetPriority
 	p.ScaleSetEvictionPolicy = api.ScaleSetEvictionPolicy
+	p.SpotMaxPrice = api.SpotMaxPrice
 	p.StorageProfile = api.StorageProfile
+	p.OSDiskType = api.OSDiskType
 	p.DiskSizesGB = []int{}
 	p.DiskSizesGB = append(p.DiskSizesGB, api.DiskSizesGB...)
+	p.DataDiskStorageAccountType = api.DataDiskStorageAccountType
+	p.DataDiskIOPSReadWrite = api.DataDiskIOPSReadWrite
+	p.DataDiskMBpsReadWrite = api.DataDiskMBpsReadWrite
+	p.EnableSharedDisk = api.EnableSharedDisk
 	p.VnetSubnetID = api.VnetSubnetID
+	p.PodSubnetID = api.PodSubnetID
 	p.SetSubnet(api.Subnet)
 	p.FQDN = api.FQDN
 	p.CustomNodeLabels = map[string]string{}
@@ -1034,9 +1215,15 @@ func convertAgentPoolProfileToVLabs(api *AgentPoolProfile, p *vlabs.AgentPoolPro
 	p.AcceleratedNetworkingEnabledWindows = api.AcceleratedNetworkingEnabledWindows
 	p.VMSSOverProvisioningEnabled = api.VMSSOverProvisioningEnabled
 	p.AvailabilityZones = api.AvailabilityZones
+	p.ZoneBalanceStrategy = api.ZoneBalanceStrategy
 	p.SinglePlacementGroup = api.SinglePlacementGroup
+	p.ProximityPlacementGroupID = api.ProximityPlacementGroupID
+	p.CreateProximityPlacementGroup = api.CreateProximityPlacementGroup
+	p.HostGroupID = api.HostGroupID
+	p.PlatformFaultDomainCount = api.PlatformFaultDomainCount
 	p.EnableVMSSNodePublicIP = api.EnableVMSSNodePublicIP
 	p.LoadBalancerBackendAddressPoolIDs = api.LoadBalancerBackendAddressPoolIDs
+	p.UserAssignedID = api.UserAssignedID
 	p.AuditDEnabled = api.AuditDEnabled
 
 	for k, v := range api.CustomNodeLabels {
@@ -1055,6 +1242,7 @@ func convertAgentPoolProfileToVLabs(api *AgentPoolProfile, p *vlabs.AgentPoolPro
 		convertExtensionToVLabs(&extension, vlabsExtension)
 		p.Extensions = append(p.Extensions, *vlabsExtension)
 	}
+	p.VMExtensions = convertVMExtensionsToVLabs(api.VMExtensions)
 	p.Distro = vlabs.Distro(api.Distro)
 	if api.KubernetesConfig != nil {
 		p.KubernetesConfig = &vlabs.KubernetesConfig{}
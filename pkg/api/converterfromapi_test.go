@@ -616,6 +616,10 @@ func getDefaultContainerService() *ContainerService {
 							PublicKey:      ValidSSHPublicKey,
 							StorageProfile: StorageAccount,
 						},
+						PrivateLink: &PrivateLinkProfile{
+							Enabled:                 to.BoolPtr(true),
+							PrivateEndpointSubnetID: "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME",
+						},
 					},
 					PodSecurityPolicyConfig: map[string]string{
 						"samplePSPConfigKey": "samplePSPConfigVal",
@@ -471,6 +471,25 @@ func convertVLabsExtension(vlabs *vlabs.Extension, api *Extension) {
 	api.Template = vlabs.Template
 }
 
+func convertVLabsVMExtensions(vlabs []vlabs.VMExtension) []VMExtension {
+	if vlabs == nil {
+		return nil
+	}
+	api := make([]VMExtension, 0, len(vlabs))
+	for _, e := range vlabs {
+		api = append(api, VMExtension{
+			Name:                    e.Name,
+			Publisher:               e.Publisher,
+			Type:                    e.Type,
+			TypeHandlerVersion:      e.TypeHandlerVersion,
+			AutoUpgradeMinorVersion: e.AutoUpgradeMinorVersion,
+			Settings:                e.Settings,
+			ProtectedSettings:       e.ProtectedSettings,
+		})
+	}
+	return api
+}
+
 func convertV20170701LinuxProfile(v20170701 *v20170701.LinuxProfile, api *LinuxProfile) {
 	api.AdminUsername = v20170701.AdminUsername
 	api.SSH.PublicKeys = []PublicKey{}
@@ -544,6 +563,7 @@ func convertVLabsWindowsProfile(vlabs *vlabs.WindowsProfile, api *WindowsProfile
 	api.WindowsOffer = vlabs.WindowsOffer
 	api.WindowsSku = vlabs.WindowsSku
 	api.WindowsDockerVersion = vlabs.WindowsDockerVersion
+	api.WindowsContainerdURL = vlabs.WindowsContainerdURL
 	api.Secrets = []KeyVaultSecrets{}
 	for _, s := range vlabs.Secrets {
 		secret := &KeyVaultSecrets{}
@@ -726,6 +746,11 @@ func convertVLabsKubernetesConfig(vlabs *vlabs.KubernetesConfig, api *Kubernetes
 	api.ProxyMode = KubeProxyMode(vlabs.ProxyMode)
 	api.PrivateAzureRegistryServer = vlabs.PrivateAzureRegistryServer
 	api.OutboundRuleIdleTimeoutInMinutes = vlabs.OutboundRuleIdleTimeoutInMinutes
+	api.OutboundRuleAllocatedPorts = vlabs.OutboundRuleAllocatedPorts
+	api.OutboundType = vlabs.OutboundType
+	api.SizingProfile = vlabs.SizingProfile
+	api.ImageRegistry = vlabs.ImageRegistry
+	convertAirGapProfileToAPI(vlabs, api)
 	convertAddonsToAPI(vlabs, api)
 	convertKubeletConfigToAPI(vlabs, api)
 	convertControllerManagerConfigToAPI(vlabs, api)
@@ -734,6 +759,13 @@ func convertVLabsKubernetesConfig(vlabs *vlabs.KubernetesConfig, api *Kubernetes
 	convertSchedulerConfigToAPI(vlabs, api)
 	convertPrivateClusterToAPI(vlabs, api)
 	convertPodSecurityPolicyConfigToAPI(vlabs, api)
+	convertRegistryMirrorsToAPI(vlabs, api)
+	convertOIDCIssuerProfileToAPI(vlabs, api)
+	convertManifestPatchesToAPI(vlabs, api)
+	convertKonnectivityProfileToAPI(vlabs, api)
+	convertDrainProfileToAPI(vlabs, api)
+	convertCloudProviderConfigToAPI(vlabs, api)
+	convertCustomManifestsToAPI(vlabs, api)
 }
 
 func setVlabsKubernetesDefaults(vp *vlabs.Properties, api *OrchestratorProfile) {
@@ -777,6 +809,7 @@ func convertAddonsToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
 			a.Addons[i].Containers = append(a.Addons[i].Containers, KubernetesContainerSpec{
 				Name:           v.Addons[i].Containers[j].Name,
 				Image:          v.Addons[i].Containers[j].Image,
+				ImageDigest:    v.Addons[i].Containers[j].ImageDigest,
 				CPURequests:    v.Addons[i].Containers[j].CPURequests,
 				MemoryRequests: v.Addons[i].Containers[j].MemoryRequests,
 				CPULimits:      v.Addons[i].Containers[j].CPULimits,
@@ -789,6 +822,17 @@ func convertAddonsToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
 				a.Addons[i].Config[key] = val
 			}
 		}
+
+		for j := range v.Addons[i].Pools {
+			pool := AddonNodePoolsConfig{
+				Name:   v.Addons[i].Pools[j].Name,
+				Config: map[string]string{},
+			}
+			for key, val := range v.Addons[i].Pools[j].Config {
+				pool.Config[key] = val
+			}
+			a.Addons[i].Pools = append(a.Addons[i].Pools, pool)
+		}
 	}
 }
 
@@ -846,6 +890,97 @@ func convertPodSecurityPolicyConfigToAPI(v *vlabs.KubernetesConfig, a *Kubernete
 	}
 }
 
+func convertRegistryMirrorsToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	for _, mirror := range v.RegistryMirrors {
+		a.RegistryMirrors = append(a.RegistryMirrors, RegistryMirror{
+			Registry:  mirror.Registry,
+			Endpoints: append([]string{}, mirror.Endpoints...),
+		})
+	}
+}
+
+func convertOIDCIssuerProfileToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.OIDCIssuerProfile != nil {
+		a.OIDCIssuerProfile = &OIDCIssuerProfile{
+			Enabled:            v.OIDCIssuerProfile.Enabled,
+			StorageAccountName: v.OIDCIssuerProfile.StorageAccountName,
+			ContainerName:      v.OIDCIssuerProfile.ContainerName,
+			IssuerURL:          v.OIDCIssuerProfile.IssuerURL,
+		}
+	}
+}
+
+func convertKonnectivityProfileToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.KonnectivityProfile != nil {
+		a.KonnectivityProfile = &KonnectivityProfile{
+			Enabled:     v.KonnectivityProfile.Enabled,
+			ServerImage: v.KonnectivityProfile.ServerImage,
+		}
+	}
+}
+
+func convertAirGapProfileToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.AirGapProfile != nil {
+		a.AirGapProfile = &AirGapProfile{
+			Enabled:     v.AirGapProfile.Enabled,
+			BinariesURL: v.AirGapProfile.BinariesURL,
+			PackagesURL: v.AirGapProfile.PackagesURL,
+		}
+	}
+}
+
+func convertDrainProfileToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.DrainProfile != nil {
+		a.DrainProfile = &DrainProfile{
+			TimeoutInMinutes:   v.DrainProfile.TimeoutInMinutes,
+			GracePeriodSeconds: v.DrainProfile.GracePeriodSeconds,
+			IgnoreDaemonSets:   v.DrainProfile.IgnoreDaemonSets,
+			DeleteEmptyDirData: v.DrainProfile.DeleteEmptyDirData,
+			ForceAfterTimeout:  v.DrainProfile.ForceAfterTimeout,
+		}
+	}
+}
+
+func convertCloudProviderConfigToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.CloudProviderConfig == nil {
+		return
+	}
+	a.CloudProviderConfig = &AzureCloudConfig{
+		VMSSCacheTTLInSeconds:                 v.CloudProviderConfig.VMSSCacheTTLInSeconds,
+		VMSSVirtualMachinesCacheTTLInSeconds:  v.CloudProviderConfig.VMSSVirtualMachinesCacheTTLInSeconds,
+		VMCacheTTLInSeconds:                   v.CloudProviderConfig.VMCacheTTLInSeconds,
+		AvailabilitySetNodesCacheTTLInSeconds: v.CloudProviderConfig.AvailabilitySetNodesCacheTTLInSeconds,
+		LoadBalancerCacheTTLInSeconds:         v.CloudProviderConfig.LoadBalancerCacheTTLInSeconds,
+		NSGCacheTTLInSeconds:                  v.CloudProviderConfig.NSGCacheTTLInSeconds,
+		RouteTableCacheTTLInSeconds:           v.CloudProviderConfig.RouteTableCacheTTLInSeconds,
+		Tags:                                  v.CloudProviderConfig.Tags,
+	}
+	if v.CloudProviderConfig.RateLimits != nil {
+		a.CloudProviderConfig.RateLimits = map[string]CloudProviderRateLimit{}
+		for client, rateLimit := range v.CloudProviderConfig.RateLimits {
+			a.CloudProviderConfig.RateLimits[client] = CloudProviderRateLimit(rateLimit)
+		}
+	}
+}
+
+func convertCustomManifestsToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	for _, manifest := range v.CustomManifests {
+		m := CustomManifest(manifest)
+		m.DependsOn = append([]string(nil), manifest.DependsOn...)
+		a.CustomManifests = append(a.CustomManifests, m)
+	}
+}
+
+func convertManifestPatchesToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	for _, patch := range v.ManifestPatches {
+		a.ManifestPatches = append(a.ManifestPatches, ManifestPatch{
+			Component: patch.Component,
+			ExtraArgs: patch.ExtraArgs,
+			Patch:     patch.Patch,
+		})
+	}
+}
+
 func convertPrivateClusterToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
 	if v.PrivateCluster != nil {
 		a.PrivateCluster = &PrivateCluster{}
@@ -854,6 +989,12 @@ func convertPrivateClusterToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig)
 			a.PrivateCluster.JumpboxProfile = &PrivateJumpboxProfile{}
 			convertPrivateJumpboxProfileToAPI(v.PrivateCluster.JumpboxProfile, a.PrivateCluster.JumpboxProfile)
 		}
+		if v.PrivateCluster.PrivateLink != nil {
+			a.PrivateCluster.PrivateLink = &PrivateLinkProfile{
+				Enabled:                 v.PrivateCluster.PrivateLink.Enabled,
+				PrivateEndpointSubnetID: v.PrivateCluster.PrivateLink.PrivateEndpointSubnetID,
+			}
+		}
 	}
 }
 
@@ -909,6 +1050,26 @@ func convertV20170701MasterProfile(v20170701 *v20170701.MasterProfile, api *Mast
 	}
 }
 
+func convertVLabsNodeDNSConfig(v *vlabs.NodeDNSConfig) *NodeDNSConfig {
+	if v == nil {
+		return nil
+	}
+	return &NodeDNSConfig{
+		DNSServers:    v.DNSServers,
+		SearchDomains: v.SearchDomains,
+	}
+}
+
+func convertVLabsScheduledEventsProfile(v *vlabs.ScheduledEventsProfile) *ScheduledEventsProfile {
+	if v == nil {
+		return nil
+	}
+	return &ScheduledEventsProfile{
+		Enabled:                             v.Enabled,
+		TerminateNotificationTimeoutMinutes: v.TerminateNotificationTimeoutMinutes,
+	}
+}
+
 func convertVLabsMasterProfile(vlabs *vlabs.MasterProfile, api *MasterProfile) {
 	api.Count = vlabs.Count
 	api.DNSPrefix = vlabs.DNSPrefix
@@ -918,13 +1079,21 @@ func convertVLabsMasterProfile(vlabs *vlabs.MasterProfile, api *MasterProfile) {
 	api.OSDiskSizeGB = vlabs.OSDiskSizeGB
 	api.VnetSubnetID = vlabs.VnetSubnetID
 	api.AgentVnetSubnetID = vlabs.AgentVnetSubnetID
+	api.VnetDNSServers = vlabs.VnetDNSServers
+	api.DNSConfig = convertVLabsNodeDNSConfig(vlabs.DNSConfig)
 	api.FirstConsecutiveStaticIP = vlabs.FirstConsecutiveStaticIP
+	api.EtcdSubnet = vlabs.EtcdSubnet
+	api.EtcdVnetSubnetID = vlabs.EtcdVnetSubnetID
+	api.EtcdFirstConsecutiveStaticIP = vlabs.EtcdFirstConsecutiveStaticIP
 	api.VnetCidr = vlabs.VnetCidr
 	api.Subnet = vlabs.GetSubnet()
 	api.SubnetIPv6 = vlabs.GetSubnetIPv6()
 	api.IPAddressCount = vlabs.IPAddressCount
 	api.FQDN = vlabs.FQDN
 	api.StorageProfile = vlabs.StorageProfile
+	api.DataDiskStorageAccountType = vlabs.DataDiskStorageAccountType
+	api.DataDiskIOPSReadWrite = vlabs.DataDiskIOPSReadWrite
+	api.DataDiskMBpsReadWrite = vlabs.DataDiskMBpsReadWrite
 	api.HTTPSourceAddressPrefix = vlabs.HTTPSourceAddressPrefix
 	api.OAuthEnabled = vlabs.OAuthEnabled
 	// by default vlabs will use managed disks as it has encryption at rest
@@ -944,6 +1113,7 @@ func convertVLabsMasterProfile(vlabs *vlabs.MasterProfile, api *MasterProfile) {
 		convertVLabsExtension(&extension, apiExtension)
 		api.Extensions = append(api.Extensions, *apiExtension)
 	}
+	api.VMExtensions = convertVLabsVMExtensions(vlabs.VMExtensions)
 
 	api.Distro = Distro(vlabs.Distro)
 	if vlabs.KubernetesConfig != nil {
@@ -963,6 +1133,8 @@ func convertVLabsMasterProfile(vlabs *vlabs.MasterProfile, api *MasterProfile) {
 	api.AgentSubnet = vlabs.AgentSubnet
 	api.AvailabilityZones = vlabs.AvailabilityZones
 	api.SinglePlacementGroup = vlabs.SinglePlacementGroup
+	api.ProximityPlacementGroupID = vlabs.ProximityPlacementGroupID
+	api.CreateProximityPlacementGroup = vlabs.CreateProximityPlacementGroup
 	api.CosmosEtcd = vlabs.CosmosEtcd
 	api.AuditDEnabled = vlabs.AuditDEnabled
 	convertCustomFilesToAPI(vlabs, api)
@@ -1033,6 +1205,8 @@ func convertVLabsAgentPoolProfile(vlabs *vlabs.AgentPoolProfile, api *AgentPoolP
 	api.Count = vlabs.Count
 	api.VMSize = vlabs.VMSize
 	api.CustomVMTags = vlabs.CustomVMTags
+	api.DNSConfig = convertVLabsNodeDNSConfig(vlabs.DNSConfig)
+	api.ScheduledEventsProfile = convertVLabsScheduledEventsProfile(vlabs.ScheduledEventsProfile)
 	api.OSDiskSizeGB = vlabs.OSDiskSizeGB
 	api.DNSPrefix = vlabs.DNSPrefix
 	api.OSType = OSType(vlabs.OSType)
@@ -1041,10 +1215,17 @@ func convertVLabsAgentPoolProfile(vlabs *vlabs.AgentPoolProfile, api *AgentPoolP
 	api.AvailabilityProfile = vlabs.AvailabilityProfile
 	api.ScaleSetPriority = vlabs.ScaleSetPriority
 	api.ScaleSetEvictionPolicy = vlabs.ScaleSetEvictionPolicy
+	api.SpotMaxPrice = vlabs.SpotMaxPrice
 	api.StorageProfile = vlabs.StorageProfile
+	api.OSDiskType = vlabs.OSDiskType
 	api.DiskSizesGB = []int{}
 	api.DiskSizesGB = append(api.DiskSizesGB, vlabs.DiskSizesGB...)
+	api.DataDiskStorageAccountType = vlabs.DataDiskStorageAccountType
+	api.DataDiskIOPSReadWrite = vlabs.DataDiskIOPSReadWrite
+	api.DataDiskMBpsReadWrite = vlabs.DataDiskMBpsReadWrite
+	api.EnableSharedDisk = vlabs.EnableSharedDisk
 	api.VnetSubnetID = vlabs.VnetSubnetID
+	api.PodSubnetID = vlabs.PodSubnetID
 	api.Subnet = vlabs.GetSubnet()
 	api.IPAddressCount = vlabs.IPAddressCount
 	api.FQDN = vlabs.FQDN
@@ -1052,9 +1233,15 @@ func convertVLabsAgentPoolProfile(vlabs *vlabs.AgentPoolProfile, api *AgentPoolP
 	api.AcceleratedNetworkingEnabledWindows = vlabs.AcceleratedNetworkingEnabledWindows
 	api.VMSSOverProvisioningEnabled = vlabs.VMSSOverProvisioningEnabled
 	api.AvailabilityZones = vlabs.AvailabilityZones
+	api.ZoneBalanceStrategy = vlabs.ZoneBalanceStrategy
 	api.SinglePlacementGroup = vlabs.SinglePlacementGroup
+	api.ProximityPlacementGroupID = vlabs.ProximityPlacementGroupID
+	api.CreateProximityPlacementGroup = vlabs.CreateProximityPlacementGroup
+	api.HostGroupID = vlabs.HostGroupID
+	api.PlatformFaultDomainCount = vlabs.PlatformFaultDomainCount
 	api.EnableVMSSNodePublicIP = vlabs.EnableVMSSNodePublicIP
 	api.LoadBalancerBackendAddressPoolIDs = vlabs.LoadBalancerBackendAddressPoolIDs
+	api.UserAssignedID = vlabs.UserAssignedID
 	api.AuditDEnabled = vlabs.AuditDEnabled
 
 	api.CustomNodeLabels = map[string]string{}
@@ -1074,6 +1261,7 @@ func convertVLabsAgentPoolProfile(vlabs *vlabs.AgentPoolProfile, api *AgentPoolP
 		convertVLabsExtension(&extension, apiExtension)
 		api.Extensions = append(api.Extensions, *apiExtension)
 	}
+	api.VMExtensions = convertVLabsVMExtensions(vlabs.VMExtensions)
 	api.Distro = Distro(vlabs.Distro)
 	if vlabs.KubernetesConfig != nil {
 		api.KubernetesConfig = &KubernetesConfig{}
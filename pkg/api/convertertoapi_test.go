@@ -956,6 +956,10 @@ func TestConvertVLabsContainerService(t *testing.T) {
 				PublicKey:      ValidSSHPublicKey,
 				StorageProfile: StorageAccount,
 			},
+			PrivateLink: &vlabs.PrivateLinkProfile{
+				Enabled:                 to.BoolPtr(false),
+				PrivateEndpointSubnetID: "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME",
+			},
 		},
 		PodSecurityPolicyConfig: map[string]string{
 			"samplePSPConfigKey": "samplePSPConfigVal",
@@ -85,6 +85,18 @@ func (cs *ContainerService) setAPIServerConfig() {
 		defaultAPIServerConfig["--oidc-issuer-url"] = "https://" + issuerHost + "/" + cs.Properties.AADProfile.TenantID + "/"
 	}
 
+	// Workload identity federation, Azure AD verifies the service account tokens with the discovery document at the issuer URL
+	if o.KubernetesConfig.IsOIDCIssuerEnabled() {
+		staticAPIServerConfig["--service-account-issuer"] = o.KubernetesConfig.OIDCIssuerProfile.IssuerURL
+		staticAPIServerConfig["--service-account-signing-key-file"] = "/etc/kubernetes/certs/apiserver.key"
+		defaultAPIServerConfig["--api-audiences"] = o.KubernetesConfig.OIDCIssuerProfile.IssuerURL
+	}
+
+	// The API server reaches the nodes through the konnectivity-server of its master
+	if o.KubernetesConfig.IsKonnectivityEnabled() {
+		staticAPIServerConfig["--egress-selector-config-file"] = "/etc/kubernetes/egress-selector-configuration.yaml"
+	}
+
 	// Audit Policy configuration
 	if common.IsKubernetesVersionGe(o.OrchestratorVersion, "1.8.0") {
 		defaultAPIServerConfig["--audit-policy-file"] = "/etc/kubernetes/addons/audit-policy.yaml"
@@ -108,6 +120,11 @@ func (cs *ContainerService) setAPIServerConfig() {
 	admissionControlKey, admissionControlValues := getDefaultAdmissionControls(cs)
 	defaultAPIServerConfig[admissionControlKey] = admissionControlValues
 
+	// Size the request limits for the cluster
+	for key, val := range cs.Properties.getSizingProfile().apiServerConfig {
+		defaultAPIServerConfig[key] = val
+	}
+
 	// If no user-configurable apiserver config values exists, use the defaults
 	if o.KubernetesConfig.APIServerConfig == nil {
 		o.KubernetesConfig.APIServerConfig = defaultAPIServerConfig
@@ -128,6 +145,11 @@ func (cs *ContainerService) setAPIServerConfig() {
 		}
 	}
 
+	// The flags of the manifest patches override the user-configurable values
+	for key, val := range o.KubernetesConfig.GetManifestPatchArgs(KubeAPIServerComponentName) {
+		o.KubernetesConfig.APIServerConfig[key] = val
+	}
+
 	// We don't support user-configurable values for the following,
 	// so any of the value assignments below will override user-provided values
 	for key, val := range staticAPIServerConfig {
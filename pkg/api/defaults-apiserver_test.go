@@ -136,6 +136,69 @@ func TestAPIServerConfigUseCloudControllerManager(t *testing.T) {
 	}
 }
 
+func TestAPIServerConfigOIDCIssuer(t *testing.T) {
+	// Test OIDCIssuerProfile enabled
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.OIDCIssuerProfile = &OIDCIssuerProfile{
+		Enabled:   to.BoolPtr(true),
+		IssuerURL: "https://oidcissuer.blob.core.windows.net/testcluster/",
+	}
+	cs.setAPIServerConfig()
+	a := cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if a["--service-account-issuer"] != "https://oidcissuer.blob.core.windows.net/testcluster/" {
+		t.Fatalf("got unexpected '--service-account-issuer' API server config value for OIDCIssuerProfile enabled: %s",
+			a["--service-account-issuer"])
+	}
+	if a["--service-account-signing-key-file"] != "/etc/kubernetes/certs/apiserver.key" {
+		t.Fatalf("got unexpected '--service-account-signing-key-file' API server config value for OIDCIssuerProfile enabled: %s",
+			a["--service-account-signing-key-file"])
+	}
+	if a["--api-audiences"] != "https://oidcissuer.blob.core.windows.net/testcluster/" {
+		t.Fatalf("got unexpected '--api-audiences' API server config value for OIDCIssuerProfile enabled: %s",
+			a["--api-audiences"])
+	}
+
+	// Test OIDCIssuerProfile disabled
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.OIDCIssuerProfile = &OIDCIssuerProfile{
+		Enabled: to.BoolPtr(false),
+	}
+	cs.setAPIServerConfig()
+	a = cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	for _, key := range []string{"--service-account-issuer", "--service-account-signing-key-file", "--api-audiences"} {
+		if _, ok := a[key]; ok {
+			t.Fatalf("got unexpected '%s' API server config value for OIDCIssuerProfile disabled: %s",
+				key, a[key])
+		}
+	}
+}
+
+func TestAPIServerConfigKonnectivity(t *testing.T) {
+	// Test KonnectivityProfile enabled
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.KonnectivityProfile = &KonnectivityProfile{
+		Enabled: to.BoolPtr(true),
+	}
+	cs.setAPIServerConfig()
+	a := cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if a["--egress-selector-config-file"] != "/etc/kubernetes/egress-selector-configuration.yaml" {
+		t.Fatalf("got unexpected '--egress-selector-config-file' API server config value for KonnectivityProfile enabled: %s",
+			a["--egress-selector-config-file"])
+	}
+
+	// Test KonnectivityProfile disabled
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.KonnectivityProfile = &KonnectivityProfile{
+		Enabled: to.BoolPtr(false),
+	}
+	cs.setAPIServerConfig()
+	a = cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if _, ok := a["--egress-selector-config-file"]; ok {
+		t.Fatalf("got unexpected '--egress-selector-config-file' API server config value for KonnectivityProfile disabled: %s",
+			a["--egress-selector-config-file"])
+	}
+}
+
 func TestAPIServerConfigHasAadProfile(t *testing.T) {
 	// Test HasAadProfile = true
 	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
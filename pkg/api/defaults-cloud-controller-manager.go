@@ -45,6 +45,11 @@ func (cs *ContainerService) setCloudControllerManagerConfig() {
 		}
 	}
 
+	// The flags of the manifest patches override the user-configurable values
+	for key, val := range o.KubernetesConfig.GetManifestPatchArgs(CloudControllerManagerComponentName) {
+		o.KubernetesConfig.CloudControllerManagerConfig[key] = val
+	}
+
 	// We don't support user-configurable values for the following,
 	// so any of the value assignments below will override user-provided values
 	for key, val := range staticCloudControllerManagerConfig {
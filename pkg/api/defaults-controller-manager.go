@@ -48,6 +48,11 @@ func (cs *ContainerService) setControllerManagerConfig() {
 		"--profiling":                       DefaultKubernetesCtrMgrEnableProfiling,
 	}
 
+	// Size the API client rate limits for the cluster
+	for key, val := range cs.Properties.getSizingProfile().controllerManagerConfig {
+		defaultControllerManagerConfig[key] = val
+	}
+
 	// If no user-configurable controller-manager config values exists, use the defaults
 	if o.KubernetesConfig.ControllerManagerConfig == nil {
 		o.KubernetesConfig.ControllerManagerConfig = defaultControllerManagerConfig
@@ -67,6 +72,11 @@ func (cs *ContainerService) setControllerManagerConfig() {
 	// Enable the consumption of local ephemeral storage and also the sizeLimit property of an emptyDir volume.
 	addDefaultFeatureGates(o.KubernetesConfig.ControllerManagerConfig, o.OrchestratorVersion, "1.10.0", "LocalStorageCapacityIsolation=true")
 
+	// The flags of the manifest patches override the user-configurable values
+	for key, val := range o.KubernetesConfig.GetManifestPatchArgs(KubeControllerManagerComponentName) {
+		o.KubernetesConfig.ControllerManagerConfig[key] = val
+	}
+
 	// We don't support user-configurable values for the following,
 	// so any of the value assignments below will override user-provided values
 	for key, val := range staticControllerManagerConfig {
@@ -6,11 +6,9 @@ package api
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"strings"
-	"time"
 
+	"github.com/Azure/aks-engine/pkg/api/persona"
 	"github.com/Azure/aks-engine/pkg/helpers"
 	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/pkg/errors"
@@ -54,17 +52,9 @@ func (cs *ContainerService) SetCustomCloudProfileEnvironment() error {
 			metadataURL := fmt.Sprintf("%s/metadata/endpoints?api-version=1.0", strings.TrimSuffix(env.ResourceManagerEndpoint, "/"))
 
 			// Retrieve the metadata
-			httpClient := &http.Client{
-				Timeout: 30 * time.Second,
-			}
-			endpointsresp, err := httpClient.Get(metadataURL)
-			if err != nil || endpointsresp.StatusCode != 200 {
-				return fmt.Errorf("%s . apimodel invalid: failed to retrieve Azure Stack endpoints from %s", err, metadataURL)
-			}
-
-			body, err := ioutil.ReadAll(endpointsresp.Body)
+			body, err := persona.Current().Get(metadataURL)
 			if err != nil {
-				return fmt.Errorf("%s . apimodel invalid: failed to read the response from %s", err, metadataURL)
+				return fmt.Errorf("%s . apimodel invalid: failed to retrieve Azure Stack endpoints from %s", err, metadataURL)
 			}
 
 			endpoints := AzureStackMetadataEndpoints{}
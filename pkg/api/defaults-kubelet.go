@@ -4,6 +4,7 @@
 package api
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -29,6 +30,12 @@ func (cs *ContainerService) setKubeletConfig(isUpgrade bool) {
 		"--tls-private-key-file":        "/etc/kubernetes/certs/kubeletserver.key",
 	}
 
+	// containerd is reached over CRI instead of the built-in docker shim
+	if o.KubernetesConfig.NeedsContainerd() {
+		staticLinuxKubeletConfig["--container-runtime"] = "remote"
+		staticLinuxKubeletConfig["--container-runtime-endpoint"] = DefaultContainerdLinuxEndpoint
+	}
+
 	for key := range staticLinuxKubeletConfig {
 		switch key {
 		case "--anonymous-auth", "--client-ca-file":
@@ -74,6 +81,11 @@ func (cs *ContainerService) setKubeletConfig(isUpgrade bool) {
 	staticWindowsKubeletConfig["--image-pull-progress-deadline"] = "20m"
 	staticWindowsKubeletConfig["--resolv-conf"] = "\"\"\"\""
 	staticWindowsKubeletConfig["--eviction-hard"] = "\"\"\"\""
+	if o.KubernetesConfig.NeedsContainerd() {
+		// kubletwin/pause is built with docker, containerd pulls the multi-arch pause image instead
+		staticWindowsKubeletConfig["--container-runtime-endpoint"] = DefaultContainerdWindowsEndpoint
+		staticWindowsKubeletConfig["--pod-infra-container-image"] = DefaultWindowsPauseImage
+	}
 
 	// Default Kubelet config
 	defaultKubeletConfig := map[string]string{
@@ -97,6 +109,10 @@ func (cs *ContainerService) setKubeletConfig(isUpgrade bool) {
 		"--streaming-connection-idle-timeout": "4h",
 	}
 
+	if o.KubernetesConfig.NeedsContainerd() {
+		defaultKubeletConfig["--runtime-request-timeout"] = "15m"
+	}
+
 	// Set --non-masquerade-cidr if ip-masq-agent is disabled on AKS
 	if !cs.Properties.IsIPMasqAgentEnabled() {
 		defaultKubeletConfig["--non-masquerade-cidr"] = cs.Properties.OrchestratorProfile.KubernetesConfig.ClusterSubnet
@@ -160,7 +176,6 @@ func (cs *ContainerService) setKubeletConfig(isUpgrade bool) {
 	if cs.Properties.MasterProfile != nil {
 		if cs.Properties.MasterProfile.KubernetesConfig == nil {
 			cs.Properties.MasterProfile.KubernetesConfig = &KubernetesConfig{}
-			cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig = make(map[string]string)
 		}
 		setMissingKubeletValues(cs.Properties.MasterProfile.KubernetesConfig, o.KubernetesConfig.KubeletConfig)
 		addDefaultFeatureGates(cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig, o.OrchestratorVersion, "", "")
@@ -186,6 +201,8 @@ func (cs *ContainerService) setKubeletConfig(isUpgrade bool) {
 	for _, profile := range cs.Properties.AgentPoolProfiles {
 		if profile.KubernetesConfig == nil {
 			profile.KubernetesConfig = &KubernetesConfig{}
+		}
+		if profile.KubernetesConfig.KubeletConfig == nil {
 			profile.KubernetesConfig.KubeletConfig = make(map[string]string)
 		}
 
@@ -201,6 +218,11 @@ func (cs *ContainerService) setKubeletConfig(isUpgrade bool) {
 
 		setMissingKubeletValues(profile.KubernetesConfig, o.KubernetesConfig.KubeletConfig)
 
+		// Spot nodes only run the pods tolerating their eviction
+		if profile.IsSpotScaleSet() {
+			addDefaultTaint(profile.KubernetesConfig.KubeletConfig, ScaleSetPriorityLabelKey, ScaleSetPrioritySpotLabelValue, "NoSchedule")
+		}
+
 		// For N Series (GPU) VMs
 		if strings.Contains(profile.VMSize, "Standard_N") {
 			if !cs.Properties.IsNVIDIADevicePluginEnabled() && !common.IsKubernetesVersionGe(o.OrchestratorVersion, "1.11.0") {
@@ -257,16 +279,34 @@ func removeKubeletFlags(k map[string]string, v string) {
 	}
 }
 
+// setMissingKubeletValues merges the cluster kubelet config d into the kubelet config of a master or agent pool profile,
+// the values of the profile overriding the cluster ones. An empty value unsets the cluster value on the profile.
 func setMissingKubeletValues(p *KubernetesConfig, d map[string]string) {
 	if p.KubeletConfig == nil {
-		p.KubeletConfig = d
-	} else {
-		for key, val := range d {
-			// If we don't have a user-configurable value for each option
-			if _, ok := p.KubeletConfig[key]; !ok {
-				// then assign the default value
-				p.KubeletConfig[key] = val
-			}
+		// copy the cluster config rather than sharing it, the profile config is changed afterwards
+		p.KubeletConfig = make(map[string]string, len(d))
+	}
+	for key, val := range d {
+		// If we don't have a user-configurable value for each option
+		if _, ok := p.KubeletConfig[key]; !ok {
+			// then assign the default value
+			p.KubeletConfig[key] = val
+		}
+	}
+}
+
+// addDefaultTaint adds a taint to the --register-with-taints of a kubelet config, unless it already registers a taint with the same key
+func addDefaultTaint(m map[string]string, key, value, effect string) {
+	var taints []string
+	for _, t := range strings.Split(m["--register-with-taints"], ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if strings.HasPrefix(t, key+"=") || strings.HasPrefix(t, key+":") {
+			return
 		}
+		taints = append(taints, t)
 	}
+	m["--register-with-taints"] = strings.Join(append(taints, fmt.Sprintf("%s=%s:%s", key, value, effect)), ",")
 }
@@ -662,6 +662,153 @@ func TestStaticWindowsConfig(t *testing.T) {
 	}
 }
 
+func TestKubeletConfigContainerd(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 1, false)
+	p := GetK8sDefaultProperties(true)
+	cs.Properties = p
+	cs.Properties.OrchestratorProfile.KubernetesConfig.ContainerRuntime = Containerd
+	cs.setKubeletConfig(false)
+
+	expected := map[string]string{
+		"--container-runtime":          "remote",
+		"--container-runtime-endpoint": DefaultContainerdLinuxEndpoint,
+		"--runtime-request-timeout":    "15m",
+	}
+	for _, k := range []map[string]string{cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig, cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig} {
+		for key, val := range expected {
+			if k[key] != val {
+				t.Fatalf("got unexpected '%s' kubelet config value, expected %s, got %s", key, val, k[key])
+			}
+		}
+	}
+
+	for _, profile := range cs.Properties.AgentPoolProfiles {
+		expected["--container-runtime-endpoint"] = DefaultContainerdLinuxEndpoint
+		expected["--pod-infra-container-image"] = cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig["--pod-infra-container-image"]
+		if profile.OSType == Windows {
+			expected["--container-runtime-endpoint"] = DefaultContainerdWindowsEndpoint
+			expected["--pod-infra-container-image"] = DefaultWindowsPauseImage
+		}
+		for key, val := range expected {
+			if profile.KubernetesConfig.KubeletConfig[key] != val {
+				t.Fatalf("got unexpected '%s' kubelet config value for %s agent pool, expected %s, got %s", key, profile.OSType, val, profile.KubernetesConfig.KubeletConfig[key])
+			}
+		}
+	}
+
+	// docker is the default container runtime, reached through the built-in docker shim
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 1, false)
+	cs.setKubeletConfig(false)
+	for _, key := range []string{"--container-runtime", "--container-runtime-endpoint", "--runtime-request-timeout"} {
+		if val, ok := cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig[key]; ok {
+			t.Fatalf("got unexpected '%s' kubelet config value %s for docker", key, val)
+		}
+	}
+}
+
+func TestKubeletConfigAgentPoolOverrides(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 3, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig = map[string]string{
+		"--eviction-hard": "memory.available<250Mi",
+		"--kube-reserved": "cpu=100m,memory=512Mi",
+	}
+	cs.Properties.AgentPoolProfiles = []*AgentPoolProfile{
+		{
+			Name:   "gpu",
+			VMSize: "Standard_NC6",
+			KubernetesConfig: &KubernetesConfig{
+				KubeletConfig: map[string]string{
+					"--eviction-hard": "memory.available<1Gi",
+					"--kube-reserved": "",
+				},
+			},
+		},
+		{
+			Name:             "nokubeletconfig",
+			KubernetesConfig: &KubernetesConfig{},
+		},
+		{
+			Name: "default",
+		},
+	}
+	cs.setKubeletConfig(false)
+
+	gpu := cs.Properties.AgentPoolProfiles[0].KubernetesConfig.KubeletConfig
+	if gpu["--eviction-hard"] != "memory.available<1Gi" {
+		t.Fatalf("expected the agent pool --eviction-hard to override the cluster value, got %s", gpu["--eviction-hard"])
+	}
+	if val, ok := gpu["--kube-reserved"]; ok {
+		t.Fatalf("expected the empty agent pool --kube-reserved to unset the cluster value, got %s", val)
+	}
+	if gpu["--cluster-domain"] != "cluster.local" {
+		t.Fatalf("expected the agent pool to inherit the cluster defaults, got --cluster-domain %s", gpu["--cluster-domain"])
+	}
+	for _, profile := range cs.Properties.AgentPoolProfiles[1:] {
+		k := profile.KubernetesConfig.KubeletConfig
+		if k["--eviction-hard"] != "memory.available<250Mi" || k["--kube-reserved"] != "cpu=100m,memory=512Mi" {
+			t.Fatalf("expected agent pool %s to inherit the cluster kubelet config, got --eviction-hard %s and --kube-reserved %s", profile.Name, k["--eviction-hard"], k["--kube-reserved"])
+		}
+	}
+
+	// the agent pools must not share the cluster kubelet config
+	cs.Properties.AgentPoolProfiles[1].KubernetesConfig.KubeletConfig["--max-pods"] = "50"
+	for _, k := range []map[string]string{cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig, cs.Properties.AgentPoolProfiles[2].KubernetesConfig.KubeletConfig} {
+		if k["--max-pods"] == "50" {
+			t.Fatalf("expected the agent pool kubelet config to be a copy of the cluster kubelet config")
+		}
+	}
+}
+
+func TestKubeletConfigSpotTaint(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 3, false)
+	cs.Properties.AgentPoolProfiles = []*AgentPoolProfile{
+		{
+			Name:                "spot",
+			AvailabilityProfile: VirtualMachineScaleSets,
+			ScaleSetPriority:    ScaleSetPrioritySpot,
+		},
+		{
+			Name:                "spottainted",
+			AvailabilityProfile: VirtualMachineScaleSets,
+			ScaleSetPriority:    ScaleSetPrioritySpot,
+			KubernetesConfig: &KubernetesConfig{
+				KubeletConfig: map[string]string{
+					"--register-with-taints": "workload=batch:NoSchedule",
+				},
+			},
+		},
+		{
+			Name:                "spotcustomtaint",
+			AvailabilityProfile: VirtualMachineScaleSets,
+			ScaleSetPriority:    ScaleSetPrioritySpot,
+			KubernetesConfig: &KubernetesConfig{
+				KubeletConfig: map[string]string{
+					"--register-with-taints": "kubernetes.azure.com/scalesetpriority=spot:PreferNoSchedule",
+				},
+			},
+		},
+		{
+			Name:                "regular",
+			AvailabilityProfile: VirtualMachineScaleSets,
+		},
+	}
+	cs.setKubeletConfig(false)
+	// setting the kubelet config again, e.g. on upgrade, does not add the taint twice
+	cs.setKubeletConfig(true)
+
+	expected := []string{
+		"kubernetes.azure.com/scalesetpriority=spot:NoSchedule",
+		"workload=batch:NoSchedule,kubernetes.azure.com/scalesetpriority=spot:NoSchedule",
+		"kubernetes.azure.com/scalesetpriority=spot:PreferNoSchedule",
+		"",
+	}
+	for i, profile := range cs.Properties.AgentPoolProfiles {
+		if taints := profile.KubernetesConfig.KubeletConfig["--register-with-taints"]; taints != expected[i] {
+			t.Fatalf("expected agent pool %s to register with taints %q, got %q", profile.Name, expected[i], taints)
+		}
+	}
+}
+
 func TestKubeletRotateCertificates(t *testing.T) {
 	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
 	cs.setKubeletConfig(false)
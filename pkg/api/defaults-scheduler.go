@@ -31,6 +31,18 @@ func (cs *ContainerService) setSchedulerConfig() {
 		}
 	}
 
+	for key, val := range cs.Properties.getSizingProfile().schedulerConfig {
+		// The sizing profile defaults are user-overridable too
+		if _, ok := o.KubernetesConfig.SchedulerConfig[key]; !ok {
+			o.KubernetesConfig.SchedulerConfig[key] = val
+		}
+	}
+
+	// The flags of the manifest patches override the user-configurable values
+	for key, val := range o.KubernetesConfig.GetManifestPatchArgs(KubeSchedulerComponentName) {
+		o.KubernetesConfig.SchedulerConfig[key] = val
+	}
+
 	// We don't support user-configurable values for the following,
 	// so any of the value assignments below will override user-provided values
 	for key, val := range staticSchedulerConfig {
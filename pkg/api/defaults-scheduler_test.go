@@ -82,3 +82,26 @@ func TestSchedulerConfigEnableProfiling(t *testing.T) {
 			s["--profiling"])
 	}
 }
+
+func TestSchedulerManifestPatchConfig(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", "1.9.6", 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.SchedulerConfig = map[string]string{
+		"--v": "3",
+	}
+	cs.Properties.OrchestratorProfile.KubernetesConfig.ManifestPatches = []ManifestPatch{
+		{Component: KubeSchedulerComponentName, ExtraArgs: map[string]string{"--v": "4", "--leader-elect": "false"}},
+		{Component: KubeSchedulerComponentName, ExtraArgs: map[string]string{"--v": "5"}},
+		{Component: KubeAPIServerComponentName, ExtraArgs: map[string]string{"--profiling": "true"}},
+	}
+	cs.setSchedulerConfig()
+	s := cs.Properties.OrchestratorProfile.KubernetesConfig.SchedulerConfig
+	if s["--v"] != "5" {
+		t.Fatalf("expected the last manifest patch to override the kube-scheduler --v config, got %s", s["--v"])
+	}
+	if s["--leader-elect"] != staticSchedulerConfig["--leader-elect"] {
+		t.Fatalf("kube-scheduler static config did not override the manifest patch --leader-elect, got %s", s["--leader-elect"])
+	}
+	if s["--profiling"] != defaultSchedulerConfig["--profiling"] {
+		t.Fatalf("expected the kube-apiserver manifest patch not to apply to kube-scheduler, got --profiling %s", s["--profiling"])
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/go-autorest/autorest/to"
 
 	"github.com/Azure/aks-engine/pkg/api/common"
@@ -76,6 +77,18 @@ func (cs *ContainerService) SetPropertiesDefaults(isUpgrade, isScale bool) (bool
 	return certsGenerated, nil
 }
 
+// getOIDCIssuerBlobContainerURL returns the endpoint of the blob container the OpenID Connect discovery document is uploaded to
+func (cs *ContainerService) getOIDCIssuerBlobContainerURL() string {
+	p := cs.Properties.OrchestratorProfile.KubernetesConfig.OIDCIssuerProfile
+	storageEndpointSuffix := azure.PublicCloud.StorageEndpointSuffix
+	if cs.Properties.IsAzureStackCloud() && cs.Properties.CustomCloudProfile.Environment != nil {
+		storageEndpointSuffix = cs.Properties.CustomCloudProfile.Environment.StorageEndpointSuffix
+	} else if env, err := azure.EnvironmentFromName(cs.GetCloudSpecConfig().CloudName); err == nil {
+		storageEndpointSuffix = env.StorageEndpointSuffix
+	}
+	return fmt.Sprintf("https://%s.blob.%s/%s/", p.StorageAccountName, storageEndpointSuffix, p.ContainerName)
+}
+
 // setOrchestratorDefaults for orchestrators
 func (cs *ContainerService) setOrchestratorDefaults(isUpgrade, isScale bool) {
 	isUpdate := isUpgrade || isScale
@@ -115,6 +128,8 @@ func (cs *ContainerService) setOrchestratorDefaults(isUpgrade, isScale bool) {
 			}
 		case NetworkPolicyCilium:
 			o.KubernetesConfig.NetworkPlugin = NetworkPluginCilium
+		case NetworkPolicyAntrea:
+			o.KubernetesConfig.NetworkPlugin = NetworkPluginAntrea
 		}
 
 		if o.KubernetesConfig.KubernetesImageBase == "" {
@@ -342,6 +357,32 @@ func (cs *ContainerService) setOrchestratorDefaults(isUpgrade, isScale bool) {
 			a.OrchestratorProfile.KubernetesConfig.OutboundRuleIdleTimeoutInMinutes == 0 {
 			a.OrchestratorProfile.KubernetesConfig.OutboundRuleIdleTimeoutInMinutes = DefaultOutboundRuleIdleTimeoutInMinutes
 		}
+		if a.OrchestratorProfile.KubernetesConfig.LoadBalancerSku == StandardLoadBalancerSku &&
+			a.OrchestratorProfile.KubernetesConfig.OutboundType == "" {
+			a.OrchestratorProfile.KubernetesConfig.OutboundType = DefaultOutboundType
+		}
+
+		if a.OrchestratorProfile.KubernetesConfig.IsOIDCIssuerEnabled() && a.OrchestratorProfile.KubernetesConfig.OIDCIssuerProfile.IssuerURL == "" {
+			a.OrchestratorProfile.KubernetesConfig.OIDCIssuerProfile.IssuerURL = cs.getOIDCIssuerBlobContainerURL()
+		}
+
+		if a.OrchestratorProfile.KubernetesConfig.IsKonnectivityEnabled() && a.OrchestratorProfile.KubernetesConfig.KonnectivityProfile.ServerImage == "" {
+			a.OrchestratorProfile.KubernetesConfig.KonnectivityProfile.ServerImage = DefaultKonnectivityServerImage
+		}
+
+		// the nodes of an air-gapped cluster must not check or have outbound internet access
+		if a.OrchestratorProfile.KubernetesConfig.IsAirGapped() {
+			if a.FeatureFlags == nil {
+				a.FeatureFlags = &FeatureFlags{}
+			}
+			a.FeatureFlags.BlockOutboundInternet = true
+		}
+
+		for i := range a.OrchestratorProfile.KubernetesConfig.CustomManifests {
+			if a.OrchestratorProfile.KubernetesConfig.CustomManifests[i].Mode == "" {
+				a.OrchestratorProfile.KubernetesConfig.CustomManifests[i].Mode = AddonManagerModeReconcile
+			}
+		}
 
 		// First, Configure addons
 		cs.setAddonsConfig(isUpdate)
@@ -377,14 +418,25 @@ func (cs *ContainerService) setOrchestratorDefaults(isUpgrade, isScale bool) {
 }
 
 func (p *Properties) setExtensionDefaults() {
-	if p.ExtensionProfiles == nil {
-		return
-	}
 	for _, extension := range p.ExtensionProfiles {
 		if extension.RootURL == "" {
 			extension.RootURL = DefaultExtensionsRootURL
 		}
 	}
+	if p.MasterProfile != nil {
+		setVMExtensionDefaults(p.MasterProfile.VMExtensions)
+	}
+	for _, profile := range p.AgentPoolProfiles {
+		setVMExtensionDefaults(profile.VMExtensions)
+	}
+}
+
+func setVMExtensionDefaults(extensions []VMExtension) {
+	for i := range extensions {
+		if extensions[i].AutoUpgradeMinorVersion == nil {
+			extensions[i].AutoUpgradeMinorVersion = to.BoolPtr(DefaultVMExtensionAutoUpgradeMinorVersion)
+		}
+	}
 }
 
 func (p *Properties) setMasterProfileDefaults(isUpgrade, isScale bool, cloudName string) {
@@ -494,6 +546,10 @@ func (p *Properties) setMasterProfileDefaults(isUpgrade, isScale bool, cloudName
 			p.MasterProfile.FirstConsecutiveStaticIP = p.MasterProfile.GetFirstConsecutiveStaticIPAddress(p.MasterProfile.VnetCidr)
 		}
 	}
+	// The etcd NICs of the masters get consecutive static IPs in the etcd subnet, the same way as their primary NICs
+	if p.MasterProfile.HasDedicatedEtcdSubnet() && len(p.MasterProfile.EtcdFirstConsecutiveStaticIP) == 0 {
+		p.MasterProfile.EtcdFirstConsecutiveStaticIP = p.MasterProfile.GetFirstConsecutiveStaticIPAddress(p.MasterProfile.EtcdSubnet)
+	}
 	// Set the default number of IP addresses allocated for masters.
 	if p.MasterProfile.IPAddressCount == 0 {
 		// Allocate one IP address for the node.
@@ -580,6 +636,11 @@ func (p *Properties) setAgentProfileDefaults(isUpgrade, isScale bool, cloudName
 			profile.PlatformFaultDomainCount = to.IntPtr(DefaultAzureStackFaultDomainCount)
 		}
 
+		// A scale set with one fault domain fits any host group, its VMs are spread across the fault domains of the host group
+		if profile.HasHostGroup() && profile.PlatformFaultDomainCount == nil {
+			profile.PlatformFaultDomainCount = to.IntPtr(DefaultHostGroupFaultDomainCount)
+		}
+
 		// Accelerated Networking is supported on most general purpose and compute-optimized instance sizes with 2 or more vCPUs.
 		// These supported series are: D/DSv2 and F/Fs // All the others are not supported
 		// On instances that support hyperthreading, Accelerated Networking is supported on VM instances with 4 or more vCPUs.
@@ -609,6 +670,12 @@ func (p *Properties) setAgentProfileDefaults(isUpgrade, isScale bool, cloudName
 			profile.AuditDEnabled = to.BoolPtr(DefaultAuditDEnabled && !isUpgrade && !isScale)
 		}
 
+		// the scale set of a pool with the handler announces the deletion of its VMs, so that scale-in drains the nodes
+		if profile.IsScheduledEventsHandlerEnabled() && profile.AvailabilityProfile == VirtualMachineScaleSets &&
+			profile.ScheduledEventsProfile.TerminateNotificationTimeoutMinutes == 0 && !p.IsAzureStackCloud() {
+			profile.ScheduledEventsProfile.TerminateNotificationTimeoutMinutes = DefaultTerminateNotificationTimeoutMinutes
+		}
+
 		if profile.OSType != Windows {
 			if profile.Distro == "" {
 				if p.OrchestratorProfile.IsKubernetes() {
@@ -737,9 +804,12 @@ func (p *Properties) setStorageDefaults() {
 				profile.AvailabilityProfile = AvailabilitySet
 			}
 		}
-		if len(profile.ScaleSetEvictionPolicy) == 0 && profile.ScaleSetPriority == ScaleSetPriorityLow {
+		if len(profile.ScaleSetEvictionPolicy) == 0 && (profile.ScaleSetPriority == ScaleSetPriorityLow || profile.ScaleSetPriority == ScaleSetPrioritySpot) {
 			profile.ScaleSetEvictionPolicy = ScaleSetEvictionPolicyDelete
 		}
+		if profile.SpotMaxPrice == nil && profile.ScaleSetPriority == ScaleSetPrioritySpot {
+			profile.SpotMaxPrice = to.Float64Ptr(DefaultSpotMaxPrice)
+		}
 	}
 }
 
@@ -760,9 +830,111 @@ func (cs *ContainerService) SetDefaultCerts() (bool, []net.IP, error) {
 		return false, nil, nil
 	}
 
+	masterExtraFQDNs, ips, err := cs.certificateSubjectAltNames()
+	if err != nil {
+		return false, nil, err
+	}
+	if p.CertificateProfile == nil {
+		p.CertificateProfile = &CertificateProfile{}
+	}
+
+	// use the specified Certificate Authority pair, or generate p new pair
+	var caPair *helpers.PkiKeyCertPair
+	if provided["ca"] {
+		caPair = p.CertificateProfile.caKeyCertPair()
+	} else {
+		var err error
+		caPair, err = helpers.CreatePkiKeyCertPair("ca")
+		if err != nil {
+			return false, ips, err
+		}
+		p.CertificateProfile.CaCertificate = caPair.CertificatePem
+		p.CertificateProfile.CaPrivateKey = caPair.PrivateKeyPem
+	}
+
+	apiServerPair, clientPair, kubeConfigPair, etcdServerPair, etcdClientPair, etcdPeerPairs, err := helpers.CreatePki(masterExtraFQDNs, ips, DefaultKubernetesClusterDomain, caPair, p.MasterProfile.Count)
+	if err != nil {
+		return false, ips, err
+	}
+
+	// If no Certificate Authority pair or no cert/key pair was provided, use generated cert/key pairs signed by provided Certificate Authority pair
+	if !provided["apiserver"] || !provided["ca"] {
+		p.CertificateProfile.APIServerCertificate = apiServerPair.CertificatePem
+		p.CertificateProfile.APIServerPrivateKey = apiServerPair.PrivateKeyPem
+	}
+	if !provided["client"] || !provided["ca"] {
+		p.CertificateProfile.ClientCertificate = clientPair.CertificatePem
+		p.CertificateProfile.ClientPrivateKey = clientPair.PrivateKeyPem
+	}
+	if !provided["kubeconfig"] || !provided["ca"] {
+		p.CertificateProfile.KubeConfigCertificate = kubeConfigPair.CertificatePem
+		p.CertificateProfile.KubeConfigPrivateKey = kubeConfigPair.PrivateKeyPem
+	}
+	if !provided["etcd"] || !provided["ca"] {
+		p.CertificateProfile.setEtcdKeyCertPairs(etcdServerPair, etcdClientPair, etcdPeerPairs)
+	}
+
+	return true, ips, nil
+}
+
+// RenewEtcdCerts replaces the etcd server, client and peer certificates with new certificates signed by the
+// certificate authority of the cluster
+func (cs *ContainerService) RenewEtcdCerts() error {
+	p := cs.Properties
+	_, ips, err := cs.certificateSubjectAltNames()
+	if err != nil {
+		return err
+	}
+	etcdServerPair, etcdClientPair, etcdPeerPairs, err := helpers.CreateEtcdPki(ips, p.CertificateProfile.caKeyCertPair(), p.MasterProfile.Count)
+	if err != nil {
+		return err
+	}
+	p.CertificateProfile.setEtcdKeyCertPairs(etcdServerPair, etcdClientPair, etcdPeerPairs)
+	return nil
+}
+
+// RenewAPIServerCert replaces the apiserver certificate with a new certificate signed by the certificate authority of
+// the cluster. The apiserver private key signs the service account tokens, it is only replaced if newKey is true.
+func (cs *ContainerService) RenewAPIServerCert(newKey bool) error {
+	p := cs.Properties
+	extraFQDNs, ips, err := cs.certificateSubjectAltNames()
+	if err != nil {
+		return err
+	}
+	privateKey := p.CertificateProfile.APIServerPrivateKey
+	if newKey {
+		privateKey = ""
+	}
+	pair, err := helpers.CreateAPIServerKeyCertPair(extraFQDNs, ips, DefaultKubernetesClusterDomain, p.CertificateProfile.caKeyCertPair(), privateKey)
+	if err != nil {
+		return err
+	}
+	p.CertificateProfile.APIServerCertificate = pair.CertificatePem
+	p.CertificateProfile.APIServerPrivateKey = pair.PrivateKeyPem
+	return nil
+}
+
+// RenewKubeletCert replaces the client certificate of the kubelets with a new certificate signed by the certificate
+// authority of the cluster
+func (cs *ContainerService) RenewKubeletCert() error {
+	pair, err := helpers.CreateClientKeyCertPair(cs.Properties.CertificateProfile.caKeyCertPair())
+	if err != nil {
+		return err
+	}
+	cs.Properties.CertificateProfile.ClientCertificate = pair.CertificatePem
+	cs.Properties.CertificateProfile.ClientPrivateKey = pair.PrivateKeyPem
+	return nil
+}
+
+// certificateSubjectAltNames returns the FQDNs and IP addresses the certificates of the masters are valid for
+func (cs *ContainerService) certificateSubjectAltNames() ([]string, []net.IP, error) {
+	p := cs.Properties
 	var azureProdFQDNs []string
 	for _, location := range cs.GetLocations() {
 		azureProdFQDNs = append(azureProdFQDNs, FormatProdFQDNByLocation(p.MasterProfile.DNSPrefix, location, p.GetCustomCloudName()))
+		if p.OrchestratorProfile != nil && p.OrchestratorProfile.KubernetesConfig.PrivateLinkEnabled() {
+			azureProdFQDNs = append(azureProdFQDNs, FormatPrivateLinkFQDNByLocation(p.MasterProfile.DNSPrefix, location, p.GetCustomCloudName()))
+		}
 	}
 
 	masterExtraFQDNs := append(azureProdFQDNs, p.MasterProfile.SubjectAltNames...)
@@ -771,7 +943,7 @@ func (cs *ContainerService) SetDefaultCerts() (bool, []net.IP, error) {
 	localhostIP := net.ParseIP("127.0.0.1").To4()
 
 	if firstMasterIP == nil {
-		return false, nil, errors.Errorf("MasterProfile.FirstConsecutiveStaticIP '%s' is an invalid IP address", p.MasterProfile.FirstConsecutiveStaticIP)
+		return nil, nil, errors.Errorf("MasterProfile.FirstConsecutiveStaticIP '%s' is an invalid IP address", p.MasterProfile.FirstConsecutiveStaticIP)
 	}
 
 	ips := []net.IP{firstMasterIP, localhostIP}
@@ -797,62 +969,25 @@ func (cs *ContainerService) SetDefaultCerts() (bool, []net.IP, error) {
 		binary.BigEndian.PutUint32(ip, newAddr)
 		ips = append(ips, ip)
 	}
-	if p.CertificateProfile == nil {
-		p.CertificateProfile = &CertificateProfile{}
-	}
-
-	// use the specified Certificate Authority pair, or generate p new pair
-	var caPair *helpers.PkiKeyCertPair
-	if provided["ca"] {
-		caPair = &helpers.PkiKeyCertPair{CertificatePem: p.CertificateProfile.CaCertificate, PrivateKeyPem: p.CertificateProfile.CaPrivateKey}
-	} else {
-		var err error
-		caPair, err = helpers.CreatePkiKeyCertPair("ca")
-		if err != nil {
-			return false, ips, err
+	// etcd serves its peers and clients on the etcd NICs of the masters
+	if p.MasterProfile.HasDedicatedEtcdSubnet() {
+		firstEtcdIP := net.ParseIP(p.MasterProfile.EtcdFirstConsecutiveStaticIP).To4()
+		if firstEtcdIP == nil {
+			return nil, nil, errors.Errorf("MasterProfile.EtcdFirstConsecutiveStaticIP '%s' is an invalid IP address", p.MasterProfile.EtcdFirstConsecutiveStaticIP)
+		}
+		etcdAddr := binary.BigEndian.Uint32(firstEtcdIP)
+		for i := 0; i < p.MasterProfile.Count; i++ {
+			ip := make(net.IP, 4)
+			binary.BigEndian.PutUint32(ip, getNewAddr(etcdAddr, i, 1))
+			ips = append(ips, ip)
 		}
-		p.CertificateProfile.CaCertificate = caPair.CertificatePem
-		p.CertificateProfile.CaPrivateKey = caPair.PrivateKeyPem
 	}
-
 	cidrFirstIP, err := common.CidrStringFirstIP(p.OrchestratorProfile.KubernetesConfig.ServiceCIDR)
 	if err != nil {
-		return false, ips, err
+		return nil, nil, err
 	}
 	ips = append(ips, cidrFirstIP)
-
-	apiServerPair, clientPair, kubeConfigPair, etcdServerPair, etcdClientPair, etcdPeerPairs, err := helpers.CreatePki(masterExtraFQDNs, ips, DefaultKubernetesClusterDomain, caPair, p.MasterProfile.Count)
-	if err != nil {
-		return false, ips, err
-	}
-
-	// If no Certificate Authority pair or no cert/key pair was provided, use generated cert/key pairs signed by provided Certificate Authority pair
-	if !provided["apiserver"] || !provided["ca"] {
-		p.CertificateProfile.APIServerCertificate = apiServerPair.CertificatePem
-		p.CertificateProfile.APIServerPrivateKey = apiServerPair.PrivateKeyPem
-	}
-	if !provided["client"] || !provided["ca"] {
-		p.CertificateProfile.ClientCertificate = clientPair.CertificatePem
-		p.CertificateProfile.ClientPrivateKey = clientPair.PrivateKeyPem
-	}
-	if !provided["kubeconfig"] || !provided["ca"] {
-		p.CertificateProfile.KubeConfigCertificate = kubeConfigPair.CertificatePem
-		p.CertificateProfile.KubeConfigPrivateKey = kubeConfigPair.PrivateKeyPem
-	}
-	if !provided["etcd"] || !provided["ca"] {
-		p.CertificateProfile.EtcdServerCertificate = etcdServerPair.CertificatePem
-		p.CertificateProfile.EtcdServerPrivateKey = etcdServerPair.PrivateKeyPem
-		p.CertificateProfile.EtcdClientCertificate = etcdClientPair.CertificatePem
-		p.CertificateProfile.EtcdClientPrivateKey = etcdClientPair.PrivateKeyPem
-		p.CertificateProfile.EtcdPeerCertificates = make([]string, p.MasterProfile.Count)
-		p.CertificateProfile.EtcdPeerPrivateKeys = make([]string, p.MasterProfile.Count)
-		for i, v := range etcdPeerPairs {
-			p.CertificateProfile.EtcdPeerCertificates[i] = v.CertificatePem
-			p.CertificateProfile.EtcdPeerPrivateKeys[i] = v.PrivateKeyPem
-		}
-	}
-
-	return true, ips, nil
+	return masterExtraFQDNs, ips, nil
 }
 
 func areAllTrue(m map[string]bool) bool {
@@ -900,6 +1035,25 @@ func certsAlreadyPresent(c *CertificateProfile, m int) map[string]bool {
 	return g
 }
 
+// caKeyCertPair returns the certificate authority pair of the cluster
+func (c *CertificateProfile) caKeyCertPair() *helpers.PkiKeyCertPair {
+	return &helpers.PkiKeyCertPair{CertificatePem: c.CaCertificate, PrivateKeyPem: c.CaPrivateKey}
+}
+
+// setEtcdKeyCertPairs sets the etcd server, client and peer certificates and private keys
+func (c *CertificateProfile) setEtcdKeyCertPairs(server, client *helpers.PkiKeyCertPair, peers []*helpers.PkiKeyCertPair) {
+	c.EtcdServerCertificate = server.CertificatePem
+	c.EtcdServerPrivateKey = server.PrivateKeyPem
+	c.EtcdClientCertificate = client.CertificatePem
+	c.EtcdClientPrivateKey = client.PrivateKeyPem
+	c.EtcdPeerCertificates = make([]string, len(peers))
+	c.EtcdPeerPrivateKeys = make([]string, len(peers))
+	for i, v := range peers {
+		c.EtcdPeerCertificates[i] = v.CertificatePem
+		c.EtcdPeerPrivateKeys[i] = v.PrivateKeyPem
+	}
+}
+
 // combine user-provided --feature-gates vals with defaults
 // a minimum k8s version may be declared as required for defaults assignment
 func addDefaultFeatureGates(m map[string]string, version string, minVersion string, defaults string) {
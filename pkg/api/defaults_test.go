@@ -648,6 +648,41 @@ func TestAuditDEnabled(t *testing.T) {
 	}
 }
 
+func TestTerminateNotificationTimeout(t *testing.T) {
+	mockCS := getMockBaseContainerService("1.16.1")
+	mockCS.Properties.OrchestratorProfile.OrchestratorType = Kubernetes
+	mockCS.Properties.AgentPoolProfiles[0].AvailabilityProfile = VirtualMachineScaleSets
+	mockCS.Properties.AgentPoolProfiles[0].ScheduledEventsProfile = &ScheduledEventsProfile{Enabled: to.BoolPtr(true)}
+	mockCS.Properties.setAgentProfileDefaults(false, false, AzurePublicCloud)
+
+	// A scale set pool with the handler enabled is notified of scale-in by default
+	if timeout := mockCS.Properties.AgentPoolProfiles[0].ScheduledEventsProfile.TerminateNotificationTimeoutMinutes; timeout != DefaultTerminateNotificationTimeoutMinutes {
+		t.Errorf("expected the default terminate notification timeout to be %d, instead got %d", DefaultTerminateNotificationTimeoutMinutes, timeout)
+	}
+
+	mockCS = getMockBaseContainerService("1.16.1")
+	mockCS.Properties.OrchestratorProfile.OrchestratorType = Kubernetes
+	mockCS.Properties.AgentPoolProfiles[0].AvailabilityProfile = VirtualMachineScaleSets
+	mockCS.Properties.AgentPoolProfiles[0].ScheduledEventsProfile = &ScheduledEventsProfile{Enabled: to.BoolPtr(true), TerminateNotificationTimeoutMinutes: 10}
+	mockCS.Properties.setAgentProfileDefaults(false, false, AzurePublicCloud)
+
+	// An explicit timeout is kept
+	if timeout := mockCS.Properties.AgentPoolProfiles[0].ScheduledEventsProfile.TerminateNotificationTimeoutMinutes; timeout != 10 {
+		t.Errorf("expected the terminate notification timeout to be 10, instead got %d", timeout)
+	}
+
+	mockCS = getMockBaseContainerService("1.16.1")
+	mockCS.Properties.OrchestratorProfile.OrchestratorType = Kubernetes
+	mockCS.Properties.AgentPoolProfiles[0].AvailabilityProfile = AvailabilitySet
+	mockCS.Properties.AgentPoolProfiles[0].ScheduledEventsProfile = &ScheduledEventsProfile{Enabled: to.BoolPtr(true)}
+	mockCS.Properties.setAgentProfileDefaults(false, false, AzurePublicCloud)
+
+	// Availability sets are not notified of the deletion of their VMs
+	if timeout := mockCS.Properties.AgentPoolProfiles[0].ScheduledEventsProfile.TerminateNotificationTimeoutMinutes; timeout != 0 {
+		t.Errorf("expected no terminate notification timeout on an availability set, instead got %d", timeout)
+	}
+}
+
 func TestKubeletFeatureGatesEnsureFeatureGatesOnAgentsFor1_6_0(t *testing.T) {
 	mockCS := getMockBaseContainerService("1.6.0")
 	properties := mockCS.Properties
@@ -786,6 +821,16 @@ func TestNetworkPolicyDefaults(t *testing.T) {
 			properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin, NetworkPluginCilium)
 	}
 
+	mockCS = getMockBaseContainerService("1.16.1")
+	properties = mockCS.Properties
+	properties.OrchestratorProfile.OrchestratorType = Kubernetes
+	properties.OrchestratorProfile.KubernetesConfig.NetworkPolicy = NetworkPolicyAntrea
+	mockCS.setOrchestratorDefaults(true, true)
+	if properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin != NetworkPluginAntrea {
+		t.Fatalf("NetworkPlugin did not have the expected value, got %s, expected %s",
+			properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin, NetworkPluginAntrea)
+	}
+
 	mockCS = getMockBaseContainerService("1.8.10")
 	properties = mockCS.Properties
 	properties.OrchestratorProfile.OrchestratorType = Kubernetes
@@ -1303,6 +1348,22 @@ func TestMasterProfileDefaults(t *testing.T) {
 		t.Fatalf("OrchestratorProfile.KubernetesConfig.OutboundRuleIdleTimeoutInMinutes did not have the expected configuration, got %d, expected %d",
 			properties.OrchestratorProfile.KubernetesConfig.OutboundRuleIdleTimeoutInMinutes, DefaultOutboundRuleIdleTimeoutInMinutes)
 	}
+	if properties.OrchestratorProfile.KubernetesConfig.OutboundType != DefaultOutboundType {
+		t.Fatalf("OrchestratorProfile.KubernetesConfig.OutboundType did not have the expected configuration, got %s, expected %s",
+			properties.OrchestratorProfile.KubernetesConfig.OutboundType, DefaultOutboundType)
+	}
+
+	// this validates that an explicit OutboundType is preserved.
+	mockCS = getMockBaseContainerService("1.14.4")
+	properties = mockCS.Properties
+	properties.OrchestratorProfile.OrchestratorType = Kubernetes
+	properties.OrchestratorProfile.KubernetesConfig.LoadBalancerSku = StandardLoadBalancerSku
+	properties.OrchestratorProfile.KubernetesConfig.OutboundType = OutboundTypeNATGateway
+	mockCS.SetPropertiesDefaults(false, false)
+	if properties.OrchestratorProfile.KubernetesConfig.OutboundType != OutboundTypeNATGateway {
+		t.Fatalf("OrchestratorProfile.KubernetesConfig.OutboundType did not have the expected configuration, got %s, expected %s",
+			properties.OrchestratorProfile.KubernetesConfig.OutboundType, OutboundTypeNATGateway)
+	}
 }
 
 func TestAgentPoolProfile(t *testing.T) {
@@ -1325,6 +1386,31 @@ func TestAgentPoolProfile(t *testing.T) {
 		t.Fatalf("AgentPoolProfile[0].ScaleSetEvictionPolicy did not have the expected configuration, got %s, expected %s",
 			properties.AgentPoolProfiles[0].ScaleSetEvictionPolicy, ScaleSetEvictionPolicyDelete)
 	}
+	if properties.AgentPoolProfiles[0].SpotMaxPrice != nil {
+		t.Fatalf("AgentPoolProfile[0].SpotMaxPrice did not have the expected configuration, got %f, expected nil",
+			*properties.AgentPoolProfiles[0].SpotMaxPrice)
+	}
+	properties.AgentPoolProfiles[0].ScaleSetPriority = ScaleSetPrioritySpot
+	properties.AgentPoolProfiles[0].ScaleSetEvictionPolicy = ""
+	mockCS.SetPropertiesDefaults(false, false)
+	if properties.AgentPoolProfiles[0].ScaleSetEvictionPolicy != ScaleSetEvictionPolicyDelete {
+		t.Fatalf("AgentPoolProfile[0].ScaleSetEvictionPolicy did not have the expected configuration, got %s, expected %s",
+			properties.AgentPoolProfiles[0].ScaleSetEvictionPolicy, ScaleSetEvictionPolicyDelete)
+	}
+	if to.Float64(properties.AgentPoolProfiles[0].SpotMaxPrice) != DefaultSpotMaxPrice {
+		t.Fatalf("AgentPoolProfile[0].SpotMaxPrice did not have the expected configuration, got %v, expected %d",
+			properties.AgentPoolProfiles[0].SpotMaxPrice, DefaultSpotMaxPrice)
+	}
+	if properties.AgentPoolProfiles[0].PlatformFaultDomainCount != nil {
+		t.Fatalf("AgentPoolProfile[0].PlatformFaultDomainCount did not have the expected configuration, got %d, expected nil",
+			*properties.AgentPoolProfiles[0].PlatformFaultDomainCount)
+	}
+	properties.AgentPoolProfiles[0].HostGroupID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/hostGroups/HG_NAME"
+	mockCS.SetPropertiesDefaults(false, false)
+	if to.Int(properties.AgentPoolProfiles[0].PlatformFaultDomainCount) != DefaultHostGroupFaultDomainCount {
+		t.Fatalf("AgentPoolProfile[0].PlatformFaultDomainCount did not have the expected configuration, got %v, expected %d",
+			properties.AgentPoolProfiles[0].PlatformFaultDomainCount, DefaultHostGroupFaultDomainCount)
+	}
 }
 
 // TestDistroDefaults covers tests for setMasterProfileDefaults and setAgentProfileDefaults
@@ -1987,6 +2073,124 @@ func TestProxyModeDefaults(t *testing.T) {
 		t.Fatalf("ProxyMode string not the expected default value, got %s, expected %s", properties.OrchestratorProfile.KubernetesConfig.ProxyMode, KubeProxyModeIPVS)
 	}
 }
+
+func TestOIDCIssuerURLDefaults(t *testing.T) {
+	// Test that the issuer is the blob container endpoint in the cloud of the cluster
+	cases := []struct {
+		location    string
+		expectedURL string
+	}{
+		{"westus2", "https://oidcissuer.blob.core.windows.net/testcluster/"},
+		{"chinaeast2", "https://oidcissuer.blob.core.chinacloudapi.cn/testcluster/"},
+		{"usgovvirginia", "https://oidcissuer.blob.core.usgovcloudapi.net/testcluster/"},
+	}
+	for _, c := range cases {
+		mockCS := getMockBaseContainerService("1.16.1")
+		mockCS.Location = c.location
+		properties := mockCS.Properties
+		properties.OrchestratorProfile.OrchestratorType = Kubernetes
+		properties.MasterProfile.Count = 1
+		properties.OrchestratorProfile.KubernetesConfig.OIDCIssuerProfile = &OIDCIssuerProfile{
+			Enabled:            to.BoolPtr(true),
+			StorageAccountName: "oidcissuer",
+			ContainerName:      "testcluster",
+		}
+		mockCS.setOrchestratorDefaults(false, false)
+
+		if properties.OrchestratorProfile.KubernetesConfig.OIDCIssuerProfile.IssuerURL != c.expectedURL {
+			t.Fatalf("OIDC issuer URL not the expected default value in %s, got %s, expected %s", c.location, properties.OrchestratorProfile.KubernetesConfig.OIDCIssuerProfile.IssuerURL, c.expectedURL)
+		}
+	}
+
+	// Test that default assignment flow doesn't overwrite a user-provided issuer
+	mockCS := getMockBaseContainerService("1.16.1")
+	properties := mockCS.Properties
+	properties.OrchestratorProfile.OrchestratorType = Kubernetes
+	properties.MasterProfile.Count = 1
+	properties.OrchestratorProfile.KubernetesConfig.OIDCIssuerProfile = &OIDCIssuerProfile{
+		Enabled:   to.BoolPtr(true),
+		IssuerURL: "https://oidc.example.com/testcluster/",
+	}
+	mockCS.setOrchestratorDefaults(false, false)
+
+	if properties.OrchestratorProfile.KubernetesConfig.OIDCIssuerProfile.IssuerURL != "https://oidc.example.com/testcluster/" {
+		t.Fatalf("OIDC issuer URL not the user-provided value, got %s", properties.OrchestratorProfile.KubernetesConfig.OIDCIssuerProfile.IssuerURL)
+	}
+}
+
+func TestCustomManifestsDefaults(t *testing.T) {
+	mockCS := getMockBaseContainerService("1.16.0")
+	properties := mockCS.Properties
+	properties.OrchestratorProfile.OrchestratorType = Kubernetes
+	properties.MasterProfile.Count = 1
+	properties.OrchestratorProfile.KubernetesConfig.CustomManifests = []CustomManifest{
+		{Name: "crds", Data: "Zm9v"},
+		{Name: "config", Data: "YmFy", Mode: AddonManagerModeEnsureExists},
+	}
+	mockCS.setOrchestratorDefaults(false, false)
+
+	manifests := properties.OrchestratorProfile.KubernetesConfig.CustomManifests
+	if manifests[0].Mode != AddonManagerModeReconcile {
+		t.Fatalf("custom manifest mode not the expected default value, got %s, expected %s", manifests[0].Mode, AddonManagerModeReconcile)
+	}
+	if manifests[1].Mode != AddonManagerModeEnsureExists {
+		t.Fatalf("custom manifest mode not the user-provided value, got %s, expected %s", manifests[1].Mode, AddonManagerModeEnsureExists)
+	}
+}
+
+func TestAirGapDefaults(t *testing.T) {
+	mockCS := getMockBaseContainerService("1.16.0")
+	properties := mockCS.Properties
+	properties.OrchestratorProfile.OrchestratorType = Kubernetes
+	properties.MasterProfile.Count = 1
+	properties.FeatureFlags = nil
+	mockCS.setOrchestratorDefaults(false, false)
+
+	if properties.FeatureFlags.IsFeatureEnabled("BlockOutboundInternet") {
+		t.Fatalf("BlockOutboundInternet enabled for a cluster that is not air-gapped")
+	}
+
+	properties.OrchestratorProfile.KubernetesConfig.AirGapProfile = &AirGapProfile{
+		Enabled:     to.BoolPtr(true),
+		BinariesURL: "https://mirror.blob.core.windows.net/binaries",
+		PackagesURL: "http://10.0.0.4/apt",
+	}
+	mockCS.setOrchestratorDefaults(false, false)
+
+	if !properties.FeatureFlags.IsFeatureEnabled("BlockOutboundInternet") {
+		t.Fatalf("BlockOutboundInternet not enabled for an air-gapped cluster")
+	}
+}
+
+func TestKonnectivityDefaults(t *testing.T) {
+	mockCS := getMockBaseContainerService("1.16.0-beta.1")
+	properties := mockCS.Properties
+	properties.OrchestratorProfile.OrchestratorType = Kubernetes
+	properties.MasterProfile.Count = 1
+	properties.OrchestratorProfile.KubernetesConfig.KonnectivityProfile = &KonnectivityProfile{
+		Enabled: to.BoolPtr(true),
+	}
+	mockCS.setOrchestratorDefaults(false, false)
+
+	if properties.OrchestratorProfile.KubernetesConfig.KonnectivityProfile.ServerImage != DefaultKonnectivityServerImage {
+		t.Fatalf("konnectivity-server image not the expected default value, got %s, expected %s", properties.OrchestratorProfile.KubernetesConfig.KonnectivityProfile.ServerImage, DefaultKonnectivityServerImage)
+	}
+	if !properties.OrchestratorProfile.KubernetesConfig.IsAddonEnabled(KonnectivityAgentAddonName) {
+		t.Fatalf("expected the %s addon to be enabled with konnectivity", KonnectivityAgentAddonName)
+	}
+
+	// Test that the agent is not deployed without konnectivity
+	mockCS = getMockBaseContainerService("1.16.0-beta.1")
+	properties = mockCS.Properties
+	properties.OrchestratorProfile.OrchestratorType = Kubernetes
+	properties.MasterProfile.Count = 1
+	mockCS.setOrchestratorDefaults(false, false)
+
+	if properties.OrchestratorProfile.KubernetesConfig.IsAddonEnabled(KonnectivityAgentAddonName) {
+		t.Fatalf("expected the %s addon to be disabled without konnectivity", KonnectivityAgentAddonName)
+	}
+}
+
 func TestSetCustomCloudProfileDefaults(t *testing.T) {
 
 	// Test that the ResourceManagerVMDNSSuffix is set in EndpointConfig
@@ -2878,3 +3082,40 @@ func TestDefaultLoadBalancerSKU(t *testing.T) {
 		})
 	}
 }
+
+func TestCertificateSubjectAltNamesPrivateLink(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", "1.18.2", 3, 2, false)
+	cs.Location = "westus2"
+	cs.Properties.MasterProfile.FirstConsecutiveStaticIP = "10.239.255.239"
+	privateLinkFQDN := "testmaster.privatelink.westus2.cloudapp.azure.com"
+
+	fqdns, _, err := cs.certificateSubjectAltNames()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, fqdn := range fqdns {
+		if fqdn == privateLinkFQDN {
+			t.Errorf("expected no private link fqdn without Private Link, got %v", fqdns)
+		}
+	}
+
+	cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster = &PrivateCluster{
+		Enabled: to.BoolPtr(true),
+		PrivateLink: &PrivateLinkProfile{
+			Enabled: to.BoolPtr(true),
+		},
+	}
+	fqdns, _, err = cs.certificateSubjectAltNames()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var found bool
+	for _, fqdn := range fqdns {
+		if fqdn == privateLinkFQDN {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the certificate to be valid for %s, got %v", privateLinkFQDN, fqdns)
+	}
+}
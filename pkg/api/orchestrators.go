@@ -221,6 +221,62 @@ func getKubernetesAvailableUpgradeVersions(orchestratorVersion string, supported
 
 }
 
+// GetKubernetesUpgradePath returns the Kubernetes versions a cluster running csOrch must be upgraded through,
+// in order, to reach targetVersion. Every hop but the last is the latest supported release of its minor version.
+func GetKubernetesUpgradePath(csOrch *OrchestratorProfile, targetVersion string, hasWindows bool) ([]string, error) {
+	supportedVersions := common.GetAllSupportedKubernetesVersions(false, hasWindows)
+	found := false
+	for _, ver := range supportedVersions {
+		if ver == targetVersion {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, errors.Errorf("Kubernetes version %s is not supported", targetVersion)
+	}
+	return getKubernetesUpgradePath(csOrch.OrchestratorVersion, targetVersion, supportedVersions)
+}
+
+func getKubernetesUpgradePath(currentVersion, targetVersion string, supportedVersions []string) ([]string, error) {
+	target, err := semver.Make(targetVersion)
+	if err != nil {
+		return nil, err
+	}
+	current, err := semver.Make(currentVersion)
+	if err != nil {
+		return nil, err
+	}
+	if !target.GT(current) {
+		return nil, errors.Errorf("target Kubernetes version %s must be greater than the current version %s", targetVersion, currentVersion)
+	}
+	preRelease := len(target.Pre) != 0
+
+	path := []string{}
+	for version := currentVersion; version != targetVersion; {
+		upgrades, err := getKubernetesAvailableUpgradeVersions(version, supportedVersions)
+		if err != nil {
+			return nil, err
+		}
+		next := ""
+		for _, upgrade := range upgrades {
+			if upgrade == targetVersion {
+				next = targetVersion
+				break
+			}
+		}
+		if next == "" {
+			next = common.GetMaxVersion(common.GetVersionsBetween(upgrades, version, targetVersion, false, preRelease), preRelease)
+		}
+		if next == "" {
+			return nil, errors.Errorf("no upgrade path found from Kubernetes version %s to %s", currentVersion, targetVersion)
+		}
+		path = append(path, next)
+		version = next
+	}
+	return path, nil
+}
+
 func dcosInfo(csOrch *OrchestratorProfile, hasWindows bool) ([]*OrchestratorVersionProfile, error) {
 	orchs := []*OrchestratorVersionProfile{}
 	if csOrch.OrchestratorVersion == "" {
@@ -9,6 +9,7 @@ import (
 	"github.com/Azure/aks-engine/pkg/api/common"
 	"github.com/blang/semver"
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 )
 
 func TestInvalidVersion(t *testing.T) {
@@ -281,3 +282,73 @@ func TestGetKubernetesAvailableUpgradeVersions(t *testing.T) {
 		})
 	}
 }
+
+func TestGetKubernetesUpgradePath(t *testing.T) {
+	RegisterTestingT(t)
+	versions := []string{"1.9.10", "1.9.11", "1.10.3", "1.10.4", "1.11.3", "1.11.4", "1.12.0-alpha.1", "1.12.1", "1.12.2"}
+	cases := []struct {
+		name         string
+		version      string
+		versions     []string
+		target       string
+		expectedPath []string
+		expectedErr  error
+	}{
+		{
+			name:         "single patch hop",
+			version:      "1.9.10",
+			target:       "1.9.11",
+			expectedPath: []string{"1.9.11"},
+		},
+		{
+			name:         "single minor hop",
+			version:      "1.9.10",
+			target:       "1.10.3",
+			expectedPath: []string{"1.10.3"},
+		},
+		{
+			name:         "multiple minor hops",
+			version:      "1.9.10",
+			target:       "1.12.1",
+			expectedPath: []string{"1.10.4", "1.11.4", "1.12.1"},
+		},
+		{
+			name:         "to a pre-release",
+			version:      "1.10.3",
+			target:       "1.12.0-alpha.1",
+			expectedPath: []string{"1.11.4", "1.12.0-alpha.1"},
+		},
+		{
+			name:        "target not greater than current version",
+			version:     "1.11.3",
+			target:      "1.10.4",
+			expectedErr: errors.New("target Kubernetes version 1.10.4 must be greater than the current version 1.11.3"),
+		},
+		{
+			name:        "only pre-release intermediate versions",
+			version:     "1.10.3",
+			versions:    []string{"1.10.3", "1.11.0-alpha.1", "1.12.1"},
+			target:      "1.12.1",
+			expectedErr: errors.New("no upgrade path found from Kubernetes version 1.10.3 to 1.12.1"),
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			supported := c.versions
+			if supported == nil {
+				supported = versions
+			}
+			path, err := getKubernetesUpgradePath(c.version, c.target, supported)
+			if c.expectedErr != nil {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal(c.expectedErr.Error()))
+			} else {
+				Expect(err).To(BeNil())
+				Expect(path).To(Equal(c.expectedPath))
+			}
+		})
+	}
+}
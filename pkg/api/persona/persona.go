@@ -0,0 +1,221 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package persona provides the cloud lookups aks-engine makes while loading api models and generating templates,
+// either from the cloud itself or, in offline mode, from a static capabilities file without any network access.
+package persona
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/pkg/errors"
+)
+
+// Persona answers the cloud lookups of template generation
+type Persona interface {
+	// Name identifies the persona in logs and errors
+	Name() string
+	// Get returns the document at url, e.g. the Azure Stack metadata endpoints or the template of an extension
+	Get(url string) ([]byte, error)
+	// Capabilities returns what the cloud offers in location, or nil if the persona does not know the location
+	Capabilities(location string) (*LocationCapabilities, error)
+}
+
+// LocationCapabilities are the resources a cloud offers in a location
+type LocationCapabilities struct {
+	// VMSizes are the VM sizes available in the location
+	VMSizes []string `json:"vmSizes,omitempty"`
+	// Zones are the availability zones of each VM size, VM sizes without an entry are not validated
+	Zones map[string][]string `json:"zones,omitempty"`
+	// EphemeralOSDiskCacheGB is the cache size in GiB of each VM size, the largest ephemeral OS disk it can have, or 0
+	// if it does not support ephemeral OS disks. VM sizes without an entry are not validated
+	EphemeralOSDiskCacheGB map[string]int `json:"ephemeralOSDiskCacheGB,omitempty"`
+	// UltraSSDZones are the availability zones offering Ultra SSD data disks, empty if the location does not offer them.
+	// Ultra SSD data disks are not validated if nil
+	UltraSSDZones *[]string `json:"ultraSSDZones,omitempty"`
+}
+
+// HasVMSize returns true if vmSize is available in the location
+func (c *LocationCapabilities) HasVMSize(vmSize string) bool {
+	for _, s := range c.VMSizes {
+		if strings.EqualFold(s, vmSize) {
+			return true
+		}
+	}
+	return false
+}
+
+// ZonesOf returns the availability zones of vmSize, and false if they are not known
+func (c *LocationCapabilities) ZonesOf(vmSize string) ([]string, bool) {
+	for s, zones := range c.Zones {
+		if strings.EqualFold(s, vmSize) {
+			return zones, true
+		}
+	}
+	return nil, false
+}
+
+// EphemeralOSDiskCacheGBOf returns the cache size in GiB of vmSize, 0 if it does not support ephemeral OS disks, and
+// false if it is not known
+func (c *LocationCapabilities) EphemeralOSDiskCacheGBOf(vmSize string) (int, bool) {
+	for s, cacheGB := range c.EphemeralOSDiskCacheGB {
+		if strings.EqualFold(s, vmSize) {
+			return cacheGB, true
+		}
+	}
+	return 0, false
+}
+
+// GetUltraSSDZones returns the availability zones offering Ultra SSD data disks, and false if they are not known
+func (c *LocationCapabilities) GetUltraSSDZones() ([]string, bool) {
+	if c.UltraSSDZones == nil {
+		return nil, false
+	}
+	return *c.UltraSSDZones, true
+}
+
+// CapabilitiesFile is the content of the capabilities file used in offline mode
+type CapabilitiesFile struct {
+	// Locations are the capabilities of each location, by location name
+	Locations map[string]LocationCapabilities `json:"locations,omitempty"`
+	// Documents are the documents returned by Get, by URL
+	Documents map[string]json.RawMessage `json:"documents,omitempty"`
+}
+
+var (
+	mu      sync.RWMutex
+	current Persona = NewOnline()
+)
+
+// Current returns the persona the cloud lookups are made with, Online unless Set was called
+func Current() Persona {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Set replaces the persona the cloud lookups are made with
+func Set(p Persona) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = p
+}
+
+// Online makes the cloud lookups over the network
+type Online struct {
+	client *http.Client
+}
+
+// NewOnline returns a persona that makes the cloud lookups over the network
+func NewOnline() *Online {
+	return &Online{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Name returns "online"
+func (o *Online) Name() string {
+	return "online"
+}
+
+// Get returns the body of a successful GET request to url
+func (o *Online) Get(url string) ([]byte, error) {
+	res, err := o.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("GET %s returned status code %d: %s", url, res.StatusCode, res.Status)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
+// Capabilities returns nil, the online persona does not look up the capabilities of a location
+func (o *Online) Capabilities(location string) (*LocationCapabilities, error) {
+	return nil, nil
+}
+
+// Offline answers the cloud lookups from a capabilities file and never accesses the network
+type Offline struct {
+	capabilities *CapabilitiesFile
+}
+
+// NewOffline returns a persona that answers the cloud lookups from capabilities
+func NewOffline(capabilities *CapabilitiesFile) *Offline {
+	if capabilities == nil {
+		capabilities = &CapabilitiesFile{}
+	}
+	return &Offline{
+		capabilities: capabilities,
+	}
+}
+
+// LoadCapabilitiesFile reads a capabilities file
+func LoadCapabilitiesFile(path string) (*CapabilitiesFile, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading capabilities file %s", path)
+	}
+	capabilities := &CapabilitiesFile{}
+	if err = json.Unmarshal(b, capabilities); err != nil {
+		return nil, errors.Wrapf(err, "parsing capabilities file %s", path)
+	}
+	return capabilities, nil
+}
+
+// BundledCapabilities returns the capabilities aks-engine is built with: every Azure location
+// offers every VM size aks-engine supports, and the availability zones are not known
+func BundledCapabilities() *CapabilitiesFile {
+	var allowed struct {
+		AllowedValues []string `json:"allowedValues"`
+	}
+	// the allowed sizes are a fragment of the ARM template parameters, e.g. `"allowedValues": [ ... ],`
+	fragment := strings.TrimSuffix(strings.TrimSpace(helpers.GetKubernetesAllowedVMSKUs()), ",")
+	if err := json.Unmarshal([]byte("{"+fragment+"}"), &allowed); err != nil {
+		panic(errors.Wrap(err, "parsing the Kubernetes allowed VM sizes"))
+	}
+
+	capabilities := &CapabilitiesFile{
+		Locations: map[string]LocationCapabilities{},
+	}
+	for _, location := range helpers.GetAzureLocations() {
+		capabilities.Locations[location] = LocationCapabilities{
+			VMSizes: allowed.AllowedValues,
+		}
+	}
+	return capabilities
+}
+
+// Name returns "offline"
+func (o *Offline) Name() string {
+	return "offline"
+}
+
+// Get returns the document for url in the capabilities file
+func (o *Offline) Get(url string) ([]byte, error) {
+	if document, ok := o.capabilities.Documents[url]; ok {
+		return document, nil
+	}
+	return nil, errors.Errorf("%s is not in the capabilities file, it cannot be retrieved in offline mode", url)
+}
+
+// Capabilities returns the capabilities of location in the capabilities file, or nil if it has none
+func (o *Offline) Capabilities(location string) (*LocationCapabilities, error) {
+	for name, c := range o.capabilities.Locations {
+		if helpers.NormalizeAzureRegion(name) == helpers.NormalizeAzureRegion(location) {
+			c := c
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,116 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package persona
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOnlineGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"graphEndpoint":"https://graph.local/"}`)
+	}))
+	defer server.Close()
+
+	o := NewOnline()
+	b, err := o.Get(server.URL + "/metadata/endpoints")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(b) != `{"graphEndpoint":"https://graph.local/"}` {
+		t.Errorf("unexpected document %s", b)
+	}
+	if _, err = o.Get(server.URL + "/missing"); err == nil {
+		t.Errorf("expected an error for a missing document")
+	}
+	if c, err := o.Capabilities("westus2"); c != nil || err != nil {
+		t.Errorf("expected the online persona not to know any capabilities, got %v, %v", c, err)
+	}
+}
+
+func TestOffline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "persona")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "capabilities.json")
+	content := `{
+  "locations": {
+    "westus2": {"vmSizes": ["Standard_D2_v3"], "zones": {"Standard_D2_v3": ["1", "2", "3"]}}
+  },
+  "documents": {
+    "https://management.local.azurestack.external/metadata/endpoints?api-version=1.0": {"graphEndpoint": "https://graph.local/"}
+  }
+}`
+	if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	capabilities, err := LoadCapabilitiesFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading the capabilities file: %s", err)
+	}
+	o := NewOffline(capabilities)
+
+	b, err := o.Get("https://management.local.azurestack.external/metadata/endpoints?api-version=1.0")
+	if err != nil || string(b) != `{"graphEndpoint": "https://graph.local/"}` {
+		t.Errorf("unexpected document %s, error %v", b, err)
+	}
+	if _, err = o.Get("https://example.com/extensions/hello-world-k8s/v1/template-link.json"); err == nil {
+		t.Errorf("expected an error for a document not in the capabilities file")
+	}
+
+	c, err := o.Capabilities("West US 2")
+	if err != nil || c == nil {
+		t.Fatalf("expected the capabilities of westus2, got %v, %v", c, err)
+	}
+	if !c.HasVMSize("standard_d2_v3") || c.HasVMSize("Standard_D4_v3") {
+		t.Errorf("unexpected VM sizes %v", c.VMSizes)
+	}
+	if zones, ok := c.ZonesOf("Standard_D2_v3"); !ok || len(zones) != 3 {
+		t.Errorf("unexpected zones %v", zones)
+	}
+	if c, _ = o.Capabilities("eastus"); c != nil {
+		t.Errorf("expected no capabilities for a location not in the capabilities file, got %v", c)
+	}
+
+	if _, err = LoadCapabilitiesFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("expected an error loading a missing capabilities file")
+	}
+}
+
+func TestBundledCapabilities(t *testing.T) {
+	o := NewOffline(BundledCapabilities())
+	c, err := o.Capabilities("westus2")
+	if err != nil || c == nil {
+		t.Fatalf("expected the bundled capabilities of westus2, got %v, %v", c, err)
+	}
+	if !c.HasVMSize("Standard_D2_v2") {
+		t.Errorf("expected Standard_D2_v2 to be available in westus2")
+	}
+}
+
+func TestSet(t *testing.T) {
+	previous := Current()
+	defer Set(previous)
+
+	if Current().Name() != "online" {
+		t.Errorf("expected the online persona by default, got %s", Current().Name())
+	}
+	Set(NewOffline(nil))
+	if Current().Name() != "offline" {
+		t.Errorf("expected the offline persona, got %s", Current().Name())
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"strconv"
+)
+
+const (
+	// sizingProfileSmallMaxNodes is the largest node count of a cluster the small sizing profile is picked for
+	sizingProfileSmallMaxNodes = 10
+	// sizingProfileMediumMaxNodes is the largest node count of a cluster the medium sizing profile is picked for
+	sizingProfileMediumMaxNodes = 100
+)
+
+// sizingProfile holds the defaults that depend on the size of the cluster.
+// The defaults of the small profile are the aks-engine defaults, the other profiles only override some of them.
+type sizingProfile struct {
+	// addonContainers are the default resources of addon containers, by addon name
+	addonContainers map[string][]KubernetesContainerSpec
+	// coreDNS are the default resources of the coredns container
+	coreDNS KubernetesContainerSpec
+	// coreDNSReplicas is the default number of coredns replicas, 0 leaves it to the deployment or the dns-autoscaler addon
+	coreDNSReplicas int
+	// controllerManagerConfig, apiServerConfig and schedulerConfig are the default flags of the control plane components
+	controllerManagerConfig map[string]string
+	apiServerConfig         map[string]string
+	schedulerConfig         map[string]string
+}
+
+var sizingProfiles = map[string]sizingProfile{
+	SizingProfileSmall: {
+		coreDNS: KubernetesContainerSpec{
+			CPURequests:    "100m",
+			MemoryRequests: "70Mi",
+			MemoryLimits:   "170Mi",
+		},
+	},
+	SizingProfileMedium: {
+		addonContainers: map[string][]KubernetesContainerSpec{
+			HeapsterAddonName: {
+				{Name: HeapsterAddonName, CPURequests: "176m", MemoryRequests: "408Mi", CPULimits: "176m", MemoryLimits: "408Mi"},
+			},
+			MetricsServerAddonName: {
+				{Name: MetricsServerAddonName, CPURequests: "100m", MemoryRequests: "200Mi", CPULimits: "500m", MemoryLimits: "500Mi"},
+			},
+			TillerAddonName: {
+				{Name: TillerAddonName, CPURequests: "100m", MemoryRequests: "300Mi", CPULimits: "100m", MemoryLimits: "300Mi"},
+			},
+			ClusterAutoscalerAddonName: {
+				{Name: ClusterAutoscalerAddonName, CPURequests: "200m", MemoryRequests: "600Mi", CPULimits: "200m", MemoryLimits: "600Mi"},
+			},
+		},
+		coreDNS: KubernetesContainerSpec{
+			CPURequests:    "200m",
+			MemoryRequests: "140Mi",
+			MemoryLimits:   "340Mi",
+		},
+		coreDNSReplicas: 2,
+		controllerManagerConfig: map[string]string{
+			"--kube-api-qps":   "50",
+			"--kube-api-burst": "100",
+		},
+		apiServerConfig: map[string]string{
+			"--max-requests-inflight":          "800",
+			"--max-mutating-requests-inflight": "400",
+		},
+	},
+	SizingProfileLarge: {
+		addonContainers: map[string][]KubernetesContainerSpec{
+			HeapsterAddonName: {
+				{Name: HeapsterAddonName, CPURequests: "352m", MemoryRequests: "816Mi", CPULimits: "352m", MemoryLimits: "816Mi"},
+			},
+			MetricsServerAddonName: {
+				{Name: MetricsServerAddonName, CPURequests: "200m", MemoryRequests: "500Mi", CPULimits: "1", MemoryLimits: "1Gi"},
+			},
+			TillerAddonName: {
+				{Name: TillerAddonName, CPURequests: "200m", MemoryRequests: "600Mi", CPULimits: "200m", MemoryLimits: "600Mi"},
+			},
+			ClusterAutoscalerAddonName: {
+				{Name: ClusterAutoscalerAddonName, CPURequests: "400m", MemoryRequests: "1Gi", CPULimits: "400m", MemoryLimits: "1Gi"},
+			},
+		},
+		coreDNS: KubernetesContainerSpec{
+			CPURequests:    "500m",
+			MemoryRequests: "300Mi",
+			MemoryLimits:   "1Gi",
+		},
+		coreDNSReplicas: 5,
+		controllerManagerConfig: map[string]string{
+			"--kube-api-qps":   "100",
+			"--kube-api-burst": "200",
+		},
+		apiServerConfig: map[string]string{
+			"--max-requests-inflight":          "1600",
+			"--max-mutating-requests-inflight": "800",
+		},
+		schedulerConfig: map[string]string{
+			"--kube-api-qps":   "100",
+			"--kube-api-burst": "200",
+		},
+	},
+}
+
+// GetSizingProfile returns the sizing profile of the cluster: the sizingProfile of the api model if set,
+// otherwise the profile for the number of nodes the cluster can grow to
+func (p *Properties) GetSizingProfile() string {
+	if p.OrchestratorProfile != nil && p.OrchestratorProfile.KubernetesConfig != nil && p.OrchestratorProfile.KubernetesConfig.SizingProfile != "" {
+		return p.OrchestratorProfile.KubernetesConfig.SizingProfile
+	}
+	nodes := p.TotalNodes()
+	if p.OrchestratorProfile != nil && p.OrchestratorProfile.KubernetesConfig != nil && p.OrchestratorProfile.KubernetesConfig.IsClusterAutoscalerEnabled() {
+		// the cluster is sized for the nodes the autoscaler may add, the pools without node bounds have the ones of the addon
+		addon := p.OrchestratorProfile.KubernetesConfig.GetAddonByName(ClusterAutoscalerAddonName)
+		maxNodes, _ := strconv.Atoi(addon.Config["max-nodes"])
+		if len(addon.Pools) > 0 {
+			addonMaxNodes := maxNodes
+			maxNodes = 0
+			for _, pool := range addon.Pools {
+				poolMaxNodes, err := strconv.Atoi(pool.Config["max-nodes"])
+				if err != nil {
+					poolMaxNodes = addonMaxNodes
+				}
+				maxNodes += poolMaxNodes
+			}
+		}
+		if maxNodes > nodes {
+			nodes = maxNodes
+		}
+	}
+	switch {
+	case nodes <= sizingProfileSmallMaxNodes:
+		return SizingProfileSmall
+	case nodes <= sizingProfileMediumMaxNodes:
+		return SizingProfileMedium
+	default:
+		return SizingProfileLarge
+	}
+}
+
+// getSizingProfile returns the defaults of the sizing profile of the cluster
+func (p *Properties) getSizingProfile() sizingProfile {
+	if profile, ok := sizingProfiles[p.GetSizingProfile()]; ok {
+		return profile
+	}
+	return sizingProfiles[SizingProfileSmall]
+}
+
+// applySizingProfile overrides the default resources of the containers of addons with those of the sizing profile of the cluster
+func (p *Properties) applySizingProfile(addons []KubernetesAddon) {
+	profile := p.getSizingProfile()
+	for i := range addons {
+		for _, spec := range profile.addonContainers[addons[i].Name] {
+			c := addons[i].GetAddonContainersIndexByName(spec.Name)
+			if c < 0 {
+				continue
+			}
+			addons[i].Containers[c].CPURequests = spec.CPURequests
+			addons[i].Containers[c].MemoryRequests = spec.MemoryRequests
+			addons[i].Containers[c].CPULimits = spec.CPULimits
+			addons[i].Containers[c].MemoryLimits = spec.MemoryLimits
+		}
+	}
+}
+
+// GetCoreDNSResources returns the resources of the coredns container: those of the coredns addon container if set,
+// otherwise the defaults of the sizing profile of the cluster
+func (p *Properties) GetCoreDNSResources() KubernetesContainerSpec {
+	resources := p.getSizingProfile().coreDNS
+	resources.Name = CoreDNSAddonName
+	if p.OrchestratorProfile == nil || p.OrchestratorProfile.KubernetesConfig == nil {
+		return resources
+	}
+	addon := p.OrchestratorProfile.KubernetesConfig.GetAddonByName(CoreDNSAddonName)
+	if c := addon.GetAddonContainersIndexByName(CoreDNSAddonName); c >= 0 {
+		if addon.Containers[c].CPURequests != "" {
+			resources.CPURequests = addon.Containers[c].CPURequests
+		}
+		if addon.Containers[c].MemoryRequests != "" {
+			resources.MemoryRequests = addon.Containers[c].MemoryRequests
+		}
+		if addon.Containers[c].CPULimits != "" {
+			resources.CPULimits = addon.Containers[c].CPULimits
+		}
+		if addon.Containers[c].MemoryLimits != "" {
+			resources.MemoryLimits = addon.Containers[c].MemoryLimits
+		}
+	}
+	return resources
+}
+
+// GetCoreDNSReplicas returns the number of coredns replicas: the replicas config of the coredns addon if set,
+// otherwise the default of the sizing profile of the cluster. It returns 0 when the number of replicas
+// is left to the deployment, and always when the dns-autoscaler addon scales coredns.
+func (p *Properties) GetCoreDNSReplicas() int {
+	replicas := p.getSizingProfile().coreDNSReplicas
+	if p.OrchestratorProfile == nil || p.OrchestratorProfile.KubernetesConfig == nil {
+		return replicas
+	}
+	k := p.OrchestratorProfile.KubernetesConfig
+	if k.IsAddonEnabled(DNSAutoscalerAddonName) {
+		return 0
+	}
+	if r, err := strconv.Atoi(k.GetAddonByName(CoreDNSAddonName).Config["replicas"]); err == nil && r > 0 {
+		replicas = r
+	}
+	return replicas
+}
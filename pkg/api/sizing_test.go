@@ -0,0 +1,182 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+func TestGetSizingProfile(t *testing.T) {
+	cases := []struct {
+		name            string
+		agentCount      int
+		sizingProfile   string
+		autoscalerMax   string
+		autoscalerPools []AddonNodePoolsConfig
+		expectedProfile string
+	}{
+		{
+			name:            "small cluster",
+			agentCount:      7,
+			expectedProfile: SizingProfileSmall,
+		},
+		{
+			name:            "medium cluster",
+			agentCount:      50,
+			expectedProfile: SizingProfileMedium,
+		},
+		{
+			name:            "large cluster",
+			agentCount:      500,
+			expectedProfile: SizingProfileLarge,
+		},
+		{
+			name:            "explicit profile",
+			agentCount:      500,
+			sizingProfile:   SizingProfileSmall,
+			expectedProfile: SizingProfileSmall,
+		},
+		{
+			name:            "cluster autoscaler max nodes",
+			agentCount:      3,
+			autoscalerMax:   "200",
+			expectedProfile: SizingProfileLarge,
+		},
+		{
+			name:          "cluster autoscaler pools max nodes",
+			agentCount:    3,
+			autoscalerMax: "5",
+			autoscalerPools: []AddonNodePoolsConfig{
+				{Name: "pool1", Config: map[string]string{"max-nodes": "40"}},
+				{Name: "pool2", Config: map[string]string{"max-nodes": "40"}},
+				{Name: "pool3"},
+			},
+			expectedProfile: SizingProfileMedium,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			cs := CreateMockContainerService("testcluster", "1.13.5", 3, c.agentCount, false)
+			k := cs.Properties.OrchestratorProfile.KubernetesConfig
+			k.SizingProfile = c.sizingProfile
+			if c.autoscalerMax != "" {
+				k.Addons = []KubernetesAddon{
+					{
+						Name:    ClusterAutoscalerAddonName,
+						Enabled: to.BoolPtr(true),
+						Config:  map[string]string{"max-nodes": c.autoscalerMax},
+						Pools:   c.autoscalerPools,
+					},
+				}
+			}
+			if profile := cs.Properties.GetSizingProfile(); profile != c.expectedProfile {
+				t.Errorf("expected sizing profile %s, got %s", c.expectedProfile, profile)
+			}
+		})
+	}
+}
+
+func TestSizingProfileAddons(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", "1.13.5", 3, 500, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.Addons = []KubernetesAddon{
+		{
+			Name:    TillerAddonName,
+			Enabled: to.BoolPtr(true),
+			Containers: []KubernetesContainerSpec{
+				{Name: TillerAddonName, CPURequests: "1"},
+			},
+		},
+	}
+	cs.setAddonsConfig(false)
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+
+	metricsServer := k.GetAddonByName(MetricsServerAddonName)
+	expected := sizingProfiles[SizingProfileLarge].addonContainers[MetricsServerAddonName][0]
+	if c := metricsServer.Containers[0]; c.CPURequests != expected.CPURequests || c.MemoryRequests != expected.MemoryRequests || c.CPULimits != expected.CPULimits || c.MemoryLimits != expected.MemoryLimits {
+		t.Errorf("expected the large metrics-server resources %v, got %v", expected, c)
+	}
+
+	tiller := k.GetAddonByName(TillerAddonName)
+	if c := tiller.Containers[0]; c.CPURequests != "1" || c.MemoryRequests != sizingProfiles[SizingProfileLarge].addonContainers[TillerAddonName][0].MemoryRequests {
+		t.Errorf("expected the tiller cpu requests of the api model and the large memory requests, got %v", c)
+	}
+
+	// the small profile keeps the aks-engine defaults
+	cs = CreateMockContainerService("testcluster", "1.13.5", 1, 3, false)
+	cs.setAddonsConfig(false)
+	if c := cs.Properties.OrchestratorProfile.KubernetesConfig.GetAddonByName(MetricsServerAddonName).Containers[0]; c.CPURequests != "50m" || c.MemoryRequests != "100Mi" || c.MemoryLimits != "" {
+		t.Errorf("expected the default metrics-server requests and no limits for a small cluster, got %v", c)
+	}
+}
+
+func TestSizingProfileControlPlane(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", "1.13.5", 3, 500, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.SchedulerConfig = map[string]string{"--kube-api-qps": "75"}
+	cs.setControllerManagerConfig()
+	cs.setAPIServerConfig()
+	cs.setSchedulerConfig()
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+
+	if k.ControllerManagerConfig["--kube-api-qps"] != "100" || k.ControllerManagerConfig["--kube-api-burst"] != "200" {
+		t.Errorf("expected the large kube-controller-manager rate limits, got %v", k.ControllerManagerConfig)
+	}
+	if k.APIServerConfig["--max-requests-inflight"] != "1600" || k.APIServerConfig["--max-mutating-requests-inflight"] != "800" {
+		t.Errorf("expected the large kube-apiserver request limits, got %v", k.APIServerConfig)
+	}
+	if k.SchedulerConfig["--kube-api-qps"] != "75" || k.SchedulerConfig["--kube-api-burst"] != "200" {
+		t.Errorf("expected the kube-scheduler qps of the api model and the large burst, got %v", k.SchedulerConfig)
+	}
+
+	cs = CreateMockContainerService("testcluster", "1.13.5", 1, 3, false)
+	cs.setControllerManagerConfig()
+	if _, ok := cs.Properties.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig["--kube-api-qps"]; ok {
+		t.Errorf("expected no kube-controller-manager rate limits for a small cluster")
+	}
+}
+
+func TestCoreDNSSizing(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", "1.13.5", 1, 3, false)
+	p := cs.Properties
+	if r := p.GetCoreDNSResources(); r.CPURequests != "100m" || r.MemoryRequests != "70Mi" || r.MemoryLimits != "170Mi" {
+		t.Errorf("expected the default coredns resources for a small cluster, got %v", r)
+	}
+	if replicas := p.GetCoreDNSReplicas(); replicas != 0 {
+		t.Errorf("expected the coredns replicas to be left to the deployment for a small cluster, got %d", replicas)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.SizingProfile = SizingProfileLarge
+	if r := p.GetCoreDNSResources(); r.CPURequests != "500m" || r.MemoryLimits != "1Gi" {
+		t.Errorf("expected the large coredns resources, got %v", r)
+	}
+	if replicas := p.GetCoreDNSReplicas(); replicas != 5 {
+		t.Errorf("expected 5 coredns replicas, got %d", replicas)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.Addons = []KubernetesAddon{
+		{
+			Name:       CoreDNSAddonName,
+			Containers: []KubernetesContainerSpec{{Name: CoreDNSAddonName, MemoryLimits: "2Gi"}},
+			Config:     map[string]string{"replicas": "8"},
+		},
+	}
+	if r := p.GetCoreDNSResources(); r.CPURequests != "500m" || r.MemoryLimits != "2Gi" {
+		t.Errorf("expected the coredns memory limits of the api model, got %v", r)
+	}
+	if replicas := p.GetCoreDNSReplicas(); replicas != 8 {
+		t.Errorf("expected the coredns replicas of the api model, got %d", replicas)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.Addons = append(p.OrchestratorProfile.KubernetesConfig.Addons, KubernetesAddon{
+		Name:    DNSAutoscalerAddonName,
+		Enabled: to.BoolPtr(true),
+	})
+	if replicas := p.GetCoreDNSReplicas(); replicas != 0 {
+		t.Errorf("expected the dns-autoscaler to scale coredns, got %d replicas", replicas)
+	}
+}
@@ -197,6 +197,22 @@ type CustomNodesDNS struct {
 	DNSServer string `json:"dnsServer,omitempty"`
 }
 
+// NodeDNSConfig represents the DNS servers and search domains of the nodes in a pool,
+// overriding the VNET DNS servers and linuxProfile customNodesDNS
+type NodeDNSConfig struct {
+	DNSServers    []string `json:"dnsServers,omitempty"`
+	SearchDomains []string `json:"searchDomains,omitempty"`
+}
+
+// ScheduledEventsProfile configures the handler that cordons and drains the nodes of a pool when Azure schedules
+// the preemption or the deletion of their VMs. A scale set only schedules the deletion of its VMs, e.g. when it is
+// scaled in, if its terminate notification is enabled, the deletion then waits up to TerminateNotificationTimeoutMinutes
+// for the node to be drained.
+type ScheduledEventsProfile struct {
+	Enabled                             *bool `json:"enabled,omitempty"`
+	TerminateNotificationTimeoutMinutes int   `json:"terminateNotificationTimeoutMinutes,omitempty"`
+}
+
 // WindowsProfile represents the windows parameters passed to the cluster
 type WindowsProfile struct {
 	AdminUsername          string            `json:"adminUsername"`
@@ -208,6 +224,7 @@ type WindowsProfile struct {
 	WindowsOffer           string            `json:"windowsOffer"`
 	WindowsSku             string            `json:"windowsSku"`
 	WindowsDockerVersion   string            `json:"windowsDockerVersion"`
+	WindowsContainerdURL   string            `json:"windowsContainerdURL,omitempty"`
 	Secrets                []KeyVaultSecrets `json:"secrets,omitempty"`
 	SSHEnabled             bool              `json:"sshEnabled,omitempty"`
 	EnableAutomaticUpdates *bool             `json:"enableAutomaticUpdates,omitempty"`
@@ -256,8 +273,10 @@ type OrchestratorVersionProfile struct {
 
 // KubernetesContainerSpec defines configuration for a container spec
 type KubernetesContainerSpec struct {
-	Name           string `json:"name,omitempty"`
-	Image          string `json:"image,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Image string `json:"image,omitempty"`
+	// ImageDigest pins the image to a sha256 digest, the image is pulled by digest instead of by tag
+	ImageDigest    string `json:"imageDigest,omitempty"`
 	CPURequests    string `json:"cpuRequests,omitempty"`
 	MemoryRequests string `json:"memoryRequests,omitempty"`
 	CPULimits      string `json:"cpuLimits,omitempty"`
@@ -271,6 +290,14 @@ type KubernetesAddon struct {
 	Containers []KubernetesContainerSpec `json:"containers,omitempty"`
 	Config     map[string]string         `json:"config,omitempty"`
 	Data       string                    `json:"data,omitempty"`
+	Pools      []AddonNodePoolsConfig    `json:"pools,omitempty"`
+}
+
+// AddonNodePoolsConfig is the configuration of an addon for an agent pool, like the node bounds of the pools the
+// cluster-autoscaler addon scales
+type AddonNodePoolsConfig struct {
+	Name   string            `json:"name,omitempty"`
+	Config map[string]string `json:"config,omitempty"`
 }
 
 // IsEnabled returns true if the addon is enabled
@@ -291,10 +318,137 @@ func (a KubernetesAddon) GetAddonContainersIndexByName(containerName string) int
 	return -1
 }
 
+// GetAddonPoolIndexByName returns the KubernetesAddon pools index with the name `poolName`
+func (a KubernetesAddon) GetAddonPoolIndexByName(poolName string) int {
+	for i := range a.Pools {
+		if a.Pools[i].Name == poolName {
+			return i
+		}
+	}
+	return -1
+}
+
 // PrivateCluster defines the configuration for a private cluster
 type PrivateCluster struct {
 	Enabled        *bool                  `json:"enabled,omitempty"`
 	JumpboxProfile *PrivateJumpboxProfile `json:"jumpboxProfile,omitempty"`
+	PrivateLink    *PrivateLinkProfile    `json:"privateLink,omitempty"`
+}
+
+// PrivateLinkProfile fronts the internal load balancer of the API server of a private cluster with a Private Link service,
+// which clients reach through a private endpoint registered in a private DNS zone
+type PrivateLinkProfile struct {
+	Enabled                 *bool  `json:"enabled,omitempty"`
+	PrivateEndpointSubnetID string `json:"privateEndpointSubnetID,omitempty"`
+}
+
+// RegistryMirror configures the container runtime on every node to pull images
+// for Registry from the Endpoints, in order, before falling back to Registry itself
+type RegistryMirror struct {
+	Registry  string   `json:"registry"`
+	Endpoints []string `json:"endpoints"`
+}
+
+// OIDCIssuerProfile configures the API server to issue service account tokens that Azure AD can verify
+// with an OpenID Connect discovery document hosted in an Azure blob container, for workload identity federation
+type OIDCIssuerProfile struct {
+	Enabled *bool `json:"enabled,omitempty"`
+	// StorageAccountName and ContainerName are the blob container the discovery document is uploaded to,
+	// it must allow anonymous read access to its blobs
+	StorageAccountName string `json:"storageAccountName,omitempty"`
+	ContainerName      string `json:"containerName,omitempty"`
+	// IssuerURL is where the discovery document is served from, the blob container endpoint by default
+	IssuerURL string `json:"issuerURL,omitempty"`
+}
+
+// KonnectivityProfile configures the API server to reach the nodes through the konnectivity tunnels the konnectivity-agent
+// of every node opens to the konnectivity-server of the masters, for clusters whose masters cannot reach the node IPs
+type KonnectivityProfile struct {
+	Enabled *bool `json:"enabled,omitempty"`
+	// ServerImage is the image of the konnectivity-server static pod of the masters
+	ServerImage string `json:"serverImage,omitempty"`
+}
+
+// AirGapProfile configures a cluster whose nodes cannot reach the internet to install exclusively from user-provided mirrors
+type AirGapProfile struct {
+	Enabled *bool `json:"enabled,omitempty"`
+	// BinariesURL is the base URL the binaries are downloaded from, e.g. a storage account blob container, which serves
+	// https://<host>/<path> as <binariesURL>/<host>/<path>
+	BinariesURL string `json:"binariesURL,omitempty"`
+	// PackagesURL is the base URL of the apt mirror, which serves the Ubuntu archive under ubuntu
+	// and the Microsoft packages repository under microsoft
+	PackagesURL string `json:"packagesURL,omitempty"`
+}
+
+// DrainProfile configures how upgrades drain the nodes before replacing them
+type DrainProfile struct {
+	// TimeoutInMinutes is how long to wait for the pods of a node to be evicted, 20 by default
+	TimeoutInMinutes int `json:"timeoutInMinutes,omitempty"`
+	// GracePeriodSeconds overrides the termination grace period of the evicted pods, which keep their own if it is not set
+	GracePeriodSeconds *int `json:"gracePeriodSeconds,omitempty"`
+	// IgnoreDaemonSets leaves the DaemonSet pods on the node, the drain fails on them otherwise. True by default.
+	IgnoreDaemonSets *bool `json:"ignoreDaemonSets,omitempty"`
+	// DeleteEmptyDirData evicts the pods with emptyDir volumes, whose data is lost, the drain fails on them otherwise.
+	// True by default.
+	DeleteEmptyDirData *bool `json:"deleteEmptyDirData,omitempty"`
+	// ForceAfterTimeout deletes the pods still not evicted after the timeout, e.g. because of a PodDisruptionBudget,
+	// rather than failing the drain
+	ForceAfterTimeout *bool `json:"forceAfterTimeout,omitempty"`
+}
+
+// AzureCloudConfig sets the azure.json settings of the Azure cloud provider that have no kubernetesConfig
+// property of their own, e.g. the loadBalancerSku and the cloudProviderRateLimit settings do
+type AzureCloudConfig struct {
+	// the TTLs in seconds of the caches of the Azure resources the cloud provider reads, the defaults of the
+	// cloud provider when they are not set
+	VMSSCacheTTLInSeconds                 int `json:"vmssCacheTTLInSeconds,omitempty"`
+	VMSSVirtualMachinesCacheTTLInSeconds  int `json:"vmssVirtualMachinesCacheTTLInSeconds,omitempty"`
+	VMCacheTTLInSeconds                   int `json:"vmCacheTTLInSeconds,omitempty"`
+	AvailabilitySetNodesCacheTTLInSeconds int `json:"availabilitySetNodesCacheTTLInSeconds,omitempty"`
+	LoadBalancerCacheTTLInSeconds         int `json:"loadBalancerCacheTTLInSeconds,omitempty"`
+	NSGCacheTTLInSeconds                  int `json:"nsgCacheTTLInSeconds,omitempty"`
+	RouteTableCacheTTLInSeconds           int `json:"routeTableCacheTTLInSeconds,omitempty"`
+	// RateLimits overrides the cloudProviderRateLimit settings of kubernetesConfig for the client of an Azure API,
+	// keyed by the name of the client, e.g. virtualMachineScaleSet
+	RateLimits map[string]CloudProviderRateLimit `json:"rateLimits,omitempty"`
+	// Tags are added to the Azure resources the cloud provider creates, e.g. the public IPs of the services
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// CloudProviderRateLimit is the rate limit of the client of an Azure API, the settings it does not set are the ones
+// of kubernetesConfig
+type CloudProviderRateLimit struct {
+	CloudProviderRateLimit            *bool   `json:"cloudProviderRateLimit,omitempty"`
+	CloudProviderRateLimitQPS         float64 `json:"cloudProviderRateLimitQPS,omitempty"`
+	CloudProviderRateLimitQPSWrite    float64 `json:"cloudProviderRateLimitQPSWrite,omitempty"`
+	CloudProviderRateLimitBucket      int     `json:"cloudProviderRateLimitBucket,omitempty"`
+	CloudProviderRateLimitBucketWrite int     `json:"cloudProviderRateLimitBucketWrite,omitempty"`
+}
+
+// ManifestPatch customizes the static pod manifest of a control plane component on the masters,
+// so that the customization is regenerated by upgrades instead of overwritten
+type ManifestPatch struct {
+	// Component is the static pod patched, e.g. kube-apiserver
+	Component string `json:"component"`
+	// ExtraArgs are added to the flags of the component, they override the flags of its config
+	ExtraArgs map[string]string `json:"extraArgs,omitempty"`
+	// Patch is merged into the pod, the items of the lists of objects with a name are merged by name
+	Patch map[string]interface{} `json:"patch,omitempty"`
+}
+
+// CustomManifest is a user-supplied manifest applied by the addon manager of the masters with the addons,
+// from the cluster bring-up on. The contents of URL or Path are embedded in Data when the template is generated.
+type CustomManifest struct {
+	// Name identifies the manifest in the DependsOn of the other manifests
+	Name string `json:"name"`
+	// Data is the base64 encoded manifest
+	Data string `json:"data,omitempty"`
+	URL  string `json:"url,omitempty"`
+	Path string `json:"path,omitempty"`
+	// Mode is the addon manager mode of the objects that do not have one, Reconcile or EnsureExists
+	Mode string `json:"mode,omitempty"`
+	// DependsOn are the names of the custom manifests applied before this one
+	DependsOn []string `json:"dependsOn,omitempty"`
 }
 
 // PrivateJumpboxProfile represents a jumpbox definition
@@ -346,72 +500,84 @@ const (
 // KubernetesConfig contains the Kubernetes config structure, containing
 // Kubernetes specific configuration
 type KubernetesConfig struct {
-	KubernetesImageBase               string            `json:"kubernetesImageBase,omitempty"`
-	ClusterSubnet                     string            `json:"clusterSubnet,omitempty"`
-	NetworkPolicy                     string            `json:"networkPolicy,omitempty"`
-	NetworkPlugin                     string            `json:"networkPlugin,omitempty"`
-	ContainerRuntime                  string            `json:"containerRuntime,omitempty"`
-	MaxPods                           int               `json:"maxPods,omitempty"`
-	DockerBridgeSubnet                string            `json:"dockerBridgeSubnet,omitempty"`
-	DNSServiceIP                      string            `json:"dnsServiceIP,omitempty"`
-	ServiceCIDR                       string            `json:"serviceCidr,omitempty"`
-	UseManagedIdentity                bool              `json:"useManagedIdentity,omitempty"`
-	UserAssignedID                    string            `json:"userAssignedID,omitempty"`
-	UserAssignedClientID              string            `json:"userAssignedClientID,omitempty"` //Note: cannot be provided in config. Used *only* for transferring this to azure.json.
-	CustomHyperkubeImage              string            `json:"customHyperkubeImage,omitempty"`
-	DockerEngineVersion               string            `json:"dockerEngineVersion,omitempty"` // Deprecated
-	MobyVersion                       string            `json:"mobyVersion,omitempty"`
-	ContainerdVersion                 string            `json:"containerdVersion,omitempty"`
-	CustomCcmImage                    string            `json:"customCcmImage,omitempty"` // Image for cloud-controller-manager
-	UseCloudControllerManager         *bool             `json:"useCloudControllerManager,omitempty"`
-	CustomWindowsPackageURL           string            `json:"customWindowsPackageURL,omitempty"`
-	WindowsNodeBinariesURL            string            `json:"windowsNodeBinariesURL,omitempty"`
-	UseInstanceMetadata               *bool             `json:"useInstanceMetadata,omitempty"`
-	EnableRbac                        *bool             `json:"enableRbac,omitempty"`
-	EnableSecureKubelet               *bool             `json:"enableSecureKubelet,omitempty"`
-	EnableAggregatedAPIs              bool              `json:"enableAggregatedAPIs,omitempty"`
-	PrivateCluster                    *PrivateCluster   `json:"privateCluster,omitempty"`
-	GCHighThreshold                   int               `json:"gchighthreshold,omitempty"`
-	GCLowThreshold                    int               `json:"gclowthreshold,omitempty"`
-	EtcdVersion                       string            `json:"etcdVersion,omitempty"`
-	EtcdDiskSizeGB                    string            `json:"etcdDiskSizeGB,omitempty"`
-	EtcdEncryptionKey                 string            `json:"etcdEncryptionKey,omitempty"`
-	EnableDataEncryptionAtRest        *bool             `json:"enableDataEncryptionAtRest,omitempty"`
-	EnableEncryptionWithExternalKms   *bool             `json:"enableEncryptionWithExternalKms,omitempty"`
-	EnablePodSecurityPolicy           *bool             `json:"enablePodSecurityPolicy,omitempty"`
-	Addons                            []KubernetesAddon `json:"addons,omitempty"`
-	KubeletConfig                     map[string]string `json:"kubeletConfig,omitempty"`
-	ControllerManagerConfig           map[string]string `json:"controllerManagerConfig,omitempty"`
-	CloudControllerManagerConfig      map[string]string `json:"cloudControllerManagerConfig,omitempty"`
-	APIServerConfig                   map[string]string `json:"apiServerConfig,omitempty"`
-	SchedulerConfig                   map[string]string `json:"schedulerConfig,omitempty"`
-	PodSecurityPolicyConfig           map[string]string `json:"podSecurityPolicyConfig,omitempty"` // Deprecated
-	CloudProviderBackoff              *bool             `json:"cloudProviderBackoff,omitempty"`
-	CloudProviderBackoffRetries       int               `json:"cloudProviderBackoffRetries,omitempty"`
-	CloudProviderBackoffJitter        float64           `json:"cloudProviderBackoffJitter,omitempty"`
-	CloudProviderBackoffDuration      int               `json:"cloudProviderBackoffDuration,omitempty"`
-	CloudProviderBackoffExponent      float64           `json:"cloudProviderBackoffExponent,omitempty"`
-	CloudProviderRateLimit            *bool             `json:"cloudProviderRateLimit,omitempty"`
-	CloudProviderRateLimitQPS         float64           `json:"cloudProviderRateLimitQPS,omitempty"`
-	CloudProviderRateLimitQPSWrite    float64           `json:"cloudProviderRateLimitQPSWrite,omitempty"`
-	CloudProviderRateLimitBucket      int               `json:"cloudProviderRateLimitBucket,omitempty"`
-	CloudProviderRateLimitBucketWrite int               `json:"cloudProviderRateLimitBucketWrite,omitempty"`
-	NonMasqueradeCidr                 string            `json:"nonMasqueradeCidr,omitempty"`
-	NodeStatusUpdateFrequency         string            `json:"nodeStatusUpdateFrequency,omitempty"`
-	HardEvictionThreshold             string            `json:"hardEvictionThreshold,omitempty"`
-	CtrlMgrNodeMonitorGracePeriod     string            `json:"ctrlMgrNodeMonitorGracePeriod,omitempty"`
-	CtrlMgrPodEvictionTimeout         string            `json:"ctrlMgrPodEvictionTimeout,omitempty"`
-	CtrlMgrRouteReconciliationPeriod  string            `json:"ctrlMgrRouteReconciliationPeriod,omitempty"`
-	LoadBalancerSku                   string            `json:"loadBalancerSku,omitempty"`
-	ExcludeMasterFromStandardLB       *bool             `json:"excludeMasterFromStandardLB,omitempty"`
-	AzureCNIVersion                   string            `json:"azureCNIVersion,omitempty"`
-	AzureCNIURLLinux                  string            `json:"azureCNIURLLinux,omitempty"`
-	AzureCNIURLWindows                string            `json:"azureCNIURLWindows,omitempty"`
-	KeyVaultSku                       string            `json:"keyVaultSku,omitempty"`
-	MaximumLoadBalancerRuleCount      int               `json:"maximumLoadBalancerRuleCount,omitempty"`
-	ProxyMode                         KubeProxyMode     `json:"kubeProxyMode,omitempty"`
-	PrivateAzureRegistryServer        string            `json:"privateAzureRegistryServer,omitempty"`
-	OutboundRuleIdleTimeoutInMinutes  int32             `json:"outboundRuleIdleTimeoutInMinutes,omitempty"`
+	KubernetesImageBase               string               `json:"kubernetesImageBase,omitempty"`
+	ClusterSubnet                     string               `json:"clusterSubnet,omitempty"`
+	NetworkPolicy                     string               `json:"networkPolicy,omitempty"`
+	NetworkPlugin                     string               `json:"networkPlugin,omitempty"`
+	ContainerRuntime                  string               `json:"containerRuntime,omitempty"`
+	MaxPods                           int                  `json:"maxPods,omitempty"`
+	DockerBridgeSubnet                string               `json:"dockerBridgeSubnet,omitempty"`
+	DNSServiceIP                      string               `json:"dnsServiceIP,omitempty"`
+	ServiceCIDR                       string               `json:"serviceCidr,omitempty"`
+	UseManagedIdentity                bool                 `json:"useManagedIdentity,omitempty"`
+	UserAssignedID                    string               `json:"userAssignedID,omitempty"`
+	UserAssignedClientID              string               `json:"userAssignedClientID,omitempty"` //Note: cannot be provided in config. Used *only* for transferring this to azure.json.
+	CustomHyperkubeImage              string               `json:"customHyperkubeImage,omitempty"`
+	DockerEngineVersion               string               `json:"dockerEngineVersion,omitempty"` // Deprecated
+	MobyVersion                       string               `json:"mobyVersion,omitempty"`
+	ContainerdVersion                 string               `json:"containerdVersion,omitempty"`
+	CustomCcmImage                    string               `json:"customCcmImage,omitempty"` // Image for cloud-controller-manager
+	UseCloudControllerManager         *bool                `json:"useCloudControllerManager,omitempty"`
+	CustomWindowsPackageURL           string               `json:"customWindowsPackageURL,omitempty"`
+	WindowsNodeBinariesURL            string               `json:"windowsNodeBinariesURL,omitempty"`
+	UseInstanceMetadata               *bool                `json:"useInstanceMetadata,omitempty"`
+	EnableRbac                        *bool                `json:"enableRbac,omitempty"`
+	EnableSecureKubelet               *bool                `json:"enableSecureKubelet,omitempty"`
+	EnableAggregatedAPIs              bool                 `json:"enableAggregatedAPIs,omitempty"`
+	PrivateCluster                    *PrivateCluster      `json:"privateCluster,omitempty"`
+	GCHighThreshold                   int                  `json:"gchighthreshold,omitempty"`
+	GCLowThreshold                    int                  `json:"gclowthreshold,omitempty"`
+	EtcdVersion                       string               `json:"etcdVersion,omitempty"`
+	EtcdDiskSizeGB                    string               `json:"etcdDiskSizeGB,omitempty"`
+	EtcdEncryptionKey                 string               `json:"etcdEncryptionKey,omitempty"`
+	EnableDataEncryptionAtRest        *bool                `json:"enableDataEncryptionAtRest,omitempty"`
+	EnableEncryptionWithExternalKms   *bool                `json:"enableEncryptionWithExternalKms,omitempty"`
+	EnablePodSecurityPolicy           *bool                `json:"enablePodSecurityPolicy,omitempty"`
+	Addons                            []KubernetesAddon    `json:"addons,omitempty"`
+	KubeletConfig                     map[string]string    `json:"kubeletConfig,omitempty"`
+	ControllerManagerConfig           map[string]string    `json:"controllerManagerConfig,omitempty"`
+	CloudControllerManagerConfig      map[string]string    `json:"cloudControllerManagerConfig,omitempty"`
+	APIServerConfig                   map[string]string    `json:"apiServerConfig,omitempty"`
+	SchedulerConfig                   map[string]string    `json:"schedulerConfig,omitempty"`
+	PodSecurityPolicyConfig           map[string]string    `json:"podSecurityPolicyConfig,omitempty"` // Deprecated
+	CloudProviderBackoff              *bool                `json:"cloudProviderBackoff,omitempty"`
+	CloudProviderBackoffRetries       int                  `json:"cloudProviderBackoffRetries,omitempty"`
+	CloudProviderBackoffJitter        float64              `json:"cloudProviderBackoffJitter,omitempty"`
+	CloudProviderBackoffDuration      int                  `json:"cloudProviderBackoffDuration,omitempty"`
+	CloudProviderBackoffExponent      float64              `json:"cloudProviderBackoffExponent,omitempty"`
+	CloudProviderRateLimit            *bool                `json:"cloudProviderRateLimit,omitempty"`
+	CloudProviderRateLimitQPS         float64              `json:"cloudProviderRateLimitQPS,omitempty"`
+	CloudProviderRateLimitQPSWrite    float64              `json:"cloudProviderRateLimitQPSWrite,omitempty"`
+	CloudProviderRateLimitBucket      int                  `json:"cloudProviderRateLimitBucket,omitempty"`
+	CloudProviderRateLimitBucketWrite int                  `json:"cloudProviderRateLimitBucketWrite,omitempty"`
+	NonMasqueradeCidr                 string               `json:"nonMasqueradeCidr,omitempty"`
+	NodeStatusUpdateFrequency         string               `json:"nodeStatusUpdateFrequency,omitempty"`
+	HardEvictionThreshold             string               `json:"hardEvictionThreshold,omitempty"`
+	CtrlMgrNodeMonitorGracePeriod     string               `json:"ctrlMgrNodeMonitorGracePeriod,omitempty"`
+	CtrlMgrPodEvictionTimeout         string               `json:"ctrlMgrPodEvictionTimeout,omitempty"`
+	CtrlMgrRouteReconciliationPeriod  string               `json:"ctrlMgrRouteReconciliationPeriod,omitempty"`
+	LoadBalancerSku                   string               `json:"loadBalancerSku,omitempty"`
+	ExcludeMasterFromStandardLB       *bool                `json:"excludeMasterFromStandardLB,omitempty"`
+	AzureCNIVersion                   string               `json:"azureCNIVersion,omitempty"`
+	AzureCNIURLLinux                  string               `json:"azureCNIURLLinux,omitempty"`
+	AzureCNIURLWindows                string               `json:"azureCNIURLWindows,omitempty"`
+	KeyVaultSku                       string               `json:"keyVaultSku,omitempty"`
+	MaximumLoadBalancerRuleCount      int                  `json:"maximumLoadBalancerRuleCount,omitempty"`
+	ProxyMode                         KubeProxyMode        `json:"kubeProxyMode,omitempty"`
+	PrivateAzureRegistryServer        string               `json:"privateAzureRegistryServer,omitempty"`
+	OutboundRuleIdleTimeoutInMinutes  int32                `json:"outboundRuleIdleTimeoutInMinutes,omitempty"`
+	OutboundRuleAllocatedPorts        int32                `json:"outboundRuleAllocatedPorts,omitempty"`
+	OutboundType                      string               `json:"outboundType,omitempty"`
+	RegistryMirrors                   []RegistryMirror     `json:"registryMirrors,omitempty"`
+	SizingProfile                     string               `json:"sizingProfile,omitempty"`
+	OIDCIssuerProfile                 *OIDCIssuerProfile   `json:"oidcIssuerProfile,omitempty"`
+	ManifestPatches                   []ManifestPatch      `json:"manifestPatches,omitempty"`
+	KonnectivityProfile               *KonnectivityProfile `json:"konnectivityProfile,omitempty"`
+	DrainProfile                      *DrainProfile        `json:"drainProfile,omitempty"`
+	CloudProviderConfig               *AzureCloudConfig    `json:"cloudProviderConfig,omitempty"`
+	CustomManifests                   []CustomManifest     `json:"customManifests,omitempty"`
+	ImageRegistry                     string               `json:"imageRegistry,omitempty"`
+	AirGapProfile                     *AirGapProfile       `json:"airGapProfile,omitempty"`
 }
 
 // CustomFile has source as the full absolute source path to a file and dest
@@ -455,34 +621,45 @@ func (d *DcosConfig) HasBootstrap() bool {
 
 // MasterProfile represents the definition of the master cluster
 type MasterProfile struct {
-	Count                    int               `json:"count"`
-	DNSPrefix                string            `json:"dnsPrefix"`
-	SubjectAltNames          []string          `json:"subjectAltNames"`
-	VMSize                   string            `json:"vmSize"`
-	OSDiskSizeGB             int               `json:"osDiskSizeGB,omitempty"`
-	VnetSubnetID             string            `json:"vnetSubnetID,omitempty"`
-	VnetCidr                 string            `json:"vnetCidr,omitempty"`
-	AgentVnetSubnetID        string            `json:"agentVnetSubnetID,omitempty"`
-	FirstConsecutiveStaticIP string            `json:"firstConsecutiveStaticIP,omitempty"`
-	Subnet                   string            `json:"subnet"`
-	SubnetIPv6               string            `json:"subnetIPv6"`
-	IPAddressCount           int               `json:"ipAddressCount,omitempty"`
-	StorageProfile           string            `json:"storageProfile,omitempty"`
-	HTTPSourceAddressPrefix  string            `json:"HTTPSourceAddressPrefix,omitempty"`
-	OAuthEnabled             bool              `json:"oauthEnabled"`
-	PreprovisionExtension    *Extension        `json:"preProvisionExtension"`
-	Extensions               []Extension       `json:"extensions"`
-	Distro                   Distro            `json:"distro,omitempty"`
-	KubernetesConfig         *KubernetesConfig `json:"kubernetesConfig,omitempty"`
-	ImageRef                 *ImageReference   `json:"imageReference,omitempty"`
-	CustomFiles              *[]CustomFile     `json:"customFiles,omitempty"`
-	AvailabilityProfile      string            `json:"availabilityProfile"`
-	PlatformFaultDomainCount *int              `json:"platformFaultDomainCount"`
-	AgentSubnet              string            `json:"agentSubnet,omitempty"`
-	AvailabilityZones        []string          `json:"availabilityZones,omitempty"`
-	SinglePlacementGroup     *bool             `json:"singlePlacementGroup,omitempty"`
-	AuditDEnabled            *bool             `json:"auditDEnabled,omitempty"`
-	CustomVMTags             map[string]string `json:"customVMTags,omitempty"`
+	Count                         int               `json:"count"`
+	DNSPrefix                     string            `json:"dnsPrefix"`
+	SubjectAltNames               []string          `json:"subjectAltNames"`
+	VMSize                        string            `json:"vmSize"`
+	OSDiskSizeGB                  int               `json:"osDiskSizeGB,omitempty"`
+	VnetSubnetID                  string            `json:"vnetSubnetID,omitempty"`
+	VnetCidr                      string            `json:"vnetCidr,omitempty"`
+	AgentVnetSubnetID             string            `json:"agentVnetSubnetID,omitempty"`
+	FirstConsecutiveStaticIP      string            `json:"firstConsecutiveStaticIP,omitempty"`
+	EtcdSubnet                    string            `json:"etcdSubnet,omitempty"`
+	EtcdVnetSubnetID              string            `json:"etcdVnetSubnetID,omitempty"`
+	EtcdFirstConsecutiveStaticIP  string            `json:"etcdFirstConsecutiveStaticIP,omitempty"`
+	Subnet                        string            `json:"subnet"`
+	SubnetIPv6                    string            `json:"subnetIPv6"`
+	IPAddressCount                int               `json:"ipAddressCount,omitempty"`
+	StorageProfile                string            `json:"storageProfile,omitempty"`
+	DataDiskStorageAccountType    string            `json:"dataDiskStorageAccountType,omitempty"`
+	DataDiskIOPSReadWrite         int               `json:"dataDiskIOPSReadWrite,omitempty"`
+	DataDiskMBpsReadWrite         int               `json:"dataDiskMBpsReadWrite,omitempty"`
+	HTTPSourceAddressPrefix       string            `json:"HTTPSourceAddressPrefix,omitempty"`
+	OAuthEnabled                  bool              `json:"oauthEnabled"`
+	PreprovisionExtension         *Extension        `json:"preProvisionExtension"`
+	Extensions                    []Extension       `json:"extensions"`
+	VMExtensions                  []VMExtension     `json:"vmExtensions,omitempty"`
+	Distro                        Distro            `json:"distro,omitempty"`
+	KubernetesConfig              *KubernetesConfig `json:"kubernetesConfig,omitempty"`
+	ImageRef                      *ImageReference   `json:"imageReference,omitempty"`
+	CustomFiles                   *[]CustomFile     `json:"customFiles,omitempty"`
+	AvailabilityProfile           string            `json:"availabilityProfile"`
+	PlatformFaultDomainCount      *int              `json:"platformFaultDomainCount"`
+	AgentSubnet                   string            `json:"agentSubnet,omitempty"`
+	AvailabilityZones             []string          `json:"availabilityZones,omitempty"`
+	SinglePlacementGroup          *bool             `json:"singlePlacementGroup,omitempty"`
+	ProximityPlacementGroupID     string            `json:"proximityPlacementGroupID,omitempty"`
+	CreateProximityPlacementGroup *bool             `json:"createProximityPlacementGroup,omitempty"`
+	AuditDEnabled                 *bool             `json:"auditDEnabled,omitempty"`
+	CustomVMTags                  map[string]string `json:"customVMTags,omitempty"`
+	VnetDNSServers                []string          `json:"vnetDnsServers,omitempty"`
+	DNSConfig                     *NodeDNSConfig    `json:"dnsConfig,omitempty"`
 	// Master LB public endpoint/FQDN with port
 	// The format will be FQDN:2376
 	// Not used during PUT, returned as part of GET
@@ -519,49 +696,76 @@ type Extension struct {
 	Template    string `json:"template"`
 }
 
+// VMExtension represents an Azure VM extension installed on every VM of the master or an agent pool,
+// e.g. a security or monitoring agent. It is provisioned after the custom script extension that bootstraps the VM.
+type VMExtension struct {
+	Name                    string                 `json:"name"`
+	Publisher               string                 `json:"publisher"`
+	Type                    string                 `json:"type"`
+	TypeHandlerVersion      string                 `json:"typeHandlerVersion"`
+	AutoUpgradeMinorVersion *bool                  `json:"autoUpgradeMinorVersion,omitempty"`
+	Settings                map[string]interface{} `json:"settings,omitempty"`
+	ProtectedSettings       map[string]interface{} `json:"protectedSettings,omitempty"`
+}
+
 // AgentPoolProfile represents an agent pool definition
 type AgentPoolProfile struct {
-	Name                                string               `json:"name"`
-	Count                               int                  `json:"count"`
-	VMSize                              string               `json:"vmSize"`
-	OSDiskSizeGB                        int                  `json:"osDiskSizeGB,omitempty"`
-	DNSPrefix                           string               `json:"dnsPrefix,omitempty"`
-	OSType                              OSType               `json:"osType,omitempty"`
-	Ports                               []int                `json:"ports,omitempty"`
-	ProvisioningState                   ProvisioningState    `json:"provisioningState,omitempty"`
-	AvailabilityProfile                 string               `json:"availabilityProfile"`
-	PlatformFaultDomainCount            *int                 `json:"platformFaultDomainCount"`
-	ScaleSetPriority                    string               `json:"scaleSetPriority,omitempty"`
-	ScaleSetEvictionPolicy              string               `json:"scaleSetEvictionPolicy,omitempty"`
-	StorageProfile                      string               `json:"storageProfile,omitempty"`
-	DiskSizesGB                         []int                `json:"diskSizesGB,omitempty"`
-	VnetSubnetID                        string               `json:"vnetSubnetID,omitempty"`
-	Subnet                              string               `json:"subnet"`
-	IPAddressCount                      int                  `json:"ipAddressCount,omitempty"`
-	Distro                              Distro               `json:"distro,omitempty"`
-	Role                                AgentPoolProfileRole `json:"role,omitempty"`
-	AcceleratedNetworkingEnabled        *bool                `json:"acceleratedNetworkingEnabled,omitempty"`
-	AcceleratedNetworkingEnabledWindows *bool                `json:"acceleratedNetworkingEnabledWindows,omitempty"`
-	VMSSOverProvisioningEnabled         *bool                `json:"vmssOverProvisioningEnabled,omitempty"`
-	FQDN                                string               `json:"fqdn,omitempty"`
-	CustomNodeLabels                    map[string]string    `json:"customNodeLabels,omitempty"`
-	PreprovisionExtension               *Extension           `json:"preProvisionExtension"`
-	Extensions                          []Extension          `json:"extensions"`
-	KubernetesConfig                    *KubernetesConfig    `json:"kubernetesConfig,omitempty"`
-	OrchestratorVersion                 string               `json:"orchestratorVersion"`
-	ImageRef                            *ImageReference      `json:"imageReference,omitempty"`
-	MaxCount                            *int                 `json:"maxCount,omitempty"`
-	MinCount                            *int                 `json:"minCount,omitempty"`
-	EnableAutoScaling                   *bool                `json:"enableAutoScaling,omitempty"`
-	AvailabilityZones                   []string             `json:"availabilityZones,omitempty"`
-	SinglePlacementGroup                *bool                `json:"singlePlacementGroup,omitempty"`
-	VnetCidrs                           []string             `json:"vnetCidrs,omitempty"`
-	PreserveNodesProperties             *bool                `json:"preserveNodesProperties,omitempty"`
-	WindowsNameVersion                  string               `json:"windowsNameVersion,omitempty"`
-	EnableVMSSNodePublicIP              *bool                `json:"enableVMSSNodePublicIP,omitempty"`
-	LoadBalancerBackendAddressPoolIDs   []string             `json:"loadBalancerBackendAddressPoolIDs,omitempty"`
-	AuditDEnabled                       *bool                `json:"auditDEnabled,omitempty"`
-	CustomVMTags                        map[string]string    `json:"customVMTags,omitempty"`
+	Name                                string                  `json:"name"`
+	Count                               int                     `json:"count"`
+	VMSize                              string                  `json:"vmSize"`
+	OSDiskSizeGB                        int                     `json:"osDiskSizeGB,omitempty"`
+	DNSPrefix                           string                  `json:"dnsPrefix,omitempty"`
+	OSType                              OSType                  `json:"osType,omitempty"`
+	Ports                               []int                   `json:"ports,omitempty"`
+	ProvisioningState                   ProvisioningState       `json:"provisioningState,omitempty"`
+	AvailabilityProfile                 string                  `json:"availabilityProfile"`
+	PlatformFaultDomainCount            *int                    `json:"platformFaultDomainCount"`
+	ScaleSetPriority                    string                  `json:"scaleSetPriority,omitempty"`
+	ScaleSetEvictionPolicy              string                  `json:"scaleSetEvictionPolicy,omitempty"`
+	SpotMaxPrice                        *float64                `json:"spotMaxPrice,omitempty"`
+	StorageProfile                      string                  `json:"storageProfile,omitempty"`
+	OSDiskType                          string                  `json:"osDiskType,omitempty"`
+	DiskSizesGB                         []int                   `json:"diskSizesGB,omitempty"`
+	DataDiskStorageAccountType          string                  `json:"dataDiskStorageAccountType,omitempty"`
+	DataDiskIOPSReadWrite               int                     `json:"dataDiskIOPSReadWrite,omitempty"`
+	DataDiskMBpsReadWrite               int                     `json:"dataDiskMBpsReadWrite,omitempty"`
+	EnableSharedDisk                    *bool                   `json:"enableSharedDisk,omitempty"`
+	VnetSubnetID                        string                  `json:"vnetSubnetID,omitempty"`
+	PodSubnetID                         string                  `json:"podSubnetID,omitempty"`
+	Subnet                              string                  `json:"subnet"`
+	IPAddressCount                      int                     `json:"ipAddressCount,omitempty"`
+	Distro                              Distro                  `json:"distro,omitempty"`
+	Role                                AgentPoolProfileRole    `json:"role,omitempty"`
+	AcceleratedNetworkingEnabled        *bool                   `json:"acceleratedNetworkingEnabled,omitempty"`
+	AcceleratedNetworkingEnabledWindows *bool                   `json:"acceleratedNetworkingEnabledWindows,omitempty"`
+	VMSSOverProvisioningEnabled         *bool                   `json:"vmssOverProvisioningEnabled,omitempty"`
+	FQDN                                string                  `json:"fqdn,omitempty"`
+	CustomNodeLabels                    map[string]string       `json:"customNodeLabels,omitempty"`
+	PreprovisionExtension               *Extension              `json:"preProvisionExtension"`
+	Extensions                          []Extension             `json:"extensions"`
+	VMExtensions                        []VMExtension           `json:"vmExtensions,omitempty"`
+	KubernetesConfig                    *KubernetesConfig       `json:"kubernetesConfig,omitempty"`
+	OrchestratorVersion                 string                  `json:"orchestratorVersion"`
+	ImageRef                            *ImageReference         `json:"imageReference,omitempty"`
+	MaxCount                            *int                    `json:"maxCount,omitempty"`
+	MinCount                            *int                    `json:"minCount,omitempty"`
+	EnableAutoScaling                   *bool                   `json:"enableAutoScaling,omitempty"`
+	AvailabilityZones                   []string                `json:"availabilityZones,omitempty"`
+	ZoneBalanceStrategy                 string                  `json:"zoneBalanceStrategy,omitempty"`
+	SinglePlacementGroup                *bool                   `json:"singlePlacementGroup,omitempty"`
+	ProximityPlacementGroupID           string                  `json:"proximityPlacementGroupID,omitempty"`
+	CreateProximityPlacementGroup       *bool                   `json:"createProximityPlacementGroup,omitempty"`
+	HostGroupID                         string                  `json:"hostGroupID,omitempty"`
+	VnetCidrs                           []string                `json:"vnetCidrs,omitempty"`
+	PreserveNodesProperties             *bool                   `json:"preserveNodesProperties,omitempty"`
+	WindowsNameVersion                  string                  `json:"windowsNameVersion,omitempty"`
+	EnableVMSSNodePublicIP              *bool                   `json:"enableVMSSNodePublicIP,omitempty"`
+	LoadBalancerBackendAddressPoolIDs   []string                `json:"loadBalancerBackendAddressPoolIDs,omitempty"`
+	UserAssignedID                      string                  `json:"userAssignedID,omitempty"`
+	AuditDEnabled                       *bool                   `json:"auditDEnabled,omitempty"`
+	CustomVMTags                        map[string]string       `json:"customVMTags,omitempty"`
+	DNSConfig                           *NodeDNSConfig          `json:"dnsConfig,omitempty"`
+	ScheduledEventsProfile              *ScheduledEventsProfile `json:"scheduledEventsProfile,omitempty"`
 }
 
 // AgentPoolProfileRole represents an agent role
@@ -1174,10 +1378,65 @@ func (p *Properties) HasAvailabilityZones() bool {
 	return hasZones
 }
 
-// HasLowPriorityScaleset returns true if any one node pool has a low-priority scaleset configuration
+// GetAvailabilityZones returns the sorted availability zones of all the profiles of the cluster
+func (p *Properties) GetAvailabilityZones() []string {
+	var zones []string
+	seen := map[string]bool{}
+	add := func(profileZones []string) {
+		for _, zone := range profileZones {
+			if !seen[zone] {
+				seen[zone] = true
+				zones = append(zones, zone)
+			}
+		}
+	}
+	if p.MasterProfile != nil {
+		add(p.MasterProfile.AvailabilityZones)
+	}
+	for _, agentPoolProfile := range p.AgentPoolProfiles {
+		add(agentPoolProfile.AvailabilityZones)
+	}
+	sort.Strings(zones)
+	return zones
+}
+
+// AnyAgentHasScheduledEventsHandler returns true if the nodes of any agent pool are drained when Azure schedules the preemption or the deletion of their VMs
+func (p *Properties) AnyAgentHasScheduledEventsHandler() bool {
+	for _, agentPoolProfile := range p.AgentPoolProfiles {
+		if agentPoolProfile.IsScheduledEventsHandlerEnabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasLowPriorityScaleset returns true if any one node pool has a low-priority or a Spot scaleset configuration, whose VMs may be evicted
 func (p *Properties) HasLowPriorityScaleset() bool {
 	for _, agentPoolProfile := range p.AgentPoolProfiles {
-		if agentPoolProfile.IsLowPriorityScaleSet() {
+		if agentPoolProfile.IsLowPriorityScaleSet() || agentPoolProfile.IsSpotScaleSet() {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatesProximityPlacementGroup returns true if the masters or any one node pool are placed in a proximity placement group created with the cluster
+func (p *Properties) CreatesProximityPlacementGroup() bool {
+	if p.MasterProfile != nil && to.Bool(p.MasterProfile.CreateProximityPlacementGroup) {
+		return true
+	}
+	for _, agentPoolProfile := range p.AgentPoolProfiles {
+		if to.Bool(agentPoolProfile.CreateProximityPlacementGroup) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasSpotScaleset returns true if any one node pool has a Spot scaleset configuration
+func (p *Properties) HasSpotScaleset() bool {
+	for _, agentPoolProfile := range p.AgentPoolProfiles {
+		if agentPoolProfile.IsSpotScaleSet() {
 			return true
 		}
 	}
@@ -1260,6 +1519,11 @@ func (m *MasterProfile) IsCustomVNET() bool {
 	return len(m.VnetSubnetID) > 0
 }
 
+// HasDedicatedEtcdSubnet returns true if etcd traffic between masters uses a subnet of its own
+func (m *MasterProfile) HasDedicatedEtcdSubnet() bool {
+	return m.EtcdSubnet != ""
+}
+
 // IsManagedDisks returns true if the master specified managed disks
 func (m *MasterProfile) IsManagedDisks() bool {
 	return m.StorageProfile == ManagedDisks
@@ -1270,6 +1534,11 @@ func (m *MasterProfile) IsStorageAccount() bool {
 	return m.StorageProfile == StorageAccount
 }
 
+// HasUltraSSD returns true if the etcd data disks of the masters are Ultra SSDs
+func (m *MasterProfile) HasUltraSSD() bool {
+	return m.DataDiskStorageAccountType == UltraSSDDataDisk
+}
+
 // IsRHEL returns true if the master specified a RHEL distro
 func (m *MasterProfile) IsRHEL() bool {
 	return m.Distro == RHEL
@@ -1324,6 +1593,11 @@ func (m *MasterProfile) HasAvailabilityZones() bool {
 	return m.AvailabilityZones != nil && len(m.AvailabilityZones) > 0
 }
 
+// HasProximityPlacementGroup returns true if the masters are placed in an existing proximity placement group or in the one of the cluster
+func (m *MasterProfile) HasProximityPlacementGroup() bool {
+	return m.ProximityPlacementGroupID != "" || to.Bool(m.CreateProximityPlacementGroup)
+}
+
 // IsUbuntu1604 returns true if the master profile distro is based on Ubuntu 16.04
 func (m *MasterProfile) IsUbuntu1604() bool {
 	switch m.Distro {
@@ -1389,6 +1663,11 @@ func (a *AgentPoolProfile) IsCustomVNET() bool {
 	return len(a.VnetSubnetID) > 0
 }
 
+// HasPodSubnet returns true if the Azure CNI pod IPs of the pool are allocated in a subnet of their own
+func (a *AgentPoolProfile) HasPodSubnet() bool {
+	return len(a.PodSubnetID) > 0
+}
+
 // IsWindows returns true if the agent pool is windows
 func (a *AgentPoolProfile) IsWindows() bool {
 	return a.OSType == Windows
@@ -1429,6 +1708,21 @@ func (a *AgentPoolProfile) IsLowPriorityScaleSet() bool {
 	return a.AvailabilityProfile == VirtualMachineScaleSets && a.ScaleSetPriority == ScaleSetPriorityLow
 }
 
+// IsSpotScaleSet returns true if the VMSS is Spot
+func (a *AgentPoolProfile) IsSpotScaleSet() bool {
+	return a.AvailabilityProfile == VirtualMachineScaleSets && a.ScaleSetPriority == ScaleSetPrioritySpot
+}
+
+// IsScheduledEventsHandlerEnabled returns true if the nodes of the pool are drained when Azure schedules the preemption or the deletion of their VMs
+func (a *AgentPoolProfile) IsScheduledEventsHandlerEnabled() bool {
+	return a.ScheduledEventsProfile != nil && to.Bool(a.ScheduledEventsProfile.Enabled)
+}
+
+// HasTerminateNotification returns true if the scale set of the pool schedules the deletion of its VMs
+func (a *AgentPoolProfile) HasTerminateNotification() bool {
+	return a.IsScheduledEventsHandlerEnabled() && a.AvailabilityProfile == VirtualMachineScaleSets && a.ScheduledEventsProfile.TerminateNotificationTimeoutMinutes > 0
+}
+
 // IsManagedDisks returns true if the customer specified disks
 func (a *AgentPoolProfile) IsManagedDisks() bool {
 	return a.StorageProfile == ManagedDisks
@@ -1439,9 +1733,10 @@ func (a *AgentPoolProfile) IsStorageAccount() bool {
 	return a.StorageProfile == StorageAccount
 }
 
-// IsStorageAccount returns true if the customer specified ephemeral disks
+// IsEphemeral returns true if the customer specified ephemeral OS disks, with either the OS disk type or the storage
+// profile
 func (a *AgentPoolProfile) IsEphemeral() bool {
-	return a.StorageProfile == Ephemeral
+	return a.OSDiskType == EphemeralOSDisk || a.StorageProfile == Ephemeral
 }
 
 // HasDisks returns true if the customer specified disks
@@ -1454,6 +1749,36 @@ func (a *AgentPoolProfile) HasAvailabilityZones() bool {
 	return a.AvailabilityZones != nil && len(a.AvailabilityZones) > 0
 }
 
+// IsZoneBalanced returns true if the scale set of the pool strictly balances its VMs across its availability zones
+func (a *AgentPoolProfile) IsZoneBalanced() bool {
+	return a.HasAvailabilityZones() && a.ZoneBalanceStrategy == StrictZoneBalance
+}
+
+// HasProximityPlacementGroup returns true if the VMs of the pool are placed in an existing proximity placement group or in the one of the cluster
+func (a *AgentPoolProfile) HasProximityPlacementGroup() bool {
+	return a.ProximityPlacementGroupID != "" || to.Bool(a.CreateProximityPlacementGroup)
+}
+
+// HasHostGroup returns true if the VMs of the pool are placed on the dedicated hosts of a host group
+func (a *AgentPoolProfile) HasHostGroup() bool {
+	return a.HostGroupID != ""
+}
+
+// HasUltraSSD returns true if the data disks of the pool are Ultra SSDs
+func (a *AgentPoolProfile) HasUltraSSD() bool {
+	return a.HasDisks() && a.DataDiskStorageAccountType == UltraSSDDataDisk
+}
+
+// HasSharedDisks returns true if the data disks of the pool are attached to all its VMs
+func (a *AgentPoolProfile) HasSharedDisks() bool {
+	return a.HasDisks() && to.Bool(a.EnableSharedDisk)
+}
+
+// HasUserAssignedID returns true if the VMs of the pool have a user assigned identity of their own
+func (a *AgentPoolProfile) HasUserAssignedID() bool {
+	return a.UserAssignedID != ""
+}
+
 // IsUbuntu1604 returns true if the agent pool profile distro is based on Ubuntu 16.04
 func (a *AgentPoolProfile) IsUbuntu1604() bool {
 	if a.OSType != Windows {
@@ -1507,6 +1832,9 @@ func (a *AgentPoolProfile) GetKubernetesLabels(rg string, deprecated bool) strin
 		accelerator := "nvidia"
 		buf.WriteString(fmt.Sprintf(",accelerator=%s", accelerator))
 	}
+	if a.IsSpotScaleSet() {
+		buf.WriteString(fmt.Sprintf(",%s=%s", ScaleSetPriorityLabelKey, ScaleSetPrioritySpotLabelValue))
+	}
 	buf.WriteString(fmt.Sprintf(",kubernetes.azure.com/cluster=%s", rg))
 	keys := []string{}
 	for key := range a.CustomNodeLabels {
@@ -1588,6 +1916,16 @@ func (l *LinuxProfile) HasCustomNodesDNS() bool {
 	return false
 }
 
+// HasDNSServers returns true if custom DNS servers are configured for the nodes
+func (d *NodeDNSConfig) HasDNSServers() bool {
+	return d != nil && len(d.DNSServers) > 0
+}
+
+// HasSearchDomains returns true if custom search domains are configured for the nodes
+func (d *NodeDNSConfig) HasSearchDomains() bool {
+	return d != nil && len(d.SearchDomains) > 0
+}
+
 // IsSwarmMode returns true if this template is for Swarm Mode orchestrator
 func (o *OrchestratorProfile) IsSwarmMode() bool {
 	return o.OrchestratorType == SwarmMode
@@ -1622,6 +1960,11 @@ func (o *OrchestratorProfile) IsAzureCNI() bool {
 func (o *OrchestratorProfile) RequireRouteTable() bool {
 	switch o.OrchestratorType {
 	case Kubernetes:
+		// cilium and antrea tunnel the pod traffic between the nodes
+		switch o.KubernetesConfig.NetworkPlugin {
+		case NetworkPluginCilium, NetworkPluginAntrea:
+			return false
+		}
 		if o.IsAzureCNI() || NetworkPolicyCilium == o.KubernetesConfig.NetworkPolicy || "flannel" == o.KubernetesConfig.NetworkPlugin {
 			return false
 		}
@@ -1711,6 +2054,134 @@ func (k *KubernetesConfig) IsIPMasqAgentEnabled() bool {
 	return k.IsAddonEnabled(IPMASQAgentAddonName)
 }
 
+// IsRegistryCacheEnabled checks if the registry-cache addon is enabled
+func (k *KubernetesConfig) IsRegistryCacheEnabled() bool {
+	return k.IsAddonEnabled(RegistryCacheAddonName)
+}
+
+// GetRegistryMirrors returns the registry mirrors to configure the container runtime with on every node.
+// When the registry-cache addon is enabled its node port is the first Docker Hub mirror.
+func (k *KubernetesConfig) GetRegistryMirrors() []RegistryMirror {
+	var mirrors []RegistryMirror
+	var cacheEndpoint string
+	if k.IsRegistryCacheEnabled() {
+		nodePort := k.GetAddonByName(RegistryCacheAddonName).Config["nodePort"]
+		if nodePort == "" {
+			nodePort = DefaultRegistryCacheNodePort
+		}
+		cacheEndpoint = "http://localhost:" + nodePort
+		mirrors = append(mirrors, RegistryMirror{Registry: DockerHubRegistry, Endpoints: []string{cacheEndpoint}})
+	}
+	for _, mirror := range k.RegistryMirrors {
+		if cacheEndpoint != "" && mirror.Registry == DockerHubRegistry {
+			mirrors[0].Endpoints = append(mirrors[0].Endpoints, mirror.Endpoints...)
+			continue
+		}
+		mirrors = append(mirrors, mirror)
+	}
+	return mirrors
+}
+
+// IsOIDCIssuerEnabled checks if the API server issues service account tokens for workload identity federation
+func (k *KubernetesConfig) IsOIDCIssuerEnabled() bool {
+	return k.OIDCIssuerProfile != nil && to.Bool(k.OIDCIssuerProfile.Enabled)
+}
+
+// IsKonnectivityEnabled checks if the API server reaches the nodes through konnectivity tunnels
+func (k *KubernetesConfig) IsKonnectivityEnabled() bool {
+	return k.KonnectivityProfile != nil && to.Bool(k.KonnectivityProfile.Enabled)
+}
+
+// IsKubeProxyReplaced checks if the cilium addon replaces kube-proxy, in which case kube-proxy is not deployed
+func (k *KubernetesConfig) IsKubeProxyReplaced() bool {
+	if k.NetworkPlugin != NetworkPluginCilium || !k.IsAddonEnabled(CiliumAddonName) {
+		return false
+	}
+	return k.GetAddonByName(CiliumAddonName).Config["kube-proxy-replacement"] == CiliumKubeProxyReplacementStrict
+}
+
+// IsAirGapped checks if the nodes install exclusively from the mirrors of the air gap profile
+func (k *KubernetesConfig) IsAirGapped() bool {
+	return k.AirGapProfile != nil && to.Bool(k.AirGapProfile.Enabled)
+}
+
+// HasOutboundLoadBalancer checks if the egress traffic of the agents goes through the outbound rule of the agent standard load balancer
+func (k *KubernetesConfig) HasOutboundLoadBalancer() bool {
+	return k.LoadBalancerSku == StandardLoadBalancerSku && (k.OutboundType == "" || k.OutboundType == OutboundTypeLoadBalancer)
+}
+
+// HasNATGateway checks if the egress traffic of the cluster goes through a NAT gateway attached to the subnets of the VNET
+func (k *KubernetesConfig) HasNATGateway() bool {
+	return k.OutboundType == OutboundTypeNATGateway
+}
+
+// GetManifestPatches returns the patches of the static pod manifest of a control plane component, in the order they apply
+func (k *KubernetesConfig) GetManifestPatches(component string) []ManifestPatch {
+	var patches []ManifestPatch
+	for _, patch := range k.ManifestPatches {
+		if patch.Component == component {
+			patches = append(patches, patch)
+		}
+	}
+	return patches
+}
+
+// GetManifestPatchArgs returns the flags the manifest patches add to a control plane component, the later patches override the earlier
+func (k *KubernetesConfig) GetManifestPatchArgs(component string) map[string]string {
+	args := map[string]string{}
+	for _, patch := range k.GetManifestPatches(component) {
+		for key, val := range patch.ExtraArgs {
+			args[key] = val
+		}
+	}
+	return args
+}
+
+// GetCustomManifests returns the custom manifests in the order the addon manager applies them: each manifest after
+// the manifests it depends on, in the order of the cluster definition otherwise
+func (k *KubernetesConfig) GetCustomManifests() ([]CustomManifest, error) {
+	names := map[string]bool{}
+	for _, manifest := range k.CustomManifests {
+		names[manifest.Name] = true
+	}
+	for _, manifest := range k.CustomManifests {
+		for _, dependency := range manifest.DependsOn {
+			if !names[dependency] {
+				return nil, fmt.Errorf("custom manifest %s depends on %s, which is not a custom manifest", manifest.Name, dependency)
+			}
+		}
+	}
+
+	ordered := make([]CustomManifest, 0, len(k.CustomManifests))
+	applied := map[string]bool{}
+	for len(ordered) < len(k.CustomManifests) {
+		progress := false
+		for _, manifest := range k.CustomManifests {
+			if applied[manifest.Name] {
+				continue
+			}
+			ready := true
+			for _, dependency := range manifest.DependsOn {
+				ready = ready && applied[dependency]
+			}
+			if ready {
+				ordered = append(ordered, manifest)
+				applied[manifest.Name] = true
+				progress = true
+			}
+		}
+		if !progress {
+			return nil, fmt.Errorf("the dependencies of the custom manifests are circular")
+		}
+	}
+	return ordered, nil
+}
+
+// IsAzureWorkloadIdentityEnabled checks if the azure-workload-identity-webhook addon is enabled
+func (k *KubernetesConfig) IsAzureWorkloadIdentityEnabled() bool {
+	return k.IsAddonEnabled(AzureWorkloadIdentityAddonName)
+}
+
 // IsRBACEnabled checks if RBAC is enabled
 func (k *KubernetesConfig) IsRBACEnabled() bool {
 	if k.EnableRbac != nil {
@@ -1920,6 +2391,12 @@ func (k *KubernetesConfig) PrivateJumpboxProvision() bool {
 	return false
 }
 
+// PrivateLinkEnabled checks if the API server of a private cluster is fronted by a Private Link service
+func (k *KubernetesConfig) PrivateLinkEnabled() bool {
+	return k != nil && k.PrivateCluster != nil && to.Bool(k.PrivateCluster.Enabled) &&
+		k.PrivateCluster.PrivateLink != nil && to.Bool(k.PrivateCluster.PrivateLink.Enabled)
+}
+
 // RequiresDocker returns if the kubernetes settings require docker binary to be installed.
 func (k *KubernetesConfig) RequiresDocker() bool {
 	runtime := strings.ToLower(k.ContainerRuntime)
@@ -2001,6 +2478,10 @@ func (cs *ContainerService) SetPlatformFaultDomainCount(count int) {
 		cs.Properties.MasterProfile.PlatformFaultDomainCount = &count
 	}
 	for _, pool := range cs.Properties.AgentPoolProfiles {
+		// the fault domain count of the scale sets on dedicated hosts is aligned with their host group and cannot change
+		if pool.HasHostGroup() {
+			continue
+		}
 		pool.PlatformFaultDomainCount = &count
 	}
 }
@@ -2012,6 +2493,14 @@ func FormatAzureProdFQDNByLocation(fqdnPrefix string, location string) string {
 	return fmt.Sprintf("%s.%s."+FQDNFormat, fqdnPrefix, location)
 }
 
+// FormatPrivateLinkFQDNByLocation constructs the fqdn registered in the private DNS zone of the
+// API server private endpoint, e.g. <fqdnPrefix>.privatelink.<location>.cloudapp.azure.com
+func FormatPrivateLinkFQDNByLocation(fqdnPrefix string, location string, cloudName string) string {
+	targetEnv := helpers.GetTargetEnv(location, cloudName)
+	FQDNFormat := AzureCloudSpecEnvMap[targetEnv].EndpointConfig.ResourceManagerVMDNSSuffix
+	return fmt.Sprintf("%s.privatelink.%s."+FQDNFormat, fqdnPrefix, location)
+}
+
 // FormatProdFQDNByLocation constructs an Azure prod fqdn with custom cloud profile
 // CustomCloudName is name of environment if customCloudProfile is provided, it will be empty string if customCloudProfile is empty.
 // Because customCloudProfile is empty for deployment for AzurePublicCloud, AzureChinaCloud,AzureGermanCloud,AzureUSGovernmentCloud,
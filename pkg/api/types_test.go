@@ -690,6 +690,29 @@ func TestHasStorageProfile(t *testing.T) {
 			expectedAgent0E:   true,
 			expectedPrivateJB: false,
 		},
+		{
+			name: "Managed disk agent with ephemeral OS disk",
+			p: Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+				},
+				MasterProfile: &MasterProfile{
+					StorageProfile: ManagedDisks,
+				},
+				AgentPoolProfiles: []*AgentPoolProfile{
+					{
+						StorageProfile: ManagedDisks,
+						OSDiskType:     EphemeralOSDisk,
+					},
+				},
+			},
+			expectedHasMD:     true,
+			expectedHasSA:     false,
+			expectedMasterMD:  true,
+			expectedAgent0MD:  true,
+			expectedAgent0E:   true,
+			expectedPrivateJB: false,
+		},
 		{
 			name: "Mixed with jumpbox",
 			p: Properties{
@@ -867,6 +890,16 @@ func TestAgentPoolProfileGetKubernetesLabels(t *testing.T) {
 			deprecated: true,
 			expected:   "kubernetes.azure.com/role=agent,node-role.kubernetes.io/agent=,kubernetes.io/role=agent,agentpool=,storageprofile=managed,storagetier=Standard_LRS,accelerator=nvidia,kubernetes.azure.com/cluster=my-resource-group,mycustomlabel1=foo,mycustomlabel2=bar",
 		},
+		{
+			name: "spot scale set",
+			ap: AgentPoolProfile{
+				AvailabilityProfile: VirtualMachineScaleSets,
+				ScaleSetPriority:    ScaleSetPrioritySpot,
+			},
+			rg:         "my-resource-group",
+			deprecated: false,
+			expected:   "kubernetes.azure.com/role=agent,agentpool=,kubernetes.azure.com/scalesetpriority=spot,kubernetes.azure.com/cluster=my-resource-group",
+		},
 	}
 
 	for _, c := range cases {
@@ -1496,11 +1529,99 @@ func TestHasAvailabilityZones(t *testing.T) {
 	}
 }
 
+func TestGetAvailabilityZones(t *testing.T) {
+	p := Properties{
+		MasterProfile: &MasterProfile{
+			Count:             3,
+			AvailabilityZones: []string{"3", "1"},
+		},
+		AgentPoolProfiles: []*AgentPoolProfile{
+			{
+				Count:             1,
+				AvailabilityZones: []string{"2", "1"},
+			},
+			{
+				Count: 1,
+			},
+		},
+	}
+	expected := []string{"1", "2", "3"}
+	if zones := p.GetAvailabilityZones(); !reflect.DeepEqual(zones, expected) {
+		t.Errorf("expected GetAvailabilityZones() to return %v but instead returned %v", expected, zones)
+	}
+
+	p = Properties{
+		MasterProfile: &MasterProfile{
+			Count: 1,
+		},
+	}
+	if zones := p.GetAvailabilityZones(); zones != nil {
+		t.Errorf("expected GetAvailabilityZones() to return nil but instead returned %v", zones)
+	}
+}
+
+func TestIsZoneBalanced(t *testing.T) {
+	cases := []struct {
+		a        AgentPoolProfile
+		expected bool
+	}{
+		{
+			a: AgentPoolProfile{
+				AvailabilityZones:   []string{"1", "2"},
+				ZoneBalanceStrategy: StrictZoneBalance,
+			},
+			expected: true,
+		},
+		{
+			a: AgentPoolProfile{
+				AvailabilityZones:   []string{"1", "2"},
+				ZoneBalanceStrategy: BestEffortZoneBalance,
+			},
+			expected: false,
+		},
+		{
+			a: AgentPoolProfile{
+				AvailabilityZones: []string{"1", "2"},
+			},
+			expected: false,
+		},
+		{
+			a: AgentPoolProfile{
+				ZoneBalanceStrategy: StrictZoneBalance,
+			},
+			expected: false,
+		},
+	}
+
+	for _, c := range cases {
+		if c.a.IsZoneBalanced() != c.expected {
+			t.Fatalf("expected IsZoneBalanced() to return %t but instead returned %t", c.expected, c.a.IsZoneBalanced())
+		}
+	}
+}
+
 func TestHasLowPriorityScaleset(t *testing.T) {
 	cases := []struct {
 		p        Properties
 		expected bool
 	}{
+		{
+			p: Properties{
+				MasterProfile: &MasterProfile{
+					Count: 1,
+				},
+				AgentPoolProfiles: []*AgentPoolProfile{
+					{
+						AvailabilityProfile: VirtualMachineScaleSets,
+					},
+					{
+						AvailabilityProfile: VirtualMachineScaleSets,
+						ScaleSetPriority:    ScaleSetPrioritySpot,
+					},
+				},
+			},
+			expected: true,
+		},
 		{
 			p: Properties{
 				MasterProfile: &MasterProfile{
@@ -1608,6 +1729,74 @@ func TestHasLowPriorityScaleset(t *testing.T) {
 	}
 }
 
+func TestProximityPlacementGroups(t *testing.T) {
+	ppgID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/proximityPlacementGroups/PPG_NAME"
+	cases := []struct {
+		name            string
+		p               Properties
+		expectedMaster  bool
+		expectedAgent   bool
+		expectedCreates bool
+	}{
+		{
+			name: "no proximity placement group",
+			p: Properties{
+				MasterProfile:     &MasterProfile{},
+				AgentPoolProfiles: []*AgentPoolProfile{{}},
+			},
+		},
+		{
+			name: "existing proximity placement group for the masters",
+			p: Properties{
+				MasterProfile:     &MasterProfile{ProximityPlacementGroupID: ppgID},
+				AgentPoolProfiles: []*AgentPoolProfile{{CreateProximityPlacementGroup: to.BoolPtr(false)}},
+			},
+			expectedMaster: true,
+		},
+		{
+			name: "created proximity placement group for the masters",
+			p: Properties{
+				MasterProfile:     &MasterProfile{CreateProximityPlacementGroup: to.BoolPtr(true)},
+				AgentPoolProfiles: []*AgentPoolProfile{{}},
+			},
+			expectedMaster:  true,
+			expectedCreates: true,
+		},
+		{
+			name: "existing proximity placement group for an agent pool",
+			p: Properties{
+				MasterProfile:     &MasterProfile{},
+				AgentPoolProfiles: []*AgentPoolProfile{{ProximityPlacementGroupID: ppgID}},
+			},
+			expectedAgent: true,
+		},
+		{
+			name: "created proximity placement group for an agent pool without masters",
+			p: Properties{
+				AgentPoolProfiles: []*AgentPoolProfile{{CreateProximityPlacementGroup: to.BoolPtr(true)}},
+			},
+			expectedAgent:   true,
+			expectedCreates: true,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			if c.p.MasterProfile != nil && c.p.MasterProfile.HasProximityPlacementGroup() != c.expectedMaster {
+				t.Errorf("expected MasterProfile.HasProximityPlacementGroup() to return %t", c.expectedMaster)
+			}
+			if c.p.AgentPoolProfiles[0].HasProximityPlacementGroup() != c.expectedAgent {
+				t.Errorf("expected AgentPoolProfile.HasProximityPlacementGroup() to return %t", c.expectedAgent)
+			}
+			if c.p.CreatesProximityPlacementGroup() != c.expectedCreates {
+				t.Errorf("expected CreatesProximityPlacementGroup() to return %t", c.expectedCreates)
+			}
+		})
+	}
+}
+
 func TestMasterIsUbuntu(t *testing.T) {
 	cases := []struct {
 		p        Properties
@@ -2724,6 +2913,28 @@ func TestRequireRouteTable(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			p: Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: &KubernetesConfig{
+						NetworkPlugin: NetworkPluginCilium,
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			p: Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: &KubernetesConfig{
+						NetworkPlugin: NetworkPluginAntrea,
+					},
+				},
+			},
+			expected: false,
+		},
 	}
 
 	for _, c := range cases {
@@ -2733,6 +2944,87 @@ func TestRequireRouteTable(t *testing.T) {
 	}
 }
 
+func TestIsKubeProxyReplaced(t *testing.T) {
+	cilium := func(enabled bool, kubeProxyReplacement string) KubernetesAddon {
+		return KubernetesAddon{
+			Name:    CiliumAddonName,
+			Enabled: to.BoolPtr(enabled),
+			Config:  map[string]string{"kube-proxy-replacement": kubeProxyReplacement},
+		}
+	}
+	cases := []struct {
+		name     string
+		k        KubernetesConfig
+		expected bool
+	}{
+		{
+			name: "kubenet",
+			k:    KubernetesConfig{NetworkPlugin: NetworkPluginKubenet},
+		},
+		{
+			name: "cilium along kube-proxy",
+			k:    KubernetesConfig{NetworkPlugin: NetworkPluginCilium, Addons: []KubernetesAddon{cilium(true, CiliumKubeProxyReplacementDisabled)}},
+		},
+		{
+			name:     "cilium replacing kube-proxy",
+			k:        KubernetesConfig{NetworkPlugin: NetworkPluginCilium, Addons: []KubernetesAddon{cilium(true, CiliumKubeProxyReplacementStrict)}},
+			expected: true,
+		},
+		{
+			name: "cilium addon disabled",
+			k:    KubernetesConfig{NetworkPlugin: NetworkPluginCilium, Addons: []KubernetesAddon{cilium(false, CiliumKubeProxyReplacementStrict)}},
+		},
+	}
+
+	for _, c := range cases {
+		if actual := c.k.IsKubeProxyReplaced(); actual != c.expected {
+			t.Errorf("%s: expected IsKubeProxyReplaced() to return %t but instead got %t", c.name, c.expected, actual)
+		}
+	}
+}
+
+func TestHasOutboundLoadBalancer(t *testing.T) {
+	cases := []struct {
+		name        string
+		k           KubernetesConfig
+		expected    bool
+		expectedNAT bool
+	}{
+		{
+			name: "basic load balancer",
+			k:    KubernetesConfig{LoadBalancerSku: BasicLoadBalancerSku},
+		},
+		{
+			name:     "standard load balancer without outbound type",
+			k:        KubernetesConfig{LoadBalancerSku: StandardLoadBalancerSku},
+			expected: true,
+		},
+		{
+			name:     "standard load balancer outbound type",
+			k:        KubernetesConfig{LoadBalancerSku: StandardLoadBalancerSku, OutboundType: OutboundTypeLoadBalancer},
+			expected: true,
+		},
+		{
+			name:        "NAT gateway outbound type",
+			k:           KubernetesConfig{LoadBalancerSku: StandardLoadBalancerSku, OutboundType: OutboundTypeNATGateway},
+			expectedNAT: true,
+		},
+		{
+			name: "user defined routing outbound type",
+			k:    KubernetesConfig{LoadBalancerSku: StandardLoadBalancerSku, OutboundType: OutboundTypeUserDefinedRouting},
+		},
+	}
+
+	for _, c := range cases {
+		if actual := c.k.HasOutboundLoadBalancer(); actual != c.expected {
+			t.Errorf("%s: expected HasOutboundLoadBalancer() to return %t but instead got %t", c.name, c.expected, actual)
+		}
+		if actual := c.k.HasNATGateway(); actual != c.expectedNAT {
+			t.Errorf("%s: expected HasNATGateway() to return %t but instead got %t", c.name, c.expectedNAT, actual)
+		}
+	}
+}
+
 func TestIsPrivateCluster(t *testing.T) {
 	cases := []struct {
 		p        Properties
@@ -3564,6 +3856,152 @@ func TestIsReschedulerEnabled(t *testing.T) {
 	}
 }
 
+func TestGetRegistryMirrors(t *testing.T) {
+	mcrMirror := RegistryMirror{Registry: "mcr.microsoft.com", Endpoints: []string{"https://mcr.example.com"}}
+	dockerHubMirror := RegistryMirror{Registry: DockerHubRegistry, Endpoints: []string{"https://dockerhub.example.com"}}
+	cases := []struct {
+		name     string
+		k        KubernetesConfig
+		expected []RegistryMirror
+	}{
+		{
+			name: "no mirrors",
+			k:    KubernetesConfig{},
+		},
+		{
+			name:     "user-provided mirrors",
+			k:        KubernetesConfig{RegistryMirrors: []RegistryMirror{mcrMirror, dockerHubMirror}},
+			expected: []RegistryMirror{mcrMirror, dockerHubMirror},
+		},
+		{
+			name: "registry-cache addon",
+			k: KubernetesConfig{
+				Addons: []KubernetesAddon{
+					{
+						Name:    RegistryCacheAddonName,
+						Enabled: to.BoolPtr(true),
+					},
+				},
+			},
+			expected: []RegistryMirror{
+				{Registry: DockerHubRegistry, Endpoints: []string{"http://localhost:" + DefaultRegistryCacheNodePort}},
+			},
+		},
+		{
+			name: "registry-cache addon in front of user-provided mirrors",
+			k: KubernetesConfig{
+				Addons: []KubernetesAddon{
+					{
+						Name:    RegistryCacheAddonName,
+						Enabled: to.BoolPtr(true),
+						Config:  map[string]string{"nodePort": "31000"},
+					},
+				},
+				RegistryMirrors: []RegistryMirror{mcrMirror, dockerHubMirror},
+			},
+			expected: []RegistryMirror{
+				{Registry: DockerHubRegistry, Endpoints: []string{"http://localhost:31000", "https://dockerhub.example.com"}},
+				mcrMirror,
+			},
+		},
+		{
+			name: "disabled registry-cache addon",
+			k: KubernetesConfig{
+				Addons: []KubernetesAddon{
+					{
+						Name:    RegistryCacheAddonName,
+						Enabled: to.BoolPtr(false),
+					},
+				},
+				RegistryMirrors: []RegistryMirror{dockerHubMirror},
+			},
+			expected: []RegistryMirror{dockerHubMirror},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			mirrors := c.k.GetRegistryMirrors()
+			if !reflect.DeepEqual(mirrors, c.expected) {
+				t.Fatalf("expected GetRegistryMirrors() to return %v, instead got %v", c.expected, mirrors)
+			}
+		})
+	}
+}
+
+func TestGetCustomManifests(t *testing.T) {
+	cases := []struct {
+		name          string
+		manifests     []CustomManifest
+		expected      []string
+		expectedError string
+	}{
+		{
+			name: "no manifests",
+		},
+		{
+			name: "order of the cluster definition",
+			manifests: []CustomManifest{
+				{Name: "crds"},
+				{Name: "operator"},
+			},
+			expected: []string{"crds", "operator"},
+		},
+		{
+			name: "dependencies first",
+			manifests: []CustomManifest{
+				{Name: "app", DependsOn: []string{"operator"}},
+				{Name: "operator", DependsOn: []string{"crds"}},
+				{Name: "crds"},
+				{Name: "config"},
+			},
+			expected: []string{"crds", "config", "operator", "app"},
+		},
+		{
+			name: "missing dependency",
+			manifests: []CustomManifest{
+				{Name: "operator", DependsOn: []string{"crds"}},
+			},
+			expectedError: "custom manifest operator depends on crds, which is not a custom manifest",
+		},
+		{
+			name: "circular dependencies",
+			manifests: []CustomManifest{
+				{Name: "crds", DependsOn: []string{"operator"}},
+				{Name: "operator", DependsOn: []string{"crds"}},
+			},
+			expectedError: "the dependencies of the custom manifests are circular",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			k := KubernetesConfig{CustomManifests: c.manifests}
+			manifests, err := k.GetCustomManifests()
+			if c.expectedError != "" {
+				if err == nil || err.Error() != c.expectedError {
+					t.Fatalf("expected GetCustomManifests() to return error %q, instead got %v", c.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var names []string
+			for _, m := range manifests {
+				names = append(names, m.Name)
+			}
+			if !reflect.DeepEqual(names, c.expected) {
+				t.Fatalf("expected GetCustomManifests() to return %v, instead got %v", c.expected, names)
+			}
+		})
+	}
+}
+
 func TestIsIPMasqAgentEnabled(t *testing.T) {
 	cases := []struct {
 		p                                            Properties
@@ -5279,6 +5717,107 @@ func TestFormatProdFQDNByLocation(t *testing.T) {
 	}
 }
 
+func TestFormatPrivateLinkFQDNByLocation(t *testing.T) {
+	cases := []struct {
+		location  string
+		cloudName string
+		expected  string
+	}{
+		{
+			location: "westus2",
+			expected: "santest.privatelink.westus2.cloudapp.azure.com",
+		},
+		{
+			location: "chinaeast2",
+			expected: "santest.privatelink.chinaeast2.cloudapp.chinacloudapi.cn",
+		},
+		{
+			location: "usgovvirginia",
+			expected: "santest.privatelink.usgovvirginia.cloudapp.usgovcloudapi.net",
+		},
+	}
+
+	for _, c := range cases {
+		actual := FormatPrivateLinkFQDNByLocation("santest", c.location, c.cloudName)
+		if actual != c.expected {
+			t.Errorf("expected private link fqdn %s, but got %s", c.expected, actual)
+		}
+	}
+}
+
+func TestPrivateLinkEnabled(t *testing.T) {
+	cases := []struct {
+		name     string
+		k        *KubernetesConfig
+		expected bool
+	}{
+		{
+			name:     "nil config",
+			expected: false,
+		},
+		{
+			name:     "no private cluster",
+			k:        &KubernetesConfig{},
+			expected: false,
+		},
+		{
+			name: "private cluster without private link",
+			k: &KubernetesConfig{
+				PrivateCluster: &PrivateCluster{
+					Enabled: to.BoolPtr(true),
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "private link disabled",
+			k: &KubernetesConfig{
+				PrivateCluster: &PrivateCluster{
+					Enabled: to.BoolPtr(true),
+					PrivateLink: &PrivateLinkProfile{
+						Enabled: to.BoolPtr(false),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "private link on a public cluster",
+			k: &KubernetesConfig{
+				PrivateCluster: &PrivateCluster{
+					Enabled: to.BoolPtr(false),
+					PrivateLink: &PrivateLinkProfile{
+						Enabled: to.BoolPtr(true),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "private link enabled",
+			k: &KubernetesConfig{
+				PrivateCluster: &PrivateCluster{
+					Enabled: to.BoolPtr(true),
+					PrivateLink: &PrivateLinkProfile{
+						Enabled: to.BoolPtr(true),
+					},
+				},
+			},
+			expected: true,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			if actual := c.k.PrivateLinkEnabled(); actual != c.expected {
+				t.Errorf("expected PrivateLinkEnabled() to return %t, but got %t", c.expected, actual)
+			}
+		})
+	}
+}
+
 func TestKubernetesConfig_GetAddonScript(t *testing.T) {
 	addon := getMockAddon(IPMASQAgentAddonName)
 	addon.Data = "foobarbazdata"
@@ -5975,6 +6514,20 @@ func TestSetPlatformFaultDomainCountNoMasters(t *testing.T) {
 	}
 }
 
+func TestSetPlatformFaultDomainCountHostGroup(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 1, 3, false)
+	cs.Properties.AgentPoolProfiles[0].HostGroupID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/hostGroups/HG_NAME"
+	cs.Properties.AgentPoolProfiles[0].PlatformFaultDomainCount = to.IntPtr(1)
+
+	cs.SetPlatformFaultDomainCount(3)
+	if *cs.Properties.AgentPoolProfiles[0].PlatformFaultDomainCount != 1 {
+		t.Errorf("expected the platformFaultDomainCount of the host group pool to stay 1, not %d", *cs.Properties.AgentPoolProfiles[0].PlatformFaultDomainCount)
+	}
+	if *cs.Properties.MasterProfile.PlatformFaultDomainCount != 3 {
+		t.Errorf("expected master platformFaultDomainCount to be 3, not %d", *cs.Properties.MasterProfile.PlatformFaultDomainCount)
+	}
+}
+
 func TestAnyAgentUsesAvailabilitySets(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -70,6 +70,16 @@ const (
 	VirtualMachineScaleSets = "VirtualMachineScaleSets"
 )
 
+// Scale set priorities
+const (
+	// ScaleSetPriorityRegular is the default ScaleSet Priority
+	ScaleSetPriorityRegular = "Regular"
+	// ScaleSetPriorityLow means the ScaleSet will use Low-priority VMs
+	ScaleSetPriorityLow = "Low"
+	// ScaleSetPrioritySpot means the ScaleSet will use Spot VMs
+	ScaleSetPrioritySpot = "Spot"
+)
+
 // storage profiles
 const (
 	// StorageAccount means that the nodes use raw storage accounts for their os and attached volumes
@@ -80,6 +90,35 @@ const (
 	Ephemeral = "Ephemeral"
 )
 
+// OS disk types
+const (
+	// ManagedOSDisk means that the node's os disk is stored as set by the storage profile
+	ManagedOSDisk = "Managed"
+	// EphemeralOSDisk means that the node's os disk is created on the cache of the VM, the storage profile applying
+	// to its attached volumes only
+	EphemeralOSDisk = "Ephemeral"
+)
+
+// data disk storage account types
+const (
+	// StandardHDDDataDisk means that the node's data disks are Standard HDDs
+	StandardHDDDataDisk = "Standard_LRS"
+	// StandardSSDDataDisk means that the node's data disks are Standard SSDs
+	StandardSSDDataDisk = "StandardSSD_LRS"
+	// PremiumSSDDataDisk means that the node's data disks are Premium SSDs, the only ones which may be shared
+	PremiumSSDDataDisk = "Premium_LRS"
+	// UltraSSDDataDisk means that the node's data disks are Ultra SSDs, whose IOPS and throughput may be set
+	UltraSSDDataDisk = "UltraSSD_LRS"
+)
+
+// zone balance strategies
+const (
+	// BestEffortZoneBalance means that the scale set spreads its VMs across its availability zones as evenly as it can
+	BestEffortZoneBalance = "BestEffort"
+	// StrictZoneBalance means that the scale set fails to scale rather than spread its VMs unevenly across its zones
+	StrictZoneBalance = "Strict"
+)
+
 // Supported container runtimes
 const (
 	Docker         = "docker"
@@ -87,13 +126,30 @@ const (
 	Containerd     = "containerd"
 )
 
+// Sizing profiles of the default resources of addons and control plane components
+const (
+	SizingProfileSmall  = "small"
+	SizingProfileMedium = "medium"
+	SizingProfileLarge  = "large"
+)
+
+// Outbound types of the egress traffic of the cluster
+const (
+	OutboundTypeLoadBalancer       = "loadBalancer"
+	OutboundTypeNATGateway         = "natGateway"
+	OutboundTypeUserDefinedRouting = "userDefinedRouting"
+)
+
+// DockerHubRegistry is the registry name used for Docker Hub in registryMirrors
+const DockerHubRegistry = "docker.io"
+
 var (
 	// NetworkPluginValues holds the valid values for network plugin implementation
-	NetworkPluginValues = [...]string{"", "kubenet", "azure", NetworkPluginCilium, "flannel"}
+	NetworkPluginValues = [...]string{"", "kubenet", "azure", NetworkPluginCilium, NetworkPluginAntrea, "flannel"}
 
 	// NetworkPolicyValues holds the valid values for a network policy
 	// "azure" and "none" are there for backwards-compatibility
-	NetworkPolicyValues = [...]string{"", "calico", NetworkPolicyCilium, "azure", "none"}
+	NetworkPolicyValues = [...]string{"", "calico", NetworkPolicyCilium, NetworkPolicyAntrea, "azure", "none"}
 
 	// ContainerRuntimeValues holds the valid values for container runtimes
 	ContainerRuntimeValues = [...]string{"", Docker, KataContainers, Containerd}
@@ -123,6 +179,16 @@ const (
 	NetworkPolicyCilium = "cilium"
 	// NetworkPluginCilium is the string expression for cilium network policy config option
 	NetworkPluginCilium = NetworkPolicyCilium
+	// NetworkPolicyAntrea is the string expression for antrea network policy config option
+	NetworkPolicyAntrea = "antrea"
+	// NetworkPluginAntrea is the string expression for antrea network plugin config option
+	NetworkPluginAntrea = NetworkPolicyAntrea
+)
+
+// the subnet of the masters and agents of a Kubernetes VNET created by aks-engine, when the apimodel does not set it
+const (
+	defaultKubernetesMasterSubnet   = "10.240.0.0/16"
+	defaultKubernetesSubnetAzureCNI = "10.240.0.0/12"
 )
 
 const (
@@ -0,0 +1,145 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package vlabs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/helpers"
+)
+
+// Deprecation is a deprecated use of the apimodel that MigrateAPIModel rewrote to its supported equivalent
+type Deprecation struct {
+	// Path is the JSON path of the deprecated field, e.g. properties.agentPoolProfiles[0].distro
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+	// Replacement is what the field was rewritten to, the field was removed if it is nil
+	Replacement interface{} `json:"replacement,omitempty"`
+	Message     string      `json:"message"`
+}
+
+func (d Deprecation) String() string {
+	if d.Replacement == nil {
+		return fmt.Sprintf("%s: %s, it was removed from the apimodel", d.Path, d.Message)
+	}
+	return fmt.Sprintf("%s: %s, it was rewritten from %v to %v", d.Path, d.Message, d.Value, d.Replacement)
+}
+
+// deprecatedDistros are the deprecated distros and the distros they are equivalent to
+var deprecatedDistros = map[string]Distro{
+	string(AKS1604Deprecated): AKSUbuntu1604,
+	string(AKS1804Deprecated): AKSUbuntu1804,
+	string(AKSDockerEngine):   AKSUbuntu1604,
+}
+
+// MigrateAPIModel rewrites the deprecated fields of a vlabs apimodel to their supported equivalents and removes those that no longer
+// have any effect, returning the migrated apimodel and the deprecations found. The apimodel is returned as is if nothing is deprecated.
+// Fields are matched case-insensitively, as they are when the apimodel is unmarshalled.
+func MigrateAPIModel(contents []byte) ([]byte, []Deprecation, error) {
+	var m map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	// numbers are kept as they are written, e.g. a large port number is not turned into a float
+	decoder.UseNumber()
+	if err := decoder.Decode(&m); err != nil {
+		return nil, nil, err
+	}
+	mig := &migration{}
+	properties := object(m, "properties")
+	if properties == nil {
+		return contents, nil, nil
+	}
+	if master := object(properties, "masterProfile"); master != nil {
+		mig.distro(master, "properties.masterProfile")
+	}
+	for i, pool := range objects(properties, "agentPoolProfiles") {
+		mig.distro(pool, fmt.Sprintf("properties.agentPoolProfiles[%d]", i))
+	}
+	if kubernetesConfig := object(object(properties, "orchestratorProfile"), "kubernetesConfig"); kubernetesConfig != nil {
+		mig.kubernetesConfig(kubernetesConfig, "properties.orchestratorProfile.kubernetesConfig")
+	}
+	if len(mig.deprecations) == 0 {
+		return contents, nil, nil
+	}
+	migrated, err := helpers.JSONMarshalIndent(m, "", "  ", false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return migrated, mig.deprecations, nil
+}
+
+type migration struct {
+	deprecations []Deprecation
+}
+
+func (mig *migration) replace(o map[string]interface{}, key, path string, replacement interface{}, message string) {
+	mig.deprecations = append(mig.deprecations, Deprecation{Path: path, Value: o[key], Replacement: replacement, Message: message})
+	o[key] = replacement
+}
+
+func (mig *migration) remove(o map[string]interface{}, key, path, message string) {
+	mig.deprecations = append(mig.deprecations, Deprecation{Path: path, Value: o[key], Message: message})
+	delete(o, key)
+}
+
+func (mig *migration) distro(profile map[string]interface{}, path string) {
+	key := fieldKey(profile, "distro")
+	distro, _ := profile[key].(string)
+	if replacement, ok := deprecatedDistros[distro]; ok {
+		mig.replace(profile, key, path+".distro", string(replacement), fmt.Sprintf("the %s distro is deprecated, %s is its equivalent", distro, replacement))
+	}
+}
+
+func (mig *migration) kubernetesConfig(k map[string]interface{}, path string) {
+	if key := fieldKey(k, "dockerEngineVersion"); k[key] != nil && k[key] != "" {
+		mig.remove(k, key, path+".dockerEngineVersion", "docker-engine is deprecated in favor of moby, dockerEngineVersion is ignored")
+	}
+	if key := fieldKey(k, "podSecurityPolicyConfig"); len(object(k, key)) > 0 {
+		mig.remove(k, key, path+".podSecurityPolicyConfig", "podSecurityPolicyConfig is deprecated in favor of the pod-security-policy addon and is ignored")
+	}
+
+	// networkPolicy "azure" without a networkPlugin, and networkPolicy "none", predate networkPlugin
+	policyKey := fieldKey(k, "networkPolicy")
+	pluginKey := fieldKey(k, "networkPlugin")
+	policy, _ := k[policyKey].(string)
+	plugin, _ := k[pluginKey].(string)
+	switch {
+	case policy == "azure" && plugin == "":
+		mig.remove(k, policyKey, path+".networkPolicy", `networkPolicy "azure" without a networkPlugin is deprecated, it is networkPlugin "azure"`)
+		mig.replace(k, pluginKey, path+".networkPlugin", "azure", `networkPolicy "azure" without a networkPlugin is deprecated, it is networkPlugin "azure"`)
+	case policy == "none":
+		mig.remove(k, policyKey, path+".networkPolicy", `networkPolicy "none" is deprecated, it is networkPlugin "kubenet"`)
+		mig.replace(k, pluginKey, path+".networkPlugin", "kubenet", `networkPolicy "none" is deprecated, it is networkPlugin "kubenet"`)
+	}
+}
+
+// fieldKey returns the key of o that matches name case-insensitively, or name if there is none
+func fieldKey(o map[string]interface{}, name string) string {
+	for k := range o {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+	}
+	return name
+}
+
+func object(o map[string]interface{}, name string) map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+	child, _ := o[fieldKey(o, name)].(map[string]interface{})
+	return child
+}
+
+func objects(o map[string]interface{}, name string) []map[string]interface{} {
+	children, _ := o[fieldKey(o, name)].([]interface{})
+	// the objects keep the index of their element, elements that are not objects are nil
+	objects := make([]map[string]interface{}, len(children))
+	for i, c := range children {
+		objects[i], _ = c.(map[string]interface{})
+	}
+	return objects
+}
@@ -0,0 +1,72 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package vlabs
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMigrateAPIModel(t *testing.T) {
+	apimodel := `{
+  "apiVersion": "vlabs",
+  "properties": {
+    "orchestratorProfile": {
+      "orchestratorType": "Kubernetes",
+      "kubernetesConfig": {
+        "networkPolicy": "none",
+        "networkPlugin": "azure",
+        "dockerEngineVersion": "17.05.*",
+        "podSecurityPolicyConfig": {"data": "manifest"}
+      }
+    },
+    "masterProfile": {"count": 1, "dnsPrefix": "prefix", "vmSize": "Standard_D2_v2", "distro": "aks-1804"},
+    "agentPoolProfiles": [
+      {"name": "pool1", "count": 3, "vmSize": "Standard_D2_v2", "distro": "aks-ubuntu-16.04"},
+      {"name": "pool2", "count": 3, "vmSize": "Standard_D2_v2", "Distro": "aks-docker-engine"}
+    ]
+  }
+}`
+	migrated, deprecations, err := MigrateAPIModel([]byte(apimodel))
+	if err != nil {
+		t.Fatalf("unexpected error migrating the api model: %s", err)
+	}
+	expectedPaths := map[string]bool{
+		"properties.masterProfile.distro":                                         true,
+		"properties.agentPoolProfiles[1].distro":                                  true,
+		"properties.orchestratorProfile.kubernetesConfig.dockerEngineVersion":     true,
+		"properties.orchestratorProfile.kubernetesConfig.podSecurityPolicyConfig": true,
+		"properties.orchestratorProfile.kubernetesConfig.networkPolicy":           true,
+		"properties.orchestratorProfile.kubernetesConfig.networkPlugin":           true,
+	}
+	if len(deprecations) != len(expectedPaths) {
+		t.Fatalf("expected %d deprecations, got %v", len(expectedPaths), deprecations)
+	}
+	for _, d := range deprecations {
+		if !expectedPaths[d.Path] {
+			t.Errorf("unexpected deprecation %s", d)
+		}
+	}
+
+	cs := &ContainerService{}
+	if err = json.Unmarshal(migrated, cs); err != nil {
+		t.Fatalf("unexpected error unmarshalling the migrated api model: %s", err)
+	}
+	if cs.Properties.MasterProfile.Distro != AKSUbuntu1804 || cs.Properties.AgentPoolProfiles[0].Distro != AKSUbuntu1604 || cs.Properties.AgentPoolProfiles[1].Distro != AKSUbuntu1604 {
+		t.Errorf("expected the deprecated distros to be migrated, got %s, %s and %s", cs.Properties.MasterProfile.Distro, cs.Properties.AgentPoolProfiles[0].Distro, cs.Properties.AgentPoolProfiles[1].Distro)
+	}
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+	if k.NetworkPlugin != "kubenet" || k.NetworkPolicy != "" || k.DockerEngineVersion != "" || len(k.PodSecurityPolicyConfig) != 0 {
+		t.Errorf("expected the deprecated kubernetesConfig fields to be migrated, got %+v", k)
+	}
+	if cs.Properties.MasterProfile.Count != 1 {
+		t.Errorf("expected the other fields to be kept, got a master count of %d", cs.Properties.MasterProfile.Count)
+	}
+
+	supported := `{"apiVersion": "vlabs", "properties": {"orchestratorProfile": {"kubernetesConfig": {"networkPlugin": "azure", "networkPolicy": "azure"}}}}`
+	migrated, deprecations, err = MigrateAPIModel([]byte(supported))
+	if err != nil || len(deprecations) != 0 || string(migrated) != supported {
+		t.Errorf("expected an api model without deprecated fields to be returned as is, got %s, %v, %v", migrated, deprecations, err)
+	}
+}
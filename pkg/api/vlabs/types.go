@@ -74,9 +74,11 @@ type ServicePrincipalProfile struct {
 // The format of 'VaultID' value should be
 // "/subscriptions/<SUB_ID>/resourceGroups/<RG_NAME>/providers/Microsoft.KeyVault/vaults/<KV_NAME>"
 // where:
-//    <SUB_ID> is the subscription ID of the keyvault
-//    <RG_NAME> is the resource group of the keyvault
-//    <KV_NAME> is the name of the keyvault
+//
+//	<SUB_ID> is the subscription ID of the keyvault
+//	<RG_NAME> is the resource group of the keyvault
+//	<KV_NAME> is the name of the keyvault
+//
 // The 'SecretName' is the name of the secret in the keyvault
 // The 'SecretVersion' (optional) is the version of the secret (default: the latest version)
 type KeyvaultSecretRef struct {
@@ -90,11 +92,12 @@ type KeyvaultSecretRef struct {
 // In the latter case, the format of the parameter's value should be
 // "/subscriptions/<SUB_ID>/resourceGroups/<RG_NAME>/providers/Microsoft.KeyVault/vaults/<KV_NAME>/secrets/<NAME>[/<VERSION>]"
 // where:
-//    <SUB_ID> is the subscription ID of the keyvault
-//    <RG_NAME> is the resource group of the keyvault
-//    <KV_NAME> is the name of the keyvault
-//    <NAME> is the name of the secret
-//    <VERSION> (optional) is the version of the secret (default: the latest version)
+//
+//	<SUB_ID> is the subscription ID of the keyvault
+//	<RG_NAME> is the resource group of the keyvault
+//	<KV_NAME> is the name of the keyvault
+//	<NAME> is the name of the secret
+//	<VERSION> (optional) is the version of the secret (default: the latest version)
 type CertificateProfile struct {
 	// CaCertificate is the certificate authority certificate.
 	CaCertificate string `json:"caCertificate,omitempty"`
@@ -155,6 +158,20 @@ type CustomNodesDNS struct {
 	DNSServer string `json:"dnsServer,omitempty"`
 }
 
+// NodeDNSConfig represents the DNS servers and search domains of the nodes in a pool,
+// overriding the VNET DNS servers and linuxProfile customNodesDNS
+type NodeDNSConfig struct {
+	DNSServers    []string `json:"dnsServers,omitempty"`
+	SearchDomains []string `json:"searchDomains,omitempty"`
+}
+
+// ScheduledEventsProfile configures the handler that cordons and drains the nodes of a pool when Azure schedules
+// the preemption or the deletion of their VMs
+type ScheduledEventsProfile struct {
+	Enabled                             *bool `json:"enabled,omitempty"`
+	TerminateNotificationTimeoutMinutes int   `json:"terminateNotificationTimeoutMinutes,omitempty"`
+}
+
 // WindowsProfile represents the windows parameters passed to the cluster
 type WindowsProfile struct {
 	AdminUsername          string            `json:"adminUsername,omitempty"`
@@ -166,6 +183,7 @@ type WindowsProfile struct {
 	WindowsOffer           string            `json:"WindowsOffer"`
 	WindowsSku             string            `json:"WindowsSku"`
 	WindowsDockerVersion   string            `json:"windowsDockerVersion"`
+	WindowsContainerdURL   string            `json:"windowsContainerdURL,omitempty"`
 	Secrets                []KeyVaultSecrets `json:"secrets,omitempty"`
 	SSHEnabled             bool              `json:"sshEnabled,omitempty"`
 	EnableAutomaticUpdates *bool             `json:"enableAutomaticUpdates,omitempty"`
@@ -228,8 +246,10 @@ func (o *OrchestratorProfile) UnmarshalJSON(b []byte) error {
 
 // KubernetesContainerSpec defines configuration for a container spec
 type KubernetesContainerSpec struct {
-	Name           string `json:"name,omitempty"`
-	Image          string `json:"image,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Image string `json:"image,omitempty"`
+	// ImageDigest pins the image to a sha256 digest, the image is pulled by digest instead of by tag
+	ImageDigest    string `json:"imageDigest,omitempty"`
 	CPURequests    string `json:"cpuRequests,omitempty"`
 	MemoryRequests string `json:"memoryRequests,omitempty"`
 	CPULimits      string `json:"cpuLimits,omitempty"`
@@ -243,12 +263,137 @@ type KubernetesAddon struct {
 	Containers []KubernetesContainerSpec `json:"containers,omitempty"`
 	Config     map[string]string         `json:"config,omitempty"`
 	Data       string                    `json:"data,omitempty"`
+	Pools      []AddonNodePoolsConfig    `json:"pools,omitempty"`
+}
+
+// AddonNodePoolsConfig is the configuration of an addon for an agent pool, like the node bounds of the pools the
+// cluster-autoscaler addon scales
+type AddonNodePoolsConfig struct {
+	Name   string            `json:"name,omitempty"`
+	Config map[string]string `json:"config,omitempty"`
 }
 
 // PrivateCluster defines the configuration for a private cluster
 type PrivateCluster struct {
 	Enabled        *bool                  `json:"enabled,omitempty"`
 	JumpboxProfile *PrivateJumpboxProfile `json:"jumpboxProfile,omitempty"`
+	PrivateLink    *PrivateLinkProfile    `json:"privateLink,omitempty"`
+}
+
+// PrivateLinkProfile fronts the internal load balancer of the API server of a private cluster with a Private Link service,
+// which clients reach through a private endpoint registered in a private DNS zone
+type PrivateLinkProfile struct {
+	Enabled                 *bool  `json:"enabled,omitempty"`
+	PrivateEndpointSubnetID string `json:"privateEndpointSubnetID,omitempty"`
+}
+
+// RegistryMirror configures the container runtime on every node to pull images
+// for Registry from the Endpoints, in order, before falling back to Registry itself
+type RegistryMirror struct {
+	Registry  string   `json:"registry"`
+	Endpoints []string `json:"endpoints"`
+}
+
+// OIDCIssuerProfile configures the API server to issue service account tokens that Azure AD can verify
+// with an OpenID Connect discovery document hosted in an Azure blob container, for workload identity federation
+type OIDCIssuerProfile struct {
+	Enabled *bool `json:"enabled,omitempty"`
+	// StorageAccountName and ContainerName are the blob container the discovery document is uploaded to,
+	// it must allow anonymous read access to its blobs
+	StorageAccountName string `json:"storageAccountName,omitempty"`
+	ContainerName      string `json:"containerName,omitempty"`
+	// IssuerURL is where the discovery document is served from, the blob container endpoint by default
+	IssuerURL string `json:"issuerURL,omitempty"`
+}
+
+// KonnectivityProfile configures the API server to reach the nodes through the konnectivity tunnels the konnectivity-agent
+// of every node opens to the konnectivity-server of the masters, for clusters whose masters cannot reach the node IPs
+type KonnectivityProfile struct {
+	Enabled *bool `json:"enabled,omitempty"`
+	// ServerImage is the image of the konnectivity-server static pod of the masters
+	ServerImage string `json:"serverImage,omitempty"`
+}
+
+// AirGapProfile configures a cluster whose nodes cannot reach the internet to install exclusively from user-provided mirrors
+type AirGapProfile struct {
+	Enabled *bool `json:"enabled,omitempty"`
+	// BinariesURL is the base URL the binaries are downloaded from, e.g. a storage account blob container, which serves
+	// https://<host>/<path> as <binariesURL>/<host>/<path>
+	BinariesURL string `json:"binariesURL,omitempty"`
+	// PackagesURL is the base URL of the apt mirror, which serves the Ubuntu archive under ubuntu
+	// and the Microsoft packages repository under microsoft
+	PackagesURL string `json:"packagesURL,omitempty"`
+}
+
+// DrainProfile configures how upgrades drain the nodes before replacing them
+type DrainProfile struct {
+	// TimeoutInMinutes is how long to wait for the pods of a node to be evicted, 20 by default
+	TimeoutInMinutes int `json:"timeoutInMinutes,omitempty"`
+	// GracePeriodSeconds overrides the termination grace period of the evicted pods, which keep their own if it is not set
+	GracePeriodSeconds *int `json:"gracePeriodSeconds,omitempty"`
+	// IgnoreDaemonSets leaves the DaemonSet pods on the node, the drain fails on them otherwise. True by default.
+	IgnoreDaemonSets *bool `json:"ignoreDaemonSets,omitempty"`
+	// DeleteEmptyDirData evicts the pods with emptyDir volumes, whose data is lost, the drain fails on them otherwise.
+	// True by default.
+	DeleteEmptyDirData *bool `json:"deleteEmptyDirData,omitempty"`
+	// ForceAfterTimeout deletes the pods still not evicted after the timeout, e.g. because of a PodDisruptionBudget,
+	// rather than failing the drain
+	ForceAfterTimeout *bool `json:"forceAfterTimeout,omitempty"`
+}
+
+// AzureCloudConfig sets the azure.json settings of the Azure cloud provider that have no kubernetesConfig
+// property of their own, e.g. the loadBalancerSku and the cloudProviderRateLimit settings do
+type AzureCloudConfig struct {
+	// the TTLs in seconds of the caches of the Azure resources the cloud provider reads, the defaults of the
+	// cloud provider when they are not set
+	VMSSCacheTTLInSeconds                 int `json:"vmssCacheTTLInSeconds,omitempty"`
+	VMSSVirtualMachinesCacheTTLInSeconds  int `json:"vmssVirtualMachinesCacheTTLInSeconds,omitempty"`
+	VMCacheTTLInSeconds                   int `json:"vmCacheTTLInSeconds,omitempty"`
+	AvailabilitySetNodesCacheTTLInSeconds int `json:"availabilitySetNodesCacheTTLInSeconds,omitempty"`
+	LoadBalancerCacheTTLInSeconds         int `json:"loadBalancerCacheTTLInSeconds,omitempty"`
+	NSGCacheTTLInSeconds                  int `json:"nsgCacheTTLInSeconds,omitempty"`
+	RouteTableCacheTTLInSeconds           int `json:"routeTableCacheTTLInSeconds,omitempty"`
+	// RateLimits overrides the cloudProviderRateLimit settings of kubernetesConfig for the client of an Azure API,
+	// keyed by the name of the client, e.g. virtualMachineScaleSet
+	RateLimits map[string]CloudProviderRateLimit `json:"rateLimits,omitempty"`
+	// Tags are added to the Azure resources the cloud provider creates, e.g. the public IPs of the services
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// CloudProviderRateLimit is the rate limit of the client of an Azure API, the settings it does not set are the ones
+// of kubernetesConfig
+type CloudProviderRateLimit struct {
+	CloudProviderRateLimit            *bool   `json:"cloudProviderRateLimit,omitempty"`
+	CloudProviderRateLimitQPS         float64 `json:"cloudProviderRateLimitQPS,omitempty"`
+	CloudProviderRateLimitQPSWrite    float64 `json:"cloudProviderRateLimitQPSWrite,omitempty"`
+	CloudProviderRateLimitBucket      int     `json:"cloudProviderRateLimitBucket,omitempty"`
+	CloudProviderRateLimitBucketWrite int     `json:"cloudProviderRateLimitBucketWrite,omitempty"`
+}
+
+// ManifestPatch customizes the static pod manifest of a control plane component on the masters,
+// so that the customization is regenerated by upgrades instead of overwritten
+type ManifestPatch struct {
+	// Component is the static pod patched, e.g. kube-apiserver
+	Component string `json:"component"`
+	// ExtraArgs are added to the flags of the component, they override the flags of its config
+	ExtraArgs map[string]string `json:"extraArgs,omitempty"`
+	// Patch is merged into the pod, the items of the lists of objects with a name are merged by name
+	Patch map[string]interface{} `json:"patch,omitempty"`
+}
+
+// CustomManifest is a user-supplied manifest applied by the addon manager of the masters with the addons,
+// from the cluster bring-up on. The contents of URL or Path are embedded in Data when the template is generated.
+type CustomManifest struct {
+	// Name identifies the manifest in the DependsOn of the other manifests
+	Name string `json:"name"`
+	// Data is the base64 encoded manifest
+	Data string `json:"data,omitempty"`
+	URL  string `json:"url,omitempty"`
+	Path string `json:"path,omitempty"`
+	// Mode is the addon manager mode of the objects that do not have one, Reconcile or EnsureExists
+	Mode string `json:"mode,omitempty"`
+	// DependsOn are the names of the custom manifests applied before this one
+	DependsOn []string `json:"dependsOn,omitempty"`
 }
 
 // PrivateJumpboxProfile represents a jumpbox definition
@@ -273,66 +418,78 @@ const (
 // KubernetesConfig contains the Kubernetes config structure, containing
 // Kubernetes specific configuration
 type KubernetesConfig struct {
-	KubernetesImageBase               string            `json:"kubernetesImageBase,omitempty"`
-	ClusterSubnet                     string            `json:"clusterSubnet,omitempty"`
-	DNSServiceIP                      string            `json:"dnsServiceIP,omitempty"`
-	ServiceCidr                       string            `json:"serviceCidr,omitempty"`
-	NetworkPolicy                     string            `json:"networkPolicy,omitempty"`
-	NetworkPlugin                     string            `json:"networkPlugin,omitempty"`
-	ContainerRuntime                  string            `json:"containerRuntime,omitempty"`
-	MaxPods                           int               `json:"maxPods,omitempty"`
-	DockerBridgeSubnet                string            `json:"dockerBridgeSubnet,omitempty"`
-	UseManagedIdentity                bool              `json:"useManagedIdentity,omitempty"`
-	UserAssignedID                    string            `json:"userAssignedID,omitempty"`
-	UserAssignedClientID              string            `json:"userAssignedClientID,omitempty"` //Note: cannot be provided in config. Used *only* for transferring this to azure.json.
-	CustomHyperkubeImage              string            `json:"customHyperkubeImage,omitempty"`
-	DockerEngineVersion               string            `json:"dockerEngineVersion,omitempty"` // Deprecated
-	MobyVersion                       string            `json:"mobyVersion,omitempty"`
-	ContainerdVersion                 string            `json:"containerdVersion,omitempty"`
-	CustomCcmImage                    string            `json:"customCcmImage,omitempty"`
-	UseCloudControllerManager         *bool             `json:"useCloudControllerManager,omitempty"`
-	CustomWindowsPackageURL           string            `json:"customWindowsPackageURL,omitempty"`
-	WindowsNodeBinariesURL            string            `json:"windowsNodeBinariesURL,omitempty"`
-	UseInstanceMetadata               *bool             `json:"useInstanceMetadata,omitempty"`
-	EnableRbac                        *bool             `json:"enableRbac,omitempty"`
-	EnableSecureKubelet               *bool             `json:"enableSecureKubelet,omitempty"`
-	EnableAggregatedAPIs              bool              `json:"enableAggregatedAPIs,omitempty"`
-	PrivateCluster                    *PrivateCluster   `json:"privateCluster,omitempty"`
-	GCHighThreshold                   int               `json:"gchighthreshold,omitempty"`
-	GCLowThreshold                    int               `json:"gclowthreshold,omitempty"`
-	EtcdVersion                       string            `json:"etcdVersion,omitempty"`
-	EtcdDiskSizeGB                    string            `json:"etcdDiskSizeGB,omitempty"`
-	EtcdEncryptionKey                 string            `json:"etcdEncryptionKey,omitempty"`
-	EnableDataEncryptionAtRest        *bool             `json:"enableDataEncryptionAtRest,omitempty"`
-	EnableEncryptionWithExternalKms   *bool             `json:"enableEncryptionWithExternalKms,omitempty"`
-	EnablePodSecurityPolicy           *bool             `json:"enablePodSecurityPolicy,omitempty"`
-	Addons                            []KubernetesAddon `json:"addons,omitempty"`
-	KubeletConfig                     map[string]string `json:"kubeletConfig,omitempty"`
-	ControllerManagerConfig           map[string]string `json:"controllerManagerConfig,omitempty"`
-	CloudControllerManagerConfig      map[string]string `json:"cloudControllerManagerConfig,omitempty"`
-	APIServerConfig                   map[string]string `json:"apiServerConfig,omitempty"`
-	SchedulerConfig                   map[string]string `json:"schedulerConfig,omitempty"`
-	PodSecurityPolicyConfig           map[string]string `json:"podSecurityPolicyConfig,omitempty"` // Deprecated
-	CloudProviderBackoff              *bool             `json:"cloudProviderBackoff,omitempty"`
-	CloudProviderBackoffRetries       int               `json:"cloudProviderBackoffRetries,omitempty"`
-	CloudProviderBackoffJitter        float64           `json:"cloudProviderBackoffJitter,omitempty"`
-	CloudProviderBackoffDuration      int               `json:"cloudProviderBackoffDuration,omitempty"`
-	CloudProviderBackoffExponent      float64           `json:"cloudProviderBackoffExponent,omitempty"`
-	CloudProviderRateLimit            *bool             `json:"cloudProviderRateLimit,omitempty"`
-	CloudProviderRateLimitQPS         float64           `json:"cloudProviderRateLimitQPS,omitempty"`
-	CloudProviderRateLimitQPSWrite    float64           `json:"cloudProviderRateLimitQPSWrite,omitempty"`
-	CloudProviderRateLimitBucket      int               `json:"cloudProviderRateLimitBucket,omitempty"`
-	CloudProviderRateLimitBucketWrite int               `json:"cloudProviderRateLimitBucketWrite,omitempty"`
-	LoadBalancerSku                   string            `json:"loadBalancerSku,omitempty"`
-	ExcludeMasterFromStandardLB       *bool             `json:"excludeMasterFromStandardLB,omitempty"`
-	AzureCNIVersion                   string            `json:"azureCNIVersion,omitempty"`
-	AzureCNIURLLinux                  string            `json:"azureCNIURLLinux,omitempty"`
-	AzureCNIURLWindows                string            `json:"azureCNIURLWindows,omitempty"`
-	KeyVaultSku                       string            `json:"keyVaultSku,omitempty"`
-	MaximumLoadBalancerRuleCount      int               `json:"maximumLoadBalancerRuleCount,omitempty"`
-	ProxyMode                         KubeProxyMode     `json:"kubeProxyMode,omitempty"`
-	PrivateAzureRegistryServer        string            `json:"privateAzureRegistryServer,omitempty"`
-	OutboundRuleIdleTimeoutInMinutes  int32             `json:"outboundRuleIdleTimeoutInMinutes,omitempty"`
+	KubernetesImageBase               string               `json:"kubernetesImageBase,omitempty"`
+	ClusterSubnet                     string               `json:"clusterSubnet,omitempty"`
+	DNSServiceIP                      string               `json:"dnsServiceIP,omitempty"`
+	ServiceCidr                       string               `json:"serviceCidr,omitempty"`
+	NetworkPolicy                     string               `json:"networkPolicy,omitempty"`
+	NetworkPlugin                     string               `json:"networkPlugin,omitempty"`
+	ContainerRuntime                  string               `json:"containerRuntime,omitempty"`
+	MaxPods                           int                  `json:"maxPods,omitempty"`
+	DockerBridgeSubnet                string               `json:"dockerBridgeSubnet,omitempty"`
+	UseManagedIdentity                bool                 `json:"useManagedIdentity,omitempty"`
+	UserAssignedID                    string               `json:"userAssignedID,omitempty"`
+	UserAssignedClientID              string               `json:"userAssignedClientID,omitempty"` //Note: cannot be provided in config. Used *only* for transferring this to azure.json.
+	CustomHyperkubeImage              string               `json:"customHyperkubeImage,omitempty"`
+	DockerEngineVersion               string               `json:"dockerEngineVersion,omitempty"` // Deprecated
+	MobyVersion                       string               `json:"mobyVersion,omitempty"`
+	ContainerdVersion                 string               `json:"containerdVersion,omitempty"`
+	CustomCcmImage                    string               `json:"customCcmImage,omitempty"`
+	UseCloudControllerManager         *bool                `json:"useCloudControllerManager,omitempty"`
+	CustomWindowsPackageURL           string               `json:"customWindowsPackageURL,omitempty"`
+	WindowsNodeBinariesURL            string               `json:"windowsNodeBinariesURL,omitempty"`
+	UseInstanceMetadata               *bool                `json:"useInstanceMetadata,omitempty"`
+	EnableRbac                        *bool                `json:"enableRbac,omitempty"`
+	EnableSecureKubelet               *bool                `json:"enableSecureKubelet,omitempty"`
+	EnableAggregatedAPIs              bool                 `json:"enableAggregatedAPIs,omitempty"`
+	PrivateCluster                    *PrivateCluster      `json:"privateCluster,omitempty"`
+	GCHighThreshold                   int                  `json:"gchighthreshold,omitempty"`
+	GCLowThreshold                    int                  `json:"gclowthreshold,omitempty"`
+	EtcdVersion                       string               `json:"etcdVersion,omitempty"`
+	EtcdDiskSizeGB                    string               `json:"etcdDiskSizeGB,omitempty"`
+	EtcdEncryptionKey                 string               `json:"etcdEncryptionKey,omitempty"`
+	EnableDataEncryptionAtRest        *bool                `json:"enableDataEncryptionAtRest,omitempty"`
+	EnableEncryptionWithExternalKms   *bool                `json:"enableEncryptionWithExternalKms,omitempty"`
+	EnablePodSecurityPolicy           *bool                `json:"enablePodSecurityPolicy,omitempty"`
+	Addons                            []KubernetesAddon    `json:"addons,omitempty"`
+	KubeletConfig                     map[string]string    `json:"kubeletConfig,omitempty"`
+	ControllerManagerConfig           map[string]string    `json:"controllerManagerConfig,omitempty"`
+	CloudControllerManagerConfig      map[string]string    `json:"cloudControllerManagerConfig,omitempty"`
+	APIServerConfig                   map[string]string    `json:"apiServerConfig,omitempty"`
+	SchedulerConfig                   map[string]string    `json:"schedulerConfig,omitempty"`
+	PodSecurityPolicyConfig           map[string]string    `json:"podSecurityPolicyConfig,omitempty"` // Deprecated
+	CloudProviderBackoff              *bool                `json:"cloudProviderBackoff,omitempty"`
+	CloudProviderBackoffRetries       int                  `json:"cloudProviderBackoffRetries,omitempty"`
+	CloudProviderBackoffJitter        float64              `json:"cloudProviderBackoffJitter,omitempty"`
+	CloudProviderBackoffDuration      int                  `json:"cloudProviderBackoffDuration,omitempty"`
+	CloudProviderBackoffExponent      float64              `json:"cloudProviderBackoffExponent,omitempty"`
+	CloudProviderRateLimit            *bool                `json:"cloudProviderRateLimit,omitempty"`
+	CloudProviderRateLimitQPS         float64              `json:"cloudProviderRateLimitQPS,omitempty"`
+	CloudProviderRateLimitQPSWrite    float64              `json:"cloudProviderRateLimitQPSWrite,omitempty"`
+	CloudProviderRateLimitBucket      int                  `json:"cloudProviderRateLimitBucket,omitempty"`
+	CloudProviderRateLimitBucketWrite int                  `json:"cloudProviderRateLimitBucketWrite,omitempty"`
+	LoadBalancerSku                   string               `json:"loadBalancerSku,omitempty"`
+	ExcludeMasterFromStandardLB       *bool                `json:"excludeMasterFromStandardLB,omitempty"`
+	AzureCNIVersion                   string               `json:"azureCNIVersion,omitempty"`
+	AzureCNIURLLinux                  string               `json:"azureCNIURLLinux,omitempty"`
+	AzureCNIURLWindows                string               `json:"azureCNIURLWindows,omitempty"`
+	KeyVaultSku                       string               `json:"keyVaultSku,omitempty"`
+	MaximumLoadBalancerRuleCount      int                  `json:"maximumLoadBalancerRuleCount,omitempty"`
+	ProxyMode                         KubeProxyMode        `json:"kubeProxyMode,omitempty"`
+	PrivateAzureRegistryServer        string               `json:"privateAzureRegistryServer,omitempty"`
+	OutboundRuleIdleTimeoutInMinutes  int32                `json:"outboundRuleIdleTimeoutInMinutes,omitempty"`
+	OutboundRuleAllocatedPorts        int32                `json:"outboundRuleAllocatedPorts,omitempty"`
+	OutboundType                      string               `json:"outboundType,omitempty"`
+	RegistryMirrors                   []RegistryMirror     `json:"registryMirrors,omitempty"`
+	SizingProfile                     string               `json:"sizingProfile,omitempty"`
+	OIDCIssuerProfile                 *OIDCIssuerProfile   `json:"oidcIssuerProfile,omitempty"`
+	ManifestPatches                   []ManifestPatch      `json:"manifestPatches,omitempty"`
+	KonnectivityProfile               *KonnectivityProfile `json:"konnectivityProfile,omitempty"`
+	DrainProfile                      *DrainProfile        `json:"drainProfile,omitempty"`
+	CloudProviderConfig               *AzureCloudConfig    `json:"cloudProviderConfig,omitempty"`
+	CustomManifests                   []CustomManifest     `json:"customManifests,omitempty"`
+	ImageRegistry                     string               `json:"imageRegistry,omitempty"`
+	AirGapProfile                     *AirGapProfile       `json:"airGapProfile,omitempty"`
 }
 
 // CustomFile has source as the full absolute source path to a file and dest
@@ -366,31 +523,42 @@ type DcosConfig struct {
 
 // MasterProfile represents the definition of the master cluster
 type MasterProfile struct {
-	Count                    int               `json:"count" validate:"required,eq=1|eq=3|eq=5"`
-	DNSPrefix                string            `json:"dnsPrefix" validate:"required"`
-	SubjectAltNames          []string          `json:"subjectAltNames"`
-	VMSize                   string            `json:"vmSize" validate:"required"`
-	OSDiskSizeGB             int               `json:"osDiskSizeGB,omitempty" validate:"min=0,max=1023"`
-	VnetSubnetID             string            `json:"vnetSubnetID,omitempty"`
-	VnetCidr                 string            `json:"vnetCidr,omitempty"`
-	AgentVnetSubnetID        string            `json:"agentVnetSubnetID,omitempty"`
-	FirstConsecutiveStaticIP string            `json:"firstConsecutiveStaticIP,omitempty"`
-	IPAddressCount           int               `json:"ipAddressCount,omitempty" validate:"min=0,max=256"`
-	StorageProfile           string            `json:"storageProfile,omitempty" validate:"eq=StorageAccount|eq=ManagedDisks|len=0"`
-	HTTPSourceAddressPrefix  string            `json:"HTTPSourceAddressPrefix,omitempty"`
-	OAuthEnabled             bool              `json:"oauthEnabled"`
-	PreProvisionExtension    *Extension        `json:"preProvisionExtension"`
-	Extensions               []Extension       `json:"extensions"`
-	Distro                   Distro            `json:"distro,omitempty"`
-	KubernetesConfig         *KubernetesConfig `json:"kubernetesConfig,omitempty"`
-	ImageRef                 *ImageReference   `json:"imageReference,omitempty"`
-	CustomFiles              *[]CustomFile     `json:"customFiles,omitempty"`
-	AvailabilityProfile      string            `json:"availabilityProfile"`
-	AgentSubnet              string            `json:"agentSubnet,omitempty"`
-	AvailabilityZones        []string          `json:"availabilityZones,omitempty"`
-	SinglePlacementGroup     *bool             `json:"singlePlacementGroup,omitempty"`
-	AuditDEnabled            *bool             `json:"auditDEnabled,omitempty"`
-	CustomVMTags             map[string]string `json:"customVMTags,omitempty"`
+	Count                         int               `json:"count" validate:"required,eq=1|eq=3|eq=5"`
+	DNSPrefix                     string            `json:"dnsPrefix" validate:"required"`
+	SubjectAltNames               []string          `json:"subjectAltNames"`
+	VMSize                        string            `json:"vmSize" validate:"required"`
+	OSDiskSizeGB                  int               `json:"osDiskSizeGB,omitempty" validate:"min=0,max=1023"`
+	VnetSubnetID                  string            `json:"vnetSubnetID,omitempty"`
+	VnetCidr                      string            `json:"vnetCidr,omitempty"`
+	AgentVnetSubnetID             string            `json:"agentVnetSubnetID,omitempty"`
+	FirstConsecutiveStaticIP      string            `json:"firstConsecutiveStaticIP,omitempty"`
+	EtcdSubnet                    string            `json:"etcdSubnet,omitempty"`
+	EtcdVnetSubnetID              string            `json:"etcdVnetSubnetID,omitempty"`
+	EtcdFirstConsecutiveStaticIP  string            `json:"etcdFirstConsecutiveStaticIP,omitempty"`
+	IPAddressCount                int               `json:"ipAddressCount,omitempty" validate:"min=0,max=256"`
+	StorageProfile                string            `json:"storageProfile,omitempty" validate:"eq=StorageAccount|eq=ManagedDisks|len=0"`
+	DataDiskStorageAccountType    string            `json:"dataDiskStorageAccountType,omitempty" validate:"eq=Standard_LRS|eq=StandardSSD_LRS|eq=Premium_LRS|eq=UltraSSD_LRS|len=0"`
+	DataDiskIOPSReadWrite         int               `json:"dataDiskIOPSReadWrite,omitempty"`
+	DataDiskMBpsReadWrite         int               `json:"dataDiskMBpsReadWrite,omitempty"`
+	HTTPSourceAddressPrefix       string            `json:"HTTPSourceAddressPrefix,omitempty"`
+	OAuthEnabled                  bool              `json:"oauthEnabled"`
+	PreProvisionExtension         *Extension        `json:"preProvisionExtension"`
+	Extensions                    []Extension       `json:"extensions"`
+	VMExtensions                  []VMExtension     `json:"vmExtensions,omitempty"`
+	Distro                        Distro            `json:"distro,omitempty"`
+	KubernetesConfig              *KubernetesConfig `json:"kubernetesConfig,omitempty"`
+	ImageRef                      *ImageReference   `json:"imageReference,omitempty"`
+	CustomFiles                   *[]CustomFile     `json:"customFiles,omitempty"`
+	AvailabilityProfile           string            `json:"availabilityProfile"`
+	AgentSubnet                   string            `json:"agentSubnet,omitempty"`
+	AvailabilityZones             []string          `json:"availabilityZones,omitempty"`
+	SinglePlacementGroup          *bool             `json:"singlePlacementGroup,omitempty"`
+	ProximityPlacementGroupID     string            `json:"proximityPlacementGroupID,omitempty"`
+	CreateProximityPlacementGroup *bool             `json:"createProximityPlacementGroup,omitempty"`
+	AuditDEnabled                 *bool             `json:"auditDEnabled,omitempty"`
+	CustomVMTags                  map[string]string `json:"customVMTags,omitempty"`
+	VnetDNSServers                []string          `json:"vnetDnsServers,omitempty"`
+	DNSConfig                     *NodeDNSConfig    `json:"dnsConfig,omitempty"`
 
 	// subnet is internal
 	subnet string
@@ -434,31 +602,52 @@ type Extension struct {
 	Template    string `json:"template"`
 }
 
+// VMExtension represents an Azure VM extension installed on every VM of the master or an agent pool,
+// e.g. a security or monitoring agent. It is provisioned after the custom script extension that bootstraps the VM.
+type VMExtension struct {
+	Name                    string                 `json:"name"`
+	Publisher               string                 `json:"publisher"`
+	Type                    string                 `json:"type"`
+	TypeHandlerVersion      string                 `json:"typeHandlerVersion"`
+	AutoUpgradeMinorVersion *bool                  `json:"autoUpgradeMinorVersion,omitempty"`
+	Settings                map[string]interface{} `json:"settings,omitempty"`
+	ProtectedSettings       map[string]interface{} `json:"protectedSettings,omitempty"`
+}
+
 // AgentPoolProfile represents an agent pool definition
 type AgentPoolProfile struct {
-	Name                                string               `json:"name" validate:"required"`
-	Count                               int                  `json:"count" validate:"required,min=1,max=100"`
-	VMSize                              string               `json:"vmSize" validate:"required"`
-	OSDiskSizeGB                        int                  `json:"osDiskSizeGB,omitempty" validate:"min=0,max=1023"`
-	DNSPrefix                           string               `json:"dnsPrefix,omitempty"`
-	OSType                              OSType               `json:"osType,omitempty"`
-	Ports                               []int                `json:"ports,omitempty" validate:"dive,min=1,max=65535"`
-	AvailabilityProfile                 string               `json:"availabilityProfile"`
-	ScaleSetPriority                    string               `json:"scaleSetPriority,omitempty" validate:"eq=Regular|eq=Low|len=0"`
-	ScaleSetEvictionPolicy              string               `json:"scaleSetEvictionPolicy,omitempty" validate:"eq=Delete|eq=Deallocate|len=0"`
-	StorageProfile                      string               `json:"storageProfile" validate:"eq=StorageAccount|eq=ManagedDisks|eq=Ephemeral|len=0"`
-	DiskSizesGB                         []int                `json:"diskSizesGB,omitempty" validate:"max=4,dive,min=1,max=1023"`
-	VnetSubnetID                        string               `json:"vnetSubnetID,omitempty"`
-	IPAddressCount                      int                  `json:"ipAddressCount,omitempty" validate:"min=0,max=256"`
-	Distro                              Distro               `json:"distro,omitempty"`
-	KubernetesConfig                    *KubernetesConfig    `json:"kubernetesConfig,omitempty"`
-	ImageRef                            *ImageReference      `json:"imageReference,omitempty"`
-	Role                                AgentPoolProfileRole `json:"role,omitempty"`
-	AcceleratedNetworkingEnabled        *bool                `json:"acceleratedNetworkingEnabled,omitempty"`
-	AcceleratedNetworkingEnabledWindows *bool                `json:"acceleratedNetworkingEnabledWindows,omitempty"`
-	VMSSOverProvisioningEnabled         *bool                `json:"vmssOverProvisioningEnabled,omitempty"`
-	AuditDEnabled                       *bool                `json:"auditDEnabled,omitempty"`
-	CustomVMTags                        map[string]string    `json:"customVMTags,omitempty"`
+	Name                                string                  `json:"name" validate:"required"`
+	Count                               int                     `json:"count" validate:"required,min=1,max=100"`
+	VMSize                              string                  `json:"vmSize" validate:"required"`
+	OSDiskSizeGB                        int                     `json:"osDiskSizeGB,omitempty" validate:"min=0,max=1023"`
+	DNSPrefix                           string                  `json:"dnsPrefix,omitempty"`
+	OSType                              OSType                  `json:"osType,omitempty"`
+	Ports                               []int                   `json:"ports,omitempty" validate:"dive,min=1,max=65535"`
+	AvailabilityProfile                 string                  `json:"availabilityProfile"`
+	ScaleSetPriority                    string                  `json:"scaleSetPriority,omitempty" validate:"eq=Regular|eq=Low|eq=Spot|len=0"`
+	ScaleSetEvictionPolicy              string                  `json:"scaleSetEvictionPolicy,omitempty" validate:"eq=Delete|eq=Deallocate|len=0"`
+	SpotMaxPrice                        *float64                `json:"spotMaxPrice,omitempty"`
+	StorageProfile                      string                  `json:"storageProfile" validate:"eq=StorageAccount|eq=ManagedDisks|eq=Ephemeral|len=0"`
+	OSDiskType                          string                  `json:"osDiskType,omitempty" validate:"eq=Managed|eq=Ephemeral|len=0"`
+	DiskSizesGB                         []int                   `json:"diskSizesGB,omitempty" validate:"max=4,dive,min=1,max=1023"`
+	DataDiskStorageAccountType          string                  `json:"dataDiskStorageAccountType,omitempty" validate:"eq=Standard_LRS|eq=StandardSSD_LRS|eq=Premium_LRS|eq=UltraSSD_LRS|len=0"`
+	DataDiskIOPSReadWrite               int                     `json:"dataDiskIOPSReadWrite,omitempty"`
+	DataDiskMBpsReadWrite               int                     `json:"dataDiskMBpsReadWrite,omitempty"`
+	EnableSharedDisk                    *bool                   `json:"enableSharedDisk,omitempty"`
+	VnetSubnetID                        string                  `json:"vnetSubnetID,omitempty"`
+	PodSubnetID                         string                  `json:"podSubnetID,omitempty"`
+	IPAddressCount                      int                     `json:"ipAddressCount,omitempty" validate:"min=0,max=256"`
+	Distro                              Distro                  `json:"distro,omitempty"`
+	KubernetesConfig                    *KubernetesConfig       `json:"kubernetesConfig,omitempty"`
+	ImageRef                            *ImageReference         `json:"imageReference,omitempty"`
+	Role                                AgentPoolProfileRole    `json:"role,omitempty"`
+	AcceleratedNetworkingEnabled        *bool                   `json:"acceleratedNetworkingEnabled,omitempty"`
+	AcceleratedNetworkingEnabledWindows *bool                   `json:"acceleratedNetworkingEnabledWindows,omitempty"`
+	VMSSOverProvisioningEnabled         *bool                   `json:"vmssOverProvisioningEnabled,omitempty"`
+	AuditDEnabled                       *bool                   `json:"auditDEnabled,omitempty"`
+	CustomVMTags                        map[string]string       `json:"customVMTags,omitempty"`
+	DNSConfig                           *NodeDNSConfig          `json:"dnsConfig,omitempty"`
+	ScheduledEventsProfile              *ScheduledEventsProfile `json:"scheduledEventsProfile,omitempty"`
 
 	// subnet is internal
 	subnet string
@@ -467,10 +656,17 @@ type AgentPoolProfile struct {
 	CustomNodeLabels                  map[string]string `json:"customNodeLabels,omitempty"`
 	PreProvisionExtension             *Extension        `json:"preProvisionExtension"`
 	Extensions                        []Extension       `json:"extensions"`
+	VMExtensions                      []VMExtension     `json:"vmExtensions,omitempty"`
 	SinglePlacementGroup              *bool             `json:"singlePlacementGroup,omitempty"`
 	AvailabilityZones                 []string          `json:"availabilityZones,omitempty"`
+	ZoneBalanceStrategy               string            `json:"zoneBalanceStrategy,omitempty" validate:"eq=BestEffort|eq=Strict|len=0"`
+	ProximityPlacementGroupID         string            `json:"proximityPlacementGroupID,omitempty"`
+	CreateProximityPlacementGroup     *bool             `json:"createProximityPlacementGroup,omitempty"`
+	HostGroupID                       string            `json:"hostGroupID,omitempty"`
+	PlatformFaultDomainCount          *int              `json:"platformFaultDomainCount,omitempty"`
 	EnableVMSSNodePublicIP            *bool             `json:"enableVMSSNodePublicIP,omitempty"`
 	LoadBalancerBackendAddressPoolIDs []string          `json:"loadBalancerBackendAddressPoolIDs,omitempty"`
+	UserAssignedID                    string            `json:"userAssignedID,omitempty"`
 }
 
 // AgentPoolProfileRole represents an agent role
@@ -578,6 +774,11 @@ func (m *MasterProfile) IsCustomVNET() bool {
 	return len(m.VnetSubnetID) > 0
 }
 
+// HasDedicatedEtcdSubnet returns true if etcd traffic between masters uses a subnet of its own
+func (m *MasterProfile) HasDedicatedEtcdSubnet() bool {
+	return m.EtcdSubnet != ""
+}
+
 // GetSubnet returns the read-only subnet for the master
 func (m *MasterProfile) GetSubnet() string {
 	return m.subnet
@@ -687,6 +888,11 @@ func (a *AgentPoolProfile) IsCustomVNET() bool {
 	return len(a.VnetSubnetID) > 0
 }
 
+// HasPodSubnet returns true if the Azure CNI pod IPs of the pool are allocated in a subnet of their own
+func (a *AgentPoolProfile) HasPodSubnet() bool {
+	return len(a.PodSubnetID) > 0
+}
+
 // IsWindows returns true if the agent pool is windows
 func (a *AgentPoolProfile) IsWindows() bool {
 	return a.OSType == Windows
@@ -727,9 +933,10 @@ func (a *AgentPoolProfile) IsManagedDisks() bool {
 	return a.StorageProfile == ManagedDisks
 }
 
-// IsEphemeral returns true if the customer specified ephemeral disks
+// IsEphemeral returns true if the customer specified ephemeral OS disks, with either the OS disk type or the storage
+// profile
 func (a *AgentPoolProfile) IsEphemeral() bool {
-	return a.StorageProfile == Ephemeral
+	return a.OSDiskType == EphemeralOSDisk || a.StorageProfile == Ephemeral
 }
 
 // IsStorageAccount returns true if the customer specified storage account
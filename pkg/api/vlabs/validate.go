@@ -5,9 +5,11 @@ package vlabs
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/url"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -25,10 +27,32 @@ import (
 )
 
 var (
-	validate        *validator.Validate
-	keyvaultIDRegex *regexp.Regexp
-	labelValueRegex *regexp.Regexp
-	labelKeyRegex   *regexp.Regexp
+	validate          *validator.Validate
+	keyvaultIDRegex   *regexp.Regexp
+	ppgIDRegex        *regexp.Regexp
+	hostGroupIDRegex  *regexp.Regexp
+	labelValueRegex   *regexp.Regexp
+	labelKeyRegex     *regexp.Regexp
+	searchDomainRegex *regexp.Regexp
+	vmExtensionRegex  *regexp.Regexp
+	// the VM sizes of the families offered by the dedicated host SKUs, e.g. Standard_D4s_v3 on a DSv3-Type1 host
+	dedicatedHostVMSizeRegex = regexp.MustCompile(`(?i)^Standard_(D\d+[ad]?s_v[34]|E\d+(-\d+)?[ad]?s_v[34]|F\d+s_v2|L\d+s_v2|DC\d+s_v2|M\d+(-\d+)?[lmst]*(_v2)?)$`)
+	// storage account and blob container names are lowercase, the container names cannot have consecutive dashes
+	storageAccountNameRegex = regexp.MustCompile(`^[a-z0-9]{3,24}$`)
+	blobContainerNameRegex  = regexp.MustCompile(`^[a-z0-9][-a-z0-9]{1,61}[a-z0-9]$`)
+	// user assigned identity names start with a letter or a digit
+	userAssignedIDNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][-a-zA-Z0-9_]{2,127}$`)
+	// the custom monitor configs of the node-problem-detector addon are the JSON files of a config map
+	nodeProblemDetectorCustomConfigRegex = regexp.MustCompile(`^[-._a-zA-Z0-9]+\.json$`)
+	// namespace names are DNS-1123 labels
+	namespaceNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+	// an image registry is a host name with an optional port, followed by an optional repository path
+	imageRegistryRegex = regexp.MustCompile(`^[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?)*(:[0-9]+)?(/[a-z0-9]+([._-][a-z0-9]+)*)*$`)
+	// images are pinned to sha256 digests
+	imageDigestRegex = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	// the clients of the Azure APIs of the cloud provider, whose rate limits are the <client>RateLimit of azure.json
+	cloudProviderRateLimitClients = []string{"route", "subnets", "interface", "routeTable", "loadBalancer", "publicIPAddress",
+		"securityGroup", "virtualMachine", "storageAccount", "disk", "snapshot", "virtualMachineScaleSet", "virtualMachineSizes"}
 	// Any version has to be mirrored in https://acs-mirror.azureedge.net/github-coreos/etcd-v[Version]-linux-amd64.tar.gz
 	etcdValidVersions = [...]string{"2.2.5", "2.3.0", "2.3.1", "2.3.2", "2.3.3", "2.3.4", "2.3.5", "2.3.6", "2.3.7", "2.3.8",
 		"3.0.0", "3.0.1", "3.0.2", "3.0.3", "3.0.4", "3.0.5", "3.0.6", "3.0.7", "3.0.8", "3.0.9", "3.0.10", "3.0.11", "3.0.12", "3.0.13", "3.0.14", "3.0.15", "3.0.16", "3.0.17",
@@ -65,6 +89,14 @@ var (
 			networkPlugin: NetworkPluginCilium,
 			networkPolicy: NetworkPolicyCilium,
 		},
+		{
+			networkPlugin: NetworkPluginAntrea,
+			networkPolicy: "",
+		},
+		{
+			networkPlugin: NetworkPluginAntrea,
+			networkPolicy: NetworkPolicyAntrea,
+		},
 		{
 			networkPlugin: "kubenet",
 			networkPolicy: "calico",
@@ -81,6 +113,10 @@ var (
 			networkPlugin: "",
 			networkPolicy: NetworkPolicyCilium,
 		},
+		{
+			networkPlugin: "",
+			networkPolicy: NetworkPolicyAntrea,
+		},
 		{
 			networkPlugin: "",
 			networkPolicy: "azure", // for backwards-compatibility w/ prior networkPolicy usage
@@ -94,8 +130,11 @@ var (
 
 const (
 	labelKeyPrefixMaxLength = 253
+	maxNodeSearchDomains    = 6
+	searchDomainFormat      = "^([a-zA-Z0-9]([-a-zA-Z0-9]{0,61}[a-zA-Z0-9])?[.])*[a-zA-Z0-9]([-a-zA-Z0-9]{0,61}[a-zA-Z0-9])?$"
 	labelValueFormat        = "^([A-Za-z0-9][-A-Za-z0-9_.]{0,61})?[A-Za-z0-9]$"
 	labelKeyFormat          = "^(([a-zA-Z0-9-]+[.])*[a-zA-Z0-9-]+[/])?([A-Za-z0-9][-A-Za-z0-9_.]{0,61})?[A-Za-z0-9]$"
+	vmExtensionNameFormat   = "^[A-Za-z0-9][-A-Za-z0-9_.]{0,63}$"
 )
 
 type k8sNetworkConfig struct {
@@ -106,8 +145,12 @@ type k8sNetworkConfig struct {
 func init() {
 	validate = validator.New()
 	keyvaultIDRegex = regexp.MustCompile(`^/subscriptions/\S+/resourceGroups/\S+/providers/Microsoft.KeyVault/vaults/[^/\s]+$`)
+	hostGroupIDRegex = regexp.MustCompile(`(?i)^/subscriptions/[^/\s]+/resourceGroups/[^/\s]+/providers/Microsoft.Compute/hostGroups/[^/\s]+$`)
+	ppgIDRegex = regexp.MustCompile(`(?i)^/subscriptions/[^/\s]+/resourceGroups/[^/\s]+/providers/Microsoft.Compute/proximityPlacementGroups/[^/\s]+$`)
 	labelValueRegex = regexp.MustCompile(labelValueFormat)
 	labelKeyRegex = regexp.MustCompile(labelKeyFormat)
+	searchDomainRegex = regexp.MustCompile(searchDomainFormat)
+	vmExtensionRegex = regexp.MustCompile(vmExtensionNameFormat)
 }
 
 // Validate implements APIObject
@@ -124,7 +167,7 @@ func (a *Properties) validate(isUpdate bool) error {
 	if e := a.validateAgentPoolProfiles(isUpdate); e != nil {
 		return e
 	}
-	if e := a.validateZones(); e != nil {
+	if e := a.validateZones(isUpdate); e != nil {
 		return e
 	}
 	if e := a.validateLinuxProfile(); e != nil {
@@ -136,9 +179,24 @@ func (a *Properties) validate(isUpdate bool) error {
 	if e := a.validateExtensions(); e != nil {
 		return e
 	}
+	if e := a.validateVMExtensions(); e != nil {
+		return e
+	}
 	if e := a.validateVNET(); e != nil {
 		return e
 	}
+	if e := a.validateEtcdSubnet(); e != nil {
+		return e
+	}
+	if e := a.validatePodSubnets(); e != nil {
+		return e
+	}
+	if e := a.validateNetworkCIDROverlaps(); e != nil {
+		return e
+	}
+	if e := a.validateNodeDNS(); e != nil {
+		return e
+	}
 	if e := a.validateServicePrincipalProfile(); e != nil {
 		return e
 	}
@@ -147,9 +205,17 @@ func (a *Properties) validate(isUpdate bool) error {
 		return e
 	}
 
+	if e := a.validateAgentPoolUserAssignedIDs(); e != nil {
+		return e
+	}
+
 	if e := a.validateAADProfile(); e != nil {
 		return e
 	}
+
+	if e := a.validateAirGapProfile(); e != nil {
+		return e
+	}
 	return nil
 }
 
@@ -308,6 +374,14 @@ func (a *Properties) ValidateOrchestratorProfile(isUpdate bool) error {
 					return errors.New("outboundRuleIdleTimeoutInMinutes shouldn't be less than 4 or greater than 120")
 				}
 
+				if e := a.validateOutboundType(); e != nil {
+					return e
+				}
+
+				if e := a.validatePrivateLink(); e != nil {
+					return e
+				}
+
 				if a.IsAzureStackCloud() {
 					if to.Bool(o.KubernetesConfig.UseInstanceMetadata) {
 						return errors.New("useInstanceMetadata shouldn't be set to true as feature not yet supported on Azure Stack")
@@ -362,6 +436,73 @@ func (a *Properties) ValidateOrchestratorProfile(isUpdate bool) error {
 	return a.validateContainerRuntime()
 }
 
+func (a *Properties) validateOutboundType() error {
+	k := a.OrchestratorProfile.KubernetesConfig
+	switch k.OutboundType {
+	case "", OutboundTypeLoadBalancer, OutboundTypeNATGateway, OutboundTypeUserDefinedRouting:
+	default:
+		return errors.Errorf("Invalid outboundType %s. Allowed types are %s, %s and %s", k.OutboundType, OutboundTypeLoadBalancer, OutboundTypeNATGateway, OutboundTypeUserDefinedRouting)
+	}
+	isStandardLoadBalancer := strings.EqualFold(k.LoadBalancerSku, StandardLoadBalancerSku)
+	if k.OutboundType != "" && !isStandardLoadBalancer {
+		return errors.Errorf("outboundType %s requires loadBalancerSku %s", k.OutboundType, StandardLoadBalancerSku)
+	}
+	isCustomVNET := a.MasterProfile != nil && a.MasterProfile.IsCustomVNET()
+	switch k.OutboundType {
+	case OutboundTypeNATGateway:
+		if isCustomVNET {
+			return errors.Errorf("outboundType %s is only supported with the VNET created by aks-engine, attach the NAT gateway to the subnets of a custom VNET and use outboundType %s", OutboundTypeNATGateway, OutboundTypeUserDefinedRouting)
+		}
+	case OutboundTypeUserDefinedRouting:
+		if !isCustomVNET {
+			return errors.Errorf("outboundType %s requires a custom VNET whose subnets provide the egress of the cluster", OutboundTypeUserDefinedRouting)
+		}
+	}
+
+	// https://docs.microsoft.com/en-us/azure/load-balancer/load-balancer-outbound-rules-overview#snatports
+	if k.OutboundRuleAllocatedPorts != 0 {
+		if !isStandardLoadBalancer || (k.OutboundType != "" && k.OutboundType != OutboundTypeLoadBalancer) {
+			return errors.Errorf("outboundRuleAllocatedPorts is only supported with loadBalancerSku %s and outboundType %s", StandardLoadBalancerSku, OutboundTypeLoadBalancer)
+		}
+		if k.OutboundRuleAllocatedPorts < 0 || k.OutboundRuleAllocatedPorts > 64000 || k.OutboundRuleAllocatedPorts%8 != 0 {
+			return errors.New("outboundRuleAllocatedPorts should be a multiple of 8 between 0 and 64000")
+		}
+	}
+	return nil
+}
+
+func (a *Properties) validatePrivateLink() error {
+	k := a.OrchestratorProfile.KubernetesConfig
+	if k.PrivateCluster == nil || k.PrivateCluster.PrivateLink == nil || !to.Bool(k.PrivateCluster.PrivateLink.Enabled) {
+		return nil
+	}
+	if !to.Bool(k.PrivateCluster.Enabled) {
+		return errors.New("privateCluster.privateLink requires privateCluster to be enabled")
+	}
+	if !strings.EqualFold(k.LoadBalancerSku, StandardLoadBalancerSku) {
+		return errors.Errorf("privateCluster.privateLink requires loadBalancerSku %s, a Private Link service can only front a Standard load balancer", StandardLoadBalancerSku)
+	}
+	if a.MasterProfile == nil || a.MasterProfile.Count < 3 {
+		return errors.New("privateCluster.privateLink requires a masterProfile count of 3 or 5, the Private Link service fronts the internal load balancer of the masters")
+	}
+	if a.IsAzureStackCloud() {
+		return errors.New("privateCluster.privateLink is not supported on Azure Stack")
+	}
+	if a.FeatureFlags.IsIPv6DualStackEnabled() {
+		return errors.New("privateCluster.privateLink is not supported with the IPv6 dual stack feature")
+	}
+	if subnetID := k.PrivateCluster.PrivateLink.PrivateEndpointSubnetID; subnetID != "" {
+		if _, _, _, _, err := common.GetVNETSubnetIDComponents(subnetID); err != nil {
+			return errors.Errorf("privateCluster.privateLink.privateEndpointSubnetID %s is not a valid subnet ID: %s", subnetID, err)
+		}
+		log.Warnf("privateCluster.privateLink.privateEndpointSubnetID is set. aks-engine does not update subnet %s, disable its private endpoint network policies before deploying the cluster.", subnetID)
+	}
+	if a.MasterProfile.IsCustomVNET() {
+		log.Warnf("privateCluster.privateLink is enabled with a custom VNET. aks-engine does not update subnet %s, disable its Private Link service and private endpoint network policies before deploying the cluster.", a.MasterProfile.VnetSubnetID)
+	}
+	return nil
+}
+
 func (a *Properties) validateMasterProfile(isUpdate bool) error {
 	m := a.MasterProfile
 
@@ -401,6 +542,14 @@ func (a *Properties) validateMasterProfile(isUpdate bool) error {
 		return errors.New("singlePlacementGroup is only supported with VirtualMachineScaleSets")
 	}
 
+	if e := validateProximityPlacementGroup("masterProfile", m.ProximityPlacementGroupID, m.CreateProximityPlacementGroup, m.AvailabilityZones, a.IsAzureStackCloud()); e != nil {
+		return e
+	}
+
+	if e := a.validateMasterDataDisks(); e != nil {
+		return e
+	}
+
 	distroValues := DistroValues
 	if isUpdate {
 		distroValues = append(distroValues, AKSDockerEngine, AKS1604Deprecated, AKS1804Deprecated)
@@ -474,6 +623,26 @@ func (a *Properties) validateAgentPoolProfiles(isUpdate bool) error {
 			}
 		}
 
+		if e := agentPoolProfile.validateScheduledEventsProfile(a.IsAzureStackCloud()); e != nil {
+			return e
+		}
+
+		if e := agentPoolProfile.validateSpotScaleSet(a.IsAzureStackCloud()); e != nil {
+			return e
+		}
+
+		if e := agentPoolProfile.validateHostGroup(a.IsAzureStackCloud()); e != nil {
+			return e
+		}
+
+		if e := validateProximityPlacementGroup(fmt.Sprintf("agent pool %s", agentPoolProfile.Name), agentPoolProfile.ProximityPlacementGroupID, agentPoolProfile.CreateProximityPlacementGroup, agentPoolProfile.AvailabilityZones, a.IsAzureStackCloud()); e != nil {
+			return e
+		}
+
+		if e := agentPoolProfile.validateDataDisks(a.IsAzureStackCloud()); e != nil {
+			return e
+		}
+
 		if to.Bool(agentPoolProfile.EnableVMSSNodePublicIP) {
 			if agentPoolProfile.AvailabilityProfile != VirtualMachineScaleSets {
 				return errors.Errorf("You have enabled VMSS node public IP in agent pool %s, but you did not specify VMSS", agentPoolProfile.Name)
@@ -544,6 +713,12 @@ func (a *Properties) validateAgentPoolProfiles(isUpdate bool) error {
 			return e
 		}
 
+		if agentPoolProfile.KubernetesConfig != nil {
+			if e := validateKubeletConfig(agentPoolProfile.KubernetesConfig.KubeletConfig); e != nil {
+				return errors.Wrapf(e, "invalid kubeletConfig in agent pool %s", agentPoolProfile.Name)
+			}
+		}
+
 		if agentPoolProfile.IsEphemeral() {
 			log.Warnf("Ephemeral disks are enabled for Agent Pool %s. This feature in AKS-Engine is experimental, and data could be lost in some cases.", agentPoolProfile.Name)
 		}
@@ -552,7 +727,7 @@ func (a *Properties) validateAgentPoolProfiles(isUpdate bool) error {
 	return nil
 }
 
-func (a *Properties) validateZones() error {
+func (a *Properties) validateZones(isUpdate bool) error {
 	if a.OrchestratorProfile.OrchestratorType == Kubernetes {
 		// all zones or no zones should be defined for the cluster
 		if a.HasAvailabilityZones() {
@@ -569,7 +744,74 @@ func (a *Properties) validateZones() error {
 			} else {
 				return errors.New("Availability Zones need to be defined for master profile and all agent pool profiles. Please set \"availabilityZones\" for all profiles")
 			}
+			if e := validateAvailabilityZones("masterProfile", a.MasterProfile.AvailabilityZones); e != nil {
+				return e
+			}
+			// the zones of the masters of an existing cluster cannot be changed
+			if !isUpdate {
+				if e := a.validateMasterZoneSpread(); e != nil {
+					return e
+				}
+			}
+		}
+		for _, agentPoolProfile := range a.AgentPoolProfiles {
+			if e := validateAvailabilityZones(fmt.Sprintf("agent pool %s", agentPoolProfile.Name), agentPoolProfile.AvailabilityZones); e != nil {
+				return e
+			}
+			if e := agentPoolProfile.validateZoneBalanceStrategy(); e != nil {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// validateAvailabilityZones validates that the availability zones of the masters or of an agent pool are not repeated
+func validateAvailabilityZones(name string, zones []string) error {
+	seen := map[string]bool{}
+	for _, zone := range zones {
+		if seen[zone] {
+			return errors.Errorf("%s has duplicate availability zone %s", name, zone)
 		}
+		seen[zone] = true
+	}
+	return nil
+}
+
+// validateMasterZoneSpread validates that the masters, assigned to their availability zones in turn, are spread so that
+// the outage of one zone does not lose the etcd quorum
+func (a *Properties) validateMasterZoneSpread() error {
+	m := a.MasterProfile
+	if m.Count <= 1 || to.Bool(m.CosmosEtcd) {
+		return nil
+	}
+	quorum := m.Count/2 + 1
+	// the most masters in one zone
+	mastersPerZone := func(zoneCount int) int {
+		return (m.Count + zoneCount - 1) / zoneCount
+	}
+	zoneCount := len(m.AvailabilityZones)
+	if m.Count-mastersPerZone(zoneCount) >= quorum {
+		return nil
+	}
+	minZoneCount := zoneCount + 1
+	for m.Count-mastersPerZone(minZoneCount) < quorum {
+		minZoneCount++
+	}
+	return errors.Errorf("masterProfile has %d masters in %d availability zones, the outage of a zone with %d of them would lose the etcd quorum of %d masters. Spread the masters across at least %d availability zones", m.Count, zoneCount, mastersPerZone(zoneCount), quorum, minZoneCount)
+}
+
+// validateZoneBalanceStrategy validates the zone balance strategy of an agent pool, which is a property of its scale set
+// spreading its VMs across more than one availability zone
+func (a *AgentPoolProfile) validateZoneBalanceStrategy() error {
+	if a.ZoneBalanceStrategy == "" {
+		return nil
+	}
+	if a.AvailabilityProfile == AvailabilitySet {
+		return errors.Errorf("agent pool %s has zoneBalanceStrategy %s, which is only supported by %s", a.Name, a.ZoneBalanceStrategy, VirtualMachineScaleSets)
+	}
+	if len(a.AvailabilityZones) < 2 {
+		return errors.Errorf("agent pool %s has zoneBalanceStrategy %s, which requires more than one availability zone", a.Name, a.ZoneBalanceStrategy)
 	}
 	return nil
 }
@@ -606,12 +848,23 @@ func (a *Properties) validateAddons() error {
 					return errors.Errorf("Addon %s's data should be base64 encoded", addon.Name)
 				}
 			}
+			for _, container := range addon.Containers {
+				if container.ImageDigest != "" && !imageDigestRegex.MatchString(container.ImageDigest) {
+					return errors.Errorf("imageDigest %s of container %s in addon %s must be a sha256 digest, e.g. sha256:<64 hexadecimal characters>", container.ImageDigest, container.Name, addon.Name)
+				}
+			}
 
 			switch addon.Name {
 			case "cluster-autoscaler":
-				if to.Bool(addon.Enabled) && isAvailabilitySets {
+				// the pools of the addon are validated to be scale sets on their own
+				if to.Bool(addon.Enabled) && isAvailabilitySets && len(addon.Pools) == 0 {
 					return errors.Errorf("Cluster Autoscaler add-on can only be used with VirtualMachineScaleSets. Please specify \"availabilityProfile\": \"%s\"", VirtualMachineScaleSets)
 				}
+				if to.Bool(addon.Enabled) {
+					if err := a.validateClusterAutoscalerAddon(addon); err != nil {
+						return err
+					}
+				}
 			case "nvidia-device-plugin":
 				if to.Bool(addon.Enabled) {
 					version := common.RationalizeReleaseAndVersion(
@@ -664,6 +917,211 @@ func (a *Properties) validateAddons() error {
 					if len(addon.Config["appgw-subnet"]) == 0 {
 						return errors.New("appgw-ingress add-ons requires 'appgw-subnet' in the Config. It is used to provision the subnet for Application Gateway in the vnet")
 					}
+
+					// the ingress controller gets the identity of the Application Gateway from aad-pod-identity
+					if a.IsAzureStackCloud() {
+						return errors.New("appgw-ingress add-ons are not supported on Azure Stack, its ingress controller requires the aad-pod-identity add-on")
+					}
+					for _, other := range a.OrchestratorProfile.KubernetesConfig.Addons {
+						if other.Name == "aad-pod-identity" && other.Enabled != nil && !*other.Enabled {
+							return errors.New("appgw-ingress add-ons require the aad-pod-identity add-on, which gives its ingress controller the identity of the Application Gateway")
+						}
+					}
+				}
+			case "nginx-ingress":
+				if to.Bool(addon.Enabled) {
+					if replicas, ok := addon.Config["replicas"]; ok {
+						if n, err := strconv.Atoi(replicas); err != nil || n < 1 {
+							return errors.Errorf("nginx-ingress add-on replicas %s must be a positive number", replicas)
+						}
+					}
+					switch serviceType := addon.Config["serviceType"]; serviceType {
+					case "", "LoadBalancer", "NodePort":
+					default:
+						return errors.Errorf("nginx-ingress add-on serviceType %s must be LoadBalancer or NodePort", serviceType)
+					}
+				}
+			case "azure-policy":
+				if to.Bool(addon.Enabled) {
+					if a.IsAzureStackCloud() {
+						return errors.New("azure-policy add-on is not supported on Azure Stack")
+					}
+					// gatekeeper sets the timeout of its validating webhook
+					version := common.RationalizeReleaseAndVersion(
+						a.OrchestratorProfile.OrchestratorType,
+						a.OrchestratorProfile.OrchestratorRelease,
+						a.OrchestratorProfile.OrchestratorVersion,
+						false,
+						false)
+					if !common.IsKubernetesVersionGe(version, "1.14.0") {
+						return errors.Errorf("azure-policy add-on is only available in kubernetes version %s or greater; unable to validate for version %s", "1.14.0", version)
+					}
+					if value, ok := addon.Config["auditInterval"]; ok {
+						if n, err := strconv.Atoi(value); err != nil || n < 1 {
+							return errors.Errorf("azure-policy add-on auditInterval %s must be a positive number of seconds", value)
+						}
+					}
+					if value, ok := addon.Config["constraintViolationsLimit"]; ok {
+						if n, err := strconv.Atoi(value); err != nil || n < 0 {
+							return errors.Errorf("azure-policy add-on constraintViolationsLimit %s must be a number", value)
+						}
+					}
+					if value := addon.Config["syncOnly"]; value != "" {
+						for _, kind := range strings.Split(value, ",") {
+							parts := strings.Split(kind, "/")
+							if (len(parts) != 2 && len(parts) != 3) || parts[len(parts)-1] == "" || parts[len(parts)-2] == "" {
+								return errors.Errorf("azure-policy add-on syncOnly kind %s must be of the form [group/]version/kind, e.g. v1/Namespace", kind)
+							}
+						}
+					}
+				}
+			case "keda":
+				if to.Bool(addon.Enabled) {
+					// the keda.k8s.io/v1alpha1 API of the manifest and the metrics adapter image are those of the 1.x releases from 1.1.0
+					if value, ok := addon.Config["version"]; ok && value != "" {
+						v, err := semver.Make(value)
+						if err != nil || v.Major != 1 || v.Minor < 1 {
+							return errors.Errorf("keda add-on version %s must be a 1.x release of KEDA from 1.1.0, e.g. 1.1.0", value)
+						}
+					}
+				}
+			case "open-service-mesh":
+				if to.Bool(addon.Enabled) {
+					version := common.RationalizeReleaseAndVersion(
+						a.OrchestratorProfile.OrchestratorType,
+						a.OrchestratorProfile.OrchestratorRelease,
+						a.OrchestratorProfile.OrchestratorVersion,
+						false,
+						false)
+					if !common.IsKubernetesVersionGe(version, "1.15.0") {
+						return errors.Errorf("open-service-mesh add-on is only available in kubernetes version %s or greater; unable to validate for version %s", "1.15.0", version)
+					}
+					if value := addon.Config["namespaces"]; value != "" {
+						for _, namespace := range strings.Split(value, ",") {
+							if !namespaceNameRegex.MatchString(namespace) {
+								return errors.Errorf("open-service-mesh add-on namespace %q must be a valid namespace name", namespace)
+							}
+							// the control plane and the system components are not part of the mesh
+							switch namespace {
+							case "kube-system", "kube-public", "kube-node-lease", "osm-system":
+								return errors.Errorf("open-service-mesh add-on namespace %s cannot have sidecar injection enabled", namespace)
+							}
+						}
+					}
+					if value, ok := addon.Config["permissiveTrafficPolicyMode"]; ok {
+						if _, err := strconv.ParseBool(value); err != nil {
+							return errors.Errorf("open-service-mesh add-on permissiveTrafficPolicyMode %s must be true or false", value)
+						}
+					}
+					if value, ok := addon.Config["envoyLogLevel"]; ok {
+						switch value {
+						case "trace", "debug", "info", "warning", "warn", "error", "critical", "off":
+						default:
+							return errors.Errorf("open-service-mesh add-on envoyLogLevel %s must be one of trace, debug, info, warning, error, critical or off", value)
+						}
+					}
+				}
+			case "registry-cache":
+				if to.Bool(addon.Enabled) {
+					if a.OrchestratorProfile.KubernetesConfig.ProxyMode == KubeProxyModeIPVS {
+						return errors.New("registry-cache add-on requires kubeProxyMode iptables, nodes pull images through its node port on localhost")
+					}
+					if nodePort, ok := addon.Config["nodePort"]; ok {
+						if port, err := strconv.Atoi(nodePort); err != nil || port < 30000 || port > 32767 {
+							return errors.Errorf("registry-cache add-on nodePort %s must be a port in the 30000-32767 range", nodePort)
+						}
+					}
+				}
+			case "fluent-bit":
+				if to.Bool(addon.Enabled) {
+					var required []string
+					switch output := addon.Config["output"]; output {
+					case "", "log-analytics":
+						required = []string{"workspaceGuid", "workspaceKey"}
+					case "storage":
+						required = []string{"storageAccountName", "storageAccountKey"}
+					default:
+						return errors.Errorf("fluent-bit add-on output %s is not supported, it must be log-analytics or storage", output)
+					}
+					for _, key := range required {
+						if len(addon.Config[key]) == 0 {
+							return errors.Errorf("fluent-bit add-on requires '%s' in the Config", key)
+						}
+					}
+					for _, key := range []string{"workspaceGuid", "workspaceKey", "storageAccountKey"} {
+						if _, err := base64.StdEncoding.DecodeString(addon.Config[key]); err != nil {
+							return errors.Errorf("fluent-bit add-on '%s' must be base64 encoded", key)
+						}
+					}
+				}
+			case "node-problem-detector":
+				if to.Bool(addon.Enabled) {
+					for key, config := range addon.Config {
+						if key == "systemLogMonitors" || key == "customPluginMonitors" {
+							continue
+						}
+						if !nodeProblemDetectorCustomConfigRegex.MatchString(key) {
+							return errors.Errorf("node-problem-detector add-on custom monitor config %s must be a file name ending in .json", key)
+						}
+						if b, err := base64.StdEncoding.DecodeString(config); err != nil || !json.Valid(b) {
+							return errors.Errorf("node-problem-detector add-on custom monitor config %s must be base64 encoded JSON", key)
+						}
+					}
+					// the custom monitor configs are mounted in /custom-config of the node-problem-detector containers
+					for _, key := range []string{"systemLogMonitors", "customPluginMonitors"} {
+						for _, monitor := range strings.Split(addon.Config[key], ",") {
+							name := strings.TrimPrefix(monitor, "/custom-config/")
+							if name == monitor {
+								continue
+							}
+							if _, ok := addon.Config[name]; !ok {
+								return errors.Errorf("node-problem-detector add-on %s monitor %s has no custom monitor config %s in the Config", key, monitor, name)
+							}
+						}
+					}
+				}
+			case "csi-secrets-store":
+				if to.Bool(addon.Enabled) {
+					// the Key Vault objects are mounted as inline ephemeral CSI volumes
+					version := common.RationalizeReleaseAndVersion(
+						a.OrchestratorProfile.OrchestratorType,
+						a.OrchestratorProfile.OrchestratorRelease,
+						a.OrchestratorProfile.OrchestratorVersion,
+						false,
+						false)
+					if !common.IsKubernetesVersionGe(version, "1.16.0-alpha.1") {
+						return errors.Errorf("csi-secrets-store add-on is only available in kubernetes version %s or greater; unable to validate for version %s", "1.16.0-alpha.1", version)
+					}
+					switch value := addon.Config["enableSecretRotation"]; value {
+					case "", "true", "false":
+					default:
+						return errors.Errorf("csi-secrets-store add-on enableSecretRotation %s must be true or false", value)
+					}
+					if value, ok := addon.Config["rotationPollInterval"]; ok {
+						if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+							return errors.Errorf("csi-secrets-store add-on rotationPollInterval %s must be a positive duration, e.g. 2m", value)
+						}
+					}
+				}
+			case "cilium-daemonset":
+				if to.Bool(addon.Enabled) {
+					if a.OrchestratorProfile.KubernetesConfig.NetworkPlugin != NetworkPluginCilium {
+						return errors.Errorf("cilium-daemonset add-on requires networkPlugin %s", NetworkPluginCilium)
+					}
+					switch value := addon.Config["kube-proxy-replacement"]; value {
+					case "", "disabled":
+					case "strict":
+						// kube-proxy is not deployed, cilium implements the services with eBPF
+						if a.OrchestratorProfile.KubernetesConfig.ProxyMode == KubeProxyModeIPVS {
+							return errors.New("cilium-daemonset add-on kube-proxy-replacement strict cannot be used with kubeProxyMode ipvs, the cluster does not run kube-proxy")
+						}
+					default:
+						return errors.Errorf("cilium-daemonset add-on kube-proxy-replacement %s must be disabled or strict", value)
+					}
+				}
+			case "antrea":
+				if to.Bool(addon.Enabled) && a.OrchestratorProfile.KubernetesConfig.NetworkPlugin != NetworkPluginAntrea {
+					return errors.Errorf("antrea add-on requires networkPlugin %s", NetworkPluginAntrea)
 				}
 			}
 		}
@@ -671,6 +1129,112 @@ func (a *Properties) validateAddons() error {
 	return nil
 }
 
+// validateClusterAutoscalerAddon validates the scale down tunables and the expander of the cluster-autoscaler addon,
+// and the node bounds and priorities of the pools it scales
+func (a *Properties) validateClusterAutoscalerAddon(addon KubernetesAddon) error {
+	for _, key := range []string{"scale-down-unneeded-time", "scale-down-delay-after-add"} {
+		if value, ok := addon.Config[key]; ok {
+			if _, err := time.ParseDuration(value); err != nil {
+				return errors.Errorf("cluster-autoscaler add-on %s %s must be a duration, e.g. 10m", key, value)
+			}
+		}
+	}
+	if value, ok := addon.Config["scale-down-utilization-threshold"]; ok {
+		if threshold, err := strconv.ParseFloat(value, 64); err != nil || threshold <= 0 || threshold > 1 {
+			return errors.Errorf("cluster-autoscaler add-on scale-down-utilization-threshold %s must be a number greater than 0 and at most 1", value)
+		}
+	}
+	expander := addon.Config["expander"]
+	switch expander {
+	case "", "random", "most-pods", "least-waste", "priority":
+	default:
+		return errors.Errorf("cluster-autoscaler add-on expander %s is not supported, it must be random, most-pods, least-waste or priority", expander)
+	}
+	switch value := addon.Config["auto-discovery"]; value {
+	case "", "true", "false":
+	default:
+		return errors.Errorf("cluster-autoscaler add-on auto-discovery %s must be true or false", value)
+	}
+	// the cluster-autoscaler of each Kubernetes version has the same minor version
+	version := common.RationalizeReleaseAndVersion(
+		a.OrchestratorProfile.OrchestratorType,
+		a.OrchestratorProfile.OrchestratorRelease,
+		a.OrchestratorProfile.OrchestratorVersion,
+		false,
+		false)
+	if expander == "priority" && !common.IsKubernetesVersionGe(version, "1.14.0") {
+		return errors.Errorf("cluster-autoscaler add-on priority expander requires Kubernetes 1.14.0 or greater, the version is %s", version)
+	}
+	if addon.Config["auto-discovery"] == "true" && !common.IsKubernetesVersionGe(version, "1.15.0") {
+		return errors.Errorf("cluster-autoscaler add-on auto-discovery requires Kubernetes 1.15.0 or greater, the version is %s", version)
+	}
+	if err := validateClusterAutoscalerNodeBounds("cluster-autoscaler add-on", addon.Config, nil); err != nil {
+		return err
+	}
+
+	hasPriorities := false
+	for i, pool := range addon.Pools {
+		var profile *AgentPoolProfile
+		for _, agentPool := range a.AgentPoolProfiles {
+			if agentPool.Name == pool.Name {
+				profile = agentPool
+			}
+		}
+		if profile == nil {
+			return errors.Errorf("cluster-autoscaler add-on pool %s is not an agent pool", pool.Name)
+		}
+		if !profile.IsVirtualMachineScaleSets() {
+			return errors.Errorf("cluster-autoscaler add-on pool %s must be a %s agent pool", pool.Name, VirtualMachineScaleSets)
+		}
+		for _, other := range addon.Pools[:i] {
+			if other.Name == pool.Name {
+				return errors.Errorf("cluster-autoscaler add-on pool %s is configured more than once", pool.Name)
+			}
+		}
+		if err := validateClusterAutoscalerNodeBounds("cluster-autoscaler add-on pool "+pool.Name, pool.Config, addon.Config); err != nil {
+			return err
+		}
+		if value, ok := pool.Config["priority"]; ok {
+			if priority, err := strconv.Atoi(value); err != nil || priority < 0 {
+				return errors.Errorf("cluster-autoscaler add-on pool %s priority %s must be a non-negative integer", pool.Name, value)
+			}
+			if expander != "priority" {
+				return errors.Errorf("cluster-autoscaler add-on pool %s has a priority, which requires the priority expander", pool.Name)
+			}
+			hasPriorities = true
+		}
+	}
+	if expander == "priority" && !hasPriorities {
+		return errors.New("cluster-autoscaler add-on priority expander requires a priority for at least one of its pools")
+	}
+	return nil
+}
+
+// validateClusterAutoscalerNodeBounds validates the min-nodes and max-nodes of a config, which defaults to the ones of
+// the defaults config
+func validateClusterAutoscalerNodeBounds(name string, config, defaults map[string]string) error {
+	bounds := map[string]int{}
+	for _, key := range []string{"min-nodes", "max-nodes"} {
+		value, ok := config[key]
+		if !ok {
+			if value, ok = defaults[key]; !ok {
+				continue
+			}
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return errors.Errorf("%s %s %s must be a non-negative integer", name, key, value)
+		}
+		bounds[key] = n
+	}
+	minNodes, hasMin := bounds["min-nodes"]
+	maxNodes, hasMax := bounds["max-nodes"]
+	if hasMin && hasMax && minNodes > maxNodes {
+		return errors.Errorf("%s min-nodes %d must not be greater than max-nodes %d", name, minNodes, maxNodes)
+	}
+	return nil
+}
+
 func (a *Properties) validateExtensions() error {
 	for _, agentPool := range a.AgentPoolProfiles {
 		if len(agentPool.Extensions) != 0 && (len(agentPool.AvailabilityProfile) == 0 || agentPool.IsVirtualMachineScaleSets()) {
@@ -686,6 +1250,9 @@ func (a *Properties) validateExtensions() error {
 		}
 	}
 
+	if len(a.ExtensionProfiles) > 0 {
+		log.Warnf("extensionProfiles are deprecated and will be removed in a future version of aks-engine. Declare the Azure VM extensions of the master and of each agent pool in their vmExtensions instead.")
+	}
 	for _, extension := range a.ExtensionProfiles {
 		if extension.ExtensionParametersKeyVaultRef != nil {
 			if e := validate.Var(extension.ExtensionParametersKeyVaultRef.VaultID, "required"); e != nil {
@@ -702,6 +1269,68 @@ func (a *Properties) validateExtensions() error {
 	return nil
 }
 
+func (a *Properties) validateVMExtensions() error {
+	if a.MasterProfile != nil && len(a.MasterProfile.VMExtensions) > 0 {
+		if a.OrchestratorProfile == nil || a.OrchestratorProfile.OrchestratorType != Kubernetes {
+			return errors.Errorf("vmExtensions are only supported with the %s orchestrator", Kubernetes)
+		}
+		if e := validateVMExtensionList("masterProfile", Linux, a.MasterProfile.VMExtensions); e != nil {
+			return e
+		}
+	}
+	for _, agentPool := range a.AgentPoolProfiles {
+		if len(agentPool.VMExtensions) == 0 {
+			continue
+		}
+		if a.OrchestratorProfile == nil || a.OrchestratorProfile.OrchestratorType != Kubernetes {
+			return errors.Errorf("vmExtensions are only supported with the %s orchestrator", Kubernetes)
+		}
+		osType := agentPool.OSType
+		if osType == "" {
+			osType = Linux
+		}
+		if e := validateVMExtensionList(fmt.Sprintf("agent pool %s", agentPool.Name), osType, agentPool.VMExtensions); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// validateVMExtensionList validates the vmExtensions of a profile. Azure installs a single extension of a given publisher and type
+// on a VM, so they must not repeat within the profile nor be the custom script extension aks-engine bootstraps the VMs with.
+func validateVMExtensionList(profile string, osType OSType, extensions []VMExtension) error {
+	cseHandler := "Microsoft.Azure.Extensions.CustomScript"
+	if osType == Windows {
+		cseHandler = "Microsoft.Compute.CustomScriptExtension"
+	}
+	names := map[string]bool{}
+	handlers := map[string]bool{}
+	for _, e := range extensions {
+		if e.Name == "" {
+			return errors.Errorf("the vmExtensions of %s must have a name", profile)
+		}
+		if !vmExtensionRegex.MatchString(e.Name) {
+			return errors.Errorf("vmExtension name %s of %s is invalid, it must match %s", e.Name, profile, vmExtensionNameFormat)
+		}
+		if names[strings.ToLower(e.Name)] {
+			return errors.Errorf("vmExtension %s is declared more than once in %s", e.Name, profile)
+		}
+		names[strings.ToLower(e.Name)] = true
+		if e.Publisher == "" || e.Type == "" || e.TypeHandlerVersion == "" {
+			return errors.Errorf("vmExtension %s of %s must have a publisher, a type and a typeHandlerVersion", e.Name, profile)
+		}
+		handler := strings.ToLower(e.Publisher + "." + e.Type)
+		if handler == strings.ToLower(cseHandler) {
+			return errors.Errorf("vmExtension %s of %s is a %s extension, which aks-engine uses to provision the VMs of %s", e.Name, profile, cseHandler, profile)
+		}
+		if handlers[handler] {
+			return errors.Errorf("vmExtension %s of %s has the same publisher and type %s.%s as another vmExtension of %s, a VM can only have one of them", e.Name, profile, e.Publisher, e.Type, profile)
+		}
+		handlers[handler] = true
+	}
+	return nil
+}
+
 func (a *Properties) validateVNET() error {
 	isCustomVNET := a.MasterProfile.IsCustomVNET()
 	for _, agentPool := range a.AgentPoolProfiles {
@@ -746,6 +1375,342 @@ func (a *Properties) validateVNET() error {
 	return nil
 }
 
+// validateEtcdSubnet validates the dedicated etcd subnet of the masters: each master gets a second NIC with a static IP
+// on it, starting at etcdFirstConsecutiveStaticIP, and etcd peer and client traffic is bound to those IPs
+func (a *Properties) validateEtcdSubnet() error {
+	m := a.MasterProfile
+	if m == nil {
+		return nil
+	}
+	if !m.HasDedicatedEtcdSubnet() {
+		if m.EtcdVnetSubnetID != "" || m.EtcdFirstConsecutiveStaticIP != "" {
+			return errors.New("masterProfile.etcdVnetSubnetID and masterProfile.etcdFirstConsecutiveStaticIP can only be set together with masterProfile.etcdSubnet")
+		}
+		return nil
+	}
+	if a.OrchestratorProfile.OrchestratorType != Kubernetes {
+		return errors.Errorf("masterProfile.etcdSubnet is only supported with the %s orchestrator", Kubernetes)
+	}
+	if m.IsVirtualMachineScaleSets() {
+		return errors.New("masterProfile.etcdSubnet is not supported with VirtualMachineScaleSets masters")
+	}
+	if to.Bool(m.CosmosEtcd) {
+		return errors.New("masterProfile.etcdSubnet cannot be used with cosmosEtcd, etcd does not run on the masters")
+	}
+
+	_, etcdSubnet, err := net.ParseCIDR(m.EtcdSubnet)
+	if err != nil {
+		return errors.Errorf("masterProfile.etcdSubnet '%s' contains invalid cidr notation", m.EtcdSubnet)
+	}
+	if m.EtcdFirstConsecutiveStaticIP != "" {
+		firstIP := net.ParseIP(m.EtcdFirstConsecutiveStaticIP).To4()
+		if firstIP == nil {
+			return errors.Errorf("masterProfile.etcdFirstConsecutiveStaticIP '%s' is an invalid IP address", m.EtcdFirstConsecutiveStaticIP)
+		}
+		lastIP := make(net.IP, len(firstIP))
+		copy(lastIP, firstIP)
+		lastIP[3] += byte(m.Count - 1)
+		if !etcdSubnet.Contains(firstIP) || !etcdSubnet.Contains(lastIP) || lastIP[3] < firstIP[3] {
+			return errors.Errorf("masterProfile.etcdSubnet '%s' must contain the %d consecutive IP addresses starting at masterProfile.etcdFirstConsecutiveStaticIP '%s'", m.EtcdSubnet, m.Count, m.EtcdFirstConsecutiveStaticIP)
+		}
+	}
+
+	if m.IsCustomVNET() {
+		if m.EtcdVnetSubnetID == "" {
+			return errors.New("masterProfile.etcdVnetSubnetID must be set to the subnet matching masterProfile.etcdSubnet when masterProfile.vnetSubnetID is set")
+		}
+		if strings.EqualFold(m.EtcdVnetSubnetID, m.VnetSubnetID) {
+			return errors.New("masterProfile.etcdVnetSubnetID must be a different subnet than masterProfile.vnetSubnetID")
+		}
+		subscription, resourceGroup, vnetName, _, err := common.GetVNETSubnetIDComponents(m.VnetSubnetID)
+		if err != nil {
+			return err
+		}
+		etcdSubscription, etcdResourceGroup, etcdVnetName, _, err := common.GetVNETSubnetIDComponents(m.EtcdVnetSubnetID)
+		if err != nil {
+			return err
+		}
+		if etcdSubscription != subscription || etcdResourceGroup != resourceGroup || etcdVnetName != vnetName {
+			return errors.New("masterProfile.etcdVnetSubnetID must reference a subnet of the VNET of masterProfile.vnetSubnetID")
+		}
+	} else if m.EtcdVnetSubnetID != "" {
+		return errors.New("masterProfile.etcdVnetSubnetID can only be set when masterProfile.vnetSubnetID is set")
+	}
+
+	etcdCIDRs := []common.NamedCIDR{{Name: "MasterProfile.EtcdSubnet", CIDR: m.EtcdSubnet}}
+	var otherCIDRs []common.NamedCIDR
+	if k := a.OrchestratorProfile.KubernetesConfig; k != nil {
+		if k.ClusterSubnet != "" {
+			for _, clusterSubnet := range strings.Split(k.ClusterSubnet, ",") {
+				otherCIDRs = append(otherCIDRs, common.NamedCIDR{Name: "OrchestratorProfile.KubernetesConfig.ClusterSubnet", CIDR: clusterSubnet})
+			}
+		}
+		if k.ServiceCidr != "" {
+			otherCIDRs = append(otherCIDRs, common.NamedCIDR{Name: "OrchestratorProfile.KubernetesConfig.ServiceCidr", CIDR: k.ServiceCidr})
+		}
+		if k.DockerBridgeSubnet != "" {
+			otherCIDRs = append(otherCIDRs, common.NamedCIDR{Name: "OrchestratorProfile.KubernetesConfig.DockerBridgeSubnet", CIDR: k.DockerBridgeSubnet})
+		}
+	}
+	if !m.IsCustomVNET() {
+		// the masters and agents of a VNET created by aks-engine share one subnet, the cluster subnet with Azure CNI
+		k := a.OrchestratorProfile.KubernetesConfig
+		if k == nil || k.NetworkPlugin == "" || k.NetworkPlugin == DefaultNetworkPlugin {
+			if k == nil || k.ClusterSubnet == "" {
+				otherCIDRs = append(otherCIDRs, common.NamedCIDR{Name: "the master subnet", CIDR: defaultKubernetesSubnetAzureCNI})
+			}
+		} else {
+			otherCIDRs = append(otherCIDRs, common.NamedCIDR{Name: "the master subnet", CIDR: defaultKubernetesMasterSubnet})
+		}
+	}
+	overlaps, err := common.GetCIDROverlaps(etcdCIDRs, otherCIDRs)
+	if err != nil {
+		return err
+	}
+	if len(overlaps) > 0 {
+		return errors.Errorf("masterProfile.etcdSubnet must not overlap other networking CIDR ranges: %s", strings.Join(overlaps, "; "))
+	}
+	return nil
+}
+
+// validatePodSubnets validates the pod subnets of the agent pools: each node of a pool with a podSubnetID gets a second NIC
+// in that subnet which holds the Azure CNI IPs of its pods, so that the node subnet is not sized by the max pods
+func (a *Properties) validatePodSubnets() error {
+	for _, agentPool := range a.AgentPoolProfiles {
+		if !agentPool.HasPodSubnet() {
+			continue
+		}
+		if a.OrchestratorProfile.OrchestratorType != Kubernetes {
+			return errors.Errorf("agentPoolProfile.podSubnetID is only supported with the %s orchestrator", Kubernetes)
+		}
+		k := a.OrchestratorProfile.KubernetesConfig
+		isAzureCNI := k == nil || k.NetworkPlugin == "azure" ||
+			(k.NetworkPlugin == "" && (k.NetworkPolicy == "" || k.NetworkPolicy == "azure"))
+		if !isAzureCNI {
+			return errors.Errorf("agentPoolProfile %s podSubnetID requires networkPlugin azure", agentPool.Name)
+		}
+		if agentPool.IsWindows() {
+			return errors.Errorf("agentPoolProfile %s podSubnetID is not supported with Windows agent pools", agentPool.Name)
+		}
+		if !agentPool.IsCustomVNET() {
+			return errors.Errorf("agentPoolProfile %s podSubnetID can only be set when vnetSubnetID is set", agentPool.Name)
+		}
+		if strings.EqualFold(agentPool.PodSubnetID, agentPool.VnetSubnetID) {
+			return errors.Errorf("agentPoolProfile %s podSubnetID must be a different subnet than vnetSubnetID", agentPool.Name)
+		}
+		subscription, resourceGroup, vnetName, _, err := common.GetVNETSubnetIDComponents(agentPool.VnetSubnetID)
+		if err != nil {
+			return err
+		}
+		podSubscription, podResourceGroup, podVnetName, _, err := common.GetVNETSubnetIDComponents(agentPool.PodSubnetID)
+		if err != nil {
+			return err
+		}
+		if podSubscription != subscription || podResourceGroup != resourceGroup || podVnetName != vnetName {
+			return errors.Errorf("agentPoolProfile %s podSubnetID must reference a subnet of the VNET of vnetSubnetID", agentPool.Name)
+		}
+	}
+	return nil
+}
+
+// validateNetworkCIDROverlaps ensures that the cluster, service and docker bridge address ranges, the node-local DNS IP
+// and the subnets of the masters and agent pools do not overlap
+func (a *Properties) validateNetworkCIDROverlaps() error {
+	o := a.OrchestratorProfile
+	if o == nil || o.OrchestratorType != Kubernetes || o.KubernetesConfig == nil {
+		return nil
+	}
+	k := o.KubernetesConfig
+
+	var clusterCIDRs, serviceCIDRs, bridgeCIDRs, subnetCIDRs, nodeDNSCIDRs []common.NamedCIDR
+	if k.ClusterSubnet != "" {
+		for _, clusterSubnet := range strings.Split(k.ClusterSubnet, ",") {
+			clusterCIDRs = append(clusterCIDRs, common.NamedCIDR{Name: "OrchestratorProfile.KubernetesConfig.ClusterSubnet", CIDR: clusterSubnet})
+		}
+	}
+	if k.ServiceCidr != "" {
+		serviceCIDRs = append(serviceCIDRs, common.NamedCIDR{Name: "OrchestratorProfile.KubernetesConfig.ServiceCidr", CIDR: k.ServiceCidr})
+	}
+	if k.DockerBridgeSubnet != "" {
+		bridgeCIDRs = append(bridgeCIDRs, common.NamedCIDR{Name: "OrchestratorProfile.KubernetesConfig.DockerBridgeSubnet", CIDR: k.DockerBridgeSubnet})
+	}
+	// The subnets are only known once the defaults are set, e.g. when validating the api model of an existing cluster
+	if m := a.MasterProfile; m != nil {
+		for _, subnet := range []common.NamedCIDR{
+			{Name: "MasterProfile.Subnet", CIDR: m.GetSubnet()},
+			{Name: "MasterProfile.AgentSubnet", CIDR: m.AgentSubnet},
+			{Name: "MasterProfile.EtcdSubnet", CIDR: m.EtcdSubnet},
+		} {
+			if subnet.CIDR != "" {
+				subnetCIDRs = append(subnetCIDRs, subnet)
+			}
+		}
+	}
+	for _, pool := range a.AgentPoolProfiles {
+		if pool.GetSubnet() != "" {
+			subnetCIDRs = append(subnetCIDRs, common.NamedCIDR{Name: fmt.Sprintf("AgentPoolProfiles[%s].Subnet", pool.Name), CIDR: pool.GetSubnet()})
+		}
+	}
+
+	// A --cluster-dns other than the DNS service IP is the node-local DNS cache the kubelet points the pods at
+	type kubeletConfig struct {
+		name   string
+		config map[string]string
+	}
+	kubeletConfigs := []kubeletConfig{{"OrchestratorProfile.KubernetesConfig.KubeletConfig", k.KubeletConfig}}
+	if a.MasterProfile != nil && a.MasterProfile.KubernetesConfig != nil {
+		kubeletConfigs = append(kubeletConfigs, kubeletConfig{"MasterProfile.KubernetesConfig.KubeletConfig", a.MasterProfile.KubernetesConfig.KubeletConfig})
+	}
+	for _, pool := range a.AgentPoolProfiles {
+		if pool.KubernetesConfig != nil {
+			kubeletConfigs = append(kubeletConfigs, kubeletConfig{fmt.Sprintf("AgentPoolProfiles[%s].KubernetesConfig.KubeletConfig", pool.Name), pool.KubernetesConfig.KubeletConfig})
+		}
+	}
+	for _, kc := range kubeletConfigs {
+		clusterDNS := kc.config["--cluster-dns"]
+		if clusterDNS == "" || clusterDNS == k.DNSServiceIP {
+			continue
+		}
+		ip := net.ParseIP(clusterDNS)
+		if ip == nil {
+			return errors.Errorf("%s['--cluster-dns'] '%s' is not a valid IP address", kc.name, clusterDNS)
+		}
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+		nodeDNSCIDRs = append(nodeDNSCIDRs, common.NamedCIDR{Name: fmt.Sprintf("%s['--cluster-dns']", kc.name), CIDR: fmt.Sprintf("%s/%d", clusterDNS, bits)})
+	}
+
+	pairs := [][2][]common.NamedCIDR{
+		{serviceCIDRs, clusterCIDRs},
+		{serviceCIDRs, bridgeCIDRs},
+		{serviceCIDRs, subnetCIDRs},
+		{clusterCIDRs, bridgeCIDRs},
+		{bridgeCIDRs, subnetCIDRs},
+		{nodeDNSCIDRs, clusterCIDRs},
+		{nodeDNSCIDRs, bridgeCIDRs},
+		{nodeDNSCIDRs, subnetCIDRs},
+	}
+	// With Azure CNI pods are allocated IPs from the subnets of the nodes, so the ClusterSubnet is expected to contain them
+	if k.getNetworkPlugin() != "azure" {
+		pairs = append(pairs, [2][]common.NamedCIDR{clusterCIDRs, subnetCIDRs})
+	}
+
+	var overlaps []string
+	for _, pair := range pairs {
+		o, err := common.GetCIDROverlaps(pair[0], pair[1])
+		if err != nil {
+			return err
+		}
+		overlaps = append(overlaps, o...)
+	}
+	if len(overlaps) > 0 {
+		return errors.Errorf("networking CIDR ranges must not overlap: %s", strings.Join(overlaps, "; "))
+	}
+	return nil
+}
+
+// getNetworkPlugin returns the network plugin the cluster runs once the api model is converted and its defaults are
+// set, which the deprecated networkPolicy values choose when they predate networkPlugin
+func (k *KubernetesConfig) getNetworkPlugin() string {
+	switch k.NetworkPolicy {
+	case "none":
+		return "kubenet"
+	case NetworkPolicyCilium:
+		return NetworkPluginCilium
+	case NetworkPolicyAntrea:
+		return NetworkPluginAntrea
+	}
+	if k.NetworkPlugin == "" {
+		return DefaultNetworkPlugin
+	}
+	return k.NetworkPlugin
+}
+
+// validateNodeDNS validates the VNET DNS servers and the DNS configuration of the master and agent pools
+func (a *Properties) validateNodeDNS() error {
+	var serviceCIDR *net.IPNet
+	var dnsServiceIP net.IP
+	isPrivateCluster := false
+	if a.OrchestratorProfile != nil && a.OrchestratorProfile.KubernetesConfig != nil {
+		k := a.OrchestratorProfile.KubernetesConfig
+		if k.ServiceCidr != "" {
+			_, serviceCIDR, _ = net.ParseCIDR(k.ServiceCidr)
+		}
+		dnsServiceIP = net.ParseIP(k.DNSServiceIP)
+		isPrivateCluster = k.PrivateCluster != nil && to.Bool(k.PrivateCluster.Enabled)
+	}
+
+	hasCustomDNSServers := false
+	if a.MasterProfile != nil {
+		if len(a.MasterProfile.VnetDNSServers) > 0 {
+			if a.MasterProfile.IsCustomVNET() {
+				return errors.New("masterProfile.vnetDnsServers is not supported with a custom VNET, configure the DNS servers on the VNET instead")
+			}
+			if e := validateDNSServers(a.MasterProfile.VnetDNSServers, "masterProfile.vnetDnsServers", serviceCIDR, dnsServiceIP); e != nil {
+				return e
+			}
+			hasCustomDNSServers = true
+		}
+		if e := validateNodeDNSConfig(a.MasterProfile.DNSConfig, "masterProfile.dnsConfig", serviceCIDR, dnsServiceIP); e != nil {
+			return e
+		}
+		hasCustomDNSServers = hasCustomDNSServers || (a.MasterProfile.DNSConfig != nil && len(a.MasterProfile.DNSConfig.DNSServers) > 0)
+	}
+	for _, agentPool := range a.AgentPoolProfiles {
+		if agentPool.DNSConfig == nil {
+			continue
+		}
+		if agentPool.IsWindows() && len(agentPool.DNSConfig.SearchDomains) > 0 {
+			return errors.Errorf("dnsConfig.searchDomains is not supported in Windows agent pool %s", agentPool.Name)
+		}
+		if e := validateNodeDNSConfig(agentPool.DNSConfig, fmt.Sprintf("agentPoolProfiles[%s].dnsConfig", agentPool.Name), serviceCIDR, dnsServiceIP); e != nil {
+			return e
+		}
+		hasCustomDNSServers = hasCustomDNSServers || len(agentPool.DNSConfig.DNSServers) > 0
+	}
+
+	if hasCustomDNSServers && isPrivateCluster {
+		log.Warnf("Custom DNS servers are configured for a private cluster. The DNS servers must forward unresolved queries to Azure DNS (168.63.129.16) so that the nodes can resolve each other and the Azure service endpoints.")
+	}
+	return nil
+}
+
+func validateNodeDNSConfig(dnsConfig *NodeDNSConfig, path string, serviceCIDR *net.IPNet, dnsServiceIP net.IP) error {
+	if dnsConfig == nil {
+		return nil
+	}
+	if e := validateDNSServers(dnsConfig.DNSServers, path+".dnsServers", serviceCIDR, dnsServiceIP); e != nil {
+		return e
+	}
+	if len(dnsConfig.SearchDomains) > maxNodeSearchDomains {
+		return errors.Errorf("%s.searchDomains has %d entries, the maximum is %d", path, len(dnsConfig.SearchDomains), maxNodeSearchDomains)
+	}
+	for _, searchDomain := range dnsConfig.SearchDomains {
+		if len(searchDomain) > 253 || !searchDomainRegex.MatchString(searchDomain) {
+			return errors.Errorf("%s.searchDomains entry '%s' is not a valid DNS domain name", path, searchDomain)
+		}
+	}
+	return nil
+}
+
+func validateDNSServers(dnsServers []string, path string, serviceCIDR *net.IPNet, dnsServiceIP net.IP) error {
+	for _, dnsServer := range dnsServers {
+		ip := net.ParseIP(dnsServer)
+		if ip == nil {
+			return errors.Errorf("%s entry '%s' is not a valid IP address", path, dnsServer)
+		}
+		if dnsServiceIP != nil && ip.Equal(dnsServiceIP) {
+			return errors.Errorf("%s entry '%s' must not be the cluster DNS service IP, nodes cannot resolve names through a cluster service", path, dnsServer)
+		}
+		if serviceCIDR != nil && serviceCIDR.Contains(ip) {
+			return errors.Errorf("%s entry '%s' must not be within the service CIDR %s", path, dnsServer, serviceCIDR.String())
+		}
+	}
+	return nil
+}
+
 func (a *Properties) validateServicePrincipalProfile() error {
 	if a.OrchestratorProfile.OrchestratorType == Kubernetes {
 		useManagedIdentity := a.OrchestratorProfile.KubernetesConfig != nil &&
@@ -820,6 +1785,40 @@ func (a *Properties) validateManagedIdentity() error {
 	return nil
 }
 
+// validateAgentPoolUserAssignedIDs validates the user assigned identities of the agent pools. The cluster identity is
+// granted to operate them, as it updates the VMs they are assigned to, so it cannot be system assigned.
+func (a *Properties) validateAgentPoolUserAssignedIDs() error {
+	k := a.OrchestratorProfile.KubernetesConfig
+	clusterHasUserAssignedID := k != nil && k.UseManagedIdentity && k.UserAssignedID != ""
+	servicePrincipalHasObjectID := (k == nil || !k.UseManagedIdentity) && a.ServicePrincipalProfile != nil && a.ServicePrincipalProfile.ObjectID != ""
+
+	// identity names are case insensitive
+	names := map[string]bool{}
+	if clusterHasUserAssignedID {
+		names[strings.ToLower(k.UserAssignedID)] = true
+	}
+	for _, agentPoolProfile := range a.AgentPoolProfiles {
+		id := agentPoolProfile.UserAssignedID
+		if id == "" {
+			continue
+		}
+		if a.IsAzureStackCloud() {
+			return errors.Errorf("agent pool %s has a userAssignedID, user assigned identities are not supported on Azure Stack", agentPoolProfile.Name)
+		}
+		if !userAssignedIDNameRegex.MatchString(id) {
+			return errors.Errorf("the userAssignedID of agent pool %s is %s, expected the name of a user assigned identity of 3 to 128 letters, digits, dashes and underscores", agentPoolProfile.Name, id)
+		}
+		if names[strings.ToLower(id)] {
+			return errors.Errorf("the userAssignedID %s of agent pool %s is already the identity of the cluster or of another agent pool", id, agentPoolProfile.Name)
+		}
+		names[strings.ToLower(id)] = true
+		if !clusterHasUserAssignedID && !servicePrincipalHasObjectID {
+			return errors.Errorf("agent pool %s has a userAssignedID, which requires the cluster to have a userAssignedID, or a servicePrincipalProfile with an objectId", agentPoolProfile.Name)
+		}
+	}
+	return nil
+}
+
 func (a *Properties) validateAADProfile() error {
 	if profile := a.AADProfile; profile != nil {
 		if a.OrchestratorProfile.OrchestratorType != Kubernetes {
@@ -836,10 +1835,58 @@ func (a *Properties) validateAADProfile() error {
 				return errors.Errorf("tenantID '%v' is invalid", profile.TenantID)
 			}
 		}
-		if len(profile.AdminGroupID) > 0 {
-			if _, err := uuid.FromString(profile.AdminGroupID); err != nil {
-				return errors.Errorf("adminGroupID '%v' is invalid", profile.AdminGroupID)
-			}
+		if len(profile.AdminGroupID) > 0 {
+			if _, err := uuid.FromString(profile.AdminGroupID); err != nil {
+				return errors.Errorf("adminGroupID '%v' is invalid", profile.AdminGroupID)
+			}
+		}
+	}
+	return nil
+}
+
+// validateAirGapProfile validates the mirrors of an air-gapped cluster and rejects the features that install from public endpoints
+func (a *Properties) validateAirGapProfile() error {
+	if a.OrchestratorProfile == nil || a.OrchestratorProfile.KubernetesConfig == nil {
+		return nil
+	}
+	k := a.OrchestratorProfile.KubernetesConfig
+	if k.AirGapProfile == nil || !to.Bool(k.AirGapProfile.Enabled) {
+		return nil
+	}
+	mirrors := []struct{ name, url string }{
+		{"binariesURL", k.AirGapProfile.BinariesURL},
+		{"packagesURL", k.AirGapProfile.PackagesURL},
+	}
+	for _, mirror := range mirrors {
+		name := mirror.name
+		if mirror.url == "" {
+			return errors.Errorf("airGapProfile %s is required when airGapProfile is enabled", name)
+		}
+		if u, err := url.Parse(mirror.url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Errorf("airGapProfile %s %s must be an http or https URL", name, mirror.url)
+		}
+	}
+	if k.ImageRegistry == "" {
+		return errors.New("airGapProfile requires an imageRegistry the nodes pull the container images from")
+	}
+	if k.ContainerRuntime == KataContainers {
+		return errors.Errorf("airGapProfile is not supported with the %s containerRuntime", KataContainers)
+	}
+	if k.PrivateCluster != nil && k.PrivateCluster.JumpboxProfile != nil {
+		return errors.New("airGapProfile is not supported with a privateCluster jumpboxProfile")
+	}
+	if a.MasterProfile != nil && (a.MasterProfile.IsRHEL() || a.MasterProfile.IsCoreOS()) {
+		return errors.Errorf("airGapProfile only supports Ubuntu distros, masterProfile has distro %s", a.MasterProfile.Distro)
+	}
+	for _, agentPool := range a.AgentPoolProfiles {
+		if agentPool.IsWindows() {
+			return errors.Errorf("airGapProfile is not supported with Windows agent pools, agent pool %s is Windows", agentPool.Name)
+		}
+		if agentPool.IsRHEL() || agentPool.IsCoreOS() {
+			return errors.Errorf("airGapProfile only supports Ubuntu distros, agent pool %s has distro %s", agentPool.Name, agentPool.Distro)
+		}
+		if agentPool.IsNSeriesSKU() || common.IsSgxEnabledSKU(agentPool.VMSize) {
+			return errors.Errorf("airGapProfile is not supported with GPU or SGX VM sizes, agent pool %s has vmSize %s", agentPool.Name, agentPool.VMSize)
 		}
 	}
 	return nil
@@ -859,6 +1906,188 @@ func (a *AgentPoolProfile) validateAvailabilityProfile() error {
 	return nil
 }
 
+func (a *AgentPoolProfile) validateScheduledEventsProfile(isAzureStack bool) error {
+	if a.ScheduledEventsProfile == nil {
+		return nil
+	}
+	timeout := a.ScheduledEventsProfile.TerminateNotificationTimeoutMinutes
+	if to.Bool(a.ScheduledEventsProfile.Enabled) && a.IsWindows() {
+		return errors.Errorf("agent pool %s has the scheduled events handler enabled, which is not supported on Windows", a.Name)
+	}
+	if timeout == 0 {
+		return nil
+	}
+	if !to.Bool(a.ScheduledEventsProfile.Enabled) {
+		return errors.Errorf("agent pool %s has a terminate notification timeout, but the scheduled events handler is not enabled", a.Name)
+	}
+	if a.AvailabilityProfile == AvailabilitySet {
+		return errors.Errorf("agent pool %s has a terminate notification timeout, which is only supported by %s", a.Name, VirtualMachineScaleSets)
+	}
+	if isAzureStack {
+		return errors.Errorf("agent pool %s has a terminate notification timeout, which is not supported on Azure Stack", a.Name)
+	}
+	if timeout < 5 || timeout > 15 {
+		return errors.Errorf("the terminate notification timeout of agent pool %s is %d minutes, it must be between 5 and 15 minutes", a.Name, timeout)
+	}
+	return nil
+}
+
+func (a *AgentPoolProfile) validateSpotScaleSet(isAzureStack bool) error {
+	if a.ScaleSetPriority != ScaleSetPrioritySpot {
+		if a.SpotMaxPrice != nil {
+			return errors.Errorf("agent pool %s has a spot max price, but its scale set priority is not %s", a.Name, ScaleSetPrioritySpot)
+		}
+		return nil
+	}
+	if a.AvailabilityProfile == AvailabilitySet {
+		return errors.Errorf("agent pool %s has the %s scale set priority, which is only supported by %s", a.Name, ScaleSetPrioritySpot, VirtualMachineScaleSets)
+	}
+	if isAzureStack {
+		return errors.Errorf("agent pool %s has the %s scale set priority, which is not supported on Azure Stack", a.Name, ScaleSetPrioritySpot)
+	}
+	if a.SpotMaxPrice != nil && *a.SpotMaxPrice != -1 && *a.SpotMaxPrice <= 0 {
+		return errors.Errorf("the spot max price of agent pool %s is %g, it must be -1 or greater than 0", a.Name, *a.SpotMaxPrice)
+	}
+	return nil
+}
+
+func (a *AgentPoolProfile) validateHostGroup(isAzureStack bool) error {
+	if a.PlatformFaultDomainCount != nil && (*a.PlatformFaultDomainCount < 1 || *a.PlatformFaultDomainCount > 3) {
+		return errors.Errorf("the platformFaultDomainCount of agent pool %s is %d, it must be between 1 and 3", a.Name, *a.PlatformFaultDomainCount)
+	}
+	if a.HostGroupID == "" {
+		return nil
+	}
+	if !hostGroupIDRegex.MatchString(a.HostGroupID) {
+		return errors.Errorf("the hostGroupID of agent pool %s is %s, expected an ID of the form /subscriptions/<subscription>/resourceGroups/<group>/providers/Microsoft.Compute/hostGroups/<name>", a.Name, a.HostGroupID)
+	}
+	if isAzureStack {
+		return errors.Errorf("agent pool %s has a hostGroupID, dedicated hosts are not supported on Azure Stack", a.Name)
+	}
+	if a.AvailabilityProfile == AvailabilitySet {
+		return errors.Errorf("agent pool %s has a hostGroupID, which is only supported by %s", a.Name, VirtualMachineScaleSets)
+	}
+	if a.ScaleSetPriority == ScaleSetPriorityLow || a.ScaleSetPriority == ScaleSetPrioritySpot {
+		return errors.Errorf("agent pool %s has a hostGroupID and the %s scale set priority, the VMs on dedicated hosts cannot be evicted", a.Name, a.ScaleSetPriority)
+	}
+	if len(a.AvailabilityZones) > 1 {
+		return errors.Errorf("agent pool %s has a hostGroupID and %d availability zones, a host group is in at most one zone", a.Name, len(a.AvailabilityZones))
+	}
+	if !dedicatedHostVMSizeRegex.MatchString(a.VMSize) {
+		return errors.Errorf("agent pool %s has a hostGroupID, but its VM size %s is not offered by the dedicated host SKUs", a.Name, a.VMSize)
+	}
+	return nil
+}
+
+// validateProximityPlacementGroup validates the proximity placement group of the masters or of an agent pool, which
+// co-locates their VMs in a single datacenter and so cannot span availability zones
+func validateProximityPlacementGroup(name, id string, create *bool, zones []string, isAzureStack bool) error {
+	if id == "" && !to.Bool(create) {
+		return nil
+	}
+	if id != "" && to.Bool(create) {
+		return errors.Errorf("%s has both a proximityPlacementGroupID and createProximityPlacementGroup, only one of them may be set", name)
+	}
+	if isAzureStack {
+		return errors.Errorf("%s has a proximity placement group, which is not supported on Azure Stack", name)
+	}
+	if id != "" && !ppgIDRegex.MatchString(id) {
+		return errors.Errorf("the proximityPlacementGroupID of %s is %s, expected an ID of the form /subscriptions/<subscription>/resourceGroups/<group>/providers/Microsoft.Compute/proximityPlacementGroups/<name>", name, id)
+	}
+	if len(zones) > 1 {
+		return errors.Errorf("%s has a proximity placement group and %d availability zones, a proximity placement group requires at most one zone", name, len(zones))
+	}
+	return nil
+}
+
+// validateMasterDataDisks validates the options of the etcd data disks of the masters
+func (a *Properties) validateMasterDataDisks() error {
+	m := a.MasterProfile
+	if m.DataDiskStorageAccountType == "" && m.DataDiskIOPSReadWrite == 0 && m.DataDiskMBpsReadWrite == 0 {
+		return nil
+	}
+	if to.Bool(m.CosmosEtcd) {
+		return errors.New("masterProfile has data disk options, but masters with cosmosEtcd have no etcd data disk")
+	}
+	var diskSizesGB []int
+	if k := a.OrchestratorProfile.KubernetesConfig; k != nil && k.EtcdDiskSizeGB != "" {
+		if size, err := strconv.Atoi(k.EtcdDiskSizeGB); err == nil {
+			diskSizesGB = append(diskSizesGB, size)
+		}
+	}
+	return validateDataDisks("masterProfile", m.DataDiskStorageAccountType, m.DataDiskIOPSReadWrite, m.DataDiskMBpsReadWrite, diskSizesGB, m.AvailabilityZones, m.IsStorageAccount(), a.IsAzureStackCloud())
+}
+
+func (a *AgentPoolProfile) validateDataDisks(isAzureStack bool) error {
+	if !a.HasDisks() {
+		if a.DataDiskStorageAccountType != "" || a.DataDiskIOPSReadWrite != 0 || a.DataDiskMBpsReadWrite != 0 || to.Bool(a.EnableSharedDisk) {
+			return errors.Errorf("agent pool %s has data disk options, but no diskSizesGB", a.Name)
+		}
+		return nil
+	}
+	if e := validateDataDisks(fmt.Sprintf("agent pool %s", a.Name), a.DataDiskStorageAccountType, a.DataDiskIOPSReadWrite, a.DataDiskMBpsReadWrite, a.DiskSizesGB, a.AvailabilityZones, a.IsStorageAccount(), isAzureStack); e != nil {
+		return e
+	}
+	if !to.Bool(a.EnableSharedDisk) {
+		return nil
+	}
+	if isAzureStack {
+		return errors.Errorf("agent pool %s has enableSharedDisk, shared disks are not supported on Azure Stack", a.Name)
+	}
+	if a.AvailabilityProfile != AvailabilitySet {
+		return errors.Errorf("agent pool %s has enableSharedDisk, which is only supported by %s", a.Name, AvailabilitySet)
+	}
+	if a.DataDiskStorageAccountType != PremiumSSDDataDisk {
+		return errors.Errorf("agent pool %s has enableSharedDisk, which requires dataDiskStorageAccountType %s", a.Name, PremiumSSDDataDisk)
+	}
+	for _, size := range a.DiskSizesGB {
+		if size < common.MinSharedDiskSizeGB {
+			return errors.Errorf("agent pool %s has a shared data disk of %d GiB, shared data disks must be at least %d GiB", a.Name, size, common.MinSharedDiskSizeGB)
+		}
+		if maxShares := common.GetSharedDiskMaxShares(size); a.Count > maxShares {
+			return errors.Errorf("agent pool %s has %d VMs, but its shared data disk of %d GiB can be attached to at most %d VMs", a.Name, a.Count, size, maxShares)
+		}
+	}
+	return nil
+}
+
+// validateDataDisks validates the storage account type, IOPS and throughput of the data disks of the masters or of an
+// agent pool. Ultra SSDs are only offered in availability zones, and their IOPS are limited by their size.
+func validateDataDisks(name, storageAccountType string, iops, mbps int, diskSizesGB []int, zones []string, isStorageAccount, isAzureStack bool) error {
+	if storageAccountType == "" && iops == 0 && mbps == 0 {
+		return nil
+	}
+	if isStorageAccount {
+		return errors.Errorf("%s has data disk options, which require storageProfile %s", name, ManagedDisks)
+	}
+	if storageAccountType != UltraSSDDataDisk {
+		if iops != 0 || mbps != 0 {
+			return errors.Errorf("%s has a dataDiskIOPSReadWrite or dataDiskMBpsReadWrite, which are only supported by %s data disks", name, UltraSSDDataDisk)
+		}
+		return nil
+	}
+	if isAzureStack {
+		return errors.Errorf("%s has %s data disks, which are not supported on Azure Stack", name, UltraSSDDataDisk)
+	}
+	if len(zones) == 0 {
+		return errors.Errorf("%s has %s data disks, which are only offered in availability zones", name, UltraSSDDataDisk)
+	}
+	if iops != 0 {
+		if iops < common.MinUltraSSDIOPS || iops > common.MaxUltraSSDIOPS {
+			return errors.Errorf("the dataDiskIOPSReadWrite of %s is %d, it must be between %d and %d", name, iops, common.MinUltraSSDIOPS, common.MaxUltraSSDIOPS)
+		}
+		for _, size := range diskSizesGB {
+			if iops > size*common.MaxUltraSSDIOPSPerGB {
+				return errors.Errorf("the dataDiskIOPSReadWrite of %s is %d, more than the %d IOPS per GiB of its data disk of %d GiB", name, iops, common.MaxUltraSSDIOPSPerGB, size)
+			}
+		}
+	}
+	if mbps != 0 && (mbps < common.MinUltraSSDMBps || mbps > common.MaxUltraSSDMBps) {
+		return errors.Errorf("the dataDiskMBpsReadWrite of %s is %d, it must be between %d and %d", name, mbps, common.MinUltraSSDMBps, common.MaxUltraSSDMBps)
+	}
+	return nil
+}
+
 func (a *AgentPoolProfile) validateRoles(orchestratorType string) error {
 	validRoles := []AgentPoolProfileRole{AgentPoolProfileRoleEmpty}
 	var found bool
@@ -899,6 +2128,11 @@ func (a *AgentPoolProfile) validateStorageProfile(orchestratorType string) error
 		}
 	}
 
+	// the storage profile applies to the attached volumes of a pool with ephemeral OS disks
+	if a.OSDiskType == EphemeralOSDisk && a.StorageProfile == StorageAccount {
+		return errors.Errorf("agent pool %s has an ephemeral OS disk, which requires storageProfile %s", a.Name, ManagedDisks)
+	}
+
 	return nil
 }
 
@@ -1034,6 +2268,39 @@ func (a *AgentPoolProfile) validateOrchestratorSpecificProperties(orchestratorTy
 	return nil
 }
 
+// validateKubeletConfig validates the values of the kubelet flags of the cluster or of an agent pool, an empty value
+// unsetting the flag
+func validateKubeletConfig(kubeletConfig map[string]string) error {
+	if val := kubeletConfig["--node-status-update-frequency"]; val != "" {
+		if _, err := time.ParseDuration(val); err != nil {
+			return errors.Errorf("--node-status-update-frequency '%s' is not a valid duration", val)
+		}
+	}
+	if val := kubeletConfig["--max-pods"]; val != "" {
+		if maxPods, err := strconv.Atoi(val); err != nil || maxPods < KubernetesMinMaxPods {
+			return errors.Errorf("--max-pods '%s' must be an integer of at least %d", val, KubernetesMinMaxPods)
+		}
+	}
+	for _, flag := range []string{"--eviction-hard", "--eviction-soft", "--eviction-minimum-reclaim", "--eviction-soft-grace-period", "--kube-reserved", "--system-reserved"} {
+		val := kubeletConfig[flag]
+		// the Windows kubelet config disables eviction with a quoted empty value
+		if strings.Trim(val, `"`) == "" {
+			continue
+		}
+		separator := "="
+		if flag == "--eviction-hard" || flag == "--eviction-soft" {
+			separator = "<"
+		}
+		for _, threshold := range strings.Split(val, ",") {
+			parts := strings.Split(threshold, separator)
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return errors.Errorf("%s '%s' must be a comma-separated list of name%svalue pairs", flag, val, separator)
+			}
+		}
+	}
+	return nil
+}
+
 func (a *AgentPoolProfile) validateLoadBalancerBackendAddressPoolIDs() error {
 
 	if a.LoadBalancerBackendAddressPoolIDs != nil {
@@ -1162,14 +2429,8 @@ func (k *KubernetesConfig) Validate(k8sVersion string, hasWindows, ipv6DualStack
 		}
 	}
 
-	if k.KubeletConfig != nil {
-		if _, ok := k.KubeletConfig["--node-status-update-frequency"]; ok {
-			val := k.KubeletConfig["--node-status-update-frequency"]
-			_, err := time.ParseDuration(val)
-			if err != nil {
-				return errors.Errorf("--node-status-update-frequency '%s' is not a valid duration", val)
-			}
-		}
+	if err := validateKubeletConfig(k.KubeletConfig); err != nil {
+		return err
 	}
 
 	if _, ok := k.ControllerManagerConfig["--node-monitor-grace-period"]; ok {
@@ -1252,6 +2513,12 @@ func (k *KubernetesConfig) Validate(k8sVersion string, hasWindows, ipv6DualStack
 		return errors.Errorf("Invalid KubeProxyMode %v. Allowed modes are %v and %v", k.ProxyMode, KubeProxyModeIPTables, KubeProxyModeIPVS)
 	}
 
+	switch k.SizingProfile {
+	case "", SizingProfileSmall, SizingProfileMedium, SizingProfileLarge:
+	default:
+		return errors.Errorf("Invalid sizingProfile %s. Allowed profiles are %s, %s and %s", k.SizingProfile, SizingProfileSmall, SizingProfileMedium, SizingProfileLarge)
+	}
+
 	// Validate that we have a valid etcd version
 	if e := validateEtcdVersion(k.EtcdVersion); e != nil {
 		return e
@@ -1291,9 +2558,289 @@ func (k *KubernetesConfig) Validate(k8sVersion string, hasWindows, ipv6DualStack
 	if e := k.validateNetworkPluginPlusPolicy(); e != nil {
 		return e
 	}
+	if e := k.validateRegistryMirrors(); e != nil {
+		return e
+	}
+	if e := k.validateOIDCIssuerProfile(k8sVersion); e != nil {
+		return e
+	}
+	if e := k.validateManifestPatches(); e != nil {
+		return e
+	}
+	if e := k.validateKonnectivityProfile(k8sVersion); e != nil {
+		return e
+	}
+	if e := k.validateDrainProfile(); e != nil {
+		return e
+	}
+	if e := k.validateCloudProviderConfig(); e != nil {
+		return e
+	}
+	if e := k.validateCustomManifests(); e != nil {
+		return e
+	}
+	if e := k.validateImageRegistry(); e != nil {
+		return e
+	}
 	return k.validatePrivateAzureRegistryServer()
 }
 
+func (k *KubernetesConfig) validateOIDCIssuerProfile(k8sVersion string) error {
+	issuerEnabled := k.OIDCIssuerProfile != nil && to.Bool(k.OIDCIssuerProfile.Enabled)
+	for _, addon := range k.Addons {
+		if addon.Name == "azure-workload-identity-webhook" && to.Bool(addon.Enabled) {
+			if !issuerEnabled {
+				return errors.New("azure-workload-identity-webhook add-on requires oidcIssuerProfile to be enabled")
+			}
+			if !common.IsKubernetesVersionGe(k8sVersion, "1.16.0") {
+				return errors.Errorf("azure-workload-identity-webhook add-on is only available in kubernetes version %s or greater; unable to validate for version %s", "1.16.0", k8sVersion)
+			}
+		}
+	}
+	if !issuerEnabled {
+		return nil
+	}
+	if !common.IsKubernetesVersionGe(k8sVersion, "1.13.0") {
+		return errors.Errorf("oidcIssuerProfile is only available in kubernetes version %s or greater; unable to validate for version %s", "1.13.0", k8sVersion)
+	}
+	p := k.OIDCIssuerProfile
+	if p.IssuerURL != "" {
+		u, err := url.Parse(p.IssuerURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			return errors.Errorf("oidcIssuerProfile issuerURL %s must be an https URL without a query or fragment", p.IssuerURL)
+		}
+		return nil
+	}
+	if !storageAccountNameRegex.MatchString(p.StorageAccountName) {
+		return errors.Errorf("oidcIssuerProfile storageAccountName %q must be 3 to 24 lowercase letters and numbers, it is required unless issuerURL is specified", p.StorageAccountName)
+	}
+	if !blobContainerNameRegex.MatchString(p.ContainerName) || strings.Contains(p.ContainerName, "--") {
+		return errors.Errorf("oidcIssuerProfile containerName %q must be a valid blob container name, it is required unless issuerURL is specified", p.ContainerName)
+	}
+	return nil
+}
+
+func (k *KubernetesConfig) validateKonnectivityProfile(k8sVersion string) error {
+	konnectivityEnabled := k.KonnectivityProfile != nil && to.Bool(k.KonnectivityProfile.Enabled)
+	for _, addon := range k.Addons {
+		if addon.Name != "konnectivity-agent" || addon.Enabled == nil {
+			continue
+		}
+		if to.Bool(addon.Enabled) && !konnectivityEnabled {
+			return errors.New("konnectivity-agent add-on requires konnectivityProfile to be enabled")
+		}
+		if !to.Bool(addon.Enabled) && konnectivityEnabled {
+			return errors.New("konnectivityProfile requires the konnectivity-agent add-on, the API server cannot reach the nodes without it")
+		}
+	}
+	if konnectivityEnabled && !common.IsKubernetesVersionGe(k8sVersion, "1.18.0") {
+		return errors.Errorf("konnectivityProfile is only available in kubernetes version %s or greater; unable to validate for version %s", "1.18.0", k8sVersion)
+	}
+	return nil
+}
+
+func (k *KubernetesConfig) validateDrainProfile() error {
+	if k.DrainProfile == nil {
+		return nil
+	}
+	if k.DrainProfile.TimeoutInMinutes < 0 {
+		return errors.Errorf("drainProfile timeoutInMinutes %d must not be negative", k.DrainProfile.TimeoutInMinutes)
+	}
+	if k.DrainProfile.GracePeriodSeconds != nil && *k.DrainProfile.GracePeriodSeconds < 0 {
+		return errors.Errorf("drainProfile gracePeriodSeconds %d must not be negative, leave it unset for the pods to keep their own termination grace period", *k.DrainProfile.GracePeriodSeconds)
+	}
+	return nil
+}
+
+func (k *KubernetesConfig) validateCloudProviderConfig() error {
+	c := k.CloudProviderConfig
+	if c == nil {
+		return nil
+	}
+	for _, ttl := range []struct {
+		name    string
+		seconds int
+	}{
+		{"vmssCacheTTLInSeconds", c.VMSSCacheTTLInSeconds},
+		{"vmssVirtualMachinesCacheTTLInSeconds", c.VMSSVirtualMachinesCacheTTLInSeconds},
+		{"vmCacheTTLInSeconds", c.VMCacheTTLInSeconds},
+		{"availabilitySetNodesCacheTTLInSeconds", c.AvailabilitySetNodesCacheTTLInSeconds},
+		{"loadBalancerCacheTTLInSeconds", c.LoadBalancerCacheTTLInSeconds},
+		{"nsgCacheTTLInSeconds", c.NSGCacheTTLInSeconds},
+		{"routeTableCacheTTLInSeconds", c.RouteTableCacheTTLInSeconds},
+	} {
+		if ttl.seconds < 0 {
+			return errors.Errorf("cloudProviderConfig %s %d must not be negative", ttl.name, ttl.seconds)
+		}
+	}
+	for client, rateLimit := range c.RateLimits {
+		if !isValidCloudProviderRateLimitClient(client) {
+			return errors.Errorf("cloudProviderConfig rateLimits has an unknown client %q, the clients are: %s", client, strings.Join(cloudProviderRateLimitClients, ", "))
+		}
+		if rateLimit.CloudProviderRateLimitQPS < 0 || rateLimit.CloudProviderRateLimitQPSWrite < 0 {
+			return errors.Errorf("cloudProviderConfig rateLimits of client %s must not have a negative QPS", client)
+		}
+		if rateLimit.CloudProviderRateLimitBucket < 0 || rateLimit.CloudProviderRateLimitBucketWrite < 0 {
+			return errors.Errorf("cloudProviderConfig rateLimits of client %s must not have a negative bucket", client)
+		}
+	}
+	// the cloud provider reads the tags as a comma separated list of key=value
+	for key, value := range c.Tags {
+		if key == "" || strings.ContainsAny(key, ",=") || strings.Contains(value, ",") {
+			return errors.Errorf("cloudProviderConfig tag %q=%q is invalid, tags must have a key and cannot contain commas, nor equal signs in their key", key, value)
+		}
+	}
+	return nil
+}
+
+func isValidCloudProviderRateLimitClient(client string) bool {
+	for _, c := range cloudProviderRateLimitClients {
+		if c == client {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *KubernetesConfig) validateManifestPatches() error {
+	for _, patch := range k.ManifestPatches {
+		switch patch.Component {
+		case "kube-apiserver", "kube-controller-manager", "kube-scheduler":
+		case "cloud-controller-manager":
+			if !to.Bool(k.UseCloudControllerManager) {
+				return errors.New("manifestPatches for cloud-controller-manager require useCloudControllerManager")
+			}
+		case "kube-addon-manager":
+			if len(patch.ExtraArgs) > 0 {
+				return errors.New("manifestPatches for kube-addon-manager cannot have extraArgs, kube-addon-manager has no flags")
+			}
+		case "etcd":
+			return errors.New("manifestPatches cannot patch etcd, it runs as a systemd service on the masters instead of a static pod")
+		default:
+			return errors.Errorf("manifestPatches component %q must be one of kube-apiserver, kube-controller-manager, kube-scheduler, cloud-controller-manager or kube-addon-manager", patch.Component)
+		}
+		for key := range patch.ExtraArgs {
+			if !strings.HasPrefix(key, "--") {
+				return errors.Errorf("manifestPatches extraArgs %q for %s must be a flag starting with --", key, patch.Component)
+			}
+		}
+		if patch.Patch == nil {
+			continue
+		}
+		spec, _ := patch.Patch["spec"].(map[string]interface{})
+		containers, _ := spec["containers"].([]interface{})
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				return errors.Errorf("manifestPatches containers for %s must be objects", patch.Component)
+			}
+			if _, ok := container["name"].(string); !ok {
+				return errors.Errorf("manifestPatches containers for %s must have a name, they are merged by name", patch.Component)
+			}
+			if container["name"] != patch.Component {
+				continue
+			}
+			for _, field := range []string{"args", "command", "image"} {
+				if _, ok := container[field]; ok {
+					return errors.Errorf("manifestPatches for %s cannot patch the %s of its container, set the flags with extraArgs instead", patch.Component, field)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (k *KubernetesConfig) validateRegistryMirrors() error {
+	registries := map[string]bool{}
+	for _, mirror := range k.RegistryMirrors {
+		if mirror.Registry == "" {
+			return errors.New("registryMirrors entries must specify a registry")
+		}
+		if registries[mirror.Registry] {
+			return errors.Errorf("registryMirrors has more than one entry for registry %s", mirror.Registry)
+		}
+		registries[mirror.Registry] = true
+		if (k.ContainerRuntime == Docker || k.ContainerRuntime == "") && mirror.Registry != DockerHubRegistry {
+			return errors.Errorf("registryMirrors for registry %s require the %s or %s containerRuntime, docker only supports mirrors for %s", mirror.Registry, Containerd, KataContainers, DockerHubRegistry)
+		}
+		if len(mirror.Endpoints) == 0 {
+			return errors.Errorf("registryMirrors entry for registry %s must specify at least one endpoint", mirror.Registry)
+		}
+		for _, endpoint := range mirror.Endpoints {
+			u, err := url.Parse(endpoint)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return errors.Errorf("registryMirrors endpoint %s for registry %s must be an http or https URL", endpoint, mirror.Registry)
+			}
+		}
+	}
+	return nil
+}
+
+func (k *KubernetesConfig) validateCustomManifests() error {
+	names := map[string]bool{}
+	for _, manifest := range k.CustomManifests {
+		if !namespaceNameRegex.MatchString(manifest.Name) {
+			return errors.Errorf("customManifests name %q must be a lowercase RFC 1123 label, e.g. my-operator", manifest.Name)
+		}
+		if names[manifest.Name] {
+			return errors.Errorf("customManifests has more than one manifest named %s", manifest.Name)
+		}
+		names[manifest.Name] = true
+		if manifest.URL != "" && manifest.Path != "" {
+			return errors.Errorf("customManifests %s cannot have both a url and a path", manifest.Name)
+		}
+		if manifest.Data == "" && manifest.URL == "" && manifest.Path == "" {
+			return errors.Errorf("customManifests %s must have its data, a url or a path", manifest.Name)
+		}
+		if manifest.Data != "" {
+			if _, err := base64.StdEncoding.DecodeString(manifest.Data); err != nil {
+				return errors.Errorf("customManifests %s data must be base64 encoded", manifest.Name)
+			}
+		}
+		if manifest.URL != "" {
+			u, err := url.Parse(manifest.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return errors.Errorf("customManifests %s url %s must be an http or https URL", manifest.Name, manifest.URL)
+			}
+		}
+		switch manifest.Mode {
+		case "", "Reconcile", "EnsureExists":
+		default:
+			return errors.Errorf("customManifests %s mode %s must be Reconcile or EnsureExists", manifest.Name, manifest.Mode)
+		}
+	}
+
+	// the manifests are applied in the order of their dependencies, which must not be circular
+	applied := map[string]bool{}
+	for len(applied) < len(k.CustomManifests) {
+		progress := false
+		for _, manifest := range k.CustomManifests {
+			ready := !applied[manifest.Name]
+			for _, dependency := range manifest.DependsOn {
+				if !names[dependency] || dependency == manifest.Name {
+					return errors.Errorf("customManifests %s depends on %s, which is not another custom manifest", manifest.Name, dependency)
+				}
+				ready = ready && applied[dependency]
+			}
+			if ready {
+				applied[manifest.Name] = true
+				progress = true
+			}
+		}
+		if !progress {
+			return errors.New("customManifests dependsOn cannot be circular")
+		}
+	}
+	return nil
+}
+
+func (k *KubernetesConfig) validateImageRegistry() error {
+	if k.ImageRegistry != "" && !imageRegistryRegex.MatchString(k.ImageRegistry) {
+		return errors.Errorf("imageRegistry %s must be a registry host name with an optional repository path, e.g. contoso.azurecr.io", k.ImageRegistry)
+	}
+	return nil
+}
+
 func (k *KubernetesConfig) validatePrivateAzureRegistryServer() error {
 
 	// Check PrivateAzureRegistryServer has a valid value.
@@ -1354,9 +2901,12 @@ func (k *KubernetesConfig) validateNetworkPolicy(k8sVersion string, hasWindows b
 	}
 
 	// Temporary safety check, to be removed when Windows support is added.
-	if (networkPolicy == "calico" || networkPolicy == NetworkPolicyCilium || networkPolicy == "flannel") && hasWindows {
+	if (networkPolicy == "calico" || networkPolicy == NetworkPolicyCilium || networkPolicy == NetworkPolicyAntrea || networkPolicy == "flannel") && hasWindows {
 		return errors.Errorf("networkPolicy '%s' is not supporting windows agents", networkPolicy)
 	}
+	if (networkPlugin == NetworkPluginCilium || networkPlugin == NetworkPluginAntrea) && hasWindows {
+		return errors.Errorf("networkPlugin '%s' is not supporting windows agents", networkPlugin)
+	}
 
 	return nil
 }
@@ -1400,10 +2950,20 @@ func (a *Properties) validateContainerRuntime() error {
 	}
 
 	// Make sure we don't use unsupported container runtimes on windows.
-	if (containerRuntime == KataContainers || containerRuntime == Containerd) && a.HasWindows() {
+	if containerRuntime == KataContainers && a.HasWindows() {
 		return errors.Errorf("containerRuntime %q is not supporting windows agents", containerRuntime)
 	}
 
+	// There is no default containerd package for windows, it must be provided
+	if containerRuntime == Containerd && a.HasWindows() {
+		if a.WindowsProfile == nil || a.WindowsProfile.WindowsContainerdURL == "" {
+			return errors.Errorf("containerRuntime %q requires windowsProfile.windowsContainerdURL for windows agents", containerRuntime)
+		}
+		if u, err := url.Parse(a.WindowsProfile.WindowsContainerdURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return errors.Errorf("windowsProfile.windowsContainerdURL %s must be an http or https URL", a.WindowsProfile.WindowsContainerdURL)
+		}
+	}
+
 	return nil
 }
 
@@ -1558,6 +3118,96 @@ func (cs *ContainerService) Validate(isUpdate bool) error {
 	return nil
 }
 
+// ValidationError is an error in the api model, with the JSON path of the field or profile it is about
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// String returns the error prefixed with its path
+func (e ValidationError) String() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidateAll runs the validations of Validate and returns all their errors rather than the first one, each with
+// the JSON path of what it is about. The field errors of the properties are all returned before validating further,
+// and the other profiles are not validated against an invalid orchestrator profile, as their validations depend on it.
+func (cs *ContainerService) ValidateAll(isUpdate bool) []ValidationError {
+	var validationErrors []ValidationError
+	add := func(path string, e error) {
+		if e != nil {
+			validationErrors = append(validationErrors, ValidationError{Path: path, Message: e.Error()})
+		}
+	}
+	if e := cs.validateProperties(); e != nil {
+		add("properties", e)
+		return validationErrors
+	}
+	add("location", cs.validateLocation())
+	add("properties.customCloudProfile", cs.validateCustomCloudProfile())
+
+	a := cs.Properties
+	if e := validate.Struct(a); e != nil {
+		for _, fieldError := range e.(validator.ValidationErrors) {
+			add(validationErrorPath(fieldError.Namespace()), handleValidationErrors(validator.ValidationErrors{fieldError}))
+		}
+		return validationErrors
+	}
+	if e := a.ValidateOrchestratorProfile(isUpdate); e != nil {
+		add("properties.orchestratorProfile", e)
+		return validationErrors
+	}
+	add("properties.masterProfile", a.validateMasterProfile(isUpdate))
+	add("properties.agentPoolProfiles", a.validateAgentPoolProfiles(isUpdate))
+	add("properties.agentPoolProfiles", a.validateZones(isUpdate))
+	add("properties.linuxProfile", a.validateLinuxProfile())
+	add("properties.orchestratorProfile.kubernetesConfig.addons", a.validateAddons())
+	add("properties.extensionProfiles", a.validateExtensions())
+	add("properties", a.validateVMExtensions())
+	add("properties.masterProfile.vnetSubnetID", a.validateVNET())
+	add("properties.masterProfile.etcdSubnet", a.validateEtcdSubnet())
+	add("properties.agentPoolProfiles", a.validatePodSubnets())
+	add("properties", a.validateNetworkCIDROverlaps())
+	add("properties", a.validateNodeDNS())
+	add("properties.servicePrincipalProfile", a.validateServicePrincipalProfile())
+	add("properties.orchestratorProfile.kubernetesConfig.useManagedIdentity", a.validateManagedIdentity())
+	add("properties.agentPoolProfiles", a.validateAgentPoolUserAssignedIDs())
+	add("properties.aadProfile", a.validateAADProfile())
+	add("properties.orchestratorProfile.kubernetesConfig.airGapProfile", a.validateAirGapProfile())
+	return validationErrors
+}
+
+// validationErrorPath returns the JSON path of the field of a struct validation error of the properties,
+// e.g. properties.agentPoolProfiles[0].vmSize for the namespace Properties.AgentPoolProfiles[0].VMSize
+func validationErrorPath(namespace string) string {
+	path := []string{"properties"}
+	t := reflect.TypeOf(Properties{})
+	for _, field := range strings.Split(namespace, ".")[1:] {
+		name, index := field, ""
+		if i := strings.Index(field, "["); i >= 0 {
+			name, index = field[:i], field[i:]
+		}
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			path = append(path, field)
+			continue
+		}
+		f, ok := t.FieldByName(name)
+		if !ok {
+			path = append(path, field)
+			continue
+		}
+		if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+			name = tag
+		}
+		path = append(path, name+index)
+		t = f.Type
+	}
+	return strings.Join(path, ".")
+}
+
 func (cs *ContainerService) validateLocation() error {
 	if cs.Properties != nil && cs.Properties.IsAzureStackCloud() && cs.Location == "" {
 		return errors.New("missing ContainerService Location")
@@ -5,6 +5,7 @@ package vlabs
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -311,6 +312,257 @@ func Test_OrchestratorProfile_Validate(t *testing.T) {
 			},
 			expectedError: "outboundRuleIdleTimeoutInMinutes shouldn't be less than 4 or greater than 120",
 		},
+		"should error when outboundType is invalid": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &KubernetesConfig{
+						LoadBalancerSku:             StandardLoadBalancerSku,
+						ExcludeMasterFromStandardLB: to.BoolPtr(true),
+						OutboundType:                "nat",
+					},
+				},
+			},
+			expectedError: "Invalid outboundType nat. Allowed types are loadBalancer, natGateway and userDefinedRouting",
+		},
+		"should error when outboundType is set with the basic loadBalancerSku": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &KubernetesConfig{
+						LoadBalancerSku: BasicLoadBalancerSku,
+						OutboundType:    OutboundTypeNATGateway,
+					},
+				},
+			},
+			expectedError: "outboundType natGateway requires loadBalancerSku Standard",
+		},
+		"should error when outboundType natGateway is set with a custom VNET": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &KubernetesConfig{
+						LoadBalancerSku:             StandardLoadBalancerSku,
+						ExcludeMasterFromStandardLB: to.BoolPtr(true),
+						OutboundType:                OutboundTypeNATGateway,
+					},
+				},
+				MasterProfile: &MasterProfile{
+					VnetSubnetID: "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME",
+				},
+			},
+			expectedError: "outboundType natGateway is only supported with the VNET created by aks-engine, attach the NAT gateway to the subnets of a custom VNET and use outboundType userDefinedRouting",
+		},
+		"should not error when outboundType natGateway is set": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &KubernetesConfig{
+						LoadBalancerSku:             StandardLoadBalancerSku,
+						ExcludeMasterFromStandardLB: to.BoolPtr(true),
+						OutboundType:                OutboundTypeNATGateway,
+					},
+				},
+			},
+			expectedError: "",
+		},
+		"should error when outboundType userDefinedRouting is set without a custom VNET": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &KubernetesConfig{
+						LoadBalancerSku:             StandardLoadBalancerSku,
+						ExcludeMasterFromStandardLB: to.BoolPtr(true),
+						OutboundType:                OutboundTypeUserDefinedRouting,
+					},
+				},
+			},
+			expectedError: "outboundType userDefinedRouting requires a custom VNET whose subnets provide the egress of the cluster",
+		},
+		"should not error when outboundType userDefinedRouting is set with a custom VNET": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &KubernetesConfig{
+						LoadBalancerSku:             StandardLoadBalancerSku,
+						ExcludeMasterFromStandardLB: to.BoolPtr(true),
+						OutboundType:                OutboundTypeUserDefinedRouting,
+					},
+				},
+				MasterProfile: &MasterProfile{
+					VnetSubnetID: "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME",
+				},
+			},
+			expectedError: "",
+		},
+		"should error when outboundRuleAllocatedPorts is set with outboundType natGateway": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &KubernetesConfig{
+						LoadBalancerSku:             StandardLoadBalancerSku,
+						ExcludeMasterFromStandardLB: to.BoolPtr(true),
+						OutboundType:                OutboundTypeNATGateway,
+						OutboundRuleAllocatedPorts:  1024,
+					},
+				},
+			},
+			expectedError: "outboundRuleAllocatedPorts is only supported with loadBalancerSku Standard and outboundType loadBalancer",
+		},
+		"should error when outboundRuleAllocatedPorts is not a multiple of 8": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &KubernetesConfig{
+						LoadBalancerSku:             StandardLoadBalancerSku,
+						ExcludeMasterFromStandardLB: to.BoolPtr(true),
+						OutboundRuleAllocatedPorts:  1001,
+					},
+				},
+			},
+			expectedError: "outboundRuleAllocatedPorts should be a multiple of 8 between 0 and 64000",
+		},
+		"should not error when outboundRuleAllocatedPorts is valid": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &KubernetesConfig{
+						LoadBalancerSku:             StandardLoadBalancerSku,
+						ExcludeMasterFromStandardLB: to.BoolPtr(true),
+						OutboundType:                OutboundTypeLoadBalancer,
+						OutboundRuleAllocatedPorts:  1024,
+					},
+				},
+			},
+			expectedError: "",
+		},
+		"should error when privateLink is enabled on a public cluster": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &KubernetesConfig{
+						LoadBalancerSku:             StandardLoadBalancerSku,
+						ExcludeMasterFromStandardLB: to.BoolPtr(true),
+						PrivateCluster: &PrivateCluster{
+							Enabled: to.BoolPtr(false),
+							PrivateLink: &PrivateLinkProfile{
+								Enabled: to.BoolPtr(true),
+							},
+						},
+					},
+				},
+				MasterProfile: &MasterProfile{
+					Count: 3,
+				},
+			},
+			expectedError: "privateCluster.privateLink requires privateCluster to be enabled",
+		},
+		"should error when privateLink is enabled with a Basic load balancer": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &KubernetesConfig{
+						PrivateCluster: &PrivateCluster{
+							Enabled: to.BoolPtr(true),
+							PrivateLink: &PrivateLinkProfile{
+								Enabled: to.BoolPtr(true),
+							},
+						},
+					},
+				},
+				MasterProfile: &MasterProfile{
+					Count: 3,
+				},
+			},
+			expectedError: "privateCluster.privateLink requires loadBalancerSku Standard, a Private Link service can only front a Standard load balancer",
+		},
+		"should error when privateLink is enabled with a single master": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &KubernetesConfig{
+						LoadBalancerSku:             StandardLoadBalancerSku,
+						ExcludeMasterFromStandardLB: to.BoolPtr(true),
+						PrivateCluster: &PrivateCluster{
+							Enabled: to.BoolPtr(true),
+							PrivateLink: &PrivateLinkProfile{
+								Enabled: to.BoolPtr(true),
+							},
+						},
+					},
+				},
+				MasterProfile: &MasterProfile{
+					Count: 1,
+				},
+			},
+			expectedError: "privateCluster.privateLink requires a masterProfile count of 3 or 5, the Private Link service fronts the internal load balancer of the masters",
+		},
+		"should error when privateLink has an invalid privateEndpointSubnetID": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &KubernetesConfig{
+						LoadBalancerSku:             StandardLoadBalancerSku,
+						ExcludeMasterFromStandardLB: to.BoolPtr(true),
+						PrivateCluster: &PrivateCluster{
+							Enabled: to.BoolPtr(true),
+							PrivateLink: &PrivateLinkProfile{
+								Enabled:                 to.BoolPtr(true),
+								PrivateEndpointSubnetID: "invalid",
+							},
+						},
+					},
+				},
+				MasterProfile: &MasterProfile{
+					Count: 3,
+				},
+			},
+			expectedError: "privateCluster.privateLink.privateEndpointSubnetID invalid is not a valid subnet ID: Unable to parse vnetSubnetID. Please use a vnetSubnetID with format /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME",
+		},
+		"should not error when privateLink is enabled": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &KubernetesConfig{
+						LoadBalancerSku:             StandardLoadBalancerSku,
+						ExcludeMasterFromStandardLB: to.BoolPtr(true),
+						PrivateCluster: &PrivateCluster{
+							Enabled: to.BoolPtr(true),
+							PrivateLink: &PrivateLinkProfile{
+								Enabled:                 to.BoolPtr(true),
+								PrivateEndpointSubnetID: "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME",
+							},
+						},
+					},
+				},
+				MasterProfile: &MasterProfile{
+					Count: 3,
+				},
+			},
+			expectedError: "",
+		},
+		"should not error when privateLink is enabled with a custom VNET": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &KubernetesConfig{
+						LoadBalancerSku:             StandardLoadBalancerSku,
+						ExcludeMasterFromStandardLB: to.BoolPtr(true),
+						PrivateCluster: &PrivateCluster{
+							Enabled: to.BoolPtr(true),
+							PrivateLink: &PrivateLinkProfile{
+								Enabled: to.BoolPtr(true),
+							},
+						},
+					},
+				},
+				MasterProfile: &MasterProfile{
+					Count:        3,
+					VnetSubnetID: "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME",
+				},
+			},
+			expectedError: "",
+		},
 	}
 
 	for testName, test := range tests {
@@ -403,6 +655,20 @@ func Test_KubernetesConfig_Validate(t *testing.T) {
 			t.Error("should error on invalid --non-masquerade-cidr")
 		}*/
 
+		c = KubernetesConfig{
+			SizingProfile: "huge",
+		}
+		if err := c.Validate(k8sVersion, false, false); err == nil {
+			t.Error("should error on invalid SizingProfile")
+		}
+
+		c = KubernetesConfig{
+			SizingProfile: SizingProfileLarge,
+		}
+		if err := c.Validate(k8sVersion, false, false); err != nil {
+			t.Errorf("should not error on valid SizingProfile: %v", err)
+		}
+
 		c = KubernetesConfig{
 			MaxPods: KubernetesMinMaxPods - 1,
 		}
@@ -624,1082 +890,3301 @@ func Test_Properties_ValidatePrivateAzureRegistryServer(t *testing.T) {
 	}
 }
 
-func Test_Properties_ValidateDistro(t *testing.T) {
-	p := &Properties{}
-	p.OrchestratorProfile = &OrchestratorProfile{}
-	p.OrchestratorProfile.OrchestratorType = Kubernetes
-	p.MasterProfile = &MasterProfile{
-		DNSPrefix: "foo",
-	}
-
-	// Should not error on valid distros in non-update scenarios
-	for _, distro := range DistroValues {
-		p.MasterProfile.Distro = distro
-		p.AgentPoolProfiles = []*AgentPoolProfile{
-			{
-				Name:   "pool1",
-				Distro: distro,
+func Test_KubernetesConfig_ValidateRegistryMirrors(t *testing.T) {
+	cases := []struct {
+		name          string
+		k             *KubernetesConfig
+		expectedError string
+	}{
+		{
+			name: "docker hub mirror with docker",
+			k: &KubernetesConfig{
+				RegistryMirrors: []RegistryMirror{
+					{Registry: "docker.io", Endpoints: []string{"https://mirror.example.com"}},
+				},
 			},
-		}
-		if err := p.validateMasterProfile(false); err != nil {
-			t.Errorf(
-				"should not error on distro=\"%s\"",
-				distro,
-			)
-		}
-		if err := p.validateAgentPoolProfiles(false); err != nil {
-			t.Errorf(
-				"should not error on distro=\"%s\"",
-				distro,
-			)
-		}
-	}
-
-	// Should not error on valid distros in update scenarios
-	for _, distro := range DistroValues {
-		p.MasterProfile.Distro = distro
-		p.AgentPoolProfiles = []*AgentPoolProfile{
-			{
-				Name:   "pool1",
-				Distro: distro,
+		},
+		{
+			name: "mcr mirror with containerd",
+			k: &KubernetesConfig{
+				ContainerRuntime: Containerd,
+				RegistryMirrors: []RegistryMirror{
+					{Registry: "docker.io", Endpoints: []string{"https://mirror.example.com"}},
+					{Registry: "mcr.microsoft.com", Endpoints: []string{"http://10.0.0.10:5000"}},
+				},
 			},
-		}
-		if err := p.validateMasterProfile(true); err != nil {
-			t.Errorf(
-				"should not error on distro=\"%s\"",
-				distro,
-			)
-		}
-		if err := p.validateAgentPoolProfiles(true); err != nil {
-			t.Errorf(
-				"should not error on distro=\"%s\"",
-				distro,
-			)
-		}
+		},
+		{
+			name: "mcr mirror with docker",
+			k: &KubernetesConfig{
+				RegistryMirrors: []RegistryMirror{
+					{Registry: "mcr.microsoft.com", Endpoints: []string{"https://mirror.example.com"}},
+				},
+			},
+			expectedError: "registryMirrors for registry mcr.microsoft.com require the containerd or kata-containers containerRuntime, docker only supports mirrors for docker.io",
+		},
+		{
+			name: "missing registry",
+			k: &KubernetesConfig{
+				RegistryMirrors: []RegistryMirror{
+					{Endpoints: []string{"https://mirror.example.com"}},
+				},
+			},
+			expectedError: "registryMirrors entries must specify a registry",
+		},
+		{
+			name: "duplicate registry",
+			k: &KubernetesConfig{
+				RegistryMirrors: []RegistryMirror{
+					{Registry: "docker.io", Endpoints: []string{"https://mirror.example.com"}},
+					{Registry: "docker.io", Endpoints: []string{"https://other.example.com"}},
+				},
+			},
+			expectedError: "registryMirrors has more than one entry for registry docker.io",
+		},
+		{
+			name: "missing endpoints",
+			k: &KubernetesConfig{
+				RegistryMirrors: []RegistryMirror{
+					{Registry: "docker.io"},
+				},
+			},
+			expectedError: "registryMirrors entry for registry docker.io must specify at least one endpoint",
+		},
+		{
+			name: "invalid endpoint",
+			k: &KubernetesConfig{
+				RegistryMirrors: []RegistryMirror{
+					{Registry: "docker.io", Endpoints: []string{"mirror.example.com"}},
+				},
+			},
+			expectedError: "registryMirrors endpoint mirror.example.com for registry docker.io must be an http or https URL",
+		},
 	}
 
-	// Should error for invalid distros on non-update scenarios
-	bogusDistroValues := []Distro{AKSDockerEngine, AKS1604Deprecated, AKS1804Deprecated, "bogon"}
-	for _, distro := range bogusDistroValues {
-		p.MasterProfile.Distro = distro
-		p.AgentPoolProfiles = []*AgentPoolProfile{
-			{
-				Name:   "pool1",
-				Distro: distro,
-			},
-		}
-		if err := p.validateMasterProfile(false); err == nil {
-			t.Errorf(
-				"should error on distro=\"%s\"",
-				distro,
-			)
-		}
-		if err := p.validateAgentPoolProfiles(false); err == nil {
-			t.Errorf(
-				"should error on distro=\"%s\"",
-				distro,
-			)
-		}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			err := c.k.validateRegistryMirrors()
+			if c.expectedError == "" {
+				if err != nil {
+					t.Errorf("expected no error, but got %s", err)
+				}
+			} else if err == nil || err.Error() != c.expectedError {
+				t.Errorf("expected error %s, but got %v", c.expectedError, err)
+			}
+		})
 	}
+}
 
-	// Should not error for deprecated distro on update scenarios
-	oldDistros := []Distro{AKSDockerEngine, AKS1604Deprecated, AKS1804Deprecated}
-	for _, distro := range oldDistros {
-		p.MasterProfile.Distro = distro
-		p.AgentPoolProfiles = []*AgentPoolProfile{
-			{
-				Name:   "pool1",
-				Distro: distro,
-			},
-		}
-		if err := p.validateMasterProfile(true); err != nil {
-			t.Errorf(
-				"should error on distro=\"%s\"",
-				distro,
-			)
-		}
-		if err := p.validateAgentPoolProfiles(true); err != nil {
-			t.Errorf(
-				"should error on distro=\"%s\"",
-				distro,
-			)
-		}
-	}
-}
-
-func Test_Properties_ValidateNetworkPolicy(t *testing.T) {
-	p := &Properties{}
-	p.OrchestratorProfile = &OrchestratorProfile{}
-	p.OrchestratorProfile.OrchestratorType = Kubernetes
-
-	k8sVersion := "1.8.0"
-	for _, policy := range NetworkPolicyValues {
-		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{}
-		p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = policy
-		if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPolicy(k8sVersion, false); err != nil {
-			t.Errorf(
-				"should not error on networkPolicy=\"%s\" on k8sVersion=\"%s\"",
-				policy,
-				k8sVersion,
-			)
-		}
-	}
-
-	p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = "not-existing"
-	if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPolicy(k8sVersion, false); err == nil {
-		t.Errorf(
-			"should error on invalid networkPolicy",
-		)
-	}
-
-	k8sVersion = "1.7.9"
-	p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = "azure"
-	p.OrchestratorProfile.KubernetesConfig.NetworkPlugin = "azure"
-	if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPolicy(k8sVersion, false); err == nil {
-		t.Errorf(
-			"should error on azure networkPolicy + azure networkPlugin with k8s version < 1.8.0",
-		)
-	}
-
-	p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = "calico"
-	if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPolicy(k8sVersion, true); err == nil {
-		t.Errorf(
-			"should error on calico for windows clusters",
-		)
-	}
-
-	p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = NetworkPolicyCilium
-	if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPolicy(k8sVersion, true); err == nil {
-		t.Errorf(
-			"should error on cilium for windows clusters",
-		)
-	}
-
-	p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = "flannel"
-	if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPolicy(k8sVersion, true); err == nil {
-		t.Errorf(
-			"should error on flannel for windows clusters",
-		)
-	}
-}
-
-func Test_Properties_ValidateNetworkPlugin(t *testing.T) {
-	p := &Properties{}
-	p.OrchestratorProfile = &OrchestratorProfile{}
-	p.OrchestratorProfile.OrchestratorType = Kubernetes
-
-	for _, policy := range NetworkPluginValues {
-		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{}
-		p.OrchestratorProfile.KubernetesConfig.NetworkPlugin = policy
-		if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPlugin(); err != nil {
-			t.Errorf(
-				"should not error on networkPolicy=\"%s\"",
-				policy,
-			)
-		}
-	}
-
-	p.OrchestratorProfile.KubernetesConfig.NetworkPlugin = "not-existing"
-	if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPlugin(); err == nil {
-		t.Errorf(
-			"should error on invalid networkPlugin",
-		)
+func Test_KubernetesConfig_ValidateOIDCIssuerProfile(t *testing.T) {
+	webhook := []KubernetesAddon{
+		{Name: "azure-workload-identity-webhook", Enabled: to.BoolPtr(true)},
 	}
-}
-
-func Test_Properties_ValidateNetworkPluginPlusPolicy(t *testing.T) {
-	p := &Properties{}
-	p.OrchestratorProfile = &OrchestratorProfile{}
-	p.OrchestratorProfile.OrchestratorType = Kubernetes
-
-	for _, config := range networkPluginPlusPolicyAllowed {
-		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{}
-		p.OrchestratorProfile.KubernetesConfig.NetworkPlugin = config.networkPlugin
-		p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = config.networkPolicy
-		if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPluginPlusPolicy(); err != nil {
-			t.Errorf(
-				"should not error on networkPolicy=\"%s\" + networkPlugin=\"%s\"",
-				config.networkPolicy, config.networkPlugin,
-			)
-		}
-	}
-
-	for _, config := range []k8sNetworkConfig{
+	cases := []struct {
+		name          string
+		k8sVersion    string
+		k             *KubernetesConfig
+		expectedError string
+	}{
 		{
-			networkPlugin: "azure",
-			networkPolicy: NetworkPolicyCilium,
+			name:       "blob container issuer",
+			k8sVersion: "1.16.1",
+			k: &KubernetesConfig{
+				OIDCIssuerProfile: &OIDCIssuerProfile{Enabled: to.BoolPtr(true), StorageAccountName: "oidcissuer", ContainerName: "my-cluster"},
+				Addons:            webhook,
+			},
 		},
 		{
-			networkPlugin: "azure",
-			networkPolicy: "flannel",
+			name:       "issuer URL",
+			k8sVersion: "1.13.12",
+			k: &KubernetesConfig{
+				OIDCIssuerProfile: &OIDCIssuerProfile{Enabled: to.BoolPtr(true), IssuerURL: "https://oidc.example.com/my-cluster/"},
+			},
 		},
 		{
-			networkPlugin: "kubenet",
-			networkPolicy: "none",
+			name:       "disabled issuer is not validated",
+			k8sVersion: "1.12.8",
+			k: &KubernetesConfig{
+				OIDCIssuerProfile: &OIDCIssuerProfile{Enabled: to.BoolPtr(false)},
+			},
 		},
 		{
-			networkPlugin: "azure",
-			networkPolicy: "none",
+			name:       "issuer on an old version",
+			k8sVersion: "1.12.8",
+			k: &KubernetesConfig{
+				OIDCIssuerProfile: &OIDCIssuerProfile{Enabled: to.BoolPtr(true), IssuerURL: "https://oidc.example.com/"},
+			},
+			expectedError: "oidcIssuerProfile is only available in kubernetes version 1.13.0 or greater; unable to validate for version 1.12.8",
 		},
 		{
-			networkPlugin: "kubenet",
-			networkPolicy: "kubenet",
+			name:       "http issuer URL",
+			k8sVersion: "1.16.1",
+			k: &KubernetesConfig{
+				OIDCIssuerProfile: &OIDCIssuerProfile{Enabled: to.BoolPtr(true), IssuerURL: "http://oidc.example.com/"},
+			},
+			expectedError: "oidcIssuerProfile issuerURL http://oidc.example.com/ must be an https URL without a query or fragment",
+		},
+		{
+			name:       "missing storage account",
+			k8sVersion: "1.16.1",
+			k: &KubernetesConfig{
+				OIDCIssuerProfile: &OIDCIssuerProfile{Enabled: to.BoolPtr(true), ContainerName: "my-cluster"},
+			},
+			expectedError: `oidcIssuerProfile storageAccountName "" must be 3 to 24 lowercase letters and numbers, it is required unless issuerURL is specified`,
+		},
+		{
+			name:       "invalid container name",
+			k8sVersion: "1.16.1",
+			k: &KubernetesConfig{
+				OIDCIssuerProfile: &OIDCIssuerProfile{Enabled: to.BoolPtr(true), StorageAccountName: "oidcissuer", ContainerName: "my--cluster"},
+			},
+			expectedError: `oidcIssuerProfile containerName "my--cluster" must be a valid blob container name, it is required unless issuerURL is specified`,
+		},
+		{
+			name:       "webhook without the issuer",
+			k8sVersion: "1.16.1",
+			k: &KubernetesConfig{
+				Addons: webhook,
+			},
+			expectedError: "azure-workload-identity-webhook add-on requires oidcIssuerProfile to be enabled",
+		},
+		{
+			name:       "webhook on an old version",
+			k8sVersion: "1.15.5",
+			k: &KubernetesConfig{
+				OIDCIssuerProfile: &OIDCIssuerProfile{Enabled: to.BoolPtr(true), StorageAccountName: "oidcissuer", ContainerName: "my-cluster"},
+				Addons:            webhook,
+			},
+			expectedError: "azure-workload-identity-webhook add-on is only available in kubernetes version 1.16.0 or greater; unable to validate for version 1.15.5",
 		},
-	} {
-		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{}
-		p.OrchestratorProfile.KubernetesConfig.NetworkPlugin = config.networkPlugin
-		p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = config.networkPolicy
-		if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPluginPlusPolicy(); err == nil {
-			t.Errorf(
-				"should error on networkPolicy=\"%s\" + networkPlugin=\"%s\"",
-				config.networkPolicy, config.networkPlugin,
-			)
-		}
-	}
-}
-
-func TestProperties_ValidateLinuxProfile(t *testing.T) {
-	cs := getK8sDefaultContainerService(true)
-	cs.Properties.LinuxProfile.SSH = struct {
-		PublicKeys []PublicKey `json:"publicKeys" validate:"required,min=1"`
-	}{
-		PublicKeys: []PublicKey{{}},
 	}
-	expectedMsg := "KeyData in LinuxProfile.SSH.PublicKeys cannot be empty string"
-	err := cs.Validate(true)
 
-	if err.Error() != expectedMsg {
-		t.Errorf("expected error message : %s to be thrown, but got : %s", expectedMsg, err.Error())
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			err := c.k.validateOIDCIssuerProfile(c.k8sVersion)
+			if c.expectedError == "" {
+				if err != nil {
+					t.Errorf("expected no error, but got %s", err)
+				}
+			} else if err == nil || err.Error() != c.expectedError {
+				t.Errorf("expected error %s, but got %v", c.expectedError, err)
+			}
+		})
 	}
+}
 
-	cs.Properties.LinuxProfile.SSH = struct {
-		PublicKeys []PublicKey `json:"publicKeys" validate:"required,min=1"`
+func Test_KubernetesConfig_ValidateKonnectivityProfile(t *testing.T) {
+	cases := []struct {
+		name          string
+		k8sVersion    string
+		k             *KubernetesConfig
+		expectedError string
 	}{
-		PublicKeys: []PublicKey{
-			{
-				KeyData: "not empty",
+		{
+			name:       "konnectivity",
+			k8sVersion: "1.18.1",
+			k: &KubernetesConfig{
+				KonnectivityProfile: &KonnectivityProfile{Enabled: to.BoolPtr(true)},
+				Addons:              []KubernetesAddon{{Name: "konnectivity-agent", Enabled: to.BoolPtr(true)}},
 			},
-			{},
+		},
+		{
+			name:       "disabled konnectivity is not validated",
+			k8sVersion: "1.15.5",
+			k: &KubernetesConfig{
+				KonnectivityProfile: &KonnectivityProfile{Enabled: to.BoolPtr(false)},
+			},
+		},
+		{
+			name:       "konnectivity on an old version",
+			k8sVersion: "1.17.4",
+			k: &KubernetesConfig{
+				KonnectivityProfile: &KonnectivityProfile{Enabled: to.BoolPtr(true)},
+			},
+			expectedError: "konnectivityProfile is only available in kubernetes version 1.18.0 or greater; unable to validate for version 1.17.4",
+		},
+		{
+			name:       "agent without konnectivity",
+			k8sVersion: "1.16.1",
+			k: &KubernetesConfig{
+				Addons: []KubernetesAddon{{Name: "konnectivity-agent", Enabled: to.BoolPtr(true)}},
+			},
+			expectedError: "konnectivity-agent add-on requires konnectivityProfile to be enabled",
+		},
+		{
+			name:       "konnectivity with the agent disabled",
+			k8sVersion: "1.16.1",
+			k: &KubernetesConfig{
+				KonnectivityProfile: &KonnectivityProfile{Enabled: to.BoolPtr(true)},
+				Addons:              []KubernetesAddon{{Name: "konnectivity-agent", Enabled: to.BoolPtr(false)}},
+			},
+			expectedError: "konnectivityProfile requires the konnectivity-agent add-on, the API server cannot reach the nodes without it",
 		},
 	}
-	expectedMsg = "KeyData in LinuxProfile.SSH.PublicKeys cannot be empty string"
-	err = cs.Validate(true)
 
-	if err.Error() != expectedMsg {
-		t.Errorf("expected error message : %s to be thrown, but got : %s", expectedMsg, err.Error())
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			err := c.k.validateKonnectivityProfile(c.k8sVersion)
+			if c.expectedError == "" {
+				if err != nil {
+					t.Errorf("expected no error, but got %s", err)
+				}
+			} else if err == nil || err.Error() != c.expectedError {
+				t.Errorf("expected error %s, but got %v", c.expectedError, err)
+			}
+		})
 	}
 }
 
-func TestProperties_ValidateInvalidExtensions(t *testing.T) {
-	tests := []struct {
-		name              string
-		agentPoolProfiles []*AgentPoolProfile
-		expectedErr       error
+func Test_KubernetesConfig_ValidateDrainProfile(t *testing.T) {
+	cases := []struct {
+		name          string
+		k             *KubernetesConfig
+		expectedError string
 	}{
 		{
-			name: "Extensions for VirtualMachineScaleSets",
-			agentPoolProfiles: []*AgentPoolProfile{
-				{
-					Name:                "agentpool",
-					VMSize:              "Standard_D2_v2",
-					Count:               1,
-					AvailabilityProfile: VirtualMachineScaleSets,
-					Extensions: []Extension{
-						{
-							Name:        "extensionName",
-							SingleOrAll: "single",
-							Template:    "fakeTemplate",
-						},
-					},
-				},
-			},
-			expectedErr: errors.New("Extensions are currently not supported with VirtualMachineScaleSets. Please specify \"availabilityProfile\": \"AvailabilitySet\""),
+			name: "no drain profile",
+			k:    &KubernetesConfig{},
 		},
 		{
-			name: "prometheus-grafana-k8s extensions for Winows agents",
-			agentPoolProfiles: []*AgentPoolProfile{
-				{
-					Name:                "agentpool",
-					VMSize:              "Standard_D2_v2",
-					Count:               1,
-					AvailabilityProfile: AvailabilitySet,
-					OSType:              "Windows",
-					Extensions: []Extension{
-						{
-							Name: "prometheus-grafana-k8s",
-						},
-					},
+			name: "drain profile",
+			k: &KubernetesConfig{
+				DrainProfile: &DrainProfile{
+					TimeoutInMinutes:   30,
+					GracePeriodSeconds: to.IntPtr(0),
+					IgnoreDaemonSets:   to.BoolPtr(false),
+					ForceAfterTimeout:  to.BoolPtr(true),
 				},
 			},
-			expectedErr: errors.New("prometheus-grafana-k8s extension is currently not supported for Windows agents"),
+		},
+		{
+			name:          "negative timeout",
+			k:             &KubernetesConfig{DrainProfile: &DrainProfile{TimeoutInMinutes: -1}},
+			expectedError: "drainProfile timeoutInMinutes -1 must not be negative",
+		},
+		{
+			name:          "negative grace period",
+			k:             &KubernetesConfig{DrainProfile: &DrainProfile{GracePeriodSeconds: to.IntPtr(-1)}},
+			expectedError: "drainProfile gracePeriodSeconds -1 must not be negative, leave it unset for the pods to keep their own termination grace period",
 		},
 	}
 
-	for _, test := range tests {
-		test := test
-		t.Run(test.name, func(t *testing.T) {
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
 			t.Parallel()
-			cs := getK8sDefaultContainerService(true)
-			cs.Properties.AgentPoolProfiles = test.agentPoolProfiles
-			err := cs.Validate(true)
-			if !helpers.EqualError(err, test.expectedErr) {
-				t.Errorf("expected error with message : %s, but got %s", test.expectedErr.Error(), err.Error())
+			err := c.k.validateDrainProfile()
+			if c.expectedError == "" {
+				if err != nil {
+					t.Errorf("expected no error, but got %s", err)
+				}
+			} else if err == nil || err.Error() != c.expectedError {
+				t.Errorf("expected error %s, but got %v", c.expectedError, err)
 			}
 		})
 	}
-
 }
 
-func TestProperties_ValidateInvalidExtensionProfiles(t *testing.T) {
-	tests := []struct {
-		name              string
-		extensionProfiles []*ExtensionProfile
-		expectedErr       error
+func Test_KubernetesConfig_ValidateCloudProviderConfig(t *testing.T) {
+	cases := []struct {
+		name          string
+		k             *KubernetesConfig
+		expectedError string
 	}{
 		{
-			name: "Extension Profile without Keyvault ID",
-			extensionProfiles: []*ExtensionProfile{
-				{
-					Name: "FakeExtensionProfile",
-					ExtensionParametersKeyVaultRef: &KeyvaultSecretRef{
-						VaultID:    "",
-						SecretName: "fakeSecret",
+			name: "no cloud provider config",
+			k:    &KubernetesConfig{},
+		},
+		{
+			name: "cloud provider config",
+			k: &KubernetesConfig{
+				CloudProviderConfig: &AzureCloudConfig{
+					VMSSCacheTTLInSeconds: 600,
+					RateLimits: map[string]CloudProviderRateLimit{
+						"virtualMachineScaleSet": {
+							CloudProviderRateLimit:    to.BoolPtr(true),
+							CloudProviderRateLimitQPS: 10,
+						},
+						"loadBalancer": {CloudProviderRateLimit: to.BoolPtr(false)},
 					},
+					Tags: map[string]string{"team": "platform", "cost-center": "a=b"},
 				},
 			},
-			expectedErr: errors.New("the Keyvault ID must be specified for Extension FakeExtensionProfile"),
 		},
 		{
-			name: "Extension Profile without Keyvault Secret",
-			extensionProfiles: []*ExtensionProfile{
-				{
-					Name: "FakeExtensionProfile",
-					ExtensionParametersKeyVaultRef: &KeyvaultSecretRef{
-						VaultID:    "fakeVaultID",
-						SecretName: "",
-					},
-				},
+			name:          "negative cache TTL",
+			k:             &KubernetesConfig{CloudProviderConfig: &AzureCloudConfig{NSGCacheTTLInSeconds: -1}},
+			expectedError: "cloudProviderConfig nsgCacheTTLInSeconds -1 must not be negative",
+		},
+		{
+			name: "unknown client",
+			k: &KubernetesConfig{CloudProviderConfig: &AzureCloudConfig{
+				RateLimits: map[string]CloudProviderRateLimit{"virtualMachineScaleSets": {}},
+			}},
+			expectedError: `cloudProviderConfig rateLimits has an unknown client "virtualMachineScaleSets", the clients are: route, subnets, interface, routeTable, loadBalancer, publicIPAddress, securityGroup, virtualMachine, storageAccount, disk, snapshot, virtualMachineScaleSet, virtualMachineSizes`,
+		},
+		{
+			name: "negative QPS",
+			k: &KubernetesConfig{CloudProviderConfig: &AzureCloudConfig{
+				RateLimits: map[string]CloudProviderRateLimit{"disk": {CloudProviderRateLimitQPSWrite: -1}},
+			}},
+			expectedError: "cloudProviderConfig rateLimits of client disk must not have a negative QPS",
+		},
+		{
+			name: "negative bucket",
+			k: &KubernetesConfig{CloudProviderConfig: &AzureCloudConfig{
+				RateLimits: map[string]CloudProviderRateLimit{"disk": {CloudProviderRateLimitBucket: -1}},
+			}},
+			expectedError: "cloudProviderConfig rateLimits of client disk must not have a negative bucket",
+		},
+		{
+			name:          "tag value with a comma",
+			k:             &KubernetesConfig{CloudProviderConfig: &AzureCloudConfig{Tags: map[string]string{"owners": "a,b"}}},
+			expectedError: `cloudProviderConfig tag "owners"="a,b" is invalid, tags must have a key and cannot contain commas, nor equal signs in their key`,
+		},
+		{
+			name:          "tag key with an equal sign",
+			k:             &KubernetesConfig{CloudProviderConfig: &AzureCloudConfig{Tags: map[string]string{"a=b": "c"}}},
+			expectedError: `cloudProviderConfig tag "a=b"="c" is invalid, tags must have a key and cannot contain commas, nor equal signs in their key`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			err := c.k.validateCloudProviderConfig()
+			if c.expectedError == "" {
+				if err != nil {
+					t.Errorf("expected no error, but got %s", err)
+				}
+			} else if err == nil || err.Error() != c.expectedError {
+				t.Errorf("expected error %s, but got %v", c.expectedError, err)
+			}
+		})
+	}
+}
+
+func Test_KubernetesConfig_ValidateManifestPatches(t *testing.T) {
+	sidecar := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "kube-apiserver", "volumeMounts": []interface{}{map[string]interface{}{"name": "webhook", "mountPath": "/etc/webhook"}}},
+				map[string]interface{}{"name": "audit-forwarder", "image": "contoso/audit-forwarder:v1"},
 			},
-			expectedErr: errors.New("the Keyvault Secret must be specified for Extension FakeExtensionProfile"),
+			"volumes": []interface{}{map[string]interface{}{"name": "webhook", "hostPath": map[string]interface{}{"path": "/etc/webhook"}}},
 		},
+	}
+	cases := []struct {
+		name          string
+		k             *KubernetesConfig
+		expectedError string
+	}{
 		{
-			name: "Extension Profile with invalid secret format",
-			extensionProfiles: []*ExtensionProfile{
-				{
-					Name: "FakeExtensionProfile",
-					ExtensionParametersKeyVaultRef: &KeyvaultSecretRef{
-						VaultID:    "fakeVaultID",
-						SecretName: "fakeSecret",
-					},
+			name: "flags, volumes and a sidecar",
+			k: &KubernetesConfig{
+				ManifestPatches: []ManifestPatch{
+					{Component: "kube-apiserver", ExtraArgs: map[string]string{"--authentication-token-webhook-config-file": "/etc/webhook/config.yaml"}, Patch: sidecar},
+					{Component: "kube-scheduler", ExtraArgs: map[string]string{"--v": "4"}},
 				},
 			},
-			expectedErr: errors.New("Extension FakeExtensionProfile's keyvault secret reference is of incorrect format"),
+		},
+		{
+			name: "cloud-controller-manager",
+			k: &KubernetesConfig{
+				UseCloudControllerManager: to.BoolPtr(true),
+				ManifestPatches:           []ManifestPatch{{Component: "cloud-controller-manager", ExtraArgs: map[string]string{"--v": "4"}}},
+			},
+		},
+		{
+			name: "cloud-controller-manager without useCloudControllerManager",
+			k: &KubernetesConfig{
+				ManifestPatches: []ManifestPatch{{Component: "cloud-controller-manager", ExtraArgs: map[string]string{"--v": "4"}}},
+			},
+			expectedError: "manifestPatches for cloud-controller-manager require useCloudControllerManager",
+		},
+		{
+			name: "etcd",
+			k: &KubernetesConfig{
+				ManifestPatches: []ManifestPatch{{Component: "etcd", ExtraArgs: map[string]string{"--quota-backend-bytes": "8589934592"}}},
+			},
+			expectedError: "manifestPatches cannot patch etcd, it runs as a systemd service on the masters instead of a static pod",
+		},
+		{
+			name: "unknown component",
+			k: &KubernetesConfig{
+				ManifestPatches: []ManifestPatch{{Component: "kube-proxy"}},
+			},
+			expectedError: `manifestPatches component "kube-proxy" must be one of kube-apiserver, kube-controller-manager, kube-scheduler, cloud-controller-manager or kube-addon-manager`,
+		},
+		{
+			name: "kube-addon-manager flags",
+			k: &KubernetesConfig{
+				ManifestPatches: []ManifestPatch{{Component: "kube-addon-manager", ExtraArgs: map[string]string{"--v": "4"}}},
+			},
+			expectedError: "manifestPatches for kube-addon-manager cannot have extraArgs, kube-addon-manager has no flags",
+		},
+		{
+			name: "flag without dashes",
+			k: &KubernetesConfig{
+				ManifestPatches: []ManifestPatch{{Component: "kube-scheduler", ExtraArgs: map[string]string{"v": "4"}}},
+			},
+			expectedError: `manifestPatches extraArgs "v" for kube-scheduler must be a flag starting with --`,
+		},
+		{
+			name: "unnamed container",
+			k: &KubernetesConfig{
+				ManifestPatches: []ManifestPatch{{Component: "kube-scheduler", Patch: map[string]interface{}{
+					"spec": map[string]interface{}{"containers": []interface{}{map[string]interface{}{"image": "busybox"}}},
+				}}},
+			},
+			expectedError: "manifestPatches containers for kube-scheduler must have a name, they are merged by name",
+		},
+		{
+			name: "component container args",
+			k: &KubernetesConfig{
+				ManifestPatches: []ManifestPatch{{Component: "kube-scheduler", Patch: map[string]interface{}{
+					"spec": map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "kube-scheduler", "args": []interface{}{"--v=4"}}}},
+				}}},
+			},
+			expectedError: "manifestPatches for kube-scheduler cannot patch the args of its container, set the flags with extraArgs instead",
 		},
 	}
 
-	for _, test := range tests {
-		test := test
-		t.Run(test.name, func(t *testing.T) {
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
 			t.Parallel()
-			cs := getK8sDefaultContainerService(true)
-			cs.Properties.ExtensionProfiles = test.extensionProfiles
-			err := cs.Validate(true)
-			if !helpers.EqualError(err, test.expectedErr) {
-				t.Errorf("expected error with message : %s, but got %s", test.expectedErr.Error(), err.Error())
+			err := c.k.validateManifestPatches()
+			if c.expectedError == "" {
+				if err != nil {
+					t.Errorf("expected no error, but got %s", err)
+				}
+			} else if err == nil || err.Error() != c.expectedError {
+				t.Errorf("expected error %s, but got %v", c.expectedError, err)
 			}
 		})
 	}
 }
 
-func Test_ServicePrincipalProfile_ValidateSecretOrKeyvaultSecretRef(t *testing.T) {
+func Test_KubernetesConfig_ValidateCustomManifests(t *testing.T) {
+	cases := []struct {
+		name          string
+		k             *KubernetesConfig
+		expectedError string
+	}{
+		{
+			name: "data, url and path with dependencies",
+			k: &KubernetesConfig{
+				CustomManifests: []CustomManifest{
+					{Name: "app", Path: "/tmp/app.yaml", DependsOn: []string{"operator"}},
+					{Name: "operator", URL: "https://example.com/operator.yaml", Mode: "Reconcile", DependsOn: []string{"crds"}},
+					{Name: "crds", Data: "a2luZDogTmFtZXNwYWNl", Mode: "EnsureExists"},
+				},
+			},
+		},
+		{
+			name: "resolved url",
+			k: &KubernetesConfig{
+				CustomManifests: []CustomManifest{{Name: "operator", URL: "https://example.com/operator.yaml", Data: "a2luZDogTmFtZXNwYWNl"}},
+			},
+		},
+		{
+			name: "invalid name",
+			k: &KubernetesConfig{
+				CustomManifests: []CustomManifest{{Name: "My_Operator", Data: "a2luZDogTmFtZXNwYWNl"}},
+			},
+			expectedError: `customManifests name "My_Operator" must be a lowercase RFC 1123 label, e.g. my-operator`,
+		},
+		{
+			name: "duplicate name",
+			k: &KubernetesConfig{
+				CustomManifests: []CustomManifest{{Name: "operator", Data: "a2luZDogTmFtZXNwYWNl"}, {Name: "operator", Path: "/tmp/operator.yaml"}},
+			},
+			expectedError: "customManifests has more than one manifest named operator",
+		},
+		{
+			name: "url and path",
+			k: &KubernetesConfig{
+				CustomManifests: []CustomManifest{{Name: "operator", URL: "https://example.com/operator.yaml", Path: "/tmp/operator.yaml"}},
+			},
+			expectedError: "customManifests operator cannot have both a url and a path",
+		},
+		{
+			name: "no contents",
+			k: &KubernetesConfig{
+				CustomManifests: []CustomManifest{{Name: "operator"}},
+			},
+			expectedError: "customManifests operator must have its data, a url or a path",
+		},
+		{
+			name: "data not base64 encoded",
+			k: &KubernetesConfig{
+				CustomManifests: []CustomManifest{{Name: "operator", Data: "kind: Namespace"}},
+			},
+			expectedError: "customManifests operator data must be base64 encoded",
+		},
+		{
+			name: "invalid url",
+			k: &KubernetesConfig{
+				CustomManifests: []CustomManifest{{Name: "operator", URL: "ftp://example.com/operator.yaml"}},
+			},
+			expectedError: "customManifests operator url ftp://example.com/operator.yaml must be an http or https URL",
+		},
+		{
+			name: "invalid mode",
+			k: &KubernetesConfig{
+				CustomManifests: []CustomManifest{{Name: "operator", Path: "/tmp/operator.yaml", Mode: "Ignore"}},
+			},
+			expectedError: "customManifests operator mode Ignore must be Reconcile or EnsureExists",
+		},
+		{
+			name: "unknown dependency",
+			k: &KubernetesConfig{
+				CustomManifests: []CustomManifest{{Name: "operator", Path: "/tmp/operator.yaml", DependsOn: []string{"crds"}}},
+			},
+			expectedError: "customManifests operator depends on crds, which is not another custom manifest",
+		},
+		{
+			name: "dependency on itself",
+			k: &KubernetesConfig{
+				CustomManifests: []CustomManifest{{Name: "operator", Path: "/tmp/operator.yaml", DependsOn: []string{"operator"}}},
+			},
+			expectedError: "customManifests operator depends on operator, which is not another custom manifest",
+		},
+		{
+			name: "circular dependencies",
+			k: &KubernetesConfig{
+				CustomManifests: []CustomManifest{
+					{Name: "crds", Path: "/tmp/crds.yaml", DependsOn: []string{"operator"}},
+					{Name: "operator", Path: "/tmp/operator.yaml", DependsOn: []string{"crds"}},
+				},
+			},
+			expectedError: "customManifests dependsOn cannot be circular",
+		},
+	}
 
-	t.Run("ServicePrincipalProfile with secret should pass", func(t *testing.T) {
-		t.Parallel()
-		cs := getK8sDefaultContainerService(false)
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			err := c.k.validateCustomManifests()
+			if c.expectedError == "" {
+				if err != nil {
+					t.Errorf("expected no error, but got %s", err)
+				}
+			} else if err == nil || err.Error() != c.expectedError {
+				t.Errorf("expected error %s, but got %v", c.expectedError, err)
+			}
+		})
+	}
+}
 
-		if err := cs.Validate(false); err != nil {
-			t.Errorf("should not error %v", err)
-		}
-	})
+func Test_Properties_ValidateDistro(t *testing.T) {
+	p := &Properties{}
+	p.OrchestratorProfile = &OrchestratorProfile{}
+	p.OrchestratorProfile.OrchestratorType = Kubernetes
+	p.MasterProfile = &MasterProfile{
+		DNSPrefix: "foo",
+	}
 
-	t.Run("ServicePrincipalProfile with KeyvaultSecretRef (with version) should pass", func(t *testing.T) {
-		t.Parallel()
-		cs := getK8sDefaultContainerService(false)
-		cs.Properties.ServicePrincipalProfile.Secret = ""
-		cs.Properties.ServicePrincipalProfile.KeyvaultSecretRef = &KeyvaultSecretRef{
-			VaultID:       "/subscriptions/SUB-ID/resourceGroups/RG-NAME/providers/Microsoft.KeyVault/vaults/KV-NAME",
-			SecretName:    "secret-name",
-			SecretVersion: "version",
+	// Should not error on valid distros in non-update scenarios
+	for _, distro := range DistroValues {
+		p.MasterProfile.Distro = distro
+		p.AgentPoolProfiles = []*AgentPoolProfile{
+			{
+				Name:   "pool1",
+				Distro: distro,
+			},
 		}
-		if err := cs.Validate(false); err != nil {
-			t.Errorf("should not error %v", err)
+		if err := p.validateMasterProfile(false); err != nil {
+			t.Errorf(
+				"should not error on distro=\"%s\"",
+				distro,
+			)
 		}
-	})
-
-	t.Run("ServicePrincipalProfile with KeyvaultSecretRef (without version) should pass", func(t *testing.T) {
-		t.Parallel()
-		cs := getK8sDefaultContainerService(false)
-		cs.Properties.ServicePrincipalProfile.Secret = ""
-		cs.Properties.ServicePrincipalProfile.KeyvaultSecretRef = &KeyvaultSecretRef{
-			VaultID:    "/subscriptions/SUB-ID/resourceGroups/RG-NAME/providers/Microsoft.KeyVault/vaults/KV-NAME",
-			SecretName: "secret-name",
+		if err := p.validateAgentPoolProfiles(false); err != nil {
+			t.Errorf(
+				"should not error on distro=\"%s\"",
+				distro,
+			)
 		}
+	}
 
-		if err := cs.Validate(false); err != nil {
-			t.Errorf("should not error %v", err)
+	// Should not error on valid distros in update scenarios
+	for _, distro := range DistroValues {
+		p.MasterProfile.Distro = distro
+		p.AgentPoolProfiles = []*AgentPoolProfile{
+			{
+				Name:   "pool1",
+				Distro: distro,
+			},
 		}
-	})
-
-	t.Run("ServicePrincipalProfile with Secret and KeyvaultSecretRef should NOT pass", func(t *testing.T) {
-		t.Parallel()
-		cs := getK8sDefaultContainerService(false)
-		cs.Properties.ServicePrincipalProfile.Secret = "secret"
-		cs.Properties.ServicePrincipalProfile.KeyvaultSecretRef = &KeyvaultSecretRef{
-			VaultID:    "/subscriptions/SUB-ID/resourceGroups/RG-NAME/providers/Microsoft.KeyVault/vaults/KV-NAME",
-			SecretName: "secret-name",
+		if err := p.validateMasterProfile(true); err != nil {
+			t.Errorf(
+				"should not error on distro=\"%s\"",
+				distro,
+			)
 		}
-
-		if err := cs.Validate(false); err == nil {
-			t.Error("error should have occurred")
+		if err := p.validateAgentPoolProfiles(true); err != nil {
+			t.Errorf(
+				"should not error on distro=\"%s\"",
+				distro,
+			)
 		}
-	})
+	}
 
-	t.Run("ServicePrincipalProfile with incorrect KeyvaultSecretRef format should NOT pass", func(t *testing.T) {
-		t.Parallel()
-		cs := getK8sDefaultContainerService(false)
-		cs.Properties.ServicePrincipalProfile.Secret = ""
-		cs.Properties.ServicePrincipalProfile.KeyvaultSecretRef = &KeyvaultSecretRef{
-			VaultID:    "randomID",
-			SecretName: "secret-name",
+	// Should error for invalid distros on non-update scenarios
+	bogusDistroValues := []Distro{AKSDockerEngine, AKS1604Deprecated, AKS1804Deprecated, "bogon"}
+	for _, distro := range bogusDistroValues {
+		p.MasterProfile.Distro = distro
+		p.AgentPoolProfiles = []*AgentPoolProfile{
+			{
+				Name:   "pool1",
+				Distro: distro,
+			},
 		}
-
-		if err := cs.Validate(false); err == nil || err.Error() != "service principal client keyvault secret reference is of incorrect format" {
-			t.Error("error should have occurred")
+		if err := p.validateMasterProfile(false); err == nil {
+			t.Errorf(
+				"should error on distro=\"%s\"",
+				distro,
+			)
 		}
-	})
-}
-
-func TestValidateKubernetesLabelValue(t *testing.T) {
-
-	validLabelValues := []string{"", "a", "a1", "this--valid--label--is--exactly--sixty--three--characters--long", "123456", "my-label_valid.com"}
-	invalidLabelValues := []string{"a$$b", "-abc", "not.valid.", "This____long____label___is______sixty______four_____chararacters", "Label with spaces"}
-
-	for _, l := range validLabelValues {
-		if err := validateKubernetesLabelValue(l); err != nil {
-			t.Fatalf("Label value %v should not return error: %v", l, err)
-		}
-	}
-
-	for _, l := range invalidLabelValues {
-		if err := validateKubernetesLabelValue(l); err == nil {
-			t.Fatalf("Label value %v should return an error", l)
-		}
-	}
-}
-
-func TestValidateKubernetesLabelKey(t *testing.T) {
-
-	validLabelKeys := []string{"a", "a1", "this--valid--label--is--exactly--sixty--three--characters--long", "123456", "my-label_valid.com", "foo.bar/name", "1.2321.324/key_name.foo", "valid.long.253.characters.label.key.prefix.12345678910.fooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooo/my-key"}
-	invalidLabelKeys := []string{"", "a/b/c", ".startswithdot", "spaces in key", "foo/", "/name", "$.$/com", "too-long-254-characters-key-prefix-------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------123/name", "wrong-slash\\foo"}
-
-	for _, l := range validLabelKeys {
-		if err := validateKubernetesLabelKey(l); err != nil {
-			t.Fatalf("Label key %v should not return error: %v", l, err)
-		}
-	}
-
-	for _, l := range invalidLabelKeys {
-		if err := validateKubernetesLabelKey(l); err == nil {
-			t.Fatalf("Label key %v should return an error", l)
-		}
-	}
-}
-
-func Test_AadProfile_Validate(t *testing.T) {
-	t.Run("Valid aadProfile should pass", func(t *testing.T) {
-		t.Parallel()
-		cs := getK8sDefaultContainerService(false)
-		for _, aadProfile := range []*AADProfile{
-			{
-				ClientAppID: "92444486-5bc3-4291-818b-d53ae480991b",
-				ServerAppID: "403f018b-4d89-495b-b548-0cf9868cdb0a",
-			},
-			{
-				ClientAppID: "92444486-5bc3-4291-818b-d53ae480991b",
-				ServerAppID: "403f018b-4d89-495b-b548-0cf9868cdb0a",
-				TenantID:    "feb784f6-7174-46da-aeae-da66e80c7a11",
-			},
-		} {
-			cs.Properties.AADProfile = aadProfile
-			if err := cs.Properties.validateAADProfile(); err != nil {
-				t.Errorf("should not error %v", err)
-			}
-		}
-	})
-
-	t.Run("Invalid aadProfiles should NOT pass", func(t *testing.T) {
-		t.Parallel()
-		cs := getK8sDefaultContainerService(false)
-		for _, aadProfile := range []*AADProfile{
-			{
-				ClientAppID: "1",
-				ServerAppID: "d",
-			},
-			{
-				ClientAppID: "6a247d73-ae33-4559-8e5d-4001fdc17b15",
-			},
-			{
-				ClientAppID: "92444486-5bc3-4291-818b-d53ae480991b",
-				ServerAppID: "403f018b-4d89-495b-b548-0cf9868cdb0a",
-				TenantID:    "1",
-			},
-			{
-				ClientAppID:  "92444486-5bc3-4291-818b-d53ae480991b",
-				ServerAppID:  "403f018b-4d89-495b-b548-0cf9868cdb0a",
-				TenantID:     "feb784f6-7174-46da-aeae-da66e80c7a11",
-				AdminGroupID: "1",
-			},
-			{},
-		} {
-			cs.Properties.AADProfile = aadProfile
-			if err := cs.Validate(true); err == nil {
-				t.Errorf("error should have occurred")
-			}
-		}
-	})
-
-	t.Run("aadProfiles should not be supported non-Kubernetes orchestrators", func(t *testing.T) {
-		t.Parallel()
-		cs := getK8sDefaultContainerService(false)
-		cs.Properties.OrchestratorProfile = &OrchestratorProfile{
-			OrchestratorType: DCOS,
-		}
-		cs.Properties.AADProfile = &AADProfile{
-			ClientAppID: "92444486-5bc3-4291-818b-d53ae480991b",
-			ServerAppID: "403f018b-4d89-495b-b548-0cf9868cdb0a",
-		}
-		expectedMsg := "'aadProfile' is only supported by orchestrator 'Kubernetes'"
-		if err := cs.Properties.validateAADProfile(); err == nil || err.Error() != expectedMsg {
-			t.Errorf("error should have occurred with msg : %s, but got : %s", expectedMsg, err.Error())
+		if err := p.validateAgentPoolProfiles(false); err == nil {
+			t.Errorf(
+				"should error on distro=\"%s\"",
+				distro,
+			)
 		}
-	})
-}
-
-func TestProperties_ValidateInvalidStruct(t *testing.T) {
-	cs := getK8sDefaultContainerService(false)
-	cs.Properties.OrchestratorProfile = &OrchestratorProfile{}
-	expectedMsg := "missing Properties.OrchestratorProfile.OrchestratorType"
-	if err := cs.Validate(false); err == nil || err.Error() != expectedMsg {
-		t.Errorf("expected validation error with message : %s", err.Error())
-	}
-}
-
-func getK8sDefaultContainerService(hasWindows bool) *ContainerService {
-	p := &Properties{
-		OrchestratorProfile: &OrchestratorProfile{
-			OrchestratorType: Kubernetes,
-		},
-		MasterProfile: &MasterProfile{
-			Count:     1,
-			DNSPrefix: "foo",
-			VMSize:    "Standard_DS2_v2",
-		},
-		AgentPoolProfiles: []*AgentPoolProfile{
-			{
-				Name:                "agentpool",
-				VMSize:              "Standard_D2_v2",
-				Count:               1,
-				AvailabilityProfile: AvailabilitySet,
-			},
-		},
-		LinuxProfile: &LinuxProfile{
-			AdminUsername: "azureuser",
-			SSH: struct {
-				PublicKeys []PublicKey `json:"publicKeys" validate:"required,min=1"`
-			}{
-				PublicKeys: []PublicKey{{
-					KeyData: "publickeydata",
-				}},
-			},
-		},
-		ServicePrincipalProfile: &ServicePrincipalProfile{
-			ClientID: "clientID",
-			Secret:   "clientSecret",
-		},
 	}
 
-	if hasWindows {
+	// Should not error for deprecated distro on update scenarios
+	oldDistros := []Distro{AKSDockerEngine, AKS1604Deprecated, AKS1804Deprecated}
+	for _, distro := range oldDistros {
+		p.MasterProfile.Distro = distro
 		p.AgentPoolProfiles = []*AgentPoolProfile{
 			{
-				Name:                "agentpool",
-				VMSize:              "Standard_D2_v2",
-				Count:               1,
-				AvailabilityProfile: AvailabilitySet,
-				OSType:              Windows,
+				Name:   "pool1",
+				Distro: distro,
 			},
 		}
-		p.WindowsProfile = &WindowsProfile{
-			AdminUsername: "azureuser",
-			AdminPassword: "replacepassword1234$",
+		if err := p.validateMasterProfile(true); err != nil {
+			t.Errorf(
+				"should error on distro=\"%s\"",
+				distro,
+			)
+		}
+		if err := p.validateAgentPoolProfiles(true); err != nil {
+			t.Errorf(
+				"should error on distro=\"%s\"",
+				distro,
+			)
 		}
 	}
-	cs := ContainerService{
-		Location:   "westus",
-		Properties: p,
-	}
-	return &cs
 }
 
-func Test_Properties_ValidateContainerRuntime(t *testing.T) {
+func Test_Properties_ValidateNetworkPolicy(t *testing.T) {
 	p := &Properties{}
 	p.OrchestratorProfile = &OrchestratorProfile{}
 	p.OrchestratorProfile.OrchestratorType = Kubernetes
 
-	for _, runtime := range ContainerRuntimeValues {
+	k8sVersion := "1.8.0"
+	for _, policy := range NetworkPolicyValues {
 		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{}
-		p.OrchestratorProfile.KubernetesConfig.ContainerRuntime = runtime
-		if err := p.validateContainerRuntime(); err != nil {
+		p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = policy
+		if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPolicy(k8sVersion, false); err != nil {
 			t.Errorf(
-				"should not error on containerRuntime=\"%s\"",
-				runtime,
+				"should not error on networkPolicy=\"%s\" on k8sVersion=\"%s\"",
+				policy,
+				k8sVersion,
 			)
 		}
 	}
 
-	p.OrchestratorProfile.KubernetesConfig.ContainerRuntime = "not-existing"
-	if err := p.validateContainerRuntime(); err == nil {
+	p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = "not-existing"
+	if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPolicy(k8sVersion, false); err == nil {
 		t.Errorf(
-			"should error on invalid containerRuntime",
+			"should error on invalid networkPolicy",
 		)
 	}
 
-	p.OrchestratorProfile.KubernetesConfig.ContainerRuntime = KataContainers
-	p.AgentPoolProfiles = []*AgentPoolProfile{
-		{
-			OSType: Windows,
-		},
+	k8sVersion = "1.7.9"
+	p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = "azure"
+	p.OrchestratorProfile.KubernetesConfig.NetworkPlugin = "azure"
+	if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPolicy(k8sVersion, false); err == nil {
+		t.Errorf(
+			"should error on azure networkPolicy + azure networkPlugin with k8s version < 1.8.0",
+		)
 	}
-	if err := p.validateContainerRuntime(); err == nil {
+
+	p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = "calico"
+	if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPolicy(k8sVersion, true); err == nil {
 		t.Errorf(
-			"should error on kata-containers for windows clusters",
+			"should error on calico for windows clusters",
 		)
 	}
 
-	p.OrchestratorProfile.KubernetesConfig.ContainerRuntime = Containerd
-	p.AgentPoolProfiles = []*AgentPoolProfile{
-		{
-			OSType: Windows,
-		},
+	p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = NetworkPolicyCilium
+	if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPolicy(k8sVersion, true); err == nil {
+		t.Errorf(
+			"should error on cilium for windows clusters",
+		)
 	}
-	if err := p.validateContainerRuntime(); err == nil {
+
+	p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = "flannel"
+	if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPolicy(k8sVersion, true); err == nil {
+		t.Errorf(
+			"should error on flannel for windows clusters",
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = NetworkPolicyAntrea
+	if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPolicy(k8sVersion, true); err == nil {
+		t.Errorf(
+			"should error on antrea for windows clusters",
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = ""
+	p.OrchestratorProfile.KubernetesConfig.NetworkPlugin = NetworkPluginCilium
+	if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPolicy(k8sVersion, true); err == nil {
 		t.Errorf(
-			"should error on containerd for windows clusters",
+			"should error on the cilium network plugin for windows clusters",
 		)
 	}
 }
 
-func Test_Properties_ValidateAddons(t *testing.T) {
+func Test_Properties_ValidateNetworkPlugin(t *testing.T) {
 	p := &Properties{}
 	p.OrchestratorProfile = &OrchestratorProfile{}
 	p.OrchestratorProfile.OrchestratorType = Kubernetes
 
-	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-		Addons: []KubernetesAddon{
-			{
-				Name:    "cluster-autoscaler",
-				Enabled: to.BoolPtr(true),
-			},
-		},
-	}
-	p.AgentPoolProfiles = []*AgentPoolProfile{
-		{
-			AvailabilityProfile: AvailabilitySet,
-		},
+	for _, policy := range NetworkPluginValues {
+		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{}
+		p.OrchestratorProfile.KubernetesConfig.NetworkPlugin = policy
+		if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPlugin(); err != nil {
+			t.Errorf(
+				"should not error on networkPolicy=\"%s\"",
+				policy,
+			)
+		}
 	}
-	if err := p.validateAddons(); err == nil {
+
+	p.OrchestratorProfile.KubernetesConfig.NetworkPlugin = "not-existing"
+	if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPlugin(); err == nil {
 		t.Errorf(
-			"should error on cluster-autoscaler with availability sets",
+			"should error on invalid networkPlugin",
 		)
 	}
+}
 
-	p.AgentPoolProfiles = []*AgentPoolProfile{
+func Test_Properties_ValidateNetworkPluginPlusPolicy(t *testing.T) {
+	p := &Properties{}
+	p.OrchestratorProfile = &OrchestratorProfile{}
+	p.OrchestratorProfile.OrchestratorType = Kubernetes
+
+	for _, config := range networkPluginPlusPolicyAllowed {
+		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{}
+		p.OrchestratorProfile.KubernetesConfig.NetworkPlugin = config.networkPlugin
+		p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = config.networkPolicy
+		if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPluginPlusPolicy(); err != nil {
+			t.Errorf(
+				"should not error on networkPolicy=\"%s\" + networkPlugin=\"%s\"",
+				config.networkPolicy, config.networkPlugin,
+			)
+		}
+	}
+
+	for _, config := range []k8sNetworkConfig{
 		{
-			VMSize: "Standard_NC6",
+			networkPlugin: "azure",
+			networkPolicy: NetworkPolicyCilium,
 		},
-	}
-	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-		Addons: []KubernetesAddon{
-			{
-				Name:    "nvidia-device-plugin",
-				Enabled: to.BoolPtr(true),
-			},
+		{
+			networkPlugin: "azure",
+			networkPolicy: "flannel",
 		},
-	}
-	p.OrchestratorProfile.OrchestratorRelease = "1.9"
-	if err := p.validateAddons(); err == nil {
-		t.Errorf(
-			"should error on nvidia-device-plugin with k8s < 1.10",
-		)
-	}
-
-	p.OrchestratorProfile.OrchestratorRelease = "1.10"
-	if err := p.validateAddons(); err != nil {
-		t.Errorf(
-			"should not error on nvidia-device-plugin with k8s >= 1.10",
-		)
-	}
-	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-		Addons: []KubernetesAddon{
-			{
-				Name: "kube-proxy-daemonset",
-				Data: "asdasdasdasda",
-				Config: map[string]string{
-					"foo": "bar",
-				},
-			},
+		{
+			networkPlugin: "azure",
+			networkPolicy: NetworkPolicyAntrea,
 		},
-	}
-	if err := p.validateAddons(); err == nil {
-		t.Errorf(
-			"expected error for non-empty Config with non-empty Data",
-		)
-	}
-	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-		Addons: []KubernetesAddon{
-			{
-				Name: "kube-proxy-daemonset",
-				Data: "asdasdasdasda",
-				Containers: []KubernetesContainerSpec{
-					{
-						Name: "FooContainerSpec",
-					},
-				},
-			},
+		{
+			networkPlugin: NetworkPluginCilium,
+			networkPolicy: NetworkPolicyAntrea,
 		},
-	}
-	if err := p.validateAddons(); err == nil {
-		t.Errorf(
-			"expected error for non-empty Containers with non-empty Data",
-		)
-	}
-	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-		Addons: []KubernetesAddon{
-			{
-				Name: "kube-proxy-daemonset",
-				Data: "foodata",
-			},
+		{
+			networkPlugin: "kubenet",
+			networkPolicy: "none",
 		},
-	}
-	if err := p.validateAddons(); err == nil {
-		t.Errorf(
-			"expected error for invalid base64",
-		)
-	}
-	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-		Addons: []KubernetesAddon{
-			{
-				Name: "kube-proxy-daemonset",
-				Data: "Zm9vZGF0YQ==",
-			},
+		{
+			networkPlugin: "azure",
+			networkPolicy: "none",
 		},
-	}
-	if err := p.validateAddons(); err != nil {
-		t.Errorf(
-			"should not error on providing valid addon.Data",
-		)
-	}
-
-	p.AgentPoolProfiles = []*AgentPoolProfile{
 		{
-			AvailabilityProfile: AvailabilitySet,
-			Distro:              CoreOS,
+			networkPlugin: "kubenet",
+			networkPolicy: "kubenet",
 		},
+	} {
+		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{}
+		p.OrchestratorProfile.KubernetesConfig.NetworkPlugin = config.networkPlugin
+		p.OrchestratorProfile.KubernetesConfig.NetworkPolicy = config.networkPolicy
+		if err := p.OrchestratorProfile.KubernetesConfig.validateNetworkPluginPlusPolicy(); err == nil {
+			t.Errorf(
+				"should error on networkPolicy=\"%s\" + networkPlugin=\"%s\"",
+				config.networkPolicy, config.networkPlugin,
+			)
+		}
 	}
+}
 
-	p.MasterProfile = &MasterProfile{
-		Distro: CoreOS,
-	}
-
-	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-		Addons: []KubernetesAddon{
-			{
-				Name:    "smb-flexvolume",
-				Enabled: to.BoolPtr(true),
-			},
-		},
+func TestProperties_ValidateLinuxProfile(t *testing.T) {
+	cs := getK8sDefaultContainerService(true)
+	cs.Properties.LinuxProfile.SSH = struct {
+		PublicKeys []PublicKey `json:"publicKeys" validate:"required,min=1"`
+	}{
+		PublicKeys: []PublicKey{{}},
 	}
+	expectedMsg := "KeyData in LinuxProfile.SSH.PublicKeys cannot be empty string"
+	err := cs.Validate(true)
 
-	if err := p.validateAddons(); err == nil {
-		t.Errorf(
-			"should error using incompatible addon with coreos (smb-flexvolume)",
-		)
+	if err.Error() != expectedMsg {
+		t.Errorf("expected error message : %s to be thrown, but got : %s", expectedMsg, err.Error())
 	}
 
-	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-		Addons: []KubernetesAddon{
+	cs.Properties.LinuxProfile.SSH = struct {
+		PublicKeys []PublicKey `json:"publicKeys" validate:"required,min=1"`
+	}{
+		PublicKeys: []PublicKey{
 			{
-				Name:    "keyvault-flexvolume",
-				Enabled: to.BoolPtr(true),
+				KeyData: "not empty",
 			},
+			{},
 		},
 	}
+	expectedMsg = "KeyData in LinuxProfile.SSH.PublicKeys cannot be empty string"
+	err = cs.Validate(true)
 
-	if err := p.validateAddons(); err == nil {
-		t.Errorf(
-			"should error using incompatible addon with coreos (keyvault-flexvolume)",
-		)
+	if err.Error() != expectedMsg {
+		t.Errorf("expected error message : %s to be thrown, but got : %s", expectedMsg, err.Error())
 	}
+}
 
-	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-		Addons: []KubernetesAddon{
-			{
-				Name:    "blobfuse-flexvolume",
-				Enabled: to.BoolPtr(true),
+func TestProperties_ValidateInvalidExtensions(t *testing.T) {
+	tests := []struct {
+		name              string
+		agentPoolProfiles []*AgentPoolProfile
+		expectedErr       error
+	}{
+		{
+			name: "Extensions for VirtualMachineScaleSets",
+			agentPoolProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_D2_v2",
+					Count:               1,
+					AvailabilityProfile: VirtualMachineScaleSets,
+					Extensions: []Extension{
+						{
+							Name:        "extensionName",
+							SingleOrAll: "single",
+							Template:    "fakeTemplate",
+						},
+					},
+				},
 			},
+			expectedErr: errors.New("Extensions are currently not supported with VirtualMachineScaleSets. Please specify \"availabilityProfile\": \"AvailabilitySet\""),
 		},
-	}
-
-	if err := p.validateAddons(); err == nil {
-		t.Errorf(
-			"should error using incompatible addon with coreos (blobfuse-flexvolume)",
-		)
-	}
-
-	// appgw-ingress add-on
-
-	// Basic test with UseManagedIdentity
-	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-		NetworkPlugin:      "azure",
-		UseManagedIdentity: true,
-		Addons: []KubernetesAddon{
-			{
-				Name:    "appgw-ingress",
-				Enabled: to.BoolPtr(true),
-				Config: map[string]string{
-					"appgw-subnet": "10.0.0.0/16",
+		{
+			name: "prometheus-grafana-k8s extensions for Winows agents",
+			agentPoolProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_D2_v2",
+					Count:               1,
+					AvailabilityProfile: AvailabilitySet,
+					OSType:              "Windows",
+					Extensions: []Extension{
+						{
+							Name: "prometheus-grafana-k8s",
+						},
+					},
 				},
 			},
+			expectedErr: errors.New("prometheus-grafana-k8s extension is currently not supported for Windows agents"),
 		},
 	}
 
-	if err := p.validateAddons(); err != nil {
-		t.Error(
-			"should not error for correct config.",
-			err,
-		)
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			cs := getK8sDefaultContainerService(true)
+			cs.Properties.AgentPoolProfiles = test.agentPoolProfiles
+			err := cs.Validate(true)
+			if !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error with message : %s, but got %s", test.expectedErr.Error(), err.Error())
+			}
+		})
 	}
 
-	// Basic test with ObjectID
-	p.ServicePrincipalProfile = &ServicePrincipalProfile{
-		ObjectID: "random",
-	}
-	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-		NetworkPlugin: "azure",
-		Addons: []KubernetesAddon{
-			{
-				Name:    "appgw-ingress",
-				Enabled: to.BoolPtr(true),
-				Config: map[string]string{
-					"appgw-subnet": "10.0.0.0/16",
+}
+
+func TestProperties_ValidateInvalidExtensionProfiles(t *testing.T) {
+	tests := []struct {
+		name              string
+		extensionProfiles []*ExtensionProfile
+		expectedErr       error
+	}{
+		{
+			name: "Extension Profile without Keyvault ID",
+			extensionProfiles: []*ExtensionProfile{
+				{
+					Name: "FakeExtensionProfile",
+					ExtensionParametersKeyVaultRef: &KeyvaultSecretRef{
+						VaultID:    "",
+						SecretName: "fakeSecret",
+					},
+				},
+			},
+			expectedErr: errors.New("the Keyvault ID must be specified for Extension FakeExtensionProfile"),
+		},
+		{
+			name: "Extension Profile without Keyvault Secret",
+			extensionProfiles: []*ExtensionProfile{
+				{
+					Name: "FakeExtensionProfile",
+					ExtensionParametersKeyVaultRef: &KeyvaultSecretRef{
+						VaultID:    "fakeVaultID",
+						SecretName: "",
+					},
+				},
+			},
+			expectedErr: errors.New("the Keyvault Secret must be specified for Extension FakeExtensionProfile"),
+		},
+		{
+			name: "Extension Profile with invalid secret format",
+			extensionProfiles: []*ExtensionProfile{
+				{
+					Name: "FakeExtensionProfile",
+					ExtensionParametersKeyVaultRef: &KeyvaultSecretRef{
+						VaultID:    "fakeVaultID",
+						SecretName: "fakeSecret",
+					},
 				},
 			},
+			expectedErr: errors.New("Extension FakeExtensionProfile's keyvault secret reference is of incorrect format"),
 		},
 	}
 
-	if err := p.validateAddons(); err != nil {
-		t.Error(
-			"should not error for correct config.",
-			err,
-		)
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			cs := getK8sDefaultContainerService(true)
+			cs.Properties.ExtensionProfiles = test.extensionProfiles
+			err := cs.Validate(true)
+			if !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error with message : %s, but got %s", test.expectedErr.Error(), err.Error())
+			}
+		})
 	}
+}
 
-	// Test with missing objectID and UseManagedIdentity false
-	p.ServicePrincipalProfile = &ServicePrincipalProfile{}
-	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-		NetworkPlugin: "azure",
-		Addons: []KubernetesAddon{
-			{
-				Name:    "appgw-ingress",
-				Enabled: to.BoolPtr(true),
-				Config: map[string]string{
-					"appgw-subnet": "10.0.0.0/16",
-				},
-			},
-		},
+func Test_ServicePrincipalProfile_ValidateSecretOrKeyvaultSecretRef(t *testing.T) {
+
+	t.Run("ServicePrincipalProfile with secret should pass", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+
+		if err := cs.Validate(false); err != nil {
+			t.Errorf("should not error %v", err)
+		}
+	})
+
+	t.Run("ServicePrincipalProfile with KeyvaultSecretRef (with version) should pass", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		cs.Properties.ServicePrincipalProfile.Secret = ""
+		cs.Properties.ServicePrincipalProfile.KeyvaultSecretRef = &KeyvaultSecretRef{
+			VaultID:       "/subscriptions/SUB-ID/resourceGroups/RG-NAME/providers/Microsoft.KeyVault/vaults/KV-NAME",
+			SecretName:    "secret-name",
+			SecretVersion: "version",
+		}
+		if err := cs.Validate(false); err != nil {
+			t.Errorf("should not error %v", err)
+		}
+	})
+
+	t.Run("ServicePrincipalProfile with KeyvaultSecretRef (without version) should pass", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		cs.Properties.ServicePrincipalProfile.Secret = ""
+		cs.Properties.ServicePrincipalProfile.KeyvaultSecretRef = &KeyvaultSecretRef{
+			VaultID:    "/subscriptions/SUB-ID/resourceGroups/RG-NAME/providers/Microsoft.KeyVault/vaults/KV-NAME",
+			SecretName: "secret-name",
+		}
+
+		if err := cs.Validate(false); err != nil {
+			t.Errorf("should not error %v", err)
+		}
+	})
+
+	t.Run("ServicePrincipalProfile with Secret and KeyvaultSecretRef should NOT pass", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		cs.Properties.ServicePrincipalProfile.Secret = "secret"
+		cs.Properties.ServicePrincipalProfile.KeyvaultSecretRef = &KeyvaultSecretRef{
+			VaultID:    "/subscriptions/SUB-ID/resourceGroups/RG-NAME/providers/Microsoft.KeyVault/vaults/KV-NAME",
+			SecretName: "secret-name",
+		}
+
+		if err := cs.Validate(false); err == nil {
+			t.Error("error should have occurred")
+		}
+	})
+
+	t.Run("ServicePrincipalProfile with incorrect KeyvaultSecretRef format should NOT pass", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		cs.Properties.ServicePrincipalProfile.Secret = ""
+		cs.Properties.ServicePrincipalProfile.KeyvaultSecretRef = &KeyvaultSecretRef{
+			VaultID:    "randomID",
+			SecretName: "secret-name",
+		}
+
+		if err := cs.Validate(false); err == nil || err.Error() != "service principal client keyvault secret reference is of incorrect format" {
+			t.Error("error should have occurred")
+		}
+	})
+}
+
+func TestValidateKubernetesLabelValue(t *testing.T) {
+
+	validLabelValues := []string{"", "a", "a1", "this--valid--label--is--exactly--sixty--three--characters--long", "123456", "my-label_valid.com"}
+	invalidLabelValues := []string{"a$$b", "-abc", "not.valid.", "This____long____label___is______sixty______four_____chararacters", "Label with spaces"}
+
+	for _, l := range validLabelValues {
+		if err := validateKubernetesLabelValue(l); err != nil {
+			t.Fatalf("Label value %v should not return error: %v", l, err)
+		}
 	}
 
-	if err := p.validateAddons(); err == nil {
-		t.Error(
-			"should error as objectID not provided or UseManagedIdentity not true",
-			err,
-		)
+	for _, l := range invalidLabelValues {
+		if err := validateKubernetesLabelValue(l); err == nil {
+			t.Fatalf("Label value %v should return an error", l)
+		}
 	}
+}
 
-	// Test with wrong Network Plugin
-	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-		NetworkPlugin: "kubelet",
-		Addons: []KubernetesAddon{
-			{
-				Name:    "appgw-ingress",
-				Enabled: to.BoolPtr(true),
-				Config: map[string]string{
-					"appgw-subnet": "10.0.0.0/16",
-				},
-			},
-		},
+func TestValidateKubernetesLabelKey(t *testing.T) {
+
+	validLabelKeys := []string{"a", "a1", "this--valid--label--is--exactly--sixty--three--characters--long", "123456", "my-label_valid.com", "foo.bar/name", "1.2321.324/key_name.foo", "valid.long.253.characters.label.key.prefix.12345678910.fooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooooo/my-key"}
+	invalidLabelKeys := []string{"", "a/b/c", ".startswithdot", "spaces in key", "foo/", "/name", "$.$/com", "too-long-254-characters-key-prefix-------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------123/name", "wrong-slash\\foo"}
+
+	for _, l := range validLabelKeys {
+		if err := validateKubernetesLabelKey(l); err != nil {
+			t.Fatalf("Label key %v should not return error: %v", l, err)
+		}
 	}
 
-	if err := p.validateAddons(); err == nil {
-		t.Errorf(
-			"should error using when not using 'azure' for Network Plugin",
-		)
+	for _, l := range invalidLabelKeys {
+		if err := validateKubernetesLabelKey(l); err == nil {
+			t.Fatalf("Label key %v should return an error", l)
+		}
 	}
+}
 
-	// Test with missing appgw-subnet
-	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-		NetworkPlugin: "azure",
-		Addons: []KubernetesAddon{
+func Test_AadProfile_Validate(t *testing.T) {
+	t.Run("Valid aadProfile should pass", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		for _, aadProfile := range []*AADProfile{
 			{
-				Name:    "appgw-ingress",
-				Enabled: to.BoolPtr(true),
-				Config:  map[string]string{},
+				ClientAppID: "92444486-5bc3-4291-818b-d53ae480991b",
+				ServerAppID: "403f018b-4d89-495b-b548-0cf9868cdb0a",
 			},
-		},
-	}
+			{
+				ClientAppID: "92444486-5bc3-4291-818b-d53ae480991b",
+				ServerAppID: "403f018b-4d89-495b-b548-0cf9868cdb0a",
+				TenantID:    "feb784f6-7174-46da-aeae-da66e80c7a11",
+			},
+		} {
+			cs.Properties.AADProfile = aadProfile
+			if err := cs.Properties.validateAADProfile(); err != nil {
+				t.Errorf("should not error %v", err)
+			}
+		}
+	})
 
-	if err := p.validateAddons(); err == nil {
-		t.Errorf(
-			"should error when missing the subnet for Application Gateway",
-		)
+	t.Run("Invalid aadProfiles should NOT pass", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		for _, aadProfile := range []*AADProfile{
+			{
+				ClientAppID: "1",
+				ServerAppID: "d",
+			},
+			{
+				ClientAppID: "6a247d73-ae33-4559-8e5d-4001fdc17b15",
+			},
+			{
+				ClientAppID: "92444486-5bc3-4291-818b-d53ae480991b",
+				ServerAppID: "403f018b-4d89-495b-b548-0cf9868cdb0a",
+				TenantID:    "1",
+			},
+			{
+				ClientAppID:  "92444486-5bc3-4291-818b-d53ae480991b",
+				ServerAppID:  "403f018b-4d89-495b-b548-0cf9868cdb0a",
+				TenantID:     "feb784f6-7174-46da-aeae-da66e80c7a11",
+				AdminGroupID: "1",
+			},
+			{},
+		} {
+			cs.Properties.AADProfile = aadProfile
+			if err := cs.Validate(true); err == nil {
+				t.Errorf("error should have occurred")
+			}
+		}
+	})
+
+	t.Run("aadProfiles should not be supported non-Kubernetes orchestrators", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		cs.Properties.OrchestratorProfile = &OrchestratorProfile{
+			OrchestratorType: DCOS,
+		}
+		cs.Properties.AADProfile = &AADProfile{
+			ClientAppID: "92444486-5bc3-4291-818b-d53ae480991b",
+			ServerAppID: "403f018b-4d89-495b-b548-0cf9868cdb0a",
+		}
+		expectedMsg := "'aadProfile' is only supported by orchestrator 'Kubernetes'"
+		if err := cs.Properties.validateAADProfile(); err == nil || err.Error() != expectedMsg {
+			t.Errorf("error should have occurred with msg : %s, but got : %s", expectedMsg, err.Error())
+		}
+	})
+}
+
+func Test_Properties_ValidateAirGapProfile(t *testing.T) {
+	airGapProfile := func() *AirGapProfile {
+		return &AirGapProfile{
+			Enabled:     to.BoolPtr(true),
+			BinariesURL: "https://mirror.blob.core.windows.net/binaries",
+			PackagesURL: "http://10.0.0.4/apt",
+		}
+	}
+	cases := []struct {
+		name        string
+		setup       func(p *Properties)
+		expectedErr string
+	}{
+		{
+			name:  "air-gapped cluster",
+			setup: func(p *Properties) {},
+		},
+		{
+			name: "disabled air gap profile",
+			setup: func(p *Properties) {
+				p.OrchestratorProfile.KubernetesConfig.AirGapProfile = &AirGapProfile{Enabled: to.BoolPtr(false)}
+				p.OrchestratorProfile.KubernetesConfig.ImageRegistry = ""
+			},
+		},
+		{
+			name: "missing binaries URL",
+			setup: func(p *Properties) {
+				p.OrchestratorProfile.KubernetesConfig.AirGapProfile.BinariesURL = ""
+			},
+			expectedErr: "airGapProfile binariesURL is required when airGapProfile is enabled",
+		},
+		{
+			name: "invalid packages URL",
+			setup: func(p *Properties) {
+				p.OrchestratorProfile.KubernetesConfig.AirGapProfile.PackagesURL = "ftp://10.0.0.4/apt"
+			},
+			expectedErr: "airGapProfile packagesURL ftp://10.0.0.4/apt must be an http or https URL",
+		},
+		{
+			name: "missing image registry",
+			setup: func(p *Properties) {
+				p.OrchestratorProfile.KubernetesConfig.ImageRegistry = ""
+			},
+			expectedErr: "airGapProfile requires an imageRegistry the nodes pull the container images from",
+		},
+		{
+			name: "kata-containers",
+			setup: func(p *Properties) {
+				p.OrchestratorProfile.KubernetesConfig.ContainerRuntime = KataContainers
+			},
+			expectedErr: "airGapProfile is not supported with the kata-containers containerRuntime",
+		},
+		{
+			name: "Windows agent pool",
+			setup: func(p *Properties) {
+				p.AgentPoolProfiles[0].OSType = Windows
+			},
+			expectedErr: "airGapProfile is not supported with Windows agent pools, agent pool agentpool is Windows",
+		},
+		{
+			name: "GPU agent pool",
+			setup: func(p *Properties) {
+				p.AgentPoolProfiles[0].VMSize = "Standard_NC6"
+			},
+			expectedErr: "airGapProfile is not supported with GPU or SGX VM sizes, agent pool agentpool has vmSize Standard_NC6",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			cs := getK8sDefaultContainerService(false)
+			cs.Properties.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+				AirGapProfile: airGapProfile(),
+				ImageRegistry: "contoso.azurecr.io",
+			}
+			c.setup(cs.Properties)
+			err := cs.Properties.validateAirGapProfile()
+			if c.expectedErr == "" {
+				if err != nil {
+					t.Errorf("should not error %v", err)
+				}
+			} else if err == nil || err.Error() != c.expectedErr {
+				t.Errorf("expected error %q, got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestProperties_ValidateInvalidStruct(t *testing.T) {
+	cs := getK8sDefaultContainerService(false)
+	cs.Properties.OrchestratorProfile = &OrchestratorProfile{}
+	expectedMsg := "missing Properties.OrchestratorProfile.OrchestratorType"
+	if err := cs.Validate(false); err == nil || err.Error() != expectedMsg {
+		t.Errorf("expected validation error with message : %s", err.Error())
+	}
+}
+
+func TestContainerService_ValidateAll(t *testing.T) {
+	cases := []struct {
+		name     string
+		setup    func(cs *ContainerService)
+		expected []ValidationError
+	}{
+		{
+			name: "valid api model",
+		},
+		{
+			name: "missing fields",
+			setup: func(cs *ContainerService) {
+				cs.Properties.MasterProfile.DNSPrefix = ""
+				cs.Properties.AgentPoolProfiles[0].VMSize = ""
+			},
+			expected: []ValidationError{
+				{Path: "properties.masterProfile.dnsPrefix", Message: "missing Properties.MasterProfile.DNSPrefix"},
+				{Path: "properties.agentPoolProfiles[0].vmSize", Message: "missing Properties.AgentPoolProfiles[0].VMSize"},
+			},
+		},
+		{
+			name: "invalid orchestrator profile",
+			setup: func(cs *ContainerService) {
+				cs.Properties.OrchestratorProfile.OrchestratorVersion = "1.2.3"
+				cs.Properties.AADProfile = &AADProfile{ClientAppID: "1"}
+			},
+			expected: []ValidationError{
+				{Path: "properties.orchestratorProfile", Message: fmt.Sprintf("the following OrchestratorProfile configuration is not supported: OrchestratorType: \"Kubernetes\", OrchestratorRelease: \"\", OrchestratorVersion: \"1.2.3\". Please use one of the following versions: %v", common.GetAllSupportedKubernetesVersions(false, false))},
+			},
+		},
+		{
+			name: "invalid profiles",
+			setup: func(cs *ContainerService) {
+				cs.Properties.LinuxProfile.SSH.PublicKeys = []PublicKey{{}}
+				cs.Properties.AADProfile = &AADProfile{ClientAppID: "1"}
+			},
+			expected: []ValidationError{
+				{Path: "properties.linuxProfile", Message: "KeyData in LinuxProfile.SSH.PublicKeys cannot be empty string"},
+				{Path: "properties.aadProfile", Message: "clientAppID '1' is invalid"},
+			},
+		},
+		{
+			name: "invalid airGapProfile",
+			setup: func(cs *ContainerService) {
+				cs.Properties.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+					AirGapProfile: &AirGapProfile{
+						Enabled:     to.BoolPtr(true),
+						BinariesURL: "http://10.0.0.4/binaries",
+					},
+				}
+			},
+			expected: []ValidationError{
+				{Path: "properties.orchestratorProfile.kubernetesConfig.airGapProfile", Message: "airGapProfile packagesURL is required when airGapProfile is enabled"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			cs := getK8sDefaultContainerService(false)
+			if c.setup != nil {
+				c.setup(cs)
+			}
+			validationErrors := cs.ValidateAll(false)
+			if !reflect.DeepEqual(validationErrors, c.expected) {
+				t.Errorf("expected validation errors %v, got %v", c.expected, validationErrors)
+			}
+		})
+	}
+}
+
+func getK8sDefaultContainerService(hasWindows bool) *ContainerService {
+	p := &Properties{
+		OrchestratorProfile: &OrchestratorProfile{
+			OrchestratorType: Kubernetes,
+		},
+		MasterProfile: &MasterProfile{
+			Count:     1,
+			DNSPrefix: "foo",
+			VMSize:    "Standard_DS2_v2",
+		},
+		AgentPoolProfiles: []*AgentPoolProfile{
+			{
+				Name:                "agentpool",
+				VMSize:              "Standard_D2_v2",
+				Count:               1,
+				AvailabilityProfile: AvailabilitySet,
+			},
+		},
+		LinuxProfile: &LinuxProfile{
+			AdminUsername: "azureuser",
+			SSH: struct {
+				PublicKeys []PublicKey `json:"publicKeys" validate:"required,min=1"`
+			}{
+				PublicKeys: []PublicKey{{
+					KeyData: "publickeydata",
+				}},
+			},
+		},
+		ServicePrincipalProfile: &ServicePrincipalProfile{
+			ClientID: "clientID",
+			Secret:   "clientSecret",
+		},
+	}
+
+	if hasWindows {
+		p.AgentPoolProfiles = []*AgentPoolProfile{
+			{
+				Name:                "agentpool",
+				VMSize:              "Standard_D2_v2",
+				Count:               1,
+				AvailabilityProfile: AvailabilitySet,
+				OSType:              Windows,
+			},
+		}
+		p.WindowsProfile = &WindowsProfile{
+			AdminUsername: "azureuser",
+			AdminPassword: "replacepassword1234$",
+		}
+	}
+	cs := ContainerService{
+		Location:   "westus",
+		Properties: p,
+	}
+	return &cs
+}
+
+func Test_Properties_ValidateContainerRuntime(t *testing.T) {
+	p := &Properties{}
+	p.OrchestratorProfile = &OrchestratorProfile{}
+	p.OrchestratorProfile.OrchestratorType = Kubernetes
+
+	for _, runtime := range ContainerRuntimeValues {
+		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{}
+		p.OrchestratorProfile.KubernetesConfig.ContainerRuntime = runtime
+		if err := p.validateContainerRuntime(); err != nil {
+			t.Errorf(
+				"should not error on containerRuntime=\"%s\"",
+				runtime,
+			)
+		}
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.ContainerRuntime = "not-existing"
+	if err := p.validateContainerRuntime(); err == nil {
+		t.Errorf(
+			"should error on invalid containerRuntime",
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.ContainerRuntime = KataContainers
+	p.AgentPoolProfiles = []*AgentPoolProfile{
+		{
+			OSType: Windows,
+		},
+	}
+	if err := p.validateContainerRuntime(); err == nil {
+		t.Errorf(
+			"should error on kata-containers for windows clusters",
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.ContainerRuntime = Containerd
+	p.AgentPoolProfiles = []*AgentPoolProfile{
+		{
+			OSType: Windows,
+		},
+	}
+	if err := p.validateContainerRuntime(); err == nil {
+		t.Errorf(
+			"should error on containerd for windows clusters without windowsContainerdURL",
+		)
+	}
+
+	p.WindowsProfile = &WindowsProfile{WindowsContainerdURL: "ftp://example.com/containerd.tar.gz"}
+	if err := p.validateContainerRuntime(); err == nil {
+		t.Errorf(
+			"should error on containerd for windows clusters with a windowsContainerdURL that is not http or https",
+		)
+	}
+
+	p.WindowsProfile.WindowsContainerdURL = "https://example.com/containerd-windows-amd64.tar.gz"
+	if err := p.validateContainerRuntime(); err != nil {
+		t.Errorf(
+			"should not error on containerd for windows clusters with windowsContainerdURL: %s", err,
+		)
+	}
+}
+
+func Test_Properties_ValidateAddons(t *testing.T) {
+	p := &Properties{}
+	p.OrchestratorProfile = &OrchestratorProfile{}
+	p.OrchestratorProfile.OrchestratorType = Kubernetes
+
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name:    "cluster-autoscaler",
+				Enabled: to.BoolPtr(true),
+			},
+		},
+	}
+	p.AgentPoolProfiles = []*AgentPoolProfile{
+		{
+			AvailabilityProfile: AvailabilitySet,
+		},
+	}
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error on cluster-autoscaler with availability sets",
+		)
+	}
+
+	p.AgentPoolProfiles = []*AgentPoolProfile{
+		{
+			VMSize: "Standard_NC6",
+		},
+	}
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name:    "nvidia-device-plugin",
+				Enabled: to.BoolPtr(true),
+			},
+		},
+	}
+	p.OrchestratorProfile.OrchestratorRelease = "1.9"
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error on nvidia-device-plugin with k8s < 1.10",
+		)
+	}
+
+	p.OrchestratorProfile.OrchestratorRelease = "1.10"
+	if err := p.validateAddons(); err != nil {
+		t.Errorf(
+			"should not error on nvidia-device-plugin with k8s >= 1.10",
+		)
+	}
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name: "kube-proxy-daemonset",
+				Data: "asdasdasdasda",
+				Config: map[string]string{
+					"foo": "bar",
+				},
+			},
+		},
+	}
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"expected error for non-empty Config with non-empty Data",
+		)
+	}
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name: "kube-proxy-daemonset",
+				Data: "asdasdasdasda",
+				Containers: []KubernetesContainerSpec{
+					{
+						Name: "FooContainerSpec",
+					},
+				},
+			},
+		},
+	}
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"expected error for non-empty Containers with non-empty Data",
+		)
+	}
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name: "kube-proxy-daemonset",
+				Data: "foodata",
+			},
+		},
+	}
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"expected error for invalid base64",
+		)
+	}
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name: "kube-proxy-daemonset",
+				Data: "Zm9vZGF0YQ==",
+			},
+		},
+	}
+	if err := p.validateAddons(); err != nil {
+		t.Errorf(
+			"should not error on providing valid addon.Data",
+		)
+	}
+
+	p.AgentPoolProfiles = []*AgentPoolProfile{
+		{
+			AvailabilityProfile: AvailabilitySet,
+			Distro:              CoreOS,
+		},
+	}
+
+	p.MasterProfile = &MasterProfile{
+		Distro: CoreOS,
+	}
+
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name:    "smb-flexvolume",
+				Enabled: to.BoolPtr(true),
+			},
+		},
+	}
+
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error using incompatible addon with coreos (smb-flexvolume)",
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name:    "keyvault-flexvolume",
+				Enabled: to.BoolPtr(true),
+			},
+		},
+	}
+
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error using incompatible addon with coreos (keyvault-flexvolume)",
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name:    "blobfuse-flexvolume",
+				Enabled: to.BoolPtr(true),
+			},
+		},
+	}
+
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error using incompatible addon with coreos (blobfuse-flexvolume)",
+		)
+	}
+
+	// appgw-ingress add-on
+
+	// Basic test with UseManagedIdentity
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		NetworkPlugin:      "azure",
+		UseManagedIdentity: true,
+		Addons: []KubernetesAddon{
+			{
+				Name:    "appgw-ingress",
+				Enabled: to.BoolPtr(true),
+				Config: map[string]string{
+					"appgw-subnet": "10.0.0.0/16",
+				},
+			},
+		},
+	}
+
+	if err := p.validateAddons(); err != nil {
+		t.Error(
+			"should not error for correct config.",
+			err,
+		)
+	}
+
+	// Basic test with ObjectID
+	p.ServicePrincipalProfile = &ServicePrincipalProfile{
+		ObjectID: "random",
+	}
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		NetworkPlugin: "azure",
+		Addons: []KubernetesAddon{
+			{
+				Name:    "appgw-ingress",
+				Enabled: to.BoolPtr(true),
+				Config: map[string]string{
+					"appgw-subnet": "10.0.0.0/16",
+				},
+			},
+		},
+	}
+
+	if err := p.validateAddons(); err != nil {
+		t.Error(
+			"should not error for correct config.",
+			err,
+		)
+	}
+
+	// Test with missing objectID and UseManagedIdentity false
+	p.ServicePrincipalProfile = &ServicePrincipalProfile{}
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		NetworkPlugin: "azure",
+		Addons: []KubernetesAddon{
+			{
+				Name:    "appgw-ingress",
+				Enabled: to.BoolPtr(true),
+				Config: map[string]string{
+					"appgw-subnet": "10.0.0.0/16",
+				},
+			},
+		},
+	}
+
+	if err := p.validateAddons(); err == nil {
+		t.Error(
+			"should error as objectID not provided or UseManagedIdentity not true",
+			err,
+		)
+	}
+
+	// Test with wrong Network Plugin
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		NetworkPlugin: "kubelet",
+		Addons: []KubernetesAddon{
+			{
+				Name:    "appgw-ingress",
+				Enabled: to.BoolPtr(true),
+				Config: map[string]string{
+					"appgw-subnet": "10.0.0.0/16",
+				},
+			},
+		},
+	}
+
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error using when not using 'azure' for Network Plugin",
+		)
+	}
+
+	// Test with missing appgw-subnet
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		NetworkPlugin: "azure",
+		Addons: []KubernetesAddon{
+			{
+				Name:    "appgw-ingress",
+				Enabled: to.BoolPtr(true),
+				Config:  map[string]string{},
+			},
+		},
+	}
+
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error when missing the subnet for Application Gateway",
+		)
+	}
+
+	// Test with aad-pod-identity disabled
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		NetworkPlugin:      "azure",
+		UseManagedIdentity: true,
+		Addons: []KubernetesAddon{
+			{
+				Name:    "appgw-ingress",
+				Enabled: to.BoolPtr(true),
+				Config: map[string]string{
+					"appgw-subnet": "10.0.0.0/16",
+				},
+			},
+			{
+				Name:    "aad-pod-identity",
+				Enabled: to.BoolPtr(false),
+			},
+		},
+	}
+
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error when the aad-pod-identity add-on is disabled",
+		)
+	}
+
+	// registry-cache add-on
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		ProxyMode: KubeProxyModeIPVS,
+		Addons: []KubernetesAddon{
+			{
+				Name:    "registry-cache",
+				Enabled: to.BoolPtr(true),
+			},
+		},
+	}
+
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error using the registry-cache add-on with kubeProxyMode ipvs",
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name:    "registry-cache",
+				Enabled: to.BoolPtr(true),
+				Config: map[string]string{
+					"nodePort": "5000",
+				},
+			},
+		},
+	}
+
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error when the registry-cache add-on nodePort is outside the node port range",
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.Addons[0].Config["nodePort"] = "30500"
+	if err := p.validateAddons(); err != nil {
+		t.Error(
+			"should not error for correct config.",
+			err,
+		)
+	}
+
+	// fluent-bit add-on
+	fluentBitCases := []struct {
+		name        string
+		config      map[string]string
+		expectedErr string
+	}{
+		{
+			name:        "log-analytics output without a workspace",
+			config:      map[string]string{"output": "log-analytics", "workspaceGuid": "MDAwMDAwMDAtMDAwMC0wMDAwLTAwMDAtMDAwMDAwMDAwMDAw"},
+			expectedErr: "fluent-bit add-on requires 'workspaceKey' in the Config",
+		},
+		{
+			name:        "workspace key not base64 encoded",
+			config:      map[string]string{"workspaceGuid": "MDAwMDAwMDAtMDAwMC0wMDAwLTAwMDAtMDAwMDAwMDAwMDAw", "workspaceKey": "not base64!"},
+			expectedErr: "fluent-bit add-on 'workspaceKey' must be base64 encoded",
+		},
+		{
+			name:        "storage output without an account key",
+			config:      map[string]string{"output": "storage", "storageAccountName": "logs"},
+			expectedErr: "fluent-bit add-on requires 'storageAccountKey' in the Config",
+		},
+		{
+			name:        "unsupported output",
+			config:      map[string]string{"output": "elasticsearch"},
+			expectedErr: "fluent-bit add-on output elasticsearch is not supported, it must be log-analytics or storage",
+		},
+		{
+			name:   "log-analytics output",
+			config: map[string]string{"workspaceGuid": "MDAwMDAwMDAtMDAwMC0wMDAwLTAwMDAtMDAwMDAwMDAwMDAw", "workspaceKey": "a2V5"},
+		},
+		{
+			name:   "storage output",
+			config: map[string]string{"output": "storage", "storageAccountName": "logs", "storageAccountKey": "a2V5"},
+		},
+	}
+	for _, c := range fluentBitCases {
+		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+			Addons: []KubernetesAddon{
+				{
+					Name:    "fluent-bit",
+					Enabled: to.BoolPtr(true),
+					Config:  c.config,
+				},
+			},
+		}
+		err := p.validateAddons()
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("%s: should not error, got %s", c.name, err)
+		}
+		if c.expectedErr != "" && (err == nil || err.Error() != c.expectedErr) {
+			t.Errorf("%s: expected error %q, got %v", c.name, c.expectedErr, err)
+		}
+	}
+
+	// node-problem-detector add-on
+	nodeProblemDetectorCases := []struct {
+		name        string
+		config      map[string]string
+		expectedErr string
+	}{
+		{
+			name:   "default monitors",
+			config: map[string]string{},
+		},
+		{
+			name: "custom monitor config",
+			config: map[string]string{
+				"systemLogMonitors":    "/config/kernel-monitor.json,/custom-config/journal-monitor.json",
+				"journal-monitor.json": "eyJwbHVnaW4iOiJqb3VybmFsZCJ9",
+			},
+		},
+		{
+			name:        "custom monitor config not a json file",
+			config:      map[string]string{"journal-monitor": "eyJwbHVnaW4iOiJqb3VybmFsZCJ9"},
+			expectedErr: "node-problem-detector add-on custom monitor config journal-monitor must be a file name ending in .json",
+		},
+		{
+			name:        "custom monitor config not base64 encoded",
+			config:      map[string]string{"journal-monitor.json": `{"plugin":"journald"}`},
+			expectedErr: "node-problem-detector add-on custom monitor config journal-monitor.json must be base64 encoded JSON",
+		},
+		{
+			name:        "custom monitor config not JSON",
+			config:      map[string]string{"journal-monitor.json": "cGx1Z2luOiBqb3VybmFsZA=="},
+			expectedErr: "node-problem-detector add-on custom monitor config journal-monitor.json must be base64 encoded JSON",
+		},
+		{
+			name:        "missing custom monitor config",
+			config:      map[string]string{"customPluginMonitors": "/custom-config/ntp-monitor.json"},
+			expectedErr: "node-problem-detector add-on customPluginMonitors monitor /custom-config/ntp-monitor.json has no custom monitor config ntp-monitor.json in the Config",
+		},
+	}
+	for _, c := range nodeProblemDetectorCases {
+		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+			Addons: []KubernetesAddon{
+				{
+					Name:    "node-problem-detector",
+					Enabled: to.BoolPtr(true),
+					Config:  c.config,
+				},
+			},
+		}
+		err := p.validateAddons()
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("%s: should not error, got %s", c.name, err)
+		}
+		if c.expectedErr != "" && (err == nil || err.Error() != c.expectedErr) {
+			t.Errorf("%s: expected error %q, got %v", c.name, c.expectedErr, err)
+		}
+	}
+
+	// cluster-autoscaler add-on
+	p.OrchestratorProfile.OrchestratorRelease = ""
+	p.AgentPoolProfiles = []*AgentPoolProfile{
+		{
+			Name:                "pool1",
+			AvailabilityProfile: VirtualMachineScaleSets,
+		},
+		{
+			Name:                "pool2",
+			AvailabilityProfile: VirtualMachineScaleSets,
+		},
+		{
+			Name:                "pool3",
+			AvailabilityProfile: AvailabilitySet,
+		},
+	}
+	clusterAutoscalerCases := []struct {
+		name        string
+		version     string
+		config      map[string]string
+		pools       []AddonNodePoolsConfig
+		expectedErr string
+	}{
+		{
+			name:   "scale down tunables",
+			config: map[string]string{"scale-down-unneeded-time": "5m", "scale-down-delay-after-add": "15m", "scale-down-utilization-threshold": "0.6"},
+		},
+		{
+			name:        "scale down unneeded time not a duration",
+			config:      map[string]string{"scale-down-unneeded-time": "5"},
+			expectedErr: "cluster-autoscaler add-on scale-down-unneeded-time 5 must be a duration, e.g. 10m",
+		},
+		{
+			name:        "scale down utilization threshold out of range",
+			config:      map[string]string{"scale-down-utilization-threshold": "1.5"},
+			expectedErr: "cluster-autoscaler add-on scale-down-utilization-threshold 1.5 must be a number greater than 0 and at most 1",
+		},
+		{
+			name:        "unsupported expander",
+			config:      map[string]string{"expander": "price"},
+			expectedErr: "cluster-autoscaler add-on expander price is not supported, it must be random, most-pods, least-waste or priority",
+		},
+		{
+			name:        "auto-discovery not a bool",
+			config:      map[string]string{"auto-discovery": "yes"},
+			expectedErr: "cluster-autoscaler add-on auto-discovery yes must be true or false",
+		},
+		{
+			name:        "auto-discovery on an old version",
+			version:     "1.14.6",
+			config:      map[string]string{"auto-discovery": "true"},
+			expectedErr: "cluster-autoscaler add-on auto-discovery requires Kubernetes 1.15.0 or greater, the version is 1.14.6",
+		},
+		{
+			name:        "priority expander on an old version",
+			version:     "1.13.10",
+			config:      map[string]string{"expander": "priority"},
+			pools:       []AddonNodePoolsConfig{{Name: "pool1", Config: map[string]string{"priority": "10"}}},
+			expectedErr: "cluster-autoscaler add-on priority expander requires Kubernetes 1.14.0 or greater, the version is 1.13.10",
+		},
+		{
+			name:        "min nodes greater than max nodes",
+			config:      map[string]string{"min-nodes": "5", "max-nodes": "1"},
+			expectedErr: "cluster-autoscaler add-on min-nodes 5 must not be greater than max-nodes 1",
+		},
+		{
+			name:   "pools",
+			config: map[string]string{"min-nodes": "1", "max-nodes": "5", "expander": "priority", "auto-discovery": "true"},
+			pools: []AddonNodePoolsConfig{
+				{Name: "pool1", Config: map[string]string{"priority": "10"}},
+				{Name: "pool2", Config: map[string]string{"min-nodes": "0", "max-nodes": "20", "priority": "20"}},
+			},
+		},
+		{
+			name:        "pool not an agent pool",
+			pools:       []AddonNodePoolsConfig{{Name: "pool4"}},
+			expectedErr: "cluster-autoscaler add-on pool pool4 is not an agent pool",
+		},
+		{
+			name:        "pool not a scale set",
+			pools:       []AddonNodePoolsConfig{{Name: "pool3"}},
+			expectedErr: "cluster-autoscaler add-on pool pool3 must be a VirtualMachineScaleSets agent pool",
+		},
+		{
+			name:        "pool configured more than once",
+			pools:       []AddonNodePoolsConfig{{Name: "pool1"}, {Name: "pool1"}},
+			expectedErr: "cluster-autoscaler add-on pool pool1 is configured more than once",
+		},
+		{
+			name:        "pool max nodes not an integer",
+			pools:       []AddonNodePoolsConfig{{Name: "pool1", Config: map[string]string{"max-nodes": "ten"}}},
+			expectedErr: "cluster-autoscaler add-on pool pool1 max-nodes ten must be a non-negative integer",
+		},
+		{
+			name:        "pool min nodes greater than the max nodes of the add-on",
+			config:      map[string]string{"max-nodes": "5"},
+			pools:       []AddonNodePoolsConfig{{Name: "pool1", Config: map[string]string{"min-nodes": "10"}}},
+			expectedErr: "cluster-autoscaler add-on pool pool1 min-nodes 10 must not be greater than max-nodes 5",
+		},
+		{
+			name:        "pool priority not an integer",
+			config:      map[string]string{"expander": "priority"},
+			pools:       []AddonNodePoolsConfig{{Name: "pool1", Config: map[string]string{"priority": "high"}}},
+			expectedErr: "cluster-autoscaler add-on pool pool1 priority high must be a non-negative integer",
+		},
+		{
+			name:        "pool priority without the priority expander",
+			pools:       []AddonNodePoolsConfig{{Name: "pool1", Config: map[string]string{"priority": "10"}}},
+			expectedErr: "cluster-autoscaler add-on pool pool1 has a priority, which requires the priority expander",
+		},
+		{
+			name:        "priority expander without pool priorities",
+			config:      map[string]string{"expander": "priority"},
+			pools:       []AddonNodePoolsConfig{{Name: "pool1"}},
+			expectedErr: "cluster-autoscaler add-on priority expander requires a priority for at least one of its pools",
+		},
+	}
+	for _, c := range clusterAutoscalerCases {
+		p.OrchestratorProfile.OrchestratorVersion = "1.15.3"
+		if c.version != "" {
+			p.OrchestratorProfile.OrchestratorVersion = c.version
+		}
+		// the availability set pool is only valid when the add-on does not scale it
+		pools := c.pools
+		if pools == nil {
+			pools = []AddonNodePoolsConfig{{Name: "pool1"}}
+		}
+		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+			Addons: []KubernetesAddon{
+				{
+					Name:    "cluster-autoscaler",
+					Enabled: to.BoolPtr(true),
+					Config:  c.config,
+					Pools:   pools,
+				},
+			},
+		}
+		err := p.validateAddons()
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("%s: should not error, got %s", c.name, err)
+		}
+		if c.expectedErr != "" && (err == nil || err.Error() != c.expectedErr) {
+			t.Errorf("%s: expected error %q, got %v", c.name, c.expectedErr, err)
+		}
+	}
+
+	// csi-secrets-store add-on
+	csiSecretsStoreCases := []struct {
+		name        string
+		version     string
+		config      map[string]string
+		expectedErr string
+	}{
+		{
+			name:    "secret rotation",
+			version: "1.16.0-beta.1",
+			config:  map[string]string{"enableSecretRotation": "true", "rotationPollInterval": "30s"},
+		},
+		{
+			name:        "old version",
+			version:     "1.15.3",
+			config:      map[string]string{},
+			expectedErr: "csi-secrets-store add-on is only available in kubernetes version 1.16.0-alpha.1 or greater; unable to validate for version 1.15.3",
+		},
+		{
+			name:        "secret rotation not a bool",
+			version:     "1.16.0-beta.1",
+			config:      map[string]string{"enableSecretRotation": "yes"},
+			expectedErr: "csi-secrets-store add-on enableSecretRotation yes must be true or false",
+		},
+		{
+			name:        "rotation poll interval not a duration",
+			version:     "1.16.0-beta.1",
+			config:      map[string]string{"rotationPollInterval": "120"},
+			expectedErr: "csi-secrets-store add-on rotationPollInterval 120 must be a positive duration, e.g. 2m",
+		},
+	}
+	for _, c := range csiSecretsStoreCases {
+		p.OrchestratorProfile.OrchestratorVersion = c.version
+		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+			Addons: []KubernetesAddon{
+				{
+					Name:    "csi-secrets-store",
+					Enabled: to.BoolPtr(true),
+					Config:  c.config,
+				},
+			},
+		}
+		err := p.validateAddons()
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("%s: should not error, got %s", c.name, err)
+		}
+		if c.expectedErr != "" && (err == nil || err.Error() != c.expectedErr) {
+			t.Errorf("%s: expected error %q, got %v", c.name, c.expectedErr, err)
+		}
+	}
+
+	// azure-policy add-on
+	azurePolicyCases := []struct {
+		name        string
+		version     string
+		config      map[string]string
+		expectedErr string
+	}{
+		{
+			name:    "synced kinds",
+			version: "1.15.3",
+			config:  map[string]string{"auditInterval": "30", "constraintViolationsLimit": "0", "syncOnly": "v1/Namespace,extensions/v1beta1/Ingress"},
+		},
+		{
+			name:        "old version",
+			version:     "1.13.10",
+			config:      map[string]string{},
+			expectedErr: "azure-policy add-on is only available in kubernetes version 1.14.0 or greater; unable to validate for version 1.13.10",
+		},
+		{
+			name:        "audit interval not a number",
+			version:     "1.15.3",
+			config:      map[string]string{"auditInterval": "1m"},
+			expectedErr: "azure-policy add-on auditInterval 1m must be a positive number of seconds",
+		},
+		{
+			name:        "negative constraint violations limit",
+			version:     "1.15.3",
+			config:      map[string]string{"constraintViolationsLimit": "-1"},
+			expectedErr: "azure-policy add-on constraintViolationsLimit -1 must be a number",
+		},
+		{
+			name:        "synced kind without version",
+			version:     "1.15.3",
+			config:      map[string]string{"syncOnly": "Namespace"},
+			expectedErr: "azure-policy add-on syncOnly kind Namespace must be of the form [group/]version/kind, e.g. v1/Namespace",
+		},
+	}
+	for _, c := range azurePolicyCases {
+		p.OrchestratorProfile.OrchestratorVersion = c.version
+		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+			Addons: []KubernetesAddon{
+				{
+					Name:    "azure-policy",
+					Enabled: to.BoolPtr(true),
+					Config:  c.config,
+				},
+			},
+		}
+		err := p.validateAddons()
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("%s: should not error, got %s", c.name, err)
+		}
+		if c.expectedErr != "" && (err == nil || err.Error() != c.expectedErr) {
+			t.Errorf("%s: expected error %q, got %v", c.name, c.expectedErr, err)
+		}
+	}
+
+	// keda add-on
+	kedaCases := []struct {
+		name        string
+		config      map[string]string
+		expectedErr string
+	}{
+		{
+			name:   "default version",
+			config: map[string]string{},
+		},
+		{
+			name:   "1.x version",
+			config: map[string]string{"version": "1.2.0"},
+		},
+		{
+			name:        "release without metrics adapter image",
+			config:      map[string]string{"version": "1.0.0"},
+			expectedErr: "keda add-on version 1.0.0 must be a 1.x release of KEDA from 1.1.0, e.g. 1.1.0",
+		},
+		{
+			name:        "2.x version",
+			config:      map[string]string{"version": "2.0.0"},
+			expectedErr: "keda add-on version 2.0.0 must be a 1.x release of KEDA from 1.1.0, e.g. 1.1.0",
+		},
+		{
+			name:        "not a version",
+			config:      map[string]string{"version": "latest"},
+			expectedErr: "keda add-on version latest must be a 1.x release of KEDA from 1.1.0, e.g. 1.1.0",
+		},
+	}
+	for _, c := range kedaCases {
+		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+			Addons: []KubernetesAddon{
+				{
+					Name:    "keda",
+					Enabled: to.BoolPtr(true),
+					Config:  c.config,
+				},
+			},
+		}
+		err := p.validateAddons()
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("%s: should not error, got %s", c.name, err)
+		}
+		if c.expectedErr != "" && (err == nil || err.Error() != c.expectedErr) {
+			t.Errorf("%s: expected error %q, got %v", c.name, c.expectedErr, err)
+		}
+	}
+
+	// open-service-mesh add-on
+	openServiceMeshCases := []struct {
+		name        string
+		version     string
+		config      map[string]string
+		expectedErr string
+	}{
+		{
+			name:    "namespaces with sidecar injection",
+			version: "1.15.3",
+			config:  map[string]string{"namespaces": "default,bookstore", "permissiveTrafficPolicyMode": "false", "envoyLogLevel": "debug"},
+		},
+		{
+			name:        "old version",
+			version:     "1.14.6",
+			config:      map[string]string{},
+			expectedErr: "open-service-mesh add-on is only available in kubernetes version 1.15.0 or greater; unable to validate for version 1.14.6",
+		},
+		{
+			name:        "invalid namespace name",
+			version:     "1.15.3",
+			config:      map[string]string{"namespaces": "default, Bookstore"},
+			expectedErr: `open-service-mesh add-on namespace " Bookstore" must be a valid namespace name`,
+		},
+		{
+			name:        "system namespace",
+			version:     "1.15.3",
+			config:      map[string]string{"namespaces": "kube-system"},
+			expectedErr: "open-service-mesh add-on namespace kube-system cannot have sidecar injection enabled",
+		},
+		{
+			name:        "permissive traffic policy mode not a bool",
+			version:     "1.15.3",
+			config:      map[string]string{"permissiveTrafficPolicyMode": "yes"},
+			expectedErr: "open-service-mesh add-on permissiveTrafficPolicyMode yes must be true or false",
+		},
+		{
+			name:        "unknown envoy log level",
+			version:     "1.15.3",
+			config:      map[string]string{"envoyLogLevel": "verbose"},
+			expectedErr: "open-service-mesh add-on envoyLogLevel verbose must be one of trace, debug, info, warning, error, critical or off",
+		},
+	}
+	for _, c := range openServiceMeshCases {
+		p.OrchestratorProfile.OrchestratorVersion = c.version
+		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+			Addons: []KubernetesAddon{
+				{
+					Name:    "open-service-mesh",
+					Enabled: to.BoolPtr(true),
+					Config:  c.config,
+				},
+			},
+		}
+		err := p.validateAddons()
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("%s: should not error, got %s", c.name, err)
+		}
+		if c.expectedErr != "" && (err == nil || err.Error() != c.expectedErr) {
+			t.Errorf("%s: expected error %q, got %v", c.name, c.expectedErr, err)
+		}
+	}
+
+	// cilium-daemonset and antrea add-ons
+	networkPluginAddonCases := []struct {
+		name          string
+		addon         string
+		networkPlugin string
+		proxyMode     KubeProxyMode
+		config        map[string]string
+		expectedErr   string
+	}{
+		{
+			name:          "cilium replacing kube-proxy",
+			addon:         "cilium-daemonset",
+			networkPlugin: NetworkPluginCilium,
+			config:        map[string]string{"kube-proxy-replacement": "strict"},
+		},
+		{
+			name:          "cilium without the cilium network plugin",
+			addon:         "cilium-daemonset",
+			networkPlugin: "kubenet",
+			expectedErr:   "cilium-daemonset add-on requires networkPlugin cilium",
+		},
+		{
+			name:          "unknown kube-proxy replacement",
+			addon:         "cilium-daemonset",
+			networkPlugin: NetworkPluginCilium,
+			config:        map[string]string{"kube-proxy-replacement": "partial"},
+			expectedErr:   "cilium-daemonset add-on kube-proxy-replacement partial must be disabled or strict",
+		},
+		{
+			name:          "kube-proxy replacement with ipvs",
+			addon:         "cilium-daemonset",
+			networkPlugin: NetworkPluginCilium,
+			proxyMode:     KubeProxyModeIPVS,
+			config:        map[string]string{"kube-proxy-replacement": "strict"},
+			expectedErr:   "cilium-daemonset add-on kube-proxy-replacement strict cannot be used with kubeProxyMode ipvs, the cluster does not run kube-proxy",
+		},
+		{
+			name:          "antrea",
+			addon:         "antrea",
+			networkPlugin: NetworkPluginAntrea,
+		},
+		{
+			name:          "antrea without the antrea network plugin",
+			addon:         "antrea",
+			networkPlugin: "azure",
+			expectedErr:   "antrea add-on requires networkPlugin antrea",
+		},
+	}
+	for _, c := range networkPluginAddonCases {
+		p.OrchestratorProfile.OrchestratorVersion = "1.16.1"
+		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+			NetworkPlugin: c.networkPlugin,
+			ProxyMode:     c.proxyMode,
+			Addons: []KubernetesAddon{
+				{
+					Name:    c.addon,
+					Enabled: to.BoolPtr(true),
+					Config:  c.config,
+				},
+			},
+		}
+		err := p.validateAddons()
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("%s: should not error, got %s", c.name, err)
+		}
+		if c.expectedErr != "" && (err == nil || err.Error() != c.expectedErr) {
+			t.Errorf("%s: expected error %q, got %v", c.name, c.expectedErr, err)
+		}
+	}
+
+	// nginx-ingress add-on
+	nginxIngressCases := []struct {
+		name        string
+		config      map[string]string
+		expectedErr string
+	}{
+		{
+			name:   "node port service",
+			config: map[string]string{"replicas": "3", "serviceType": "NodePort"},
+		},
+		{
+			name:        "no replicas",
+			config:      map[string]string{"replicas": "0"},
+			expectedErr: "nginx-ingress add-on replicas 0 must be a positive number",
+		},
+		{
+			name:        "cluster IP service",
+			config:      map[string]string{"serviceType": "ClusterIP"},
+			expectedErr: "nginx-ingress add-on serviceType ClusterIP must be LoadBalancer or NodePort",
+		},
+	}
+	for _, c := range nginxIngressCases {
+		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+			Addons: []KubernetesAddon{
+				{
+					Name:    "nginx-ingress",
+					Enabled: to.BoolPtr(true),
+					Config:  c.config,
+				},
+			},
+		}
+		err := p.validateAddons()
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("%s: should not error, got %s", c.name, err)
+		}
+		if c.expectedErr != "" && (err == nil || err.Error() != c.expectedErr) {
+			t.Errorf("%s: expected error %q, got %v", c.name, c.expectedErr, err)
+		}
+	}
+
+	// image digests of the add-on containers
+	imageDigestCases := []struct {
+		name        string
+		digest      string
+		expectedErr string
+	}{
+		{
+			name:   "sha256 digest",
+			digest: "sha256:" + strings.Repeat("0f", 32),
+		},
+		{
+			name:        "tag",
+			digest:      "v1.2.3",
+			expectedErr: "imageDigest v1.2.3 of container coredns in addon coredns must be a sha256 digest, e.g. sha256:<64 hexadecimal characters>",
+		},
+		{
+			name:        "short digest",
+			digest:      "sha256:0f0f",
+			expectedErr: "imageDigest sha256:0f0f of container coredns in addon coredns must be a sha256 digest, e.g. sha256:<64 hexadecimal characters>",
+		},
+	}
+	for _, c := range imageDigestCases {
+		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+			Addons: []KubernetesAddon{
+				{
+					Name:       "coredns",
+					Enabled:    to.BoolPtr(true),
+					Containers: []KubernetesContainerSpec{{Name: "coredns", ImageDigest: c.digest}},
+				},
+			},
+		}
+		err := p.validateAddons()
+		if c.expectedErr == "" && err != nil {
+			t.Errorf("%s: should not error, got %s", c.name, err)
+		}
+		if c.expectedErr != "" && (err == nil || err.Error() != c.expectedErr) {
+			t.Errorf("%s: expected error %q, got %v", c.name, c.expectedErr, err)
+		}
+	}
+}
+
+func Test_KubernetesConfig_ValidateImageRegistry(t *testing.T) {
+	cases := []struct {
+		registry      string
+		expectedError string
+	}{
+		{registry: ""},
+		{registry: "contoso.azurecr.io"},
+		{registry: "contoso.azurecr.io/mirror/k8s"},
+		{registry: "localhost:5000"},
+		{
+			registry:      "https://contoso.azurecr.io",
+			expectedError: "imageRegistry https://contoso.azurecr.io must be a registry host name with an optional repository path, e.g. contoso.azurecr.io",
+		},
+		{
+			registry:      "contoso.azurecr.io/",
+			expectedError: "imageRegistry contoso.azurecr.io/ must be a registry host name with an optional repository path, e.g. contoso.azurecr.io",
+		},
+		{
+			registry:      "contoso.azurecr.io/Mirror",
+			expectedError: "imageRegistry contoso.azurecr.io/Mirror must be a registry host name with an optional repository path, e.g. contoso.azurecr.io",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.registry, func(t *testing.T) {
+			t.Parallel()
+			k := &KubernetesConfig{ImageRegistry: c.registry}
+			err := k.validateImageRegistry()
+			if c.expectedError == "" {
+				if err != nil {
+					t.Errorf("expected no error, but got %s", err)
+				}
+			} else if err == nil || err.Error() != c.expectedError {
+				t.Errorf("expected error %s, but got %v", c.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestWindowsVersions(t *testing.T) {
+	for _, version := range common.GetAllSupportedKubernetesVersions(false, true) {
+		cs := getK8sDefaultContainerService(true)
+		cs.Properties.OrchestratorProfile.OrchestratorVersion = version
+		if err := cs.Validate(false); err != nil {
+			t.Errorf(
+				"should not error on valid Windows version: %v", err,
+			)
+		}
+		cs = getK8sDefaultContainerService(true)
+		cs.Properties.WindowsProfile.AdminPassword = "Password"
+		if err := cs.Validate(false); err == nil {
+			t.Errorf(
+				"should error on windows password complexity not match because no digits and special characters found in the password ",
+			)
+		}
+		cs = getK8sDefaultContainerService(true)
+		cs.Properties.WindowsProfile.AdminPassword = "123!@#"
+		if err := cs.Validate(false); err == nil {
+			t.Errorf(
+				"should error on windows password complexity not match because uppercase and lowercase letters found in the password",
+			)
+		}
+		cs = getK8sDefaultContainerService(true)
+		cs.Properties.WindowsProfile.AdminPassword = ""
+		if err := cs.Validate(false); err == nil {
+			t.Errorf(
+				"should error on windows password length is zero",
+			)
+		}
+		cs = getK8sDefaultContainerService(true)
+		cs.Properties.WindowsProfile.AdminUsername = "User@123"
+		cs.Properties.WindowsProfile.AdminPassword = "User@123"
+		if err := cs.Validate(false); err == nil {
+			t.Errorf(
+				"should error on windows password complexity not match because username and password are  same",
+			)
+		}
+		sv, _ := semver.Make(version)
+		cs = getK8sDefaultContainerService(true)
+		cs.Properties.OrchestratorProfile.OrchestratorRelease = fmt.Sprintf("%d.%d", sv.Major, sv.Minor)
+		if err := cs.Validate(false); err != nil {
+			t.Errorf(
+				"should not error on valid Windows version: %v", err,
+			)
+		}
+	}
+	cs := getK8sDefaultContainerService(true)
+	cs.Properties.OrchestratorProfile.OrchestratorRelease = "1.4"
+	if err := cs.Validate(false); err == nil {
+		t.Errorf(
+			"should error on invalid Windows version",
+		)
+	}
+
+	cs = getK8sDefaultContainerService(true)
+	cs.Properties.OrchestratorProfile.OrchestratorVersion = "1.4.0"
+	if err := cs.Validate(false); err == nil {
+		t.Errorf(
+			"should error on invalid Windows version",
+		)
+	}
+}
+
+func TestLinuxVersions(t *testing.T) {
+	for _, version := range common.GetAllSupportedKubernetesVersions(false, false) {
+		cs := getK8sDefaultContainerService(false)
+		cs.Properties.OrchestratorProfile.OrchestratorVersion = version
+		if err := cs.Validate(false); err != nil {
+			t.Errorf(
+				"should not error on valid Linux version: %v", err,
+			)
+		}
+		sv, _ := semver.Make(version)
+		cs = getK8sDefaultContainerService(false)
+		cs.Properties.OrchestratorProfile.OrchestratorRelease = fmt.Sprintf("%d.%d", sv.Major, sv.Minor)
+		if err := cs.Validate(false); err != nil {
+			t.Errorf(
+				"should not error on valid Linux version: %v", err,
+			)
+		}
+	}
+	cs := getK8sDefaultContainerService(false)
+	cs.Properties.OrchestratorProfile.OrchestratorRelease = "1.4"
+	if err := cs.Validate(false); err == nil {
+		t.Errorf(
+			"should error on invalid Linux version",
+		)
+	}
+
+	cs = getK8sDefaultContainerService(false)
+	cs.Properties.OrchestratorProfile.OrchestratorVersion = "1.4.0"
+	if err := cs.Validate(false); err == nil {
+		t.Errorf(
+			"should error on invalid Linux version",
+		)
+	}
+}
+
+func TestValidateImageNameAndGroup(t *testing.T) {
+	tests := []struct {
+		name        string
+		image       ImageReference
+		expectedErr error
+	}{
+		{
+			name: "valid run",
+			image: ImageReference{
+				Name:          "rhel9000",
+				ResourceGroup: "club",
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "invalid: image name is missing",
+			image: ImageReference{
+				ResourceGroup: "club",
+			},
+			expectedErr: errors.New(`imageName needs to be specified when imageResourceGroup is provided`),
+		},
+		{
+			name: "invalid: image resource group is missing",
+			image: ImageReference{
+				Name: "rhel9000",
+			},
+			expectedErr: errors.New(`imageResourceGroup needs to be specified when imageName is provided`),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			cs := getK8sDefaultContainerService(true)
+			cs.Properties.AgentPoolProfiles = []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_D2_v2",
+					Count:               1,
+					AvailabilityProfile: AvailabilitySet,
+					ImageRef:            &test.image,
+				},
+			}
+			gotErr := cs.Properties.validateAgentPoolProfiles(true)
+			if !helpers.EqualError(gotErr, test.expectedErr) {
+				t.Logf("scenario %q", test.name)
+				t.Errorf("expected error: %v, got: %v", test.expectedErr, gotErr)
+			}
+		})
+	}
+}
+func TestProperties_ValidateManagedIdentity(t *testing.T) {
+	tests := []struct {
+		name                string
+		orchestratorRelease string
+		useManagedIdentity  bool
+		userAssignedID      string
+		masterProfile       MasterProfile
+		expectedErr         string
+		agentPoolProfiles   []*AgentPoolProfile
+	}{
+		{
+			name:                "use managed identity with master VMSS",
+			orchestratorRelease: "1.11",
+			useManagedIdentity:  true,
+			userAssignedID:      "utaksenginetestid",
+			masterProfile: MasterProfile{
+				DNSPrefix:           "dummy",
+				Count:               3,
+				AvailabilityProfile: VirtualMachineScaleSets,
+			},
+			agentPoolProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_DS2_v2",
+					Count:               1,
+					AvailabilityProfile: VirtualMachineScaleSets,
+				},
+			},
+			expectedErr: "managed identity and VMSS masters can only be used with Kubernetes 1.12.0 or above. Please specify \"orchestratorRelease\": \"1.12\"",
+		},
+		{
+			name:                "use managed identity with master vmas",
+			orchestratorRelease: "1.11",
+			useManagedIdentity:  true,
+			masterProfile: MasterProfile{
+				DNSPrefix: "dummy",
+				Count:     3,
+			},
+			agentPoolProfiles: []*AgentPoolProfile{
+				{
+					Name:   "agentpool",
+					VMSize: "Standard_DS2_v2",
+					Count:  1,
+				},
+			},
+		},
+		{
+			name:                "use user assigned identity with master vmas",
+			orchestratorRelease: "1.11",
+			useManagedIdentity:  true,
+			userAssignedID:      "aksenginetestid",
+			masterProfile: MasterProfile{
+				DNSPrefix: "dummy",
+				Count:     3,
+			},
+			agentPoolProfiles: []*AgentPoolProfile{
+				{
+					Name:   "agentpool",
+					VMSize: "Standard_DS2_v2",
+					Count:  1,
+				},
+			},
+			expectedErr: "user assigned identity can only be used with Kubernetes 1.12.0 or above. Please specify \"orchestratorRelease\": \"1.12\"",
+		},
+		{
+			name:                "user master VMSS with empty user assigned ID",
+			orchestratorRelease: "1.12",
+			useManagedIdentity:  true,
+			masterProfile: MasterProfile{
+				DNSPrefix:           "dummy",
+				Count:               3,
+				AvailabilityProfile: VirtualMachineScaleSets,
+			},
+			agentPoolProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_DS2_v2",
+					Count:               1,
+					AvailabilityProfile: VirtualMachineScaleSets,
+				},
+			},
+			expectedErr: "virtualMachineScaleSets for master profile can be used only with user assigned MSI ! Please specify \"userAssignedID\" in \"kubernetesConfig\"",
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			cs := getK8sDefaultContainerService(true)
+			cs.Properties.MasterProfile = &test.masterProfile
+			cs.Properties.MasterProfile.VMSize = "Standard_DS2_v2"
+			cs.Properties.OrchestratorProfile = &OrchestratorProfile{
+				OrchestratorRelease: test.orchestratorRelease,
+				OrchestratorType:    Kubernetes,
+				KubernetesConfig: &KubernetesConfig{
+					UseManagedIdentity: test.useManagedIdentity,
+					UserAssignedID:     test.userAssignedID,
+				},
+			}
+			cs.Properties.AgentPoolProfiles = test.agentPoolProfiles
+			err := cs.Validate(false)
+			if test.expectedErr == "" && err != nil ||
+				test.expectedErr != "" && (err == nil || test.expectedErr != err.Error()) {
+				t.Errorf("test %s: unexpected error %q\n", test.name, err)
+			}
+		})
+	}
+}
+
+func TestMasterProfileValidate(t *testing.T) {
+	tests := []struct {
+		name                string
+		orchestratorType    string
+		orchestratorVersion string
+		orchestratorRelease string
+		useInstanceMetadata bool
+		masterProfile       MasterProfile
+		agentPoolProfiles   []*AgentPoolProfile
+		expectedErr         string
+	}{
+		{
+			name: "Master Profile with Invalid DNS Prefix",
+			masterProfile: MasterProfile{
+				DNSPrefix: "bad!",
+			},
+			expectedErr: "DNSPrefix 'bad!' is invalid. The DNSPrefix must contain between 3 and 45 characters and can contain only letters, numbers, and hyphens.  It must start with a letter and must end with a letter or a number. (length was 4)",
+		},
+		{
+			name: "Master Profile with valid DNS Prefix 1",
+			masterProfile: MasterProfile{
+				DNSPrefix: "dummy",
+				Count:     1,
+			},
+		},
+		{
+			name: "Master Profile with valid DNS Prefix 2",
+			masterProfile: MasterProfile{
+				DNSPrefix: "dummy",
+				Count:     3,
+			},
+		},
+		{
+			name:             "Master Profile with empty imageName and non-empty imageResourceGroup",
+			orchestratorType: Kubernetes,
+			masterProfile: MasterProfile{
+				DNSPrefix: "dummy",
+				Count:     3,
+				ImageRef: &ImageReference{
+					Name:          "",
+					ResourceGroup: "rg",
+				},
+			},
+			expectedErr: "imageName needs to be specified when imageResourceGroup is provided",
+		},
+		{
+			name:                "Master Profile with VMSS and storage account",
+			orchestratorType:    Kubernetes,
+			orchestratorRelease: "1.10",
+			masterProfile: MasterProfile{
+				DNSPrefix:           "dummy",
+				Count:               3,
+				AvailabilityProfile: VirtualMachineScaleSets,
+				StorageProfile:      StorageAccount,
+			},
+			expectedErr: "VirtualMachineScaleSets does not support StorageAccount disks.  Please specify \"storageProfile\": \"ManagedDisks\" (recommended) or \"availabilityProfile\": \"AvailabilitySet\"",
+		},
+		{
+			name:                "Master Profile with VMSS and agent profiles with VMAS",
+			orchestratorType:    Kubernetes,
+			orchestratorRelease: "1.10",
+			masterProfile: MasterProfile{
+				DNSPrefix:           "dummy",
+				Count:               3,
+				AvailabilityProfile: VirtualMachineScaleSets,
+			},
+			agentPoolProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_DS2_v2",
+					Count:               1,
+					AvailabilityProfile: AvailabilitySet,
+				},
+			},
+			expectedErr: "VirtualMachineScaleSets for master profile must be used together with virtualMachineScaleSets for agent profiles. Set \"availabilityProfile\" to \"VirtualMachineScaleSets\" for agent profiles",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			cs := getK8sDefaultContainerService(true)
+			cs.Properties.MasterProfile = &test.masterProfile
+			cs.Properties.MasterProfile.StorageProfile = test.masterProfile.StorageProfile
+			cs.Properties.OrchestratorProfile = &OrchestratorProfile{
+				OrchestratorType:    test.orchestratorType,
+				OrchestratorVersion: test.orchestratorVersion,
+				OrchestratorRelease: test.orchestratorRelease,
+				KubernetesConfig: &KubernetesConfig{
+					UseInstanceMetadata: to.BoolPtr(test.useInstanceMetadata),
+				},
+			}
+			cs.Properties.AgentPoolProfiles = test.agentPoolProfiles
+			err := cs.Properties.validateMasterProfile(false)
+			if test.expectedErr == "" && err != nil ||
+				test.expectedErr != "" && (err == nil || test.expectedErr != err.Error()) {
+				t.Errorf("test %s: unexpected error %q\n", test.name, err)
+			}
+		})
+	}
+}
+
+func TestProperties_ValidateZones(t *testing.T) {
+	tests := []struct {
+		name                        string
+		orchestratorRelease         string
+		loadBalancerSku             string
+		excludeMasterFromStandardLB bool
+		masterProfile               *MasterProfile
+		agentProfiles               []*AgentPoolProfile
+		expectedErr                 string
+	}{
+		{
+			name:                "Master profile with zones version",
+			orchestratorRelease: "1.11",
+			masterProfile: &MasterProfile{
+				Count:               3,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
+				AvailabilityProfile: VirtualMachineScaleSets,
+				AvailabilityZones:   []string{"1", "2"},
+			},
+			expectedErr: "availabilityZone is only available in Kubernetes version 1.12 or greater",
+		},
+		{
+			name:                "Agent profile with zones version",
+			orchestratorRelease: "1.11",
+			masterProfile: &MasterProfile{
+				Count:               1,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
+				AvailabilityProfile: VirtualMachineScaleSets,
+				AvailabilityZones:   []string{"1", "2"},
+			},
+			agentProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_DS2_v2",
+					Count:               4,
+					AvailabilityProfile: VirtualMachineScaleSets,
+					AvailabilityZones:   []string{"1", "2"},
+				},
+			},
+			expectedErr: "availabilityZone is only available in Kubernetes version 1.12 or greater",
+		},
+		{
+			name:                "Agent profile with zones vmas",
+			orchestratorRelease: "1.12",
+			masterProfile: &MasterProfile{
+				Count:               5,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
+				AvailabilityProfile: VirtualMachineScaleSets,
+				AvailabilityZones:   []string{"1", "2"},
+			},
+			agentProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_DS2_v2",
+					Count:               4,
+					AvailabilityProfile: AvailabilitySet,
+					AvailabilityZones:   []string{"1", "2"},
+				},
+			},
+			expectedErr: "VirtualMachineScaleSets for master profile must be used together with virtualMachineScaleSets for agent profiles. Set \"availabilityProfile\" to \"VirtualMachineScaleSets\" for agent profiles",
+		},
+		{
+			name:                "Master profile with zones and Agent profile without zones",
+			orchestratorRelease: "1.12",
+			masterProfile: &MasterProfile{
+				Count:               5,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
+				AvailabilityProfile: VirtualMachineScaleSets,
+				AvailabilityZones:   []string{"1", "2"},
+			},
+			agentProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_DS2_v2",
+					Count:               4,
+					AvailabilityProfile: VirtualMachineScaleSets,
+				},
+			},
+			expectedErr: "Availability Zones need to be defined for master profile and all agent pool profiles. Please set \"availabilityZones\" for all profiles",
+		},
+		{
+			name:                "Master profile without zones and Agent profile with zones",
+			orchestratorRelease: "1.12",
+			masterProfile: &MasterProfile{
+				Count:               3,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
+				AvailabilityProfile: VirtualMachineScaleSets,
+			},
+			agentProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_DS2_v2",
+					Count:               4,
+					AvailabilityProfile: VirtualMachineScaleSets,
+					AvailabilityZones:   []string{"1", "2"},
+				},
+			},
+			expectedErr: "Availability Zones need to be defined for master profile and all agent pool profiles. Please set \"availabilityZones\" for all profiles",
+		},
+		{
+			name:                "all zones and basic loadbalancer",
+			orchestratorRelease: "1.12",
+			loadBalancerSku:     BasicLoadBalancerSku,
+			masterProfile: &MasterProfile{
+				Count:               5,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
+				AvailabilityProfile: VirtualMachineScaleSets,
+				AvailabilityZones:   []string{"1", "2"},
+			},
+			agentProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_DS2_v2",
+					Count:               4,
+					AvailabilityProfile: VirtualMachineScaleSets,
+					AvailabilityZones:   []string{"1", "2"},
+				},
+			},
+			expectedErr: "Availability Zones requires Standard LoadBalancer. Please set KubernetesConfig \"LoadBalancerSku\" to \"Standard\"",
+		},
+		{
+			name:                        "all zones with standard loadbalancer and false excludeMasterFromStandardLB",
+			orchestratorRelease:         "1.12",
+			loadBalancerSku:             StandardLoadBalancerSku,
+			excludeMasterFromStandardLB: false,
+			masterProfile: &MasterProfile{
+				Count:               5,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
+				AvailabilityProfile: VirtualMachineScaleSets,
+				AvailabilityZones:   []string{"1", "2"},
+			},
+			agentProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_DS2_v2",
+					Count:               4,
+					AvailabilityProfile: VirtualMachineScaleSets,
+					AvailabilityZones:   []string{"1", "2"},
+				},
+			},
+			expectedErr: "standard loadBalancerSku should exclude master nodes. Please set KubernetesConfig \"ExcludeMasterFromStandardLB\" to \"true\"",
+		},
+		{
+			name:                        "masters in too few zones for the etcd quorum",
+			orchestratorRelease:         "1.12",
+			loadBalancerSku:             StandardLoadBalancerSku,
+			excludeMasterFromStandardLB: true,
+			masterProfile: &MasterProfile{
+				Count:               5,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
+				AvailabilityProfile: VirtualMachineScaleSets,
+				AvailabilityZones:   []string{"1", "2"},
+			},
+			agentProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_DS2_v2",
+					Count:               4,
+					AvailabilityProfile: VirtualMachineScaleSets,
+					AvailabilityZones:   []string{"1", "2"},
+				},
+			},
+			expectedErr: "masterProfile has 5 masters in 2 availability zones, the outage of a zone with 3 of them would lose the etcd quorum of 3 masters. Spread the masters across at least 3 availability zones",
+		},
+		{
+			name:                        "duplicate availability zone",
+			orchestratorRelease:         "1.12",
+			loadBalancerSku:             StandardLoadBalancerSku,
+			excludeMasterFromStandardLB: true,
+			masterProfile: &MasterProfile{
+				Count:               3,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
+				AvailabilityProfile: VirtualMachineScaleSets,
+				AvailabilityZones:   []string{"1", "2", "3"},
+			},
+			agentProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_DS2_v2",
+					Count:               4,
+					AvailabilityProfile: VirtualMachineScaleSets,
+					AvailabilityZones:   []string{"1", "1"},
+				},
+			},
+			expectedErr: "agent pool agentpool has duplicate availability zone 1",
+		},
+		{
+			name:                        "zone balance strategy with one zone",
+			orchestratorRelease:         "1.12",
+			loadBalancerSku:             StandardLoadBalancerSku,
+			excludeMasterFromStandardLB: true,
+			masterProfile: &MasterProfile{
+				Count:               3,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
+				AvailabilityProfile: VirtualMachineScaleSets,
+				AvailabilityZones:   []string{"1", "2", "3"},
+			},
+			agentProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_DS2_v2",
+					Count:               4,
+					AvailabilityProfile: VirtualMachineScaleSets,
+					AvailabilityZones:   []string{"1"},
+					ZoneBalanceStrategy: StrictZoneBalance,
+				},
+			},
+			expectedErr: "agent pool agentpool has zoneBalanceStrategy Strict, which requires more than one availability zone",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			cs := getK8sDefaultContainerService(true)
+			cs.Properties.MasterProfile = test.masterProfile
+			cs.Properties.AgentPoolProfiles = test.agentProfiles
+			cs.Properties.OrchestratorProfile.OrchestratorRelease = test.orchestratorRelease
+			cs.Properties.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+				LoadBalancerSku:             test.loadBalancerSku,
+				ExcludeMasterFromStandardLB: to.BoolPtr(test.excludeMasterFromStandardLB),
+			}
+
+			if err := cs.Validate(false); err != nil {
+				expectedMsg := test.expectedErr
+				if err.Error() != expectedMsg {
+					t.Errorf("expected error with message : %s, but got : %s", expectedMsg, err.Error())
+				}
+			} else {
+				t.Errorf("error should have occurred")
+			}
+		})
 	}
 }
 
-func TestWindowsVersions(t *testing.T) {
-	for _, version := range common.GetAllSupportedKubernetesVersions(false, true) {
-		cs := getK8sDefaultContainerService(true)
-		cs.Properties.OrchestratorProfile.OrchestratorVersion = version
-		if err := cs.Validate(false); err != nil {
-			t.Errorf(
-				"should not error on valid Windows version: %v", err,
-			)
-		}
-		cs = getK8sDefaultContainerService(true)
-		cs.Properties.WindowsProfile.AdminPassword = "Password"
-		if err := cs.Validate(false); err == nil {
-			t.Errorf(
-				"should error on windows password complexity not match because no digits and special characters found in the password ",
-			)
-		}
-		cs = getK8sDefaultContainerService(true)
-		cs.Properties.WindowsProfile.AdminPassword = "123!@#"
-		if err := cs.Validate(false); err == nil {
-			t.Errorf(
-				"should error on windows password complexity not match because uppercase and lowercase letters found in the password",
-			)
-		}
-		cs = getK8sDefaultContainerService(true)
-		cs.Properties.WindowsProfile.AdminPassword = ""
-		if err := cs.Validate(false); err == nil {
-			t.Errorf(
-				"should error on windows password length is zero",
-			)
-		}
-		cs = getK8sDefaultContainerService(true)
-		cs.Properties.WindowsProfile.AdminUsername = "User@123"
-		cs.Properties.WindowsProfile.AdminPassword = "User@123"
-		if err := cs.Validate(false); err == nil {
-			t.Errorf(
-				"should error on windows password complexity not match because username and password are  same",
-			)
-		}
-		sv, _ := semver.Make(version)
-		cs = getK8sDefaultContainerService(true)
-		cs.Properties.OrchestratorProfile.OrchestratorRelease = fmt.Sprintf("%d.%d", sv.Major, sv.Minor)
-		if err := cs.Validate(false); err != nil {
-			t.Errorf(
-				"should not error on valid Windows version: %v", err,
-			)
-		}
-	}
-	cs := getK8sDefaultContainerService(true)
-	cs.Properties.OrchestratorProfile.OrchestratorRelease = "1.4"
-	if err := cs.Validate(false); err == nil {
-		t.Errorf(
-			"should error on invalid Windows version",
-		)
+func TestProperties_ValidateMasterZoneSpread(t *testing.T) {
+	tests := []struct {
+		name        string
+		count       int
+		zones       []string
+		cosmosEtcd  bool
+		expectedMsg string
+	}{
+		{
+			name:  "single master",
+			count: 1,
+			zones: []string{"1"},
+		},
+		{
+			name:  "3 masters in 3 zones",
+			count: 3,
+			zones: []string{"1", "2", "3"},
+		},
+		{
+			name:  "5 masters in 3 zones",
+			count: 5,
+			zones: []string{"1", "2", "3"},
+		},
+		{
+			name:       "cosmos etcd",
+			count:      3,
+			zones:      []string{"1"},
+			cosmosEtcd: true,
+		},
+		{
+			name:        "3 masters in 1 zone",
+			count:       3,
+			zones:       []string{"1"},
+			expectedMsg: "masterProfile has 3 masters in 1 availability zones, the outage of a zone with 3 of them would lose the etcd quorum of 2 masters. Spread the masters across at least 3 availability zones",
+		},
+		{
+			name:        "3 masters in 2 zones",
+			count:       3,
+			zones:       []string{"1", "2"},
+			expectedMsg: "masterProfile has 3 masters in 2 availability zones, the outage of a zone with 2 of them would lose the etcd quorum of 2 masters. Spread the masters across at least 3 availability zones",
+		},
 	}
 
-	cs = getK8sDefaultContainerService(true)
-	cs.Properties.OrchestratorProfile.OrchestratorVersion = "1.4.0"
-	if err := cs.Validate(false); err == nil {
-		t.Errorf(
-			"should error on invalid Windows version",
-		)
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			cs := getK8sDefaultContainerService(false)
+			m := cs.Properties.MasterProfile
+			m.Count = test.count
+			m.AvailabilityZones = test.zones
+			m.CosmosEtcd = to.BoolPtr(test.cosmosEtcd)
+			err := cs.Properties.validateMasterZoneSpread()
+			if test.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedMsg {
+				t.Errorf("expected error with message : %s, but got %v", test.expectedMsg, err)
+			}
+		})
 	}
 }
 
-func TestLinuxVersions(t *testing.T) {
-	for _, version := range common.GetAllSupportedKubernetesVersions(false, false) {
-		cs := getK8sDefaultContainerService(false)
-		cs.Properties.OrchestratorProfile.OrchestratorVersion = version
-		if err := cs.Validate(false); err != nil {
-			t.Errorf(
-				"should not error on valid Linux version: %v", err,
-			)
-		}
-		sv, _ := semver.Make(version)
-		cs = getK8sDefaultContainerService(false)
-		cs.Properties.OrchestratorProfile.OrchestratorRelease = fmt.Sprintf("%d.%d", sv.Major, sv.Minor)
-		if err := cs.Validate(false); err != nil {
-			t.Errorf(
-				"should not error on valid Linux version: %v", err,
-			)
-		}
-	}
-	cs := getK8sDefaultContainerService(false)
-	cs.Properties.OrchestratorProfile.OrchestratorRelease = "1.4"
-	if err := cs.Validate(false); err == nil {
-		t.Errorf(
-			"should error on invalid Linux version",
-		)
+func TestProperties_ValidateAgentPoolUserAssignedIDs(t *testing.T) {
+	tests := []struct {
+		name               string
+		useManagedIdentity bool
+		userAssignedID     string
+		objectID           string
+		azureStack         bool
+		poolIDs            []string
+		expectedMsg        string
+	}{
+		{
+			name:               "no agent pool identity",
+			useManagedIdentity: true,
+			poolIDs:            []string{""},
+		},
+		{
+			name:               "agent pool identities with the cluster user assigned identity",
+			useManagedIdentity: true,
+			userAssignedID:     "clusterid",
+			poolIDs:            []string{"agentpool1id", "", "agentpool3id"},
+		},
+		{
+			name:     "agent pool identity with the object id of the service principal",
+			objectID: "xxxx",
+			poolIDs:  []string{"agentpool1id"},
+		},
+		{
+			name:               "Azure Stack",
+			useManagedIdentity: true,
+			userAssignedID:     "clusterid",
+			azureStack:         true,
+			poolIDs:            []string{"agentpool1id"},
+			expectedMsg:        "agent pool agentpool0 has a userAssignedID, user assigned identities are not supported on Azure Stack",
+		},
+		{
+			name:               "invalid name",
+			useManagedIdentity: true,
+			userAssignedID:     "clusterid",
+			poolIDs:            []string{"agent pool"},
+			expectedMsg:        "the userAssignedID of agent pool agentpool0 is agent pool, expected the name of a user assigned identity of 3 to 128 letters, digits, dashes and underscores",
+		},
+		{
+			name:               "identity of the cluster",
+			useManagedIdentity: true,
+			userAssignedID:     "clusterid",
+			poolIDs:            []string{"ClusterID"},
+			expectedMsg:        "the userAssignedID ClusterID of agent pool agentpool0 is already the identity of the cluster or of another agent pool",
+		},
+		{
+			name:               "identity of another agent pool",
+			useManagedIdentity: true,
+			userAssignedID:     "clusterid",
+			poolIDs:            []string{"agentpoolid", "agentpoolid"},
+			expectedMsg:        "the userAssignedID agentpoolid of agent pool agentpool1 is already the identity of the cluster or of another agent pool",
+		},
+		{
+			name:               "system assigned cluster identity",
+			useManagedIdentity: true,
+			objectID:           "xxxx",
+			poolIDs:            []string{"agentpool1id"},
+			expectedMsg:        "agent pool agentpool0 has a userAssignedID, which requires the cluster to have a userAssignedID, or a servicePrincipalProfile with an objectId",
+		},
+		{
+			name:        "service principal without object id",
+			poolIDs:     []string{"agentpool1id"},
+			expectedMsg: "agent pool agentpool0 has a userAssignedID, which requires the cluster to have a userAssignedID, or a servicePrincipalProfile with an objectId",
+		},
 	}
 
-	cs = getK8sDefaultContainerService(false)
-	cs.Properties.OrchestratorProfile.OrchestratorVersion = "1.4.0"
-	if err := cs.Validate(false); err == nil {
-		t.Errorf(
-			"should error on invalid Linux version",
-		)
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			cs := getK8sDefaultContainerService(false)
+			cs.Properties.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+				UseManagedIdentity: test.useManagedIdentity,
+				UserAssignedID:     test.userAssignedID,
+			}
+			cs.Properties.ServicePrincipalProfile.ObjectID = test.objectID
+			if test.azureStack {
+				cs.Properties.CustomCloudProfile = &CustomCloudProfile{}
+			}
+			cs.Properties.AgentPoolProfiles = nil
+			for i, id := range test.poolIDs {
+				cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &AgentPoolProfile{
+					Name:           fmt.Sprintf("agentpool%d", i),
+					UserAssignedID: id,
+				})
+			}
+			err := cs.Properties.validateAgentPoolUserAssignedIDs()
+			if test.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedMsg {
+				t.Errorf("expected error with message : %s, but got %v", test.expectedMsg, err)
+			}
+		})
 	}
 }
 
-func TestValidateImageNameAndGroup(t *testing.T) {
+func TestAgentPoolProfile_ValidateZoneBalanceStrategy(t *testing.T) {
 	tests := []struct {
-		name        string
-		image       ImageReference
-		expectedErr error
+		name         string
+		availability string
+		zones        []string
+		strategy     string
+		expectedMsg  string
 	}{
 		{
-			name: "valid run",
-			image: ImageReference{
-				Name:          "rhel9000",
-				ResourceGroup: "club",
-			},
-			expectedErr: nil,
+			name:         "no strategy",
+			availability: VirtualMachineScaleSets,
+			zones:        []string{"1"},
 		},
 		{
-			name: "invalid: image name is missing",
-			image: ImageReference{
-				ResourceGroup: "club",
-			},
-			expectedErr: errors.New(`imageName needs to be specified when imageResourceGroup is provided`),
+			name:         "strict",
+			availability: VirtualMachineScaleSets,
+			zones:        []string{"1", "2", "3"},
+			strategy:     StrictZoneBalance,
 		},
 		{
-			name: "invalid: image resource group is missing",
-			image: ImageReference{
-				Name: "rhel9000",
-			},
-			expectedErr: errors.New(`imageResourceGroup needs to be specified when imageName is provided`),
+			name:         "best effort",
+			availability: VirtualMachineScaleSets,
+			zones:        []string{"1", "2"},
+			strategy:     BestEffortZoneBalance,
+		},
+		{
+			name:         "availability set",
+			availability: AvailabilitySet,
+			strategy:     StrictZoneBalance,
+			expectedMsg:  "agent pool agentpool has zoneBalanceStrategy Strict, which is only supported by VirtualMachineScaleSets",
+		},
+		{
+			name:         "no zones",
+			availability: VirtualMachineScaleSets,
+			strategy:     BestEffortZoneBalance,
+			expectedMsg:  "agent pool agentpool has zoneBalanceStrategy BestEffort, which requires more than one availability zone",
 		},
 	}
 
@@ -1707,208 +4192,189 @@ func TestValidateImageNameAndGroup(t *testing.T) {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			cs := getK8sDefaultContainerService(true)
-			cs.Properties.AgentPoolProfiles = []*AgentPoolProfile{
-				{
-					Name:                "agentpool",
-					VMSize:              "Standard_D2_v2",
-					Count:               1,
-					AvailabilityProfile: AvailabilitySet,
-					ImageRef:            &test.image,
-				},
+			a := &AgentPoolProfile{
+				Name:                "agentpool",
+				AvailabilityProfile: test.availability,
+				AvailabilityZones:   test.zones,
+				ZoneBalanceStrategy: test.strategy,
 			}
-			gotErr := cs.Properties.validateAgentPoolProfiles(true)
-			if !helpers.EqualError(gotErr, test.expectedErr) {
-				t.Logf("scenario %q", test.name)
-				t.Errorf("expected error: %v, got: %v", test.expectedErr, gotErr)
+			err := a.validateZoneBalanceStrategy()
+			if test.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedMsg {
+				t.Errorf("expected error with message : %s, but got %v", test.expectedMsg, err)
 			}
 		})
 	}
 }
-func TestProperties_ValidateManagedIdentity(t *testing.T) {
+
+func TestProperties_ValidateLoadBalancer(t *testing.T) {
 	tests := []struct {
 		name                string
 		orchestratorRelease string
-		useManagedIdentity  bool
-		userAssignedID      string
-		masterProfile       MasterProfile
-		expectedErr         string
-		agentPoolProfiles   []*AgentPoolProfile
+		loadBalancerSku     string
+		masterProfile       *MasterProfile
+		agentProfiles       []*AgentPoolProfile
+		expectedErr         bool
+		expectedErrStr      string
 	}{
 		{
-			name:                "use managed identity with master VMSS",
-			orchestratorRelease: "1.11",
-			useManagedIdentity:  true,
-			userAssignedID:      "utaksenginetestid",
-			masterProfile: MasterProfile{
-				DNSPrefix:           "dummy",
+			name:                "lowercase basic LB",
+			orchestratorRelease: "1.12",
+			loadBalancerSku:     "basic",
+			masterProfile: &MasterProfile{
 				Count:               3,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
 				AvailabilityProfile: VirtualMachineScaleSets,
 			},
-			agentPoolProfiles: []*AgentPoolProfile{
-				{
-					Name:                "agentpool",
-					VMSize:              "Standard_DS2_v2",
-					Count:               1,
-					AvailabilityProfile: VirtualMachineScaleSets,
-				},
-			},
-			expectedErr: "managed identity and VMSS masters can only be used with Kubernetes 1.12.0 or above. Please specify \"orchestratorRelease\": \"1.12\"",
 		},
 		{
-			name:                "use managed identity with master vmas",
-			orchestratorRelease: "1.11",
-			useManagedIdentity:  true,
-			masterProfile: MasterProfile{
-				DNSPrefix: "dummy",
-				Count:     3,
+			name:                "Basic LB",
+			orchestratorRelease: "1.12",
+			loadBalancerSku:     BasicLoadBalancerSku,
+			masterProfile: &MasterProfile{
+				Count:               3,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
+				AvailabilityProfile: VirtualMachineScaleSets,
 			},
-			agentPoolProfiles: []*AgentPoolProfile{
-				{
-					Name:   "agentpool",
-					VMSize: "Standard_DS2_v2",
-					Count:  1,
-				},
+		},
+		{
+			name:                "lowercase standard LB",
+			orchestratorRelease: "1.12",
+			loadBalancerSku:     "standard",
+			masterProfile: &MasterProfile{
+				Count:               3,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
+				AvailabilityProfile: VirtualMachineScaleSets,
 			},
 		},
 		{
-			name:                "use user assigned identity with master vmas",
-			orchestratorRelease: "1.11",
-			useManagedIdentity:  true,
-			userAssignedID:      "aksenginetestid",
-			masterProfile: MasterProfile{
-				DNSPrefix: "dummy",
-				Count:     3,
-			},
-			agentPoolProfiles: []*AgentPoolProfile{
-				{
-					Name:   "agentpool",
-					VMSize: "Standard_DS2_v2",
-					Count:  1,
-				},
+			name:                "Standard LB",
+			orchestratorRelease: "1.12",
+			loadBalancerSku:     StandardLoadBalancerSku,
+			masterProfile: &MasterProfile{
+				Count:               3,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
+				AvailabilityProfile: VirtualMachineScaleSets,
 			},
-			expectedErr: "user assigned identity can only be used with Kubernetes 1.12.0 or above. Please specify \"orchestratorRelease\": \"1.12\"",
 		},
 		{
-			name:                "user master VMSS with empty user assigned ID",
+			name:                "empty string LB value",
 			orchestratorRelease: "1.12",
-			useManagedIdentity:  true,
-			masterProfile: MasterProfile{
-				DNSPrefix:           "dummy",
+			loadBalancerSku:     "",
+			masterProfile: &MasterProfile{
 				Count:               3,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
 				AvailabilityProfile: VirtualMachineScaleSets,
 			},
-			agentPoolProfiles: []*AgentPoolProfile{
-				{
-					Name:                "agentpool",
-					VMSize:              "Standard_DS2_v2",
-					Count:               1,
-					AvailabilityProfile: VirtualMachineScaleSets,
-				},
+		},
+		{
+			name:                "invalid LB string value",
+			orchestratorRelease: "1.12",
+			loadBalancerSku:     "foo",
+			masterProfile: &MasterProfile{
+				Count:               3,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
+				AvailabilityProfile: VirtualMachineScaleSets,
 			},
-			expectedErr: "virtualMachineScaleSets for master profile can be used only with user assigned MSI ! Please specify \"userAssignedID\" in \"kubernetesConfig\"",
+			expectedErr:    true,
+			expectedErrStr: fmt.Sprintf("Invalid value for loadBalancerSku, only %s and %s are supported", StandardLoadBalancerSku, BasicLoadBalancerSku),
 		},
 	}
+
 	for _, test := range tests {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 			cs := getK8sDefaultContainerService(true)
-			cs.Properties.MasterProfile = &test.masterProfile
-			cs.Properties.MasterProfile.VMSize = "Standard_DS2_v2"
-			cs.Properties.OrchestratorProfile = &OrchestratorProfile{
-				OrchestratorRelease: test.orchestratorRelease,
-				OrchestratorType:    Kubernetes,
-				KubernetesConfig: &KubernetesConfig{
-					UseManagedIdentity: test.useManagedIdentity,
-					UserAssignedID:     test.userAssignedID,
-				},
+			cs.Properties.MasterProfile = test.masterProfile
+			cs.Properties.AgentPoolProfiles = test.agentProfiles
+			cs.Properties.OrchestratorProfile.OrchestratorRelease = test.orchestratorRelease
+			cs.Properties.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+				LoadBalancerSku: test.loadBalancerSku,
 			}
-			cs.Properties.AgentPoolProfiles = test.agentPoolProfiles
+
 			err := cs.Validate(false)
-			if test.expectedErr == "" && err != nil ||
-				test.expectedErr != "" && (err == nil || test.expectedErr != err.Error()) {
-				t.Errorf("test %s: unexpected error %q\n", test.name, err)
+			if test.expectedErr {
+				if err == nil {
+					t.Errorf("error should have occurred")
+				} else {
+					if err.Error() != test.expectedErrStr {
+						t.Errorf("expected error with message : %s, but got : %s", test.expectedErrStr, err.Error())
+					}
+				}
 			}
 		})
 	}
 }
 
-func TestMasterProfileValidate(t *testing.T) {
+func TestProperties_ValidateSinglePlacementGroup(t *testing.T) {
+
 	tests := []struct {
-		name                string
-		orchestratorType    string
-		orchestratorVersion string
-		orchestratorRelease string
-		useInstanceMetadata bool
-		masterProfile       MasterProfile
-		agentPoolProfiles   []*AgentPoolProfile
-		expectedErr         string
+		name              string
+		masterProfile     *MasterProfile
+		agentPoolProfiles []*AgentPoolProfile
+		expectedMsg       string
 	}{
 		{
-			name: "Master Profile with Invalid DNS Prefix",
-			masterProfile: MasterProfile{
-				DNSPrefix: "bad!",
-			},
-			expectedErr: "DNSPrefix 'bad!' is invalid. The DNSPrefix must contain between 3 and 45 characters and can contain only letters, numbers, and hyphens.  It must start with a letter and must end with a letter or a number. (length was 4)",
-		},
-		{
-			name: "Master Profile with valid DNS Prefix 1",
-			masterProfile: MasterProfile{
-				DNSPrefix: "dummy",
-				Count:     1,
+			name: "Master profile VMAS with SinglePlacementGroup",
+			masterProfile: &MasterProfile{
+				Count:                1,
+				DNSPrefix:            "foo",
+				VMSize:               "Standard_DS2_v2",
+				AvailabilityProfile:  AvailabilitySet,
+				SinglePlacementGroup: to.BoolPtr(false),
 			},
+			expectedMsg: "singlePlacementGroup is only supported with VirtualMachineScaleSets",
 		},
 		{
-			name: "Master Profile with valid DNS Prefix 2",
-			masterProfile: MasterProfile{
-				DNSPrefix: "dummy",
-				Count:     3,
+			name: "Agent profile VMAS with SinglePlacementGroup",
+			masterProfile: &MasterProfile{
+				Count:               1,
+				DNSPrefix:           "foo",
+				VMSize:              "Standard_DS2_v2",
+				AvailabilityProfile: VirtualMachineScaleSets,
 			},
-		},
-		{
-			name:             "Master Profile with empty imageName and non-empty imageResourceGroup",
-			orchestratorType: Kubernetes,
-			masterProfile: MasterProfile{
-				DNSPrefix: "dummy",
-				Count:     3,
-				ImageRef: &ImageReference{
-					Name:          "",
-					ResourceGroup: "rg",
+			agentPoolProfiles: []*AgentPoolProfile{
+				{
+					Name:                 "agentpool",
+					VMSize:               "Standard_DS2_v2",
+					Count:                4,
+					AvailabilityProfile:  AvailabilitySet,
+					SinglePlacementGroup: to.BoolPtr(false),
 				},
 			},
-			expectedErr: "imageName needs to be specified when imageResourceGroup is provided",
-		},
-		{
-			name:                "Master Profile with VMSS and storage account",
-			orchestratorType:    Kubernetes,
-			orchestratorRelease: "1.10",
-			masterProfile: MasterProfile{
-				DNSPrefix:           "dummy",
-				Count:               3,
-				AvailabilityProfile: VirtualMachineScaleSets,
-				StorageProfile:      StorageAccount,
-			},
-			expectedErr: "VirtualMachineScaleSets does not support StorageAccount disks.  Please specify \"storageProfile\": \"ManagedDisks\" (recommended) or \"availabilityProfile\": \"AvailabilitySet\"",
+			expectedMsg: `VirtualMachineScaleSets for master profile must be used together with virtualMachineScaleSets for agent profiles. Set "availabilityProfile" to "VirtualMachineScaleSets" for agent profiles`,
 		},
 		{
-			name:                "Master Profile with VMSS and agent profiles with VMAS",
-			orchestratorType:    Kubernetes,
-			orchestratorRelease: "1.10",
-			masterProfile: MasterProfile{
-				DNSPrefix:           "dummy",
-				Count:               3,
-				AvailabilityProfile: VirtualMachineScaleSets,
+			name: "VMSS with SinglePlacementGroup false and StorageAccount storage",
+			masterProfile: &MasterProfile{
+				Count:                1,
+				DNSPrefix:            "foo",
+				VMSize:               "Standard_DS2_v2",
+				AvailabilityProfile:  VirtualMachineScaleSets,
+				SinglePlacementGroup: to.BoolPtr(false),
+				StorageProfile:       StorageAccount,
 			},
 			agentPoolProfiles: []*AgentPoolProfile{
 				{
 					Name:                "agentpool",
 					VMSize:              "Standard_DS2_v2",
-					Count:               1,
-					AvailabilityProfile: AvailabilitySet,
+					Count:               4,
+					AvailabilityProfile: VirtualMachineScaleSets,
 				},
 			},
-			expectedErr: "VirtualMachineScaleSets for master profile must be used together with virtualMachineScaleSets for agent profiles. Set \"availabilityProfile\" to \"VirtualMachineScaleSets\" for agent profiles",
+			expectedMsg: "VirtualMachineScaleSets does not support StorageAccount disks.  Please specify \"storageProfile\": \"ManagedDisks\" (recommended) or \"availabilityProfile\": \"AvailabilitySet\"",
 		},
 	}
 
@@ -1917,174 +4383,172 @@ func TestMasterProfileValidate(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 			cs := getK8sDefaultContainerService(true)
-			cs.Properties.MasterProfile = &test.masterProfile
-			cs.Properties.MasterProfile.StorageProfile = test.masterProfile.StorageProfile
-			cs.Properties.OrchestratorProfile = &OrchestratorProfile{
-				OrchestratorType:    test.orchestratorType,
-				OrchestratorVersion: test.orchestratorVersion,
-				OrchestratorRelease: test.orchestratorRelease,
-				KubernetesConfig: &KubernetesConfig{
-					UseInstanceMetadata: to.BoolPtr(test.useInstanceMetadata),
-				},
-			}
+			cs.Properties.OrchestratorProfile.OrchestratorRelease = "1.12"
+			cs.Properties.MasterProfile = test.masterProfile
 			cs.Properties.AgentPoolProfiles = test.agentPoolProfiles
-			err := cs.Properties.validateMasterProfile(false)
-			if test.expectedErr == "" && err != nil ||
-				test.expectedErr != "" && (err == nil || test.expectedErr != err.Error()) {
-				t.Errorf("test %s: unexpected error %q\n", test.name, err)
+			err := cs.Validate(true)
+			if err.Error() != test.expectedMsg {
+				t.Errorf("expected error message : %s, but got %s", test.expectedMsg, err.Error())
 			}
 		})
 	}
 }
 
-func TestProperties_ValidateZones(t *testing.T) {
+func TestProperties_ValidateVNET(t *testing.T) {
+	validVNetSubnetID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME"
+	validVNetSubnetID2 := "/subscriptions/SUB_ID2/resourceGroups/RG_NAME2/providers/Microsoft.Network/virtualNetworks/VNET_NAME2/subnets/SUBNET_NAME"
+
 	tests := []struct {
-		name                        string
-		orchestratorRelease         string
-		loadBalancerSku             string
-		excludeMasterFromStandardLB bool
-		masterProfile               *MasterProfile
-		agentProfiles               []*AgentPoolProfile
-		expectedErr                 string
+		name              string
+		masterProfile     *MasterProfile
+		agentPoolProfiles []*AgentPoolProfile
+		expectedMsg       string
 	}{
 		{
-			name:                "Master profile with zones version",
-			orchestratorRelease: "1.11",
+			name: "Multiple VNET Subnet configs",
 			masterProfile: &MasterProfile{
-				Count:               3,
-				DNSPrefix:           "foo",
-				VMSize:              "Standard_DS2_v2",
-				AvailabilityProfile: VirtualMachineScaleSets,
-				AvailabilityZones:   []string{"1", "2"},
+				VnetSubnetID: "testvnetstring",
+				Count:        1,
+				DNSPrefix:    "foo",
+				VMSize:       "Standard_DS2_v2",
 			},
-			expectedErr: "availabilityZone is only available in Kubernetes version 1.12 or greater",
+			agentPoolProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_D2_v2",
+					Count:               1,
+					AvailabilityProfile: AvailabilitySet,
+					VnetSubnetID:        "",
+				},
+			},
+			expectedMsg: "Multiple VNET Subnet configurations specified.  The master profile and each agent pool profile must all specify a custom VNET Subnet, or none at all",
 		},
 		{
-			name:                "Agent profile with zones version",
-			orchestratorRelease: "1.11",
+			name: "Invalid vnet subnet ID",
 			masterProfile: &MasterProfile{
-				Count:               1,
-				DNSPrefix:           "foo",
-				VMSize:              "Standard_DS2_v2",
-				AvailabilityProfile: VirtualMachineScaleSets,
-				AvailabilityZones:   []string{"1", "2"},
+				VnetSubnetID: "testvnetstring",
+				Count:        1,
+				DNSPrefix:    "foo",
+				VMSize:       "Standard_DS2_v2",
 			},
-			agentProfiles: []*AgentPoolProfile{
-				{
-					Name:                "agentpool",
-					VMSize:              "Standard_DS2_v2",
-					Count:               4,
-					AvailabilityProfile: VirtualMachineScaleSets,
-					AvailabilityZones:   []string{"1", "2"},
+			agentPoolProfiles: []*AgentPoolProfile{
+				{
+					Name:                "agentpool",
+					VMSize:              "Standard_D2_v2",
+					Count:               1,
+					AvailabilityProfile: AvailabilitySet,
+					VnetSubnetID:        "testvnetstring",
 				},
 			},
-			expectedErr: "availabilityZone is only available in Kubernetes version 1.12 or greater",
+			expectedMsg: "Unable to parse vnetSubnetID. Please use a vnetSubnetID with format /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME",
 		},
 		{
-			name:                "Agent profile with zones vmas",
-			orchestratorRelease: "1.12",
+			name: "Multiple VNETs",
 			masterProfile: &MasterProfile{
-				Count:               5,
-				DNSPrefix:           "foo",
-				VMSize:              "Standard_DS2_v2",
-				AvailabilityProfile: VirtualMachineScaleSets,
-				AvailabilityZones:   []string{"1", "2"},
+				VnetSubnetID: validVNetSubnetID,
+				Count:        1,
+				DNSPrefix:    "foo",
+				VMSize:       "Standard_DS2_v2",
 			},
-			agentProfiles: []*AgentPoolProfile{
+			agentPoolProfiles: []*AgentPoolProfile{
 				{
 					Name:                "agentpool",
-					VMSize:              "Standard_DS2_v2",
-					Count:               4,
+					VMSize:              "Standard_D2_v2",
+					Count:               1,
 					AvailabilityProfile: AvailabilitySet,
-					AvailabilityZones:   []string{"1", "2"},
+					VnetSubnetID:        validVNetSubnetID,
+				},
+				{
+					Name:                "agentpool2",
+					VMSize:              "Standard_D2_v2",
+					Count:               1,
+					AvailabilityProfile: AvailabilitySet,
+					VnetSubnetID:        validVNetSubnetID2,
 				},
 			},
-			expectedErr: "VirtualMachineScaleSets for master profile must be used together with virtualMachineScaleSets for agent profiles. Set \"availabilityProfile\" to \"VirtualMachineScaleSets\" for agent profiles",
+			expectedMsg: "Multiple VNETS specified.  The master profile and each agent pool must reference the same VNET (but it is ok to reference different subnets on that VNET)",
 		},
 		{
-			name:                "Master profile with zones and Agent profile without zones",
-			orchestratorRelease: "1.12",
+			name: "Invalid MasterProfile FirstConsecutiveStaticIP when master is VMAS",
 			masterProfile: &MasterProfile{
-				Count:               5,
-				DNSPrefix:           "foo",
-				VMSize:              "Standard_DS2_v2",
-				AvailabilityProfile: VirtualMachineScaleSets,
-				AvailabilityZones:   []string{"1", "2"},
+				VnetSubnetID:             validVNetSubnetID,
+				Count:                    1,
+				DNSPrefix:                "foo",
+				VMSize:                   "Standard_DS2_v2",
+				FirstConsecutiveStaticIP: "10.0.0.invalid",
 			},
-			agentProfiles: []*AgentPoolProfile{
+			agentPoolProfiles: []*AgentPoolProfile{
 				{
 					Name:                "agentpool",
-					VMSize:              "Standard_DS2_v2",
-					Count:               4,
-					AvailabilityProfile: VirtualMachineScaleSets,
+					VMSize:              "Standard_D2_v2",
+					Count:               1,
+					AvailabilityProfile: AvailabilitySet,
+					VnetSubnetID:        validVNetSubnetID,
 				},
 			},
-			expectedErr: "Availability Zones need to be defined for master profile and all agent pool profiles. Please set \"availabilityZones\" for all profiles",
+			expectedMsg: "MasterProfile.FirstConsecutiveStaticIP (with VNET Subnet specification) '10.0.0.invalid' is an invalid IP address",
 		},
 		{
-			name:                "Master profile without zones and Agent profile with zones",
-			orchestratorRelease: "1.12",
+			name: "Empty MasterProfile FirstConsecutiveStaticIP and empty agentVnetSubnetID when master is VMSS",
 			masterProfile: &MasterProfile{
-				Count:               3,
+				VnetSubnetID:        validVNetSubnetID,
+				Count:               1,
 				DNSPrefix:           "foo",
 				VMSize:              "Standard_DS2_v2",
 				AvailabilityProfile: VirtualMachineScaleSets,
 			},
-			agentProfiles: []*AgentPoolProfile{
+			agentPoolProfiles: []*AgentPoolProfile{
 				{
 					Name:                "agentpool",
-					VMSize:              "Standard_DS2_v2",
-					Count:               4,
+					VMSize:              "Standard_D2_v2",
+					Count:               1,
 					AvailabilityProfile: VirtualMachineScaleSets,
-					AvailabilityZones:   []string{"1", "2"},
+					VnetSubnetID:        validVNetSubnetID,
 				},
 			},
-			expectedErr: "Availability Zones need to be defined for master profile and all agent pool profiles. Please set \"availabilityZones\" for all profiles",
+			expectedMsg: "when master profile is using VirtualMachineScaleSets and is custom vnet, set \"vnetsubnetid\" and \"agentVnetSubnetID\" for master profile",
 		},
 		{
-			name:                "all zones and basic loadbalancer",
-			orchestratorRelease: "1.12",
-			loadBalancerSku:     BasicLoadBalancerSku,
+			name: "User-provided MasterProfile FirstConsecutiveStaticIP when master is VMSS",
 			masterProfile: &MasterProfile{
-				Count:               5,
-				DNSPrefix:           "foo",
-				VMSize:              "Standard_DS2_v2",
-				AvailabilityProfile: VirtualMachineScaleSets,
-				AvailabilityZones:   []string{"1", "2"},
+				VnetSubnetID:             validVNetSubnetID,
+				Count:                    1,
+				DNSPrefix:                "foo",
+				VMSize:                   "Standard_DS2_v2",
+				AvailabilityProfile:      VirtualMachineScaleSets,
+				FirstConsecutiveStaticIP: "10.0.0.4",
 			},
-			agentProfiles: []*AgentPoolProfile{
+			agentPoolProfiles: []*AgentPoolProfile{
 				{
 					Name:                "agentpool",
-					VMSize:              "Standard_DS2_v2",
-					Count:               4,
+					VMSize:              "Standard_D2_v2",
+					Count:               1,
 					AvailabilityProfile: VirtualMachineScaleSets,
-					AvailabilityZones:   []string{"1", "2"},
+					VnetSubnetID:        validVNetSubnetID,
 				},
 			},
-			expectedErr: "Availability Zones requires Standard LoadBalancer. Please set KubernetesConfig \"LoadBalancerSku\" to \"Standard\"",
+			expectedMsg: "when masterProfile's availabilityProfile is VirtualMachineScaleSets and a vnetSubnetID is specified, the firstConsecutiveStaticIP should be empty and will be determined by an offset from the first IP in the vnetCidr",
 		},
 		{
-			name:                        "all zones with standard loadbalancer and false excludeMasterFromStandardLB",
-			orchestratorRelease:         "1.12",
-			loadBalancerSku:             StandardLoadBalancerSku,
-			excludeMasterFromStandardLB: false,
+			name: "Invalid vnetcidr",
 			masterProfile: &MasterProfile{
-				Count:               5,
-				DNSPrefix:           "foo",
-				VMSize:              "Standard_DS2_v2",
-				AvailabilityProfile: VirtualMachineScaleSets,
-				AvailabilityZones:   []string{"1", "2"},
+				VnetSubnetID:             validVNetSubnetID,
+				Count:                    1,
+				DNSPrefix:                "foo",
+				VMSize:                   "Standard_DS2_v2",
+				FirstConsecutiveStaticIP: "10.0.0.1",
+				VnetCidr:                 "10.1.0.0/invalid",
 			},
-			agentProfiles: []*AgentPoolProfile{
+			agentPoolProfiles: []*AgentPoolProfile{
 				{
 					Name:                "agentpool",
-					VMSize:              "Standard_DS2_v2",
-					Count:               4,
-					AvailabilityProfile: VirtualMachineScaleSets,
-					AvailabilityZones:   []string{"1", "2"},
+					VMSize:              "Standard_D2_v2",
+					Count:               1,
+					AvailabilityProfile: AvailabilitySet,
+					VnetSubnetID:        validVNetSubnetID,
 				},
 			},
-			expectedErr: "standard loadBalancerSku should exclude master nodes. Please set KubernetesConfig \"ExcludeMasterFromStandardLB\" to \"true\"",
+			expectedMsg: "MasterProfile.VnetCidr '10.1.0.0/invalid' contains invalid cidr notation",
 		},
 	}
 
@@ -2093,103 +4557,290 @@ func TestProperties_ValidateZones(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 			cs := getK8sDefaultContainerService(true)
+			cs.Properties.OrchestratorProfile.OrchestratorRelease = "1.10"
 			cs.Properties.MasterProfile = test.masterProfile
-			cs.Properties.AgentPoolProfiles = test.agentProfiles
-			cs.Properties.OrchestratorProfile.OrchestratorRelease = test.orchestratorRelease
-			cs.Properties.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-				LoadBalancerSku:             test.loadBalancerSku,
-				ExcludeMasterFromStandardLB: to.BoolPtr(test.excludeMasterFromStandardLB),
+			cs.Properties.AgentPoolProfiles = test.agentPoolProfiles
+			err := cs.Validate(true)
+			if err.Error() != test.expectedMsg {
+				t.Errorf("expected error message : %s, but got %s", test.expectedMsg, err.Error())
 			}
+		})
+	}
+}
 
-			if err := cs.Validate(false); err != nil {
-				expectedMsg := test.expectedErr
-				if err.Error() != expectedMsg {
-					t.Errorf("expected error with message : %s, but got : %s", expectedMsg, err.Error())
+func TestProperties_ValidateEtcdSubnet(t *testing.T) {
+	validVNetSubnetID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME"
+	validEtcdVNetSubnetID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/ETCD_SUBNET_NAME"
+	otherVNetEtcdSubnetID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME2/subnets/ETCD_SUBNET_NAME"
+
+	tests := []struct {
+		name          string
+		masterProfile *MasterProfile
+		networkPlugin string
+		expectedMsg   string
+	}{
+		{
+			name:          "no etcd subnet",
+			masterProfile: &MasterProfile{},
+		},
+		{
+			name:          "etcd subnet",
+			masterProfile: &MasterProfile{EtcdSubnet: "10.239.0.0/24", EtcdFirstConsecutiveStaticIP: "10.239.0.10"},
+		},
+		{
+			name:          "etcd subnet in a custom VNET",
+			masterProfile: &MasterProfile{VnetSubnetID: validVNetSubnetID, EtcdSubnet: "10.239.0.0/24", EtcdVnetSubnetID: validEtcdVNetSubnetID},
+		},
+		{
+			name:          "etcd subnet outside of the kubenet master subnet",
+			masterProfile: &MasterProfile{EtcdSubnet: "10.241.0.0/24"},
+			networkPlugin: "kubenet",
+		},
+		{
+			name:          "etcd static IP without etcd subnet",
+			masterProfile: &MasterProfile{EtcdFirstConsecutiveStaticIP: "10.239.0.10"},
+			expectedMsg:   "masterProfile.etcdVnetSubnetID and masterProfile.etcdFirstConsecutiveStaticIP can only be set together with masterProfile.etcdSubnet",
+		},
+		{
+			name:          "VMSS masters",
+			masterProfile: &MasterProfile{AvailabilityProfile: VirtualMachineScaleSets, EtcdSubnet: "10.239.0.0/24"},
+			expectedMsg:   "masterProfile.etcdSubnet is not supported with VirtualMachineScaleSets masters",
+		},
+		{
+			name:          "cosmos etcd",
+			masterProfile: &MasterProfile{CosmosEtcd: to.BoolPtr(true), EtcdSubnet: "10.239.0.0/24"},
+			expectedMsg:   "masterProfile.etcdSubnet cannot be used with cosmosEtcd, etcd does not run on the masters",
+		},
+		{
+			name:          "invalid etcd subnet",
+			masterProfile: &MasterProfile{EtcdSubnet: "10.239.0.0/invalid"},
+			expectedMsg:   "masterProfile.etcdSubnet '10.239.0.0/invalid' contains invalid cidr notation",
+		},
+		{
+			name:          "etcd static IPs outside of the etcd subnet",
+			masterProfile: &MasterProfile{EtcdSubnet: "10.239.0.0/24", EtcdFirstConsecutiveStaticIP: "10.239.0.254"},
+			expectedMsg:   "masterProfile.etcdSubnet '10.239.0.0/24' must contain the 3 consecutive IP addresses starting at masterProfile.etcdFirstConsecutiveStaticIP '10.239.0.254'",
+		},
+		{
+			name:          "missing etcd vnet subnet ID in a custom VNET",
+			masterProfile: &MasterProfile{VnetSubnetID: validVNetSubnetID, EtcdSubnet: "10.239.0.0/24"},
+			expectedMsg:   "masterProfile.etcdVnetSubnetID must be set to the subnet matching masterProfile.etcdSubnet when masterProfile.vnetSubnetID is set",
+		},
+		{
+			name:          "etcd vnet subnet ID of the masters",
+			masterProfile: &MasterProfile{VnetSubnetID: validVNetSubnetID, EtcdSubnet: "10.239.0.0/24", EtcdVnetSubnetID: validVNetSubnetID},
+			expectedMsg:   "masterProfile.etcdVnetSubnetID must be a different subnet than masterProfile.vnetSubnetID",
+		},
+		{
+			name:          "etcd vnet subnet ID in another VNET",
+			masterProfile: &MasterProfile{VnetSubnetID: validVNetSubnetID, EtcdSubnet: "10.239.0.0/24", EtcdVnetSubnetID: otherVNetEtcdSubnetID},
+			expectedMsg:   "masterProfile.etcdVnetSubnetID must reference a subnet of the VNET of masterProfile.vnetSubnetID",
+		},
+		{
+			name:          "etcd vnet subnet ID without custom VNET",
+			masterProfile: &MasterProfile{EtcdSubnet: "10.239.0.0/24", EtcdVnetSubnetID: validEtcdVNetSubnetID},
+			expectedMsg:   "masterProfile.etcdVnetSubnetID can only be set when masterProfile.vnetSubnetID is set",
+		},
+		{
+			name:          "etcd subnet in the Azure CNI master subnet",
+			masterProfile: &MasterProfile{EtcdSubnet: "10.241.0.0/24"},
+			expectedMsg:   "masterProfile.etcdSubnet must not overlap other networking CIDR ranges: MasterProfile.EtcdSubnet '10.241.0.0/24' overlaps with the master subnet '10.240.0.0/12'",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			m := test.masterProfile
+			m.Count = 3
+			p := &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: &KubernetesConfig{NetworkPlugin: test.networkPlugin},
+				},
+				MasterProfile: m,
+			}
+			err := p.validateEtcdSubnet()
+			if test.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
 				}
-			} else {
-				t.Errorf("error should have occurred")
+				return
+			}
+			if err == nil || err.Error() != test.expectedMsg {
+				t.Errorf("expected error message : %s, but got %v", test.expectedMsg, err)
 			}
 		})
 	}
 }
 
-func TestProperties_ValidateLoadBalancer(t *testing.T) {
+func TestProperties_ValidatePodSubnets(t *testing.T) {
+	validVNetSubnetID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME"
+	validPodSubnetID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/POD_SUBNET_NAME"
+	otherVNetPodSubnetID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME2/subnets/POD_SUBNET_NAME"
+
 	tests := []struct {
-		name                string
-		orchestratorRelease string
-		loadBalancerSku     string
-		masterProfile       *MasterProfile
-		agentProfiles       []*AgentPoolProfile
-		expectedErr         bool
-		expectedErrStr      string
+		name          string
+		agentPool     *AgentPoolProfile
+		networkPlugin string
+		networkPolicy string
+		expectedMsg   string
+	}{
+		{
+			name:      "no pod subnet",
+			agentPool: &AgentPoolProfile{},
+		},
+		{
+			name:      "pod subnet",
+			agentPool: &AgentPoolProfile{VnetSubnetID: validVNetSubnetID, PodSubnetID: validPodSubnetID},
+		},
+		{
+			name:          "pod subnet with azure network plugin",
+			agentPool:     &AgentPoolProfile{VnetSubnetID: validVNetSubnetID, PodSubnetID: validPodSubnetID},
+			networkPlugin: "azure",
+			networkPolicy: "calico",
+		},
+		{
+			name:          "kubenet network plugin",
+			agentPool:     &AgentPoolProfile{VnetSubnetID: validVNetSubnetID, PodSubnetID: validPodSubnetID},
+			networkPlugin: "kubenet",
+			expectedMsg:   "agentPoolProfile agentpool podSubnetID requires networkPlugin azure",
+		},
+		{
+			name:          "cilium network policy",
+			agentPool:     &AgentPoolProfile{VnetSubnetID: validVNetSubnetID, PodSubnetID: validPodSubnetID},
+			networkPolicy: "cilium",
+			expectedMsg:   "agentPoolProfile agentpool podSubnetID requires networkPlugin azure",
+		},
+		{
+			name:        "windows agent pool",
+			agentPool:   &AgentPoolProfile{OSType: Windows, VnetSubnetID: validVNetSubnetID, PodSubnetID: validPodSubnetID},
+			expectedMsg: "agentPoolProfile agentpool podSubnetID is not supported with Windows agent pools",
+		},
+		{
+			name:        "pod subnet without custom VNET",
+			agentPool:   &AgentPoolProfile{PodSubnetID: validPodSubnetID},
+			expectedMsg: "agentPoolProfile agentpool podSubnetID can only be set when vnetSubnetID is set",
+		},
+		{
+			name:        "pod subnet of the nodes",
+			agentPool:   &AgentPoolProfile{VnetSubnetID: validVNetSubnetID, PodSubnetID: validVNetSubnetID},
+			expectedMsg: "agentPoolProfile agentpool podSubnetID must be a different subnet than vnetSubnetID",
+		},
+		{
+			name:        "pod subnet in another VNET",
+			agentPool:   &AgentPoolProfile{VnetSubnetID: validVNetSubnetID, PodSubnetID: otherVNetPodSubnetID},
+			expectedMsg: "agentPoolProfile agentpool podSubnetID must reference a subnet of the VNET of vnetSubnetID",
+		},
+		{
+			name:        "invalid pod subnet",
+			agentPool:   &AgentPoolProfile{VnetSubnetID: validVNetSubnetID, PodSubnetID: "POD_SUBNET_NAME"},
+			expectedMsg: "Unable to parse vnetSubnetID. Please use a vnetSubnetID with format /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			test.agentPool.Name = "agentpool"
+			p := &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: &KubernetesConfig{NetworkPlugin: test.networkPlugin, NetworkPolicy: test.networkPolicy},
+				},
+				AgentPoolProfiles: []*AgentPoolProfile{test.agentPool},
+			}
+			err := p.validatePodSubnets()
+			if test.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedMsg {
+				t.Errorf("expected error message : %s, but got %v", test.expectedMsg, err)
+			}
+		})
+	}
+}
+
+func TestProperties_ValidateVMExtensions(t *testing.T) {
+	qualys := VMExtension{Name: "qualys", Publisher: "Qualys", Type: "QualysAgentLinux", TypeHandlerVersion: "1.6"}
+	monitoring := VMExtension{Name: "monitoring", Publisher: "Microsoft.EnterpriseCloud.Monitoring", Type: "OmsAgentForLinux", TypeHandlerVersion: "1.12"}
+
+	tests := []struct {
+		name             string
+		orchestratorType string
+		masterExtensions []VMExtension
+		osType           OSType
+		agentExtensions  []VMExtension
+		expectedMsg      string
 	}{
 		{
-			name:                "lowercase basic LB",
-			orchestratorRelease: "1.12",
-			loadBalancerSku:     "basic",
-			masterProfile: &MasterProfile{
-				Count:               3,
-				DNSPrefix:           "foo",
-				VMSize:              "Standard_DS2_v2",
-				AvailabilityProfile: VirtualMachineScaleSets,
-			},
+			name:             "no vmExtensions",
+			orchestratorType: Kubernetes,
+		},
+		{
+			name:             "vmExtensions on the master and an agent pool",
+			orchestratorType: Kubernetes,
+			masterExtensions: []VMExtension{qualys},
+			agentExtensions:  []VMExtension{qualys, monitoring},
+		},
+		{
+			name:             "Windows custom script extension on Linux",
+			orchestratorType: Kubernetes,
+			agentExtensions:  []VMExtension{{Name: "script", Publisher: "Microsoft.Compute", Type: "CustomScriptExtension", TypeHandlerVersion: "1.8"}},
 		},
 		{
-			name:                "Basic LB",
-			orchestratorRelease: "1.12",
-			loadBalancerSku:     BasicLoadBalancerSku,
-			masterProfile: &MasterProfile{
-				Count:               3,
-				DNSPrefix:           "foo",
-				VMSize:              "Standard_DS2_v2",
-				AvailabilityProfile: VirtualMachineScaleSets,
-			},
+			name:             "another orchestrator",
+			orchestratorType: DCOS,
+			agentExtensions:  []VMExtension{qualys},
+			expectedMsg:      "vmExtensions are only supported with the Kubernetes orchestrator",
 		},
 		{
-			name:                "lowercase standard LB",
-			orchestratorRelease: "1.12",
-			loadBalancerSku:     "standard",
-			masterProfile: &MasterProfile{
-				Count:               3,
-				DNSPrefix:           "foo",
-				VMSize:              "Standard_DS2_v2",
-				AvailabilityProfile: VirtualMachineScaleSets,
-			},
+			name:             "missing name",
+			orchestratorType: Kubernetes,
+			masterExtensions: []VMExtension{{Publisher: "Qualys", Type: "QualysAgentLinux", TypeHandlerVersion: "1.6"}},
+			expectedMsg:      "the vmExtensions of masterProfile must have a name",
 		},
 		{
-			name:                "Standard LB",
-			orchestratorRelease: "1.12",
-			loadBalancerSku:     StandardLoadBalancerSku,
-			masterProfile: &MasterProfile{
-				Count:               3,
-				DNSPrefix:           "foo",
-				VMSize:              "Standard_DS2_v2",
-				AvailabilityProfile: VirtualMachineScaleSets,
-			},
+			name:             "invalid name",
+			orchestratorType: Kubernetes,
+			agentExtensions:  []VMExtension{{Name: "qualys/agent", Publisher: "Qualys", Type: "QualysAgentLinux", TypeHandlerVersion: "1.6"}},
+			expectedMsg:      "vmExtension name qualys/agent of agent pool agentpool1 is invalid, it must match ^[A-Za-z0-9][-A-Za-z0-9_.]{0,63}$",
 		},
 		{
-			name:                "empty string LB value",
-			orchestratorRelease: "1.12",
-			loadBalancerSku:     "",
-			masterProfile: &MasterProfile{
-				Count:               3,
-				DNSPrefix:           "foo",
-				VMSize:              "Standard_DS2_v2",
-				AvailabilityProfile: VirtualMachineScaleSets,
-			},
+			name:             "duplicate name",
+			orchestratorType: Kubernetes,
+			agentExtensions:  []VMExtension{qualys, {Name: "Qualys", Publisher: "Qualys", Type: "QualysAgent", TypeHandlerVersion: "1.6"}},
+			expectedMsg:      "vmExtension Qualys is declared more than once in agent pool agentpool1",
 		},
 		{
-			name:                "invalid LB string value",
-			orchestratorRelease: "1.12",
-			loadBalancerSku:     "foo",
-			masterProfile: &MasterProfile{
-				Count:               3,
-				DNSPrefix:           "foo",
-				VMSize:              "Standard_DS2_v2",
-				AvailabilityProfile: VirtualMachineScaleSets,
-			},
-			expectedErr:    true,
-			expectedErrStr: fmt.Sprintf("Invalid value for loadBalancerSku, only %s and %s are supported", StandardLoadBalancerSku, BasicLoadBalancerSku),
+			name:             "missing type handler version",
+			orchestratorType: Kubernetes,
+			agentExtensions:  []VMExtension{{Name: "qualys", Publisher: "Qualys", Type: "QualysAgentLinux"}},
+			expectedMsg:      "vmExtension qualys of agent pool agentpool1 must have a publisher, a type and a typeHandlerVersion",
+		},
+		{
+			name:             "duplicate publisher and type",
+			orchestratorType: Kubernetes,
+			agentExtensions:  []VMExtension{qualys, {Name: "qualys2", Publisher: "Qualys", Type: "QualysAgentLinux", TypeHandlerVersion: "1.7"}},
+			expectedMsg:      "vmExtension qualys2 of agent pool agentpool1 has the same publisher and type Qualys.QualysAgentLinux as another vmExtension of agent pool agentpool1, a VM can only have one of them",
+		},
+		{
+			name:             "Linux custom script extension",
+			orchestratorType: Kubernetes,
+			masterExtensions: []VMExtension{{Name: "script", Publisher: "Microsoft.Azure.Extensions", Type: "CustomScript", TypeHandlerVersion: "2.0"}},
+			expectedMsg:      "vmExtension script of masterProfile is a Microsoft.Azure.Extensions.CustomScript extension, which aks-engine uses to provision the VMs of masterProfile",
+		},
+		{
+			name:             "Windows custom script extension",
+			orchestratorType: Kubernetes,
+			osType:           Windows,
+			agentExtensions:  []VMExtension{{Name: "script", Publisher: "Microsoft.Compute", Type: "CustomScriptExtension", TypeHandlerVersion: "1.8"}},
+			expectedMsg:      "vmExtension script of agent pool agentpool1 is a Microsoft.Compute.CustomScriptExtension extension, which aks-engine uses to provision the VMs of agent pool agentpool1",
 		},
 	}
 
@@ -2197,85 +4848,161 @@ func TestProperties_ValidateLoadBalancer(t *testing.T) {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			cs := getK8sDefaultContainerService(true)
-			cs.Properties.MasterProfile = test.masterProfile
-			cs.Properties.AgentPoolProfiles = test.agentProfiles
-			cs.Properties.OrchestratorProfile.OrchestratorRelease = test.orchestratorRelease
-			cs.Properties.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
-				LoadBalancerSku: test.loadBalancerSku,
+			p := &Properties{
+				OrchestratorProfile: &OrchestratorProfile{OrchestratorType: test.orchestratorType},
+				MasterProfile:       &MasterProfile{VMExtensions: test.masterExtensions},
+				AgentPoolProfiles: []*AgentPoolProfile{
+					{Name: "agentpool1", OSType: test.osType, VMExtensions: test.agentExtensions},
+				},
 			}
-
-			err := cs.Validate(false)
-			if test.expectedErr {
-				if err == nil {
-					t.Errorf("error should have occurred")
-				} else {
-					if err.Error() != test.expectedErrStr {
-						t.Errorf("expected error with message : %s, but got : %s", test.expectedErrStr, err.Error())
-					}
+			err := p.validateVMExtensions()
+			if test.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
 				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedMsg {
+				t.Errorf("expected error message : %s, but got %v", test.expectedMsg, err)
 			}
 		})
 	}
 }
 
-func TestProperties_ValidateSinglePlacementGroup(t *testing.T) {
-
+func TestProperties_ValidateNetworkCIDROverlaps(t *testing.T) {
 	tests := []struct {
-		name              string
-		masterProfile     *MasterProfile
-		agentPoolProfiles []*AgentPoolProfile
-		expectedMsg       string
+		name             string
+		kubernetesConfig *KubernetesConfig
+		vnetCidr         string
+		masterSubnet     string
+		agentSubnets     map[string]string
+		poolKubelet      map[string]string
+		expectedMsg      string
 	}{
 		{
-			name: "Master profile VMAS with SinglePlacementGroup",
-			masterProfile: &MasterProfile{
-				Count:                1,
-				DNSPrefix:            "foo",
-				VMSize:               "Standard_DS2_v2",
-				AvailabilityProfile:  AvailabilitySet,
-				SinglePlacementGroup: to.BoolPtr(false),
-			},
-			expectedMsg: "singlePlacementGroup is only supported with VirtualMachineScaleSets",
+			name: "no overlaps",
+			kubernetesConfig: &KubernetesConfig{
+				NetworkPlugin:      "kubenet",
+				ClusterSubnet:      "10.244.0.0/16",
+				ServiceCidr:        "10.0.0.0/16",
+				DNSServiceIP:       "10.0.0.10",
+				DockerBridgeSubnet: "172.17.0.1/16",
+			},
+			masterSubnet: "10.239.0.0/16",
+			agentSubnets: map[string]string{"agentpool1": "10.239.0.0/16"},
+			expectedMsg:  "",
 		},
 		{
-			name: "Agent profile VMAS with SinglePlacementGroup",
-			masterProfile: &MasterProfile{
-				Count:               1,
-				DNSPrefix:           "foo",
-				VMSize:              "Standard_DS2_v2",
-				AvailabilityProfile: VirtualMachineScaleSets,
+			name: "default vnet cidr contains the service cidr",
+			kubernetesConfig: &KubernetesConfig{
+				NetworkPlugin: "kubenet",
+				ClusterSubnet: "10.244.0.0/16",
+				ServiceCidr:   "10.0.0.0/16",
+				DNSServiceIP:  "10.0.0.10",
+			},
+			vnetCidr:     "10.0.0.0/8",
+			masterSubnet: "10.240.0.0/16",
+			expectedMsg:  "",
+		},
+		{
+			name: "service cidr overlaps cluster subnet",
+			kubernetesConfig: &KubernetesConfig{
+				NetworkPlugin: "kubenet",
+				ClusterSubnet: "10.0.0.0/8",
+				ServiceCidr:   "10.0.0.0/16",
+				DNSServiceIP:  "10.0.0.10",
+			},
+			expectedMsg: "networking CIDR ranges must not overlap: OrchestratorProfile.KubernetesConfig.ServiceCidr '10.0.0.0/16' overlaps with OrchestratorProfile.KubernetesConfig.ClusterSubnet '10.0.0.0/8'",
+		},
+		{
+			name: "docker bridge overlaps cluster subnet and master subnet",
+			kubernetesConfig: &KubernetesConfig{
+				NetworkPlugin:      "kubenet",
+				ClusterSubnet:      "172.16.0.0/12",
+				DockerBridgeSubnet: "172.17.0.1/16",
+			},
+			masterSubnet: "172.17.0.0/24",
+			expectedMsg:  "networking CIDR ranges must not overlap: OrchestratorProfile.KubernetesConfig.ClusterSubnet '172.16.0.0/12' overlaps with OrchestratorProfile.KubernetesConfig.DockerBridgeSubnet '172.17.0.1/16'; OrchestratorProfile.KubernetesConfig.DockerBridgeSubnet '172.17.0.1/16' overlaps with MasterProfile.Subnet '172.17.0.0/24'; OrchestratorProfile.KubernetesConfig.ClusterSubnet '172.16.0.0/12' overlaps with MasterProfile.Subnet '172.17.0.0/24'",
+		},
+		{
+			name: "service cidr overlaps agent pool subnet",
+			kubernetesConfig: &KubernetesConfig{
+				NetworkPlugin: "azure",
+				ServiceCidr:   "10.0.0.0/16",
+				DNSServiceIP:  "10.0.0.10",
+			},
+			masterSubnet: "10.240.0.0/16",
+			agentSubnets: map[string]string{"agentpool1": "10.0.128.0/24"},
+			expectedMsg:  "networking CIDR ranges must not overlap: OrchestratorProfile.KubernetesConfig.ServiceCidr '10.0.0.0/16' overlaps with AgentPoolProfiles[agentpool1].Subnet '10.0.128.0/24'",
+		},
+		{
+			name: "azure cni cluster subnet is the master subnet",
+			kubernetesConfig: &KubernetesConfig{
+				NetworkPlugin: "azure",
+				ClusterSubnet: "10.240.0.0/12",
+			},
+			vnetCidr:     "10.0.0.0/8",
+			masterSubnet: "10.240.0.0/12",
+			expectedMsg:  "",
+		},
+		{
+			name: "calico network policy without a network plugin runs azure cni",
+			kubernetesConfig: &KubernetesConfig{
+				NetworkPolicy: "calico",
+				ClusterSubnet: "10.240.0.0/12",
 			},
-			agentPoolProfiles: []*AgentPoolProfile{
-				{
-					Name:                 "agentpool",
-					VMSize:               "Standard_DS2_v2",
-					Count:                4,
-					AvailabilityProfile:  AvailabilitySet,
-					SinglePlacementGroup: to.BoolPtr(false),
-				},
+			masterSubnet: "10.240.0.0/16",
+			expectedMsg:  "",
+		},
+		{
+			name: "cilium network policy without a network plugin cluster subnet overlaps master subnet",
+			kubernetesConfig: &KubernetesConfig{
+				NetworkPolicy: "cilium",
+				ClusterSubnet: "10.240.0.0/12",
 			},
-			expectedMsg: `VirtualMachineScaleSets for master profile must be used together with virtualMachineScaleSets for agent profiles. Set "availabilityProfile" to "VirtualMachineScaleSets" for agent profiles`,
+			masterSubnet: "10.240.0.0/16",
+			expectedMsg:  "networking CIDR ranges must not overlap: OrchestratorProfile.KubernetesConfig.ClusterSubnet '10.240.0.0/12' overlaps with MasterProfile.Subnet '10.240.0.0/16'",
 		},
 		{
-			name: "VMSS with SinglePlacementGroup false and StorageAccount storage",
-			masterProfile: &MasterProfile{
-				Count:                1,
-				DNSPrefix:            "foo",
-				VMSize:               "Standard_DS2_v2",
-				AvailabilityProfile:  VirtualMachineScaleSets,
-				SinglePlacementGroup: to.BoolPtr(false),
-				StorageProfile:       StorageAccount,
+			name: "none network policy cluster subnet overlaps agent pool subnet",
+			kubernetesConfig: &KubernetesConfig{
+				NetworkPolicy: "none",
+				ClusterSubnet: "10.244.0.0/16",
 			},
-			agentPoolProfiles: []*AgentPoolProfile{
-				{
-					Name:                "agentpool",
-					VMSize:              "Standard_DS2_v2",
-					Count:               4,
-					AvailabilityProfile: VirtualMachineScaleSets,
-				},
+			agentSubnets: map[string]string{"agentpool1": "10.244.1.0/24"},
+			expectedMsg:  "networking CIDR ranges must not overlap: OrchestratorProfile.KubernetesConfig.ClusterSubnet '10.244.0.0/16' overlaps with AgentPoolProfiles[agentpool1].Subnet '10.244.1.0/24'",
+		},
+		{
+			name: "node-local dns ip",
+			kubernetesConfig: &KubernetesConfig{
+				NetworkPlugin: "kubenet",
+				ClusterSubnet: "10.244.0.0/16",
+				ServiceCidr:   "10.0.0.0/16",
+				DNSServiceIP:  "10.0.0.10",
+				KubeletConfig: map[string]string{"--cluster-dns": "169.254.20.10"},
+			},
+			masterSubnet: "10.240.0.0/16",
+			expectedMsg:  "",
+		},
+		{
+			name: "node-local dns ip in the cluster subnet",
+			kubernetesConfig: &KubernetesConfig{
+				NetworkPlugin: "kubenet",
+				ClusterSubnet: "10.244.0.0/16",
+				ServiceCidr:   "10.0.0.0/16",
+				DNSServiceIP:  "10.0.0.10",
+			},
+			agentSubnets: map[string]string{"agentpool1": "10.240.0.0/16"},
+			poolKubelet:  map[string]string{"--cluster-dns": "10.244.0.10"},
+			expectedMsg:  "networking CIDR ranges must not overlap: AgentPoolProfiles[agentpool1].KubernetesConfig.KubeletConfig['--cluster-dns'] '10.244.0.10/32' overlaps with OrchestratorProfile.KubernetesConfig.ClusterSubnet '10.244.0.0/16'",
+		},
+		{
+			name: "invalid node-local dns ip",
+			kubernetesConfig: &KubernetesConfig{
+				DNSServiceIP:  "10.0.0.10",
+				KubeletConfig: map[string]string{"--cluster-dns": "dns.contoso.com"},
 			},
-			expectedMsg: "VirtualMachineScaleSets does not support StorageAccount disks.  Please specify \"storageProfile\": \"ManagedDisks\" (recommended) or \"availabilityProfile\": \"AvailabilitySet\"",
+			expectedMsg: "OrchestratorProfile.KubernetesConfig.KubeletConfig['--cluster-dns'] 'dns.contoso.com' is not a valid IP address",
 		},
 	}
 
@@ -2283,22 +5010,37 @@ func TestProperties_ValidateSinglePlacementGroup(t *testing.T) {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			cs := getK8sDefaultContainerService(true)
-			cs.Properties.OrchestratorProfile.OrchestratorRelease = "1.12"
-			cs.Properties.MasterProfile = test.masterProfile
-			cs.Properties.AgentPoolProfiles = test.agentPoolProfiles
-			err := cs.Validate(true)
-			if err.Error() != test.expectedMsg {
-				t.Errorf("expected error message : %s, but got %s", test.expectedMsg, err.Error())
+			p := &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: test.kubernetesConfig,
+				},
+				MasterProfile: &MasterProfile{
+					VnetCidr: test.vnetCidr,
+				},
+			}
+			p.MasterProfile.SetSubnet(test.masterSubnet)
+			for name, subnet := range test.agentSubnets {
+				pool := &AgentPoolProfile{Name: name}
+				pool.SetSubnet(subnet)
+				if test.poolKubelet != nil {
+					pool.KubernetesConfig = &KubernetesConfig{KubeletConfig: test.poolKubelet}
+				}
+				p.AgentPoolProfiles = append(p.AgentPoolProfiles, pool)
+			}
+			err := p.validateNetworkCIDROverlaps()
+			if test.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("expected no error, but got %s", err.Error())
+				}
+			} else if err == nil || err.Error() != test.expectedMsg {
+				t.Errorf("expected error message : %s, but got %v", test.expectedMsg, err)
 			}
 		})
 	}
 }
 
-func TestProperties_ValidateVNET(t *testing.T) {
-	validVNetSubnetID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME"
-	validVNetSubnetID2 := "/subscriptions/SUB_ID2/resourceGroups/RG_NAME2/providers/Microsoft.Network/virtualNetworks/VNET_NAME2/subnets/SUBNET_NAME"
-
+func TestProperties_ValidateNodeDNS(t *testing.T) {
 	tests := []struct {
 		name              string
 		masterProfile     *MasterProfile
@@ -2306,150 +5048,103 @@ func TestProperties_ValidateVNET(t *testing.T) {
 		expectedMsg       string
 	}{
 		{
-			name: "Multiple VNET Subnet configs",
+			name: "valid vnet and pool dns configuration",
 			masterProfile: &MasterProfile{
-				VnetSubnetID: "testvnetstring",
-				Count:        1,
-				DNSPrefix:    "foo",
-				VMSize:       "Standard_DS2_v2",
+				VnetDNSServers: []string{"10.239.0.4", "10.239.0.5"},
+				DNSConfig: &NodeDNSConfig{
+					SearchDomains: []string{"corp.contoso.com", "contoso.com"},
+				},
 			},
 			agentPoolProfiles: []*AgentPoolProfile{
 				{
-					Name:                "agentpool",
-					VMSize:              "Standard_D2_v2",
-					Count:               1,
-					AvailabilityProfile: AvailabilitySet,
-					VnetSubnetID:        "",
+					Name: "linuxpool",
+					DNSConfig: &NodeDNSConfig{
+						DNSServers:    []string{"10.239.0.6"},
+						SearchDomains: []string{"contoso.com"},
+					},
+				},
+				{
+					Name:   "windowspool",
+					OSType: Windows,
+					DNSConfig: &NodeDNSConfig{
+						DNSServers: []string{"10.239.0.6"},
+					},
 				},
 			},
-			expectedMsg: "Multiple VNET Subnet configurations specified.  The master profile and each agent pool profile must all specify a custom VNET Subnet, or none at all",
+			expectedMsg: "",
 		},
 		{
-			name: "Invalid vnet subnet ID",
+			name: "vnet dns servers with custom vnet",
 			masterProfile: &MasterProfile{
-				VnetSubnetID: "testvnetstring",
-				Count:        1,
-				DNSPrefix:    "foo",
-				VMSize:       "Standard_DS2_v2",
-			},
-			agentPoolProfiles: []*AgentPoolProfile{
-				{
-					Name:                "agentpool",
-					VMSize:              "Standard_D2_v2",
-					Count:               1,
-					AvailabilityProfile: AvailabilitySet,
-					VnetSubnetID:        "testvnetstring",
-				},
+				VnetSubnetID:   "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/master",
+				VnetDNSServers: []string{"10.239.0.4"},
 			},
-			expectedMsg: "Unable to parse vnetSubnetID. Please use a vnetSubnetID with format /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME",
+			expectedMsg: "masterProfile.vnetDnsServers is not supported with a custom VNET, configure the DNS servers on the VNET instead",
 		},
 		{
-			name: "Multiple VNETs",
+			name: "invalid vnet dns server",
 			masterProfile: &MasterProfile{
-				VnetSubnetID: validVNetSubnetID,
-				Count:        1,
-				DNSPrefix:    "foo",
-				VMSize:       "Standard_DS2_v2",
-			},
-			agentPoolProfiles: []*AgentPoolProfile{
-				{
-					Name:                "agentpool",
-					VMSize:              "Standard_D2_v2",
-					Count:               1,
-					AvailabilityProfile: AvailabilitySet,
-					VnetSubnetID:        validVNetSubnetID,
-				},
-				{
-					Name:                "agentpool2",
-					VMSize:              "Standard_D2_v2",
-					Count:               1,
-					AvailabilityProfile: AvailabilitySet,
-					VnetSubnetID:        validVNetSubnetID2,
-				},
+				VnetDNSServers: []string{"dns.contoso.com"},
 			},
-			expectedMsg: "Multiple VNETS specified.  The master profile and each agent pool must reference the same VNET (but it is ok to reference different subnets on that VNET)",
+			expectedMsg: "masterProfile.vnetDnsServers entry 'dns.contoso.com' is not a valid IP address",
 		},
 		{
-			name: "Invalid MasterProfile FirstConsecutiveStaticIP when master is VMAS",
+			name: "dns server is the cluster dns service ip",
 			masterProfile: &MasterProfile{
-				VnetSubnetID:             validVNetSubnetID,
-				Count:                    1,
-				DNSPrefix:                "foo",
-				VMSize:                   "Standard_DS2_v2",
-				FirstConsecutiveStaticIP: "10.0.0.invalid",
-			},
-			agentPoolProfiles: []*AgentPoolProfile{
-				{
-					Name:                "agentpool",
-					VMSize:              "Standard_D2_v2",
-					Count:               1,
-					AvailabilityProfile: AvailabilitySet,
-					VnetSubnetID:        validVNetSubnetID,
+				DNSConfig: &NodeDNSConfig{
+					DNSServers: []string{"10.0.0.10"},
 				},
 			},
-			expectedMsg: "MasterProfile.FirstConsecutiveStaticIP (with VNET Subnet specification) '10.0.0.invalid' is an invalid IP address",
+			expectedMsg: "masterProfile.dnsConfig.dnsServers entry '10.0.0.10' must not be the cluster DNS service IP, nodes cannot resolve names through a cluster service",
 		},
 		{
-			name: "Empty MasterProfile FirstConsecutiveStaticIP and empty agentVnetSubnetID when master is VMSS",
-			masterProfile: &MasterProfile{
-				VnetSubnetID:        validVNetSubnetID,
-				Count:               1,
-				DNSPrefix:           "foo",
-				VMSize:              "Standard_DS2_v2",
-				AvailabilityProfile: VirtualMachineScaleSets,
-			},
-			agentPoolProfiles: []*AgentPoolProfile{
-				{
-					Name:                "agentpool",
-					VMSize:              "Standard_D2_v2",
-					Count:               1,
-					AvailabilityProfile: VirtualMachineScaleSets,
-					VnetSubnetID:        validVNetSubnetID,
+			name:          "dns server within the service cidr",
+			masterProfile: &MasterProfile{},
+			agentPoolProfiles: []*AgentPoolProfile{
+				{
+					Name: "linuxpool",
+					DNSConfig: &NodeDNSConfig{
+						DNSServers: []string{"10.0.1.4"},
+					},
 				},
 			},
-			expectedMsg: "when master profile is using VirtualMachineScaleSets and is custom vnet, set \"vnetsubnetid\" and \"agentVnetSubnetID\" for master profile",
+			expectedMsg: "agentPoolProfiles[linuxpool].dnsConfig.dnsServers entry '10.0.1.4' must not be within the service CIDR 10.0.0.0/16",
 		},
 		{
-			name: "User-provided MasterProfile FirstConsecutiveStaticIP when master is VMSS",
-			masterProfile: &MasterProfile{
-				VnetSubnetID:             validVNetSubnetID,
-				Count:                    1,
-				DNSPrefix:                "foo",
-				VMSize:                   "Standard_DS2_v2",
-				AvailabilityProfile:      VirtualMachineScaleSets,
-				FirstConsecutiveStaticIP: "10.0.0.4",
-			},
+			name:          "invalid search domain",
+			masterProfile: &MasterProfile{},
 			agentPoolProfiles: []*AgentPoolProfile{
 				{
-					Name:                "agentpool",
-					VMSize:              "Standard_D2_v2",
-					Count:               1,
-					AvailabilityProfile: VirtualMachineScaleSets,
-					VnetSubnetID:        validVNetSubnetID,
+					Name: "linuxpool",
+					DNSConfig: &NodeDNSConfig{
+						SearchDomains: []string{"-contoso.com"},
+					},
 				},
 			},
-			expectedMsg: "when masterProfile's availabilityProfile is VirtualMachineScaleSets and a vnetSubnetID is specified, the firstConsecutiveStaticIP should be empty and will be determined by an offset from the first IP in the vnetCidr",
+			expectedMsg: "agentPoolProfiles[linuxpool].dnsConfig.searchDomains entry '-contoso.com' is not a valid DNS domain name",
 		},
 		{
-			name: "Invalid vnetcidr",
+			name: "too many search domains",
 			masterProfile: &MasterProfile{
-				VnetSubnetID:             validVNetSubnetID,
-				Count:                    1,
-				DNSPrefix:                "foo",
-				VMSize:                   "Standard_DS2_v2",
-				FirstConsecutiveStaticIP: "10.0.0.1",
-				VnetCidr:                 "10.1.0.0/invalid",
+				DNSConfig: &NodeDNSConfig{
+					SearchDomains: []string{"a.com", "b.com", "c.com", "d.com", "e.com", "f.com", "g.com"},
+				},
 			},
+			expectedMsg: "masterProfile.dnsConfig.searchDomains has 7 entries, the maximum is 6",
+		},
+		{
+			name:          "search domains in a windows pool",
+			masterProfile: &MasterProfile{},
 			agentPoolProfiles: []*AgentPoolProfile{
 				{
-					Name:                "agentpool",
-					VMSize:              "Standard_D2_v2",
-					Count:               1,
-					AvailabilityProfile: AvailabilitySet,
-					VnetSubnetID:        validVNetSubnetID,
+					Name:   "windowspool",
+					OSType: Windows,
+					DNSConfig: &NodeDNSConfig{
+						SearchDomains: []string{"contoso.com"},
+					},
 				},
 			},
-			expectedMsg: "MasterProfile.VnetCidr '10.1.0.0/invalid' contains invalid cidr notation",
+			expectedMsg: "dnsConfig.searchDomains is not supported in Windows agent pool windowspool",
 		},
 	}
 
@@ -2457,13 +5152,24 @@ func TestProperties_ValidateVNET(t *testing.T) {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			cs := getK8sDefaultContainerService(true)
-			cs.Properties.OrchestratorProfile.OrchestratorRelease = "1.10"
-			cs.Properties.MasterProfile = test.masterProfile
-			cs.Properties.AgentPoolProfiles = test.agentPoolProfiles
-			err := cs.Validate(true)
-			if err.Error() != test.expectedMsg {
-				t.Errorf("expected error message : %s, but got %s", test.expectedMsg, err.Error())
+			p := &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: &KubernetesConfig{
+						ServiceCidr:  "10.0.0.0/16",
+						DNSServiceIP: "10.0.0.10",
+					},
+				},
+				MasterProfile:     test.masterProfile,
+				AgentPoolProfiles: test.agentPoolProfiles,
+			}
+			err := p.validateNodeDNS()
+			if test.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("expected no error, but got %s", err.Error())
+				}
+			} else if err == nil || err.Error() != test.expectedMsg {
+				t.Errorf("expected error message : %s, but got %v", test.expectedMsg, err)
 			}
 		})
 	}
@@ -2751,119 +5457,749 @@ func TestAgentPoolProfile_ValidateAvailabilityProfile(t *testing.T) {
 	})
 }
 
-func TestAgentPoolProfile_ValidateVirtualMachineScaleSet(t *testing.T) {
-	t.Run("Should fail for invalid VMSS + Overprovisioning config", func(t *testing.T) {
-		t.Parallel()
-		cs := getK8sDefaultContainerService(false)
-		agentPoolProfiles := cs.Properties.AgentPoolProfiles
-		agentPoolProfiles[0].AvailabilityProfile = AvailabilitySet
-		agentPoolProfiles[0].VMSSOverProvisioningEnabled = to.BoolPtr(true)
-		expectedMsg := fmt.Sprintf("You have specified VMSS Overprovisioning in agent pool %s, but you did not specify VMSS", agentPoolProfiles[0].Name)
-		if err := cs.Properties.validateAgentPoolProfiles(false); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+func TestAgentPoolProfile_ValidateVirtualMachineScaleSet(t *testing.T) {
+	t.Run("Should fail for invalid VMSS + Overprovisioning config", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		agentPoolProfiles := cs.Properties.AgentPoolProfiles
+		agentPoolProfiles[0].AvailabilityProfile = AvailabilitySet
+		agentPoolProfiles[0].VMSSOverProvisioningEnabled = to.BoolPtr(true)
+		expectedMsg := fmt.Sprintf("You have specified VMSS Overprovisioning in agent pool %s, but you did not specify VMSS", agentPoolProfiles[0].Name)
+		if err := cs.Properties.validateAgentPoolProfiles(false); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should fail for invalid VMSS + Enable VMSS node public IP config", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		agentPoolProfiles := cs.Properties.AgentPoolProfiles
+		agentPoolProfiles[0].AvailabilityProfile = AvailabilitySet
+		agentPoolProfiles[0].EnableVMSSNodePublicIP = to.BoolPtr(true)
+		expectedMsg := fmt.Sprintf("You have enabled VMSS node public IP in agent pool %s, but you did not specify VMSS", agentPoolProfiles[0].Name)
+		if err := cs.Properties.validateAgentPoolProfiles(false); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should fail for invalid VMSS + VnetSubnetID + FirstConsecutiveStaticIP config", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		cs.Properties.MasterProfile.AvailabilityProfile = VirtualMachineScaleSets
+		cs.Properties.MasterProfile.VnetSubnetID = "vnet"
+		cs.Properties.MasterProfile.FirstConsecutiveStaticIP = "10.10.10.240"
+		expectedMsg := fmt.Sprintf("when masterProfile's availabilityProfile is VirtualMachineScaleSets and a vnetSubnetID is specified, the firstConsecutiveStaticIP should be empty and will be determined by an offset from the first IP in the vnetCidr")
+		if err := cs.Properties.validateMasterProfile(false); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should fail for VMSS master + AvailabilitySet agent pool", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		cs.Properties.MasterProfile.AvailabilityProfile = VirtualMachineScaleSets
+		agentPoolProfiles := cs.Properties.AgentPoolProfiles
+		agentPoolProfiles[0].AvailabilityProfile = AvailabilitySet
+		expectedMsg := fmt.Sprintf("VirtualMachineScaleSets for master profile must be used together with virtualMachineScaleSets for agent profiles. Set \"availabilityProfile\" to \"VirtualMachineScaleSets\" for agent profiles")
+		if err := cs.Properties.validateMasterProfile(false); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should fail for VMSS + StorageAccount", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		agentPoolProfiles := cs.Properties.AgentPoolProfiles
+		agentPoolProfiles[0].AvailabilityProfile = VirtualMachineScaleSets
+		agentPoolProfiles[0].StorageProfile = StorageAccount
+		expectedMsg := fmt.Sprintf("VirtualMachineScaleSets does not support %s disks.  Please specify \"storageProfile\": \"%s\" (recommended) or \"availabilityProfile\": \"%s\"", StorageAccount, ManagedDisks, AvailabilitySet)
+		if err := cs.Properties.validateAgentPoolProfiles(false); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should fail for VMSS + VMAS", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &AgentPoolProfile{
+			Name:                "agentpool2",
+			VMSize:              "Standard_D2_v2",
+			Count:               1,
+			AvailabilityProfile: AvailabilitySet,
+		})
+		agentPoolProfiles := cs.Properties.AgentPoolProfiles
+		agentPoolProfiles[0].AvailabilityProfile = VirtualMachineScaleSets
+		agentPoolProfiles[1].AvailabilityProfile = AvailabilitySet
+		expectedMsg := fmt.Sprintf("mixed mode availability profiles are not allowed. Please set either VirtualMachineScaleSets or AvailabilitySet in availabilityProfile for all agent pools")
+		if err := cs.Properties.validateAgentPoolProfiles(false); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should fail for VMSS + invalid LoadBalancerBackendAddressPoolIDs", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		agentPoolProfiles := cs.Properties.AgentPoolProfiles
+		agentPoolProfiles[0].AvailabilityProfile = VirtualMachineScaleSets
+		agentPoolProfiles[0].LoadBalancerBackendAddressPoolIDs = []string{"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/loadBalancers/myVMSSSLB/backendAddressPools/myVMSSSLBBEPool", ""}
+		expectedMsg := fmt.Sprintf("AgentPoolProfile.LoadBalancerBackendAddressPoolIDs can not contain empty string. Agent pool name: %s", agentPoolProfiles[0].Name)
+		if err := cs.Properties.validateAgentPoolProfiles(false); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+}
+
+func TestAgentPoolProfile_ValidateAuditDEnabled(t *testing.T) {
+	t.Run("Should have proper validation for auditd + distro combinations", func(t *testing.T) {
+		t.Parallel()
+		for _, distro := range DistroValues {
+			cs := getK8sDefaultContainerService(false)
+			agentPoolProfiles := cs.Properties.AgentPoolProfiles
+			agentPoolProfiles[0].Distro = distro
+			agentPoolProfiles[0].AuditDEnabled = to.BoolPtr(true)
+			switch distro {
+			case RHEL, CoreOS:
+				expectedMsg := fmt.Sprintf("You have enabled auditd in agent pool %s, but you did not specify an Ubuntu-based distro", agentPoolProfiles[0].Name)
+				if err := cs.Properties.validateAgentPoolProfiles(false); err.Error() != expectedMsg {
+					t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+				}
+			case Ubuntu, Ubuntu1804, AKSUbuntu1604, AKSUbuntu1804, ACC1604:
+				if err := cs.Properties.validateAgentPoolProfiles(false); err != nil {
+					t.Errorf("AuditDEnabled should work with distro %s, got error %s", distro, err.Error())
+				}
+			}
+		}
+	})
+}
+
+func TestAgentPoolProfile_ValidateScheduledEventsProfile(t *testing.T) {
+	tests := []struct {
+		name         string
+		profile      *ScheduledEventsProfile
+		osType       OSType
+		availability string
+		isAzureStack bool
+		expectedMsg  string
+	}{
+		{
+			name:         "handler enabled without a timeout",
+			profile:      &ScheduledEventsProfile{Enabled: to.BoolPtr(true)},
+			availability: AvailabilitySet,
+		},
+		{
+			name:         "handler enabled with a timeout",
+			profile:      &ScheduledEventsProfile{Enabled: to.BoolPtr(true), TerminateNotificationTimeoutMinutes: 15},
+			availability: VirtualMachineScaleSets,
+		},
+		{
+			name:         "handler enabled on Windows",
+			profile:      &ScheduledEventsProfile{Enabled: to.BoolPtr(true)},
+			osType:       Windows,
+			availability: VirtualMachineScaleSets,
+			expectedMsg:  "agent pool agentpool has the scheduled events handler enabled, which is not supported on Windows",
+		},
+		{
+			name:         "timeout without the handler",
+			profile:      &ScheduledEventsProfile{TerminateNotificationTimeoutMinutes: 5},
+			availability: VirtualMachineScaleSets,
+			expectedMsg:  "agent pool agentpool has a terminate notification timeout, but the scheduled events handler is not enabled",
+		},
+		{
+			name:         "timeout on an availability set",
+			profile:      &ScheduledEventsProfile{Enabled: to.BoolPtr(true), TerminateNotificationTimeoutMinutes: 5},
+			availability: AvailabilitySet,
+			expectedMsg:  "agent pool agentpool has a terminate notification timeout, which is only supported by VirtualMachineScaleSets",
+		},
+		{
+			name:         "timeout on Azure Stack",
+			profile:      &ScheduledEventsProfile{Enabled: to.BoolPtr(true), TerminateNotificationTimeoutMinutes: 5},
+			availability: VirtualMachineScaleSets,
+			isAzureStack: true,
+			expectedMsg:  "agent pool agentpool has a terminate notification timeout, which is not supported on Azure Stack",
+		},
+		{
+			name:         "timeout too short",
+			profile:      &ScheduledEventsProfile{Enabled: to.BoolPtr(true), TerminateNotificationTimeoutMinutes: 4},
+			availability: VirtualMachineScaleSets,
+			expectedMsg:  "the terminate notification timeout of agent pool agentpool is 4 minutes, it must be between 5 and 15 minutes",
+		},
+		{
+			name:         "timeout too long",
+			profile:      &ScheduledEventsProfile{Enabled: to.BoolPtr(true), TerminateNotificationTimeoutMinutes: 16},
+			availability: VirtualMachineScaleSets,
+			expectedMsg:  "the terminate notification timeout of agent pool agentpool is 16 minutes, it must be between 5 and 15 minutes",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			a := &AgentPoolProfile{
+				Name:                   "agentpool",
+				OSType:                 test.osType,
+				AvailabilityProfile:    test.availability,
+				ScheduledEventsProfile: test.profile,
+			}
+			err := a.validateScheduledEventsProfile(test.isAzureStack)
+			if test.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedMsg {
+				t.Errorf("expected error with message : %s, but got %v", test.expectedMsg, err)
+			}
+		})
+	}
+}
+
+func TestAgentPoolProfile_ValidateSpotScaleSet(t *testing.T) {
+	tests := []struct {
+		name         string
+		priority     string
+		maxPrice     *float64
+		availability string
+		isAzureStack bool
+		expectedMsg  string
+	}{
+		{
+			name:         "spot without a max price",
+			priority:     ScaleSetPrioritySpot,
+			availability: VirtualMachineScaleSets,
+		},
+		{
+			name:         "spot capped at the pay-as-you-go price",
+			priority:     ScaleSetPrioritySpot,
+			maxPrice:     to.Float64Ptr(-1),
+			availability: VirtualMachineScaleSets,
+		},
+		{
+			name:     "spot with a max price and the default availability profile",
+			priority: ScaleSetPrioritySpot,
+			maxPrice: to.Float64Ptr(0.05),
+		},
+		{
+			name:         "max price without spot",
+			priority:     ScaleSetPriorityLow,
+			maxPrice:     to.Float64Ptr(0.05),
+			availability: VirtualMachineScaleSets,
+			expectedMsg:  "agent pool agentpool has a spot max price, but its scale set priority is not Spot",
+		},
+		{
+			name:         "spot on an availability set",
+			priority:     ScaleSetPrioritySpot,
+			availability: AvailabilitySet,
+			expectedMsg:  "agent pool agentpool has the Spot scale set priority, which is only supported by VirtualMachineScaleSets",
+		},
+		{
+			name:         "spot on Azure Stack",
+			priority:     ScaleSetPrioritySpot,
+			availability: VirtualMachineScaleSets,
+			isAzureStack: true,
+			expectedMsg:  "agent pool agentpool has the Spot scale set priority, which is not supported on Azure Stack",
+		},
+		{
+			name:         "zero max price",
+			priority:     ScaleSetPrioritySpot,
+			maxPrice:     to.Float64Ptr(0),
+			availability: VirtualMachineScaleSets,
+			expectedMsg:  "the spot max price of agent pool agentpool is 0, it must be -1 or greater than 0",
+		},
+		{
+			name:         "negative max price",
+			priority:     ScaleSetPrioritySpot,
+			maxPrice:     to.Float64Ptr(-0.5),
+			availability: VirtualMachineScaleSets,
+			expectedMsg:  "the spot max price of agent pool agentpool is -0.5, it must be -1 or greater than 0",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			a := &AgentPoolProfile{
+				Name:                "agentpool",
+				AvailabilityProfile: test.availability,
+				ScaleSetPriority:    test.priority,
+				SpotMaxPrice:        test.maxPrice,
+			}
+			err := a.validateSpotScaleSet(test.isAzureStack)
+			if test.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedMsg {
+				t.Errorf("expected error with message : %s, but got %v", test.expectedMsg, err)
+			}
+		})
+	}
+}
+
+func TestAgentPoolProfile_ValidateHostGroup(t *testing.T) {
+	const hostGroupID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/hostGroups/HG_NAME"
+	tests := []struct {
+		name         string
+		hostGroupID  string
+		vmSize       string
+		availability string
+		priority     string
+		zones        []string
+		faultDomains *int
+		isAzureStack bool
+		expectedMsg  string
+	}{
+		{
+			name:         "no host group",
+			vmSize:       "Standard_D2_v2",
+			availability: AvailabilitySet,
+			faultDomains: to.IntPtr(3),
+		},
+		{
+			name:         "host group",
+			hostGroupID:  hostGroupID,
+			vmSize:       "Standard_D4s_v3",
+			availability: VirtualMachineScaleSets,
+			zones:        []string{"1"},
+			faultDomains: to.IntPtr(2),
+		},
+		{
+			name:        "host group with the default availability profile",
+			hostGroupID: hostGroupID,
+			vmSize:      "Standard_E16-4ds_v4",
+		},
+		{
+			name:         "too many fault domains",
+			faultDomains: to.IntPtr(5),
+			expectedMsg:  "the platformFaultDomainCount of agent pool agentpool is 5, it must be between 1 and 3",
+		},
+		{
+			name:        "malformed host group ID",
+			hostGroupID: "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/hostGroups/HG_NAME/hosts/HOST_NAME",
+			vmSize:      "Standard_D4s_v3",
+			expectedMsg: "the hostGroupID of agent pool agentpool is /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/hostGroups/HG_NAME/hosts/HOST_NAME, expected an ID of the form /subscriptions/<subscription>/resourceGroups/<group>/providers/Microsoft.Compute/hostGroups/<name>",
+		},
+		{
+			name:         "Azure Stack",
+			hostGroupID:  hostGroupID,
+			vmSize:       "Standard_D4s_v3",
+			isAzureStack: true,
+			expectedMsg:  "agent pool agentpool has a hostGroupID, dedicated hosts are not supported on Azure Stack",
+		},
+		{
+			name:         "availability set",
+			hostGroupID:  hostGroupID,
+			vmSize:       "Standard_D4s_v3",
+			availability: AvailabilitySet,
+			expectedMsg:  "agent pool agentpool has a hostGroupID, which is only supported by VirtualMachineScaleSets",
+		},
+		{
+			name:        "spot",
+			hostGroupID: hostGroupID,
+			vmSize:      "Standard_D4s_v3",
+			priority:    ScaleSetPrioritySpot,
+			expectedMsg: "agent pool agentpool has a hostGroupID and the Spot scale set priority, the VMs on dedicated hosts cannot be evicted",
+		},
+		{
+			name:        "several zones",
+			hostGroupID: hostGroupID,
+			vmSize:      "Standard_D4s_v3",
+			zones:       []string{"1", "2"},
+			expectedMsg: "agent pool agentpool has a hostGroupID and 2 availability zones, a host group is in at most one zone",
+		},
+		{
+			name:        "VM size without dedicated hosts",
+			hostGroupID: hostGroupID,
+			vmSize:      "Standard_D2_v2",
+			expectedMsg: "agent pool agentpool has a hostGroupID, but its VM size Standard_D2_v2 is not offered by the dedicated host SKUs",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			a := &AgentPoolProfile{
+				Name:                     "agentpool",
+				VMSize:                   test.vmSize,
+				AvailabilityProfile:      test.availability,
+				ScaleSetPriority:         test.priority,
+				AvailabilityZones:        test.zones,
+				HostGroupID:              test.hostGroupID,
+				PlatformFaultDomainCount: test.faultDomains,
+			}
+			err := a.validateHostGroup(test.isAzureStack)
+			if test.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedMsg {
+				t.Errorf("expected error with message : %s, but got %v", test.expectedMsg, err)
+			}
+		})
+	}
+}
+
+func TestValidateProximityPlacementGroup(t *testing.T) {
+	const ppgID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/proximityPlacementGroups/PPG_NAME"
+	tests := []struct {
+		name         string
+		id           string
+		create       *bool
+		zones        []string
+		isAzureStack bool
+		expectedMsg  string
+	}{
+		{
+			name: "no proximity placement group",
+		},
+		{
+			name: "existing proximity placement group",
+			id:   ppgID,
+		},
+		{
+			name:   "created proximity placement group in a single zone",
+			create: to.BoolPtr(true),
+			zones:  []string{"1"},
+		},
+		{
+			name:   "create disabled",
+			create: to.BoolPtr(false),
+			zones:  []string{"1", "2"},
+		},
+		{
+			name:        "both an ID and create",
+			id:          ppgID,
+			create:      to.BoolPtr(true),
+			expectedMsg: "agent pool agentpool has both a proximityPlacementGroupID and createProximityPlacementGroup, only one of them may be set",
+		},
+		{
+			name:         "Azure Stack",
+			create:       to.BoolPtr(true),
+			isAzureStack: true,
+			expectedMsg:  "agent pool agentpool has a proximity placement group, which is not supported on Azure Stack",
+		},
+		{
+			name:        "malformed ID",
+			id:          "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/availabilitySets/AS_NAME",
+			expectedMsg: "the proximityPlacementGroupID of agent pool agentpool is /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/availabilitySets/AS_NAME, expected an ID of the form /subscriptions/<subscription>/resourceGroups/<group>/providers/Microsoft.Compute/proximityPlacementGroups/<name>",
+		},
+		{
+			name:        "several zones",
+			id:          ppgID,
+			zones:       []string{"1", "2"},
+			expectedMsg: "agent pool agentpool has a proximity placement group and 2 availability zones, a proximity placement group requires at most one zone",
+		},
+	}
 
-	t.Run("Should fail for invalid VMSS + Enable VMSS node public IP config", func(t *testing.T) {
-		t.Parallel()
-		cs := getK8sDefaultContainerService(false)
-		agentPoolProfiles := cs.Properties.AgentPoolProfiles
-		agentPoolProfiles[0].AvailabilityProfile = AvailabilitySet
-		agentPoolProfiles[0].EnableVMSSNodePublicIP = to.BoolPtr(true)
-		expectedMsg := fmt.Sprintf("You have enabled VMSS node public IP in agent pool %s, but you did not specify VMSS", agentPoolProfiles[0].Name)
-		if err := cs.Properties.validateAgentPoolProfiles(false); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateProximityPlacementGroup("agent pool agentpool", test.id, test.create, test.zones, test.isAzureStack)
+			if test.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedMsg {
+				t.Errorf("expected error with message : %s, but got %v", test.expectedMsg, err)
+			}
+		})
+	}
+}
 
-	t.Run("Should fail for invalid VMSS + VnetSubnetID + FirstConsecutiveStaticIP config", func(t *testing.T) {
-		t.Parallel()
-		cs := getK8sDefaultContainerService(false)
-		cs.Properties.MasterProfile.AvailabilityProfile = VirtualMachineScaleSets
-		cs.Properties.MasterProfile.VnetSubnetID = "vnet"
-		cs.Properties.MasterProfile.FirstConsecutiveStaticIP = "10.10.10.240"
-		expectedMsg := fmt.Sprintf("when masterProfile's availabilityProfile is VirtualMachineScaleSets and a vnetSubnetID is specified, the firstConsecutiveStaticIP should be empty and will be determined by an offset from the first IP in the vnetCidr")
-		if err := cs.Properties.validateMasterProfile(false); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+func TestAgentPoolProfile_ValidateDataDisks(t *testing.T) {
+	tests := []struct {
+		name               string
+		count              int
+		diskSizesGB        []int
+		storageProfile     string
+		availability       string
+		storageAccountType string
+		iops               int
+		mbps               int
+		shared             *bool
+		zones              []string
+		isAzureStack       bool
+		expectedMsg        string
+	}{
+		{
+			name:        "no data disk options",
+			diskSizesGB: []int{128},
+		},
+		{
+			name:               "Premium SSD data disks",
+			diskSizesGB:        []int{128},
+			storageAccountType: PremiumSSDDataDisk,
+		},
+		{
+			name:               "Ultra SSD data disks",
+			diskSizesGB:        []int{128, 256},
+			storageAccountType: UltraSSDDataDisk,
+			iops:               38400,
+			mbps:               2000,
+			zones:              []string{"1", "2"},
+		},
+		{
+			name:               "shared data disks",
+			count:              5,
+			diskSizesGB:        []int{1023},
+			availability:       AvailabilitySet,
+			storageAccountType: PremiumSSDDataDisk,
+			shared:             to.BoolPtr(true),
+		},
+		{
+			name:               "no data disks",
+			storageAccountType: PremiumSSDDataDisk,
+			expectedMsg:        "agent pool agentpool has data disk options, but no diskSizesGB",
+		},
+		{
+			name:               "storage accounts",
+			diskSizesGB:        []int{128},
+			storageProfile:     StorageAccount,
+			storageAccountType: PremiumSSDDataDisk,
+			expectedMsg:        "agent pool agentpool has data disk options, which require storageProfile ManagedDisks",
+		},
+		{
+			name:               "IOPS of a Premium SSD",
+			diskSizesGB:        []int{128},
+			storageAccountType: PremiumSSDDataDisk,
+			iops:               1000,
+			expectedMsg:        "agent pool agentpool has a dataDiskIOPSReadWrite or dataDiskMBpsReadWrite, which are only supported by UltraSSD_LRS data disks",
+		},
+		{
+			name:               "Ultra SSD on Azure Stack",
+			diskSizesGB:        []int{128},
+			storageAccountType: UltraSSDDataDisk,
+			zones:              []string{"1"},
+			isAzureStack:       true,
+			expectedMsg:        "agent pool agentpool has UltraSSD_LRS data disks, which are not supported on Azure Stack",
+		},
+		{
+			name:               "Ultra SSD without availability zones",
+			diskSizesGB:        []int{128},
+			storageAccountType: UltraSSDDataDisk,
+			expectedMsg:        "agent pool agentpool has UltraSSD_LRS data disks, which are only offered in availability zones",
+		},
+		{
+			name:               "too few IOPS",
+			diskSizesGB:        []int{128},
+			storageAccountType: UltraSSDDataDisk,
+			iops:               50,
+			zones:              []string{"1"},
+			expectedMsg:        "the dataDiskIOPSReadWrite of agent pool agentpool is 50, it must be between 100 and 160000",
+		},
+		{
+			name:               "too many IOPS for the disk size",
+			diskSizesGB:        []int{256, 64},
+			storageAccountType: UltraSSDDataDisk,
+			iops:               20000,
+			zones:              []string{"1"},
+			expectedMsg:        "the dataDiskIOPSReadWrite of agent pool agentpool is 20000, more than the 300 IOPS per GiB of its data disk of 64 GiB",
+		},
+		{
+			name:               "too much throughput",
+			diskSizesGB:        []int{128},
+			storageAccountType: UltraSSDDataDisk,
+			mbps:               4000,
+			zones:              []string{"1"},
+			expectedMsg:        "the dataDiskMBpsReadWrite of agent pool agentpool is 4000, it must be between 1 and 2000",
+		},
+		{
+			name:               "shared data disks on Azure Stack",
+			diskSizesGB:        []int{256},
+			availability:       AvailabilitySet,
+			storageAccountType: PremiumSSDDataDisk,
+			shared:             to.BoolPtr(true),
+			isAzureStack:       true,
+			expectedMsg:        "agent pool agentpool has enableSharedDisk, shared disks are not supported on Azure Stack",
+		},
+		{
+			name:               "shared data disks of a scale set",
+			diskSizesGB:        []int{256},
+			availability:       VirtualMachineScaleSets,
+			storageAccountType: PremiumSSDDataDisk,
+			shared:             to.BoolPtr(true),
+			expectedMsg:        "agent pool agentpool has enableSharedDisk, which is only supported by AvailabilitySet",
+		},
+		{
+			name:         "shared data disks without a storage account type",
+			diskSizesGB:  []int{256},
+			availability: AvailabilitySet,
+			shared:       to.BoolPtr(true),
+			expectedMsg:  "agent pool agentpool has enableSharedDisk, which requires dataDiskStorageAccountType Premium_LRS",
+		},
+		{
+			name:               "small shared data disk",
+			diskSizesGB:        []int{128},
+			availability:       AvailabilitySet,
+			storageAccountType: PremiumSSDDataDisk,
+			shared:             to.BoolPtr(true),
+			expectedMsg:        "agent pool agentpool has a shared data disk of 128 GiB, shared data disks must be at least 256 GiB",
+		},
+		{
+			name:               "too many VMs for a shared data disk",
+			count:              3,
+			diskSizesGB:        []int{512},
+			availability:       AvailabilitySet,
+			storageAccountType: PremiumSSDDataDisk,
+			shared:             to.BoolPtr(true),
+			expectedMsg:        "agent pool agentpool has 3 VMs, but its shared data disk of 512 GiB can be attached to at most 2 VMs",
+		},
+	}
 
-	t.Run("Should fail for VMSS master + AvailabilitySet agent pool", func(t *testing.T) {
-		t.Parallel()
-		cs := getK8sDefaultContainerService(false)
-		cs.Properties.MasterProfile.AvailabilityProfile = VirtualMachineScaleSets
-		agentPoolProfiles := cs.Properties.AgentPoolProfiles
-		agentPoolProfiles[0].AvailabilityProfile = AvailabilitySet
-		expectedMsg := fmt.Sprintf("VirtualMachineScaleSets for master profile must be used together with virtualMachineScaleSets for agent profiles. Set \"availabilityProfile\" to \"VirtualMachineScaleSets\" for agent profiles")
-		if err := cs.Properties.validateMasterProfile(false); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			a := &AgentPoolProfile{
+				Name:                       "agentpool",
+				Count:                      test.count,
+				DiskSizesGB:                test.diskSizesGB,
+				StorageProfile:             test.storageProfile,
+				AvailabilityProfile:        test.availability,
+				AvailabilityZones:          test.zones,
+				DataDiskStorageAccountType: test.storageAccountType,
+				DataDiskIOPSReadWrite:      test.iops,
+				DataDiskMBpsReadWrite:      test.mbps,
+				EnableSharedDisk:           test.shared,
+			}
+			err := a.validateDataDisks(test.isAzureStack)
+			if test.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedMsg {
+				t.Errorf("expected error with message : %s, but got %v", test.expectedMsg, err)
+			}
+		})
+	}
+}
 
-	t.Run("Should fail for VMSS + StorageAccount", func(t *testing.T) {
-		t.Parallel()
-		cs := getK8sDefaultContainerService(false)
-		agentPoolProfiles := cs.Properties.AgentPoolProfiles
-		agentPoolProfiles[0].AvailabilityProfile = VirtualMachineScaleSets
-		agentPoolProfiles[0].StorageProfile = StorageAccount
-		expectedMsg := fmt.Sprintf("VirtualMachineScaleSets does not support %s disks.  Please specify \"storageProfile\": \"%s\" (recommended) or \"availabilityProfile\": \"%s\"", StorageAccount, ManagedDisks, AvailabilitySet)
-		if err := cs.Properties.validateAgentPoolProfiles(false); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+func TestAgentPoolProfile_ValidateKubeletConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		kubeletConfig map[string]string
+		expectedMsg   string
+	}{
+		{
+			name: "valid overrides, with the quoted empty eviction threshold of Windows",
+			kubeletConfig: map[string]string{
+				"--eviction-hard":                "memory.available<750Mi,nodefs.available<10%",
+				"--eviction-soft":                `""""`,
+				"--eviction-soft-grace-period":   "memory.available=1m30s",
+				"--kube-reserved":                "cpu=500m,memory=1Gi",
+				"--max-pods":                     "110",
+				"--node-status-update-frequency": "20s",
+			},
+		},
+		{
+			name:          "invalid eviction threshold",
+			kubeletConfig: map[string]string{"--eviction-hard": "memory.available=750Mi"},
+			expectedMsg:   "invalid kubeletConfig in agent pool agentpool: --eviction-hard 'memory.available=750Mi' must be a comma-separated list of name<value pairs",
+		},
+		{
+			name:          "invalid reserved resources",
+			kubeletConfig: map[string]string{"--system-reserved": "cpu=1,memory"},
+			expectedMsg:   "invalid kubeletConfig in agent pool agentpool: --system-reserved 'cpu=1,memory' must be a comma-separated list of name=value pairs",
+		},
+		{
+			name:          "max pods too low",
+			kubeletConfig: map[string]string{"--max-pods": "2"},
+			expectedMsg:   "invalid kubeletConfig in agent pool agentpool: --max-pods '2' must be an integer of at least 5",
+		},
+		{
+			name:          "invalid node status update frequency",
+			kubeletConfig: map[string]string{"--node-status-update-frequency": "often"},
+			expectedMsg:   "invalid kubeletConfig in agent pool agentpool: --node-status-update-frequency 'often' is not a valid duration",
+		},
+	}
 
-	t.Run("Should fail for VMSS + VMAS", func(t *testing.T) {
-		t.Parallel()
-		cs := getK8sDefaultContainerService(false)
-		cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &AgentPoolProfile{
-			Name:                "agentpool2",
-			VMSize:              "Standard_D2_v2",
-			Count:               1,
-			AvailabilityProfile: AvailabilitySet,
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			cs := getK8sDefaultContainerService(false)
+			cs.Properties.AgentPoolProfiles[0].Name = "agentpool"
+			cs.Properties.AgentPoolProfiles[0].KubernetesConfig = &KubernetesConfig{KubeletConfig: test.kubeletConfig}
+			err := cs.Properties.validateAgentPoolProfiles(false)
+			if test.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedMsg {
+				t.Errorf("expected error with message : %s, but got %v", test.expectedMsg, err)
+			}
 		})
-		agentPoolProfiles := cs.Properties.AgentPoolProfiles
-		agentPoolProfiles[0].AvailabilityProfile = VirtualMachineScaleSets
-		agentPoolProfiles[1].AvailabilityProfile = AvailabilitySet
-		expectedMsg := fmt.Sprintf("mixed mode availability profiles are not allowed. Please set either VirtualMachineScaleSets or AvailabilitySet in availabilityProfile for all agent pools")
-		if err := cs.Properties.validateAgentPoolProfiles(false); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
-
-	t.Run("Should fail for VMSS + invalid LoadBalancerBackendAddressPoolIDs", func(t *testing.T) {
-		t.Parallel()
-		cs := getK8sDefaultContainerService(false)
-		agentPoolProfiles := cs.Properties.AgentPoolProfiles
-		agentPoolProfiles[0].AvailabilityProfile = VirtualMachineScaleSets
-		agentPoolProfiles[0].LoadBalancerBackendAddressPoolIDs = []string{"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/loadBalancers/myVMSSSLB/backendAddressPools/myVMSSSLBBEPool", ""}
-		expectedMsg := fmt.Sprintf("AgentPoolProfile.LoadBalancerBackendAddressPoolIDs can not contain empty string. Agent pool name: %s", agentPoolProfiles[0].Name)
-		if err := cs.Properties.validateAgentPoolProfiles(false); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+	}
 }
 
-func TestAgentPoolProfile_ValidateAuditDEnabled(t *testing.T) {
-	t.Run("Should have proper validation for auditd + distro combinations", func(t *testing.T) {
-		t.Parallel()
-		for _, distro := range DistroValues {
+func TestAgentPoolProfile_ValidateOSDiskType(t *testing.T) {
+	tests := []struct {
+		name           string
+		osDiskType     string
+		storageProfile string
+		expectedMsg    string
+	}{
+		{
+			name:           "ephemeral OS disk with managed disks",
+			osDiskType:     EphemeralOSDisk,
+			storageProfile: ManagedDisks,
+		},
+		{
+			name:       "ephemeral OS disk with the default storage profile",
+			osDiskType: EphemeralOSDisk,
+		},
+		{
+			name:           "managed OS disk with storage accounts",
+			osDiskType:     ManagedOSDisk,
+			storageProfile: StorageAccount,
+		},
+		{
+			name:           "ephemeral OS disk with storage accounts",
+			osDiskType:     EphemeralOSDisk,
+			storageProfile: StorageAccount,
+			expectedMsg:    "agent pool agentpool has an ephemeral OS disk, which requires storageProfile ManagedDisks",
+		},
+		{
+			name:        "unknown OS disk type",
+			osDiskType:  "Local",
+			expectedMsg: "Unknown osDiskType 'Local'. Specify either Managed or Ephemeral",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
 			cs := getK8sDefaultContainerService(false)
-			agentPoolProfiles := cs.Properties.AgentPoolProfiles
-			agentPoolProfiles[0].Distro = distro
-			agentPoolProfiles[0].AuditDEnabled = to.BoolPtr(true)
-			switch distro {
-			case RHEL, CoreOS:
-				expectedMsg := fmt.Sprintf("You have enabled auditd in agent pool %s, but you did not specify an Ubuntu-based distro", agentPoolProfiles[0].Name)
-				if err := cs.Properties.validateAgentPoolProfiles(false); err.Error() != expectedMsg {
-					t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-				}
-			case Ubuntu, Ubuntu1804, AKSUbuntu1604, AKSUbuntu1804, ACC1604:
-				if err := cs.Properties.validateAgentPoolProfiles(false); err != nil {
-					t.Errorf("AuditDEnabled should work with distro %s, got error %s", distro, err.Error())
+			cs.Properties.AgentPoolProfiles[0].Name = "agentpool"
+			cs.Properties.AgentPoolProfiles[0].OSDiskType = test.osDiskType
+			cs.Properties.AgentPoolProfiles[0].StorageProfile = test.storageProfile
+			err := cs.Validate(false)
+			if test.expectedMsg == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %s", err)
 				}
+				return
 			}
-		}
-	})
+			if err == nil || err.Error() != test.expectedMsg {
+				t.Errorf("expected error with message : %s, but got %v", test.expectedMsg, err)
+			}
+		})
+	}
 }
 
 func TestMasterProfile_ValidateAuditDEnabled(t *testing.T) {
@@ -2889,6 +6225,41 @@ func TestMasterProfile_ValidateAuditDEnabled(t *testing.T) {
 	})
 }
 
+func TestMasterProfile_ValidateDataDisks(t *testing.T) {
+	t.Run("Ultra SSD etcd disks", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		cs.Properties.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+			EtcdDiskSizeGB: "256",
+		}
+		m := cs.Properties.MasterProfile
+		m.AvailabilityZones = []string{"1", "2", "3"}
+		m.DataDiskStorageAccountType = UltraSSDDataDisk
+		m.DataDiskIOPSReadWrite = 20000
+		if err := cs.Properties.validateMasterDataDisks(); err != nil {
+			t.Errorf("expected no error, got %s", err)
+		}
+
+		m.DataDiskIOPSReadWrite = 80000
+		expectedMsg := "the dataDiskIOPSReadWrite of masterProfile is 80000, more than the 300 IOPS per GiB of its data disk of 256 GiB"
+		if err := cs.Properties.validateMasterDataDisks(); err == nil || err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %v", expectedMsg, err)
+		}
+	})
+
+	t.Run("cosmos etcd", func(t *testing.T) {
+		t.Parallel()
+		cs := getK8sDefaultContainerService(false)
+		m := cs.Properties.MasterProfile
+		m.CosmosEtcd = to.BoolPtr(true)
+		m.DataDiskStorageAccountType = PremiumSSDDataDisk
+		expectedMsg := "masterProfile has data disk options, but masters with cosmosEtcd have no etcd data disk"
+		if err := cs.Properties.validateMasterDataDisks(); err == nil || err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %v", expectedMsg, err)
+		}
+	})
+}
+
 func TestValidateCustomCloudProfile(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -53,6 +53,7 @@ type AzureClient struct {
 	auxiliaryTokens []string
 	environment     azure.Environment
 	subscriptionID  string
+	tenantID        string
 
 	authorizationClient             authorization.RoleAssignmentsClient
 	deploymentsClient               resources.DeploymentsClient
@@ -61,6 +62,10 @@ type AzureClient struct {
 	resourcesClient                 apimanagement.GroupClient
 	storageAccountsClient           storage.AccountsClient
 	interfacesClient                network.InterfacesClient
+	virtualNetworksClient           network.VirtualNetworksClient
+	securityGroupsClient            network.SecurityGroupsClient
+	securityRulesClient             network.SecurityRulesClient
+	loadBalancersClient             network.LoadBalancersClient
 	groupsClient                    resources.GroupsClient
 	providersClient                 resources.ProvidersClient
 	virtualMachinesClient           compute.VirtualMachinesClient
@@ -69,6 +74,8 @@ type AzureClient struct {
 	virtualMachineExtensionsClient  compute.VirtualMachineExtensionsClient
 	disksClient                     compute.DisksClient
 	availabilitySetsClient          compute.AvailabilitySetsClient
+	resourceSkusClient              compute.ResourceSkusClient
+	usageClient                     compute.UsageClient
 	workspacesClient                operationalinsights.WorkspacesClient
 
 	applicationsClient      graphrbac.ApplicationsClient
@@ -213,24 +220,9 @@ func NewAzureClientWithClientSecretExternalTenant(env azure.Environment, subscri
 
 // NewAzureClientWithClientCertificateFile returns an AzureClient via client_id and jwt certificate assertion
 func NewAzureClientWithClientCertificateFile(env azure.Environment, subscriptionID, clientID, certificatePath, privateKeyPath string) (*AzureClient, error) {
-	certificateData, err := ioutil.ReadFile(certificatePath)
+	certificate, privateKey, err := readClientCertificate(certificatePath, privateKeyPath)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to read certificate")
-	}
-
-	block, _ := pem.Decode(certificateData)
-	if block == nil {
-		return nil, errors.New("Failed to decode pem block from certificate")
-	}
-
-	certificate, err := x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to parse certificate")
-	}
-
-	privateKey, err := parseRsaPrivateKey(privateKeyPath)
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to parse rsa private key")
+		return nil, err
 	}
 
 	return NewAzureClientWithClientCertificate(env, subscriptionID, clientID, certificate, privateKey)
@@ -278,6 +270,45 @@ func newAzureClientWithCertificate(env azure.Environment, oauthConfig *adal.OAut
 	return getClient(env, subscriptionID, tenantID, autorest.NewBearerAuthorizer(armSpt), autorest.NewBearerAuthorizer(graphSpt)), nil
 }
 
+// GetAuxiliaryTokensWithClientSecret returns the ARM tokens of a multi-tenant service principal, via client_id and
+// client_secret, in each of tenantIDs, to pass to AddAuxiliaryTokens when the cluster uses resources of other tenants
+func GetAuxiliaryTokensWithClientSecret(env azure.Environment, clientID, clientSecret string, tenantIDs []string) ([]string, error) {
+	return getAuxiliaryTokens(env, tenantIDs, func(oauthConfig adal.OAuthConfig) (*adal.ServicePrincipalToken, error) {
+		return adal.NewServicePrincipalToken(oauthConfig, clientID, clientSecret, env.ServiceManagementEndpoint)
+	})
+}
+
+// GetAuxiliaryTokensWithClientCertificateFile returns the ARM tokens of a multi-tenant service principal, via client_id
+// and jwt certificate assertion, in each of tenantIDs, to pass to AddAuxiliaryTokens
+func GetAuxiliaryTokensWithClientCertificateFile(env azure.Environment, clientID, certificatePath, privateKeyPath string, tenantIDs []string) ([]string, error) {
+	certificate, privateKey, err := readClientCertificate(certificatePath, privateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	return getAuxiliaryTokens(env, tenantIDs, func(oauthConfig adal.OAuthConfig) (*adal.ServicePrincipalToken, error) {
+		return adal.NewServicePrincipalTokenFromCertificate(oauthConfig, clientID, certificate, privateKey, env.ServiceManagementEndpoint)
+	})
+}
+
+func getAuxiliaryTokens(env azure.Environment, tenantIDs []string, newToken func(adal.OAuthConfig) (*adal.ServicePrincipalToken, error)) ([]string, error) {
+	tokens := make([]string, 0, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		oauthConfig, err := adal.NewOAuthConfig(env.ActiveDirectoryEndpoint, tenantID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "acquiring a token in auxiliary tenant %s", tenantID)
+		}
+		spt, err := newToken(*oauthConfig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "acquiring a token in auxiliary tenant %s", tenantID)
+		}
+		if err = spt.Refresh(); err != nil {
+			return nil, errors.Wrapf(err, "acquiring a token in auxiliary tenant %s", tenantID)
+		}
+		tokens = append(tokens, spt.OAuthToken())
+	}
+	return tokens, nil
+}
+
 func tokenCallback(path string) func(t adal.Token) error {
 	return func(token adal.Token) error {
 		err := adal.SaveToken(path, 0600, token)
@@ -336,6 +367,7 @@ func getClient(env azure.Environment, subscriptionID, tenantID string, armAuthor
 	c := &AzureClient{
 		environment:    env,
 		subscriptionID: subscriptionID,
+		tenantID:       tenantID,
 
 		authorizationClient:             authorization.NewRoleAssignmentsClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		deploymentsClient:               resources.NewDeploymentsClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
@@ -344,6 +376,10 @@ func getClient(env azure.Environment, subscriptionID, tenantID string, armAuthor
 		resourcesClient:                 apimanagement.NewGroupClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		storageAccountsClient:           storage.NewAccountsClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		interfacesClient:                network.NewInterfacesClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
+		virtualNetworksClient:           network.NewVirtualNetworksClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
+		securityGroupsClient:            network.NewSecurityGroupsClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
+		securityRulesClient:             network.NewSecurityRulesClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
+		loadBalancersClient:             network.NewLoadBalancersClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		groupsClient:                    resources.NewGroupsClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		providersClient:                 resources.NewProvidersClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		virtualMachinesClient:           compute.NewVirtualMachinesClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
@@ -352,6 +388,8 @@ func getClient(env azure.Environment, subscriptionID, tenantID string, armAuthor
 		virtualMachineExtensionsClient:  compute.NewVirtualMachineExtensionsClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		disksClient:                     compute.NewDisksClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		availabilitySetsClient:          compute.NewAvailabilitySetsClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
+		resourceSkusClient:              compute.NewResourceSkusClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
+		usageClient:                     compute.NewUsageClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		workspacesClient:                operationalinsights.NewWorkspacesClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 
 		applicationsClient:      graphrbac.NewApplicationsClientWithBaseURI(env.GraphEndpoint, tenantID),
@@ -365,6 +403,10 @@ func getClient(env azure.Environment, subscriptionID, tenantID string, armAuthor
 	c.resourcesClient.Authorizer = armAuthorizer
 	c.storageAccountsClient.Authorizer = armAuthorizer
 	c.interfacesClient.Authorizer = armAuthorizer
+	c.virtualNetworksClient.Authorizer = armAuthorizer
+	c.securityGroupsClient.Authorizer = armAuthorizer
+	c.securityRulesClient.Authorizer = armAuthorizer
+	c.loadBalancersClient.Authorizer = armAuthorizer
 	c.groupsClient.Authorizer = armAuthorizer
 	c.providersClient.Authorizer = armAuthorizer
 	c.virtualMachinesClient.Authorizer = armAuthorizer
@@ -372,6 +414,8 @@ func getClient(env azure.Environment, subscriptionID, tenantID string, armAuthor
 	c.virtualMachineScaleSetVMsClient.Authorizer = armAuthorizer
 	c.disksClient.Authorizer = armAuthorizer
 	c.availabilitySetsClient.Authorizer = armAuthorizer
+	c.resourceSkusClient.Authorizer = armAuthorizer
+	c.usageClient.Authorizer = armAuthorizer
 	c.workspacesClient.Authorizer = armAuthorizer
 
 	c.deploymentsClient.PollingDelay = time.Second * 5
@@ -385,6 +429,10 @@ func getClient(env azure.Environment, subscriptionID, tenantID string, armAuthor
 	c.disksClient.PollingDuration = DefaultARMOperationTimeout
 	c.groupsClient.PollingDuration = DefaultARMOperationTimeout
 	c.interfacesClient.PollingDuration = DefaultARMOperationTimeout
+	c.virtualNetworksClient.PollingDuration = DefaultARMOperationTimeout
+	c.securityGroupsClient.PollingDuration = DefaultARMOperationTimeout
+	c.securityRulesClient.PollingDuration = DefaultARMOperationTimeout
+	c.loadBalancersClient.PollingDuration = DefaultARMOperationTimeout
 	c.providersClient.PollingDuration = DefaultARMOperationTimeout
 	c.resourcesClient.PollingDuration = DefaultARMOperationTimeout
 	c.storageAccountsClient.PollingDuration = DefaultARMOperationTimeout
@@ -433,6 +481,29 @@ func (az *AzureClient) EnsureProvidersRegistered(subscriptionID string) error {
 	return nil
 }
 
+func readClientCertificate(certificatePath, privateKeyPath string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	certificateData, err := ioutil.ReadFile(certificatePath)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to read certificate")
+	}
+
+	block, _ := pem.Decode(certificateData)
+	if block == nil {
+		return nil, nil, errors.New("Failed to decode pem block from certificate")
+	}
+
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to parse certificate")
+	}
+
+	privateKey, err := parseRsaPrivateKey(privateKeyPath)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to parse rsa private key")
+	}
+	return certificate, privateKey, nil
+}
+
 func parseRsaPrivateKey(path string) (*rsa.PrivateKey, error) {
 	privateKeyData, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -464,21 +535,30 @@ func parseRsaPrivateKey(path string) (*rsa.PrivateKey, error) {
 //AddAcceptLanguages sets the list of languages to accept on this request
 func (az *AzureClient) AddAcceptLanguages(languages []string) {
 	az.acceptLanguages = languages
-	az.authorizationClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.deploymentOperationsClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.deploymentsClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.deploymentOperationsClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.resourcesClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.storageAccountsClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.interfacesClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.groupsClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.providersClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.virtualMachinesClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.virtualMachineScaleSetsClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.disksClient.Client.RequestInspector = az.addAcceptLanguages()
-
-	az.applicationsClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.servicePrincipalsClient.Client.RequestInspector = az.addAcceptLanguages()
+	az.setRequestInspectors()
+}
+
+// setRequestInspectors sets the inspector adding the accept languages and auxiliary tokens to the requests of the clients
+func (az *AzureClient) setRequestInspectors() {
+	az.authorizationClient.Client.RequestInspector = az.inspectRequests()
+	az.deploymentOperationsClient.Client.RequestInspector = az.inspectRequests()
+	az.deploymentsClient.Client.RequestInspector = az.inspectRequests()
+	az.deploymentOperationsClient.Client.RequestInspector = az.inspectRequests()
+	az.resourcesClient.Client.RequestInspector = az.inspectRequests()
+	az.storageAccountsClient.Client.RequestInspector = az.inspectRequests()
+	az.interfacesClient.Client.RequestInspector = az.inspectRequests()
+	az.virtualNetworksClient.Client.RequestInspector = az.inspectRequests()
+	az.securityGroupsClient.Client.RequestInspector = az.inspectRequests()
+	az.securityRulesClient.Client.RequestInspector = az.inspectRequests()
+	az.loadBalancersClient.Client.RequestInspector = az.inspectRequests()
+	az.groupsClient.Client.RequestInspector = az.inspectRequests()
+	az.providersClient.Client.RequestInspector = az.inspectRequests()
+	az.virtualMachinesClient.Client.RequestInspector = az.inspectRequests()
+	az.virtualMachineScaleSetsClient.Client.RequestInspector = az.inspectRequests()
+	az.disksClient.Client.RequestInspector = az.inspectRequests()
+
+	az.applicationsClient.Client.RequestInspector = az.inspectRequests()
+	az.servicePrincipalsClient.Client.RequestInspector = az.inspectRequests()
 }
 
 func (az *AzureClient) addAcceptLanguages() autorest.PrepareDecorator {
@@ -488,6 +568,9 @@ func (az *AzureClient) addAcceptLanguages() autorest.PrepareDecorator {
 			if err != nil {
 				return r, err
 			}
+			if r.Header == nil {
+				r.Header = make(http.Header)
+			}
 			if az.acceptLanguages != nil {
 				for _, language := range az.acceptLanguages {
 					r.Header.Add("Accept-Language", language)
@@ -508,38 +591,32 @@ func (az *AzureClient) setAuxiliaryTokens() autorest.PrepareDecorator {
 			if r.Header == nil {
 				r.Header = make(http.Header)
 			}
-			if az.auxiliaryTokens != nil {
-				for _, token := range az.auxiliaryTokens {
-					if token == "" {
-						continue
-					}
-
-					r.Header.Set("x-ms-authorization-auxiliary", fmt.Sprintf("Bearer %s", token))
+			// ARM expects the tokens of all the auxiliary tenants in a single comma separated header
+			var values []string
+			for _, token := range az.auxiliaryTokens {
+				if token == "" {
+					continue
 				}
+				values = append(values, fmt.Sprintf("Bearer %s", token))
+			}
+			if len(values) > 0 {
+				r.Header.Set("x-ms-authorization-auxiliary", strings.Join(values, ", "))
 			}
 			return r, nil
 		})
 	}
 }
 
-// AddAuxiliaryTokens sets the list of aux tokens to accept on this request
+// inspectRequests adds the accept languages, then the auxiliary tokens, to a request
+func (az *AzureClient) inspectRequests() autorest.PrepareDecorator {
+	return func(p autorest.Preparer) autorest.Preparer {
+		return az.setAuxiliaryTokens()(az.addAcceptLanguages()(p))
+	}
+}
+
+// AddAuxiliaryTokens sets the list of aux tokens to accept on this request, such as the tokens of the tenants of
+// the shared image galleries of the cluster
 func (az *AzureClient) AddAuxiliaryTokens(tokens []string) {
 	az.auxiliaryTokens = tokens
-	requestWithTokens := az.setAuxiliaryTokens()
-
-	az.authorizationClient.Client.RequestInspector = requestWithTokens
-	az.deploymentOperationsClient.Client.RequestInspector = requestWithTokens
-	az.deploymentsClient.Client.RequestInspector = requestWithTokens
-	az.deploymentOperationsClient.Client.RequestInspector = requestWithTokens
-	az.resourcesClient.Client.RequestInspector = requestWithTokens
-	az.storageAccountsClient.Client.RequestInspector = requestWithTokens
-	az.interfacesClient.Client.RequestInspector = requestWithTokens
-	az.groupsClient.Client.RequestInspector = requestWithTokens
-	az.providersClient.Client.RequestInspector = requestWithTokens
-	az.virtualMachinesClient.Client.RequestInspector = requestWithTokens
-	az.virtualMachineScaleSetsClient.Client.RequestInspector = requestWithTokens
-	az.disksClient.Client.RequestInspector = requestWithTokens
-
-	az.applicationsClient.Client.RequestInspector = requestWithTokens
-	az.servicePrincipalsClient.Client.RequestInspector = requestWithTokens
+	az.setRequestInspectors()
 }
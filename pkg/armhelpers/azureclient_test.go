@@ -37,4 +37,20 @@ var _ = Describe("AzureClient Aux token tests", func() {
 		Expect(err).To(BeNil())
 		Expect(request.Header.Get("x-ms-authorization-auxiliary")).To(Equal(fmt.Sprintf("Bearer %s", token)))
 	})
+
+	It("Should set the Aux tokens of all the tenants along with the accept languages", func() {
+		env, err := azure.EnvironmentFromName("AZUREPUBLICCLOUD")
+		Expect(err).To(BeNil())
+
+		azureClient, err := NewAzureClientWithClientSecretExternalTenant(env, "subID", "d1a3-4ea4", "clientID", "secret")
+		Expect(err).To(BeNil())
+		azureClient.AddAuxiliaryTokens([]string{"token1", "", "token2"})
+		azureClient.AddAcceptLanguages([]string{"en-us"})
+		request, err := azureClient.deploymentsClient.GetPreparer(context.Background(), "testRG", "testDeployment")
+		Expect(err).To(BeNil())
+		request, err = autorest.Prepare(request, azureClient.deploymentsClient.WithInspection())
+		Expect(err).To(BeNil())
+		Expect(request.Header.Get("x-ms-authorization-auxiliary")).To(Equal("Bearer token1, Bearer token2"))
+		Expect(request.Header.Get("Accept-Language")).To(Equal("en-us"))
+	})
 })
@@ -57,6 +57,10 @@ type AzureClient struct {
 	resourcesClient                 apimanagement.GroupClient
 	storageAccountsClient           storage.AccountsClient
 	interfacesClient                network.InterfacesClient
+	virtualNetworksClient           network.VirtualNetworksClient
+	securityGroupsClient            network.SecurityGroupsClient
+	securityRulesClient             network.SecurityRulesClient
+	loadBalancersClient             network.LoadBalancersClient
 	groupsClient                    resources.GroupsClient
 	providersClient                 resources.ProvidersClient
 	virtualMachinesClient           compute.VirtualMachinesClient
@@ -209,6 +213,10 @@ func getClient(env azure.Environment, subscriptionID, tenantID string, armAuthor
 		resourcesClient:                 apimanagement.NewGroupClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		storageAccountsClient:           storage.NewAccountsClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		interfacesClient:                network.NewInterfacesClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
+		virtualNetworksClient:           network.NewVirtualNetworksClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
+		securityGroupsClient:            network.NewSecurityGroupsClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
+		securityRulesClient:             network.NewSecurityRulesClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
+		loadBalancersClient:             network.NewLoadBalancersClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		groupsClient:                    resources.NewGroupsClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		providersClient:                 resources.NewProvidersClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		virtualMachinesClient:           compute.NewVirtualMachinesClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
@@ -230,6 +238,10 @@ func getClient(env azure.Environment, subscriptionID, tenantID string, armAuthor
 	c.resourcesClient.Authorizer = armAuthorizer
 	c.storageAccountsClient.Authorizer = armAuthorizer
 	c.interfacesClient.Authorizer = armAuthorizer
+	c.virtualNetworksClient.Authorizer = armAuthorizer
+	c.securityGroupsClient.Authorizer = armAuthorizer
+	c.securityRulesClient.Authorizer = armAuthorizer
+	c.loadBalancersClient.Authorizer = armAuthorizer
 	c.groupsClient.Authorizer = armAuthorizer
 	c.providersClient.Authorizer = armAuthorizer
 	c.virtualMachinesClient.Authorizer = armAuthorizer
@@ -250,6 +262,10 @@ func getClient(env azure.Environment, subscriptionID, tenantID string, armAuthor
 	c.disksClient.PollingDuration = DefaultARMOperationTimeout
 	c.groupsClient.PollingDuration = DefaultARMOperationTimeout
 	c.interfacesClient.PollingDuration = DefaultARMOperationTimeout
+	c.virtualNetworksClient.PollingDuration = DefaultARMOperationTimeout
+	c.securityGroupsClient.PollingDuration = DefaultARMOperationTimeout
+	c.securityRulesClient.PollingDuration = DefaultARMOperationTimeout
+	c.loadBalancersClient.PollingDuration = DefaultARMOperationTimeout
 	c.providersClient.PollingDuration = DefaultARMOperationTimeout
 	c.resourcesClient.PollingDuration = DefaultARMOperationTimeout
 	c.storageAccountsClient.PollingDuration = DefaultARMOperationTimeout
@@ -347,21 +363,30 @@ func parseRsaPrivateKey(path string) (*rsa.PrivateKey, error) {
 //AddAcceptLanguages sets the list of languages to accept on this request
 func (az *AzureClient) AddAcceptLanguages(languages []string) {
 	az.acceptLanguages = languages
-	az.authorizationClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.deploymentOperationsClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.deploymentsClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.deploymentOperationsClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.resourcesClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.storageAccountsClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.interfacesClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.groupsClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.providersClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.virtualMachinesClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.virtualMachineScaleSetsClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.disksClient.Client.RequestInspector = az.addAcceptLanguages()
-
-	az.applicationsClient.Client.RequestInspector = az.addAcceptLanguages()
-	az.servicePrincipalsClient.Client.RequestInspector = az.addAcceptLanguages()
+	az.setRequestInspectors()
+}
+
+// setRequestInspectors sets the inspector adding the accept languages and auxiliary tokens to the requests of the clients
+func (az *AzureClient) setRequestInspectors() {
+	az.authorizationClient.Client.RequestInspector = az.inspectRequests()
+	az.deploymentOperationsClient.Client.RequestInspector = az.inspectRequests()
+	az.deploymentsClient.Client.RequestInspector = az.inspectRequests()
+	az.deploymentOperationsClient.Client.RequestInspector = az.inspectRequests()
+	az.resourcesClient.Client.RequestInspector = az.inspectRequests()
+	az.storageAccountsClient.Client.RequestInspector = az.inspectRequests()
+	az.interfacesClient.Client.RequestInspector = az.inspectRequests()
+	az.virtualNetworksClient.Client.RequestInspector = az.inspectRequests()
+	az.securityGroupsClient.Client.RequestInspector = az.inspectRequests()
+	az.securityRulesClient.Client.RequestInspector = az.inspectRequests()
+	az.loadBalancersClient.Client.RequestInspector = az.inspectRequests()
+	az.groupsClient.Client.RequestInspector = az.inspectRequests()
+	az.providersClient.Client.RequestInspector = az.inspectRequests()
+	az.virtualMachinesClient.Client.RequestInspector = az.inspectRequests()
+	az.virtualMachineScaleSetsClient.Client.RequestInspector = az.inspectRequests()
+	az.disksClient.Client.RequestInspector = az.inspectRequests()
+
+	az.applicationsClient.Client.RequestInspector = az.inspectRequests()
+	az.servicePrincipalsClient.Client.RequestInspector = az.inspectRequests()
 }
 
 func (az *AzureClient) addAcceptLanguages() autorest.PrepareDecorator {
@@ -371,6 +396,9 @@ func (az *AzureClient) addAcceptLanguages() autorest.PrepareDecorator {
 			if err != nil {
 				return r, err
 			}
+			if r.Header == nil {
+				r.Header = make(http.Header)
+			}
 			if az.acceptLanguages != nil {
 				for _, language := range az.acceptLanguages {
 					r.Header.Add("Accept-Language", language)
@@ -391,38 +419,32 @@ func (az *AzureClient) setAuxiliaryTokens() autorest.PrepareDecorator {
 			if r.Header == nil {
 				r.Header = make(http.Header)
 			}
-			if az.auxiliaryTokens != nil {
-				for _, token := range az.auxiliaryTokens {
-					if token == "" {
-						continue
-					}
-
-					r.Header.Set("x-ms-authorization-auxiliary", fmt.Sprintf("Bearer %s", token))
+			// ARM expects the tokens of all the auxiliary tenants in a single comma separated header
+			var values []string
+			for _, token := range az.auxiliaryTokens {
+				if token == "" {
+					continue
 				}
+				values = append(values, fmt.Sprintf("Bearer %s", token))
+			}
+			if len(values) > 0 {
+				r.Header.Set("x-ms-authorization-auxiliary", strings.Join(values, ", "))
 			}
 			return r, nil
 		})
 	}
 }
 
-// AddAuxiliaryTokens sets the list of aux tokens to accept on this request
+// inspectRequests adds the accept languages, then the auxiliary tokens, to a request
+func (az *AzureClient) inspectRequests() autorest.PrepareDecorator {
+	return func(p autorest.Preparer) autorest.Preparer {
+		return az.setAuxiliaryTokens()(az.addAcceptLanguages()(p))
+	}
+}
+
+// AddAuxiliaryTokens sets the list of aux tokens to accept on this request, such as the tokens of the tenants of
+// the shared image galleries of the cluster
 func (az *AzureClient) AddAuxiliaryTokens(tokens []string) {
 	az.auxiliaryTokens = tokens
-	requestWithTokens := az.setAuxiliaryTokens()
-
-	az.authorizationClient.Client.RequestInspector = requestWithTokens
-	az.deploymentOperationsClient.Client.RequestInspector = requestWithTokens
-	az.deploymentsClient.Client.RequestInspector = requestWithTokens
-	az.deploymentOperationsClient.Client.RequestInspector = requestWithTokens
-	az.resourcesClient.Client.RequestInspector = requestWithTokens
-	az.storageAccountsClient.Client.RequestInspector = requestWithTokens
-	az.interfacesClient.Client.RequestInspector = requestWithTokens
-	az.groupsClient.Client.RequestInspector = requestWithTokens
-	az.providersClient.Client.RequestInspector = requestWithTokens
-	az.virtualMachinesClient.Client.RequestInspector = requestWithTokens
-	az.virtualMachineScaleSetsClient.Client.RequestInspector = requestWithTokens
-	az.disksClient.Client.RequestInspector = requestWithTokens
-
-	az.applicationsClient.Client.RequestInspector = requestWithTokens
-	az.servicePrincipalsClient.Client.RequestInspector = requestWithTokens
+	az.setRequestInspectors()
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/Azure/aks-engine/pkg/armhelpers"
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2017-03-30/compute"
 	azcompute "github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -108,6 +109,12 @@ func (az *AzureClient) ListVirtualMachineScaleSetVMs(ctx context.Context, resour
 	return &c, err
 }
 
+// SimulateEvictionVirtualMachineScaleSetVM evicts a low-priority VM of a VMSS as Azure would to reclaim its capacity
+func (az *AzureClient) SimulateEvictionVirtualMachineScaleSetVM(ctx context.Context, resourceGroup, virtualMachineScaleSet, instanceID string) error {
+	errorMessage := "error azure stack does not support low-priority scale sets"
+	return errors.New(errorMessage)
+}
+
 // DeleteVirtualMachineScaleSetVM deletes a VM in a VMSS
 func (az *AzureClient) DeleteVirtualMachineScaleSetVM(ctx context.Context, resourceGroup, virtualMachineScaleSet, instanceID string) error {
 	future, err := az.virtualMachineScaleSetVMsClient.Delete(ctx, resourceGroup, virtualMachineScaleSet, instanceID)
@@ -195,3 +202,15 @@ func (az *AzureClient) GetAvailabilitySetFaultDomainCount(ctx context.Context, r
 	}
 	return count, nil
 }
+
+// ListResourceSkus lists the compute resource SKUs available to the subscription
+func (az *AzureClient) ListResourceSkus(ctx context.Context) ([]azcompute.ResourceSku, error) {
+	errorMessage := "error azure stack does not support listing the resource SKUs"
+	return nil, errors.New(errorMessage)
+}
+
+// ListComputeUsages lists the compute quotas of the subscription in location, with their current usage
+func (az *AzureClient) ListComputeUsages(ctx context.Context, location string) ([]azcompute.Usage, error) {
+	errorMessage := "error azure stack does not support listing the compute usages"
+	return nil, errors.New(errorMessage)
+}
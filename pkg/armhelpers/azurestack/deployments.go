@@ -7,8 +7,10 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/Azure/aks-engine/pkg/armhelpers"
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -71,3 +73,9 @@ func (az *AzureClient) GetDeployment(ctx context.Context, resourceGroupName, dep
 func (az *AzureClient) CheckDeploymentExistence(ctx context.Context, resourceGroupName string, deploymentName string) (result autorest.Response, err error) {
 	return az.deploymentsClient.CheckExistence(ctx, resourceGroupName, deploymentName)
 }
+
+// WhatIfDeployment returns the changes deploying the template would make to the resource group, without deploying it
+func (az *AzureClient) WhatIfDeployment(ctx context.Context, resourceGroupName, deploymentName string, template, parameters map[string]interface{}) (armhelpers.WhatIfOperationResult, error) {
+	errorMessage := "error azure stack does not support the What-If operation of deployments"
+	return armhelpers.WhatIfOperationResult{}, errors.New(errorMessage)
+}
@@ -40,6 +40,18 @@ func (az *AzureClient) CreateGraphPrincipal(ctx context.Context, servicePrincipa
 	return graphrbac.ServicePrincipal{}, errors.New(errorMessage)
 }
 
+// ValidateServicePrincipalSecret acquires a token for the service principal to ensure its secret is valid and not expired
+func (az *AzureClient) ValidateServicePrincipalSecret(ctx context.Context, clientID, secret string) error {
+	errorMessage := "error azure stack does not support validating service principal secrets"
+	return errors.New(errorMessage)
+}
+
+// GetServicePrincipalPasswordCredentials returns the object ID and the password credentials of a service principal
+func (az *AzureClient) GetServicePrincipalPasswordCredentials(ctx context.Context, clientID string) (string, []graphrbac.PasswordCredential, error) {
+	errorMessage := "error azure stack does not support listing service principal credentials"
+	return "", nil, errors.New(errorMessage)
+}
+
 // CreateRoleAssignment creates a role assignment via the authorization client
 func (az *AzureClient) CreateRoleAssignment(ctx context.Context, scope string, roleAssignmentName string, parameters authorization.RoleAssignmentCreateParameters) (authorization.RoleAssignment, error) {
 	errorMessage := "error azure stack does not support creating role assignement"
@@ -117,13 +117,14 @@ func (c *KubernetesClientSetClient) SupportEviction() (string, error) {
 	return "", nil
 }
 
-// DeletePod deletes the passed in pod.
-func (c *KubernetesClientSetClient) DeletePod(pod *v1.Pod) error {
-	return c.clientset.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{})
+// DeletePod deletes the passed in pod, with the termination grace period of the pod unless gracePeriodSeconds is set.
+func (c *KubernetesClientSetClient) DeletePod(pod *v1.Pod, gracePeriodSeconds *int64) error {
+	return c.clientset.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{GracePeriodSeconds: gracePeriodSeconds})
 }
 
-// EvictPod evicts the passed in pod using the passed in api version.
-func (c *KubernetesClientSetClient) EvictPod(pod *v1.Pod, policyGroupVersion string) error {
+// EvictPod evicts the passed in pod using the passed in api version, with the termination grace period of the pod
+// unless gracePeriodSeconds is set.
+func (c *KubernetesClientSetClient) EvictPod(pod *v1.Pod, policyGroupVersion string, gracePeriodSeconds *int64) error {
 	eviction := &policy.Eviction{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: policyGroupVersion,
@@ -133,6 +134,7 @@ func (c *KubernetesClientSetClient) EvictPod(pod *v1.Pod, policyGroupVersion str
 			Name:      pod.Name,
 			Namespace: pod.Namespace,
 		},
+		DeleteOptions: &metav1.DeleteOptions{GracePeriodSeconds: gracePeriodSeconds},
 	}
 	return c.clientset.PolicyV1beta1().Evictions(eviction.Namespace).Evict(eviction)
 }
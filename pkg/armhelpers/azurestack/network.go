@@ -5,6 +5,10 @@ package azurestack
 
 import (
 	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2017-10-01/network"
+	aznetwork "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
 )
 
 // DeleteNetworkInterface deletes the specified network interface.
@@ -21,3 +25,86 @@ func (az *AzureClient) DeleteNetworkInterface(ctx context.Context, resourceGroup
 	_, err = future.Result(az.interfacesClient)
 	return err
 }
+
+// GetVirtualNetwork retrieves the specified virtual network.
+func (az *AzureClient) GetVirtualNetwork(ctx context.Context, resourceGroup, vnetName string) (aznetwork.VirtualNetwork, error) {
+	azVNET := aznetwork.VirtualNetwork{}
+	vnet, err := az.virtualNetworksClient.Get(ctx, resourceGroup, vnetName, "")
+	if err != nil {
+		return azVNET, fmt.Errorf("fail to get virtual network, %s", err)
+	}
+	if err = DeepCopy(&azVNET, vnet); err != nil {
+		return azVNET, fmt.Errorf("fail to convert virtual network, %s", err)
+	}
+	return azVNET, nil
+}
+
+// ListNetworkSecurityGroups lists the network security groups in the resource group
+func (az *AzureClient) ListNetworkSecurityGroups(ctx context.Context, resourceGroup string) ([]aznetwork.SecurityGroup, error) {
+	page, err := az.securityGroupsClient.List(ctx, resourceGroup)
+	if err != nil {
+		return nil, fmt.Errorf("fail to list network security groups, %s", err)
+	}
+	var nsgs []aznetwork.SecurityGroup
+	for page.NotDone() {
+		var azNSGs []aznetwork.SecurityGroup
+		if err = DeepCopy(&azNSGs, page.Values()); err != nil {
+			return nil, fmt.Errorf("fail to convert network security groups, %s", err)
+		}
+		nsgs = append(nsgs, azNSGs...)
+		if err = page.NextWithContext(ctx); err != nil {
+			return nil, fmt.Errorf("fail to list network security groups, %s", err)
+		}
+	}
+	return nsgs, nil
+}
+
+// CreateOrUpdateSecurityRule creates or replaces the rule of the name of rule in a network security group
+func (az *AzureClient) CreateOrUpdateSecurityRule(ctx context.Context, resourceGroup, nsgName string, rule aznetwork.SecurityRule) error {
+	azRule := network.SecurityRule{}
+	if err := DeepCopy(&azRule, rule); err != nil {
+		return fmt.Errorf("fail to convert security rule, %s", err)
+	}
+	future, err := az.securityRulesClient.CreateOrUpdate(ctx, resourceGroup, nsgName, *rule.Name, azRule)
+	if err != nil {
+		return fmt.Errorf("fail to create or update security rule, %s", err)
+	}
+	if err = future.WaitForCompletionRef(ctx, az.securityRulesClient.Client); err != nil {
+		return fmt.Errorf("fail to create or update security rule, %s", err)
+	}
+	_, err = future.Result(az.securityRulesClient)
+	return err
+}
+
+// DeleteSecurityRule deletes a rule of a network security group
+func (az *AzureClient) DeleteSecurityRule(ctx context.Context, resourceGroup, nsgName, ruleName string) error {
+	future, err := az.securityRulesClient.Delete(ctx, resourceGroup, nsgName, ruleName)
+	if err != nil {
+		return fmt.Errorf("fail to delete security rule, %s", err)
+	}
+	if err = future.WaitForCompletionRef(ctx, az.securityRulesClient.Client); err != nil {
+		return fmt.Errorf("fail to delete security rule, %s", err)
+	}
+	_, err = future.Result(az.securityRulesClient)
+	return err
+}
+
+// ListLoadBalancers lists the load balancers in the resource group
+func (az *AzureClient) ListLoadBalancers(ctx context.Context, resourceGroup string) ([]aznetwork.LoadBalancer, error) {
+	page, err := az.loadBalancersClient.List(ctx, resourceGroup)
+	if err != nil {
+		return nil, fmt.Errorf("fail to list load balancers, %s", err)
+	}
+	var lbs []aznetwork.LoadBalancer
+	for page.NotDone() {
+		var azLBs []aznetwork.LoadBalancer
+		if err = DeepCopy(&azLBs, page.Values()); err != nil {
+			return nil, fmt.Errorf("fail to convert load balancers, %s", err)
+		}
+		lbs = append(lbs, azLBs...)
+		if err = page.NextWithContext(ctx); err != nil {
+			return nil, fmt.Errorf("fail to list load balancers, %s", err)
+		}
+	}
+	return lbs, nil
+}
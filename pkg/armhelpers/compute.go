@@ -5,11 +5,18 @@ package armhelpers
 
 import (
 	"context"
+	"net/http"
 	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
 )
 
+// simulateEvictionAPIVersion is the first version of the compute API with the simulateEviction operation,
+// which the vendored compute SDK predates
+const simulateEvictionAPIVersion = "2019-07-01"
+
 // ListVirtualMachines returns (the first page of) the machines in the specified resource group.
 func (az *AzureClient) ListVirtualMachines(ctx context.Context, resourceGroup string) (VirtualMachineListResultPage, error) {
 	page, err := az.virtualMachinesClient.List(ctx, resourceGroup)
@@ -93,6 +100,38 @@ func (az *AzureClient) DeleteVirtualMachineScaleSetVM(ctx context.Context, resou
 	return err
 }
 
+// SimulateEvictionVirtualMachineScaleSetVM has Azure evict a low-priority VM of a VMSS as it would to reclaim its capacity,
+// the VM is notified with a Preempt scheduled event 30 seconds before it is evicted
+func (az *AzureClient) SimulateEvictionVirtualMachineScaleSetVM(ctx context.Context, resourceGroup, virtualMachineScaleSet, instanceID string) error {
+	client := az.virtualMachineScaleSetVMsClient
+	pathParameters := map[string]interface{}{
+		"instanceId":        autorest.Encode("path", instanceID),
+		"resourceGroupName": autorest.Encode("path", resourceGroup),
+		"subscriptionId":    autorest.Encode("path", client.SubscriptionID),
+		"vmScaleSetName":    autorest.Encode("path", virtualMachineScaleSet),
+	}
+	queryParameters := map[string]interface{}{
+		"api-version": simulateEvictionAPIVersion,
+	}
+	req, err := autorest.Prepare((&http.Request{}).WithContext(ctx),
+		autorest.AsPost(),
+		autorest.WithBaseURL(client.BaseURI),
+		autorest.WithPathParameters("/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Compute/virtualMachineScaleSets/{vmScaleSetName}/virtualMachines/{instanceId}/simulateEviction", pathParameters),
+		autorest.WithQueryParameters(queryParameters))
+	if err != nil {
+		return err
+	}
+	resp, err := autorest.SendWithSender(client, req, azure.DoRetryWithRegistration(client.Client))
+	if err != nil {
+		return err
+	}
+	return autorest.Respond(
+		resp,
+		client.ByInspecting(),
+		azure.WithErrorUnlessStatusCode(http.StatusOK, http.StatusNoContent),
+		autorest.ByClosing())
+}
+
 // DeleteVirtualMachineScaleSet deletes an entire VM Scale Set.
 func (az *AzureClient) DeleteVirtualMachineScaleSet(ctx context.Context, resourceGroup, vmssName string) error {
 	future, err := az.virtualMachineScaleSetsClient.Delete(ctx, resourceGroup, vmssName)
@@ -150,3 +189,35 @@ func (az *AzureClient) GetAvailabilitySetFaultDomainCount(ctx context.Context, r
 	}
 	return count, nil
 }
+
+// ListResourceSkus lists the compute resource SKUs available to the subscription
+func (az *AzureClient) ListResourceSkus(ctx context.Context) ([]compute.ResourceSku, error) {
+	page, err := az.resourceSkusClient.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var skus []compute.ResourceSku
+	for page.NotDone() {
+		skus = append(skus, page.Values()...)
+		if err = page.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return skus, nil
+}
+
+// ListComputeUsages lists the compute quotas of the subscription in location, with their current usage
+func (az *AzureClient) ListComputeUsages(ctx context.Context, location string) ([]compute.Usage, error) {
+	page, err := az.usageClient.List(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	var usages []compute.Usage
+	for page.NotDone() {
+		usages = append(usages, page.Values()...)
+		if err = page.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return usages, nil
+}
@@ -211,6 +211,38 @@ func TestDeleteVirtualMachine(t *testing.T) {
 	}
 }
 
+func TestSimulateEvictionVirtualMachineScaleSetVM(t *testing.T) {
+	mc, err := NewHTTPMockClient()
+	if err != nil {
+		t.Fatalf("failed to create HttpMockClient - %s", err)
+	}
+
+	mc.RegisterLogin()
+	mc.RegisterSimulateEviction()
+
+	err = mc.Activate()
+	if err != nil {
+		t.Fatalf("failed to activate HttpMockClient - %s", err)
+	}
+	defer mc.DeactivateAndReset()
+
+	env := mc.GetEnvironment()
+	azureClient, err := NewAzureClientWithClientSecret(env, subscriptionID, "clientID", "secret")
+	if err != nil {
+		t.Fatalf("can not get client %s", err)
+	}
+
+	err = azureClient.SimulateEvictionVirtualMachineScaleSetVM(context.Background(), resourceGroup, virtualMachineScaleSetName, "0")
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = azureClient.SimulateEvictionVirtualMachineScaleSetVM(context.Background(), resourceGroup, virtualMachineScaleSetName, "1")
+	if err == nil {
+		t.Error("expected the eviction of an instance that does not exist to fail")
+	}
+}
+
 func TestGetAvailabilitySet(t *testing.T) {
 	mc, err := NewHTTPMockClient()
 	if err != nil {
@@ -66,3 +66,40 @@ func TestDeployTemplateSync(t *testing.T) {
 		t.Error("err should not be nil")
 	}
 }
+
+func TestWhatIfDeployment(t *testing.T) {
+	mc, err := NewHTTPMockClient()
+	if err != nil {
+		t.Fatalf("failed to create HttpMockClient - %s", err)
+	}
+
+	mc.RegisterLogin()
+	mc.RegisterWhatIfDeployment()
+
+	err = mc.Activate()
+	if err != nil {
+		t.Fatalf("failed to activate HttpMockClient - %s", err)
+	}
+	defer mc.DeactivateAndReset()
+
+	env := mc.GetEnvironment()
+	azureClient, err := NewAzureClientWithClientSecret(env, subscriptionID, "clientID", "secret")
+	if err != nil {
+		t.Fatalf("can not get client %s", err)
+	}
+
+	result, err := azureClient.WhatIfDeployment(context.Background(), resourceGroup, deploymentName, map[string]interface{}{}, map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Properties == nil || len(result.Properties.Changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", result)
+	}
+	modify := result.Properties.Changes[0]
+	if modify.ChangeType != WhatIfChangeTypeModify || len(modify.Delta) != 1 || len(modify.Delta[0].Children) != 1 {
+		t.Errorf("expected the VNET to be modified, got %+v", modify)
+	}
+	if create := result.Properties.Changes[1]; create.ChangeType != WhatIfChangeTypeCreate {
+		t.Errorf("expected the scale set to be created, got %+v", create)
+	}
+}
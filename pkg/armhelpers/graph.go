@@ -12,15 +12,21 @@ import (
 	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
 	"github.com/Azure/azure-sdk-for-go/services/graphrbac/1.6/graphrbac"
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
 	"github.com/Azure/go-autorest/autorest/date"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
 const (
 	// AADContributorRoleID is the role id that exists in every subscription for 'Contributor'
 	AADContributorRoleID = "b24988ac-6180-42a0-ab88-20f7382dd24c"
+	// AADOwnerRoleID is the role id that exists in every subscription for 'Owner'
+	AADOwnerRoleID = "8e3af657-a8ff-443c-a75c-2fe8c4bcb635"
+	// AADNetworkContributorRoleID is the role id that exists in every subscription for 'Network Contributor'
+	AADNetworkContributorRoleID = "4d97b98b-1d4f-4787-a291-c67834d212e7"
 	// AADRoleReferenceTemplate is a template for a roleDefinitionId
 	AADRoleReferenceTemplate = "/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s"
 	// AADRoleResourceGroupScopeTemplate is a template for a roleDefinition scope
@@ -42,6 +48,58 @@ func (az *AzureClient) CreateGraphPrincipal(ctx context.Context, servicePrincipa
 	return az.servicePrincipalsClient.Create(ctx, servicePrincipalCreateParameters)
 }
 
+// ValidateServicePrincipalSecret acquires a token for the service principal to ensure its secret is valid and not expired
+func (az *AzureClient) ValidateServicePrincipalSecret(ctx context.Context, clientID, secret string) error {
+	oauthConfig, err := adal.NewOAuthConfig(az.environment.ActiveDirectoryEndpoint, az.tenantID)
+	if err != nil {
+		return err
+	}
+	spt, err := adal.NewServicePrincipalToken(*oauthConfig, clientID, secret, az.environment.ServiceManagementEndpoint)
+	if err != nil {
+		return err
+	}
+	return spt.RefreshWithContext(ctx)
+}
+
+// GetServicePrincipalPasswordCredentials returns the object ID of the service principal with the given application (client) ID,
+// and the password credentials registered for it and for its application
+func (az *AzureClient) GetServicePrincipalPasswordCredentials(ctx context.Context, clientID string) (string, []graphrbac.PasswordCredential, error) {
+	filter := fmt.Sprintf("appId eq '%s'", clientID)
+	spPage, err := az.servicePrincipalsClient.List(ctx, filter)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(spPage.Values()) == 0 {
+		return "", nil, errors.Errorf("service principal with appId %s not found", clientID)
+	}
+	objectID := to.String(spPage.Values()[0].ObjectID)
+
+	var credentials []graphrbac.PasswordCredential
+	spCredentials, err := az.servicePrincipalsClient.ListPasswordCredentials(ctx, objectID)
+	if err != nil {
+		return "", nil, err
+	}
+	if spCredentials.Value != nil {
+		credentials = append(credentials, *spCredentials.Value...)
+	}
+
+	// secrets created with 'az ad sp create-for-rbac' are registered on the application rather than the service principal
+	appPage, err := az.applicationsClient.List(ctx, filter)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, app := range appPage.Values() {
+		appCredentials, err := az.applicationsClient.ListPasswordCredentials(ctx, to.String(app.ObjectID))
+		if err != nil {
+			return "", nil, err
+		}
+		if appCredentials.Value != nil {
+			credentials = append(credentials, *appCredentials.Value...)
+		}
+	}
+	return objectID, credentials, nil
+}
+
 // CreateRoleAssignment creates a role assignment via the authorization client
 func (az *AzureClient) CreateRoleAssignment(ctx context.Context, scope string, roleAssignmentName string, parameters authorization.RoleAssignmentCreateParameters) (authorization.RoleAssignment, error) {
 	return az.authorizationClient.Create(ctx, scope, roleAssignmentName, parameters)
@@ -37,6 +37,8 @@ const (
 	logAnalyticsWorkspaceName                  = "testLogAnalyticsWorkspace"
 	logAnalyticsSolutionName                   = "ContainerInsights(testLogAnalyticsWorkspace)"
 	virtualNicName                             = "testVirtualNicName"
+	networkSecurityGroupName                   = "testNetworkSecurityGroupName"
+	securityRuleName                           = "testSecurityRuleName"
 	virutalDiskName                            = "testVirtualdickName"
 	location                                   = "local"
 	operationID                                = "7184adda-13fc-4d49-b941-fbbc3b08ed64"
@@ -54,6 +56,7 @@ const (
 	filePathCreateOrUpdateWorkspace            = "httpMockClientData/createOrUpdateWorkspace.json"
 	filePathListWorkspacesByResourceGroupInMC  = "httpMockClientData/getListWorkspacesByResourceGroup.json"
 	filePathCreateOrUpdateWorkspaceInMC        = "httpMockClientData/createOrUpdateWorkspace.json"
+	filePathWhatIfDeployment                   = "httpMockClientData/whatIfDeployment.json"
 )
 
 //HTTPMockClient is an wrapper of httpmock
@@ -97,6 +100,7 @@ type HTTPMockClient struct {
 	ResponseCreateOrUpdateWorkspace            string
 	ResponseListWorkspacesByResourceGroupInMC  string
 	ResponseCreateOrUpdateWorkspaceInMC        string
+	ResponseWhatIfDeployment                   string
 	mux                                        *http.ServeMux
 	server                                     *testserver.TestServer
 }
@@ -176,6 +180,10 @@ func NewHTTPMockClient() (HTTPMockClient, error) {
 	if err != nil {
 		return client, err
 	}
+	client.ResponseWhatIfDeployment, err = readFromFile(filePathWhatIfDeployment)
+	if err != nil {
+		return client, err
+	}
 	client.ResponseGetAvailabilitySet, err = readFromFile(filePathGetAvailabilitySet)
 	if err != nil {
 		return client, err
@@ -290,6 +298,18 @@ func (mc HTTPMockClient) RegisterListVirtualMachineScaleSetVMs() {
 	})
 }
 
+// RegisterSimulateEviction registers the mock response for SimulateEvictionVirtualMachineScaleSetVM
+func (mc HTTPMockClient) RegisterSimulateEviction() {
+	pattern := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/virtualMachineScaleSets/%s/virtualMachines/0/simulateEviction", mc.SubscriptionID, mc.ResourceGroup, mc.VirtualMachineScaleSetName)
+	mc.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api-version") != simulateEvictionAPIVersion || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
+
 // RegisterListVirtualMachines registers the mock response for ListVirtualMachines
 func (mc HTTPMockClient) RegisterListVirtualMachines() {
 	pattern := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/virtualMachines", mc.SubscriptionID, mc.ResourceGroup)
@@ -414,6 +434,29 @@ func (mc HTTPMockClient) RegisterDeployOperationFailure() {
 	})
 }
 
+// RegisterWhatIfDeployment registers the mock responses for the What-If of a deployment
+func (mc *HTTPMockClient) RegisterWhatIfDeployment() {
+	pattern := fmt.Sprintf("/subscriptions/%s/resourcegroups/%s/providers/Microsoft.Resources/deployments/%s/whatIf", mc.SubscriptionID, mc.ResourceGroup, mc.DeploymentName)
+	mc.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api-version") != whatIfAPIVersion || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.Header().Add("Location", fmt.Sprintf("http://localhost:%d/subscriptions/%s/providers/Microsoft.Resources/locations/%s/operationResults/%s?api-version=%s", mc.server.Port, mc.SubscriptionID, mc.Location, mc.OperationID, whatIfAPIVersion))
+			w.Header().Add("Content-Length", "0")
+			w.WriteHeader(http.StatusAccepted)
+		}
+	})
+
+	pattern = fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Resources/locations/%s/operationResults/%s", mc.SubscriptionID, mc.Location, mc.OperationID)
+	mc.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api-version") != whatIfAPIVersion {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			_, _ = fmt.Fprint(w, mc.ResponseWhatIfDeployment)
+		}
+	})
+}
+
 // RegisterDeleteNetworkInterface registers the mock response for DeleteNetworkInterface
 func (mc *HTTPMockClient) RegisterDeleteNetworkInterface() {
 	pattern := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/networkInterfaces/%s", mc.SubscriptionID, mc.ResourceGroup, mc.VirtualNicName)
@@ -448,6 +491,23 @@ func (mc *HTTPMockClient) RegisterDeleteNetworkInterface() {
 	})
 }
 
+// RegisterSecurityRule registers the mock responses for CreateOrUpdateSecurityRule and DeleteSecurityRule
+func (mc *HTTPMockClient) RegisterSecurityRule() {
+	pattern := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/networkSecurityGroups/%s/securityRules/%s", mc.SubscriptionID, mc.ResourceGroup, networkSecurityGroupName, securityRuleName)
+	mc.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Get("api-version") != mc.NetworkAPIVersion:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPut, r.Method == http.MethodGet:
+			_, _ = fmt.Fprintf(w, `{"name": "%s", "properties": {"access": "Deny", "provisioningState": "Succeeded"}}`, securityRuleName)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
 // RegisterDeleteManagedDisk registers the mock response for DeleteManagedDisk
 func (mc *HTTPMockClient) RegisterDeleteManagedDisk() {
 	pattern := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/disks/%s", mc.SubscriptionID, mc.ResourceGroup, mc.VirutalDiskName)
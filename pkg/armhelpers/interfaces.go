@@ -10,6 +10,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
 	"github.com/Azure/azure-sdk-for-go/services/graphrbac/1.6/graphrbac"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
 	"github.com/Azure/azure-sdk-for-go/services/preview/msi/mgmt/2015-08-31-preview/msi"
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
 	azStorage "github.com/Azure/azure-sdk-for-go/storage"
@@ -95,6 +96,9 @@ type AKSEngineClient interface {
 	// DeployTemplate can deploy a template into Azure ARM
 	DeployTemplate(ctx context.Context, resourceGroup, name string, template, parameters map[string]interface{}) (resources.DeploymentExtended, error)
 
+	// WhatIfDeployment returns the changes deploying a template would make to the resource group, without deploying it
+	WhatIfDeployment(ctx context.Context, resourceGroup, name string, template, parameters map[string]interface{}) (WhatIfOperationResult, error)
+
 	// EnsureResourceGroup ensures the specified resource group exists in the specified location
 	EnsureResourceGroup(ctx context.Context, resourceGroup, location string, managedBy *string) (*resources.Group, error)
 
@@ -125,6 +129,12 @@ type AKSEngineClient interface {
 	// DeleteVirtualMachineScaleSetVM deletes a VM in a VMSS
 	DeleteVirtualMachineScaleSetVM(ctx context.Context, resourceGroup, virtualMachineScaleSet, instanceID string) error
 
+	// DeleteVirtualMachineScaleSet deletes an entire VM Scale Set.
+	DeleteVirtualMachineScaleSet(ctx context.Context, resourceGroup, vmssName string) error
+
+	// SimulateEvictionVirtualMachineScaleSetVM evicts a low-priority VM of a VMSS as Azure would to reclaim its capacity
+	SimulateEvictionVirtualMachineScaleSetVM(ctx context.Context, resourceGroup, virtualMachineScaleSet, instanceID string) error
+
 	// SetVirtualMachineScaleSetCapacity sets the VMSS capacity
 	SetVirtualMachineScaleSetCapacity(ctx context.Context, resourceGroup, virtualMachineScaleSet string, sku compute.Sku, location string) error
 
@@ -135,6 +145,12 @@ type AKSEngineClient interface {
 	// VM availability set IDs provided.
 	GetAvailabilitySetFaultDomainCount(ctx context.Context, resourceGroup string, vmasIDs []string) (int, error)
 
+	// ListResourceSkus lists the compute resource SKUs available to the subscription
+	ListResourceSkus(ctx context.Context) ([]compute.ResourceSku, error)
+
+	// ListComputeUsages lists the compute quotas of the subscription in location, with their current usage
+	ListComputeUsages(ctx context.Context, location string) ([]compute.Usage, error)
+
 	//
 	// STORAGE
 
@@ -148,6 +164,21 @@ type AKSEngineClient interface {
 	// DeleteNetworkInterface deletes the specified network interface.
 	DeleteNetworkInterface(ctx context.Context, resourceGroup, nicName string) error
 
+	// GetVirtualNetwork retrieves the specified virtual network.
+	GetVirtualNetwork(ctx context.Context, resourceGroup, vnetName string) (network.VirtualNetwork, error)
+
+	// ListNetworkSecurityGroups lists the network security groups in the resource group
+	ListNetworkSecurityGroups(ctx context.Context, resourceGroup string) ([]network.SecurityGroup, error)
+
+	// CreateOrUpdateSecurityRule creates or replaces the rule of the name of rule in a network security group
+	CreateOrUpdateSecurityRule(ctx context.Context, resourceGroup, nsgName string, rule network.SecurityRule) error
+
+	// DeleteSecurityRule deletes a rule of a network security group
+	DeleteSecurityRule(ctx context.Context, resourceGroup, nsgName, ruleName string) error
+
+	// ListLoadBalancers lists the load balancers in the resource group
+	ListLoadBalancers(ctx context.Context, resourceGroup string) ([]network.LoadBalancer, error)
+
 	//
 	// GRAPH
 
@@ -159,6 +190,12 @@ type AKSEngineClient interface {
 	CreateApp(ctx context.Context, applicationName, applicationURL string, replyURLs *[]string, requiredResourceAccess *[]graphrbac.RequiredResourceAccess) (result graphrbac.Application, servicePrincipalObjectID, secret string, err error)
 	DeleteApp(ctx context.Context, applicationName, applicationObjectID string) (autorest.Response, error)
 
+	// ValidateServicePrincipalSecret acquires a token for the service principal to ensure its secret is valid and not expired
+	ValidateServicePrincipalSecret(ctx context.Context, clientID, secret string) error
+
+	// GetServicePrincipalPasswordCredentials returns the object ID and the password credentials of a service principal
+	GetServicePrincipalPasswordCredentials(ctx context.Context, clientID string) (objectID string, credentials []graphrbac.PasswordCredential, err error)
+
 	// User Assigned MSI
 	//CreateUserAssignedID - Creates a user assigned msi.
 	CreateUserAssignedID(location string, resourceGroup string, userAssignedID string) (*msi.Identity, error)
@@ -202,6 +239,12 @@ type AKSStorageClient interface {
 	CreateContainer(containerName string, options *azStorage.CreateContainerOptions) (bool, error)
 	// SaveBlockBlob initializes a block blob by taking the byte
 	SaveBlockBlob(containerName, blobName string, b []byte, options *azStorage.PutBlobOptions) error
+	// PutBlock uploads a block of a block blob, which is not part of the blob until it is committed by PutBlockList
+	PutBlock(containerName, blobName, blockID string, chunk []byte) error
+	// PutBlockList commits the uploaded blocks of a block blob, in order, as its content
+	PutBlockList(containerName, blobName string, blockIDs []string) error
+	// GetBlobSASURI returns a URI granting read access to the specified blob until the expiry time
+	GetBlobSASURI(containerName, blobName string, expiry time.Time) (string, error)
 }
 
 // KubernetesClient interface models client for interacting with kubernetes api server
@@ -222,12 +265,13 @@ type KubernetesClient interface {
 	DeleteNode(name string) error
 	// SupportEviction queries the api server to discover if it supports eviction, and returns supported type if it is supported.
 	SupportEviction() (string, error)
-	// DeletePod deletes the passed in pod.
-	DeletePod(pod *v1.Pod) error
+	// DeletePod deletes the passed in pod, with the termination grace period of the pod unless gracePeriodSeconds is set.
+	DeletePod(pod *v1.Pod, gracePeriodSeconds *int64) error
 	// DeleteServiceAccount deletes the passed in service account.
 	DeleteServiceAccount(sa *v1.ServiceAccount) error
-	// EvictPod evicts the passed in pod using the passed in api version.
-	EvictPod(pod *v1.Pod, policyGroupVersion string) error
+	// EvictPod evicts the passed in pod using the passed in api version, with the termination grace period of the pod
+	// unless gracePeriodSeconds is set.
+	EvictPod(pod *v1.Pod, policyGroupVersion string, gracePeriodSeconds *int64) error
 	// WaitForDelete waits until all pods are deleted. Returns all pods not deleted and an error on failure.
 	WaitForDelete(logger *log.Entry, pods []v1.Pod, usingEviction bool) ([]v1.Pod, error)
 	// GetDeployment returns a given deployment in a namespace.
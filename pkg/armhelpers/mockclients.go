@@ -17,13 +17,16 @@ import (
 	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
 	"github.com/Azure/azure-sdk-for-go/services/graphrbac/1.6/graphrbac"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
 	"github.com/Azure/azure-sdk-for-go/services/preview/msi/mgmt/2015-08-31-preview/msi"
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
 	azStorage "github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/date"
 	log "github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 const (
@@ -38,6 +41,7 @@ type MockAKSEngineClient struct {
 	FailDeployTemplateQuota                 bool
 	FailDeployTemplateConflict              bool
 	FailDeployTemplateWithProperties        bool
+	FailWhatIfDeployment                    bool
 	FailEnsureResourceGroup                 bool
 	FailListVirtualMachines                 bool
 	FailListVirtualMachinesTags             bool
@@ -47,27 +51,57 @@ type MockAKSEngineClient struct {
 	FailRestartVirtualMachine               bool
 	FailDeleteVirtualMachine                bool
 	FailDeleteVirtualMachineScaleSetVM      bool
+	FailDeleteVirtualMachineScaleSet        bool
+	FailSimulateEviction                    bool
 	FailSetVirtualMachineScaleSetCapacity   bool
 	FailListVirtualMachineScaleSetVMs       bool
 	FailGetStorageClient                    bool
 	FailDeleteNetworkInterface              bool
+	FailGetVirtualNetwork                   bool
+	FakeVirtualNetworkAddressPrefixes       []string
+	FakeVirtualNetworkSubnets               []network.Subnet
+	FailListNetworkSecurityGroups           bool
+	FakeNetworkSecurityGroups               []network.SecurityGroup
+	FailCreateOrUpdateSecurityRule          bool
+	FailDeleteSecurityRule                  bool
+	FailListLoadBalancers                   bool
+	FakeLoadBalancers                       []network.LoadBalancer
+	FailListResourceSkus                    bool
+	FakeResourceSkus                        []compute.ResourceSku
+	FailListComputeUsages                   bool
+	FakeComputeUsages                       []compute.Usage
 	FailGetKubernetesClient                 bool
 	FailListProviders                       bool
 	ShouldSupportVMIdentity                 bool
+	FailValidateServicePrincipalSecret      bool
+	FailGetServicePrincipalCredentials      bool
+	FakeServicePrincipalSecretEndDate       time.Time
+	MissingServicePrincipalRoleAssignments  bool
 	FailDeleteRoleAssignment                bool
 	FailEnsureDefaultLogAnalyticsWorkspace  bool
 	FailAddContainerInsightsSolution        bool
 	FailGetLogAnalyticsWorkspaceInfo        bool
 	MockKubernetesClient                    *MockKubernetesClient
+	MockStorageClient                       *MockStorageClient
 	FakeListVirtualMachineScaleSetsResult   func() []compute.VirtualMachineScaleSet
 	FakeListVirtualMachineResult            func() []compute.VirtualMachine
 	FakeListVirtualMachineScaleSetVMsResult func() []compute.VirtualMachineScaleSetVM
+	SetVirtualMachineScaleSetCapacityFunc   func(sku compute.Sku) error
+	DeployTemplateFunc                      func(resourceGroup, name string) error
+	DeleteVirtualMachineScaleSetVMFunc      func(instanceID string) error
 }
 
 //MockStorageClient mock implementation of StorageClient
 type MockStorageClient struct {
 	FailCreateContainer bool
 	FailSaveBlockBlob   bool
+	FailPutBlock        bool
+	FailPutBlockList    bool
+	FailGetBlobSASURI   bool
+	// Blocks are the uploaded blocks, by block ID
+	Blocks map[string][]byte
+	// Blobs are the contents of the blobs committed by PutBlockList, by container and blob name
+	Blobs map[string][]byte
 }
 
 //MockKubernetesClient mock implementation of KubernetesClient
@@ -84,12 +118,17 @@ type MockKubernetesClient struct {
 	FailSupportEviction       bool
 	FailDeletePod             bool
 	FailEvictPod              bool
+	EvictPodFunc              func(pod *v1.Pod, gracePeriodSeconds *int64) error
+	DeletePodFunc             func(pod *v1.Pod, gracePeriodSeconds *int64) error
 	FailWaitForDelete         bool
 	ShouldSupportEviction     bool
 	PodsList                  *v1.PodList
+	NodeList                  *v1.NodeList
 	ServiceAccountList        *v1.ServiceAccountList
 	FailGetDeploymentCount    int
 	FailUpdateDeploymentCount int
+	// Deployments are returned by GetDeployment by name if set, names without a deployment are not found
+	Deployments map[string]*appsv1.Deployment
 }
 
 // MockVirtualMachineListResultPage contains a page of VirtualMachine values.
@@ -306,6 +345,9 @@ func (mkc *MockKubernetesClient) ListNodes() (*v1.NodeList, error) {
 	if mkc.FailListNodes {
 		return nil, errors.New("ListNodes failed")
 	}
+	if mkc.NodeList != nil {
+		return mkc.NodeList, nil
+	}
 	node := &v1.Node{}
 	node.Name = "k8s-master-1234"
 	node.Status.Conditions = append(node.Status.Conditions, v1.NodeCondition{Type: v1.NodeReady, Status: v1.ConditionTrue})
@@ -393,18 +435,24 @@ func (mkc *MockKubernetesClient) SupportEviction() (string, error) {
 }
 
 //DeletePod deletes the passed in pod
-func (mkc *MockKubernetesClient) DeletePod(pod *v1.Pod) error {
+func (mkc *MockKubernetesClient) DeletePod(pod *v1.Pod, gracePeriodSeconds *int64) error {
 	if mkc.FailDeletePod {
 		return errors.New("DeletePod failed")
 	}
+	if mkc.DeletePodFunc != nil {
+		return mkc.DeletePodFunc(pod, gracePeriodSeconds)
+	}
 	return nil
 }
 
 //EvictPod evicts the passed in pod using the passed in api version
-func (mkc *MockKubernetesClient) EvictPod(pod *v1.Pod, policyGroupVersion string) error {
+func (mkc *MockKubernetesClient) EvictPod(pod *v1.Pod, policyGroupVersion string, gracePeriodSeconds *int64) error {
 	if mkc.FailEvictPod {
 		return errors.New("EvictPod failed")
 	}
+	if mkc.EvictPodFunc != nil {
+		return mkc.EvictPodFunc(pod, gracePeriodSeconds)
+	}
 	return nil
 }
 
@@ -422,6 +470,13 @@ func (mkc *MockKubernetesClient) GetDeployment(namespace, name string) (*appsv1.
 		mkc.FailGetDeploymentCount--
 		return nil, errors.New("GetDeployment failed")
 	}
+	if mkc.Deployments != nil {
+		d, ok := mkc.Deployments[name]
+		if !ok {
+			return nil, apierrors.NewNotFound(appsv1.Resource("deployments"), name)
+		}
+		return d.DeepCopy(), nil
+	}
 	var replicas int32 = 1
 	return &appsv1.Deployment{
 		Spec: appsv1.DeploymentSpec{
@@ -460,12 +515,74 @@ func (msc *MockStorageClient) SaveBlockBlob(container, blob string, b []byte, op
 	return errors.New("SaveBlockBlob failed")
 }
 
+//PutBlock mock
+func (msc *MockStorageClient) PutBlock(container, blob, blockID string, chunk []byte) error {
+	if msc.FailPutBlock {
+		return errors.New("PutBlock failed")
+	}
+	if msc.Blocks == nil {
+		msc.Blocks = map[string][]byte{}
+	}
+	msc.Blocks[blockID] = append([]byte{}, chunk...)
+	return nil
+}
+
+//PutBlockList mock
+func (msc *MockStorageClient) PutBlockList(container, blob string, blockIDs []string) error {
+	if msc.FailPutBlockList {
+		return errors.New("PutBlockList failed")
+	}
+	var content []byte
+	for _, id := range blockIDs {
+		chunk, ok := msc.Blocks[id]
+		if !ok {
+			return fmt.Errorf("PutBlockList failed, block %s was not uploaded", id)
+		}
+		content = append(content, chunk...)
+	}
+	if msc.Blobs == nil {
+		msc.Blobs = map[string][]byte{}
+	}
+	msc.Blobs[container+"/"+blob] = content
+	return nil
+}
+
+//GetBlobSASURI mock
+func (msc *MockStorageClient) GetBlobSASURI(container, blob string, expiry time.Time) (string, error) {
+	if !msc.FailGetBlobSASURI {
+		return fmt.Sprintf("https://fakeaccount.blob.core.windows.net/%s/%s?se=%s&sp=r&sig=fake", container, blob, expiry.UTC().Format(time.RFC3339)), nil
+	}
+	return "", errors.New("GetBlobSASURI failed")
+}
+
 //AddAcceptLanguages mock
 func (mc *MockAKSEngineClient) AddAcceptLanguages(languages []string) {}
 
 // AddAuxiliaryTokens mock
 func (mc *MockAKSEngineClient) AddAuxiliaryTokens(tokens []string) {}
 
+//WhatIfDeployment mock
+func (mc *MockAKSEngineClient) WhatIfDeployment(ctx context.Context, resourceGroup, name string, template, parameters map[string]interface{}) (WhatIfOperationResult, error) {
+	if mc.FailWhatIfDeployment {
+		return WhatIfOperationResult{}, errors.New("WhatIfDeployment failed")
+	}
+	return WhatIfOperationResult{
+		Status: "Succeeded",
+		Properties: &WhatIfOperationProperties{
+			Changes: []WhatIfChange{
+				{
+					ResourceID: fmt.Sprintf("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/%s/providers/Microsoft.Network/virtualNetworks/k8s-vnet-12345678", resourceGroup),
+					ChangeType: WhatIfChangeTypeNoChange,
+				},
+				{
+					ResourceID: fmt.Sprintf("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/%s/providers/Microsoft.Compute/virtualMachines/k8s-master-12345678-0", resourceGroup),
+					ChangeType: WhatIfChangeTypeCreate,
+				},
+			},
+		},
+	}, nil
+}
+
 //DeployTemplate mock
 func (mc *MockAKSEngineClient) DeployTemplate(ctx context.Context, resourceGroup, name string, template, parameters map[string]interface{}) (de resources.DeploymentExtended, err error) {
 	switch {
@@ -535,6 +652,8 @@ func (mc *MockAKSEngineClient) DeployTemplate(ctx context.Context, resourceGroup
 					ProvisioningState: &provisioningState,
 				}},
 			errors.New(errmsg)
+	case mc.DeployTemplateFunc != nil:
+		return de, mc.DeployTemplateFunc(resourceGroup, name)
 	default:
 		return de, nil
 	}
@@ -747,8 +866,28 @@ func (mc *MockAKSEngineClient) DeleteVirtualMachine(ctx context.Context, resourc
 	return nil
 }
 
+//DeleteVirtualMachineScaleSet mock
+func (mc *MockAKSEngineClient) DeleteVirtualMachineScaleSet(ctx context.Context, resourceGroup, vmssName string) error {
+	if mc.FailDeleteVirtualMachineScaleSet {
+		return errors.New("DeleteVirtualMachineScaleSet failed")
+	}
+
+	return nil
+}
+
+//SimulateEvictionVirtualMachineScaleSetVM mock
+func (mc *MockAKSEngineClient) SimulateEvictionVirtualMachineScaleSetVM(ctx context.Context, resourceGroup, virtualMachineScaleSet, instanceID string) error {
+	if mc.FailSimulateEviction {
+		return errors.New("SimulateEvictionVirtualMachineScaleSetVM failed")
+	}
+	return nil
+}
+
 //DeleteVirtualMachineScaleSetVM mock
 func (mc *MockAKSEngineClient) DeleteVirtualMachineScaleSetVM(ctx context.Context, resourceGroup, virtualMachineScaleSet, instanceID string) error {
+	if mc.DeleteVirtualMachineScaleSetVMFunc != nil {
+		return mc.DeleteVirtualMachineScaleSetVMFunc(instanceID)
+	}
 	if mc.FailDeleteVirtualMachineScaleSetVM {
 		return errors.New("DeleteVirtualMachineScaleSetVM failed")
 	}
@@ -758,6 +897,9 @@ func (mc *MockAKSEngineClient) DeleteVirtualMachineScaleSetVM(ctx context.Contex
 
 //SetVirtualMachineScaleSetCapacity mock
 func (mc *MockAKSEngineClient) SetVirtualMachineScaleSetCapacity(ctx context.Context, resourceGroup, virtualMachineScaleSet string, sku compute.Sku, location string) error {
+	if mc.SetVirtualMachineScaleSetCapacityFunc != nil {
+		return mc.SetVirtualMachineScaleSetCapacityFunc(sku)
+	}
 	if mc.FailSetVirtualMachineScaleSetCapacity {
 		return errors.New("SetVirtualMachineScaleSetCapacity failed")
 	}
@@ -800,11 +942,30 @@ func (mc *MockAKSEngineClient) GetAvailabilitySetFaultDomainCount(ctx context.Co
 	return 3, nil
 }
 
+// ListResourceSkus mock
+func (mc *MockAKSEngineClient) ListResourceSkus(ctx context.Context) ([]compute.ResourceSku, error) {
+	if mc.FailListResourceSkus {
+		return nil, errors.New("ListResourceSkus failed")
+	}
+	return mc.FakeResourceSkus, nil
+}
+
+// ListComputeUsages mock
+func (mc *MockAKSEngineClient) ListComputeUsages(ctx context.Context, location string) ([]compute.Usage, error) {
+	if mc.FailListComputeUsages {
+		return nil, errors.New("ListComputeUsages failed")
+	}
+	return mc.FakeComputeUsages, nil
+}
+
 //GetStorageClient mock
 func (mc *MockAKSEngineClient) GetStorageClient(ctx context.Context, resourceGroup, accountName string) (AKSStorageClient, error) {
 	if mc.FailGetStorageClient {
 		return nil, errors.New("GetStorageClient failed")
 	}
+	if mc.MockStorageClient != nil {
+		return mc.MockStorageClient, nil
+	}
 
 	return &MockStorageClient{}, nil
 }
@@ -818,6 +979,59 @@ func (mc *MockAKSEngineClient) DeleteNetworkInterface(ctx context.Context, resou
 	return nil
 }
 
+//ListNetworkSecurityGroups mock
+func (mc *MockAKSEngineClient) ListNetworkSecurityGroups(ctx context.Context, resourceGroup string) ([]network.SecurityGroup, error) {
+	if mc.FailListNetworkSecurityGroups {
+		return nil, errors.New("ListNetworkSecurityGroups failed")
+	}
+	return mc.FakeNetworkSecurityGroups, nil
+}
+
+//CreateOrUpdateSecurityRule mock
+func (mc *MockAKSEngineClient) CreateOrUpdateSecurityRule(ctx context.Context, resourceGroup, nsgName string, rule network.SecurityRule) error {
+	if mc.FailCreateOrUpdateSecurityRule {
+		return errors.New("CreateOrUpdateSecurityRule failed")
+	}
+	return nil
+}
+
+//DeleteSecurityRule mock
+func (mc *MockAKSEngineClient) DeleteSecurityRule(ctx context.Context, resourceGroup, nsgName, ruleName string) error {
+	if mc.FailDeleteSecurityRule {
+		return errors.New("DeleteSecurityRule failed")
+	}
+	return nil
+}
+
+//ListLoadBalancers mock
+func (mc *MockAKSEngineClient) ListLoadBalancers(ctx context.Context, resourceGroup string) ([]network.LoadBalancer, error) {
+	if mc.FailListLoadBalancers {
+		return nil, errors.New("ListLoadBalancers failed")
+	}
+	return mc.FakeLoadBalancers, nil
+}
+
+//GetVirtualNetwork mock
+func (mc *MockAKSEngineClient) GetVirtualNetwork(ctx context.Context, resourceGroup, vnetName string) (network.VirtualNetwork, error) {
+	if mc.FailGetVirtualNetwork {
+		return network.VirtualNetwork{}, errors.New("GetVirtualNetwork failed")
+	}
+
+	prefixes := mc.FakeVirtualNetworkAddressPrefixes
+	subnets := mc.FakeVirtualNetworkSubnets
+	return network.VirtualNetwork{
+		Name: to.StringPtr(vnetName),
+		VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+			AddressSpace: &network.AddressSpace{
+				AddressPrefixes: &prefixes,
+			},
+			Subnets: &subnets,
+		},
+	}, nil
+}
+
+const mockServicePrincipalObjectID = "sp-object-id"
+
 var validOSDiskResourceName = "https://00k71r4u927seqiagnt0.blob.core.windows.net/osdisk/k8s-agentpool1-12345678-0-osdisk.vhd"
 var validNicResourceName = "/subscriptions/DEC923E3-1EF1-4745-9516-37906D56DEC4/resourceGroups/acsK8sTest/providers/Microsoft.Network/networkInterfaces/k8s-agent-12345678-nic-0"
 
@@ -848,6 +1062,31 @@ func (mc *MockAKSEngineClient) DeleteApp(ctx context.Context, appName, applicati
 	return response, nil
 }
 
+// ValidateServicePrincipalSecret mock
+func (mc *MockAKSEngineClient) ValidateServicePrincipalSecret(ctx context.Context, clientID, secret string) error {
+	if mc.FailValidateServicePrincipalSecret {
+		return errors.New("AADSTS7000222: The provided client secret keys are expired")
+	}
+	return nil
+}
+
+// GetServicePrincipalPasswordCredentials mock
+func (mc *MockAKSEngineClient) GetServicePrincipalPasswordCredentials(ctx context.Context, clientID string) (string, []graphrbac.PasswordCredential, error) {
+	if mc.FailGetServicePrincipalCredentials {
+		return "", nil, errors.New("GetServicePrincipalPasswordCredentials failed")
+	}
+	endDate := mc.FakeServicePrincipalSecretEndDate
+	if endDate.IsZero() {
+		endDate = time.Now().AddDate(1, 0, 0)
+	}
+	return mockServicePrincipalObjectID, []graphrbac.PasswordCredential{
+		{
+			KeyID:   to.StringPtr("key-id"),
+			EndDate: &date.Time{Time: endDate},
+		},
+	}, nil
+}
+
 // User Assigned MSI
 
 //CreateUserAssignedID - Creates a user assigned msi.
@@ -986,7 +1225,21 @@ func (mc *MockAKSEngineClient) ListRoleAssignmentsForPrincipal(ctx context.Conte
 		roleAssignments = append(roleAssignments, assignment)
 	}
 
+	if principalID == mockServicePrincipalObjectID && !mc.MissingServicePrincipalRoleAssignments {
+		roleAssignments = append(roleAssignments, authorization.RoleAssignment{
+			ID: to.StringPtr("sp-role-assignment-id"),
+			Properties: &authorization.RoleAssignmentPropertiesWithScope{
+				Scope:            to.StringPtr(scope),
+				RoleDefinitionID: to.StringPtr(fmt.Sprintf(AADRoleReferenceTemplate, "sub-id", AADContributorRoleID)),
+				PrincipalID:      to.StringPtr(principalID),
+			},
+		})
+	}
+
 	return &MockRoleAssignmentListResultPage{
+		Fn: func(authorization.RoleAssignmentListResult) (authorization.RoleAssignmentListResult, error) {
+			return authorization.RoleAssignmentListResult{}, nil
+		},
 		Ralr: authorization.RoleAssignmentListResult{
 			Value: &roleAssignments,
 		},
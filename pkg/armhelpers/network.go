@@ -5,6 +5,8 @@ package armhelpers
 
 import (
 	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
 )
 
 // DeleteNetworkInterface deletes the specified network interface.
@@ -21,3 +23,70 @@ func (az *AzureClient) DeleteNetworkInterface(ctx context.Context, resourceGroup
 	_, err = future.Result(az.interfacesClient)
 	return err
 }
+
+// GetVirtualNetwork retrieves the specified virtual network.
+func (az *AzureClient) GetVirtualNetwork(ctx context.Context, resourceGroup, vnetName string) (network.VirtualNetwork, error) {
+	return az.virtualNetworksClient.Get(ctx, resourceGroup, vnetName, "")
+}
+
+// ListNetworkSecurityGroups lists the network security groups in the resource group
+func (az *AzureClient) ListNetworkSecurityGroups(ctx context.Context, resourceGroup string) ([]network.SecurityGroup, error) {
+	page, err := az.securityGroupsClient.List(ctx, resourceGroup)
+	if err != nil {
+		return nil, err
+	}
+	var nsgs []network.SecurityGroup
+	for page.NotDone() {
+		nsgs = append(nsgs, page.Values()...)
+		if err = page.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return nsgs, nil
+}
+
+// CreateOrUpdateSecurityRule creates or replaces the rule of the name of rule in a network security group
+func (az *AzureClient) CreateOrUpdateSecurityRule(ctx context.Context, resourceGroup, nsgName string, rule network.SecurityRule) error {
+	future, err := az.securityRulesClient.CreateOrUpdate(ctx, resourceGroup, nsgName, *rule.Name, rule)
+	if err != nil {
+		return err
+	}
+
+	if err = future.WaitForCompletionRef(ctx, az.securityRulesClient.Client); err != nil {
+		return err
+	}
+
+	_, err = future.Result(az.securityRulesClient)
+	return err
+}
+
+// DeleteSecurityRule deletes a rule of a network security group
+func (az *AzureClient) DeleteSecurityRule(ctx context.Context, resourceGroup, nsgName, ruleName string) error {
+	future, err := az.securityRulesClient.Delete(ctx, resourceGroup, nsgName, ruleName)
+	if err != nil {
+		return err
+	}
+
+	if err = future.WaitForCompletionRef(ctx, az.securityRulesClient.Client); err != nil {
+		return err
+	}
+
+	_, err = future.Result(az.securityRulesClient)
+	return err
+}
+
+// ListLoadBalancers lists the load balancers in the resource group
+func (az *AzureClient) ListLoadBalancers(ctx context.Context, resourceGroup string) ([]network.LoadBalancer, error) {
+	page, err := az.loadBalancersClient.List(ctx, resourceGroup)
+	if err != nil {
+		return nil, err
+	}
+	var lbs []network.LoadBalancer
+	for page.NotDone() {
+		lbs = append(lbs, page.Values()...)
+		if err = page.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return lbs, nil
+}
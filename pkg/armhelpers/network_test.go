@@ -6,6 +6,9 @@ package armhelpers
 import (
 	"context"
 	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
 )
 
 func TestDeleteNetworkInterface(t *testing.T) {
@@ -34,3 +37,48 @@ func TestDeleteNetworkInterface(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestSecurityRule(t *testing.T) {
+	mc, err := NewHTTPMockClient()
+	if err != nil {
+		t.Fatalf("failed to create HttpMockClient - %s", err)
+	}
+
+	mc.RegisterLogin()
+	mc.RegisterSecurityRule()
+
+	err = mc.Activate()
+	if err != nil {
+		t.Fatalf("failed to activate HttpMockClient - %s", err)
+	}
+	defer mc.DeactivateAndReset()
+
+	env := mc.GetEnvironment()
+	azureClient, err := NewAzureClientWithClientSecret(env, subscriptionID, "clientID", "secret")
+	if err != nil {
+		t.Fatalf("can not get client %s", err)
+	}
+
+	rule := network.SecurityRule{
+		Name: to.StringPtr(securityRuleName),
+		SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+			Access:    network.SecurityRuleAccessDeny,
+			Direction: network.SecurityRuleDirectionInbound,
+			Priority:  to.Int32Ptr(100),
+		},
+	}
+	err = azureClient.CreateOrUpdateSecurityRule(context.Background(), resourceGroup, networkSecurityGroupName, rule)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = azureClient.DeleteSecurityRule(context.Background(), resourceGroup, networkSecurityGroupName, securityRuleName)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = azureClient.DeleteSecurityRule(context.Background(), resourceGroup, networkSecurityGroupName, "otherRule")
+	if err == nil {
+		t.Error("expected the deletion of a rule that does not exist to fail")
+	}
+}
@@ -6,6 +6,7 @@ package armhelpers
 import (
 	"bytes"
 	"context"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2018-02-01/storage"
 	azStorage "github.com/Azure/azure-sdk-for-go/storage"
@@ -69,6 +70,42 @@ func (as *AzureStorageClient) SaveBlockBlob(containerName, blobName string, b []
 	return blobRef.CreateBlockBlobFromReader(bytes.NewReader(b), options)
 }
 
+// PutBlock uploads a block of a block blob, which is not part of the blob until it is committed by PutBlockList
+func (as *AzureStorageClient) PutBlock(containerName, blobName, blockID string, chunk []byte) error {
+	containerRef := getContainerRef(as.client, containerName)
+	blobRef := containerRef.GetBlobReference(blobName)
+
+	return blobRef.PutBlock(blockID, chunk, nil)
+}
+
+// PutBlockList commits the uploaded blocks of a block blob, in order, as its content
+func (as *AzureStorageClient) PutBlockList(containerName, blobName string, blockIDs []string) error {
+	containerRef := getContainerRef(as.client, containerName)
+	blobRef := containerRef.GetBlobReference(blobName)
+
+	blocks := make([]azStorage.Block, len(blockIDs))
+	for i, id := range blockIDs {
+		blocks[i] = azStorage.Block{ID: id, Status: azStorage.BlockStatusUncommitted}
+	}
+	return blobRef.PutBlockList(blocks, nil)
+}
+
+// GetBlobSASURI returns a URI granting read access to the specified blob until the expiry time
+func (as *AzureStorageClient) GetBlobSASURI(containerName, blobName string, expiry time.Time) (string, error) {
+	containerRef := getContainerRef(as.client, containerName)
+	blobRef := containerRef.GetBlobReference(blobName)
+
+	return blobRef.GetSASURI(azStorage.BlobSASOptions{
+		BlobServiceSASPermissions: azStorage.BlobServiceSASPermissions{
+			Read: true,
+		},
+		SASOptions: azStorage.SASOptions{
+			Expiry:   expiry,
+			UseHTTPS: true,
+		},
+	})
+}
+
 func getContainerRef(client *azStorage.Client, containerName string) *azStorage.Container {
 	bs := client.GetBlobService()
 	return bs.GetContainerReference(containerName)
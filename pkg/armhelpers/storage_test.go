@@ -5,6 +5,7 @@ package armhelpers
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/Azure/aks-engine/pkg/test"
 	. "github.com/onsi/ginkgo"
@@ -52,3 +53,39 @@ var _ = Describe("SaveBlockBlob Test", func() {
 		Expect(err).NotTo(BeNil())
 	})
 })
+
+var _ = Describe("PutBlockList Test", func() {
+	It("Should commit the uploaded blocks in order", func() {
+		client := MockStorageClient{}
+		Expect(client.PutBlock("fakeContainerName", "fakeBlobName", "MQ==", []byte("en"))).To(BeNil())
+		Expect(client.PutBlock("fakeContainerName", "fakeBlobName", "Mg==", []byte("tity"))).To(BeNil())
+		err := client.PutBlockList("fakeContainerName", "fakeBlobName", []string{"MQ==", "Mg=="})
+		Expect(err).To(BeNil())
+		Expect(string(client.Blobs["fakeContainerName/fakeBlobName"])).To(Equal("entity"))
+	})
+
+	It("Should return error when a block was not uploaded", func() {
+		client := MockStorageClient{}
+		err := client.PutBlockList("fakeContainerName", "fakeBlobName", []string{"MQ=="})
+		Expect(err).NotTo(BeNil())
+	})
+})
+
+var _ = Describe("GetBlobSASURI Test", func() {
+	It("Should return a read-only URI expiring at the expiry time", func() {
+		client := MockStorageClient{}
+		expiry := time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC)
+		uri, err := client.GetBlobSASURI("fakeContainerName", "fakeBlobName", expiry)
+		Expect(err).To(BeNil())
+		Expect(uri).To(ContainSubstring("/fakeContainerName/fakeBlobName?"))
+		Expect(uri).To(ContainSubstring("se=2019-03-01T00:00:00Z"))
+	})
+
+	It("Should return error when the SAS URI generation failed", func() {
+		client := MockStorageClient{
+			FailGetBlobSASURI: true,
+		}
+		_, err := client.GetBlobSASURI("fakeContainerName", "fakeBlobName", time.Now())
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,141 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package armhelpers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// whatIfAPIVersion is the first version of the deployments API with the What-If operation,
+// which the vendored resources SDK predates
+const whatIfAPIVersion = "2019-07-01"
+
+// WhatIfChangeType is the change a deployment makes to a resource
+type WhatIfChangeType string
+
+const (
+	// WhatIfChangeTypeCreate means the resource does not exist and the deployment creates it
+	WhatIfChangeTypeCreate WhatIfChangeType = "Create"
+	// WhatIfChangeTypeDelete means the resource exists and the deployment deletes it
+	WhatIfChangeTypeDelete WhatIfChangeType = "Delete"
+	// WhatIfChangeTypeIgnore means the resource exists, is not in the template and the deployment leaves it alone
+	WhatIfChangeTypeIgnore WhatIfChangeType = "Ignore"
+	// WhatIfChangeTypeDeploy means the resource exists and is redeployed, its changes cannot be predicted
+	WhatIfChangeTypeDeploy WhatIfChangeType = "Deploy"
+	// WhatIfChangeTypeNoChange means the resource exists and the deployment does not change it
+	WhatIfChangeTypeNoChange WhatIfChangeType = "NoChange"
+	// WhatIfChangeTypeModify means the resource exists and the deployment changes some of its properties
+	WhatIfChangeTypeModify WhatIfChangeType = "Modify"
+)
+
+// WhatIfPropertyChangeType is the change a deployment makes to a property of a resource
+type WhatIfPropertyChangeType string
+
+const (
+	// WhatIfPropertyChangeTypeCreate means the property is added
+	WhatIfPropertyChangeTypeCreate WhatIfPropertyChangeType = "Create"
+	// WhatIfPropertyChangeTypeDelete means the property is removed
+	WhatIfPropertyChangeTypeDelete WhatIfPropertyChangeType = "Delete"
+	// WhatIfPropertyChangeTypeModify means the value of the property changes
+	WhatIfPropertyChangeTypeModify WhatIfPropertyChangeType = "Modify"
+	// WhatIfPropertyChangeTypeArray means the items of the array property change, as described by the children
+	WhatIfPropertyChangeTypeArray WhatIfPropertyChangeType = "Array"
+)
+
+// WhatIfPropertyChange is a property of a resource the deployment changes
+type WhatIfPropertyChange struct {
+	Path               string                   `json:"path"`
+	PropertyChangeType WhatIfPropertyChangeType `json:"propertyChangeType"`
+	Before             interface{}              `json:"before,omitempty"`
+	After              interface{}              `json:"after,omitempty"`
+	Children           []WhatIfPropertyChange   `json:"children,omitempty"`
+}
+
+// WhatIfChange is a resource the deployment changes
+type WhatIfChange struct {
+	ResourceID string                 `json:"resourceId"`
+	ChangeType WhatIfChangeType       `json:"changeType"`
+	Before     interface{}            `json:"before,omitempty"`
+	After      interface{}            `json:"after,omitempty"`
+	Delta      []WhatIfPropertyChange `json:"delta,omitempty"`
+}
+
+// WhatIfOperationProperties are the changes predicted by a What-If operation
+type WhatIfOperationProperties struct {
+	Changes []WhatIfChange `json:"changes,omitempty"`
+}
+
+// WhatIfOperationResult is the result of a What-If operation
+type WhatIfOperationResult struct {
+	Status     string                     `json:"status,omitempty"`
+	Properties *WhatIfOperationProperties `json:"properties,omitempty"`
+	Error      *azure.ServiceError        `json:"error,omitempty"`
+}
+
+// WhatIfDeployment returns the changes deploying the template would make to the resource group, without deploying it
+func (az *AzureClient) WhatIfDeployment(ctx context.Context, resourceGroupName, deploymentName string, template, parameters map[string]interface{}) (result WhatIfOperationResult, err error) {
+	deployment := resources.Deployment{
+		Properties: &resources.DeploymentProperties{
+			Template:   &template,
+			Parameters: &parameters,
+			Mode:       resources.Incremental,
+		},
+	}
+	pathParameters := map[string]interface{}{
+		"deploymentName":    autorest.Encode("path", deploymentName),
+		"resourceGroupName": autorest.Encode("path", resourceGroupName),
+		"subscriptionId":    autorest.Encode("path", az.deploymentsClient.SubscriptionID),
+	}
+	queryParameters := map[string]interface{}{
+		"api-version": whatIfAPIVersion,
+	}
+	req, err := autorest.Prepare((&http.Request{}).WithContext(ctx),
+		autorest.AsContentType("application/json; charset=utf-8"),
+		autorest.AsPost(),
+		autorest.WithBaseURL(az.deploymentsClient.BaseURI),
+		autorest.WithPathParameters("/subscriptions/{subscriptionId}/resourcegroups/{resourceGroupName}/providers/Microsoft.Resources/deployments/{deploymentName}/whatIf", pathParameters),
+		autorest.WithJSON(deployment),
+		autorest.WithQueryParameters(queryParameters))
+	if err != nil {
+		return result, errors.Wrap(err, "preparing the What-If request")
+	}
+
+	log.Infof("Starting ARM What-If of deployment %s in resource group %s...", deploymentName, resourceGroupName)
+	resp, err := autorest.SendWithSender(az.deploymentsClient, req, azure.DoRetryWithRegistration(az.deploymentsClient.Client))
+	if err != nil {
+		return result, errors.Wrap(err, "sending the What-If request")
+	}
+	future, err := azure.NewFutureFromResponse(resp)
+	if err != nil {
+		return result, errors.Wrap(err, "starting the What-If operation")
+	}
+	if err = future.WaitForCompletionRef(ctx, az.deploymentsClient.Client); err != nil {
+		return result, errors.Wrap(err, "waiting for the What-If operation")
+	}
+
+	sender := autorest.DecorateSender(az.deploymentsClient, autorest.DoRetryForStatusCodes(az.deploymentsClient.RetryAttempts, az.deploymentsClient.RetryDuration, autorest.StatusCodesForRetry...))
+	if resp, err = future.GetResult(sender); err != nil {
+		return result, errors.Wrap(err, "getting the What-If result")
+	}
+	err = autorest.Respond(
+		resp,
+		az.deploymentsClient.ByInspecting(),
+		azure.WithErrorUnlessStatusCode(http.StatusOK),
+		autorest.ByUnmarshallingJSON(&result),
+		autorest.ByClosing())
+	if err != nil {
+		return result, err
+	}
+	if result.Error != nil {
+		return result, errors.Errorf("What-If of deployment %s failed: %s", deploymentName, result.Error.Message)
+	}
+	return result, nil
+}
@@ -0,0 +1,254 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/pkg/errors"
+)
+
+// AirGapAssetsFileName is the name of the file listing what the mirrors of an air-gapped cluster must serve
+const AirGapAssetsFileName = "airgap-assets.json"
+
+// imgDownloadURL is where installImg of cse_install.sh downloads img from
+const imgDownloadURL = "https://acs-mirror.azureedge.net/img/img-linux-amd64-v0.5.6"
+
+// airGapPublicHosts are the public endpoints the nodes download their binaries, packages and images from,
+// which the nodes of an air-gapped cluster cannot reach
+var airGapPublicHosts = []string{
+	"acs-mirror.azureedge.net",
+	"kubernetesartifacts.azureedge.net",
+	"mirror.azk8s.cn",
+	"gcr.azk8s.cn",
+	"dockerhub.azk8s.cn",
+	"packages.microsoft.com",
+	"azure.archive.ubuntu.com",
+	"archive.ubuntu.com",
+	"security.ubuntu.com",
+	"storage.googleapis.com",
+	"nvidia.github.io",
+	"us.download.nvidia.com",
+	"download.01.org",
+	"download.opensuse.org",
+	"aksrepos.azurecr.io",
+	"mcr.microsoft.com",
+	"k8s.gcr.io",
+	"gcr.io",
+	"quay.io",
+	"registry-1.docker.io",
+	"docker.io",
+}
+
+var (
+	airGapPublicHostsPattern = func() string {
+		hosts := make([]string, len(airGapPublicHosts))
+		for i, host := range airGapPublicHosts {
+			hosts[i] = regexp.QuoteMeta(host)
+		}
+		return "(?:" + strings.Join(hosts, "|") + ")"
+	}()
+	// airGapPublicURLRegex matches the URLs of the public endpoints, e.g. https://acs-mirror.azureedge.net/cni/cni-plugins.tgz
+	airGapPublicURLRegex = regexp.MustCompile(`https?://(` + airGapPublicHostsPattern + `)((?:/[^\s"'<>\\]*)?)`)
+	// airGapPublicEndpointRegex matches the URLs of the public endpoints and the images of their registries,
+	// e.g. k8s.gcr.io/pause:3.1, but not the paths of a mirror named after them
+	airGapPublicEndpointRegex = regexp.MustCompile(`(?m)(https?://` + airGapPublicHostsPattern + `\b)|(?:^|[^\w./-])(` + airGapPublicHostsPattern + `/[^\s"'<>\\]*)`)
+	// gzipBlobRegex matches the base64 encoded gzip data embedded in a template, e.g. the cloud-init files
+	gzipBlobRegex = regexp.MustCompile(`H4sI[A-Za-z0-9+/]+=*`)
+	// aptPackagesRegex matches the packages installDeps of cse_install.sh installs
+	aptPackagesRegex = regexp.MustCompile(`for apt_package in ([^;]+); do`)
+)
+
+// AirGapAssets are what the mirrors of an air-gapped cluster must serve for its nodes to install
+type AirGapAssets struct {
+	VHDs     []AirGapVHD      `json:"vhds"`
+	Binaries []AirGapBinary   `json:"binaries"`
+	Images   []ContainerImage `json:"images"`
+	Packages []string         `json:"packages"`
+}
+
+// AirGapVHD is an OS image the nodes of an air-gapped cluster boot from
+type AirGapVHD struct {
+	Distro    api.Distro          `json:"distro,omitempty"`
+	Publisher string              `json:"publisher,omitempty"`
+	Offer     string              `json:"offer,omitempty"`
+	SKU       string              `json:"sku,omitempty"`
+	Version   string              `json:"version,omitempty"`
+	ImageRef  *api.ImageReference `json:"imageReference,omitempty"`
+}
+
+// AirGapBinary is a binary the nodes of an air-gapped cluster download from the binaries mirror
+type AirGapBinary struct {
+	// Source is where the binary is downloaded from when the cluster is not air-gapped
+	Source string `json:"source"`
+	// URL is where the binaries mirror must serve it
+	URL string `json:"url"`
+}
+
+// airGapMirrorURL returns where the binaries mirror of an air-gapped cluster serves the URLs of the public endpoints
+// in s, https://<host>/<path> is served as <binariesURL>/<host>/<path>
+func airGapMirrorURL(s string, profile *api.AirGapProfile) string {
+	binariesURL := strings.TrimSuffix(profile.BinariesURL, "/")
+	return airGapPublicURLRegex.ReplaceAllStringFunc(s, func(u string) string {
+		m := airGapPublicURLRegex.FindStringSubmatch(u)
+		return binariesURL + "/" + m[1] + m[2]
+	})
+}
+
+// gunzipBase64 returns the data of a base64 encoded gzip string
+func gunzipBase64(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	return string(data), err
+}
+
+// rewriteAirGapCloudInitFiles rewrites the URLs of the public endpoints in the cloud-init files of an air-gapped cluster
+// to its binaries mirror, they are only reachable by the code paths of the features it does not support
+func rewriteAirGapCloudInitFiles(cloudInitFiles map[string]interface{}, profile *api.AirGapProfile) {
+	for name, file := range cloudInitFiles {
+		b64GzipString, ok := file.(string)
+		if !ok {
+			continue
+		}
+		data, err := gunzipBase64(b64GzipString)
+		if err != nil {
+			// this should never happen and this is a bug
+			panic(errors.Wrapf(err, "BUG: decoding cloud-init file %s", name))
+		}
+		cloudInitFiles[name] = getBase64EncodedGzippedCustomScriptFromStr(airGapMirrorURL(data, profile))
+	}
+}
+
+// validateAirGappedTemplate checks that the template and parameters of an air-gapped cluster, and the files embedded
+// in them, reference none of the public endpoints and pull the container images from the imageRegistry only
+func validateAirGappedTemplate(cs *api.ContainerService, template, parameters string) error {
+	texts := []string{template, parameters}
+	for i := 0; i < len(texts); i++ {
+		for _, blob := range gzipBlobRegex.FindAllString(texts[i], -1) {
+			if data, err := gunzipBase64(blob); err == nil {
+				texts = append(texts, data)
+			}
+		}
+	}
+
+	registry := strings.TrimSuffix(cs.Properties.OrchestratorProfile.KubernetesConfig.ImageRegistry, "/")
+	endpoints := map[string]bool{}
+	images := map[string]bool{}
+	for _, text := range texts {
+		for _, m := range airGapPublicEndpointRegex.FindAllStringSubmatch(text, -1) {
+			endpoints[m[1]+m[2]] = true
+		}
+		for _, image := range getManifestImages(text) {
+			if !strings.HasPrefix(image, registry+"/") && !strings.Contains(image, "{{") {
+				images[image] = true
+			}
+		}
+	}
+	if len(endpoints) > 0 {
+		return errors.Errorf("the template of the air-gapped cluster references the public endpoints %s", strings.Join(sortedKeys(endpoints), ", "))
+	}
+	if len(images) > 0 {
+		return errors.Errorf("the air-gapped cluster pulls the images %s, which are not in its imageRegistry %s", strings.Join(sortedKeys(images), ", "), registry)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetAirGapAssets returns the VHDs, binaries, container images and apt packages of an air-gapped cluster, for its
+// mirrors and imageRegistry to be populated before the cluster is deployed
+func GetAirGapAssets(cs *api.ContainerService) (*AirGapAssets, error) {
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+	if !k.IsAirGapped() {
+		return nil, errors.New("the cluster is not air-gapped")
+	}
+	cloudSpecConfig := cs.GetCloudSpecConfig()
+	assets := &AirGapAssets{}
+
+	addVHD := func(distro api.Distro, imageRef *api.ImageReference) {
+		vhd := AirGapVHD{ImageRef: imageRef}
+		if imageRef == nil {
+			config := cloudSpecConfig.OSImageConfig[distro]
+			vhd = AirGapVHD{Distro: distro, Publisher: config.ImagePublisher, Offer: config.ImageOffer, SKU: config.ImageSku, Version: config.ImageVersion}
+		}
+		for _, v := range assets.VHDs {
+			if reflect.DeepEqual(v, vhd) {
+				return
+			}
+		}
+		assets.VHDs = append(assets.VHDs, vhd)
+	}
+	if cs.Properties.MasterProfile != nil {
+		addVHD(cs.Properties.MasterProfile.Distro, cs.Properties.MasterProfile.ImageRef)
+	}
+	for _, agentPool := range cs.Properties.AgentPoolProfiles {
+		addVHD(agentPool.Distro, agentPool.ImageRef)
+	}
+
+	var binaries []string
+	if cs.Properties.MasterProfile != nil && !cs.Properties.MasterProfile.HasCosmosEtcd() {
+		binaries = append(binaries, cloudSpecConfig.KubernetesSpecConfig.EtcdDownloadURLBase+"/etcd-v"+k.EtcdVersion+"-linux-amd64.tar.gz")
+	}
+	binaries = append(binaries, cloudSpecConfig.KubernetesSpecConfig.CNIPluginsDownloadURL)
+	if cs.Properties.OrchestratorProfile.IsAzureCNI() {
+		binaries = append(binaries, k.GetAzureCNIURLLinux(cloudSpecConfig))
+	}
+	if k.ContainerRuntime != api.Docker {
+		binaries = append(binaries,
+			cloudSpecConfig.KubernetesSpecConfig.ContainerdDownloadURLBase+"cri-containerd-"+k.ContainerdVersion+".linux-amd64.tar.gz",
+			imgDownloadURL)
+	}
+	for _, binary := range binaries {
+		assets.Binaries = append(assets.Binaries, AirGapBinary{Source: binary, URL: airGapMirrorURL(binary, k.AirGapProfile)})
+	}
+
+	images, err := GetImages(cs)
+	if err != nil {
+		return nil, err
+	}
+	assets.Images = images
+
+	installScript, err := Asset(kubernetesCSEInstall)
+	if err != nil {
+		// this should never happen and this is a bug
+		panic(errors.Wrap(err, "BUG"))
+	}
+	if m := aptPackagesRegex.FindStringSubmatch(string(installScript)); m != nil {
+		assets.Packages = strings.Fields(m[1])
+	}
+	if k.ContainerRuntime == api.Docker {
+		// as installMoby of cse_install.sh does
+		mobyCLI := k.MobyVersion
+		if mobyCLI == "3.0.4" {
+			mobyCLI = "3.0.3"
+		}
+		assets.Packages = append(assets.Packages, "moby-engine="+k.MobyVersion, "moby-cli="+mobyCLI)
+	}
+	sort.Strings(assets.Packages)
+
+	return assets, nil
+}
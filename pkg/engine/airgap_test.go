@@ -0,0 +1,178 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+func getMockAirGappedContainerService(t *testing.T) *api.ContainerService {
+	cs := api.CreateMockContainerService("testcluster", "1.16.1", 3, 2, false)
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+	k.ImageRegistry = "contoso.azurecr.io"
+	k.AirGapProfile = &api.AirGapProfile{
+		Enabled:     to.BoolPtr(true),
+		BinariesURL: "https://mirror.blob.core.windows.net/binaries/",
+		PackagesURL: "http://10.0.0.4/apt",
+	}
+	if _, err := cs.SetPropertiesDefaults(false, false); err != nil {
+		t.Fatal(err)
+	}
+	return cs
+}
+
+func TestAirGapMirrorURL(t *testing.T) {
+	profile := &api.AirGapProfile{BinariesURL: "https://mirror.blob.core.windows.net/binaries/"}
+	cases := []struct {
+		s        string
+		expected string
+	}{
+		{s: "https://acs-mirror.azureedge.net/cni/cni-plugins-amd64-v0.7.5.tgz", expected: "https://mirror.blob.core.windows.net/binaries/acs-mirror.azureedge.net/cni/cni-plugins-amd64-v0.7.5.tgz"},
+		{s: "https://storage.googleapis.com/cri-containerd-release/", expected: "https://mirror.blob.core.windows.net/binaries/storage.googleapis.com/cri-containerd-release/"},
+		{s: "curl -fsSL https://packages.microsoft.com/keys/microsoft.asc | gpg", expected: "curl -fsSL https://mirror.blob.core.windows.net/binaries/packages.microsoft.com/keys/microsoft.asc | gpg"},
+		{s: "https://contoso.blob.core.windows.net/cni/cni-plugins.tgz", expected: "https://contoso.blob.core.windows.net/cni/cni-plugins.tgz"},
+		{s: "# https://bugs.launchpad.net/ubuntu/+source/linux/+bug/1676635", expected: "# https://bugs.launchpad.net/ubuntu/+source/linux/+bug/1676635"},
+	}
+
+	for _, c := range cases {
+		if actual := airGapMirrorURL(c.s, profile); actual != c.expected {
+			t.Errorf("expected %s to be served from the mirror as %s, instead got %s", c.s, c.expected, actual)
+		}
+	}
+}
+
+func TestRewriteAirGapCloudInitFiles(t *testing.T) {
+	profile := &api.AirGapProfile{BinariesURL: "https://mirror.blob.core.windows.net/binaries"}
+	cloudInitFiles := map[string]interface{}{
+		"provisionInstalls": getBase64EncodedGzippedCustomScriptFromStr(`installImg() {
+    retrycmd_get_executable 120 5 /usr/local/bin/img "https://acs-mirror.azureedge.net/img/img-linux-amd64-v0.5.6" ls
+}
+`),
+	}
+	rewriteAirGapCloudInitFiles(cloudInitFiles, profile)
+
+	data, err := gunzipBase64(cloudInitFiles["provisionInstalls"].(string))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `installImg() {
+    retrycmd_get_executable 120 5 /usr/local/bin/img "https://mirror.blob.core.windows.net/binaries/acs-mirror.azureedge.net/img/img-linux-amd64-v0.5.6" ls
+}
+`
+	if data != expected {
+		t.Errorf("expected the cloud-init file to be:\n%s\ninstead got:\n%s", expected, data)
+	}
+}
+
+func TestValidateAirGappedTemplate(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				KubernetesConfig: &api.KubernetesConfig{ImageRegistry: "contoso.azurecr.io"},
+			},
+		},
+	}
+	manifest := func(image string) string {
+		return getBase64EncodedGzippedCustomScriptFromStr("spec:\n  containers:\n  - image: " + image + "\n")
+	}
+	cases := []struct {
+		name        string
+		template    string
+		parameters  string
+		expectedErr string
+	}{
+		{
+			name:       "mirrors only",
+			template:   `{"cniPluginsURL": "https://mirror.blob.core.windows.net/binaries/acs-mirror.azureedge.net/cni/cni-plugins.tgz", "addon": "` + manifest("contoso.azurecr.io/coredns:1.6.6") + `"}`,
+			parameters: `{"kubernetesHyperkubeSpec": {"value": "contoso.azurecr.io/hyperkube-amd64:v1.16.1"}}`,
+		},
+		{
+			name:        "public URL",
+			template:    `{"cniPluginsURL": "https://acs-mirror.azureedge.net/cni/cni-plugins.tgz"}`,
+			expectedErr: "the template of the air-gapped cluster references the public endpoints https://acs-mirror.azureedge.net",
+		},
+		{
+			name:        "public image",
+			parameters:  `{"kubernetesHyperkubeSpec": {"value": "k8s.gcr.io/hyperkube-amd64:v1.16.1"}}`,
+			expectedErr: "the template of the air-gapped cluster references the public endpoints k8s.gcr.io/hyperkube-amd64:v1.16.1",
+		},
+		{
+			name:        "embedded public URL",
+			template:    `{"provisionInstalls": "` + getBase64EncodedGzippedCustomScriptFromStr("curl https://packages.microsoft.com/keys/microsoft.asc\n") + `"}`,
+			expectedErr: "the template of the air-gapped cluster references the public endpoints https://packages.microsoft.com",
+		},
+		{
+			name:        "embedded image of another registry",
+			template:    `{"addon": "` + manifest("nginx:1.17") + `"}`,
+			expectedErr: "the air-gapped cluster pulls the images nginx:1.17, which are not in its imageRegistry contoso.azurecr.io",
+		},
+	}
+
+	for _, c := range cases {
+		err := validateAirGappedTemplate(cs, c.template, c.parameters)
+		if c.expectedErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", c.name, err)
+			}
+		} else if err == nil || err.Error() != c.expectedErr {
+			t.Errorf("%s: expected error %q, instead got %v", c.name, c.expectedErr, err)
+		}
+	}
+}
+
+func TestGetAirGapAssets(t *testing.T) {
+	cs := getMockAirGappedContainerService(t)
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+
+	assets, err := GetAirGapAssets(cs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(assets.VHDs) != 1 || assets.VHDs[0].Distro != cs.Properties.MasterProfile.Distro {
+		t.Errorf("expected the VHD of the %s distro, instead got %v", cs.Properties.MasterProfile.Distro, assets.VHDs)
+	}
+	for _, binary := range assets.Binaries {
+		if !strings.HasPrefix(binary.URL, "https://mirror.blob.core.windows.net/binaries/") {
+			t.Errorf("expected %s to be served from the binaries mirror, instead got %s", binary.Source, binary.URL)
+		}
+	}
+	if len(assets.Images) == 0 {
+		t.Errorf("expected the images of the cluster")
+	}
+	packages := strings.Join(assets.Packages, " ")
+	for _, expected := range []string{"conntrack", "ipset", "moby-engine=" + k.MobyVersion} {
+		if !strings.Contains(packages, expected) {
+			t.Errorf("expected the packages to have %s, instead got %v", expected, assets.Packages)
+		}
+	}
+
+	k.AirGapProfile.Enabled = to.BoolPtr(false)
+	if _, err = GetAirGapAssets(cs); err == nil || err.Error() != "the cluster is not air-gapped" {
+		t.Errorf("expected an error for a cluster that is not air-gapped, instead got %v", err)
+	}
+}
+
+func TestGenerateAirGappedTemplate(t *testing.T) {
+	tg, err := InitializeTemplateGenerator(Context{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cs := getMockAirGappedContainerService(t)
+	if _, _, err = tg.GenerateTemplateV2(cs, DefaultGeneratorCode, TestAKSEngineVersion); err != nil {
+		t.Fatalf("unexpected error generating the template of the air-gapped cluster: %v", err)
+	}
+
+	cs.Properties.OrchestratorProfile.KubernetesConfig.CustomManifests = []api.CustomManifest{
+		{Name: "app", Data: base64.StdEncoding.EncodeToString([]byte("kind: Pod\nspec:\n  containers:\n  - image: quay.io/contoso/app:v1\n"))},
+	}
+	if _, _, err = tg.GenerateTemplateV2(cs, DefaultGeneratorCode, TestAKSEngineVersion); err == nil || !strings.Contains(err.Error(), "quay.io/contoso/app:v1") {
+		t.Errorf("expected an error for the public image of the custom manifest, instead got %v", err)
+	}
+}
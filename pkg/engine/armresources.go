@@ -34,16 +34,28 @@ func GenerateARMResources(cs *api.ContainerService) []interface{} {
 		armResources = append(armResources, userAssignedID, msiRoleAssignment)
 	}
 
+	if cs.Properties.CreatesProximityPlacementGroup() {
+		armResources = append(armResources, createProximityPlacementGroup())
+	}
+
 	if !cs.Properties.OrchestratorProfile.IsPrivateCluster() &&
 		!isHostedMaster &&
 		!cs.Properties.AnyAgentHasLoadBalancerBackendAddressPoolIDs() &&
-		cs.Properties.OrchestratorProfile.KubernetesConfig.LoadBalancerSku == api.StandardLoadBalancerSku {
+		cs.Properties.OrchestratorProfile.KubernetesConfig.HasOutboundLoadBalancer() {
 		isForMaster := false
-		publicIPAddress := CreatePublicIPAddress(isForMaster)
+		publicIPAddress := CreatePublicIPAddress(isForMaster, cs.Properties.GetAvailabilityZones())
 		loadBalancer := CreateAgentLoadBalancer(cs.Properties, true)
 		armResources = append(armResources, publicIPAddress, loadBalancer)
 	}
 
+	if !isHostedMaster && cs.Properties.OrchestratorProfile.KubernetesConfig.HasNATGateway() {
+		armResources = append(armResources, createNATGatewayPublicIPAddress(), createNATGateway(cs.Properties))
+	}
+
+	if !isHostedMaster && cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateLinkEnabled() {
+		armResources = append(armResources, createAPIServerPrivateLinkResources(cs)...)
+	}
+
 	profiles := cs.Properties.AgentPoolProfiles
 
 	for _, profile := range profiles {
@@ -55,6 +67,10 @@ func GenerateARMResources(cs *api.ContainerService) []interface{} {
 			}
 		}
 
+		if profile.HasUserAssignedID() {
+			armResources = append(armResources, createAgentPoolUserAssignedIdentity(profile), createAgentPoolIdentityOperatorRoleAssignment(cs.Properties, profile))
+		}
+
 		if profile.IsVirtualMachineScaleSets() {
 			if useManagedIdentity && !userAssignedIDEnabled {
 				armResources = append(armResources, createAgentVMSSSysRoleAssignment(profile))
@@ -110,10 +126,18 @@ func createKubernetesAgentVMASResources(cs *api.ContainerService, profile *api.A
 
 	agentVMASNIC := createAgentVMASNetworkInterface(cs, profile)
 	agentVMASResources = append(agentVMASResources, agentVMASNIC)
+	if profile.HasPodSubnet() {
+		agentVMASResources = append(agentVMASResources, createAgentVMASPodNetworkInterface(profile))
+	}
 
 	if profile.IsManagedDisks() {
 		agentAvSet := createAgentAvailabilitySets(profile)
 		agentVMASResources = append(agentVMASResources, agentAvSet)
+		if profile.HasSharedDisks() {
+			for _, disk := range createSharedDataDisks(profile) {
+				agentVMASResources = append(agentVMASResources, disk)
+			}
+		}
 	} else if profile.IsStorageAccount() {
 		agentStorageAccount := createAgentVMASStorageAccount(cs, profile, false)
 		agentVMASResources = append(agentVMASResources, agentStorageAccount)
@@ -134,6 +158,12 @@ func createKubernetesAgentVMASResources(cs *api.ContainerService, profile *api.A
 				Type:                      to.StringPtr("Microsoft.Compute/availabilitySets"),
 			},
 		}
+		if ppg, dependency := getProximityPlacementGroupReference(profile.ProximityPlacementGroupID, profile.CreateProximityPlacementGroup); ppg != nil {
+			avSet.ProximityPlacementGroup = ppg
+			if dependency != "" {
+				avSet.DependsOn = []string{dependency}
+			}
+		}
 
 		agentVMASResources = append(agentVMASResources, avSet)
 	}
@@ -152,6 +182,10 @@ func createKubernetesAgentVMASResources(cs *api.ContainerService, profile *api.A
 	agentVMASCSE := createAgentVMASCustomScriptExtension(cs, profile)
 	agentVMASResources = append(agentVMASResources, agentVMASCSE)
 
+	for _, ext := range createAgentVMASVMExtensions(profile) {
+		agentVMASResources = append(agentVMASResources, ext)
+	}
+
 	if cs.IsAKSBillingEnabled() {
 		agentVMASAKSBilling := CreateAgentVMASAKSBillingExtension(cs, profile)
 		agentVMASResources = append(agentVMASResources, agentVMASAKSBilling)
@@ -5,7 +5,9 @@ package engine
 
 import (
 	"encoding/json"
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/cosmos-db/mgmt/2015-04-08/documentdb"
@@ -47,6 +49,101 @@ type VirtualMachineARM struct {
 type VirtualMachineScaleSetARM struct {
 	ARMResource
 	compute.VirtualMachineScaleSet
+	// TerminateNotificationTimeout is how long, as an ISO 8601 duration, the deletion of a VM waits for its scheduled event to be started.
+	// The compute API of compute.VirtualMachineScaleSet predates the terminate notification profile.
+	TerminateNotificationTimeout string `json:"-"`
+	// SpotMaxPrice is the price in US dollars above which the Spot VMs of the scale set are evicted, -1 for the pay-as-you-go price.
+	// The compute API of compute.VirtualMachineScaleSet predates the billing profile.
+	SpotMaxPrice *float64 `json:"-"`
+	// HostGroupID is the ID of the host group whose dedicated hosts the VMs of the scale set are placed on.
+	// The compute API of compute.VirtualMachineScaleSet predates dedicated hosts.
+	HostGroupID string `json:"-"`
+	// DataDiskIOPSReadWrite and DataDiskMBpsReadWrite are the IOPS and throughput in MB/s of the Ultra SSD data disks of the scale set.
+	// The compute API of compute.VirtualMachineScaleSet predates setting them.
+	DataDiskIOPSReadWrite int `json:"-"`
+	DataDiskMBpsReadWrite int `json:"-"`
+}
+
+// MarshalJSON is the custom marshaler for a VirtualMachineScaleSetARM.
+// It adds the terminate notification profile to the VM profile of the scale set if it has a terminate notification timeout,
+// the billing profile if it has a Spot max price, the host group to the scale set properties if it has a host group, and
+// the IOPS and throughput of its Ultra SSD data disks.
+func (v VirtualMachineScaleSetARM) MarshalJSON() ([]byte, error) {
+	// alias the type to avoid infinite recursion in marshaling
+	type Alias VirtualMachineScaleSetARM
+	bytes, err := json.Marshal((Alias)(v))
+	if err != nil {
+		return nil, err
+	}
+
+	if v.TerminateNotificationTimeout == "" && v.SpotMaxPrice == nil && v.HostGroupID == "" && v.DataDiskIOPSReadWrite == 0 && v.DataDiskMBpsReadWrite == 0 {
+		return bytes, nil
+	}
+
+	s := string(bytes)
+	if v.TerminateNotificationTimeout != "" || v.SpotMaxPrice != nil {
+		// NOTE: this relies on the VM profile always having properties, e.g. its extension profile.
+		re := regexp.MustCompile(`"virtualMachineProfile" *: *{`)
+		profile := `"virtualMachineProfile":{`
+		if v.TerminateNotificationTimeout != "" {
+			profile += fmt.Sprintf(`"scheduledEventsProfile":{"terminateNotificationProfile":{"notBeforeTimeout":"%s","enable":true}},`, v.TerminateNotificationTimeout)
+		}
+		if v.SpotMaxPrice != nil {
+			profile += fmt.Sprintf(`"billingProfile":{"maxPrice":%s},`, strconv.FormatFloat(*v.SpotMaxPrice, 'f', -1, 64))
+		}
+		s = re.ReplaceAllLiteralString(s, profile)
+	}
+	if v.HostGroupID != "" {
+		id, err := json.Marshal(v.HostGroupID)
+		if err != nil {
+			return nil, err
+		}
+		// NOTE: this relies on the scale set properties being the first properties of the JSON, before the ones of its
+		// VM profile, and on the upgrade policy always being set.
+		s = strings.Replace(s, `"properties":{"upgradePolicy":`, `"properties":{"hostGroup":{"id":`+string(id)+`},"upgradePolicy":`, 1)
+	}
+	if v.DataDiskIOPSReadWrite != 0 || v.DataDiskMBpsReadWrite != 0 {
+		var disk string
+		if v.DataDiskIOPSReadWrite != 0 {
+			disk += fmt.Sprintf(`"diskIOPSReadWrite":%d,`, v.DataDiskIOPSReadWrite)
+		}
+		if v.DataDiskMBpsReadWrite != 0 {
+			disk += fmt.Sprintf(`"diskMBpsReadWrite":%d,`, v.DataDiskMBpsReadWrite)
+		}
+		// NOTE: this relies on the managed disk parameters of the Ultra SSD data disks being the last of their properties.
+		s = strings.Replace(s, `"managedDisk":{"storageAccountType":"UltraSSD_LRS"}`, disk+`"managedDisk":{"storageAccountType":"UltraSSD_LRS"}`, -1)
+	}
+
+	return []byte(s), nil
+}
+
+// DiskARM embeds the ARMResource type in compute.Disk.
+type DiskARM struct {
+	ARMResource
+	compute.Disk
+	// MaxShares is the number of VMs the disk can be attached to at the same time, 0 if it is not shared.
+	// The compute API of compute.Disk predates shared disks.
+	MaxShares int `json:"-"`
+}
+
+// MarshalJSON is the custom marshaler for a DiskARM.
+// It adds the max shares to the disk properties if the disk is shared.
+func (d DiskARM) MarshalJSON() ([]byte, error) {
+	// alias the type to avoid infinite recursion in marshaling
+	type Alias DiskARM
+	bytes, err := json.Marshal((Alias)(d))
+	if err != nil {
+		return nil, err
+	}
+
+	if d.MaxShares == 0 {
+		return bytes, nil
+	}
+
+	// NOTE: this relies on the disk always having properties, e.g. its creation data.
+	re := regexp.MustCompile(`"properties" *: *{`)
+	s := re.ReplaceAllLiteralString(string(bytes), fmt.Sprintf(`"properties":{"maxShares":%d,`, d.MaxShares))
+	return []byte(s), nil
 }
 
 // VirtualMachineExtensionARM embeds the ARMResource type in compute.VirtualMachineExtension.
@@ -107,6 +204,12 @@ func (a AvailabilitySetARM) MarshalJSON() ([]byte, error) {
 	return []byte(s), nil
 }
 
+// ProximityPlacementGroupARM embeds the ARMResource type in compute.ProximityPlacementGroup.
+type ProximityPlacementGroupARM struct {
+	ARMResource
+	compute.ProximityPlacementGroup
+}
+
 // StorageAccountARM embeds the ARMResource type in storage.Account.
 type StorageAccountARM struct {
 	ARMResource
@@ -123,6 +226,50 @@ type SystemRoleAssignmentARM struct {
 type VirtualNetworkARM struct {
 	ARMResource
 	network.VirtualNetwork
+	// SubnetProperties are properties of the subnets, by subnet name, that the network API of network.VirtualNetwork
+	// predates, such as NAT gateways and the network policies of private endpoints.
+	SubnetProperties map[string]map[string]interface{} `json:"-"`
+}
+
+// setSubnetProperty sets a property of the subnet name that network.Subnet has no field for.
+func (v *VirtualNetworkARM) setSubnetProperty(name, property string, value interface{}) {
+	if v.SubnetProperties == nil {
+		v.SubnetProperties = map[string]map[string]interface{}{}
+	}
+	if v.SubnetProperties[name] == nil {
+		v.SubnetProperties[name] = map[string]interface{}{}
+	}
+	v.SubnetProperties[name][property] = value
+}
+
+// MarshalJSON is the custom marshaler for a VirtualNetworkARM.
+// It adds the SubnetProperties to the properties of their subnets.
+func (v VirtualNetworkARM) MarshalJSON() ([]byte, error) {
+	// alias the type to avoid infinite recursion in marshaling
+	type Alias VirtualNetworkARM
+	bytes, err := json.Marshal((Alias)(v))
+	if err != nil || len(v.SubnetProperties) == 0 {
+		return bytes, err
+	}
+
+	var vnet map[string]interface{}
+	if err = json.Unmarshal(bytes, &vnet); err != nil {
+		return nil, err
+	}
+	properties, _ := vnet["properties"].(map[string]interface{})
+	subnets, _ := properties["subnets"].([]interface{})
+	for _, s := range subnets {
+		subnet, _ := s.(map[string]interface{})
+		name, _ := subnet["name"].(string)
+		subnetProperties, ok := subnet["properties"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for property, value := range v.SubnetProperties[name] {
+			subnetProperties[property] = value
+		}
+	}
+	return json.Marshal(vnet)
 }
 
 // NetworkSecurityGroupARM embeds the ARMResource type in network.SecurityGroup.
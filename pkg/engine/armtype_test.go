@@ -11,6 +11,7 @@ import (
 	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
 	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/gomega"
 )
 
@@ -155,3 +156,203 @@ func TestMarshalJSONAvailabilitySetARM(t *testing.T) {
 		g.Expect(string(output)).To(MatchJSON(vmasTestDatum.json))
 	}
 }
+
+func TestMarshalJSONVirtualMachineScaleSetARM(t *testing.T) {
+	vmss := VirtualMachineScaleSetARM{
+		ARMResource: ARMResource{
+			APIVersion: terminateNotificationAPIVersionCompute,
+		},
+		VirtualMachineScaleSet: compute.VirtualMachineScaleSet{
+			Name: to.StringPtr("[variables('agentpool1VMNamePrefix')]"),
+			VirtualMachineScaleSetProperties: &compute.VirtualMachineScaleSetProperties{
+				UpgradePolicy: &compute.UpgradePolicy{
+					Mode: compute.Manual,
+				},
+				VirtualMachineProfile: &compute.VirtualMachineScaleSetVMProfile{
+					ExtensionProfile: &compute.VirtualMachineScaleSetExtensionProfile{
+						Extensions: &[]compute.VirtualMachineScaleSetExtension{},
+					},
+				},
+			},
+		},
+	}
+
+	profile := func(vmss VirtualMachineScaleSetARM) map[string]interface{} {
+		b, err := json.Marshal(vmss)
+		if err != nil {
+			t.Fatalf("unexpected error marshaling the scale set: %s", err)
+		}
+		var m map[string]interface{}
+		if err = json.Unmarshal(b, &m); err != nil {
+			t.Fatalf("the scale set was not marshaled to valid JSON: %s\n%s", err, b)
+		}
+		return m["properties"].(map[string]interface{})["virtualMachineProfile"].(map[string]interface{})
+	}
+
+	if p := profile(vmss); p["scheduledEventsProfile"] != nil {
+		t.Errorf("expected no scheduled events profile without a terminate notification timeout, got %v", p["scheduledEventsProfile"])
+	}
+
+	vmss.TerminateNotificationTimeout = "PT10M"
+	p := profile(vmss)
+	expected := map[string]interface{}{
+		"terminateNotificationProfile": map[string]interface{}{
+			"notBeforeTimeout": "PT10M",
+			"enable":           true,
+		},
+	}
+	if diff := cmp.Diff(p["scheduledEventsProfile"], expected); diff != "" {
+		t.Errorf("unexpected scheduled events profile: %s", diff)
+	}
+	if p["extensionProfile"] == nil {
+		t.Errorf("expected the extension profile to be kept")
+	}
+	if p["billingProfile"] != nil {
+		t.Errorf("expected no billing profile without a spot max price, got %v", p["billingProfile"])
+	}
+
+	vmss.SpotMaxPrice = to.Float64Ptr(0.025)
+	p = profile(vmss)
+	if diff := cmp.Diff(p["billingProfile"], map[string]interface{}{"maxPrice": 0.025}); diff != "" {
+		t.Errorf("unexpected billing profile: %s", diff)
+	}
+	if p["scheduledEventsProfile"] == nil || p["extensionProfile"] == nil {
+		t.Errorf("expected the scheduled events and extension profiles to be kept, got %v", p)
+	}
+
+	vmss.TerminateNotificationTimeout = ""
+	vmss.SpotMaxPrice = to.Float64Ptr(-1)
+	p = profile(vmss)
+	if diff := cmp.Diff(p["billingProfile"], map[string]interface{}{"maxPrice": float64(-1)}); diff != "" {
+		t.Errorf("unexpected billing profile: %s", diff)
+	}
+	if p["scheduledEventsProfile"] != nil {
+		t.Errorf("expected no scheduled events profile without a terminate notification timeout, got %v", p["scheduledEventsProfile"])
+	}
+
+	properties := func(vmss VirtualMachineScaleSetARM) map[string]interface{} {
+		b, err := json.Marshal(vmss)
+		if err != nil {
+			t.Fatalf("unexpected error marshaling the scale set: %s", err)
+		}
+		var m map[string]interface{}
+		if err = json.Unmarshal(b, &m); err != nil {
+			t.Fatalf("the scale set was not marshaled to valid JSON: %s\n%s", err, b)
+		}
+		return m["properties"].(map[string]interface{})
+	}
+	if p = properties(vmss); p["hostGroup"] != nil {
+		t.Errorf("expected no host group without a host group ID, got %v", p["hostGroup"])
+	}
+
+	hostGroupID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/hostGroups/HG_NAME"
+	vmss.HostGroupID = hostGroupID
+	p = properties(vmss)
+	if diff := cmp.Diff(p["hostGroup"], map[string]interface{}{"id": hostGroupID}); diff != "" {
+		t.Errorf("unexpected host group: %s", diff)
+	}
+	if p["upgradePolicy"] == nil {
+		t.Errorf("expected the upgrade policy to be kept, got %v", p)
+	}
+	vmProfile := p["virtualMachineProfile"].(map[string]interface{})
+	if vmProfile["hostGroup"] != nil || vmProfile["billingProfile"] == nil {
+		t.Errorf("expected the host group to be set on the scale set only and the billing profile to be kept, got %v", vmProfile)
+	}
+}
+
+func TestMarshalJSONVirtualMachineScaleSetARMUltraSSD(t *testing.T) {
+	g := NewGomegaWithT(t)
+	vmss := VirtualMachineScaleSetARM{
+		VirtualMachineScaleSet: compute.VirtualMachineScaleSet{
+			VirtualMachineScaleSetProperties: &compute.VirtualMachineScaleSetProperties{
+				VirtualMachineProfile: &compute.VirtualMachineScaleSetVMProfile{
+					StorageProfile: &compute.VirtualMachineScaleSetStorageProfile{
+						DataDisks: &[]compute.VirtualMachineScaleSetDataDisk{
+							{
+								Lun:          to.Int32Ptr(0),
+								CreateOption: compute.DiskCreateOptionTypesEmpty,
+								ManagedDisk: &compute.VirtualMachineScaleSetManagedDiskParameters{
+									StorageAccountType: compute.StorageAccountTypesUltraSSDLRS,
+								},
+							},
+							{
+								Lun:          to.Int32Ptr(1),
+								CreateOption: compute.DiskCreateOptionTypesEmpty,
+								ManagedDisk: &compute.VirtualMachineScaleSetManagedDiskParameters{
+									StorageAccountType: compute.StorageAccountTypesUltraSSDLRS,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		DataDiskIOPSReadWrite: 2000,
+		DataDiskMBpsReadWrite: 100,
+	}
+
+	output, err := json.Marshal(vmss)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(output)).To(MatchJSON(`{
+		"properties": {
+			"virtualMachineProfile": {
+				"storageProfile": {
+					"dataDisks": [
+						{"lun": 0, "createOption": "Empty", "diskIOPSReadWrite": 2000, "diskMBpsReadWrite": 100, "managedDisk": {"storageAccountType": "UltraSSD_LRS"}},
+						{"lun": 1, "createOption": "Empty", "diskIOPSReadWrite": 2000, "diskMBpsReadWrite": 100, "managedDisk": {"storageAccountType": "UltraSSD_LRS"}}
+					]
+				}
+			}
+		},
+		"tags": null
+	}`))
+
+	// only the throughput is set
+	vmss.DataDiskIOPSReadWrite = 0
+	output, err = json.Marshal(vmss)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(output)).NotTo(ContainSubstring("diskIOPSReadWrite"))
+	g.Expect(string(output)).To(ContainSubstring(`"diskMBpsReadWrite":100,"managedDisk"`))
+}
+
+func TestMarshalJSONDiskARM(t *testing.T) {
+	g := NewGomegaWithT(t)
+	disk := DiskARM{
+		ARMResource: ARMResource{
+			APIVersion: sharedDiskAPIVersionCompute,
+		},
+		Disk: compute.Disk{
+			Name: to.StringPtr("shareddisk0"),
+			Sku: &compute.DiskSku{
+				Name: compute.PremiumLRS,
+			},
+			DiskProperties: &compute.DiskProperties{
+				CreationData: &compute.CreationData{
+					CreateOption: compute.Empty,
+				},
+				DiskSizeGB: to.Int32Ptr(256),
+			},
+		},
+	}
+
+	output, err := json.Marshal(disk)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(output)).To(MatchJSON(`{
+		"apiVersion": "2019-07-01",
+		"name": "shareddisk0",
+		"sku": {"name": "Premium_LRS"},
+		"properties": {"creationData": {"createOption": "Empty"}, "diskSizeGB": 256},
+		"tags": null
+	}`))
+
+	disk.MaxShares = 2
+	output, err = json.Marshal(disk)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(output)).To(MatchJSON(`{
+		"apiVersion": "2019-07-01",
+		"name": "shareddisk0",
+		"sku": {"name": "Premium_LRS"},
+		"properties": {"maxShares": 2, "creationData": {"createOption": "Empty"}, "diskSizeGB": 256},
+		"tags": null
+	}`))
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/aks-engine/pkg/helpers"
@@ -67,6 +68,10 @@ func getK8sMasterVars(cs *api.ContainerService) (map[string]interface{}, error)
 		maxLoadBalancerCount = kubernetesConfig.MaximumLoadBalancerRuleCount
 		provisionJumpbox = kubernetesConfig.PrivateJumpboxProvision()
 	}
+	var airGapParameters string
+	if kubernetesConfig != nil && kubernetesConfig.IsAirGapped() {
+		airGapParameters = fmt.Sprintf(",' PACKAGES_URL=%s'", strings.TrimSuffix(kubernetesConfig.AirGapProfile.PackagesURL, "/"))
+	}
 	isHostedMaster := cs.Properties.IsHostedMasterProfile()
 	isMasterVMSS := masterProfile != nil && masterProfile.IsVirtualMachineScaleSets()
 	hasStorageAccountDisks := cs.Properties.HasStorageAccountDisks()
@@ -110,8 +115,8 @@ func getK8sMasterVars(cs *api.ContainerService) (map[string]interface{}, error)
 		"routeTableID":           "[resourceId('Microsoft.Network/routeTables', variables('routeTableName'))]",
 		"sshNatPorts":            []int{22, 2201, 2202, 2203, 2204},
 		"sshKeyPath":             "[concat('/home/',parameters('linuxAdminUsername'),'/.ssh/authorized_keys')]",
-		"provisionScriptParametersCommon": fmt.Sprintf("[concat('ADMINUSER=',parameters('linuxAdminUsername'),' ETCD_DOWNLOAD_URL=',parameters('etcdDownloadURLBase'),' ETCD_VERSION=',parameters('etcdVersion'),' CONTAINERD_VERSION=',parameters('containerdVersion'),' MOBY_VERSION=',parameters('mobyVersion'),' TENANT_ID=',variables('tenantID'),' KUBERNETES_VERSION=%s HYPERKUBE_URL=',parameters('kubernetesHyperkubeSpec'),' APISERVER_PUBLIC_KEY=',parameters('apiServerCertificate'),' SUBSCRIPTION_ID=',variables('subscriptionId'),' RESOURCE_GROUP=',variables('resourceGroup'),' LOCATION=',variables('location'),' VM_TYPE=',variables('vmType'),' SUBNET=',variables('subnetName'),' NETWORK_SECURITY_GROUP=',variables('nsgName'),' VIRTUAL_NETWORK=',variables('virtualNetworkName'),' VIRTUAL_NETWORK_RESOURCE_GROUP=',variables('virtualNetworkResourceGroupName'),' ROUTE_TABLE=',variables('routeTableName'),' PRIMARY_AVAILABILITY_SET=',variables('primaryAvailabilitySetName'),' PRIMARY_SCALE_SET=',variables('primaryScaleSetName'),' SERVICE_PRINCIPAL_CLIENT_ID=',variables('servicePrincipalClientId'),' SERVICE_PRINCIPAL_CLIENT_SECRET=',variables('singleQuote'),variables('servicePrincipalClientSecret'),variables('singleQuote'),' KUBELET_PRIVATE_KEY=',parameters('clientPrivateKey'),' TARGET_ENVIRONMENT=',parameters('targetEnvironment'),' NETWORK_PLUGIN=',parameters('networkPlugin'),' NETWORK_POLICY=',parameters('networkPolicy'),' VNET_CNI_PLUGINS_URL=',parameters('vnetCniLinuxPluginsURL'),' CNI_PLUGINS_URL=',parameters('cniPluginsURL'),' CLOUDPROVIDER_BACKOFF=',toLower(string(parameters('cloudproviderConfig').cloudProviderBackoff)),' CLOUDPROVIDER_BACKOFF_RETRIES=',parameters('cloudproviderConfig').cloudProviderBackoffRetries,' CLOUDPROVIDER_BACKOFF_EXPONENT=',parameters('cloudproviderConfig').cloudProviderBackoffExponent,' CLOUDPROVIDER_BACKOFF_DURATION=',parameters('cloudproviderConfig').cloudProviderBackoffDuration,' CLOUDPROVIDER_BACKOFF_JITTER=',parameters('cloudproviderConfig').cloudProviderBackoffJitter,' CLOUDPROVIDER_RATELIMIT=',toLower(string(parameters('cloudproviderConfig').cloudProviderRatelimit)),' CLOUDPROVIDER_RATELIMIT_QPS=',parameters('cloudproviderConfig').cloudProviderRatelimitQPS,' CLOUDPROVIDER_RATELIMIT_QPS_WRITE=',parameters('cloudproviderConfig').cloudProviderRatelimitQPSWrite,' CLOUDPROVIDER_RATELIMIT_BUCKET=',parameters('cloudproviderConfig').cloudProviderRatelimitBucket,' CLOUDPROVIDER_RATELIMIT_BUCKET_WRITE=',parameters('cloudproviderConfig').cloudProviderRatelimitBucketWrite,' USE_MANAGED_IDENTITY_EXTENSION=',variables('useManagedIdentityExtension'),' USE_INSTANCE_METADATA=',variables('useInstanceMetadata'),' LOAD_BALANCER_SKU=',variables('loadBalancerSku'),' EXCLUDE_MASTER_FROM_STANDARD_LB=',variables('excludeMasterFromStandardLB'),' MAXIMUM_LOADBALANCER_RULE_COUNT=',variables('maximumLoadBalancerRuleCount'),' CONTAINER_RUNTIME=',parameters('containerRuntime'),' CONTAINERD_DOWNLOAD_URL_BASE=',parameters('containerdDownloadURLBase'),' POD_INFRA_CONTAINER_SPEC=',parameters('kubernetesPodInfraContainerSpec'),' KMS_PROVIDER_VAULT_NAME=',variables('clusterKeyVaultName'),' IS_HOSTED_MASTER=%t',' IS_IPV6_DUALSTACK_FEATURE_ENABLED=%t',' PRIVATE_AZURE_REGISTRY_SERVER=',parameters('privateAzureRegistryServer'),' AUTHENTICATION_METHOD=',variables('customCloudAuthenticationMethod'),' IDENTITY_SYSTEM=',variables('customCloudIdentifySystem'),' NETWORK_API_VERSION=',variables('apiVersionNetwork'))]",
-			kubernetesVersion, isHostedMaster, isIPv6DualStackFeatureEnabled),
+		"provisionScriptParametersCommon": fmt.Sprintf("[concat('ADMINUSER=',parameters('linuxAdminUsername'),' ETCD_DOWNLOAD_URL=',parameters('etcdDownloadURLBase'),' ETCD_VERSION=',parameters('etcdVersion'),' CONTAINERD_VERSION=',parameters('containerdVersion'),' MOBY_VERSION=',parameters('mobyVersion'),' TENANT_ID=',variables('tenantID'),' KUBERNETES_VERSION=%s HYPERKUBE_URL=',parameters('kubernetesHyperkubeSpec'),' APISERVER_PUBLIC_KEY=',parameters('apiServerCertificate'),' SUBSCRIPTION_ID=',variables('subscriptionId'),' RESOURCE_GROUP=',variables('resourceGroup'),' LOCATION=',variables('location'),' VM_TYPE=',variables('vmType'),' SUBNET=',variables('subnetName'),' NETWORK_SECURITY_GROUP=',variables('nsgName'),' VIRTUAL_NETWORK=',variables('virtualNetworkName'),' VIRTUAL_NETWORK_RESOURCE_GROUP=',variables('virtualNetworkResourceGroupName'),' ROUTE_TABLE=',variables('routeTableName'),' PRIMARY_AVAILABILITY_SET=',variables('primaryAvailabilitySetName'),' PRIMARY_SCALE_SET=',variables('primaryScaleSetName'),' SERVICE_PRINCIPAL_CLIENT_ID=',variables('servicePrincipalClientId'),' SERVICE_PRINCIPAL_CLIENT_SECRET=',variables('singleQuote'),variables('servicePrincipalClientSecret'),variables('singleQuote'),' KUBELET_PRIVATE_KEY=',parameters('clientPrivateKey'),' TARGET_ENVIRONMENT=',parameters('targetEnvironment'),' NETWORK_PLUGIN=',parameters('networkPlugin'),' NETWORK_POLICY=',parameters('networkPolicy'),' VNET_CNI_PLUGINS_URL=',parameters('vnetCniLinuxPluginsURL'),' CNI_PLUGINS_URL=',parameters('cniPluginsURL'),' CLOUDPROVIDER_BACKOFF=',toLower(string(parameters('cloudproviderConfig').cloudProviderBackoff)),' CLOUDPROVIDER_BACKOFF_RETRIES=',parameters('cloudproviderConfig').cloudProviderBackoffRetries,' CLOUDPROVIDER_BACKOFF_EXPONENT=',parameters('cloudproviderConfig').cloudProviderBackoffExponent,' CLOUDPROVIDER_BACKOFF_DURATION=',parameters('cloudproviderConfig').cloudProviderBackoffDuration,' CLOUDPROVIDER_BACKOFF_JITTER=',parameters('cloudproviderConfig').cloudProviderBackoffJitter,' CLOUDPROVIDER_RATELIMIT=',toLower(string(parameters('cloudproviderConfig').cloudProviderRatelimit)),' CLOUDPROVIDER_RATELIMIT_QPS=',parameters('cloudproviderConfig').cloudProviderRatelimitQPS,' CLOUDPROVIDER_RATELIMIT_QPS_WRITE=',parameters('cloudproviderConfig').cloudProviderRatelimitQPSWrite,' CLOUDPROVIDER_RATELIMIT_BUCKET=',parameters('cloudproviderConfig').cloudProviderRatelimitBucket,' CLOUDPROVIDER_RATELIMIT_BUCKET_WRITE=',parameters('cloudproviderConfig').cloudProviderRatelimitBucketWrite,' USE_MANAGED_IDENTITY_EXTENSION=',variables('useManagedIdentityExtension'),' USE_INSTANCE_METADATA=',variables('useInstanceMetadata'),' LOAD_BALANCER_SKU=',variables('loadBalancerSku'),' EXCLUDE_MASTER_FROM_STANDARD_LB=',variables('excludeMasterFromStandardLB'),' MAXIMUM_LOADBALANCER_RULE_COUNT=',variables('maximumLoadBalancerRuleCount'),' CONTAINER_RUNTIME=',parameters('containerRuntime'),' CONTAINERD_DOWNLOAD_URL_BASE=',parameters('containerdDownloadURLBase'),' POD_INFRA_CONTAINER_SPEC=',parameters('kubernetesPodInfraContainerSpec'),' KMS_PROVIDER_VAULT_NAME=',variables('clusterKeyVaultName'),' IS_HOSTED_MASTER=%t',' IS_IPV6_DUALSTACK_FEATURE_ENABLED=%t',' PRIVATE_AZURE_REGISTRY_SERVER=',parameters('privateAzureRegistryServer'),' AUTHENTICATION_METHOD=',variables('customCloudAuthenticationMethod'),' IDENTITY_SYSTEM=',variables('customCloudIdentifySystem'),' NETWORK_API_VERSION=',variables('apiVersionNetwork')%s)]",
+			kubernetesVersion, isHostedMaster, isIPv6DualStackFeatureEnabled, airGapParameters),
 		"orchestratorNameVersionTag":                fmt.Sprintf("%s:%s", orchProfile.OrchestratorType, orchProfile.OrchestratorVersion),
 		"subnetNameResourceSegmentIndex":            10,
 		"vnetNameResourceSegmentIndex":              8,
@@ -145,6 +150,20 @@ func getK8sMasterVars(cs *api.ContainerService) (map[string]interface{}, error)
 		cloudInitFiles["dockerClearMountPropagationFlags"] = getBase64EncodedGzippedCustomScript(dockerClearMountPropagationFlags)
 	}
 
+	if kubernetesConfig != nil && kubernetesConfig.IsKonnectivityEnabled() {
+		cloudInitFiles["generateKonnectivityCertsScript"] = getBase64EncodedGzippedCustomScript(kubernetesMasterGenerateKonnectivityCertsScript)
+	}
+
+	// the handler is not on the VHD, it is only provisioned on the pools it is enabled for
+	if cs.Properties.AnyAgentHasScheduledEventsHandler() {
+		cloudInitFiles["scheduledEventsHandlerScript"] = getBase64EncodedGzippedCustomScript(scheduledEventsHandlerScript)
+		cloudInitFiles["scheduledEventsHandlerSystemdService"] = getBase64EncodedGzippedCustomScript(scheduledEventsHandlerSystemdService)
+	}
+
+	if kubernetesConfig != nil && kubernetesConfig.IsAirGapped() {
+		rewriteAirGapCloudInitFiles(cloudInitFiles, kubernetesConfig.AirGapProfile)
+	}
+
 	masterVars["cloudInitFiles"] = cloudInitFiles
 
 	blockOutboundInternet := cs.Properties.FeatureFlags.IsFeatureEnabled("BlockOutboundInternet")
@@ -185,11 +204,15 @@ func getK8sMasterVars(cs *api.ContainerService) (map[string]interface{}, error)
 	if masterProfile != nil {
 		auditDEnabled = strconv.FormatBool(to.Bool(masterProfile.AuditDEnabled))
 	}
+	var etcdSubnetParameters string
+	if masterProfile != nil && masterProfile.HasDedicatedEtcdSubnet() {
+		etcdSubnetParameters = ",' ETCD_SUBNET=',parameters('etcdSubnet'),' ETCD_FIRST_CONSECUTIVE_STATIC_IP=',parameters('etcdFirstConsecutiveStaticIP')"
+	}
 	if !isHostedMaster {
 		if isMasterVMSS {
 			masterVars["provisionScriptParametersMaster"] = fmt.Sprintf("[concat('COSMOS_URI=%s MASTER_NODE=true NO_OUTBOUND=%t AUDITD_ENABLED=%s CLUSTER_AUTOSCALER_ADDON=',parameters('kubernetesClusterAutoscalerEnabled'),' ACI_CONNECTOR_ADDON=',parameters('kubernetesACIConnectorEnabled'),' APISERVER_PRIVATE_KEY=',parameters('apiServerPrivateKey'),' CA_CERTIFICATE=',parameters('caCertificate'),' CA_PRIVATE_KEY=',parameters('caPrivateKey'),' MASTER_FQDN=',variables('masterFqdnPrefix'),' KUBECONFIG_CERTIFICATE=',parameters('kubeConfigCertificate'),' KUBECONFIG_KEY=',parameters('kubeConfigPrivateKey'),' ETCD_SERVER_CERTIFICATE=',parameters('etcdServerCertificate'),' ETCD_CLIENT_CERTIFICATE=',parameters('etcdClientCertificate'),' ETCD_SERVER_PRIVATE_KEY=',parameters('etcdServerPrivateKey'),' ETCD_CLIENT_PRIVATE_KEY=',parameters('etcdClientPrivateKey'),' ETCD_PEER_CERTIFICATES=',string(variables('etcdPeerCertificates')),' ETCD_PEER_PRIVATE_KEYS=',string(variables('etcdPeerPrivateKeys')),' ENABLE_AGGREGATED_APIS=',string(parameters('enableAggregatedAPIs')),' KUBECONFIG_SERVER=',variables('kubeconfigServer'))]", cosmosEndPointURI, blockOutboundInternet, auditDEnabled)
 		} else {
-			masterVars["provisionScriptParametersMaster"] = fmt.Sprintf("[concat('COSMOS_URI=%s MASTER_VM_NAME=',variables('masterVMNames')[variables('masterOffset')],' ETCD_PEER_URL=',variables('masterEtcdPeerURLs')[variables('masterOffset')],' ETCD_CLIENT_URL=',variables('masterEtcdClientURLs')[variables('masterOffset')],' MASTER_NODE=true NO_OUTBOUND=%t AUDITD_ENABLED=%s CLUSTER_AUTOSCALER_ADDON=',parameters('kubernetesClusterAutoscalerEnabled'),' ACI_CONNECTOR_ADDON=',parameters('kubernetesACIConnectorEnabled'),' APISERVER_PRIVATE_KEY=',parameters('apiServerPrivateKey'),' CA_CERTIFICATE=',parameters('caCertificate'),' CA_PRIVATE_KEY=',parameters('caPrivateKey'),' MASTER_FQDN=',variables('masterFqdnPrefix'),' KUBECONFIG_CERTIFICATE=',parameters('kubeConfigCertificate'),' KUBECONFIG_KEY=',parameters('kubeConfigPrivateKey'),' ETCD_SERVER_CERTIFICATE=',parameters('etcdServerCertificate'),' ETCD_CLIENT_CERTIFICATE=',parameters('etcdClientCertificate'),' ETCD_SERVER_PRIVATE_KEY=',parameters('etcdServerPrivateKey'),' ETCD_CLIENT_PRIVATE_KEY=',parameters('etcdClientPrivateKey'),' ETCD_PEER_CERTIFICATES=',string(variables('etcdPeerCertificates')),' ETCD_PEER_PRIVATE_KEYS=',string(variables('etcdPeerPrivateKeys')),' ENABLE_AGGREGATED_APIS=',string(parameters('enableAggregatedAPIs')),' KUBECONFIG_SERVER=',variables('kubeconfigServer'))]", cosmosEndPointURI, blockOutboundInternet, auditDEnabled)
+			masterVars["provisionScriptParametersMaster"] = fmt.Sprintf("[concat('COSMOS_URI=%s MASTER_VM_NAME=',variables('masterVMNames')[variables('masterOffset')],' ETCD_PEER_URL=',variables('masterEtcdPeerURLs')[variables('masterOffset')],' ETCD_CLIENT_URL=',variables('masterEtcdClientURLs')[variables('masterOffset')],' MASTER_NODE=true NO_OUTBOUND=%t AUDITD_ENABLED=%s CLUSTER_AUTOSCALER_ADDON=',parameters('kubernetesClusterAutoscalerEnabled'),' ACI_CONNECTOR_ADDON=',parameters('kubernetesACIConnectorEnabled'),' APISERVER_PRIVATE_KEY=',parameters('apiServerPrivateKey'),' CA_CERTIFICATE=',parameters('caCertificate'),' CA_PRIVATE_KEY=',parameters('caPrivateKey'),' MASTER_FQDN=',variables('masterFqdnPrefix'),' KUBECONFIG_CERTIFICATE=',parameters('kubeConfigCertificate'),' KUBECONFIG_KEY=',parameters('kubeConfigPrivateKey'),' ETCD_SERVER_CERTIFICATE=',parameters('etcdServerCertificate'),' ETCD_CLIENT_CERTIFICATE=',parameters('etcdClientCertificate'),' ETCD_SERVER_PRIVATE_KEY=',parameters('etcdServerPrivateKey'),' ETCD_CLIENT_PRIVATE_KEY=',parameters('etcdClientPrivateKey'),' ETCD_PEER_CERTIFICATES=',string(variables('etcdPeerCertificates')),' ETCD_PEER_PRIVATE_KEYS=',string(variables('etcdPeerPrivateKeys')),' ENABLE_AGGREGATED_APIS=',string(parameters('enableAggregatedAPIs')),' KUBECONFIG_SERVER=',variables('kubeconfigServer')%s)]", cosmosEndPointURI, blockOutboundInternet, auditDEnabled, etcdSubnetParameters)
 		}
 	}
 
@@ -205,6 +228,11 @@ func getK8sMasterVars(cs *api.ContainerService) (map[string]interface{}, error)
 		masterVars["userAssignedClientID"] = ""
 	}
 
+	if cs.Properties.CreatesProximityPlacementGroup() {
+		masterVars["proximityPlacementGroupName"] = "[concat(parameters('orchestratorName'), '-ppg-', parameters('nameSuffix'))]"
+		masterVars["proximityPlacementGroupID"] = "[resourceId('Microsoft.Compute/proximityPlacementGroups', variables('proximityPlacementGroupName'))]"
+	}
+
 	if !isHostedMaster {
 		masterCount := masterProfile.Count
 
@@ -306,6 +334,17 @@ func getK8sMasterVars(cs *api.ContainerService) (map[string]interface{}, error)
 			masterVars["masterStorageAccountName"] = "[concat(variables('storageAccountBaseName'), 'mstr0')]"
 		}
 		masterVars["nsgName"] = "[concat(variables('masterVMNamePrefix'), 'nsg')]"
+		if masterProfile.HasDedicatedEtcdSubnet() {
+			if masterProfile.IsCustomVNET() {
+				masterVars["etcdVnetSubnetID"] = "[parameters('etcdVnetSubnetID')]"
+				masterVars["etcdSubnetName"] = "[split(parameters('etcdVnetSubnetID'), '/')[variables('subnetNameResourceSegmentIndex')]]"
+			} else {
+				masterVars["etcdSubnetName"] = "[concat(parameters('orchestratorName'), '-etcd-subnet')]"
+				masterVars["etcdVnetSubnetID"] = "[concat(variables('vnetID'),'/subnets/',variables('etcdSubnetName'))]"
+			}
+			masterVars["etcdNsgName"] = "[concat(variables('masterVMNamePrefix'), 'etcd-nsg')]"
+			masterVars["etcdNsgID"] = "[resourceId('Microsoft.Network/networkSecurityGroups',variables('etcdNsgName'))]"
+		}
 
 	} else {
 		if isCustomVnet {
@@ -387,7 +426,7 @@ func getK8sMasterVars(cs *api.ContainerService) (map[string]interface{}, error)
 
 			}
 		} else {
-			if cs.Properties.OrchestratorProfile.KubernetesConfig.LoadBalancerSku == api.StandardLoadBalancerSku && hasAgentPool {
+			if cs.Properties.OrchestratorProfile.KubernetesConfig.HasOutboundLoadBalancer() && hasAgentPool {
 				masterVars["agentPublicIPAddressName"] = "[concat(parameters('orchestratorName'), '-agent-ip-outbound')]"
 				masterVars["agentLbID"] = "[resourceId('Microsoft.Network/loadBalancers',variables('agentLbName'))]"
 				masterVars["agentLbIPConfigID"] = "[concat(variables('agentLbID'),'/frontendIPConfigurations/', variables('agentLbIPConfigName'))]"
@@ -402,6 +441,39 @@ func getK8sMasterVars(cs *api.ContainerService) (map[string]interface{}, error)
 			masterVars["masterLbName"] = "[concat(parameters('orchestratorName'), '-master-lb-', parameters('nameSuffix'))]"
 			masterVars["kubeconfigServer"] = "[concat('https://', variables('masterFqdnPrefix'), '.', variables('location'), '.', parameters('fqdnEndpointSuffix'))]"
 		}
+		if cs.Properties.OrchestratorProfile.KubernetesConfig.HasNATGateway() {
+			masterVars["apiVersionNATGateway"] = "2020-06-01"
+			masterVars["natGatewayName"] = "[concat(parameters('orchestratorName'), '-nat-gateway')]"
+			masterVars["natGatewayID"] = "[resourceId('Microsoft.Network/natGateways', variables('natGatewayName'))]"
+			masterVars["natGatewayPublicIPAddressName"] = "[concat(parameters('orchestratorName'), '-nat-ip-outbound')]"
+		}
+		if cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateLinkEnabled() {
+			masterVars["apiVersionPrivateLink"] = "2020-06-01"
+			masterVars["apiVersionPrivateDNS"] = "2018-09-01"
+			masterVars["apiServerPrivateLinkServiceName"] = "[concat(parameters('orchestratorName'), '-apiserver-pls-', parameters('nameSuffix'))]"
+			masterVars["apiServerPrivateLinkServiceID"] = "[resourceId('Microsoft.Network/privateLinkServices', variables('apiServerPrivateLinkServiceName'))]"
+			masterVars["apiServerPrivateEndpointName"] = "[concat(parameters('orchestratorName'), '-apiserver-pe-', parameters('nameSuffix'))]"
+			masterVars["apiServerPrivateEndpointID"] = "[resourceId('Microsoft.Network/privateEndpoints', variables('apiServerPrivateEndpointName'))]"
+			masterVars["apiServerPrivateDNSZoneName"] = "[concat('privatelink.', variables('location'), '.', parameters('fqdnEndpointSuffix'))]"
+			if isMasterVMSS {
+				masterVars["apiServerPrivateLinkSubnetID"] = "[variables('vnetSubnetIDMaster')]"
+			} else {
+				masterVars["apiServerPrivateLinkSubnetID"] = "[variables('vnetSubnetID')]"
+			}
+			if masterProfile.IsCustomVNET() {
+				masterVars["apiServerClusterVnetID"] = "[substring(parameters('masterVnetSubnetID'), 0, indexOf(toLower(parameters('masterVnetSubnetID')), '/subnets/'))]"
+			} else {
+				masterVars["apiServerClusterVnetID"] = "[variables('vnetID')]"
+			}
+			if subnetID := cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster.PrivateLink.PrivateEndpointSubnetID; subnetID != "" {
+				masterVars["apiServerPrivateEndpointSubnetID"] = subnetID
+			} else {
+				masterVars["apiServerPrivateEndpointSubnetID"] = "[variables('apiServerPrivateLinkSubnetID')]"
+			}
+			if vnetID := privateEndpointVnetID(cs); vnetID != "" {
+				masterVars["apiServerPrivateEndpointVnetID"] = vnetID
+			}
+		}
 
 		if masterProfile.HasMultipleNodes() {
 			masterVars["masterInternalLbName"] = "[concat(parameters('orchestratorName'), '-master-internal-lb-', parameters('nameSuffix'))]"
@@ -459,6 +531,33 @@ func getK8sMasterVars(cs *api.ContainerService) (map[string]interface{}, error)
 				"[concat('https://', variables('masterPrivateIpAddrs')[3], ':', variables('masterEtcdClientPort'))]",
 				"[concat('https://', variables('masterPrivateIpAddrs')[4], ':', variables('masterEtcdClientPort'))]",
 			}
+			if masterProfile.HasDedicatedEtcdSubnet() {
+				// etcd peer and client traffic goes through the etcd NICs of the masters
+				masterVars["etcdFirstAddrOctets"] = "[split(parameters('etcdFirstConsecutiveStaticIP'),'.')]"
+				masterVars["etcdFirstAddrOctet4"] = "[variables('etcdFirstAddrOctets')[3]]"
+				masterVars["etcdFirstAddrPrefix"] = "[concat(variables('etcdFirstAddrOctets')[0],'.',variables('etcdFirstAddrOctets')[1],'.',variables('etcdFirstAddrOctets')[2],'.')]"
+				masterVars["masterEtcdPrivateIpAddrs"] = []string{
+					"[concat(variables('etcdFirstAddrPrefix'), add(0, int(variables('etcdFirstAddrOctet4'))))]",
+					"[concat(variables('etcdFirstAddrPrefix'), add(1, int(variables('etcdFirstAddrOctet4'))))]",
+					"[concat(variables('etcdFirstAddrPrefix'), add(2, int(variables('etcdFirstAddrOctet4'))))]",
+					"[concat(variables('etcdFirstAddrPrefix'), add(3, int(variables('etcdFirstAddrOctet4'))))]",
+					"[concat(variables('etcdFirstAddrPrefix'), add(4, int(variables('etcdFirstAddrOctet4'))))]",
+				}
+				masterVars["masterEtcdPeerURLs"] = []string{
+					"[concat('https://', variables('masterEtcdPrivateIpAddrs')[0], ':', variables('masterEtcdServerPort'))]",
+					"[concat('https://', variables('masterEtcdPrivateIpAddrs')[1], ':', variables('masterEtcdServerPort'))]",
+					"[concat('https://', variables('masterEtcdPrivateIpAddrs')[2], ':', variables('masterEtcdServerPort'))]",
+					"[concat('https://', variables('masterEtcdPrivateIpAddrs')[3], ':', variables('masterEtcdServerPort'))]",
+					"[concat('https://', variables('masterEtcdPrivateIpAddrs')[4], ':', variables('masterEtcdServerPort'))]",
+				}
+				masterVars["masterEtcdClientURLs"] = []string{
+					"[concat('https://', variables('masterEtcdPrivateIpAddrs')[0], ':', variables('masterEtcdClientPort'))]",
+					"[concat('https://', variables('masterEtcdPrivateIpAddrs')[1], ':', variables('masterEtcdClientPort'))]",
+					"[concat('https://', variables('masterEtcdPrivateIpAddrs')[2], ':', variables('masterEtcdClientPort'))]",
+					"[concat('https://', variables('masterEtcdPrivateIpAddrs')[3], ':', variables('masterEtcdClientPort'))]",
+					"[concat('https://', variables('masterEtcdPrivateIpAddrs')[4], ':', variables('masterEtcdClientPort'))]",
+				}
+			}
 			masterVars["masterEtcdClusterStates"] = []string{
 				"[concat(variables('masterVMNames')[0], '=', variables('masterEtcdPeerURLs')[0])]",
 				"[concat(variables('masterVMNames')[0], '=', variables('masterEtcdPeerURLs')[0], ',', variables('masterVMNames')[1], '=', variables('masterEtcdPeerURLs')[1], ',', variables('masterVMNames')[2], '=', variables('masterEtcdPeerURLs')[2])]",
@@ -515,6 +614,7 @@ func getK8sAgentVars(cs *api.ContainerService, profile *api.AgentPoolProfile) ma
 	agentVnetSubnetID := fmt.Sprintf("%sVnetSubnetID", agentName)
 	agentSubnetName := fmt.Sprintf("%sSubnetName", agentName)
 	agentVnetParts := fmt.Sprintf("%sVnetParts", agentName)
+	agentPodSubnetID := fmt.Sprintf("%sPodSubnetID", agentName)
 
 	agentOsImageOffer := fmt.Sprintf("%sosImageOffer", agentName)
 	agentOsImageSku := fmt.Sprintf("%sosImageSKU", agentName)
@@ -538,7 +638,7 @@ func getK8sAgentVars(cs *api.ContainerService, profile *api.AgentPoolProfile) ma
 	if profile.IsAvailabilitySets() {
 		agentVars[agentOffset] = fmt.Sprintf("[parameters('%s')]", agentOffset)
 		agentVars[agentAvailabilitySet] = fmt.Sprintf("[concat('%s-availabilitySet-', parameters('nameSuffix'))]", agentName)
-	} else if profile.IsLowPriorityScaleSet() {
+	} else if profile.IsLowPriorityScaleSet() || profile.IsSpotScaleSet() {
 		agentVars[agentScaleSetPriority] = fmt.Sprintf("[parameters('%s')]", agentScaleSetPriority)
 		agentVars[agentScaleSetEvictionPolicy] = fmt.Sprintf("[parameters('%s')]", agentScaleSetEvictionPolicy)
 	}
@@ -548,6 +648,9 @@ func getK8sAgentVars(cs *api.ContainerService, profile *api.AgentPoolProfile) ma
 		agentVars[agentVnetSubnetID] = fmt.Sprintf("[parameters('%s')]", agentVnetSubnetID)
 		agentVars[agentSubnetName] = fmt.Sprintf("[parameters('%s')]", agentVnetSubnetID)
 		agentVars[agentVnetParts] = fmt.Sprintf("[split(parameters('%sVnetSubnetID'),'/subnets/')]", agentName)
+		if profile.HasPodSubnet() {
+			agentVars[agentPodSubnetID] = fmt.Sprintf("[parameters('%s')]", agentPodSubnetID)
+		}
 	} else {
 		agentVars[agentVnetSubnetID] = fmt.Sprintf("[variables('vnetSubnetID')]")
 		agentVars[agentSubnetName] = fmt.Sprintf("[variables('subnetName')]")
@@ -560,6 +663,11 @@ func getK8sAgentVars(cs *api.ContainerService, profile *api.AgentPoolProfile) ma
 	agentVars[agentOsImageName] = fmt.Sprintf("[parameters('%sosImageName')]", agentName)
 	agentVars[agentOsImageResourceGroup] = fmt.Sprintf("[parameters('%sosImageResourceGroup')]", agentName)
 
+	if profile.HasUserAssignedID() {
+		agentVars[fmt.Sprintf("%sUserAssignedID", agentName)] = profile.UserAssignedID
+		agentVars[fmt.Sprintf("%sUserAssignedIDReference", agentName)] = fmt.Sprintf("[resourceId('Microsoft.ManagedIdentity/userAssignedIdentities/', variables('%sUserAssignedID'))]", agentName)
+	}
+
 	return agentVars
 }
 
@@ -134,12 +134,90 @@ func kubernetesContainerAddonSettingsInit(p *api.Properties) map[string]kubernet
 			destinationFile: "calico-daemonset.yaml",
 			isEnabled:       k.IsAddonEnabled(CalicoAddonName),
 		},
+		CiliumAddonName: {
+			sourceFile:      "kubernetesmasteraddons-cilium-daemonset.yaml",
+			base64Data:      k.GetAddonScript(CiliumAddonName),
+			destinationFile: "cilium-daemonset.yaml",
+			isEnabled:       k.IsAddonEnabled(CiliumAddonName),
+		},
+		AntreaAddonName: {
+			sourceFile:      "kubernetesmasteraddons-antrea.yaml",
+			base64Data:      k.GetAddonScript(AntreaAddonName),
+			destinationFile: "antrea.yaml",
+			isEnabled:       k.IsAddonEnabled(AntreaAddonName),
+		},
 		AzureNetworkPolicyAddonName: {
 			sourceFile:      "kubernetesmasteraddons-azure-npm-daemonset.yaml",
 			base64Data:      k.GetAddonScript(AzureNetworkPolicyAddonName),
 			destinationFile: "azure-npm-daemonset.yaml",
 			isEnabled:       k.IsAddonEnabled(AzureNetworkPolicyAddonName),
 		},
+		RegistryCacheAddonName: {
+			sourceFile:      "kubernetesmasteraddons-registry-cache-deployment.yaml",
+			base64Data:      k.GetAddonScript(RegistryCacheAddonName),
+			destinationFile: "registry-cache-deployment.yaml",
+			isEnabled:       k.IsAddonEnabled(RegistryCacheAddonName),
+		},
+		AzureWorkloadIdentityAddonName: {
+			sourceFile:      "kubernetesmasteraddons-azure-workload-identity-webhook-deployment.yaml",
+			base64Data:      k.GetAddonScript(AzureWorkloadIdentityAddonName),
+			destinationFile: "azure-workload-identity-webhook-deployment.yaml",
+			isEnabled:       k.IsAddonEnabled(AzureWorkloadIdentityAddonName),
+		},
+		FluentBitAddonName: {
+			sourceFile:      "kubernetesmasteraddons-fluent-bit-daemonset.yaml",
+			base64Data:      k.GetAddonScript(FluentBitAddonName),
+			destinationFile: "fluent-bit-daemonset.yaml",
+			isEnabled:       k.IsAddonEnabled(FluentBitAddonName),
+		},
+		NodeProblemDetectorAddonName: {
+			sourceFile:      "kubernetesmasteraddons-node-problem-detector-daemonset.yaml",
+			base64Data:      k.GetAddonScript(NodeProblemDetectorAddonName),
+			destinationFile: "node-problem-detector-daemonset.yaml",
+			isEnabled:       k.IsAddonEnabled(NodeProblemDetectorAddonName),
+		},
+		CSISecretsStoreAddonName: {
+			sourceFile:      "kubernetesmasteraddons-csi-secrets-store-daemonset.yaml",
+			base64Data:      k.GetAddonScript(CSISecretsStoreAddonName),
+			destinationFile: "csi-secrets-store-daemonset.yaml",
+			isEnabled:       k.IsAddonEnabled(CSISecretsStoreAddonName),
+		},
+		NginxIngressAddonName: {
+			sourceFile:      "kubernetesmasteraddons-nginx-ingress-deployment.yaml",
+			base64Data:      k.GetAddonScript(NginxIngressAddonName),
+			destinationFile: "nginx-ingress-deployment.yaml",
+			isEnabled:       k.IsAddonEnabled(NginxIngressAddonName),
+		},
+		AppGwIngressAddonName: {
+			sourceFile:      "kubernetesmasteraddons-appgw-ingress-deployment.yaml",
+			base64Data:      k.GetAddonScript(AppGwIngressAddonName),
+			destinationFile: "appgw-ingress-deployment.yaml",
+			isEnabled:       k.IsAddonEnabled(AppGwIngressAddonName),
+		},
+		AzurePolicyAddonName: {
+			sourceFile:      "kubernetesmasteraddons-azure-policy-deployment.yaml",
+			base64Data:      k.GetAddonScript(AzurePolicyAddonName),
+			destinationFile: "azure-policy-deployment.yaml",
+			isEnabled:       k.IsAddonEnabled(AzurePolicyAddonName),
+		},
+		KEDAAddonName: {
+			sourceFile:      "kubernetesmasteraddons-keda-deployment.yaml",
+			base64Data:      k.GetAddonScript(KEDAAddonName),
+			destinationFile: "keda-deployment.yaml",
+			isEnabled:       k.IsAddonEnabled(KEDAAddonName),
+		},
+		OpenServiceMeshAddonName: {
+			sourceFile:      "kubernetesmasteraddons-open-service-mesh-deployment.yaml",
+			base64Data:      k.GetAddonScript(OpenServiceMeshAddonName),
+			destinationFile: "open-service-mesh-deployment.yaml",
+			isEnabled:       k.IsAddonEnabled(OpenServiceMeshAddonName),
+		},
+		KonnectivityAgentAddonName: {
+			sourceFile:      "kubernetesmasteraddons-konnectivity-agent-daemonset.yaml",
+			base64Data:      k.GetAddonScript(KonnectivityAgentAddonName),
+			destinationFile: "konnectivity-agent-daemonset.yaml",
+			isEnabled:       k.IsAddonEnabled(KonnectivityAgentAddonName),
+		},
 	}
 }
 
@@ -169,13 +247,7 @@ func kubernetesAddonSettingsInit(p *api.Properties) []kubernetesComponentFileSpe
 			sourceFile:      "kubernetesmasteraddons-kube-proxy-daemonset.yaml",
 			base64Data:      k.GetAddonScript(KubeProxyAddonName),
 			destinationFile: "kube-proxy-daemonset.yaml",
-			isEnabled:       true,
-		},
-		{
-			sourceFile:      "kubernetesmasteraddons-cilium-daemonset.yaml",
-			base64Data:      k.GetAddonScript(CiliumAddonName),
-			destinationFile: "cilium-daemonset.yaml",
-			isEnabled:       k.NetworkPolicy == NetworkPolicyCilium,
+			isEnabled:       !k.IsKubeProxyReplaced(),
 		},
 		{
 			sourceFile:      "kubernetesmasteraddons-flannel-daemonset.yaml",
@@ -301,6 +373,12 @@ func kubernetesManifestSettingsInit(p *api.Properties) []kubernetesComponentFile
 			destinationFile: "kube-addon-manager.yaml",
 			isEnabled:       true,
 		},
+		{
+			sourceFile:      "kubernetesmaster-konnectivity-server.yaml",
+			base64Data:      "", // arbitrary user-provided data not enabled for konnectivity-server spec
+			destinationFile: "konnectivity-server.yaml",
+			isEnabled:       k.IsKonnectivityEnabled(),
+		},
 	}
 }
 
@@ -32,7 +32,20 @@ func TestKubernetesContainerAddonSettingsInit(t *testing.T) {
 		expectedAzureCNINetworkMonitor bool
 		expectedDNSAutoscaler          bool
 		expectedCalico                 bool
+		expectedCilium                 bool
+		expectedAntrea                 bool
 		expectedAzureNetworkPolicy     bool
+		expectedRegistryCache          bool
+		expectedAzureWorkloadIdentity  bool
+		expectedFluentBit              bool
+		expectedNodeProblemDetector    bool
+		expectedCSISecretsStore        bool
+		expectedNginxIngress           bool
+		expectedAppGwIngress           bool
+		expectedAzurePolicy            bool
+		expectedKEDA                   bool
+		expectedOpenServiceMesh        bool
+		expectedKonnectivityAgent      bool
 	}{
 		// addons disabled scenario
 		{
@@ -115,6 +128,50 @@ func TestKubernetesContainerAddonSettingsInit(t *testing.T) {
 								Name:    AzureNetworkPolicyAddonName,
 								Enabled: to.BoolPtr(false),
 							},
+							{
+								Name:    RegistryCacheAddonName,
+								Enabled: to.BoolPtr(false),
+							},
+							{
+								Name:    AzureWorkloadIdentityAddonName,
+								Enabled: to.BoolPtr(false),
+							},
+							{
+								Name:    FluentBitAddonName,
+								Enabled: to.BoolPtr(false),
+							},
+							{
+								Name:    NodeProblemDetectorAddonName,
+								Enabled: to.BoolPtr(false),
+							},
+							{
+								Name:    CSISecretsStoreAddonName,
+								Enabled: to.BoolPtr(false),
+							},
+							{
+								Name:    NginxIngressAddonName,
+								Enabled: to.BoolPtr(false),
+							},
+							{
+								Name:    AppGwIngressAddonName,
+								Enabled: to.BoolPtr(false),
+							},
+							{
+								Name:    AzurePolicyAddonName,
+								Enabled: to.BoolPtr(false),
+							},
+							{
+								Name:    KEDAAddonName,
+								Enabled: to.BoolPtr(false),
+							},
+							{
+								Name:    OpenServiceMeshAddonName,
+								Enabled: to.BoolPtr(false),
+							},
+							{
+								Name:    KonnectivityAgentAddonName,
+								Enabled: to.BoolPtr(false),
+							},
 						},
 					},
 				},
@@ -137,6 +194,17 @@ func TestKubernetesContainerAddonSettingsInit(t *testing.T) {
 			expectedDNSAutoscaler:          false,
 			expectedCalico:                 false,
 			expectedAzureNetworkPolicy:     false,
+			expectedRegistryCache:          false,
+			expectedAzureWorkloadIdentity:  false,
+			expectedFluentBit:              false,
+			expectedNodeProblemDetector:    false,
+			expectedCSISecretsStore:        false,
+			expectedNginxIngress:           false,
+			expectedAppGwIngress:           false,
+			expectedAzurePolicy:            false,
+			expectedKEDA:                   false,
+			expectedOpenServiceMesh:        false,
+			expectedKonnectivityAgent:      false,
 		},
 		// addons enabled scenario
 		{
@@ -215,10 +283,62 @@ func TestKubernetesContainerAddonSettingsInit(t *testing.T) {
 								Name:    CalicoAddonName,
 								Enabled: to.BoolPtr(true),
 							},
+							{
+								Name:    CiliumAddonName,
+								Enabled: to.BoolPtr(true),
+							},
+							{
+								Name:    AntreaAddonName,
+								Enabled: to.BoolPtr(true),
+							},
 							{
 								Name:    AzureNetworkPolicyAddonName,
 								Enabled: to.BoolPtr(true),
 							},
+							{
+								Name:    RegistryCacheAddonName,
+								Enabled: to.BoolPtr(true),
+							},
+							{
+								Name:    AzureWorkloadIdentityAddonName,
+								Enabled: to.BoolPtr(true),
+							},
+							{
+								Name:    FluentBitAddonName,
+								Enabled: to.BoolPtr(true),
+							},
+							{
+								Name:    NodeProblemDetectorAddonName,
+								Enabled: to.BoolPtr(true),
+							},
+							{
+								Name:    CSISecretsStoreAddonName,
+								Enabled: to.BoolPtr(true),
+							},
+							{
+								Name:    NginxIngressAddonName,
+								Enabled: to.BoolPtr(true),
+							},
+							{
+								Name:    AppGwIngressAddonName,
+								Enabled: to.BoolPtr(true),
+							},
+							{
+								Name:    AzurePolicyAddonName,
+								Enabled: to.BoolPtr(true),
+							},
+							{
+								Name:    KEDAAddonName,
+								Enabled: to.BoolPtr(true),
+							},
+							{
+								Name:    OpenServiceMeshAddonName,
+								Enabled: to.BoolPtr(true),
+							},
+							{
+								Name:    KonnectivityAgentAddonName,
+								Enabled: to.BoolPtr(true),
+							},
 						},
 					},
 				},
@@ -240,7 +360,20 @@ func TestKubernetesContainerAddonSettingsInit(t *testing.T) {
 			expectedAzureCNINetworkMonitor: true,
 			expectedDNSAutoscaler:          true,
 			expectedCalico:                 true,
+			expectedCilium:                 true,
+			expectedAntrea:                 true,
 			expectedAzureNetworkPolicy:     true,
+			expectedRegistryCache:          true,
+			expectedAzureWorkloadIdentity:  true,
+			expectedFluentBit:              true,
+			expectedNodeProblemDetector:    true,
+			expectedCSISecretsStore:        true,
+			expectedNginxIngress:           true,
+			expectedAppGwIngress:           true,
+			expectedAzurePolicy:            true,
+			expectedKEDA:                   true,
+			expectedOpenServiceMesh:        true,
+			expectedKonnectivityAgent:      true,
 		},
 	}
 
@@ -297,9 +430,48 @@ func TestKubernetesContainerAddonSettingsInit(t *testing.T) {
 		if c.expectedCalico != componentFileSpec[CalicoAddonName].isEnabled {
 			t.Fatalf("Expected componentFileSpec[%s] to be %t", CalicoAddonName, c.expectedCalico)
 		}
+		if c.expectedCilium != componentFileSpec[CiliumAddonName].isEnabled {
+			t.Fatalf("Expected componentFileSpec[%s] to be %t", CiliumAddonName, c.expectedCilium)
+		}
+		if c.expectedAntrea != componentFileSpec[AntreaAddonName].isEnabled {
+			t.Fatalf("Expected componentFileSpec[%s] to be %t", AntreaAddonName, c.expectedAntrea)
+		}
 		if c.expectedAzureNetworkPolicy != componentFileSpec[AzureNetworkPolicyAddonName].isEnabled {
 			t.Fatalf("Expected componentFileSpec[%s] to be %t", AzureNetworkPolicyAddonName, c.expectedAzureNetworkPolicy)
 		}
+		if c.expectedRegistryCache != componentFileSpec[RegistryCacheAddonName].isEnabled {
+			t.Fatalf("Expected componentFileSpec[%s] to be %t", RegistryCacheAddonName, c.expectedRegistryCache)
+		}
+		if c.expectedAzureWorkloadIdentity != componentFileSpec[AzureWorkloadIdentityAddonName].isEnabled {
+			t.Fatalf("Expected componentFileSpec[%s] to be %t", AzureWorkloadIdentityAddonName, c.expectedAzureWorkloadIdentity)
+		}
+		if c.expectedFluentBit != componentFileSpec[FluentBitAddonName].isEnabled {
+			t.Fatalf("Expected componentFileSpec[%s] to be %t", FluentBitAddonName, c.expectedFluentBit)
+		}
+		if c.expectedNodeProblemDetector != componentFileSpec[NodeProblemDetectorAddonName].isEnabled {
+			t.Fatalf("Expected componentFileSpec[%s] to be %t", NodeProblemDetectorAddonName, c.expectedNodeProblemDetector)
+		}
+		if c.expectedCSISecretsStore != componentFileSpec[CSISecretsStoreAddonName].isEnabled {
+			t.Fatalf("Expected componentFileSpec[%s] to be %t", CSISecretsStoreAddonName, c.expectedCSISecretsStore)
+		}
+		if c.expectedNginxIngress != componentFileSpec[NginxIngressAddonName].isEnabled {
+			t.Fatalf("Expected componentFileSpec[%s] to be %t", NginxIngressAddonName, c.expectedNginxIngress)
+		}
+		if c.expectedAppGwIngress != componentFileSpec[AppGwIngressAddonName].isEnabled {
+			t.Fatalf("Expected componentFileSpec[%s] to be %t", AppGwIngressAddonName, c.expectedAppGwIngress)
+		}
+		if c.expectedAzurePolicy != componentFileSpec[AzurePolicyAddonName].isEnabled {
+			t.Fatalf("Expected componentFileSpec[%s] to be %t", AzurePolicyAddonName, c.expectedAzurePolicy)
+		}
+		if c.expectedKEDA != componentFileSpec[KEDAAddonName].isEnabled {
+			t.Fatalf("Expected componentFileSpec[%s] to be %t", KEDAAddonName, c.expectedKEDA)
+		}
+		if c.expectedOpenServiceMesh != componentFileSpec[OpenServiceMeshAddonName].isEnabled {
+			t.Fatalf("Expected componentFileSpec[%s] to be %t", OpenServiceMeshAddonName, c.expectedOpenServiceMesh)
+		}
+		if c.expectedKonnectivityAgent != componentFileSpec[KonnectivityAgentAddonName].isEnabled {
+			t.Fatalf("Expected componentFileSpec[%s] to be %t", KonnectivityAgentAddonName, c.expectedKonnectivityAgent)
+		}
 	}
 }
 
@@ -310,7 +482,6 @@ func TestKubernetesAddonSettingsInit(t *testing.T) {
 		expectedKubeDNS               bool
 		expectedCoreDNS               bool
 		expectedKubeProxy             bool
-		expectedCilium                bool
 		expectedFlannel               bool
 		expectedAADAdminGroup         bool
 		expectedAzureCloudProvider    bool
@@ -339,7 +510,6 @@ func TestKubernetesAddonSettingsInit(t *testing.T) {
 			expectedKubeDNS:               true,
 			expectedCoreDNS:               false,
 			expectedKubeProxy:             true,
-			expectedCilium:                false,
 			expectedFlannel:               false,
 			expectedAADAdminGroup:         false,
 			expectedAzureCloudProvider:    true,
@@ -363,7 +533,6 @@ func TestKubernetesAddonSettingsInit(t *testing.T) {
 			expectedKubeDNS:               false,
 			expectedCoreDNS:               true,
 			expectedKubeProxy:             true,
-			expectedCilium:                false,
 			expectedFlannel:               false,
 			expectedAADAdminGroup:         false,
 			expectedAzureCloudProvider:    true,
@@ -373,21 +542,29 @@ func TestKubernetesAddonSettingsInit(t *testing.T) {
 			expectedUnmanagedStorageClass: false,
 			expectedScheduledMaintenance:  false,
 		},
-		// Cilium scenario
+		// Cilium kube-proxy replacement scenario
 		{
 			p: &api.Properties{
 				OrchestratorProfile: &api.OrchestratorProfile{
 					OrchestratorType:    Kubernetes,
 					OrchestratorVersion: "1.14.1",
 					KubernetesConfig: &api.KubernetesConfig{
-						NetworkPolicy: NetworkPolicyCilium,
+						NetworkPlugin: NetworkPluginCilium,
+						Addons: []api.KubernetesAddon{
+							{
+								Name:    CiliumAddonName,
+								Enabled: to.BoolPtr(true),
+								Config: map[string]string{
+									"kube-proxy-replacement": "strict",
+								},
+							},
+						},
 					},
 				},
 			},
 			expectedKubeDNS:               false,
 			expectedCoreDNS:               true,
-			expectedKubeProxy:             true,
-			expectedCilium:                true,
+			expectedKubeProxy:             false,
 			expectedFlannel:               false,
 			expectedAADAdminGroup:         false,
 			expectedAzureCloudProvider:    true,
@@ -411,7 +588,6 @@ func TestKubernetesAddonSettingsInit(t *testing.T) {
 			expectedKubeDNS:               false,
 			expectedCoreDNS:               true,
 			expectedKubeProxy:             true,
-			expectedCilium:                false,
 			expectedFlannel:               true,
 			expectedAADAdminGroup:         false,
 			expectedAzureCloudProvider:    true,
@@ -438,7 +614,6 @@ func TestKubernetesAddonSettingsInit(t *testing.T) {
 			expectedKubeDNS:               false,
 			expectedCoreDNS:               true,
 			expectedKubeProxy:             true,
-			expectedCilium:                false,
 			expectedFlannel:               false,
 			expectedAADAdminGroup:         true,
 			expectedAzureCloudProvider:    true,
@@ -463,7 +638,6 @@ func TestKubernetesAddonSettingsInit(t *testing.T) {
 			expectedKubeDNS:               false,
 			expectedCoreDNS:               true,
 			expectedKubeProxy:             true,
-			expectedCilium:                false,
 			expectedFlannel:               false,
 			expectedAADAdminGroup:         false,
 			expectedAzureCloudProvider:    true,
@@ -494,7 +668,6 @@ func TestKubernetesAddonSettingsInit(t *testing.T) {
 			expectedKubeDNS:               false,
 			expectedCoreDNS:               true,
 			expectedKubeProxy:             true,
-			expectedCilium:                false,
 			expectedFlannel:               false,
 			expectedAADAdminGroup:         false,
 			expectedAzureCloudProvider:    true,
@@ -518,7 +691,6 @@ func TestKubernetesAddonSettingsInit(t *testing.T) {
 			expectedKubeDNS:               false,
 			expectedCoreDNS:               true,
 			expectedKubeProxy:             true,
-			expectedCilium:                false,
 			expectedFlannel:               false,
 			expectedAADAdminGroup:         false,
 			expectedAzureCloudProvider:    true,
@@ -547,7 +719,6 @@ func TestKubernetesAddonSettingsInit(t *testing.T) {
 			expectedKubeDNS:               true,
 			expectedCoreDNS:               false,
 			expectedKubeProxy:             true,
-			expectedCilium:                false,
 			expectedFlannel:               false,
 			expectedAADAdminGroup:         false,
 			expectedAzureCloudProvider:    true,
@@ -577,7 +748,6 @@ func TestKubernetesAddonSettingsInit(t *testing.T) {
 			expectedKubeDNS:               false,
 			expectedCoreDNS:               true,
 			expectedKubeProxy:             true,
-			expectedCilium:                false,
 			expectedFlannel:               false,
 			expectedAADAdminGroup:         false,
 			expectedAzureCloudProvider:    true,
@@ -607,7 +777,6 @@ func TestKubernetesAddonSettingsInit(t *testing.T) {
 			expectedKubeDNS:               false,
 			expectedCoreDNS:               true,
 			expectedKubeProxy:             true,
-			expectedCilium:                false,
 			expectedFlannel:               false,
 			expectedAADAdminGroup:         false,
 			expectedAzureCloudProvider:    true,
@@ -631,7 +800,6 @@ func TestKubernetesAddonSettingsInit(t *testing.T) {
 			expectedKubeDNS:               false,
 			expectedCoreDNS:               true,
 			expectedKubeProxy:             true,
-			expectedCilium:                false,
 			expectedFlannel:               false,
 			expectedAADAdminGroup:         false,
 			expectedAzureCloudProvider:    true,
@@ -659,10 +827,6 @@ func TestKubernetesAddonSettingsInit(t *testing.T) {
 				if c.expectedKubeProxy != componentFileSpec.isEnabled {
 					t.Fatalf("Expected %s to be %t", KubeProxyAddonName, c.expectedKubeProxy)
 				}
-			case "cilium-daemonset.yaml":
-				if c.expectedCilium != componentFileSpec.isEnabled {
-					t.Fatalf("Expected %s to be %t", CiliumAddonName, c.expectedCilium)
-				}
 			case "flannel-daemonset.yaml":
 				if c.expectedFlannel != componentFileSpec.isEnabled {
 					t.Fatalf("Expected %s to be %t", FlannelAddonName, c.expectedFlannel)
@@ -729,6 +893,7 @@ func TestKubernetesManifestSettingsInit(t *testing.T) {
 		expectedKubeCCM               bool
 		expectedKubeAPIServer         bool
 		expectedKubeAddonManager      bool
+		expectedKonnectivityServer    bool
 	}{
 		// Default scenario
 		{
@@ -746,6 +911,7 @@ func TestKubernetesManifestSettingsInit(t *testing.T) {
 			expectedKubeCCM:               false,
 			expectedKubeAPIServer:         true,
 			expectedKubeAddonManager:      true,
+			expectedKonnectivityServer:    false,
 		},
 		// CCM scenario
 		{
@@ -765,6 +931,7 @@ func TestKubernetesManifestSettingsInit(t *testing.T) {
 			expectedKubeCCM:               true,
 			expectedKubeAPIServer:         true,
 			expectedKubeAddonManager:      true,
+			expectedKonnectivityServer:    false,
 		},
 		// Azure Stack Scenario
 		{
@@ -783,6 +950,7 @@ func TestKubernetesManifestSettingsInit(t *testing.T) {
 			expectedKubeCCM:               false,
 			expectedKubeAPIServer:         true,
 			expectedKubeAddonManager:      true,
+			expectedKonnectivityServer:    false,
 		},
 		// Custom data scenario
 		{
@@ -812,6 +980,28 @@ func TestKubernetesManifestSettingsInit(t *testing.T) {
 			expectedKubeCCM:               true,
 			expectedKubeAPIServer:         true,
 			expectedKubeAddonManager:      true,
+			expectedKonnectivityServer:    false,
+		},
+		// Konnectivity scenario
+		{
+			p: &api.Properties{
+				OrchestratorProfile: &api.OrchestratorProfile{
+					OrchestratorType:    Kubernetes,
+					OrchestratorVersion: "1.16.0-beta.1",
+					KubernetesConfig: &api.KubernetesConfig{
+						SchedulerConfig: map[string]string{},
+						KonnectivityProfile: &api.KonnectivityProfile{
+							Enabled: to.BoolPtr(true),
+						},
+					},
+				},
+			},
+			expectedKubeScheduler:         true,
+			expectedKubeControllerManager: true,
+			expectedKubeCCM:               false,
+			expectedKubeAPIServer:         true,
+			expectedKubeAddonManager:      true,
+			expectedKonnectivityServer:    true,
 		},
 	}
 	for _, c := range cases {
@@ -862,6 +1052,10 @@ func TestKubernetesManifestSettingsInit(t *testing.T) {
 				if componentFileSpec.base64Data != "" {
 					t.Fatalf("Expected %s to be %s", componentFileSpec.base64Data, "")
 				}
+			case "konnectivity-server.yaml":
+				if c.expectedKonnectivityServer != componentFileSpec.isEnabled {
+					t.Fatalf("Expected %s to be %t", "konnectivity-server", c.expectedKonnectivityServer)
+				}
 			}
 		}
 	}
@@ -38,6 +38,13 @@ func CreateAvailabilitySet(cs *api.ContainerService, isManagedDisks bool) Availa
 		} else if cs.Properties.MasterProfile.IsStorageAccount() {
 			avSet.AvailabilitySetProperties = &compute.AvailabilitySetProperties{}
 		}
+		if avSet.AvailabilitySetProperties != nil {
+			ppg, dependency := getProximityPlacementGroupReference(cs.Properties.MasterProfile.ProximityPlacementGroupID, cs.Properties.MasterProfile.CreateProximityPlacementGroup)
+			avSet.ProximityPlacementGroup = ppg
+			if dependency != "" {
+				armResource.DependsOn = []string{dependency}
+			}
+		}
 	}
 
 	return AvailabilitySetARM{
@@ -70,6 +77,12 @@ func createAgentAvailabilitySets(profile *api.AgentPoolProfile) AvailabilitySetA
 		}
 	}
 
+	ppg, dependency := getProximityPlacementGroupReference(profile.ProximityPlacementGroupID, profile.CreateProximityPlacementGroup)
+	avSet.ProximityPlacementGroup = ppg
+	if dependency != "" {
+		armResource.DependsOn = []string{dependency}
+	}
+
 	return AvailabilitySetARM{
 		ARMResource:     armResource,
 		AvailabilitySet: avSet,
@@ -140,6 +140,44 @@ func TestCreateAvailabilitySet(t *testing.T) {
 	if diff != "" {
 		t.Errorf("unexpected error while comparing availability sets: %s", diff)
 	}
+
+	// Test availability set in the proximity placement group of the cluster
+	cs = &api.ContainerService{
+		Properties: &api.Properties{
+			MasterProfile: &api.MasterProfile{
+				CreateProximityPlacementGroup: to.BoolPtr(true),
+			},
+		},
+	}
+
+	avSet = CreateAvailabilitySet(cs, true)
+
+	expectedAvSet = AvailabilitySetARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionCompute')]",
+			DependsOn:  []string{"[concat('Microsoft.Compute/proximityPlacementGroups/', variables('proximityPlacementGroupName'))]"},
+		},
+		AvailabilitySet: compute.AvailabilitySet{
+			Name:     to.StringPtr("[variables('masterAvailabilitySet')]"),
+			Location: to.StringPtr("[variables('location')]"),
+			Type:     to.StringPtr("Microsoft.Compute/availabilitySets"),
+			Sku: &compute.Sku{
+				Name: to.StringPtr("Aligned"),
+			},
+			AvailabilitySetProperties: &compute.AvailabilitySetProperties{
+				PlatformUpdateDomainCount: to.Int32Ptr(3),
+				ProximityPlacementGroup: &compute.SubResource{
+					ID: to.StringPtr("[variables('proximityPlacementGroupID')]"),
+				},
+			},
+		},
+	}
+
+	diff = cmp.Diff(avSet, expectedAvSet)
+
+	if diff != "" {
+		t.Errorf("unexpected error while comparing availability sets: %s", diff)
+	}
 }
 
 func TestCreateAgentAvailabilitySets(t *testing.T) {
@@ -233,4 +271,40 @@ func TestCreateAgentAvailabilitySets(t *testing.T) {
 	if diff != "" {
 		t.Errorf("unexpected error while comparing availability sets: %s", diff)
 	}
+
+	// Test availability set in an existing proximity placement group
+	ppgID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/proximityPlacementGroups/PPG_NAME"
+	profile = &api.AgentPoolProfile{
+		Name:                      "foobar",
+		StorageProfile:            api.ManagedDisks,
+		ProximityPlacementGroupID: ppgID,
+	}
+
+	avSet = createAgentAvailabilitySets(profile)
+
+	expectedAvSet = AvailabilitySetARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionCompute')]",
+		},
+		AvailabilitySet: compute.AvailabilitySet{
+			Name:     to.StringPtr("[variables('foobarAvailabilitySet')]"),
+			Location: to.StringPtr("[variables('location')]"),
+			Type:     to.StringPtr("Microsoft.Compute/availabilitySets"),
+			AvailabilitySetProperties: &compute.AvailabilitySetProperties{
+				PlatformUpdateDomainCount: to.Int32Ptr(3),
+				ProximityPlacementGroup: &compute.SubResource{
+					ID: to.StringPtr(ppgID),
+				},
+			},
+			Sku: &compute.Sku{
+				Name: to.StringPtr("Aligned"),
+			},
+		},
+	}
+
+	diff = cmp.Diff(avSet, expectedAvSet)
+
+	if diff != "" {
+		t.Errorf("unexpected error while comparing availability sets: %s", diff)
+	}
 }
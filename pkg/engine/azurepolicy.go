@@ -0,0 +1,34 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+)
+
+// gatekeeperKind is a kind of resource gatekeeper syncs into its cache, for the constraint templates referring to the
+// other resources of the cluster
+type gatekeeperKind struct {
+	Group   string
+	Version string
+	Kind    string
+}
+
+// getGatekeeperSyncOnlyKinds returns the kinds of resources of the comma-separated [group/]version/kind list of the
+// syncOnly config of the azure-policy addon
+func getGatekeeperSyncOnlyKinds(addon api.KubernetesAddon) []gatekeeperKind {
+	var kinds []gatekeeperKind
+	for _, kind := range strings.Split(addon.Config["syncOnly"], ",") {
+		parts := strings.Split(strings.TrimSpace(kind), "/")
+		switch len(parts) {
+		case 2:
+			kinds = append(kinds, gatekeeperKind{Version: parts[0], Kind: parts[1]})
+		case 3:
+			kinds = append(kinds, gatekeeperKind{Group: parts[0], Version: parts[1], Kind: parts[2]})
+		}
+	}
+	return kinds
+}
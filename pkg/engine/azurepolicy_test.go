@@ -0,0 +1,46 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetGatekeeperSyncOnlyKinds(t *testing.T) {
+	cases := []struct {
+		name     string
+		syncOnly string
+		expected []gatekeeperKind
+	}{
+		{
+			name: "no synced kinds",
+		},
+		{
+			name:     "core and grouped kinds",
+			syncOnly: "v1/Namespace, extensions/v1beta1/Ingress",
+			expected: []gatekeeperKind{
+				{Version: "v1", Kind: "Namespace"},
+				{Group: "extensions", Version: "v1beta1", Kind: "Ingress"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			addon := api.KubernetesAddon{
+				Name:   AzurePolicyAddonName,
+				Config: map[string]string{"syncOnly": c.syncOnly},
+			}
+			actual := getGatekeeperSyncOnlyKinds(addon)
+			if diff := cmp.Diff(c.expected, actual); diff != "" {
+				t.Errorf("unexpected diff while comparing synced kinds: %s", diff)
+			}
+		})
+	}
+}
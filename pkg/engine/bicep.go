@@ -0,0 +1,1210 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// BicepFileName is the name of the Bicep file written alongside the ARM template
+const BicepFileName = "azuredeploy.bicep"
+
+// bicepIndent is the indentation of a level of the Bicep file
+const bicepIndent = "  "
+
+// bicepKeywords cannot be the name of a Bicep symbol
+var bicepKeywords = map[string]bool{
+	"assert": true, "existing": true, "extension": true, "false": true, "for": true, "func": true, "if": true, "import": true, "in": true,
+	"metadata": true, "module": true, "null": true, "output": true, "param": true, "resource": true, "targetScope": true, "true": true,
+	"type": true, "using": true, "var": true,
+}
+
+// bicepFunctions are the ARM template functions by lowercase name, with the casing of their Bicep equivalent
+var bicepFunctions = map[string]string{}
+
+// bicepAzFunctions are the functions of the az namespace of Bicep, the others are in the sys namespace
+var bicepAzFunctions = map[string]bool{}
+
+func init() {
+	for _, f := range []string{"array", "base64", "base64ToJson", "base64ToString", "bool", "coalesce", "concat", "contains", "dataUri",
+		"dataUriToString", "dateTimeAdd", "empty", "endsWith", "first", "format", "guid", "indexOf", "int", "intersection", "json", "last",
+		"lastIndexOf", "length", "max", "min", "padLeft", "range", "replace", "skip", "split", "startsWith", "string", "substring", "take",
+		"toLower", "toUpper", "trim", "union", "uniqueString", "uri", "uriComponent", "uriComponentToString", "utcNow"} {
+		bicepFunctions[strings.ToLower(f)] = f
+	}
+	for _, f := range []string{"deployment", "environment", "extensionResourceId", "listKeys", "managementGroup", "pickZones", "providers",
+		"reference", "resourceGroup", "resourceId", "subscription", "subscriptionResourceId", "tenant", "tenantResourceId"} {
+		bicepFunctions[strings.ToLower(f)] = f
+		bicepAzFunctions[f] = true
+	}
+}
+
+// GenerateBicep returns the Bicep equivalent of an ARM template, so that it can be deployed with the same parameters file.
+// The parameters keep the names of the template, the variables and resources are symbols named after them.
+func GenerateBicep(template string) ([]byte, error) {
+	root, err := parseOrderedJSON(template)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the ARM template")
+	}
+	t, ok := root.(*orderedObject)
+	if !ok {
+		return nil, errors.New("the ARM template is not a JSON object")
+	}
+	for _, unsupported := range []string{"functions"} {
+		if _, ok := t.get(unsupported); ok {
+			return nil, errors.Errorf("the ARM template %s cannot be converted to Bicep", unsupported)
+		}
+	}
+	g, err := newBicepGenerator(t)
+	if err != nil {
+		return nil, err
+	}
+	return g.generate()
+}
+
+type bicepResource struct {
+	symbol string
+	value  *orderedObject
+	// canonical is the type and name of the resource that identify it in a dependency, name its name alone
+	canonical string
+	name      string
+	copyName  string
+}
+
+type bicepGenerator struct {
+	parameters *orderedObject
+	variables  *orderedObject
+	resources  []*bicepResource
+	outputs    *orderedObject
+	// symbols are the Bicep symbols of the parameters and variables by lowercase name, the ARM references are case insensitive
+	parameterSymbols map[string]string
+	variableSymbols  map[string]string
+	declared         map[string]bool
+	// flattening are the variables being flattened, to detect the variables that reference themselves
+	flattening map[string]bool
+}
+
+// bicepScope is where an expression is translated, in a resource loop or a property loop with their index
+type bicepScope struct {
+	indent        string
+	resourceIndex string
+	loopIndexes   map[string]string
+}
+
+func (s bicepScope) nested() bicepScope {
+	s.indent += bicepIndent
+	return s
+}
+
+func newBicepGenerator(t *orderedObject) (*bicepGenerator, error) {
+	g := &bicepGenerator{
+		parameters:       t.getObject("parameters"),
+		variables:        t.getObject("variables"),
+		outputs:          t.getObject("outputs"),
+		parameterSymbols: map[string]string{},
+		variableSymbols:  map[string]string{},
+		declared:         map[string]bool{},
+		flattening:       map[string]bool{},
+	}
+	for _, o := range []**orderedObject{&g.parameters, &g.variables, &g.outputs} {
+		if *o == nil {
+			*o = &orderedObject{values: map[string]interface{}{}}
+		}
+	}
+	for _, name := range g.parameters.keys {
+		if !isBicepIdentifier(name) || bicepKeywords[name] {
+			return nil, errors.Errorf("parameter %s is not a valid Bicep identifier", name)
+		}
+		g.parameterSymbols[strings.ToLower(name)] = name
+		g.declared[name] = true
+	}
+	for _, name := range g.variables.keys {
+		if name == "copy" {
+			return nil, errors.New("the ARM template variable loops cannot be converted to Bicep")
+		}
+		g.variableSymbols[strings.ToLower(name)] = g.declare(name, "Var")
+	}
+
+	resources, _ := t.get("resources")
+	list, ok := resources.([]interface{})
+	if resources != nil && !ok {
+		return nil, errors.New("the ARM template resources are not an array")
+	}
+	for i, item := range list {
+		value, ok := item.(*orderedObject)
+		if !ok {
+			return nil, errors.Errorf("resource %d of the ARM template is not an object", i)
+		}
+		if _, ok := value.get("resources"); ok {
+			return nil, errors.Errorf("the child resources of resource %d cannot be converted to Bicep", i)
+		}
+		r, err := g.newBicepResource(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "resource %d", i)
+		}
+		g.resources = append(g.resources, r)
+	}
+	return g, nil
+}
+
+func (g *bicepGenerator) newBicepResource(value *orderedObject) (*bicepResource, error) {
+	resourceType, _ := value.get("type")
+	typeName, ok := resourceType.(string)
+	if !ok || typeName == "" {
+		return nil, errors.New("the resource has no type")
+	}
+	nameValue, _ := value.get("name")
+	name, ok := nameValue.(string)
+	if !ok {
+		return nil, errors.New("the resource has no name")
+	}
+	nameExpression, err := parseARMValue(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing the name %s", name)
+	}
+	r := &bicepResource{
+		value: value,
+		name:  g.flatten(nameExpression),
+	}
+	r.canonical = strings.ToLower(typeName) + "/" + r.name
+	if c := value.getObject("copy"); c != nil {
+		if copyName, ok := c.get("name"); ok {
+			r.copyName = strings.ToLower(fmt.Sprint(copyName))
+		}
+	}
+
+	// the symbol is named after the variable or parameter naming the resource, e.g. masterLbName is masterLbLoadBalancer
+	segments := strings.Split(typeName, "/")
+	kind := singular(segments[len(segments)-1])
+	symbol := lowerFirst(kind)
+	if prefix := namePrefix(nameExpression); prefix != "" {
+		symbol = prefix
+		if !strings.HasSuffix(strings.ToLower(prefix), strings.ToLower(kind)) {
+			symbol += upperFirst(kind)
+		}
+	}
+	// the symbols of the parameters and variables win over those of the resources, the resources of a kind are numbered
+	suffix := "Resource"
+	for _, other := range g.resources {
+		if other.symbol == sanitizeBicepIdentifier(symbol) {
+			suffix = ""
+		}
+	}
+	r.symbol = g.declare(symbol, suffix)
+	return r, nil
+}
+
+// declare returns a Bicep symbol named after name that is not declared yet and declares it
+func (g *bicepGenerator) declare(name, suffix string) string {
+	symbol := sanitizeBicepIdentifier(name)
+	if g.declared[symbol] || bicepKeywords[symbol] || bicepFunctions[strings.ToLower(symbol)] != "" {
+		symbol += suffix
+	}
+	for i := 2; g.declared[symbol] || bicepKeywords[symbol]; i++ {
+		symbol = fmt.Sprintf("%s%s%d", sanitizeBicepIdentifier(name), suffix, i)
+	}
+	g.declared[symbol] = true
+	return symbol
+}
+
+func (g *bicepGenerator) generate() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Generated by aks-engine, deploy it with the azuredeploy.parameters.json parameters file\n")
+	top := bicepScope{}
+
+	for _, name := range g.parameters.keys {
+		value, _ := g.parameters.get(name)
+		p, ok := value.(*orderedObject)
+		if !ok {
+			return nil, errors.Errorf("parameter %s is not an object", name)
+		}
+		if err := g.writeParameter(&b, name, p, top); err != nil {
+			return nil, errors.Wrapf(err, "parameter %s", name)
+		}
+	}
+
+	for _, name := range g.variables.keys {
+		value, _ := g.variables.get(name)
+		v, err := g.value(value, top)
+		if err != nil {
+			return nil, errors.Wrapf(err, "variable %s", name)
+		}
+		fmt.Fprintf(&b, "\nvar %s = %s\n", g.variableSymbols[strings.ToLower(name)], v)
+	}
+
+	for _, r := range g.resources {
+		if err := g.writeResource(&b, r); err != nil {
+			return nil, errors.Wrapf(err, "resource %s", r.symbol)
+		}
+	}
+
+	for _, name := range g.outputs.keys {
+		value, _ := g.outputs.get(name)
+		o, ok := value.(*orderedObject)
+		if !ok {
+			return nil, errors.Errorf("output %s is not an object", name)
+		}
+		outputType, _ := o.get("type")
+		v, _ := o.get("value")
+		translated, err := g.value(v, top)
+		if err != nil {
+			return nil, errors.Wrapf(err, "output %s", name)
+		}
+		fmt.Fprintf(&b, "\noutput %s %s = %s\n", name, bicepType(fmt.Sprint(outputType)), translated)
+	}
+	return b.Bytes(), nil
+}
+
+func (g *bicepGenerator) writeParameter(b *bytes.Buffer, name string, p *orderedObject, scope bicepScope) error {
+	b.WriteString("\n")
+	if metadata := p.getObject("metadata"); metadata != nil {
+		if description, ok := metadata.get("description"); ok {
+			fmt.Fprintf(b, "@description(%s)\n", bicepString(fmt.Sprint(description)))
+		}
+	}
+	if allowed, ok := p.get("allowedValues"); ok {
+		v, err := g.value(allowed, scope)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "@allowed(%s)\n", v)
+	}
+	for _, decorator := range []string{"minValue", "maxValue", "minLength", "maxLength"} {
+		if v, ok := p.get(decorator); ok {
+			fmt.Fprintf(b, "@%s(%v)\n", decorator, v)
+		}
+	}
+	parameterType, _ := p.get("type")
+	t := fmt.Sprint(parameterType)
+	if strings.HasPrefix(strings.ToLower(t), "secure") {
+		b.WriteString("@secure()\n")
+	}
+	fmt.Fprintf(b, "param %s %s", name, bicepType(t))
+	if defaultValue, ok := p.get("defaultValue"); ok {
+		v, err := g.value(defaultValue, scope)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(b, " = %s", v)
+	}
+	b.WriteString("\n")
+	return nil
+}
+
+func (g *bicepGenerator) writeResource(b *bytes.Buffer, r *bicepResource) error {
+	resourceType, _ := r.value.get("type")
+	apiVersionValue, _ := r.value.get("apiVersion")
+	apiVersion, ok := apiVersionValue.(string)
+	if !ok {
+		return errors.New("the resource has no apiVersion")
+	}
+	apiVersionExpression, err := parseARMValue(apiVersion)
+	if err != nil {
+		return errors.Wrapf(err, "parsing the apiVersion %s", apiVersion)
+	}
+	if apiVersion, ok = g.evaluate(apiVersionExpression); !ok {
+		return errors.Errorf("the apiVersion %s is not a constant", apiVersion)
+	}
+
+	b.WriteString("\n")
+	if comments, ok := r.value.get("comments"); ok {
+		fmt.Fprintf(b, "// %s\n", strings.Replace(fmt.Sprint(comments), "\n", " ", -1))
+	}
+	scope := bicepScope{}
+	var loop, condition string
+	if c := r.value.getObject("copy"); c != nil {
+		count, _ := c.get("count")
+		n, err := g.value(count, scope)
+		if err != nil {
+			return errors.Wrap(err, "translating the copy count")
+		}
+		if mode, _ := c.get("mode"); strings.EqualFold(fmt.Sprint(mode), "serial") {
+			batchSize, ok := c.get("batchSize")
+			if !ok {
+				batchSize = 1
+			}
+			fmt.Fprintf(b, "@batchSize(%v)\n", batchSize)
+		}
+		scope.resourceIndex = "i"
+		loop = fmt.Sprintf("for %s in range(0, %s): ", scope.resourceIndex, n)
+	}
+	if c, ok := r.value.get("condition"); ok {
+		v, err := g.value(c, scope)
+		if err != nil {
+			return errors.Wrap(err, "translating the condition")
+		}
+		condition = fmt.Sprintf("if (%s) ", v)
+	}
+
+	body := &orderedObject{values: map[string]interface{}{}}
+	for _, key := range r.value.keys {
+		switch key {
+		case "apiVersion", "type", "copy", "condition", "comments", "dependsOn":
+			continue
+		}
+		value, _ := r.value.get(key)
+		body.set(key, value)
+	}
+	v, err := g.value(body, scope)
+	if err != nil {
+		return err
+	}
+	dependencies, err := g.dependencies(r, scope.nested())
+	if err != nil {
+		return err
+	}
+	if dependencies != "" {
+		v = strings.TrimSuffix(v, "}") + dependencies + "}"
+	}
+
+	declaration := fmt.Sprintf("resource %s '%s@%s' = ", r.symbol, resourceType, apiVersion)
+	if loop != "" {
+		fmt.Fprintf(b, "%s[%s%s%s]\n", declaration, loop, condition, v)
+	} else {
+		fmt.Fprintf(b, "%s%s%s\n", declaration, condition, v)
+	}
+	return nil
+}
+
+// dependencies returns the dependsOn of the Bicep resource, the dependencies of the ARM resource are matched against the types and names
+// of the resources, and their copy loops
+func (g *bicepGenerator) dependencies(r *bicepResource, scope bicepScope) (string, error) {
+	value, ok := r.value.get("dependsOn")
+	if !ok {
+		return "", nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return "", errors.New("dependsOn is not an array")
+	}
+	var symbols, unresolved []string
+	seen := map[string]bool{}
+	for _, item := range list {
+		dependency, ok := item.(string)
+		if !ok {
+			return "", errors.New("dependsOn is not an array of strings")
+		}
+		e, err := parseARMValue(dependency)
+		if err != nil {
+			return "", errors.Wrapf(err, "parsing the dependency %s", dependency)
+		}
+		canonical := g.flatten(e)
+		var found *bicepResource
+		for _, candidate := range g.resources {
+			if candidate != r && (canonical == candidate.canonical || canonical == candidate.name || (candidate.copyName != "" && canonical == candidate.copyName)) {
+				found = candidate
+				break
+			}
+		}
+		if found == nil {
+			// the names of the resources of a loop are computed from the loop index, e.g. the storage accounts of the virtual machines,
+			// the dependency is the only resource whose name has the same constant parts
+			pattern := wildcard(canonical)
+			for _, candidate := range g.resources {
+				if candidate != r && (pattern == wildcard(candidate.canonical) || pattern == wildcard(candidate.name)) {
+					if found != nil {
+						found = nil
+						break
+					}
+					found = candidate
+				}
+			}
+		}
+		if found == nil {
+			unresolved = append(unresolved, dependency)
+			continue
+		}
+		if !seen[found.symbol] {
+			seen[found.symbol] = true
+			symbols = append(symbols, found.symbol)
+		}
+	}
+	if len(symbols) == 0 && len(unresolved) == 0 {
+		return "", nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%sdependsOn: [\n", scope.indent)
+	for _, s := range symbols {
+		fmt.Fprintf(&b, "%s%s%s\n", scope.indent, bicepIndent, s)
+	}
+	for _, u := range unresolved {
+		fmt.Fprintf(&b, "%s%s// %s is not a resource of the template\n", scope.indent, bicepIndent, u)
+	}
+	fmt.Fprintf(&b, "%s]\n", scope.indent)
+	return b.String(), nil
+}
+
+// value returns the Bicep expression of a JSON value of the template at the indentation of the scope
+func (g *bicepGenerator) value(v interface{}, scope bicepScope) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return fmt.Sprint(t), nil
+	case json.Number:
+		if _, err := t.Int64(); err != nil {
+			// Bicep has no floating point literals
+			return fmt.Sprintf("json(%s)", bicepString(t.String())), nil
+		}
+		return t.String(), nil
+	case int:
+		return fmt.Sprint(t), nil
+	case string:
+		e, err := parseARMValue(t)
+		if err != nil {
+			return "", errors.Wrapf(err, "parsing the expression %s", t)
+		}
+		return g.expression(e, scope, true)
+	case []interface{}:
+		if len(t) == 0 {
+			return "[]", nil
+		}
+		var b strings.Builder
+		b.WriteString("[\n")
+		inner := scope.nested()
+		for _, item := range t {
+			s, err := g.value(item, inner)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, "%s%s\n", inner.indent, s)
+		}
+		fmt.Fprintf(&b, "%s]", scope.indent)
+		return b.String(), nil
+	case *orderedObject:
+		if len(t.keys) == 0 {
+			return "{}", nil
+		}
+		var b strings.Builder
+		b.WriteString("{\n")
+		inner := scope.nested()
+		for _, key := range t.keys {
+			item, _ := t.get(key)
+			if key == "copy" {
+				if loops, ok := item.([]interface{}); ok {
+					if err := g.propertyLoops(&b, loops, inner); err != nil {
+						return "", err
+					}
+					continue
+				}
+			}
+			s, err := g.value(item, inner)
+			if err != nil {
+				return "", errors.Wrapf(err, "property %s", key)
+			}
+			fmt.Fprintf(&b, "%s%s: %s\n", inner.indent, bicepKey(key), s)
+		}
+		fmt.Fprintf(&b, "%s}", scope.indent)
+		return b.String(), nil
+	}
+	return "", errors.Errorf("unexpected JSON value %v", v)
+}
+
+// propertyLoops writes the properties of an ARM property copy as Bicep loops
+func (g *bicepGenerator) propertyLoops(b *strings.Builder, loops []interface{}, scope bicepScope) error {
+	for _, item := range loops {
+		loop, ok := item.(*orderedObject)
+		if !ok {
+			return errors.New("the property copy is not an array of objects")
+		}
+		nameValue, _ := loop.get("name")
+		name := fmt.Sprint(nameValue)
+		count, _ := loop.get("count")
+		n, err := g.value(count, scope)
+		if err != nil {
+			return errors.Wrapf(err, "translating the count of the property copy %s", name)
+		}
+		inner := scope
+		inner.loopIndexes = map[string]string{}
+		for k, v := range scope.loopIndexes {
+			inner.loopIndexes[k] = v
+		}
+		index := fmt.Sprintf("%sIndex", sanitizeBicepIdentifier(lowerFirst(name)))
+		inner.loopIndexes[strings.ToLower(name)] = index
+		input, _ := loop.get("input")
+		s, err := g.value(input, inner)
+		if err != nil {
+			return errors.Wrapf(err, "translating the input of the property copy %s", name)
+		}
+		fmt.Fprintf(b, "%s%s: [for %s in range(0, %s): %s]\n", scope.indent, bicepKey(name), index, n, s)
+	}
+	return nil
+}
+
+// armExpression is a node of an ARM template expression
+type armExpression struct {
+	// literal is a string or number literal when function is empty
+	literal  interface{}
+	function string
+	args     []*armExpression
+	// target is the expression a property or index is accessed on, property is the accessed property or index the accessed index
+	target   *armExpression
+	property string
+	index    *armExpression
+}
+
+// parseARMValue parses a string of the template, strings in brackets are expressions and the others are literals
+func parseARMValue(s string) (*armExpression, error) {
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return &armExpression{literal: s}, nil
+	}
+	if strings.HasPrefix(s, "[[") {
+		return &armExpression{literal: s[1:]}, nil
+	}
+	p := &armParser{input: s[1 : len(s)-1]}
+	e, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos != len(p.input) {
+		return nil, errors.Errorf("unexpected %q at %d", p.input[p.pos:], p.pos)
+	}
+	return e, nil
+}
+
+type armParser struct {
+	input string
+	pos   int
+}
+
+func (p *armParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *armParser) consume(c byte) bool {
+	p.skipSpaces()
+	if p.pos < len(p.input) && p.input[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *armParser) identifier() string {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.input) && (isIdentifierChar(rune(p.input[p.pos]))) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *armParser) parse() (*armExpression, error) {
+	e, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.consume('.'):
+			property := p.identifier()
+			if property == "" {
+				return nil, errors.Errorf("expected a property at %d", p.pos)
+			}
+			e = &armExpression{target: e, property: property}
+		case p.consume('['):
+			index, err := p.parse()
+			if err != nil {
+				return nil, err
+			}
+			if !p.consume(']') {
+				return nil, errors.Errorf("expected ] at %d", p.pos)
+			}
+			e = &armExpression{target: e, index: index}
+		default:
+			return e, nil
+		}
+	}
+}
+
+func (p *armParser) primary() (*armExpression, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return nil, errors.New("unexpected end of the expression")
+	}
+	c := p.input[p.pos]
+	switch {
+	case c == '\'':
+		var b strings.Builder
+		for p.pos++; ; p.pos++ {
+			if p.pos >= len(p.input) {
+				return nil, errors.New("unterminated string")
+			}
+			if p.input[p.pos] == '\'' {
+				if p.pos+1 < len(p.input) && p.input[p.pos+1] == '\'' {
+					b.WriteByte('\'')
+					p.pos++
+					continue
+				}
+				p.pos++
+				return &armExpression{literal: b.String()}, nil
+			}
+			b.WriteByte(p.input[p.pos])
+		}
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos++; p.pos < len(p.input) && (p.input[p.pos] >= '0' && p.input[p.pos] <= '9' || p.input[p.pos] == '.'); p.pos++ {
+		}
+		return &armExpression{literal: json.Number(p.input[start:p.pos])}, nil
+	}
+	name := p.identifier()
+	if name == "" {
+		return nil, errors.Errorf("unexpected %q at %d", c, p.pos)
+	}
+	if !p.consume('(') {
+		return nil, errors.Errorf("expected ( after %s", name)
+	}
+	e := &armExpression{function: name}
+	if p.consume(')') {
+		return e, nil
+	}
+	for {
+		arg, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		e.args = append(e.args, arg)
+		if p.consume(')') {
+			return e, nil
+		}
+		if !p.consume(',') {
+			return nil, errors.Errorf("expected , or ) at %d", p.pos)
+		}
+	}
+}
+
+var bicepOperators = map[string]string{
+	"add": "+", "sub": "-", "mul": "*", "div": "/", "mod": "%", "equals": "==", "and": "&&", "or": "||",
+	"less": "<", "lessorequals": "<=", "greater": ">", "greaterorequals": ">=",
+}
+
+// expression returns the Bicep expression of an ARM expression, operators are parenthesized unless the expression is top-level
+func (g *bicepGenerator) expression(e *armExpression, scope bicepScope, top bool) (string, error) {
+	if e.target != nil {
+		target, err := g.expression(e.target, scope, false)
+		if err != nil {
+			return "", err
+		}
+		if e.index != nil {
+			index, err := g.expression(e.index, scope, true)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s[%s]", target, index), nil
+		}
+		return fmt.Sprintf("%s.%s", target, e.property), nil
+	}
+	if e.function == "" {
+		if s, ok := e.literal.(string); ok {
+			return bicepString(s), nil
+		}
+		return fmt.Sprint(e.literal), nil
+	}
+
+	args := make([]string, len(e.args))
+	for i, arg := range e.args {
+		s, err := g.expression(arg, scope, false)
+		if err != nil {
+			return "", err
+		}
+		args[i] = s
+	}
+	parenthesize := func(s string) string {
+		if top {
+			return s
+		}
+		return "(" + s + ")"
+	}
+	function := strings.ToLower(e.function)
+	switch function {
+	case "parameters", "variables":
+		if len(e.args) != 1 || e.args[0].function != "" {
+			return "", errors.Errorf("%s() argument is not a constant", e.function)
+		}
+		name := strings.ToLower(fmt.Sprint(e.args[0].literal))
+		symbols := g.parameterSymbols
+		if function == "variables" {
+			symbols = g.variableSymbols
+		}
+		symbol, ok := symbols[name]
+		if !ok {
+			return "", errors.Errorf("%s('%s') is not declared in the template", e.function, e.args[0].literal)
+		}
+		return symbol, nil
+	case "copyindex":
+		index := scope.resourceIndex
+		offset := args
+		if len(e.args) > 0 {
+			if s, ok := e.args[0].literal.(string); ok && e.args[0].function == "" {
+				index = scope.loopIndexes[strings.ToLower(s)]
+				offset = args[1:]
+			}
+		}
+		if index == "" {
+			return "", errors.New("copyIndex() is not in a copy loop")
+		}
+		if len(offset) == 0 {
+			return index, nil
+		}
+		return parenthesize(fmt.Sprintf("%s + %s", index, offset[0])), nil
+	case "concat":
+		// the concatenation of strings is an interpolated string, the concatenation of arrays is left to concat()
+		for _, arg := range e.args {
+			if isStringConcatenation(arg) {
+				return g.interpolation(e.args, scope)
+			}
+		}
+	case "if":
+		if len(args) == 3 {
+			return parenthesize(fmt.Sprintf("%s ? %s : %s", args[0], args[1], args[2])), nil
+		}
+	case "not":
+		if len(args) == 1 {
+			return "!" + args[0], nil
+		}
+	case "true", "false", "null":
+		if len(args) == 0 {
+			return function, nil
+		}
+	case "createarray":
+		if len(args) == 0 {
+			return "[]", nil
+		}
+		var b strings.Builder
+		b.WriteString("[\n")
+		inner := scope.nested()
+		for _, arg := range e.args {
+			item, err := g.expression(arg, inner, true)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, "%s%s\n", inner.indent, item)
+		}
+		fmt.Fprintf(&b, "%s]", scope.indent)
+		return b.String(), nil
+	case "createobject":
+		if len(args)%2 == 0 {
+			var b strings.Builder
+			if len(args) == 0 {
+				return "{}", nil
+			}
+			b.WriteString("{\n")
+			inner := scope.nested()
+			for i := 0; i < len(args); i += 2 {
+				key := args[i]
+				if s, ok := e.args[i].literal.(string); ok && e.args[i].function == "" {
+					key = bicepKey(s)
+				} else {
+					key = "'${" + key + "}'"
+				}
+				value, err := g.expression(e.args[i+1], inner, true)
+				if err != nil {
+					return "", err
+				}
+				fmt.Fprintf(&b, "%s%s: %s\n", inner.indent, key, value)
+			}
+			fmt.Fprintf(&b, "%s}", scope.indent)
+			return b.String(), nil
+		}
+	}
+	if operator, ok := bicepOperators[function]; ok && len(args) >= 2 {
+		return parenthesize(strings.Join(args, " "+operator+" ")), nil
+	}
+
+	name, ok := bicepFunctions[function]
+	if !ok {
+		name = e.function
+	}
+	if g.declared[name] {
+		// a parameter hides the function of the same name
+		if bicepAzFunctions[name] {
+			name = "az." + name
+		} else {
+			name = "sys." + name
+		}
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(args, ", ")), nil
+}
+
+// isStringConcatenation returns true if an argument of concat() is a string or a number, which are only concatenated to strings
+func isStringConcatenation(arg *armExpression) bool {
+	if arg.target != nil {
+		return false
+	}
+	if arg.function == "" {
+		_, ok := arg.literal.(string)
+		return ok
+	}
+	switch strings.ToLower(arg.function) {
+	case "copyindex", "add", "sub", "mul", "div", "mod", "length", "string", "int":
+		return true
+	}
+	return false
+}
+
+// interpolation returns the interpolated string of the concatenation of the arguments
+func (g *bicepGenerator) interpolation(args []*armExpression, scope bicepScope) (string, error) {
+	var b strings.Builder
+	b.WriteString("'")
+	for _, arg := range args {
+		if s, ok := arg.literal.(string); ok && arg.function == "" && arg.target == nil {
+			b.WriteString(escapeBicepString(s))
+			continue
+		}
+		if arg.function != "" && strings.EqualFold(arg.function, "concat") && arg.target == nil {
+			nested, err := g.interpolation(arg.args, scope)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(strings.TrimSuffix(strings.TrimPrefix(nested, "'"), "'"))
+			continue
+		}
+		s, err := g.expression(arg, scope, true)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "${%s}", s)
+	}
+	b.WriteString("'")
+	return b.String(), nil
+}
+
+// flatten returns the canonical form of a resource name or id expression to match the dependencies against, the variables are
+// substituted and the concatenations flattened. The constant parts are lowercase, the others are the ARM expression between \x01 and \x02.
+func (g *bicepGenerator) flatten(e *armExpression) string {
+	if e.target == nil && e.function == "" {
+		return strings.ToLower(fmt.Sprint(e.literal))
+	}
+	if e.target == nil {
+		function := strings.ToLower(e.function)
+		switch function {
+		case "concat":
+			var b strings.Builder
+			for _, arg := range e.args {
+				b.WriteString(g.flatten(arg))
+			}
+			return b.String()
+		case "variables":
+			if len(e.args) == 1 && e.args[0].function == "" {
+				name := strings.ToLower(fmt.Sprint(e.args[0].literal))
+				if value, ok := g.variableValue(name); ok && !g.flattening[name] {
+					if s, ok := value.(string); ok {
+						if v, err := parseARMValue(s); err == nil {
+							g.flattening[name] = true
+							defer delete(g.flattening, name)
+							return g.flatten(v)
+						}
+					}
+				}
+			}
+		case "resourceid":
+			// the optional subscription and resource group precede the type, which is the first argument with a namespace
+			for i, arg := range e.args {
+				if t := g.flatten(arg); strings.Contains(t, "/") && !strings.HasPrefix(t, "\x01") {
+					parts := []string{t}
+					for _, name := range e.args[i+1:] {
+						parts = append(parts, g.flatten(name))
+					}
+					return strings.Join(parts, "/")
+				}
+			}
+		}
+		args := make([]string, len(e.args))
+		for i, arg := range e.args {
+			args[i] = g.flatten(arg)
+		}
+		return fmt.Sprintf("\x01%s(%s)\x02", function, strings.Join(args, ","))
+	}
+	suffix := "." + strings.ToLower(e.property)
+	if e.index != nil {
+		suffix = "[" + g.flatten(e.index) + "]"
+	}
+	return fmt.Sprintf("\x01%s%s\x02", g.flatten(e.target), suffix)
+}
+
+// wildcard replaces the expressions of a canonical form by a wildcard, the consecutive expressions by a single one
+func wildcard(canonical string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range canonical {
+		switch {
+		case r == '\x01':
+			if depth == 0 && !strings.HasSuffix(b.String(), "*") {
+				b.WriteRune('*')
+			}
+			depth++
+		case r == '\x02':
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// evaluate returns the value of a constant string expression
+func (g *bicepGenerator) evaluate(e *armExpression) (string, bool) {
+	if e.target != nil {
+		return "", false
+	}
+	if e.function == "" {
+		s, ok := e.literal.(string)
+		return s, ok
+	}
+	switch strings.ToLower(e.function) {
+	case "concat":
+		var b strings.Builder
+		for _, arg := range e.args {
+			s, ok := g.evaluate(arg)
+			if !ok {
+				return "", false
+			}
+			b.WriteString(s)
+		}
+		return b.String(), true
+	case "variables", "parameters":
+		if len(e.args) != 1 || e.args[0].function != "" {
+			return "", false
+		}
+		name := strings.ToLower(fmt.Sprint(e.args[0].literal))
+		var value interface{}
+		var ok bool
+		if strings.EqualFold(e.function, "variables") {
+			value, ok = g.variableValue(name)
+		} else if p := g.parameters.getObjectFold(name); p != nil {
+			value, ok = p.get("defaultValue")
+		}
+		s, isString := value.(string)
+		if !ok || !isString || g.flattening[name] {
+			return "", false
+		}
+		v, err := parseARMValue(s)
+		if err != nil {
+			return "", false
+		}
+		g.flattening[name] = true
+		defer delete(g.flattening, name)
+		return g.evaluate(v)
+	}
+	return "", false
+}
+
+func (g *bicepGenerator) variableValue(lowerName string) (interface{}, bool) {
+	for _, name := range g.variables.keys {
+		if strings.ToLower(name) == lowerName {
+			return g.variables.get(name)
+		}
+	}
+	return nil, false
+}
+
+// namePrefix returns the name of the first variable or parameter in a resource name, without the Name suffix
+func namePrefix(e *armExpression) string {
+	if e.function == "" && e.target == nil {
+		return ""
+	}
+	switch strings.ToLower(e.function) {
+	case "variables", "parameters":
+		if len(e.args) == 1 && e.args[0].function == "" {
+			name := fmt.Sprint(e.args[0].literal)
+			for _, suffix := range []string{"VMNamePrefix", "NamePrefix", "Name", "Prefix"} {
+				if trimmed := strings.TrimSuffix(name, suffix); trimmed != name && trimmed != "" {
+					return trimmed
+				}
+			}
+			return name
+		}
+	}
+	for _, arg := range e.args {
+		if prefix := namePrefix(arg); prefix != "" {
+			return prefix
+		}
+	}
+	return ""
+}
+
+func bicepType(armType string) string {
+	switch strings.ToLower(armType) {
+	case "securestring":
+		return "string"
+	case "secureobject":
+		return "object"
+	}
+	return strings.ToLower(armType)
+}
+
+func bicepKey(key string) string {
+	if isBicepIdentifier(key) {
+		return key
+	}
+	return bicepString(key)
+}
+
+func bicepString(s string) string {
+	return "'" + escapeBicepString(s) + "'"
+}
+
+var bicepStringEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "${", `\${`)
+
+func escapeBicepString(s string) string {
+	return bicepStringEscaper.Replace(s)
+}
+
+var bicepIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func isBicepIdentifier(s string) bool {
+	return bicepIdentifier.MatchString(s)
+}
+
+func isIdentifierChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func sanitizeBicepIdentifier(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r < unicode.MaxASCII && isIdentifierChar(r):
+			if i == 0 && unicode.IsDigit(r) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies"):
+		return strings.TrimSuffix(s, "ies") + "y"
+	case strings.HasSuffix(s, "sses"), strings.HasSuffix(s, "xes"):
+		return strings.TrimSuffix(s, "es")
+	case strings.HasSuffix(s, "s"):
+		return strings.TrimSuffix(s, "s")
+	}
+	return s
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// orderedObject is a JSON object that keeps the order of its keys, so that the Bicep file follows the template
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *orderedObject) get(key string) (interface{}, bool) {
+	if o == nil {
+		return nil, false
+	}
+	v, ok := o.values[key]
+	return v, ok
+}
+
+func (o *orderedObject) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *orderedObject) getObject(key string) *orderedObject {
+	v, _ := o.get(key)
+	object, _ := v.(*orderedObject)
+	return object
+}
+
+func (o *orderedObject) getObjectFold(lowerKey string) *orderedObject {
+	if o == nil {
+		return nil
+	}
+	for _, key := range o.keys {
+		if strings.ToLower(key) == lowerKey {
+			return o.getObject(key)
+		}
+	}
+	return nil
+}
+
+func parseOrderedJSON(s string) (interface{}, error) {
+	d := json.NewDecoder(strings.NewReader(s))
+	d.UseNumber()
+	v, err := decodeOrderedJSON(d)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return v, nil
+}
+
+func decodeOrderedJSON(d *json.Decoder) (interface{}, error) {
+	token, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			o := &orderedObject{values: map[string]interface{}{}}
+			for d.More() {
+				key, err := d.Token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := decodeOrderedJSON(d)
+				if err != nil {
+					return nil, err
+				}
+				o.set(key.(string), v)
+			}
+			_, err := d.Token()
+			return o, err
+		case '[':
+			a := []interface{}{}
+			for d.More() {
+				v, err := decodeOrderedJSON(d)
+				if err != nil {
+					return nil, err
+				}
+				a = append(a, v)
+			}
+			_, err := d.Token()
+			return a, err
+		}
+		return nil, errors.Errorf("unexpected %s", t)
+	}
+	return token, nil
+}
@@ -0,0 +1,136 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateBicep(t *testing.T) {
+	template := `{
+  "parameters": {
+    "location": {"type": "string", "defaultValue": "[resourceGroup().location]", "metadata": {"description": "Sets the location"}},
+    "masterCount": {"type": "int", "allowedValues": [1, 3, 5], "minValue": 1},
+    "apiServerCertificate": {"type": "securestring"}
+  },
+  "variables": {
+    "location": "[parameters('location')]",
+    "apiVersionNetwork": "2018-08-01",
+    "masterVMNamePrefix": "k8s-master-",
+    "nsgName": "[concat(variables('masterVMNamePrefix'), 'nsg')]",
+    "nsgID": "[resourceId('Microsoft.Network/networkSecurityGroups', variables('nsgName'))]",
+    "script": "echo ${HOME} 'quoted'",
+    "literal": "[[not an expression]",
+    "zones": "[if(equals(parameters('masterCount'), 1), createArray('1'), createArray('1', '2'))]"
+  },
+  "resources": [
+    {
+      "apiVersion": "[variables('apiVersionNetwork')]",
+      "type": "Microsoft.Network/networkSecurityGroups",
+      "name": "[variables('nsgName')]",
+      "location": "[variables('location')]",
+      "properties": {"securityRules": []}
+    },
+    {
+      "apiVersion": "[variables('apiVersionNetwork')]",
+      "type": "Microsoft.Network/networkInterfaces",
+      "name": "[concat(variables('masterVMNamePrefix'), 'nic-', copyIndex(1))]",
+      "location": "[variables('location')]",
+      "copy": {"count": "[sub(parameters('masterCount'), 1)]", "name": "nicLoopNode"},
+      "dependsOn": ["[variables('nsgID')]"],
+      "properties": {"networkSecurityGroup": {"id": "[variables('nsgID')]"}, "primary": "[not(equals(copyIndex(), 0))]"}
+    },
+    {
+      "apiVersion": "2018-10-01",
+      "type": "Microsoft.Compute/virtualMachines",
+      "name": "[concat(variables('masterVMNamePrefix'), copyIndex())]",
+      "copy": {"count": "[parameters('masterCount')]", "name": "vmLoopNode", "mode": "Serial"},
+      "dependsOn": ["nicLoopNode", "[concat('Microsoft.Storage/storageAccounts/', variables('masterVMNamePrefix'))]"],
+      "properties": {}
+    }
+  ],
+  "outputs": {
+    "nsgName": {"type": "string", "value": "[variables('nsgName')]"}
+  }
+}`
+	b, err := GenerateBicep(template)
+	if err != nil {
+		t.Fatalf("unexpected error generating the Bicep file: %s", err)
+	}
+	bicep := string(b)
+	for _, expected := range []string{
+		"@description('Sets the location')\nparam location string = resourceGroup().location\n",
+		"@allowed([\n  1\n  3\n  5\n])\n@minValue(1)\nparam masterCount int\n",
+		"@secure()\nparam apiServerCertificate string\n",
+		"var locationVar = location\n",
+		"var nsgName = '${masterVMNamePrefix}nsg'\n",
+		"var nsgID = resourceId('Microsoft.Network/networkSecurityGroups', nsgName)\n",
+		`var script = 'echo \${HOME} \'quoted\''` + "\n",
+		"var literal = '[not an expression]'\n",
+		"var zones = (masterCount == 1) ? [\n  '1'\n] : [\n  '1'\n  '2'\n]\n",
+		"resource nsgNetworkSecurityGroup 'Microsoft.Network/networkSecurityGroups@2018-08-01' = {\n  name: nsgName\n  location: locationVar\n  properties: {\n    securityRules: []\n  }\n}\n",
+		"resource masterNetworkInterface 'Microsoft.Network/networkInterfaces@2018-08-01' = [for i in range(0, masterCount - 1): {\n  name: '${masterVMNamePrefix}nic-${i + 1}'\n",
+		"    primary: !(i == 0)\n",
+		"  dependsOn: [\n    nsgNetworkSecurityGroup\n  ]\n}]\n",
+		"@batchSize(1)\nresource masterVirtualMachine 'Microsoft.Compute/virtualMachines@2018-10-01' = [for i in range(0, masterCount): {\n  name: '${masterVMNamePrefix}${i}'\n",
+		"    masterNetworkInterface\n    // [concat('Microsoft.Storage/storageAccounts/', variables('masterVMNamePrefix'))] is not a resource of the template\n",
+		"output nsgName string = nsgName\n",
+	} {
+		if !strings.Contains(bicep, expected) {
+			t.Errorf("expected the Bicep file to contain\n%s\ngot\n%s", expected, bicep)
+		}
+	}
+}
+
+func TestGenerateBicepErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{
+			name:     "invalid JSON",
+			template: `{"resources": [`,
+			expected: "parsing the ARM template",
+		},
+		{
+			name:     "parameter that is not an identifier",
+			template: `{"parameters": {"hello-world": {"type": "string"}}}`,
+			expected: "parameter hello-world is not a valid Bicep identifier",
+		},
+		{
+			name:     "undeclared variable",
+			template: `{"variables": {"a": "[variables('b')]"}}`,
+			expected: "variables('b') is not declared in the template",
+		},
+		{
+			name:     "computed apiVersion",
+			template: `{"parameters": {"v": {"type": "string"}}, "resources": [{"apiVersion": "[parameters('v')]", "type": "Microsoft.Network/virtualNetworks", "name": "vnet"}]}`,
+			expected: "is not a constant",
+		},
+		{
+			name:     "copyIndex outside a loop",
+			template: `{"resources": [{"apiVersion": "2018-08-01", "type": "Microsoft.Network/virtualNetworks", "name": "[string(copyIndex())]"}]}`,
+			expected: "copyIndex() is not in a copy loop",
+		},
+		{
+			name:     "unterminated string",
+			template: `{"variables": {"a": "[concat('a]"}}`,
+			expected: "unterminated string",
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			_, err := GenerateBicep(c.template)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if !strings.Contains(err.Error(), c.expected) {
+				t.Errorf("expected error %q to contain %q", err, c.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+// getClusterAutoscalerNodeGroups returns the node groups of the pools the cluster-autoscaler addon scales, as the
+// min:max:name of their scale sets of its --nodes arguments
+func getClusterAutoscalerNodeGroups(addon api.KubernetesAddon, p *api.Properties) []string {
+	var nodeGroups []string
+	for _, pool := range addon.Pools {
+		if name := getScaleSetName(p, pool.Name); name != "" {
+			nodeGroups = append(nodeGroups, fmt.Sprintf("%s:%s:%s", pool.Config["min-nodes"], pool.Config["max-nodes"], name))
+		}
+	}
+	return nodeGroups
+}
+
+// getClusterAutoscalerPriorities returns the scale sets of the pools of the cluster-autoscaler addon by their priority,
+// for the config of its priority expander. The pools without a priority are left out.
+func getClusterAutoscalerPriorities(addon api.KubernetesAddon, p *api.Properties) map[int][]string {
+	priorities := map[int][]string{}
+	for _, pool := range addon.Pools {
+		priority, err := strconv.Atoi(pool.Config["priority"])
+		if err != nil {
+			continue
+		}
+		if name := getScaleSetName(p, pool.Name); name != "" {
+			priorities[priority] = append(priorities[priority], name)
+		}
+	}
+	return priorities
+}
+
+// getClusterAutoscalerScaleSetTags returns the tags the cluster-autoscaler addon discovers the scale set of an agent
+// pool by, with the node bounds of the pool, when the addon scales the pool with auto-discovery
+func getClusterAutoscalerScaleSetTags(p *api.Properties, profile *api.AgentPoolProfile) map[string]*string {
+	if p.OrchestratorProfile == nil || p.OrchestratorProfile.KubernetesConfig == nil || !p.OrchestratorProfile.KubernetesConfig.IsClusterAutoscalerEnabled() {
+		return nil
+	}
+	addon := p.OrchestratorProfile.KubernetesConfig.GetAddonByName(ClusterAutoscalerAddonName)
+	i := addon.GetAddonPoolIndexByName(profile.Name)
+	if addon.Config["auto-discovery"] != "true" || i < 0 {
+		return nil
+	}
+	return map[string]*string{
+		"cluster-autoscaler-enabled": to.StringPtr("true"),
+		"cluster-autoscaler-name":    to.StringPtr("[parameters('nameSuffix')]"),
+		"min":                        to.StringPtr(addon.Pools[i].Config["min-nodes"]),
+		"max":                        to.StringPtr(addon.Pools[i].Config["max-nodes"]),
+	}
+}
+
+// getScaleSetName returns the name of the scale set of an agent pool, or an empty string if the pool is not a scale set
+func getScaleSetName(p *api.Properties, poolName string) string {
+	i := p.GetAgentPoolIndexByName(poolName)
+	if i < 0 || !p.AgentPoolProfiles[i].IsVirtualMachineScaleSets() {
+		return ""
+	}
+	return p.GetAgentVMPrefix(p.AgentPoolProfiles[i], i)
+}
@@ -0,0 +1,132 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
+)
+
+func getClusterAutoscalerTestProperties(config map[string]string, pools []api.AddonNodePoolsConfig) *api.Properties {
+	return &api.Properties{
+		ClusterID: "12345678",
+		OrchestratorProfile: &api.OrchestratorProfile{
+			OrchestratorType: api.Kubernetes,
+			KubernetesConfig: &api.KubernetesConfig{
+				Addons: []api.KubernetesAddon{
+					{
+						Name:    ClusterAutoscalerAddonName,
+						Enabled: to.BoolPtr(true),
+						Config:  config,
+						Pools:   pools,
+					},
+				},
+			},
+		},
+		AgentPoolProfiles: []*api.AgentPoolProfile{
+			{
+				Name:                "pool1",
+				AvailabilityProfile: api.VirtualMachineScaleSets,
+			},
+			{
+				Name:                "pool2",
+				AvailabilityProfile: api.VirtualMachineScaleSets,
+			},
+			{
+				Name:                "pool3",
+				AvailabilityProfile: api.AvailabilitySet,
+			},
+		},
+	}
+}
+
+func TestGetClusterAutoscalerNodeGroups(t *testing.T) {
+	p := getClusterAutoscalerTestProperties(nil, []api.AddonNodePoolsConfig{
+		{Name: "pool1", Config: map[string]string{"min-nodes": "1", "max-nodes": "10"}},
+		{Name: "pool2", Config: map[string]string{"min-nodes": "0", "max-nodes": "20"}},
+		{Name: "pool3", Config: map[string]string{"min-nodes": "1", "max-nodes": "5"}},
+	})
+	expected := []string{
+		"1:10:k8s-pool1-12345678-vmss",
+		"0:20:k8s-pool2-12345678-vmss",
+	}
+
+	actual := getClusterAutoscalerNodeGroups(p.OrchestratorProfile.KubernetesConfig.Addons[0], p)
+
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected diff while comparing node groups: %s", diff)
+	}
+}
+
+func TestGetClusterAutoscalerPriorities(t *testing.T) {
+	p := getClusterAutoscalerTestProperties(nil, []api.AddonNodePoolsConfig{
+		{Name: "pool1", Config: map[string]string{"priority": "10"}},
+		{Name: "pool2", Config: map[string]string{"priority": "10"}},
+		{Name: "pool3", Config: map[string]string{"priority": "20"}},
+		{Name: "pool4", Config: map[string]string{}},
+	})
+	p.AgentPoolProfiles = append(p.AgentPoolProfiles, &api.AgentPoolProfile{
+		Name:                "pool4",
+		AvailabilityProfile: api.VirtualMachineScaleSets,
+	})
+	expected := map[int][]string{
+		10: {"k8s-pool1-12345678-vmss", "k8s-pool2-12345678-vmss"},
+	}
+
+	actual := getClusterAutoscalerPriorities(p.OrchestratorProfile.KubernetesConfig.Addons[0], p)
+
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected diff while comparing priorities: %s", diff)
+	}
+}
+
+func TestGetClusterAutoscalerScaleSetTags(t *testing.T) {
+	pools := []api.AddonNodePoolsConfig{
+		{Name: "pool1", Config: map[string]string{"min-nodes": "1", "max-nodes": "10"}},
+	}
+	cases := []struct {
+		name     string
+		config   map[string]string
+		pool     string
+		expected map[string]*string
+	}{
+		{
+			name:   "pool without auto-discovery",
+			config: map[string]string{"auto-discovery": "false"},
+			pool:   "pool1",
+		},
+		{
+			name:   "pool not scaled by the addon",
+			config: map[string]string{"auto-discovery": "true"},
+			pool:   "pool2",
+		},
+		{
+			name:   "pool with auto-discovery",
+			config: map[string]string{"auto-discovery": "true"},
+			pool:   "pool1",
+			expected: map[string]*string{
+				"cluster-autoscaler-enabled": to.StringPtr("true"),
+				"cluster-autoscaler-name":    to.StringPtr("[parameters('nameSuffix')]"),
+				"min":                        to.StringPtr("1"),
+				"max":                        to.StringPtr("10"),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			p := getClusterAutoscalerTestProperties(c.config, pools)
+			profile := p.AgentPoolProfiles[p.GetAgentPoolIndexByName(c.pool)]
+			actual := getClusterAutoscalerScaleSetTags(p, profile)
+			if diff := cmp.Diff(c.expected, actual); diff != "" {
+				t.Errorf("unexpected diff while comparing scale set tags: %s", diff)
+			}
+		})
+	}
+}
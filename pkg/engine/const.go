@@ -32,12 +32,26 @@ const (
 	NetworkPolicyCilium = "cilium"
 	// NetworkPluginCilium is the string expression for cilium network plugin config option
 	NetworkPluginCilium = NetworkPolicyCilium
+	// NetworkPolicyAntrea is the string expression for antrea network policy config option
+	NetworkPolicyAntrea = "antrea"
+	// NetworkPluginAntrea is the string expression for antrea network plugin config option
+	NetworkPluginAntrea = NetworkPolicyAntrea
 	// NetworkPolicyAzure is the string expression for Azure CNI network policy manager
 	NetworkPolicyAzure = "azure"
 	// NetworkPluginAzure is the string expression for Azure CNI plugin
 	NetworkPluginAzure = "azure"
 	// NetworkPluginKubenet is the string expression for kubenet network plugin
 	NetworkPluginKubenet = "kubenet"
+	// terminateNotificationAPIVersionCompute is the compute API version of the scale sets with terminate notifications, the first to support them
+	terminateNotificationAPIVersionCompute = "2019-03-01"
+	// spotAPIVersionCompute is the compute API version of the Spot scale sets, the first to support them
+	spotAPIVersionCompute = "2019-03-01"
+	// hostGroupAPIVersionCompute is the compute API version of the scale sets on dedicated hosts, the first to support them
+	hostGroupAPIVersionCompute = "2020-06-01"
+	// ultraSSDAPIVersionCompute is the compute API version of the scale sets setting the IOPS and throughput of their Ultra SSD data disks, the first to support them
+	ultraSSDAPIVersionCompute = "2019-07-01"
+	// sharedDiskAPIVersionCompute is the compute API version of the managed disks created apart from their VMs, the first to support shared disks
+	sharedDiskAPIVersionCompute = "2019-07-01"
 	// NetworkPluginFlannel is the string expression for flannel network plugin
 	NetworkPluginFlannel = "flannel"
 	// KubeDNSAddonName is the name of the kube-dns-deployment addon
@@ -58,6 +72,8 @@ const (
 	CalicoAddonName = "calico-daemonset"
 	// CiliumAddonName is the name of cilium daemonset addon
 	CiliumAddonName = "cilium-daemonset"
+	// AntreaAddonName is the name of antrea daemonset addon
+	AntreaAddonName = "antrea"
 	// FlannelAddonName is the name of flannel plugin daemonset addon
 	FlannelAddonName = "flannel-daemonset"
 	// AADAdminGroupAddonName is the name of the default admin group RBAC addon
@@ -86,6 +102,26 @@ const (
 	SMBFlexVolumeAddonName = "smb-flexvolume"
 	// KeyVaultFlexVolumeAddonName is the name of the keyvault flexvolume addon deployment
 	KeyVaultFlexVolumeAddonName = "keyvault-flexvolume"
+	// RegistryCacheAddonName is the name of the Docker Hub pull-through cache addon deployment
+	RegistryCacheAddonName = "registry-cache"
+	// AzureWorkloadIdentityAddonName is the name of the azure-workload-identity mutating webhook addon deployment
+	AzureWorkloadIdentityAddonName = "azure-workload-identity-webhook"
+	// FluentBitAddonName is the name of the fluent-bit log forwarding addon daemonsets
+	FluentBitAddonName = "fluent-bit"
+	// NodeProblemDetectorAddonName is the name of the node-problem-detector addon daemonset
+	NodeProblemDetectorAddonName = "node-problem-detector"
+	// CSISecretsStoreAddonName is the name of the secrets-store-csi-driver addon daemonsets
+	CSISecretsStoreAddonName = "csi-secrets-store"
+	// NginxIngressAddonName is the name of the nginx-ingress addon deployment
+	NginxIngressAddonName = "nginx-ingress"
+	// AzurePolicyAddonName is the name of the azure-policy addon deployment
+	AzurePolicyAddonName = "azure-policy"
+	// KEDAAddonName is the name of the keda addon deployments
+	KEDAAddonName = "keda"
+	// OpenServiceMeshAddonName is the name of the open-service-mesh addon deployment
+	OpenServiceMeshAddonName = "open-service-mesh"
+	// KonnectivityAgentAddonName is the name of the konnectivity-agent addon daemonset
+	KonnectivityAgentAddonName = "konnectivity-agent"
 	// ScheduledMaintenanceAddonName is the name of the scheduled maintenance addon deployment
 	ScheduledMaintenanceAddonName = "scheduled-maintenance"
 	// DefaultGeneratorCode specifies the source generator of the cluster template.
@@ -112,6 +148,8 @@ const (
 	DefaultMasterEtcdServerPort = 2380
 	// DefaultMasterEtcdClientPort is the default etcd client port for Kubernetes master nodes
 	DefaultMasterEtcdClientPort = 2379
+	// DefaultKubeConfigTunnelLocalPort is the local port the tunneled kubeconfig of a private cluster forwards to the API server
+	DefaultKubeConfigTunnelLocalPort = 6443
 	// etcdAccountNameFmt is the name format for a typical etcd account on Cosmos
 	etcdAccountNameFmt = "%sk8s"
 	// BasicLoadBalancerSku is the string const for Azure Basic Load Balancer
@@ -164,20 +202,23 @@ const (
 	kubernetesCSECustomCloud           = "k8s/cloud-init/artifacts/cse_customcloud.sh"
 	kubernetesHealthMonitorScript      = "k8s/cloud-init/artifacts/health-monitor.sh"
 	// kubernetesKubeletMonitorSystemdTimer     = "k8s/cloud-init/artifacts/kubelet-monitor.timer" // TODO enable
-	kubernetesKubeletMonitorSystemdService   = "k8s/cloud-init/artifacts/kubelet-monitor.service"
-	kubernetesDockerMonitorSystemdTimer      = "k8s/cloud-init/artifacts/docker-monitor.timer"
-	kubernetesDockerMonitorSystemdService    = "k8s/cloud-init/artifacts/docker-monitor.service"
-	labelNodesScript                         = "k8s/cloud-init/artifacts/label-nodes.sh"
-	labelNodesSystemdService                 = "k8s/cloud-init/artifacts/label-nodes.service"
-	kubernetesMountEtcd                      = "k8s/cloud-init/artifacts/mountetcd.sh"
-	kubernetesMasterGenerateProxyCertsScript = "k8s/cloud-init/artifacts/generateproxycerts.sh"
-	kubernetesCustomSearchDomainsScript      = "k8s/cloud-init/artifacts/setup-custom-search-domains.sh"
-	kubeletSystemdService                    = "k8s/cloud-init/artifacts/kubelet.service"
-	kmsSystemdService                        = "k8s/cloud-init/artifacts/kms.service"
-	aptPreferences                           = "k8s/cloud-init/artifacts/apt-preferences"
-	dockerClearMountPropagationFlags         = "k8s/cloud-init/artifacts/docker_clear_mount_propagation_flags.conf"
-	systemdBPFMount                          = "k8s/cloud-init/artifacts/sys-fs-bpf.mount"
-	etcdSystemdService                       = "k8s/cloud-init/artifacts/etcd.service"
+	kubernetesKubeletMonitorSystemdService          = "k8s/cloud-init/artifacts/kubelet-monitor.service"
+	kubernetesDockerMonitorSystemdTimer             = "k8s/cloud-init/artifacts/docker-monitor.timer"
+	kubernetesDockerMonitorSystemdService           = "k8s/cloud-init/artifacts/docker-monitor.service"
+	labelNodesScript                                = "k8s/cloud-init/artifacts/label-nodes.sh"
+	labelNodesSystemdService                        = "k8s/cloud-init/artifacts/label-nodes.service"
+	scheduledEventsHandlerScript                    = "k8s/cloud-init/artifacts/scheduled-events-handler.sh"
+	scheduledEventsHandlerSystemdService            = "k8s/cloud-init/artifacts/scheduled-events-handler.service"
+	kubernetesMountEtcd                             = "k8s/cloud-init/artifacts/mountetcd.sh"
+	kubernetesMasterGenerateProxyCertsScript        = "k8s/cloud-init/artifacts/generateproxycerts.sh"
+	kubernetesMasterGenerateKonnectivityCertsScript = "k8s/cloud-init/artifacts/generatekonnectivitycerts.sh"
+	kubernetesCustomSearchDomainsScript             = "k8s/cloud-init/artifacts/setup-custom-search-domains.sh"
+	kubeletSystemdService                           = "k8s/cloud-init/artifacts/kubelet.service"
+	kmsSystemdService                               = "k8s/cloud-init/artifacts/kms.service"
+	aptPreferences                                  = "k8s/cloud-init/artifacts/apt-preferences"
+	dockerClearMountPropagationFlags                = "k8s/cloud-init/artifacts/docker_clear_mount_propagation_flags.conf"
+	systemdBPFMount                                 = "k8s/cloud-init/artifacts/sys-fs-bpf.mount"
+	etcdSystemdService                              = "k8s/cloud-init/artifacts/etcd.service"
 	// scripts and service for enabling ipv6 dual stack
 	dhcpv6SystemdService      = "k8s/cloud-init/artifacts/dhcpv6.service"
 	dhcpv6ConfigurationScript = "k8s/cloud-init/artifacts/enable-dhcpv6.sh"
@@ -0,0 +1,177 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"encoding/base64"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+)
+
+// imageLineRegex matches the image of a container in a manifest, e.g. "- image: k8s.gcr.io/pause:3.1"
+var imageLineRegex = regexp.MustCompile(`(?m)^(\s*(?:-\s+)?image:\s*["']?)([^\s"'#]+)`)
+
+// ContainerImage is an image pulled by the nodes of a cluster
+type ContainerImage struct {
+	// Image is the image as pulled by the nodes, from the imageRegistry of the cluster if it has one
+	Image string `json:"image"`
+	// Source is the image pulled without the imageRegistry override, i.e. the image to import in that registry
+	Source string `json:"source"`
+}
+
+// rewriteImageRegistry returns the image pulled from registry instead of its own registry, with the same repository,
+// tag and digest, e.g. k8s.gcr.io/coredns:1.6.6 is pulled as contoso.azurecr.io/coredns:1.6.6. The images of Docker
+// Hub without a namespace are in its library namespace. The image is left as it is if registry is empty, or if it is
+// a placeholder replaced on the nodes, whose image is rewritten on its own.
+func rewriteImageRegistry(image, registry string) string {
+	if registry == "" || image == "" || strings.HasPrefix(image, "<") || strings.Contains(image, "{{") {
+		return image
+	}
+	repository := "library/" + image
+	if i := strings.Index(image, "/"); i > 0 {
+		repository = image
+		if domain := image[:i]; strings.ContainsAny(domain, ".:") || domain == "localhost" {
+			repository = image[i+1:]
+		}
+	}
+	return strings.TrimSuffix(registry, "/") + "/" + repository
+}
+
+// rewriteManifestImages returns the manifest with the images of its containers pulled from registry
+func rewriteManifestImages(manifest, registry string) string {
+	if registry == "" {
+		return manifest
+	}
+	return imageLineRegex.ReplaceAllStringFunc(manifest, func(line string) string {
+		m := imageLineRegex.FindStringSubmatch(line)
+		return m[1] + rewriteImageRegistry(m[2], registry)
+	})
+}
+
+// getManifestImages returns the images of the containers of a manifest, without the placeholders replaced on the nodes
+func getManifestImages(manifest string) []string {
+	var images []string
+	for _, m := range imageLineRegex.FindAllStringSubmatch(manifest, -1) {
+		if !strings.HasPrefix(m[2], "<") {
+			images = append(images, m[2])
+		}
+	}
+	return images
+}
+
+// rewriteAddonSettingsImages returns the settings of the addons delivered as they are to the masters, with the
+// manifests of the enabled addons without user-provided data embedded with their images pulled from registry.
+func rewriteAddonSettingsImages(settings []kubernetesComponentFileSpec, sourcePath, orchestratorVersion, registry string) ([]kubernetesComponentFileSpec, error) {
+	if registry == "" {
+		return settings, nil
+	}
+	versions := strings.Split(orchestratorVersion, ".")
+	for i := range settings {
+		if !settings[i].isEnabled || settings[i].base64Data != "" {
+			continue
+		}
+		b, err := Asset(getCustomDataFilePath(settings[i].sourceFile, sourcePath, versions[0]+"."+versions[1]))
+		if err != nil {
+			return nil, err
+		}
+		settings[i].base64Data = base64.StdEncoding.EncodeToString([]byte(rewriteManifestImages(string(b), registry)))
+	}
+	return settings, nil
+}
+
+// GetImages returns the images pulled by the Linux nodes of a cluster: the images of the Kubernetes components, of
+// the enabled addons and of the custom manifests, in the order of their names. The images of the addons with
+// user-provided data and of the custom manifests are pulled as they are, the others from the imageRegistry of the
+// cluster if it has one. The properties are expected to have their defaults set and the custom manifests resolved.
+func GetImages(cs *api.ContainerService) ([]ContainerImage, error) {
+	properties := cs.Properties
+	if properties.OrchestratorProfile == nil || !properties.OrchestratorProfile.IsKubernetes() {
+		return nil, nil
+	}
+	k := properties.OrchestratorProfile.KubernetesConfig
+
+	// the images are collected without the registry override, which is applied to the generated ones
+	registry := k.ImageRegistry
+	k.ImageRegistry = ""
+	defer func() {
+		k.ImageRegistry = registry
+	}()
+	generated := map[string]bool{}
+	provided := map[string]bool{}
+
+	parametersMap := paramsMap{}
+	assignKubernetesParameters(properties, parametersMap, cs.GetCloudSpecConfig(), DefaultGeneratorCode)
+	specs := []string{"kubernetesHyperkubeSpec", "kubernetesCcmImageSpec", "kubernetesAddonManagerSpec", "kubernetesExecHealthzSpec",
+		"kubernetesCoreDNSSpec", "kubernetesKubeDNSSpec", "kubernetesDNSMasqSpec", "kubernetesPodInfraContainerSpec"}
+	if _, ok := parametersMap["kubernetesKubeDNSSpec"]; ok {
+		specs = append(specs, "kubernetesDNSSidecarSpec")
+	}
+	for _, spec := range specs {
+		if v, ok := parametersMap[spec].(paramsMap); ok {
+			if image, _ := v["value"].(string); image != "" {
+				generated[image] = true
+			}
+		}
+	}
+	if k.IsKonnectivityEnabled() && k.KonnectivityProfile != nil && k.KonnectivityProfile.ServerImage != "" {
+		generated[k.KonnectivityProfile.ServerImage] = true
+	}
+
+	versions := strings.Split(properties.OrchestratorProfile.OrchestratorVersion, ".")
+	for _, setting := range kubernetesAddonSettingsInit(properties) {
+		if !setting.isEnabled {
+			continue
+		}
+		images := generated
+		var manifest string
+		if setting.base64Data != "" {
+			var err error
+			if manifest, err = getStringFromBase64(setting.base64Data); err != nil {
+				return nil, err
+			}
+			images = provided
+		} else {
+			b, err := Asset(getCustomDataFilePath(setting.sourceFile, "k8s/addons", versions[0]+"."+versions[1]))
+			if err != nil {
+				return nil, err
+			}
+			manifest = string(b)
+		}
+		for _, image := range getManifestImages(manifest) {
+			images[image] = true
+		}
+	}
+
+	settingsMap := kubernetesContainerAddonSettingsInit(properties)
+	addons, err := getContainerAddonManifests(properties, "k8s/containeraddons")
+	if err != nil {
+		return nil, err
+	}
+	for _, addon := range addons {
+		images := provided
+		if setting, ok := settingsMap[addon.name]; ok && setting.base64Data == "" {
+			images = generated
+		}
+		for _, image := range getManifestImages(addon.manifest) {
+			images[image] = true
+		}
+	}
+
+	var containerImages []ContainerImage
+	for image := range generated {
+		containerImages = append(containerImages, ContainerImage{Image: rewriteImageRegistry(image, registry), Source: image})
+	}
+	for image := range provided {
+		if registry != "" || !generated[image] {
+			containerImages = append(containerImages, ContainerImage{Image: image, Source: image})
+		}
+	}
+	sort.Slice(containerImages, func(i, j int) bool {
+		return containerImages[i].Image < containerImages[j].Image
+	})
+	return containerImages, nil
+}
@@ -0,0 +1,133 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+func TestRewriteImageRegistry(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("0f", 32)
+	cases := []struct {
+		image    string
+		registry string
+		expected string
+	}{
+		{image: "k8s.gcr.io/coredns:1.6.6", registry: "", expected: "k8s.gcr.io/coredns:1.6.6"},
+		{image: "k8s.gcr.io/coredns:1.6.6", registry: "contoso.azurecr.io", expected: "contoso.azurecr.io/coredns:1.6.6"},
+		{image: "mcr.microsoft.com/oss/kubernetes/pause:1.3.1", registry: "contoso.azurecr.io/mirror/", expected: "contoso.azurecr.io/mirror/oss/kubernetes/pause:1.3.1"},
+		{image: "localhost:5000/pause:3.1", registry: "contoso.azurecr.io", expected: "contoso.azurecr.io/pause:3.1"},
+		{image: "localhost/team/pause", registry: "contoso.azurecr.io", expected: "contoso.azurecr.io/team/pause"},
+		{image: "cilium/cilium:v1.4", registry: "contoso.azurecr.io", expected: "contoso.azurecr.io/cilium/cilium:v1.4"},
+		{image: "busybox", registry: "contoso.azurecr.io", expected: "contoso.azurecr.io/library/busybox"},
+		{image: "k8s.gcr.io/pause:3.1@" + digest, registry: "contoso.azurecr.io", expected: "contoso.azurecr.io/pause:3.1@" + digest},
+		{image: "<img>", registry: "contoso.azurecr.io", expected: "<img>"},
+	}
+
+	for _, c := range cases {
+		if actual := rewriteImageRegistry(c.image, c.registry); actual != c.expected {
+			t.Errorf("expected %s from registry %q to be %s, instead got %s", c.image, c.registry, c.expected, actual)
+		}
+	}
+}
+
+func TestRewriteManifestImages(t *testing.T) {
+	manifest := `spec:
+  initContainers:
+  - image: "quay.io/coreos/flannel:v0.10.0-amd64"
+  containers:
+  - name: kube-proxy
+    image: <img>
+  - name: drainsafe
+    image: quay.io/awesomenix/drainsafe-manager:latest # the manager
+    args:
+    - --image: k8s.gcr.io/pause
+`
+	expected := `spec:
+  initContainers:
+  - image: "contoso.azurecr.io/coreos/flannel:v0.10.0-amd64"
+  containers:
+  - name: kube-proxy
+    image: <img>
+  - name: drainsafe
+    image: contoso.azurecr.io/awesomenix/drainsafe-manager:latest # the manager
+    args:
+    - --image: k8s.gcr.io/pause
+`
+	if actual := rewriteManifestImages(manifest, "contoso.azurecr.io"); actual != expected {
+		t.Errorf("expected rewriteManifestImages to return:\n%s\ninstead got:\n%s", expected, actual)
+	}
+	if actual := rewriteManifestImages(manifest, ""); actual != manifest {
+		t.Errorf("expected the manifest to be left as it is without a registry, instead got:\n%s", actual)
+	}
+	images := getManifestImages(manifest)
+	if len(images) != 2 || images[0] != "quay.io/coreos/flannel:v0.10.0-amd64" || images[1] != "quay.io/awesomenix/drainsafe-manager:latest" {
+		t.Errorf("expected the flannel and drainsafe-manager images, instead got %v", images)
+	}
+}
+
+func TestGetImages(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("0f", 32)
+	cs := api.CreateMockContainerService("testcluster", "1.16.1", 3, 2, false)
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+	k.Addons = []api.KubernetesAddon{
+		{
+			Name:       api.MetricsServerAddonName,
+			Enabled:    to.BoolPtr(true),
+			Containers: []api.KubernetesContainerSpec{{Name: api.MetricsServerAddonName, ImageDigest: digest}},
+		},
+	}
+	k.CustomManifests = []api.CustomManifest{
+		{Name: "app", Data: base64.StdEncoding.EncodeToString([]byte("kind: Pod\nspec:\n  containers:\n  - image: contoso.io/app:v1\n"))},
+	}
+	if _, err := cs.SetPropertiesDefaults(false, false); err != nil {
+		t.Fatal(err)
+	}
+	metricsServerAddon := k.GetAddonByName(api.MetricsServerAddonName)
+	metricsServer := metricsServerAddon.Containers[metricsServerAddon.GetAddonContainersIndexByName(api.MetricsServerAddonName)].Image
+
+	images, err := GetImages(cs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sources := map[string]string{}
+	for i, image := range images {
+		if i > 0 && images[i-1].Image > image.Image {
+			t.Errorf("expected the images to be sorted, instead got %s before %s", images[i-1].Image, image.Image)
+		}
+		if image.Image != image.Source {
+			t.Errorf("expected %s to be pulled from its own registry, instead got %s", image.Source, image.Image)
+		}
+		sources[image.Source] = image.Image
+	}
+	hyperkube := k.KubernetesImageBase + api.K8sComponentsByVersionMap[cs.Properties.OrchestratorProfile.OrchestratorVersion]["hyperkube"]
+	for _, expected := range []string{metricsServer + "@" + digest, "contoso.io/app:v1", hyperkube} {
+		if _, ok := sources[expected]; !ok {
+			t.Errorf("expected the images to have %s, instead got %v", expected, images)
+		}
+	}
+
+	k.ImageRegistry = "contoso.azurecr.io"
+	if images, err = GetImages(cs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if k.ImageRegistry != "contoso.azurecr.io" {
+		t.Errorf("expected the imageRegistry to be kept, instead got %q", k.ImageRegistry)
+	}
+	for _, image := range images {
+		switch {
+		case image.Source == "contoso.io/app:v1":
+			if image.Image != image.Source {
+				t.Errorf("expected the image of the custom manifest to be pulled as it is, instead got %s", image.Image)
+			}
+		case image.Image != rewriteImageRegistry(image.Source, "contoso.azurecr.io"):
+			t.Errorf("expected %s to be pulled from the image registry, instead got %s", image.Source, image.Image)
+		}
+	}
+}
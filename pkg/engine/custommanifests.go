@@ -0,0 +1,109 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/persona"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// addonManagerModeLabel is the label of the objects the addon manager applies, with their addon manager mode
+const addonManagerModeLabel = "addonmanager.kubernetes.io/mode"
+
+// ResolveCustomManifests embeds the contents of the custom manifests with a url or a path in their data, so that
+// the api model written with the template does not depend on them anymore, e.g. to upgrade the cluster
+func ResolveCustomManifests(cs *api.ContainerService) error {
+	if cs.Properties.OrchestratorProfile == nil || cs.Properties.OrchestratorProfile.KubernetesConfig == nil {
+		return nil
+	}
+	manifests := cs.Properties.OrchestratorProfile.KubernetesConfig.CustomManifests
+	for i := range manifests {
+		var b []byte
+		var err error
+		switch {
+		case manifests[i].URL != "":
+			if b, err = persona.Current().Get(manifests[i].URL); err != nil {
+				return errors.Wrapf(err, "getting custom manifest %s from %s", manifests[i].Name, manifests[i].URL)
+			}
+		case manifests[i].Path != "":
+			if b, err = ioutil.ReadFile(manifests[i].Path); err != nil {
+				return errors.Wrapf(err, "reading custom manifest %s", manifests[i].Name)
+			}
+		default:
+			continue
+		}
+		manifests[i].Data = base64.StdEncoding.EncodeToString(b)
+	}
+	return nil
+}
+
+// getCustomManifests returns the custom manifests as written to /etc/kubernetes/addons on the masters, in the order
+// of their dependencies. The addon manager applies the files in the order of their names, which are prefixed with
+// the position of the manifest in that order.
+func getCustomManifests(k *api.KubernetesConfig) ([]containerAddonManifest, error) {
+	ordered, err := k.GetCustomManifests()
+	if err != nil {
+		return nil, err
+	}
+	var manifests []containerAddonManifest
+	for i, m := range ordered {
+		if m.Data == "" {
+			return nil, errors.Errorf("custom manifest %s has no data, its url or path must be resolved before generating the template", m.Name)
+		}
+		input, err := getStringFromBase64(m.Data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding custom manifest %s", m.Name)
+		}
+		mode := m.Mode
+		if mode == "" {
+			mode = api.AddonManagerModeReconcile
+		}
+		manifests = append(manifests, containerAddonManifest{
+			name:            "custom-manifest-" + m.Name,
+			destinationFile: fmt.Sprintf("custom-manifest-%02d-%s.yaml", i, m.Name),
+			manifest:        setAddonManagerMode(input, mode),
+		})
+	}
+	return manifests, nil
+}
+
+// setAddonManagerMode returns the manifest with the addon manager mode label on the objects missing one, the addon
+// manager ignores them otherwise. The documents that already have the label are left as they are.
+func setAddonManagerMode(manifest, mode string) string {
+	documents := strings.Split(manifest, "\n---")
+	for i, document := range documents {
+		var object map[string]interface{}
+		if err := yaml.Unmarshal([]byte(document), &object); err != nil || object == nil {
+			continue
+		}
+		metadata, _ := object["metadata"].(map[string]interface{})
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+			object["metadata"] = metadata
+		}
+		labels, _ := metadata["labels"].(map[string]interface{})
+		if labels == nil {
+			labels = map[string]interface{}{}
+			metadata["labels"] = labels
+		}
+		if _, ok := labels[addonManagerModeLabel]; ok {
+			continue
+		}
+		labels[addonManagerModeLabel] = mode
+		if b, err := yaml.Marshal(object); err == nil {
+			documents[i] = string(b)
+			if i > 0 {
+				documents[i] = "\n" + documents[i]
+			}
+		}
+	}
+	return strings.Join(documents, "\n---")
+}
@@ -0,0 +1,174 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+)
+
+func TestResolveCustomManifests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/operator.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "kind: Deployment\n")
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "custommanifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "crds.yaml")
+	if err = ioutil.WriteFile(path, []byte("kind: CustomResourceDefinition\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				KubernetesConfig: &api.KubernetesConfig{
+					CustomManifests: []api.CustomManifest{
+						{Name: "crds", Path: path},
+						{Name: "operator", URL: server.URL + "/operator.yaml", Data: base64.StdEncoding.EncodeToString([]byte("kind: Pod\n"))},
+						{Name: "config", Data: base64.StdEncoding.EncodeToString([]byte("kind: ConfigMap\n"))},
+					},
+				},
+			},
+		},
+	}
+	if err = ResolveCustomManifests(cs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{
+		"crds":     "kind: CustomResourceDefinition\n",
+		"operator": "kind: Deployment\n",
+		"config":   "kind: ConfigMap\n",
+	}
+	for _, m := range cs.Properties.OrchestratorProfile.KubernetesConfig.CustomManifests {
+		data, _ := base64.StdEncoding.DecodeString(m.Data)
+		if string(data) != expected[m.Name] {
+			t.Errorf("expected the data of custom manifest %s to be %q, instead got %q", m.Name, expected[m.Name], string(data))
+		}
+	}
+
+	cs.Properties.OrchestratorProfile.KubernetesConfig.CustomManifests = []api.CustomManifest{
+		{Name: "operator", URL: server.URL + "/missing.yaml"},
+	}
+	if err = ResolveCustomManifests(cs); err == nil || !strings.Contains(err.Error(), "getting custom manifest operator from "+server.URL+"/missing.yaml") {
+		t.Errorf("expected an error getting the missing custom manifest, instead got %v", err)
+	}
+
+	cs.Properties.OrchestratorProfile.KubernetesConfig.CustomManifests = []api.CustomManifest{
+		{Name: "crds", Path: filepath.Join(dir, "missing.yaml")},
+	}
+	if err = ResolveCustomManifests(cs); err == nil || !strings.Contains(err.Error(), "reading custom manifest crds") {
+		t.Errorf("expected an error reading the missing custom manifest, instead got %v", err)
+	}
+}
+
+func TestGetCustomManifests(t *testing.T) {
+	crds := "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: widgets.example.com\n"
+	operator := "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: widgets\n  labels:\n    addonmanager.kubernetes.io/mode: EnsureExists\n"
+	k := &api.KubernetesConfig{
+		CustomManifests: []api.CustomManifest{
+			{Name: "operator", Data: base64.StdEncoding.EncodeToString([]byte(operator)), Mode: api.AddonManagerModeReconcile, DependsOn: []string{"crds"}},
+			{Name: "crds", Data: base64.StdEncoding.EncodeToString([]byte(crds)), Mode: api.AddonManagerModeEnsureExists},
+		},
+	}
+	manifests, err := getCustomManifests(k)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(manifests) != 2 {
+		t.Fatalf("expected 2 custom manifests, instead got %d", len(manifests))
+	}
+	if manifests[0].name != "custom-manifest-crds" || manifests[0].destinationFile != "custom-manifest-00-crds.yaml" {
+		t.Errorf("expected the crds custom manifest first, instead got %s in %s", manifests[0].name, manifests[0].destinationFile)
+	}
+	if manifests[1].name != "custom-manifest-operator" || manifests[1].destinationFile != "custom-manifest-01-operator.yaml" {
+		t.Errorf("expected the operator custom manifest second, instead got %s in %s", manifests[1].name, manifests[1].destinationFile)
+	}
+	if !strings.Contains(manifests[0].manifest, "addonmanager.kubernetes.io/mode: EnsureExists") {
+		t.Errorf("expected the crds custom manifest to have the EnsureExists mode, instead got:\n%s", manifests[0].manifest)
+	}
+	if manifests[1].manifest != operator {
+		t.Errorf("expected the operator custom manifest to be left as it is, instead got:\n%s", manifests[1].manifest)
+	}
+
+	k.CustomManifests = []api.CustomManifest{{Name: "operator", URL: "https://example.com/operator.yaml"}}
+	if _, err = getCustomManifests(k); err == nil || err.Error() != "custom manifest operator has no data, its url or path must be resolved before generating the template" {
+		t.Errorf("expected an error for the unresolved custom manifest, instead got %v", err)
+	}
+}
+
+func TestSetAddonManagerMode(t *testing.T) {
+	manifest := `# the widgets
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: widgets
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: widgets
+  namespace: widgets
+  labels:
+    app: widgets
+data:
+  size: small
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: widgets
+  namespace: widgets
+  labels:
+    addonmanager.kubernetes.io/mode: EnsureExists
+`
+	expected := `apiVersion: v1
+kind: Namespace
+metadata:
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+  name: widgets
+
+---
+apiVersion: v1
+data:
+  size: small
+kind: ConfigMap
+metadata:
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+    app: widgets
+  name: widgets
+  namespace: widgets
+
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: widgets
+  namespace: widgets
+  labels:
+    addonmanager.kubernetes.io/mode: EnsureExists
+`
+	if actual := setAddonManagerMode(manifest, api.AddonManagerModeReconcile); actual != expected {
+		t.Errorf("expected setAddonManagerMode to return:\n%s\ninstead got:\n%s", expected, actual)
+	}
+}
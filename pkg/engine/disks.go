@@ -0,0 +1,91 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/common"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+// createMasterEtcdDisks returns the Ultra SSD etcd data disks of the master VMs. Unlike the other data disks they are
+// not created with their VMs, whose compute API cannot set the IOPS and throughput of their data disks.
+func createMasterEtcdDisks(cs *api.ContainerService) DiskARM {
+	masterProfile := cs.Properties.MasterProfile
+	etcdSizeGB, _ := strconv.Atoi(cs.Properties.OrchestratorProfile.KubernetesConfig.EtcdDiskSizeGB)
+
+	diskProperties := &compute.DiskProperties{
+		CreationData: &compute.CreationData{
+			CreateOption: compute.Empty,
+		},
+		DiskSizeGB: to.Int32Ptr(int32(etcdSizeGB)),
+	}
+	if masterProfile.DataDiskIOPSReadWrite != 0 {
+		diskProperties.DiskIOPSReadWrite = to.Int64Ptr(int64(masterProfile.DataDiskIOPSReadWrite))
+	}
+	if masterProfile.DataDiskMBpsReadWrite != 0 {
+		diskProperties.DiskMBpsReadWrite = to.Int32Ptr(int32(masterProfile.DataDiskMBpsReadWrite))
+	}
+
+	return DiskARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionCompute')]",
+			Copy: map[string]string{
+				"count": "[sub(variables('masterCount'), variables('masterOffset'))]",
+				"name":  "etcdDiskLoopNode",
+			},
+		},
+		Disk: compute.Disk{
+			Name:     to.StringPtr("[concat(variables('masterVMNamePrefix'), copyIndex(variables('masterOffset')),'-etcddisk')]"),
+			Location: to.StringPtr("[variables('location')]"),
+			Type:     to.StringPtr("Microsoft.Compute/disks"),
+			Sku: &compute.DiskSku{
+				Name: compute.UltraSSDLRS,
+			},
+			// the disk of each master is in the availability zone of the master
+			Zones: &[]string{
+				"[string(parameters('availabilityZones')[mod(copyIndex(variables('masterOffset')), length(parameters('availabilityZones')))])]",
+			},
+			DiskProperties: diskProperties,
+		},
+	}
+}
+
+// createSharedDataDisks returns the data disks of an agent pool with shared disks, each of them attached to all the
+// VMs of the pool
+func createSharedDataDisks(profile *api.AgentPoolProfile) []DiskARM {
+	var disks []DiskARM
+	for i, diskSize := range profile.DiskSizesGB {
+		disks = append(disks, DiskARM{
+			ARMResource: ARMResource{
+				APIVersion: sharedDiskAPIVersionCompute,
+			},
+			Disk: compute.Disk{
+				Name:     to.StringPtr("[" + getSharedDataDiskName(profile, i) + "]"),
+				Location: to.StringPtr("[variables('location')]"),
+				Type:     to.StringPtr("Microsoft.Compute/disks"),
+				Sku: &compute.DiskSku{
+					Name: compute.DiskStorageAccountTypes(profile.DataDiskStorageAccountType),
+				},
+				DiskProperties: &compute.DiskProperties{
+					CreationData: &compute.CreationData{
+						CreateOption: compute.Empty,
+					},
+					DiskSizeGB: to.Int32Ptr(int32(diskSize)),
+				},
+			},
+			MaxShares: common.GetSharedDiskMaxShares(diskSize),
+		})
+	}
+	return disks
+}
+
+// getSharedDataDiskName returns the ARM expression of the name of the shared data disk of an agent pool at lun
+func getSharedDataDiskName(profile *api.AgentPoolProfile, lun int) string {
+	return fmt.Sprintf("concat(variables('%sVMNamePrefix'), 'shareddisk%d')", profile.Name, lun)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+func TestCreateMasterEtcdDisks(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				KubernetesConfig: &api.KubernetesConfig{
+					EtcdDiskSizeGB: "256",
+				},
+			},
+			MasterProfile: &api.MasterProfile{
+				AvailabilityZones:          []string{"1", "2", "3"},
+				DataDiskStorageAccountType: api.UltraSSDDataDisk,
+				DataDiskIOPSReadWrite:      5000,
+			},
+		},
+	}
+
+	expected := DiskARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionCompute')]",
+			Copy: map[string]string{
+				"count": "[sub(variables('masterCount'), variables('masterOffset'))]",
+				"name":  "etcdDiskLoopNode",
+			},
+		},
+		Disk: compute.Disk{
+			Name:     to.StringPtr("[concat(variables('masterVMNamePrefix'), copyIndex(variables('masterOffset')),'-etcddisk')]"),
+			Location: to.StringPtr("[variables('location')]"),
+			Type:     to.StringPtr("Microsoft.Compute/disks"),
+			Sku: &compute.DiskSku{
+				Name: compute.UltraSSDLRS,
+			},
+			Zones: &[]string{
+				"[string(parameters('availabilityZones')[mod(copyIndex(variables('masterOffset')), length(parameters('availabilityZones')))])]",
+			},
+			DiskProperties: &compute.DiskProperties{
+				CreationData: &compute.CreationData{
+					CreateOption: compute.Empty,
+				},
+				DiskSizeGB:        to.Int32Ptr(256),
+				DiskIOPSReadWrite: to.Int64Ptr(5000),
+			},
+		},
+	}
+
+	actual := createMasterEtcdDisks(cs)
+
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected diff while comparing structs: %s", diff)
+	}
+
+	// Test with a throughput
+	cs.Properties.MasterProfile.DataDiskMBpsReadWrite = 200
+	expected.DiskMBpsReadWrite = to.Int32Ptr(200)
+
+	actual = createMasterEtcdDisks(cs)
+
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected diff while comparing structs: %s", diff)
+	}
+}
+
+func TestCreateSharedDataDisks(t *testing.T) {
+	profile := &api.AgentPoolProfile{
+		Name:                       "agentpool1",
+		Count:                      2,
+		AvailabilityProfile:        api.AvailabilitySet,
+		DiskSizesGB:                []int{256, 1023},
+		DataDiskStorageAccountType: api.PremiumSSDDataDisk,
+		EnableSharedDisk:           to.BoolPtr(true),
+	}
+
+	disk := func(name string, sizeGB int32, maxShares int) DiskARM {
+		return DiskARM{
+			ARMResource: ARMResource{
+				APIVersion: sharedDiskAPIVersionCompute,
+			},
+			Disk: compute.Disk{
+				Name:     to.StringPtr(name),
+				Location: to.StringPtr("[variables('location')]"),
+				Type:     to.StringPtr("Microsoft.Compute/disks"),
+				Sku: &compute.DiskSku{
+					Name: compute.PremiumLRS,
+				},
+				DiskProperties: &compute.DiskProperties{
+					CreationData: &compute.CreationData{
+						CreateOption: compute.Empty,
+					},
+					DiskSizeGB: to.Int32Ptr(sizeGB),
+				},
+			},
+			MaxShares: maxShares,
+		}
+	}
+	expected := []DiskARM{
+		disk("[concat(variables('agentpool1VMNamePrefix'), 'shareddisk0')]", 256, 2),
+		disk("[concat(variables('agentpool1VMNamePrefix'), 'shareddisk1')]", 1023, 5),
+	}
+
+	actual := createSharedDataDisks(profile)
+
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected diff while comparing structs: %s", diff)
+	}
+}
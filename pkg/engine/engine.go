@@ -9,10 +9,8 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net"
-	"net/http"
 	"regexp"
 	"sort"
 	"strconv"
@@ -24,6 +22,7 @@ import (
 	//log "github.com/sirupsen/logrus"
 	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/aks-engine/pkg/api/common"
+	"github.com/Azure/aks-engine/pkg/api/persona"
 	"github.com/Azure/aks-engine/pkg/helpers"
 	"github.com/pkg/errors"
 
@@ -62,18 +61,15 @@ func GenerateKubeConfig(properties *api.Properties, location string) (string, er
 		properties.OrchestratorProfile.KubernetesConfig != nil &&
 		properties.OrchestratorProfile.KubernetesConfig.PrivateCluster != nil &&
 		to.Bool(properties.OrchestratorProfile.KubernetesConfig.PrivateCluster.Enabled) {
-		if properties.MasterProfile.HasMultipleNodes() {
-			// more than 1 master, use the internal lb IP
-			firstMasterIP := net.ParseIP(properties.MasterProfile.FirstConsecutiveStaticIP).To4()
-			if firstMasterIP == nil {
-				return "", errors.Errorf("MasterProfile.FirstConsecutiveStaticIP '%s' is an invalid IP address", properties.MasterProfile.FirstConsecutiveStaticIP)
-			}
-			lbIP := net.IP{firstMasterIP[0], firstMasterIP[1], firstMasterIP[2], firstMasterIP[3] + byte(DefaultInternalLbStaticIPOffset)}
-			kubeconfig = strings.Replace(kubeconfig, "{{WrapAsVerbatim \"reference(concat('Microsoft.Network/publicIPAddresses/', variables('masterPublicIPAddressName'))).dnsSettings.fqdn\"}}", lbIP.String(), -1)
-		} else {
-			// Master count is 1, use the master IP
-			kubeconfig = strings.Replace(kubeconfig, "{{WrapAsVerbatim \"reference(concat('Microsoft.Network/publicIPAddresses/', variables('masterPublicIPAddressName'))).dnsSettings.fqdn\"}}", properties.MasterProfile.FirstConsecutiveStaticIP, -1)
+		address, err := privateClusterAPIServerAddress(properties)
+		if err != nil {
+			return "", err
+		}
+		if properties.OrchestratorProfile.KubernetesConfig.PrivateLinkEnabled() {
+			// the private DNS zone of the API server private endpoint resolves this fqdn
+			address = api.FormatPrivateLinkFQDNByLocation(properties.MasterProfile.DNSPrefix, location, properties.GetCustomCloudName())
 		}
+		kubeconfig = strings.Replace(kubeconfig, "{{WrapAsVerbatim \"reference(concat('Microsoft.Network/publicIPAddresses/', variables('masterPublicIPAddressName'))).dnsSettings.fqdn\"}}", address, -1)
 	} else {
 		kubeconfig = strings.Replace(kubeconfig, "{{WrapAsVerbatim \"reference(concat('Microsoft.Network/publicIPAddresses/', variables('masterPublicIPAddressName'))).dnsSettings.fqdn\"}}", api.FormatProdFQDNByLocation(properties.MasterProfile.DNSPrefix, location, properties.GetCustomCloudName()), -1)
 	}
@@ -101,6 +97,79 @@ func GenerateKubeConfig(properties *api.Properties, location string) (string, er
 	return kubeconfig, nil
 }
 
+// privateClusterAPIServerAddress returns the address of the API server of a private cluster inside the VNET
+func privateClusterAPIServerAddress(properties *api.Properties) (string, error) {
+	if !properties.MasterProfile.HasMultipleNodes() {
+		// Master count is 1, use the master IP
+		return properties.MasterProfile.FirstConsecutiveStaticIP, nil
+	}
+	// more than 1 master, use the internal lb IP
+	firstMasterIP := net.ParseIP(properties.MasterProfile.FirstConsecutiveStaticIP).To4()
+	if firstMasterIP == nil {
+		return "", errors.Errorf("MasterProfile.FirstConsecutiveStaticIP '%s' is an invalid IP address", properties.MasterProfile.FirstConsecutiveStaticIP)
+	}
+	lbIP := net.IP{firstMasterIP[0], firstMasterIP[1], firstMasterIP[2], firstMasterIP[3] + byte(DefaultInternalLbStaticIPOffset)}
+	return lbIP.String(), nil
+}
+
+// GenerateTunneledKubeConfig returns a JSON string representing a KubeConfig for a private cluster with a jumpbox that
+// kubectl can use from outside the VNET. The kubeconfig talks to the API server on localPort of the loopback address, and
+// its exec credential plugin opens an SSH tunnel through the jumpbox to the API server before handing over the client
+// certificate. The tunnel is shared by kubectl invocations through an SSH control socket. The plugin runs sh and ssh.
+func GenerateTunneledKubeConfig(properties *api.Properties, location string, localPort int) (string, error) {
+	if properties == nil || properties.OrchestratorProfile == nil || !properties.OrchestratorProfile.KubernetesConfig.PrivateJumpboxProvision() {
+		return "", errors.New("a tunneled kubeconfig requires a private cluster with a jumpboxProfile")
+	}
+	if properties.AADProfile != nil {
+		return "", errors.New("a tunneled kubeconfig requires client certificate authentication, it cannot be generated for a cluster with an aadProfile")
+	}
+	b, err := GenerateKubeConfig(properties, location)
+	if err != nil {
+		return "", err
+	}
+	address, err := privateClusterAPIServerAddress(properties)
+	if err != nil {
+		return "", err
+	}
+
+	kubeconfig := map[string]interface{}{}
+	if err = json.Unmarshal([]byte(b), &kubeconfig); err != nil {
+		return "", errors.Wrap(err, "error parsing kubeconfig")
+	}
+	// the API server certificate is valid for the loopback address
+	kubeconfig["clusters"].([]interface{})[0].(map[string]interface{})["cluster"].(map[string]interface{})["server"] = fmt.Sprintf("https://127.0.0.1:%d", localPort)
+
+	credential, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "client.authentication.k8s.io/v1beta1",
+		"kind":       "ExecCredential",
+		"status": map[string]string{
+			"clientCertificateData": properties.CertificateProfile.KubeConfigCertificate,
+			"clientKeyData":         properties.CertificateProfile.KubeConfigPrivateKey,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	jumpbox := fmt.Sprintf("%s@%s", properties.OrchestratorProfile.KubernetesConfig.PrivateCluster.JumpboxProfile.Username, api.FormatProdFQDNByLocation(properties.MasterProfile.DNSPrefix, location, properties.GetCustomCloudName()))
+	controlPath := fmt.Sprintf("~/.ssh/aks-engine-%s-%d", properties.MasterProfile.DNSPrefix, localPort)
+	// reuse the tunnel if it is up, open it otherwise, and print the ExecCredential; ssh must not write to stdout
+	script := fmt.Sprintf("ssh -o ControlPath=%[1]s -O check %[2]s 2>/dev/null || ssh -o ControlPath=%[1]s -o ControlMaster=yes -o ControlPersist=30m -o ExitOnForwardFailure=yes -fN -L 127.0.0.1:%[3]d:%[4]s:443 %[2]s >&2 || exit 1; printf '%%s' '%[5]s'",
+		controlPath, jumpbox, localPort, address, credential)
+	kubeconfig["users"].([]interface{})[0].(map[string]interface{})["user"] = map[string]interface{}{
+		"exec": map[string]interface{}{
+			"apiVersion": "client.authentication.k8s.io/v1beta1",
+			"command":    "sh",
+			"args":       []string{"-c", script},
+		},
+	}
+
+	tunneled, err := helpers.JSONMarshalIndent(kubeconfig, "", "  ", false)
+	if err != nil {
+		return "", errors.Wrap(err, "error encoding kubeconfig")
+	}
+	return string(tunneled), nil
+}
+
 // validateDistro checks if the requested orchestrator type is supported on the requested Linux distro.
 func validateDistro(cs *api.ContainerService) bool {
 	// Check Master distro
@@ -635,11 +704,15 @@ func getBase64EncodedGzippedCustomScriptFromStr(str string) string {
 	return base64.StdEncoding.EncodeToString(gzipB.Bytes())
 }
 
-func getAddonFuncMap(addon api.KubernetesAddon) template.FuncMap {
+func getAddonFuncMap(addon api.KubernetesAddon, p *api.Properties) template.FuncMap {
 	return template.FuncMap{
 		"ContainerImage": func(name string) string {
 			i := addon.GetAddonContainersIndexByName(name)
-			return addon.Containers[i].Image
+			image := rewriteImageRegistry(addon.Containers[i].Image, p.OrchestratorProfile.KubernetesConfig.ImageRegistry)
+			if addon.Containers[i].ImageDigest != "" {
+				image += "@" + addon.Containers[i].ImageDigest
+			}
+			return image
 		},
 
 		"ContainerCPUReqs": func(name string) string {
@@ -664,11 +737,75 @@ func getAddonFuncMap(addon api.KubernetesAddon) template.FuncMap {
 		"ContainerConfig": func(name string) string {
 			return addon.Config[name]
 		},
+
+		"ClusterAutoscalerNodeGroups": func() []string {
+			return getClusterAutoscalerNodeGroups(addon, p)
+		},
+
+		"ClusterAutoscalerPriorities": func() map[int][]string {
+			return getClusterAutoscalerPriorities(addon, p)
+		},
+
+		"ClusterAutoscalerClusterName": func() string {
+			return p.GetClusterID()
+		},
+
+		"GatekeeperSyncOnlyKinds": func() []gatekeeperKind {
+			return getGatekeeperSyncOnlyKinds(addon)
+		},
+
+		"OpenServiceMeshNamespaces": func() []string {
+			return getOpenServiceMeshNamespaces(addon)
+		},
 	}
 }
 
+// containerAddonManifest is the manifest of an enabled container addon as written to the masters,
+// with the findings of its system components
+type containerAddonManifest struct {
+	name            string
+	destinationFile string
+	manifest        string
+	findings        []SystemComponentFinding
+}
+
 func getContainerAddonsString(properties *api.Properties, sourcePath string) string {
+	addons, err := getContainerAddonManifests(properties, sourcePath)
+	if err != nil {
+		return ""
+	}
 	var result string
+	for _, addon := range addons {
+		result += getAddonString(addon.manifest, "/etc/kubernetes/addons", addon.destinationFile)
+	}
+	return result
+}
+
+// AddonManifest is the manifest of an enabled container addon as written to /etc/kubernetes/addons on the masters
+type AddonManifest struct {
+	Name            string
+	DestinationFile string
+	Manifest        string
+}
+
+// GetContainerAddonManifests renders the manifests of the enabled container addons of a cluster, in the order of
+// their names, and its custom manifests. The defaults of the properties of the cluster are expected to be set.
+func GetContainerAddonManifests(cs *api.ContainerService) ([]AddonManifest, error) {
+	addons, err := getContainerAddonManifests(cs.Properties, "k8s/containeraddons")
+	if err != nil {
+		return nil, err
+	}
+	manifests := make([]AddonManifest, 0, len(addons))
+	for _, addon := range addons {
+		manifests = append(manifests, AddonManifest{Name: addon.name, DestinationFile: addon.destinationFile, Manifest: addon.manifest})
+	}
+	return manifests, nil
+}
+
+// getContainerAddonManifests renders the manifests of the enabled container addons, in the order of their names,
+// giving their system components the priority class they are missing, followed by the custom manifests
+func getContainerAddonManifests(properties *api.Properties, sourcePath string) ([]containerAddonManifest, error) {
+	var addons []containerAddonManifest
 	settingsMap := kubernetesContainerAddonSettingsInit(properties)
 
 	var addonNames []string
@@ -687,30 +824,114 @@ func getContainerAddonsString(properties *api.Properties, sourcePath string) str
 				var err error
 				input, err = getStringFromBase64(setting.base64Data)
 				if err != nil {
-					return ""
+					return nil, err
 				}
 			} else {
 				orchProfile := properties.OrchestratorProfile
 				versions := strings.Split(orchProfile.OrchestratorVersion, ".")
 				addon := orchProfile.KubernetesConfig.GetAddonByName(addonName)
-				templ := template.New("addon resolver template").Funcs(getAddonFuncMap(addon))
+				templ := template.New("addon resolver template").Funcs(getAddonFuncMap(addon, properties))
 				addonFile := getCustomDataFilePath(setting.sourceFile, sourcePath, versions[0]+"."+versions[1])
 				addonFileBytes, err := Asset(addonFile)
 				if err != nil {
-					return ""
+					return nil, err
 				}
 				_, err = templ.Parse(string(addonFileBytes))
 				if err != nil {
-					return ""
+					return nil, err
 				}
 				var buffer bytes.Buffer
 				templ.Execute(&buffer, addon)
 				input = buffer.String()
 			}
-			result += getAddonString(input, "/etc/kubernetes/addons", setting.destinationFile)
+			manifest, findings := protectSystemComponents(addonName, input, properties.OrchestratorProfile.OrchestratorVersion, true)
+			addons = append(addons, containerAddonManifest{
+				name:            addonName,
+				destinationFile: setting.destinationFile,
+				manifest:        manifest,
+				findings:        findings,
+			})
 		}
 	}
-	return result
+
+	customManifests, err := getCustomManifests(properties.OrchestratorProfile.KubernetesConfig)
+	if err != nil {
+		return nil, err
+	}
+	return append(addons, customManifests...), nil
+}
+
+// getDockerRegistryMirrors returns the dockerd registry-mirrors daemon.json value as a JSON array,
+// or "" if there are no Docker Hub mirrors; dockerd does not support mirrors for other registries
+func getDockerRegistryMirrors(k *api.KubernetesConfig) string {
+	for _, mirror := range k.GetRegistryMirrors() {
+		if mirror.Registry == api.DockerHubRegistry && len(mirror.Endpoints) > 0 {
+			b, _ := json.Marshal(mirror.Endpoints)
+			return string(b)
+		}
+	}
+	return ""
+}
+
+// getContainerdRegistryMirrorEndpoints returns the containerd CRI mirror endpoints for a registry as a TOML array.
+// containerd does not fall back to the registry itself once mirrors are configured, so it is always the last endpoint.
+func getContainerdRegistryMirrorEndpoints(mirror api.RegistryMirror) string {
+	upstream := "https://" + mirror.Registry
+	if mirror.Registry == api.DockerHubRegistry {
+		upstream = "https://registry-1.docker.io"
+	}
+	endpoints := append([]string{}, mirror.Endpoints...)
+	endpoints = append(endpoints, upstream)
+	b, _ := json.Marshal(endpoints)
+	return string(b)
+}
+
+// getContainerdRegistryMirrors returns the containerd CRI registry mirrors configuration, or "" if there are no mirrors
+func getContainerdRegistryMirrors(k *api.KubernetesConfig) string {
+	var b strings.Builder
+	for _, mirror := range k.GetRegistryMirrors() {
+		fmt.Fprintf(&b, "[plugins.cri.registry.mirrors.%q]\nendpoint = %s\n", mirror.Registry, getContainerdRegistryMirrorEndpoints(mirror))
+	}
+	return b.String()
+}
+
+// getCloudProviderConfig returns the azure.json settings of the cloudProviderConfig of kubernetesConfig as a JSON
+// object the nodes merge into their azure.json, or "" if it sets none
+func getCloudProviderConfig(k *api.KubernetesConfig) string {
+	if k == nil || k.CloudProviderConfig == nil {
+		return ""
+	}
+	c := k.CloudProviderConfig
+	settings := map[string]interface{}{}
+	for key, seconds := range map[string]int{
+		"vmssCacheTTLInSeconds":                 c.VMSSCacheTTLInSeconds,
+		"vmssVirtualMachinesCacheTTLInSeconds":  c.VMSSVirtualMachinesCacheTTLInSeconds,
+		"vmCacheTTLInSeconds":                   c.VMCacheTTLInSeconds,
+		"availabilitySetNodesCacheTTLInSeconds": c.AvailabilitySetNodesCacheTTLInSeconds,
+		"loadBalancerCacheTTLInSeconds":         c.LoadBalancerCacheTTLInSeconds,
+		"nsgCacheTTLInSeconds":                  c.NSGCacheTTLInSeconds,
+		"routeTableCacheTTLInSeconds":           c.RouteTableCacheTTLInSeconds,
+	} {
+		if seconds != 0 {
+			settings[key] = seconds
+		}
+	}
+	for client, rateLimit := range c.RateLimits {
+		settings[client+"RateLimit"] = rateLimit
+	}
+	if len(c.Tags) > 0 {
+		var tags []string
+		for key, value := range c.Tags {
+			tags = append(tags, key+"="+value)
+		}
+		sort.Strings(tags)
+		settings["tags"] = strings.Join(tags, ",")
+	}
+	if len(settings) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(settings)
+	return string(b)
 }
 
 func getDCOSMasterProvisionScript(orchProfile *api.OrchestratorProfile, bootstrapIP string) string {
@@ -919,22 +1140,11 @@ func orchestratorSupportsExtension(rootURL, orchestrator, extensionName, version
 func getExtensionResource(rootURL, extensionName, version, fileName, query string) ([]byte, error) {
 	requestURL := getExtensionURL(rootURL, extensionName, version, fileName, query)
 
-	res, err := http.Get(requestURL)
+	body, err := persona.Current().Get(requestURL)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Unable to GET extension resource for extension: %s with version %s with filename %s at URL: %s", extensionName, version, fileName, requestURL)
 	}
 
-	defer res.Body.Close()
-
-	if res.StatusCode != 200 {
-		return nil, errors.Errorf("Unable to GET extension resource for extension: %s with version %s with filename %s at URL: %s StatusCode: %s: Status: %s", extensionName, version, fileName, requestURL, strconv.Itoa(res.StatusCode), res.Status)
-	}
-
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return nil, errors.Wrapf(err, "Unable to GET extension resource for extension: %s with version %s  with filename %s at URL: %s", extensionName, version, fileName, requestURL)
-	}
-
 	return body, nil
 }
 
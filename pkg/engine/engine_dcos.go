@@ -1,4 +1,5 @@
-//+build !test
+//go:build !test
+// +build !test
 
 // Copyright (c) Microsoft Corporation. All rights reserved.
 // Licensed under the MIT license.
@@ -26,7 +26,7 @@ func createWindowsImageReference(agentPoolProfileName string, windowsProfile *ap
 			}
 		} else {
 			computeImageRef = compute.ImageReference{
-				ID: to.StringPtr("[resourceId(parameters('agentWindowsImageResourceGroup'), 'Microsoft.Compute/images', parameters('agentWindowsImageName'))]"),
+				ID: to.StringPtr(imageResourceID(imageRef, "parameters('agentWindowsImageResourceGroup')", "parameters('agentWindowsImageName')")),
 			}
 		}
 	} else {
@@ -63,3 +63,12 @@ func createWindowsImage(profile *api.AgentPoolProfile) ImageARM {
 		},
 	}
 }
+
+// imageResourceID returns the resource ID of the managed image of a reference, whose resource group and name are the
+// given template expressions, in the subscription of the reference if it names one
+func imageResourceID(imageRef *api.ImageReference, resourceGroup, name string) string {
+	if imageRef != nil && imageRef.SubscriptionID != "" {
+		return fmt.Sprintf("[resourceId('%s', %s, 'Microsoft.Compute/images', %s)]", imageRef.SubscriptionID, resourceGroup, name)
+	}
+	return fmt.Sprintf("[resourceId(%s, 'Microsoft.Compute/images', %s)]", resourceGroup, name)
+}
@@ -58,6 +58,20 @@ func TestCreateWindowsImageReference(t *testing.T) {
 				ID: to.StringPtr("[resourceId(parameters('agentWindowsImageResourceGroup'), 'Microsoft.Compute/images', parameters('agentWindowsImageName'))]"),
 			},
 		},
+		{
+			name:        "Image reference in another subscription",
+			profileName: "bar",
+			w: api.WindowsProfile{
+				ImageRef: &api.ImageReference{
+					Name:           "tead",
+					ResourceGroup:  "testRg",
+					SubscriptionID: "00000000-0000-0000-0000-000000000000",
+				},
+			},
+			expected: compute.ImageReference{
+				ID: to.StringPtr("[resourceId('00000000-0000-0000-0000-000000000000', parameters('agentWindowsImageResourceGroup'), 'Microsoft.Compute/images', parameters('agentWindowsImageName'))]"),
+			},
+		},
 		{
 			name:        "Marketplace image",
 			profileName: "baz",
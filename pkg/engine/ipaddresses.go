@@ -12,7 +12,8 @@ import (
 // When it's for master, this public ip address is created and added to the loadbalancer's frontendIPConfigurations
 // and it's created with the fqdn as name.
 // When it's for agent, this public ip address is created and added to the loadbalancer's frontendIPConfigurations.
-func CreatePublicIPAddress(isForMaster bool) PublicIPAddressARM {
+// When the cluster has availability zones, the public ip address is zone redundant across them.
+func CreatePublicIPAddress(isForMaster bool, zones []string) PublicIPAddressARM {
 	var dnsSettings *network.PublicIPAddressDNSSettings
 	name := "agentPublicIPAddressName"
 
@@ -23,7 +24,7 @@ func CreatePublicIPAddress(isForMaster bool) PublicIPAddressARM {
 		}
 	}
 
-	return PublicIPAddressARM{
+	publicIPAddress := PublicIPAddressARM{
 		ARMResource: ARMResource{
 			APIVersion: "[variables('apiVersionNetwork')]",
 		},
@@ -40,6 +41,12 @@ func CreatePublicIPAddress(isForMaster bool) PublicIPAddressARM {
 			Type: to.StringPtr("Microsoft.Network/publicIPAddresses"),
 		},
 	}
+
+	if len(zones) > 0 {
+		publicIPAddress.Zones = &zones
+	}
+
+	return publicIPAddress
 }
 
 func createAppGwPublicIPAddress() PublicIPAddressARM {
@@ -61,6 +68,25 @@ func createAppGwPublicIPAddress() PublicIPAddressARM {
 	}
 }
 
+func createNATGatewayPublicIPAddress() PublicIPAddressARM {
+	return PublicIPAddressARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionNetwork')]",
+		},
+		PublicIPAddress: network.PublicIPAddress{
+			Location: to.StringPtr("[variables('location')]"),
+			Name:     to.StringPtr("[variables('natGatewayPublicIPAddressName')]"),
+			PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{
+				PublicIPAllocationMethod: network.Static,
+			},
+			Sku: &network.PublicIPAddressSku{
+				Name: network.PublicIPAddressSkuNameStandard,
+			},
+			Type: to.StringPtr("Microsoft.Network/publicIPAddresses"),
+		},
+	}
+}
+
 func createJumpboxPublicIPAddress() PublicIPAddressARM {
 	return PublicIPAddressARM{
 		ARMResource: ARMResource{
@@ -33,7 +33,7 @@ func TestCreatePublicIPAddress(t *testing.T) {
 		},
 	}
 	isForMaster := true
-	actual := CreatePublicIPAddress(isForMaster)
+	actual := CreatePublicIPAddress(isForMaster, nil)
 
 	diff := cmp.Diff(actual, expected)
 
@@ -59,7 +59,18 @@ func TestCreatePublicIPAddress(t *testing.T) {
 		},
 	}
 	isForMaster = false
-	actual = CreatePublicIPAddress(isForMaster)
+	actual = CreatePublicIPAddress(isForMaster, nil)
+
+	diff = cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
+	}
+
+	// Testing CreatePublicIPAddress when the cluster has availability zones
+	zones := []string{"1", "2", "3"}
+	expected.Zones = &zones
+	actual = CreatePublicIPAddress(isForMaster, zones)
 
 	diff = cmp.Diff(actual, expected)
 
@@ -179,3 +190,30 @@ func TestCreateAppGwPublicIPAddress(t *testing.T) {
 		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
 	}
 }
+
+func TestCreateNATGatewayPublicIPAddress(t *testing.T) {
+	expected := PublicIPAddressARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionNetwork')]",
+		},
+		PublicIPAddress: network.PublicIPAddress{
+			Location: to.StringPtr("[variables('location')]"),
+			Name:     to.StringPtr("[variables('natGatewayPublicIPAddressName')]"),
+			PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{
+				PublicIPAllocationMethod: network.Static,
+			},
+			Sku: &network.PublicIPAddressSku{
+				Name: "Standard",
+			},
+			Type: to.StringPtr("Microsoft.Network/publicIPAddresses"),
+		},
+	}
+
+	actual := createNATGatewayPublicIPAddress()
+
+	diff := cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
+	}
+}
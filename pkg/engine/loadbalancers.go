@@ -322,6 +322,12 @@ func CreateAgentLoadBalancer(prop *api.Properties, isVMSS bool) LoadBalancerARM
 		},
 	}
 
+	if ports := prop.OrchestratorProfile.KubernetesConfig.OutboundRuleAllocatedPorts; ports > 0 {
+		for i := range *loadBalancer.OutboundRules {
+			(*loadBalancer.OutboundRules)[i].AllocatedOutboundPorts = to.Int32Ptr(ports)
+		}
+	}
+
 	return loadBalancer
 }
 
@@ -425,6 +431,40 @@ func CreateMasterInternalLoadBalancer(cs *api.ContainerService) LoadBalancerARM
 		*loadBalancer.LoadBalancerPropertiesFormat.LoadBalancingRules = append(*loadBalancer.LoadBalancerPropertiesFormat.LoadBalancingRules, udpRule)
 	}
 
+	// The konnectivity-agents of the nodes open their tunnels to the konnectivity-servers of the masters through the internal LB
+	if cs.Properties.OrchestratorProfile.KubernetesConfig.IsKonnectivityEnabled() {
+		konnectivityRule := network.LoadBalancingRule{
+			Name: to.StringPtr("InternalLBRuleKonnectivity"),
+			LoadBalancingRulePropertiesFormat: &network.LoadBalancingRulePropertiesFormat{
+				BackendAddressPool: &network.SubResource{
+					ID: to.StringPtr("[concat(variables('masterInternalLbID'), '/backendAddressPools/', variables('masterLbBackendPoolName'))]"),
+				},
+				BackendPort:      to.Int32Ptr(8132),
+				EnableFloatingIP: to.BoolPtr(false),
+				FrontendIPConfiguration: &network.SubResource{
+					ID: to.StringPtr("[variables('masterInternalLbIPConfigID')]"),
+				},
+				FrontendPort:         to.Int32Ptr(8132),
+				IdleTimeoutInMinutes: to.Int32Ptr(30),
+				Protocol:             network.TransportProtocolTCP,
+				Probe: &network.SubResource{
+					ID: to.StringPtr("[concat(variables('masterInternalLbID'),'/probes/tcpKonnectivityProbe')]"),
+				},
+			},
+		}
+		konnectivityProbe := network.Probe{
+			Name: to.StringPtr("tcpKonnectivityProbe"),
+			ProbePropertiesFormat: &network.ProbePropertiesFormat{
+				IntervalInSeconds: to.Int32Ptr(5),
+				NumberOfProbes:    to.Int32Ptr(2),
+				Port:              to.Int32Ptr(8132),
+				Protocol:          network.ProbeProtocolTCP,
+			},
+		}
+		*loadBalancer.LoadBalancerPropertiesFormat.LoadBalancingRules = append(*loadBalancer.LoadBalancerPropertiesFormat.LoadBalancingRules, konnectivityRule)
+		*loadBalancer.LoadBalancerPropertiesFormat.Probes = append(*loadBalancer.LoadBalancerPropertiesFormat.Probes, konnectivityProbe)
+	}
+
 	loadBalancerARM := LoadBalancerARM{
 		ARMResource:  armResource,
 		LoadBalancer: loadBalancer,
@@ -558,6 +558,48 @@ func TestCreateMasterInternalLoadBalancer(t *testing.T) {
 	if diff != "" {
 		t.Errorf("unexpected error while comparing load balancers: %s", diff)
 	}
+
+	// Test with konnectivity
+	cs.Properties.OrchestratorProfile.KubernetesConfig.KonnectivityProfile = &api.KonnectivityProfile{
+		Enabled: to.BoolPtr(true),
+	}
+
+	actual = CreateMasterInternalLoadBalancer(cs)
+
+	*expected.LoadBalancerPropertiesFormat.LoadBalancingRules = append(*expected.LoadBalancerPropertiesFormat.LoadBalancingRules, network.LoadBalancingRule{
+		Name: to.StringPtr("InternalLBRuleKonnectivity"),
+		LoadBalancingRulePropertiesFormat: &network.LoadBalancingRulePropertiesFormat{
+			BackendAddressPool: &network.SubResource{
+				ID: to.StringPtr("[concat(variables('masterInternalLbID'), '/backendAddressPools/', variables('masterLbBackendPoolName'))]"),
+			},
+			BackendPort:      to.Int32Ptr(8132),
+			EnableFloatingIP: to.BoolPtr(false),
+			FrontendIPConfiguration: &network.SubResource{
+				ID: to.StringPtr("[variables('masterInternalLbIPConfigID')]"),
+			},
+			FrontendPort:         to.Int32Ptr(8132),
+			IdleTimeoutInMinutes: to.Int32Ptr(30),
+			Protocol:             network.TransportProtocolTCP,
+			Probe: &network.SubResource{
+				ID: to.StringPtr("[concat(variables('masterInternalLbID'),'/probes/tcpKonnectivityProbe')]"),
+			},
+		},
+	})
+	*expected.LoadBalancerPropertiesFormat.Probes = append(*expected.LoadBalancerPropertiesFormat.Probes, network.Probe{
+		Name: to.StringPtr("tcpKonnectivityProbe"),
+		ProbePropertiesFormat: &network.ProbePropertiesFormat{
+			IntervalInSeconds: to.Int32Ptr(5),
+			NumberOfProbes:    to.Int32Ptr(2),
+			Port:              to.Int32Ptr(8132),
+			Protocol:          network.ProbeProtocolTCP,
+		},
+	})
+
+	diff = cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected error while comparing load balancers: %s", diff)
+	}
 }
 
 // TestCreateClusterLoadBalancerForIPv6 is a simple test..This setup and test will eventually
@@ -724,3 +766,27 @@ func TestCreateAgentLoadBalancer(t *testing.T) {
 	}
 
 }
+
+func TestCreateAgentLoadBalancerAllocatedPorts(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorVersion: "1.16.1",
+				KubernetesConfig: &api.KubernetesConfig{
+					LoadBalancerSku:                  StandardLoadBalancerSku,
+					OutboundRuleIdleTimeoutInMinutes: 15,
+					OutboundRuleAllocatedPorts:       1024,
+				},
+			},
+		},
+	}
+	actual := CreateAgentLoadBalancer(cs.Properties, false)
+
+	rule := (*actual.OutboundRules)[0]
+	if to.Int32(rule.AllocatedOutboundPorts) != 1024 {
+		t.Errorf("expected the outbound rule to allocate 1024 ports, got %d", to.Int32(rule.AllocatedOutboundPorts))
+	}
+	if to.Int32(rule.IdleTimeoutInMinutes) != 15 {
+		t.Errorf("expected the outbound rule to have an idle timeout of 15 minutes, got %d", to.Int32(rule.IdleTimeoutInMinutes))
+	}
+}
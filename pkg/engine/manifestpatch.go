@@ -0,0 +1,139 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"encoding/base64"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// manifestArgsPlaceholder is the placeholder of the flags of a static pod manifest, replaced on the masters by an inline list.
+// It is a single item list once the manifest is parsed, and must be inline again in the patched manifest.
+var manifestArgsPlaceholder = regexp.MustCompile(`args:\n *- <args>\n`)
+
+// patchManifestSettings applies the manifest patches of the apimodel to the static pod manifests of the control plane components,
+// the patched manifests are delivered as base64 data instead of their source file
+func patchManifestSettings(specs []kubernetesComponentFileSpec, p *api.Properties) ([]kubernetesComponentFileSpec, error) {
+	k := p.OrchestratorProfile.KubernetesConfig
+	if k == nil || len(k.ManifestPatches) == 0 {
+		return specs, nil
+	}
+	versions := strings.Split(p.OrchestratorProfile.OrchestratorVersion, ".")
+	patched := make([]kubernetesComponentFileSpec, len(specs))
+	for i, spec := range specs {
+		patched[i] = spec
+		component := strings.TrimSuffix(spec.destinationFile, ".yaml")
+		// the flags of the patches are added to the component config, only the patch documents change the manifest
+		var patches []api.ManifestPatch
+		for _, patch := range k.GetManifestPatches(component) {
+			if patch.Patch != nil {
+				patches = append(patches, patch)
+			}
+		}
+		if !spec.isEnabled || len(patches) == 0 {
+			continue
+		}
+		var manifest []byte
+		var err error
+		if spec.base64Data != "" {
+			manifest, err = base64.StdEncoding.DecodeString(spec.base64Data)
+		} else {
+			manifest, err = Asset(getCustomDataFilePath(spec.sourceFile, "k8s/manifests", versions[0]+"."+versions[1]))
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading the %s manifest", component)
+		}
+		if manifest, err = patchManifest(manifest, patches); err != nil {
+			return nil, errors.Wrapf(err, "patching the %s manifest", component)
+		}
+		patched[i].base64Data = base64.StdEncoding.EncodeToString(manifest)
+	}
+	return patched, nil
+}
+
+// patchManifest merges the patches into a static pod manifest
+func patchManifest(manifest []byte, patches []api.ManifestPatch) ([]byte, error) {
+	var pod interface{}
+	if err := yaml.Unmarshal(manifest, &pod); err != nil {
+		return nil, errors.Wrap(err, "parsing the manifest")
+	}
+	for _, patch := range patches {
+		// the patch is copied through YAML, so that the merge does not share the maps of the apimodel
+		b, err := yaml.Marshal(patch.Patch)
+		if err != nil {
+			return nil, err
+		}
+		var document interface{}
+		if err = yaml.Unmarshal(b, &document); err != nil {
+			return nil, err
+		}
+		pod = mergeManifestPatch(pod, document)
+	}
+	b, err := yaml.Marshal(pod)
+	if err != nil {
+		return nil, errors.Wrap(err, "writing the patched manifest")
+	}
+	return manifestArgsPlaceholder.ReplaceAll(b, []byte("args: [<args>]\n")), nil
+}
+
+// mergeManifestPatch merges a patch into a value of the manifest. Objects are merged recursively, a null value removes the field.
+// The lists of objects with a name, e.g. the containers, volumes, volumeMounts and env, are merged by name and the new items appended,
+// other lists are replaced.
+func mergeManifestPatch(value, patch interface{}) interface{} {
+	switch p := patch.(type) {
+	case map[string]interface{}:
+		v, ok := value.(map[string]interface{})
+		if !ok {
+			v = map[string]interface{}{}
+		}
+		for key, item := range p {
+			if item == nil {
+				delete(v, key)
+				continue
+			}
+			v[key] = mergeManifestPatch(v[key], item)
+		}
+		return v
+	case []interface{}:
+		v, ok := value.([]interface{})
+		if !ok || !isNamedList(p) || !isNamedList(v) {
+			return p
+		}
+		for _, item := range p {
+			name := item.(map[string]interface{})["name"]
+			merged := false
+			for i, existing := range v {
+				if existing.(map[string]interface{})["name"] == name {
+					v[i] = mergeManifestPatch(existing, item)
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				v = append(v, item)
+			}
+		}
+		return v
+	}
+	return patch
+}
+
+// isNamedList returns true if the items of a list are objects with a name
+func isNamedList(list []interface{}) bool {
+	for _, item := range list {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := object["name"].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
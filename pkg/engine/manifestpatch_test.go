@@ -0,0 +1,131 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/ghodss/yaml"
+)
+
+func TestPatchManifest(t *testing.T) {
+	manifest, err := Asset("k8s/manifests/kubernetesmaster-kube-apiserver.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	patches := []api.ManifestPatch{
+		{
+			Component: api.KubeAPIServerComponentName,
+			Patch: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name": "kube-apiserver",
+							"volumeMounts": []interface{}{
+								map[string]interface{}{"name": "webhook", "mountPath": "/etc/webhook", "readOnly": true},
+								map[string]interface{}{"name": "auditlog", "mountPath": "/var/log/audit"},
+							},
+						},
+						map[string]interface{}{"name": "audit-forwarder", "image": "contoso/audit-forwarder:v1"},
+					},
+					"volumes": []interface{}{
+						map[string]interface{}{"name": "webhook", "hostPath": map[string]interface{}{"path": "/etc/webhook"}},
+					},
+				},
+			},
+		},
+		{
+			Component: api.KubeAPIServerComponentName,
+			Patch: map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"tier": nil, "team": "platform"}},
+			},
+		},
+	}
+	b, err := patchManifest(manifest, patches)
+	if err != nil {
+		t.Fatalf("unexpected error patching the manifest: %s", err)
+	}
+	if !strings.Contains(string(b), "args: [<args>]\n") || !strings.Contains(string(b), "image: <img>\n") {
+		t.Errorf("expected the placeholders of the manifest to be kept, got\n%s", b)
+	}
+
+	var pod struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+		Spec struct {
+			Containers []struct {
+				Name         string `json:"name"`
+				VolumeMounts []struct {
+					Name      string `json:"name"`
+					MountPath string `json:"mountPath"`
+				} `json:"volumeMounts"`
+			} `json:"containers"`
+			Volumes []struct {
+				Name string `json:"name"`
+			} `json:"volumes"`
+		} `json:"spec"`
+	}
+	if err = yaml.Unmarshal(b, &pod); err != nil {
+		t.Fatalf("unexpected error parsing the patched manifest: %s", err)
+	}
+	if len(pod.Spec.Containers) != 2 || pod.Spec.Containers[0].Name != "kube-apiserver" || pod.Spec.Containers[1].Name != "audit-forwarder" {
+		t.Fatalf("expected the sidecar to be added after the kube-apiserver container, got %+v", pod.Spec.Containers)
+	}
+	mounts := map[string]string{}
+	for _, m := range pod.Spec.Containers[0].VolumeMounts {
+		mounts[m.Name] = m.MountPath
+	}
+	if len(mounts) != 6 || mounts["webhook"] != "/etc/webhook" || mounts["auditlog"] != "/var/log/audit" || mounts["etc-kubernetes"] != "/etc/kubernetes" {
+		t.Errorf("expected the volume mounts to be merged by name, got %v", mounts)
+	}
+	if len(pod.Spec.Volumes) != 6 || pod.Spec.Volumes[5].Name != "webhook" {
+		t.Errorf("expected the webhook volume to be added, got %+v", pod.Spec.Volumes)
+	}
+	if _, ok := pod.Metadata.Labels["tier"]; ok || pod.Metadata.Labels["team"] != "platform" || pod.Metadata.Labels["component"] != "kube-apiserver" {
+		t.Errorf("expected the tier label to be removed and the team label to be added, got %v", pod.Metadata.Labels)
+	}
+}
+
+func TestPatchManifestSettings(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.16.1", 3, 2, false)
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+	k.SchedulerConfig = map[string]string{
+		"data": base64.StdEncoding.EncodeToString([]byte("apiVersion: v1\nkind: Pod\nspec:\n  containers:\n  - name: kube-scheduler\n    image: <img>\n")),
+	}
+	k.ManifestPatches = []api.ManifestPatch{
+		{
+			Component: api.KubeSchedulerComponentName,
+			Patch:     map[string]interface{}{"spec": map[string]interface{}{"priorityClassName": "system-node-critical"}},
+		},
+		{
+			Component: api.KubeAPIServerComponentName,
+			ExtraArgs: map[string]string{"--v": "4"},
+		},
+	}
+	specs, err := patchManifestSettings(kubernetesManifestSettingsInit(cs.Properties), cs.Properties)
+	if err != nil {
+		t.Fatalf("unexpected error patching the manifests: %s", err)
+	}
+	for _, spec := range specs {
+		switch spec.destinationFile {
+		case "kube-scheduler.yaml":
+			b, err := base64.StdEncoding.DecodeString(spec.base64Data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(b), "priorityClassName: system-node-critical") || !strings.Contains(string(b), "name: kube-scheduler") {
+				t.Errorf("expected the user-provided kube-scheduler manifest to be patched, got\n%s", b)
+			}
+		case "kube-apiserver.yaml", "kube-controller-manager.yaml":
+			// the kube-apiserver patch only has flags, which are set on the masters from the apiserver config
+			if spec.base64Data != "" {
+				t.Errorf("expected the %s manifest not to be patched", spec.destinationFile)
+			}
+		}
+	}
+}
@@ -35,6 +35,10 @@ func createKubernetesMasterResourcesVMAS(cs *api.ContainerService) []interface{}
 	masterNsg := CreateNetworkSecurityGroup(cs)
 	masterResources = append(masterResources, masterNsg)
 
+	if p.MasterProfile.HasDedicatedEtcdSubnet() {
+		masterResources = append(masterResources, createEtcdNSG(), createEtcdNetworkInterfaces(cs))
+	}
+
 	if cs.Properties.OrchestratorProfile.RequireRouteTable() {
 		masterResources = append(masterResources, createRouteTable())
 	}
@@ -43,7 +47,7 @@ func createKubernetesMasterResourcesVMAS(cs *api.ContainerService) []interface{}
 
 	if !cs.Properties.OrchestratorProfile.IsPrivateCluster() {
 		isForMaster := true
-		publicIPAddress := CreatePublicIPAddress(isForMaster)
+		publicIPAddress := CreatePublicIPAddress(isForMaster, cs.Properties.GetAvailabilityZones())
 		loadBalancer := CreateLoadBalancer(cs.Properties, false)
 		masterNic := CreateNetworkInterfaces(cs)
 
@@ -99,6 +103,10 @@ func createKubernetesMasterResourcesVMAS(cs *api.ContainerService) []interface{}
 		masterResources = append(masterResources, clusterIPv4PublicIPAddress, clusterIPv6PublicIPAddress, clusterLB)
 	}
 
+	if cs.Properties.MasterProfile.HasUltraSSD() && !cs.Properties.MasterProfile.HasCosmosEtcd() {
+		masterResources = append(masterResources, createMasterEtcdDisks(cs))
+	}
+
 	masterVM := CreateMasterVM(cs)
 	masterResources = append(masterResources, masterVM)
 
@@ -122,6 +130,10 @@ func createKubernetesMasterResourcesVMAS(cs *api.ContainerService) []interface{}
 		masterResources = append(masterResources, aksBillingExtension)
 	}
 
+	for _, ext := range createMasterVMExtensions(cs) {
+		masterResources = append(masterResources, ext)
+	}
+
 	customExtensions := CreateCustomExtensions(cs.Properties)
 	for _, ext := range customExtensions {
 		masterResources = append(masterResources, ext)
@@ -155,7 +167,7 @@ func createKubernetesMasterResourcesVMSS(cs *api.ContainerService) []interface{}
 
 	if !cs.Properties.OrchestratorProfile.IsPrivateCluster() {
 		isForMaster := true
-		publicIPAddress := CreatePublicIPAddress(isForMaster)
+		publicIPAddress := CreatePublicIPAddress(isForMaster, cs.Properties.GetAvailabilityZones())
 		loadBalancer := CreateLoadBalancer(cs.Properties, true)
 		masterResources = append(masterResources, publicIPAddress, loadBalancer)
 	}
@@ -0,0 +1,33 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"github.com/Azure/aks-engine/pkg/api"
+)
+
+// createNATGateway returns the NAT gateway the subnets of the VNET send their egress traffic through.
+// The network SDK vendored here predates NAT gateways, so the resource is a plain map.
+func createNATGateway(prop *api.Properties) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "[variables('apiVersionNATGateway')]",
+		"name":       "[variables('natGatewayName')]",
+		"location":   "[variables('location')]",
+		"type":       "Microsoft.Network/natGateways",
+		"dependsOn": []string{
+			"[concat('Microsoft.Network/publicIPAddresses/', variables('natGatewayPublicIPAddressName'))]",
+		},
+		"sku": map[string]string{
+			"name": "Standard",
+		},
+		"properties": map[string]interface{}{
+			"idleTimeoutInMinutes": prop.OrchestratorProfile.KubernetesConfig.OutboundRuleIdleTimeoutInMinutes,
+			"publicIpAddresses": []map[string]string{
+				{
+					"id": "[resourceId('Microsoft.Network/publicIPAddresses', variables('natGatewayPublicIPAddressName'))]",
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCreateNATGateway(t *testing.T) {
+	prop := &api.Properties{
+		OrchestratorProfile: &api.OrchestratorProfile{
+			KubernetesConfig: &api.KubernetesConfig{
+				LoadBalancerSku:                  api.StandardLoadBalancerSku,
+				OutboundType:                     api.OutboundTypeNATGateway,
+				OutboundRuleIdleTimeoutInMinutes: 10,
+			},
+		},
+	}
+
+	actual := createNATGateway(prop)
+	expected := map[string]interface{}{
+		"apiVersion": "[variables('apiVersionNATGateway')]",
+		"name":       "[variables('natGatewayName')]",
+		"location":   "[variables('location')]",
+		"type":       "Microsoft.Network/natGateways",
+		"dependsOn": []string{
+			"[concat('Microsoft.Network/publicIPAddresses/', variables('natGatewayPublicIPAddressName'))]",
+		},
+		"sku": map[string]string{
+			"name": "Standard",
+		},
+		"properties": map[string]interface{}{
+			"idleTimeoutInMinutes": int32(10),
+			"publicIpAddresses": []map[string]string{
+				{
+					"id": "[resourceId('Microsoft.Network/publicIPAddresses', variables('natGatewayPublicIPAddressName'))]",
+				},
+			},
+		},
+	}
+
+	diff := cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing NAT gateways: %s", diff)
+	}
+}
@@ -107,12 +107,9 @@ func CreateNetworkInterfaces(cs *api.ContainerService) NetworkInterfaceARM {
 		ipConfigurations = append(ipConfigurations, ipv6Config)
 	}
 
-	linuxProfile := cs.Properties.LinuxProfile
-	if linuxProfile != nil && linuxProfile.HasCustomNodesDNS() {
+	if dnsServers := getNodeDNSServers(cs.Properties.MasterProfile.DNSConfig, cs.Properties.LinuxProfile, false); dnsServers != nil {
 		nicProperties.DNSSettings = &network.InterfaceDNSSettings{
-			DNSServers: &[]string{
-				"[parameters('dnsServer')]",
-			},
+			DNSServers: dnsServers,
 		}
 	}
 
@@ -135,6 +132,61 @@ func CreateNetworkInterfaces(cs *api.ContainerService) NetworkInterfaceARM {
 	}
 }
 
+// createEtcdNetworkInterfaces creates the second NIC of each master, with a static IP on the etcd subnet that etcd binds to
+func createEtcdNetworkInterfaces(cs *api.ContainerService) NetworkInterfaceARM {
+	var dependencies []string
+	if cs.Properties.MasterProfile.IsCustomVNET() {
+		dependencies = append(dependencies, "[variables('etcdNsgID')]")
+	} else {
+		dependencies = append(dependencies, "[variables('vnetID')]")
+	}
+
+	armResource := ARMResource{
+		APIVersion: "[variables('apiVersionNetwork')]",
+		Copy: map[string]string{
+			"count": "[sub(variables('masterCount'), variables('masterOffset'))]",
+			"name":  "etcdNicLoopNode",
+		},
+		DependsOn: dependencies,
+	}
+
+	ipConfigurations := []network.InterfaceIPConfiguration{
+		{
+			Name: to.StringPtr("ipconfig1"),
+			InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+				PrivateIPAddress:          to.StringPtr("[variables('masterEtcdPrivateIpAddrs')[copyIndex(variables('masterOffset'))]]"),
+				Primary:                   to.BoolPtr(true),
+				PrivateIPAllocationMethod: network.Static,
+				Subnet: &network.Subnet{
+					ID: to.StringPtr("[variables('etcdVnetSubnetID')]"),
+				},
+			},
+		},
+	}
+
+	nicProperties := network.InterfacePropertiesFormat{
+		IPConfigurations: &ipConfigurations,
+	}
+
+	if cs.Properties.MasterProfile.IsCustomVNET() {
+		nicProperties.NetworkSecurityGroup = &network.SecurityGroup{
+			ID: to.StringPtr("[variables('etcdNsgID')]"),
+		}
+	}
+
+	networkInterface := network.Interface{
+		Location:                  to.StringPtr("[variables('location')]"),
+		Name:                      to.StringPtr("[concat(variables('masterVMNamePrefix'), 'etcd-nic-', copyIndex(variables('masterOffset')))]"),
+		InterfacePropertiesFormat: &nicProperties,
+		Type:                      to.StringPtr("Microsoft.Network/networkInterfaces"),
+	}
+
+	return NetworkInterfaceARM{
+		ARMResource: armResource,
+		Interface:   networkInterface,
+	}
+}
+
 func createPrivateClusterNetworkInterface(cs *api.ContainerService) NetworkInterfaceARM {
 	var dependencies []string
 	if cs.Properties.MasterProfile.IsCustomVNET() {
@@ -207,12 +259,9 @@ func createPrivateClusterNetworkInterface(cs *api.ContainerService) NetworkInter
 		nicProperties.EnableIPForwarding = to.BoolPtr(true)
 	}
 
-	linuxProfile := cs.Properties.LinuxProfile
-	if linuxProfile != nil && linuxProfile.HasCustomNodesDNS() {
+	if dnsServers := getNodeDNSServers(cs.Properties.MasterProfile.DNSConfig, cs.Properties.LinuxProfile, false); dnsServers != nil {
 		nicProperties.DNSSettings = &network.InterfaceDNSSettings{
-			DNSServers: &[]string{
-				"[parameters('dnsServer')]",
-			},
+			DNSServers: dnsServers,
 		}
 	}
 
@@ -307,7 +356,7 @@ func createAgentVMASNetworkInterface(cs *api.ContainerService, profile *api.Agen
 	}
 	if !cs.Properties.OrchestratorProfile.IsPrivateCluster() &&
 		profile.LoadBalancerBackendAddressPoolIDs == nil &&
-		cs.Properties.OrchestratorProfile.KubernetesConfig.LoadBalancerSku == api.StandardLoadBalancerSku &&
+		cs.Properties.OrchestratorProfile.KubernetesConfig.HasOutboundLoadBalancer() &&
 		!isHostedMaster {
 		dependencies = append(dependencies, "[variables('agentLbID')]")
 	}
@@ -334,8 +383,14 @@ func createAgentVMASNetworkInterface(cs *api.ContainerService, profile *api.Agen
 		networkInterface.EnableAcceleratedNetworking = profile.AcceleratedNetworkingEnabled
 	}
 
+	// With a pod subnet the pod IPs are on the pod NIC, the node NIC only has the IP of the node
+	ipAddressCount := profile.IPAddressCount
+	if profile.HasPodSubnet() {
+		ipAddressCount = 1
+	}
+
 	var ipConfigurations []network.InterfaceIPConfiguration
-	for i := 1; i <= profile.IPAddressCount; i++ {
+	for i := 1; i <= ipAddressCount; i++ {
 		ipConfig := network.InterfaceIPConfiguration{
 			Name:                                     to.StringPtr(fmt.Sprintf("ipconfig%d", i)),
 			InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{},
@@ -353,7 +408,7 @@ func createAgentVMASNetworkInterface(cs *api.ContainerService, profile *api.Agen
 				}
 			} else {
 				if !cs.Properties.OrchestratorProfile.IsPrivateCluster() &&
-					cs.Properties.OrchestratorProfile.KubernetesConfig.LoadBalancerSku == api.StandardLoadBalancerSku &&
+					cs.Properties.OrchestratorProfile.KubernetesConfig.HasOutboundLoadBalancer() &&
 					!isHostedMaster {
 					agentLbBackendAddressPools := network.BackendAddressPool{
 						ID: to.StringPtr("[concat(variables('agentLbID'), '/backendAddressPools/', variables('agentLbBackendPoolName'))]"),
@@ -412,6 +467,12 @@ func createAgentVMASNetworkInterface(cs *api.ContainerService, profile *api.Agen
 
 	networkInterface.IPConfigurations = &ipConfigurations
 
+	if dnsServers := getNodeDNSServers(profile.DNSConfig, nil, profile.IsWindows()); dnsServers != nil {
+		networkInterface.DNSSettings = &network.InterfaceDNSSettings{
+			DNSServers: dnsServers,
+		}
+	}
+
 	if !isAzureCNI && !cs.Properties.IsAzureStackCloud() {
 		networkInterface.EnableIPForwarding = to.BoolPtr(true)
 	}
@@ -422,6 +483,51 @@ func createAgentVMASNetworkInterface(cs *api.ContainerService, profile *api.Agen
 	}
 }
 
+// createAgentVMASPodNetworkInterface creates the second NIC of each node of a pool with a pod subnet,
+// whose IPs in the pod subnet are allocated to the pods by Azure CNI
+func createAgentVMASPodNetworkInterface(profile *api.AgentPoolProfile) NetworkInterfaceARM {
+	armResource := ARMResource{
+		APIVersion: "[variables('apiVersionNetwork')]",
+		Copy: map[string]string{
+			"count": fmt.Sprintf("[sub(variables('%[1]sCount'), variables('%[1]sOffset'))]", profile.Name),
+			"name":  "podNicLoop",
+		},
+		DependsOn: []string{"[variables('nsgID')]"},
+	}
+
+	var ipConfigurations []network.InterfaceIPConfiguration
+	for i := 1; i <= profile.IPAddressCount; i++ {
+		ipConfigurations = append(ipConfigurations, network.InterfaceIPConfiguration{
+			Name: to.StringPtr(fmt.Sprintf("ipconfig%d", i)),
+			InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+				Primary:                   to.BoolPtr(i == 1),
+				PrivateIPAllocationMethod: network.Dynamic,
+				Subnet: &network.Subnet{
+					ID: to.StringPtr(fmt.Sprintf("[variables('%sPodSubnetID')]", profile.Name)),
+				},
+			},
+		})
+	}
+
+	networkInterface := network.Interface{
+		Type:     to.StringPtr("Microsoft.Network/networkInterfaces"),
+		Name:     to.StringPtr("[concat(variables('" + profile.Name + "VMNamePrefix'), 'pod-nic-', copyIndex(variables('" + profile.Name + "Offset')))]"),
+		Location: to.StringPtr("[variables('location')]"),
+		InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
+			IPConfigurations:            &ipConfigurations,
+			EnableAcceleratedNetworking: profile.AcceleratedNetworkingEnabled,
+			NetworkSecurityGroup: &network.SecurityGroup{
+				ID: to.StringPtr("[variables('nsgID')]"),
+			},
+		},
+	}
+
+	return NetworkInterfaceARM{
+		ARMResource: armResource,
+		Interface:   networkInterface,
+	}
+}
+
 func getSecondaryNICIPConfigs(n int) []network.InterfaceIPConfiguration {
 	var ipConfigurations []network.InterfaceIPConfiguration
 	for i := 2; i <= n; i++ {
@@ -605,6 +605,79 @@ func TestCreateJumpboxNIC(t *testing.T) {
 		t.Errorf("unexpected diff while comparing: %s", diff)
 	}
 }
+func TestCreateEtcdNetworkInterfaces(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			MasterProfile: &api.MasterProfile{
+				Count:      3,
+				DNSPrefix:  "myprefix1",
+				VMSize:     "Standard_DS2_v2",
+				EtcdSubnet: "10.239.0.0/24",
+			},
+		},
+	}
+
+	actual := createEtcdNetworkInterfaces(cs)
+
+	expected := NetworkInterfaceARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionNetwork')]",
+			Copy: map[string]string{
+				"count": "[sub(variables('masterCount'), variables('masterOffset'))]",
+				"name":  "etcdNicLoopNode",
+			},
+			DependsOn: []string{
+				"[variables('vnetID')]",
+			},
+		},
+		Interface: network.Interface{
+			Location: to.StringPtr("[variables('location')]"),
+			Name:     to.StringPtr("[concat(variables('masterVMNamePrefix'), 'etcd-nic-', copyIndex(variables('masterOffset')))]"),
+			InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
+				IPConfigurations: &[]network.InterfaceIPConfiguration{
+					{
+						Name: to.StringPtr("ipconfig1"),
+						InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+							PrivateIPAddress:          to.StringPtr("[variables('masterEtcdPrivateIpAddrs')[copyIndex(variables('masterOffset'))]]"),
+							Primary:                   to.BoolPtr(true),
+							PrivateIPAllocationMethod: network.Static,
+							Subnet: &network.Subnet{
+								ID: to.StringPtr("[variables('etcdVnetSubnetID')]"),
+							},
+						},
+					},
+				},
+			},
+			Type: to.StringPtr("Microsoft.Network/networkInterfaces"),
+		},
+	}
+
+	diff := cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing: %s", diff)
+	}
+
+	// Test with custom VNET, the etcd NSG is attached to the NICs
+	cs.Properties.MasterProfile.VnetSubnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME"
+	cs.Properties.MasterProfile.EtcdVnetSubnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/ETCD_SUBNET_NAME"
+
+	actual = createEtcdNetworkInterfaces(cs)
+
+	expected.DependsOn = []string{
+		"[variables('etcdNsgID')]",
+	}
+	expected.NetworkSecurityGroup = &network.SecurityGroup{
+		ID: to.StringPtr("[variables('etcdNsgID')]"),
+	}
+
+	diff = cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing: %s", diff)
+	}
+}
+
 func TestCreateAgentVMASNICWithSLB(t *testing.T) {
 	cs := &api.ContainerService{
 		Properties: &api.Properties{
@@ -693,6 +766,100 @@ func TestCreateAgentVMASNICWithSLB(t *testing.T) {
 	}
 }
 
+func TestCreateAgentVMASPodNetworkInterface(t *testing.T) {
+	profile := &api.AgentPoolProfile{
+		Name:                         "fooAgent",
+		OSType:                       "Linux",
+		IPAddressCount:               3,
+		VnetSubnetID:                 "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME",
+		PodSubnetID:                  "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/POD_SUBNET_NAME",
+		AcceleratedNetworkingEnabled: to.BoolPtr(true),
+	}
+
+	actual := createAgentVMASPodNetworkInterface(profile)
+
+	expected := NetworkInterfaceARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionNetwork')]",
+			Copy: map[string]string{
+				"count": "[sub(variables('fooAgentCount'), variables('fooAgentOffset'))]",
+				"name":  "podNicLoop",
+			},
+			DependsOn: []string{
+				"[variables('nsgID')]",
+			},
+		},
+		Interface: network.Interface{
+			Type:     to.StringPtr("Microsoft.Network/networkInterfaces"),
+			Name:     to.StringPtr("[concat(variables('fooAgentVMNamePrefix'), 'pod-nic-', copyIndex(variables('fooAgentOffset')))]"),
+			Location: to.StringPtr("[variables('location')]"),
+			InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
+				IPConfigurations: &[]network.InterfaceIPConfiguration{
+					{
+						Name: to.StringPtr("ipconfig1"),
+						InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+							Primary:                   to.BoolPtr(true),
+							PrivateIPAllocationMethod: network.Dynamic,
+							Subnet: &network.Subnet{
+								ID: to.StringPtr("[variables('fooAgentPodSubnetID')]"),
+							},
+						},
+					},
+					{
+						Name: to.StringPtr("ipconfig2"),
+						InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+							Primary:                   to.BoolPtr(false),
+							PrivateIPAllocationMethod: network.Dynamic,
+							Subnet: &network.Subnet{
+								ID: to.StringPtr("[variables('fooAgentPodSubnetID')]"),
+							},
+						},
+					},
+					{
+						Name: to.StringPtr("ipconfig3"),
+						InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+							Primary:                   to.BoolPtr(false),
+							PrivateIPAllocationMethod: network.Dynamic,
+							Subnet: &network.Subnet{
+								ID: to.StringPtr("[variables('fooAgentPodSubnetID')]"),
+							},
+						},
+					},
+				},
+				EnableAcceleratedNetworking: to.BoolPtr(true),
+				NetworkSecurityGroup: &network.SecurityGroup{
+					ID: to.StringPtr("[variables('nsgID')]"),
+				},
+			},
+		},
+	}
+
+	diff := cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing: %s", diff)
+	}
+
+	// The node NIC only keeps the IP of the node
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorType: api.Kubernetes,
+				KubernetesConfig: &api.KubernetesConfig{
+					NetworkPlugin: "azure",
+				},
+			},
+			AgentPoolProfiles: []*api.AgentPoolProfile{profile},
+		},
+	}
+
+	nic := createAgentVMASNetworkInterface(cs, profile)
+
+	if len(*nic.IPConfigurations) != 1 {
+		t.Errorf("expected the node NIC to have 1 ip configuration, instead got %d", len(*nic.IPConfigurations))
+	}
+}
+
 func TestCreateAgentVMASNICWithSLBHostedMaster(t *testing.T) {
 	cs := &api.ContainerService{
 		Properties: &api.Properties{
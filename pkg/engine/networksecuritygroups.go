@@ -4,6 +4,8 @@
 package engine
 
 import (
+	"fmt"
+
 	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
@@ -191,6 +193,56 @@ func createJumpboxNSG() NetworkSecurityGroupARM {
 	}
 }
 
+// createEtcdNSG creates the NSG of the etcd subnet, which only lets etcd peer and client traffic in from the etcd NICs of the masters
+func createEtcdNSG() NetworkSecurityGroupARM {
+	armResource := ARMResource{
+		APIVersion: "[variables('apiVersionNetwork')]",
+	}
+
+	securityRules := []network.SecurityRule{
+		{
+			Name: to.StringPtr("allow_etcd"),
+			SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+				Access:                   network.SecurityRuleAccessAllow,
+				Description:              to.StringPtr("Allow etcd peer and client traffic between masters"),
+				DestinationAddressPrefix: to.StringPtr("[parameters('etcdSubnet')]"),
+				DestinationPortRange:     to.StringPtr(fmt.Sprintf("%d-%d", DefaultMasterEtcdClientPort, DefaultMasterEtcdServerPort)),
+				Direction:                network.SecurityRuleDirectionInbound,
+				Priority:                 to.Int32Ptr(100),
+				Protocol:                 network.SecurityRuleProtocolTCP,
+				SourceAddressPrefix:      to.StringPtr("[parameters('etcdSubnet')]"),
+				SourcePortRange:          to.StringPtr("*"),
+			},
+		},
+		{
+			Name: to.StringPtr("deny_vnet_inbound"),
+			SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+				Access:                   network.SecurityRuleAccessDeny,
+				Description:              to.StringPtr("Deny any other traffic from the VNET to the etcd subnet"),
+				DestinationAddressPrefix: to.StringPtr("*"),
+				DestinationPortRange:     to.StringPtr("*"),
+				Direction:                network.SecurityRuleDirectionInbound,
+				Priority:                 to.Int32Ptr(4096),
+				Protocol:                 network.SecurityRuleProtocolAsterisk,
+				SourceAddressPrefix:      to.StringPtr("VirtualNetwork"),
+				SourcePortRange:          to.StringPtr("*"),
+			},
+		},
+	}
+	nsg := network.SecurityGroup{
+		Location: to.StringPtr("[variables('location')]"),
+		Name:     to.StringPtr("[variables('etcdNsgName')]"),
+		Type:     to.StringPtr("Microsoft.Network/networkSecurityGroups"),
+		SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
+			SecurityRules: &securityRules,
+		},
+	}
+	return NetworkSecurityGroupARM{
+		ARMResource:   armResource,
+		SecurityGroup: nsg,
+	}
+}
+
 func createHostedMasterNSG() NetworkSecurityGroupARM {
 	armResource := ARMResource{
 		APIVersion: "[variables('apiVersionNetwork')]",
@@ -195,6 +195,59 @@ func TestCreateJumpboxNSG(t *testing.T) {
 	}
 }
 
+func TestCreateEtcdNSG(t *testing.T) {
+	expected := NetworkSecurityGroupARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionNetwork')]",
+		},
+		SecurityGroup: network.SecurityGroup{
+			Location: to.StringPtr("[variables('location')]"),
+			Name:     to.StringPtr("[variables('etcdNsgName')]"),
+			Type:     to.StringPtr("Microsoft.Network/networkSecurityGroups"),
+			SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
+				SecurityRules: &[]network.SecurityRule{
+					{
+						Name: to.StringPtr("allow_etcd"),
+						SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+							Access:                   network.SecurityRuleAccessAllow,
+							Description:              to.StringPtr("Allow etcd peer and client traffic between masters"),
+							DestinationAddressPrefix: to.StringPtr("[parameters('etcdSubnet')]"),
+							DestinationPortRange:     to.StringPtr("2379-2380"),
+							Direction:                network.SecurityRuleDirectionInbound,
+							Priority:                 to.Int32Ptr(100),
+							Protocol:                 network.SecurityRuleProtocolTCP,
+							SourceAddressPrefix:      to.StringPtr("[parameters('etcdSubnet')]"),
+							SourcePortRange:          to.StringPtr("*"),
+						},
+					},
+					{
+						Name: to.StringPtr("deny_vnet_inbound"),
+						SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+							Access:                   network.SecurityRuleAccessDeny,
+							Description:              to.StringPtr("Deny any other traffic from the VNET to the etcd subnet"),
+							DestinationAddressPrefix: to.StringPtr("*"),
+							DestinationPortRange:     to.StringPtr("*"),
+							Direction:                network.SecurityRuleDirectionInbound,
+							Priority:                 to.Int32Ptr(4096),
+							Protocol:                 network.SecurityRuleProtocolAsterisk,
+							SourceAddressPrefix:      to.StringPtr("VirtualNetwork"),
+							SourcePortRange:          to.StringPtr("*"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	actual := createEtcdNSG()
+
+	diff := cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing nsgs : %s", diff)
+	}
+}
+
 func TestCreateHostedMasterNSG(t *testing.T) {
 	expected := NetworkSecurityGroupARM{
 		ARMResource: ARMResource{
@@ -0,0 +1,105 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/pkg/errors"
+)
+
+const (
+	// OIDCDiscoveryDocumentPath is the path of the OpenID Connect discovery document, relative to the issuer URL
+	OIDCDiscoveryDocumentPath = ".well-known/openid-configuration"
+	// OIDCJWKSPath is the path of the JSON Web Key Set the service account tokens are verified with, relative to the issuer URL
+	OIDCJWKSPath = "openid/v1/jwks"
+)
+
+type oidcDiscoveryDocument struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+type jsonWebKey struct {
+	Use string `json:"use"`
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// GenerateOIDCDiscoveryDocuments returns the OpenID Connect discovery document and the JSON Web Key Set to upload to the issuer URL.
+// The service account tokens are signed with the apiserver private key, the key set holds the public key of the apiserver certificate.
+func GenerateOIDCDiscoveryDocuments(properties *api.Properties) (discovery, jwks []byte, err error) {
+	issuer := properties.OrchestratorProfile.KubernetesConfig.OIDCIssuerProfile.IssuerURL
+	block, _ := pem.Decode([]byte(properties.CertificateProfile.APIServerCertificate))
+	if block == nil {
+		return nil, nil, errors.New("the apiserver certificate is not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parsing the apiserver certificate")
+	}
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, nil, errors.New("the apiserver certificate does not have an RSA public key")
+	}
+	kid, err := keyID(publicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	discovery, err = json.MarshalIndent(oidcDiscoveryDocument{
+		Issuer:                           issuer,
+		JWKSURI:                          strings.TrimSuffix(issuer, "/") + "/" + OIDCJWKSPath,
+		ResponseTypesSupported:           []string{"id_token"},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: []string{"RS256"},
+	}, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	jwks, err = json.MarshalIndent(jsonWebKeySet{
+		Keys: []jsonWebKey{
+			{
+				Use: "sig",
+				Kty: "RSA",
+				Kid: kid,
+				Alg: "RS256",
+				N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			},
+		},
+	}, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return discovery, jwks, nil
+}
+
+// keyID returns the key ID the API server sets in the header of the tokens it signs with the key, the hash of the public key
+func keyID(publicKey *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(hash[:]), nil
+}
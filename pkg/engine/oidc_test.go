@@ -0,0 +1,88 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+func TestGenerateOIDCDiscoveryDocuments(t *testing.T) {
+	pair, err := helpers.CreatePkiKeyCertPair("apiserver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := api.CreateMockContainerService("testcluster", "1.16.1", 1, 2, false)
+	cs.Properties.CertificateProfile.APIServerCertificate = pair.CertificatePem
+	cs.Properties.OrchestratorProfile.KubernetesConfig.OIDCIssuerProfile = &api.OIDCIssuerProfile{
+		Enabled:   to.BoolPtr(true),
+		IssuerURL: "https://oidcissuer.blob.core.windows.net/testcluster/",
+	}
+
+	discovery, jwks, err := GenerateOIDCDiscoveryDocuments(cs.Properties)
+	if err != nil {
+		t.Fatalf("unexpected error generating the discovery documents: %s", err)
+	}
+
+	var d oidcDiscoveryDocument
+	if err = json.Unmarshal(discovery, &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Issuer != "https://oidcissuer.blob.core.windows.net/testcluster/" {
+		t.Errorf("expected the issuer to be the issuer URL, got %s", d.Issuer)
+	}
+	if d.JWKSURI != "https://oidcissuer.blob.core.windows.net/testcluster/openid/v1/jwks" {
+		t.Errorf("expected the jwks_uri to be the key set under the issuer URL, got %s", d.JWKSURI)
+	}
+
+	var keySet jsonWebKeySet
+	if err = json.Unmarshal(jwks, &keySet); err != nil {
+		t.Fatal(err)
+	}
+	if len(keySet.Keys) != 1 {
+		t.Fatalf("expected 1 key, got %d", len(keySet.Keys))
+	}
+	key := keySet.Keys[0]
+	block, _ := pem.Decode([]byte(pair.CertificatePem))
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := cert.PublicKey.(*rsa.PublicKey)
+	n, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if new(big.Int).SetBytes(n).Cmp(publicKey.N) != 0 || new(big.Int).SetBytes(e).Int64() != int64(publicKey.E) {
+		t.Errorf("expected the key to be the public key of the apiserver certificate")
+	}
+	if key.Kty != "RSA" || key.Alg != "RS256" || key.Use != "sig" {
+		t.Errorf("expected an RS256 signing key, got kty %s alg %s use %s", key.Kty, key.Alg, key.Use)
+	}
+	kid, err := keyID(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Kid != kid || kid == "" {
+		t.Errorf("expected the key ID to be %s, got %s", kid, key.Kid)
+	}
+
+	cs.Properties.CertificateProfile.APIServerCertificate = "apiservercert"
+	if _, _, err = GenerateOIDCDiscoveryDocuments(cs.Properties); err == nil {
+		t.Errorf("expected an error generating the discovery documents from an invalid apiserver certificate")
+	}
+}
@@ -0,0 +1,22 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+)
+
+// getOpenServiceMeshNamespaces returns the namespaces of the comma-separated namespaces config of the open-service-mesh
+// addon, whose pods get the envoy sidecar of the mesh injected
+func getOpenServiceMeshNamespaces(addon api.KubernetesAddon) []string {
+	var namespaces []string
+	for _, namespace := range strings.Split(addon.Config["namespaces"], ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
@@ -0,0 +1,43 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetOpenServiceMeshNamespaces(t *testing.T) {
+	cases := []struct {
+		name       string
+		namespaces string
+		expected   []string
+	}{
+		{
+			name: "no namespaces",
+		},
+		{
+			name:       "namespaces",
+			namespaces: "default, bookstore,",
+			expected:   []string{"default", "bookstore"},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			addon := api.KubernetesAddon{
+				Name:   OpenServiceMeshAddonName,
+				Config: map[string]string{"namespaces": c.namespaces},
+			}
+			actual := getOpenServiceMeshNamespaces(addon)
+			if diff := cmp.Diff(c.expected, actual); diff != "" {
+				t.Errorf("unexpected diff while comparing namespaces: %s", diff)
+			}
+		})
+	}
+}
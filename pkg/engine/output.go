@@ -19,6 +19,46 @@ type ArtifactWriter struct {
 	Translator *i18n.Translator
 }
 
+// WriteTerraformArtifacts saves the Terraform configuration deploying the ARM template and parameters files written by WriteTLSArtifacts
+func (w *ArtifactWriter) WriteTerraformArtifacts(containerService *api.ContainerService, template, parameters, artifactsDir string) error {
+	config, err := GenerateTerraformConfig(containerService, template, parameters)
+	if err != nil {
+		return errors.Wrap(err, "generating the Terraform configuration")
+	}
+	f := &helpers.FileSaver{
+		Translator: w.Translator,
+	}
+	return f.SaveFile(artifactsDir, TerraformConfigFileName, config)
+}
+
+// WriteBicepArtifacts saves the Bicep equivalent of the ARM template written by WriteTLSArtifacts
+func (w *ArtifactWriter) WriteBicepArtifacts(template, artifactsDir string) error {
+	bicep, err := GenerateBicep(template)
+	if err != nil {
+		return errors.Wrap(err, "converting the ARM template to Bicep")
+	}
+	f := &helpers.FileSaver{
+		Translator: w.Translator,
+	}
+	return f.SaveFile(artifactsDir, BicepFileName, bicep)
+}
+
+// WriteAirGapArtifacts saves the VHDs, binaries, container images and apt packages the mirrors of an air-gapped cluster must serve
+func (w *ArtifactWriter) WriteAirGapArtifacts(containerService *api.ContainerService, artifactsDir string) error {
+	assets, err := GetAirGapAssets(containerService)
+	if err != nil {
+		return errors.Wrap(err, "listing the air gap assets")
+	}
+	b, err := helpers.JSONMarshalIndent(assets, "", "  ", false)
+	if err != nil {
+		return errors.Wrap(err, "encoding the air gap assets")
+	}
+	f := &helpers.FileSaver{
+		Translator: w.Translator,
+	}
+	return f.SaveFile(artifactsDir, AirGapAssetsFileName, b)
+}
+
 // WriteTLSArtifacts saves TLS certificates and keys to the server filesystem
 func (w *ArtifactWriter) WriteTLSArtifacts(containerService *api.ContainerService, apiVersion, template, parameters, artifactsDir string, certsGenerated bool, parametersOnly bool) error {
 	if len(artifactsDir) == 0 {
@@ -56,11 +96,27 @@ func (w *ArtifactWriter) WriteTLSArtifacts(containerService *api.ContainerServic
 		return e
 	}
 
+	properties := containerService.Properties
+	// the discovery documents are uploaded to the issuer URL, they change whenever the apiserver certificate does
+	if properties.OrchestratorProfile.IsKubernetes() && properties.OrchestratorProfile.KubernetesConfig != nil &&
+		properties.OrchestratorProfile.KubernetesConfig.IsOIDCIssuerEnabled() {
+		discovery, jwks, oidcErr := GenerateOIDCDiscoveryDocuments(properties)
+		if oidcErr != nil {
+			return oidcErr
+		}
+		directory := path.Join(artifactsDir, "oidc")
+		if e := f.SaveFile(path.Join(directory, path.Dir(OIDCDiscoveryDocumentPath)), path.Base(OIDCDiscoveryDocumentPath), discovery); e != nil {
+			return e
+		}
+		if e := f.SaveFile(path.Join(directory, path.Dir(OIDCJWKSPath)), path.Base(OIDCJWKSPath), jwks); e != nil {
+			return e
+		}
+	}
+
 	if !certsGenerated {
 		return nil
 	}
 
-	properties := containerService.Properties
 	if properties.OrchestratorProfile.IsKubernetes() {
 		directory := path.Join(artifactsDir, "kubeconfig")
 		var locations []string
@@ -78,6 +134,15 @@ func (w *ArtifactWriter) WriteTLSArtifacts(containerService *api.ContainerServic
 			if e := f.SaveFileString(directory, fmt.Sprintf("kubeconfig.%s.json", location), b); e != nil {
 				return e
 			}
+			if properties.OrchestratorProfile.KubernetesConfig.PrivateJumpboxProvision() && properties.AADProfile == nil {
+				t, gkcerr := GenerateTunneledKubeConfig(properties, location, DefaultKubeConfigTunnelLocalPort)
+				if gkcerr != nil {
+					return gkcerr
+				}
+				if e := f.SaveFileString(directory, fmt.Sprintf("kubeconfig.%s.tunnel.json", location), t); e != nil {
+					return e
+				}
+			}
 		}
 
 		if e := f.SaveFileString(artifactsDir, "ca.key", properties.CertificateProfile.CaPrivateKey); e != nil {
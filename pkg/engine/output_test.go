@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/to"
 
 	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/aks-engine/pkg/helpers"
@@ -134,4 +135,28 @@ func TestWriteTLSArtifacts(t *testing.T) {
 			t.Fatalf("expected kubeconfig for region %s to be generated by WriteTLSArtifacts", region)
 		}
 	}
+	os.RemoveAll(defaultDir)
+
+	// Generate the OIDC discovery documents, with the provided certs
+	pair, err := helpers.CreatePkiKeyCertPair("apiserver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs.Properties.CertificateProfile.APIServerCertificate = pair.CertificatePem
+	cs.Properties.OrchestratorProfile.KubernetesConfig.OIDCIssuerProfile = &api.OIDCIssuerProfile{
+		Enabled:   to.BoolPtr(true),
+		IssuerURL: "https://oidcissuer.blob.core.windows.net/testcluster/",
+	}
+	err = writer.WriteTLSArtifacts(cs, "vlabs", "fake template", "fake parameters", dir, false, false)
+	if err != nil {
+		t.Fatalf("unexpected error trying to write TLS artifacts: %s", err.Error())
+	}
+
+	expectedFiles = []string{"oidc/" + OIDCDiscoveryDocumentPath, "oidc/" + OIDCJWKSPath}
+
+	for _, f := range expectedFiles {
+		if _, err = os.Stat(dir + "/" + f); os.IsNotExist(err) {
+			t.Fatalf("expected file %s/%s to be generated by WriteTLSArtifacts", dir, f)
+		}
+	}
 }
@@ -76,6 +76,13 @@ func getParameters(cs *api.ContainerService, generatorCode string, aksEngineVers
 			}
 		}
 		addValue(parametersMap, "firstConsecutiveStaticIP", properties.MasterProfile.FirstConsecutiveStaticIP)
+		if properties.MasterProfile.HasDedicatedEtcdSubnet() {
+			addValue(parametersMap, "etcdSubnet", properties.MasterProfile.EtcdSubnet)
+			if properties.MasterProfile.IsCustomVNET() {
+				addValue(parametersMap, "etcdVnetSubnetID", properties.MasterProfile.EtcdVnetSubnetID)
+			}
+			addValue(parametersMap, "etcdFirstConsecutiveStaticIP", properties.MasterProfile.EtcdFirstConsecutiveStaticIP)
+		}
 		addValue(parametersMap, "masterVMSize", properties.MasterProfile.VMSize)
 		if properties.MasterProfile.HasAvailabilityZones() {
 			addValue(parametersMap, "availabilityZones", properties.MasterProfile.AvailabilityZones)
@@ -194,6 +201,9 @@ func getParameters(cs *api.ContainerService, generatorCode string, aksEngineVers
 		}
 		if agentProfile.IsCustomVNET() {
 			addValue(parametersMap, fmt.Sprintf("%sVnetSubnetID", agentProfile.Name), agentProfile.VnetSubnetID)
+			if agentProfile.HasPodSubnet() {
+				addValue(parametersMap, fmt.Sprintf("%sPodSubnetID", agentProfile.Name), agentProfile.PodSubnetID)
+			}
 		} else {
 			addValue(parametersMap, fmt.Sprintf("%sSubnet", agentProfile.Name), agentProfile.Subnet)
 		}
@@ -234,6 +244,9 @@ func getParameters(cs *api.ContainerService, generatorCode string, aksEngineVers
 		}
 
 		addValue(parametersMap, "windowsDockerVersion", properties.WindowsProfile.GetWindowsDockerVersion())
+		if properties.WindowsProfile.WindowsContainerdURL != "" {
+			addValue(parametersMap, "windowsContainerdURL", properties.WindowsProfile.WindowsContainerdURL)
+		}
 
 		for i, s := range properties.WindowsProfile.Secrets {
 			addValue(parametersMap, fmt.Sprintf("windowsKeyVaultID%d", i), s.SourceVault.ID)
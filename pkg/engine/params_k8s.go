@@ -38,7 +38,7 @@ func assignKubernetesParameters(properties *api.Properties, parametersMap params
 					kubernetesCcmSpec = kubernetesConfig.CustomCcmImage
 				}
 
-				addValue(parametersMap, "kubernetesCcmImageSpec", kubernetesCcmSpec)
+				addValue(parametersMap, "kubernetesCcmImageSpec", rewriteImageRegistry(kubernetesCcmSpec, kubernetesConfig.ImageRegistry))
 			}
 
 			kubernetesHyperkubeSpec := hyperkubeImageBase + k8sComponents["hyperkube"]
@@ -48,17 +48,17 @@ func assignKubernetesParameters(properties *api.Properties, parametersMap params
 			if kubernetesConfig.CustomHyperkubeImage != "" {
 				kubernetesHyperkubeSpec = kubernetesConfig.CustomHyperkubeImage
 			}
-			addValue(parametersMap, "kubernetesHyperkubeSpec", kubernetesHyperkubeSpec)
+			addValue(parametersMap, "kubernetesHyperkubeSpec", rewriteImageRegistry(kubernetesHyperkubeSpec, kubernetesConfig.ImageRegistry))
 
 			addValue(parametersMap, "kubeDNSServiceIP", kubernetesConfig.DNSServiceIP)
 			if kubernetesConfig.PrivateAzureRegistryServer != "" {
 				addValue(parametersMap, "privateAzureRegistryServer", kubernetesConfig.PrivateAzureRegistryServer)
 			}
-			addValue(parametersMap, "kubernetesAddonManagerSpec", kubernetesImageBase+k8sComponents["addonmanager"])
+			addValue(parametersMap, "kubernetesAddonManagerSpec", rewriteImageRegistry(kubernetesImageBase+k8sComponents["addonmanager"], kubernetesConfig.ImageRegistry))
 			if orchestratorProfile.NeedsExecHealthz() {
-				addValue(parametersMap, "kubernetesExecHealthzSpec", kubernetesImageBase+k8sComponents["exechealthz"])
+				addValue(parametersMap, "kubernetesExecHealthzSpec", rewriteImageRegistry(kubernetesImageBase+k8sComponents["exechealthz"], kubernetesConfig.ImageRegistry))
 			}
-			addValue(parametersMap, "kubernetesDNSSidecarSpec", kubernetesImageBase+k8sComponents["k8s-dns-sidecar"])
+			addValue(parametersMap, "kubernetesDNSSidecarSpec", rewriteImageRegistry(kubernetesImageBase+k8sComponents["k8s-dns-sidecar"], kubernetesConfig.ImageRegistry))
 			if kubernetesConfig.IsAADPodIdentityEnabled() {
 				aadPodIdentityAddon := kubernetesConfig.GetAddonByName(AADPodIdentityAddonName)
 				aadIndex := aadPodIdentityAddon.GetAddonContainersIndexByName(AADPodIdentityAddonName)
@@ -83,12 +83,12 @@ func assignKubernetesParameters(properties *api.Properties, parametersMap params
 				addValue(parametersMap, "kubernetesClusterAutoscalerEnabled", false)
 			}
 			if common.IsKubernetesVersionGe(k8sVersion, "1.12.0") {
-				addValue(parametersMap, "kubernetesCoreDNSSpec", kubernetesImageBase+k8sComponents["coredns"])
+				addValue(parametersMap, "kubernetesCoreDNSSpec", rewriteImageRegistry(kubernetesImageBase+k8sComponents["coredns"], kubernetesConfig.ImageRegistry))
 			} else {
-				addValue(parametersMap, "kubernetesKubeDNSSpec", kubernetesImageBase+k8sComponents["kube-dns"])
-				addValue(parametersMap, "kubernetesDNSMasqSpec", kubernetesImageBase+k8sComponents["dnsmasq"])
+				addValue(parametersMap, "kubernetesKubeDNSSpec", rewriteImageRegistry(kubernetesImageBase+k8sComponents["kube-dns"], kubernetesConfig.ImageRegistry))
+				addValue(parametersMap, "kubernetesDNSMasqSpec", rewriteImageRegistry(kubernetesImageBase+k8sComponents["dnsmasq"], kubernetesConfig.ImageRegistry))
 			}
-			addValue(parametersMap, "kubernetesPodInfraContainerSpec", kubernetesImageBase+k8sComponents["pause"])
+			addValue(parametersMap, "kubernetesPodInfraContainerSpec", rewriteImageRegistry(kubernetesImageBase+k8sComponents["pause"], kubernetesConfig.ImageRegistry))
 			addValue(parametersMap, "cloudproviderConfig", api.CloudProviderConfig{
 				CloudProviderBackoff:              kubernetesConfig.CloudProviderBackoff,
 				CloudProviderBackoffRetries:       kubernetesConfig.CloudProviderBackoffRetries,
@@ -107,13 +107,25 @@ func assignKubernetesParameters(properties *api.Properties, parametersMap params
 			addValue(parametersMap, "networkPolicy", kubernetesConfig.NetworkPolicy)
 			addValue(parametersMap, "networkPlugin", kubernetesConfig.NetworkPlugin)
 			addValue(parametersMap, "containerRuntime", kubernetesConfig.ContainerRuntime)
-			addValue(parametersMap, "containerdDownloadURLBase", cloudSpecConfig.KubernetesSpecConfig.ContainerdDownloadURLBase)
-			addValue(parametersMap, "cniPluginsURL", cloudSpecConfig.KubernetesSpecConfig.CNIPluginsDownloadURL)
-			addValue(parametersMap, "vnetCniLinuxPluginsURL", kubernetesConfig.GetAzureCNIURLLinux(cloudSpecConfig))
-			addValue(parametersMap, "vnetCniWindowsPluginsURL", kubernetesConfig.GetAzureCNIURLWindows(cloudSpecConfig))
+			containerdDownloadURLBase := cloudSpecConfig.KubernetesSpecConfig.ContainerdDownloadURLBase
+			cniPluginsURL := cloudSpecConfig.KubernetesSpecConfig.CNIPluginsDownloadURL
+			vnetCniLinuxPluginsURL := kubernetesConfig.GetAzureCNIURLLinux(cloudSpecConfig)
+			vnetCniWindowsPluginsURL := kubernetesConfig.GetAzureCNIURLWindows(cloudSpecConfig)
+			etcdDownloadURLBase := cloudSpecConfig.KubernetesSpecConfig.EtcdDownloadURLBase
+			if kubernetesConfig.IsAirGapped() {
+				containerdDownloadURLBase = airGapMirrorURL(containerdDownloadURLBase, kubernetesConfig.AirGapProfile)
+				cniPluginsURL = airGapMirrorURL(cniPluginsURL, kubernetesConfig.AirGapProfile)
+				vnetCniLinuxPluginsURL = airGapMirrorURL(vnetCniLinuxPluginsURL, kubernetesConfig.AirGapProfile)
+				vnetCniWindowsPluginsURL = airGapMirrorURL(vnetCniWindowsPluginsURL, kubernetesConfig.AirGapProfile)
+				etcdDownloadURLBase = airGapMirrorURL(etcdDownloadURLBase, kubernetesConfig.AirGapProfile)
+			}
+			addValue(parametersMap, "containerdDownloadURLBase", containerdDownloadURLBase)
+			addValue(parametersMap, "cniPluginsURL", cniPluginsURL)
+			addValue(parametersMap, "vnetCniLinuxPluginsURL", vnetCniLinuxPluginsURL)
+			addValue(parametersMap, "vnetCniWindowsPluginsURL", vnetCniWindowsPluginsURL)
 			addValue(parametersMap, "gchighthreshold", kubernetesConfig.GCHighThreshold)
 			addValue(parametersMap, "gclowthreshold", kubernetesConfig.GCLowThreshold)
-			addValue(parametersMap, "etcdDownloadURLBase", cloudSpecConfig.KubernetesSpecConfig.EtcdDownloadURLBase)
+			addValue(parametersMap, "etcdDownloadURLBase", etcdDownloadURLBase)
 			addValue(parametersMap, "etcdVersion", kubernetesConfig.EtcdVersion)
 			addValue(parametersMap, "etcdDiskSizeGB", kubernetesConfig.EtcdDiskSizeGB)
 			addValue(parametersMap, "etcdEncryptionKey", kubernetesConfig.EtcdEncryptionKey)
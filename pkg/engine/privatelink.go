@@ -0,0 +1,184 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+)
+
+// The network SDK vendored here predates Private Link services, private endpoints and private DNS zones,
+// so the resources of the private API server endpoint are plain maps.
+
+// createAPIServerPrivateLinkService returns the Private Link service fronting the internal load balancer of the masters.
+func createAPIServerPrivateLinkService() map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "[variables('apiVersionPrivateLink')]",
+		"name":       "[variables('apiServerPrivateLinkServiceName')]",
+		"location":   "[variables('location')]",
+		"type":       "Microsoft.Network/privateLinkServices",
+		"dependsOn": []string{
+			"[concat('Microsoft.Network/loadBalancers/', variables('masterInternalLbName'))]",
+		},
+		"properties": map[string]interface{}{
+			"loadBalancerFrontendIpConfigurations": []map[string]string{
+				{
+					"id": "[variables('masterInternalLbIPConfigID')]",
+				},
+			},
+			"ipConfigurations": []map[string]interface{}{
+				{
+					"name": "[concat(variables('apiServerPrivateLinkServiceName'), '-ipconfig')]",
+					"properties": map[string]interface{}{
+						"primary":                   true,
+						"privateIPAllocationMethod": "Dynamic",
+						"privateIPAddressVersion":   "IPv4",
+						"subnet": map[string]string{
+							"id": "[variables('apiServerPrivateLinkSubnetID')]",
+						},
+					},
+				},
+			},
+			"visibility": map[string][]string{
+				"subscriptions": {"[subscription().subscriptionId]"},
+			},
+			"autoApproval": map[string][]string{
+				"subscriptions": {"[subscription().subscriptionId]"},
+			},
+		},
+	}
+}
+
+// createAPIServerPrivateEndpoint returns the private endpoint clients reach the API server through.
+func createAPIServerPrivateEndpoint() map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "[variables('apiVersionPrivateLink')]",
+		"name":       "[variables('apiServerPrivateEndpointName')]",
+		"location":   "[variables('location')]",
+		"type":       "Microsoft.Network/privateEndpoints",
+		"dependsOn": []string{
+			"[variables('apiServerPrivateLinkServiceID')]",
+		},
+		"properties": map[string]interface{}{
+			"subnet": map[string]string{
+				"id": "[variables('apiServerPrivateEndpointSubnetID')]",
+			},
+			"privateLinkServiceConnections": []map[string]interface{}{
+				{
+					"name": "[variables('apiServerPrivateEndpointName')]",
+					"properties": map[string]string{
+						"privateLinkServiceId": "[variables('apiServerPrivateLinkServiceID')]",
+					},
+				},
+			},
+		},
+	}
+}
+
+// createAPIServerPrivateDNSZone returns the private DNS zone resolving the fqdn of the API server to its private endpoint.
+func createAPIServerPrivateDNSZone() map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "[variables('apiVersionPrivateDNS')]",
+		"name":       "[variables('apiServerPrivateDNSZoneName')]",
+		"location":   "global",
+		"type":       "Microsoft.Network/privateDnsZones",
+	}
+}
+
+// createAPIServerPrivateDNSZoneARecord returns the A record of the fqdn of the API server, which points at the
+// address the private endpoint got in its subnet.
+func createAPIServerPrivateDNSZoneARecord() map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "[variables('apiVersionPrivateDNS')]",
+		"name":       "[concat(variables('apiServerPrivateDNSZoneName'), '/', variables('masterFqdnPrefix'))]",
+		"type":       "Microsoft.Network/privateDnsZones/A",
+		"dependsOn": []string{
+			"[resourceId('Microsoft.Network/privateDnsZones', variables('apiServerPrivateDNSZoneName'))]",
+			"[variables('apiServerPrivateEndpointID')]",
+		},
+		"properties": map[string]interface{}{
+			"ttl": 300,
+			"aRecords": []map[string]string{
+				{
+					"ipv4Address": "[reference(reference(variables('apiServerPrivateEndpointID'), variables('apiVersionPrivateLink')).networkInterfaces[0].id, variables('apiVersionPrivateLink')).ipConfigurations[0].properties.privateIPAddress]",
+				},
+			},
+		},
+	}
+}
+
+// createAPIServerPrivateDNSZoneLinks returns the links of the private DNS zone to the VNET of the cluster and,
+// if the private endpoint lives in another VNET, to the VNET of the private endpoint.
+func createAPIServerPrivateDNSZoneLinks(cs *api.ContainerService) []map[string]interface{} {
+	vnets := []struct{ name, id string }{
+		{"cluster", "[variables('apiServerClusterVnetID')]"},
+	}
+	if privateEndpointVnetID(cs) != "" {
+		vnets = append(vnets, struct{ name, id string }{"endpoint", "[variables('apiServerPrivateEndpointVnetID')]"})
+	}
+
+	dependencies := []string{
+		"[resourceId('Microsoft.Network/privateDnsZones', variables('apiServerPrivateDNSZoneName'))]",
+	}
+	if !cs.Properties.MasterProfile.IsCustomVNET() {
+		dependencies = append(dependencies, "[concat('Microsoft.Network/virtualNetworks/', variables('virtualNetworkName'))]")
+	}
+
+	var links []map[string]interface{}
+	for _, vnet := range vnets {
+		links = append(links, map[string]interface{}{
+			"apiVersion": "[variables('apiVersionPrivateDNS')]",
+			"name":       fmt.Sprintf("[concat(variables('apiServerPrivateDNSZoneName'), '/', variables('masterFqdnPrefix'), '-%s')]", vnet.name),
+			"location":   "global",
+			"type":       "Microsoft.Network/privateDnsZones/virtualNetworkLinks",
+			"dependsOn":  dependencies,
+			"properties": map[string]interface{}{
+				"registrationEnabled": false,
+				"virtualNetwork": map[string]string{
+					"id": vnet.id,
+				},
+			},
+		})
+	}
+	return links
+}
+
+// createAPIServerPrivateLinkResources returns the Private Link service, private endpoint and private DNS records
+// of the API server of a private cluster.
+func createAPIServerPrivateLinkResources(cs *api.ContainerService) []interface{} {
+	resources := []interface{}{
+		createAPIServerPrivateLinkService(),
+		createAPIServerPrivateEndpoint(),
+		createAPIServerPrivateDNSZone(),
+		createAPIServerPrivateDNSZoneARecord(),
+	}
+	for _, link := range createAPIServerPrivateDNSZoneLinks(cs) {
+		resources = append(resources, link)
+	}
+	return resources
+}
+
+// privateEndpointVnetID returns the ID of the VNET of the private endpoint of the API server if the user placed it in a
+// VNET other than the one of the masters, and an empty string otherwise.
+func privateEndpointVnetID(cs *api.ContainerService) string {
+	subnetID := cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster.PrivateLink.PrivateEndpointSubnetID
+	if subnetID == "" {
+		return ""
+	}
+	vnetID := vnetIDOfSubnet(subnetID)
+	if cs.Properties.MasterProfile.IsCustomVNET() && strings.EqualFold(vnetID, vnetIDOfSubnet(cs.Properties.MasterProfile.VnetSubnetID)) {
+		return ""
+	}
+	return vnetID
+}
+
+// vnetIDOfSubnet returns the ID of the VNET of a subnet ID
+func vnetIDOfSubnet(subnetID string) string {
+	if i := strings.Index(strings.ToLower(subnetID), "/subnets/"); i >= 0 {
+		return subnetID[:i]
+	}
+	return subnetID
+}
@@ -0,0 +1,118 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCreateAPIServerPrivateEndpoint(t *testing.T) {
+	actual := createAPIServerPrivateEndpoint()
+	expected := map[string]interface{}{
+		"apiVersion": "[variables('apiVersionPrivateLink')]",
+		"name":       "[variables('apiServerPrivateEndpointName')]",
+		"location":   "[variables('location')]",
+		"type":       "Microsoft.Network/privateEndpoints",
+		"dependsOn": []string{
+			"[variables('apiServerPrivateLinkServiceID')]",
+		},
+		"properties": map[string]interface{}{
+			"subnet": map[string]string{
+				"id": "[variables('apiServerPrivateEndpointSubnetID')]",
+			},
+			"privateLinkServiceConnections": []map[string]interface{}{
+				{
+					"name": "[variables('apiServerPrivateEndpointName')]",
+					"properties": map[string]string{
+						"privateLinkServiceId": "[variables('apiServerPrivateLinkServiceID')]",
+					},
+				},
+			},
+		},
+	}
+
+	diff := cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing private endpoints: %s", diff)
+	}
+}
+
+func TestCreateAPIServerPrivateLinkResources(t *testing.T) {
+	masterSubnetID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/MASTER_SUBNET"
+	hubSubnetID := "/subscriptions/SUB_ID/resourceGroups/HUB_RG/providers/Microsoft.Network/virtualNetworks/HUB_VNET/subnets/ENDPOINTS"
+	cases := []struct {
+		name                 string
+		masterVnetSubnetID   string
+		endpointSubnetID     string
+		expectedEndpointVnet string
+		expectedLinks        int
+	}{
+		{
+			name:          "endpoint in the master subnet",
+			expectedLinks: 1,
+		},
+		{
+			name:               "endpoint in another subnet of the custom VNET of the masters",
+			masterVnetSubnetID: masterSubnetID,
+			endpointSubnetID:   "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/vnet_name/subnets/ENDPOINTS",
+			expectedLinks:      1,
+		},
+		{
+			name:                 "endpoint in a hub VNET",
+			masterVnetSubnetID:   masterSubnetID,
+			endpointSubnetID:     hubSubnetID,
+			expectedEndpointVnet: "/subscriptions/SUB_ID/resourceGroups/HUB_RG/providers/Microsoft.Network/virtualNetworks/HUB_VNET",
+			expectedLinks:        2,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			cs := &api.ContainerService{
+				Properties: &api.Properties{
+					OrchestratorProfile: &api.OrchestratorProfile{
+						KubernetesConfig: &api.KubernetesConfig{
+							PrivateCluster: &api.PrivateCluster{
+								Enabled: to.BoolPtr(true),
+								PrivateLink: &api.PrivateLinkProfile{
+									Enabled:                 to.BoolPtr(true),
+									PrivateEndpointSubnetID: c.endpointSubnetID,
+								},
+							},
+						},
+					},
+					MasterProfile: &api.MasterProfile{
+						Count:        3,
+						VnetSubnetID: c.masterVnetSubnetID,
+					},
+				},
+			}
+
+			if actual := privateEndpointVnetID(cs); actual != c.expectedEndpointVnet {
+				t.Errorf("expected private endpoint VNET %q, got %q", c.expectedEndpointVnet, actual)
+			}
+
+			resources := createAPIServerPrivateLinkResources(cs)
+			links := 0
+			for _, resource := range resources {
+				if resource.(map[string]interface{})["type"] == "Microsoft.Network/privateDnsZones/virtualNetworkLinks" {
+					links++
+				}
+			}
+			if links != c.expectedLinks {
+				t.Errorf("expected %d private DNS zone links, got %d", c.expectedLinks, links)
+			}
+			if len(resources) != 4+c.expectedLinks {
+				t.Errorf("expected %d Private Link resources, got %d", 4+c.expectedLinks, len(resources))
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+// createProximityPlacementGroup returns the proximity placement group shared by the masters and the agent pools with
+// createProximityPlacementGroup
+func createProximityPlacementGroup() ProximityPlacementGroupARM {
+	return ProximityPlacementGroupARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionCompute')]",
+		},
+		ProximityPlacementGroup: compute.ProximityPlacementGroup{
+			Name:     to.StringPtr("[variables('proximityPlacementGroupName')]"),
+			Location: to.StringPtr("[variables('location')]"),
+			Type:     to.StringPtr("Microsoft.Compute/proximityPlacementGroups"),
+			ProximityPlacementGroupProperties: &compute.ProximityPlacementGroupProperties{
+				ProximityPlacementGroupType: compute.Standard,
+			},
+		},
+	}
+}
+
+// getProximityPlacementGroupReference returns the reference to the proximity placement group of a profile, either the
+// existing one of id or the one of the cluster, and the dependency on the latter. It returns nil when the profile has no
+// proximity placement group.
+func getProximityPlacementGroupReference(id string, create *bool) (*compute.SubResource, string) {
+	if id != "" {
+		return &compute.SubResource{ID: to.StringPtr(id)}, ""
+	}
+	if to.Bool(create) {
+		return &compute.SubResource{ID: to.StringPtr("[variables('proximityPlacementGroupID')]")},
+			"[concat('Microsoft.Compute/proximityPlacementGroups/', variables('proximityPlacementGroupName'))]"
+	}
+	return nil, ""
+}
@@ -0,0 +1,81 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+func TestCreateProximityPlacementGroup(t *testing.T) {
+	expected := ProximityPlacementGroupARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionCompute')]",
+		},
+		ProximityPlacementGroup: compute.ProximityPlacementGroup{
+			Name:     to.StringPtr("[variables('proximityPlacementGroupName')]"),
+			Location: to.StringPtr("[variables('location')]"),
+			Type:     to.StringPtr("Microsoft.Compute/proximityPlacementGroups"),
+			ProximityPlacementGroupProperties: &compute.ProximityPlacementGroupProperties{
+				ProximityPlacementGroupType: compute.Standard,
+			},
+		},
+	}
+
+	actual := createProximityPlacementGroup()
+
+	diff := cmp.Diff(expected, actual)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing structs: %s", diff)
+	}
+}
+
+func TestGetProximityPlacementGroupReference(t *testing.T) {
+	ppgID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/proximityPlacementGroups/PPG_NAME"
+	cases := []struct {
+		name               string
+		id                 string
+		create             *bool
+		expected           *compute.SubResource
+		expectedDependency string
+	}{
+		{
+			name: "no proximity placement group",
+		},
+		{
+			name:   "create disabled",
+			create: to.BoolPtr(false),
+		},
+		{
+			name:     "existing proximity placement group",
+			id:       ppgID,
+			expected: &compute.SubResource{ID: to.StringPtr(ppgID)},
+		},
+		{
+			name:               "proximity placement group of the cluster",
+			create:             to.BoolPtr(true),
+			expected:           &compute.SubResource{ID: to.StringPtr("[variables('proximityPlacementGroupID')]")},
+			expectedDependency: "[concat('Microsoft.Compute/proximityPlacementGroups/', variables('proximityPlacementGroupName'))]",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			actual, dependency := getProximityPlacementGroupReference(c.id, c.create)
+			if diff := cmp.Diff(c.expected, actual); diff != "" {
+				t.Errorf("unexpected diff while comparing proximity placement group references: %s", diff)
+			}
+			if dependency != c.expectedDependency {
+				t.Errorf("expected dependency %q, got %q", c.expectedDependency, dependency)
+			}
+		})
+	}
+}
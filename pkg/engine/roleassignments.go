@@ -4,6 +4,8 @@
 package engine
 
 import (
+	"fmt"
+
 	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/azure-sdk-for-go/services/preview/authorization/mgmt/2018-09-01-preview/authorization"
 	"github.com/Azure/go-autorest/autorest/to"
@@ -74,6 +76,36 @@ func createKubernetesSpAppGIdentityOperatorAccessRoleAssignment(prop *api.Proper
 	}
 }
 
+// createAgentPoolIdentityOperatorRoleAssignment gives operator access on the user assigned identity of an agent pool to
+// the cluster identity, which updates the VMs the identity is assigned to
+func createAgentPoolIdentityOperatorRoleAssignment(prop *api.Properties, profile *api.AgentPoolProfile) RoleAssignmentARM {
+	dependencies := []string{getAgentPoolUserAssignedIDDependency(profile)}
+	var kubernetesSpObjectID string
+	if prop.OrchestratorProfile.KubernetesConfig.UseManagedIdentity {
+		dependencies = append(dependencies, "[concat('Microsoft.ManagedIdentity/userAssignedIdentities/', variables('userAssignedID'))]")
+		kubernetesSpObjectID = "[reference(concat('Microsoft.ManagedIdentity/userAssignedIdentities/', variables('userAssignedID'))).principalId]"
+	} else if prop.ServicePrincipalProfile != nil {
+		kubernetesSpObjectID = prop.ServicePrincipalProfile.ObjectID
+	}
+
+	return RoleAssignmentARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionAuthorizationSystem')]",
+			DependsOn:  dependencies,
+		},
+		RoleAssignment: authorization.RoleAssignment{
+			Type: to.StringPtr("Microsoft.ManagedIdentity/userAssignedIdentities/providers/roleAssignments"),
+			Name: to.StringPtr(fmt.Sprintf("[concat(variables('%[1]sUserAssignedID'), '/Microsoft.Authorization/', guid(resourceGroup().id, variables('%[1]sUserAssignedID'), 'aksidentityaccess'))]", profile.Name)),
+			RoleAssignmentPropertiesWithScope: &authorization.RoleAssignmentPropertiesWithScope{
+				RoleDefinitionID: to.StringPtr(string(IdentityManagedIdentityOperatorRole)),
+				PrincipalID:      to.StringPtr(kubernetesSpObjectID),
+				PrincipalType:    authorization.ServicePrincipal,
+				Scope:            to.StringPtr(fmt.Sprintf("[variables('%sUserAssignedIDReference')]", profile.Name)),
+			},
+		},
+	}
+}
+
 // createAppGwIdentityResourceGroupReadSysRoleAssignment gives read access to Resource Group for Identity used by AGIC
 func createAppGwIdentityResourceGroupReadSysRoleAssignment() RoleAssignmentARM {
 	return RoleAssignmentARM{
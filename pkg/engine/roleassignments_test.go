@@ -144,6 +144,65 @@ func TestCreateKubernetesSpAppGIdentityOperatorAccessRoleAssignment(t *testing.T
 	}
 }
 
+func TestCreateAgentPoolIdentityOperatorRoleAssignment(t *testing.T) {
+	profile := &api.AgentPoolProfile{
+		Name:           "agentpool1",
+		UserAssignedID: "agentpool1identity",
+	}
+
+	// using service principal
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				KubernetesConfig: &api.KubernetesConfig{},
+			},
+			ServicePrincipalProfile: &api.ServicePrincipalProfile{
+				ObjectID: "xxxx",
+			},
+		},
+	}
+
+	actual := createAgentPoolIdentityOperatorRoleAssignment(cs.Properties, profile)
+	expected := RoleAssignmentARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionAuthorizationSystem')]",
+			DependsOn: []string{
+				"[concat('Microsoft.ManagedIdentity/userAssignedIdentities/', variables('agentpool1UserAssignedID'))]",
+			},
+		},
+		RoleAssignment: authorization.RoleAssignment{
+			Type: to.StringPtr("Microsoft.ManagedIdentity/userAssignedIdentities/providers/roleAssignments"),
+			Name: to.StringPtr("[concat(variables('agentpool1UserAssignedID'), '/Microsoft.Authorization/', guid(resourceGroup().id, variables('agentpool1UserAssignedID'), 'aksidentityaccess'))]"),
+			RoleAssignmentPropertiesWithScope: &authorization.RoleAssignmentPropertiesWithScope{
+				RoleDefinitionID: to.StringPtr(string(IdentityManagedIdentityOperatorRole)),
+				PrincipalID:      to.StringPtr("xxxx"),
+				PrincipalType:    authorization.ServicePrincipal,
+				Scope:            to.StringPtr("[variables('agentpool1UserAssignedIDReference')]"),
+			},
+		},
+	}
+
+	diff := cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing: %s", diff)
+	}
+
+	// using managed identity
+	cs.Properties.OrchestratorProfile.KubernetesConfig.UseManagedIdentity = true
+	cs.Properties.OrchestratorProfile.KubernetesConfig.UserAssignedID = "clusteridentity"
+
+	actual = createAgentPoolIdentityOperatorRoleAssignment(cs.Properties, profile)
+	expected.DependsOn = append(expected.DependsOn, "[concat('Microsoft.ManagedIdentity/userAssignedIdentities/', variables('userAssignedID'))]")
+	expected.PrincipalID = to.StringPtr("[reference(concat('Microsoft.ManagedIdentity/userAssignedIdentities/', variables('userAssignedID'))).principalId]")
+
+	diff = cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing: %s", diff)
+	}
+}
+
 func TestCreateAppGwIdentityResourceGroupReadSysRoleAssignment(t *testing.T) {
 	actual := createAppGwIdentityResourceGroupReadSysRoleAssignment()
 	expected := RoleAssignmentARM{
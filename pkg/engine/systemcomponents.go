@@ -0,0 +1,182 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/common"
+	"github.com/ghodss/yaml"
+)
+
+const (
+	systemNodeCritical    = "system-node-critical"
+	systemClusterCritical = "system-cluster-critical"
+	// systemPriorityClassesMinVersion is the first Kubernetes version creating the system priority classes
+	systemPriorityClassesMinVersion = "1.11.0"
+)
+
+// SystemComponentFinding is a workload of a system addon that user workloads can starve on a packed node,
+// because it has no system priority class or its containers do not request resources
+type SystemComponentFinding struct {
+	Addon     string `json:"addon"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Container string `json:"container,omitempty"`
+	Problem   string `json:"problem"`
+	// Fixed is true if the generated manifest is corrected, which it is for the missing priority classes
+	Fixed bool `json:"fixed"`
+}
+
+func (f SystemComponentFinding) String() string {
+	workload := fmt.Sprintf("%s kube-system/%s of addon %s", f.Kind, f.Name, f.Addon)
+	if f.Container != "" {
+		workload += fmt.Sprintf(", container %s", f.Container)
+	}
+	return fmt.Sprintf("%s: %s", workload, f.Problem)
+}
+
+// AuditSystemComponents returns the findings of the workloads the enabled addons of a cluster run in kube-system:
+// those missing a system priority class, which the generated manifests are given, and the containers without
+// cpu or memory requests. The properties are expected to have their defaults set.
+func AuditSystemComponents(cs *api.ContainerService) ([]SystemComponentFinding, error) {
+	properties := cs.Properties
+	if properties.OrchestratorProfile == nil || !properties.OrchestratorProfile.IsKubernetes() {
+		return nil, nil
+	}
+	addons, err := getContainerAddonManifests(properties, "k8s/containeraddons")
+	if err != nil {
+		return nil, err
+	}
+	var findings []SystemComponentFinding
+	for _, addon := range addons {
+		findings = append(findings, addon.findings...)
+	}
+
+	// the other addons are delivered as they are, their placeholders are replaced on the masters
+	orchestratorVersion := properties.OrchestratorProfile.OrchestratorVersion
+	versions := strings.Split(orchestratorVersion, ".")
+	for _, setting := range kubernetesAddonSettingsInit(properties) {
+		if !setting.isEnabled {
+			continue
+		}
+		var manifest string
+		if setting.base64Data != "" {
+			if manifest, err = getStringFromBase64(setting.base64Data); err != nil {
+				return nil, err
+			}
+		} else {
+			b, err := Asset(getCustomDataFilePath(setting.sourceFile, "k8s/addons", versions[0]+"."+versions[1]))
+			if err != nil {
+				return nil, err
+			}
+			manifest = string(b)
+		}
+		_, addonFindings := protectSystemComponents(strings.TrimSuffix(setting.destinationFile, ".yaml"), manifest, orchestratorVersion, false)
+		findings = append(findings, addonFindings...)
+	}
+	return findings, nil
+}
+
+// protectSystemComponents returns the findings of the kube-system workloads of an addon manifest, and the manifest with
+// the system priority class of the workloads missing one if fix is true: system-node-critical for the DaemonSets and
+// system-cluster-critical for the others. The documents that need no priority class are left as they are.
+func protectSystemComponents(addon, manifest, orchestratorVersion string, fix bool) (string, []SystemComponentFinding) {
+	var findings []SystemComponentFinding
+	documents := strings.Split(manifest, "\n---")
+	for i, document := range documents {
+		var object map[string]interface{}
+		if err := yaml.Unmarshal([]byte(document), &object); err != nil || object == nil {
+			continue
+		}
+		kind, _ := object["kind"].(string)
+		metadata, _ := object["metadata"].(map[string]interface{})
+		podSpec := workloadPodSpec(kind, object)
+		if podSpec == nil || metadata["namespace"] != "kube-system" {
+			continue
+		}
+		name, _ := metadata["name"].(string)
+		finding := SystemComponentFinding{Addon: addon, Kind: kind, Name: name}
+
+		if common.IsKubernetesVersionGe(orchestratorVersion, systemPriorityClassesMinVersion) {
+			switch priorityClass, _ := podSpec["priorityClassName"].(string); priorityClass {
+			case systemNodeCritical, systemClusterCritical:
+			case "":
+				expected := systemClusterCritical
+				if kind == "DaemonSet" {
+					expected = systemNodeCritical
+				}
+				f := finding
+				f.Problem = fmt.Sprintf("no priority class, %s is expected", expected)
+				if fix {
+					podSpec["priorityClassName"] = expected
+					if b, err := yaml.Marshal(object); err == nil {
+						documents[i] = string(b)
+						if i > 0 {
+							documents[i] = "\n" + documents[i]
+						}
+						f.Fixed = true
+					}
+				}
+				findings = append(findings, f)
+			default:
+				f := finding
+				f.Problem = fmt.Sprintf("priority class %s is not a system priority class", priorityClass)
+				findings = append(findings, f)
+			}
+		}
+
+		for _, key := range []string{"initContainers", "containers"} {
+			containers, _ := podSpec[key].([]interface{})
+			for _, c := range containers {
+				container, _ := c.(map[string]interface{})
+				if missing := missingResourceRequests(container); len(missing) > 0 {
+					f := finding
+					f.Container, _ = container["name"].(string)
+					f.Problem = fmt.Sprintf("no %s requests", strings.Join(missing, " or "))
+					findings = append(findings, f)
+				}
+			}
+		}
+	}
+	return strings.Join(documents, "\n---"), findings
+}
+
+// workloadPodSpec returns the spec of the pods of a workload, or nil for the other kinds of objects
+func workloadPodSpec(kind string, object map[string]interface{}) map[string]interface{} {
+	var path []string
+	switch kind {
+	case "Deployment", "DaemonSet", "StatefulSet", "ReplicaSet", "Job":
+		path = []string{"spec", "template", "spec"}
+	case "CronJob":
+		path = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return nil
+	}
+	value := object
+	for _, key := range path {
+		var ok bool
+		if value, ok = value[key].(map[string]interface{}); !ok {
+			return nil
+		}
+	}
+	return value
+}
+
+// missingResourceRequests returns the resources a container neither requests nor limits, the requests of a container
+// default to its limits
+func missingResourceRequests(container map[string]interface{}) []string {
+	resources, _ := container["resources"].(map[string]interface{})
+	requests, _ := resources["requests"].(map[string]interface{})
+	limits, _ := resources["limits"].(map[string]interface{})
+	var missing []string
+	for _, resource := range []string{"cpu", "memory"} {
+		if (requests[resource] == nil || requests[resource] == "") && (limits[resource] == nil || limits[resource] == "") {
+			missing = append(missing, resource)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,124 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+)
+
+const systemComponentsManifest = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: cni
+  namespace: kube-system
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: cni
+  namespace: kube-system
+spec:
+  template:
+    spec:
+      containers:
+        - name: cni
+          image: contoso/cni:v1
+          resources:
+            requests:
+              cpu: 10m
+              memory: 20Mi
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: dns
+  namespace: kube-system
+spec:
+  template:
+    spec:
+      priorityClassName: system-cluster-critical
+      initContainers:
+        - name: init
+          image: contoso/init:v1
+          resources:
+            limits:
+              cpu: 10m
+              memory: 20Mi
+      containers:
+        - name: dns
+          image: contoso/dns:v1
+          resources:
+            requests:
+              cpu: 10m
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: contoso/app:v1
+`
+
+func TestProtectSystemComponents(t *testing.T) {
+	manifest, findings := protectSystemComponents("cni", systemComponentsManifest, "1.16.0", true)
+
+	expected := []SystemComponentFinding{
+		{Addon: "cni", Kind: "DaemonSet", Name: "cni", Problem: "no priority class, system-node-critical is expected", Fixed: true},
+		{Addon: "cni", Kind: "Deployment", Name: "dns", Container: "dns", Problem: "no memory requests"},
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %v", len(expected), findings)
+	}
+	for i := range expected {
+		if findings[i] != expected[i] {
+			t.Errorf("expected finding %v, got %v", expected[i], findings[i])
+		}
+	}
+
+	documents := strings.Split(manifest, "\n---")
+	if len(documents) != 4 {
+		t.Fatalf("expected the 4 documents of the manifest to be kept, got %d", len(documents))
+	}
+	var daemonSet struct {
+		Spec struct {
+			Template struct {
+				Spec struct {
+					PriorityClassName string `json:"priorityClassName"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	if err := yaml.Unmarshal([]byte(documents[1]), &daemonSet); err != nil {
+		t.Fatal(err)
+	}
+	if daemonSet.Spec.Template.Spec.PriorityClassName != systemNodeCritical {
+		t.Errorf("expected the DaemonSet to get priority class %s, got %q", systemNodeCritical, daemonSet.Spec.Template.Spec.PriorityClassName)
+	}
+	for _, i := range []int{0, 2, 3} {
+		if original := strings.Split(systemComponentsManifest, "\n---")[i]; documents[i] != original {
+			t.Errorf("expected document %d to be left as it is, got\n%s", i, documents[i])
+		}
+	}
+
+	manifest, findings = protectSystemComponents("cni", systemComponentsManifest, "1.16.0", false)
+	if manifest != systemComponentsManifest {
+		t.Errorf("expected the manifest not to be changed when not fixing it, got\n%s", manifest)
+	}
+	if len(findings) != 2 || findings[0].Fixed {
+		t.Errorf("expected the unfixed findings of the manifest, got %v", findings)
+	}
+
+	_, findings = protectSystemComponents("cni", systemComponentsManifest, "1.10.13", true)
+	if len(findings) != 1 || findings[0].Container != "dns" {
+		t.Errorf("expected no priority class findings before Kubernetes 1.11, got %v", findings)
+	}
+}
@@ -176,16 +176,25 @@ func (t *TemplateGenerator) GetMasterCustomDataJSONObject(cs *api.ContainerServi
 		panic(e)
 	}
 	// add manifests
+	manifests, e := patchManifestSettings(kubernetesManifestSettingsInit(profile), profile)
+	if e != nil {
+		panic(e)
+	}
 	str = substituteConfigString(str,
-		kubernetesManifestSettingsInit(profile),
+		manifests,
 		"k8s/manifests",
 		"/etc/kubernetes/manifests",
 		"MASTER_MANIFESTS_CONFIG_PLACEHOLDER",
 		profile.OrchestratorProfile.OrchestratorVersion)
 
 	// add addons
+	addons, e := rewriteAddonSettingsImages(kubernetesAddonSettingsInit(profile), "k8s/addons",
+		profile.OrchestratorProfile.OrchestratorVersion, profile.OrchestratorProfile.KubernetesConfig.ImageRegistry)
+	if e != nil {
+		panic(e)
+	}
 	str = substituteConfigString(str,
-		kubernetesAddonSettingsInit(profile),
+		addons,
 		"k8s/addons",
 		"/etc/kubernetes/addons",
 		"MASTER_ADDONS_CONFIG_PLACEHOLDER",
@@ -285,6 +294,17 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 			if kc == nil {
 				return ""
 			}
+			// the kubelet pulls the pause image itself, from the imageRegistry as the other images
+			registry := cs.Properties.OrchestratorProfile.KubernetesConfig.ImageRegistry
+			if image, ok := kc.KubeletConfig["--pod-infra-container-image"]; ok && registry != "" {
+				config := *kc
+				config.KubeletConfig = map[string]string{}
+				for key, val := range kc.KubeletConfig {
+					config.KubeletConfig[key] = val
+				}
+				config.KubeletConfig["--pod-infra-container-image"] = rewriteImageRegistry(image, registry)
+				return config.GetOrderedKubeletConfigString()
+			}
 			return kc.GetOrderedKubeletConfigString()
 		},
 		"GetKubeletConfigKeyValsPsh": func(kc *api.KubernetesConfig) string {
@@ -553,6 +573,12 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 		"HasCiliumNetworkPlugin": func() bool {
 			return cs.Properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin == NetworkPluginCilium
 		},
+		"HasNodeSearchDomains": func(dnsConfig *api.NodeDNSConfig) bool {
+			return dnsConfig.HasSearchDomains()
+		},
+		"GetNodeSearchDomains": func(dnsConfig *api.NodeDNSConfig) string {
+			return strings.Join(dnsConfig.SearchDomains, " ")
+		},
 		"HasCustomNodesDNS": func() bool {
 			return cs.Properties.LinuxProfile.HasCustomNodesDNS()
 		},
@@ -647,10 +673,49 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 		"NeedsContainerd": func() bool {
 			return cs.Properties.OrchestratorProfile.KubernetesConfig.NeedsContainerd()
 		},
+		"GetAirGapMirrorURL": func(u string) string {
+			if cs.Properties.OrchestratorProfile.KubernetesConfig.IsAirGapped() {
+				return airGapMirrorURL(u, cs.Properties.OrchestratorProfile.KubernetesConfig.AirGapProfile)
+			}
+			return u
+		},
+		"GetKonnectivityServerImage": func() string {
+			return rewriteImageRegistry(cs.Properties.OrchestratorProfile.KubernetesConfig.KonnectivityProfile.ServerImage, cs.Properties.OrchestratorProfile.KubernetesConfig.ImageRegistry)
+		},
+		"GetRegistryMirrors": func() []api.RegistryMirror {
+			return cs.Properties.OrchestratorProfile.KubernetesConfig.GetRegistryMirrors()
+		},
+		"HasRegistryMirrors": func() bool {
+			return len(cs.Properties.OrchestratorProfile.KubernetesConfig.GetRegistryMirrors()) > 0
+		},
+		"HasDockerRegistryMirrors": func() bool {
+			return getDockerRegistryMirrors(cs.Properties.OrchestratorProfile.KubernetesConfig) != ""
+		},
+		"GetDockerRegistryMirrors": func() string {
+			return getDockerRegistryMirrors(cs.Properties.OrchestratorProfile.KubernetesConfig)
+		},
+		"GetContainerdRegistryMirrorEndpoints": func(mirror api.RegistryMirror) string {
+			return getContainerdRegistryMirrorEndpoints(mirror)
+		},
+		"GetBase64EncodedContainerdRegistryMirrors": func() string {
+			return base64.StdEncoding.EncodeToString([]byte(getContainerdRegistryMirrors(cs.Properties.OrchestratorProfile.KubernetesConfig)))
+		},
+		"HasCloudProviderConfig": func() bool {
+			return getCloudProviderConfig(cs.Properties.OrchestratorProfile.KubernetesConfig) != ""
+		},
+		"GetBase64EncodedCloudProviderConfig": func() string {
+			return base64.StdEncoding.EncodeToString([]byte(getCloudProviderConfig(cs.Properties.OrchestratorProfile.KubernetesConfig)))
+		},
 	}
 }
 
 func (t *TemplateGenerator) GenerateTemplateV2(containerService *api.ContainerService, generatorCode string, acsengineVersion string) (templateRaw string, parametersRaw string, err error) {
+	// the custom data of the masters has none of the addons if the custom manifests cannot be rendered
+	if containerService.Properties.OrchestratorProfile != nil && containerService.Properties.OrchestratorProfile.KubernetesConfig != nil {
+		if _, err = getCustomManifests(containerService.Properties.OrchestratorProfile.KubernetesConfig); err != nil {
+			return "", "", err
+		}
+	}
 
 	armParams, _ := t.getParameterDescMap(containerService)
 	armResources := GenerateARMResources(containerService)
@@ -683,6 +748,14 @@ func (t *TemplateGenerator) GenerateTemplateV2(containerService *api.ContainerSe
 	}
 	parametersRaw = string(parameterBytes)
 
+	// the nodes of an air-gapped cluster cannot install from the internet, the template must only reference its mirrors
+	if containerService.Properties.OrchestratorProfile != nil && containerService.Properties.OrchestratorProfile.KubernetesConfig != nil &&
+		containerService.Properties.OrchestratorProfile.KubernetesConfig.IsAirGapped() {
+		if err = validateAirGappedTemplate(containerService, templateRaw, parametersRaw); err != nil {
+			return "", "", err
+		}
+	}
+
 	return templateRaw, parametersRaw, err
 }
 
@@ -732,3 +805,27 @@ func generateUserAssignedIdentityClientIDParameter(isUserAssignedIdentity bool)
 	}
 	return "' USER_ASSIGNED_IDENTITY_ID=',' '"
 }
+
+// generateMasterAddonsParameters returns the parameters of the master CSE configuring the manifests of the enabled addons
+// with the Azure resources of the cluster: the Application Gateway of the appgw-ingress addon and its identity, and the
+// resource group whose policy assignments the azure-policy addon syncs. It also has the namespaces the master CSE
+// enrolls in the mesh of the open-service-mesh addon once the API server is up
+func generateMasterAddonsParameters(cs *api.ContainerService) string {
+	if cs.Properties.OrchestratorProfile == nil || cs.Properties.OrchestratorProfile.KubernetesConfig == nil {
+		return ""
+	}
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+	var params string
+	if k.IsAddonEnabled(AppGwIngressAddonName) {
+		params += ",' APPGW_INGRESS_ADDON=true APPGW_NAME=',variables('appGwName'),' APPGW_IDENTITY_RESOURCE_ID=',variables('appGwICIdentityId'),' APPGW_IDENTITY_CLIENT_ID=',reference(variables('appGwICIdentityId'), variables('apiVersionManagedIdentity')).clientId"
+	}
+	if k.IsAddonEnabled(AzurePolicyAddonName) {
+		params += ",' AZURE_POLICY_ADDON=true'"
+	}
+	if k.IsAddonEnabled(OpenServiceMeshAddonName) {
+		if namespaces := k.GetAddonByName(OpenServiceMeshAddonName).Config["namespaces"]; namespaces != "" {
+			params += ",' OSM_NAMESPACES=" + namespaces + "'"
+		}
+	}
+	return params
+}
@@ -37,7 +37,6 @@
 // ../../parts/k8s/addons/1.15/kubernetesmasteraddons-pod-security-policy.yaml
 // ../../parts/k8s/addons/1.16/kubernetesmaster-audit-policy.yaml
 // ../../parts/k8s/addons/1.16/kubernetesmasteraddons-azure-cloud-provider-deployment.yaml
-// ../../parts/k8s/addons/1.16/kubernetesmasteraddons-cilium-daemonset.yaml
 // ../../parts/k8s/addons/1.16/kubernetesmasteraddons-flannel-daemonset.yaml
 // ../../parts/k8s/addons/1.16/kubernetesmasteraddons-kube-dns-deployment.yaml
 // ../../parts/k8s/addons/1.16/kubernetesmasteraddons-kube-proxy-daemonset.yaml
@@ -52,7 +51,6 @@
 // ../../parts/k8s/addons/kubernetesmaster-audit-policy.yaml
 // ../../parts/k8s/addons/kubernetesmasteraddons-aad-default-admin-group-rbac.yaml
 // ../../parts/k8s/addons/kubernetesmasteraddons-azure-cloud-provider-deployment.yaml
-// ../../parts/k8s/addons/kubernetesmasteraddons-cilium-daemonset.yaml
 // ../../parts/k8s/addons/kubernetesmasteraddons-elb-svc.yaml
 // ../../parts/k8s/addons/kubernetesmasteraddons-flannel-daemonset.yaml
 // ../../parts/k8s/addons/kubernetesmasteraddons-kube-dns-deployment.yaml
@@ -81,6 +79,7 @@
 // ../../parts/k8s/cloud-init/artifacts/etc-issue
 // ../../parts/k8s/cloud-init/artifacts/etc-issue.net
 // ../../parts/k8s/cloud-init/artifacts/etcd.service
+// ../../parts/k8s/cloud-init/artifacts/generatekonnectivitycerts.sh
 // ../../parts/k8s/cloud-init/artifacts/generateproxycerts.sh
 // ../../parts/k8s/cloud-init/artifacts/health-monitor.sh
 // ../../parts/k8s/cloud-init/artifacts/kms.service
@@ -97,6 +96,8 @@
 // ../../parts/k8s/cloud-init/artifacts/profile-d-cis.sh
 // ../../parts/k8s/cloud-init/artifacts/pwquality-CIS.conf
 // ../../parts/k8s/cloud-init/artifacts/rsyslog-d-60-CIS.conf
+// ../../parts/k8s/cloud-init/artifacts/scheduled-events-handler.service
+// ../../parts/k8s/cloud-init/artifacts/scheduled-events-handler.sh
 // ../../parts/k8s/cloud-init/artifacts/setup-custom-search-domains.sh
 // ../../parts/k8s/cloud-init/artifacts/sshd_config
 // ../../parts/k8s/cloud-init/artifacts/sshd_config_1604
@@ -130,17 +131,30 @@
 // ../../parts/k8s/containeraddons/ip-masq-agent.yaml
 // ../../parts/k8s/containeraddons/kubernetesmasteraddons-aad-pod-identity-deployment.yaml
 // ../../parts/k8s/containeraddons/kubernetesmasteraddons-aci-connector-deployment.yaml
+// ../../parts/k8s/containeraddons/kubernetesmasteraddons-antrea.yaml
+// ../../parts/k8s/containeraddons/kubernetesmasteraddons-appgw-ingress-deployment.yaml
 // ../../parts/k8s/containeraddons/kubernetesmasteraddons-azure-npm-daemonset.yaml
+// ../../parts/k8s/containeraddons/kubernetesmasteraddons-azure-policy-deployment.yaml
+// ../../parts/k8s/containeraddons/kubernetesmasteraddons-azure-workload-identity-webhook-deployment.yaml
 // ../../parts/k8s/containeraddons/kubernetesmasteraddons-blobfuse-flexvolume-installer.yaml
 // ../../parts/k8s/containeraddons/kubernetesmasteraddons-calico-daemonset.yaml
+// ../../parts/k8s/containeraddons/kubernetesmasteraddons-cilium-daemonset.yaml
 // ../../parts/k8s/containeraddons/kubernetesmasteraddons-cluster-autoscaler-deployment.yaml
+// ../../parts/k8s/containeraddons/kubernetesmasteraddons-csi-secrets-store-daemonset.yaml
+// ../../parts/k8s/containeraddons/kubernetesmasteraddons-fluent-bit-daemonset.yaml
 // ../../parts/k8s/containeraddons/kubernetesmasteraddons-heapster-deployment.yaml
+// ../../parts/k8s/containeraddons/kubernetesmasteraddons-keda-deployment.yaml
 // ../../parts/k8s/containeraddons/kubernetesmasteraddons-keyvault-flexvolume-installer.yaml
+// ../../parts/k8s/containeraddons/kubernetesmasteraddons-konnectivity-agent-daemonset.yaml
 // ../../parts/k8s/containeraddons/kubernetesmasteraddons-kube-rescheduler-deployment.yaml
 // ../../parts/k8s/containeraddons/kubernetesmasteraddons-kubernetes-dashboard-deployment.yaml
 // ../../parts/k8s/containeraddons/kubernetesmasteraddons-metrics-server-deployment.yaml
+// ../../parts/k8s/containeraddons/kubernetesmasteraddons-nginx-ingress-deployment.yaml
+// ../../parts/k8s/containeraddons/kubernetesmasteraddons-node-problem-detector-daemonset.yaml
 // ../../parts/k8s/containeraddons/kubernetesmasteraddons-nvidia-device-plugin-daemonset.yaml
 // ../../parts/k8s/containeraddons/kubernetesmasteraddons-omsagent-daemonset.yaml
+// ../../parts/k8s/containeraddons/kubernetesmasteraddons-open-service-mesh-deployment.yaml
+// ../../parts/k8s/containeraddons/kubernetesmasteraddons-registry-cache-deployment.yaml
 // ../../parts/k8s/containeraddons/kubernetesmasteraddons-smb-flexvolume-installer.yaml
 // ../../parts/k8s/containeraddons/kubernetesmasteraddons-tiller-deployment.yaml
 // ../../parts/k8s/kubeconfig.json
@@ -148,6 +162,7 @@
 // ../../parts/k8s/kuberneteswindowsfunctions.ps1
 // ../../parts/k8s/kuberneteswindowssetup.ps1
 // ../../parts/k8s/manifests/kubernetesmaster-cloud-controller-manager.yaml
+// ../../parts/k8s/manifests/kubernetesmaster-konnectivity-server.yaml
 // ../../parts/k8s/manifests/kubernetesmaster-kube-addon-manager.yaml
 // ../../parts/k8s/manifests/kubernetesmaster-kube-apiserver.yaml
 // ../../parts/k8s/manifests/kubernetesmaster-kube-controller-manager-custom.yaml
@@ -270,16 +285,17 @@ var _agentparamsT = []byte(`    "{{.Name}}Count": {
       "type": "int"
     },
 {{end}}
-    {{if .IsLowPriorityScaleSet}}
+    {{if or .IsLowPriorityScaleSet .IsSpotScaleSet}}
     "{{.Name}}ScaleSetPriority": {
       "allowedValues":[
         "Low",
+        "Spot",
         "Regular",
         ""
       ],
       "defaultValue": "{{.ScaleSetPriority}}",
       "metadata": {
-        "description": "The priority for the VM Scale Set. This value can be Low or Regular."
+        "description": "The priority for the VM Scale Set. This value can be Low, Spot or Regular."
       },
       "type": "string"
     },
@@ -291,7 +307,7 @@ var _agentparamsT = []byte(`    "{{.Name}}Count": {
       ],
       "defaultValue": "{{.ScaleSetEvictionPolicy}}",
       "metadata": {
-        "description": "The Eviction Policy for a Low-priority VM Scale Set."
+        "description": "The Eviction Policy for a Low-priority or Spot VM Scale Set."
       },
       "type": "string"
     },
@@ -361,6 +377,14 @@ var _agentparamsT = []byte(`    "{{.Name}}Count": {
       },
       "type": "string"
     }
+{{if .HasPodSubnet}}
+  ,"{{.Name}}PodSubnetID": {
+      "metadata": {
+        "description": "Sets the subnet of the pod NICs of agent pool '{{.Name}}'."
+      },
+      "type": "string"
+    }
+{{end}}
 {{else}}
     "{{.Name}}Subnet": {
       "defaultValue": "{{.Subnet}}",
@@ -7011,1091 +7035,795 @@ func k8sAddons116KubernetesmasteraddonsAzureCloudProviderDeploymentYaml() (*asse
 	return a, nil
 }
 
-var _k8sAddons116KubernetesmasteraddonsCiliumDaemonsetYaml = []byte(`---
+var _k8sAddons116KubernetesmasteraddonsFlannelDaemonsetYaml = []byte(`# This file was pulled from:
+# https://github.com/coreos/flannel (HEAD at time of pull was 4973e02e539378)
+---
 apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: flannel
+  namespace: kube-system
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+---
 kind: ConfigMap
+apiVersion: v1
 metadata:
-  name: cilium-config
+  name: kube-flannel-cfg
   namespace: kube-system
   labels:
-    addonmanager.kubernetes.io/mode: "EnsureExists"
+    tier: node
+    app: flannel
+    addonmanager.kubernetes.io/mode: EnsureExists
 data:
-  etcd-config: |-
-    ---
-    endpoints:
-      - https://cilium-etcd-client.kube-system.svc:2379
-    ca-file: '/var/lib/etcd-secrets/etcd-client-ca.crt'
-    key-file: '/var/lib/etcd-secrets/etcd-client.key'
-    cert-file: '/var/lib/etcd-secrets/etcd-client.crt'
-  debug: "false"
-  enable-ipv4: "true"
-  enable-ipv6: "false"
-  clean-cilium-state: "false"
-  clean-cilium-bpf-state: "false"
-  monitor-aggregation-level: "none"
-  ct-global-max-entries-tcp: "524288"
-  ct-global-max-entries-other: "262144"
-  preallocate-bpf-maps: "false"
-  sidecar-istio-proxy-image: "cilium/istio_proxy"
-  tunnel: "vxlan"
-  cluster-name: default
-  flannel-master-device: ""
-  flannel-uninstall-on-exit: "false"
-  flannel-manage-existing-containers: "false"
-  tofqdns-enable-poller: "false"
+  cni-conf.json: |
+    {
+      "name": "cbr0",
+      "type": "flannel",
+      "delegate": {
+        "isDefaultGateway": true
+      }
+    }
+  net-conf.json: |
+    {
+      "Network": "<kubeClusterCidr>",
+      "Backend": {
+        "Type": "vxlan"
+      }
+    }
 ---
 apiVersion: apps/v1
 kind: DaemonSet
 metadata:
-  labels:
-    k8s-app: cilium
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: "Reconcile"
-  name: cilium
+  name: kube-flannel-ds
   namespace: kube-system
+  labels:
+    tier: node
+    app: flannel
+    addonmanager.kubernetes.io/mode: Reconcile
 spec:
   selector:
     matchLabels:
-      k8s-app: cilium
-      kubernetes.io/cluster-service: "true"
+      tier: node
+      app: flannel
   template:
     metadata:
-      annotations:
-        prometheus.io/port: "9090"
-        prometheus.io/scrape: "true"
-        scheduler.alpha.kubernetes.io/tolerations: '[{"key":"dedicated","operator":"Equal","value":"master","effect":"NoSchedule"}]'
       labels:
-        k8s-app: cilium
-        kubernetes.io/cluster-service: "true"
+        tier: node
+        app: flannel
     spec:
+      hostNetwork: true
+      nodeSelector:
+        beta.kubernetes.io/arch: amd64
+        beta.kubernetes.io/os: linux
+      priorityClassName: system-node-critical
+      tolerations:
+        - key: node.kubernetes.io/not-ready
+          operator: Exists
+          effect: NoSchedule
+        - key: node-role.kubernetes.io/master
+          operator: Equal
+          value: "true"
+          effect: NoSchedule
+        - key: CriticalAddonsOnly
+          operator: Exists
+      serviceAccountName: flannel
       containers:
-      - args:
-        - --debug=$(CILIUM_DEBUG)
-        - --kvstore=etcd
-        - --kvstore-opt=etcd.config=/var/lib/etcd-config/etcd.config
-        command:
-        - cilium-agent
+      - name: kube-flannel
+        image: quay.io/coreos/flannel:v0.8.0-amd64
+        command: [ "/opt/bin/flanneld", "--ip-masq", "--kube-subnet-mgr" ]
+        securityContext:
+          privileged: true
         env:
-        - name: K8S_NODE_NAME
+        - name: POD_NAME
           valueFrom:
             fieldRef:
-              apiVersion: v1
-              fieldPath: spec.nodeName
-        - name: CILIUM_K8S_NAMESPACE
+              fieldPath: metadata.name
+        - name: POD_NAMESPACE
           valueFrom:
             fieldRef:
-              apiVersion: v1
               fieldPath: metadata.namespace
-        - name: CILIUM_DEBUG
-          valueFrom:
-            configMapKeyRef:
-              key: debug
-              name: cilium-config
-        - name: CILIUM_ENABLE_IPV4
-          valueFrom:
-            configMapKeyRef:
-              key: enable-ipv4
-              name: cilium-config
-              optional: true
-        - name: CILIUM_ENABLE_IPV6
-          valueFrom:
-            configMapKeyRef:
-              key: enable-ipv6
-              name: cilium-config
-              optional: true
-        - name: CILIUM_PROMETHEUS_SERVE_ADDR
-          valueFrom:
-            configMapKeyRef:
-              key: prometheus-serve-addr
-              name: cilium-metrics-config
-              optional: true
-        - name: CILIUM_LEGACY_HOST_ALLOWS_WORLD
-          valueFrom:
-            configMapKeyRef:
-              key: legacy-host-allows-world
-              name: cilium-config
-              optional: true
-        - name: CILIUM_SIDECAR_ISTIO_PROXY_IMAGE
-          valueFrom:
-            configMapKeyRef:
-              key: sidecar-istio-proxy-image
-              name: cilium-config
-              optional: true
-        - name: CILIUM_TUNNEL
-          valueFrom:
-            configMapKeyRef:
-              key: tunnel
-              name: cilium-config
-              optional: true
-        - name: CILIUM_MONITOR_AGGREGATION_LEVEL
-          valueFrom:
-            configMapKeyRef:
-              key: monitor-aggregation-level
-              name: cilium-config
-              optional: true
-        - name: CILIUM_CLUSTERMESH_CONFIG
-          value: /var/lib/cilium/clustermesh/
-        - name: CILIUM_CLUSTER_NAME
-          valueFrom:
-            configMapKeyRef:
-              key: cluster-name
-              name: cilium-config
-              optional: true
-        - name: CILIUM_CLUSTER_ID
-          valueFrom:
-            configMapKeyRef:
-              key: cluster-id
-              name: cilium-config
-              optional: true
-        - name: CILIUM_GLOBAL_CT_MAX_TCP
-          valueFrom:
-            configMapKeyRef:
-              key: ct-global-max-entries-tcp
-              name: cilium-config
-              optional: true
-        - name: CILIUM_GLOBAL_CT_MAX_ANY
-          valueFrom:
-            configMapKeyRef:
-              key: ct-global-max-entries-other
-              name: cilium-config
-              optional: true
-        - name: CILIUM_PREALLOCATE_BPF_MAPS
-          valueFrom:
-            configMapKeyRef:
-              key: preallocate-bpf-maps
-              name: cilium-config
-              optional: true
-        - name: CILIUM_FLANNEL_MASTER_DEVICE
-          valueFrom:
-            configMapKeyRef:
-              key: flannel-master-device
-              name: cilium-config
-              optional: true
-        - name: CILIUM_FLANNEL_UNINSTALL_ON_EXIT
-          valueFrom:
-            configMapKeyRef:
-              key: flannel-uninstall-on-exit
-              name: cilium-config
-              optional: true
-        - name: CILIUM_FLANNEL_MANAGE_EXISTING_CONTAINERS
-          valueFrom:
-            configMapKeyRef:
-              key: flannel-manage-existing-containers
-              name: cilium-config
-              optional: true
-        - name: CILIUM_DATAPATH_MODE
-          valueFrom:
-            configMapKeyRef:
-              key: datapath-mode
-              name: cilium-config
-              optional: true
-        - name: CILIUM_IPVLAN_MASTER_DEVICE
-          valueFrom:
-            configMapKeyRef:
-              key: ipvlan-master-device
-              name: cilium-config
-              optional: true
-        - name: CILIUM_INSTALL_IPTABLES_RULES
-          valueFrom:
-            configMapKeyRef:
-              key: install-iptables-rules
-              name: cilium-config
-              optional: true
-        - name: CILIUM_MASQUERADE
-          valueFrom:
-            configMapKeyRef:
-              key: masquerade
-              name: cilium-config
-              optional: true
-        - name: CILIUM_AUTO_DIRECT_NODE_ROUTES
-          valueFrom:
-            configMapKeyRef:
-              key: auto-direct-node-routes
-              name: cilium-config
-              optional: true
-        - name: CILIUM_TOFQDNS_ENABLE_POLLER
-          valueFrom:
-            configMapKeyRef:
-              key: tofqdns-enable-poller
-              name: cilium-config
-              optional: true
-        image: docker.io/cilium/cilium:v1.4
-        imagePullPolicy: Always
-        lifecycle:
-          postStart:
-            exec:
-              command:
-              - /cni-install.sh
-          preStop:
-            exec:
-              command:
-              - /cni-uninstall.sh
-        livenessProbe:
-          exec:
-            command:
-            - cilium
-            - status
-          failureThreshold: 10
-          initialDelaySeconds: 120
-          periodSeconds: 10
-          successThreshold: 1
-          timeoutSeconds: 1
-        name: cilium-agent
-        ports:
-        - containerPort: 9090
-          hostPort: 9090
-          name: prometheus
-          protocol: TCP
-        readinessProbe:
-          exec:
-            command:
-            - cilium
-            - status
-          failureThreshold: 3
-          initialDelaySeconds: 5
-          periodSeconds: 5
-          successThreshold: 1
-          timeoutSeconds: 1
-        securityContext:
-          capabilities:
-            add:
-            - NET_ADMIN
-          privileged: true
-        volumeMounts:
-        - mountPath: /sys/fs/bpf
-          name: bpf-maps
-        - mountPath: /var/run/cilium
-          name: cilium-run
-        - mountPath: /host/opt/cni/bin
-          name: cni-path
-        - mountPath: /host/etc/cni/net.d
-          name: etc-cni-netd
-        - mountPath: /var/run/docker.sock
-          name: docker-socket
-          readOnly: true
-        - mountPath: /var/lib/etcd-config
-          name: etcd-config-path
-          readOnly: true
-        - mountPath: /var/lib/etcd-secrets
-          name: etcd-secrets
-          readOnly: true
-        - mountPath: /var/lib/cilium/clustermesh
-          name: clustermesh-secrets
-          readOnly: true
-      dnsPolicy: ClusterFirstWithHostNet
-      hostNetwork: true
-      hostPID: false
-      initContainers:
-      - command:
-        - /init-container.sh
-        env:
-        - name: CLEAN_CILIUM_STATE
-          valueFrom:
-            configMapKeyRef:
-              key: clean-cilium-state
-              name: cilium-config
-              optional: true
-        - name: CLEAN_CILIUM_BPF_STATE
-          valueFrom:
-            configMapKeyRef:
-              key: clean-cilium-bpf-state
-              name: cilium-config
-              optional: true
-        image: docker.io/cilium/cilium-init:2018-10-16
-        imagePullPolicy: IfNotPresent
-        name: clean-cilium-state
-        securityContext:
-          capabilities:
-            add:
-            - NET_ADMIN
-          privileged: true
         volumeMounts:
-        - mountPath: /sys/fs/bpf
-          name: bpf-maps
-        - mountPath: /var/run/cilium
-          name: cilium-run
-      priorityClassName: system-node-critical
-      restartPolicy: Always
-      serviceAccount: cilium
-      serviceAccountName: cilium
-      terminationGracePeriodSeconds: 1
-      tolerations:
-      - operator: Exists
-      - effect: NoSchedule
-        key: node.kubernetes.io/not-ready
-        operator: Exists
-      volumes:
-      - hostPath:
-          path: /var/run/cilium
-          type: DirectoryOrCreate
-        name: cilium-run
-      - hostPath:
-          path: /sys/fs/bpf
-          type: DirectoryOrCreate
-        name: bpf-maps
-      - hostPath:
-          path: /var/run/docker.sock
-          type: Socket
-        name: docker-socket
-      - hostPath:
-          path: /opt/cni/bin
-          type: DirectoryOrCreate
-        name: cni-path
-      - hostPath:
-          path: /etc/cni/net.d
-          type: DirectoryOrCreate
-        name: etc-cni-netd
-      - configMap:
-          defaultMode: 420
-          items:
-          - key: etcd-config
-            path: etcd.config
-          name: cilium-config
-        name: etcd-config-path
-      - name: etcd-secrets
-        secret:
-          defaultMode: 420
-          optional: true
-          secretName: cilium-etcd-secrets
-      - name: clustermesh-secrets
-        secret:
-          defaultMode: 420
-          optional: true
-          secretName: cilium-clustermesh
-  updateStrategy:
-    rollingUpdate:
-      maxUnavailable: 2
-    type: RollingUpdate
----
-apiVersion: apps/v1
-kind: Deployment
-metadata:
-  labels:
-    io.cilium/app: operator
-    name: cilium-operator
-  name: cilium-operator
-  namespace: kube-system
-  labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-spec:
-  replicas: 1
-  selector:
-    matchLabels:
-      io.cilium/app: operator
-      name: cilium-operator
-  strategy:
-    rollingUpdate:
-      maxSurge: 1
-      maxUnavailable: 1
-    type: RollingUpdate
-  template:
-    metadata:
-      labels:
-        io.cilium/app: operator
-        name: cilium-operator
-    spec:
-      containers:
-      - args:
-        - --debug=$(CILIUM_DEBUG)
-        - --kvstore=etcd
-        - --kvstore-opt=etcd.config=/var/lib/etcd-config/etcd.config
-        command:
-        - cilium-operator
-        env:
-        - name: POD_NAMESPACE
-          valueFrom:
-            fieldRef:
-              apiVersion: v1
-              fieldPath: metadata.namespace
-        - name: K8S_NODE_NAME
-          valueFrom:
-            fieldRef:
-              apiVersion: v1
-              fieldPath: spec.nodeName
-        - name: CILIUM_DEBUG
-          valueFrom:
-            configMapKeyRef:
-              key: debug
-              name: cilium-config
-              optional: true
-        - name: CILIUM_CLUSTER_NAME
-          valueFrom:
-            configMapKeyRef:
-              key: cluster-name
-              name: cilium-config
-              optional: true
-        - name: CILIUM_CLUSTER_ID
-          valueFrom:
-            configMapKeyRef:
-              key: cluster-id
-              name: cilium-config
-              optional: true
-        - name: CILIUM_DISABLE_ENDPOINT_CRD
-          valueFrom:
-            configMapKeyRef:
-              key: disable-endpoint-crd
-              name: cilium-config
-              optional: true
-        - name: AWS_ACCESS_KEY_ID
-          valueFrom:
-            secretKeyRef:
-              key: AWS_ACCESS_KEY_ID
-              name: cilium-aws
-              optional: true
-        - name: AWS_SECRET_ACCESS_KEY
-          valueFrom:
-            secretKeyRef:
-              key: AWS_SECRET_ACCESS_KEY
-              name: cilium-aws
-              optional: true
-        - name: AWS_DEFAULT_REGION
-          valueFrom:
-            secretKeyRef:
-              key: AWS_DEFAULT_REGION
-              name: cilium-aws
-              optional: true
-        image: docker.io/cilium/operator:v1.4
-        imagePullPolicy: Always
-        name: cilium-operator
+        - name: run
+          mountPath: /run
+        - name: flannel-cfg
+          mountPath: /etc/kube-flannel/
+      - name: install-cni
+        image: quay.io/coreos/flannel:v0.10.0-amd64
+        command: [ "/bin/sh", "-c", "set -e -x; cp -f /etc/kube-flannel/cni-conf.json /etc/cni/net.d/10-flannel.conf; while true; do sleep 3600; done" ]
         volumeMounts:
-        - mountPath: /var/lib/etcd-config
-          name: etcd-config-path
-          readOnly: true
-        - mountPath: /var/lib/etcd-secrets
-          name: etcd-secrets
-          readOnly: true
-      dnsPolicy: ClusterFirst
-      priorityClassName: system-node-critical
-      restartPolicy: Always
-      serviceAccount: cilium-operator
-      serviceAccountName: cilium-operator
+        - name: cni
+          mountPath: /etc/cni/net.d
+        - name: flannel-cfg
+          mountPath: /etc/kube-flannel/
       volumes:
-      - configMap:
-          defaultMode: 420
-          items:
-          - key: etcd-config
-            path: etcd.config
-          name: cilium-config
-        name: etcd-config-path
-      - name: etcd-secrets
-        secret:
-          defaultMode: 420
-          optional: true
-          secretName: cilium-etcd-secrets
+        - name: run
+          hostPath:
+            path: /run
+        - name: cni
+          hostPath:
+            path: /etc/cni/net.d
+        - name: flannel-cfg
+          configMap:
+            name: kube-flannel-cfg
 ---
-apiVersion: v1
-kind: ServiceAccount
-metadata:
-  name: cilium-operator
-  namespace: kube-system
-  labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+# This file was pulled from:
+# https://github.com/coreos/flannel (HEAD at time of pull was 4973e02e539378)
 ---
-apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRole
+apiVersion: rbac.authorization.k8s.io/v1beta1
 metadata:
-  name: cilium-operator
+  name: flannel
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    addonmanager.kubernetes.io/mode: Reconcile
 rules:
-- apiGroups:
-  - ""
-  resources:
-  - pods
-  - deployments
-  - componentstatuses
-  verbs:
-  - '*'
-- apiGroups:
-  - ""
-  resources:
-  - services
-  - endpoints
-  verbs:
-  - get
-  - list
-  - watch
-- apiGroups:
-  - cilium.io
-  resources:
-  - ciliumnetworkpolicies
-  - ciliumnetworkpolicies/status
-  - ciliumendpoints
-  - ciliumendpoints/status
-  verbs:
-  - '*'
+  - apiGroups:
+      - ""
+    resources:
+      - pods
+    verbs:
+      - get
+  - apiGroups:
+      - ""
+    resources:
+      - nodes
+    verbs:
+      - list
+      - watch
+  - apiGroups:
+      - ""
+    resources:
+      - nodes/status
+    verbs:
+      - patch
 ---
-apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRoleBinding
+apiVersion: rbac.authorization.k8s.io/v1beta1
 metadata:
-  name: cilium-operator
+  name: flannel
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    addonmanager.kubernetes.io/mode: Reconcile
 roleRef:
   apiGroup: rbac.authorization.k8s.io
   kind: ClusterRole
-  name: cilium-operator
+  name: flannel
 subjects:
 - kind: ServiceAccount
-  name: cilium-operator
+  name: flannel
   namespace: kube-system
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
+`)
+
+func k8sAddons116KubernetesmasteraddonsFlannelDaemonsetYamlBytes() ([]byte, error) {
+	return _k8sAddons116KubernetesmasteraddonsFlannelDaemonsetYaml, nil
+}
+
+func k8sAddons116KubernetesmasteraddonsFlannelDaemonsetYaml() (*asset, error) {
+	bytes, err := k8sAddons116KubernetesmasteraddonsFlannelDaemonsetYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/addons/1.16/kubernetesmasteraddons-flannel-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sAddons116KubernetesmasteraddonsKubeDnsDeploymentYaml = []byte(`# Copyright 2016 The Kubernetes Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Should keep target in cluster/addons/dns-horizontal-autoscaler/dns-horizontal-autoscaler.yaml
+# in sync with this file.
+
+apiVersion: v1
+kind: Service
 metadata:
-  name: cilium-etcd-operator
+  name: kube-dns
+  namespace: kube-system
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-rules:
-- apiGroups:
-  - etcd.database.coreos.com
-  resources:
-  - etcdclusters
-  verbs:
-  - get
-  - delete
-  - create
-- apiGroups:
-  - apiextensions.k8s.io
-  resources:
-  - customresourcedefinitions
-  verbs:
-  - delete
-  - get
-  - create
-- apiGroups:
-  - ""
-  resources:
-  - deployments
-  verbs:
-  - delete
-  - create
-  - get
-  - update
-- apiGroups:
-  - ""
-  resources:
-  - pods
-  verbs:
-  - list
-  - delete
-  - get
-- apiGroups:
-  - apps
-  resources:
-  - deployments
-  verbs:
-  - delete
-  - create
-  - get
-  - update
-- apiGroups:
-  - ""
-  resources:
-  - componentstatuses
-  verbs:
-  - get
-- apiGroups:
-  - extensions
-  resources:
-  - deployments
-  verbs:
-  - delete
-  - create
-  - get
-  - update
-- apiGroups:
-  - ""
-  resources:
-  - secrets
-  verbs:
-  - get
-  - create
-  - delete
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
-metadata:
-  labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-  name: cilium-etcd-operator
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: cilium-etcd-operator
-subjects:
-- kind: ServiceAccount
-  name: cilium-etcd-operator
-  namespace: kube-system
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
-metadata:
-  name: etcd-operator
-  labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-rules:
-- apiGroups:
-  - etcd.database.coreos.com
-  resources:
-  - etcdclusters
-  - etcdbackups
-  - etcdrestores
-  verbs:
-  - '*'
-- apiGroups:
-  - apiextensions.k8s.io
-  resources:
-  - customresourcedefinitions
-  verbs:
-  - '*'
-- apiGroups:
-  - ""
-  resources:
-  - pods
-  - services
-  - endpoints
-  - persistentvolumeclaims
-  - events
-  - deployments
-  verbs:
-  - '*'
-- apiGroups:
-  - apps
-  resources:
-  - deployments
-  verbs:
-  - '*'
-- apiGroups:
-  - extensions
-  resources:
-  - deployments
-  verbs:
-  - create
-  - get
-  - list
-  - patch
-  - update
-- apiGroups:
-  - ""
-  resources:
-  - secrets
-  verbs:
-  - get
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
-metadata:
-  name: etcd-operator
-  labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: etcd-operator
-subjects:
-- kind: ServiceAccount
-  name: cilium-etcd-sa
-  namespace: kube-system
+    k8s-app: kube-dns
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+    kubernetes.io/name: "KubeDNS"
+spec:
+  selector:
+    k8s-app: kube-dns
+  clusterIP: <clustIP>
+  ports:
+  - name: dns
+    port: 53
+    protocol: UDP
+  - name: dns-tcp
+    port: 53
+    protocol: TCP
 ---
 apiVersion: v1
 kind: ServiceAccount
 metadata:
-  name: cilium-etcd-operator
+  name: kube-dns
   namespace: kube-system
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
 ---
 apiVersion: v1
-kind: ServiceAccount
+kind: ConfigMap
 metadata:
-  name: cilium-etcd-sa
+  name: kube-dns
   namespace: kube-system
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    addonmanager.kubernetes.io/mode: EnsureExists
 ---
 apiVersion: apps/v1
 kind: Deployment
 metadata:
-  labels:
-    io.cilium/app: etcd-operator
-    name: cilium-etcd-operator
-    addonmanager.kubernetes.io/mode: "Reconcile"
-  name: cilium-etcd-operator
+  name: kube-dns
   namespace: kube-system
+  labels:
+    k8s-app: kube-dns
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
 spec:
-  replicas: 1
-  selector:
-    matchLabels:
-      io.cilium/app: etcd-operator
-      name: cilium-etcd-operator
+  # replicas: not specified here:
+  # 1. In order to make Addon Manager do not reconcile this replicas parameter.
+  # 2. Default is 1.
+  # 3. Will be tuned in real time if DNS horizontal auto-scaling is turned on.
   strategy:
     rollingUpdate:
-      maxSurge: 1
-      maxUnavailable: 1
-    type: RollingUpdate
+      maxSurge: 10%
+      maxUnavailable: 0
+  selector:
+    matchLabels:
+      k8s-app: kube-dns
   template:
     metadata:
       labels:
-        io.cilium/app: etcd-operator
-        name: cilium-etcd-operator
+        k8s-app: kube-dns
     spec:
+      priorityClassName: system-node-critical
+      tolerations:
+      - key: "CriticalAddonsOnly"
+        operator: "Exists"
+      volumes:
+      - name: kube-dns-config
+        configMap:
+          name: kube-dns
+          optional: true
       containers:
-      - command:
-        - /usr/bin/cilium-etcd-operator
+      - name: kubedns
+        image: <img>
+        imagePullPolicy: IfNotPresent
+        resources:
+          # TODO: Set memory limits when we've profiled the container for large
+          # clusters, then set request = limit to keep this container in
+          # guaranteed class. Currently, this container falls into the
+          # "burstable" category so the kubelet doesn't backoff from restarting it.
+          limits:
+            memory: 170Mi
+          requests:
+            cpu: 100m
+            memory: 70Mi
+        livenessProbe:
+          httpGet:
+            path: /healthcheck/kubedns
+            port: 10054
+            scheme: HTTP
+          initialDelaySeconds: 60
+          timeoutSeconds: 5
+          successThreshold: 1
+          failureThreshold: 5
+        readinessProbe:
+          httpGet:
+            path: /readiness
+            port: 8081
+            scheme: HTTP
+          initialDelaySeconds: 3
+          timeoutSeconds: 5
+        args:
+        - --domain=<domain>.
+        - --dns-port=10053
+        - --config-dir=/kube-dns-config
+        - --v=2
         env:
-        - name: CILIUM_ETCD_OPERATOR_CLUSTER_DOMAIN
-          value: cluster.local
-        - name: CILIUM_ETCD_OPERATOR_ETCD_CLUSTER_SIZE
-          value: "3"
-        - name: CILIUM_ETCD_OPERATOR_NAMESPACE
-          valueFrom:
-            fieldRef:
-              apiVersion: v1
-              fieldPath: metadata.namespace
-        - name: CILIUM_ETCD_OPERATOR_POD_NAME
-          valueFrom:
-            fieldRef:
-              apiVersion: v1
-              fieldPath: metadata.name
-        - name: CILIUM_ETCD_OPERATOR_POD_UID
-          valueFrom:
-            fieldRef:
-              apiVersion: v1
-              fieldPath: metadata.uid
-        image: docker.io/cilium/cilium-etcd-operator:v2.0.5
+        - name: PROMETHEUS_PORT
+          value: "10055"
+        ports:
+        - containerPort: 10053
+          name: dns-local
+          protocol: UDP
+        - containerPort: 10053
+          name: dns-tcp-local
+          protocol: TCP
+        - containerPort: 10055
+          name: metrics
+          protocol: TCP
+        volumeMounts:
+        - name: kube-dns-config
+          mountPath: /kube-dns-config
+      - name: dnsmasq
+        image: <imgMasq>
         imagePullPolicy: IfNotPresent
-        name: cilium-etcd-operator
-      dnsPolicy: ClusterFirst
-      hostNetwork: true
-      priorityClassName: system-node-critical
-      restartPolicy: Always
-      serviceAccount: cilium-etcd-operator
-      serviceAccountName: cilium-etcd-operator
-      tolerations:
-      - operator: Exists
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
-metadata:
-  name: cilium
-  labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: cilium
-subjects:
-- kind: ServiceAccount
-  name: cilium
-  namespace: kube-system
-- apiGroup: rbac.authorization.k8s.io
-  kind: Group
-  name: system:nodes
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
-metadata:
-  name: cilium
-  labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-rules:
-- apiGroups:
-  - networking.k8s.io
-  resources:
-  - networkpolicies
-  verbs:
-  - get
-  - list
-  - watch
-- apiGroups:
-  - ""
-  resources:
-  - namespaces
-  - services
-  - nodes
-  - endpoints
-  - componentstatuses
-  verbs:
-  - get
-  - list
-  - watch
-- apiGroups:
-  - ""
-  resources:
-  - pods
-  - nodes
-  verbs:
-  - get
-  - list
-  - watch
-  - update
-- apiGroups:
-  - extensions
-  resources:
-  - ingresses
-  verbs:
-  - create
-  - get
-  - list
-  - watch
-- apiGroups:
-  - apiextensions.k8s.io
-  resources:
-  - customresourcedefinitions
-  verbs:
-  - create
-  - get
-  - list
-  - watch
-  - update
-- apiGroups:
-  - cilium.io
-  resources:
-  - ciliumnetworkpolicies
-  - ciliumnetworkpolicies/status
-  - ciliumendpoints
-  - ciliumendpoints/status
-  verbs:
-  - '*'
----
-apiVersion: v1
-kind: ServiceAccount
-metadata:
-  name: cilium
-  namespace: kube-system
-  labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+        livenessProbe:
+          httpGet:
+            path: /healthcheck/dnsmasq
+            port: 10054
+            scheme: HTTP
+          initialDelaySeconds: 60
+          timeoutSeconds: 5
+          successThreshold: 1
+          failureThreshold: 5
+        args:
+        - -v=2
+        - -logtostderr
+        - -configDir=/etc/k8s/dns/dnsmasq-nanny
+        - -restartDnsmasq=true
+        - --
+        - -k
+        - --cache-size=1000
+        - --no-negcache
+        - --log-facility=-
+        - --server=/cluster.local/127.0.0.1#10053
+        - --server=/in-addr.arpa/127.0.0.1#10053
+        - --server=/ip6.arpa/127.0.0.1#10053
+        ports:
+        - containerPort: 53
+          name: dns
+          protocol: UDP
+        - containerPort: 53
+          name: dns-tcp
+          protocol: TCP
+        resources:
+          requests:
+            cpu: 150m
+            memory: 20Mi
+        volumeMounts:
+        - name: kube-dns-config
+          mountPath: /etc/k8s/dns/dnsmasq-nanny
+      - name: sidecar
+        image: <imgSidecar>
+        imagePullPolicy: IfNotPresent
+        livenessProbe:
+          httpGet:
+            path: /metrics
+            port: 10054
+            scheme: HTTP
+          initialDelaySeconds: 60
+          timeoutSeconds: 5
+          successThreshold: 1
+          failureThreshold: 5
+        args:
+        - --v=2
+        - --logtostderr
+        - --probe=kubedns,127.0.0.1:10053,kubernetes.default.svc.<domain>,5,SRV
+        - --probe=dnsmasq,127.0.0.1:53,kubernetes.default.svc.<domain>,5,SRV
+        ports:
+        - containerPort: 10054
+          name: metrics
+          protocol: TCP
+        resources:
+          requests:
+            memory: 20Mi
+            cpu: 10m
+      dnsPolicy: Default
+      serviceAccountName: kube-dns
+      nodeSelector:
+        beta.kubernetes.io/os: linux
 `)
 
-func k8sAddons116KubernetesmasteraddonsCiliumDaemonsetYamlBytes() ([]byte, error) {
-	return _k8sAddons116KubernetesmasteraddonsCiliumDaemonsetYaml, nil
+func k8sAddons116KubernetesmasteraddonsKubeDnsDeploymentYamlBytes() ([]byte, error) {
+	return _k8sAddons116KubernetesmasteraddonsKubeDnsDeploymentYaml, nil
 }
 
-func k8sAddons116KubernetesmasteraddonsCiliumDaemonsetYaml() (*asset, error) {
-	bytes, err := k8sAddons116KubernetesmasteraddonsCiliumDaemonsetYamlBytes()
+func k8sAddons116KubernetesmasteraddonsKubeDnsDeploymentYaml() (*asset, error) {
+	bytes, err := k8sAddons116KubernetesmasteraddonsKubeDnsDeploymentYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/addons/1.16/kubernetesmasteraddons-cilium-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/addons/1.16/kubernetesmasteraddons-kube-dns-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sAddons116KubernetesmasteraddonsFlannelDaemonsetYaml = []byte(`# This file was pulled from:
-# https://github.com/coreos/flannel (HEAD at time of pull was 4973e02e539378)
----
+var _k8sAddons116KubernetesmasteraddonsKubeProxyDaemonsetYaml = []byte(`---
 apiVersion: v1
-kind: ServiceAccount
-metadata:
-  name: flannel
-  namespace: kube-system
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
----
 kind: ConfigMap
-apiVersion: v1
+data:
+  config.yaml: |
+    apiVersion: kubeproxy.config.k8s.io/v1alpha1
+    kind: KubeProxyConfiguration
+    clientConnection:
+      kubeconfig: /var/lib/kubelet/kubeconfig
+    clusterCIDR: "<CIDR>"
+    mode: "<kubeProxyMode>"
 metadata:
-  name: kube-flannel-cfg
+  name: kube-proxy-config
   namespace: kube-system
   labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+    kubernetes.io/cluster-service: "true"
+    component: kube-proxy
     tier: node
-    app: flannel
-    addonmanager.kubernetes.io/mode: EnsureExists
-data:
-  cni-conf.json: |
-    {
-      "name": "cbr0",
-      "type": "flannel",
-      "delegate": {
-        "isDefaultGateway": true
-      }
-    }
-  net-conf.json: |
-    {
-      "Network": "<kubeClusterCidr>",
-      "Backend": {
-        "Type": "vxlan"
-      }
-    }
+    k8s-app: kube-proxy
 ---
 apiVersion: apps/v1
 kind: DaemonSet
 metadata:
-  name: kube-flannel-ds
-  namespace: kube-system
   labels:
-    tier: node
-    app: flannel
     addonmanager.kubernetes.io/mode: Reconcile
+    kubernetes.io/cluster-service: "true"
+    component: kube-proxy
+    tier: node
+    k8s-app: kube-proxy
+  name: kube-proxy
+  namespace: kube-system
 spec:
+  updateStrategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxUnavailable: 50%
   selector:
     matchLabels:
+      component: kube-proxy
       tier: node
-      app: flannel
+      k8s-app: kube-proxy
   template:
     metadata:
       labels:
+        component: kube-proxy
         tier: node
-        app: flannel
+        k8s-app: kube-proxy
+      annotations:
+        scheduler.alpha.kubernetes.io/critical-pod: ''
     spec:
-      hostNetwork: true
-      nodeSelector:
-        beta.kubernetes.io/arch: amd64
-        beta.kubernetes.io/os: linux
       priorityClassName: system-node-critical
       tolerations:
-        - key: node.kubernetes.io/not-ready
-          operator: Exists
-          effect: NoSchedule
-        - key: node-role.kubernetes.io/master
-          operator: Equal
-          value: "true"
-          effect: NoSchedule
-        - key: CriticalAddonsOnly
-          operator: Exists
-      serviceAccountName: flannel
+      - key: node-role.kubernetes.io/master
+        operator: Equal
+        value: "true"
+        effect: NoSchedule
+      - operator: "Exists"
+        effect: NoExecute
+      - operator: "Exists"
+        effect: NoSchedule
+      - key: CriticalAddonsOnly
+        operator: Exists
       containers:
-      - name: kube-flannel
-        image: quay.io/coreos/flannel:v0.8.0-amd64
-        command: [ "/opt/bin/flanneld", "--ip-masq", "--kube-subnet-mgr" ]
-        securityContext:
-          privileged: true
-        env:
-        - name: POD_NAME
-          valueFrom:
-            fieldRef:
-              fieldPath: metadata.name
-        - name: POD_NAMESPACE
-          valueFrom:
-            fieldRef:
-              fieldPath: metadata.namespace
-        volumeMounts:
-        - name: run
-          mountPath: /run
-        - name: flannel-cfg
-          mountPath: /etc/kube-flannel/
-      - name: install-cni
-        image: quay.io/coreos/flannel:v0.10.0-amd64
-        command: [ "/bin/sh", "-c", "set -e -x; cp -f /etc/kube-flannel/cni-conf.json /etc/cni/net.d/10-flannel.conf; while true; do sleep 3600; done" ]
+      - command:
+        - /hyperkube
+        - kube-proxy
+        - --config=/var/lib/kube-proxy/config.yaml
+        image: <img>
+        imagePullPolicy: IfNotPresent
+        name: kube-proxy
+        resources:
+          requests:
+            cpu: 100m
+            memory: 50Mi
+        securityContext:
+          privileged: true
         volumeMounts:
-        - name: cni
-          mountPath: /etc/cni/net.d
-        - name: flannel-cfg
-          mountPath: /etc/kube-flannel/
+        - mountPath: /etc/ssl/certs
+          name: ssl-certs-host
+          readOnly: true
+        - mountPath: /etc/kubernetes
+          name: etc-kubernetes
+          readOnly: true
+        - mountPath: /var/lib/kubelet/kubeconfig
+          name: kubeconfig
+          readOnly: true
+        - mountPath: /run/xtables.lock
+          name: iptableslock
+        - mountPath: /lib/modules/
+          name: kernelmodules
+          readOnly: true
+        - mountPath: /var/lib/kube-proxy/config.yaml
+          subPath: config.yaml
+          name: kube-proxy-config-volume
+          readOnly: true
+      hostNetwork: true
       volumes:
-        - name: run
-          hostPath:
-            path: /run
-        - name: cni
-          hostPath:
-            path: /etc/cni/net.d
-        - name: flannel-cfg
-          configMap:
-            name: kube-flannel-cfg
+      - hostPath:
+          path: /usr/share/ca-certificates
+        name: ssl-certs-host
+      - hostPath:
+          path: /var/lib/kubelet/kubeconfig
+        name: kubeconfig
+      - hostPath:
+          path: /etc/kubernetes
+        name: etc-kubernetes
+      - hostPath:
+          path: /run/xtables.lock
+        name: iptableslock
+      - hostPath:
+          path: /lib/modules/
+        name: kernelmodules
+      - configMap:
+          name: kube-proxy-config
+        name: kube-proxy-config-volume
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+`)
+
+func k8sAddons116KubernetesmasteraddonsKubeProxyDaemonsetYamlBytes() ([]byte, error) {
+	return _k8sAddons116KubernetesmasteraddonsKubeProxyDaemonsetYaml, nil
+}
+
+func k8sAddons116KubernetesmasteraddonsKubeProxyDaemonsetYaml() (*asset, error) {
+	bytes, err := k8sAddons116KubernetesmasteraddonsKubeProxyDaemonsetYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/addons/1.16/kubernetesmasteraddons-kube-proxy-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sAddons116KubernetesmasteraddonsPodSecurityPolicyYaml = []byte(`apiVersion: policy/v1beta1
+kind: PodSecurityPolicy
+metadata:
+  name: privileged
+  annotations:
+    seccomp.security.alpha.kubernetes.io/allowedProfileNames: "*"
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  privileged: true
+  allowPrivilegeEscalation: true
+  allowedCapabilities:
+  - "*"
+  volumes:
+  - "*"
+  hostNetwork: true
+  hostPorts:
+  - min: 0
+    max: 65535
+  hostIPC: true
+  hostPID: true
+  runAsUser:
+    rule: RunAsAny
+  seLinux:
+    rule: RunAsAny
+  supplementalGroups:
+    rule: RunAsAny
+  fsGroup:
+    rule: RunAsAny
 ---
-# This file was pulled from:
-# https://github.com/coreos/flannel (HEAD at time of pull was 4973e02e539378)
+apiVersion: policy/v1beta1
+kind: PodSecurityPolicy
+metadata:
+  name: restricted
+  annotations:
+    seccomp.security.alpha.kubernetes.io/allowedProfileNames: docker/default
+    apparmor.security.beta.kubernetes.io/allowedProfileNames: runtime/default
+    seccomp.security.alpha.kubernetes.io/defaultProfileName:  docker/default
+    apparmor.security.beta.kubernetes.io/defaultProfileName:  runtime/default
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  privileged: false
+  allowPrivilegeEscalation: false
+  requiredDropCapabilities:
+    - ALL
+  volumes:
+    - configMap
+    - emptyDir
+    - projected
+    - secret
+    - downwardAPI
+    - persistentVolumeClaim
+  hostNetwork: false
+  hostIPC: false
+  hostPID: false
+  runAsUser:
+    rule: MustRunAsNonRoot
+  seLinux:
+    rule: RunAsAny
+  supplementalGroups:
+    rule: MustRunAs
+    ranges:
+      # Forbid adding the root group.
+      - min: 1
+        max: 65535
+  fsGroup:
+    rule: MustRunAs
+    ranges:
+      # Forbid adding the root group.
+      - min: 1
+        max: 65535
+  readOnlyRootFilesystem: false
 ---
+apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRole
-apiVersion: rbac.authorization.k8s.io/v1beta1
 metadata:
-  name: flannel
+  name: psp:privileged
   labels:
     addonmanager.kubernetes.io/mode: Reconcile
 rules:
-  - apiGroups:
-      - ""
-    resources:
-      - pods
-    verbs:
-      - get
-  - apiGroups:
-      - ""
-    resources:
-      - nodes
-    verbs:
-      - list
-      - watch
-  - apiGroups:
-      - ""
-    resources:
-      - nodes/status
-    verbs:
-      - patch
+- apiGroups: ['extensions']
+  resources: ['podsecuritypolicies']
+  verbs:     ['use']
+  resourceNames:
+  - privileged
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: psp:restricted
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups: ['extensions']
+  resources: ['podsecuritypolicies']
+  verbs:     ['use']
+  resourceNames:
+  - restricted
 ---
+apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRoleBinding
-apiVersion: rbac.authorization.k8s.io/v1beta1
 metadata:
-  name: flannel
+  name: default:restricted
   labels:
     addonmanager.kubernetes.io/mode: Reconcile
 roleRef:
   apiGroup: rbac.authorization.k8s.io
   kind: ClusterRole
-  name: flannel
+  name: psp:restricted
 subjects:
-- kind: ServiceAccount
-  name: flannel
-  namespace: kube-system
+- kind: Group
+  name: system:authenticated
+  apiGroup: rbac.authorization.k8s.io
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: default:privileged
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: psp:privileged
+subjects:
+- kind: Group
+  name: system:authenticated
+  apiGroup: rbac.authorization.k8s.io
+- kind: Group
+  name: system:nodes
+  apiGroup: rbac.authorization.k8s.io
 `)
 
-func k8sAddons116KubernetesmasteraddonsFlannelDaemonsetYamlBytes() ([]byte, error) {
-	return _k8sAddons116KubernetesmasteraddonsFlannelDaemonsetYaml, nil
+func k8sAddons116KubernetesmasteraddonsPodSecurityPolicyYamlBytes() ([]byte, error) {
+	return _k8sAddons116KubernetesmasteraddonsPodSecurityPolicyYaml, nil
 }
 
-func k8sAddons116KubernetesmasteraddonsFlannelDaemonsetYaml() (*asset, error) {
-	bytes, err := k8sAddons116KubernetesmasteraddonsFlannelDaemonsetYamlBytes()
+func k8sAddons116KubernetesmasteraddonsPodSecurityPolicyYaml() (*asset, error) {
+	bytes, err := k8sAddons116KubernetesmasteraddonsPodSecurityPolicyYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/addons/1.16/kubernetesmasteraddons-flannel-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/addons/1.16/kubernetesmasteraddons-pod-security-policy.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sAddons116KubernetesmasteraddonsKubeDnsDeploymentYaml = []byte(`# Copyright 2016 The Kubernetes Authors.
-#
-# Licensed under the Apache License, Version 2.0 (the "License");
-# you may not use this file except in compliance with the License.
-# You may obtain a copy of the License at
-#
-#     http://www.apache.org/licenses/LICENSE-2.0
-#
-# Unless required by applicable law or agreed to in writing, software
-# distributed under the License is distributed on an "AS IS" BASIS,
-# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
-# See the License for the specific language governing permissions and
-# limitations under the License.
-
-# Should keep target in cluster/addons/dns-horizontal-autoscaler/dns-horizontal-autoscaler.yaml
-# in sync with this file.
-
+var _k8sAddons16KubernetesmasteraddonsKubernetesDashboardDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  labels:
+    k8s-app: kubernetes-dashboard
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+  name: kubernetes-dashboard
+  namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRoleBinding
+metadata:
+  name: kubernetes-dashboard
+  labels:
+    k8s-app: kubernetes-dashboard
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cluster-admin
+subjects:
+- kind: ServiceAccount
+  name: kubernetes-dashboard
+  namespace: kube-system
+---
 apiVersion: v1
 kind: Service
 metadata:
-  name: kube-dns
+  labels:
+    kubernetes.io/cluster-service: "true"
+    k8s-app: kubernetes-dashboard
+  name: kubernetes-dashboard
   namespace: kube-system
+spec:
+  ports:
+  - port: 80
+    targetPort: 9090
+  selector:
+    k8s-app: kubernetes-dashboard
+  type: NodePort
+---
+apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
   labels:
-    k8s-app: kube-dns
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
-    kubernetes.io/name: "KubeDNS"
+    k8s-app: kubernetes-dashboard
+  name: kubernetes-dashboard
+  namespace: kube-system
 spec:
+  replicas: 1
   selector:
-    k8s-app: kube-dns
-  clusterIP: <clustIP>
-  ports:
-  - name: dns
-    port: 53
-    protocol: UDP
-  - name: dns-tcp
-    port: 53
-    protocol: TCP
----
-apiVersion: v1
+    matchLabels:
+      k8s-app: kubernetes-dashboard
+  template:
+    metadata:
+      labels:
+        k8s-app: kubernetes-dashboard
+    spec:
+      containers:
+      - args:
+        - --heapster-host=http://heapster.kube-system:80
+        image: <img>
+        imagePullPolicy: IfNotPresent
+        livenessProbe:
+          httpGet:
+            path: "/"
+            port: 9090
+          initialDelaySeconds: 30
+          timeoutSeconds: 30
+        name: kubernetes-dashboard
+        ports:
+        - containerPort: 9090
+          protocol: TCP
+        resources:
+          requests:
+            cpu: <cpuReq>
+            memory: <memReq>
+          limits:
+            cpu: <cpuLim>
+            memory: <memLim>
+      serviceAccountName: kubernetes-dashboard
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+`)
+
+func k8sAddons16KubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes() ([]byte, error) {
+	return _k8sAddons16KubernetesmasteraddonsKubernetesDashboardDeploymentYaml, nil
+}
+
+func k8sAddons16KubernetesmasteraddonsKubernetesDashboardDeploymentYaml() (*asset, error) {
+	bytes, err := k8sAddons16KubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/addons/1.6/kubernetesmasteraddons-kubernetes-dashboard-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sAddons17KubernetesmasteraddonsKubeDnsDeploymentYaml = []byte(`apiVersion: v1
 kind: ServiceAccount
 metadata:
   name: kube-dns
@@ -8105,86 +7833,93 @@ metadata:
     addonmanager.kubernetes.io/mode: Reconcile
 ---
 apiVersion: v1
-kind: ConfigMap
+kind: Service
 metadata:
+  labels:
+    k8s-app: kube-dns
+    kubernetes.io/cluster-service: "true"
+    kubernetes.io/name: KubeDNS
   name: kube-dns
   namespace: kube-system
-  labels:
-    addonmanager.kubernetes.io/mode: EnsureExists
+spec:
+  clusterIP: <clustIP>
+  ports:
+  - name: dns
+    port: 53
+    protocol: UDP
+  - name: dns-tcp
+    port: 53
+    protocol: TCP
+  selector:
+    k8s-app: kube-dns
 ---
-apiVersion: apps/v1
+apiVersion: apps/v1beta1
 kind: Deployment
 metadata:
-  name: kube-dns
-  namespace: kube-system
   labels:
-    k8s-app: kube-dns
     kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
+    k8s-app: kube-dns
+    version: v20
+  name: kube-dns-v20
+  namespace: kube-system
 spec:
-  # replicas: not specified here:
-  # 1. In order to make Addon Manager do not reconcile this replicas parameter.
-  # 2. Default is 1.
-  # 3. Will be tuned in real time if DNS horizontal auto-scaling is turned on.
-  strategy:
-    rollingUpdate:
-      maxSurge: 10%
-      maxUnavailable: 0
+  replicas: 2
   selector:
     matchLabels:
       k8s-app: kube-dns
+      version: v20
   template:
     metadata:
+      annotations:
+        scheduler.alpha.kubernetes.io/critical-pod: ""
+        prometheus.io/scrape: "true"
+        prometheus.io/port: "10055"
       labels:
         k8s-app: kube-dns
+        kubernetes.io/cluster-service: "true"
+        version: v20
     spec:
-      priorityClassName: system-node-critical
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+          - weight: 100
+            podAffinityTerm:
+              labelSelector:
+                matchExpressions:
+                - key: k8s-app
+                  operator: In
+                  values:
+                  - kube-dns
+              topologyKey: kubernetes.io/hostname
       tolerations:
-      - key: "CriticalAddonsOnly"
-        operator: "Exists"
+      - key: CriticalAddonsOnly
+        operator: Exists
       volumes:
       - name: kube-dns-config
         configMap:
           name: kube-dns
           optional: true
       containers:
-      - name: kubedns
+      - args:
+        - "--domain=<domain>."
+        - "--dns-port=10053"
+        - "--v=2"
+        - "--config-dir=/kube-dns-config"
+        env:
+        - name: PROMETHEUS_PORT
+          value: "10055"
         image: <img>
         imagePullPolicy: IfNotPresent
-        resources:
-          # TODO: Set memory limits when we've profiled the container for large
-          # clusters, then set request = limit to keep this container in
-          # guaranteed class. Currently, this container falls into the
-          # "burstable" category so the kubelet doesn't backoff from restarting it.
-          limits:
-            memory: 170Mi
-          requests:
-            cpu: 100m
-            memory: 70Mi
         livenessProbe:
+          failureThreshold: 5
           httpGet:
-            path: /healthcheck/kubedns
-            port: 10054
+            path: "/healthz-kubedns"
+            port: 8080
             scheme: HTTP
           initialDelaySeconds: 60
-          timeoutSeconds: 5
           successThreshold: 1
-          failureThreshold: 5
-        readinessProbe:
-          httpGet:
-            path: /readiness
-            port: 8081
-            scheme: HTTP
-          initialDelaySeconds: 3
           timeoutSeconds: 5
-        args:
-        - --domain=<domain>.
-        - --dns-port=10053
-        - --config-dir=/kube-dns-config
-        - --v=2
-        env:
-        - name: PROMETHEUS_PORT
-          value: "10055"
+        name: kubedns
         ports:
         - containerPort: 10053
           name: dns-local
@@ -8195,34 +7930,38 @@ spec:
         - containerPort: 10055
           name: metrics
           protocol: TCP
+        readinessProbe:
+          httpGet:
+            path: "/readiness"
+            port: 8081
+            scheme: HTTP
+          initialDelaySeconds: 30
+          timeoutSeconds: 5
+        resources:
+          limits:
+            memory: 170Mi
+          requests:
+            cpu: 100m
+            memory: 70Mi
         volumeMounts:
         - name: kube-dns-config
           mountPath: /kube-dns-config
-      - name: dnsmasq
+      - args:
+        - "-v=2"
+        - "-logtostderr"
+        - "-configDir=/kube-dns-config"
+        - "-restartDnsmasq=true"
+        - "--"
+        - "-k"
+        - "--cache-size=1000"
+        - "--no-resolv"
+        - "--server=127.0.0.1#10053"
+        - "--server=/in-addr.arpa/127.0.0.1#10053"
+        - "--server=/ip6.arpa/127.0.0.1#10053"
+        - "--log-facility=-"
         image: <imgMasq>
         imagePullPolicy: IfNotPresent
-        livenessProbe:
-          httpGet:
-            path: /healthcheck/dnsmasq
-            port: 10054
-            scheme: HTTP
-          initialDelaySeconds: 60
-          timeoutSeconds: 5
-          successThreshold: 1
-          failureThreshold: 5
-        args:
-        - -v=2
-        - -logtostderr
-        - -configDir=/etc/k8s/dns/dnsmasq-nanny
-        - -restartDnsmasq=true
-        - --
-        - -k
-        - --cache-size=1000
-        - --no-negcache
-        - --log-facility=-
-        - --server=/cluster.local/127.0.0.1#10053
-        - --server=/in-addr.arpa/127.0.0.1#10053
-        - --server=/ip6.arpa/127.0.0.1#10053
+        name: dnsmasq
         ports:
         - containerPort: 53
           name: dns
@@ -8230,549 +7969,268 @@ spec:
         - containerPort: 53
           name: dns-tcp
           protocol: TCP
-        resources:
-          requests:
-            cpu: 150m
-            memory: 20Mi
         volumeMounts:
-        - name: kube-dns-config
-          mountPath: /etc/k8s/dns/dnsmasq-nanny
-      - name: sidecar
-        image: <imgSidecar>
+        - mountPath: /kube-dns-config
+          name: kube-dns-config
+      - args:
+        - "--cmd=for d in $PROBE_DOMAINS; do nslookup $d 127.0.0.1 >/dev/null || exit 1; done"
+        - "--url=/healthz-dnsmasq"
+        - "--cmd=for d in $PROBE_DOMAINS; do nslookup $d 127.0.0.1:10053 >/dev/null || exit 1; done"
+        - "--url=/healthz-kubedns"
+        - "--port=8080"
+        - "--quiet"
+        env:
+        - name: PROBE_DOMAINS
+          value: bing.com kubernetes.default.svc.<domain>
+        image: <imgHealthz>
         imagePullPolicy: IfNotPresent
         livenessProbe:
+          failureThreshold: 5
           httpGet:
-            path: /metrics
-            port: 10054
+            path: "/healthz-dnsmasq"
+            port: 8080
             scheme: HTTP
           initialDelaySeconds: 60
-          timeoutSeconds: 5
           successThreshold: 1
-          failureThreshold: 5
-        args:
-        - --v=2
-        - --logtostderr
-        - --probe=kubedns,127.0.0.1:10053,kubernetes.default.svc.<domain>,5,SRV
-        - --probe=dnsmasq,127.0.0.1:53,kubernetes.default.svc.<domain>,5,SRV
+          timeoutSeconds: 5
+        name: healthz
         ports:
-        - containerPort: 10054
-          name: metrics
+        - containerPort: 8080
           protocol: TCP
         resources:
+          limits:
+            memory: 50Mi
           requests:
-            memory: 20Mi
             cpu: 10m
+            memory: 50Mi
       dnsPolicy: Default
       serviceAccountName: kube-dns
       nodeSelector:
         beta.kubernetes.io/os: linux
 `)
 
-func k8sAddons116KubernetesmasteraddonsKubeDnsDeploymentYamlBytes() ([]byte, error) {
-	return _k8sAddons116KubernetesmasteraddonsKubeDnsDeploymentYaml, nil
+func k8sAddons17KubernetesmasteraddonsKubeDnsDeploymentYamlBytes() ([]byte, error) {
+	return _k8sAddons17KubernetesmasteraddonsKubeDnsDeploymentYaml, nil
 }
 
-func k8sAddons116KubernetesmasteraddonsKubeDnsDeploymentYaml() (*asset, error) {
-	bytes, err := k8sAddons116KubernetesmasteraddonsKubeDnsDeploymentYamlBytes()
+func k8sAddons17KubernetesmasteraddonsKubeDnsDeploymentYaml() (*asset, error) {
+	bytes, err := k8sAddons17KubernetesmasteraddonsKubeDnsDeploymentYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/addons/1.16/kubernetesmasteraddons-kube-dns-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/addons/1.7/kubernetesmasteraddons-kube-dns-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sAddons116KubernetesmasteraddonsKubeProxyDaemonsetYaml = []byte(`---
-apiVersion: v1
-kind: ConfigMap
-data:
-  config.yaml: |
-    apiVersion: kubeproxy.config.k8s.io/v1alpha1
-    kind: KubeProxyConfiguration
-    clientConnection:
-      kubeconfig: /var/lib/kubelet/kubeconfig
-    clusterCIDR: "<CIDR>"
-    mode: "<kubeProxyMode>"
+var _k8sAddons17KubernetesmasteraddonsKubernetesDashboardDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
 metadata:
-  name: kube-proxy-config
+  labels:
+    k8s-app: kubernetes-dashboard
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+  name: kubernetes-dashboard
   namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRoleBinding
+metadata:
+  name: kubernetes-dashboard
   labels:
+    k8s-app: kubernetes-dashboard
+    kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cluster-admin
+subjects:
+- kind: ServiceAccount
+  name: kubernetes-dashboard
+  namespace: kube-system
+---
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
     kubernetes.io/cluster-service: "true"
-    component: kube-proxy
-    tier: node
-    k8s-app: kube-proxy
+    k8s-app: kubernetes-dashboard
+  name: kubernetes-dashboard
+  namespace: kube-system
+spec:
+  ports:
+  - port: 80
+    targetPort: 9090
+  selector:
+    k8s-app: kubernetes-dashboard
+  type: NodePort
 ---
-apiVersion: apps/v1
-kind: DaemonSet
+apiVersion: extensions/v1beta1
+kind: Deployment
 metadata:
   labels:
-    addonmanager.kubernetes.io/mode: Reconcile
     kubernetes.io/cluster-service: "true"
-    component: kube-proxy
-    tier: node
-    k8s-app: kube-proxy
-  name: kube-proxy
+    addonmanager.kubernetes.io/mode: Reconcile
+    k8s-app: kubernetes-dashboard
+  name: kubernetes-dashboard
   namespace: kube-system
 spec:
-  updateStrategy:
-    type: RollingUpdate
-    rollingUpdate:
-      maxUnavailable: 50%
+  replicas: 1
   selector:
     matchLabels:
-      component: kube-proxy
-      tier: node
-      k8s-app: kube-proxy
+      k8s-app: kubernetes-dashboard
   template:
     metadata:
       labels:
-        component: kube-proxy
-        tier: node
-        k8s-app: kube-proxy
-      annotations:
-        scheduler.alpha.kubernetes.io/critical-pod: ''
+        k8s-app: kubernetes-dashboard
     spec:
-      priorityClassName: system-node-critical
-      tolerations:
-      - key: node-role.kubernetes.io/master
-        operator: Equal
-        value: "true"
-        effect: NoSchedule
-      - operator: "Exists"
-        effect: NoExecute
-      - operator: "Exists"
-        effect: NoSchedule
-      - key: CriticalAddonsOnly
-        operator: Exists
       containers:
-      - command:
-        - /hyperkube
-        - kube-proxy
-        - --config=/var/lib/kube-proxy/config.yaml
+      - args:
+        - --heapster-host=http://heapster.kube-system:80
         image: <img>
         imagePullPolicy: IfNotPresent
-        name: kube-proxy
+        livenessProbe:
+          httpGet:
+            path: "/"
+            port: 9090
+          initialDelaySeconds: 30
+          timeoutSeconds: 30
+        name: kubernetes-dashboard
+        ports:
+        - containerPort: 9090
+          protocol: TCP
         resources:
           requests:
-            cpu: 100m
-        securityContext:
-          privileged: true
-        volumeMounts:
-        - mountPath: /etc/ssl/certs
-          name: ssl-certs-host
-          readOnly: true
-        - mountPath: /etc/kubernetes
-          name: etc-kubernetes
-          readOnly: true
-        - mountPath: /var/lib/kubelet/kubeconfig
-          name: kubeconfig
-          readOnly: true
-        - mountPath: /run/xtables.lock
-          name: iptableslock
-        - mountPath: /lib/modules/
-          name: kernelmodules
-          readOnly: true
-        - mountPath: /var/lib/kube-proxy/config.yaml
-          subPath: config.yaml
-          name: kube-proxy-config-volume
-          readOnly: true
-      hostNetwork: true
-      volumes:
-      - hostPath:
-          path: /usr/share/ca-certificates
-        name: ssl-certs-host
-      - hostPath:
-          path: /var/lib/kubelet/kubeconfig
-        name: kubeconfig
-      - hostPath:
-          path: /etc/kubernetes
-        name: etc-kubernetes
-      - hostPath:
-          path: /run/xtables.lock
-        name: iptableslock
-      - hostPath:
-          path: /lib/modules/
-        name: kernelmodules
-      - configMap:
-          name: kube-proxy-config
-        name: kube-proxy-config-volume
+            cpu: <cpuReq>
+            memory: <memReq>
+          limits:
+            cpu: <cpuLim>
+            memory: <memLim>
+      serviceAccountName: kubernetes-dashboard
       nodeSelector:
         beta.kubernetes.io/os: linux
 `)
 
-func k8sAddons116KubernetesmasteraddonsKubeProxyDaemonsetYamlBytes() ([]byte, error) {
-	return _k8sAddons116KubernetesmasteraddonsKubeProxyDaemonsetYaml, nil
+func k8sAddons17KubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes() ([]byte, error) {
+	return _k8sAddons17KubernetesmasteraddonsKubernetesDashboardDeploymentYaml, nil
 }
 
-func k8sAddons116KubernetesmasteraddonsKubeProxyDaemonsetYaml() (*asset, error) {
-	bytes, err := k8sAddons116KubernetesmasteraddonsKubeProxyDaemonsetYamlBytes()
+func k8sAddons17KubernetesmasteraddonsKubernetesDashboardDeploymentYaml() (*asset, error) {
+	bytes, err := k8sAddons17KubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/addons/1.16/kubernetesmasteraddons-kube-proxy-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/addons/1.7/kubernetesmasteraddons-kubernetes-dashboard-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sAddons116KubernetesmasteraddonsPodSecurityPolicyYaml = []byte(`apiVersion: policy/v1beta1
-kind: PodSecurityPolicy
+var _k8sAddons18KubernetesmasteraddonsKubeDnsDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
 metadata:
-  name: privileged
-  annotations:
-    seccomp.security.alpha.kubernetes.io/allowedProfileNames: "*"
+  name: kube-dns
+  namespace: kube-system
   labels:
+    kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    k8s-app: kube-dns
+    kubernetes.io/cluster-service: "true"
+    kubernetes.io/name: KubeDNS
+  name: kube-dns
+  namespace: kube-system
 spec:
-  privileged: true
-  allowPrivilegeEscalation: true
-  allowedCapabilities:
-  - "*"
-  volumes:
-  - "*"
-  hostNetwork: true
-  hostPorts:
-  - min: 0
-    max: 65535
-  hostIPC: true
-  hostPID: true
-  runAsUser:
-    rule: RunAsAny
-  seLinux:
-    rule: RunAsAny
-  supplementalGroups:
-    rule: RunAsAny
-  fsGroup:
-    rule: RunAsAny
----
-apiVersion: policy/v1beta1
-kind: PodSecurityPolicy
-metadata:
-  name: restricted
-  annotations:
-    seccomp.security.alpha.kubernetes.io/allowedProfileNames: docker/default
-    apparmor.security.beta.kubernetes.io/allowedProfileNames: runtime/default
-    seccomp.security.alpha.kubernetes.io/defaultProfileName:  docker/default
-    apparmor.security.beta.kubernetes.io/defaultProfileName:  runtime/default
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-spec:
-  privileged: false
-  allowPrivilegeEscalation: false
-  requiredDropCapabilities:
-    - ALL
-  volumes:
-    - configMap
-    - emptyDir
-    - projected
-    - secret
-    - downwardAPI
-    - persistentVolumeClaim
-  hostNetwork: false
-  hostIPC: false
-  hostPID: false
-  runAsUser:
-    rule: MustRunAsNonRoot
-  seLinux:
-    rule: RunAsAny
-  supplementalGroups:
-    rule: MustRunAs
-    ranges:
-      # Forbid adding the root group.
-      - min: 1
-        max: 65535
-  fsGroup:
-    rule: MustRunAs
-    ranges:
-      # Forbid adding the root group.
-      - min: 1
-        max: 65535
-  readOnlyRootFilesystem: false
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
-metadata:
-  name: psp:privileged
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-rules:
-- apiGroups: ['extensions']
-  resources: ['podsecuritypolicies']
-  verbs:     ['use']
-  resourceNames:
-  - privileged
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
-metadata:
-  name: psp:restricted
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-rules:
-- apiGroups: ['extensions']
-  resources: ['podsecuritypolicies']
-  verbs:     ['use']
-  resourceNames:
-  - restricted
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
-metadata:
-  name: default:restricted
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: psp:restricted
-subjects:
-- kind: Group
-  name: system:authenticated
-  apiGroup: rbac.authorization.k8s.io
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
-metadata:
-  name: default:privileged
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: psp:privileged
-subjects:
-- kind: Group
-  name: system:authenticated
-  apiGroup: rbac.authorization.k8s.io
-- kind: Group
-  name: system:nodes
-  apiGroup: rbac.authorization.k8s.io
-`)
-
-func k8sAddons116KubernetesmasteraddonsPodSecurityPolicyYamlBytes() ([]byte, error) {
-	return _k8sAddons116KubernetesmasteraddonsPodSecurityPolicyYaml, nil
-}
-
-func k8sAddons116KubernetesmasteraddonsPodSecurityPolicyYaml() (*asset, error) {
-	bytes, err := k8sAddons116KubernetesmasteraddonsPodSecurityPolicyYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/addons/1.16/kubernetesmasteraddons-pod-security-policy.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sAddons16KubernetesmasteraddonsKubernetesDashboardDeploymentYaml = []byte(`apiVersion: v1
-kind: ServiceAccount
-metadata:
-  labels:
-    k8s-app: kubernetes-dashboard
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-  name: kubernetes-dashboard
-  namespace: kube-system
----
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: ClusterRoleBinding
-metadata:
-  name: kubernetes-dashboard
-  labels:
-    k8s-app: kubernetes-dashboard
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: cluster-admin
-subjects:
-- kind: ServiceAccount
-  name: kubernetes-dashboard
-  namespace: kube-system
----
-apiVersion: v1
-kind: Service
-metadata:
-  labels:
-    kubernetes.io/cluster-service: "true"
-    k8s-app: kubernetes-dashboard
-  name: kubernetes-dashboard
-  namespace: kube-system
-spec:
+  clusterIP: <clustIP>
   ports:
-  - port: 80
-    targetPort: 9090
+  - name: dns
+    port: 53
+    protocol: UDP
+  - name: dns-tcp
+    port: 53
+    protocol: TCP
   selector:
-    k8s-app: kubernetes-dashboard
-  type: NodePort
+    k8s-app: kube-dns
 ---
-apiVersion: extensions/v1beta1
+apiVersion: apps/v1beta1
 kind: Deployment
 metadata:
   labels:
     kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-    k8s-app: kubernetes-dashboard
-  name: kubernetes-dashboard
+    k8s-app: kube-dns
+    version: v20
+  name: kube-dns-v20
   namespace: kube-system
 spec:
-  replicas: 1
+  replicas: 2
   selector:
     matchLabels:
-      k8s-app: kubernetes-dashboard
+      k8s-app: kube-dns
+      version: v20
   template:
     metadata:
+      annotations:
+        scheduler.alpha.kubernetes.io/critical-pod: ""
+        prometheus.io/scrape: "true"
+        prometheus.io/port: "10055"
       labels:
-        k8s-app: kubernetes-dashboard
+        k8s-app: kube-dns
+        kubernetes.io/cluster-service: "true"
+        version: v20
     spec:
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+          - weight: 100
+            podAffinityTerm:
+              labelSelector:
+                matchExpressions:
+                - key: k8s-app
+                  operator: In
+                  values:
+                  - kube-dns
+              topologyKey: kubernetes.io/hostname
+      tolerations:
+      - key: CriticalAddonsOnly
+        operator: Exists
+      volumes:
+      - name: kube-dns-config
+        configMap:
+          name: kube-dns
+          optional: true
       containers:
       - args:
-        - --heapster-host=http://heapster.kube-system:80
+        - "--domain=<domain>."
+        - "--dns-port=10053"
+        - "--v=2"
+        - "--config-dir=/kube-dns-config"
+        env:
+        - name: PROMETHEUS_PORT
+          value: "10055"
         image: <img>
         imagePullPolicy: IfNotPresent
         livenessProbe:
+          failureThreshold: 5
           httpGet:
-            path: "/"
-            port: 9090
-          initialDelaySeconds: 30
-          timeoutSeconds: 30
-        name: kubernetes-dashboard
+            path: "/healthz-kubedns"
+            port: 8080
+            scheme: HTTP
+          initialDelaySeconds: 60
+          successThreshold: 1
+          timeoutSeconds: 5
+        name: kubedns
         ports:
-        - containerPort: 9090
-          protocol: TCP
-        resources:
-          requests:
-            cpu: <cpuReq>
-            memory: <memReq>
-          limits:
-            cpu: <cpuLim>
-            memory: <memLim>
-      serviceAccountName: kubernetes-dashboard
-      nodeSelector:
-        beta.kubernetes.io/os: linux
-`)
-
-func k8sAddons16KubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes() ([]byte, error) {
-	return _k8sAddons16KubernetesmasteraddonsKubernetesDashboardDeploymentYaml, nil
-}
-
-func k8sAddons16KubernetesmasteraddonsKubernetesDashboardDeploymentYaml() (*asset, error) {
-	bytes, err := k8sAddons16KubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/addons/1.6/kubernetesmasteraddons-kubernetes-dashboard-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sAddons17KubernetesmasteraddonsKubeDnsDeploymentYaml = []byte(`apiVersion: v1
-kind: ServiceAccount
-metadata:
-  name: kube-dns
-  namespace: kube-system
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
----
-apiVersion: v1
-kind: Service
-metadata:
-  labels:
-    k8s-app: kube-dns
-    kubernetes.io/cluster-service: "true"
-    kubernetes.io/name: KubeDNS
-  name: kube-dns
-  namespace: kube-system
-spec:
-  clusterIP: <clustIP>
-  ports:
-  - name: dns
-    port: 53
-    protocol: UDP
-  - name: dns-tcp
-    port: 53
-    protocol: TCP
-  selector:
-    k8s-app: kube-dns
----
-apiVersion: apps/v1beta1
-kind: Deployment
-metadata:
-  labels:
-    kubernetes.io/cluster-service: "true"
-    k8s-app: kube-dns
-    version: v20
-  name: kube-dns-v20
-  namespace: kube-system
-spec:
-  replicas: 2
-  selector:
-    matchLabels:
-      k8s-app: kube-dns
-      version: v20
-  template:
-    metadata:
-      annotations:
-        scheduler.alpha.kubernetes.io/critical-pod: ""
-        prometheus.io/scrape: "true"
-        prometheus.io/port: "10055"
-      labels:
-        k8s-app: kube-dns
-        kubernetes.io/cluster-service: "true"
-        version: v20
-    spec:
-      affinity:
-        podAntiAffinity:
-          preferredDuringSchedulingIgnoredDuringExecution:
-          - weight: 100
-            podAffinityTerm:
-              labelSelector:
-                matchExpressions:
-                - key: k8s-app
-                  operator: In
-                  values:
-                  - kube-dns
-              topologyKey: kubernetes.io/hostname
-      tolerations:
-      - key: CriticalAddonsOnly
-        operator: Exists
-      volumes:
-      - name: kube-dns-config
-        configMap:
-          name: kube-dns
-          optional: true
-      containers:
-      - args:
-        - "--domain=<domain>."
-        - "--dns-port=10053"
-        - "--v=2"
-        - "--config-dir=/kube-dns-config"
-        env:
-        - name: PROMETHEUS_PORT
-          value: "10055"
-        image: <img>
-        imagePullPolicy: IfNotPresent
-        livenessProbe:
-          failureThreshold: 5
-          httpGet:
-            path: "/healthz-kubedns"
-            port: 8080
-            scheme: HTTP
-          initialDelaySeconds: 60
-          successThreshold: 1
-          timeoutSeconds: 5
-        name: kubedns
-        ports:
-        - containerPort: 10053
-          name: dns-local
-          protocol: UDP
-        - containerPort: 10053
-          name: dns-tcp-local
+        - containerPort: 10053
+          name: dns-local
+          protocol: UDP
+        - containerPort: 10053
+          name: dns-tcp-local
           protocol: TCP
         - containerPort: 10055
           name: metrics
@@ -8856,22 +8314,22 @@ spec:
         beta.kubernetes.io/os: linux
 `)
 
-func k8sAddons17KubernetesmasteraddonsKubeDnsDeploymentYamlBytes() ([]byte, error) {
-	return _k8sAddons17KubernetesmasteraddonsKubeDnsDeploymentYaml, nil
+func k8sAddons18KubernetesmasteraddonsKubeDnsDeploymentYamlBytes() ([]byte, error) {
+	return _k8sAddons18KubernetesmasteraddonsKubeDnsDeploymentYaml, nil
 }
 
-func k8sAddons17KubernetesmasteraddonsKubeDnsDeploymentYaml() (*asset, error) {
-	bytes, err := k8sAddons17KubernetesmasteraddonsKubeDnsDeploymentYamlBytes()
+func k8sAddons18KubernetesmasteraddonsKubeDnsDeploymentYaml() (*asset, error) {
+	bytes, err := k8sAddons18KubernetesmasteraddonsKubeDnsDeploymentYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/addons/1.7/kubernetesmasteraddons-kube-dns-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/addons/1.8/kubernetesmasteraddons-kube-dns-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sAddons17KubernetesmasteraddonsKubernetesDashboardDeploymentYaml = []byte(`apiVersion: v1
+var _k8sAddons18KubernetesmasteraddonsKubernetesDashboardDeploymentYaml = []byte(`apiVersion: v1
 kind: ServiceAccount
 metadata:
   labels:
@@ -8960,40 +8418,51 @@ spec:
         beta.kubernetes.io/os: linux
 `)
 
-func k8sAddons17KubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes() ([]byte, error) {
-	return _k8sAddons17KubernetesmasteraddonsKubernetesDashboardDeploymentYaml, nil
+func k8sAddons18KubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes() ([]byte, error) {
+	return _k8sAddons18KubernetesmasteraddonsKubernetesDashboardDeploymentYaml, nil
 }
 
-func k8sAddons17KubernetesmasteraddonsKubernetesDashboardDeploymentYaml() (*asset, error) {
-	bytes, err := k8sAddons17KubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes()
+func k8sAddons18KubernetesmasteraddonsKubernetesDashboardDeploymentYaml() (*asset, error) {
+	bytes, err := k8sAddons18KubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/addons/1.7/kubernetesmasteraddons-kubernetes-dashboard-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/addons/1.8/kubernetesmasteraddons-kubernetes-dashboard-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sAddons18KubernetesmasteraddonsKubeDnsDeploymentYaml = []byte(`apiVersion: v1
-kind: ServiceAccount
-metadata:
-  name: kube-dns
-  namespace: kube-system
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
----
+var _k8sAddons19KubernetesmasteraddonsKubeDnsDeploymentYaml = []byte(`# Copyright 2016 The Kubernetes Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Should keep target in cluster/addons/dns-horizontal-autoscaler/dns-horizontal-autoscaler.yaml
+# in sync with this file.
+
 apiVersion: v1
 kind: Service
 metadata:
+  name: kube-dns
+  namespace: kube-system
   labels:
     k8s-app: kube-dns
     kubernetes.io/cluster-service: "true"
-    kubernetes.io/name: KubeDNS
-  name: kube-dns
-  namespace: kube-system
+    addonmanager.kubernetes.io/mode: Reconcile
+    kubernetes.io/name: "KubeDNS"
 spec:
+  selector:
+    k8s-app: kube-dns
   clusterIP: <clustIP>
   ports:
   - name: dns
@@ -9002,76 +8471,99 @@ spec:
   - name: dns-tcp
     port: 53
     protocol: TCP
-  selector:
-    k8s-app: kube-dns
 ---
-apiVersion: apps/v1beta1
-kind: Deployment
+apiVersion: v1
+kind: ServiceAccount
 metadata:
+  name: kube-dns
+  namespace: kube-system
   labels:
     kubernetes.io/cluster-service: "true"
-    k8s-app: kube-dns
-    version: v20
-  name: kube-dns-v20
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kube-dns
+  namespace: kube-system
+  labels:
+    addonmanager.kubernetes.io/mode: EnsureExists
+---
+apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: kube-dns
   namespace: kube-system
+  labels:
+    k8s-app: kube-dns
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
 spec:
-  replicas: 2
+  # replicas: not specified here:
+  # 1. In order to make Addon Manager do not reconcile this replicas parameter.
+  # 2. Default is 1.
+  # 3. Will be tuned in real time if DNS horizontal auto-scaling is turned on.
+  strategy:
+    rollingUpdate:
+      maxSurge: 10%
+      maxUnavailable: 0
   selector:
     matchLabels:
       k8s-app: kube-dns
-      version: v20
   template:
     metadata:
-      annotations:
-        scheduler.alpha.kubernetes.io/critical-pod: ""
-        prometheus.io/scrape: "true"
-        prometheus.io/port: "10055"
       labels:
         k8s-app: kube-dns
-        kubernetes.io/cluster-service: "true"
-        version: v20
+      annotations:
+        scheduler.alpha.kubernetes.io/critical-pod: ''
     spec:
-      affinity:
-        podAntiAffinity:
-          preferredDuringSchedulingIgnoredDuringExecution:
-          - weight: 100
-            podAffinityTerm:
-              labelSelector:
-                matchExpressions:
-                - key: k8s-app
-                  operator: In
-                  values:
-                  - kube-dns
-              topologyKey: kubernetes.io/hostname
+      priorityClassName: system-node-critical
       tolerations:
-      - key: CriticalAddonsOnly
-        operator: Exists
+      - key: "CriticalAddonsOnly"
+        operator: "Exists"
       volumes:
       - name: kube-dns-config
         configMap:
           name: kube-dns
           optional: true
       containers:
-      - args:
-        - "--domain=<domain>."
-        - "--dns-port=10053"
-        - "--v=2"
-        - "--config-dir=/kube-dns-config"
-        env:
-        - name: PROMETHEUS_PORT
-          value: "10055"
+      - name: kubedns
         image: <img>
         imagePullPolicy: IfNotPresent
+        resources:
+          # TODO: Set memory limits when we've profiled the container for large
+          # clusters, then set request = limit to keep this container in
+          # guaranteed class. Currently, this container falls into the
+          # "burstable" category so the kubelet doesn't backoff from restarting it.
+          limits:
+            memory: 170Mi
+          requests:
+            cpu: 100m
+            memory: 70Mi
         livenessProbe:
-          failureThreshold: 5
           httpGet:
-            path: "/healthz-kubedns"
-            port: 8080
+            path: /healthcheck/kubedns
+            port: 10054
             scheme: HTTP
           initialDelaySeconds: 60
+          timeoutSeconds: 5
           successThreshold: 1
+          failureThreshold: 5
+        readinessProbe:
+          httpGet:
+            path: /readiness
+            port: 8081
+            scheme: HTTP
+          initialDelaySeconds: 3
           timeoutSeconds: 5
-        name: kubedns
+        args:
+        - --domain=<domain>.
+        - --dns-port=10053
+        - --config-dir=/kube-dns-config
+        - --v=2
+        env:
+        - name: PROMETHEUS_PORT
+          value: "10055"
         ports:
         - containerPort: 10053
           name: dns-local
@@ -9082,38 +8574,34 @@ spec:
         - containerPort: 10055
           name: metrics
           protocol: TCP
-        readinessProbe:
-          httpGet:
-            path: "/readiness"
-            port: 8081
-            scheme: HTTP
-          initialDelaySeconds: 30
-          timeoutSeconds: 5
-        resources:
-          limits:
-            memory: 170Mi
-          requests:
-            cpu: 100m
-            memory: 70Mi
         volumeMounts:
         - name: kube-dns-config
           mountPath: /kube-dns-config
-      - args:
-        - "-v=2"
-        - "-logtostderr"
-        - "-configDir=/kube-dns-config"
-        - "-restartDnsmasq=true"
-        - "--"
-        - "-k"
-        - "--cache-size=1000"
-        - "--no-resolv"
-        - "--server=127.0.0.1#10053"
-        - "--server=/in-addr.arpa/127.0.0.1#10053"
-        - "--server=/ip6.arpa/127.0.0.1#10053"
-        - "--log-facility=-"
+      - name: dnsmasq
         image: <imgMasq>
         imagePullPolicy: IfNotPresent
-        name: dnsmasq
+        livenessProbe:
+          httpGet:
+            path: /healthcheck/dnsmasq
+            port: 10054
+            scheme: HTTP
+          initialDelaySeconds: 60
+          timeoutSeconds: 5
+          successThreshold: 1
+          failureThreshold: 5
+        args:
+        - -v=2
+        - -logtostderr
+        - -configDir=/etc/k8s/dns/dnsmasq-nanny
+        - -restartDnsmasq=true
+        - --
+        - -k
+        - --cache-size=1000
+        - --no-negcache
+        - --log-facility=-
+        - --server=/cluster.local/127.0.0.1#10053
+        - --server=/in-addr.arpa/127.0.0.1#10053
+        - --server=/ip6.arpa/127.0.0.1#10053
         ports:
         - containerPort: 53
           name: dns
@@ -9121,192 +8609,294 @@ spec:
         - containerPort: 53
           name: dns-tcp
           protocol: TCP
+        resources:
+          requests:
+            cpu: 150m
+            memory: 20Mi
         volumeMounts:
-        - mountPath: /kube-dns-config
-          name: kube-dns-config
-      - args:
-        - "--cmd=for d in $PROBE_DOMAINS; do nslookup $d 127.0.0.1 >/dev/null || exit 1; done"
-        - "--url=/healthz-dnsmasq"
-        - "--cmd=for d in $PROBE_DOMAINS; do nslookup $d 127.0.0.1:10053 >/dev/null || exit 1; done"
-        - "--url=/healthz-kubedns"
-        - "--port=8080"
-        - "--quiet"
-        env:
-        - name: PROBE_DOMAINS
-          value: bing.com kubernetes.default.svc.<domain>
-        image: <imgHealthz>
+        - name: kube-dns-config
+          mountPath: /etc/k8s/dns/dnsmasq-nanny
+      - name: sidecar
+        image: <imgSidecar>
         imagePullPolicy: IfNotPresent
         livenessProbe:
-          failureThreshold: 5
           httpGet:
-            path: "/healthz-dnsmasq"
-            port: 8080
+            path: /metrics
+            port: 10054
             scheme: HTTP
           initialDelaySeconds: 60
-          successThreshold: 1
           timeoutSeconds: 5
-        name: healthz
+          successThreshold: 1
+          failureThreshold: 5
+        args:
+        - --v=2
+        - --logtostderr
+        - --probe=kubedns,127.0.0.1:10053,kubernetes.default.svc.<domain>,5,SRV
+        - --probe=dnsmasq,127.0.0.1:53,kubernetes.default.svc.<domain>,5,SRV
         ports:
-        - containerPort: 8080
+        - containerPort: 10054
+          name: metrics
           protocol: TCP
         resources:
-          limits:
-            memory: 50Mi
           requests:
+            memory: 20Mi
             cpu: 10m
-            memory: 50Mi
       dnsPolicy: Default
       serviceAccountName: kube-dns
       nodeSelector:
-        beta.kubernetes.io/os: linux
-`)
+        beta.kubernetes.io/os: linux`)
 
-func k8sAddons18KubernetesmasteraddonsKubeDnsDeploymentYamlBytes() ([]byte, error) {
-	return _k8sAddons18KubernetesmasteraddonsKubeDnsDeploymentYaml, nil
+func k8sAddons19KubernetesmasteraddonsKubeDnsDeploymentYamlBytes() ([]byte, error) {
+	return _k8sAddons19KubernetesmasteraddonsKubeDnsDeploymentYaml, nil
 }
 
-func k8sAddons18KubernetesmasteraddonsKubeDnsDeploymentYaml() (*asset, error) {
-	bytes, err := k8sAddons18KubernetesmasteraddonsKubeDnsDeploymentYamlBytes()
+func k8sAddons19KubernetesmasteraddonsKubeDnsDeploymentYaml() (*asset, error) {
+	bytes, err := k8sAddons19KubernetesmasteraddonsKubeDnsDeploymentYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/addons/1.8/kubernetesmasteraddons-kube-dns-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/addons/1.9/kubernetesmasteraddons-kube-dns-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sAddons18KubernetesmasteraddonsKubernetesDashboardDeploymentYaml = []byte(`apiVersion: v1
+var _k8sAddonsCorednsYaml = []byte(`apiVersion: v1
 kind: ServiceAccount
 metadata:
+  name: coredns
+  namespace: kube-system
   labels:
-    k8s-app: kubernetes-dashboard
-    kubernetes.io/cluster-service: "true"
+      kubernetes.io/cluster-service: "true"
+      addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  labels:
+    kubernetes.io/bootstrapping: rbac-defaults
     addonmanager.kubernetes.io/mode: Reconcile
-  name: kubernetes-dashboard
-  namespace: kube-system
+  name: system:coredns
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - endpoints
+  - services
+  - pods
+  - namespaces
+  verbs:
+  - list
+  - watch
+- apiGroups:
+  - ""
+  resources:
+  - nodes
+  verbs:
+  - get
 ---
-apiVersion: rbac.authorization.k8s.io/v1beta1
+apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRoleBinding
 metadata:
-  name: kubernetes-dashboard
+  annotations:
+    rbac.authorization.kubernetes.io/autoupdate: "true"
   labels:
-    k8s-app: kubernetes-dashboard
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
+    kubernetes.io/bootstrapping: rbac-defaults
+    addonmanager.kubernetes.io/mode: EnsureExists
+  name: system:coredns
 roleRef:
   apiGroup: rbac.authorization.k8s.io
   kind: ClusterRole
-  name: cluster-admin
+  name: system:coredns
 subjects:
 - kind: ServiceAccount
-  name: kubernetes-dashboard
+  name: coredns
   namespace: kube-system
 ---
 apiVersion: v1
-kind: Service
+kind: ConfigMap
 metadata:
+  name: coredns
+  namespace: kube-system
   labels:
-    kubernetes.io/cluster-service: "true"
-    k8s-app: kubernetes-dashboard
-  name: kubernetes-dashboard
+      addonmanager.kubernetes.io/mode: Reconcile
+data:
+  Corefile: |
+    import conf.d/Corefile*
+    .:53 {
+        errors
+        health
+        ready
+        kubernetes <domain> in-addr.arpa ip6.arpa {
+            pods insecure
+            fallthrough in-addr.arpa ip6.arpa
+        }
+        prometheus :9153
+        forward . /etc/resolv.conf
+        cache 30
+        loop
+        reload
+        loadbalance
+    }
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: coredns-custom
   namespace: kube-system
-spec:
-  ports:
-  - port: 80
-    targetPort: 9090
-  selector:
-    k8s-app: kubernetes-dashboard
-  type: NodePort
+  labels:
+      addonmanager.kubernetes.io/mode: EnsureExists
+data:
+  Corefile: |
+    # Add custom CoreDNS configuration here.
+    #
+    # See https://github.com/coredns/coredns/tree/master/plugin/azure for information
+    # about the Azure DNS plugin.
 ---
-apiVersion: extensions/v1beta1
+apiVersion: apps/v1
 kind: Deployment
 metadata:
+  name: coredns
+  namespace: kube-system
   labels:
+    k8s-app: kube-dns
+    kubernetes.io/name: "CoreDNS"
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
-    k8s-app: kubernetes-dashboard
-  name: kubernetes-dashboard
-  namespace: kube-system
 spec:
-  replicas: 1
+  # replicas: not specified here unless the sizing profile of the cluster sets it:
+  # 1. In order to make Addon Manager do not reconcile this replicas parameter.
+  # 2. Default is 1.
+  # 3. Will be tuned in real time if DNS horizontal auto-scaling is turned on.
+  #<replicas>
+  strategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxUnavailable: 1
   selector:
     matchLabels:
-      k8s-app: kubernetes-dashboard
+      k8s-app: kube-dns
   template:
     metadata:
       labels:
-        k8s-app: kubernetes-dashboard
-    spec:
-      containers:
-      - args:
-        - --heapster-host=http://heapster.kube-system:80
+        k8s-app: kube-dns
+      annotations:
+        seccomp.security.alpha.kubernetes.io/pod: docker/default
+    spec:
+      priorityClassName: system-cluster-critical
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+          - podAffinityTerm:
+              labelSelector:
+                matchExpressions:
+                - key: k8s-app
+                  operator: In
+                  values:
+                  - kube-dns
+              topologyKey: failure-domain.beta.kubernetes.io/zone
+            weight: 10
+          - podAffinityTerm:
+              labelSelector:
+                matchExpressions:
+                - key: k8s-app
+                  operator: In
+                  values:
+                  - kube-dns
+              topologyKey: kubernetes.io/hostname
+            weight: 5
+      serviceAccountName: coredns
+      tolerations:
+        - key: node-role.kubernetes.io/master
+          effect: NoSchedule
+        - key: CriticalAddonsOnly
+          operator: "Exists"
+        - operator: "Exists"
+          effect: NoExecute
+        - operator: "Exists"
+          effect: NoSchedule
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      containers:
+      - name: coredns
         image: <img>
         imagePullPolicy: IfNotPresent
-        livenessProbe:
-          httpGet:
-            path: "/"
-            port: 9090
-          initialDelaySeconds: 30
-          timeoutSeconds: 30
-        name: kubernetes-dashboard
-        ports:
-        - containerPort: 9090
-          protocol: TCP
         resources:
+          limits:
+            memory: <memLimit>
           requests:
             cpu: <cpuReq>
             memory: <memReq>
-          limits:
-            cpu: <cpuLim>
-            memory: <memLim>
-      serviceAccountName: kubernetes-dashboard
-      nodeSelector:
-        beta.kubernetes.io/os: linux
-`)
-
-func k8sAddons18KubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes() ([]byte, error) {
-	return _k8sAddons18KubernetesmasteraddonsKubernetesDashboardDeploymentYaml, nil
-}
-
-func k8sAddons18KubernetesmasteraddonsKubernetesDashboardDeploymentYaml() (*asset, error) {
-	bytes, err := k8sAddons18KubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/addons/1.8/kubernetesmasteraddons-kubernetes-dashboard-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sAddons19KubernetesmasteraddonsKubeDnsDeploymentYaml = []byte(`# Copyright 2016 The Kubernetes Authors.
-#
-# Licensed under the Apache License, Version 2.0 (the "License");
-# you may not use this file except in compliance with the License.
-# You may obtain a copy of the License at
-#
-#     http://www.apache.org/licenses/LICENSE-2.0
-#
-# Unless required by applicable law or agreed to in writing, software
-# distributed under the License is distributed on an "AS IS" BASIS,
-# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
-# See the License for the specific language governing permissions and
-# limitations under the License.
-
-# Should keep target in cluster/addons/dns-horizontal-autoscaler/dns-horizontal-autoscaler.yaml
-# in sync with this file.
+        args: [ "-conf", "/etc/coredns/Corefile" ]
+        volumeMounts:
+        - name: config-volume
+          mountPath: /etc/coredns
+          readOnly: true
+        - mountPath: /etc/coredns/conf.d
+          name: config-custom
+          readOnly: true
+        ports:
+        - containerPort: 53
+          name: dns
+          protocol: UDP
+        - containerPort: 53
+          name: dns-tcp
+          protocol: TCP
+        - containerPort: 9153
+          name: metrics
+          protocol: TCP
+        livenessProbe:
+          httpGet:
+            path: /health
+            port: 8080
+            scheme: HTTP
+          initialDelaySeconds: 60
+          timeoutSeconds: 5
+          successThreshold: 1
+          failureThreshold: 5
+        readinessProbe:
+          httpGet:
+            path: /ready
+            port: 8181
+            scheme: HTTP
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            add:
+            - NET_BIND_SERVICE
+            drop:
+            - all
+          readOnlyRootFilesystem: true
+      dnsPolicy: Default
+      volumes:
+        - name: config-volume
+          configMap:
+            name: coredns
+            items:
+            - key: Corefile
+              path: Corefile
+        - name: config-custom
+          configMap:
+            name: coredns-custom
+            items:
+            - key: Corefile
+              path: Corefile
+            optional: true
 
+---
 apiVersion: v1
 kind: Service
 metadata:
   name: kube-dns
   namespace: kube-system
+  annotations:
+    prometheus.io/port: "9153"
+    prometheus.io/scrape: "true"
   labels:
     k8s-app: kube-dns
     kubernetes.io/cluster-service: "true"
+    kubernetes.io/name: CoreDNS
     addonmanager.kubernetes.io/mode: Reconcile
-    kubernetes.io/name: "KubeDNS"
 spec:
   selector:
     k8s-app: kube-dns
@@ -9318,2145 +8908,1455 @@ spec:
   - name: dns-tcp
     port: 53
     protocol: TCP
----
-apiVersion: v1
-kind: ServiceAccount
+  - name: metrics
+    port: 9153
+    protocol: TCP
+`)
+
+func k8sAddonsCorednsYamlBytes() ([]byte, error) {
+	return _k8sAddonsCorednsYaml, nil
+}
+
+func k8sAddonsCorednsYaml() (*asset, error) {
+	bytes, err := k8sAddonsCorednsYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/addons/coredns.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sAddonsKubernetesmasterAuditPolicyYaml = []byte(`apiVersion: audit.k8s.io/v1beta1 # This is required.
+kind: Policy
+omitStages:
+  - RequestReceived
+rules:
+  - level: RequestResponse
+    resources:
+    - group: ""
+      resources: ["pods"]
+  - level: Metadata
+    resources:
+    - group: ""
+      resources: ["pods/log", "pods/status"]
+  - level: None
+    users: ["system:kube-proxy"]
+    verbs: ["watch"]
+    resources:
+    - group: ""
+      resources: ["endpoints", "services"]
+  - level: None
+    userGroups: ["system:authenticated"]
+    nonResourceURLs:
+    - /api*
+    - /version
+  - level: Request
+    resources:
+    - group: ""
+      resources: ["configmaps"]
+    namespaces: ["kube-system"]
+  - level: Request
+    resources:
+    - group: ""
+      resources: ["secrets"]
+  - level: Request
+    resources:
+    - group: ""
+    - group: extensions
+  - level: Metadata
+    omitStages:
+      - RequestReceived
+`)
+
+func k8sAddonsKubernetesmasterAuditPolicyYamlBytes() ([]byte, error) {
+	return _k8sAddonsKubernetesmasterAuditPolicyYaml, nil
+}
+
+func k8sAddonsKubernetesmasterAuditPolicyYaml() (*asset, error) {
+	bytes, err := k8sAddonsKubernetesmasterAuditPolicyYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/addons/kubernetesmaster-audit-policy.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sAddonsKubernetesmasteraddonsAadDefaultAdminGroupRbacYaml = []byte(`kind: ClusterRoleBinding
+apiVersion: rbac.authorization.k8s.io/v1
 metadata:
-  name: kube-dns
-  namespace: kube-system
+  name: aad-default-admin-group
   labels:
     kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
----
-apiVersion: v1
-kind: ConfigMap
-metadata:
-  name: kube-dns
-  namespace: kube-system
-  labels:
     addonmanager.kubernetes.io/mode: EnsureExists
----
-apiVersion: extensions/v1beta1
-kind: Deployment
-metadata:
-  name: kube-dns
-  namespace: kube-system
-  labels:
-    k8s-app: kube-dns
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-spec:
-  # replicas: not specified here:
-  # 1. In order to make Addon Manager do not reconcile this replicas parameter.
-  # 2. Default is 1.
-  # 3. Will be tuned in real time if DNS horizontal auto-scaling is turned on.
-  strategy:
-    rollingUpdate:
-      maxSurge: 10%
-      maxUnavailable: 0
-  selector:
-    matchLabels:
-      k8s-app: kube-dns
-  template:
-    metadata:
-      labels:
-        k8s-app: kube-dns
-      annotations:
-        scheduler.alpha.kubernetes.io/critical-pod: ''
-    spec:
-      priorityClassName: system-node-critical
-      tolerations:
-      - key: "CriticalAddonsOnly"
-        operator: "Exists"
-      volumes:
-      - name: kube-dns-config
-        configMap:
-          name: kube-dns
-          optional: true
-      containers:
-      - name: kubedns
-        image: <img>
-        imagePullPolicy: IfNotPresent
-        resources:
-          # TODO: Set memory limits when we've profiled the container for large
-          # clusters, then set request = limit to keep this container in
-          # guaranteed class. Currently, this container falls into the
-          # "burstable" category so the kubelet doesn't backoff from restarting it.
-          limits:
-            memory: 170Mi
-          requests:
-            cpu: 100m
-            memory: 70Mi
-        livenessProbe:
-          httpGet:
-            path: /healthcheck/kubedns
-            port: 10054
-            scheme: HTTP
-          initialDelaySeconds: 60
-          timeoutSeconds: 5
-          successThreshold: 1
-          failureThreshold: 5
-        readinessProbe:
-          httpGet:
-            path: /readiness
-            port: 8081
-            scheme: HTTP
-          initialDelaySeconds: 3
-          timeoutSeconds: 5
-        args:
-        - --domain=<domain>.
-        - --dns-port=10053
-        - --config-dir=/kube-dns-config
-        - --v=2
-        env:
-        - name: PROMETHEUS_PORT
-          value: "10055"
-        ports:
-        - containerPort: 10053
-          name: dns-local
-          protocol: UDP
-        - containerPort: 10053
-          name: dns-tcp-local
-          protocol: TCP
-        - containerPort: 10055
-          name: metrics
-          protocol: TCP
-        volumeMounts:
-        - name: kube-dns-config
-          mountPath: /kube-dns-config
-      - name: dnsmasq
-        image: <imgMasq>
-        imagePullPolicy: IfNotPresent
-        livenessProbe:
-          httpGet:
-            path: /healthcheck/dnsmasq
-            port: 10054
-            scheme: HTTP
-          initialDelaySeconds: 60
-          timeoutSeconds: 5
-          successThreshold: 1
-          failureThreshold: 5
-        args:
-        - -v=2
-        - -logtostderr
-        - -configDir=/etc/k8s/dns/dnsmasq-nanny
-        - -restartDnsmasq=true
-        - --
-        - -k
-        - --cache-size=1000
-        - --no-negcache
-        - --log-facility=-
-        - --server=/cluster.local/127.0.0.1#10053
-        - --server=/in-addr.arpa/127.0.0.1#10053
-        - --server=/ip6.arpa/127.0.0.1#10053
-        ports:
-        - containerPort: 53
-          name: dns
-          protocol: UDP
-        - containerPort: 53
-          name: dns-tcp
-          protocol: TCP
-        resources:
-          requests:
-            cpu: 150m
-            memory: 20Mi
-        volumeMounts:
-        - name: kube-dns-config
-          mountPath: /etc/k8s/dns/dnsmasq-nanny
-      - name: sidecar
-        image: <imgSidecar>
-        imagePullPolicy: IfNotPresent
-        livenessProbe:
-          httpGet:
-            path: /metrics
-            port: 10054
-            scheme: HTTP
-          initialDelaySeconds: 60
-          timeoutSeconds: 5
-          successThreshold: 1
-          failureThreshold: 5
-        args:
-        - --v=2
-        - --logtostderr
-        - --probe=kubedns,127.0.0.1:10053,kubernetes.default.svc.<domain>,5,SRV
-        - --probe=dnsmasq,127.0.0.1:53,kubernetes.default.svc.<domain>,5,SRV
-        ports:
-        - containerPort: 10054
-          name: metrics
-          protocol: TCP
-        resources:
-          requests:
-            memory: 20Mi
-            cpu: 10m
-      dnsPolicy: Default
-      serviceAccountName: kube-dns
-      nodeSelector:
-        beta.kubernetes.io/os: linux`)
+subjects:
+- kind: Group
+  name: <gID>
+  apiGroup: rbac.authorization.k8s.io
+roleRef:
+  kind: ClusterRole
+  name: cluster-admin
+  apiGroup: rbac.authorization.k8s.io
+`)
 
-func k8sAddons19KubernetesmasteraddonsKubeDnsDeploymentYamlBytes() ([]byte, error) {
-	return _k8sAddons19KubernetesmasteraddonsKubeDnsDeploymentYaml, nil
+func k8sAddonsKubernetesmasteraddonsAadDefaultAdminGroupRbacYamlBytes() ([]byte, error) {
+	return _k8sAddonsKubernetesmasteraddonsAadDefaultAdminGroupRbacYaml, nil
 }
 
-func k8sAddons19KubernetesmasteraddonsKubeDnsDeploymentYaml() (*asset, error) {
-	bytes, err := k8sAddons19KubernetesmasteraddonsKubeDnsDeploymentYamlBytes()
+func k8sAddonsKubernetesmasteraddonsAadDefaultAdminGroupRbacYaml() (*asset, error) {
+	bytes, err := k8sAddonsKubernetesmasteraddonsAadDefaultAdminGroupRbacYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/addons/1.9/kubernetesmasteraddons-kube-dns-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-aad-default-admin-group-rbac.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sAddonsCorednsYaml = []byte(`apiVersion: v1
-kind: ServiceAccount
-metadata:
-  name: coredns
-  namespace: kube-system
-  labels:
-      kubernetes.io/cluster-service: "true"
-      addonmanager.kubernetes.io/mode: Reconcile
----
-apiVersion: rbac.authorization.k8s.io/v1
+var _k8sAddonsKubernetesmasteraddonsAzureCloudProviderDeploymentYaml = []byte(`---
+apiVersion: rbac.authorization.k8s.io/v1beta1
 kind: ClusterRole
 metadata:
   labels:
-    kubernetes.io/bootstrapping: rbac-defaults
-    addonmanager.kubernetes.io/mode: Reconcile
-  name: system:coredns
+    kubernetes.io/cluster-service: "true"
+  name: system:azure-cloud-provider
 rules:
-- apiGroups:
-  - ""
-  resources:
-  - endpoints
-  - services
-  - pods
-  - namespaces
-  verbs:
-  - list
-  - watch
-- apiGroups:
-  - ""
-  resources:
-  - nodes
+- apiGroups: [""]
+  resources: ["events"]
   verbs:
-  - get
+  - create
+  - patch
+  - update
 ---
-apiVersion: rbac.authorization.k8s.io/v1
+apiVersion: rbac.authorization.k8s.io/v1beta1
 kind: ClusterRoleBinding
 metadata:
-  annotations:
-    rbac.authorization.kubernetes.io/autoupdate: "true"
   labels:
-    kubernetes.io/bootstrapping: rbac-defaults
-    addonmanager.kubernetes.io/mode: EnsureExists
-  name: system:coredns
+    kubernetes.io/cluster-service: "true"
+  name: system:azure-cloud-provider
 roleRef:
   apiGroup: rbac.authorization.k8s.io
   kind: ClusterRole
-  name: system:coredns
+  name: system:azure-cloud-provider
 subjects:
 - kind: ServiceAccount
-  name: coredns
+  name: azure-cloud-provider
   namespace: kube-system
 ---
-apiVersion: v1
-kind: ConfigMap
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRole
 metadata:
-  name: coredns
-  namespace: kube-system
+  name: system:azure-persistent-volume-binder
   labels:
-      addonmanager.kubernetes.io/mode: Reconcile
-data:
-  Corefile: |
-    import conf.d/Corefile*
-    .:53 {
-        errors
-        health
-        ready
-        kubernetes <domain> in-addr.arpa ip6.arpa {
-            pods insecure
-            fallthrough in-addr.arpa ip6.arpa
-        }
-        prometheus :9153
-        forward . /etc/resolv.conf
-        cache 30
-        loop
-        reload
-        loadbalance
-    }
+    kubernetes.io/cluster-service: "true"
+rules:
+- apiGroups: ['']
+  resources: ['secrets']
+  verbs:     ['get','create']
 ---
-apiVersion: v1
-kind: ConfigMap
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRoleBinding
 metadata:
-  name: coredns-custom
-  namespace: kube-system
+  name: system:azure-persistent-volume-binder
   labels:
-      addonmanager.kubernetes.io/mode: EnsureExists
-data:
-  Corefile: |
-    # Add custom CoreDNS configuration here.
-    #
-    # See https://github.com/coredns/coredns/tree/master/plugin/azure for information
-    # about the Azure DNS plugin.
----
-apiVersion: apps/v1
-kind: Deployment
-metadata:
-  name: coredns
+    kubernetes.io/cluster-service: "true"
+roleRef:
+  kind: ClusterRole
+  apiGroup: rbac.authorization.k8s.io
+  name: system:azure-persistent-volume-binder
+subjects:
+- kind: ServiceAccount
+  name: persistent-volume-binder
   namespace: kube-system
+`)
+
+func k8sAddonsKubernetesmasteraddonsAzureCloudProviderDeploymentYamlBytes() ([]byte, error) {
+	return _k8sAddonsKubernetesmasteraddonsAzureCloudProviderDeploymentYaml, nil
+}
+
+func k8sAddonsKubernetesmasteraddonsAzureCloudProviderDeploymentYaml() (*asset, error) {
+	bytes, err := k8sAddonsKubernetesmasteraddonsAzureCloudProviderDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-azure-cloud-provider-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sAddonsKubernetesmasteraddonsElbSvcYaml = []byte(`---
+apiVersion: v1
+kind: Service
+metadata:
   labels:
-    k8s-app: kube-dns
-    kubernetes.io/name: "CoreDNS"
     kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
+    addonmanager.kubernetes.io/mode: EnsureExists
+  name: elb
+  namespace: kube-system
 spec:
-  # replicas: not specified here:
-  # 1. In order to make Addon Manager do not reconcile this replicas parameter.
-  # 2. Default is 1.
-  # 3. Will be tuned in real time if DNS horizontal auto-scaling is turned on.
-  strategy:
-    type: RollingUpdate
-    rollingUpdate:
-      maxUnavailable: 1
+  ports:
+  - port: 8765
+    targetPort: 9376
+    protocol: TCP
   selector:
-    matchLabels:
-      k8s-app: kube-dns
-  template:
-    metadata:
-      labels:
-        k8s-app: kube-dns
-      annotations:
-        seccomp.security.alpha.kubernetes.io/pod: docker/default
-    spec:
-      priorityClassName: system-cluster-critical
-      affinity:
-        podAntiAffinity:
-          preferredDuringSchedulingIgnoredDuringExecution:
-          - podAffinityTerm:
-              labelSelector:
-                matchExpressions:
-                - key: k8s-app
-                  operator: In
-                  values:
-                  - kube-dns
-              topologyKey: failure-domain.beta.kubernetes.io/zone
-            weight: 10
-          - podAffinityTerm:
-              labelSelector:
-                matchExpressions:
-                - key: k8s-app
-                  operator: In
-                  values:
-                  - kube-dns
-              topologyKey: kubernetes.io/hostname
-            weight: 5
-      serviceAccountName: coredns
-      tolerations:
-        - key: node-role.kubernetes.io/master
-          effect: NoSchedule
-        - key: CriticalAddonsOnly
-          operator: "Exists"
-        - operator: "Exists"
-          effect: NoExecute
-        - operator: "Exists"
-          effect: NoSchedule
-      nodeSelector:
-        beta.kubernetes.io/os: linux
-      containers:
-      - name: coredns
-        image: <img>
-        imagePullPolicy: IfNotPresent
-        resources:
-          limits:
-            memory: 170Mi
-          requests:
-            cpu: 100m
-            memory: 70Mi
-        args: [ "-conf", "/etc/coredns/Corefile" ]
-        volumeMounts:
-        - name: config-volume
-          mountPath: /etc/coredns
-          readOnly: true
-        - mountPath: /etc/coredns/conf.d
-          name: config-custom
-          readOnly: true
-        ports:
-        - containerPort: 53
-          name: dns
-          protocol: UDP
-        - containerPort: 53
-          name: dns-tcp
-          protocol: TCP
-        - containerPort: 9153
-          name: metrics
-          protocol: TCP
-        livenessProbe:
-          httpGet:
-            path: /health
-            port: 8080
-            scheme: HTTP
-          initialDelaySeconds: 60
-          timeoutSeconds: 5
-          successThreshold: 1
-          failureThreshold: 5
-        readinessProbe:
-          httpGet:
-            path: /ready
-            port: 8181
-            scheme: HTTP
-        securityContext:
-          allowPrivilegeEscalation: false
-          capabilities:
-            add:
-            - NET_BIND_SERVICE
-            drop:
-            - all
-          readOnlyRootFilesystem: true
-      dnsPolicy: Default
-      volumes:
-        - name: config-volume
-          configMap:
-            name: coredns
-            items:
-            - key: Corefile
-              path: Corefile
-        - name: config-custom
-          configMap:
-            name: coredns-custom
-            items:
-            - key: Corefile
-              path: Corefile
-            optional: true
-
+    app: "<svcName>"
+  type: LoadBalancer
 ---
 apiVersion: v1
 kind: Service
 metadata:
-  name: kube-dns
-  namespace: kube-system
-  annotations:
-    prometheus.io/port: "9153"
-    prometheus.io/scrape: "true"
   labels:
-    k8s-app: kube-dns
     kubernetes.io/cluster-service: "true"
-    kubernetes.io/name: CoreDNS
-    addonmanager.kubernetes.io/mode: Reconcile
+    addonmanager.kubernetes.io/mode: EnsureExists
+  name: elb-udp
+  namespace: kube-system
 spec:
-  selector:
-    k8s-app: kube-dns
-  clusterIP: <clustIP>
   ports:
-  - name: dns
-    port: 53
+  - port: 8765
+    targetPort: 9376
     protocol: UDP
-  - name: dns-tcp
-    port: 53
-    protocol: TCP
-  - name: metrics
-    port: 9153
-    protocol: TCP
-`)
+  selector:
+    app: "<svcName>"
+  type: LoadBalancer`)
 
-func k8sAddonsCorednsYamlBytes() ([]byte, error) {
-	return _k8sAddonsCorednsYaml, nil
+func k8sAddonsKubernetesmasteraddonsElbSvcYamlBytes() ([]byte, error) {
+	return _k8sAddonsKubernetesmasteraddonsElbSvcYaml, nil
 }
 
-func k8sAddonsCorednsYaml() (*asset, error) {
-	bytes, err := k8sAddonsCorednsYamlBytes()
+func k8sAddonsKubernetesmasteraddonsElbSvcYaml() (*asset, error) {
+	bytes, err := k8sAddonsKubernetesmasteraddonsElbSvcYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/addons/coredns.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-elb-svc.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sAddonsKubernetesmasterAuditPolicyYaml = []byte(`apiVersion: audit.k8s.io/v1beta1 # This is required.
-kind: Policy
-omitStages:
-  - RequestReceived
-rules:
-  - level: RequestResponse
-    resources:
-    - group: ""
-      resources: ["pods"]
-  - level: Metadata
-    resources:
-    - group: ""
-      resources: ["pods/log", "pods/status"]
-  - level: None
-    users: ["system:kube-proxy"]
-    verbs: ["watch"]
-    resources:
-    - group: ""
-      resources: ["endpoints", "services"]
-  - level: None
-    userGroups: ["system:authenticated"]
-    nonResourceURLs:
-    - /api*
-    - /version
-  - level: Request
-    resources:
-    - group: ""
-      resources: ["configmaps"]
-    namespaces: ["kube-system"]
-  - level: Request
-    resources:
-    - group: ""
-      resources: ["secrets"]
-  - level: Request
-    resources:
-    - group: ""
-    - group: extensions
-  - level: Metadata
-    omitStages:
-      - RequestReceived
-`)
-
-func k8sAddonsKubernetesmasterAuditPolicyYamlBytes() ([]byte, error) {
-	return _k8sAddonsKubernetesmasterAuditPolicyYaml, nil
-}
-
-func k8sAddonsKubernetesmasterAuditPolicyYaml() (*asset, error) {
-	bytes, err := k8sAddonsKubernetesmasterAuditPolicyYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/addons/kubernetesmaster-audit-policy.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sAddonsKubernetesmasteraddonsAadDefaultAdminGroupRbacYaml = []byte(`kind: ClusterRoleBinding
-apiVersion: rbac.authorization.k8s.io/v1
+var _k8sAddonsKubernetesmasteraddonsFlannelDaemonsetYaml = []byte(`# This file was pulled from:
+# https://github.com/coreos/flannel (HEAD at time of pull was 4973e02e539378)
+---
+apiVersion: v1
+kind: ServiceAccount
 metadata:
-  name: aad-default-admin-group
+  name: flannel
+  namespace: kube-system
   labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
-subjects:
-- kind: Group
-  name: <gID>
-  apiGroup: rbac.authorization.k8s.io
-roleRef:
-  kind: ClusterRole
-  name: cluster-admin
-  apiGroup: rbac.authorization.k8s.io
-`)
-
-func k8sAddonsKubernetesmasteraddonsAadDefaultAdminGroupRbacYamlBytes() ([]byte, error) {
-	return _k8sAddonsKubernetesmasteraddonsAadDefaultAdminGroupRbacYaml, nil
-}
-
-func k8sAddonsKubernetesmasteraddonsAadDefaultAdminGroupRbacYaml() (*asset, error) {
-	bytes, err := k8sAddonsKubernetesmasteraddonsAadDefaultAdminGroupRbacYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-aad-default-admin-group-rbac.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sAddonsKubernetesmasteraddonsAzureCloudProviderDeploymentYaml = []byte(`---
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: ClusterRole
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+kind: ConfigMap
+apiVersion: v1
 metadata:
+  name: kube-flannel-cfg
+  namespace: kube-system
   labels:
-    kubernetes.io/cluster-service: "true"
-  name: system:azure-cloud-provider
-rules:
-- apiGroups: [""]
-  resources: ["events"]
-  verbs:
-  - create
-  - patch
-  - update
+    tier: node
+    app: flannel
+    addonmanager.kubernetes.io/mode: EnsureExists
+data:
+  cni-conf.json: |
+    {
+      "name": "cbr0",
+      "type": "flannel",
+      "delegate": {
+        "isDefaultGateway": true
+      }
+    }
+  net-conf.json: |
+    {
+      "Network": "<kubeClusterCidr>",
+      "Backend": {
+        "Type": "vxlan"
+      }
+    }
 ---
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: ClusterRoleBinding
+apiVersion: extensions/v1beta1
+kind: DaemonSet
 metadata:
-  labels:
-    kubernetes.io/cluster-service: "true"
-  name: system:azure-cloud-provider
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: system:azure-cloud-provider
-subjects:
-- kind: ServiceAccount
-  name: azure-cloud-provider
+  name: kube-flannel-ds
   namespace: kube-system
+  labels:
+    tier: node
+    app: flannel
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  template:
+    metadata:
+      labels:
+        tier: node
+        app: flannel
+      annotations:
+        scheduler.alpha.kubernetes.io/critical-pod: ''
+    spec:
+      hostNetwork: true
+      nodeSelector:
+        beta.kubernetes.io/arch: amd64
+        beta.kubernetes.io/os: linux
+      priorityClassName: system-node-critical
+      tolerations:
+        - key: node.kubernetes.io/not-ready
+          operator: Exists
+          effect: NoSchedule
+        - key: node-role.kubernetes.io/master
+          operator: Equal
+          value: "true"
+          effect: NoSchedule
+        - key: CriticalAddonsOnly
+          operator: Exists
+      serviceAccountName: flannel
+      containers:
+      - name: kube-flannel
+        image: quay.io/coreos/flannel:v0.8.0-amd64
+        command: [ "/opt/bin/flanneld", "--ip-masq", "--kube-subnet-mgr" ]
+        securityContext:
+          privileged: true
+        env:
+        - name: POD_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.name
+        - name: POD_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+        volumeMounts:
+        - name: run
+          mountPath: /run
+        - name: flannel-cfg
+          mountPath: /etc/kube-flannel/
+      - name: install-cni
+        image: quay.io/coreos/flannel:v0.10.0-amd64
+        command: [ "/bin/sh", "-c", "set -e -x; cp -f /etc/kube-flannel/cni-conf.json /etc/cni/net.d/10-flannel.conf; while true; do sleep 3600; done" ]
+        volumeMounts:
+        - name: cni
+          mountPath: /etc/cni/net.d
+        - name: flannel-cfg
+          mountPath: /etc/kube-flannel/
+      volumes:
+        - name: run
+          hostPath:
+            path: /run
+        - name: cni
+          hostPath:
+            path: /etc/cni/net.d
+        - name: flannel-cfg
+          configMap:
+            name: kube-flannel-cfg
+---
+# This file was pulled from:
+# https://github.com/coreos/flannel (HEAD at time of pull was 4973e02e539378)
 ---
-apiVersion: rbac.authorization.k8s.io/v1beta1
 kind: ClusterRole
+apiVersion: rbac.authorization.k8s.io/v1beta1
 metadata:
-  name: system:azure-persistent-volume-binder
+  name: flannel
   labels:
-    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
 rules:
-- apiGroups: ['']
-  resources: ['secrets']
-  verbs:     ['get','create']
+  - apiGroups:
+      - ""
+    resources:
+      - pods
+    verbs:
+      - get
+  - apiGroups:
+      - ""
+    resources:
+      - nodes
+    verbs:
+      - list
+      - watch
+  - apiGroups:
+      - ""
+    resources:
+      - nodes/status
+    verbs:
+      - patch
 ---
-apiVersion: rbac.authorization.k8s.io/v1beta1
 kind: ClusterRoleBinding
+apiVersion: rbac.authorization.k8s.io/v1beta1
 metadata:
-  name: system:azure-persistent-volume-binder
+  name: flannel
   labels:
-    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
 roleRef:
-  kind: ClusterRole
   apiGroup: rbac.authorization.k8s.io
-  name: system:azure-persistent-volume-binder
+  kind: ClusterRole
+  name: flannel
 subjects:
 - kind: ServiceAccount
-  name: persistent-volume-binder
+  name: flannel
   namespace: kube-system
 `)
 
-func k8sAddonsKubernetesmasteraddonsAzureCloudProviderDeploymentYamlBytes() ([]byte, error) {
-	return _k8sAddonsKubernetesmasteraddonsAzureCloudProviderDeploymentYaml, nil
+func k8sAddonsKubernetesmasteraddonsFlannelDaemonsetYamlBytes() ([]byte, error) {
+	return _k8sAddonsKubernetesmasteraddonsFlannelDaemonsetYaml, nil
 }
 
-func k8sAddonsKubernetesmasteraddonsAzureCloudProviderDeploymentYaml() (*asset, error) {
-	bytes, err := k8sAddonsKubernetesmasteraddonsAzureCloudProviderDeploymentYamlBytes()
+func k8sAddonsKubernetesmasteraddonsFlannelDaemonsetYaml() (*asset, error) {
+	bytes, err := k8sAddonsKubernetesmasteraddonsFlannelDaemonsetYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-azure-cloud-provider-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-flannel-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sAddonsKubernetesmasteraddonsCiliumDaemonsetYaml = []byte(`---
+var _k8sAddonsKubernetesmasteraddonsKubeDnsDeploymentYaml = []byte(`# Copyright 2016 The Kubernetes Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Should keep target in cluster/addons/dns-horizontal-autoscaler/dns-horizontal-autoscaler.yaml
+# in sync with this file.
+
 apiVersion: v1
-kind: ConfigMap
+kind: Service
 metadata:
-  name: cilium-config
+  name: kube-dns
   namespace: kube-system
   labels:
-    addonmanager.kubernetes.io/mode: "EnsureExists"
-data:
-  etcd-config: |-
-    ---
-    endpoints:
-      - https://cilium-etcd-client.kube-system.svc:2379
-    ca-file: '/var/lib/etcd-secrets/etcd-client-ca.crt'
-    key-file: '/var/lib/etcd-secrets/etcd-client.key'
-    cert-file: '/var/lib/etcd-secrets/etcd-client.crt'
-  debug: "false"
-  enable-ipv4: "true"
-  enable-ipv6: "false"
-  clean-cilium-state: "false"
-  clean-cilium-bpf-state: "false"
-  monitor-aggregation-level: "none"
-  ct-global-max-entries-tcp: "524288"
-  ct-global-max-entries-other: "262144"
-  preallocate-bpf-maps: "false"
-  sidecar-istio-proxy-image: "cilium/istio_proxy"
-  tunnel: "vxlan"
-  cluster-name: default
-  flannel-master-device: ""
-  flannel-uninstall-on-exit: "false"
-  flannel-manage-existing-containers: "false"
-  tofqdns-enable-poller: "false"
----
-apiVersion: apps/v1
-kind: DaemonSet
-metadata:
-  labels:
-    k8s-app: cilium
+    k8s-app: kube-dns
     kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: "Reconcile"
-  name: cilium
-  namespace: kube-system
+    addonmanager.kubernetes.io/mode: Reconcile
+    kubernetes.io/name: KubeDNS
 spec:
+  selector:
+    k8s-app: kube-dns
+  clusterIP: <clustIP>
+  ports:
+  - name: dns
+    port: 53
+    protocol: UDP
+  - name: dns-tcp
+    port: 53
+    protocol: TCP
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: kube-dns
+  namespace: kube-system
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kube-dns
+  namespace: kube-system
+  labels:
+    addonmanager.kubernetes.io/mode: EnsureExists
+---
+apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: kube-dns
+  namespace: kube-system
+  labels:
+    k8s-app: kube-dns
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  # replicas: not specified here:
+  # 1. In order to make Addon Manager do not reconcile this replicas parameter.
+  # 2. Default is 1.
+  # 3. Will be tuned in real time if DNS horizontal auto-scaling is turned on.
+  strategy:
+    rollingUpdate:
+      maxSurge: 10%
+      maxUnavailable: 0
   selector:
     matchLabels:
-      k8s-app: cilium
-      kubernetes.io/cluster-service: "true"
+      k8s-app: kube-dns
   template:
     metadata:
-      annotations:
-        prometheus.io/port: "9090"
-        prometheus.io/scrape: "true"
-        scheduler.alpha.kubernetes.io/critical-pod: ""
-        scheduler.alpha.kubernetes.io/tolerations: '[{"key":"dedicated","operator":"Equal","value":"master","effect":"NoSchedule"}]'
       labels:
-        k8s-app: cilium
-        kubernetes.io/cluster-service: "true"
+        k8s-app: kube-dns
+      annotations:
+        scheduler.alpha.kubernetes.io/critical-pod: ''
+        seccomp.security.alpha.kubernetes.io/pod: 'docker/default'
     spec:
+      priorityClassName: system-node-critical
+      tolerations:
+      - key: CriticalAddonsOnly
+        operator: Exists
+      volumes:
+      - name: kube-dns-config
+        configMap:
+          name: kube-dns
+          optional: true
       containers:
-      - args:
-        - --debug=$(CILIUM_DEBUG)
-        - --kvstore=etcd
-        - --kvstore-opt=etcd.config=/var/lib/etcd-config/etcd.config
-        command:
-        - cilium-agent
-        env:
-        - name: K8S_NODE_NAME
-          valueFrom:
-            fieldRef:
-              apiVersion: v1
-              fieldPath: spec.nodeName
-        - name: CILIUM_K8S_NAMESPACE
-          valueFrom:
-            fieldRef:
-              apiVersion: v1
-              fieldPath: metadata.namespace
-        - name: CILIUM_DEBUG
-          valueFrom:
-            configMapKeyRef:
-              key: debug
-              name: cilium-config
-        - name: CILIUM_ENABLE_IPV4
-          valueFrom:
-            configMapKeyRef:
-              key: enable-ipv4
-              name: cilium-config
-              optional: true
-        - name: CILIUM_ENABLE_IPV6
-          valueFrom:
-            configMapKeyRef:
-              key: enable-ipv6
-              name: cilium-config
-              optional: true
-        - name: CILIUM_PROMETHEUS_SERVE_ADDR
-          valueFrom:
-            configMapKeyRef:
-              key: prometheus-serve-addr
-              name: cilium-metrics-config
-              optional: true
-        - name: CILIUM_LEGACY_HOST_ALLOWS_WORLD
-          valueFrom:
-            configMapKeyRef:
-              key: legacy-host-allows-world
-              name: cilium-config
-              optional: true
-        - name: CILIUM_SIDECAR_ISTIO_PROXY_IMAGE
-          valueFrom:
-            configMapKeyRef:
-              key: sidecar-istio-proxy-image
-              name: cilium-config
-              optional: true
-        - name: CILIUM_TUNNEL
-          valueFrom:
-            configMapKeyRef:
-              key: tunnel
-              name: cilium-config
-              optional: true
-        - name: CILIUM_MONITOR_AGGREGATION_LEVEL
-          valueFrom:
-            configMapKeyRef:
-              key: monitor-aggregation-level
-              name: cilium-config
-              optional: true
-        - name: CILIUM_CLUSTERMESH_CONFIG
-          value: /var/lib/cilium/clustermesh/
-        - name: CILIUM_CLUSTER_NAME
-          valueFrom:
-            configMapKeyRef:
-              key: cluster-name
-              name: cilium-config
-              optional: true
-        - name: CILIUM_CLUSTER_ID
-          valueFrom:
-            configMapKeyRef:
-              key: cluster-id
-              name: cilium-config
-              optional: true
-        - name: CILIUM_GLOBAL_CT_MAX_TCP
-          valueFrom:
-            configMapKeyRef:
-              key: ct-global-max-entries-tcp
-              name: cilium-config
-              optional: true
-        - name: CILIUM_GLOBAL_CT_MAX_ANY
-          valueFrom:
-            configMapKeyRef:
-              key: ct-global-max-entries-other
-              name: cilium-config
-              optional: true
-        - name: CILIUM_PREALLOCATE_BPF_MAPS
-          valueFrom:
-            configMapKeyRef:
-              key: preallocate-bpf-maps
-              name: cilium-config
-              optional: true
-        - name: CILIUM_FLANNEL_MASTER_DEVICE
-          valueFrom:
-            configMapKeyRef:
-              key: flannel-master-device
-              name: cilium-config
-              optional: true
-        - name: CILIUM_FLANNEL_UNINSTALL_ON_EXIT
-          valueFrom:
-            configMapKeyRef:
-              key: flannel-uninstall-on-exit
-              name: cilium-config
-              optional: true
-        - name: CILIUM_FLANNEL_MANAGE_EXISTING_CONTAINERS
-          valueFrom:
-            configMapKeyRef:
-              key: flannel-manage-existing-containers
-              name: cilium-config
-              optional: true
-        - name: CILIUM_DATAPATH_MODE
-          valueFrom:
-            configMapKeyRef:
-              key: datapath-mode
-              name: cilium-config
-              optional: true
-        - name: CILIUM_IPVLAN_MASTER_DEVICE
-          valueFrom:
-            configMapKeyRef:
-              key: ipvlan-master-device
-              name: cilium-config
-              optional: true
-        - name: CILIUM_INSTALL_IPTABLES_RULES
-          valueFrom:
-            configMapKeyRef:
-              key: install-iptables-rules
-              name: cilium-config
-              optional: true
-        - name: CILIUM_MASQUERADE
-          valueFrom:
-            configMapKeyRef:
-              key: masquerade
-              name: cilium-config
-              optional: true
-        - name: CILIUM_AUTO_DIRECT_NODE_ROUTES
-          valueFrom:
-            configMapKeyRef:
-              key: auto-direct-node-routes
-              name: cilium-config
-              optional: true
-        - name: CILIUM_TOFQDNS_ENABLE_POLLER
-          valueFrom:
-            configMapKeyRef:
-              key: tofqdns-enable-poller
-              name: cilium-config
-              optional: true
-        image: docker.io/cilium/cilium:v1.4
-        imagePullPolicy: Always
-        lifecycle:
-          postStart:
-            exec:
-              command:
-              - /cni-install.sh
-          preStop:
-            exec:
-              command:
-              - /cni-uninstall.sh
+      - name: kubedns
+        image: <img>
+        imagePullPolicy: IfNotPresent
+        resources:
+          # TODO: Set memory limits when we've profiled the container for large
+          # clusters, then set request = limit to keep this container in
+          # guaranteed class. Currently, this container falls into the
+          # "burstable" category so the kubelet doesn't backoff from restarting it.
+          limits:
+            memory: 170Mi
+          requests:
+            cpu: 100m
+            memory: 70Mi
         livenessProbe:
-          exec:
-            command:
-            - cilium
-            - status
-          failureThreshold: 10
-          initialDelaySeconds: 120
-          periodSeconds: 10
+          httpGet:
+            path: /healthcheck/kubedns
+            port: 10054
+            scheme: HTTP
+          initialDelaySeconds: 60
+          timeoutSeconds: 5
           successThreshold: 1
-          timeoutSeconds: 1
-        name: cilium-agent
+          failureThreshold: 5
+        readinessProbe:
+          httpGet:
+            path: /readiness
+            port: 8081
+            scheme: HTTP
+          initialDelaySeconds: 3
+          timeoutSeconds: 5
+        args:
+        - --domain=<domain>.
+        - --dns-port=10053
+        - --config-dir=/kube-dns-config
+        - --v=2
+        env:
+        - name: PROMETHEUS_PORT
+          value: "10055"
         ports:
-        - containerPort: 9090
-          hostPort: 9090
-          name: prometheus
+        - containerPort: 10053
+          name: dns-local
+          protocol: UDP
+        - containerPort: 10053
+          name: dns-tcp-local
+          protocol: TCP
+        - containerPort: 10055
+          name: metrics
           protocol: TCP
-        readinessProbe:
-          exec:
-            command:
-            - cilium
-            - status
-          failureThreshold: 3
-          initialDelaySeconds: 5
-          periodSeconds: 5
-          successThreshold: 1
-          timeoutSeconds: 1
-        securityContext:
-          capabilities:
-            add:
-            - NET_ADMIN
-          privileged: true
         volumeMounts:
-        - mountPath: /sys/fs/bpf
-          name: bpf-maps
-        - mountPath: /var/run/cilium
-          name: cilium-run
-        - mountPath: /host/opt/cni/bin
-          name: cni-path
-        - mountPath: /host/etc/cni/net.d
-          name: etc-cni-netd
-        - mountPath: /var/run/docker.sock
-          name: docker-socket
-          readOnly: true
-        - mountPath: /var/lib/etcd-config
-          name: etcd-config-path
-          readOnly: true
-        - mountPath: /var/lib/etcd-secrets
-          name: etcd-secrets
-          readOnly: true
-        - mountPath: /var/lib/cilium/clustermesh
-          name: clustermesh-secrets
-          readOnly: true
-      dnsPolicy: ClusterFirstWithHostNet
-      hostNetwork: true
-      hostPID: false
-      initContainers:
-      - command:
-        - /init-container.sh
-        env:
-        - name: CLEAN_CILIUM_STATE
-          valueFrom:
-            configMapKeyRef:
-              key: clean-cilium-state
-              name: cilium-config
-              optional: true
-        - name: CLEAN_CILIUM_BPF_STATE
-          valueFrom:
-            configMapKeyRef:
-              key: clean-cilium-bpf-state
-              name: cilium-config
-              optional: true
-        image: docker.io/cilium/cilium-init:2018-10-16
+        - name: kube-dns-config
+          mountPath: /kube-dns-config
+      - name: dnsmasq
+        image: <imgMasq>
         imagePullPolicy: IfNotPresent
-        name: clean-cilium-state
-        securityContext:
-          capabilities:
-            add:
-            - NET_ADMIN
-          privileged: true
+        livenessProbe:
+          httpGet:
+            path: /healthcheck/dnsmasq
+            port: 10054
+            scheme: HTTP
+          initialDelaySeconds: 60
+          timeoutSeconds: 5
+          successThreshold: 1
+          failureThreshold: 5
+        args:
+        - -v=2
+        - -logtostderr
+        - -configDir=/etc/k8s/dns/dnsmasq-nanny
+        - -restartDnsmasq=true
+        - --
+        - -k
+        - --cache-size=1000
+        - --no-negcache
+        - --log-facility=-
+        - --server=/cluster.local/127.0.0.1#10053
+        - --server=/in-addr.arpa/127.0.0.1#10053
+        - --server=/ip6.arpa/127.0.0.1#10053
+        ports:
+        - containerPort: 53
+          name: dns
+          protocol: UDP
+        - containerPort: 53
+          name: dns-tcp
+          protocol: TCP
+        resources:
+          requests:
+            cpu: 150m
+            memory: 20Mi
         volumeMounts:
-        - mountPath: /sys/fs/bpf
-          name: bpf-maps
-        - mountPath: /var/run/cilium
-          name: cilium-run
-      priorityClassName: system-node-critical
-      restartPolicy: Always
-      serviceAccount: cilium
-      serviceAccountName: cilium
-      terminationGracePeriodSeconds: 1
-      tolerations:
-      - operator: Exists
-      - effect: NoSchedule
-        key: node.kubernetes.io/not-ready
-        operator: Exists
-      volumes:
-      - hostPath:
-          path: /var/run/cilium
-          type: DirectoryOrCreate
-        name: cilium-run
-      - hostPath:
-          path: /sys/fs/bpf
-          type: DirectoryOrCreate
-        name: bpf-maps
-      - hostPath:
-          path: /var/run/docker.sock
-          type: Socket
-        name: docker-socket
-      - hostPath:
-          path: /opt/cni/bin
-          type: DirectoryOrCreate
-        name: cni-path
-      - hostPath:
-          path: /etc/cni/net.d
-          type: DirectoryOrCreate
-        name: etc-cni-netd
-      - configMap:
-          defaultMode: 420
-          items:
-          - key: etcd-config
-            path: etcd.config
-          name: cilium-config
-        name: etcd-config-path
-      - name: etcd-secrets
-        secret:
-          defaultMode: 420
-          optional: true
-          secretName: cilium-etcd-secrets
-      - name: clustermesh-secrets
-        secret:
-          defaultMode: 420
-          optional: true
-          secretName: cilium-clustermesh
-  updateStrategy:
-    rollingUpdate:
-      maxUnavailable: 2
-    type: RollingUpdate
----
-apiVersion: apps/v1
-kind: Deployment
+        - name: kube-dns-config
+          mountPath: /etc/k8s/dns/dnsmasq-nanny
+      - name: sidecar
+        image: <imgSidecar>
+        imagePullPolicy: IfNotPresent
+        livenessProbe:
+          httpGet:
+            path: /metrics
+            port: 10054
+            scheme: HTTP
+          initialDelaySeconds: 60
+          timeoutSeconds: 5
+          successThreshold: 1
+          failureThreshold: 5
+        args:
+        - --v=2
+        - --logtostderr
+        - --probe=kubedns,127.0.0.1:10053,kubernetes.default.svc.<domain>,5,SRV
+        - --probe=dnsmasq,127.0.0.1:53,kubernetes.default.svc.<domain>,5,SRV
+        ports:
+        - containerPort: 10054
+          name: metrics
+          protocol: TCP
+        resources:
+          requests:
+            memory: 20Mi
+            cpu: 10m
+      dnsPolicy: Default
+      serviceAccountName: kube-dns
+      nodeSelector:
+        beta.kubernetes.io/os: linux`)
+
+func k8sAddonsKubernetesmasteraddonsKubeDnsDeploymentYamlBytes() ([]byte, error) {
+	return _k8sAddonsKubernetesmasteraddonsKubeDnsDeploymentYaml, nil
+}
+
+func k8sAddonsKubernetesmasteraddonsKubeDnsDeploymentYaml() (*asset, error) {
+	bytes, err := k8sAddonsKubernetesmasteraddonsKubeDnsDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-kube-dns-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sAddonsKubernetesmasteraddonsKubeProxyDaemonsetYaml = []byte(`apiVersion: extensions/v1beta1
+kind: DaemonSet
 metadata:
   labels:
-    io.cilium/app: operator
-    name: cilium-operator
-  name: cilium-operator
+    addonmanager.kubernetes.io/mode: Reconcile
+    kubernetes.io/cluster-service: "true"
+    component: kube-proxy
+    tier: node
+    k8s-app: kube-proxy
+  name: kube-proxy
   namespace: kube-system
-  labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
 spec:
-  replicas: 1
   selector:
     matchLabels:
-      io.cilium/app: operator
-      name: cilium-operator
-  strategy:
-    rollingUpdate:
-      maxSurge: 1
-      maxUnavailable: 1
+      k8s-app: kube-proxy
+  updateStrategy:
     type: RollingUpdate
+    rollingUpdate:
+      maxUnavailable: 50%
   template:
     metadata:
       labels:
-        io.cilium/app: operator
-        name: cilium-operator
+        component: kube-proxy
+        tier: node
+        k8s-app: kube-proxy
+      annotations:
+        scheduler.alpha.kubernetes.io/critical-pod: ''
     spec:
+      priorityClassName: system-node-critical
+      tolerations:
+      - key: node-role.kubernetes.io/master
+        operator: Equal
+        value: "true"
+        effect: NoSchedule
+      - operator: "Exists"
+        effect: NoExecute
+      - operator: "Exists"
+        effect: NoSchedule
+      - key: CriticalAddonsOnly
+        operator: Exists
       containers:
-      - args:
-        - --debug=$(CILIUM_DEBUG)
-        - --kvstore=etcd
-        - --kvstore-opt=etcd.config=/var/lib/etcd-config/etcd.config
-        command:
-        - cilium-operator
-        env:
-        - name: POD_NAMESPACE
-          valueFrom:
-            fieldRef:
-              apiVersion: v1
-              fieldPath: metadata.namespace
-        - name: K8S_NODE_NAME
-          valueFrom:
-            fieldRef:
-              apiVersion: v1
-              fieldPath: spec.nodeName
-        - name: CILIUM_DEBUG
-          valueFrom:
-            configMapKeyRef:
-              key: debug
-              name: cilium-config
-              optional: true
-        - name: CILIUM_CLUSTER_NAME
-          valueFrom:
-            configMapKeyRef:
-              key: cluster-name
-              name: cilium-config
-              optional: true
-        - name: CILIUM_CLUSTER_ID
-          valueFrom:
-            configMapKeyRef:
-              key: cluster-id
-              name: cilium-config
-              optional: true
-        - name: CILIUM_DISABLE_ENDPOINT_CRD
-          valueFrom:
-            configMapKeyRef:
-              key: disable-endpoint-crd
-              name: cilium-config
-              optional: true
-        - name: AWS_ACCESS_KEY_ID
-          valueFrom:
-            secretKeyRef:
-              key: AWS_ACCESS_KEY_ID
-              name: cilium-aws
-              optional: true
-        - name: AWS_SECRET_ACCESS_KEY
-          valueFrom:
-            secretKeyRef:
-              key: AWS_SECRET_ACCESS_KEY
-              name: cilium-aws
-              optional: true
-        - name: AWS_DEFAULT_REGION
-          valueFrom:
-            secretKeyRef:
-              key: AWS_DEFAULT_REGION
-              name: cilium-aws
-              optional: true
-        image: docker.io/cilium/operator:v1.4
-        imagePullPolicy: Always
-        name: cilium-operator
+      - command:
+        - /hyperkube
+        - kube-proxy
+        - --kubeconfig=/var/lib/kubelet/kubeconfig
+        - --cluster-cidr=<CIDR>
+        - --feature-gates=ExperimentalCriticalPodAnnotation=true
+        - --proxy-mode=<kubeProxyMode>
+        image: <img>
+        imagePullPolicy: IfNotPresent
+        name: kube-proxy
+        resources:
+          requests:
+            cpu: 100m
+            memory: 50Mi
+        securityContext:
+          privileged: true
         volumeMounts:
-        - mountPath: /var/lib/etcd-config
-          name: etcd-config-path
+        - mountPath: /etc/ssl/certs
+          name: ssl-certs-host
           readOnly: true
-        - mountPath: /var/lib/etcd-secrets
-          name: etcd-secrets
+        - mountPath: /etc/kubernetes
+          name: etc-kubernetes
           readOnly: true
-      dnsPolicy: ClusterFirst
-      priorityClassName: system-node-critical
-      restartPolicy: Always
-      serviceAccount: cilium-operator
-      serviceAccountName: cilium-operator
-      volumes:
-      - configMap:
-          defaultMode: 420
-          items:
-          - key: etcd-config
-            path: etcd.config
-          name: cilium-config
-        name: etcd-config-path
-      - name: etcd-secrets
-        secret:
-          defaultMode: 420
-          optional: true
-          secretName: cilium-etcd-secrets
----
-apiVersion: v1
-kind: ServiceAccount
+        - mountPath: /var/lib/kubelet/kubeconfig
+          name: kubeconfig
+          readOnly: true
+        - mountPath: /run/xtables.lock
+          name: iptableslock
+        - mountPath: /lib/modules/
+          name: kernelmodules
+          readOnly: true
+      hostNetwork: true
+      volumes:
+      - hostPath:
+          path: /usr/share/ca-certificates
+        name: ssl-certs-host
+      - hostPath:
+          path: /var/lib/kubelet/kubeconfig
+        name: kubeconfig
+      - hostPath:
+          path: /etc/kubernetes
+        name: etc-kubernetes
+      - hostPath:
+          path: /run/xtables.lock
+        name: iptableslock
+      - hostPath:
+          path: /lib/modules/
+        name: kernelmodules
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+`)
+
+func k8sAddonsKubernetesmasteraddonsKubeProxyDaemonsetYamlBytes() ([]byte, error) {
+	return _k8sAddonsKubernetesmasteraddonsKubeProxyDaemonsetYaml, nil
+}
+
+func k8sAddonsKubernetesmasteraddonsKubeProxyDaemonsetYaml() (*asset, error) {
+	bytes, err := k8sAddonsKubernetesmasteraddonsKubeProxyDaemonsetYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-kube-proxy-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesCustomYaml = []byte(`apiVersion: storage.k8s.io/v1beta1
+kind: StorageClass
 metadata:
-  name: cilium-operator
-  namespace: kube-system
+  name: default
+  annotations:
+    storageclass.beta.kubernetes.io/is-default-class: "true"
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    kubernetes.io/cluster-service: "true"
+provisioner: kubernetes.io/azure-disk
+parameters:
+  kind: Managed
+  storageaccounttype: Standard_LRS
+  cachingmode: ReadOnly
 ---
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
+apiVersion: storage.k8s.io/v1beta1
+kind: StorageClass
 metadata:
-  name: cilium-operator
+  name: managed-premium
+  annotations:
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-rules:
-- apiGroups:
-  - ""
-  resources:
-  - pods
-  - deployments
-  - componentstatuses
-  verbs:
-  - '*'
-- apiGroups:
-  - ""
-  resources:
-  - services
-  - endpoints
-  verbs:
-  - get
-  - list
-  - watch
-- apiGroups:
-  - cilium.io
-  resources:
-  - ciliumnetworkpolicies
-  - ciliumnetworkpolicies/status
-  - ciliumendpoints
-  - ciliumendpoints/status
-  verbs:
-  - '*'
+    kubernetes.io/cluster-service: "true"
+provisioner: kubernetes.io/azure-disk
+parameters:
+  kind: Managed
+  storageaccounttype: Premium_LRS
+  cachingmode: ReadOnly
 ---
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
+apiVersion: storage.k8s.io/v1beta1
+kind: StorageClass
 metadata:
-  name: cilium-operator
+  name: managed-standard
+  annotations:
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: cilium-operator
-subjects:
-- kind: ServiceAccount
-  name: cilium-operator
-  namespace: kube-system
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
+    kubernetes.io/cluster-service: "true"
+provisioner: kubernetes.io/azure-disk
+parameters:
+  kind: Managed
+  storageaccounttype: Standard_LRS
+  cachingmode: ReadOnly
+`)
+
+func k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesCustomYamlBytes() ([]byte, error) {
+	return _k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesCustomYaml, nil
+}
+
+func k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesCustomYaml() (*asset, error) {
+	bytes, err := k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesCustomYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-managed-azure-storage-classes-custom.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesYaml = []byte(`apiVersion: storage.k8s.io/v1beta1
+kind: StorageClass
 metadata:
-  name: cilium-etcd-operator
+  name: default
+  annotations:
+    storageclass.beta.kubernetes.io/is-default-class: "true"
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-rules:
-- apiGroups:
-  - etcd.database.coreos.com
-  resources:
-  - etcdclusters
-  verbs:
-  - get
-  - delete
-  - create
-- apiGroups:
-  - apiextensions.k8s.io
-  resources:
-  - customresourcedefinitions
-  verbs:
-  - delete
-  - get
-  - create
-- apiGroups:
-  - ""
-  resources:
-  - deployments
-  verbs:
-  - delete
-  - create
-  - get
-  - update
-- apiGroups:
-  - ""
-  resources:
-  - pods
-  verbs:
-  - list
-  - delete
-  - get
-- apiGroups:
-  - apps
-  resources:
-  - deployments
-  verbs:
-  - delete
-  - create
-  - get
-  - update
-- apiGroups:
-  - ""
-  resources:
-  - componentstatuses
-  verbs:
-  - get
-- apiGroups:
-  - extensions
-  resources:
-  - deployments
-  verbs:
-  - delete
-  - create
-  - get
-  - update
-- apiGroups:
-  - ""
-  resources:
-  - secrets
-  verbs:
-  - get
-  - create
-  - delete
+    kubernetes.io/cluster-service: "true"
+provisioner: kubernetes.io/azure-disk
+parameters:
+  kind: Managed
+  storageaccounttype: Standard_LRS
+  cachingmode: ReadOnly
 ---
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
+apiVersion: storage.k8s.io/v1beta1
+kind: StorageClass
 metadata:
+  name: managed-premium
+  annotations:
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-  name: cilium-etcd-operator
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: cilium-etcd-operator
-subjects:
-- kind: ServiceAccount
-  name: cilium-etcd-operator
-  namespace: kube-system
+    kubernetes.io/cluster-service: "true"
+provisioner: kubernetes.io/azure-disk
+parameters:
+  kind: Managed
+  storageaccounttype: Premium_LRS
+  cachingmode: ReadOnly
 ---
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
+apiVersion: storage.k8s.io/v1beta1
+kind: StorageClass
 metadata:
-  name: etcd-operator
+  name: managed-standard
+  annotations:
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-rules:
-- apiGroups:
-  - etcd.database.coreos.com
-  resources:
-  - etcdclusters
-  - etcdbackups
-  - etcdrestores
-  verbs:
-  - '*'
-- apiGroups:
-  - apiextensions.k8s.io
-  resources:
-  - customresourcedefinitions
-  verbs:
-  - '*'
-- apiGroups:
-  - ""
-  resources:
-  - pods
-  - services
-  - endpoints
-  - persistentvolumeclaims
-  - events
-  - deployments
-  verbs:
-  - '*'
-- apiGroups:
-  - apps
-  resources:
-  - deployments
-  verbs:
-  - '*'
-- apiGroups:
-  - extensions
-  resources:
-  - deployments
-  verbs:
-  - create
-  - get
-  - list
-  - patch
-  - update
-- apiGroups:
-  - ""
-  resources:
-  - secrets
-  verbs:
-  - get
+    kubernetes.io/cluster-service: "true"
+provisioner: kubernetes.io/azure-disk
+parameters:
+  kind: Managed
+  storageaccounttype: Standard_LRS
+  cachingmode: ReadOnly
 ---
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
+kind: StorageClass
+apiVersion: storage.k8s.io/v1
 metadata:
-  name: etcd-operator
+  name: azurefile
+  annotations:
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: etcd-operator
-subjects:
-- kind: ServiceAccount
-  name: cilium-etcd-sa
-  namespace: kube-system
+    kubernetes.io/cluster-service: "true"
+provisioner: kubernetes.io/azure-file
+parameters:
+  skuName: Standard_LRS
+`)
+
+func k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesYamlBytes() ([]byte, error) {
+	return _k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesYaml, nil
+}
+
+func k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesYaml() (*asset, error) {
+	bytes, err := k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-managed-azure-storage-classes.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sAddonsKubernetesmasteraddonsPodSecurityPolicyYaml = []byte(`apiVersion: extensions/v1beta1
+kind: PodSecurityPolicy
+metadata:
+  name: privileged
+  annotations:
+    seccomp.security.alpha.kubernetes.io/allowedProfileNames: "*"
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  privileged: true
+  allowPrivilegeEscalation: true
+  allowedCapabilities:
+  - "*"
+  volumes:
+  - "*"
+  hostNetwork: true
+  hostPorts:
+  - min: 0
+    max: 65535
+  hostIPC: true
+  hostPID: true
+  runAsUser:
+    rule: RunAsAny
+  seLinux:
+    rule: RunAsAny
+  supplementalGroups:
+    rule: RunAsAny
+  fsGroup:
+    rule: RunAsAny
 ---
-apiVersion: v1
-kind: ServiceAccount
+apiVersion: extensions/v1beta1
+kind: PodSecurityPolicy
 metadata:
-  name: cilium-etcd-operator
-  namespace: kube-system
+  name: restricted
+  annotations:
+    seccomp.security.alpha.kubernetes.io/allowedProfileNames: docker/default
+    apparmor.security.beta.kubernetes.io/allowedProfileNames: runtime/default
+    seccomp.security.alpha.kubernetes.io/defaultProfileName:  docker/default
+    apparmor.security.beta.kubernetes.io/defaultProfileName:  runtime/default
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  privileged: false
+  allowPrivilegeEscalation: false
+  requiredDropCapabilities:
+    - ALL
+  volumes:
+    - configMap
+    - emptyDir
+    - projected
+    - secret
+    - downwardAPI
+    - persistentVolumeClaim
+  hostNetwork: false
+  hostIPC: false
+  hostPID: false
+  runAsUser:
+    rule: MustRunAsNonRoot
+  seLinux:
+    rule: RunAsAny
+  supplementalGroups:
+    rule: MustRunAs
+    ranges:
+      # Forbid adding the root group.
+      - min: 1
+        max: 65535
+  fsGroup:
+    rule: MustRunAs
+    ranges:
+      # Forbid adding the root group.
+      - min: 1
+        max: 65535
+  readOnlyRootFilesystem: false
 ---
-apiVersion: v1
-kind: ServiceAccount
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
 metadata:
-  name: cilium-etcd-sa
-  namespace: kube-system
+  name: psp:privileged
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups: ['extensions']
+  resources: ['podsecuritypolicies']
+  verbs:     ['use']
+  resourceNames:
+  - privileged
 ---
-apiVersion: apps/v1
-kind: Deployment
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
 metadata:
+  name: psp:restricted
   labels:
-    io.cilium/app: etcd-operator
-    name: cilium-etcd-operator
-    addonmanager.kubernetes.io/mode: "Reconcile"
-  name: cilium-etcd-operator
-  namespace: kube-system
-spec:
-  replicas: 1
-  selector:
-    matchLabels:
-      io.cilium/app: etcd-operator
-      name: cilium-etcd-operator
-  strategy:
-    rollingUpdate:
-      maxSurge: 1
-      maxUnavailable: 1
-    type: RollingUpdate
-  template:
-    metadata:
-      labels:
-        io.cilium/app: etcd-operator
-        name: cilium-etcd-operator
-    spec:
-      containers:
-      - command:
-        - /usr/bin/cilium-etcd-operator
-        env:
-        - name: CILIUM_ETCD_OPERATOR_CLUSTER_DOMAIN
-          value: cluster.local
-        - name: CILIUM_ETCD_OPERATOR_ETCD_CLUSTER_SIZE
-          value: "3"
-        - name: CILIUM_ETCD_OPERATOR_NAMESPACE
-          valueFrom:
-            fieldRef:
-              apiVersion: v1
-              fieldPath: metadata.namespace
-        - name: CILIUM_ETCD_OPERATOR_POD_NAME
-          valueFrom:
-            fieldRef:
-              apiVersion: v1
-              fieldPath: metadata.name
-        - name: CILIUM_ETCD_OPERATOR_POD_UID
-          valueFrom:
-            fieldRef:
-              apiVersion: v1
-              fieldPath: metadata.uid
-        image: docker.io/cilium/cilium-etcd-operator:v2.0.5
-        imagePullPolicy: IfNotPresent
-        name: cilium-etcd-operator
-      dnsPolicy: ClusterFirst
-      hostNetwork: true
-      priorityClassName: system-node-critical
-      restartPolicy: Always
-      serviceAccount: cilium-etcd-operator
-      serviceAccountName: cilium-etcd-operator
-      tolerations:
-      - operator: Exists
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups: ['extensions']
+  resources: ['podsecuritypolicies']
+  verbs:     ['use']
+  resourceNames:
+  - restricted
 ---
 apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRoleBinding
 metadata:
-  name: cilium
+  name: default:restricted
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    addonmanager.kubernetes.io/mode: Reconcile
 roleRef:
   apiGroup: rbac.authorization.k8s.io
   kind: ClusterRole
-  name: cilium
+  name: psp:restricted
 subjects:
-- kind: ServiceAccount
-  name: cilium
-  namespace: kube-system
-- apiGroup: rbac.authorization.k8s.io
-  kind: Group
-  name: system:nodes
+- kind: Group
+  name: system:authenticated
+  apiGroup: rbac.authorization.k8s.io
 ---
 apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
+kind: RoleBinding
 metadata:
-  name: cilium
+  name: default:privileged
+  namespace: kube-system
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-rules:
-- apiGroups:
-  - networking.k8s.io
-  resources:
-  - networkpolicies
-  verbs:
-  - get
-  - list
-  - watch
-- apiGroups:
-  - ""
-  resources:
-  - namespaces
-  - services
-  - nodes
-  - endpoints
-  - componentstatuses
-  verbs:
-  - get
-  - list
-  - watch
-- apiGroups:
-  - ""
-  resources:
-  - pods
-  - nodes
-  verbs:
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: psp:privileged
+subjects:
+- kind: Group
+  name: system:masters
+  apiGroup: rbac.authorization.k8s.io
+- kind: Group
+  name: system:serviceaccounts:kube-system
+  apiGroup: rbac.authorization.k8s.io
+- kind: Group
+  name: system:nodes
+  apiGroup: rbac.authorization.k8s.io`)
+
+func k8sAddonsKubernetesmasteraddonsPodSecurityPolicyYamlBytes() ([]byte, error) {
+	return _k8sAddonsKubernetesmasteraddonsPodSecurityPolicyYaml, nil
+}
+
+func k8sAddonsKubernetesmasteraddonsPodSecurityPolicyYaml() (*asset, error) {
+	bytes, err := k8sAddonsKubernetesmasteraddonsPodSecurityPolicyYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-pod-security-policy.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sAddonsKubernetesmasteraddonsScheduledMaintenanceDeploymentYaml = []byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  labels:
+    control-plane: controller-manager
+    addonmanager.kubernetes.io/mode: Reconcile
+  name: drainsafe-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: drainsafe-leader-election-role
+  namespace: drainsafe-system
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - configmaps
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - patch
+  - delete
+- apiGroups:
+  - ""
+  resources:
+  - configmaps/status
+  verbs:
+  - get
+  - update
+  - patch
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  creationTimestamp: null
+  name: drainsafe-manager-role
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups:
+  - apiextensions.k8s.io
+  resources:
+  - customresourcedefinitions
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - patch
+  - delete
+- apiGroups:
+  - repairman.k8s.io
+  resources:
+  - maintenancerequests
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - patch
+  - delete
+- apiGroups:
+  - ""
+  resources:
+  - nodes
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - patch
+  - delete
+- apiGroups:
+  - apps
+  resources:
+  - daemonsets
+  verbs:
   - get
   - list
   - watch
+  - create
   - update
+  - patch
+  - delete
 - apiGroups:
   - extensions
   resources:
-  - ingresses
+  - daemonsets
   verbs:
+  - get
+  - list
+  - watch
   - create
+  - update
+  - patch
+  - delete
+- apiGroups:
+  - ""
+  resources:
+  - pods
+  verbs:
   - get
   - list
   - watch
+  - create
+  - update
+  - patch
+  - delete
 - apiGroups:
-  - apiextensions.k8s.io
+  - ""
   resources:
-  - customresourcedefinitions
+  - pods/eviction
   verbs:
+  - get
+  - list
+  - watch
   - create
+  - update
+  - patch
+  - delete
+- apiGroups:
+  - ""
+  resources:
+  - events
+  verbs:
   - get
   - list
   - watch
+  - create
   - update
+  - patch
+  - delete
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: drainsafe-proxy-role
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
 - apiGroups:
-  - cilium.io
+  - authentication.k8s.io
   resources:
-  - ciliumnetworkpolicies
-  - ciliumnetworkpolicies/status
-  - ciliumendpoints
-  - ciliumendpoints/status
+  - tokenreviews
+  - subjectaccessreviews
   verbs:
-  - '*'
+  - create
 ---
-apiVersion: v1
-kind: ServiceAccount
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
 metadata:
-  name: cilium
-  namespace: kube-system
+  name: drainsafe-leader-election-rolebinding
+  namespace: drainsafe-system
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"`)
-
-func k8sAddonsKubernetesmasteraddonsCiliumDaemonsetYamlBytes() ([]byte, error) {
-	return _k8sAddonsKubernetesmasteraddonsCiliumDaemonsetYaml, nil
-}
-
-func k8sAddonsKubernetesmasteraddonsCiliumDaemonsetYaml() (*asset, error) {
-	bytes, err := k8sAddonsKubernetesmasteraddonsCiliumDaemonsetYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-cilium-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sAddonsKubernetesmasteraddonsElbSvcYaml = []byte(`---
-apiVersion: v1
-kind: Service
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: drainsafe-leader-election-role
+subjects:
+- kind: ServiceAccount
+  name: default
+  namespace: drainsafe-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
 metadata:
+  name: drainsafe-manager-rolebinding
   labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
-  name: elb
-  namespace: kube-system
-spec:
-  ports:
-  - port: 8765
-    targetPort: 9376
-    protocol: TCP
-  selector:
-    app: "<svcName>"
-  type: LoadBalancer
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: drainsafe-manager-role
+subjects:
+- kind: ServiceAccount
+  name: default
+  namespace: drainsafe-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: drainsafe-proxy-rolebinding
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: drainsafe-proxy-role
+subjects:
+- kind: ServiceAccount
+  name: default
+  namespace: drainsafe-system
 ---
 apiVersion: v1
 kind: Service
 metadata:
+  annotations:
+    prometheus.io/port: "8443"
+    prometheus.io/scheme: https
+    prometheus.io/scrape: "true"
   labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
-  name: elb-udp
-  namespace: kube-system
+    control-plane: controller-manager
+    addonmanager.kubernetes.io/mode: Reconcile
+  name: drainsafe-controller-manager-metrics-service
+  namespace: drainsafe-system
 spec:
   ports:
-  - port: 8765
-    targetPort: 9376
-    protocol: UDP
+  - name: https
+    port: 8443
+    targetPort: https
   selector:
-    app: "<svcName>"
-  type: LoadBalancer`)
-
-func k8sAddonsKubernetesmasteraddonsElbSvcYamlBytes() ([]byte, error) {
-	return _k8sAddonsKubernetesmasteraddonsElbSvcYaml, nil
-}
-
-func k8sAddonsKubernetesmasteraddonsElbSvcYaml() (*asset, error) {
-	bytes, err := k8sAddonsKubernetesmasteraddonsElbSvcYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-elb-svc.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sAddonsKubernetesmasteraddonsFlannelDaemonsetYaml = []byte(`# This file was pulled from:
-# https://github.com/coreos/flannel (HEAD at time of pull was 4973e02e539378)
+    control-plane: controller-manager
 ---
-apiVersion: v1
-kind: ServiceAccount
+apiVersion: apps/v1
+kind: Deployment
 metadata:
-  name: flannel
-  namespace: kube-system
   labels:
+    control-plane: controller-manager
     addonmanager.kubernetes.io/mode: Reconcile
+  name: drainsafe-controller-manager
+  namespace: drainsafe-system
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      control-plane: controller-manager
+  template:
+    metadata:
+      labels:
+        control-plane: controller-manager
+    spec:
+      containers:
+      - args:
+        - --secure-listen-address=0.0.0.0:8443
+        - --upstream=http://127.0.0.1:8080/
+        - --logtostderr=true
+        - --v=10
+        image: gcr.io/kubebuilder/kube-rbac-proxy:v0.4.0
+        name: kube-rbac-proxy
+        ports:
+        - containerPort: 8443
+          name: https
+      - args:
+        - --metrics-addr=127.0.0.1:8080
+        command:
+        - /manager
+        env:
+        - name: POD_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+        - name: POD_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.name
+        - name: NODE_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: spec.nodeName
+        image: quay.io/awesomenix/drainsafe-manager:latest
+        name: manager
+        resources:
+          limits:
+            cpu: 100m
+            memory: 30Mi
+          requests:
+            cpu: 100m
+            memory: 20Mi
+      terminationGracePeriodSeconds: 10
+      tolerations:
+      - effect: NoSchedule
+        key: node-role.kubernetes.io/master
 ---
-kind: ConfigMap
-apiVersion: v1
-metadata:
-  name: kube-flannel-cfg
-  namespace: kube-system
-  labels:
-    tier: node
-    app: flannel
-    addonmanager.kubernetes.io/mode: EnsureExists
-data:
-  cni-conf.json: |
-    {
-      "name": "cbr0",
-      "type": "flannel",
-      "delegate": {
-        "isDefaultGateway": true
-      }
-    }
-  net-conf.json: |
-    {
-      "Network": "<kubeClusterCidr>",
-      "Backend": {
-        "Type": "vxlan"
-      }
-    }
----
-apiVersion: extensions/v1beta1
+apiVersion: apps/v1
 kind: DaemonSet
 metadata:
-  name: kube-flannel-ds
-  namespace: kube-system
   labels:
-    tier: node
-    app: flannel
+    control-plane: controller-manager
     addonmanager.kubernetes.io/mode: Reconcile
+  name: drainsafe-controller-scheduledevent-manager
+  namespace: drainsafe-system
 spec:
+  selector:
+    matchLabels:
+      control-plane: controller-manager
   template:
     metadata:
       labels:
-        tier: node
-        app: flannel
-      annotations:
-        scheduler.alpha.kubernetes.io/critical-pod: ''
+        control-plane: controller-manager
     spec:
-      hostNetwork: true
-      nodeSelector:
-        beta.kubernetes.io/arch: amd64
-        beta.kubernetes.io/os: linux
-      priorityClassName: system-node-critical
-      tolerations:
-        - key: node.kubernetes.io/not-ready
-          operator: Exists
-          effect: NoSchedule
-        - key: node-role.kubernetes.io/master
-          operator: Equal
-          value: "true"
-          effect: NoSchedule
-        - key: CriticalAddonsOnly
-          operator: Exists
-      serviceAccountName: flannel
       containers:
-      - name: kube-flannel
-        image: quay.io/coreos/flannel:v0.8.0-amd64
-        command: [ "/opt/bin/flanneld", "--ip-masq", "--kube-subnet-mgr" ]
-        securityContext:
-          privileged: true
+      - command:
+        - /scheduledevent-manager
         env:
+        - name: POD_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
         - name: POD_NAME
           valueFrom:
             fieldRef:
               fieldPath: metadata.name
-        - name: POD_NAMESPACE
+        - name: NODE_NAME
           valueFrom:
             fieldRef:
-              fieldPath: metadata.namespace
-        volumeMounts:
-        - name: run
-          mountPath: /run
-        - name: flannel-cfg
-          mountPath: /etc/kube-flannel/
-      - name: install-cni
-        image: quay.io/coreos/flannel:v0.10.0-amd64
-        command: [ "/bin/sh", "-c", "set -e -x; cp -f /etc/kube-flannel/cni-conf.json /etc/cni/net.d/10-flannel.conf; while true; do sleep 3600; done" ]
-        volumeMounts:
-        - name: cni
-          mountPath: /etc/cni/net.d
-        - name: flannel-cfg
-          mountPath: /etc/kube-flannel/
-      volumes:
-        - name: run
-          hostPath:
-            path: /run
-        - name: cni
-          hostPath:
-            path: /etc/cni/net.d
-        - name: flannel-cfg
-          configMap:
-            name: kube-flannel-cfg
----
-# This file was pulled from:
-# https://github.com/coreos/flannel (HEAD at time of pull was 4973e02e539378)
----
-kind: ClusterRole
-apiVersion: rbac.authorization.k8s.io/v1beta1
-metadata:
-  name: flannel
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-rules:
-  - apiGroups:
-      - ""
-    resources:
-      - pods
-    verbs:
-      - get
-  - apiGroups:
-      - ""
-    resources:
-      - nodes
-    verbs:
-      - list
-      - watch
-  - apiGroups:
-      - ""
-    resources:
-      - nodes/status
-    verbs:
-      - patch
----
-kind: ClusterRoleBinding
-apiVersion: rbac.authorization.k8s.io/v1beta1
-metadata:
-  name: flannel
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: flannel
-subjects:
-- kind: ServiceAccount
-  name: flannel
-  namespace: kube-system
+              fieldPath: spec.nodeName
+        image: quay.io/awesomenix/drainsafe-manager:latest
+        name: manager
+        resources:
+          limits:
+            cpu: 100m
+            memory: 30Mi
+          requests:
+            cpu: 100m
+            memory: 20Mi
+      terminationGracePeriodSeconds: 10
+      tolerations:
+      - effect: NoSchedule
+        key: node-role.kubernetes.io/master
 `)
 
-func k8sAddonsKubernetesmasteraddonsFlannelDaemonsetYamlBytes() ([]byte, error) {
-	return _k8sAddonsKubernetesmasteraddonsFlannelDaemonsetYaml, nil
+func k8sAddonsKubernetesmasteraddonsScheduledMaintenanceDeploymentYamlBytes() ([]byte, error) {
+	return _k8sAddonsKubernetesmasteraddonsScheduledMaintenanceDeploymentYaml, nil
 }
 
-func k8sAddonsKubernetesmasteraddonsFlannelDaemonsetYaml() (*asset, error) {
-	bytes, err := k8sAddonsKubernetesmasteraddonsFlannelDaemonsetYamlBytes()
+func k8sAddonsKubernetesmasteraddonsScheduledMaintenanceDeploymentYaml() (*asset, error) {
+	bytes, err := k8sAddonsKubernetesmasteraddonsScheduledMaintenanceDeploymentYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-flannel-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-scheduled-maintenance-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sAddonsKubernetesmasteraddonsKubeDnsDeploymentYaml = []byte(`# Copyright 2016 The Kubernetes Authors.
-#
-# Licensed under the Apache License, Version 2.0 (the "License");
-# you may not use this file except in compliance with the License.
-# You may obtain a copy of the License at
-#
-#     http://www.apache.org/licenses/LICENSE-2.0
-#
-# Unless required by applicable law or agreed to in writing, software
-# distributed under the License is distributed on an "AS IS" BASIS,
-# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
-# See the License for the specific language governing permissions and
-# limitations under the License.
-
-# Should keep target in cluster/addons/dns-horizontal-autoscaler/dns-horizontal-autoscaler.yaml
-# in sync with this file.
-
-apiVersion: v1
-kind: Service
+var _k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesCustomYaml = []byte(`apiVersion: storage.k8s.io/v1beta1
+kind: StorageClass
 metadata:
-  name: kube-dns
-  namespace: kube-system
+  name: default
+  annotations:
+    storageclass.beta.kubernetes.io/is-default-class: "true"
   labels:
-    k8s-app: kube-dns
     kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-    kubernetes.io/name: KubeDNS
-spec:
-  selector:
-    k8s-app: kube-dns
-  clusterIP: <clustIP>
-  ports:
-  - name: dns
-    port: 53
-    protocol: UDP
-  - name: dns-tcp
-    port: 53
-    protocol: TCP
+provisioner: kubernetes.io/azure-disk
+parameters:
+  cachingmode: ReadOnly
 ---
-apiVersion: v1
-kind: ServiceAccount
+apiVersion: storage.k8s.io/v1beta1
+kind: StorageClass
 metadata:
-  name: kube-dns
-  namespace: kube-system
+  name: unmanaged-premium
+  annotations:
   labels:
     kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
----
-apiVersion: v1
-kind: ConfigMap
-metadata:
-  name: kube-dns
-  namespace: kube-system
-  labels:
-    addonmanager.kubernetes.io/mode: EnsureExists
+provisioner: kubernetes.io/azure-disk
+parameters:
+  kind: shared
+  storageaccounttype: Premium_LRS
+  cachingmode: ReadOnly
 ---
-apiVersion: extensions/v1beta1
-kind: Deployment
+apiVersion: storage.k8s.io/v1beta1
+kind: StorageClass
 metadata:
-  name: kube-dns
-  namespace: kube-system
+  name: unmanaged-standard
+  annotations:
   labels:
-    k8s-app: kube-dns
     kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-spec:
-  # replicas: not specified here:
-  # 1. In order to make Addon Manager do not reconcile this replicas parameter.
-  # 2. Default is 1.
-  # 3. Will be tuned in real time if DNS horizontal auto-scaling is turned on.
-  strategy:
-    rollingUpdate:
-      maxSurge: 10%
-      maxUnavailable: 0
-  selector:
-    matchLabels:
-      k8s-app: kube-dns
-  template:
-    metadata:
-      labels:
-        k8s-app: kube-dns
-      annotations:
-        scheduler.alpha.kubernetes.io/critical-pod: ''
-        seccomp.security.alpha.kubernetes.io/pod: 'docker/default'
-    spec:
-      priorityClassName: system-node-critical
-      tolerations:
-      - key: CriticalAddonsOnly
-        operator: Exists
-      volumes:
-      - name: kube-dns-config
-        configMap:
-          name: kube-dns
-          optional: true
-      containers:
-      - name: kubedns
-        image: <img>
-        imagePullPolicy: IfNotPresent
-        resources:
-          # TODO: Set memory limits when we've profiled the container for large
-          # clusters, then set request = limit to keep this container in
-          # guaranteed class. Currently, this container falls into the
-          # "burstable" category so the kubelet doesn't backoff from restarting it.
-          limits:
-            memory: 170Mi
-          requests:
-            cpu: 100m
-            memory: 70Mi
-        livenessProbe:
-          httpGet:
-            path: /healthcheck/kubedns
-            port: 10054
-            scheme: HTTP
-          initialDelaySeconds: 60
-          timeoutSeconds: 5
-          successThreshold: 1
-          failureThreshold: 5
-        readinessProbe:
-          httpGet:
-            path: /readiness
-            port: 8081
-            scheme: HTTP
-          initialDelaySeconds: 3
-          timeoutSeconds: 5
-        args:
-        - --domain=<domain>.
-        - --dns-port=10053
-        - --config-dir=/kube-dns-config
-        - --v=2
-        env:
-        - name: PROMETHEUS_PORT
-          value: "10055"
-        ports:
-        - containerPort: 10053
-          name: dns-local
-          protocol: UDP
-        - containerPort: 10053
-          name: dns-tcp-local
-          protocol: TCP
-        - containerPort: 10055
-          name: metrics
-          protocol: TCP
-        volumeMounts:
-        - name: kube-dns-config
-          mountPath: /kube-dns-config
-      - name: dnsmasq
-        image: <imgMasq>
-        imagePullPolicy: IfNotPresent
-        livenessProbe:
-          httpGet:
-            path: /healthcheck/dnsmasq
-            port: 10054
-            scheme: HTTP
-          initialDelaySeconds: 60
-          timeoutSeconds: 5
-          successThreshold: 1
-          failureThreshold: 5
-        args:
-        - -v=2
-        - -logtostderr
-        - -configDir=/etc/k8s/dns/dnsmasq-nanny
-        - -restartDnsmasq=true
-        - --
-        - -k
-        - --cache-size=1000
-        - --no-negcache
-        - --log-facility=-
-        - --server=/cluster.local/127.0.0.1#10053
-        - --server=/in-addr.arpa/127.0.0.1#10053
-        - --server=/ip6.arpa/127.0.0.1#10053
-        ports:
-        - containerPort: 53
-          name: dns
-          protocol: UDP
-        - containerPort: 53
-          name: dns-tcp
-          protocol: TCP
-        resources:
-          requests:
-            cpu: 150m
-            memory: 20Mi
-        volumeMounts:
-        - name: kube-dns-config
-          mountPath: /etc/k8s/dns/dnsmasq-nanny
-      - name: sidecar
-        image: <imgSidecar>
-        imagePullPolicy: IfNotPresent
-        livenessProbe:
-          httpGet:
-            path: /metrics
-            port: 10054
-            scheme: HTTP
-          initialDelaySeconds: 60
-          timeoutSeconds: 5
-          successThreshold: 1
-          failureThreshold: 5
-        args:
-        - --v=2
-        - --logtostderr
-        - --probe=kubedns,127.0.0.1:10053,kubernetes.default.svc.<domain>,5,SRV
-        - --probe=dnsmasq,127.0.0.1:53,kubernetes.default.svc.<domain>,5,SRV
-        ports:
-        - containerPort: 10054
-          name: metrics
-          protocol: TCP
-        resources:
-          requests:
-            memory: 20Mi
-            cpu: 10m
-      dnsPolicy: Default
-      serviceAccountName: kube-dns
-      nodeSelector:
-        beta.kubernetes.io/os: linux`)
+provisioner: kubernetes.io/azure-disk
+parameters:
+  kind: shared
+  storageaccounttype: Standard_LRS
+  cachingmode: ReadOnly
+`)
 
-func k8sAddonsKubernetesmasteraddonsKubeDnsDeploymentYamlBytes() ([]byte, error) {
-	return _k8sAddonsKubernetesmasteraddonsKubeDnsDeploymentYaml, nil
+func k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesCustomYamlBytes() ([]byte, error) {
+	return _k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesCustomYaml, nil
 }
 
-func k8sAddonsKubernetesmasteraddonsKubeDnsDeploymentYaml() (*asset, error) {
-	bytes, err := k8sAddonsKubernetesmasteraddonsKubeDnsDeploymentYamlBytes()
+func k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesCustomYaml() (*asset, error) {
+	bytes, err := k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesCustomYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-kube-dns-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-unmanaged-azure-storage-classes-custom.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sAddonsKubernetesmasteraddonsKubeProxyDaemonsetYaml = []byte(`apiVersion: extensions/v1beta1
-kind: DaemonSet
+var _k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesYaml = []byte(`apiVersion: storage.k8s.io/v1beta1
+kind: StorageClass
 metadata:
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-    kubernetes.io/cluster-service: "true"
-    component: kube-proxy
-    tier: node
-    k8s-app: kube-proxy
-  name: kube-proxy
-  namespace: kube-system
-spec:
-  selector:
-    matchLabels:
-      k8s-app: kube-proxy
-  updateStrategy:
-    type: RollingUpdate
-    rollingUpdate:
-      maxUnavailable: 50%
-  template:
-    metadata:
-      labels:
-        component: kube-proxy
-        tier: node
-        k8s-app: kube-proxy
-      annotations:
-        scheduler.alpha.kubernetes.io/critical-pod: ''
-    spec:
-      priorityClassName: system-node-critical
-      tolerations:
-      - key: node-role.kubernetes.io/master
-        operator: Equal
-        value: "true"
-        effect: NoSchedule
-      - operator: "Exists"
-        effect: NoExecute
-      - operator: "Exists"
-        effect: NoSchedule
-      - key: CriticalAddonsOnly
-        operator: Exists
-      containers:
-      - command:
-        - /hyperkube
-        - kube-proxy
-        - --kubeconfig=/var/lib/kubelet/kubeconfig
-        - --cluster-cidr=<CIDR>
-        - --feature-gates=ExperimentalCriticalPodAnnotation=true
-        - --proxy-mode=<kubeProxyMode>
-        image: <img>
-        imagePullPolicy: IfNotPresent
-        name: kube-proxy
-        resources:
-          requests:
-            cpu: 100m
-        securityContext:
-          privileged: true
-        volumeMounts:
-        - mountPath: /etc/ssl/certs
-          name: ssl-certs-host
-          readOnly: true
-        - mountPath: /etc/kubernetes
-          name: etc-kubernetes
-          readOnly: true
-        - mountPath: /var/lib/kubelet/kubeconfig
-          name: kubeconfig
-          readOnly: true
-        - mountPath: /run/xtables.lock
-          name: iptableslock
-        - mountPath: /lib/modules/
-          name: kernelmodules
-          readOnly: true
-      hostNetwork: true
-      volumes:
-      - hostPath:
-          path: /usr/share/ca-certificates
-        name: ssl-certs-host
-      - hostPath:
-          path: /var/lib/kubelet/kubeconfig
-        name: kubeconfig
-      - hostPath:
-          path: /etc/kubernetes
-        name: etc-kubernetes
-      - hostPath:
-          path: /run/xtables.lock
-        name: iptableslock
-      - hostPath:
-          path: /lib/modules/
-        name: kernelmodules
-      nodeSelector:
-        beta.kubernetes.io/os: linux
-`)
-
-func k8sAddonsKubernetesmasteraddonsKubeProxyDaemonsetYamlBytes() ([]byte, error) {
-	return _k8sAddonsKubernetesmasteraddonsKubeProxyDaemonsetYaml, nil
-}
-
-func k8sAddonsKubernetesmasteraddonsKubeProxyDaemonsetYaml() (*asset, error) {
-	bytes, err := k8sAddonsKubernetesmasteraddonsKubeProxyDaemonsetYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-kube-proxy-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesCustomYaml = []byte(`apiVersion: storage.k8s.io/v1beta1
-kind: StorageClass
-metadata:
-  name: default
-  annotations:
-    storageclass.beta.kubernetes.io/is-default-class: "true"
-  labels:
-    kubernetes.io/cluster-service: "true"
-provisioner: kubernetes.io/azure-disk
-parameters:
-  kind: Managed
-  storageaccounttype: Standard_LRS
-  cachingmode: ReadOnly
----
-apiVersion: storage.k8s.io/v1beta1
-kind: StorageClass
-metadata:
-  name: managed-premium
-  annotations:
-  labels:
-    kubernetes.io/cluster-service: "true"
-provisioner: kubernetes.io/azure-disk
-parameters:
-  kind: Managed
-  storageaccounttype: Premium_LRS
-  cachingmode: ReadOnly
----
-apiVersion: storage.k8s.io/v1beta1
-kind: StorageClass
-metadata:
-  name: managed-standard
-  annotations:
-  labels:
-    kubernetes.io/cluster-service: "true"
-provisioner: kubernetes.io/azure-disk
-parameters:
-  kind: Managed
-  storageaccounttype: Standard_LRS
-  cachingmode: ReadOnly
-`)
-
-func k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesCustomYamlBytes() ([]byte, error) {
-	return _k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesCustomYaml, nil
-}
-
-func k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesCustomYaml() (*asset, error) {
-	bytes, err := k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesCustomYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-managed-azure-storage-classes-custom.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesYaml = []byte(`apiVersion: storage.k8s.io/v1beta1
-kind: StorageClass
-metadata:
-  name: default
-  annotations:
-    storageclass.beta.kubernetes.io/is-default-class: "true"
+  name: default
+  annotations:
+    storageclass.beta.kubernetes.io/is-default-class: "true"
   labels:
     kubernetes.io/cluster-service: "true"
 provisioner: kubernetes.io/azure-disk
 parameters:
-  kind: Managed
-  storageaccounttype: Standard_LRS
   cachingmode: ReadOnly
 ---
 apiVersion: storage.k8s.io/v1beta1
 kind: StorageClass
 metadata:
-  name: managed-premium
+  name: unmanaged-premium
   annotations:
   labels:
     kubernetes.io/cluster-service: "true"
 provisioner: kubernetes.io/azure-disk
 parameters:
-  kind: Managed
+  kind: shared
   storageaccounttype: Premium_LRS
   cachingmode: ReadOnly
 ---
 apiVersion: storage.k8s.io/v1beta1
 kind: StorageClass
 metadata:
-  name: managed-standard
+  name: unmanaged-standard
   annotations:
   labels:
     kubernetes.io/cluster-service: "true"
 provisioner: kubernetes.io/azure-disk
 parameters:
-  kind: Managed
+  kind: shared
   storageaccounttype: Standard_LRS
   cachingmode: ReadOnly
 ---
@@ -11472,706 +10372,89 @@ parameters:
   skuName: Standard_LRS
 `)
 
-func k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesYamlBytes() ([]byte, error) {
-	return _k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesYaml, nil
+func k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesYamlBytes() ([]byte, error) {
+	return _k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesYaml, nil
 }
 
-func k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesYaml() (*asset, error) {
-	bytes, err := k8sAddonsKubernetesmasteraddonsManagedAzureStorageClassesYamlBytes()
+func k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesYaml() (*asset, error) {
+	bytes, err := k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-managed-azure-storage-classes.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-unmanaged-azure-storage-classes.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sAddonsKubernetesmasteraddonsPodSecurityPolicyYaml = []byte(`apiVersion: extensions/v1beta1
-kind: PodSecurityPolicy
-metadata:
-  name: privileged
-  annotations:
-    seccomp.security.alpha.kubernetes.io/allowedProfileNames: "*"
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-spec:
-  privileged: true
-  allowPrivilegeEscalation: true
-  allowedCapabilities:
-  - "*"
-  volumes:
-  - "*"
-  hostNetwork: true
-  hostPorts:
-  - min: 0
-    max: 65535
-  hostIPC: true
-  hostPID: true
-  runAsUser:
-    rule: RunAsAny
-  seLinux:
-    rule: RunAsAny
-  supplementalGroups:
-    rule: RunAsAny
-  fsGroup:
-    rule: RunAsAny
----
-apiVersion: extensions/v1beta1
-kind: PodSecurityPolicy
-metadata:
-  name: restricted
-  annotations:
-    seccomp.security.alpha.kubernetes.io/allowedProfileNames: docker/default
-    apparmor.security.beta.kubernetes.io/allowedProfileNames: runtime/default
-    seccomp.security.alpha.kubernetes.io/defaultProfileName:  docker/default
-    apparmor.security.beta.kubernetes.io/defaultProfileName:  runtime/default
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-spec:
-  privileged: false
-  allowPrivilegeEscalation: false
-  requiredDropCapabilities:
-    - ALL
-  volumes:
-    - configMap
-    - emptyDir
-    - projected
-    - secret
-    - downwardAPI
-    - persistentVolumeClaim
-  hostNetwork: false
-  hostIPC: false
-  hostPID: false
-  runAsUser:
-    rule: MustRunAsNonRoot
-  seLinux:
-    rule: RunAsAny
-  supplementalGroups:
-    rule: MustRunAs
-    ranges:
-      # Forbid adding the root group.
-      - min: 1
-        max: 65535
-  fsGroup:
-    rule: MustRunAs
-    ranges:
-      # Forbid adding the root group.
-      - min: 1
-        max: 65535
-  readOnlyRootFilesystem: false
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
-metadata:
-  name: psp:privileged
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-rules:
-- apiGroups: ['extensions']
-  resources: ['podsecuritypolicies']
-  verbs:     ['use']
-  resourceNames:
-  - privileged
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
-metadata:
-  name: psp:restricted
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-rules:
-- apiGroups: ['extensions']
-  resources: ['podsecuritypolicies']
-  verbs:     ['use']
-  resourceNames:
-  - restricted
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
-metadata:
-  name: default:restricted
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: psp:restricted
-subjects:
-- kind: Group
-  name: system:authenticated
-  apiGroup: rbac.authorization.k8s.io
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: RoleBinding
-metadata:
-  name: default:privileged
-  namespace: kube-system
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: psp:privileged
-subjects:
-- kind: Group
-  name: system:masters
-  apiGroup: rbac.authorization.k8s.io
-- kind: Group
-  name: system:serviceaccounts:kube-system
-  apiGroup: rbac.authorization.k8s.io
-- kind: Group
-  name: system:nodes
-  apiGroup: rbac.authorization.k8s.io`)
+var _k8sArmparametersT = []byte(`{
+  "parameters": {
+    {{range .AgentPoolProfiles}}{{template "agentparams.t" .}},{{end}}
+    {{if .HasWindows}}
+      {{template "windowsparams.t"}},
+    {{end}}
+    {{template "masterparams.t" .}},
+    {{template "k8s/kubernetesparams.t" .}}
+  }
+}`)
 
-func k8sAddonsKubernetesmasteraddonsPodSecurityPolicyYamlBytes() ([]byte, error) {
-	return _k8sAddonsKubernetesmasteraddonsPodSecurityPolicyYaml, nil
+func k8sArmparametersTBytes() ([]byte, error) {
+	return _k8sArmparametersT, nil
 }
 
-func k8sAddonsKubernetesmasteraddonsPodSecurityPolicyYaml() (*asset, error) {
-	bytes, err := k8sAddonsKubernetesmasteraddonsPodSecurityPolicyYamlBytes()
+func k8sArmparametersT() (*asset, error) {
+	bytes, err := k8sArmparametersTBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-pod-security-policy.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/armparameters.t", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sAddonsKubernetesmasteraddonsScheduledMaintenanceDeploymentYaml = []byte(`apiVersion: v1
-kind: Namespace
-metadata:
-  labels:
-    control-plane: controller-manager
-    addonmanager.kubernetes.io/mode: Reconcile
-  name: drainsafe-system
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: Role
-metadata:
-  name: drainsafe-leader-election-role
-  namespace: drainsafe-system
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-rules:
-- apiGroups:
-  - ""
-  resources:
-  - configmaps
-  verbs:
-  - get
-  - list
-  - watch
-  - create
-  - update
-  - patch
-  - delete
-- apiGroups:
-  - ""
-  resources:
-  - configmaps/status
-  verbs:
-  - get
-  - update
-  - patch
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
-metadata:
-  creationTimestamp: null
-  name: drainsafe-manager-role
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-rules:
-- apiGroups:
-  - apiextensions.k8s.io
-  resources:
-  - customresourcedefinitions
-  verbs:
-  - get
-  - list
-  - watch
-  - create
-  - update
-  - patch
-  - delete
-- apiGroups:
-  - repairman.k8s.io
-  resources:
-  - maintenancerequests
-  verbs:
-  - get
-  - list
-  - watch
-  - create
-  - update
-  - patch
-  - delete
-- apiGroups:
-  - ""
-  resources:
-  - nodes
-  verbs:
-  - get
-  - list
-  - watch
-  - create
-  - update
-  - patch
-  - delete
-- apiGroups:
-  - apps
-  resources:
-  - daemonsets
-  verbs:
-  - get
-  - list
-  - watch
-  - create
-  - update
-  - patch
-  - delete
-- apiGroups:
-  - extensions
-  resources:
-  - daemonsets
-  verbs:
-  - get
-  - list
-  - watch
-  - create
-  - update
-  - patch
-  - delete
-- apiGroups:
-  - ""
-  resources:
-  - pods
-  verbs:
-  - get
-  - list
-  - watch
-  - create
-  - update
-  - patch
-  - delete
-- apiGroups:
-  - ""
-  resources:
-  - pods/eviction
-  verbs:
-  - get
-  - list
-  - watch
-  - create
-  - update
-  - patch
-  - delete
-- apiGroups:
-  - ""
-  resources:
-  - events
-  verbs:
-  - get
-  - list
-  - watch
-  - create
-  - update
-  - patch
-  - delete
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
-metadata:
-  name: drainsafe-proxy-role
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-rules:
-- apiGroups:
-  - authentication.k8s.io
-  resources:
-  - tokenreviews
-  - subjectaccessreviews
-  verbs:
-  - create
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: RoleBinding
-metadata:
-  name: drainsafe-leader-election-rolebinding
-  namespace: drainsafe-system
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: Role
-  name: drainsafe-leader-election-role
-subjects:
-- kind: ServiceAccount
-  name: default
-  namespace: drainsafe-system
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
-metadata:
-  name: drainsafe-manager-rolebinding
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: drainsafe-manager-role
-subjects:
-- kind: ServiceAccount
-  name: default
-  namespace: drainsafe-system
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
-metadata:
-  name: drainsafe-proxy-rolebinding
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: drainsafe-proxy-role
-subjects:
-- kind: ServiceAccount
-  name: default
-  namespace: drainsafe-system
----
-apiVersion: v1
-kind: Service
-metadata:
-  annotations:
-    prometheus.io/port: "8443"
-    prometheus.io/scheme: https
-    prometheus.io/scrape: "true"
-  labels:
-    control-plane: controller-manager
-    addonmanager.kubernetes.io/mode: Reconcile
-  name: drainsafe-controller-manager-metrics-service
-  namespace: drainsafe-system
-spec:
-  ports:
-  - name: https
-    port: 8443
-    targetPort: https
-  selector:
-    control-plane: controller-manager
----
-apiVersion: apps/v1
-kind: Deployment
-metadata:
-  labels:
-    control-plane: controller-manager
-    addonmanager.kubernetes.io/mode: Reconcile
-  name: drainsafe-controller-manager
-  namespace: drainsafe-system
-spec:
-  replicas: 1
-  selector:
-    matchLabels:
-      control-plane: controller-manager
-  template:
-    metadata:
-      labels:
-        control-plane: controller-manager
-    spec:
-      containers:
-      - args:
-        - --secure-listen-address=0.0.0.0:8443
-        - --upstream=http://127.0.0.1:8080/
-        - --logtostderr=true
-        - --v=10
-        image: gcr.io/kubebuilder/kube-rbac-proxy:v0.4.0
-        name: kube-rbac-proxy
-        ports:
-        - containerPort: 8443
-          name: https
-      - args:
-        - --metrics-addr=127.0.0.1:8080
-        command:
-        - /manager
-        env:
-        - name: POD_NAMESPACE
-          valueFrom:
-            fieldRef:
-              fieldPath: metadata.namespace
-        - name: POD_NAME
-          valueFrom:
-            fieldRef:
-              fieldPath: metadata.name
-        - name: NODE_NAME
-          valueFrom:
-            fieldRef:
-              fieldPath: spec.nodeName
-        image: quay.io/awesomenix/drainsafe-manager:latest
-        name: manager
-        resources:
-          limits:
-            cpu: 100m
-            memory: 30Mi
-          requests:
-            cpu: 100m
-            memory: 20Mi
-      terminationGracePeriodSeconds: 10
-      tolerations:
-      - effect: NoSchedule
-        key: node-role.kubernetes.io/master
----
-apiVersion: apps/v1
-kind: DaemonSet
-metadata:
-  labels:
-    control-plane: controller-manager
-    addonmanager.kubernetes.io/mode: Reconcile
-  name: drainsafe-controller-scheduledevent-manager
-  namespace: drainsafe-system
-spec:
-  selector:
-    matchLabels:
-      control-plane: controller-manager
-  template:
-    metadata:
-      labels:
-        control-plane: controller-manager
-    spec:
-      containers:
-      - command:
-        - /scheduledevent-manager
-        env:
-        - name: POD_NAMESPACE
-          valueFrom:
-            fieldRef:
-              fieldPath: metadata.namespace
-        - name: POD_NAME
-          valueFrom:
-            fieldRef:
-              fieldPath: metadata.name
-        - name: NODE_NAME
-          valueFrom:
-            fieldRef:
-              fieldPath: spec.nodeName
-        image: quay.io/awesomenix/drainsafe-manager:latest
-        name: manager
-        resources:
-          limits:
-            cpu: 100m
-            memory: 30Mi
-          requests:
-            cpu: 100m
-            memory: 20Mi
-      terminationGracePeriodSeconds: 10
-      tolerations:
-      - effect: NoSchedule
-        key: node-role.kubernetes.io/master
-`)
+var _k8sCloudInitArtifactsAptPreferences = []byte(``)
 
-func k8sAddonsKubernetesmasteraddonsScheduledMaintenanceDeploymentYamlBytes() ([]byte, error) {
-	return _k8sAddonsKubernetesmasteraddonsScheduledMaintenanceDeploymentYaml, nil
+func k8sCloudInitArtifactsAptPreferencesBytes() ([]byte, error) {
+	return _k8sCloudInitArtifactsAptPreferences, nil
 }
 
-func k8sAddonsKubernetesmasteraddonsScheduledMaintenanceDeploymentYaml() (*asset, error) {
-	bytes, err := k8sAddonsKubernetesmasteraddonsScheduledMaintenanceDeploymentYamlBytes()
+func k8sCloudInitArtifactsAptPreferences() (*asset, error) {
+	bytes, err := k8sCloudInitArtifactsAptPreferencesBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-scheduled-maintenance-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/cloud-init/artifacts/apt-preferences", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesCustomYaml = []byte(`apiVersion: storage.k8s.io/v1beta1
-kind: StorageClass
-metadata:
-  name: default
-  annotations:
-    storageclass.beta.kubernetes.io/is-default-class: "true"
-  labels:
-    kubernetes.io/cluster-service: "true"
-provisioner: kubernetes.io/azure-disk
-parameters:
-  cachingmode: ReadOnly
----
-apiVersion: storage.k8s.io/v1beta1
-kind: StorageClass
-metadata:
-  name: unmanaged-premium
-  annotations:
-  labels:
-    kubernetes.io/cluster-service: "true"
-provisioner: kubernetes.io/azure-disk
-parameters:
-  kind: shared
-  storageaccounttype: Premium_LRS
-  cachingmode: ReadOnly
----
-apiVersion: storage.k8s.io/v1beta1
-kind: StorageClass
-metadata:
-  name: unmanaged-standard
-  annotations:
-  labels:
-    kubernetes.io/cluster-service: "true"
-provisioner: kubernetes.io/azure-disk
-parameters:
-  kind: shared
-  storageaccounttype: Standard_LRS
-  cachingmode: ReadOnly
-`)
+var _k8sCloudInitArtifactsAuditdRules = []byte(`# increase kernel audit buffers since we have a lot of rules
+-b 8192
 
-func k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesCustomYamlBytes() ([]byte, error) {
-	return _k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesCustomYaml, nil
-}
+# 4.1.4 Ensure events that modify date and time information are collected
+-a always,exit -F arch=b64 -S adjtimex -S settimeofday -k time-change
+-a always,exit -F arch=b32 -S adjtimex -S settimeofday -S stime -k time-change
+-a always,exit -F arch=b64 -S clock_settime -k time-change
+-a always,exit -F arch=b32 -S clock_settime -k time-change
+-w /etc/localtime -p wa -k time-change
 
-func k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesCustomYaml() (*asset, error) {
-	bytes, err := k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesCustomYamlBytes()
-	if err != nil {
-		return nil, err
-	}
+# 4.1.5 Ensure events that modify user/group information are collected
+-w /etc/group -p wa -k identity
+-w /etc/passwd -p wa -k identity
+-w /etc/gshadow -p wa -k identity
+-w /etc/shadow -p wa -k identity
+-w /etc/security/opasswd -p wa -k identity
 
-	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-unmanaged-azure-storage-classes-custom.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesYaml = []byte(`apiVersion: storage.k8s.io/v1beta1
-kind: StorageClass
-metadata:
-  name: default
-  annotations:
-    storageclass.beta.kubernetes.io/is-default-class: "true"
-  labels:
-    kubernetes.io/cluster-service: "true"
-provisioner: kubernetes.io/azure-disk
-parameters:
-  cachingmode: ReadOnly
----
-apiVersion: storage.k8s.io/v1beta1
-kind: StorageClass
-metadata:
-  name: unmanaged-premium
-  annotations:
-  labels:
-    kubernetes.io/cluster-service: "true"
-provisioner: kubernetes.io/azure-disk
-parameters:
-  kind: shared
-  storageaccounttype: Premium_LRS
-  cachingmode: ReadOnly
----
-apiVersion: storage.k8s.io/v1beta1
-kind: StorageClass
-metadata:
-  name: unmanaged-standard
-  annotations:
-  labels:
-    kubernetes.io/cluster-service: "true"
-provisioner: kubernetes.io/azure-disk
-parameters:
-  kind: shared
-  storageaccounttype: Standard_LRS
-  cachingmode: ReadOnly
----
-kind: StorageClass
-apiVersion: storage.k8s.io/v1
-metadata:
-  name: azurefile
-  annotations:
-  labels:
-    kubernetes.io/cluster-service: "true"
-provisioner: kubernetes.io/azure-file
-parameters:
-  skuName: Standard_LRS
-`)
-
-func k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesYamlBytes() ([]byte, error) {
-	return _k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesYaml, nil
-}
-
-func k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesYaml() (*asset, error) {
-	bytes, err := k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/addons/kubernetesmasteraddons-unmanaged-azure-storage-classes.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sArmparametersT = []byte(`{
-  "parameters": {
-    {{range .AgentPoolProfiles}}{{template "agentparams.t" .}},{{end}}
-    {{if .HasWindows}}
-      {{template "windowsparams.t"}},
-    {{end}}
-    {{template "masterparams.t" .}},
-    {{template "k8s/kubernetesparams.t" .}}
-  }
-}`)
-
-func k8sArmparametersTBytes() ([]byte, error) {
-	return _k8sArmparametersT, nil
-}
-
-func k8sArmparametersT() (*asset, error) {
-	bytes, err := k8sArmparametersTBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/armparameters.t", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sCloudInitArtifactsAptPreferences = []byte(``)
-
-func k8sCloudInitArtifactsAptPreferencesBytes() ([]byte, error) {
-	return _k8sCloudInitArtifactsAptPreferences, nil
-}
-
-func k8sCloudInitArtifactsAptPreferences() (*asset, error) {
-	bytes, err := k8sCloudInitArtifactsAptPreferencesBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/cloud-init/artifacts/apt-preferences", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sCloudInitArtifactsAuditdRules = []byte(`# increase kernel audit buffers since we have a lot of rules
--b 8192
-
-# 4.1.4 Ensure events that modify date and time information are collected
--a always,exit -F arch=b64 -S adjtimex -S settimeofday -k time-change
--a always,exit -F arch=b32 -S adjtimex -S settimeofday -S stime -k time-change
--a always,exit -F arch=b64 -S clock_settime -k time-change
--a always,exit -F arch=b32 -S clock_settime -k time-change
--w /etc/localtime -p wa -k time-change
-
-# 4.1.5 Ensure events that modify user/group information are collected
--w /etc/group -p wa -k identity
--w /etc/passwd -p wa -k identity
--w /etc/gshadow -p wa -k identity
--w /etc/shadow -p wa -k identity
--w /etc/security/opasswd -p wa -k identity
-
-# 4.1.6 Ensure events that modify the system's network environment are collected
--a always,exit -F arch=b64 -S sethostname -S setdomainname -k system-locale
--a always,exit -F arch=b32 -S sethostname -S setdomainname -k system-locale
--w /etc/issue -p wa -k system-locale
--w /etc/issue.net -p wa -k system-locale
--w /etc/hosts -p wa -k system-locale
--w /etc/network -p wa -k system-locale
--w /etc/networks -p wa -k system-locale
+# 4.1.6 Ensure events that modify the system's network environment are collected
+-a always,exit -F arch=b64 -S sethostname -S setdomainname -k system-locale
+-a always,exit -F arch=b32 -S sethostname -S setdomainname -k system-locale
+-w /etc/issue -p wa -k system-locale
+-w /etc/issue.net -p wa -k system-locale
+-w /etc/hosts -p wa -k system-locale
+-w /etc/network -p wa -k system-locale
+-w /etc/networks -p wa -k system-locale
 
 # 4.1.7 Ensure events that modify the system's Mandatory Access Controls are collected
 -w /etc/selinux/ -p wa -k MAC-policy
@@ -12377,6 +10660,13 @@ else
 fi
 ETCD_PEER_URL="https://${PRIVATE_IP}:2380"
 ETCD_CLIENT_URL="https://${PRIVATE_IP}:2379"
+if [[ -n "${ETCD_FIRST_CONSECUTIVE_STATIC_IP}" ]]; then
+    # etcd uses the second NIC of the master, on the dedicated etcd subnet
+    ETCD_NIC=eth1
+    ETCD_PRIVATE_IP=$(echo ${ETCD_FIRST_CONSECUTIVE_STATIC_IP} | awk -F. -v i=${NODE_INDEX} '{print $1"."$2"."$3"."$4+i}')
+    ETCD_PEER_URL="https://${ETCD_PRIVATE_IP}:2380"
+    ETCD_CLIENT_URL="https://${ETCD_PRIVATE_IP}:2379"
+fi
 
 systemctlEnableAndStart() {
     systemctl_restart 100 5 30 $1
@@ -12465,6 +10755,38 @@ configureSecrets(){
     echo "${ETCD_PEER_CERT}" | base64 --decode > "${ETCD_PEER_CERTIFICATE_PATH}"
 }
 
+ensureEtcdNIC() {
+    if ip -4 addr show ${ETCD_NIC} | grep -q "inet ${ETCD_PRIVATE_IP}/"; then
+        return
+    fi
+    # no gateway, only the routes of the etcd subnet go through the etcd NIC
+    if [[ -d /etc/netplan ]]; then
+        cat << EOF > /etc/netplan/60-etcd-nic.yaml
+network:
+  version: 2
+  ethernets:
+    ${ETCD_NIC}:
+      dhcp4: false
+      addresses: [${ETCD_PRIVATE_IP}/${ETCD_SUBNET#*/}]
+EOF
+        retrycmd_if_failure 10 5 30 netplan apply || exit $ERR_ETCD_NIC_CONFIG_FAIL
+    else
+        cat << EOF > /etc/network/interfaces.d/60-etcd-nic.cfg
+auto ${ETCD_NIC}
+iface ${ETCD_NIC} inet static
+    address ${ETCD_PRIVATE_IP}/${ETCD_SUBNET#*/}
+EOF
+        retrycmd_if_failure 10 5 30 ifup ${ETCD_NIC} || exit $ERR_ETCD_NIC_CONFIG_FAIL
+    fi
+    for i in $(seq 1 60); do
+        if ip -4 addr show ${ETCD_NIC} | grep -q "inet ${ETCD_PRIVATE_IP}/"; then
+            return
+        fi
+        sleep 1
+    done
+    exit $ERR_ETCD_NIC_CONFIG_FAIL
+}
+
 configureEtcd() {
     set -x
 
@@ -12578,6 +10900,12 @@ configureK8s() {
     "providerKeyVersion": ""
 }
 EOF
+    CLOUD_PROVIDER_CONFIG_PATH="/etc/kubernetes/azure-cloud-provider-config.json"
+    if [[ -f "${CLOUD_PROVIDER_CONFIG_PATH}" ]]; then
+        # merge the cloudProviderConfig settings of the api model, which have no variable of their own
+        jq -s '.[0] * .[1]' "${AZURE_JSON_PATH}" "${CLOUD_PROVIDER_CONFIG_PATH}" > "${AZURE_JSON_PATH}.tmp" && mv "${AZURE_JSON_PATH}.tmp" "${AZURE_JSON_PATH}"
+        chmod 0600 "${AZURE_JSON_PATH}"
+    fi
     set -x
     if [[ -n "${MASTER_NODE}" ]]; then
         if [[ "${ENABLE_AGGREGATED_APIS}" = True ]]; then
@@ -12598,6 +10926,11 @@ configureCNI() {
         systemctl restart sys-fs-bpf.mount
         REBOOTREQUIRED=true
     fi
+    if [[ "${NETWORK_PLUGIN}" = "antrea" ]]; then
+        # the antrea-ovs container runs the Open vSwitch daemons of the node on its kernel datapath
+        retrycmd_if_failure 120 5 25 modprobe openvswitch || exit $ERR_MODPROBE_FAIL
+        echo -n "openvswitch" > /etc/modules-load.d/openvswitch.conf
+    fi
 
     if [[ "${TARGET_ENVIRONMENT,,}" == "${AZURE_STACK_ENV}"  ]] && [[ "${NETWORK_PLUGIN}" = "azure" ]]; then
         # set environment to mas when using Azure CNI on Azure Stack
@@ -12636,6 +10969,9 @@ setupContainerd() {
         echo "[plugins.cri.containerd.default_runtime]"
         echo "runtime_type = 'io.containerd.runtime.v1.linux'"
         echo "runtime_engine = '/usr/local/sbin/runc'"
+        if [ -f /etc/containerd/registry-mirrors.toml ]; then
+            cat /etc/containerd/registry-mirrors.toml
+        fi
     } > "$CRI_CONTAINERD_CONFIG"
 }
 
@@ -12645,6 +10981,18 @@ ensureContainerd() {
     systemctlEnableAndStart containerd || exit $ERR_SYSTEMCTL_START_FAIL
 }
 
+configureNodeSearchDomains() {
+    NODE_SEARCH_DOMAINS=$(cat $NODE_SEARCH_DOMAINS_FILE)
+    if systemctl is-active --quiet systemd-resolved; then
+        mkdir -p /etc/systemd/resolved.conf.d
+        echo -e "[Resolve]\nDomains=${NODE_SEARCH_DOMAINS}" > /etc/systemd/resolved.conf.d/search-domains.conf || exit $ERR_CUSTOM_SEARCH_DOMAINS_FAIL
+        systemctl restart systemd-resolved || exit $ERR_CUSTOM_SEARCH_DOMAINS_FAIL
+    else
+        echo "search ${NODE_SEARCH_DOMAINS}" >> /etc/resolvconf/resolv.conf.d/base || exit $ERR_CUSTOM_SEARCH_DOMAINS_FAIL
+        resolvconf -u || exit $ERR_CUSTOM_SEARCH_DOMAINS_FAIL
+    fi
+}
+
 ensureDocker() {
     DOCKER_SERVICE_EXEC_START_FILE=/etc/systemd/system/docker.service.d/exec_start.conf
     wait_for_file 1200 1 $DOCKER_SERVICE_EXEC_START_FILE || exit $ERR_FILE_WATCH_TIMEOUT
@@ -12699,6 +11047,14 @@ ensureLabelNodes() {
     systemctlEnableAndStart label-nodes || exit $ERR_SYSTEMCTL_START_FAIL
 }
 
+ensureScheduledEventsHandler() {
+    SCHEDULED_EVENTS_HANDLER_SCRIPT_FILE=/opt/azure/containers/scheduled-events-handler.sh
+    wait_for_file 1200 1 $SCHEDULED_EVENTS_HANDLER_SCRIPT_FILE || exit $ERR_FILE_WATCH_TIMEOUT
+    SCHEDULED_EVENTS_HANDLER_SYSTEMD_FILE=/etc/systemd/system/scheduled-events-handler.service
+    wait_for_file 1200 1 $SCHEDULED_EVENTS_HANDLER_SYSTEMD_FILE || exit $ERR_FILE_WATCH_TIMEOUT
+    systemctlEnableAndStart scheduled-events-handler || exit $ERR_SYSTEMCTL_START_FAIL
+}
+
 ensureJournal() {
     {
         echo "Storage=persistent"
@@ -12716,6 +11072,17 @@ ensureK8sControlPlane() {
     retrycmd_if_failure 120 5 25 $KUBECTL 2>/dev/null cluster-info || exit $ERR_K8S_RUNNING_TIMEOUT
 }
 
+ensureOpenServiceMeshNamespaces() {
+    if $REBOOTREQUIRED || [ "$NO_OUTBOUND" = "true" ]; then
+        return
+    fi
+    # the addon manager creates the namespaces missing, the existing ones are enrolled here
+    for NAMESPACE in ${OSM_NAMESPACES//,/ }; do
+        retrycmd_if_failure 120 5 25 $KUBECTL label namespace $NAMESPACE --overwrite openservicemesh.io/monitored-by=osm || exit $ERR_OSM_NAMESPACES_FAIL
+        retrycmd_if_failure 120 5 25 $KUBECTL annotate namespace $NAMESPACE --overwrite openservicemesh.io/sidecar-injection=enabled || exit $ERR_OSM_NAMESPACES_FAIL
+    done
+}
+
 ensureEtcd() {
     retrycmd_if_failure 120 5 25 curl --cacert /etc/kubernetes/certs/ca.crt --cert /etc/kubernetes/certs/etcdclient.crt --key /etc/kubernetes/certs/etcdclient.key ${ETCD_CLIENT_URL}/v2/machines || exit $ERR_ETCD_RUNNING_TIMEOUT
 }
@@ -12782,7 +11149,7 @@ configClusterAutoscalerAddon() {
     sed -i "s|<tenantID>|$(echo $TENANT_ID | base64)|g" $CLUSTER_AUTOSCALER_ADDON_FILE
     sed -i "s|<rg>|$(echo $RESOURCE_GROUP | base64)|g" $CLUSTER_AUTOSCALER_ADDON_FILE
     sed -i "s|<vmType>|$(echo $VM_TYPE | base64)|g" $CLUSTER_AUTOSCALER_ADDON_FILE
-    sed -i "s|<vmssName>|$PRIMARY_SCALE_SET|g" $CLUSTER_AUTOSCALER_ADDON_FILE
+    sed -i "s|<userAssignedIdentityID>|$USER_ASSIGNED_IDENTITY_ID|g" $CLUSTER_AUTOSCALER_ADDON_FILE
 }
 
 configACIConnectorAddon() {
@@ -12800,6 +11167,22 @@ configACIConnectorAddon() {
     sed -i "s|<key>|$ACI_CONNECTOR_KEY|g" $ACI_CONNECTOR_ADDON_FILE
 }
 
+configAppGwIngressAddon() {
+    APPGW_INGRESS_ADDON_FILE=/etc/kubernetes/addons/appgw-ingress-deployment.yaml
+    wait_for_file 1200 1 $APPGW_INGRESS_ADDON_FILE || exit $ERR_FILE_WATCH_TIMEOUT
+    sed -i "s|<subscriptionId>|$SUBSCRIPTION_ID|g" $APPGW_INGRESS_ADDON_FILE
+    sed -i "s|<resourceGroup>|$RESOURCE_GROUP|g" $APPGW_INGRESS_ADDON_FILE
+    sed -i "s|<appGwName>|$APPGW_NAME|g" $APPGW_INGRESS_ADDON_FILE
+    sed -i "s|<identityResourceId>|$APPGW_IDENTITY_RESOURCE_ID|g" $APPGW_INGRESS_ADDON_FILE
+    sed -i "s|<identityClientId>|$APPGW_IDENTITY_CLIENT_ID|g" $APPGW_INGRESS_ADDON_FILE
+}
+
+configAzurePolicyAddon() {
+    AZURE_POLICY_ADDON_FILE=/etc/kubernetes/addons/azure-policy-deployment.yaml
+    wait_for_file 1200 1 $AZURE_POLICY_ADDON_FILE || exit $ERR_FILE_WATCH_TIMEOUT
+    sed -i "s|<resourceId>|/subscriptions/$SUBSCRIPTION_ID/resourceGroups/$RESOURCE_GROUP|g" $AZURE_POLICY_ADDON_FILE
+}
+
 configAddons() {
     if [[ "${CLUSTER_AUTOSCALER_ADDON}" = True ]]; then
         configClusterAutoscalerAddon
@@ -12808,6 +11191,14 @@ configAddons() {
     if [[ "${ACI_CONNECTOR_ADDON}" = True ]]; then
         configACIConnectorAddon
     fi
+
+    if [[ "${APPGW_INGRESS_ADDON}" = true ]]; then
+        configAppGwIngressAddon
+    fi
+
+    if [[ "${AZURE_POLICY_ADDON}" = true ]]; then
+        configAzurePolicyAddon
+    fi
 }
 
 configGPUDrivers() {
@@ -13070,6 +11461,7 @@ ERR_ETCD_DOWNLOAD_TIMEOUT=12 # Timeout waiting for etcd to download
 ERR_ETCD_VOL_MOUNT_FAIL=13 # Unable to mount etcd disk volume
 ERR_ETCD_START_TIMEOUT=14 # Unable to start etcd runtime
 ERR_ETCD_CONFIG_FAIL=15 # Unable to configure etcd cluster
+ERR_ETCD_NIC_CONFIG_FAIL=16 # Unable to configure the NIC of the dedicated etcd subnet
 ERR_DOCKER_INSTALL_TIMEOUT=20 # Timeout waiting for docker install
 ERR_DOCKER_DOWNLOAD_TIMEOUT=21 # Timout waiting for docker download(s)
 ERR_DOCKER_KEY_DOWNLOAD_TIMEOUT=22 # Timeout waiting to download docker repo key
@@ -13084,6 +11476,7 @@ ERR_KUBECTL_NOT_FOUND=32 # kubectl client binary not found on local disk
 ERR_IMG_DOWNLOAD_TIMEOUT=33 # Timeout waiting for img download
 ERR_KUBELET_START_FAIL=34 # kubelet could not be started by systemctl
 ERR_CONTAINER_IMG_PULL_TIMEOUT=35 # Timeout trying to pull a container image
+ERR_OSM_NAMESPACES_FAIL=36 # Unable to enroll the namespaces of the open-service-mesh addon in the mesh
 ERR_CNI_DOWNLOAD_TIMEOUT=41 # Timeout waiting for CNI download(s)
 ERR_MS_PROD_DEB_DOWNLOAD_TIMEOUT=42 # Timeout waiting for https://packages.microsoft.com/config/ubuntu/16.04/packages-microsoft-prod.deb
 ERR_MS_PROD_DEB_PKG_ADD_FAIL=43 # Failed to add repo pkg file
@@ -13106,6 +11499,7 @@ ERR_CSE_PROVISION_SCRIPT_NOT_READY_TIMEOUT=100 # Timeout waiting for cloud-init
 ERR_APT_DIST_UPGRADE_TIMEOUT=101 # Timeout waiting for apt-get dist-upgrade to complete
 ERR_APT_PURGE_FAIL=102 # Error purging distro packages
 ERR_SYSCTL_RELOAD=103 # Error reloading sysctl config
+ERR_APT_MIRROR_CONFIG_FAIL=104 # Error configuring the apt mirror of an air-gapped cluster
 ERR_CIS_ASSIGN_ROOT_PW=111 # Error assigning root password in CIS enforcement
 ERR_CIS_ASSIGN_FILE_PERMISSION=112 # Error assigning permission to a file in CIS enforcement
 ERR_PACKER_COPY_FILE=113 # Error writing a file to disk during VHD CI
@@ -13374,9 +11768,24 @@ installEtcd() {
     fi
 }
 
+configureAptMirror() {
+    UBUNTU_CODENAME=$(lsb_release -c -s)
+    retrycmd_if_failure_no_stats 120 5 25 curl -fsSL ${PACKAGES_URL}/microsoft/keys/microsoft.asc | gpg --dearmor > /tmp/microsoft.gpg || exit $ERR_MS_GPG_KEY_DOWNLOAD_TIMEOUT
+    retrycmd_if_failure 10 5 10 cp /tmp/microsoft.gpg /etc/apt/trusted.gpg.d/ || exit $ERR_MS_GPG_KEY_DOWNLOAD_TIMEOUT
+    rm -f /etc/apt/sources.list.d/*.list || exit $ERR_APT_MIRROR_CONFIG_FAIL
+    cat << EOF > /etc/apt/sources.list || exit $ERR_APT_MIRROR_CONFIG_FAIL
+deb ${PACKAGES_URL}/ubuntu ${UBUNTU_CODENAME} main restricted universe multiverse
+deb ${PACKAGES_URL}/ubuntu ${UBUNTU_CODENAME}-updates main restricted universe multiverse
+deb ${PACKAGES_URL}/ubuntu ${UBUNTU_CODENAME}-security main restricted universe multiverse
+deb [arch=amd64] ${PACKAGES_URL}/microsoft/ubuntu/${UBUNTU_RELEASE}/prod ${UBUNTU_CODENAME} main
+EOF
+}
+
 installDeps() {
-    retrycmd_if_failure_no_stats 120 5 25 curl -fsSL https://packages.microsoft.com/config/ubuntu/${UBUNTU_RELEASE}/packages-microsoft-prod.deb > /tmp/packages-microsoft-prod.deb || exit $ERR_MS_PROD_DEB_DOWNLOAD_TIMEOUT
-    retrycmd_if_failure 60 5 10 dpkg -i /tmp/packages-microsoft-prod.deb || exit $ERR_MS_PROD_DEB_PKG_ADD_FAIL
+    if [[ -z "${PACKAGES_URL}" ]]; then
+        retrycmd_if_failure_no_stats 120 5 25 curl -fsSL https://packages.microsoft.com/config/ubuntu/${UBUNTU_RELEASE}/packages-microsoft-prod.deb > /tmp/packages-microsoft-prod.deb || exit $ERR_MS_PROD_DEB_DOWNLOAD_TIMEOUT
+        retrycmd_if_failure 60 5 10 dpkg -i /tmp/packages-microsoft-prod.deb || exit $ERR_MS_PROD_DEB_PKG_ADD_FAIL
+    fi
     aptmarkWALinuxAgent hold
     apt_get_update || exit $ERR_APT_UPDATE_TIMEOUT
     apt_get_dist_upgrade || exit $ERR_APT_DIST_UPGRADE_TIMEOUT
@@ -13453,10 +11862,12 @@ installMoby() {
         echo "dockerd $MOBY_VERSION is already installed, skipping Moby download"
     else
         removeMoby
-        retrycmd_if_failure_no_stats 120 5 25 curl https://packages.microsoft.com/config/ubuntu/${UBUNTU_RELEASE}/prod.list > /tmp/microsoft-prod.list || exit $ERR_MOBY_APT_LIST_TIMEOUT
-        retrycmd_if_failure 10 5 10 cp /tmp/microsoft-prod.list /etc/apt/sources.list.d/ || exit $ERR_MOBY_APT_LIST_TIMEOUT
-        retrycmd_if_failure_no_stats 120 5 25 curl https://packages.microsoft.com/keys/microsoft.asc | gpg --dearmor > /tmp/microsoft.gpg || exit $ERR_MS_GPG_KEY_DOWNLOAD_TIMEOUT
-        retrycmd_if_failure 10 5 10 cp /tmp/microsoft.gpg /etc/apt/trusted.gpg.d/ || exit $ERR_MS_GPG_KEY_DOWNLOAD_TIMEOUT
+        if [[ -z "${PACKAGES_URL}" ]]; then
+            retrycmd_if_failure_no_stats 120 5 25 curl https://packages.microsoft.com/config/ubuntu/${UBUNTU_RELEASE}/prod.list > /tmp/microsoft-prod.list || exit $ERR_MOBY_APT_LIST_TIMEOUT
+            retrycmd_if_failure 10 5 10 cp /tmp/microsoft-prod.list /etc/apt/sources.list.d/ || exit $ERR_MOBY_APT_LIST_TIMEOUT
+            retrycmd_if_failure_no_stats 120 5 25 curl https://packages.microsoft.com/keys/microsoft.asc | gpg --dearmor > /tmp/microsoft.gpg || exit $ERR_MS_GPG_KEY_DOWNLOAD_TIMEOUT
+            retrycmd_if_failure 10 5 10 cp /tmp/microsoft.gpg /etc/apt/trusted.gpg.d/ || exit $ERR_MS_GPG_KEY_DOWNLOAD_TIMEOUT
+        fi
         apt_get_update || exit $ERR_APT_UPDATE_TIMEOUT
         MOBY_CLI=${MOBY_VERSION}
         if [[ "${MOBY_CLI}" == "3.0.4" ]]; then
@@ -13493,25 +11904,25 @@ installNetworkPlugin() {
 
 downloadCNI() {
     mkdir -p $CNI_DOWNLOADS_DIR
-    CNI_TGZ_TMP=$(echo ${CNI_PLUGINS_URL} | cut -d "/" -f 5)
+    CNI_TGZ_TMP=${CNI_PLUGINS_URL##*/}
     retrycmd_get_tarball 120 5 "$CNI_DOWNLOADS_DIR/${CNI_TGZ_TMP}" ${CNI_PLUGINS_URL} || exit $ERR_CNI_DOWNLOAD_TIMEOUT
 }
 
 downloadAzureCNI() {
     mkdir -p $CNI_DOWNLOADS_DIR
-    CNI_TGZ_TMP=$(echo ${VNET_CNI_PLUGINS_URL} | cut -d "/" -f 5)
+    CNI_TGZ_TMP=${VNET_CNI_PLUGINS_URL##*/}
     retrycmd_get_tarball 120 5 "$CNI_DOWNLOADS_DIR/${CNI_TGZ_TMP}" ${VNET_CNI_PLUGINS_URL} || exit $ERR_CNI_DOWNLOAD_TIMEOUT
 }
 
 downloadContainerd() {
     CONTAINERD_DOWNLOAD_URL="${CONTAINERD_DOWNLOAD_URL_BASE}cri-containerd-${CONTAINERD_VERSION}.linux-amd64.tar.gz"
     mkdir -p $CONTAINERD_DOWNLOADS_DIR
-    CONTAINERD_TGZ_TMP=$(echo ${CONTAINERD_DOWNLOAD_URL} | cut -d "/" -f 5)
+    CONTAINERD_TGZ_TMP=${CONTAINERD_DOWNLOAD_URL##*/}
     retrycmd_get_tarball 120 5 "$CONTAINERD_DOWNLOADS_DIR/${CONTAINERD_TGZ_TMP}" ${CONTAINERD_DOWNLOAD_URL} || exit $ERR_CONTAINERD_DOWNLOAD_TIMEOUT
 }
 
 installCNI() {
-    CNI_TGZ_TMP=$(echo ${CNI_PLUGINS_URL} | cut -d "/" -f 5)
+    CNI_TGZ_TMP=${CNI_PLUGINS_URL##*/}
     if [[ ! -f "$CNI_DOWNLOADS_DIR/${CNI_TGZ_TMP}" ]]; then
         downloadCNI
     fi
@@ -13522,7 +11933,7 @@ installCNI() {
 }
 
 installAzureCNI() {
-    CNI_TGZ_TMP=$(echo ${VNET_CNI_PLUGINS_URL} | cut -d "/" -f 5)
+    CNI_TGZ_TMP=${VNET_CNI_PLUGINS_URL##*/}
     if [[ ! -f "$CNI_DOWNLOADS_DIR/${CNI_TGZ_TMP}" ]]; then
         downloadAzureCNI
     fi
@@ -13688,6 +12099,7 @@ if [[ "${TARGET_ENVIRONMENT,,}" == "${AZURE_STACK_ENV}"  ]]; then
 fi
 
 CUSTOM_SEARCH_DOMAIN_SCRIPT=/opt/azure/containers/setup-custom-search-domains.sh
+NODE_SEARCH_DOMAINS_FILE=/opt/azure/containers/node-search-domains
 
 set +x
 ETCD_PEER_CERT=$(echo ${ETCD_PEER_CERTIFICATES} | cut -d'[' -f 2 | cut -d']' -f 1 | cut -d',' -f $((${NODE_INDEX}+1)))
@@ -13715,6 +12127,10 @@ else
     FULL_INSTALL_REQUIRED=true
 fi
 
+if [[ $OS == $UBUNTU_OS_NAME ]] && [[ -n "${PACKAGES_URL}" ]]; then
+    configureAptMirror
+fi
+
 if [[ $OS == $UBUNTU_OS_NAME ]] && [ "$FULL_INSTALL_REQUIRED" = "true" ]; then
     installDeps
 else
@@ -13764,6 +12180,9 @@ if [[ -n "${MASTER_NODE}" ]]; then
 fi
 # configure etcd if we are configured for etcd
 if [[ -n "${MASTER_NODE}" ]] && [[ -z "${COSMOS_URI}" ]]; then
+    if [[ -n "${ETCD_FIRST_CONSECUTIVE_STATIC_IP}" ]]; then
+        ensureEtcdNIC
+    fi
     configureEtcd
 else
     removeEtcd
@@ -13774,6 +12193,10 @@ if [ -f $CUSTOM_SEARCH_DOMAIN_SCRIPT ]; then
     $CUSTOM_SEARCH_DOMAIN_SCRIPT > /opt/azure/containers/setup-custom-search-domain.log 2>&1 || exit $ERR_CUSTOM_SEARCH_DOMAINS_FAIL
 fi
 
+if [ -f $NODE_SEARCH_DOMAINS_FILE ]; then
+    configureNodeSearchDomains
+fi
+
 if [[ "$CONTAINER_RUNTIME" == "docker" ]]; then
     ensureDocker
 elif [[ "$CONTAINER_RUNTIME" == "kata-containers" ]]; then
@@ -13819,6 +12242,10 @@ fi
 ensureKubelet
 ensureJournal
 
+if [[ "${SCHEDULED_EVENTS_HANDLER}" == true ]]; then
+    ensureScheduledEventsHandler
+fi
+
 if [[ -n "${MASTER_NODE}" ]]; then
     if version_gte ${KUBERNETES_VERSION} 1.16; then
       ensureLabelNodes
@@ -13828,6 +12255,9 @@ if [[ -n "${MASTER_NODE}" ]]; then
       ensureEtcd
     fi
     ensureK8sControlPlane
+    if [[ -n "${OSM_NAMESPACES}" ]]; then
+        ensureOpenServiceMeshNamespaces
+    fi
 fi
 
 if $FULL_INSTALL_REQUIRED; then
@@ -14126,6 +12556,68 @@ func k8sCloudInitArtifactsEtcdService() (*asset, error) {
 	return a, nil
 }
 
+var _k8sCloudInitArtifactsGeneratekonnectivitycertsSh = []byte(`#!/bin/bash
+
+# Generates the certificates of the konnectivity-server static pod, signed by the cluster CA:
+# a server certificate valid for the names of the API server, which the konnectivity-agents of the nodes verify,
+# and the client certificate the API server authenticates to the konnectivity-server with.
+
+K8S_CA_CRT_FILEPATH="${K8S_CA_CRT_FILEPATH:=/etc/kubernetes/certs/ca.crt}"
+K8S_CA_KEY_FILEPATH="${K8S_CA_KEY_FILEPATH:=/etc/kubernetes/certs/ca.key}"
+K8S_APISERVER_CRT_FILEPATH="${K8S_APISERVER_CRT_FILEPATH:=/etc/kubernetes/certs/apiserver.crt}"
+KONNECTIVITY_SERVER_KEY="${KONNECTIVITY_SERVER_KEY:=/etc/kubernetes/certs/konnectivity-server.key}"
+KONNECTIVITY_SERVER_CRT="${KONNECTIVITY_SERVER_CRT:=/etc/kubernetes/certs/konnectivity-server.crt}"
+KONNECTIVITY_CLIENT_KEY="${KONNECTIVITY_CLIENT_KEY:=/etc/kubernetes/certs/konnectivity-client.key}"
+KONNECTIVITY_CLIENT_CRT="${KONNECTIVITY_CLIENT_CRT:=/etc/kubernetes/certs/konnectivity-client.crt}"
+
+if [[ -s $KONNECTIVITY_SERVER_CRT && -s $KONNECTIVITY_CLIENT_CRT ]]; then
+    echo "found konnectivity certs, not creating them"
+    exit 0
+fi
+
+RANDFILE=$(mktemp)
+export RANDFILE
+EXTFILE=$(mktemp)
+CSR=$(mktemp)
+
+# the agents connect to the address of the API server, so the server certificate gets the same names
+SUBJECT_ALT_NAME=$(openssl x509 -in $K8S_APISERVER_CRT_FILEPATH -noout -text | grep -A1 "Subject Alternative Name" | tail -n1 | sed 's/IP Address:/IP:/g; s/ //g')
+
+generate_cert() {
+    KEY=$1
+    CRT=$2
+    SUBJECT=$3
+    EXTENSIONS=$4
+    echo "$EXTENSIONS" > $EXTFILE
+    openssl genrsa -out $KEY 2048
+    chmod 600 $KEY
+    openssl req -new -key $KEY -out $CSR -subj "$SUBJECT"
+    openssl x509 -req -days 730 -in $CSR -CA $K8S_CA_CRT_FILEPATH -CAkey $K8S_CA_KEY_FILEPATH -set_serial "0x$(openssl rand -hex 16)" -extfile $EXTFILE -out $CRT
+}
+
+generate_cert $KONNECTIVITY_SERVER_KEY $KONNECTIVITY_SERVER_CRT "/CN=konnectivity-server" "extendedKeyUsage=serverAuth
+subjectAltName=${SUBJECT_ALT_NAME}"
+generate_cert $KONNECTIVITY_CLIENT_KEY $KONNECTIVITY_CLIENT_CRT "/CN=kube-apiserver-konnectivity-client" "extendedKeyUsage=clientAuth"
+
+rm -f $RANDFILE $EXTFILE $CSR
+#EOF
+`)
+
+func k8sCloudInitArtifactsGeneratekonnectivitycertsShBytes() ([]byte, error) {
+	return _k8sCloudInitArtifactsGeneratekonnectivitycertsSh, nil
+}
+
+func k8sCloudInitArtifactsGeneratekonnectivitycertsSh() (*asset, error) {
+	bytes, err := k8sCloudInitArtifactsGeneratekonnectivitycertsShBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/cloud-init/artifacts/generatekonnectivitycerts.sh", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _k8sCloudInitArtifactsGenerateproxycertsSh = []byte(`#!/bin/bash
 
 source /opt/azure/containers/provision_source.sh
@@ -14852,6 +13344,115 @@ func k8sCloudInitArtifactsRsyslogD60CisConf() (*asset, error) {
 	return a, nil
 }
 
+var _k8sCloudInitArtifactsScheduledEventsHandlerService = []byte(`[Unit]
+Description=Cordon and drain the node when Azure schedules the preemption or the deletion of its VM
+After=kubelet.service
+[Service]
+Restart=always
+RestartSec=10
+ExecStart=/bin/bash /opt/azure/containers/scheduled-events-handler.sh
+#EOF
+`)
+
+func k8sCloudInitArtifactsScheduledEventsHandlerServiceBytes() ([]byte, error) {
+	return _k8sCloudInitArtifactsScheduledEventsHandlerService, nil
+}
+
+func k8sCloudInitArtifactsScheduledEventsHandlerService() (*asset, error) {
+	bytes, err := k8sCloudInitArtifactsScheduledEventsHandlerServiceBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/cloud-init/artifacts/scheduled-events-handler.service", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sCloudInitArtifactsScheduledEventsHandlerSh = []byte(`#!/usr/bin/env bash
+
+# Cordons and drains this node when Azure schedules the preemption or the deletion of its VM, then starts the event
+# so that the VM does not wait for the end of its notice once the node is drained.
+#
+# Azure schedules Preempt events for the VMs of a low-priority scale set, and Terminate events for the VMs of a
+# scale set with terminate notifications enabled, e.g. when it is scaled in.
+
+set -o nounset
+set -o pipefail
+
+SCHEDULED_EVENTS_URL="http://169.254.169.254/metadata/scheduledevents?api-version=2019-08-01"
+INSTANCE_NAME_URL="http://169.254.169.254/metadata/instance/compute/name?api-version=2019-08-01&format=text"
+KUBECONFIG=/var/lib/kubelet/kubeconfig
+POLL_SECONDS=${POLL_SECONDS:-5}
+# the drain must finish within the 30 second notice of a preemption
+DRAIN_TIMEOUT_PREEMPT=25s
+DRAIN_TIMEOUT_TERMINATE=${DRAIN_TIMEOUT_TERMINATE:-10m}
+
+KUBECTL=kubectl
+if [[ -x /opt/kubectl ]]; then
+  KUBECTL=/opt/kubectl
+fi
+NODE_NAME=$(hostname | tr '[:upper:]' '[:lower:]')
+
+until INSTANCE_NAME=$(curl -fsS -H Metadata:true "${INSTANCE_NAME_URL}"); do
+  echo "The name of this instance could not be retrieved from the instance metadata service, trying again in ${POLL_SECONDS} seconds"
+  sleep "${POLL_SECONDS}"
+done
+
+drain() {
+  local timeout=$1
+  ${KUBECTL} --kubeconfig "${KUBECONFIG}" cordon "${NODE_NAME}"
+  ${KUBECTL} --kubeconfig "${KUBECONFIG}" drain "${NODE_NAME}" --ignore-daemonsets --delete-local-data --force --grace-period=-1 --timeout="${timeout}"
+}
+
+start_event() {
+  local event_id=$1
+  curl -fsS -H Metadata:true -X POST -d "{\"StartRequests\": [{\"EventId\": \"${event_id}\"}]}" "${SCHEDULED_EVENTS_URL}"
+}
+
+HANDLED=""
+while true; do
+  if events=$(curl -fsS -H Metadata:true "${SCHEDULED_EVENTS_URL}"); then
+    # the events scheduled for this instance that are about to remove it
+    echo "${events}" | jq -r --arg instance "${INSTANCE_NAME}" \
+      '.Events[] | select((.EventType == "Preempt" or .EventType == "Terminate") and (.Resources | index($instance))) | "\(.EventId) \(.EventType)"' |
+    while read -r event_id event_type; do
+      if [[ " ${HANDLED} " == *" ${event_id} "* ]]; then
+        continue
+      fi
+      echo "Azure scheduled the ${event_type} event ${event_id} for ${INSTANCE_NAME}, draining node ${NODE_NAME}"
+      timeout=${DRAIN_TIMEOUT_TERMINATE}
+      if [[ "${event_type}" == "Preempt" ]]; then
+        timeout=${DRAIN_TIMEOUT_PREEMPT}
+      fi
+      drain "${timeout}" || echo "Node ${NODE_NAME} could not be drained before the ${event_type} event, starting it anyway"
+      start_event "${event_id}" && echo "Started the ${event_type} event ${event_id}"
+      echo "${event_id}" >> /var/run/scheduled-events-handled
+    done
+    HANDLED=$(cat /var/run/scheduled-events-handled 2>/dev/null)
+  else
+    echo "The scheduled events could not be retrieved from the instance metadata service"
+  fi
+  sleep "${POLL_SECONDS}"
+done
+#EOF
+`)
+
+func k8sCloudInitArtifactsScheduledEventsHandlerShBytes() ([]byte, error) {
+	return _k8sCloudInitArtifactsScheduledEventsHandlerSh, nil
+}
+
+func k8sCloudInitArtifactsScheduledEventsHandlerSh() (*asset, error) {
+	bytes, err := k8sCloudInitArtifactsScheduledEventsHandlerShBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/cloud-init/artifacts/scheduled-events-handler.sh", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _k8sCloudInitArtifactsSetupCustomSearchDomainsSh = []byte(`#!/bin/bash
 set -x
 source /opt/azure/containers/provision_source.sh
@@ -15384,10 +13985,23 @@ write_files:
       "log-opts":  {
          "max-size": "50m",
          "max-file": "5"
-      }
+      }{{if HasDockerRegistryMirrors}}
+      ,"registry-mirrors": {{GetDockerRegistryMirrors}}{{end}}
     }
 {{end}}
 
+{{if and NeedsContainerd HasRegistryMirrors}}
+- path: /etc/containerd/registry-mirrors.toml
+  permissions: "0644"
+  owner: root
+  content: |
+{{range GetRegistryMirrors}}
+    [plugins.cri.registry.mirrors."{{.Registry}}"]
+    endpoint = {{GetContainerdRegistryMirrorEndpoints .}}
+{{end}}
+    #EOF
+{{end}}
+
 {{if eq .OrchestratorProfile.KubernetesConfig.NetworkPlugin "cilium"}}
 - path: /etc/systemd/system/sys-fs-bpf.mount
   permissions: "0644"
@@ -15397,6 +14011,15 @@ write_files:
     {{WrapAsVariable "systemdBPFMount"}}
 {{end}}
 
+{{if HasCloudProviderConfig}}
+- path: /etc/kubernetes/azure-cloud-provider-config.json
+  permissions: "0600"
+  encoding: base64
+  owner: root
+  content: |
+    {{GetBase64EncodedCloudProviderConfig}}
+{{end}}
+
 - path: /etc/kubernetes/certs/ca.crt
   permissions: "0644"
   encoding: base64
@@ -15420,6 +14043,31 @@ write_files:
     {{CloudInitData "generateProxyCertsScript"}}
 {{end}}
 
+{{if .OrchestratorProfile.KubernetesConfig.IsKonnectivityEnabled}}
+- path: /etc/kubernetes/generate-konnectivity-certs.sh
+  permissions: "0744"
+  encoding: gzip
+  owner: root
+  content: !!binary |
+    {{CloudInitData "generateKonnectivityCertsScript"}}
+
+- path: /etc/kubernetes/egress-selector-configuration.yaml
+  permissions: "0644"
+  owner: root
+  content: |
+    apiVersion: apiserver.k8s.io/v1alpha1
+    kind: EgressSelectorConfiguration
+    egressSelections:
+    - name: cluster
+      connection:
+        type: http-connect
+        httpConnect:
+          url: https://127.0.0.1:8131
+          caBundle: /etc/kubernetes/certs/ca.crt
+          clientKey: /etc/kubernetes/certs/konnectivity-client.key
+          clientCert: /etc/kubernetes/certs/konnectivity-client.crt
+{{end}}
+
 {{if HasLinuxProfile}}{{if HasCustomSearchDomain}}
 - path: /opt/azure/containers/setup-custom-search-domains.sh
   permissions: "0744"
@@ -15429,7 +14077,15 @@ write_files:
     {{CloudInitData "customSearchDomainsScript"}}
 {{end}}{{end}}
 
-- path: /var/lib/kubelet/kubeconfig
+{{if HasNodeSearchDomains .MasterProfile.DNSConfig}}
+- path: /opt/azure/containers/node-search-domains
+  permissions: "0644"
+  owner: root
+  content: |
+    {{GetNodeSearchDomains .MasterProfile.DNSConfig}}
+{{end}}
+
+- path: /var/lib/kubelet/kubeconfig
   permissions: "0644"
   owner: root
   content: |
@@ -15505,11 +14161,7 @@ MASTER_CONTAINER_ADDONS_PLACEHOLDER
   permissions: "0644"
   owner: root
   content: |
-{{if NeedsContainerd}}
-    KUBELET_OPTS=--container-runtime=remote --runtime-request-timeout=15m --container-runtime-endpoint=unix:///run/containerd/containerd.sock
-{{else}}
     KUBELET_OPTS=
-{{end}}
     KUBELET_CONFIG={{GetKubeletConfigKeyVals .MasterProfile.KubernetesConfig}}
     KUBELET_IMAGE={{WrapAsParameter "kubernetesHyperkubeSpec"}}
 {{if IsKubernetesVersionGe "1.16.0-alpha.1"}}
@@ -15573,6 +14225,10 @@ MASTER_CONTAINER_ADDONS_PLACEHOLDER
     sed -i "s|<img>|{{WrapAsParameter "kubernetesKubeDNSSpec"}}|g; s|<imgMasq>|{{WrapAsParameter "kubernetesDNSMasqSpec"}}|g; s|<imgHealthz>|{{WrapAsParameter "kubernetesExecHealthzSpec"}}|g; s|<imgSidecar>|{{WrapAsParameter "kubernetesDNSSidecarSpec"}}|g; s|<domain>|{{WrapAsParameter "kubernetesKubeletClusterDomain"}}|g; s|<clustIP>|{{WrapAsParameter "kubeDNSServiceIP"}}|g" $KUBEDNS
 {{else if IsKubernetesVersionGe "1.12.0"}}
     sed -i "s|<img>|{{WrapAsParameter "kubernetesCoreDNSSpec"}}|g; s|<domain>|{{WrapAsParameter "kubernetesKubeletClusterDomain"}}|g; s|<clustIP>|{{WrapAsParameter "kubeDNSServiceIP"}}|g" /etc/kubernetes/addons/coredns.yaml
+    sed -i "s|<cpuReq>|{{.GetCoreDNSResources.CPURequests}}|g; s|<memReq>|{{.GetCoreDNSResources.MemoryRequests}}|g; s|<memLimit>|{{.GetCoreDNSResources.MemoryLimits}}|g" /etc/kubernetes/addons/coredns.yaml
+{{- if gt .GetCoreDNSReplicas 0}}
+    sed -i "s|#<replicas>|replicas: {{.GetCoreDNSReplicas}}|g" /etc/kubernetes/addons/coredns.yaml
+{{- end}}
 {{else}}
     sed -i "s|<img>|{{WrapAsParameter "kubernetesKubeDNSSpec"}}|g; s|<imgMasq>|{{WrapAsParameter "kubernetesDNSMasqSpec"}}|g; s|<imgSidecar>|{{WrapAsParameter "kubernetesDNSSidecarSpec"}}|g; s|<domain>|{{WrapAsParameter "kubernetesKubeletClusterDomain"}}|g; s|<clustIP>|{{WrapAsParameter "kubeDNSServiceIP"}}|g" $KUBEDNS
 {{end}}
@@ -15585,6 +14241,16 @@ MASTER_CONTAINER_ADDONS_PLACEHOLDER
     sed -i "s|<cloud>|{{WrapAsParameter "kubernetesClusterAutoscalerAzureCloud"}}|g; s|<useManagedIdentity>|{{WrapAsParameter "kubernetesClusterAutoscalerUseManagedIdentity"}}|g" /etc/kubernetes/addons/cluster-autoscaler-deployment.yaml
 {{end}}
 
+{{if .OrchestratorProfile.KubernetesConfig.IsAzureWorkloadIdentityEnabled}}
+    sed -i "s|<cloud>|{{WrapAsParameter "targetEnvironment"}}|g; s|<tenantID>|{{WrapAsVariable "tenantID"}}|g" /etc/kubernetes/addons/azure-workload-identity-webhook-deployment.yaml
+{{end}}
+
+{{if .OrchestratorProfile.KubernetesConfig.IsKonnectivityEnabled}}
+    /etc/kubernetes/generate-konnectivity-certs.sh
+    sed -i "s|<img>|{{GetKonnectivityServerImage}}|g; s|<serverCount>|{{.MasterProfile.Count}}|g" /etc/kubernetes/manifests/konnectivity-server.yaml
+    sed -i "s|<kubernetesAPIServerIP>|{{WrapAsVariable "kubernetesAPIServerIP"}}|g" /etc/kubernetes/addons/konnectivity-agent-daemonset.yaml
+{{end}}
+
 {{if EnableDataEncryptionAtRest }}
     sed -i "s|<etcdEncryptionSecret>|\"{{WrapAsParameter "etcdEncryptionKey"}}\"|g" /etc/kubernetes/encryption-config.yaml
 {{end}}
@@ -15601,6 +14267,12 @@ MASTER_CONTAINER_ADDONS_PLACEHOLDER
 {{if eq .OrchestratorProfile.KubernetesConfig.NetworkPlugin "flannel"}}
     sed -i "s|<kubeClusterCidr>|{{WrapAsParameter "kubeClusterCidr"}}|g" /etc/kubernetes/addons/flannel-daemonset.yaml
 {{end}}
+{{if .OrchestratorProfile.KubernetesConfig.IsKubeProxyReplaced}}
+    sed -i "s|<kubernetesAPIServerIP>|{{WrapAsVariable "kubernetesAPIServerIP"}}|g" /etc/kubernetes/addons/cilium-daemonset.yaml
+{{end}}
+{{if .OrchestratorProfile.KubernetesConfig.IsAddonEnabled "antrea"}}
+    sed -i "s|<kubeServiceCidr>|{{WrapAsParameter "kubeServiceCidr"}}|g" /etc/kubernetes/addons/antrea.yaml
+{{end}}
 {{if UseCloudControllerManager }}
     sed -i "s|<img>|{{WrapAsParameter "kubernetesCcmImageSpec"}}|g" /etc/kubernetes/manifests/cloud-controller-manager.yaml
     sed -i "s|<config>|{{GetK8sRuntimeConfigKeyVals .OrchestratorProfile.KubernetesConfig.CloudControllerManagerConfig}}|g" /etc/kubernetes/manifests/cloud-controller-manager.yaml
@@ -15912,6 +14584,22 @@ write_files:
     {{CloudInitData "dhcpv6ConfigurationScript"}}
 {{end}}
 
+{{if .IsScheduledEventsHandlerEnabled}}
+- path: /opt/azure/containers/scheduled-events-handler.sh
+  permissions: "0544"
+  encoding: gzip
+  owner: root
+  content: !!binary |
+    {{CloudInitData "scheduledEventsHandlerScript"}}
+
+- path: /etc/systemd/system/scheduled-events-handler.service
+  permissions: "0644"
+  encoding: gzip
+  owner: root
+  content: !!binary |
+    {{CloudInitData "scheduledEventsHandlerSystemdService"}}
+{{end}}
+
 {{if .KubernetesConfig.RequiresDocker}}
     {{if not .IsCoreOS}}
         {{if not .IsVHDDistro}}
@@ -15948,7 +14636,8 @@ write_files:
       "log-opts":  {
          "max-size": "50m",
          "max-file": "5"
-      }{{if IsNSeriesSKU .}}
+      }{{if HasDockerRegistryMirrors}}
+      ,"registry-mirrors": {{GetDockerRegistryMirrors}}{{end}}{{if IsNSeriesSKU .}}
       ,"default-runtime": "nvidia",
       "runtimes": {
          "nvidia": {
@@ -15959,6 +14648,18 @@ write_files:
     }
 {{end}}
 
+{{if and NeedsContainerd HasRegistryMirrors}}
+- path: /etc/containerd/registry-mirrors.toml
+  permissions: "0644"
+  owner: root
+  content: |
+{{range GetRegistryMirrors}}
+    [plugins.cri.registry.mirrors."{{.Registry}}"]
+    endpoint = {{GetContainerdRegistryMirrorEndpoints .}}
+{{end}}
+    #EOF
+{{end}}
+
 {{if HasCiliumNetworkPlugin }}
 - path: /etc/systemd/system/sys-fs-bpf.mount
   permissions: "0644"
@@ -15985,6 +14686,15 @@ write_files:
     WantedBy=multi-user.target
 {{end}}
 
+{{if HasCloudProviderConfig}}
+- path: /etc/kubernetes/azure-cloud-provider-config.json
+  permissions: "0600"
+  encoding: base64
+  owner: root
+  content: |
+    {{GetBase64EncodedCloudProviderConfig}}
+{{end}}
+
 - path: /etc/kubernetes/certs/ca.crt
   permissions: "0644"
   encoding: base64
@@ -16008,6 +14718,14 @@ write_files:
     {{CloudInitData "customSearchDomainsScript"}}
 {{end}}{{end}}
 
+{{if HasNodeSearchDomains .DNSConfig}}
+- path: /opt/azure/containers/node-search-domains
+  permissions: "0644"
+  owner: root
+  content: |
+    {{GetNodeSearchDomains .DNSConfig}}
+{{end}}
+
 - path: /var/lib/kubelet/kubeconfig
   permissions: "0644"
   owner: root
@@ -16036,11 +14754,7 @@ write_files:
   permissions: "0644"
   owner: root
   content: |
-{{if NeedsContainerd}}
-    KUBELET_OPTS=--container-runtime=remote --runtime-request-timeout=15m --container-runtime-endpoint=unix:///run/containerd/containerd.sock
-{{else}}
     KUBELET_OPTS=
-{{end}}
     KUBELET_CONFIG={{GetKubeletConfigKeyVals .KubernetesConfig }}
     KUBELET_IMAGE={{WrapAsParameter "kubernetesHyperkubeSpec"}}
     KUBELET_REGISTER_SCHEDULABLE=true
@@ -16182,6 +14896,10 @@ spec:
       containers:
         - name: azure-cnms
           image: {{ContainerImage "azure-cni-networkmonitor"}}
+          resources:
+            requests:
+              cpu: {{ContainerCPUReqs "azure-cni-networkmonitor"}}
+              memory: {{ContainerMemReqs "azure-cni-networkmonitor"}}
           imagePullPolicy: IfNotPresent
           securityContext:
             privileged: true
@@ -16790,6 +15508,10 @@ spec:
       containers:
         - name: azure-npm
           image: {{ContainerImage "azure-npm-daemonset"}}
+          resources:
+            requests:
+              cpu: {{ContainerCPUReqs "azure-npm-daemonset"}}
+              memory: {{ContainerMemReqs "azure-npm-daemonset"}}
           securityContext:
             privileged: true
           env:
@@ -17723,7 +16445,7 @@ rules:
   resources: ["jobs"]
   verbs: ["watch","list"]
 - apiGroups: ["storage.k8s.io"]
-  resources: ["storageclasses"]
+  resources: ["storageclasses","csinodes"]
   verbs: ["get", "list", "watch"]
 ---
 apiVersion: rbac.authorization.k8s.io/v1beta1
@@ -17739,11 +16461,11 @@ metadata:
 rules:
 - apiGroups: [""]
   resources: ["configmaps"]
-  verbs: ["create"]
+  verbs: ["create","list","watch"]
 - apiGroups: [""]
   resources: ["configmaps"]
-  resourceNames: ["cluster-autoscaler-status"]
-  verbs: ["delete","get","update"]
+  resourceNames: ["cluster-autoscaler-status", "cluster-autoscaler-priority-expander"]
+  verbs: ["delete","get","update","watch"]
 ---
 apiVersion: rbac.authorization.k8s.io/v1beta1
 kind: ClusterRoleBinding
@@ -17781,6 +16503,27 @@ subjects:
   - kind: ServiceAccount
     name: cluster-autoscaler
     namespace: kube-system
+{{- if eq (ContainerConfig "expander") "priority"}}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cluster-autoscaler-priority-expander
+  namespace: kube-system
+  labels:
+    k8s-addon: cluster-autoscaler.addons.k8s.io
+    k8s-app: cluster-autoscaler
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+data:
+  priorities: |-
+{{- range $priority, $scaleSets := ClusterAutoscalerPriorities}}
+    {{$priority}}:
+{{- range $scaleSets}}
+    - ^{{.}}$
+{{- end}}
+{{- end}}
+{{- end}}
 ---
 apiVersion: v1
 data:
@@ -17845,8 +16588,18 @@ spec:
         - --logtostderr=true
         - --cloud-provider=azure
         - --skip-nodes-with-local-storage=false
-        - --nodes={{ContainerConfig "min-nodes"}}:{{ContainerConfig "max-nodes"}}:<vmssName>
         - --scan-interval={{ContainerConfig "scan-interval"}}
+        - --scale-down-unneeded-time={{ContainerConfig "scale-down-unneeded-time"}}
+        - --scale-down-delay-after-add={{ContainerConfig "scale-down-delay-after-add"}}
+        - --scale-down-utilization-threshold={{ContainerConfig "scale-down-utilization-threshold"}}
+        - --expander={{ContainerConfig "expander"}}
+{{- if eq (ContainerConfig "auto-discovery") "true"}}
+        - --node-group-auto-discovery=label:cluster-autoscaler-enabled=true,cluster-autoscaler-name={{ClusterAutoscalerClusterName}}
+{{- else}}
+{{- range ClusterAutoscalerNodeGroups}}
+        - --nodes={{.}}
+{{- end}}
+{{- end}}
         env:
         - name: ARM_CLOUD
           value: "<cloud>"
@@ -17882,6 +16635,8 @@ spec:
               name: cluster-autoscaler-azure
         - name: ARM_USE_MANAGED_IDENTITY_EXTENSION
           value: "<useManagedIdentity>"
+        - name: ARM_USER_ASSIGNED_IDENTITY_ID
+          value: "<userAssignedIdentityID>"
         volumeMounts:
         - mountPath: /etc/ssl/certs/ca-certificates.crt
           name: ssl-certs
@@ -18489,6 +17244,15 @@ spec:
       - name: metrics-server
         image: {{ContainerImage "metrics-server"}}
         imagePullPolicy: IfNotPresent
+{{- if ContainerCPUReqs "metrics-server"}}
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "metrics-server"}}
+            memory: {{ContainerMemReqs "metrics-server"}}
+          limits:
+            cpu: {{ContainerCPULimits "metrics-server"}}
+            memory: {{ContainerMemLimits "metrics-server"}}
+{{- end}}
         command:
         - /metrics-server
         - --source=kubernetes.summary_api:''
@@ -18888,3355 +17652,7468 @@ metadata:
 apiVersion: apps/v1
 kind: DaemonSet
 metadata:
-  labels:
-    component: oms-agent
-    tier: node
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-  name: omsagent
+  labels:
+    component: oms-agent
+    tier: node
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+  name: omsagent
+  namespace: kube-system
+spec:
+  selector:
+    matchLabels:
+      component: oms-agent
+      tier: node
+  template:
+    metadata:
+      annotations:
+        agentVersion: {{ContainerConfig "omsAgentVersion"}}
+        dockerProviderVersion: {{ContainerConfig "dockerProviderVersion"}}
+        schema-versions:  {{ContainerConfig "schema-versions"}}
+      labels:
+        component: oms-agent
+        tier: node
+    spec:
+      priorityClassName: system-node-critical
+      serviceAccountName: omsagent
+      containers:
+        - name: omsagent
+          image: {{ContainerImage "omsagent"}}
+          imagePullPolicy: Always
+          resources:
+            limits:
+              cpu: {{ContainerCPULimits "omsagent"}}
+              memory: {{ContainerMemLimits "omsagent"}}
+            requests:
+              cpu: {{ContainerCPUReqs "omsagent"}}
+              memory: {{ContainerMemReqs "omsagent"}}
+          env:
+            - name: NODE_IP
+              valueFrom:
+                fieldRef:
+                  fieldPath: status.hostIP
+            - name: ACS_RESOURCE_NAME
+              value: {{ContainerConfig "clusterName"}}            
+            - name: CONTROLLER_TYPE
+              value: "DaemonSet"
+            - name: ISTEST
+              value: "true"
+          livenessProbe:
+            exec:
+              command:
+                - /bin/bash
+                - -c
+                - /opt/livenessprobe.sh
+            initialDelaySeconds: 60
+            periodSeconds: 60
+          ports:
+            - containerPort: 25225
+              protocol: TCP
+            - containerPort: 25224
+              protocol: UDP
+          securityContext:
+            privileged: true
+          volumeMounts:
+            - mountPath: /hostfs
+              name: host-root
+            - mountPath: /var/run/host
+              name: docker-sock
+            - mountPath: /var/log
+              name: host-log
+            - mountPath: /var/lib/docker/containers
+              name: containerlog-path
+            - mountPath: /etc/kubernetes/host
+              name: azure-json-path
+            - mountPath: /etc/omsagent-secret
+              name: omsagent-secret
+              readOnly: true
+            - mountPath: /etc/config/settings
+              name: settings-vol-config  
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      tolerations:
+        - effect: NoSchedule
+          key: node-role.kubernetes.io/master
+          operator: Equal
+          value: "true"
+      volumes:
+        - name: host-root
+          hostPath:
+            path: /
+        - name: docker-sock
+          hostPath:
+            path: /var/run
+        - name: container-hostname
+          hostPath:
+            path: /etc/hostname
+        - name: host-log
+          hostPath:
+            path: /var/log
+        - name: containerlog-path
+          hostPath:
+            path: /var/lib/docker/containers
+        - name: azure-json-path
+          hostPath:
+            path: /etc/kubernetes
+        - name: omsagent-secret
+          secret:
+            secretName: omsagent-secret
+        - name: settings-vol-config
+          configMap:
+            name: container-azm-ms-agentconfig
+            optional: true    
+  updateStrategy:
+    type: RollingUpdate
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: omsagent-rs
+  namespace: kube-system
+  labels:
+    component: oms-agent
+    tier: node
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      rsName: omsagent-rs
+  strategy:
+    type: RollingUpdate
+  template:
+    metadata:
+      labels:
+        rsName: omsagent-rs
+      annotations:
+        agentVersion: {{ContainerConfig "omsAgentVersion"}}
+        dockerProviderVersion: {{ContainerConfig "dockerProviderVersion"}}
+        schema-versions:  {{ContainerConfig "schema-versions"}}
+    spec:
+      serviceAccountName: omsagent
+      containers:
+        - name: omsagent
+          image: {{ContainerImage "omsagent"}}
+          imagePullPolicy: IfNotPresent
+          resources:
+            limits:
+              cpu: {{ContainerCPULimits "omsagent"}}
+              memory: {{ContainerMemLimits "omsagent"}}
+            requests:
+              cpu: {{ContainerCPUReqs "omsagent"}}
+              memory: {{ContainerMemReqs "omsagent"}}
+          env:
+            - name: NODE_IP
+              valueFrom:
+                fieldRef:
+                  fieldPath: status.hostIP
+            - name: ACS_RESOURCE_NAME
+              value: {{ContainerConfig "clusterName"}}            
+            - name: CONTROLLER_TYPE
+              value: "ReplicaSet"
+            - name: ISTEST
+              value: "true"
+          securityContext:
+            privileged: true
+          ports:
+            - containerPort: 25225
+              protocol: TCP
+            - containerPort: 25224
+              protocol: UDP
+          volumeMounts:
+            - mountPath: /var/run/host
+              name: docker-sock
+            - mountPath: /var/log
+              name: host-log
+            - mountPath: /var/lib/docker/containers
+              name: containerlog-path
+            - mountPath: /etc/kubernetes/host
+              name: azure-json-path
+            - mountPath: /etc/omsagent-secret
+              name: omsagent-secret
+              readOnly: true
+            - mountPath : /etc/config
+              name: omsagent-rs-config
+            - mountPath: /etc/config/settings
+              name: settings-vol-config              
+          livenessProbe:
+            exec:
+              command:
+                - /bin/bash
+                - -c
+                - /opt/livenessprobe.sh
+            initialDelaySeconds: 60
+            periodSeconds: 60
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+        kubernetes.io/role: agent
+      volumes:
+        - name: docker-sock
+          hostPath:
+            path: /var/run
+        - name: container-hostname
+          hostPath:
+            path: /etc/hostname
+        - name: host-log
+          hostPath:
+            path: /var/log
+        - name: containerlog-path
+          hostPath:
+            path: /var/lib/docker/containers
+        - name: azure-json-path
+          hostPath:
+            path: /etc/kubernetes
+        - name: omsagent-secret
+          secret:
+            secretName: omsagent-secret
+        - name: omsagent-rs-config
+          configMap:
+            name: omsagent-rs-config
+        - name: settings-vol-config
+          configMap:
+            name: container-azm-ms-agentconfig
+            optional: true    
+`)
+
+func k8sContaineraddons116KubernetesmasteraddonsOmsagentDaemonsetYamlBytes() ([]byte, error) {
+	return _k8sContaineraddons116KubernetesmasteraddonsOmsagentDaemonsetYaml, nil
+}
+
+func k8sContaineraddons116KubernetesmasteraddonsOmsagentDaemonsetYaml() (*asset, error) {
+	bytes, err := k8sContaineraddons116KubernetesmasteraddonsOmsagentDaemonsetYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/1.16/kubernetesmasteraddons-omsagent-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddons116KubernetesmasteraddonsSmbFlexvolumeInstallerYaml = []byte(`apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: smb-flexvol-installer
+  namespace: kube-system
+  labels:
+    k8s-app: smb
+    kubernetes.io/cluster-service: "true"
+spec:
+  selector:
+    matchLabels:
+      name: smb
+  template:
+    metadata:
+      labels:
+        name: smb
+        kubernetes.io/cluster-service: "true"
+    spec:
+      containers:
+      - name: smb-flexvol-installer
+        image: {{ContainerImage "smb-flexvolume"}}
+        imagePullPolicy: Always
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "smb-flexvolume"}}
+            memory: {{ContainerMemReqs "smb-flexvolume"}}
+          limits:
+            cpu: {{ContainerCPULimits "smb-flexvolume"}}
+            memory: {{ContainerMemLimits "smb-flexvolume"}}
+        volumeMounts:
+        - name: volplugins
+          mountPath: /etc/kubernetes/volumeplugins/
+        - name: varlog
+          mountPath: /var/log/
+      volumes:
+      - name: varlog
+        hostPath:
+          path: /var/log/
+      - name: volplugins
+        hostPath:
+          path: /etc/kubernetes/volumeplugins/
+          type: DirectoryOrCreate
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+`)
+
+func k8sContaineraddons116KubernetesmasteraddonsSmbFlexvolumeInstallerYamlBytes() ([]byte, error) {
+	return _k8sContaineraddons116KubernetesmasteraddonsSmbFlexvolumeInstallerYaml, nil
+}
+
+func k8sContaineraddons116KubernetesmasteraddonsSmbFlexvolumeInstallerYaml() (*asset, error) {
+	bytes, err := k8sContaineraddons116KubernetesmasteraddonsSmbFlexvolumeInstallerYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/1.16/kubernetesmasteraddons-smb-flexvolume-installer.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddons116KubernetesmasteraddonsTillerDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: tiller
+  namespace: kube-system
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRoleBinding
+metadata:
+  name: tiller
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cluster-admin
+subjects:
+- kind: ServiceAccount
+  name: tiller
+  namespace: kube-system
+---
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    app: helm
+    name: tiller
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+  name: tiller-deploy
+  namespace: kube-system
+spec:
+  ports:
+  - name: tiller
+    port: 44134
+    targetPort: tiller
+  selector:
+    app: helm
+    name: tiller
+  type: ClusterIP
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: helm
+    name: tiller
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+  name: tiller-deploy
+  namespace: kube-system
+spec:
+  selector:
+    matchLabels:
+      app: helm
+      name: tiller
+  template:
+    metadata:
+      labels:
+        app: helm
+        name: tiller
+    spec:
+      serviceAccountName: tiller
+      containers:
+      - env:
+        - name: TILLER_NAMESPACE
+          value: kube-system
+        - name: TILLER_HISTORY_MAX
+          value: "{{ContainerConfig "max-history"}}"
+        image: {{ContainerImage "tiller"}}
+        imagePullPolicy: IfNotPresent
+        livenessProbe:
+          httpGet:
+            path: /liveness
+            port: 44135
+          initialDelaySeconds: 1
+          timeoutSeconds: 1
+        name: tiller
+        ports:
+        - containerPort: 44134
+          name: tiller
+        readinessProbe:
+          httpGet:
+            path: /readiness
+            port: 44135
+          initialDelaySeconds: 1
+          timeoutSeconds: 1
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "tiller"}}
+            memory: {{ContainerMemReqs "tiller"}}
+          limits:
+            cpu: {{ContainerCPULimits "tiller"}}
+            memory: {{ContainerMemLimits "tiller"}}
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+`)
+
+func k8sContaineraddons116KubernetesmasteraddonsTillerDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddons116KubernetesmasteraddonsTillerDeploymentYaml, nil
+}
+
+func k8sContaineraddons116KubernetesmasteraddonsTillerDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddons116KubernetesmasteraddonsTillerDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/1.16/kubernetesmasteraddons-tiller-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddons16KubernetesmasteraddonsHeapsterDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: heapster
+  namespace: kube-system
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRole
+metadata:
+  name: system:heapster-with-nanny
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
+rules:
+- apiGroups:
+  - extensions
+  - apps
+  resources:
+  - deployments
+  verbs:
+  - get
+  - list
+  - watch
+  - update
+  - patch
+- apiGroups:
+  - ""
+  resources:
+  - events
+  - namespaces
+  - nodes
+  - pods
+  verbs:
+  - get
+  - list
+  - watch
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRoleBinding
+metadata:
+  name: system:heapster-with-nanny
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: system:heapster-with-nanny
+subjects:
+- kind: ServiceAccount
+  name: heapster
+  namespace: kube-system
+---
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    kubernetes.io/cluster-service: "true"
+    kubernetes.io/name: Heapster
+  name: heapster
+  namespace: kube-system
+spec:
+  ports:
+  - port: 80
+    targetPort: 8082
+  selector:
+    k8s-app: heapster
+---
+kind: Deployment
+apiVersion: extensions/v1beta1
+metadata:
+  labels:
+    k8s-app: heapster
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
+  namespace: kube-system
+  name: heapster
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      k8s-app: heapster
+  template:
+    metadata:
+      labels:
+        k8s-app: heapster
+      annotations:
+        scheduler.alpha.kubernetes.io/critical-pod: ""
+    spec:
+      tolerations:
+      - key: CriticalAddonsOnly
+        operator: Exists
+      serviceAccountName: heapster
+      containers:
+      - image: {{ContainerImage "heapster"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - "/heapster"
+        - "--source=kubernetes.summary_api:\"\""
+        name: heapster
+        resources:
+          requests:
+            cpu: 80m
+            memory: 140Mi
+          limits:
+            cpu: 80m
+            memory: 140Mi
+      - image: {{ContainerImage "heapster-nanny"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - "/pod_nanny"
+        - "--cpu=80m"
+        - "--extra-cpu=0.5m"
+        - "--memory=140Mi"
+        - "--extra-memory=4Mi"
+        - "--threshold=5"
+        - "--deployment=heapster"
+        - "--container=heapster"
+        - "--poll-period=300000"
+        - "--estimator=exponential"
+        name: heapster-nanny
+        resources:
+          requests:
+            cpu: 50m
+            memory: 90Mi
+          limits:
+            cpu: 50m
+            memory: 90Mi
+        env:
+        - valueFrom:
+            fieldRef:
+              fieldPath: metadata.name
+          name: MY_POD_NAME
+        - valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+          name: MY_POD_NAMESPACE
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+`)
+
+func k8sContaineraddons16KubernetesmasteraddonsHeapsterDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddons16KubernetesmasteraddonsHeapsterDeploymentYaml, nil
+}
+
+func k8sContaineraddons16KubernetesmasteraddonsHeapsterDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddons16KubernetesmasteraddonsHeapsterDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/1.6/kubernetesmasteraddons-heapster-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddons17KubernetesmasteraddonsHeapsterDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: heapster
+  namespace: kube-system
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRole
+metadata:
+  name: system:heapster-with-nanny
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
+rules:
+- apiGroups:
+  - extensions
+  - apps
+  resources:
+  - deployments
+  verbs:
+  - get
+  - list
+  - watch
+  - update
+  - patch
+- apiGroups:
+  - ""
+  resources:
+  - events
+  - namespaces
+  - nodes
+  - pods
+  verbs:
+  - get
+  - list
+  - watch
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRoleBinding
+metadata:
+  name: system:heapster-with-nanny
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: system:heapster-with-nanny
+subjects:
+- kind: ServiceAccount
+  name: heapster
+  namespace: kube-system
+---
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    kubernetes.io/cluster-service: "true"
+    kubernetes.io/name: Heapster
+  name: heapster
+  namespace: kube-system
+spec:
+  ports:
+  - port: 80
+    targetPort: 8082
+  selector:
+    k8s-app: heapster
+---
+kind: Deployment
+apiVersion: extensions/v1beta1
+metadata:
+  labels:
+    k8s-app: heapster
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
+  namespace: kube-system
+  name: heapster
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      k8s-app: heapster
+  template:
+    metadata:
+      labels:
+        k8s-app: heapster
+      annotations:
+        scheduler.alpha.kubernetes.io/critical-pod: ""
+    spec:
+      tolerations:
+      - key: CriticalAddonsOnly
+        operator: Exists
+      serviceAccountName: heapster
+      containers:
+      - image: {{ContainerImage "heapster"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - "/heapster"
+        - "--source=kubernetes.summary_api:\"\""
+        name: heapster
+        resources:
+          requests:
+            cpu: 80m
+            memory: 140Mi
+          limits:
+            cpu: 80m
+            memory: 140Mi
+      - image: {{ContainerImage "heapster-nanny"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - "/pod_nanny"
+        - "--cpu=80m"
+        - "--extra-cpu=0.5m"
+        - "--memory=140Mi"
+        - "--extra-memory=4Mi"
+        - "--threshold=5"
+        - "--deployment=heapster"
+        - "--container=heapster"
+        - "--poll-period=300000"
+        - "--estimator=exponential"
+        name: heapster-nanny
+        resources:
+          requests:
+            cpu: 50m
+            memory: 90Mi
+          limits:
+            cpu: 50m
+            memory: 90Mi
+        env:
+        - valueFrom:
+            fieldRef:
+              fieldPath: metadata.name
+          name: MY_POD_NAME
+        - valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+          name: MY_POD_NAMESPACE
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+`)
+
+func k8sContaineraddons17KubernetesmasteraddonsHeapsterDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddons17KubernetesmasteraddonsHeapsterDeploymentYaml, nil
+}
+
+func k8sContaineraddons17KubernetesmasteraddonsHeapsterDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddons17KubernetesmasteraddonsHeapsterDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/1.7/kubernetesmasteraddons-heapster-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddons18KubernetesmasteraddonsHeapsterDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: heapster
+  namespace: kube-system
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRole
+metadata:
+  name: system:heapster-with-nanny
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
+rules:
+- apiGroups:
+  - extensions
+  - apps
+  resources:
+  - deployments
+  verbs:
+  - get
+  - list
+  - watch
+  - update
+  - patch
+- apiGroups:
+  - ""
+  resources:
+  - events
+  - namespaces
+  - nodes
+  - pods
+  verbs:
+  - get
+  - list
+  - watch
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRoleBinding
+metadata:
+  name: system:heapster-with-nanny
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: system:heapster-with-nanny
+subjects:
+- kind: ServiceAccount
+  name: heapster
+  namespace: kube-system
+---
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    kubernetes.io/cluster-service: "true"
+    kubernetes.io/name: Heapster
+  name: heapster
+  namespace: kube-system
+spec:
+  ports:
+  - port: 80
+    targetPort: 8082
+  selector:
+    k8s-app: heapster
+---
+kind: Deployment
+apiVersion: extensions/v1beta1
+metadata:
+  labels:
+    k8s-app: heapster
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
+  namespace: kube-system
+  name: heapster
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      k8s-app: heapster
+  template:
+    metadata:
+      labels:
+        k8s-app: heapster
+      annotations:
+        scheduler.alpha.kubernetes.io/critical-pod: ""
+    spec:
+      tolerations:
+      - key: CriticalAddonsOnly
+        operator: Exists
+      serviceAccountName: heapster
+      containers:
+      - image: {{ContainerImage "heapster"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - "/heapster"
+        - "--source=kubernetes.summary_api:\"\""
+        name: heapster
+        resources:
+          requests:
+            cpu: 80m
+            memory: 140Mi
+          limits:
+            cpu: 80m
+            memory: 140Mi
+      - image: {{ContainerImage "heapster-nanny"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - "/pod_nanny"
+        - "--cpu=80m"
+        - "--extra-cpu=0.5m"
+        - "--memory=140Mi"
+        - "--extra-memory=4Mi"
+        - "--threshold=5"
+        - "--deployment=heapster"
+        - "--container=heapster"
+        - "--poll-period=300000"
+        - "--estimator=exponential"
+        name: heapster-nanny
+        resources:
+          requests:
+            cpu: 50m
+            memory: 90Mi
+          limits:
+            cpu: 50m
+            memory: 90Mi
+        env:
+        - valueFrom:
+            fieldRef:
+              fieldPath: metadata.name
+          name: MY_POD_NAME
+        - valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+          name: MY_POD_NAMESPACE
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+`)
+
+func k8sContaineraddons18KubernetesmasteraddonsHeapsterDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddons18KubernetesmasteraddonsHeapsterDeploymentYaml, nil
+}
+
+func k8sContaineraddons18KubernetesmasteraddonsHeapsterDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddons18KubernetesmasteraddonsHeapsterDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/1.8/kubernetesmasteraddons-heapster-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsAzureCniNetworkmonitorYaml = []byte(`apiVersion: extensions/v1beta1
+kind: DaemonSet
+metadata:
+  name: azure-cni-networkmonitor
+  namespace: kube-system
+  labels:
+    app: azure-cnms
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  selector:
+    matchLabels:
+      k8s-app: azure-cnms
+  template:
+    metadata:
+      labels:
+        k8s-app: azure-cnms
+      annotations:
+        scheduler.alpha.kubernetes.io/critical-pod: ''
+    spec:
+      priorityClassName: system-node-critical
+      tolerations:
+      - key: CriticalAddonsOnly
+        operator: Exists
+      - key: node-role.kubernetes.io/master
+        operator: Equal
+        value: "true"
+        effect: NoSchedule
+      - operator: "Exists"
+        effect: NoExecute
+      - operator: "Exists"
+        effect: NoSchedule
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      containers:
+        - name: azure-cnms
+          image: {{ContainerImage "azure-cni-networkmonitor"}}
+          resources:
+            requests:
+              cpu: {{ContainerCPUReqs "azure-cni-networkmonitor"}}
+              memory: {{ContainerMemReqs "azure-cni-networkmonitor"}}
+          imagePullPolicy: IfNotPresent
+          securityContext:
+            privileged: true
+          env:
+            - name: HOSTNAME
+              valueFrom:
+                fieldRef:
+                  apiVersion: v1
+                  fieldPath: spec.nodeName
+          volumeMounts:
+          - name: ebtables-rule-repo
+            mountPath: /var/run
+          - name: log
+            mountPath: /var/log
+          - name: telemetry
+            mountPath: /opt/cni/bin
+      hostNetwork: true
+      volumes:
+      - name: log
+        hostPath:
+          path: /var/log
+          type: Directory
+      - name: ebtables-rule-repo
+        hostPath:
+          path: /var/run/
+          type: Directory
+      - name: telemetry
+        hostPath:
+          path: /opt/cni/bin
+          type: Directory`)
+
+func k8sContaineraddonsAzureCniNetworkmonitorYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsAzureCniNetworkmonitorYaml, nil
+}
+
+func k8sContaineraddonsAzureCniNetworkmonitorYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsAzureCniNetworkmonitorYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/azure-cni-networkmonitor.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsDnsAutoscalerYaml = []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: dns-autoscaler
+  namespace: kube-system
+  labels:
+    k8s-app: dns-autoscaler
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  selector:
+    matchLabels:
+       k8s-app: dns-autoscaler
+  template:
+    metadata:
+      labels:
+        k8s-app: dns-autoscaler
+    spec:
+      containers:
+      - name: autoscaler
+        image: {{ContainerImage "dns-autoscaler"}}
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "dns-autoscaler"}}
+            memory: {{ContainerMemReqs "dns-autoscaler"}}
+        command:
+          - /cluster-proportional-autoscaler
+          - --namespace=kube-system
+          - --configmap=dns-autoscaler
+          - --target=Deployment/coredns
+          # When cluster is using large nodes(with more cores), "coresPerReplica" should dominate.
+          # If using small nodes, "nodesPerReplica" should dominate.
+          - --default-params={"linear":{"coresPerReplica":256,"nodesPerReplica":16,"min":1}}
+          - --logtostderr=true
+          - --v=2
+`)
+
+func k8sContaineraddonsDnsAutoscalerYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsDnsAutoscalerYaml, nil
+}
+
+func k8sContaineraddonsDnsAutoscalerYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsDnsAutoscalerYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/dns-autoscaler.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsIpMasqAgentYaml = []byte(`apiVersion: extensions/v1beta1
+kind: DaemonSet
+metadata:
+  name: azure-ip-masq-agent
+  namespace: kube-system
+  labels:
+    component: azure-ip-masq-agent
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+    tier: node
+spec:
+  template:
+    metadata:
+      labels:
+        k8s-app: azure-ip-masq-agent
+        tier: node
+    spec:
+      priorityClassName: system-node-critical
+      hostNetwork: true
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      tolerations:
+      - key: CriticalAddonsOnly
+        operator: Exists
+      - key: node-role.kubernetes.io/master
+        operator: Equal
+        value: "true"
+        effect: NoSchedule
+      - operator: "Exists"
+        effect: NoExecute
+      - operator: "Exists"
+        effect: NoSchedule
+      containers:
+      - name: azure-ip-masq-agent
+        image: {{ContainerImage "ip-masq-agent"}}
+        imagePullPolicy: IfNotPresent
+        securityContext:
+          privileged: true
+        volumeMounts:
+          - name: azure-ip-masq-agent-config-volume
+            mountPath: /etc/config
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "ip-masq-agent"}}
+            memory: {{ContainerMemReqs "ip-masq-agent"}}
+          limits:
+            cpu: {{ContainerCPULimits "ip-masq-agent"}}
+            memory: {{ContainerMemLimits "ip-masq-agent"}}
+      volumes:
+        - name: azure-ip-masq-agent-config-volume
+          configMap:
+            name: azure-ip-masq-agent-config
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: azure-ip-masq-agent-config
+  namespace: kube-system
+  labels:
+    component: azure-ip-masq-agent
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
+data:
+  ip-masq-agent: |-
+    nonMasqueradeCIDRs:
+      - {{ContainerConfig "non-masquerade-cidr"}}
+    {{- if ContainerConfig "non-masq-cni-cidr"}}
+      - {{ContainerConfig "non-masq-cni-cidr"}}
+    masqLinkLocal: true
+    {{else}}
+    masqLinkLocal: false
+    {{end -}}
+    resyncInterval: 60s`)
+
+func k8sContaineraddonsIpMasqAgentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsIpMasqAgentYaml, nil
+}
+
+func k8sContaineraddonsIpMasqAgentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsIpMasqAgentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/ip-masq-agent.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsKubernetesmasteraddonsAadPodIdentityDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: aad-pod-id-nmi-service-account
+  namespace: default
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: azureassignedidentities.aadpodidentity.k8s.io
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: aadpodidentity.k8s.io
+  version: v1
+  names:
+    kind: AzureAssignedIdentity
+    plural: azureassignedidentities
+  scope: Namespaced
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: azureidentitybindings.aadpodidentity.k8s.io
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: aadpodidentity.k8s.io
+  version: v1
+  names:
+    kind: AzureIdentityBinding
+    plural: azureidentitybindings
+  scope: Namespaced
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: azureidentities.aadpodidentity.k8s.io
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: aadpodidentity.k8s.io
+  version: v1
+  names:
+    kind: AzureIdentity
+    singular: azureidentity
+    plural: azureidentities
+  scope: Namespaced
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: aad-pod-id-nmi-role
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups: ["*"]
+  resources: ["*"]
+  verbs: ["get", "list"]
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRoleBinding
+metadata:
+  name: aad-pod-id-nmi-binding
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+    k8s-app: aad-pod-id-nmi-binding
+subjects:
+- kind: ServiceAccount
+  name: aad-pod-id-nmi-service-account
+  namespace: default
+roleRef:
+  kind: ClusterRole
+  name: aad-pod-id-nmi-role
+  apiGroup: rbac.authorization.k8s.io
+---
+apiVersion: extensions/v1beta1
+kind: DaemonSet
+metadata:
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+    component: nmi
+    tier: node
+    k8s-app: aad-pod-id
+  name: nmi
+  namespace: default
+spec:
+  template:
+    metadata:
+      labels:
+        component: nmi
+        tier: node
+    spec:
+      serviceAccountName: aad-pod-id-nmi-service-account
+      hostNetwork: true
+      containers:
+      - name: nmi
+        image: {{ContainerImage "nmi"}}
+        imagePullPolicy: IfNotPresent
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "nmi"}}
+            memory: {{ContainerMemReqs "nmi"}}
+          limits:
+            cpu: {{ContainerCPULimits "nmi"}}
+            memory: {{ContainerMemLimits "nmi"}}
+        args:
+          - "--host-ip=$(HOST_IP)"
+          - "--node=$(NODE_NAME)"
+        env:
+          - name: HOST_IP
+            valueFrom:
+              fieldRef:
+                fieldPath: status.podIP
+          - name: NODE_NAME
+            valueFrom:
+              fieldRef:
+                fieldPath: spec.nodeName
+        securityContext:
+          privileged: true
+          capabilities:
+            add:
+            - NET_ADMIN
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: aad-pod-id-mic-service-account
+  namespace: default
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: aad-pod-id-mic-role
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups: ["apiextensions.k8s.io"]
+  resources: ["customresourcedefinitions"]
+  verbs: ["*"]
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: [ "list", "watch" ]
+- apiGroups: [""]
+  resources: ["events"]
+  verbs: ["create", "patch"]
+- apiGroups: ["aadpodidentity.k8s.io"]
+  resources: ["azureidentitybindings", "azureidentities"]
+  verbs: ["get", "list", "watch", "post"]
+- apiGroups: ["aadpodidentity.k8s.io"]
+  resources: ["azureassignedidentities"]
+  verbs: ["*"]
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRoleBinding
+metadata:
+  name: aad-pod-id-mic-binding
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+    k8s-app: aad-pod-id-mic-binding
+subjects:
+- kind: ServiceAccount
+  name: aad-pod-id-mic-service-account
+  namespace: default
+roleRef:
+  kind: ClusterRole
+  name: aad-pod-id-mic-role
+  apiGroup: rbac.authorization.k8s.io
+---
+apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  labels:
+    component: mic
+    k8s-app: aad-pod-id
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+  name: mic
+  namespace: default
+spec:
+  template:
+    metadata:
+      labels:
+        component: mic
+    spec:
+      serviceAccountName: aad-pod-id-mic-service-account
+      containers:
+      - name: mic
+        image: {{ContainerImage "mic"}}
+        imagePullPolicy: IfNotPresent
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "mic"}}
+            memory: {{ContainerMemReqs "mic"}}
+          limits:
+            cpu: {{ContainerCPULimits "mic"}}
+            memory: {{ContainerMemLimits "mic"}}
+        args:
+          - --cloudconfig=/etc/kubernetes/azure.json
+          - --logtostderr
+        volumeMounts:
+          - name: k8s-azure-file
+            mountPath: /etc/kubernetes/azure.json
+            readOnly: true
+      volumes:
+      - name: k8s-azure-file
+        hostPath:
+          path: /etc/kubernetes/azure.json
+`)
+
+func k8sContaineraddonsKubernetesmasteraddonsAadPodIdentityDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsAadPodIdentityDeploymentYaml, nil
+}
+
+func k8sContaineraddonsKubernetesmasteraddonsAadPodIdentityDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsAadPodIdentityDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-aad-pod-identity-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsKubernetesmasteraddonsAciConnectorDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: aci-connector
+  namespace: kube-system
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRole
+metadata:
+  name: aci-connector
+  labels:
+    app: aci-connector
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - configmaps
+  - pods
+  - services
+  - endpoints
+  - events
+  - secrets
+  - nodes
+  - nodes/status
+  - pods/status
+  verbs:
+  - "*"
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRoleBinding
+metadata:
+  name: aci-connector
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: aci-connector
+subjects:
+- kind: ServiceAccount
+  name: aci-connector
+  namespace: kube-system
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: aci-connector-secret
+  namespace: kube-system
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+type: Opaque
+data:
+  credentials.json: <creds>
+  cert.pem: <cert>
+  key.pem: <key>
+---
+apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: aci-connector
+  namespace: kube-system
+  labels:
+    app: aci-connector
+    name: aci-connector
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  replicas: 1
+  template:
+    metadata:
+      labels:
+        app: aci-connector
+    spec:
+      serviceAccountName: aci-connector
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      containers:
+      - name: aci-connector
+        image: {{ContainerImage "aci-connector"}}
+        imagePullPolicy: Always
+        env:
+        - name: KUBELET_PORT
+          value: "10250"
+        - name: AZURE_AUTH_LOCATION
+          value: /etc/virtual-kubelet/credentials.json
+        - name: ACI_RESOURCE_GROUP
+          value: <rgName>
+        - name: ACI_REGION
+          value: <region>
+        - name: APISERVER_CERT_LOCATION
+          value: /etc/virtual-kubelet/cert.pem
+        - name: APISERVER_KEY_LOCATION
+          value: /etc/virtual-kubelet/key.pem
+        - name: VKUBELET_POD_IP
+          valueFrom:
+            fieldRef:
+              fieldPath: status.podIP
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "aci-connector"}}
+            memory: {{ContainerMemReqs "aci-connector"}}
+          limits:
+            cpu: {{ContainerCPULimits "aci-connector"}}
+            memory: {{ContainerMemLimits "aci-connector"}}
+        volumeMounts:
+        - name: credentials
+          mountPath: "/etc/virtual-kubelet"
+          readOnly: true
+        command: ["virtual-kubelet"]
+        args: ["--provider", "azure", "--nodename", "{{ContainerConfig "nodeName"}}" , "--os", "{{ContainerConfig "os"}}", "--taint", "{{ContainerConfig "taint"}}"]
+      volumes:
+      - name: credentials
+        secret:
+          secretName: aci-connector-secret
+#EOF
+`)
+
+func k8sContaineraddonsKubernetesmasteraddonsAciConnectorDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsAciConnectorDeploymentYaml, nil
+}
+
+func k8sContaineraddonsKubernetesmasteraddonsAciConnectorDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsAciConnectorDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-aci-connector-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsKubernetesmasteraddonsAntreaYaml = []byte(`apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: antreaagentinfos.clusterinformation.antrea.tanzu.vmware.com
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: clusterinformation.antrea.tanzu.vmware.com
+  versions:
+  - name: v1beta1
+    served: true
+    storage: true
+  scope: Cluster
+  names:
+    plural: antreaagentinfos
+    singular: antreaagentinfo
+    kind: AntreaAgentInfo
+    shortNames:
+    - aai
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: antreacontrollerinfos.clusterinformation.antrea.tanzu.vmware.com
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: clusterinformation.antrea.tanzu.vmware.com
+  versions:
+  - name: v1beta1
+    served: true
+    storage: true
+  scope: Cluster
+  names:
+    plural: antreacontrollerinfos
+    singular: antreacontrollerinfo
+    kind: AntreaControllerInfo
+    shortNames:
+    - aci
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: clusternetworkpolicies.security.antrea.tanzu.vmware.com
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: security.antrea.tanzu.vmware.com
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+  scope: Cluster
+  names:
+    plural: clusternetworkpolicies
+    singular: clusternetworkpolicy
+    kind: ClusterNetworkPolicy
+    shortNames:
+    - acnp
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: networkpolicies.security.antrea.tanzu.vmware.com
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: security.antrea.tanzu.vmware.com
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+  scope: Namespaced
+  names:
+    plural: networkpolicies
+    singular: networkpolicy
+    kind: NetworkPolicy
+    shortNames:
+    - anp
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: tiers.security.antrea.tanzu.vmware.com
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: security.antrea.tanzu.vmware.com
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+  scope: Cluster
+  names:
+    plural: tiers
+    singular: tier
+    kind: Tier
+    shortNames:
+    - tr
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: traceflows.ops.antrea.tanzu.vmware.com
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: ops.antrea.tanzu.vmware.com
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+  scope: Cluster
+  names:
+    plural: traceflows
+    singular: traceflow
+    kind: Traceflow
+    shortNames:
+    - tf
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: externalentities.core.antrea.tanzu.vmware.com
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: core.antrea.tanzu.vmware.com
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+  scope: Namespaced
+  names:
+    plural: externalentities
+    singular: externalentity
+    kind: ExternalEntity
+    shortNames:
+    - ee
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: antrea-agent
+  namespace: kube-system
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: antrea-controller
+  namespace: kube-system
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: antrea-agent
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - nodes
+  verbs:
+  - get
+  - watch
+  - list
+- apiGroups:
+  - ""
+  resources:
+  - pods
+  verbs:
+  - get
+  - watch
+  - list
+- apiGroups:
+  - ""
+  resources:
+  - endpoints
+  - services
+  verbs:
+  - get
+  - watch
+  - list
+- apiGroups:
+  - discovery.k8s.io
+  resources:
+  - endpointslices
+  verbs:
+  - get
+  - watch
+  - list
+- apiGroups:
+  - clusterinformation.antrea.tanzu.vmware.com
+  resources:
+  - antreaagentinfos
+  verbs:
+  - get
+  - create
+  - update
+  - delete
+- apiGroups:
+  - controlplane.antrea.tanzu.vmware.com
+  - networking.antrea.tanzu.vmware.com
+  resources:
+  - networkpolicies
+  - appliedtogroups
+  - addressgroups
+  verbs:
+  - get
+  - watch
+  - list
+- apiGroups:
+  - controlplane.antrea.tanzu.vmware.com
+  resources:
+  - nodestatssummaries
+  verbs:
+  - create
+- apiGroups:
+  - controlplane.antrea.tanzu.vmware.com
+  resources:
+  - networkpolicies/status
+  verbs:
+  - create
+  - get
+- apiGroups:
+  - authentication.k8s.io
+  resources:
+  - tokenreviews
+  verbs:
+  - create
+- apiGroups:
+  - authorization.k8s.io
+  resources:
+  - subjectaccessreviews
+  verbs:
+  - create
+- apiGroups:
+  - ""
+  resources:
+  - configmaps
+  resourceNames:
+  - extension-apiserver-authentication
+  - antrea-ca
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - ops.antrea.tanzu.vmware.com
+  resources:
+  - traceflows
+  - traceflows/status
+  verbs:
+  - get
+  - watch
+  - list
+  - update
+  - patch
+  - create
+  - delete
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: antrea-controller
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - nodes
+  - pods
+  - namespaces
+  verbs:
+  - get
+  - watch
+  - list
+- apiGroups:
+  - networking.k8s.io
+  resources:
+  - networkpolicies
+  verbs:
+  - get
+  - watch
+  - list
+- apiGroups:
+  - clusterinformation.antrea.tanzu.vmware.com
+  resources:
+  - antreacontrollerinfos
+  verbs:
+  - get
+  - create
+  - update
+  - delete
+- apiGroups:
+  - clusterinformation.antrea.tanzu.vmware.com
+  resources:
+  - antreaagentinfos
+  verbs:
+  - list
+  - delete
+- apiGroups:
+  - authentication.k8s.io
+  resources:
+  - tokenreviews
+  verbs:
+  - create
+- apiGroups:
+  - authorization.k8s.io
+  resources:
+  - subjectaccessreviews
+  verbs:
+  - create
+- apiGroups:
+  - ""
+  resources:
+  - configmaps
+  resourceNames:
+  - extension-apiserver-authentication
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - ""
+  resources:
+  - configmaps
+  resourceNames:
+  - antrea-ca
+  verbs:
+  - get
+  - update
+- apiGroups:
+  - ""
+  resources:
+  - configmaps
+  verbs:
+  - create
+- apiGroups:
+  - apiregistration.k8s.io
+  resources:
+  - apiservices
+  resourceNames:
+  - v1beta1.controlplane.antrea.tanzu.vmware.com
+  - v1beta1.networking.antrea.tanzu.vmware.com
+  - v1beta1.system.antrea.tanzu.vmware.com
+  - v1alpha1.stats.antrea.tanzu.vmware.com
+  verbs:
+  - get
+  - update
+- apiGroups:
+  - security.antrea.tanzu.vmware.com
+  resources:
+  - clusternetworkpolicies
+  - networkpolicies
+  - tiers
+  verbs:
+  - get
+  - watch
+  - list
+  - create
+  - update
+  - patch
+- apiGroups:
+  - security.antrea.tanzu.vmware.com
+  resources:
+  - clusternetworkpolicies/status
+  - networkpolicies/status
+  verbs:
+  - update
+- apiGroups:
+  - ops.antrea.tanzu.vmware.com
+  resources:
+  - traceflows
+  - traceflows/status
+  verbs:
+  - get
+  - watch
+  - list
+  - update
+  - patch
+  - create
+  - delete
+- apiGroups:
+  - core.antrea.tanzu.vmware.com
+  resources:
+  - externalentities
+  verbs:
+  - get
+  - watch
+  - list
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: antrea-agent
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: antrea-agent
+subjects:
+- kind: ServiceAccount
+  name: antrea-agent
+  namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: antrea-controller
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: antrea-controller
+subjects:
+- kind: ServiceAccount
+  name: antrea-controller
+  namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: antrea-controller-auth-delegator
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: system:auth-delegator
+subjects:
+- kind: ServiceAccount
+  name: antrea-controller
+  namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: antrea-controller-authentication-reader
+  namespace: kube-system
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: extension-apiserver-authentication-reader
+subjects:
+- kind: ServiceAccount
+  name: antrea-controller
+  namespace: kube-system
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: antrea-config
+  namespace: kube-system
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+data:
+  antrea-agent.conf: |
+    # the nodes tunnel the pod traffic between them, the Azure VNET does not route the pod CIDRs
+    trafficEncapMode: encap
+    tunnelType: geneve
+    ovsBridge: br-int
+    hostGateway: antrea-gw0
+    serviceCIDR: <kubeServiceCidr>
+    enablePrometheusMetrics: true
+  antrea-controller.conf: |
+    enablePrometheusMetrics: true
+  antrea-cni.conflist: |
+    {
+        "cniVersion":"0.3.0",
+        "name": "antrea",
+        "plugins": [
+            {
+                "type": "antrea",
+                "ipam": {
+                    "type": "host-local"
+                }
+            },
+            {
+                "type": "portmap",
+                "capabilities": {"portMappings": true}
+            },
+            {
+                "type": "bandwidth",
+                "capabilities": {"bandwidth": true}
+            }
+        ]
+    }
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: antrea
+  namespace: kube-system
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  ports:
+  - port: 443
+    protocol: TCP
+    targetPort: api
+  selector:
+    app: antrea
+    component: antrea-controller
+---
+apiVersion: apiregistration.k8s.io/v1
+kind: APIService
+metadata:
+  name: v1beta1.controlplane.antrea.tanzu.vmware.com
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: controlplane.antrea.tanzu.vmware.com
+  groupPriorityMinimum: 100
+  version: v1beta1
+  versionPriority: 100
+  service:
+    name: antrea
+    namespace: kube-system
+---
+apiVersion: apiregistration.k8s.io/v1
+kind: APIService
+metadata:
+  name: v1beta1.networking.antrea.tanzu.vmware.com
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: networking.antrea.tanzu.vmware.com
+  groupPriorityMinimum: 100
+  version: v1beta1
+  versionPriority: 100
+  service:
+    name: antrea
+    namespace: kube-system
+---
+apiVersion: apiregistration.k8s.io/v1
+kind: APIService
+metadata:
+  name: v1beta1.system.antrea.tanzu.vmware.com
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: system.antrea.tanzu.vmware.com
+  groupPriorityMinimum: 100
+  version: v1beta1
+  versionPriority: 100
+  service:
+    name: antrea
+    namespace: kube-system
+---
+apiVersion: apiregistration.k8s.io/v1
+kind: APIService
+metadata:
+  name: v1alpha1.stats.antrea.tanzu.vmware.com
+  labels:
+    app: antrea
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: stats.antrea.tanzu.vmware.com
+  groupPriorityMinimum: 100
+  version: v1alpha1
+  versionPriority: 100
+  service:
+    name: antrea
+    namespace: kube-system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: antrea-controller
+  namespace: kube-system
+  labels:
+    app: antrea
+    component: antrea-controller
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  replicas: 1
+  strategy:
+    type: Recreate
+  selector:
+    matchLabels:
+      app: antrea
+      component: antrea-controller
+  template:
+    metadata:
+      labels:
+        app: antrea
+        component: antrea-controller
+    spec:
+      priorityClassName: system-cluster-critical
+      serviceAccountName: antrea-controller
+      # the controller computes the policies of the agents before the pod network is up
+      hostNetwork: true
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      tolerations:
+      - key: CriticalAddonsOnly
+        operator: Exists
+      - key: node-role.kubernetes.io/master
+        effect: NoSchedule
+      containers:
+      - name: antrea-controller
+        image: {{ContainerImage "antrea-controller"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - antrea-controller
+        args:
+        - --config
+        - /etc/antrea/antrea-controller.conf
+        - --logtostderr=true
+        - --v=0
+        env:
+        - name: POD_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.name
+        - name: POD_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+        - name: NODE_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: spec.nodeName
+        - name: SERVICEACCOUNT_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: spec.serviceAccountName
+        - name: ANTREA_CONFIG_MAP_NAME
+          value: antrea-config
+        ports:
+        - containerPort: 10349
+          name: api
+          protocol: TCP
+        readinessProbe:
+          httpGet:
+            host: 127.0.0.1
+            path: /readyz
+            port: api
+            scheme: HTTPS
+          initialDelaySeconds: 5
+          periodSeconds: 10
+          timeoutSeconds: 5
+          failureThreshold: 5
+        livenessProbe:
+          httpGet:
+            host: 127.0.0.1
+            path: /livez
+            port: api
+            scheme: HTTPS
+          periodSeconds: 10
+          timeoutSeconds: 5
+          failureThreshold: 5
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "antrea-controller"}}
+        volumeMounts:
+        - name: antrea-config
+          mountPath: /etc/antrea/antrea-controller.conf
+          subPath: antrea-controller.conf
+          readOnly: true
+      volumes:
+      - name: antrea-config
+        configMap:
+          name: antrea-config
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: antrea-agent
+  namespace: kube-system
+  labels:
+    app: antrea
+    component: antrea-agent
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  selector:
+    matchLabels:
+      app: antrea
+      component: antrea-agent
+  updateStrategy:
+    type: RollingUpdate
+  template:
+    metadata:
+      labels:
+        app: antrea
+        component: antrea-agent
+    spec:
+      priorityClassName: system-node-critical
+      serviceAccountName: antrea-agent
+      hostNetwork: true
+      dnsPolicy: ClusterFirstWithHostNet
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      tolerations:
+      - operator: Exists
+      initContainers:
+      # install-cni writes the antrea CNI plugin and its conflist, and checks that the openvswitch module is loaded
+      - name: install-cni
+        image: {{ContainerImage "antrea-agent"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - install_cni
+        securityContext:
+          capabilities:
+            add:
+            - SYS_MODULE
+        resources:
+          requests:
+            cpu: 100m
+        volumeMounts:
+        - name: antrea-config
+          mountPath: /etc/antrea/antrea-cni.conflist
+          subPath: antrea-cni.conflist
+          readOnly: true
+        - name: host-cni-conf
+          mountPath: /host/etc/cni/net.d
+        - name: host-cni-bin
+          mountPath: /host/opt/cni/bin
+        - name: host-lib-modules
+          mountPath: /lib/modules
+          readOnly: true
+        - name: host-var-run-antrea
+          mountPath: /var/run/antrea
+      containers:
+      - name: antrea-agent
+        image: {{ContainerImage "antrea-agent"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - antrea-agent
+        args:
+        - --config
+        - /etc/antrea/antrea-agent.conf
+        - --logtostderr=true
+        - --v=0
+        env:
+        - name: POD_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.name
+        - name: POD_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+        - name: NODE_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: spec.nodeName
+        ports:
+        - containerPort: 10350
+          name: api
+          protocol: TCP
+        readinessProbe:
+          httpGet:
+            host: 127.0.0.1
+            path: /readyz
+            port: api
+            scheme: HTTPS
+          initialDelaySeconds: 5
+          periodSeconds: 10
+          timeoutSeconds: 5
+          failureThreshold: 5
+        livenessProbe:
+          httpGet:
+            host: 127.0.0.1
+            path: /livez
+            port: api
+            scheme: HTTPS
+          periodSeconds: 10
+          timeoutSeconds: 5
+          failureThreshold: 5
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "antrea-agent"}}
+        securityContext:
+          privileged: true
+        volumeMounts:
+        - name: antrea-config
+          mountPath: /etc/antrea/antrea-agent.conf
+          subPath: antrea-agent.conf
+          readOnly: true
+        - name: host-var-run-antrea
+          mountPath: /var/run/antrea
+        - name: host-var-run-antrea
+          mountPath: /var/run/openvswitch
+          subPath: openvswitch
+        - name: host-var-lib-cni
+          mountPath: /var/lib/cni
+        - name: host-var-log-antrea
+          mountPath: /var/log/antrea
+        - name: host-proc
+          mountPath: /host/proc
+          readOnly: true
+        - name: host-var-run-netns
+          mountPath: /host/var/run/netns
+          mountPropagation: HostToContainer
+        - name: xtables-lock
+          mountPath: /run/xtables.lock
+      - name: antrea-ovs
+        image: {{ContainerImage "antrea-ovs"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - start_ovs
+        args:
+        - --log_file_max_size=100
+        - --log_file_max_num=4
+        livenessProbe:
+          exec:
+            command:
+            - /bin/sh
+            - -c
+            - timeout 10 container_liveness_probe ovs
+          initialDelaySeconds: 5
+          periodSeconds: 10
+          timeoutSeconds: 10
+          failureThreshold: 5
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "antrea-ovs"}}
+        securityContext:
+          capabilities:
+            add:
+            - SYS_NICE
+            - NET_ADMIN
+            - SYS_ADMIN
+            - IPC_LOCK
+        volumeMounts:
+        - name: host-var-run-antrea
+          mountPath: /var/run/openvswitch
+          subPath: openvswitch
+        - name: host-var-log-antrea
+          mountPath: /var/log/openvswitch
+          subPath: openvswitch
+      volumes:
+      - name: antrea-config
+        configMap:
+          name: antrea-config
+      - name: host-cni-conf
+        hostPath:
+          path: /etc/cni/net.d
+      - name: host-cni-bin
+        hostPath:
+          path: /opt/cni/bin
+      - name: host-proc
+        hostPath:
+          path: /proc
+      - name: host-var-run-netns
+        hostPath:
+          path: /var/run/netns
+      - name: host-var-run-antrea
+        hostPath:
+          path: /var/run/antrea
+          type: DirectoryOrCreate
+      - name: host-var-lib-cni
+        hostPath:
+          path: /var/lib/cni
+          type: DirectoryOrCreate
+      - name: host-var-log-antrea
+        hostPath:
+          path: /var/log/antrea
+          type: DirectoryOrCreate
+      - name: host-lib-modules
+        hostPath:
+          path: /lib/modules
+      - name: xtables-lock
+        hostPath:
+          path: /run/xtables.lock
+          type: FileOrCreate
+`)
+
+func k8sContaineraddonsKubernetesmasteraddonsAntreaYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsAntreaYaml, nil
+}
+
+func k8sContaineraddonsKubernetesmasteraddonsAntreaYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsAntreaYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-antrea.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsKubernetesmasteraddonsAppgwIngressDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: appgw-ingress
+  namespace: kube-system
+  labels:
+    k8s-app: appgw-ingress
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: appgw-ingress
+  labels:
+    k8s-app: appgw-ingress
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups: [""]
+  resources: ["endpoints", "nodes", "pods", "secrets", "services"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: [""]
+  resources: ["events"]
+  verbs: ["create", "patch"]
+- apiGroups: ["extensions", "networking.k8s.io"]
+  resources: ["ingresses"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: ["extensions", "networking.k8s.io"]
+  resources: ["ingresses/status"]
+  verbs: ["update"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: appgw-ingress
+  labels:
+    k8s-app: appgw-ingress
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: appgw-ingress
+subjects:
+- kind: ServiceAccount
+  name: appgw-ingress
+  namespace: kube-system
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: appgw-ingress
+  namespace: kube-system
+  labels:
+    k8s-app: appgw-ingress
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+data:
+  APPGW_VERBOSITY_LEVEL: "1"
+  APPGW_SUBSCRIPTION_ID: <subscriptionId>
+  APPGW_RESOURCE_GROUP: <resourceGroup>
+  APPGW_NAME: <appGwName>
+  KUBERNETES_WATCHNAMESPACE: ""
+  USE_MANAGED_IDENTITY_FOR_POD: "true"
+  AZURE_CLIENT_ID: <identityClientId>
+---
+apiVersion: aadpodidentity.k8s.io/v1
+kind: AzureIdentity
+metadata:
+  name: appgw-ingress
+  namespace: kube-system
+  labels:
+    k8s-app: appgw-ingress
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  type: 0
+  ResourceID: <identityResourceId>
+  ClientID: <identityClientId>
+---
+apiVersion: aadpodidentity.k8s.io/v1
+kind: AzureIdentityBinding
+metadata:
+  name: appgw-ingress
+  namespace: kube-system
+  labels:
+    k8s-app: appgw-ingress
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  AzureIdentity: appgw-ingress
+  Selector: appgw-ingress
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: appgw-ingress
+  namespace: kube-system
+  labels:
+    k8s-app: appgw-ingress
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      k8s-app: appgw-ingress
+  template:
+    metadata:
+      labels:
+        k8s-app: appgw-ingress
+        # the identity of the Application Gateway is assigned by aad-pod-identity to the pods with this binding
+        aadpodidbinding: appgw-ingress
+    spec:
+      serviceAccountName: appgw-ingress
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      containers:
+      - name: appgw-ingress-controller
+        image: {{ContainerImage "appgw-ingress-controller"}}
+        imagePullPolicy: IfNotPresent
+        readinessProbe:
+          httpGet:
+            path: /health/ready
+            port: 8123
+          initialDelaySeconds: 5
+          periodSeconds: 10
+        livenessProbe:
+          httpGet:
+            path: /health/alive
+            port: 8123
+          initialDelaySeconds: 15
+          periodSeconds: 20
+        env:
+        - name: AGIC_POD_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.name
+        - name: AGIC_POD_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+        envFrom:
+        - configMapRef:
+            name: appgw-ingress
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "appgw-ingress-controller"}}
+            memory: {{ContainerMemReqs "appgw-ingress-controller"}}
+          limits:
+            cpu: {{ContainerCPULimits "appgw-ingress-controller"}}
+            memory: {{ContainerMemLimits "appgw-ingress-controller"}}
+`)
+
+func k8sContaineraddonsKubernetesmasteraddonsAppgwIngressDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsAppgwIngressDeploymentYaml, nil
+}
+
+func k8sContaineraddonsKubernetesmasteraddonsAppgwIngressDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsAppgwIngressDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-appgw-ingress-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsKubernetesmasteraddonsAzureNpmDaemonsetYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: azure-npm
+  namespace: kube-system
+  labels:
+    addonmanager.kubernetes.io/mode: EnsureExists
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRole
+metadata:
+  name: azure-npm
+  namespace: kube-system
+  labels:
+    addonmanager.kubernetes.io/mode: EnsureExists
+rules:
+  - apiGroups:
+    - ""
+    resources:
+      - pods
+      - nodes
+      - namespaces
+    verbs:
+      - get
+      - list
+      - watch
+  - apiGroups:
+    - networking.k8s.io
+    resources:
+      - networkpolicies
+    verbs:
+      - get
+      - list
+      - watch
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRoleBinding
+metadata:
+  name: azure-npm-binding
+  namespace: kube-system
+  labels:
+    addonmanager.kubernetes.io/mode: EnsureExists
+subjects:
+  - kind: ServiceAccount
+    name: azure-npm
+    namespace: kube-system
+roleRef:
+  kind: ClusterRole
+  name: azure-npm
+  apiGroup: rbac.authorization.k8s.io
+---
+apiVersion: extensions/v1beta1
+kind: DaemonSet
+metadata:
+  name: azure-npm
+  namespace: kube-system
+  labels:
+    app: azure-npm
+    addonmanager.kubernetes.io/mode: EnsureExists
+spec:
+  selector:
+    matchLabels:
+      k8s-app: azure-npm
+  template:
+    metadata:
+      labels:
+        k8s-app: azure-npm
+      annotations:
+        scheduler.alpha.kubernetes.io/critical-pod: ''
+    spec:
+      priorityClassName: system-node-critical
+      tolerations:
+      - key: CriticalAddonsOnly
+        operator: Exists
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      containers:
+        - name: azure-npm
+          image: {{ContainerImage "azure-npm-daemonset"}}
+          resources:
+            requests:
+              cpu: {{ContainerCPUReqs "azure-npm-daemonset"}}
+              memory: {{ContainerMemReqs "azure-npm-daemonset"}}
+          securityContext:
+            privileged: true
+          env:
+            - name: HOSTNAME
+              valueFrom:
+                fieldRef:
+                  apiVersion: v1
+                  fieldPath: spec.nodeName
+          volumeMounts:
+          - name: xtables-lock
+            mountPath: /run/xtables.lock
+          - name: log
+            mountPath: /var/log
+          - name: socket-dir
+            mountPath: /var/run
+          - name: tmp
+            mountPath: /tmp
+        - name: azure-vnet-telemetry
+          image: {{ContainerImage "azure-vnet-telemetry-daemonset"}}
+          resources:
+            requests:
+              cpu: {{ContainerCPUReqs "azure-vnet-telemetry-daemonset"}}
+              memory: {{ContainerMemReqs "azure-vnet-telemetry-daemonset"}}
+          volumeMounts:
+          - name: socket-dir
+            mountPath: /var/run
+          - name: tmp
+            mountPath: /tmp
+      hostNetwork: true
+      volumes:
+      - name: log
+        hostPath:
+          path: /var/log
+          type: Directory
+      - name: xtables-lock
+        hostPath:
+          path: /run/xtables.lock
+          type: File
+      - name: tmp
+        hostPath:
+          path: /tmp
+          type: Directory
+      - name: socket-dir
+        emptyDir: {}
+      serviceAccountName: azure-npm
+`)
+
+func k8sContaineraddonsKubernetesmasteraddonsAzureNpmDaemonsetYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsAzureNpmDaemonsetYaml, nil
+}
+
+func k8sContaineraddonsKubernetesmasteraddonsAzureNpmDaemonsetYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsAzureNpmDaemonsetYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-azure-npm-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsKubernetesmasteraddonsAzurePolicyDeploymentYaml = []byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: gatekeeper-system
+  labels:
+    control-plane: controller-manager
+    admission.gatekeeper.sh/ignore: no-self-managing
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: configs.config.gatekeeper.sh
+  labels:
+    gatekeeper.sh/system: "yes"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: config.gatekeeper.sh
+  names:
+    kind: Config
+    listKind: ConfigList
+    plural: configs
+    singular: config
+  scope: Namespaced
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: constrainttemplates.templates.gatekeeper.sh
+  labels:
+    controller-tools.k8s.io: "1.0"
+    gatekeeper.sh/system: "yes"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: templates.gatekeeper.sh
+  names:
+    kind: ConstraintTemplate
+    plural: constrainttemplates
+  scope: Cluster
+  subresources:
+    status: {}
+  versions:
+  - name: v1beta1
+    served: true
+    storage: true
+  - name: v1alpha1
+    served: true
+    storage: false
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: gatekeeper-admin
+  namespace: gatekeeper-system
+  labels:
+    gatekeeper.sh/system: "yes"
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: gatekeeper-manager-role
+  namespace: gatekeeper-system
+  labels:
+    gatekeeper.sh/system: "yes"
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups: [""]
+  resources: ["secrets"]
+  verbs: ["create", "delete", "get", "list", "patch", "update", "watch"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: gatekeeper-manager-role
+  labels:
+    gatekeeper.sh/system: "yes"
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups: ["*"]
+  resources: ["*"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: ["apiextensions.k8s.io"]
+  resources: ["customresourcedefinitions"]
+  verbs: ["create", "delete", "get", "list", "patch", "update", "watch"]
+- apiGroups: ["config.gatekeeper.sh"]
+  resources: ["configs"]
+  verbs: ["create", "delete", "get", "list", "patch", "update", "watch"]
+- apiGroups: ["config.gatekeeper.sh"]
+  resources: ["configs/status"]
+  verbs: ["get", "patch", "update"]
+- apiGroups: ["constraints.gatekeeper.sh"]
+  resources: ["*"]
+  verbs: ["create", "delete", "get", "list", "patch", "update", "watch"]
+- apiGroups: ["templates.gatekeeper.sh"]
+  resources: ["constrainttemplates", "constrainttemplates/finalizers"]
+  verbs: ["create", "delete", "get", "list", "patch", "update", "watch"]
+- apiGroups: ["templates.gatekeeper.sh"]
+  resources: ["constrainttemplates/status"]
+  verbs: ["get", "patch", "update"]
+- apiGroups: ["admissionregistration.k8s.io"]
+  resources: ["validatingwebhookconfigurations"]
+  verbs: ["create", "delete", "get", "list", "patch", "update", "watch"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: gatekeeper-manager-rolebinding
+  namespace: gatekeeper-system
+  labels:
+    gatekeeper.sh/system: "yes"
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: gatekeeper-manager-role
+subjects:
+- kind: ServiceAccount
+  name: gatekeeper-admin
+  namespace: gatekeeper-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: gatekeeper-manager-rolebinding
+  labels:
+    gatekeeper.sh/system: "yes"
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: gatekeeper-manager-role
+subjects:
+- kind: ServiceAccount
+  name: gatekeeper-admin
+  namespace: gatekeeper-system
+---
+# the certificate of the webhook server, generated and rotated by gatekeeper
+apiVersion: v1
+kind: Secret
+metadata:
+  name: gatekeeper-webhook-server-cert
+  namespace: gatekeeper-system
+  labels:
+    gatekeeper.sh/system: "yes"
+    addonmanager.kubernetes.io/mode: EnsureExists
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: gatekeeper-webhook-service
+  namespace: gatekeeper-system
+  labels:
+    gatekeeper.sh/system: "yes"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  ports:
+  - port: 443
+    targetPort: 8443
+  selector:
+    control-plane: controller-manager
+    gatekeeper.sh/system: "yes"
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: gatekeeper-controller-manager
+  namespace: gatekeeper-system
+  labels:
+    control-plane: controller-manager
+    gatekeeper.sh/system: "yes"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      control-plane: controller-manager
+      gatekeeper.sh/system: "yes"
+  template:
+    metadata:
+      labels:
+        control-plane: controller-manager
+        gatekeeper.sh/system: "yes"
+    spec:
+      serviceAccountName: gatekeeper-admin
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      containers:
+      - name: manager
+        image: {{ContainerImage "gatekeeper"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - /manager
+        args:
+        - --auditInterval={{ContainerConfig "auditInterval"}}
+        - --constraintViolationsLimit={{ContainerConfig "constraintViolationsLimit"}}
+        - --port=8443
+        - --logtostderr
+        env:
+        - name: POD_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+        - name: POD_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.name
+        ports:
+        - name: webhook-server
+          containerPort: 8443
+          protocol: TCP
+        - name: metrics
+          containerPort: 8888
+          protocol: TCP
+        - name: healthz
+          containerPort: 9090
+          protocol: TCP
+        livenessProbe:
+          httpGet:
+            path: /healthz
+            port: 9090
+        readinessProbe:
+          httpGet:
+            path: /readyz
+            port: 9090
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop:
+            - all
+          runAsGroup: 999
+          runAsNonRoot: true
+          runAsUser: 1000
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "gatekeeper"}}
+            memory: {{ContainerMemReqs "gatekeeper"}}
+          limits:
+            cpu: {{ContainerCPULimits "gatekeeper"}}
+            memory: {{ContainerMemLimits "gatekeeper"}}
+        volumeMounts:
+        - name: cert
+          mountPath: /certs
+          readOnly: true
+      terminationGracePeriodSeconds: 60
+      volumes:
+      - name: cert
+        secret:
+          defaultMode: 420
+          secretName: gatekeeper-webhook-server-cert
+---
+# gatekeeper injects the CA bundle of its certificate, the addon manager only creates the webhook configuration
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: gatekeeper-validating-webhook-configuration
+  labels:
+    gatekeeper.sh/system: "yes"
+    addonmanager.kubernetes.io/mode: EnsureExists
+webhooks:
+- name: validation.gatekeeper.sh
+  clientConfig:
+    service:
+      name: gatekeeper-webhook-service
+      namespace: gatekeeper-system
+      path: /v1/admit
+  failurePolicy: Ignore
+  namespaceSelector:
+    matchExpressions:
+    - key: admission.gatekeeper.sh/ignore
+      operator: DoesNotExist
+  rules:
+  - apiGroups: ["*"]
+    apiVersions: ["*"]
+    operations: ["CREATE", "UPDATE"]
+    resources: ["*"]
+  sideEffects: None
+  timeoutSeconds: 3
+{{- if GatekeeperSyncOnlyKinds}}
+---
+# the resources synced into the cache of gatekeeper, for the constraint templates referring to the other resources of the cluster
+apiVersion: config.gatekeeper.sh/v1alpha1
+kind: Config
+metadata:
+  name: config
+  namespace: gatekeeper-system
+  labels:
+    gatekeeper.sh/system: "yes"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  sync:
+    syncOnly:
+{{- range GatekeeperSyncOnlyKinds}}
+    - group: "{{.Group}}"
+      version: "{{.Version}}"
+      kind: "{{.Kind}}"
+{{- end}}
+{{- end}}
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: azure-policy
+  namespace: kube-system
+  labels:
+    app: azure-policy
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: azure-policy
+  labels:
+    app: azure-policy
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups: ["constraints.gatekeeper.sh", "templates.gatekeeper.sh"]
+  resources: ["*"]
+  verbs: ["create", "delete", "get", "list", "patch", "update", "watch"]
+- apiGroups: [""]
+  resources: ["namespaces"]
+  verbs: ["get", "list"]
+- apiGroups: [""]
+  resources: ["events"]
+  verbs: ["create", "patch"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: azure-policy
+  labels:
+    app: azure-policy
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: azure-policy
+subjects:
+- kind: ServiceAccount
+  name: azure-policy
+  namespace: kube-system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: azure-policy
+  namespace: kube-system
+  labels:
+    app: azure-policy
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: azure-policy
+  template:
+    metadata:
+      labels:
+        app: azure-policy
+    spec:
+      serviceAccountName: azure-policy
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      containers:
+      - name: azure-policy
+        image: {{ContainerImage "azure-policy"}}
+        imagePullPolicy: IfNotPresent
+        env:
+        # the policy assignments of the resource group of the cluster are synced as constraint templates and constraints
+        - name: RESOURCE_ID
+          value: <resourceId>
+        - name: ACS_CREDENTIAL_LOCATION
+          value: /etc/acs/azure.json
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "azure-policy"}}
+            memory: {{ContainerMemReqs "azure-policy"}}
+          limits:
+            cpu: {{ContainerCPULimits "azure-policy"}}
+            memory: {{ContainerMemLimits "azure-policy"}}
+        volumeMounts:
+        - name: acs-credential
+          mountPath: /etc/acs/azure.json
+          readOnly: true
+      volumes:
+      - name: acs-credential
+        hostPath:
+          path: /etc/kubernetes/azure.json
+          type: File
+`)
+
+func k8sContaineraddonsKubernetesmasteraddonsAzurePolicyDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsAzurePolicyDeploymentYaml, nil
+}
+
+func k8sContaineraddonsKubernetesmasteraddonsAzurePolicyDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsAzurePolicyDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-azure-policy-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsKubernetesmasteraddonsAzureWorkloadIdentityWebhookDeploymentYaml = []byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: azure-workload-identity-system
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: azure-wi-webhook-admin
+  namespace: azure-workload-identity-system
+  labels:
+    azure-workload-identity.io/system: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: azure-wi-webhook-manager-role
+  namespace: azure-workload-identity-system
+  labels:
+    azure-workload-identity.io/system: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups: [""]
+  resources: ["secrets"]
+  verbs: ["create", "get", "list", "patch", "update", "watch"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: azure-wi-webhook-manager-role
+  labels:
+    azure-workload-identity.io/system: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups: [""]
+  resources: ["serviceaccounts"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: ["admissionregistration.k8s.io"]
+  resources: ["mutatingwebhookconfigurations"]
+  verbs: ["get", "list", "update", "watch"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: azure-wi-webhook-manager-rolebinding
+  namespace: azure-workload-identity-system
+  labels:
+    azure-workload-identity.io/system: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: azure-wi-webhook-manager-role
+subjects:
+- kind: ServiceAccount
+  name: azure-wi-webhook-admin
+  namespace: azure-workload-identity-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: azure-wi-webhook-manager-rolebinding
+  labels:
+    azure-workload-identity.io/system: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: azure-wi-webhook-manager-role
+subjects:
+- kind: ServiceAccount
+  name: azure-wi-webhook-admin
+  namespace: azure-workload-identity-system
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: azure-wi-webhook-config
+  namespace: azure-workload-identity-system
+  labels:
+    azure-workload-identity.io/system: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+data:
+  AZURE_ENVIRONMENT: <cloud>
+  AZURE_TENANT_ID: <tenantID>
+---
+# the webhook generates its serving certificate in this secret and rotates it
+apiVersion: v1
+kind: Secret
+metadata:
+  name: azure-wi-webhook-server-cert
+  namespace: azure-workload-identity-system
+  labels:
+    azure-workload-identity.io/system: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: azure-wi-webhook-webhook-service
+  namespace: azure-workload-identity-system
+  labels:
+    azure-workload-identity.io/system: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  selector:
+    azure-workload-identity.io/system: "true"
+  ports:
+  - port: 443
+    targetPort: 9443
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: azure-wi-webhook-controller-manager
+  namespace: azure-workload-identity-system
+  labels:
+    azure-workload-identity.io/system: "true"
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      azure-workload-identity.io/system: "true"
+  template:
+    metadata:
+      labels:
+        azure-workload-identity.io/system: "true"
+    spec:
+      serviceAccountName: azure-wi-webhook-admin
+      priorityClassName: system-cluster-critical
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      containers:
+      - name: azure-workload-identity-webhook
+        image: {{ContainerImage "azure-workload-identity-webhook"}}
+        imagePullPolicy: IfNotPresent
+        args:
+        - --log-level=info
+        env:
+        - name: POD_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+        envFrom:
+        - configMapRef:
+            name: azure-wi-webhook-config
+        ports:
+        - name: webhook-server
+          containerPort: 9443
+          protocol: TCP
+        - name: metrics
+          containerPort: 8095
+          protocol: TCP
+        - name: healthz
+          containerPort: 9440
+          protocol: TCP
+        readinessProbe:
+          httpGet:
+            path: /readyz
+            port: healthz
+        livenessProbe:
+          httpGet:
+            path: /healthz
+            port: healthz
+          initialDelaySeconds: 15
+          periodSeconds: 20
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "azure-workload-identity-webhook"}}
+            memory: {{ContainerMemReqs "azure-workload-identity-webhook"}}
+          limits:
+            cpu: {{ContainerCPULimits "azure-workload-identity-webhook"}}
+            memory: {{ContainerMemLimits "azure-workload-identity-webhook"}}
+        securityContext:
+          allowPrivilegeEscalation: false
+          readOnlyRootFilesystem: true
+          runAsNonRoot: true
+        volumeMounts:
+        - name: cert
+          mountPath: /certs
+          readOnly: true
+      volumes:
+      - name: cert
+        secret:
+          secretName: azure-wi-webhook-server-cert
+          defaultMode: 420
+---
+# the webhook injects its CA bundle into this configuration, the addon manager must not reconcile it away
+apiVersion: admissionregistration.k8s.io/v1
+kind: MutatingWebhookConfiguration
+metadata:
+  name: azure-wi-webhook-mutating-webhook-configuration
+  labels:
+    azure-workload-identity.io/system: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
+webhooks:
+- name: mutation.azure-workload-identity.io
+  admissionReviewVersions: ["v1", "v1beta1"]
+  clientConfig:
+    service:
+      name: azure-wi-webhook-webhook-service
+      namespace: azure-workload-identity-system
+      path: /mutate-v1-pod
+  failurePolicy: Fail
+  matchPolicy: Equivalent
+  reinvocationPolicy: IfNeeded
+  sideEffects: None
+  objectSelector:
+    matchLabels:
+      azure.workload.identity/use: "true"
+  rules:
+  - apiGroups: [""]
+    apiVersions: ["v1"]
+    operations: ["CREATE"]
+    resources: ["pods"]
+`)
+
+func k8sContaineraddonsKubernetesmasteraddonsAzureWorkloadIdentityWebhookDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsAzureWorkloadIdentityWebhookDeploymentYaml, nil
+}
+
+func k8sContaineraddonsKubernetesmasteraddonsAzureWorkloadIdentityWebhookDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsAzureWorkloadIdentityWebhookDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-azure-workload-identity-webhook-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsKubernetesmasteraddonsBlobfuseFlexvolumeInstallerYaml = []byte(`apiVersion: extensions/v1beta1
+kind: DaemonSet
+metadata:
+  name: blobfuse-flexvol-installer
+  namespace: kube-system
+  labels:
+    k8s-app: blobfuse
+    kubernetes.io/cluster-service: "true"
+spec:
+  selector:
+    matchLabels:
+      name: blobfuse
+  template:
+    metadata:
+      labels:
+        name: blobfuse
+        kubernetes.io/cluster-service: "true"
+    spec:
+      containers:
+      - name: blobfuse-flexvol-installer
+        image: {{ContainerImage "blobfuse-flexvolume"}}
+        imagePullPolicy: IfNotPresent
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "blobfuse-flexvolume"}}
+            memory: {{ContainerMemReqs "blobfuse-flexvolume"}}
+          limits:
+            cpu: {{ContainerCPULimits "blobfuse-flexvolume"}}
+            memory: {{ContainerMemLimits "blobfuse-flexvolume"}}
+        volumeMounts:
+        - name: volplugins
+          mountPath: /etc/kubernetes/volumeplugins/
+        - name: varlog
+          mountPath: /var/log/      
+      volumes:
+      - name: varlog
+        hostPath:
+          path: /var/log/              
+      - name: volplugins
+        hostPath:
+          path: /etc/kubernetes/volumeplugins/
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+`)
+
+func k8sContaineraddonsKubernetesmasteraddonsBlobfuseFlexvolumeInstallerYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsBlobfuseFlexvolumeInstallerYaml, nil
+}
+
+func k8sContaineraddonsKubernetesmasteraddonsBlobfuseFlexvolumeInstallerYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsBlobfuseFlexvolumeInstallerYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-blobfuse-flexvolume-installer.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsKubernetesmasteraddonsCalicoDaemonsetYaml = []byte(`# Source: calico/templates/calico-config.yaml
+# This ConfigMap is used to configure a self-hosted Calico installation.
+kind: ConfigMap
+apiVersion: v1
+metadata:
+  name: calico-config
+  namespace: kube-system
+  labels:
+    addonmanager.kubernetes.io/mode: "EnsureExists"
+data:
+  # You must set a non-zero value for Typha replicas below.
+  typha_service_name: "calico-typha"
+
+  # The CNI network configuration to install on each node.  The special
+  # values in this config will be automatically populated.
+  cni_network_config: |-
+    {
+      "name": "k8s-pod-network",
+      "cniVersion": "0.3.1",
+      "plugins": [
+        {
+          "type": "calico",
+          "log_level": "info",
+          "datastore_type": "kubernetes",
+          "nodename": "__KUBERNETES_NODE_NAME__",
+          "mtu": 1500,
+          "ipam": <calicoIPAMConfig>,
+          "policy": {
+              "type": "k8s"
+          },
+          "kubernetes": {
+              "kubeconfig": "__KUBECONFIG_FILEPATH__"
+          }
+        },
+        {
+          "type": "portmap",
+          "snat": true,
+          "capabilities": {"portMappings": true}
+        }
+      ]
+    }
+
+---
+# Source: calico/templates/kdd-crds.yaml
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: felixconfigurations.crd.projectcalico.org
+  labels:
+    addonmanager.kubernetes.io/mode: "Reconcile"
+spec:
+  scope: Cluster
+  group: crd.projectcalico.org
+  version: v1
+  names:
+    kind: FelixConfiguration
+    plural: felixconfigurations
+    singular: felixconfiguration
+---
+
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: bgpconfigurations.crd.projectcalico.org
+  labels:
+    addonmanager.kubernetes.io/mode: "Reconcile"
+spec:
+  scope: Cluster
+  group: crd.projectcalico.org
+  version: v1
+  names:
+    kind: BGPConfiguration
+    plural: bgpconfigurations
+    singular: bgpconfiguration
+---
+
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: ippools.crd.projectcalico.org
+  labels:
+    addonmanager.kubernetes.io/mode: "Reconcile"
+spec:
+  scope: Cluster
+  group: crd.projectcalico.org
+  version: v1
+  names:
+    kind: IPPool
+    plural: ippools
+    singular: ippool
+---
+
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: hostendpoints.crd.projectcalico.org
+  labels:
+    addonmanager.kubernetes.io/mode: "Reconcile"
+spec:
+  scope: Cluster
+  group: crd.projectcalico.org
+  version: v1
+  names:
+    kind: HostEndpoint
+    plural: hostendpoints
+    singular: hostendpoint
+---
+
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: clusterinformations.crd.projectcalico.org
+  labels:
+    addonmanager.kubernetes.io/mode: "Reconcile"
+spec:
+  scope: Cluster
+  group: crd.projectcalico.org
+  version: v1
+  names:
+    kind: ClusterInformation
+    plural: clusterinformations
+    singular: clusterinformation
+---
+
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: globalnetworkpolicies.crd.projectcalico.org
+  labels:
+    addonmanager.kubernetes.io/mode: "Reconcile"
+spec:
+  scope: Cluster
+  group: crd.projectcalico.org
+  version: v1
+  names:
+    kind: GlobalNetworkPolicy
+    plural: globalnetworkpolicies
+    singular: globalnetworkpolicy
+---
+
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: globalnetworksets.crd.projectcalico.org
+  labels:
+    addonmanager.kubernetes.io/mode: "Reconcile"
+spec:
+  scope: Cluster
+  group: crd.projectcalico.org
+  version: v1
+  names:
+    kind: GlobalNetworkSet
+    plural: globalnetworksets
+    singular: globalnetworkset
+---
+
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: networkpolicies.crd.projectcalico.org
+  labels:
+    addonmanager.kubernetes.io/mode: "Reconcile"
+spec:
+  scope: Namespaced
+  group: crd.projectcalico.org
+  version: v1
+  names:
+    kind: NetworkPolicy
+    plural: networkpolicies
+    singular: networkpolicy
+---
+
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: networksets.crd.projectcalico.org
+  labels:
+    addonmanager.kubernetes.io/mode: "Reconcile"
+spec:
+  scope: Namespaced
+  group: crd.projectcalico.org
+  version: v1
+  names:
+    kind: NetworkSet
+    plural: networksets
+    singular: networkset
+---
+# Source: calico/templates/rbac.yaml
+
+# Include a clusterrole for the calico-node DaemonSet,
+# and bind it to the calico-node serviceaccount.
+kind: ClusterRole
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: calico-node
+  labels:
+    addonmanager.kubernetes.io/mode: "Reconcile"
+rules:
+# The CNI plugin needs to get pods, nodes, and namespaces.
+- apiGroups: [""]
+  resources:
+  - pods
+  - nodes
+  - namespaces
+  verbs:
+  - get
+- apiGroups: [""]
+  resources:
+  - endpoints
+  - services
+  verbs:
+  # Used to discover service IPs for advertisement.
+  - watch
+  - list
+  # Used to discover Typhas.
+  - get
+- apiGroups: [""]
+  resources:
+  - nodes/status
+  verbs:
+  # Needed for clearing NodeNetworkUnavailable flag.
+  - patch
+  # Calico stores some configuration information in node annotations.
+  - update
+# Watch for changes to Kubernetes NetworkPolicies.
+- apiGroups: ["networking.k8s.io"]
+  resources:
+  - networkpolicies
+  verbs:
+  - watch
+  - list
+# Used by Calico for policy information.
+- apiGroups: [""]
+  resources:
+  - pods
+  - namespaces
+  - serviceaccounts
+  verbs:
+  - list
+  - watch
+# The CNI plugin patches pods/status.
+- apiGroups: [""]
+  resources:
+  - pods/status
+  verbs:
+  - patch
+# Calico monitors various CRDs for config.
+- apiGroups: ["crd.projectcalico.org"]
+  resources:
+  - globalfelixconfigs
+  - felixconfigurations
+  - bgppeers
+  - globalbgpconfigs
+  - bgpconfigurations
+  - ippools
+  - ipamblocks
+  - globalnetworkpolicies
+  - globalnetworksets
+  - networkpolicies
+  - networksets
+  - clusterinformations
+  - hostendpoints
+  verbs:
+  - get
+  - list
+  - watch
+# Calico must create and update some CRDs on startup.
+- apiGroups: ["crd.projectcalico.org"]
+  resources:
+  - ippools
+  - felixconfigurations
+  - clusterinformations
+  verbs:
+  - create
+  - update
+# Calico stores some configuration information on the node.
+- apiGroups: [""]
+  resources:
+  - nodes
+  verbs:
+  - get
+  - list
+  - watch
+# These permissions are only requried for upgrade from v2.6, and can
+# be removed after upgrade or on fresh installations.
+- apiGroups: ["crd.projectcalico.org"]
+  resources:
+  - bgpconfigurations
+  - bgppeers
+  verbs:
+  - create
+  - update
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: calico-node
+  labels:
+    addonmanager.kubernetes.io/mode: "Reconcile"
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: calico-node
+subjects:
+- kind: ServiceAccount
+  name: calico-node
+  namespace: kube-system
+---
+# Source: calico/templates/calico-typha.yaml
+# This manifest creates a Service, which will be backed by Calico's Typha daemon.
+# Typha sits in between Felix and the API server, reducing Calico's load on the API server.
+
+apiVersion: v1
+kind: Service
+metadata:
+  name: calico-typha
   namespace: kube-system
+  labels:
+    k8s-app: calico-typha
+    addonmanager.kubernetes.io/mode: "Reconcile"
 spec:
+  ports:
+  - port: 5473
+    protocol: TCP
+    targetPort: calico-typha
+    name: calico-typha
   selector:
-    matchLabels:
-      component: oms-agent
-      tier: node
-  template:
-    metadata:
-      annotations:
-        agentVersion: {{ContainerConfig "omsAgentVersion"}}
-        dockerProviderVersion: {{ContainerConfig "dockerProviderVersion"}}
-        schema-versions:  {{ContainerConfig "schema-versions"}}
-      labels:
-        component: oms-agent
-        tier: node
-    spec:
-      priorityClassName: system-node-critical
-      serviceAccountName: omsagent
-      containers:
-        - name: omsagent
-          image: {{ContainerImage "omsagent"}}
-          imagePullPolicy: Always
-          resources:
-            limits:
-              cpu: {{ContainerCPULimits "omsagent"}}
-              memory: {{ContainerMemLimits "omsagent"}}
-            requests:
-              cpu: {{ContainerCPUReqs "omsagent"}}
-              memory: {{ContainerMemReqs "omsagent"}}
-          env:
-            - name: NODE_IP
-              valueFrom:
-                fieldRef:
-                  fieldPath: status.hostIP
-            - name: ACS_RESOURCE_NAME
-              value: {{ContainerConfig "clusterName"}}            
-            - name: CONTROLLER_TYPE
-              value: "DaemonSet"
-            - name: ISTEST
-              value: "true"
-          livenessProbe:
-            exec:
-              command:
-                - /bin/bash
-                - -c
-                - /opt/livenessprobe.sh
-            initialDelaySeconds: 60
-            periodSeconds: 60
-          ports:
-            - containerPort: 25225
-              protocol: TCP
-            - containerPort: 25224
-              protocol: UDP
-          securityContext:
-            privileged: true
-          volumeMounts:
-            - mountPath: /hostfs
-              name: host-root
-            - mountPath: /var/run/host
-              name: docker-sock
-            - mountPath: /var/log
-              name: host-log
-            - mountPath: /var/lib/docker/containers
-              name: containerlog-path
-            - mountPath: /etc/kubernetes/host
-              name: azure-json-path
-            - mountPath: /etc/omsagent-secret
-              name: omsagent-secret
-              readOnly: true
-            - mountPath: /etc/config/settings
-              name: settings-vol-config  
-      nodeSelector:
-        beta.kubernetes.io/os: linux
-      tolerations:
-        - effect: NoSchedule
-          key: node-role.kubernetes.io/master
-          operator: Equal
-          value: "true"
-      volumes:
-        - name: host-root
-          hostPath:
-            path: /
-        - name: docker-sock
-          hostPath:
-            path: /var/run
-        - name: container-hostname
-          hostPath:
-            path: /etc/hostname
-        - name: host-log
-          hostPath:
-            path: /var/log
-        - name: containerlog-path
-          hostPath:
-            path: /var/lib/docker/containers
-        - name: azure-json-path
-          hostPath:
-            path: /etc/kubernetes
-        - name: omsagent-secret
-          secret:
-            secretName: omsagent-secret
-        - name: settings-vol-config
-          configMap:
-            name: container-azm-ms-agentconfig
-            optional: true    
-  updateStrategy:
-    type: RollingUpdate
+    k8s-app: calico-typha
 ---
+
+# This manifest creates a Deployment of Typha to back the above service.
+
 apiVersion: apps/v1
 kind: Deployment
 metadata:
-  name: omsagent-rs
+  name: calico-typha
   namespace: kube-system
   labels:
-    component: oms-agent
-    tier: node
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
+    k8s-app: calico-typha
+    addonmanager.kubernetes.io/mode: "Reconcile"
 spec:
-  replicas: 1
-  selector:
-    matchLabels:
-      rsName: omsagent-rs
-  strategy:
-    type: RollingUpdate
-  template:
-    metadata:
-      labels:
-        rsName: omsagent-rs
-      annotations:
-        agentVersion: {{ContainerConfig "omsAgentVersion"}}
-        dockerProviderVersion: {{ContainerConfig "dockerProviderVersion"}}
-        schema-versions:  {{ContainerConfig "schema-versions"}}
-    spec:
-      serviceAccountName: omsagent
-      containers:
-        - name: omsagent
-          image: {{ContainerImage "omsagent"}}
-          imagePullPolicy: IfNotPresent
-          resources:
-            limits:
-              cpu: {{ContainerCPULimits "omsagent"}}
-              memory: {{ContainerMemLimits "omsagent"}}
-            requests:
-              cpu: {{ContainerCPUReqs "omsagent"}}
-              memory: {{ContainerMemReqs "omsagent"}}
-          env:
-            - name: NODE_IP
-              valueFrom:
-                fieldRef:
-                  fieldPath: status.hostIP
-            - name: ACS_RESOURCE_NAME
-              value: {{ContainerConfig "clusterName"}}            
-            - name: CONTROLLER_TYPE
-              value: "ReplicaSet"
-            - name: ISTEST
-              value: "true"
-          securityContext:
-            privileged: true
-          ports:
-            - containerPort: 25225
-              protocol: TCP
-            - containerPort: 25224
-              protocol: UDP
-          volumeMounts:
-            - mountPath: /var/run/host
-              name: docker-sock
-            - mountPath: /var/log
-              name: host-log
-            - mountPath: /var/lib/docker/containers
-              name: containerlog-path
-            - mountPath: /etc/kubernetes/host
-              name: azure-json-path
-            - mountPath: /etc/omsagent-secret
-              name: omsagent-secret
-              readOnly: true
-            - mountPath : /etc/config
-              name: omsagent-rs-config
-            - mountPath: /etc/config/settings
-              name: settings-vol-config              
-          livenessProbe:
-            exec:
-              command:
-                - /bin/bash
-                - -c
-                - /opt/livenessprobe.sh
-            initialDelaySeconds: 60
-            periodSeconds: 60
-      nodeSelector:
-        beta.kubernetes.io/os: linux
-        kubernetes.io/role: agent
-      volumes:
-        - name: docker-sock
-          hostPath:
-            path: /var/run
-        - name: container-hostname
-          hostPath:
-            path: /etc/hostname
-        - name: host-log
-          hostPath:
-            path: /var/log
-        - name: containerlog-path
-          hostPath:
-            path: /var/lib/docker/containers
-        - name: azure-json-path
-          hostPath:
-            path: /etc/kubernetes
-        - name: omsagent-secret
-          secret:
-            secretName: omsagent-secret
-        - name: omsagent-rs-config
-          configMap:
-            name: omsagent-rs-config
-        - name: settings-vol-config
-          configMap:
-            name: container-azm-ms-agentconfig
-            optional: true    
-`)
-
-func k8sContaineraddons116KubernetesmasteraddonsOmsagentDaemonsetYamlBytes() ([]byte, error) {
-	return _k8sContaineraddons116KubernetesmasteraddonsOmsagentDaemonsetYaml, nil
-}
-
-func k8sContaineraddons116KubernetesmasteraddonsOmsagentDaemonsetYaml() (*asset, error) {
-	bytes, err := k8sContaineraddons116KubernetesmasteraddonsOmsagentDaemonsetYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/containeraddons/1.16/kubernetesmasteraddons-omsagent-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sContaineraddons116KubernetesmasteraddonsSmbFlexvolumeInstallerYaml = []byte(`apiVersion: apps/v1
+  # Number of Typha replicas.  To enable Typha, set this to a non-zero value *and* set the
+  # typha_service_name variable in the calico-config ConfigMap above.
+  #
+  # We recommend using Typha if you have more than 50 nodes.  Above 100 nodes it is essential
+  # (when using the Kubernetes datastore).  Use one replica for every 100-200 nodes.  In
+  # production, we recommend running at least 3 replicas to reduce the impact of rolling upgrade.
+  replicas: 1
+  revisionHistoryLimit: 2
+  selector:
+    matchLabels:
+      k8s-app: calico-typha
+  template:
+    metadata:
+      labels:
+        k8s-app: calico-typha
+      annotations:
+        # This, along with the CriticalAddonsOnly toleration below, marks the pod as a critical
+        # add-on, ensuring it gets priority scheduling and that its resources are reserved
+        # if it ever gets evicted.
+        scheduler.alpha.kubernetes.io/critical-pod: ''
+        cluster-autoscaler.kubernetes.io/safe-to-evict: 'true'
+    spec:
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      hostNetwork: true
+      tolerations:
+      # Mark the pod as a critical add-on for rescheduling.
+      - key: CriticalAddonsOnly
+        operator: Exists
+      # Since Calico can't network a pod until Typha is up, we need to run Typha itself
+      # as a host-networked pod.
+      serviceAccountName: calico-node
+      priorityClassName: system-cluster-critical
+      containers:
+      - image: {{ContainerImage "calico-typha"}}
+        name: calico-typha
+        ports:
+        - containerPort: 5473
+          name: calico-typha
+          protocol: TCP
+        env:
+        # Enable "info" logging by default.  Can be set to "debug" to increase verbosity.
+        - name: TYPHA_LOGSEVERITYSCREEN
+          value: "info"
+        # Disable logging to file and syslog since those don't make sense in Kubernetes.
+        - name: TYPHA_LOGFILEPATH
+          value: "none"
+        - name: TYPHA_LOGSEVERITYSYS
+          value: "none"
+        # Monitor the Kubernetes API to find the number of running instances and rebalance
+        # connections.
+        - name: TYPHA_CONNECTIONREBALANCINGMODE
+          value: "kubernetes"
+        - name: TYPHA_DATASTORETYPE
+          value: "kubernetes"
+        - name: TYPHA_HEALTHENABLED
+          value: "true"
+        # Configure route aggregation based on pod CIDR.
+        - name: USE_POD_CIDR
+          value: "true"
+        - name: FELIX_INTERFACEPREFIX
+          value: "azv"
+        # Uncomment these lines to enable prometheus metrics.  Since Typha is host-networked,
+        # this opens a port on the host, which may need to be secured.
+        #- name: TYPHA_PROMETHEUSMETRICSENABLED
+        #  value: "true"
+        #- name: TYPHA_PROMETHEUSMETRICSPORT
+        #  value: "9093"
+        livenessProbe:
+          httpGet:
+            path: /liveness
+            port: 9098
+            host: localhost
+          periodSeconds: 30
+          initialDelaySeconds: 30
+        readinessProbe:
+          httpGet:
+            path: /readiness
+            port: 9098
+            host: localhost
+          periodSeconds: 10
+---
+# Source: calico/templates/calico-node.yaml
+# This manifest installs the calico-node container, as well
+# as the CNI plugins and network config on
+# each master and worker node in a Kubernetes cluster.
 kind: DaemonSet
+apiVersion: apps/v1
 metadata:
-  name: smb-flexvol-installer
+  name: calico-node
   namespace: kube-system
   labels:
-    k8s-app: smb
-    kubernetes.io/cluster-service: "true"
+    k8s-app: calico-node
+    addonmanager.kubernetes.io/mode: "Reconcile"
 spec:
   selector:
     matchLabels:
-      name: smb
+      k8s-app: calico-node
+  updateStrategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxUnavailable: 1
   template:
     metadata:
       labels:
-        name: smb
-        kubernetes.io/cluster-service: "true"
+        k8s-app: calico-node
+      annotations:
+        # This, along with the CriticalAddonsOnly toleration below,
+        # marks the pod as a critical add-on, ensuring it gets
+        # priority scheduling and that its resources are reserved
+        # if it ever gets evicted.
+        scheduler.alpha.kubernetes.io/critical-pod: ''
     spec:
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      hostNetwork: true
+      tolerations:
+      # Make sure calico-node gets scheduled on all nodes.
+      - effect: NoSchedule
+        operator: Exists
+      # Mark the pod as a critical add-on for rescheduling.
+      - key: CriticalAddonsOnly
+        operator: Exists
+      - effect: NoExecute
+        operator: Exists
+      serviceAccountName: calico-node
+      # Minimize downtime during a rolling upgrade or deletion; tell Kubernetes to do a "force
+      # deletion": https://kubernetes.io/docs/concepts/workloads/pods/pod/#termination-of-pods.
+      terminationGracePeriodSeconds: 0
+      priorityClassName: system-node-critical
+      initContainers:
+      # Start of install-cni initContainer
+      # This container installs the CNI binaries
+      # and CNI network config file on each node.
+      - name: install-cni
+        image: {{ContainerImage "calico-cni"}}
+        command: ["/install-cni.sh"]
+        env:
+        # Name of the CNI config file to create.
+        - name: CNI_CONF_NAME
+          value: "10-calico.conflist"
+        # The CNI network config to install on each node.
+        - name: CNI_NETWORK_CONFIG
+          valueFrom:
+            configMapKeyRef:
+              name: calico-config
+              key: cni_network_config
+        # Set the hostname based on the k8s node name.
+        - name: KUBERNETES_NODE_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: spec.nodeName
+        # Prevents the container from sleeping forever.
+        - name: SLEEP
+          value: "false"
+        volumeMounts:
+        - mountPath: /host/opt/cni/bin
+          name: cni-bin-dir
+        - mountPath: /host/etc/cni/net.d
+          name: cni-net-dir
+      # End of install-cni initContainer
+      # Adds a Flex Volume Driver that creates a per-pod Unix Domain Socket to allow Dikastes
+      # to communicate with Felix over the Policy Sync API.
+      - name: flexvol-driver
+        image: {{ContainerImage "calico-pod2daemon"}}
+        volumeMounts:
+        - name: flexvol-driver-host
+          mountPath: /host/driver
       containers:
-      - name: smb-flexvol-installer
-        image: {{ContainerImage "smb-flexvolume"}}
-        imagePullPolicy: Always
+      # Runs calico-node container on each Kubernetes node.  This
+      # container programs network policy and routes on each
+      # host.
+      - name: calico-node
+        image: {{ContainerImage "calico-node"}}
+        env:
+        # Use Kubernetes API as the backing datastore.
+        - name: DATASTORE_TYPE
+          value: "kubernetes"
+        # Configure route aggregation based on pod CIDR.
+        - name: USE_POD_CIDR
+          value: "true"
+        # Typha support: controlled by the ConfigMap.
+        - name: FELIX_TYPHAK8SSERVICENAME
+          valueFrom:
+            configMapKeyRef:
+              name: calico-config
+              key: typha_service_name
+        # Wait for the datastore.
+        - name: WAIT_FOR_DATASTORE
+          value: "true"
+        # Set based on the k8s node name.
+        - name: NODENAME
+          valueFrom:
+            fieldRef:
+              fieldPath: spec.nodeName
+        # Don't enable BGP.
+        - name: CALICO_NETWORKING_BACKEND
+          value: "none"
+        # Cluster type to identify the deployment type
+        - name: CLUSTER_TYPE
+          value: "k8s"
+        # The default IPv4 pool to create on startup if none exists. Pod IPs will be
+        # chosen from this range. Changing this value after installation will have
+        # no effect. This should fall within ` + "`" + `--cluster-cidr` + "`" + `.
+        - name: CALICO_IPV4POOL_CIDR
+          value: "<kubeClusterCidr>"
+        # Disable file logging so ` + "`" + `kubectl logs` + "`" + ` works.
+        - name: CALICO_DISABLE_FILE_LOGGING
+          value: "true"
+        # Set Felix endpoint to host default action to ACCEPT.
+        - name: FELIX_DEFAULTENDPOINTTOHOSTACTION
+          value: "ACCEPT"
+        # Disable IPv6 on Kubernetes.
+        - name: FELIX_IPV6SUPPORT
+          value: "false"
+        # Set Felix logging to "info"
+        - name: FELIX_LOGSEVERITYSCREEN
+          value: "info"
+        - name: FELIX_HEALTHENABLED
+          value: "true"
+        - name: CALICO_IPV4POOL_IPIP
+          value: "off"
+        - name: FELIX_INTERFACEPREFIX
+          value: "azv"
+        securityContext:
+          privileged: true
         resources:
           requests:
-            cpu: {{ContainerCPUReqs "smb-flexvolume"}}
-            memory: {{ContainerMemReqs "smb-flexvolume"}}
-          limits:
-            cpu: {{ContainerCPULimits "smb-flexvolume"}}
-            memory: {{ContainerMemLimits "smb-flexvolume"}}
+            cpu: 250m
+        livenessProbe:
+          httpGet:
+            path: /liveness
+            port: 9099
+            host: localhost
+          periodSeconds: 10
+          initialDelaySeconds: 10
+          failureThreshold: 6
+        readinessProbe:
+          exec:
+            command:
+            - /bin/calico-node
+            - -felix-ready
+          periodSeconds: 10
         volumeMounts:
-        - name: volplugins
-          mountPath: /etc/kubernetes/volumeplugins/
-        - name: varlog
-          mountPath: /var/log/
+        - mountPath: /lib/modules
+          name: lib-modules
+          readOnly: true
+        - mountPath: /run/xtables.lock
+          name: xtables-lock
+          readOnly: false
+        - mountPath: /var/run/calico
+          name: var-run-calico
+          readOnly: false
+        - mountPath: /var/lib/calico
+          name: var-lib-calico
+          readOnly: false
+        - name: policysync
+          mountPath: /var/run/nodeagent
       volumes:
-      - name: varlog
+      # Used by calico-node.
+      - name: lib-modules
         hostPath:
-          path: /var/log/
-      - name: volplugins
+          path: /lib/modules
+      - name: var-run-calico
+        hostPath:
+          path: /var/run/calico
+      - name: var-lib-calico
+        hostPath:
+          path: /var/lib/calico
+      - name: xtables-lock
+        hostPath:
+          path: /run/xtables.lock
+          type: FileOrCreate
+      # Used to install CNI.
+      - name: cni-bin-dir
+        hostPath:
+          path: /opt/cni/bin
+      - name: cni-net-dir
+        hostPath:
+          path: /etc/cni/net.d
+      # Used to create per-pod Unix Domain Sockets
+      - name: policysync
         hostPath:
-          path: /etc/kubernetes/volumeplugins/
           type: DirectoryOrCreate
-      nodeSelector:
-        beta.kubernetes.io/os: linux
-`)
-
-func k8sContaineraddons116KubernetesmasteraddonsSmbFlexvolumeInstallerYamlBytes() ([]byte, error) {
-	return _k8sContaineraddons116KubernetesmasteraddonsSmbFlexvolumeInstallerYaml, nil
-}
-
-func k8sContaineraddons116KubernetesmasteraddonsSmbFlexvolumeInstallerYaml() (*asset, error) {
-	bytes, err := k8sContaineraddons116KubernetesmasteraddonsSmbFlexvolumeInstallerYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/containeraddons/1.16/kubernetesmasteraddons-smb-flexvolume-installer.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
+          path: /var/run/nodeagent
+      # Used to install Flex Volume Driver
+      - name: flexvol-driver-host
+        hostPath:
+          type: DirectoryOrCreate
+          path: /etc/kubernetes/volumeplugins/nodeagent~uds
+---
 
-var _k8sContaineraddons116KubernetesmasteraddonsTillerDeploymentYaml = []byte(`apiVersion: v1
+apiVersion: v1
 kind: ServiceAccount
 metadata:
-  name: tiller
+  name: calico-node
   namespace: kube-system
   labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
----
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: ClusterRoleBinding
-metadata:
-  name: tiller
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: cluster-admin
-subjects:
-- kind: ServiceAccount
-  name: tiller
-  namespace: kube-system
+    addonmanager.kubernetes.io/mode: "Reconcile"
 ---
+
+# Typha Horizontal Autoscaler ConfigMap
+kind: ConfigMap
 apiVersion: v1
-kind: Service
 metadata:
-  labels:
-    app: helm
-    name: tiller
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-  name: tiller-deploy
+  name: calico-typha-horizontal-autoscaler
   namespace: kube-system
-spec:
-  ports:
-  - name: tiller
-    port: 44134
-    targetPort: tiller
-  selector:
-    app: helm
-    name: tiller
-  type: ClusterIP
----
-apiVersion: apps/v1
-kind: Deployment
-metadata:
   labels:
-    app: helm
-    name: tiller
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-  name: tiller-deploy
-  namespace: kube-system
-spec:
-  selector:
-    matchLabels:
-      app: helm
-      name: tiller
-  template:
-    metadata:
-      labels:
-        app: helm
-        name: tiller
-    spec:
-      serviceAccountName: tiller
-      containers:
-      - env:
-        - name: TILLER_NAMESPACE
-          value: kube-system
-        - name: TILLER_HISTORY_MAX
-          value: "{{ContainerConfig "max-history"}}"
-        image: {{ContainerImage "tiller"}}
-        imagePullPolicy: IfNotPresent
-        livenessProbe:
-          httpGet:
-            path: /liveness
-            port: 44135
-          initialDelaySeconds: 1
-          timeoutSeconds: 1
-        name: tiller
-        ports:
-        - containerPort: 44134
-          name: tiller
-        readinessProbe:
-          httpGet:
-            path: /readiness
-            port: 44135
-          initialDelaySeconds: 1
-          timeoutSeconds: 1
-        resources:
-          requests:
-            cpu: {{ContainerCPUReqs "tiller"}}
-            memory: {{ContainerMemReqs "tiller"}}
-          limits:
-            cpu: {{ContainerCPULimits "tiller"}}
-            memory: {{ContainerMemLimits "tiller"}}
-      nodeSelector:
-        beta.kubernetes.io/os: linux
-`)
-
-func k8sContaineraddons116KubernetesmasteraddonsTillerDeploymentYamlBytes() ([]byte, error) {
-	return _k8sContaineraddons116KubernetesmasteraddonsTillerDeploymentYaml, nil
-}
-
-func k8sContaineraddons116KubernetesmasteraddonsTillerDeploymentYaml() (*asset, error) {
-	bytes, err := k8sContaineraddons116KubernetesmasteraddonsTillerDeploymentYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/containeraddons/1.16/kubernetesmasteraddons-tiller-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
+    addonmanager.kubernetes.io/mode: "EnsureExists"
+data:
+  ladder: |-
+    {
+      "coresToReplicas": [],
+      "nodesToReplicas":
+      [
+        [1, 1],
+        [10, 2],
+        [100, 3],
+        [250, 4],
+        [500, 5],
+        [1000, 6],
+        [1500, 7],
+        [2000, 8]
+      ]
+    }
 
-var _k8sContaineraddons16KubernetesmasteraddonsHeapsterDeploymentYaml = []byte(`apiVersion: v1
-kind: ServiceAccount
-metadata:
-  name: heapster
-  namespace: kube-system
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
----
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: ClusterRole
-metadata:
-  name: system:heapster-with-nanny
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
-rules:
-- apiGroups:
-  - extensions
-  - apps
-  resources:
-  - deployments
-  verbs:
-  - get
-  - list
-  - watch
-  - update
-  - patch
-- apiGroups:
-  - ""
-  resources:
-  - events
-  - namespaces
-  - nodes
-  - pods
-  verbs:
-  - get
-  - list
-  - watch
----
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: ClusterRoleBinding
-metadata:
-  name: system:heapster-with-nanny
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: system:heapster-with-nanny
-subjects:
-- kind: ServiceAccount
-  name: heapster
-  namespace: kube-system
----
-apiVersion: v1
-kind: Service
-metadata:
-  labels:
-    kubernetes.io/cluster-service: "true"
-    kubernetes.io/name: Heapster
-  name: heapster
-  namespace: kube-system
-spec:
-  ports:
-  - port: 80
-    targetPort: 8082
-  selector:
-    k8s-app: heapster
 ---
-kind: Deployment
+
+# Typha Horizontal Autoscaler Deployment
 apiVersion: extensions/v1beta1
+kind: Deployment
 metadata:
-  labels:
-    k8s-app: heapster
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
+  name: calico-typha-horizontal-autoscaler
   namespace: kube-system
-  name: heapster
+  labels:
+    k8s-app: calico-typha-autoscaler
+    addonmanager.kubernetes.io/mode: "Reconcile"
 spec:
   replicas: 1
-  selector:
-    matchLabels:
-      k8s-app: heapster
   template:
     metadata:
       labels:
-        k8s-app: heapster
+        k8s-app: calico-typha-autoscaler
       annotations:
-        scheduler.alpha.kubernetes.io/critical-pod: ""
+        scheduler.alpha.kubernetes.io/critical-pod: ''
     spec:
-      tolerations:
-      - key: CriticalAddonsOnly
-        operator: Exists
-      serviceAccountName: heapster
+      priorityClassName: system-cluster-critical
+      securityContext:
+        supplementalGroups: [65534]
+        fsGroup: 65534
       containers:
-      - image: {{ContainerImage "heapster"}}
-        imagePullPolicy: IfNotPresent
-        command:
-        - "/heapster"
-        - "--source=kubernetes.summary_api:\"\""
-        name: heapster
-        resources:
-          requests:
-            cpu: 80m
-            memory: 140Mi
-          limits:
-            cpu: 80m
-            memory: 140Mi
-      - image: {{ContainerImage "heapster-nanny"}}
-        imagePullPolicy: IfNotPresent
+      - image: {{ContainerImage "calico-cluster-proportional-autoscaler"}}
+        name: autoscaler
         command:
-        - "/pod_nanny"
-        - "--cpu=80m"
-        - "--extra-cpu=0.5m"
-        - "--memory=140Mi"
-        - "--extra-memory=4Mi"
-        - "--threshold=5"
-        - "--deployment=heapster"
-        - "--container=heapster"
-        - "--poll-period=300000"
-        - "--estimator=exponential"
-        name: heapster-nanny
+        - /cluster-proportional-autoscaler
+        - --namespace=kube-system
+        - --configmap=calico-typha-horizontal-autoscaler
+        - --target=deployment/calico-typha
+        - --logtostderr=true
+        - --v=2
         resources:
           requests:
-            cpu: 50m
-            memory: 90Mi
+            cpu: 10m
           limits:
-            cpu: 50m
-            memory: 90Mi
-        env:
-        - valueFrom:
-            fieldRef:
-              fieldPath: metadata.name
-          name: MY_POD_NAME
-        - valueFrom:
-            fieldRef:
-              fieldPath: metadata.namespace
-          name: MY_POD_NAMESPACE
-      nodeSelector:
-        beta.kubernetes.io/os: linux
-`)
-
-func k8sContaineraddons16KubernetesmasteraddonsHeapsterDeploymentYamlBytes() ([]byte, error) {
-	return _k8sContaineraddons16KubernetesmasteraddonsHeapsterDeploymentYaml, nil
-}
-
-func k8sContaineraddons16KubernetesmasteraddonsHeapsterDeploymentYaml() (*asset, error) {
-	bytes, err := k8sContaineraddons16KubernetesmasteraddonsHeapsterDeploymentYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/containeraddons/1.6/kubernetesmasteraddons-heapster-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sContaineraddons17KubernetesmasteraddonsHeapsterDeploymentYaml = []byte(`apiVersion: v1
-kind: ServiceAccount
-metadata:
-  name: heapster
-  namespace: kube-system
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
+            cpu: 10m
+      serviceAccountName: typha-cpha
 ---
-apiVersion: rbac.authorization.k8s.io/v1beta1
+
+# Typha Horizontal Autoscaler Cluster Role
+apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRole
 metadata:
-  name: system:heapster-with-nanny
+  name: typha-cpha
   labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
+    addonmanager.kubernetes.io/mode: "Reconcile"
 rules:
-- apiGroups:
-  - extensions
-  - apps
-  resources:
-  - deployments
-  verbs:
-  - get
-  - list
-  - watch
-  - update
-  - patch
-- apiGroups:
-  - ""
-  resources:
-  - events
-  - namespaces
-  - nodes
-  - pods
-  verbs:
-  - get
-  - list
-  - watch
+- apiGroups: [""]
+  resources: ["nodes"]
+  verbs: ["list"]
+
 ---
-apiVersion: rbac.authorization.k8s.io/v1beta1
+
+# Typha Horizontal Autoscaler Cluster Role Binding
+apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRoleBinding
 metadata:
-  name: system:heapster-with-nanny
+  name: typha-cpha
   labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
+    addonmanager.kubernetes.io/mode: "Reconcile"
 roleRef:
   apiGroup: rbac.authorization.k8s.io
   kind: ClusterRole
-  name: system:heapster-with-nanny
+  name: typha-cpha
 subjects:
 - kind: ServiceAccount
-  name: heapster
+  name: typha-cpha
   namespace: kube-system
 ---
-apiVersion: v1
-kind: Service
+
+# Typha Horizontal Autoscaler Role
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
 metadata:
-  labels:
-    kubernetes.io/cluster-service: "true"
-    kubernetes.io/name: Heapster
-  name: heapster
+  name: typha-cpha
   namespace: kube-system
-spec:
-  ports:
-  - port: 80
-    targetPort: 8082
-  selector:
-    k8s-app: heapster
+  labels:
+    addonmanager.kubernetes.io/mode: "Reconcile"
+rules:
+- apiGroups: [""]
+  resources: ["configmaps"]
+  verbs: ["get"]
+- apiGroups: ["extensions"]
+  resources: ["deployments/scale"]
+  verbs: ["get", "update"]
+
 ---
-kind: Deployment
-apiVersion: extensions/v1beta1
+
+# Typha Horizontal Autoscaler Role Binding
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
 metadata:
-  labels:
-    k8s-app: heapster
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
+  name: typha-cpha
   namespace: kube-system
-  name: heapster
-spec:
-  replicas: 1
-  selector:
-    matchLabels:
-      k8s-app: heapster
-  template:
-    metadata:
-      labels:
-        k8s-app: heapster
-      annotations:
-        scheduler.alpha.kubernetes.io/critical-pod: ""
-    spec:
-      tolerations:
-      - key: CriticalAddonsOnly
-        operator: Exists
-      serviceAccountName: heapster
-      containers:
-      - image: {{ContainerImage "heapster"}}
-        imagePullPolicy: IfNotPresent
-        command:
-        - "/heapster"
-        - "--source=kubernetes.summary_api:\"\""
-        name: heapster
-        resources:
-          requests:
-            cpu: 80m
-            memory: 140Mi
-          limits:
-            cpu: 80m
-            memory: 140Mi
-      - image: {{ContainerImage "heapster-nanny"}}
-        imagePullPolicy: IfNotPresent
-        command:
-        - "/pod_nanny"
-        - "--cpu=80m"
-        - "--extra-cpu=0.5m"
-        - "--memory=140Mi"
-        - "--extra-memory=4Mi"
-        - "--threshold=5"
-        - "--deployment=heapster"
-        - "--container=heapster"
-        - "--poll-period=300000"
-        - "--estimator=exponential"
-        name: heapster-nanny
-        resources:
-          requests:
-            cpu: 50m
-            memory: 90Mi
-          limits:
-            cpu: 50m
-            memory: 90Mi
-        env:
-        - valueFrom:
-            fieldRef:
-              fieldPath: metadata.name
-          name: MY_POD_NAME
-        - valueFrom:
-            fieldRef:
-              fieldPath: metadata.namespace
-          name: MY_POD_NAMESPACE
-      nodeSelector:
-        beta.kubernetes.io/os: linux
+  labels:
+    addonmanager.kubernetes.io/mode: "Reconcile"
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: typha-cpha
+subjects:
+- kind: ServiceAccount
+  name: typha-cpha
+  namespace: kube-system
+---
+
+# Typha Horizontal Autoscaler Service Account
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: typha-cpha
+  namespace: kube-system
+  labels:
+    addonmanager.kubernetes.io/mode: "Reconcile"
 `)
 
-func k8sContaineraddons17KubernetesmasteraddonsHeapsterDeploymentYamlBytes() ([]byte, error) {
-	return _k8sContaineraddons17KubernetesmasteraddonsHeapsterDeploymentYaml, nil
+func k8sContaineraddonsKubernetesmasteraddonsCalicoDaemonsetYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsCalicoDaemonsetYaml, nil
 }
 
-func k8sContaineraddons17KubernetesmasteraddonsHeapsterDeploymentYaml() (*asset, error) {
-	bytes, err := k8sContaineraddons17KubernetesmasteraddonsHeapsterDeploymentYamlBytes()
+func k8sContaineraddonsKubernetesmasteraddonsCalicoDaemonsetYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsCalicoDaemonsetYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/containeraddons/1.7/kubernetesmasteraddons-heapster-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-calico-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sContaineraddons18KubernetesmasteraddonsHeapsterDeploymentYaml = []byte(`apiVersion: v1
+var _k8sContaineraddonsKubernetesmasteraddonsCiliumDaemonsetYaml = []byte(`apiVersion: v1
 kind: ServiceAccount
 metadata:
-  name: heapster
+  name: cilium
   namespace: kube-system
   labels:
-    kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
 ---
-apiVersion: rbac.authorization.k8s.io/v1beta1
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: cilium-operator
+  namespace: kube-system
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cilium-config
+  namespace: kube-system
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+data:
+  identity-allocation-mode: crd
+  debug: "false"
+  enable-ipv4: "true"
+  enable-ipv6: "false"
+  enable-bpf-clock-probe: "true"
+  monitor-aggregation: medium
+  monitor-aggregation-interval: 5s
+  monitor-aggregation-flags: all
+  bpf-map-dynamic-size-ratio: "0.0025"
+  bpf-policy-map-max: "16384"
+  preallocate-bpf-maps: "false"
+  sidecar-istio-proxy-image: cilium/istio_proxy
+  tunnel: vxlan
+  cluster-name: default
+  wait-bpf-mount: "false"
+  masquerade: "true"
+  enable-bpf-masquerade: "true"
+  enable-xt-socket-fallback: "true"
+  install-iptables-rules: "true"
+  auto-direct-node-routes: "false"
+  kube-proxy-replacement: {{ContainerConfig "kube-proxy-replacement"}}
+  node-port-bind-protection: "true"
+  enable-auto-protect-node-port-range: "true"
+  enable-session-affinity: "true"
+  enable-endpoint-health-checking: "true"
+  enable-well-known-identities: "false"
+  enable-remote-node-identity: "true"
+  operator-api-serve-addr: "127.0.0.1:9234"
+  ipam: kubernetes
+  disable-cnp-status-updates: "true"
+---
+apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRole
 metadata:
-  name: system:heapster-with-nanny
+  name: cilium
   labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
+    addonmanager.kubernetes.io/mode: Reconcile
 rules:
 - apiGroups:
-  - extensions
-  - apps
+  - networking.k8s.io
   resources:
-  - deployments
+  - networkpolicies
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - discovery.k8s.io
+  resources:
+  - endpointslices
   verbs:
   - get
   - list
   - watch
-  - update
-  - patch
 - apiGroups:
   - ""
   resources:
-  - events
   - namespaces
+  - services
   - nodes
+  - endpoints
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - ""
+  resources:
   - pods
+  - nodes
   verbs:
   - get
   - list
   - watch
+  - update
+- apiGroups:
+  - ""
+  resources:
+  - nodes
+  - nodes/status
+  verbs:
+  - patch
+- apiGroups:
+  - apiextensions.k8s.io
+  resources:
+  - customresourcedefinitions
+  verbs:
+  - create
+  - get
+  - list
+  - watch
+  - update
+- apiGroups:
+  - cilium.io
+  resources:
+  - ciliumnetworkpolicies
+  - ciliumnetworkpolicies/status
+  - ciliumclusterwidenetworkpolicies
+  - ciliumclusterwidenetworkpolicies/status
+  - ciliumendpoints
+  - ciliumendpoints/status
+  - ciliumnodes
+  - ciliumnodes/status
+  - ciliumidentities
+  - ciliumidentities/status
+  verbs:
+  - '*'
 ---
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: ClusterRoleBinding
-metadata:
-  name: system:heapster-with-nanny
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: system:heapster-with-nanny
-subjects:
-- kind: ServiceAccount
-  name: heapster
-  namespace: kube-system
----
-apiVersion: v1
-kind: Service
-metadata:
-  labels:
-    kubernetes.io/cluster-service: "true"
-    kubernetes.io/name: Heapster
-  name: heapster
-  namespace: kube-system
-spec:
-  ports:
-  - port: 80
-    targetPort: 8082
-  selector:
-    k8s-app: heapster
----
-kind: Deployment
-apiVersion: extensions/v1beta1
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
 metadata:
+  name: cilium-operator
   labels:
-    k8s-app: heapster
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
-  namespace: kube-system
-  name: heapster
-spec:
-  replicas: 1
-  selector:
-    matchLabels:
-      k8s-app: heapster
-  template:
-    metadata:
-      labels:
-        k8s-app: heapster
-      annotations:
-        scheduler.alpha.kubernetes.io/critical-pod: ""
-    spec:
-      tolerations:
-      - key: CriticalAddonsOnly
-        operator: Exists
-      serviceAccountName: heapster
-      containers:
-      - image: {{ContainerImage "heapster"}}
-        imagePullPolicy: IfNotPresent
-        command:
-        - "/heapster"
-        - "--source=kubernetes.summary_api:\"\""
-        name: heapster
-        resources:
-          requests:
-            cpu: 80m
-            memory: 140Mi
-          limits:
-            cpu: 80m
-            memory: 140Mi
-      - image: {{ContainerImage "heapster-nanny"}}
-        imagePullPolicy: IfNotPresent
-        command:
-        - "/pod_nanny"
-        - "--cpu=80m"
-        - "--extra-cpu=0.5m"
-        - "--memory=140Mi"
-        - "--extra-memory=4Mi"
-        - "--threshold=5"
-        - "--deployment=heapster"
-        - "--container=heapster"
-        - "--poll-period=300000"
-        - "--estimator=exponential"
-        name: heapster-nanny
-        resources:
-          requests:
-            cpu: 50m
-            memory: 90Mi
-          limits:
-            cpu: 50m
-            memory: 90Mi
-        env:
-        - valueFrom:
-            fieldRef:
-              fieldPath: metadata.name
-          name: MY_POD_NAME
-        - valueFrom:
-            fieldRef:
-              fieldPath: metadata.namespace
-          name: MY_POD_NAMESPACE
-      nodeSelector:
-        beta.kubernetes.io/os: linux
-`)
-
-func k8sContaineraddons18KubernetesmasteraddonsHeapsterDeploymentYamlBytes() ([]byte, error) {
-	return _k8sContaineraddons18KubernetesmasteraddonsHeapsterDeploymentYaml, nil
-}
-
-func k8sContaineraddons18KubernetesmasteraddonsHeapsterDeploymentYaml() (*asset, error) {
-	bytes, err := k8sContaineraddons18KubernetesmasteraddonsHeapsterDeploymentYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/containeraddons/1.8/kubernetesmasteraddons-heapster-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sContaineraddonsAzureCniNetworkmonitorYaml = []byte(`apiVersion: extensions/v1beta1
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - pods
+  verbs:
+  - get
+  - list
+  - watch
+  - delete
+- apiGroups:
+  - discovery.k8s.io
+  resources:
+  - endpointslices
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - ""
+  resources:
+  - services
+  - endpoints
+  - namespaces
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - cilium.io
+  resources:
+  - ciliumnetworkpolicies
+  - ciliumnetworkpolicies/status
+  - ciliumclusterwidenetworkpolicies
+  - ciliumclusterwidenetworkpolicies/status
+  - ciliumendpoints
+  - ciliumendpoints/status
+  - ciliumnodes
+  - ciliumnodes/status
+  - ciliumidentities
+  - ciliumidentities/status
+  verbs:
+  - '*'
+- apiGroups:
+  - apiextensions.k8s.io
+  resources:
+  - customresourcedefinitions
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - coordination.k8s.io
+  resources:
+  - leases
+  verbs:
+  - create
+  - get
+  - update
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: cilium
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cilium
+subjects:
+- kind: ServiceAccount
+  name: cilium
+  namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: cilium-operator
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cilium-operator
+subjects:
+- kind: ServiceAccount
+  name: cilium-operator
+  namespace: kube-system
+---
+apiVersion: apps/v1
 kind: DaemonSet
 metadata:
-  name: azure-cni-networkmonitor
+  name: cilium
   namespace: kube-system
   labels:
-    app: azure-cnms
+    k8s-app: cilium
+    kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
 spec:
   selector:
     matchLabels:
-      k8s-app: azure-cnms
+      k8s-app: cilium
+  updateStrategy:
+    rollingUpdate:
+      maxUnavailable: 2
+    type: RollingUpdate
   template:
     metadata:
       labels:
-        k8s-app: azure-cnms
-      annotations:
-        scheduler.alpha.kubernetes.io/critical-pod: ''
+        k8s-app: cilium
     spec:
       priorityClassName: system-node-critical
-      tolerations:
-      - key: CriticalAddonsOnly
-        operator: Exists
-      - key: node-role.kubernetes.io/master
-        operator: Equal
-        value: "true"
-        effect: NoSchedule
-      - operator: "Exists"
-        effect: NoExecute
-      - operator: "Exists"
-        effect: NoSchedule
+      serviceAccountName: cilium
+      hostNetwork: true
+      restartPolicy: Always
+      terminationGracePeriodSeconds: 1
       nodeSelector:
         beta.kubernetes.io/os: linux
+      tolerations:
+      - operator: Exists
+      initContainers:
+      - name: clean-cilium-state
+        image: {{ContainerImage "cilium-agent"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - /init-container.sh
+        env:
+        - name: CILIUM_ALL_STATE
+          valueFrom:
+            configMapKeyRef:
+              name: cilium-config
+              key: clean-cilium-state
+              optional: true
+        - name: CILIUM_BPF_STATE
+          valueFrom:
+            configMapKeyRef:
+              name: cilium-config
+              key: clean-cilium-bpf-state
+              optional: true
+        - name: CILIUM_WAIT_BPF_MOUNT
+          valueFrom:
+            configMapKeyRef:
+              name: cilium-config
+              key: wait-bpf-mount
+              optional: true
+        securityContext:
+          privileged: true
+          capabilities:
+            add:
+            - NET_ADMIN
+        volumeMounts:
+        - name: bpf-maps
+          mountPath: /sys/fs/bpf
+          mountPropagation: HostToContainer
+        - name: cilium-run
+          mountPath: /var/run/cilium
       containers:
-        - name: azure-cnms
-          image: {{ContainerImage "azure-cni-networkmonitor"}}
-          imagePullPolicy: IfNotPresent
-          securityContext:
-            privileged: true
-          env:
-            - name: HOSTNAME
-              valueFrom:
-                fieldRef:
-                  apiVersion: v1
-                  fieldPath: spec.nodeName
-          volumeMounts:
-          - name: ebtables-rule-repo
-            mountPath: /var/run
-          - name: log
-            mountPath: /var/log
-          - name: telemetry
-            mountPath: /opt/cni/bin
-      hostNetwork: true
+      - name: cilium-agent
+        image: {{ContainerImage "cilium-agent"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - cilium-agent
+        args:
+        - --config-dir=/tmp/cilium/config-map
+        env:
+        - name: K8S_NODE_NAME
+          valueFrom:
+            fieldRef:
+              apiVersion: v1
+              fieldPath: spec.nodeName
+        - name: CILIUM_K8S_NAMESPACE
+          valueFrom:
+            fieldRef:
+              apiVersion: v1
+              fieldPath: metadata.namespace
+        - name: CILIUM_FLANNEL_MASTER_DEVICE
+          valueFrom:
+            configMapKeyRef:
+              name: cilium-config
+              key: flannel-master-device
+              optional: true
+        - name: CILIUM_FLANNEL_UNINSTALL_ON_EXIT
+          valueFrom:
+            configMapKeyRef:
+              name: cilium-config
+              key: flannel-uninstall-on-exit
+              optional: true
+        - name: CILIUM_CLUSTERMESH_CONFIG
+          value: /var/lib/cilium/clustermesh/
+        - name: CILIUM_CNI_CHAINING_MODE
+          valueFrom:
+            configMapKeyRef:
+              name: cilium-config
+              key: cni-chaining-mode
+              optional: true
+        - name: CILIUM_CUSTOM_CNI_CONF
+          valueFrom:
+            configMapKeyRef:
+              name: cilium-config
+              key: custom-cni-conf
+              optional: true
+{{- if eq (ContainerConfig "kube-proxy-replacement") "strict"}}
+        # without kube-proxy, the kubernetes service of the API server is only reachable once cilium runs
+        - name: KUBERNETES_SERVICE_HOST
+          value: <kubernetesAPIServerIP>
+        - name: KUBERNETES_SERVICE_PORT
+          value: "443"
+{{- end}}
+        lifecycle:
+          postStart:
+            exec:
+              command:
+              - /cni-install.sh
+              - --enable-debug=false
+          preStop:
+            exec:
+              command:
+              - /cni-uninstall.sh
+        livenessProbe:
+          httpGet:
+            host: 127.0.0.1
+            path: /healthz
+            port: 9876
+            scheme: HTTP
+            httpHeaders:
+            - name: brief
+              value: "true"
+          failureThreshold: 10
+          initialDelaySeconds: 120
+          periodSeconds: 30
+          successThreshold: 1
+          timeoutSeconds: 5
+        readinessProbe:
+          httpGet:
+            host: 127.0.0.1
+            path: /healthz
+            port: 9876
+            scheme: HTTP
+            httpHeaders:
+            - name: brief
+              value: "true"
+          failureThreshold: 3
+          initialDelaySeconds: 5
+          periodSeconds: 30
+          successThreshold: 1
+          timeoutSeconds: 5
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "cilium-agent"}}
+            memory: {{ContainerMemReqs "cilium-agent"}}
+        securityContext:
+          privileged: true
+          capabilities:
+            add:
+            - NET_ADMIN
+            - SYS_MODULE
+        volumeMounts:
+        - name: bpf-maps
+          mountPath: /sys/fs/bpf
+        - name: cilium-run
+          mountPath: /var/run/cilium
+        - name: cni-path
+          mountPath: /host/opt/cni/bin
+        - name: etc-cni-netd
+          mountPath: /host/etc/cni/net.d
+        - name: clustermesh-secrets
+          mountPath: /var/lib/cilium/clustermesh
+          readOnly: true
+        - name: cilium-config-path
+          mountPath: /tmp/cilium/config-map
+          readOnly: true
+        - name: lib-modules
+          mountPath: /lib/modules
+          readOnly: true
+        - name: xtables-lock
+          mountPath: /run/xtables.lock
       volumes:
-      - name: log
+      - name: cilium-run
         hostPath:
-          path: /var/log
-          type: Directory
-      - name: ebtables-rule-repo
+          path: /var/run/cilium
+          type: DirectoryOrCreate
+      - name: bpf-maps
         hostPath:
-          path: /var/run/
-          type: Directory
-      - name: telemetry
+          path: /sys/fs/bpf
+          type: DirectoryOrCreate
+      - name: cni-path
         hostPath:
           path: /opt/cni/bin
-          type: Directory`)
-
-func k8sContaineraddonsAzureCniNetworkmonitorYamlBytes() ([]byte, error) {
-	return _k8sContaineraddonsAzureCniNetworkmonitorYaml, nil
-}
-
-func k8sContaineraddonsAzureCniNetworkmonitorYaml() (*asset, error) {
-	bytes, err := k8sContaineraddonsAzureCniNetworkmonitorYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/containeraddons/azure-cni-networkmonitor.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sContaineraddonsDnsAutoscalerYaml = []byte(`apiVersion: apps/v1
+          type: DirectoryOrCreate
+      - name: etc-cni-netd
+        hostPath:
+          path: /etc/cni/net.d
+          type: DirectoryOrCreate
+      - name: lib-modules
+        hostPath:
+          path: /lib/modules
+      - name: xtables-lock
+        hostPath:
+          path: /run/xtables.lock
+          type: FileOrCreate
+      - name: clustermesh-secrets
+        secret:
+          defaultMode: 420
+          optional: true
+          secretName: cilium-clustermesh
+      - name: cilium-config-path
+        configMap:
+          name: cilium-config
+---
+apiVersion: apps/v1
 kind: Deployment
 metadata:
-  name: dns-autoscaler
+  name: cilium-operator
   namespace: kube-system
   labels:
-    k8s-app: dns-autoscaler
-    kubernetes.io/cluster-service: "true"
+    io.cilium/app: operator
+    name: cilium-operator
     addonmanager.kubernetes.io/mode: Reconcile
 spec:
+  replicas: 1
   selector:
     matchLabels:
-       k8s-app: dns-autoscaler
-  template:
-    metadata:
-      labels:
-        k8s-app: dns-autoscaler
-    spec:
-      containers:
-      - name: autoscaler
-        image: {{ContainerImage "dns-autoscaler"}}
-        resources:
-          requests:
-            cpu: {{ContainerCPUReqs "dns-autoscaler"}}
-            memory: {{ContainerMemReqs "dns-autoscaler"}}
-        command:
-          - /cluster-proportional-autoscaler
-          - --namespace=kube-system
-          - --configmap=dns-autoscaler
-          - --target=Deployment/coredns
-          # When cluster is using large nodes(with more cores), "coresPerReplica" should dominate.
-          # If using small nodes, "nodesPerReplica" should dominate.
-          - --default-params={"linear":{"coresPerReplica":256,"nodesPerReplica":16,"min":1}}
-          - --logtostderr=true
-          - --v=2
-`)
-
-func k8sContaineraddonsDnsAutoscalerYamlBytes() ([]byte, error) {
-	return _k8sContaineraddonsDnsAutoscalerYaml, nil
-}
-
-func k8sContaineraddonsDnsAutoscalerYaml() (*asset, error) {
-	bytes, err := k8sContaineraddonsDnsAutoscalerYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/containeraddons/dns-autoscaler.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sContaineraddonsIpMasqAgentYaml = []byte(`apiVersion: extensions/v1beta1
-kind: DaemonSet
-metadata:
-  name: azure-ip-masq-agent
-  namespace: kube-system
-  labels:
-    component: azure-ip-masq-agent
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-    tier: node
-spec:
+      io.cilium/app: operator
+      name: cilium-operator
+  strategy:
+    rollingUpdate:
+      maxSurge: 1
+      maxUnavailable: 1
+    type: RollingUpdate
   template:
     metadata:
       labels:
-        k8s-app: azure-ip-masq-agent
-        tier: node
+        io.cilium/app: operator
+        name: cilium-operator
     spec:
-      priorityClassName: system-node-critical
+      priorityClassName: system-cluster-critical
+      serviceAccountName: cilium-operator
+      # the operator creates the CRDs and allocates the identities the agents wait for, before the pod network is up
       hostNetwork: true
+      restartPolicy: Always
       nodeSelector:
         beta.kubernetes.io/os: linux
       tolerations:
-      - key: CriticalAddonsOnly
-        operator: Exists
-      - key: node-role.kubernetes.io/master
-        operator: Equal
-        value: "true"
-        effect: NoSchedule
-      - operator: "Exists"
-        effect: NoExecute
-      - operator: "Exists"
-        effect: NoSchedule
+      - operator: Exists
       containers:
-      - name: azure-ip-masq-agent
-        image: {{ContainerImage "ip-masq-agent"}}
+      - name: cilium-operator
+        image: {{ContainerImage "cilium-operator"}}
         imagePullPolicy: IfNotPresent
-        securityContext:
-          privileged: true
-        volumeMounts:
-          - name: azure-ip-masq-agent-config-volume
-            mountPath: /etc/config
+        command:
+        - cilium-operator-generic
+        args:
+        - --config-dir=/tmp/cilium/config-map
+        - --debug=$(CILIUM_DEBUG)
+        env:
+        - name: K8S_NODE_NAME
+          valueFrom:
+            fieldRef:
+              apiVersion: v1
+              fieldPath: spec.nodeName
+        - name: CILIUM_K8S_NAMESPACE
+          valueFrom:
+            fieldRef:
+              apiVersion: v1
+              fieldPath: metadata.namespace
+        - name: CILIUM_DEBUG
+          valueFrom:
+            configMapKeyRef:
+              name: cilium-config
+              key: debug
+              optional: true
+{{- if eq (ContainerConfig "kube-proxy-replacement") "strict"}}
+        - name: KUBERNETES_SERVICE_HOST
+          value: <kubernetesAPIServerIP>
+        - name: KUBERNETES_SERVICE_PORT
+          value: "443"
+{{- end}}
+        livenessProbe:
+          httpGet:
+            host: 127.0.0.1
+            path: /healthz
+            port: 9234
+            scheme: HTTP
+          initialDelaySeconds: 60
+          periodSeconds: 10
+          timeoutSeconds: 3
         resources:
           requests:
-            cpu: {{ContainerCPUReqs "ip-masq-agent"}}
-            memory: {{ContainerMemReqs "ip-masq-agent"}}
-          limits:
-            cpu: {{ContainerCPULimits "ip-masq-agent"}}
-            memory: {{ContainerMemLimits "ip-masq-agent"}}
+            cpu: {{ContainerCPUReqs "cilium-operator"}}
+            memory: {{ContainerMemReqs "cilium-operator"}}
+        volumeMounts:
+        - name: cilium-config-path
+          mountPath: /tmp/cilium/config-map
+          readOnly: true
       volumes:
-        - name: azure-ip-masq-agent-config-volume
-          configMap:
-            name: azure-ip-masq-agent-config
----
-apiVersion: v1
-kind: ConfigMap
-metadata:
-  name: azure-ip-masq-agent-config
-  namespace: kube-system
-  labels:
-    component: azure-ip-masq-agent
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
-data:
-  ip-masq-agent: |-
-    nonMasqueradeCIDRs:
-      - {{ContainerConfig "non-masquerade-cidr"}}
-    {{- if ContainerConfig "non-masq-cni-cidr"}}
-      - {{ContainerConfig "non-masq-cni-cidr"}}
-    masqLinkLocal: true
-    {{else}}
-    masqLinkLocal: false
-    {{end -}}
-    resyncInterval: 60s`)
+      - name: cilium-config-path
+        configMap:
+          name: cilium-config
+`)
 
-func k8sContaineraddonsIpMasqAgentYamlBytes() ([]byte, error) {
-	return _k8sContaineraddonsIpMasqAgentYaml, nil
+func k8sContaineraddonsKubernetesmasteraddonsCiliumDaemonsetYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsCiliumDaemonsetYaml, nil
 }
 
-func k8sContaineraddonsIpMasqAgentYaml() (*asset, error) {
-	bytes, err := k8sContaineraddonsIpMasqAgentYamlBytes()
+func k8sContaineraddonsKubernetesmasteraddonsCiliumDaemonsetYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsCiliumDaemonsetYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/containeraddons/ip-masq-agent.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-cilium-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sContaineraddonsKubernetesmasteraddonsAadPodIdentityDeploymentYaml = []byte(`apiVersion: v1
+var _k8sContaineraddonsKubernetesmasteraddonsClusterAutoscalerDeploymentYaml = []byte(`apiVersion: v1
 kind: ServiceAccount
 metadata:
-  name: aad-pod-id-nmi-service-account
-  namespace: default
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
----
-apiVersion: apiextensions.k8s.io/v1beta1
-kind: CustomResourceDefinition
-metadata:
-  name: azureassignedidentities.aadpodidentity.k8s.io
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-spec:
-  group: aadpodidentity.k8s.io
-  version: v1
-  names:
-    kind: AzureAssignedIdentity
-    plural: azureassignedidentities
-  scope: Namespaced
----
-apiVersion: apiextensions.k8s.io/v1beta1
-kind: CustomResourceDefinition
-metadata:
-  name: azureidentitybindings.aadpodidentity.k8s.io
   labels:
+    k8s-addon: cluster-autoscaler.addons.k8s.io
+    k8s-app: cluster-autoscaler
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
-spec:
-  group: aadpodidentity.k8s.io
-  version: v1
-  names:
-    kind: AzureIdentityBinding
-    plural: azureidentitybindings
-  scope: Namespaced
+  name: cluster-autoscaler
+  namespace: kube-system
 ---
-apiVersion: apiextensions.k8s.io/v1beta1
-kind: CustomResourceDefinition
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRole
 metadata:
-  name: azureidentities.aadpodidentity.k8s.io
+  name: cluster-autoscaler
   labels:
+    k8s-addon: cluster-autoscaler.addons.k8s.io
+    k8s-app: cluster-autoscaler
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
-spec:
-  group: aadpodidentity.k8s.io
-  version: v1
-  names:
-    kind: AzureIdentity
-    singular: azureidentity
-    plural: azureidentities
-  scope: Namespaced
+rules:
+- apiGroups: [""]
+  resources: ["events","endpoints"]
+  verbs: ["create", "patch"]
+- apiGroups: [""]
+  resources: ["pods/eviction"]
+  verbs: ["create"]
+- apiGroups: [""]
+  resources: ["pods/status"]
+  verbs: ["update"]
+- apiGroups: [""]
+  resources: ["endpoints"]
+  resourceNames: ["cluster-autoscaler"]
+  verbs: ["get","update"]
+- apiGroups: [""]
+  resources: ["nodes"]
+  verbs: ["watch","list","get","update"]
+- apiGroups: [""]
+  resources: ["pods","services","replicationcontrollers","persistentvolumeclaims","persistentvolumes"]
+  verbs: ["watch","list","get"]
+- apiGroups: ["extensions"]
+  resources: ["replicasets","daemonsets"]
+  verbs: ["watch","list","get"]
+- apiGroups: ["policy"]
+  resources: ["poddisruptionbudgets"]
+  verbs: ["watch","list"]
+- apiGroups: ["apps"]
+  resources: ["statefulsets","replicasets","daemonsets"]
+  verbs: ["watch","list","get"]
+- apiGroups: ["batch"]
+  resources: ["jobs"]
+  verbs: ["watch","list"]
+- apiGroups: ["storage.k8s.io"]
+  resources: ["storageclasses","csinodes"]
+  verbs: ["get", "list", "watch"]
 ---
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: Role
 metadata:
-  name: aad-pod-id-nmi-role
+  name: cluster-autoscaler
+  namespace: kube-system
   labels:
+    k8s-addon: cluster-autoscaler.addons.k8s.io
+    k8s-app: cluster-autoscaler
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
-rules:
-- apiGroups: ["*"]
-  resources: ["*"]
-  verbs: ["get", "list"]
+rules:
+- apiGroups: [""]
+  resources: ["configmaps"]
+  verbs: ["create","list","watch"]
+- apiGroups: [""]
+  resources: ["configmaps"]
+  resourceNames: ["cluster-autoscaler-status", "cluster-autoscaler-priority-expander"]
+  verbs: ["delete","get","update","watch"]
 ---
 apiVersion: rbac.authorization.k8s.io/v1beta1
 kind: ClusterRoleBinding
 metadata:
-  name: aad-pod-id-nmi-binding
+  name: cluster-autoscaler
   labels:
+    k8s-addon: cluster-autoscaler.addons.k8s.io
+    k8s-app: cluster-autoscaler
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
-    k8s-app: aad-pod-id-nmi-binding
-subjects:
-- kind: ServiceAccount
-  name: aad-pod-id-nmi-service-account
-  namespace: default
 roleRef:
-  kind: ClusterRole
-  name: aad-pod-id-nmi-role
   apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cluster-autoscaler
+subjects:
+  - kind: ServiceAccount
+    name: cluster-autoscaler
+    namespace: kube-system
 ---
-apiVersion: extensions/v1beta1
-kind: DaemonSet
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: RoleBinding
 metadata:
+  name: cluster-autoscaler
+  namespace: kube-system
   labels:
+    k8s-addon: cluster-autoscaler.addons.k8s.io
+    k8s-app: cluster-autoscaler
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
-    component: nmi
-    tier: node
-    k8s-app: aad-pod-id
-  name: nmi
-  namespace: default
-spec:
-  template:
-    metadata:
-      labels:
-        component: nmi
-        tier: node
-    spec:
-      serviceAccountName: aad-pod-id-nmi-service-account
-      hostNetwork: true
-      containers:
-      - name: nmi
-        image: {{ContainerImage "nmi"}}
-        imagePullPolicy: IfNotPresent
-        resources:
-          requests:
-            cpu: {{ContainerCPUReqs "nmi"}}
-            memory: {{ContainerMemReqs "nmi"}}
-          limits:
-            cpu: {{ContainerCPULimits "nmi"}}
-            memory: {{ContainerMemLimits "nmi"}}
-        args:
-          - "--host-ip=$(HOST_IP)"
-          - "--node=$(NODE_NAME)"
-        env:
-          - name: HOST_IP
-            valueFrom:
-              fieldRef:
-                fieldPath: status.podIP
-          - name: NODE_NAME
-            valueFrom:
-              fieldRef:
-                fieldPath: spec.nodeName
-        securityContext:
-          privileged: true
-          capabilities:
-            add:
-            - NET_ADMIN
-      nodeSelector:
-        beta.kubernetes.io/os: linux
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: cluster-autoscaler
+subjects:
+  - kind: ServiceAccount
+    name: cluster-autoscaler
+    namespace: kube-system
+{{- if eq (ContainerConfig "expander") "priority"}}
 ---
 apiVersion: v1
-kind: ServiceAccount
-metadata:
-  name: aad-pod-id-mic-service-account
-  namespace: default
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
+kind: ConfigMap
 metadata:
-  name: aad-pod-id-mic-role
+  name: cluster-autoscaler-priority-expander
+  namespace: kube-system
   labels:
+    k8s-addon: cluster-autoscaler.addons.k8s.io
+    k8s-app: cluster-autoscaler
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
-rules:
-- apiGroups: ["apiextensions.k8s.io"]
-  resources: ["customresourcedefinitions"]
-  verbs: ["*"]
-- apiGroups: [""]
-  resources: ["pods"]
-  verbs: [ "list", "watch" ]
-- apiGroups: [""]
-  resources: ["events"]
-  verbs: ["create", "patch"]
-- apiGroups: ["aadpodidentity.k8s.io"]
-  resources: ["azureidentitybindings", "azureidentities"]
-  verbs: ["get", "list", "watch", "post"]
-- apiGroups: ["aadpodidentity.k8s.io"]
-  resources: ["azureassignedidentities"]
-  verbs: ["*"]
+data:
+  priorities: |-
+{{- range $priority, $scaleSets := ClusterAutoscalerPriorities}}
+    {{$priority}}:
+{{- range $scaleSets}}
+    - ^{{.}}$
+{{- end}}
+{{- end}}
+{{- end}}
 ---
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: ClusterRoleBinding
+apiVersion: v1
+data:
+  ClientID: <clientID>
+  ClientSecret: <clientSec>
+  ResourceGroup: <rg>
+  SubscriptionID: <subID>
+  TenantID: <tenantID>
+  VMType: <vmType>
+kind: Secret
 metadata:
-  name: aad-pod-id-mic-binding
+  name: cluster-autoscaler-azure
+  namespace: kube-system
   labels:
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
-    k8s-app: aad-pod-id-mic-binding
-subjects:
-- kind: ServiceAccount
-  name: aad-pod-id-mic-service-account
-  namespace: default
-roleRef:
-  kind: ClusterRole
-  name: aad-pod-id-mic-role
-  apiGroup: rbac.authorization.k8s.io
 ---
 apiVersion: extensions/v1beta1
 kind: Deployment
 metadata:
   labels:
-    component: mic
-    k8s-app: aad-pod-id
+    app: cluster-autoscaler
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
-  name: mic
-  namespace: default
+  name: cluster-autoscaler
+  namespace: kube-system
 spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: cluster-autoscaler
   template:
     metadata:
       labels:
-        component: mic
+        app: cluster-autoscaler
     spec:
-      serviceAccountName: aad-pod-id-mic-service-account
+      priorityClassName: system-node-critical
+      <hostNet>
+      serviceAccountName: cluster-autoscaler
+      tolerations:
+      - effect: NoSchedule
+        operator: "Equal"
+        value: "true"
+        key: node-role.kubernetes.io/master
+      nodeSelector:
+        kubernetes.io/role: master
+        beta.kubernetes.io/os: linux
       containers:
-      - name: mic
-        image: {{ContainerImage "mic"}}
+      - image: {{ContainerImage "cluster-autoscaler"}}
         imagePullPolicy: IfNotPresent
+        name: cluster-autoscaler
         resources:
-          requests:
-            cpu: {{ContainerCPUReqs "mic"}}
-            memory: {{ContainerMemReqs "mic"}}
           limits:
-            cpu: {{ContainerCPULimits "mic"}}
-            memory: {{ContainerMemLimits "mic"}}
-        args:
-          - --cloudconfig=/etc/kubernetes/azure.json
-          - --logtostderr
+            cpu: {{ContainerCPULimits "cluster-autoscaler"}}
+            memory: {{ContainerMemLimits "cluster-autoscaler"}}
+          requests:
+            cpu: {{ContainerCPUReqs "cluster-autoscaler"}}
+            memory: {{ContainerMemReqs "cluster-autoscaler"}}
+        command:
+        - ./cluster-autoscaler
+        - --v=3
+        - --logtostderr=true
+        - --cloud-provider=azure
+        - --skip-nodes-with-local-storage=false
+        - --scan-interval={{ContainerConfig "scan-interval"}}
+        - --scale-down-unneeded-time={{ContainerConfig "scale-down-unneeded-time"}}
+        - --scale-down-delay-after-add={{ContainerConfig "scale-down-delay-after-add"}}
+        - --scale-down-utilization-threshold={{ContainerConfig "scale-down-utilization-threshold"}}
+        - --expander={{ContainerConfig "expander"}}
+{{- if eq (ContainerConfig "auto-discovery") "true"}}
+        - --node-group-auto-discovery=label:cluster-autoscaler-enabled=true,cluster-autoscaler-name={{ClusterAutoscalerClusterName}}
+{{- else}}
+{{- range ClusterAutoscalerNodeGroups}}
+        - --nodes={{.}}
+{{- end}}
+{{- end}}
+        env:
+        - name: ARM_CLOUD
+          value: "<cloud>"
+        - name: ARM_SUBSCRIPTION_ID
+          valueFrom:
+            secretKeyRef:
+              key: SubscriptionID
+              name: cluster-autoscaler-azure
+        - name: ARM_RESOURCE_GROUP
+          valueFrom:
+            secretKeyRef:
+              key: ResourceGroup
+              name: cluster-autoscaler-azure
+        - name: ARM_TENANT_ID
+          valueFrom:
+            secretKeyRef:
+              key: TenantID
+              name: cluster-autoscaler-azure
+        - name: ARM_CLIENT_ID
+          valueFrom:
+            secretKeyRef:
+              key: ClientID
+              name: cluster-autoscaler-azure
+        - name: ARM_CLIENT_SECRET
+          valueFrom:
+            secretKeyRef:
+              key: ClientSecret
+              name: cluster-autoscaler-azure
+        - name: ARM_VM_TYPE
+          valueFrom:
+            secretKeyRef:
+              key: VMType
+              name: cluster-autoscaler-azure
+        - name: ARM_USE_MANAGED_IDENTITY_EXTENSION
+          value: "<useManagedIdentity>"
+        - name: ARM_USER_ASSIGNED_IDENTITY_ID
+          value: "<userAssignedIdentityID>"
         volumeMounts:
-          - name: k8s-azure-file
-            mountPath: /etc/kubernetes/azure.json
-            readOnly: true
+        - mountPath: /etc/ssl/certs/ca-certificates.crt
+          name: ssl-certs
+          readOnly: true
+        <volMounts>
+      restartPolicy: Always
       volumes:
-      - name: k8s-azure-file
-        hostPath:
-          path: /etc/kubernetes/azure.json
+      - hostPath:
+          path: /etc/ssl/certs/ca-certificates.crt
+          type: ""
+        name: ssl-certs
+      <vols>
+#EOF
 `)
 
-func k8sContaineraddonsKubernetesmasteraddonsAadPodIdentityDeploymentYamlBytes() ([]byte, error) {
-	return _k8sContaineraddonsKubernetesmasteraddonsAadPodIdentityDeploymentYaml, nil
+func k8sContaineraddonsKubernetesmasteraddonsClusterAutoscalerDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsClusterAutoscalerDeploymentYaml, nil
 }
 
-func k8sContaineraddonsKubernetesmasteraddonsAadPodIdentityDeploymentYaml() (*asset, error) {
-	bytes, err := k8sContaineraddonsKubernetesmasteraddonsAadPodIdentityDeploymentYamlBytes()
+func k8sContaineraddonsKubernetesmasteraddonsClusterAutoscalerDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsClusterAutoscalerDeploymentYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-aad-pod-identity-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-cluster-autoscaler-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sContaineraddonsKubernetesmasteraddonsAciConnectorDeploymentYaml = []byte(`apiVersion: v1
+var _k8sContaineraddonsKubernetesmasteraddonsCsiSecretsStoreDaemonsetYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: secrets-store-csi-driver
+  namespace: kube-system
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: v1
 kind: ServiceAccount
 metadata:
-  name: aci-connector
+  name: csi-secrets-store-provider-azure
   namespace: kube-system
   labels:
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
 ---
-apiVersion: rbac.authorization.k8s.io/v1beta1
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: secretproviderclasses.secrets-store.csi.x-k8s.io
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: secrets-store.csi.x-k8s.io
+  names:
+    kind: SecretProviderClass
+    listKind: SecretProviderClassList
+    plural: secretproviderclasses
+    singular: secretproviderclass
+  scope: Namespaced
+  version: v1alpha1
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        apiVersion:
+          type: string
+        kind:
+          type: string
+        metadata:
+          type: object
+        spec:
+          type: object
+          properties:
+            provider:
+              type: string
+            parameters:
+              type: object
+              additionalProperties:
+                type: string
+            secretObjects:
+              type: array
+              items:
+                type: object
+                properties:
+                  secretName:
+                    type: string
+                  type:
+                    type: string
+                  labels:
+                    type: object
+                    additionalProperties:
+                      type: string
+                  data:
+                    type: array
+                    items:
+                      type: object
+                      properties:
+                        key:
+                          type: string
+                        objectName:
+                          type: string
+        status:
+          type: object
+          properties:
+            byPod:
+              type: array
+              items:
+                type: object
+                properties:
+                  id:
+                    type: string
+                  namespace:
+                    type: string
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: secretproviderclasspodstatuses.secrets-store.csi.x-k8s.io
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: secrets-store.csi.x-k8s.io
+  names:
+    kind: SecretProviderClassPodStatus
+    listKind: SecretProviderClassPodStatusList
+    plural: secretproviderclasspodstatuses
+    singular: secretproviderclasspodstatus
+  scope: Namespaced
+  version: v1alpha1
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        apiVersion:
+          type: string
+        kind:
+          type: string
+        metadata:
+          type: object
+        status:
+          type: object
+          properties:
+            mounted:
+              type: boolean
+            objects:
+              type: array
+              items:
+                type: object
+                properties:
+                  id:
+                    type: string
+                  version:
+                    type: string
+            podName:
+              type: string
+            secretProviderClassName:
+              type: string
+            targetPath:
+              type: string
+---
+apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRole
 metadata:
-  name: aci-connector
+  name: secretproviderclasses-role
   labels:
-    app: aci-connector
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
 rules:
-- apiGroups:
-  - ""
-  resources:
-  - configmaps
-  - pods
-  - services
-  - endpoints
-  - events
-  - secrets
-  - nodes
-  - nodes/status
-  - pods/status
-  verbs:
-  - "*"
+- apiGroups: [""]
+  resources: ["events"]
+  verbs: ["create", "patch"]
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: ["secrets-store.csi.x-k8s.io"]
+  resources: ["secretproviderclasses"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: ["secrets-store.csi.x-k8s.io"]
+  resources: ["secretproviderclasspodstatuses"]
+  verbs: ["create", "delete", "get", "list", "patch", "update", "watch"]
+- apiGroups: ["secrets-store.csi.x-k8s.io"]
+  resources: ["secretproviderclasspodstatuses/status"]
+  verbs: ["get", "patch", "update"]
+{{- if eq (ContainerConfig "enableSecretRotation") "true"}}
+- apiGroups: [""]
+  resources: ["secrets"]
+  verbs: ["get", "list", "watch"]
+{{- end}}
 ---
-apiVersion: rbac.authorization.k8s.io/v1beta1
+apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRoleBinding
 metadata:
-  name: aci-connector
+  name: secretproviderclasses-rolebinding
   labels:
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
 roleRef:
   apiGroup: rbac.authorization.k8s.io
   kind: ClusterRole
-  name: aci-connector
+  name: secretproviderclasses-role
 subjects:
 - kind: ServiceAccount
-  name: aci-connector
+  name: secrets-store-csi-driver
   namespace: kube-system
 ---
-apiVersion: v1
-kind: Secret
+apiVersion: storage.k8s.io/v1beta1
+kind: CSIDriver
 metadata:
-  name: aci-connector-secret
-  namespace: kube-system
+  name: secrets-store.csi.k8s.io
   labels:
-    kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
-type: Opaque
-data:
-  credentials.json: <creds>
-  cert.pem: <cert>
-  key.pem: <key>
+spec:
+  attachRequired: false
+  podInfoOnMount: true
+  volumeLifecycleModes:
+  - Ephemeral
 ---
-apiVersion: extensions/v1beta1
-kind: Deployment
+apiVersion: apps/v1
+kind: DaemonSet
 metadata:
-  name: aci-connector
+  name: csi-secrets-store
   namespace: kube-system
   labels:
-    app: aci-connector
-    name: aci-connector
+    app: csi-secrets-store
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
 spec:
-  replicas: 1
+  selector:
+    matchLabels:
+      app: csi-secrets-store
+  updateStrategy:
+    type: RollingUpdate
   template:
     metadata:
       labels:
-        app: aci-connector
+        app: csi-secrets-store
     spec:
-      serviceAccountName: aci-connector
+      priorityClassName: system-node-critical
+      serviceAccountName: secrets-store-csi-driver
+      hostNetwork: true
       nodeSelector:
         beta.kubernetes.io/os: linux
+      tolerations:
+      - operator: Exists
       containers:
-      - name: aci-connector
-        image: {{ContainerImage "aci-connector"}}
-        imagePullPolicy: Always
+      - name: node-driver-registrar
+        image: {{ContainerImage "node-driver-registrar"}}
+        imagePullPolicy: IfNotPresent
+        args:
+        - --v=2
+        - --csi-address=/csi/csi.sock
+        - --kubelet-registration-path=/var/lib/kubelet/plugins/csi-secrets-store/csi.sock
+        lifecycle:
+          preStop:
+            exec:
+              command: ["/bin/sh", "-c", "rm -rf /registration/secrets-store.csi.k8s.io-reg.sock"]
         env:
-        - name: KUBELET_PORT
-          value: "10250"
-        - name: AZURE_AUTH_LOCATION
-          value: /etc/virtual-kubelet/credentials.json
-        - name: ACI_RESOURCE_GROUP
-          value: <rgName>
-        - name: ACI_REGION
-          value: <region>
-        - name: APISERVER_CERT_LOCATION
-          value: /etc/virtual-kubelet/cert.pem
-        - name: APISERVER_KEY_LOCATION
-          value: /etc/virtual-kubelet/key.pem
-        - name: VKUBELET_POD_IP
+        - name: KUBE_NODE_NAME
           valueFrom:
             fieldRef:
-              fieldPath: status.podIP
+              apiVersion: v1
+              fieldPath: spec.nodeName
         resources:
           requests:
-            cpu: {{ContainerCPUReqs "aci-connector"}}
-            memory: {{ContainerMemReqs "aci-connector"}}
+            cpu: {{ContainerCPUReqs "node-driver-registrar"}}
+            memory: {{ContainerMemReqs "node-driver-registrar"}}
           limits:
-            cpu: {{ContainerCPULimits "aci-connector"}}
-            memory: {{ContainerMemLimits "aci-connector"}}
+            cpu: {{ContainerCPULimits "node-driver-registrar"}}
+            memory: {{ContainerMemLimits "node-driver-registrar"}}
         volumeMounts:
-        - name: credentials
-          mountPath: "/etc/virtual-kubelet"
-          readOnly: true
-        command: ["virtual-kubelet"]
-        args: ["--provider", "azure", "--nodename", "{{ContainerConfig "nodeName"}}" , "--os", "{{ContainerConfig "os"}}", "--taint", "{{ContainerConfig "taint"}}"]
-      volumes:
-      - name: credentials
-        secret:
-          secretName: aci-connector-secret
-#EOF
-`)
-
-func k8sContaineraddonsKubernetesmasteraddonsAciConnectorDeploymentYamlBytes() ([]byte, error) {
-	return _k8sContaineraddonsKubernetesmasteraddonsAciConnectorDeploymentYaml, nil
-}
-
-func k8sContaineraddonsKubernetesmasteraddonsAciConnectorDeploymentYaml() (*asset, error) {
-	bytes, err := k8sContaineraddonsKubernetesmasteraddonsAciConnectorDeploymentYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-aci-connector-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sContaineraddonsKubernetesmasteraddonsAzureNpmDaemonsetYaml = []byte(`apiVersion: v1
-kind: ServiceAccount
-metadata:
-  name: azure-npm
-  namespace: kube-system
-  labels:
-    addonmanager.kubernetes.io/mode: EnsureExists
----
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: ClusterRole
-metadata:
-  name: azure-npm
-  namespace: kube-system
-  labels:
-    addonmanager.kubernetes.io/mode: EnsureExists
-rules:
-  - apiGroups:
-    - ""
-    resources:
-      - pods
-      - nodes
-      - namespaces
-    verbs:
-      - get
-      - list
-      - watch
-  - apiGroups:
-    - networking.k8s.io
-    resources:
-      - networkpolicies
-    verbs:
-      - get
-      - list
-      - watch
----
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: ClusterRoleBinding
-metadata:
-  name: azure-npm-binding
-  namespace: kube-system
-  labels:
-    addonmanager.kubernetes.io/mode: EnsureExists
-subjects:
-  - kind: ServiceAccount
-    name: azure-npm
-    namespace: kube-system
-roleRef:
-  kind: ClusterRole
-  name: azure-npm
-  apiGroup: rbac.authorization.k8s.io
----
-apiVersion: extensions/v1beta1
-kind: DaemonSet
-metadata:
-  name: azure-npm
-  namespace: kube-system
-  labels:
-    app: azure-npm
-    addonmanager.kubernetes.io/mode: EnsureExists
-spec:
-  selector:
-    matchLabels:
-      k8s-app: azure-npm
-  template:
-    metadata:
-      labels:
-        k8s-app: azure-npm
-      annotations:
-        scheduler.alpha.kubernetes.io/critical-pod: ''
-    spec:
-      priorityClassName: system-node-critical
-      tolerations:
-      - key: CriticalAddonsOnly
-        operator: Exists
-      nodeSelector:
-        beta.kubernetes.io/os: linux
-      containers:
-        - name: azure-npm
-          image: {{ContainerImage "azure-npm-daemonset"}}
-          securityContext:
-            privileged: true
-          env:
-            - name: HOSTNAME
-              valueFrom:
-                fieldRef:
-                  apiVersion: v1
-                  fieldPath: spec.nodeName
-          volumeMounts:
-          - name: xtables-lock
-            mountPath: /run/xtables.lock
-          - name: log
-            mountPath: /var/log
-          - name: socket-dir
-            mountPath: /var/run
-          - name: tmp
-            mountPath: /tmp
-        - name: azure-vnet-telemetry
-          image: {{ContainerImage "azure-vnet-telemetry-daemonset"}}
-          volumeMounts:
-          - name: socket-dir
-            mountPath: /var/run
-          - name: tmp
-            mountPath: /tmp
-      hostNetwork: true
+        - name: plugin-dir
+          mountPath: /csi
+        - name: registration-dir
+          mountPath: /registration
+      - name: secrets-store
+        image: {{ContainerImage "secrets-store"}}
+        imagePullPolicy: IfNotPresent
+        args:
+        - --endpoint=$(CSI_ENDPOINT)
+        - --nodeid=$(KUBE_NODE_NAME)
+        - --provider-volume=/etc/kubernetes/secrets-store-csi-providers
+        - --enable-secret-rotation={{ContainerConfig "enableSecretRotation"}}
+        - --rotation-poll-interval={{ContainerConfig "rotationPollInterval"}}
+        env:
+        - name: CSI_ENDPOINT
+          value: unix:///csi/csi.sock
+        - name: KUBE_NODE_NAME
+          valueFrom:
+            fieldRef:
+              apiVersion: v1
+              fieldPath: spec.nodeName
+        securityContext:
+          privileged: true
+        ports:
+        - containerPort: 9808
+          name: healthz
+          protocol: TCP
+        livenessProbe:
+          failureThreshold: 5
+          httpGet:
+            path: /healthz
+            port: healthz
+          initialDelaySeconds: 30
+          timeoutSeconds: 10
+          periodSeconds: 15
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "secrets-store"}}
+            memory: {{ContainerMemReqs "secrets-store"}}
+          limits:
+            cpu: {{ContainerCPULimits "secrets-store"}}
+            memory: {{ContainerMemLimits "secrets-store"}}
+        volumeMounts:
+        - name: plugin-dir
+          mountPath: /csi
+        - name: mountpoint-dir
+          mountPath: /var/lib/kubelet/pods
+          mountPropagation: Bidirectional
+        - name: providers-dir
+          mountPath: /etc/kubernetes/secrets-store-csi-providers
+      - name: liveness-probe
+        image: {{ContainerImage "liveness-probe"}}
+        imagePullPolicy: IfNotPresent
+        args:
+        - --csi-address=/csi/csi.sock
+        - --probe-timeout=3s
+        - --health-port=9808
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "liveness-probe"}}
+            memory: {{ContainerMemReqs "liveness-probe"}}
+          limits:
+            cpu: {{ContainerCPULimits "liveness-probe"}}
+            memory: {{ContainerMemLimits "liveness-probe"}}
+        volumeMounts:
+        - name: plugin-dir
+          mountPath: /csi
       volumes:
-      - name: log
+      - name: mountpoint-dir
         hostPath:
-          path: /var/log
+          path: /var/lib/kubelet/pods
+          type: DirectoryOrCreate
+      - name: registration-dir
+        hostPath:
+          path: /var/lib/kubelet/plugins_registry/
           type: Directory
-      - name: xtables-lock
+      - name: plugin-dir
         hostPath:
-          path: /run/xtables.lock
-          type: File
-      - name: tmp
+          path: /var/lib/kubelet/plugins/csi-secrets-store/
+          type: DirectoryOrCreate
+      - name: providers-dir
         hostPath:
-          path: /tmp
-          type: Directory
-      - name: socket-dir
-        emptyDir: {}
-      serviceAccountName: azure-npm
-`)
-
-func k8sContaineraddonsKubernetesmasteraddonsAzureNpmDaemonsetYamlBytes() ([]byte, error) {
-	return _k8sContaineraddonsKubernetesmasteraddonsAzureNpmDaemonsetYaml, nil
-}
-
-func k8sContaineraddonsKubernetesmasteraddonsAzureNpmDaemonsetYaml() (*asset, error) {
-	bytes, err := k8sContaineraddonsKubernetesmasteraddonsAzureNpmDaemonsetYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-azure-npm-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sContaineraddonsKubernetesmasteraddonsBlobfuseFlexvolumeInstallerYaml = []byte(`apiVersion: extensions/v1beta1
+          path: /etc/kubernetes/secrets-store-csi-providers
+          type: DirectoryOrCreate
+---
+apiVersion: apps/v1
 kind: DaemonSet
 metadata:
-  name: blobfuse-flexvol-installer
+  name: csi-secrets-store-provider-azure
   namespace: kube-system
   labels:
-    k8s-app: blobfuse
+    app: csi-secrets-store-provider-azure
     kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
 spec:
   selector:
     matchLabels:
-      name: blobfuse
+      app: csi-secrets-store-provider-azure
+  updateStrategy:
+    type: RollingUpdate
   template:
     metadata:
       labels:
-        name: blobfuse
-        kubernetes.io/cluster-service: "true"
+        app: csi-secrets-store-provider-azure
     spec:
+      priorityClassName: system-node-critical
+      serviceAccountName: csi-secrets-store-provider-azure
+      hostNetwork: true
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      tolerations:
+      - operator: Exists
       containers:
-      - name: blobfuse-flexvol-installer
-        image: {{ContainerImage "blobfuse-flexvolume"}}
+      - name: provider-azure-installer
+        image: {{ContainerImage "provider-azure-installer"}}
         imagePullPolicy: IfNotPresent
+        args:
+        - --endpoint=unix:///provider/azure.sock
         resources:
           requests:
-            cpu: {{ContainerCPUReqs "blobfuse-flexvolume"}}
-            memory: {{ContainerMemReqs "blobfuse-flexvolume"}}
+            cpu: {{ContainerCPUReqs "provider-azure-installer"}}
+            memory: {{ContainerMemReqs "provider-azure-installer"}}
           limits:
-            cpu: {{ContainerCPULimits "blobfuse-flexvolume"}}
-            memory: {{ContainerMemLimits "blobfuse-flexvolume"}}
+            cpu: {{ContainerCPULimits "provider-azure-installer"}}
+            memory: {{ContainerMemLimits "provider-azure-installer"}}
         volumeMounts:
-        - name: volplugins
-          mountPath: /etc/kubernetes/volumeplugins/
-        - name: varlog
-          mountPath: /var/log/      
+        - name: providervol
+          mountPath: /provider
+        - name: mountpoint-dir
+          mountPath: /var/lib/kubelet/pods
+          mountPropagation: HostToContainer
       volumes:
-      - name: varlog
+      - name: providervol
         hostPath:
-          path: /var/log/              
-      - name: volplugins
+          path: /etc/kubernetes/secrets-store-csi-providers
+          type: DirectoryOrCreate
+      - name: mountpoint-dir
         hostPath:
-          path: /etc/kubernetes/volumeplugins/
-      nodeSelector:
-        beta.kubernetes.io/os: linux
-`)
-
-func k8sContaineraddonsKubernetesmasteraddonsBlobfuseFlexvolumeInstallerYamlBytes() ([]byte, error) {
-	return _k8sContaineraddonsKubernetesmasteraddonsBlobfuseFlexvolumeInstallerYaml, nil
-}
-
-func k8sContaineraddonsKubernetesmasteraddonsBlobfuseFlexvolumeInstallerYaml() (*asset, error) {
-	bytes, err := k8sContaineraddonsKubernetesmasteraddonsBlobfuseFlexvolumeInstallerYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-blobfuse-flexvolume-installer.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sContaineraddonsKubernetesmasteraddonsCalicoDaemonsetYaml = []byte(`# Source: calico/templates/calico-config.yaml
-# This ConfigMap is used to configure a self-hosted Calico installation.
-kind: ConfigMap
-apiVersion: v1
-metadata:
-  name: calico-config
-  namespace: kube-system
-  labels:
-    addonmanager.kubernetes.io/mode: "EnsureExists"
-data:
-  # You must set a non-zero value for Typha replicas below.
-  typha_service_name: "calico-typha"
-
-  # The CNI network configuration to install on each node.  The special
-  # values in this config will be automatically populated.
-  cni_network_config: |-
-    {
-      "name": "k8s-pod-network",
-      "cniVersion": "0.3.1",
-      "plugins": [
-        {
-          "type": "calico",
-          "log_level": "info",
-          "datastore_type": "kubernetes",
-          "nodename": "__KUBERNETES_NODE_NAME__",
-          "mtu": 1500,
-          "ipam": <calicoIPAMConfig>,
-          "policy": {
-              "type": "k8s"
-          },
-          "kubernetes": {
-              "kubeconfig": "__KUBECONFIG_FILEPATH__"
-          }
-        },
-        {
-          "type": "portmap",
-          "snat": true,
-          "capabilities": {"portMappings": true}
-        }
-      ]
-    }
-
----
-# Source: calico/templates/kdd-crds.yaml
-apiVersion: apiextensions.k8s.io/v1beta1
-kind: CustomResourceDefinition
-metadata:
-  name: felixconfigurations.crd.projectcalico.org
-  labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-spec:
-  scope: Cluster
-  group: crd.projectcalico.org
-  version: v1
-  names:
-    kind: FelixConfiguration
-    plural: felixconfigurations
-    singular: felixconfiguration
----
-
-apiVersion: apiextensions.k8s.io/v1beta1
-kind: CustomResourceDefinition
-metadata:
-  name: bgpconfigurations.crd.projectcalico.org
-  labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-spec:
-  scope: Cluster
-  group: crd.projectcalico.org
-  version: v1
-  names:
-    kind: BGPConfiguration
-    plural: bgpconfigurations
-    singular: bgpconfiguration
----
-
-apiVersion: apiextensions.k8s.io/v1beta1
-kind: CustomResourceDefinition
-metadata:
-  name: ippools.crd.projectcalico.org
-  labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-spec:
-  scope: Cluster
-  group: crd.projectcalico.org
-  version: v1
-  names:
-    kind: IPPool
-    plural: ippools
-    singular: ippool
+          path: /var/lib/kubelet/pods
+          type: DirectoryOrCreate
 ---
-
-apiVersion: apiextensions.k8s.io/v1beta1
-kind: CustomResourceDefinition
+apiVersion: apps/v1
+kind: DaemonSet
 metadata:
-  name: hostendpoints.crd.projectcalico.org
+  name: csi-secrets-store-windows
+  namespace: kube-system
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    app: csi-secrets-store-windows
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
 spec:
-  scope: Cluster
-  group: crd.projectcalico.org
-  version: v1
-  names:
-    kind: HostEndpoint
-    plural: hostendpoints
-    singular: hostendpoint
+  selector:
+    matchLabels:
+      app: csi-secrets-store-windows
+  updateStrategy:
+    type: RollingUpdate
+  template:
+    metadata:
+      labels:
+        app: csi-secrets-store-windows
+    spec:
+      priorityClassName: system-node-critical
+      serviceAccountName: secrets-store-csi-driver
+      nodeSelector:
+        beta.kubernetes.io/os: windows
+      tolerations:
+      - operator: Exists
+      containers:
+      - name: node-driver-registrar
+        image: {{ContainerImage "node-driver-registrar"}}
+        imagePullPolicy: IfNotPresent
+        args:
+        - --v=2
+        - --csi-address=unix://C:\\csi\\csi.sock
+        - --kubelet-registration-path=C:\\var\\lib\\kubelet\\plugins\\csi-secrets-store\\csi.sock
+        env:
+        - name: KUBE_NODE_NAME
+          valueFrom:
+            fieldRef:
+              apiVersion: v1
+              fieldPath: spec.nodeName
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "node-driver-registrar"}}
+            memory: {{ContainerMemReqs "node-driver-registrar"}}
+          limits:
+            cpu: {{ContainerCPULimits "node-driver-registrar"}}
+            memory: {{ContainerMemLimits "node-driver-registrar"}}
+        volumeMounts:
+        - name: plugin-dir
+          mountPath: C:\csi
+        - name: registration-dir
+          mountPath: C:\registration
+      - name: secrets-store
+        image: {{ContainerImage "secrets-store"}}
+        imagePullPolicy: IfNotPresent
+        args:
+        - --endpoint=$(CSI_ENDPOINT)
+        - --nodeid=$(KUBE_NODE_NAME)
+        - --provider-volume=C:\k\secrets-store-csi-providers
+        - --enable-secret-rotation={{ContainerConfig "enableSecretRotation"}}
+        - --rotation-poll-interval={{ContainerConfig "rotationPollInterval"}}
+        env:
+        - name: CSI_ENDPOINT
+          value: unix://C:\\csi\\csi.sock
+        - name: KUBE_NODE_NAME
+          valueFrom:
+            fieldRef:
+              apiVersion: v1
+              fieldPath: spec.nodeName
+        ports:
+        - containerPort: 9808
+          name: healthz
+          protocol: TCP
+        livenessProbe:
+          failureThreshold: 5
+          httpGet:
+            path: /healthz
+            port: healthz
+          initialDelaySeconds: 30
+          timeoutSeconds: 10
+          periodSeconds: 15
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "secrets-store"}}
+            memory: {{ContainerMemReqs "secrets-store"}}
+          limits:
+            cpu: {{ContainerCPULimits "secrets-store"}}
+            memory: {{ContainerMemLimits "secrets-store"}}
+        volumeMounts:
+        - name: plugin-dir
+          mountPath: C:\csi
+        - name: mountpoint-dir
+          mountPath: C:\var\lib\kubelet\pods
+        - name: providers-dir
+          mountPath: C:\k\secrets-store-csi-providers
+        - name: csi-proxy-fs-pipe
+          mountPath: \\.\pipe\csi-proxy-filesystem-v1alpha1
+        - name: csi-proxy-smb-pipe
+          mountPath: \\.\pipe\csi-proxy-smb-v1alpha1
+      - name: liveness-probe
+        image: {{ContainerImage "liveness-probe"}}
+        imagePullPolicy: IfNotPresent
+        args:
+        - --csi-address=unix://C:\\csi\\csi.sock
+        - --probe-timeout=3s
+        - --health-port=9808
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "liveness-probe"}}
+            memory: {{ContainerMemReqs "liveness-probe"}}
+          limits:
+            cpu: {{ContainerCPULimits "liveness-probe"}}
+            memory: {{ContainerMemLimits "liveness-probe"}}
+        volumeMounts:
+        - name: plugin-dir
+          mountPath: C:\csi
+      volumes:
+      - name: csi-proxy-fs-pipe
+        hostPath:
+          path: \\.\pipe\csi-proxy-filesystem-v1alpha1
+      - name: csi-proxy-smb-pipe
+        hostPath:
+          path: \\.\pipe\csi-proxy-smb-v1alpha1
+      - name: mountpoint-dir
+        hostPath:
+          path: C:\var\lib\kubelet\pods\
+          type: DirectoryOrCreate
+      - name: registration-dir
+        hostPath:
+          path: C:\var\lib\kubelet\plugins_registry\
+          type: Directory
+      - name: plugin-dir
+        hostPath:
+          path: C:\var\lib\kubelet\plugins\csi-secrets-store\
+          type: DirectoryOrCreate
+      - name: providers-dir
+        hostPath:
+          path: C:\k\secrets-store-csi-providers\
+          type: DirectoryOrCreate
 ---
-
-apiVersion: apiextensions.k8s.io/v1beta1
-kind: CustomResourceDefinition
+apiVersion: apps/v1
+kind: DaemonSet
 metadata:
-  name: clusterinformations.crd.projectcalico.org
+  name: csi-secrets-store-provider-azure-windows
+  namespace: kube-system
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    app: csi-secrets-store-provider-azure-windows
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
 spec:
-  scope: Cluster
-  group: crd.projectcalico.org
-  version: v1
-  names:
-    kind: ClusterInformation
-    plural: clusterinformations
-    singular: clusterinformation
----
+  selector:
+    matchLabels:
+      app: csi-secrets-store-provider-azure-windows
+  updateStrategy:
+    type: RollingUpdate
+  template:
+    metadata:
+      labels:
+        app: csi-secrets-store-provider-azure-windows
+    spec:
+      priorityClassName: system-node-critical
+      serviceAccountName: csi-secrets-store-provider-azure
+      nodeSelector:
+        beta.kubernetes.io/os: windows
+      tolerations:
+      - operator: Exists
+      containers:
+      - name: provider-azure-installer
+        image: {{ContainerImage "provider-azure-installer"}}
+        imagePullPolicy: IfNotPresent
+        args:
+        - --endpoint=unix://C:\\provider\\azure.sock
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "provider-azure-installer"}}
+            memory: {{ContainerMemReqs "provider-azure-installer"}}
+          limits:
+            cpu: {{ContainerCPULimits "provider-azure-installer"}}
+            memory: {{ContainerMemLimits "provider-azure-installer"}}
+        volumeMounts:
+        - name: providervol
+          mountPath: C:\provider
+        - name: mountpoint-dir
+          mountPath: C:\var\lib\kubelet\pods
+      volumes:
+      - name: providervol
+        hostPath:
+          path: C:\k\secrets-store-csi-providers\
+          type: DirectoryOrCreate
+      - name: mountpoint-dir
+        hostPath:
+          path: C:\var\lib\kubelet\pods\
+          type: DirectoryOrCreate
+`)
 
-apiVersion: apiextensions.k8s.io/v1beta1
-kind: CustomResourceDefinition
-metadata:
-  name: globalnetworkpolicies.crd.projectcalico.org
-  labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-spec:
-  scope: Cluster
-  group: crd.projectcalico.org
-  version: v1
-  names:
-    kind: GlobalNetworkPolicy
-    plural: globalnetworkpolicies
-    singular: globalnetworkpolicy
----
+func k8sContaineraddonsKubernetesmasteraddonsCsiSecretsStoreDaemonsetYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsCsiSecretsStoreDaemonsetYaml, nil
+}
 
-apiVersion: apiextensions.k8s.io/v1beta1
-kind: CustomResourceDefinition
-metadata:
-  name: globalnetworksets.crd.projectcalico.org
-  labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-spec:
-  scope: Cluster
-  group: crd.projectcalico.org
-  version: v1
-  names:
-    kind: GlobalNetworkSet
-    plural: globalnetworksets
-    singular: globalnetworkset
----
+func k8sContaineraddonsKubernetesmasteraddonsCsiSecretsStoreDaemonsetYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsCsiSecretsStoreDaemonsetYamlBytes()
+	if err != nil {
+		return nil, err
+	}
 
-apiVersion: apiextensions.k8s.io/v1beta1
-kind: CustomResourceDefinition
-metadata:
-  name: networkpolicies.crd.projectcalico.org
-  labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-spec:
-  scope: Namespaced
-  group: crd.projectcalico.org
-  version: v1
-  names:
-    kind: NetworkPolicy
-    plural: networkpolicies
-    singular: networkpolicy
----
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-csi-secrets-store-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
 
-apiVersion: apiextensions.k8s.io/v1beta1
-kind: CustomResourceDefinition
+var _k8sContaineraddonsKubernetesmasteraddonsFluentBitDaemonsetYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
 metadata:
-  name: networksets.crd.projectcalico.org
+  name: fluent-bit
+  namespace: kube-system
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-spec:
-  scope: Namespaced
-  group: crd.projectcalico.org
-  version: v1
-  names:
-    kind: NetworkSet
-    plural: networksets
-    singular: networkset
+    k8s-app: fluent-bit
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
 ---
-# Source: calico/templates/rbac.yaml
-
-# Include a clusterrole for the calico-node DaemonSet,
-# and bind it to the calico-node serviceaccount.
-kind: ClusterRole
 apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
 metadata:
-  name: calico-node
+  name: fluent-bit
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    k8s-app: fluent-bit
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
 rules:
-# The CNI plugin needs to get pods, nodes, and namespaces.
-- apiGroups: [""]
-  resources:
-  - pods
-  - nodes
-  - namespaces
-  verbs:
-  - get
-- apiGroups: [""]
-  resources:
-  - endpoints
-  - services
-  verbs:
-  # Used to discover service IPs for advertisement.
-  - watch
-  - list
-  # Used to discover Typhas.
-  - get
-- apiGroups: [""]
-  resources:
-  - nodes/status
-  verbs:
-  # Needed for clearing NodeNetworkUnavailable flag.
-  - patch
-  # Calico stores some configuration information in node annotations.
-  - update
-# Watch for changes to Kubernetes NetworkPolicies.
-- apiGroups: ["networking.k8s.io"]
-  resources:
-  - networkpolicies
-  verbs:
-  - watch
-  - list
-# Used by Calico for policy information.
-- apiGroups: [""]
-  resources:
-  - pods
-  - namespaces
-  - serviceaccounts
-  verbs:
-  - list
-  - watch
-# The CNI plugin patches pods/status.
-- apiGroups: [""]
-  resources:
-  - pods/status
-  verbs:
-  - patch
-# Calico monitors various CRDs for config.
-- apiGroups: ["crd.projectcalico.org"]
-  resources:
-  - globalfelixconfigs
-  - felixconfigurations
-  - bgppeers
-  - globalbgpconfigs
-  - bgpconfigurations
-  - ippools
-  - ipamblocks
-  - globalnetworkpolicies
-  - globalnetworksets
-  - networkpolicies
-  - networksets
-  - clusterinformations
-  - hostendpoints
-  verbs:
-  - get
-  - list
-  - watch
-# Calico must create and update some CRDs on startup.
-- apiGroups: ["crd.projectcalico.org"]
-  resources:
-  - ippools
-  - felixconfigurations
-  - clusterinformations
-  verbs:
-  - create
-  - update
-# Calico stores some configuration information on the node.
 - apiGroups: [""]
-  resources:
-  - nodes
-  verbs:
-  - get
-  - list
-  - watch
-# These permissions are only requried for upgrade from v2.6, and can
-# be removed after upgrade or on fresh installations.
-- apiGroups: ["crd.projectcalico.org"]
-  resources:
-  - bgpconfigurations
-  - bgppeers
-  verbs:
-  - create
-  - update
+  resources: ["namespaces", "pods"]
+  verbs: ["get", "list", "watch"]
 ---
 apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRoleBinding
 metadata:
-  name: calico-node
+  name: fluent-bit
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    k8s-app: fluent-bit
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
 roleRef:
   apiGroup: rbac.authorization.k8s.io
   kind: ClusterRole
-  name: calico-node
+  name: fluent-bit
 subjects:
 - kind: ServiceAccount
-  name: calico-node
+  name: fluent-bit
   namespace: kube-system
 ---
-# Source: calico/templates/calico-typha.yaml
-# This manifest creates a Service, which will be backed by Calico's Typha daemon.
-# Typha sits in between Felix and the API server, reducing Calico's load on the API server.
-
 apiVersion: v1
-kind: Service
+kind: Secret
 metadata:
-  name: calico-typha
+  name: fluent-bit
   namespace: kube-system
   labels:
-    k8s-app: calico-typha
-    addonmanager.kubernetes.io/mode: "Reconcile"
-spec:
-  ports:
-  - port: 5473
-    protocol: TCP
-    targetPort: calico-typha
-    name: calico-typha
-  selector:
-    k8s-app: calico-typha
+    k8s-app: fluent-bit
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+type: Opaque
+data:
+{{- if eq (ContainerConfig "output") "storage"}}
+  STORAGE_ACCOUNT_KEY: "{{ContainerConfig "storageAccountKey"}}"
+{{- else}}
+  WORKSPACE_ID: "{{ContainerConfig "workspaceGuid"}}"
+  WORKSPACE_KEY: "{{ContainerConfig "workspaceKey"}}"
+{{- end}}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: fluent-bit
+  namespace: kube-system
+  labels:
+    k8s-app: fluent-bit
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+data:
+  output.conf: |
+{{- if eq (ContainerConfig "output") "storage"}}
+    [OUTPUT]
+        Name                  azure_blob
+        Match                 kube.*
+        account_name          {{ContainerConfig "storageAccountName"}}
+        shared_key            ${STORAGE_ACCOUNT_KEY}
+        container_name        {{ContainerConfig "storageContainer"}}
+        blob_type             appendblob
+        auto_create_container on
+        tls                   on
+{{- else}}
+    [OUTPUT]
+        Name        azure
+        Match       kube.*
+        Customer_ID ${WORKSPACE_ID}
+        Shared_Key  ${WORKSPACE_KEY}
+        Log_Type    {{ContainerConfig "logType"}}
+{{- end}}
+  fluent-bit.conf: |
+    [SERVICE]
+        Flush        5
+        Log_Level    info
+        Daemon       off
+        HTTP_Server  On
+        HTTP_Listen  0.0.0.0
+        HTTP_Port    2020
+
+    [INPUT]
+        Name              tail
+        Tag               kube.*
+        Path              /var/log/containers/*.log
+        multiline.parser  docker, cri
+        DB                /var/lib/fluent-bit/containers.db
+        Mem_Buf_Limit     5MB
+        Skip_Long_Lines   On
+        Refresh_Interval  10
+
+    [FILTER]
+        Name                kubernetes
+        Match               kube.*
+        Kube_URL            https://kubernetes.default.svc:443
+        Merge_Log           On
+        Keep_Log            On
+        K8S-Logging.Exclude On
+
+    @INCLUDE output.conf
+  fluent-bit-windows.conf: |
+    [SERVICE]
+        Flush        5
+        Log_Level    info
+        Daemon       off
+        HTTP_Server  On
+        HTTP_Listen  0.0.0.0
+        HTTP_Port    2020
+
+    [INPUT]
+        Name              tail
+        Tag               kube.*
+        Path              C:\var\log\containers\*.log
+        multiline.parser  docker, cri
+        DB                C:\var\lib\fluent-bit\containers.db
+        Mem_Buf_Limit     5MB
+        Skip_Long_Lines   On
+        Refresh_Interval  10
+
+    [FILTER]
+        Name                kubernetes
+        Match               kube.*
+        Kube_URL            https://kubernetes.default.svc:443
+        Merge_Log           On
+        Keep_Log            On
+        K8S-Logging.Exclude On
+
+    @INCLUDE output.conf
 ---
-
-# This manifest creates a Deployment of Typha to back the above service.
-
 apiVersion: apps/v1
-kind: Deployment
+kind: DaemonSet
 metadata:
-  name: calico-typha
+  name: fluent-bit
   namespace: kube-system
   labels:
-    k8s-app: calico-typha
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    k8s-app: fluent-bit
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
 spec:
-  # Number of Typha replicas.  To enable Typha, set this to a non-zero value *and* set the
-  # typha_service_name variable in the calico-config ConfigMap above.
-  #
-  # We recommend using Typha if you have more than 50 nodes.  Above 100 nodes it is essential
-  # (when using the Kubernetes datastore).  Use one replica for every 100-200 nodes.  In
-  # production, we recommend running at least 3 replicas to reduce the impact of rolling upgrade.
-  replicas: 1
-  revisionHistoryLimit: 2
   selector:
     matchLabels:
-      k8s-app: calico-typha
+      k8s-app: fluent-bit
+  updateStrategy:
+    type: RollingUpdate
   template:
     metadata:
       labels:
-        k8s-app: calico-typha
-      annotations:
-        # This, along with the CriticalAddonsOnly toleration below, marks the pod as a critical
-        # add-on, ensuring it gets priority scheduling and that its resources are reserved
-        # if it ever gets evicted.
-        scheduler.alpha.kubernetes.io/critical-pod: ''
-        cluster-autoscaler.kubernetes.io/safe-to-evict: 'true'
+        k8s-app: fluent-bit
     spec:
+      priorityClassName: system-node-critical
+      serviceAccountName: fluent-bit
       nodeSelector:
         beta.kubernetes.io/os: linux
-      hostNetwork: true
       tolerations:
-      # Mark the pod as a critical add-on for rescheduling.
-      - key: CriticalAddonsOnly
-        operator: Exists
-      # Since Calico can't network a pod until Typha is up, we need to run Typha itself
-      # as a host-networked pod.
-      serviceAccountName: calico-node
-      priorityClassName: system-cluster-critical
+      - operator: Exists
       containers:
-      - image: {{ContainerImage "calico-typha"}}
-        name: calico-typha
+      - name: fluent-bit
+        image: {{ContainerImage "fluent-bit"}}
+        imagePullPolicy: IfNotPresent
+        envFrom:
+        - secretRef:
+            name: fluent-bit
         ports:
-        - containerPort: 5473
-          name: calico-typha
+        - name: http
+          containerPort: 2020
           protocol: TCP
-        env:
-        # Enable "info" logging by default.  Can be set to "debug" to increase verbosity.
-        - name: TYPHA_LOGSEVERITYSCREEN
-          value: "info"
-        # Disable logging to file and syslog since those don't make sense in Kubernetes.
-        - name: TYPHA_LOGFILEPATH
-          value: "none"
-        - name: TYPHA_LOGSEVERITYSYS
-          value: "none"
-        # Monitor the Kubernetes API to find the number of running instances and rebalance
-        # connections.
-        - name: TYPHA_CONNECTIONREBALANCINGMODE
-          value: "kubernetes"
-        - name: TYPHA_DATASTORETYPE
-          value: "kubernetes"
-        - name: TYPHA_HEALTHENABLED
-          value: "true"
-        # Configure route aggregation based on pod CIDR.
-        - name: USE_POD_CIDR
-          value: "true"
-        - name: FELIX_INTERFACEPREFIX
-          value: "azv"
-        # Uncomment these lines to enable prometheus metrics.  Since Typha is host-networked,
-        # this opens a port on the host, which may need to be secured.
-        #- name: TYPHA_PROMETHEUSMETRICSENABLED
-        #  value: "true"
-        #- name: TYPHA_PROMETHEUSMETRICSPORT
-        #  value: "9093"
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "fluent-bit"}}
+            memory: {{ContainerMemReqs "fluent-bit"}}
+          limits:
+            cpu: {{ContainerCPULimits "fluent-bit"}}
+            memory: {{ContainerMemLimits "fluent-bit"}}
         livenessProbe:
           httpGet:
-            path: /liveness
-            port: 9098
-            host: localhost
-          periodSeconds: 30
-          initialDelaySeconds: 30
-        readinessProbe:
-          httpGet:
-            path: /readiness
-            port: 9098
-            host: localhost
-          periodSeconds: 10
+            path: /
+            port: http
+          initialDelaySeconds: 10
+        volumeMounts:
+        - name: config
+          mountPath: /fluent-bit/etc/
+        - name: varlog
+          mountPath: /var/log
+          readOnly: true
+        - name: varlibdockercontainers
+          mountPath: /var/lib/docker/containers
+          readOnly: true
+        - name: state
+          mountPath: /var/lib/fluent-bit
+      terminationGracePeriodSeconds: 10
+      volumes:
+      - name: config
+        configMap:
+          name: fluent-bit
+          items:
+          - key: fluent-bit.conf
+            path: fluent-bit.conf
+          - key: output.conf
+            path: output.conf
+      - name: varlog
+        hostPath:
+          path: /var/log
+      - name: varlibdockercontainers
+        hostPath:
+          path: /var/lib/docker/containers
+      - name: state
+        hostPath:
+          path: /var/lib/fluent-bit
+          type: DirectoryOrCreate
 ---
-# Source: calico/templates/calico-node.yaml
-# This manifest installs the calico-node container, as well
-# as the CNI plugins and network config on
-# each master and worker node in a Kubernetes cluster.
-kind: DaemonSet
 apiVersion: apps/v1
+kind: DaemonSet
 metadata:
-  name: calico-node
+  name: fluent-bit-windows
   namespace: kube-system
   labels:
-    k8s-app: calico-node
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    k8s-app: fluent-bit-windows
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
 spec:
   selector:
     matchLabels:
-      k8s-app: calico-node
+      k8s-app: fluent-bit-windows
   updateStrategy:
     type: RollingUpdate
-    rollingUpdate:
-      maxUnavailable: 1
   template:
     metadata:
       labels:
-        k8s-app: calico-node
-      annotations:
-        # This, along with the CriticalAddonsOnly toleration below,
-        # marks the pod as a critical add-on, ensuring it gets
-        # priority scheduling and that its resources are reserved
-        # if it ever gets evicted.
-        scheduler.alpha.kubernetes.io/critical-pod: ''
+        k8s-app: fluent-bit-windows
     spec:
+      priorityClassName: system-node-critical
+      serviceAccountName: fluent-bit
       nodeSelector:
-        beta.kubernetes.io/os: linux
-      hostNetwork: true
+        beta.kubernetes.io/os: windows
       tolerations:
-      # Make sure calico-node gets scheduled on all nodes.
-      - effect: NoSchedule
-        operator: Exists
-      # Mark the pod as a critical add-on for rescheduling.
-      - key: CriticalAddonsOnly
-        operator: Exists
-      - effect: NoExecute
-        operator: Exists
-      serviceAccountName: calico-node
-      # Minimize downtime during a rolling upgrade or deletion; tell Kubernetes to do a "force
-      # deletion": https://kubernetes.io/docs/concepts/workloads/pods/pod/#termination-of-pods.
-      terminationGracePeriodSeconds: 0
-      priorityClassName: system-node-critical
-      initContainers:
-      # Start of install-cni initContainer
-      # This container installs the CNI binaries
-      # and CNI network config file on each node.
-      - name: install-cni
-        image: {{ContainerImage "calico-cni"}}
-        command: ["/install-cni.sh"]
-        env:
-        # Name of the CNI config file to create.
-        - name: CNI_CONF_NAME
-          value: "10-calico.conflist"
-        # The CNI network config to install on each node.
-        - name: CNI_NETWORK_CONFIG
-          valueFrom:
-            configMapKeyRef:
-              name: calico-config
-              key: cni_network_config
-        # Set the hostname based on the k8s node name.
-        - name: KUBERNETES_NODE_NAME
-          valueFrom:
-            fieldRef:
-              fieldPath: spec.nodeName
-        # Prevents the container from sleeping forever.
-        - name: SLEEP
-          value: "false"
-        volumeMounts:
-        - mountPath: /host/opt/cni/bin
-          name: cni-bin-dir
-        - mountPath: /host/etc/cni/net.d
-          name: cni-net-dir
-      # End of install-cni initContainer
-      # Adds a Flex Volume Driver that creates a per-pod Unix Domain Socket to allow Dikastes
-      # to communicate with Felix over the Policy Sync API.
-      - name: flexvol-driver
-        image: {{ContainerImage "calico-pod2daemon"}}
-        volumeMounts:
-        - name: flexvol-driver-host
-          mountPath: /host/driver
+      - operator: Exists
       containers:
-      # Runs calico-node container on each Kubernetes node.  This
-      # container programs network policy and routes on each
-      # host.
-      - name: calico-node
-        image: {{ContainerImage "calico-node"}}
-        env:
-        # Use Kubernetes API as the backing datastore.
-        - name: DATASTORE_TYPE
-          value: "kubernetes"
-        # Configure route aggregation based on pod CIDR.
-        - name: USE_POD_CIDR
-          value: "true"
-        # Typha support: controlled by the ConfigMap.
-        - name: FELIX_TYPHAK8SSERVICENAME
-          valueFrom:
-            configMapKeyRef:
-              name: calico-config
-              key: typha_service_name
-        # Wait for the datastore.
-        - name: WAIT_FOR_DATASTORE
-          value: "true"
-        # Set based on the k8s node name.
-        - name: NODENAME
-          valueFrom:
-            fieldRef:
-              fieldPath: spec.nodeName
-        # Don't enable BGP.
-        - name: CALICO_NETWORKING_BACKEND
-          value: "none"
-        # Cluster type to identify the deployment type
-        - name: CLUSTER_TYPE
-          value: "k8s"
-        # The default IPv4 pool to create on startup if none exists. Pod IPs will be
-        # chosen from this range. Changing this value after installation will have
-        # no effect. This should fall within ` + "`" + `--cluster-cidr` + "`" + `.
-        - name: CALICO_IPV4POOL_CIDR
-          value: "<kubeClusterCidr>"
-        # Disable file logging so ` + "`" + `kubectl logs` + "`" + ` works.
-        - name: CALICO_DISABLE_FILE_LOGGING
-          value: "true"
-        # Set Felix endpoint to host default action to ACCEPT.
-        - name: FELIX_DEFAULTENDPOINTTOHOSTACTION
-          value: "ACCEPT"
-        # Disable IPv6 on Kubernetes.
-        - name: FELIX_IPV6SUPPORT
-          value: "false"
-        # Set Felix logging to "info"
-        - name: FELIX_LOGSEVERITYSCREEN
-          value: "info"
-        - name: FELIX_HEALTHENABLED
-          value: "true"
-        - name: CALICO_IPV4POOL_IPIP
-          value: "off"
-        - name: FELIX_INTERFACEPREFIX
-          value: "azv"
-        securityContext:
-          privileged: true
-        resources:
-          requests:
-            cpu: 250m
-        livenessProbe:
-          httpGet:
-            path: /liveness
-            port: 9099
-            host: localhost
-          periodSeconds: 10
-          initialDelaySeconds: 10
-          failureThreshold: 6
-        readinessProbe:
-          exec:
-            command:
-            - /bin/calico-node
-            - -felix-ready
-          periodSeconds: 10
+      - name: fluent-bit
+        image: {{ContainerImage "fluent-bit-windows"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - C:\fluent-bit\bin\fluent-bit.exe
+        - -c
+        - C:\fluent-bit\etc\fluent-bit.conf
+        envFrom:
+        - secretRef:
+            name: fluent-bit
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "fluent-bit-windows"}}
+            memory: {{ContainerMemReqs "fluent-bit-windows"}}
+          limits:
+            cpu: {{ContainerCPULimits "fluent-bit-windows"}}
+            memory: {{ContainerMemLimits "fluent-bit-windows"}}
         volumeMounts:
-        - mountPath: /lib/modules
-          name: lib-modules
+        - name: config
+          mountPath: C:\fluent-bit\etc
+        - name: varlog
+          mountPath: C:\var\log
+        - name: dockercontainers
+          mountPath: C:\ProgramData\docker\containers
           readOnly: true
-        - mountPath: /run/xtables.lock
-          name: xtables-lock
-          readOnly: false
-        - mountPath: /var/run/calico
-          name: var-run-calico
-          readOnly: false
-        - mountPath: /var/lib/calico
-          name: var-lib-calico
-          readOnly: false
-        - name: policysync
-          mountPath: /var/run/nodeagent
+        - name: state
+          mountPath: C:\var\lib\fluent-bit
+      terminationGracePeriodSeconds: 10
       volumes:
-      # Used by calico-node.
-      - name: lib-modules
-        hostPath:
-          path: /lib/modules
-      - name: var-run-calico
-        hostPath:
-          path: /var/run/calico
-      - name: var-lib-calico
-        hostPath:
-          path: /var/lib/calico
-      - name: xtables-lock
-        hostPath:
-          path: /run/xtables.lock
-          type: FileOrCreate
-      # Used to install CNI.
-      - name: cni-bin-dir
-        hostPath:
-          path: /opt/cni/bin
-      - name: cni-net-dir
+      - name: config
+        configMap:
+          name: fluent-bit
+          items:
+          - key: fluent-bit-windows.conf
+            path: fluent-bit.conf
+          - key: output.conf
+            path: output.conf
+      - name: varlog
         hostPath:
-          path: /etc/cni/net.d
-      # Used to create per-pod Unix Domain Sockets
-      - name: policysync
+          path: C:\var\log
+      - name: dockercontainers
         hostPath:
-          type: DirectoryOrCreate
-          path: /var/run/nodeagent
-      # Used to install Flex Volume Driver
-      - name: flexvol-driver-host
+          path: C:\ProgramData\docker\containers
+      - name: state
         hostPath:
+          path: C:\var\lib\fluent-bit
           type: DirectoryOrCreate
-          path: /etc/kubernetes/volumeplugins/nodeagent~uds
----
+`)
 
-apiVersion: v1
+func k8sContaineraddonsKubernetesmasteraddonsFluentBitDaemonsetYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsFluentBitDaemonsetYaml, nil
+}
+
+func k8sContaineraddonsKubernetesmasteraddonsFluentBitDaemonsetYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsFluentBitDaemonsetYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-fluent-bit-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsKubernetesmasteraddonsHeapsterDeploymentYaml = []byte(`apiVersion: v1
 kind: ServiceAccount
 metadata:
-  name: calico-node
+  name: heapster
   namespace: kube-system
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRole
+metadata:
+  name: system:heapster-with-nanny
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
+rules:
+- apiGroups:
+  - extensions
+  - apps
+  resources:
+  - deployments
+  verbs:
+  - get
+  - list
+  - watch
+  - update
+  - patch
+- apiGroups:
+  - ""
+  resources:
+  - events
+  - namespaces
+  - nodes
+  - pods
+  verbs:
+  - get
+  - list
+  - watch
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRoleBinding
+metadata:
+  name: system:heapster-with-nanny
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: system:heapster-with-nanny
+subjects:
+- kind: ServiceAccount
+  name: heapster
+  namespace: kube-system
 ---
-
-# Typha Horizontal Autoscaler ConfigMap
-kind: ConfigMap
 apiVersion: v1
+kind: ConfigMap
 metadata:
-  name: calico-typha-horizontal-autoscaler
+  name: heapster-config
   namespace: kube-system
   labels:
-    addonmanager.kubernetes.io/mode: "EnsureExists"
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
 data:
-  ladder: |-
-    {
-      "coresToReplicas": [],
-      "nodesToReplicas":
-      [
-        [1, 1],
-        [10, 2],
-        [100, 3],
-        [250, 4],
-        [500, 5],
-        [1000, 6],
-        [1500, 7],
-        [2000, 8]
-      ]
-    }
+  NannyConfiguration: |-
+    apiVersion: nannyconfig/v1alpha1
+    kind: NannyConfiguration
+---
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    kubernetes.io/cluster-service: "true"
+    kubernetes.io/name: Heapster
+  name: heapster
+  namespace: kube-system
+spec:
+  ports:
+  - port: 80
+    targetPort: 8082
+  selector:
+    k8s-app: heapster
+---
+apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: heapster
+  namespace: kube-system
+  labels:
+    k8s-app: heapster
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: EnsureExists
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      k8s-app: heapster
+  template:
+    metadata:
+      labels:
+        k8s-app: heapster
+      annotations:
+        scheduler.alpha.kubernetes.io/critical-pod: ''
+    spec:
+      priorityClassName: system-node-critical
+      containers:
+        - image: {{ContainerImage "heapster"}}
+          imagePullPolicy: IfNotPresent
+          name: heapster
+          resources:
+            requests:
+              cpu: {{ContainerCPUReqs "heapster"}}
+              memory: {{ContainerMemReqs "heapster"}}
+            limits:
+              cpu: {{ContainerCPULimits "heapster"}}
+              memory: {{ContainerMemLimits "heapster"}}
+          livenessProbe:
+            httpGet:
+              path: /healthz
+              port: 8082
+              scheme: HTTP
+            initialDelaySeconds: 180
+            timeoutSeconds: 5
+          command:
+            - /heapster
+            - --source=kubernetes.summary_api:''
+        - image: {{ContainerImage "heapster-nanny"}}
+          imagePullPolicy: IfNotPresent
+          name: heapster-nanny
+          resources:
+            requests:
+              cpu: {{ContainerCPUReqs "heapster-nanny"}}
+              memory: {{ContainerMemReqs "heapster-nanny"}}
+            limits:
+              cpu: {{ContainerCPULimits "heapster-nanny"}}
+              memory: {{ContainerMemLimits "heapster-nanny"}}
+          env:
+            - name: MY_POD_NAME
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.name
+            - name: MY_POD_NAMESPACE
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.namespace
+          volumeMounts:
+          - name: heapster-config-volume
+            mountPath: /etc/config
+          command:
+            - /pod_nanny
+            - --config-dir=/etc/config
+            - --cpu=80m
+            - --extra-cpu=0.5m
+            - --memory=140Mi
+            - --extra-memory=4Mi
+            - --threshold=5
+            - --deployment=heapster
+            - --container=heapster
+            - --poll-period=300000
+            - --estimator=exponential
+      volumes:
+        - name: heapster-config-volume
+          configMap:
+            name: heapster-config
+      serviceAccountName: heapster
+      tolerations:
+        - key: CriticalAddonsOnly
+          operator: Exists
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+`)
+
+func k8sContaineraddonsKubernetesmasteraddonsHeapsterDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsHeapsterDeploymentYaml, nil
+}
+
+func k8sContaineraddonsKubernetesmasteraddonsHeapsterDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsHeapsterDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
 
----
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-heapster-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
 
-# Typha Horizontal Autoscaler Deployment
-apiVersion: extensions/v1beta1
-kind: Deployment
+var _k8sContaineraddonsKubernetesmasteraddonsKedaDeploymentYaml = []byte(`apiVersion: v1
+kind: Namespace
 metadata:
-  name: calico-typha-horizontal-autoscaler
-  namespace: kube-system
+  name: keda
   labels:
-    k8s-app: calico-typha-autoscaler
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    app: keda
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: scaledobjects.keda.k8s.io
+  labels:
+    app.kubernetes.io/name: keda
+    app.kubernetes.io/part-of: keda
+    addonmanager.kubernetes.io/mode: Reconcile
 spec:
-  replicas: 1
-  template:
-    metadata:
-      labels:
-        k8s-app: calico-typha-autoscaler
-      annotations:
-        scheduler.alpha.kubernetes.io/critical-pod: ''
-    spec:
-      priorityClassName: system-cluster-critical
-      securityContext:
-        supplementalGroups: [65534]
-        fsGroup: 65534
-      containers:
-      - image: {{ContainerImage "calico-cluster-proportional-autoscaler"}}
-        name: autoscaler
-        command:
-        - /cluster-proportional-autoscaler
-        - --namespace=kube-system
-        - --configmap=calico-typha-horizontal-autoscaler
-        - --target=deployment/calico-typha
-        - --logtostderr=true
-        - --v=2
-        resources:
-          requests:
-            cpu: 10m
-          limits:
-            cpu: 10m
-      serviceAccountName: typha-cpha
+  group: keda.k8s.io
+  names:
+    kind: ScaledObject
+    listKind: ScaledObjectList
+    plural: scaledobjects
+    singular: scaledobject
+    shortNames:
+    - sco
+  scope: Namespaced
+  additionalPrinterColumns:
+  - name: Deployment
+    type: string
+    JSONPath: .spec.scaleTargetRef.deploymentName
+  - name: Triggers
+    type: string
+    JSONPath: .spec.triggers[*].type
+  - name: Age
+    type: date
+    JSONPath: .metadata.creationTimestamp
+  subresources:
+    status: {}
+  version: v1alpha1
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: triggerauthentications.keda.k8s.io
+  labels:
+    app.kubernetes.io/name: keda
+    app.kubernetes.io/part-of: keda
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: keda.k8s.io
+  names:
+    kind: TriggerAuthentication
+    listKind: TriggerAuthenticationList
+    plural: triggerauthentications
+    singular: triggerauthentication
+    shortNames:
+    - triggerauth
+  scope: Namespaced
+  version: v1alpha1
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: keda-operator
+  namespace: keda
+  labels:
+    app.kubernetes.io/name: keda
+    app.kubernetes.io/part-of: keda
+    addonmanager.kubernetes.io/mode: Reconcile
 ---
-
-# Typha Horizontal Autoscaler Cluster Role
 apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRole
 metadata:
-  name: typha-cpha
+  name: keda-operator
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    app.kubernetes.io/name: keda
+    app.kubernetes.io/part-of: keda
+    addonmanager.kubernetes.io/mode: Reconcile
 rules:
 - apiGroups: [""]
-  resources: ["nodes"]
-  verbs: ["list"]
-
+  resources: ["configmaps", "configmaps/status", "events", "pods", "secrets", "services", "endpoints"]
+  verbs: ["*"]
+- apiGroups: ["apps", "extensions"]
+  resources: ["deployments", "deployments/scale"]
+  verbs: ["*"]
+- apiGroups: ["batch"]
+  resources: ["jobs"]
+  verbs: ["*"]
+- apiGroups: ["autoscaling"]
+  resources: ["horizontalpodautoscalers"]
+  verbs: ["*"]
+- apiGroups: ["keda.k8s.io"]
+  resources: ["*"]
+  verbs: ["*"]
+- apiGroups: ["coordination.k8s.io"]
+  resources: ["leases"]
+  verbs: ["*"]
 ---
-
-# Typha Horizontal Autoscaler Cluster Role Binding
 apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRoleBinding
 metadata:
-  name: typha-cpha
+  name: keda-operator
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    app.kubernetes.io/name: keda
+    app.kubernetes.io/part-of: keda
+    addonmanager.kubernetes.io/mode: Reconcile
 roleRef:
   apiGroup: rbac.authorization.k8s.io
   kind: ClusterRole
-  name: typha-cpha
+  name: keda-operator
 subjects:
 - kind: ServiceAccount
-  name: typha-cpha
-  namespace: kube-system
+  name: keda-operator
+  namespace: keda
 ---
-
-# Typha Horizontal Autoscaler Role
+# the external metrics server delegates the authentication and authorization of its requests to the API server
 apiVersion: rbac.authorization.k8s.io/v1
-kind: Role
+kind: ClusterRoleBinding
 metadata:
-  name: typha-cpha
-  namespace: kube-system
+  name: keda:system:auth-delegator
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-rules:
-- apiGroups: [""]
-  resources: ["configmaps"]
-  verbs: ["get"]
-- apiGroups: ["extensions"]
-  resources: ["deployments/scale"]
-  verbs: ["get", "update"]
-
+    app.kubernetes.io/name: keda
+    app.kubernetes.io/part-of: keda
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: system:auth-delegator
+subjects:
+- kind: ServiceAccount
+  name: keda-operator
+  namespace: keda
 ---
-
-# Typha Horizontal Autoscaler Role Binding
 apiVersion: rbac.authorization.k8s.io/v1
 kind: RoleBinding
 metadata:
-  name: typha-cpha
+  name: keda-auth-reader
   namespace: kube-system
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
+    app.kubernetes.io/name: keda
+    app.kubernetes.io/part-of: keda
+    addonmanager.kubernetes.io/mode: Reconcile
 roleRef:
   apiGroup: rbac.authorization.k8s.io
   kind: Role
-  name: typha-cpha
+  name: extension-apiserver-authentication-reader
 subjects:
 - kind: ServiceAccount
-  name: typha-cpha
-  namespace: kube-system
+  name: keda-operator
+  namespace: keda
 ---
-
-# Typha Horizontal Autoscaler Service Account
-apiVersion: v1
-kind: ServiceAccount
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
 metadata:
-  name: typha-cpha
-  namespace: kube-system
+  name: keda-external-metrics-reader
   labels:
-    addonmanager.kubernetes.io/mode: "Reconcile"
-`)
-
-func k8sContaineraddonsKubernetesmasteraddonsCalicoDaemonsetYamlBytes() ([]byte, error) {
-	return _k8sContaineraddonsKubernetesmasteraddonsCalicoDaemonsetYaml, nil
-}
-
-func k8sContaineraddonsKubernetesmasteraddonsCalicoDaemonsetYaml() (*asset, error) {
-	bytes, err := k8sContaineraddonsKubernetesmasteraddonsCalicoDaemonsetYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-calico-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sContaineraddonsKubernetesmasteraddonsClusterAutoscalerDeploymentYaml = []byte(`apiVersion: v1
-kind: ServiceAccount
+    app.kubernetes.io/name: keda
+    app.kubernetes.io/part-of: keda
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups: ["external.metrics.k8s.io"]
+  resources: ["*"]
+  verbs: ["*"]
+---
+# the horizontal pod autoscalers KEDA creates for the scaled objects get the external metrics of their triggers
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
 metadata:
+  name: keda-hpa-controller-external-metrics
   labels:
-    k8s-addon: cluster-autoscaler.addons.k8s.io
-    k8s-app: cluster-autoscaler
-    kubernetes.io/cluster-service: "true"
+    app.kubernetes.io/name: keda
+    app.kubernetes.io/part-of: keda
     addonmanager.kubernetes.io/mode: Reconcile
-  name: cluster-autoscaler
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: keda-external-metrics-reader
+subjects:
+- kind: ServiceAccount
+  name: horizontal-pod-autoscaler
   namespace: kube-system
 ---
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: ClusterRole
+apiVersion: apps/v1
+kind: Deployment
 metadata:
-  name: cluster-autoscaler
+  name: keda-operator
+  namespace: keda
   labels:
-    k8s-addon: cluster-autoscaler.addons.k8s.io
-    k8s-app: cluster-autoscaler
-    kubernetes.io/cluster-service: "true"
+    app: keda-operator
+    app.kubernetes.io/name: keda-operator
+    app.kubernetes.io/part-of: keda
     addonmanager.kubernetes.io/mode: Reconcile
-rules:
-- apiGroups: [""]
-  resources: ["events","endpoints"]
-  verbs: ["create", "patch"]
-- apiGroups: [""]
-  resources: ["pods/eviction"]
-  verbs: ["create"]
-- apiGroups: [""]
-  resources: ["pods/status"]
-  verbs: ["update"]
-- apiGroups: [""]
-  resources: ["endpoints"]
-  resourceNames: ["cluster-autoscaler"]
-  verbs: ["get","update"]
-- apiGroups: [""]
-  resources: ["nodes"]
-  verbs: ["watch","list","get","update"]
-- apiGroups: [""]
-  resources: ["pods","services","replicationcontrollers","persistentvolumeclaims","persistentvolumes"]
-  verbs: ["watch","list","get"]
-- apiGroups: ["extensions"]
-  resources: ["replicasets","daemonsets"]
-  verbs: ["watch","list","get"]
-- apiGroups: ["policy"]
-  resources: ["poddisruptionbudgets"]
-  verbs: ["watch","list"]
-- apiGroups: ["apps"]
-  resources: ["statefulsets","replicasets","daemonsets"]
-  verbs: ["watch","list","get"]
-- apiGroups: ["batch"]
-  resources: ["jobs"]
-  verbs: ["watch","list"]
-- apiGroups: ["storage.k8s.io"]
-  resources: ["storageclasses"]
-  verbs: ["get", "list", "watch"]
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: keda-operator
+  template:
+    metadata:
+      labels:
+        app: keda-operator
+        app.kubernetes.io/name: keda-operator
+        app.kubernetes.io/part-of: keda
+    spec:
+      serviceAccountName: keda-operator
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      containers:
+      - name: keda-operator
+        image: {{ContainerImage "keda-operator"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - keda
+        args:
+        - --zap-level=info
+        env:
+        - name: WATCH_NAMESPACE
+          value: ""
+        - name: POD_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.name
+        - name: OPERATOR_NAME
+          value: keda-operator
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "keda-operator"}}
+            memory: {{ContainerMemReqs "keda-operator"}}
+          limits:
+            cpu: {{ContainerCPULimits "keda-operator"}}
+            memory: {{ContainerMemLimits "keda-operator"}}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: keda-metrics-apiserver
+  namespace: keda
+  labels:
+    app: keda-metrics-apiserver
+    app.kubernetes.io/name: keda-metrics-apiserver
+    app.kubernetes.io/part-of: keda
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: keda-metrics-apiserver
+  template:
+    metadata:
+      labels:
+        app: keda-metrics-apiserver
+        app.kubernetes.io/name: keda-metrics-apiserver
+        app.kubernetes.io/part-of: keda
+    spec:
+      serviceAccountName: keda-operator
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      containers:
+      - name: keda-metrics-apiserver
+        image: {{ContainerImage "keda-metrics-apiserver"}}
+        imagePullPolicy: IfNotPresent
+        args:
+        - /usr/local/bin/keda-adapter
+        - --secure-port=6443
+        - --logtostderr=true
+        - --v=0
+        env:
+        - name: WATCH_NAMESPACE
+          value: ""
+        ports:
+        - name: https
+          containerPort: 6443
+        - name: http
+          containerPort: 8080
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "keda-metrics-apiserver"}}
+            memory: {{ContainerMemReqs "keda-metrics-apiserver"}}
+          limits:
+            cpu: {{ContainerCPULimits "keda-metrics-apiserver"}}
+            memory: {{ContainerMemLimits "keda-metrics-apiserver"}}
+        volumeMounts:
+        - name: temp-vol
+          mountPath: /tmp
+      volumes:
+      - name: temp-vol
+        emptyDir: {}
 ---
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: Role
+apiVersion: v1
+kind: Service
 metadata:
-  name: cluster-autoscaler
-  namespace: kube-system
+  name: keda-metrics-apiserver
+  namespace: keda
   labels:
-    k8s-addon: cluster-autoscaler.addons.k8s.io
-    k8s-app: cluster-autoscaler
-    kubernetes.io/cluster-service: "true"
+    app.kubernetes.io/name: keda-metrics-apiserver
+    app.kubernetes.io/part-of: keda
     addonmanager.kubernetes.io/mode: Reconcile
-rules:
-- apiGroups: [""]
-  resources: ["configmaps"]
-  verbs: ["create"]
-- apiGroups: [""]
-  resources: ["configmaps"]
-  resourceNames: ["cluster-autoscaler-status"]
-  verbs: ["delete","get","update"]
+spec:
+  selector:
+    app: keda-metrics-apiserver
+  ports:
+  - name: https
+    port: 443
+    targetPort: 6443
+  - name: http
+    port: 80
+    targetPort: 8080
 ---
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: ClusterRoleBinding
+apiVersion: apiregistration.k8s.io/v1beta1
+kind: APIService
 metadata:
-  name: cluster-autoscaler
+  name: v1beta1.external.metrics.k8s.io
   labels:
-    k8s-addon: cluster-autoscaler.addons.k8s.io
-    k8s-app: cluster-autoscaler
-    kubernetes.io/cluster-service: "true"
+    app.kubernetes.io/name: keda-metrics-apiserver
+    app.kubernetes.io/part-of: keda
     addonmanager.kubernetes.io/mode: Reconcile
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: cluster-autoscaler
-subjects:
-  - kind: ServiceAccount
-    name: cluster-autoscaler
-    namespace: kube-system
----
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: RoleBinding
+spec:
+  service:
+    name: keda-metrics-apiserver
+    namespace: keda
+  group: external.metrics.k8s.io
+  version: v1beta1
+  # the metrics server serves a self-signed certificate
+  insecureSkipTLSVerify: true
+  groupPriorityMinimum: 100
+  versionPriority: 100
+`)
+
+func k8sContaineraddonsKubernetesmasteraddonsKedaDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsKedaDeploymentYaml, nil
+}
+
+func k8sContaineraddonsKubernetesmasteraddonsKedaDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsKedaDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-keda-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsKubernetesmasteraddonsKeyvaultFlexvolumeInstallerYaml = []byte(`apiVersion: extensions/v1beta1
+kind: DaemonSet
 metadata:
-  name: cluster-autoscaler
-  namespace: kube-system
   labels:
-    k8s-addon: cluster-autoscaler.addons.k8s.io
-    k8s-app: cluster-autoscaler
+    app: keyvault-flexvolume
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: Role
-  name: cluster-autoscaler
-subjects:
-  - kind: ServiceAccount
-    name: cluster-autoscaler
-    namespace: kube-system
----
-apiVersion: v1
-data:
-  ClientID: <clientID>
-  ClientSecret: <clientSec>
-  ResourceGroup: <rg>
-  SubscriptionID: <subID>
-  TenantID: <tenantID>
-  VMType: <vmType>
-kind: Secret
-metadata:
-  name: cluster-autoscaler-azure
+  name: keyvault-flexvolume
   namespace: kube-system
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
----
-apiVersion: extensions/v1beta1
-kind: Deployment
+spec:
+  updateStrategy:
+    type: RollingUpdate
+  template:
+    metadata:
+      labels:
+        app: keyvault-flexvolume
+        kubernetes.io/cluster-service: "true"
+        addonmanager.kubernetes.io/mode: Reconcile
+    spec:
+      tolerations:
+      containers:
+      - name: keyvault-flexvolume
+        image: {{ContainerImage "keyvault-flexvolume"}}
+        imagePullPolicy: IfNotPresent
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "keyvault-flexvolume"}}
+            memory: {{ContainerMemReqs "keyvault-flexvolume"}}
+          limits:
+            cpu: {{ContainerCPULimits "keyvault-flexvolume"}}
+            memory: {{ContainerMemLimits "keyvault-flexvolume"}}
+        env:
+        - name: TARGET_DIR
+          value: /etc/kubernetes/volumeplugins
+        volumeMounts:
+        - mountPath: /etc/kubernetes/volumeplugins
+          name: volplugins
+      volumes:
+      - hostPath:
+          path: /etc/kubernetes/volumeplugins
+        name: volplugins
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+`)
+
+func k8sContaineraddonsKubernetesmasteraddonsKeyvaultFlexvolumeInstallerYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsKeyvaultFlexvolumeInstallerYaml, nil
+}
+
+func k8sContaineraddonsKubernetesmasteraddonsKeyvaultFlexvolumeInstallerYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsKeyvaultFlexvolumeInstallerYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-keyvault-flexvolume-installer.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsKubernetesmasteraddonsKonnectivityAgentDaemonsetYaml = []byte(`apiVersion: apps/v1
+kind: DaemonSet
 metadata:
+  name: konnectivity-agent
+  namespace: kube-system
   labels:
-    app: cluster-autoscaler
+    k8s-app: konnectivity-agent
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
-  name: cluster-autoscaler
-  namespace: kube-system
 spec:
-  replicas: 1
   selector:
     matchLabels:
-      app: cluster-autoscaler
+      k8s-app: konnectivity-agent
+  updateStrategy:
+    type: RollingUpdate
   template:
     metadata:
       labels:
-        app: cluster-autoscaler
+        k8s-app: konnectivity-agent
     spec:
       priorityClassName: system-node-critical
-      <hostNet>
-      serviceAccountName: cluster-autoscaler
-      tolerations:
-      - effect: NoSchedule
-        operator: "Equal"
-        value: "true"
-        key: node-role.kubernetes.io/master
+      hostNetwork: true
       nodeSelector:
-        kubernetes.io/role: master
         beta.kubernetes.io/os: linux
+      tolerations:
+      - operator: Exists
       containers:
-      - image: {{ContainerImage "cluster-autoscaler"}}
+      - name: konnectivity-agent
+        image: {{ContainerImage "konnectivity-agent"}}
         imagePullPolicy: IfNotPresent
-        name: cluster-autoscaler
-        resources:
-          limits:
-            cpu: {{ContainerCPULimits "cluster-autoscaler"}}
-            memory: {{ContainerMemLimits "cluster-autoscaler"}}
-          requests:
-            cpu: {{ContainerCPUReqs "cluster-autoscaler"}}
-            memory: {{ContainerMemReqs "cluster-autoscaler"}}
         command:
-        - ./cluster-autoscaler
-        - --v=3
+        - /proxy-agent
+        args:
+        - --ca-cert=/etc/kubernetes/certs/ca.crt
+        - --agent-cert=/etc/kubernetes/certs/client.crt
+        - --agent-key=/etc/kubernetes/certs/client.key
+        - --proxy-server-host=<kubernetesAPIServerIP>
+        - --proxy-server-port=8132
         - --logtostderr=true
-        - --cloud-provider=azure
-        - --skip-nodes-with-local-storage=false
-        - --nodes={{ContainerConfig "min-nodes"}}:{{ContainerConfig "max-nodes"}}:<vmssName>
-        - --scan-interval={{ContainerConfig "scan-interval"}}
-        env:
-        - name: ARM_CLOUD
-          value: "<cloud>"
-        - name: ARM_SUBSCRIPTION_ID
-          valueFrom:
-            secretKeyRef:
-              key: SubscriptionID
-              name: cluster-autoscaler-azure
-        - name: ARM_RESOURCE_GROUP
-          valueFrom:
-            secretKeyRef:
-              key: ResourceGroup
-              name: cluster-autoscaler-azure
-        - name: ARM_TENANT_ID
-          valueFrom:
-            secretKeyRef:
-              key: TenantID
-              name: cluster-autoscaler-azure
-        - name: ARM_CLIENT_ID
-          valueFrom:
-            secretKeyRef:
-              key: ClientID
-              name: cluster-autoscaler-azure
-        - name: ARM_CLIENT_SECRET
-          valueFrom:
-            secretKeyRef:
-              key: ClientSecret
-              name: cluster-autoscaler-azure
-        - name: ARM_VM_TYPE
-          valueFrom:
-            secretKeyRef:
-              key: VMType
-              name: cluster-autoscaler-azure
-        - name: ARM_USE_MANAGED_IDENTITY_EXTENSION
-          value: "<useManagedIdentity>"
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "konnectivity-agent"}}
+            memory: {{ContainerMemReqs "konnectivity-agent"}}
+          limits:
+            cpu: {{ContainerCPULimits "konnectivity-agent"}}
+            memory: {{ContainerMemLimits "konnectivity-agent"}}
         volumeMounts:
-        - mountPath: /etc/ssl/certs/ca-certificates.crt
-          name: ssl-certs
+        - name: certs
+          mountPath: /etc/kubernetes/certs
           readOnly: true
-        <volMounts>
-      restartPolicy: Always
       volumes:
-      - hostPath:
-          path: /etc/ssl/certs/ca-certificates.crt
-          type: ""
-        name: ssl-certs
-      <vols>
-#EOF
+      - name: certs
+        hostPath:
+          path: /etc/kubernetes/certs
+`)
+
+func k8sContaineraddonsKubernetesmasteraddonsKonnectivityAgentDaemonsetYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsKonnectivityAgentDaemonsetYaml, nil
+}
+
+func k8sContaineraddonsKubernetesmasteraddonsKonnectivityAgentDaemonsetYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsKonnectivityAgentDaemonsetYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-konnectivity-agent-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsKubernetesmasteraddonsKubeReschedulerDeploymentYaml = []byte(`apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: rescheduler
+  namespace: kube-system
+  labels:
+    k8s-app: rescheduler
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      k8s-app: rescheduler
+  template:
+    metadata:
+      labels:
+        k8s-app: rescheduler
+      annotations:
+        scheduler.alpha.kubernetes.io/critical-pod: ''
+    spec:
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      containers:
+      - image: {{ContainerImage "rescheduler"}}
+        imagePullPolicy: IfNotPresent
+        name: rescheduler
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "rescheduler"}}
+            memory: {{ContainerMemReqs "rescheduler"}}
+          limits:
+            cpu: {{ContainerCPULimits "rescheduler"}}
+            memory: {{ContainerMemLimits "rescheduler"}}
+        command:
+        - sh
+        - -c
+        - '/rescheduler'
 `)
 
-func k8sContaineraddonsKubernetesmasteraddonsClusterAutoscalerDeploymentYamlBytes() ([]byte, error) {
-	return _k8sContaineraddonsKubernetesmasteraddonsClusterAutoscalerDeploymentYaml, nil
+func k8sContaineraddonsKubernetesmasteraddonsKubeReschedulerDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsKubeReschedulerDeploymentYaml, nil
 }
 
-func k8sContaineraddonsKubernetesmasteraddonsClusterAutoscalerDeploymentYaml() (*asset, error) {
-	bytes, err := k8sContaineraddonsKubernetesmasteraddonsClusterAutoscalerDeploymentYamlBytes()
+func k8sContaineraddonsKubernetesmasteraddonsKubeReschedulerDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsKubeReschedulerDeploymentYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-cluster-autoscaler-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-kube-rescheduler-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sContaineraddonsKubernetesmasteraddonsHeapsterDeploymentYaml = []byte(`apiVersion: v1
+var _k8sContaineraddonsKubernetesmasteraddonsKubernetesDashboardDeploymentYaml = []byte(`apiVersion: v1
 kind: ServiceAccount
 metadata:
-  name: heapster
-  namespace: kube-system
   labels:
+    k8s-app: kubernetes-dashboard
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
+  name: kubernetes-dashboard
+  namespace: kube-system
 ---
----
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: ClusterRole
+kind: Role
+apiVersion: rbac.authorization.k8s.io/v1
 metadata:
-  name: system:heapster-with-nanny
+  name: kubernetes-dashboard-minimal
+  namespace: kube-system
   labels:
+    k8s-app: kubernetes-dashboard
     kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
+    addonmanager.kubernetes.io/mode: Reconcile
 rules:
-- apiGroups:
-  - extensions
-  - apps
-  resources:
-  - deployments
-  verbs:
-  - get
-  - list
-  - watch
-  - update
-  - patch
-- apiGroups:
-  - ""
-  resources:
-  - events
-  - namespaces
-  - nodes
-  - pods
-  verbs:
-  - get
-  - list
-  - watch
+- apiGroups: [""]
+  resources: ["secrets"]
+  verbs: ["create"]
+- apiGroups: [""]
+  resources: ["configmaps"]
+  verbs: ["create"]
+- apiGroups: [""]
+  resources: ["secrets"]
+  resourceNames: ["kubernetes-dashboard-key-holder"]
+  verbs: ["get", "update", "delete"]
+- apiGroups: [""]
+  resources: ["configmaps"]
+  resourceNames: ["kubernetes-dashboard-settings"]
+  verbs: ["get", "update"]
+- apiGroups: [""]
+  resources: ["services"]
+  resourceNames: ["heapster"]
+  verbs: ["proxy"]
+- apiGroups: [""]
+  resources: ["services/proxy"]
+  resourceNames: ["heapster", "http:heapster:", "https:heapster:"]
+  verbs: ["get"]
 ---
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: ClusterRoleBinding
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
 metadata:
-  name: system:heapster-with-nanny
+  name: kubernetes-dashboard-minimal
+  namespace: kube-system
   labels:
+    k8s-app: kubernetes-dashboard
     kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
+    addonmanager.kubernetes.io/mode: Reconcile
 roleRef:
   apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: system:heapster-with-nanny
+  kind: Role
+  name: kubernetes-dashboard-minimal
 subjects:
 - kind: ServiceAccount
-  name: heapster
-  namespace: kube-system
----
-apiVersion: v1
-kind: ConfigMap
-metadata:
-  name: heapster-config
+  name: kubernetes-dashboard
   namespace: kube-system
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
-data:
-  NannyConfiguration: |-
-    apiVersion: nannyconfig/v1alpha1
-    kind: NannyConfiguration
 ---
 apiVersion: v1
 kind: Service
 metadata:
   labels:
     kubernetes.io/cluster-service: "true"
-    kubernetes.io/name: Heapster
-  name: heapster
+    k8s-app: kubernetes-dashboard
+  name: kubernetes-dashboard
   namespace: kube-system
 spec:
   ports:
-  - port: 80
-    targetPort: 8082
+  - port: 443
+    targetPort: 8443
   selector:
-    k8s-app: heapster
+    k8s-app: kubernetes-dashboard
+  type: NodePort
 ---
 apiVersion: extensions/v1beta1
 kind: Deployment
 metadata:
-  name: heapster
-  namespace: kube-system
   labels:
-    k8s-app: heapster
     kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: EnsureExists
+    addonmanager.kubernetes.io/mode: Reconcile
+    k8s-app: kubernetes-dashboard
+  name: kubernetes-dashboard
+  namespace: kube-system
 spec:
   replicas: 1
   selector:
     matchLabels:
-      k8s-app: heapster
-  template:
-    metadata:
-      labels:
-        k8s-app: heapster
-      annotations:
-        scheduler.alpha.kubernetes.io/critical-pod: ''
-    spec:
-      priorityClassName: system-node-critical
-      containers:
-        - image: {{ContainerImage "heapster"}}
-          imagePullPolicy: IfNotPresent
-          name: heapster
-          resources:
-            requests:
-              cpu: {{ContainerCPUReqs "heapster"}}
-              memory: {{ContainerMemReqs "heapster"}}
-            limits:
-              cpu: {{ContainerCPULimits "heapster"}}
-              memory: {{ContainerMemLimits "heapster"}}
-          livenessProbe:
-            httpGet:
-              path: /healthz
-              port: 8082
-              scheme: HTTP
-            initialDelaySeconds: 180
-            timeoutSeconds: 5
-          command:
-            - /heapster
-            - --source=kubernetes.summary_api:''
-        - image: {{ContainerImage "heapster-nanny"}}
-          imagePullPolicy: IfNotPresent
-          name: heapster-nanny
-          resources:
-            requests:
-              cpu: {{ContainerCPUReqs "heapster-nanny"}}
-              memory: {{ContainerMemReqs "heapster-nanny"}}
-            limits:
-              cpu: {{ContainerCPULimits "heapster-nanny"}}
-              memory: {{ContainerMemLimits "heapster-nanny"}}
-          env:
-            - name: MY_POD_NAME
-              valueFrom:
-                fieldRef:
-                  fieldPath: metadata.name
-            - name: MY_POD_NAMESPACE
-              valueFrom:
-                fieldRef:
-                  fieldPath: metadata.namespace
-          volumeMounts:
-          - name: heapster-config-volume
-            mountPath: /etc/config
-          command:
-            - /pod_nanny
-            - --config-dir=/etc/config
-            - --cpu=80m
-            - --extra-cpu=0.5m
-            - --memory=140Mi
-            - --extra-memory=4Mi
-            - --threshold=5
-            - --deployment=heapster
-            - --container=heapster
-            - --poll-period=300000
-            - --estimator=exponential
-      volumes:
-        - name: heapster-config-volume
-          configMap:
-            name: heapster-config
-      serviceAccountName: heapster
-      tolerations:
-        - key: CriticalAddonsOnly
-          operator: Exists
-      nodeSelector:
-        beta.kubernetes.io/os: linux
-`)
-
-func k8sContaineraddonsKubernetesmasteraddonsHeapsterDeploymentYamlBytes() ([]byte, error) {
-	return _k8sContaineraddonsKubernetesmasteraddonsHeapsterDeploymentYaml, nil
-}
-
-func k8sContaineraddonsKubernetesmasteraddonsHeapsterDeploymentYaml() (*asset, error) {
-	bytes, err := k8sContaineraddonsKubernetesmasteraddonsHeapsterDeploymentYamlBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-heapster-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _k8sContaineraddonsKubernetesmasteraddonsKeyvaultFlexvolumeInstallerYaml = []byte(`apiVersion: extensions/v1beta1
-kind: DaemonSet
-metadata:
-  labels:
-    app: keyvault-flexvolume
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-  name: keyvault-flexvolume
-  namespace: kube-system
-spec:
-  updateStrategy:
-    type: RollingUpdate
+      k8s-app: kubernetes-dashboard
   template:
     metadata:
       labels:
-        app: keyvault-flexvolume
-        kubernetes.io/cluster-service: "true"
-        addonmanager.kubernetes.io/mode: Reconcile
+        k8s-app: kubernetes-dashboard
     spec:
-      tolerations:
       containers:
-      - name: keyvault-flexvolume
-        image: {{ContainerImage "keyvault-flexvolume"}}
+      - args:
+        - --auto-generate-certificates
+        - --heapster-host=http://heapster.kube-system:80
+        image: {{ContainerImage "kubernetes-dashboard"}}
         imagePullPolicy: IfNotPresent
+        livenessProbe:
+          httpGet:
+            path: "/"
+            port: 8443
+            scheme: HTTPS
+          initialDelaySeconds: 30
+          timeoutSeconds: 30
+        name: kubernetes-dashboard
+        ports:
+        - containerPort: 8443
+          protocol: TCP
         resources:
           requests:
-            cpu: {{ContainerCPUReqs "keyvault-flexvolume"}}
-            memory: {{ContainerMemReqs "keyvault-flexvolume"}}
+            cpu: {{ContainerCPUReqs "kubernetes-dashboard"}}
+            memory: {{ContainerMemReqs "kubernetes-dashboard"}}
           limits:
-            cpu: {{ContainerCPULimits "keyvault-flexvolume"}}
-            memory: {{ContainerMemLimits "keyvault-flexvolume"}}
-        env:
-        - name: TARGET_DIR
-          value: /etc/kubernetes/volumeplugins
+            cpu: {{ContainerCPULimits "kubernetes-dashboard"}}
+            memory: {{ContainerMemLimits "kubernetes-dashboard"}}
         volumeMounts:
-        - mountPath: /etc/kubernetes/volumeplugins
-          name: volplugins
+         - name: kubernetes-dashboard-certs
+           mountPath: /certs
       volumes:
-      - hostPath:
-          path: /etc/kubernetes/volumeplugins
-        name: volplugins
+        - name: kubernetes-dashboard-certs
+          emptyDir: {}
+      serviceAccountName: kubernetes-dashboard
       nodeSelector:
         beta.kubernetes.io/os: linux
 `)
 
-func k8sContaineraddonsKubernetesmasteraddonsKeyvaultFlexvolumeInstallerYamlBytes() ([]byte, error) {
-	return _k8sContaineraddonsKubernetesmasteraddonsKeyvaultFlexvolumeInstallerYaml, nil
+func k8sContaineraddonsKubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsKubernetesDashboardDeploymentYaml, nil
 }
 
-func k8sContaineraddonsKubernetesmasteraddonsKeyvaultFlexvolumeInstallerYaml() (*asset, error) {
-	bytes, err := k8sContaineraddonsKubernetesmasteraddonsKeyvaultFlexvolumeInstallerYamlBytes()
+func k8sContaineraddonsKubernetesmasteraddonsKubernetesDashboardDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-keyvault-flexvolume-installer.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-kubernetes-dashboard-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sContaineraddonsKubernetesmasteraddonsKubeReschedulerDeploymentYaml = []byte(`apiVersion: extensions/v1beta1
+var _k8sContaineraddonsKubernetesmasteraddonsMetricsServerDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: metrics-server
+  namespace: kube-system
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: system:metrics-server
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - pods
+  - nodes
+  - nodes/stats
+  - namespaces
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - "extensions"
+  resources:
+  - deployments
+  verbs:
+  - get
+  - list
+  - watch
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: system:metrics-server
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: system:metrics-server
+subjects:
+- kind: ServiceAccount
+  name: metrics-server
+  namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: RoleBinding
+metadata:
+  name: metrics-server-auth-reader
+  namespace: kube-system
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: extension-apiserver-authentication-reader
+subjects:
+- kind: ServiceAccount
+  name: metrics-server
+  namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1beta1
+kind: ClusterRoleBinding
+metadata:
+  name: metrics-server:system:auth-delegator
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: system:auth-delegator
+subjects:
+- kind: ServiceAccount
+  name: metrics-server
+  namespace: kube-system
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: metrics-server
+  namespace: kube-system
+  labels:
+    addonmanager.kubernetes.io/mode: Reconcile
+    kubernetes.io/name: "Metrics-server"
+    kubernetes.io/cluster-service: "true"
+spec:
+  selector:
+    k8s-app: metrics-server
+  ports:
+  - port: 443
+    protocol: TCP
+    targetPort: 443
+---
+apiVersion: extensions/v1beta1
 kind: Deployment
 metadata:
-  name: rescheduler
+  name: metrics-server
   namespace: kube-system
   labels:
-    k8s-app: rescheduler
+    k8s-app: metrics-server
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
 spec:
-  replicas: 1
   selector:
     matchLabels:
-      k8s-app: rescheduler
+      k8s-app: metrics-server
   template:
     metadata:
+      name: metrics-server
       labels:
-        k8s-app: rescheduler
-      annotations:
-        scheduler.alpha.kubernetes.io/critical-pod: ''
+        k8s-app: metrics-server
     spec:
-      nodeSelector:
-        beta.kubernetes.io/os: linux
+      serviceAccountName: metrics-server
       containers:
-      - image: {{ContainerImage "rescheduler"}}
+      - name: metrics-server
+        image: {{ContainerImage "metrics-server"}}
         imagePullPolicy: IfNotPresent
-        name: rescheduler
+{{- if ContainerCPUReqs "metrics-server"}}
         resources:
           requests:
-            cpu: {{ContainerCPUReqs "rescheduler"}}
-            memory: {{ContainerMemReqs "rescheduler"}}
+            cpu: {{ContainerCPUReqs "metrics-server"}}
+            memory: {{ContainerMemReqs "metrics-server"}}
           limits:
-            cpu: {{ContainerCPULimits "rescheduler"}}
-            memory: {{ContainerMemLimits "rescheduler"}}
+            cpu: {{ContainerCPULimits "metrics-server"}}
+            memory: {{ContainerMemLimits "metrics-server"}}
+{{- end}}
         command:
-        - sh
-        - -c
-        - '/rescheduler'
+        - /metrics-server
+        - --source=kubernetes.summary_api:''
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+---
+apiVersion: apiregistration.k8s.io/v1beta1
+kind: APIService
+metadata:
+  name: v1beta1.metrics.k8s.io
+  labels:
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  service:
+    name: metrics-server
+    namespace: kube-system
+  group: metrics.k8s.io
+  version: v1beta1
+  insecureSkipTLSVerify: true
+  groupPriorityMinimum: 100
+  versionPriority: 100
 `)
 
-func k8sContaineraddonsKubernetesmasteraddonsKubeReschedulerDeploymentYamlBytes() ([]byte, error) {
-	return _k8sContaineraddonsKubernetesmasteraddonsKubeReschedulerDeploymentYaml, nil
+func k8sContaineraddonsKubernetesmasteraddonsMetricsServerDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsMetricsServerDeploymentYaml, nil
 }
 
-func k8sContaineraddonsKubernetesmasteraddonsKubeReschedulerDeploymentYaml() (*asset, error) {
-	bytes, err := k8sContaineraddonsKubernetesmasteraddonsKubeReschedulerDeploymentYamlBytes()
+func k8sContaineraddonsKubernetesmasteraddonsMetricsServerDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsMetricsServerDeploymentYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-kube-rescheduler-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-metrics-server-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sContaineraddonsKubernetesmasteraddonsKubernetesDashboardDeploymentYaml = []byte(`apiVersion: v1
+var _k8sContaineraddonsKubernetesmasteraddonsNginxIngressDeploymentYaml = []byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: ingress-nginx
+  labels:
+    app.kubernetes.io/name: ingress-nginx
+    app.kubernetes.io/part-of: ingress-nginx
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+kind: ConfigMap
+apiVersion: v1
+metadata:
+  name: nginx-configuration
+  namespace: ingress-nginx
+  labels:
+    app.kubernetes.io/name: ingress-nginx
+    app.kubernetes.io/part-of: ingress-nginx
+    addonmanager.kubernetes.io/mode: EnsureExists
+---
+kind: ConfigMap
+apiVersion: v1
+metadata:
+  name: tcp-services
+  namespace: ingress-nginx
+  labels:
+    app.kubernetes.io/name: ingress-nginx
+    app.kubernetes.io/part-of: ingress-nginx
+    addonmanager.kubernetes.io/mode: EnsureExists
+---
+kind: ConfigMap
+apiVersion: v1
+metadata:
+  name: udp-services
+  namespace: ingress-nginx
+  labels:
+    app.kubernetes.io/name: ingress-nginx
+    app.kubernetes.io/part-of: ingress-nginx
+    addonmanager.kubernetes.io/mode: EnsureExists
+---
+apiVersion: v1
 kind: ServiceAccount
 metadata:
+  name: nginx-ingress-serviceaccount
+  namespace: ingress-nginx
   labels:
-    k8s-app: kubernetes-dashboard
-    kubernetes.io/cluster-service: "true"
+    app.kubernetes.io/name: ingress-nginx
+    app.kubernetes.io/part-of: ingress-nginx
     addonmanager.kubernetes.io/mode: Reconcile
-  name: kubernetes-dashboard
-  namespace: kube-system
 ---
-kind: Role
 apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
 metadata:
-  name: kubernetes-dashboard-minimal
-  namespace: kube-system
+  name: nginx-ingress-clusterrole
   labels:
-    k8s-app: kubernetes-dashboard
-    kubernetes.io/cluster-service: "true"
+    app.kubernetes.io/name: ingress-nginx
+    app.kubernetes.io/part-of: ingress-nginx
     addonmanager.kubernetes.io/mode: Reconcile
 rules:
 - apiGroups: [""]
-  resources: ["secrets"]
-  verbs: ["create"]
+  resources: ["configmaps", "endpoints", "nodes", "pods", "secrets"]
+  verbs: ["list", "watch"]
 - apiGroups: [""]
-  resources: ["configmaps"]
-  verbs: ["create"]
+  resources: ["nodes"]
+  verbs: ["get"]
+- apiGroups: [""]
+  resources: ["services"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: [""]
+  resources: ["events"]
+  verbs: ["create", "patch"]
+- apiGroups: ["extensions", "networking.k8s.io"]
+  resources: ["ingresses"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: ["extensions", "networking.k8s.io"]
+  resources: ["ingresses/status"]
+  verbs: ["update"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: nginx-ingress-role
+  namespace: ingress-nginx
+  labels:
+    app.kubernetes.io/name: ingress-nginx
+    app.kubernetes.io/part-of: ingress-nginx
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
 - apiGroups: [""]
-  resources: ["secrets"]
-  resourceNames: ["kubernetes-dashboard-key-holder"]
-  verbs: ["get", "update", "delete"]
+  resources: ["configmaps", "pods", "secrets", "namespaces"]
+  verbs: ["get"]
 - apiGroups: [""]
   resources: ["configmaps"]
-  resourceNames: ["kubernetes-dashboard-settings"]
+  # the leader election configmap of the ingress controller, <election-id>-<ingress-class>
+  resourceNames: ["ingress-controller-leader-nginx"]
   verbs: ["get", "update"]
 - apiGroups: [""]
-  resources: ["services"]
-  resourceNames: ["heapster"]
-  verbs: ["proxy"]
+  resources: ["configmaps"]
+  verbs: ["create"]
 - apiGroups: [""]
-  resources: ["services/proxy"]
-  resourceNames: ["heapster", "http:heapster:", "https:heapster:"]
+  resources: ["endpoints"]
   verbs: ["get"]
 ---
 apiVersion: rbac.authorization.k8s.io/v1
 kind: RoleBinding
 metadata:
-  name: kubernetes-dashboard-minimal
-  namespace: kube-system
+  name: nginx-ingress-role-nisa-binding
+  namespace: ingress-nginx
   labels:
-    k8s-app: kubernetes-dashboard
-    kubernetes.io/cluster-service: "true"
+    app.kubernetes.io/name: ingress-nginx
+    app.kubernetes.io/part-of: ingress-nginx
     addonmanager.kubernetes.io/mode: Reconcile
 roleRef:
   apiGroup: rbac.authorization.k8s.io
   kind: Role
-  name: kubernetes-dashboard-minimal
+  name: nginx-ingress-role
 subjects:
 - kind: ServiceAccount
-  name: kubernetes-dashboard
-  namespace: kube-system
+  name: nginx-ingress-serviceaccount
+  namespace: ingress-nginx
 ---
-apiVersion: v1
-kind: Service
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
 metadata:
+  name: nginx-ingress-clusterrole-nisa-binding
   labels:
-    kubernetes.io/cluster-service: "true"
-    k8s-app: kubernetes-dashboard
-  name: kubernetes-dashboard
-  namespace: kube-system
-spec:
-  ports:
-  - port: 443
-    targetPort: 8443
-  selector:
-    k8s-app: kubernetes-dashboard
-  type: NodePort
+    app.kubernetes.io/name: ingress-nginx
+    app.kubernetes.io/part-of: ingress-nginx
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: nginx-ingress-clusterrole
+subjects:
+- kind: ServiceAccount
+  name: nginx-ingress-serviceaccount
+  namespace: ingress-nginx
 ---
-apiVersion: extensions/v1beta1
+apiVersion: apps/v1
 kind: Deployment
 metadata:
+  name: nginx-ingress-controller
+  namespace: ingress-nginx
   labels:
-    kubernetes.io/cluster-service: "true"
+    app.kubernetes.io/name: ingress-nginx
+    app.kubernetes.io/part-of: ingress-nginx
     addonmanager.kubernetes.io/mode: Reconcile
-    k8s-app: kubernetes-dashboard
-  name: kubernetes-dashboard
-  namespace: kube-system
 spec:
-  replicas: 1
+  replicas: {{ContainerConfig "replicas"}}
   selector:
     matchLabels:
-      k8s-app: kubernetes-dashboard
+      app.kubernetes.io/name: ingress-nginx
+      app.kubernetes.io/part-of: ingress-nginx
   template:
     metadata:
       labels:
-        k8s-app: kubernetes-dashboard
+        app.kubernetes.io/name: ingress-nginx
+        app.kubernetes.io/part-of: ingress-nginx
+      annotations:
+        prometheus.io/port: "10254"
+        prometheus.io/scrape: "true"
     spec:
+      serviceAccountName: nginx-ingress-serviceaccount
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+          - weight: 100
+            podAffinityTerm:
+              topologyKey: kubernetes.io/hostname
+              labelSelector:
+                matchLabels:
+                  app.kubernetes.io/name: ingress-nginx
       containers:
-      - args:
-        - --auto-generate-certificates
-        - --heapster-host=http://heapster.kube-system:80
-        image: {{ContainerImage "kubernetes-dashboard"}}
+      - name: nginx-ingress-controller
+        image: {{ContainerImage "nginx-ingress"}}
         imagePullPolicy: IfNotPresent
+        args:
+        - /nginx-ingress-controller
+        - --configmap=$(POD_NAMESPACE)/nginx-configuration
+        - --tcp-services-configmap=$(POD_NAMESPACE)/tcp-services
+        - --udp-services-configmap=$(POD_NAMESPACE)/udp-services
+        - --publish-service=$(POD_NAMESPACE)/ingress-nginx
+        - --annotations-prefix=nginx.ingress.kubernetes.io
+        securityContext:
+          allowPrivilegeEscalation: true
+          capabilities:
+            drop:
+            - ALL
+            add:
+            - NET_BIND_SERVICE
+          # www-data
+          runAsUser: 33
+        env:
+        - name: POD_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.name
+        - name: POD_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+        ports:
+        - name: http
+          containerPort: 80
+        - name: https
+          containerPort: 443
         livenessProbe:
+          failureThreshold: 3
           httpGet:
-            path: "/"
-            port: 8443
-            scheme: HTTPS
-          initialDelaySeconds: 30
-          timeoutSeconds: 30
-        name: kubernetes-dashboard
-        ports:
-        - containerPort: 8443
-          protocol: TCP
+            path: /healthz
+            port: 10254
+            scheme: HTTP
+          initialDelaySeconds: 10
+          periodSeconds: 10
+          successThreshold: 1
+          timeoutSeconds: 10
+        readinessProbe:
+          failureThreshold: 3
+          httpGet:
+            path: /healthz
+            port: 10254
+            scheme: HTTP
+          periodSeconds: 10
+          successThreshold: 1
+          timeoutSeconds: 10
         resources:
           requests:
-            cpu: {{ContainerCPUReqs "kubernetes-dashboard"}}
-            memory: {{ContainerMemReqs "kubernetes-dashboard"}}
+            cpu: {{ContainerCPUReqs "nginx-ingress"}}
+            memory: {{ContainerMemReqs "nginx-ingress"}}
           limits:
-            cpu: {{ContainerCPULimits "kubernetes-dashboard"}}
-            memory: {{ContainerMemLimits "kubernetes-dashboard"}}
-        volumeMounts:
-         - name: kubernetes-dashboard-certs
-           mountPath: /certs
-      volumes:
-        - name: kubernetes-dashboard-certs
-          emptyDir: {}
-      serviceAccountName: kubernetes-dashboard
-      nodeSelector:
-        beta.kubernetes.io/os: linux
+            cpu: {{ContainerCPULimits "nginx-ingress"}}
+            memory: {{ContainerMemLimits "nginx-ingress"}}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: ingress-nginx
+  namespace: ingress-nginx
+  labels:
+    app.kubernetes.io/name: ingress-nginx
+    app.kubernetes.io/part-of: ingress-nginx
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  type: {{ContainerConfig "serviceType"}}
+{{- if eq (ContainerConfig "serviceType") "LoadBalancer"}}
+  # keep the client IPs of the requests
+  externalTrafficPolicy: Local
+{{- end}}
+  selector:
+    app.kubernetes.io/name: ingress-nginx
+    app.kubernetes.io/part-of: ingress-nginx
+  ports:
+  - name: http
+    port: 80
+    targetPort: http
+  - name: https
+    port: 443
+    targetPort: https
 `)
 
-func k8sContaineraddonsKubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes() ([]byte, error) {
-	return _k8sContaineraddonsKubernetesmasteraddonsKubernetesDashboardDeploymentYaml, nil
+func k8sContaineraddonsKubernetesmasteraddonsNginxIngressDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsNginxIngressDeploymentYaml, nil
 }
 
-func k8sContaineraddonsKubernetesmasteraddonsKubernetesDashboardDeploymentYaml() (*asset, error) {
-	bytes, err := k8sContaineraddonsKubernetesmasteraddonsKubernetesDashboardDeploymentYamlBytes()
+func k8sContaineraddonsKubernetesmasteraddonsNginxIngressDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsNginxIngressDeploymentYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-kubernetes-dashboard-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-nginx-ingress-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _k8sContaineraddonsKubernetesmasteraddonsMetricsServerDeploymentYaml = []byte(`apiVersion: v1
+var _k8sContaineraddonsKubernetesmasteraddonsNodeProblemDetectorDaemonsetYaml = []byte(`apiVersion: v1
 kind: ServiceAccount
 metadata:
-  name: metrics-server
+  name: node-problem-detector
   namespace: kube-system
   labels:
+    k8s-app: node-problem-detector
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
 ---
 apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRole
 metadata:
-  name: system:metrics-server
+  name: node-problem-detector
   labels:
+    k8s-app: node-problem-detector
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
 rules:
-- apiGroups:
-  - ""
-  resources:
-  - pods
-  - nodes
-  - nodes/stats
-  - namespaces
-  verbs:
-  - get
-  - list
-  - watch
-- apiGroups:
-  - "extensions"
-  resources:
-  - deployments
-  verbs:
-  - get
-  - list
-  - watch
+- apiGroups: [""]
+  resources: ["nodes"]
+  verbs: ["get"]
+- apiGroups: [""]
+  resources: ["nodes/status"]
+  verbs: ["patch"]
+- apiGroups: [""]
+  resources: ["events"]
+  verbs: ["create", "patch", "update"]
 ---
 apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRoleBinding
 metadata:
-  name: system:metrics-server
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: system:metrics-server
-subjects:
-- kind: ServiceAccount
-  name: metrics-server
-  namespace: kube-system
----
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: RoleBinding
-metadata:
-  name: metrics-server-auth-reader
-  namespace: kube-system
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: Role
-  name: extension-apiserver-authentication-reader
-subjects:
-- kind: ServiceAccount
-  name: metrics-server
-  namespace: kube-system
----
-apiVersion: rbac.authorization.k8s.io/v1beta1
-kind: ClusterRoleBinding
-metadata:
-  name: metrics-server:system:auth-delegator
+  name: node-problem-detector
   labels:
+    k8s-app: node-problem-detector
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
 roleRef:
   apiGroup: rbac.authorization.k8s.io
   kind: ClusterRole
-  name: system:auth-delegator
+  name: node-problem-detector
 subjects:
 - kind: ServiceAccount
-  name: metrics-server
+  name: node-problem-detector
   namespace: kube-system
 ---
 apiVersion: v1
-kind: Service
+kind: ConfigMap
 metadata:
-  name: metrics-server
+  name: node-problem-detector-custom-config
   namespace: kube-system
   labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-    kubernetes.io/name: "Metrics-server"
+    k8s-app: node-problem-detector
     kubernetes.io/cluster-service: "true"
-spec:
-  selector:
-    k8s-app: metrics-server
-  ports:
-  - port: 443
-    protocol: TCP
-    targetPort: 443
+    addonmanager.kubernetes.io/mode: Reconcile
+{{- $hasCustomConfigs := false}}
+{{- range $name, $config := .Config}}{{if and (ne $name "systemLogMonitors") (ne $name "customPluginMonitors")}}{{$hasCustomConfigs = true}}{{end}}{{end}}
+{{- if $hasCustomConfigs}}
+binaryData:
+{{- range $name, $config := .Config}}{{if and (ne $name "systemLogMonitors") (ne $name "customPluginMonitors")}}
+  {{$name}}: {{$config}}
+{{- end}}{{end}}
+{{- end}}
 ---
-apiVersion: extensions/v1beta1
-kind: Deployment
+apiVersion: apps/v1
+kind: DaemonSet
 metadata:
-  name: metrics-server
+  name: node-problem-detector
   namespace: kube-system
   labels:
-    k8s-app: metrics-server
+    k8s-app: node-problem-detector
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
 spec:
   selector:
     matchLabels:
-      k8s-app: metrics-server
+      k8s-app: node-problem-detector
+  updateStrategy:
+    type: RollingUpdate
   template:
     metadata:
-      name: metrics-server
       labels:
-        k8s-app: metrics-server
+        k8s-app: node-problem-detector
     spec:
-      serviceAccountName: metrics-server
+      priorityClassName: system-node-critical
+      serviceAccountName: node-problem-detector
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      tolerations:
+      - operator: Exists
       containers:
-      - name: metrics-server
-        image: {{ContainerImage "metrics-server"}}
+      - name: node-problem-detector
+        image: {{ContainerImage "node-problem-detector"}}
         imagePullPolicy: IfNotPresent
         command:
-        - /metrics-server
-        - --source=kubernetes.summary_api:''
-      nodeSelector:
-        beta.kubernetes.io/os: linux
----
-apiVersion: apiregistration.k8s.io/v1beta1
-kind: APIService
-metadata:
-  name: v1beta1.metrics.k8s.io
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-spec:
-  service:
-    name: metrics-server
-    namespace: kube-system
-  group: metrics.k8s.io
-  version: v1beta1
-  insecureSkipTLSVerify: true
-  groupPriorityMinimum: 100
-  versionPriority: 100
+        - /node-problem-detector
+        - --logtostderr
+{{- if ContainerConfig "systemLogMonitors"}}
+        - --config.system-log-monitor={{ContainerConfig "systemLogMonitors"}}
+{{- end}}
+{{- if ContainerConfig "customPluginMonitors"}}
+        - --config.custom-plugin-monitor={{ContainerConfig "customPluginMonitors"}}
+{{- end}}
+        securityContext:
+          privileged: true
+        env:
+        - name: NODE_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: spec.nodeName
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "node-problem-detector"}}
+            memory: {{ContainerMemReqs "node-problem-detector"}}
+          limits:
+            cpu: {{ContainerCPULimits "node-problem-detector"}}
+            memory: {{ContainerMemLimits "node-problem-detector"}}
+        volumeMounts:
+        - name: log
+          mountPath: /var/log
+          readOnly: true
+        - name: kmsg
+          mountPath: /dev/kmsg
+          readOnly: true
+        - name: localtime
+          mountPath: /etc/localtime
+          readOnly: true
+        - name: custom-config
+          mountPath: /custom-config
+          readOnly: true
+      volumes:
+      - name: log
+        hostPath:
+          path: /var/log
+      - name: kmsg
+        hostPath:
+          path: /dev/kmsg
+      - name: localtime
+        hostPath:
+          path: /etc/localtime
+          type: FileOrCreate
+      - name: custom-config
+        configMap:
+          name: node-problem-detector-custom-config
 `)
 
-func k8sContaineraddonsKubernetesmasteraddonsMetricsServerDeploymentYamlBytes() ([]byte, error) {
-	return _k8sContaineraddonsKubernetesmasteraddonsMetricsServerDeploymentYaml, nil
+func k8sContaineraddonsKubernetesmasteraddonsNodeProblemDetectorDaemonsetYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsNodeProblemDetectorDaemonsetYaml, nil
 }
 
-func k8sContaineraddonsKubernetesmasteraddonsMetricsServerDeploymentYaml() (*asset, error) {
-	bytes, err := k8sContaineraddonsKubernetesmasteraddonsMetricsServerDeploymentYamlBytes()
+func k8sContaineraddonsKubernetesmasteraddonsNodeProblemDetectorDaemonsetYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsNodeProblemDetectorDaemonsetYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-metrics-server-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-node-problem-detector-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -22719,125 +25596,515 @@ spec:
 apiVersion: extensions/v1beta1
 kind: Deployment
 metadata:
-  name: omsagent-rs
+  name: omsagent-rs
+  namespace: kube-system
+  labels:
+    component: oms-agent
+    tier: node
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      rsName: omsagent-rs
+  strategy:
+    type: RollingUpdate
+  template:
+    metadata:
+      labels:
+        rsName: omsagent-rs
+      annotations:
+        agentVersion: {{ContainerConfig "omsAgentVersion"}}
+        dockerProviderVersion: {{ContainerConfig "dockerProviderVersion"}}
+        schema-versions:  {{ContainerConfig "schema-versions"}}
+    spec:
+      serviceAccountName: omsagent
+      containers:
+        - name: omsagent
+          image: {{ContainerImage "omsagent"}}
+          imagePullPolicy: IfNotPresent
+          resources:
+            limits:
+              cpu: {{ContainerCPULimits "omsagent"}}
+              memory: {{ContainerMemLimits "omsagent"}}
+            requests:
+              cpu: {{ContainerCPUReqs "omsagent"}}
+              memory: {{ContainerMemReqs "omsagent"}}
+          env:
+            - name: NODE_IP
+              valueFrom:
+                fieldRef:
+                  fieldPath: status.hostIP
+            - name: ACS_RESOURCE_NAME
+              value: {{ContainerConfig "clusterName"}}            
+            - name: CONTROLLER_TYPE
+              value: "ReplicaSet"
+            - name: ISTEST
+              value: "true"
+          securityContext:
+            privileged: true
+          ports:
+            - containerPort: 25225
+              protocol: TCP
+            - containerPort: 25224
+              protocol: UDP
+          volumeMounts:
+            - mountPath: /var/run/host
+              name: docker-sock
+            - mountPath: /var/log
+              name: host-log
+            - mountPath: /var/lib/docker/containers
+              name: containerlog-path
+            - mountPath: /etc/kubernetes/host
+              name: azure-json-path
+            - mountPath: /etc/omsagent-secret
+              name: omsagent-secret
+              readOnly: true
+            - mountPath : /etc/config
+              name: omsagent-rs-config
+            - mountPath: /etc/config/settings
+              name: settings-vol-config              
+          livenessProbe:
+            exec:
+              command:
+                - /bin/bash
+                - -c
+                - /opt/livenessprobe.sh
+            initialDelaySeconds: 60
+            periodSeconds: 60
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+        kubernetes.io/role: agent
+      volumes:
+        - name: docker-sock
+          hostPath:
+            path: /var/run
+        - name: container-hostname
+          hostPath:
+            path: /etc/hostname
+        - name: host-log
+          hostPath:
+            path: /var/log
+        - name: containerlog-path
+          hostPath:
+            path: /var/lib/docker/containers
+        - name: azure-json-path
+          hostPath:
+            path: /etc/kubernetes
+        - name: omsagent-secret
+          secret:
+            secretName: omsagent-secret
+        - name: omsagent-rs-config
+          configMap:
+            name: omsagent-rs-config
+        - name: settings-vol-config
+          configMap:
+            name: container-azm-ms-agentconfig
+            optional: true    
+`)
+
+func k8sContaineraddonsKubernetesmasteraddonsOmsagentDaemonsetYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsOmsagentDaemonsetYaml, nil
+}
+
+func k8sContaineraddonsKubernetesmasteraddonsOmsagentDaemonsetYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsOmsagentDaemonsetYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-omsagent-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsKubernetesmasteraddonsOpenServiceMeshDeploymentYaml = []byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: osm-system
+  labels:
+    app: osm-controller
+    addonmanager.kubernetes.io/mode: Reconcile
+# the missing namespaces of the mesh are created enrolled in it, the master CSE enrolls the existing ones
+{{- range OpenServiceMeshNamespaces}}
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{.}}
+  labels:
+    openservicemesh.io/monitored-by: osm
+    addonmanager.kubernetes.io/mode: EnsureExists
+  annotations:
+    openservicemesh.io/sidecar-injection: enabled
+{{- end}}
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: traffictargets.access.smi-spec.io
+  labels:
+    app.kubernetes.io/name: openservicemesh.io
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: access.smi-spec.io
+  names:
+    kind: TrafficTarget
+    listKind: TrafficTargetList
+    plural: traffictargets
+    singular: traffictarget
+    shortNames:
+    - tt
+  scope: Namespaced
+  versions:
+  - name: v1alpha3
+    served: true
+    storage: true
+  - name: v1alpha2
+    served: true
+    storage: false
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: httproutegroups.specs.smi-spec.io
+  labels:
+    app.kubernetes.io/name: openservicemesh.io
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: specs.smi-spec.io
+  names:
+    kind: HTTPRouteGroup
+    listKind: HTTPRouteGroupList
+    plural: httproutegroups
+    singular: httproutegroup
+    shortNames:
+    - htr
+  scope: Namespaced
+  versions:
+  - name: v1alpha4
+    served: true
+    storage: true
+  - name: v1alpha3
+    served: true
+    storage: false
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: tcproutes.specs.smi-spec.io
+  labels:
+    app.kubernetes.io/name: openservicemesh.io
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: specs.smi-spec.io
+  names:
+    kind: TCPRoute
+    listKind: TCPRouteList
+    plural: tcproutes
+    singular: tcproute
+  scope: Namespaced
+  versions:
+  - name: v1alpha4
+    served: true
+    storage: true
+  - name: v1alpha3
+    served: true
+    storage: false
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: trafficsplits.split.smi-spec.io
+  labels:
+    app.kubernetes.io/name: openservicemesh.io
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  group: split.smi-spec.io
+  names:
+    kind: TrafficSplit
+    listKind: TrafficSplitList
+    plural: trafficsplits
+    singular: trafficsplit
+    shortNames:
+    - ts
+  scope: Namespaced
+  versions:
+  - name: v1alpha2
+    served: true
+    storage: true
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: osm
+  namespace: osm-system
+  labels:
+    app: osm-controller
+    addonmanager.kubernetes.io/mode: Reconcile
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: osm
+  labels:
+    app: osm-controller
+    addonmanager.kubernetes.io/mode: Reconcile
+rules:
+- apiGroups: ["apps"]
+  resources: ["daemonsets", "deployments", "replicasets", "statefulsets"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: [""]
+  resources: ["configmaps", "endpoints", "namespaces", "pods", "serviceaccounts", "services"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: [""]
+  resources: ["secrets"]
+  verbs: ["create", "get", "list", "update", "watch"]
+- apiGroups: [""]
+  resources: ["events"]
+  verbs: ["create", "patch"]
+- apiGroups: ["extensions", "networking.k8s.io"]
+  resources: ["ingresses"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: ["access.smi-spec.io", "specs.smi-spec.io", "split.smi-spec.io"]
+  resources: ["*"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: ["apiextensions.k8s.io"]
+  resources: ["customresourcedefinitions"]
+  verbs: ["get", "list", "watch"]
+# the controller registers the sidecar injection webhook with the CA bundle of its certificate
+- apiGroups: ["admissionregistration.k8s.io"]
+  resources: ["mutatingwebhookconfigurations"]
+  verbs: ["create", "get", "list", "patch", "update", "watch"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: osm
+  labels:
+    app: osm-controller
+    addonmanager.kubernetes.io/mode: Reconcile
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: osm
+subjects:
+- kind: ServiceAccount
+  name: osm
+  namespace: osm-system
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: osm-config
+  namespace: osm-system
+  labels:
+    app: osm-controller
+    addonmanager.kubernetes.io/mode: Reconcile
+data:
+  permissive_traffic_policy_mode: "{{ContainerConfig "permissiveTrafficPolicyMode"}}"
+  egress: "false"
+  enable_debug_server: "false"
+  prometheus_scraping: "true"
+  tracing_enable: "false"
+  use_https_ingress: "false"
+  envoy_log_level: {{ContainerConfig "envoyLogLevel"}}
+  service_cert_validity_duration: 24h
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: osm-controller
+  namespace: osm-system
+  labels:
+    app: osm-controller
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  selector:
+    app: osm-controller
+  ports:
+  - name: osm-port
+    port: 15128
+    targetPort: 15128
+  - name: sidecar-injector
+    port: 443
+    targetPort: 9090
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: osm-controller
+  namespace: osm-system
+  labels:
+    app: osm-controller
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: osm-controller
+  template:
+    metadata:
+      labels:
+        app: osm-controller
+      annotations:
+        prometheus.io/scrape: "true"
+        prometheus.io/port: "9091"
+    spec:
+      serviceAccountName: osm
+      nodeSelector:
+        beta.kubernetes.io/os: linux
+      containers:
+      - name: osm-controller
+        image: {{ContainerImage "osm-controller"}}
+        imagePullPolicy: IfNotPresent
+        command:
+        - /osm-controller
+        args:
+        - --verbosity=info
+        - --osm-namespace=osm-system
+        - --mesh-name=osm
+        - --init-container-image={{ContainerConfig "initImage"}}
+        - --sidecar-image={{ContainerConfig "envoyImage"}}
+        - --webhook-config-name=osm-webhook-osm
+        - --ca-bundle-secret-name=osm-ca-bundle
+        - --certificate-manager=tresor
+        ports:
+        - name: admin-port
+          containerPort: 15000
+        - name: osm-port
+          containerPort: 15128
+        - name: sidecar-injector
+          containerPort: 9090
+        - name: metrics
+          containerPort: 9091
+        readinessProbe:
+          httpGet:
+            path: /health/ready
+            port: 9091
+          initialDelaySeconds: 1
+          timeoutSeconds: 5
+        livenessProbe:
+          httpGet:
+            path: /health/alive
+            port: 9091
+          initialDelaySeconds: 1
+          timeoutSeconds: 5
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "osm-controller"}}
+            memory: {{ContainerMemReqs "osm-controller"}}
+          limits:
+            cpu: {{ContainerCPULimits "osm-controller"}}
+            memory: {{ContainerMemLimits "osm-controller"}}
+`)
+
+func k8sContaineraddonsKubernetesmasteraddonsOpenServiceMeshDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsOpenServiceMeshDeploymentYaml, nil
+}
+
+func k8sContaineraddonsKubernetesmasteraddonsOpenServiceMeshDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsOpenServiceMeshDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-open-service-mesh-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _k8sContaineraddonsKubernetesmasteraddonsRegistryCacheDeploymentYaml = []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: registry-cache
   namespace: kube-system
   labels:
-    component: oms-agent
-    tier: node
+    k8s-app: registry-cache
     kubernetes.io/cluster-service: "true"
     addonmanager.kubernetes.io/mode: Reconcile
 spec:
   replicas: 1
   selector:
     matchLabels:
-      rsName: omsagent-rs
-  strategy:
-    type: RollingUpdate
+      k8s-app: registry-cache
   template:
     metadata:
       labels:
-        rsName: omsagent-rs
-      annotations:
-        agentVersion: {{ContainerConfig "omsAgentVersion"}}
-        dockerProviderVersion: {{ContainerConfig "dockerProviderVersion"}}
-        schema-versions:  {{ContainerConfig "schema-versions"}}
+        k8s-app: registry-cache
     spec:
-      serviceAccountName: omsagent
-      containers:
-        - name: omsagent
-          image: {{ContainerImage "omsagent"}}
-          imagePullPolicy: IfNotPresent
-          resources:
-            limits:
-              cpu: {{ContainerCPULimits "omsagent"}}
-              memory: {{ContainerMemLimits "omsagent"}}
-            requests:
-              cpu: {{ContainerCPUReqs "omsagent"}}
-              memory: {{ContainerMemReqs "omsagent"}}
-          env:
-            - name: NODE_IP
-              valueFrom:
-                fieldRef:
-                  fieldPath: status.hostIP
-            - name: ACS_RESOURCE_NAME
-              value: {{ContainerConfig "clusterName"}}            
-            - name: CONTROLLER_TYPE
-              value: "ReplicaSet"
-            - name: ISTEST
-              value: "true"
-          securityContext:
-            privileged: true
-          ports:
-            - containerPort: 25225
-              protocol: TCP
-            - containerPort: 25224
-              protocol: UDP
-          volumeMounts:
-            - mountPath: /var/run/host
-              name: docker-sock
-            - mountPath: /var/log
-              name: host-log
-            - mountPath: /var/lib/docker/containers
-              name: containerlog-path
-            - mountPath: /etc/kubernetes/host
-              name: azure-json-path
-            - mountPath: /etc/omsagent-secret
-              name: omsagent-secret
-              readOnly: true
-            - mountPath : /etc/config
-              name: omsagent-rs-config
-            - mountPath: /etc/config/settings
-              name: settings-vol-config              
-          livenessProbe:
-            exec:
-              command:
-                - /bin/bash
-                - -c
-                - /opt/livenessprobe.sh
-            initialDelaySeconds: 60
-            periodSeconds: 60
+      priorityClassName: system-cluster-critical
       nodeSelector:
         beta.kubernetes.io/os: linux
-        kubernetes.io/role: agent
+      containers:
+      - name: registry-cache
+        image: {{ContainerImage "registry-cache"}}
+        imagePullPolicy: IfNotPresent
+        env:
+        - name: REGISTRY_PROXY_REMOTEURL
+          value: {{ContainerConfig "remoteURL"}}
+        - name: REGISTRY_STORAGE_DELETE_ENABLED
+          value: "true"
+        ports:
+        - name: registry
+          containerPort: 5000
+          protocol: TCP
+        resources:
+          requests:
+            cpu: {{ContainerCPUReqs "registry-cache"}}
+            memory: {{ContainerMemReqs "registry-cache"}}
+          limits:
+            cpu: {{ContainerCPULimits "registry-cache"}}
+            memory: {{ContainerMemLimits "registry-cache"}}
+        readinessProbe:
+          httpGet:
+            path: /
+            port: registry
+        livenessProbe:
+          httpGet:
+            path: /
+            port: registry
+          initialDelaySeconds: 10
+        volumeMounts:
+        - name: cache
+          mountPath: /var/lib/registry
       volumes:
-        - name: docker-sock
-          hostPath:
-            path: /var/run
-        - name: container-hostname
-          hostPath:
-            path: /etc/hostname
-        - name: host-log
-          hostPath:
-            path: /var/log
-        - name: containerlog-path
-          hostPath:
-            path: /var/lib/docker/containers
-        - name: azure-json-path
-          hostPath:
-            path: /etc/kubernetes
-        - name: omsagent-secret
-          secret:
-            secretName: omsagent-secret
-        - name: omsagent-rs-config
-          configMap:
-            name: omsagent-rs-config
-        - name: settings-vol-config
-          configMap:
-            name: container-azm-ms-agentconfig
-            optional: true    
+      - name: cache
+        emptyDir: {}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: registry-cache
+  namespace: kube-system
+  labels:
+    k8s-app: registry-cache
+    kubernetes.io/cluster-service: "true"
+    addonmanager.kubernetes.io/mode: Reconcile
+spec:
+  type: NodePort
+  selector:
+    k8s-app: registry-cache
+  ports:
+  - name: registry
+    port: 5000
+    targetPort: registry
+    nodePort: {{ContainerConfig "nodePort"}}
+    protocol: TCP
 `)
 
-func k8sContaineraddonsKubernetesmasteraddonsOmsagentDaemonsetYamlBytes() ([]byte, error) {
-	return _k8sContaineraddonsKubernetesmasteraddonsOmsagentDaemonsetYaml, nil
+func k8sContaineraddonsKubernetesmasteraddonsRegistryCacheDeploymentYamlBytes() ([]byte, error) {
+	return _k8sContaineraddonsKubernetesmasteraddonsRegistryCacheDeploymentYaml, nil
 }
 
-func k8sContaineraddonsKubernetesmasteraddonsOmsagentDaemonsetYaml() (*asset, error) {
-	bytes, err := k8sContaineraddonsKubernetesmasteraddonsOmsagentDaemonsetYamlBytes()
+func k8sContaineraddonsKubernetesmasteraddonsRegistryCacheDeploymentYaml() (*asset, error) {
+	bytes, err := k8sContaineraddonsKubernetesmasteraddonsRegistryCacheDeploymentYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-omsagent-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "k8s/containeraddons/kubernetesmasteraddons-registry-cache-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -23415,27 +26682,29 @@ var _k8sKubernetesparamsT = []byte(`{{if .HasAadProfile}}
     "networkPolicy": {
       "defaultValue": "{{.OrchestratorProfile.KubernetesConfig.NetworkPolicy}}",
       "metadata": {
-        "description": "The network policy enforcement to use (calico|cilium); 'none' and 'azure' here for backwards compatibility"
+        "description": "The network policy enforcement to use (calico|cilium|antrea); 'none' and 'azure' here for backwards compatibility"
       },
       "allowedValues": [
         "",
         "none",
         "azure",
         "calico",
-        "cilium"
+        "cilium",
+        "antrea"
       ],
       "type": "string"
     },
     "networkPlugin": {
       "defaultValue": "{{.OrchestratorProfile.KubernetesConfig.NetworkPlugin}}",
       "metadata": {
-        "description": "The network plugin to use for Kubernetes (kubenet|azure|flannel|cilium)"
+        "description": "The network plugin to use for Kubernetes (kubenet|azure|flannel|cilium|antrea)"
       },
       "allowedValues": [
         "kubenet",
         "azure",
         "flannel",
-        "cilium"
+        "cilium",
+        "antrea"
       ],
       "type": "string"
     },
@@ -23452,19 +26721,19 @@ var _k8sKubernetesparamsT = []byte(`{{if .HasAadProfile}}
       "type": "string"
     },
     "containerdDownloadURLBase": {
-      "defaultValue": "https://storage.googleapis.com/cri-containerd-release/",
+      "defaultValue": "{{GetAirGapMirrorURL "https://storage.googleapis.com/cri-containerd-release/"}}",
       "type": "string"
     },
     "cniPluginsURL": {
-      "defaultValue": "https://acs-mirror.azureedge.net/cni/cni-plugins-amd64-latest.tgz",
+      "defaultValue": "{{GetAirGapMirrorURL "https://acs-mirror.azureedge.net/cni/cni-plugins-amd64-latest.tgz"}}",
       "type": "string"
     },
     "vnetCniLinuxPluginsURL": {
-      "defaultValue": "https://acs-mirror.azureedge.net/cni/azure-vnet-cni-linux-amd64-latest.tgz",
+      "defaultValue": "{{GetAirGapMirrorURL "https://acs-mirror.azureedge.net/cni/azure-vnet-cni-linux-amd64-latest.tgz"}}",
       "type": "string"
     },
     "vnetCniWindowsPluginsURL": {
-      "defaultValue": "https://acs-mirror.azureedge.net/cni/azure-vnet-cni-windows-amd64-latest.zip",
+      "defaultValue": "{{GetAirGapMirrorURL "https://acs-mirror.azureedge.net/cni/azure-vnet-cni-windows-amd64-latest.zip"}}",
       "type": "string"
     },
     "maxPods": {
@@ -23857,6 +27126,11 @@ $global:KubeBinariesVersion = "{{WrapAsParameter "kubeBinariesVersion"}}"
 ## Docker Version
 $global:DockerVersion = "{{WrapAsParameter "windowsDockerVersion"}}"
 
+## Container runtime, docker or containerd
+$global:ContainerRuntime = "{{WrapAsParameter "containerRuntime"}}"
+$global:ContainerdURL = "{{WrapAsParameter "windowsContainerdURL"}}"
+$global:ContainerdRegistryMirrors = "{{GetBase64EncodedContainerdRegistryMirrors}}"
+
 ## VM configuration passed by Azure
 $global:WindowsTelemetryGUID = "{{WrapAsParameter "windowsTelemetryGUID"}}"
 {{if eq GetIdentitySystem "adfs"}}
@@ -23891,6 +27165,7 @@ $global:UseInstanceMetadata = "{{WrapAsVariable "useInstanceMetadata"}}"
 
 $global:LoadBalancerSku = "{{WrapAsVariable "loadBalancerSku"}}"
 $global:ExcludeMasterFromStandardLB = "{{WrapAsVariable "excludeMasterFromStandardLB"}}"
+$global:CloudProviderConfig = "{{GetBase64EncodedCloudProviderConfig}}"
 
 
 # Windows defaults, not changed by aks-engine
@@ -23934,7 +27209,7 @@ Update-ServiceFailureActions()
 {
     sc.exe failure "kubelet" actions= restart/60000/restart/60000/restart/60000 reset= 900
     sc.exe failure "kubeproxy" actions= restart/60000/restart/60000/restart/60000 reset= 900
-    sc.exe failure "docker" actions= restart/60000/restart/60000/restart/60000 reset= 900
+    sc.exe failure $global:ContainerRuntime actions= restart/60000/restart/60000/restart/60000 reset= 900
 }
 
 try
@@ -23958,8 +27233,25 @@ try
         Write-Log "Create required data directories as needed"
         Initialize-DataDirectories
 
-        Write-Log "Install docker"
-        Install-Docker -DockerVersion $global:DockerVersion
+        if ($global:ContainerRuntime -eq "containerd") {
+            Write-Log "Install containerd"
+            $pauseImage = ($global:KubeletConfigArgs | Where-Object { $_ -like "--pod-infra-container-image=*" }) -replace "--pod-infra-container-image=", ""
+            if ($global:NetworkPlugin -eq "azure") {
+                $cniBinDir = $global:AzureCNIBinDir
+                $cniConfDir = $global:AzureCNIConfDir
+            } else {
+                $cniBinDir = $global:CNIPath
+                $cniConfDir = $global:CNIConfigPath
+            }
+            Install-Containerd -ContainerdURL $global:ContainerdURL ` + "`" + `
+                               -CNIBinDir $cniBinDir ` + "`" + `
+                               -CNIConfDir $cniConfDir ` + "`" + `
+                               -PauseImage $pauseImage ` + "`" + `
+                               -RegistryMirrors $global:ContainerdRegistryMirrors
+        } else {
+            Write-Log "Install docker"
+            Install-Docker -DockerVersion $global:DockerVersion
+        }
 
         Write-Log "Download kubelet binaries and unzip"
         Get-KubePackage -KubeBinariesSASURL $global:KubeBinariesPackageSASURL
@@ -23995,6 +27287,7 @@ try
             -UseInstanceMetadata $global:UseInstanceMetadata ` + "`" + `
             -LoadBalancerSku $global:LoadBalancerSku ` + "`" + `
             -ExcludeMasterFromStandardLB $global:ExcludeMasterFromStandardLB ` + "`" + `
+            -CloudProviderConfig $global:CloudProviderConfig ` + "`" + `
             -TargetEnvironment $TargetEnvironment
 
         {{if IsAzureStackCloud}}
@@ -24016,8 +27309,10 @@ try
                          -AgentCertificate $global:AgentCertificate
 
 
-        Write-Log "Create the Pause Container kubletwin/pause"
-        New-InfraContainer -KubeDir $global:KubeDir
+        if ($global:ContainerRuntime -ne "containerd") {
+            Write-Log "Create the Pause Container kubletwin/pause"
+            New-InfraContainer -KubeDir $global:KubeDir
+        }
 
         Write-Log "Configuring networking with NetworkPlugin:$global:NetworkPlugin"
 
@@ -24069,7 +27364,8 @@ try
             -KubeClusterCIDR $global:KubeClusterCIDR ` + "`" + `
             -KubeServiceCIDR $global:KubeServiceCIDR ` + "`" + `
             -HNSModule $global:HNSModule ` + "`" + `
-            -KubeletNodeLabels $global:KubeletNodeLabels
+            -KubeletNodeLabels $global:KubeletNodeLabels ` + "`" + `
+            -ContainerRuntime $global:ContainerRuntime
 
         # Install OpenSSH if SSH enabled
         $sshEnabled = [System.Convert]::ToBoolean("{{ WindowsSSHEnabled }}")
@@ -24177,6 +27473,51 @@ func k8sManifestsKubernetesmasterCloudControllerManagerYaml() (*asset, error) {
 	return a, nil
 }
 
+var _k8sManifestsKubernetesmasterKonnectivityServerYaml = []byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: konnectivity-server
+  namespace: kube-system
+  labels:
+    tier: control-plane
+    component: konnectivity-server
+spec:
+  hostNetwork: true
+  containers:
+    - name: konnectivity-server
+      image: <img>
+      imagePullPolicy: IfNotPresent
+      command: ["/proxy-server"]
+      args: ["--mode=http-connect", "--server-port=8131", "--agent-port=8132", "--admin-port=8133", "--server-count=<serverCount>", "--server-ca-cert=/etc/kubernetes/certs/ca.crt", "--server-cert=/etc/kubernetes/certs/konnectivity-server.crt", "--server-key=/etc/kubernetes/certs/konnectivity-server.key", "--cluster-ca-cert=/etc/kubernetes/certs/ca.crt", "--cluster-cert=/etc/kubernetes/certs/konnectivity-server.crt", "--cluster-key=/etc/kubernetes/certs/konnectivity-server.key", "--logtostderr=true"]
+      resources:
+        requests:
+          cpu: 20m
+          memory: 50Mi
+      volumeMounts:
+        - name: certs
+          mountPath: /etc/kubernetes/certs
+          readOnly: true
+  volumes:
+    - name: certs
+      hostPath:
+        path: /etc/kubernetes/certs
+`)
+
+func k8sManifestsKubernetesmasterKonnectivityServerYamlBytes() ([]byte, error) {
+	return _k8sManifestsKubernetesmasterKonnectivityServerYaml, nil
+}
+
+func k8sManifestsKubernetesmasterKonnectivityServerYaml() (*asset, error) {
+	bytes, err := k8sManifestsKubernetesmasterKonnectivityServerYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "k8s/manifests/kubernetesmaster-konnectivity-server.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _k8sManifestsKubernetesmasterKubeAddonManagerYaml = []byte(`apiVersion: v1
 kind: Pod
 metadata:
@@ -24797,6 +28138,66 @@ function Install-Docker
     }
 }
 
+# Installs containerd from a package holding containerd.exe, ctr.exe and containerd-shim-runhcs-v1.exe, and registers it as a service
+function Install-Containerd
+{
+    Param(
+        [Parameter(Mandatory=$true)][string]
+        $ContainerdURL,
+        [Parameter(Mandatory=$true)][string]
+        $CNIBinDir,
+        [Parameter(Mandatory=$true)][string]
+        $CNIConfDir,
+        [Parameter(Mandatory=$true)][string]
+        $PauseImage,
+        [string]
+        $RegistryMirrors # base64 encoded TOML
+    )
+
+    $containerdDir = [Io.path]::Combine($env:ProgramFiles, "containerd")
+    $tempdir = New-TemporaryDirectory
+    $package = [Io.path]::Combine($tempdir, "containerd.tar.gz")
+    for ($i = 0; $i -le 10; $i++) {
+        DownloadFileOverHttp -Url $ContainerdURL -DestinationPath $package
+        if ($?) {
+            break
+        }
+        else {
+            Write-Log $Error[0].Exception.Message
+        }
+    }
+    tar -xzf $package -C $tempdir
+    mkdir $containerdDir -Force
+    # the binaries may be at the root of the package or in a bin directory
+    Get-ChildItem -Path $tempdir -Recurse -Include *.exe | Copy-Item -Destination $containerdDir -Force
+    del $tempdir -Recurse
+
+    $configFile = [Io.path]::Combine($containerdDir, "config.toml")
+    $config = @"
+[plugins.cri]
+  sandbox_image = "$PauseImage"
+  [plugins.cri.containerd]
+    snapshotter = "windows"
+    [plugins.cri.containerd.default_runtime]
+      runtime_type = "io.containerd.runhcs.v1"
+  [plugins.cri.cni]
+    bin_dir = "$($CNIBinDir.Replace("\", "/"))"
+    conf_dir = "$($CNIConfDir.Replace("\", "/"))"
+"@
+    if ($RegistryMirrors) {
+        $config += "` + "`" + `n" + [System.Text.Encoding]::UTF8.GetString([System.Convert]::FromBase64String($RegistryMirrors))
+    }
+    $config | Out-File -encoding ASCII -filepath $configFile
+
+    $machinePath = [System.Environment]::GetEnvironmentVariable("Path", [System.EnvironmentVariableTarget]::Machine)
+    [System.Environment]::SetEnvironmentVariable("Path", "$machinePath;$containerdDir", [System.EnvironmentVariableTarget]::Machine)
+    $env:Path += ";$containerdDir"
+
+    & "$containerdDir\containerd.exe" --register-service --config $configFile
+    Start-Service containerd
+    Write-Log "Installed containerd from $ContainerdURL"
+}
+
 # Pagefile adjustments
 function Adjust-PageFileSize()
 {
@@ -24929,6 +28330,8 @@ Write-AzureConfig {
         $LoadBalancerSku,
         [Parameter(Mandatory = $true)][string]
         $ExcludeMasterFromStandardLB,
+        [string]
+        $CloudProviderConfig,
         [Parameter(Mandatory = $true)][string]
         $KubeDir,
         [Parameter(Mandatory = $true)][string]
@@ -24958,13 +28361,23 @@ Write-AzureConfig {
     "primaryAvailabilitySetName": "$PrimaryAvailabilitySetName",
     "primaryScaleSetName": "$PrimaryScaleSetName",
     "useManagedIdentityExtension": $UseManagedIdentityExtension,
-    "userAssignedIdentityID": $UserAssignedClientID,
+    "userAssignedIdentityID": "$UserAssignedClientID",
     "useInstanceMetadata": $UseInstanceMetadata,
     "loadBalancerSku": "$LoadBalancerSku",
     "excludeMasterFromStandardLB": $ExcludeMasterFromStandardLB
 }
 "@
 
+    # merge the cloudProviderConfig settings of the api model, which have no parameter of their own
+    if ($CloudProviderConfig) {
+        $config = $azureConfig | ConvertFrom-Json
+        $settings = [System.Text.Encoding]::UTF8.GetString([System.Convert]::FromBase64String($CloudProviderConfig)) | ConvertFrom-Json
+        foreach ($setting in $settings.PSObject.Properties) {
+            $config | Add-Member -MemberType NoteProperty -Name $setting.Name -Value $setting.Value -Force
+        }
+        $azureConfig = $config | ConvertTo-Json -Depth 10
+    }
+
     $azureConfig | Out-File -encoding ASCII -filepath "$azureConfigFile"
 }
 
@@ -25129,7 +28542,9 @@ New-NSSMService {
         $KubeletStartFile,
         [string]
         [Parameter(Mandatory = $true)]
-        $KubeProxyStartFile
+        $KubeProxyStartFile,
+        [string]
+        $ContainerRuntime = "docker"
     )
 
     # setup kubelet
@@ -25138,7 +28553,7 @@ New-NSSMService {
     & "$KubeDir\nssm.exe" set Kubelet AppParameters $KubeletStartFile
     & "$KubeDir\nssm.exe" set Kubelet DisplayName Kubelet
     & "$KubeDir\nssm.exe" set Kubelet AppRestartDelay 5000
-    & "$KubeDir\nssm.exe" set Kubelet DependOnService docker
+    & "$KubeDir\nssm.exe" set Kubelet DependOnService $ContainerRuntime
     & "$KubeDir\nssm.exe" set Kubelet Description Kubelet
     & "$KubeDir\nssm.exe" set Kubelet Start SERVICE_AUTO_START
     & "$KubeDir\nssm.exe" set Kubelet ObjectName LocalSystem
@@ -25209,7 +28624,9 @@ Install-KubernetesServices {
         [Parameter(Mandatory = $true)][string]
         $HNSModule,
         [Parameter(Mandatory = $true)][string]
-        $KubeletNodeLabels
+        $KubeletNodeLabels,
+        [string]
+        $ContainerRuntime = "docker"
     )
 
     # Calculate some local paths
@@ -25277,6 +28694,7 @@ Install-KubernetesServices {
 ` + "`" + `$global:VolumePluginDir = "$VolumePluginDir"
 ` + "`" + `$global:NetworkPlugin="$NetworkPlugin"
 ` + "`" + `$global:KubeletNodeLabels="$KubeletNodeLabels"
+` + "`" + `$global:ContainerRuntime="$ContainerRuntime"
 
 "@
 
@@ -25307,8 +28725,10 @@ if (!` + "`" + `$hnsNetwork)
 ` + "`" + `$hnsNetwork = Get-HnsNetwork | ? Name -EQ $KubeNetwork
 if (` + "`" + `$hnsNetwork)
 {
-    # Cleanup all containers
-    docker ps -q | foreach {docker rm ` + "`" + `$_ -f}
+    # Cleanup all containers, containerd removes the pod sandboxes of the previous boot itself
+    if (` + "`" + `$global:ContainerRuntime -eq "docker") {
+        docker ps -q | foreach {docker rm ` + "`" + `$_ -f}
+    }
 
     Write-Host "Cleaning up old HNS network found"
     Remove-HnsNetwork ` + "`" + `$hnsNetwork
@@ -25453,8 +28873,10 @@ try
     if (` + "`" + `$hnsNetwork)
     {
         # Kubelet has been restarted with existing network.
-        # Cleanup all containers
-        docker ps -q | foreach {docker rm ` + "`" + `$_ -f}
+        # Cleanup all containers, containerd removes the pod sandboxes of the previous boot itself
+        if (` + "`" + `$global:ContainerRuntime -eq "docker") {
+            docker ps -q | foreach {docker rm ` + "`" + `$_ -f}
+        }
         # cleanup network
         Write-Host "Cleaning up old HNS network found"
         Remove-HnsNetwork ` + "`" + `$hnsNetwork
@@ -25510,7 +28932,8 @@ $KubeDir\kube-proxy.exe --v=3 --proxy-mode=kernelspace --hostname-override=$env:
 
     New-NSSMService -KubeDir $KubeDir ` + "`" + `
         -KubeletStartFile $KubeletStartFile ` + "`" + `
-        -KubeProxyStartFile $KubeProxyStartFile
+        -KubeProxyStartFile $KubeProxyStartFile ` + "`" + `
+        -ContainerRuntime $ContainerRuntime
 }
 `)
 
@@ -25668,6 +29091,30 @@ var _masterparamsT = []byte(`    "linuxAdminUsername": {
       },
       "type": "string"
     },
+    {{if .MasterProfile.HasDedicatedEtcdSubnet}}
+    "etcdSubnet": {
+      "defaultValue": "{{.MasterProfile.EtcdSubnet}}",
+      "metadata": {
+        "description": "Sets the subnet of the etcd NICs of the master node(s)."
+      },
+      "type": "string"
+    },
+    {{if .MasterProfile.IsCustomVNET}}
+    "etcdVnetSubnetID": {
+      "metadata": {
+        "description": "Sets the vnet subnet of the etcd NICs of the master node(s)."
+      },
+      "type": "string"
+    },
+    {{end}}
+    "etcdFirstConsecutiveStaticIP": {
+      "defaultValue": "{{.MasterProfile.EtcdFirstConsecutiveStaticIP}}",
+      "metadata": {
+        "description": "Sets the static IP of the etcd NIC of the first master"
+      },
+      "type": "string"
+    },
+    {{end}}
     "masterVMSize": {
       {{GetMasterAllowedSizes}}
       "metadata": {
@@ -28862,6 +32309,13 @@ var _windowsparamsT = []byte(` {{if IsKubernetes}}
         "description": "The version of Docker to be installed on Windows Nodes"
       },
       "type": "string"
+    },
+    "windowsContainerdURL": {
+      "defaultValue": "",
+      "metadata": {
+        "description": "The URL of the containerd package to be installed on Windows Nodes with the containerd container runtime"
+      },
+      "type": "string"
     }
 `)
 
@@ -28969,7 +32423,6 @@ var _bindata = map[string]func() (*asset, error){
 	"k8s/addons/1.15/kubernetesmasteraddons-pod-security-policy.yaml":             k8sAddons115KubernetesmasteraddonsPodSecurityPolicyYaml,
 	"k8s/addons/1.16/kubernetesmaster-audit-policy.yaml":                          k8sAddons116KubernetesmasterAuditPolicyYaml,
 	"k8s/addons/1.16/kubernetesmasteraddons-azure-cloud-provider-deployment.yaml": k8sAddons116KubernetesmasteraddonsAzureCloudProviderDeploymentYaml,
-	"k8s/addons/1.16/kubernetesmasteraddons-cilium-daemonset.yaml":                k8sAddons116KubernetesmasteraddonsCiliumDaemonsetYaml,
 	"k8s/addons/1.16/kubernetesmasteraddons-flannel-daemonset.yaml":               k8sAddons116KubernetesmasteraddonsFlannelDaemonsetYaml,
 	"k8s/addons/1.16/kubernetesmasteraddons-kube-dns-deployment.yaml":             k8sAddons116KubernetesmasteraddonsKubeDnsDeploymentYaml,
 	"k8s/addons/1.16/kubernetesmasteraddons-kube-proxy-daemonset.yaml":            k8sAddons116KubernetesmasteraddonsKubeProxyDaemonsetYaml,
@@ -28984,7 +32437,6 @@ var _bindata = map[string]func() (*asset, error){
 	"k8s/addons/kubernetesmaster-audit-policy.yaml":                                 k8sAddonsKubernetesmasterAuditPolicyYaml,
 	"k8s/addons/kubernetesmasteraddons-aad-default-admin-group-rbac.yaml":           k8sAddonsKubernetesmasteraddonsAadDefaultAdminGroupRbacYaml,
 	"k8s/addons/kubernetesmasteraddons-azure-cloud-provider-deployment.yaml":        k8sAddonsKubernetesmasteraddonsAzureCloudProviderDeploymentYaml,
-	"k8s/addons/kubernetesmasteraddons-cilium-daemonset.yaml":                       k8sAddonsKubernetesmasteraddonsCiliumDaemonsetYaml,
 	"k8s/addons/kubernetesmasteraddons-elb-svc.yaml":                                k8sAddonsKubernetesmasteraddonsElbSvcYaml,
 	"k8s/addons/kubernetesmasteraddons-flannel-daemonset.yaml":                      k8sAddonsKubernetesmasteraddonsFlannelDaemonsetYaml,
 	"k8s/addons/kubernetesmasteraddons-kube-dns-deployment.yaml":                    k8sAddonsKubernetesmasteraddonsKubeDnsDeploymentYaml,
@@ -28995,91 +32447,108 @@ var _bindata = map[string]func() (*asset, error){
 	"k8s/addons/kubernetesmasteraddons-scheduled-maintenance-deployment.yaml":       k8sAddonsKubernetesmasteraddonsScheduledMaintenanceDeploymentYaml,
 	"k8s/addons/kubernetesmasteraddons-unmanaged-azure-storage-classes-custom.yaml": k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesCustomYaml,
 	"k8s/addons/kubernetesmasteraddons-unmanaged-azure-storage-classes.yaml":        k8sAddonsKubernetesmasteraddonsUnmanagedAzureStorageClassesYaml,
-	"k8s/armparameters.t":                                                                  k8sArmparametersT,
-	"k8s/cloud-init/artifacts/apt-preferences":                                             k8sCloudInitArtifactsAptPreferences,
-	"k8s/cloud-init/artifacts/auditd-rules":                                                k8sCloudInitArtifactsAuditdRules,
-	"k8s/cloud-init/artifacts/cis.sh":                                                      k8sCloudInitArtifactsCisSh,
-	"k8s/cloud-init/artifacts/cse_config.sh":                                               k8sCloudInitArtifactsCse_configSh,
-	"k8s/cloud-init/artifacts/cse_customcloud.sh":                                          k8sCloudInitArtifactsCse_customcloudSh,
-	"k8s/cloud-init/artifacts/cse_helpers.sh":                                              k8sCloudInitArtifactsCse_helpersSh,
-	"k8s/cloud-init/artifacts/cse_install.sh":                                              k8sCloudInitArtifactsCse_installSh,
-	"k8s/cloud-init/artifacts/cse_main.sh":                                                 k8sCloudInitArtifactsCse_mainSh,
-	"k8s/cloud-init/artifacts/default-grub":                                                k8sCloudInitArtifactsDefaultGrub,
-	"k8s/cloud-init/artifacts/dhcpv6.service":                                              k8sCloudInitArtifactsDhcpv6Service,
-	"k8s/cloud-init/artifacts/docker-monitor.service":                                      k8sCloudInitArtifactsDockerMonitorService,
-	"k8s/cloud-init/artifacts/docker-monitor.timer":                                        k8sCloudInitArtifactsDockerMonitorTimer,
-	"k8s/cloud-init/artifacts/docker_clear_mount_propagation_flags.conf":                   k8sCloudInitArtifactsDocker_clear_mount_propagation_flagsConf,
-	"k8s/cloud-init/artifacts/enable-dhcpv6.sh":                                            k8sCloudInitArtifactsEnableDhcpv6Sh,
-	"k8s/cloud-init/artifacts/etc-issue":                                                   k8sCloudInitArtifactsEtcIssue,
-	"k8s/cloud-init/artifacts/etc-issue.net":                                               k8sCloudInitArtifactsEtcIssueNet,
-	"k8s/cloud-init/artifacts/etcd.service":                                                k8sCloudInitArtifactsEtcdService,
-	"k8s/cloud-init/artifacts/generateproxycerts.sh":                                       k8sCloudInitArtifactsGenerateproxycertsSh,
-	"k8s/cloud-init/artifacts/health-monitor.sh":                                           k8sCloudInitArtifactsHealthMonitorSh,
-	"k8s/cloud-init/artifacts/kms.service":                                                 k8sCloudInitArtifactsKmsService,
-	"k8s/cloud-init/artifacts/kubelet-monitor.service":                                     k8sCloudInitArtifactsKubeletMonitorService,
-	"k8s/cloud-init/artifacts/kubelet-monitor.timer":                                       k8sCloudInitArtifactsKubeletMonitorTimer,
-	"k8s/cloud-init/artifacts/kubelet.service":                                             k8sCloudInitArtifactsKubeletService,
-	"k8s/cloud-init/artifacts/label-nodes.service":                                         k8sCloudInitArtifactsLabelNodesService,
-	"k8s/cloud-init/artifacts/label-nodes.sh":                                              k8sCloudInitArtifactsLabelNodesSh,
-	"k8s/cloud-init/artifacts/modprobe-CIS.conf":                                           k8sCloudInitArtifactsModprobeCisConf,
-	"k8s/cloud-init/artifacts/mountetcd.sh":                                                k8sCloudInitArtifactsMountetcdSh,
-	"k8s/cloud-init/artifacts/pam-d-common-auth":                                           k8sCloudInitArtifactsPamDCommonAuth,
-	"k8s/cloud-init/artifacts/pam-d-common-password":                                       k8sCloudInitArtifactsPamDCommonPassword,
-	"k8s/cloud-init/artifacts/pam-d-su":                                                    k8sCloudInitArtifactsPamDSu,
-	"k8s/cloud-init/artifacts/profile-d-cis.sh":                                            k8sCloudInitArtifactsProfileDCisSh,
-	"k8s/cloud-init/artifacts/pwquality-CIS.conf":                                          k8sCloudInitArtifactsPwqualityCisConf,
-	"k8s/cloud-init/artifacts/rsyslog-d-60-CIS.conf":                                       k8sCloudInitArtifactsRsyslogD60CisConf,
-	"k8s/cloud-init/artifacts/setup-custom-search-domains.sh":                              k8sCloudInitArtifactsSetupCustomSearchDomainsSh,
-	"k8s/cloud-init/artifacts/sshd_config":                                                 k8sCloudInitArtifactsSshd_config,
-	"k8s/cloud-init/artifacts/sshd_config_1604":                                            k8sCloudInitArtifactsSshd_config_1604,
-	"k8s/cloud-init/artifacts/sys-fs-bpf.mount":                                            k8sCloudInitArtifactsSysFsBpfMount,
-	"k8s/cloud-init/artifacts/sysctl-d-60-CIS.conf":                                        k8sCloudInitArtifactsSysctlD60CisConf,
-	"k8s/cloud-init/jumpboxcustomdata.yml":                                                 k8sCloudInitJumpboxcustomdataYml,
-	"k8s/cloud-init/masternodecustomdata.yml":                                              k8sCloudInitMasternodecustomdataYml,
-	"k8s/cloud-init/nodecustomdata.yml":                                                    k8sCloudInitNodecustomdataYml,
-	"k8s/containeraddons/1.16/azure-cni-networkmonitor.yaml":                               k8sContaineraddons116AzureCniNetworkmonitorYaml,
-	"k8s/containeraddons/1.16/ip-masq-agent.yaml":                                          k8sContaineraddons116IpMasqAgentYaml,
-	"k8s/containeraddons/1.16/kubernetesmasteraddons-aad-pod-identity-deployment.yaml":     k8sContaineraddons116KubernetesmasteraddonsAadPodIdentityDeploymentYaml,
-	"k8s/containeraddons/1.16/kubernetesmasteraddons-aci-connector-deployment.yaml":        k8sContaineraddons116KubernetesmasteraddonsAciConnectorDeploymentYaml,
-	"k8s/containeraddons/1.16/kubernetesmasteraddons-azure-npm-daemonset.yaml":             k8sContaineraddons116KubernetesmasteraddonsAzureNpmDaemonsetYaml,
-	"k8s/containeraddons/1.16/kubernetesmasteraddons-blobfuse-flexvolume-installer.yaml":   k8sContaineraddons116KubernetesmasteraddonsBlobfuseFlexvolumeInstallerYaml,
-	"k8s/containeraddons/1.16/kubernetesmasteraddons-calico-daemonset.yaml":                k8sContaineraddons116KubernetesmasteraddonsCalicoDaemonsetYaml,
-	"k8s/containeraddons/1.16/kubernetesmasteraddons-cluster-autoscaler-deployment.yaml":   k8sContaineraddons116KubernetesmasteraddonsClusterAutoscalerDeploymentYaml,
-	"k8s/containeraddons/1.16/kubernetesmasteraddons-heapster-deployment.yaml":             k8sContaineraddons116KubernetesmasteraddonsHeapsterDeploymentYaml,
-	"k8s/containeraddons/1.16/kubernetesmasteraddons-keyvault-flexvolume-installer.yaml":   k8sContaineraddons116KubernetesmasteraddonsKeyvaultFlexvolumeInstallerYaml,
-	"k8s/containeraddons/1.16/kubernetesmasteraddons-kube-rescheduler-deployment.yaml":     k8sContaineraddons116KubernetesmasteraddonsKubeReschedulerDeploymentYaml,
-	"k8s/containeraddons/1.16/kubernetesmasteraddons-kubernetes-dashboard-deployment.yaml": k8sContaineraddons116KubernetesmasteraddonsKubernetesDashboardDeploymentYaml,
-	"k8s/containeraddons/1.16/kubernetesmasteraddons-metrics-server-deployment.yaml":       k8sContaineraddons116KubernetesmasteraddonsMetricsServerDeploymentYaml,
-	"k8s/containeraddons/1.16/kubernetesmasteraddons-nvidia-device-plugin-daemonset.yaml":  k8sContaineraddons116KubernetesmasteraddonsNvidiaDevicePluginDaemonsetYaml,
-	"k8s/containeraddons/1.16/kubernetesmasteraddons-omsagent-daemonset.yaml":              k8sContaineraddons116KubernetesmasteraddonsOmsagentDaemonsetYaml,
-	"k8s/containeraddons/1.16/kubernetesmasteraddons-smb-flexvolume-installer.yaml":        k8sContaineraddons116KubernetesmasteraddonsSmbFlexvolumeInstallerYaml,
-	"k8s/containeraddons/1.16/kubernetesmasteraddons-tiller-deployment.yaml":               k8sContaineraddons116KubernetesmasteraddonsTillerDeploymentYaml,
-	"k8s/containeraddons/1.6/kubernetesmasteraddons-heapster-deployment.yaml":              k8sContaineraddons16KubernetesmasteraddonsHeapsterDeploymentYaml,
-	"k8s/containeraddons/1.7/kubernetesmasteraddons-heapster-deployment.yaml":              k8sContaineraddons17KubernetesmasteraddonsHeapsterDeploymentYaml,
-	"k8s/containeraddons/1.8/kubernetesmasteraddons-heapster-deployment.yaml":              k8sContaineraddons18KubernetesmasteraddonsHeapsterDeploymentYaml,
-	"k8s/containeraddons/azure-cni-networkmonitor.yaml":                                    k8sContaineraddonsAzureCniNetworkmonitorYaml,
-	"k8s/containeraddons/dns-autoscaler.yaml":                                              k8sContaineraddonsDnsAutoscalerYaml,
-	"k8s/containeraddons/ip-masq-agent.yaml":                                               k8sContaineraddonsIpMasqAgentYaml,
-	"k8s/containeraddons/kubernetesmasteraddons-aad-pod-identity-deployment.yaml":          k8sContaineraddonsKubernetesmasteraddonsAadPodIdentityDeploymentYaml,
-	"k8s/containeraddons/kubernetesmasteraddons-aci-connector-deployment.yaml":             k8sContaineraddonsKubernetesmasteraddonsAciConnectorDeploymentYaml,
-	"k8s/containeraddons/kubernetesmasteraddons-azure-npm-daemonset.yaml":                  k8sContaineraddonsKubernetesmasteraddonsAzureNpmDaemonsetYaml,
-	"k8s/containeraddons/kubernetesmasteraddons-blobfuse-flexvolume-installer.yaml":        k8sContaineraddonsKubernetesmasteraddonsBlobfuseFlexvolumeInstallerYaml,
-	"k8s/containeraddons/kubernetesmasteraddons-calico-daemonset.yaml":                     k8sContaineraddonsKubernetesmasteraddonsCalicoDaemonsetYaml,
-	"k8s/containeraddons/kubernetesmasteraddons-cluster-autoscaler-deployment.yaml":        k8sContaineraddonsKubernetesmasteraddonsClusterAutoscalerDeploymentYaml,
-	"k8s/containeraddons/kubernetesmasteraddons-heapster-deployment.yaml":                  k8sContaineraddonsKubernetesmasteraddonsHeapsterDeploymentYaml,
-	"k8s/containeraddons/kubernetesmasteraddons-keyvault-flexvolume-installer.yaml":        k8sContaineraddonsKubernetesmasteraddonsKeyvaultFlexvolumeInstallerYaml,
-	"k8s/containeraddons/kubernetesmasteraddons-kube-rescheduler-deployment.yaml":          k8sContaineraddonsKubernetesmasteraddonsKubeReschedulerDeploymentYaml,
-	"k8s/containeraddons/kubernetesmasteraddons-kubernetes-dashboard-deployment.yaml":      k8sContaineraddonsKubernetesmasteraddonsKubernetesDashboardDeploymentYaml,
-	"k8s/containeraddons/kubernetesmasteraddons-metrics-server-deployment.yaml":            k8sContaineraddonsKubernetesmasteraddonsMetricsServerDeploymentYaml,
-	"k8s/containeraddons/kubernetesmasteraddons-nvidia-device-plugin-daemonset.yaml":       k8sContaineraddonsKubernetesmasteraddonsNvidiaDevicePluginDaemonsetYaml,
-	"k8s/containeraddons/kubernetesmasteraddons-omsagent-daemonset.yaml":                   k8sContaineraddonsKubernetesmasteraddonsOmsagentDaemonsetYaml,
-	"k8s/containeraddons/kubernetesmasteraddons-smb-flexvolume-installer.yaml":             k8sContaineraddonsKubernetesmasteraddonsSmbFlexvolumeInstallerYaml,
-	"k8s/containeraddons/kubernetesmasteraddons-tiller-deployment.yaml":                    k8sContaineraddonsKubernetesmasteraddonsTillerDeploymentYaml,
+	"k8s/armparameters.t":                                                                        k8sArmparametersT,
+	"k8s/cloud-init/artifacts/apt-preferences":                                                   k8sCloudInitArtifactsAptPreferences,
+	"k8s/cloud-init/artifacts/auditd-rules":                                                      k8sCloudInitArtifactsAuditdRules,
+	"k8s/cloud-init/artifacts/cis.sh":                                                            k8sCloudInitArtifactsCisSh,
+	"k8s/cloud-init/artifacts/cse_config.sh":                                                     k8sCloudInitArtifactsCse_configSh,
+	"k8s/cloud-init/artifacts/cse_customcloud.sh":                                                k8sCloudInitArtifactsCse_customcloudSh,
+	"k8s/cloud-init/artifacts/cse_helpers.sh":                                                    k8sCloudInitArtifactsCse_helpersSh,
+	"k8s/cloud-init/artifacts/cse_install.sh":                                                    k8sCloudInitArtifactsCse_installSh,
+	"k8s/cloud-init/artifacts/cse_main.sh":                                                       k8sCloudInitArtifactsCse_mainSh,
+	"k8s/cloud-init/artifacts/default-grub":                                                      k8sCloudInitArtifactsDefaultGrub,
+	"k8s/cloud-init/artifacts/dhcpv6.service":                                                    k8sCloudInitArtifactsDhcpv6Service,
+	"k8s/cloud-init/artifacts/docker-monitor.service":                                            k8sCloudInitArtifactsDockerMonitorService,
+	"k8s/cloud-init/artifacts/docker-monitor.timer":                                              k8sCloudInitArtifactsDockerMonitorTimer,
+	"k8s/cloud-init/artifacts/docker_clear_mount_propagation_flags.conf":                         k8sCloudInitArtifactsDocker_clear_mount_propagation_flagsConf,
+	"k8s/cloud-init/artifacts/enable-dhcpv6.sh":                                                  k8sCloudInitArtifactsEnableDhcpv6Sh,
+	"k8s/cloud-init/artifacts/etc-issue":                                                         k8sCloudInitArtifactsEtcIssue,
+	"k8s/cloud-init/artifacts/etc-issue.net":                                                     k8sCloudInitArtifactsEtcIssueNet,
+	"k8s/cloud-init/artifacts/etcd.service":                                                      k8sCloudInitArtifactsEtcdService,
+	"k8s/cloud-init/artifacts/generatekonnectivitycerts.sh":                                      k8sCloudInitArtifactsGeneratekonnectivitycertsSh,
+	"k8s/cloud-init/artifacts/generateproxycerts.sh":                                             k8sCloudInitArtifactsGenerateproxycertsSh,
+	"k8s/cloud-init/artifacts/health-monitor.sh":                                                 k8sCloudInitArtifactsHealthMonitorSh,
+	"k8s/cloud-init/artifacts/kms.service":                                                       k8sCloudInitArtifactsKmsService,
+	"k8s/cloud-init/artifacts/kubelet-monitor.service":                                           k8sCloudInitArtifactsKubeletMonitorService,
+	"k8s/cloud-init/artifacts/kubelet-monitor.timer":                                             k8sCloudInitArtifactsKubeletMonitorTimer,
+	"k8s/cloud-init/artifacts/kubelet.service":                                                   k8sCloudInitArtifactsKubeletService,
+	"k8s/cloud-init/artifacts/label-nodes.service":                                               k8sCloudInitArtifactsLabelNodesService,
+	"k8s/cloud-init/artifacts/label-nodes.sh":                                                    k8sCloudInitArtifactsLabelNodesSh,
+	"k8s/cloud-init/artifacts/modprobe-CIS.conf":                                                 k8sCloudInitArtifactsModprobeCisConf,
+	"k8s/cloud-init/artifacts/mountetcd.sh":                                                      k8sCloudInitArtifactsMountetcdSh,
+	"k8s/cloud-init/artifacts/pam-d-common-auth":                                                 k8sCloudInitArtifactsPamDCommonAuth,
+	"k8s/cloud-init/artifacts/pam-d-common-password":                                             k8sCloudInitArtifactsPamDCommonPassword,
+	"k8s/cloud-init/artifacts/pam-d-su":                                                          k8sCloudInitArtifactsPamDSu,
+	"k8s/cloud-init/artifacts/profile-d-cis.sh":                                                  k8sCloudInitArtifactsProfileDCisSh,
+	"k8s/cloud-init/artifacts/pwquality-CIS.conf":                                                k8sCloudInitArtifactsPwqualityCisConf,
+	"k8s/cloud-init/artifacts/rsyslog-d-60-CIS.conf":                                             k8sCloudInitArtifactsRsyslogD60CisConf,
+	"k8s/cloud-init/artifacts/scheduled-events-handler.service":                                  k8sCloudInitArtifactsScheduledEventsHandlerService,
+	"k8s/cloud-init/artifacts/scheduled-events-handler.sh":                                       k8sCloudInitArtifactsScheduledEventsHandlerSh,
+	"k8s/cloud-init/artifacts/setup-custom-search-domains.sh":                                    k8sCloudInitArtifactsSetupCustomSearchDomainsSh,
+	"k8s/cloud-init/artifacts/sshd_config":                                                       k8sCloudInitArtifactsSshd_config,
+	"k8s/cloud-init/artifacts/sshd_config_1604":                                                  k8sCloudInitArtifactsSshd_config_1604,
+	"k8s/cloud-init/artifacts/sys-fs-bpf.mount":                                                  k8sCloudInitArtifactsSysFsBpfMount,
+	"k8s/cloud-init/artifacts/sysctl-d-60-CIS.conf":                                              k8sCloudInitArtifactsSysctlD60CisConf,
+	"k8s/cloud-init/jumpboxcustomdata.yml":                                                       k8sCloudInitJumpboxcustomdataYml,
+	"k8s/cloud-init/masternodecustomdata.yml":                                                    k8sCloudInitMasternodecustomdataYml,
+	"k8s/cloud-init/nodecustomdata.yml":                                                          k8sCloudInitNodecustomdataYml,
+	"k8s/containeraddons/1.16/azure-cni-networkmonitor.yaml":                                     k8sContaineraddons116AzureCniNetworkmonitorYaml,
+	"k8s/containeraddons/1.16/ip-masq-agent.yaml":                                                k8sContaineraddons116IpMasqAgentYaml,
+	"k8s/containeraddons/1.16/kubernetesmasteraddons-aad-pod-identity-deployment.yaml":           k8sContaineraddons116KubernetesmasteraddonsAadPodIdentityDeploymentYaml,
+	"k8s/containeraddons/1.16/kubernetesmasteraddons-aci-connector-deployment.yaml":              k8sContaineraddons116KubernetesmasteraddonsAciConnectorDeploymentYaml,
+	"k8s/containeraddons/1.16/kubernetesmasteraddons-azure-npm-daemonset.yaml":                   k8sContaineraddons116KubernetesmasteraddonsAzureNpmDaemonsetYaml,
+	"k8s/containeraddons/1.16/kubernetesmasteraddons-blobfuse-flexvolume-installer.yaml":         k8sContaineraddons116KubernetesmasteraddonsBlobfuseFlexvolumeInstallerYaml,
+	"k8s/containeraddons/1.16/kubernetesmasteraddons-calico-daemonset.yaml":                      k8sContaineraddons116KubernetesmasteraddonsCalicoDaemonsetYaml,
+	"k8s/containeraddons/1.16/kubernetesmasteraddons-cluster-autoscaler-deployment.yaml":         k8sContaineraddons116KubernetesmasteraddonsClusterAutoscalerDeploymentYaml,
+	"k8s/containeraddons/1.16/kubernetesmasteraddons-heapster-deployment.yaml":                   k8sContaineraddons116KubernetesmasteraddonsHeapsterDeploymentYaml,
+	"k8s/containeraddons/1.16/kubernetesmasteraddons-keyvault-flexvolume-installer.yaml":         k8sContaineraddons116KubernetesmasteraddonsKeyvaultFlexvolumeInstallerYaml,
+	"k8s/containeraddons/1.16/kubernetesmasteraddons-kube-rescheduler-deployment.yaml":           k8sContaineraddons116KubernetesmasteraddonsKubeReschedulerDeploymentYaml,
+	"k8s/containeraddons/1.16/kubernetesmasteraddons-kubernetes-dashboard-deployment.yaml":       k8sContaineraddons116KubernetesmasteraddonsKubernetesDashboardDeploymentYaml,
+	"k8s/containeraddons/1.16/kubernetesmasteraddons-metrics-server-deployment.yaml":             k8sContaineraddons116KubernetesmasteraddonsMetricsServerDeploymentYaml,
+	"k8s/containeraddons/1.16/kubernetesmasteraddons-nvidia-device-plugin-daemonset.yaml":        k8sContaineraddons116KubernetesmasteraddonsNvidiaDevicePluginDaemonsetYaml,
+	"k8s/containeraddons/1.16/kubernetesmasteraddons-omsagent-daemonset.yaml":                    k8sContaineraddons116KubernetesmasteraddonsOmsagentDaemonsetYaml,
+	"k8s/containeraddons/1.16/kubernetesmasteraddons-smb-flexvolume-installer.yaml":              k8sContaineraddons116KubernetesmasteraddonsSmbFlexvolumeInstallerYaml,
+	"k8s/containeraddons/1.16/kubernetesmasteraddons-tiller-deployment.yaml":                     k8sContaineraddons116KubernetesmasteraddonsTillerDeploymentYaml,
+	"k8s/containeraddons/1.6/kubernetesmasteraddons-heapster-deployment.yaml":                    k8sContaineraddons16KubernetesmasteraddonsHeapsterDeploymentYaml,
+	"k8s/containeraddons/1.7/kubernetesmasteraddons-heapster-deployment.yaml":                    k8sContaineraddons17KubernetesmasteraddonsHeapsterDeploymentYaml,
+	"k8s/containeraddons/1.8/kubernetesmasteraddons-heapster-deployment.yaml":                    k8sContaineraddons18KubernetesmasteraddonsHeapsterDeploymentYaml,
+	"k8s/containeraddons/azure-cni-networkmonitor.yaml":                                          k8sContaineraddonsAzureCniNetworkmonitorYaml,
+	"k8s/containeraddons/dns-autoscaler.yaml":                                                    k8sContaineraddonsDnsAutoscalerYaml,
+	"k8s/containeraddons/ip-masq-agent.yaml":                                                     k8sContaineraddonsIpMasqAgentYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-aad-pod-identity-deployment.yaml":                k8sContaineraddonsKubernetesmasteraddonsAadPodIdentityDeploymentYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-aci-connector-deployment.yaml":                   k8sContaineraddonsKubernetesmasteraddonsAciConnectorDeploymentYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-antrea.yaml":                                     k8sContaineraddonsKubernetesmasteraddonsAntreaYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-appgw-ingress-deployment.yaml":                   k8sContaineraddonsKubernetesmasteraddonsAppgwIngressDeploymentYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-azure-npm-daemonset.yaml":                        k8sContaineraddonsKubernetesmasteraddonsAzureNpmDaemonsetYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-azure-policy-deployment.yaml":                    k8sContaineraddonsKubernetesmasteraddonsAzurePolicyDeploymentYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-azure-workload-identity-webhook-deployment.yaml": k8sContaineraddonsKubernetesmasteraddonsAzureWorkloadIdentityWebhookDeploymentYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-blobfuse-flexvolume-installer.yaml":              k8sContaineraddonsKubernetesmasteraddonsBlobfuseFlexvolumeInstallerYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-calico-daemonset.yaml":                           k8sContaineraddonsKubernetesmasteraddonsCalicoDaemonsetYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-cilium-daemonset.yaml":                           k8sContaineraddonsKubernetesmasteraddonsCiliumDaemonsetYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-cluster-autoscaler-deployment.yaml":              k8sContaineraddonsKubernetesmasteraddonsClusterAutoscalerDeploymentYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-csi-secrets-store-daemonset.yaml":                k8sContaineraddonsKubernetesmasteraddonsCsiSecretsStoreDaemonsetYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-fluent-bit-daemonset.yaml":                       k8sContaineraddonsKubernetesmasteraddonsFluentBitDaemonsetYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-heapster-deployment.yaml":                        k8sContaineraddonsKubernetesmasteraddonsHeapsterDeploymentYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-keda-deployment.yaml":                            k8sContaineraddonsKubernetesmasteraddonsKedaDeploymentYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-keyvault-flexvolume-installer.yaml":              k8sContaineraddonsKubernetesmasteraddonsKeyvaultFlexvolumeInstallerYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-konnectivity-agent-daemonset.yaml":               k8sContaineraddonsKubernetesmasteraddonsKonnectivityAgentDaemonsetYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-kube-rescheduler-deployment.yaml":                k8sContaineraddonsKubernetesmasteraddonsKubeReschedulerDeploymentYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-kubernetes-dashboard-deployment.yaml":            k8sContaineraddonsKubernetesmasteraddonsKubernetesDashboardDeploymentYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-metrics-server-deployment.yaml":                  k8sContaineraddonsKubernetesmasteraddonsMetricsServerDeploymentYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-nginx-ingress-deployment.yaml":                   k8sContaineraddonsKubernetesmasteraddonsNginxIngressDeploymentYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-node-problem-detector-daemonset.yaml":            k8sContaineraddonsKubernetesmasteraddonsNodeProblemDetectorDaemonsetYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-nvidia-device-plugin-daemonset.yaml":             k8sContaineraddonsKubernetesmasteraddonsNvidiaDevicePluginDaemonsetYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-omsagent-daemonset.yaml":                         k8sContaineraddonsKubernetesmasteraddonsOmsagentDaemonsetYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-open-service-mesh-deployment.yaml":               k8sContaineraddonsKubernetesmasteraddonsOpenServiceMeshDeploymentYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-registry-cache-deployment.yaml":                  k8sContaineraddonsKubernetesmasteraddonsRegistryCacheDeploymentYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-smb-flexvolume-installer.yaml":                   k8sContaineraddonsKubernetesmasteraddonsSmbFlexvolumeInstallerYaml,
+	"k8s/containeraddons/kubernetesmasteraddons-tiller-deployment.yaml":                          k8sContaineraddonsKubernetesmasteraddonsTillerDeploymentYaml,
 	"k8s/kubeconfig.json":                                                k8sKubeconfigJson,
 	"k8s/kubernetesparams.t":                                             k8sKubernetesparamsT,
 	"k8s/kuberneteswindowsfunctions.ps1":                                 k8sKuberneteswindowsfunctionsPs1,
 	"k8s/kuberneteswindowssetup.ps1":                                     k8sKuberneteswindowssetupPs1,
 	"k8s/manifests/kubernetesmaster-cloud-controller-manager.yaml":       k8sManifestsKubernetesmasterCloudControllerManagerYaml,
+	"k8s/manifests/kubernetesmaster-konnectivity-server.yaml":            k8sManifestsKubernetesmasterKonnectivityServerYaml,
 	"k8s/manifests/kubernetesmaster-kube-addon-manager.yaml":             k8sManifestsKubernetesmasterKubeAddonManagerYaml,
 	"k8s/manifests/kubernetesmaster-kube-apiserver.yaml":                 k8sManifestsKubernetesmasterKubeApiserverYaml,
 	"k8s/manifests/kubernetesmaster-kube-controller-manager-custom.yaml": k8sManifestsKubernetesmasterKubeControllerManagerCustomYaml,
@@ -29112,11 +32581,13 @@ var _bindata = map[string]func() (*asset, error){
 // directory embedded in the file by go-bindata.
 // For example if you run go-bindata on data/... and data contains the
 // following hierarchy:
-//     data/
-//       foo.txt
-//       img/
-//         a.png
-//         b.png
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
 // then AssetDir("data") would return []string{"foo.txt", "img"}
 // AssetDir("data/img") would return []string{"a.png", "b.png"}
 // AssetDir("foo.txt") and AssetDir("notexist") would return an error
@@ -29197,7 +32668,6 @@ var _bintree = &bintree{nil, map[string]*bintree{
 			"1.16": {nil, map[string]*bintree{
 				"kubernetesmaster-audit-policy.yaml":                          {k8sAddons116KubernetesmasterAuditPolicyYaml, map[string]*bintree{}},
 				"kubernetesmasteraddons-azure-cloud-provider-deployment.yaml": {k8sAddons116KubernetesmasteraddonsAzureCloudProviderDeploymentYaml, map[string]*bintree{}},
-				"kubernetesmasteraddons-cilium-daemonset.yaml":                {k8sAddons116KubernetesmasteraddonsCiliumDaemonsetYaml, map[string]*bintree{}},
 				"kubernetesmasteraddons-flannel-daemonset.yaml":               {k8sAddons116KubernetesmasteraddonsFlannelDaemonsetYaml, map[string]*bintree{}},
 				"kubernetesmasteraddons-kube-dns-deployment.yaml":             {k8sAddons116KubernetesmasteraddonsKubeDnsDeploymentYaml, map[string]*bintree{}},
 				"kubernetesmasteraddons-kube-proxy-daemonset.yaml":            {k8sAddons116KubernetesmasteraddonsKubeProxyDaemonsetYaml, map[string]*bintree{}},
@@ -29221,7 +32691,6 @@ var _bintree = &bintree{nil, map[string]*bintree{
 			"kubernetesmaster-audit-policy.yaml": {k8sAddonsKubernetesmasterAuditPolicyYaml, map[string]*bintree{}},
 			"kubernetesmasteraddons-aad-default-admin-group-rbac.yaml":           {k8sAddonsKubernetesmasteraddonsAadDefaultAdminGroupRbacYaml, map[string]*bintree{}},
 			"kubernetesmasteraddons-azure-cloud-provider-deployment.yaml":        {k8sAddonsKubernetesmasteraddonsAzureCloudProviderDeploymentYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-cilium-daemonset.yaml":                       {k8sAddonsKubernetesmasteraddonsCiliumDaemonsetYaml, map[string]*bintree{}},
 			"kubernetesmasteraddons-elb-svc.yaml":                                {k8sAddonsKubernetesmasteraddonsElbSvcYaml, map[string]*bintree{}},
 			"kubernetesmasteraddons-flannel-daemonset.yaml":                      {k8sAddonsKubernetesmasteraddonsFlannelDaemonsetYaml, map[string]*bintree{}},
 			"kubernetesmasteraddons-kube-dns-deployment.yaml":                    {k8sAddonsKubernetesmasteraddonsKubeDnsDeploymentYaml, map[string]*bintree{}},
@@ -29253,6 +32722,7 @@ var _bintree = &bintree{nil, map[string]*bintree{
 				"etc-issue":                                 {k8sCloudInitArtifactsEtcIssue, map[string]*bintree{}},
 				"etc-issue.net":                             {k8sCloudInitArtifactsEtcIssueNet, map[string]*bintree{}},
 				"etcd.service":                              {k8sCloudInitArtifactsEtcdService, map[string]*bintree{}},
+				"generatekonnectivitycerts.sh":              {k8sCloudInitArtifactsGeneratekonnectivitycertsSh, map[string]*bintree{}},
 				"generateproxycerts.sh":                     {k8sCloudInitArtifactsGenerateproxycertsSh, map[string]*bintree{}},
 				"health-monitor.sh":                         {k8sCloudInitArtifactsHealthMonitorSh, map[string]*bintree{}},
 				"kms.service":                               {k8sCloudInitArtifactsKmsService, map[string]*bintree{}},
@@ -29269,6 +32739,8 @@ var _bintree = &bintree{nil, map[string]*bintree{
 				"profile-d-cis.sh":                          {k8sCloudInitArtifactsProfileDCisSh, map[string]*bintree{}},
 				"pwquality-CIS.conf":                        {k8sCloudInitArtifactsPwqualityCisConf, map[string]*bintree{}},
 				"rsyslog-d-60-CIS.conf":                     {k8sCloudInitArtifactsRsyslogD60CisConf, map[string]*bintree{}},
+				"scheduled-events-handler.service":          {k8sCloudInitArtifactsScheduledEventsHandlerService, map[string]*bintree{}},
+				"scheduled-events-handler.sh":               {k8sCloudInitArtifactsScheduledEventsHandlerSh, map[string]*bintree{}},
 				"setup-custom-search-domains.sh":            {k8sCloudInitArtifactsSetupCustomSearchDomainsSh, map[string]*bintree{}},
 				"sshd_config":                               {k8sCloudInitArtifactsSshd_config, map[string]*bintree{}},
 				"sshd_config_1604":                          {k8sCloudInitArtifactsSshd_config_1604, map[string]*bintree{}},
@@ -29308,24 +32780,37 @@ var _bintree = &bintree{nil, map[string]*bintree{
 			"1.8": {nil, map[string]*bintree{
 				"kubernetesmasteraddons-heapster-deployment.yaml": {k8sContaineraddons18KubernetesmasteraddonsHeapsterDeploymentYaml, map[string]*bintree{}},
 			}},
-			"azure-cni-networkmonitor.yaml":                               {k8sContaineraddonsAzureCniNetworkmonitorYaml, map[string]*bintree{}},
-			"dns-autoscaler.yaml":                                         {k8sContaineraddonsDnsAutoscalerYaml, map[string]*bintree{}},
-			"ip-masq-agent.yaml":                                          {k8sContaineraddonsIpMasqAgentYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-aad-pod-identity-deployment.yaml":     {k8sContaineraddonsKubernetesmasteraddonsAadPodIdentityDeploymentYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-aci-connector-deployment.yaml":        {k8sContaineraddonsKubernetesmasteraddonsAciConnectorDeploymentYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-azure-npm-daemonset.yaml":             {k8sContaineraddonsKubernetesmasteraddonsAzureNpmDaemonsetYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-blobfuse-flexvolume-installer.yaml":   {k8sContaineraddonsKubernetesmasteraddonsBlobfuseFlexvolumeInstallerYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-calico-daemonset.yaml":                {k8sContaineraddonsKubernetesmasteraddonsCalicoDaemonsetYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-cluster-autoscaler-deployment.yaml":   {k8sContaineraddonsKubernetesmasteraddonsClusterAutoscalerDeploymentYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-heapster-deployment.yaml":             {k8sContaineraddonsKubernetesmasteraddonsHeapsterDeploymentYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-keyvault-flexvolume-installer.yaml":   {k8sContaineraddonsKubernetesmasteraddonsKeyvaultFlexvolumeInstallerYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-kube-rescheduler-deployment.yaml":     {k8sContaineraddonsKubernetesmasteraddonsKubeReschedulerDeploymentYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-kubernetes-dashboard-deployment.yaml": {k8sContaineraddonsKubernetesmasteraddonsKubernetesDashboardDeploymentYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-metrics-server-deployment.yaml":       {k8sContaineraddonsKubernetesmasteraddonsMetricsServerDeploymentYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-nvidia-device-plugin-daemonset.yaml":  {k8sContaineraddonsKubernetesmasteraddonsNvidiaDevicePluginDaemonsetYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-omsagent-daemonset.yaml":              {k8sContaineraddonsKubernetesmasteraddonsOmsagentDaemonsetYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-smb-flexvolume-installer.yaml":        {k8sContaineraddonsKubernetesmasteraddonsSmbFlexvolumeInstallerYaml, map[string]*bintree{}},
-			"kubernetesmasteraddons-tiller-deployment.yaml":               {k8sContaineraddonsKubernetesmasteraddonsTillerDeploymentYaml, map[string]*bintree{}},
+			"azure-cni-networkmonitor.yaml":                                          {k8sContaineraddonsAzureCniNetworkmonitorYaml, map[string]*bintree{}},
+			"dns-autoscaler.yaml":                                                    {k8sContaineraddonsDnsAutoscalerYaml, map[string]*bintree{}},
+			"ip-masq-agent.yaml":                                                     {k8sContaineraddonsIpMasqAgentYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-aad-pod-identity-deployment.yaml":                {k8sContaineraddonsKubernetesmasteraddonsAadPodIdentityDeploymentYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-aci-connector-deployment.yaml":                   {k8sContaineraddonsKubernetesmasteraddonsAciConnectorDeploymentYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-antrea.yaml":                                     {k8sContaineraddonsKubernetesmasteraddonsAntreaYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-appgw-ingress-deployment.yaml":                   {k8sContaineraddonsKubernetesmasteraddonsAppgwIngressDeploymentYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-azure-npm-daemonset.yaml":                        {k8sContaineraddonsKubernetesmasteraddonsAzureNpmDaemonsetYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-azure-policy-deployment.yaml":                    {k8sContaineraddonsKubernetesmasteraddonsAzurePolicyDeploymentYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-azure-workload-identity-webhook-deployment.yaml": {k8sContaineraddonsKubernetesmasteraddonsAzureWorkloadIdentityWebhookDeploymentYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-blobfuse-flexvolume-installer.yaml":              {k8sContaineraddonsKubernetesmasteraddonsBlobfuseFlexvolumeInstallerYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-calico-daemonset.yaml":                           {k8sContaineraddonsKubernetesmasteraddonsCalicoDaemonsetYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-cilium-daemonset.yaml":                           {k8sContaineraddonsKubernetesmasteraddonsCiliumDaemonsetYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-cluster-autoscaler-deployment.yaml":              {k8sContaineraddonsKubernetesmasteraddonsClusterAutoscalerDeploymentYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-csi-secrets-store-daemonset.yaml":                {k8sContaineraddonsKubernetesmasteraddonsCsiSecretsStoreDaemonsetYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-fluent-bit-daemonset.yaml":                       {k8sContaineraddonsKubernetesmasteraddonsFluentBitDaemonsetYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-heapster-deployment.yaml":                        {k8sContaineraddonsKubernetesmasteraddonsHeapsterDeploymentYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-keda-deployment.yaml":                            {k8sContaineraddonsKubernetesmasteraddonsKedaDeploymentYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-keyvault-flexvolume-installer.yaml":              {k8sContaineraddonsKubernetesmasteraddonsKeyvaultFlexvolumeInstallerYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-konnectivity-agent-daemonset.yaml":               {k8sContaineraddonsKubernetesmasteraddonsKonnectivityAgentDaemonsetYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-kube-rescheduler-deployment.yaml":                {k8sContaineraddonsKubernetesmasteraddonsKubeReschedulerDeploymentYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-kubernetes-dashboard-deployment.yaml":            {k8sContaineraddonsKubernetesmasteraddonsKubernetesDashboardDeploymentYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-metrics-server-deployment.yaml":                  {k8sContaineraddonsKubernetesmasteraddonsMetricsServerDeploymentYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-nginx-ingress-deployment.yaml":                   {k8sContaineraddonsKubernetesmasteraddonsNginxIngressDeploymentYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-node-problem-detector-daemonset.yaml":            {k8sContaineraddonsKubernetesmasteraddonsNodeProblemDetectorDaemonsetYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-nvidia-device-plugin-daemonset.yaml":             {k8sContaineraddonsKubernetesmasteraddonsNvidiaDevicePluginDaemonsetYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-omsagent-daemonset.yaml":                         {k8sContaineraddonsKubernetesmasteraddonsOmsagentDaemonsetYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-open-service-mesh-deployment.yaml":               {k8sContaineraddonsKubernetesmasteraddonsOpenServiceMeshDeploymentYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-registry-cache-deployment.yaml":                  {k8sContaineraddonsKubernetesmasteraddonsRegistryCacheDeploymentYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-smb-flexvolume-installer.yaml":                   {k8sContaineraddonsKubernetesmasteraddonsSmbFlexvolumeInstallerYaml, map[string]*bintree{}},
+			"kubernetesmasteraddons-tiller-deployment.yaml":                          {k8sContaineraddonsKubernetesmasteraddonsTillerDeploymentYaml, map[string]*bintree{}},
 		}},
 		"kubeconfig.json":                {k8sKubeconfigJson, map[string]*bintree{}},
 		"kubernetesparams.t":             {k8sKubernetesparamsT, map[string]*bintree{}},
@@ -29333,6 +32818,7 @@ var _bintree = &bintree{nil, map[string]*bintree{
 		"kuberneteswindowssetup.ps1":     {k8sKuberneteswindowssetupPs1, map[string]*bintree{}},
 		"manifests": {nil, map[string]*bintree{
 			"kubernetesmaster-cloud-controller-manager.yaml":       {k8sManifestsKubernetesmasterCloudControllerManagerYaml, map[string]*bintree{}},
+			"kubernetesmaster-konnectivity-server.yaml":            {k8sManifestsKubernetesmasterKonnectivityServerYaml, map[string]*bintree{}},
 			"kubernetesmaster-kube-addon-manager.yaml":             {k8sManifestsKubernetesmasterKubeAddonManagerYaml, map[string]*bintree{}},
 			"kubernetesmaster-kube-apiserver.yaml":                 {k8sManifestsKubernetesmasterKubeApiserverYaml, map[string]*bintree{}},
 			"kubernetesmaster-kube-controller-manager-custom.yaml": {k8sManifestsKubernetesmasterKubeControllerManagerCustomYaml, map[string]*bintree{}},
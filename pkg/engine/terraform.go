@@ -0,0 +1,118 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TerraformConfigFileName is the name of the Terraform configuration written alongside the ARM template
+	TerraformConfigFileName = "main.tf.json"
+	// terraformAzureRMVersion is the first version of the azurerm provider with azurerm_resource_group_template_deployment
+	terraformAzureRMVersion = ">= 2.36.0"
+	// terraformResourceName is the name of the Terraform resources of the cluster
+	terraformResourceName = "cluster"
+)
+
+// GenerateTerraformConfig returns a Terraform configuration, in the JSON syntax, that deploys the cluster with the azurerm provider.
+// The resources of the cluster are deployed from the ARM template and parameters files written alongside it, so that the configuration
+// deploys exactly what an ARM deployment of the template would, and the template outputs are the outputs of the configuration.
+func GenerateTerraformConfig(cs *api.ContainerService, template, parameters string) ([]byte, error) {
+	var t struct {
+		Outputs map[string]interface{} `json:"outputs"`
+	}
+	if err := json.Unmarshal([]byte(template), &t); err != nil {
+		return nil, errors.Wrap(err, "parsing the ARM template")
+	}
+	// the parameters file is the bare parameters when it is not pretty printed
+	var p map[string]interface{}
+	if err := json.Unmarshal([]byte(parameters), &p); err != nil {
+		return nil, errors.Wrap(err, "parsing the ARM template parameters")
+	}
+	parametersFile := `jsondecode(file("${path.module}/azuredeploy.parameters.json"))`
+	if _, ok := p["contentVersion"]; ok {
+		parametersFile += ".parameters"
+	}
+
+	dnsPrefix := getTerraformDNSPrefix(cs.Properties)
+	deployment := "azurerm_resource_group_template_deployment." + terraformResourceName
+	outputs := map[string]interface{}{}
+	for name := range t.Outputs {
+		outputs[name] = map[string]interface{}{
+			"value": fmt.Sprintf("${jsondecode(%s.output_content).%s.value}", deployment, name),
+		}
+	}
+
+	config := map[string]interface{}{
+		"terraform": map[string]interface{}{
+			"required_providers": map[string]interface{}{
+				"azurerm": map[string]interface{}{
+					"source":  "hashicorp/azurerm",
+					"version": terraformAzureRMVersion,
+				},
+			},
+		},
+		"provider": map[string]interface{}{
+			"azurerm": map[string]interface{}{
+				"features": map[string]interface{}{},
+			},
+		},
+		"variable": map[string]interface{}{
+			"resource_group_name": map[string]interface{}{
+				"type":        "string",
+				"description": "The resource group the cluster is deployed to",
+				"default":     dnsPrefix,
+			},
+			"location": map[string]interface{}{
+				"type":        "string",
+				"description": "The location of the resource group",
+				"default":     cs.Location,
+			},
+		},
+		"resource": map[string]interface{}{
+			"azurerm_resource_group": map[string]interface{}{
+				terraformResourceName: map[string]interface{}{
+					"name":     "${var.resource_group_name}",
+					"location": "${var.location}",
+				},
+			},
+			"azurerm_resource_group_template_deployment": map[string]interface{}{
+				terraformResourceName: map[string]interface{}{
+					"name":                dnsPrefix,
+					"resource_group_name": fmt.Sprintf("${azurerm_resource_group.%s.name}", terraformResourceName),
+					"deployment_mode":     "Incremental",
+					"template_content":    `${file("${path.module}/azuredeploy.json")}`,
+					"parameters_content":  fmt.Sprintf("${jsonencode(%s)}", parametersFile),
+				},
+			},
+		},
+	}
+	if len(outputs) > 0 {
+		config["output"] = outputs
+	}
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(config); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func getTerraformDNSPrefix(properties *api.Properties) string {
+	if properties.MasterProfile != nil {
+		return properties.MasterProfile.DNSPrefix
+	}
+	if properties.HostedMasterProfile != nil {
+		return properties.HostedMasterProfile.DNSPrefix
+	}
+	return ""
+}
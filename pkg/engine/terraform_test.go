@@ -0,0 +1,85 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerateTerraformConfig(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.16.1", 1, 2, false)
+	cs.Location = "westus2"
+	template := `{"resources": [], "outputs": {"masterFQDN": {"type": "string", "value": "[reference('masterPublicIP').dnsSettings.fqdn]"}}}`
+
+	cases := []struct {
+		name               string
+		parameters         string
+		expectedParameters string
+	}{
+		{
+			name:               "pretty printed parameters file",
+			parameters:         `{"$schema": "https://schema.management.azure.com/schemas/2015-01-01/deploymentParameters.json#", "contentVersion": "1.0.0.0", "parameters": {}}`,
+			expectedParameters: `${jsonencode(jsondecode(file("${path.module}/azuredeploy.parameters.json")).parameters)}`,
+		},
+		{
+			name:               "bare parameters",
+			parameters:         `{"location": {"value": "westus2"}}`,
+			expectedParameters: `${jsonencode(jsondecode(file("${path.module}/azuredeploy.parameters.json")))}`,
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			b, err := GenerateTerraformConfig(cs, template, c.parameters)
+			if err != nil {
+				t.Fatalf("unexpected error generating the Terraform configuration: %s", err)
+			}
+			if strings.Contains(string(b), `\u003e`) {
+				t.Errorf("expected the Terraform configuration not to escape HTML characters:\n%s", b)
+			}
+			var config struct {
+				Terraform struct {
+					RequiredProviders map[string]map[string]string `json:"required_providers"`
+				} `json:"terraform"`
+				Variable map[string]map[string]string            `json:"variable"`
+				Resource map[string]map[string]map[string]string `json:"resource"`
+				Output   map[string]map[string]string            `json:"output"`
+			}
+			if err = json.Unmarshal(b, &config); err != nil {
+				t.Fatalf("the Terraform configuration is not valid JSON: %s\n%s", err, b)
+			}
+
+			if diff := cmp.Diff(config.Terraform.RequiredProviders["azurerm"], map[string]string{"source": "hashicorp/azurerm", "version": ">= 2.36.0"}); diff != "" {
+				t.Errorf("unexpected azurerm provider requirement: %s", diff)
+			}
+			if config.Variable["resource_group_name"]["default"] != "testmaster" || config.Variable["location"]["default"] != "westus2" {
+				t.Errorf("expected the resource group and location to default to the dns prefix and location of the cluster, got %v", config.Variable)
+			}
+			expectedDeployment := map[string]string{
+				"name":                "testmaster",
+				"resource_group_name": "${azurerm_resource_group.cluster.name}",
+				"deployment_mode":     "Incremental",
+				"template_content":    `${file("${path.module}/azuredeploy.json")}`,
+				"parameters_content":  c.expectedParameters,
+			}
+			if diff := cmp.Diff(config.Resource["azurerm_resource_group_template_deployment"]["cluster"], expectedDeployment); diff != "" {
+				t.Errorf("unexpected template deployment: %s", diff)
+			}
+			if diff := cmp.Diff(config.Output, map[string]map[string]string{
+				"masterFQDN": {"value": "${jsondecode(azurerm_resource_group_template_deployment.cluster.output_content).masterFQDN.value}"},
+			}); diff != "" {
+				t.Errorf("unexpected outputs: %s", diff)
+			}
+		})
+	}
+
+	if _, err := GenerateTerraformConfig(cs, "not a template", "{}"); err == nil {
+		t.Errorf("expected an error generating the Terraform configuration of an invalid template")
+	}
+}
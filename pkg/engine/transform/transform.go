@@ -116,6 +116,21 @@ func (t *Transformer) NormalizeForK8sSLBScalingOrUpgrade(logger *logrus.Entry, t
 
 // NormalizeForK8sVMASScalingUp takes a template and removes elements that are unwanted in a K8s VMAS scale up/down case
 func (t *Transformer) NormalizeForK8sVMASScalingUp(logger *logrus.Entry, templateMap map[string]interface{}) error {
+	return t.normalizeForK8sScalingUp(logger, templateMap, "")
+}
+
+// NormalizeForK8sAddAgentPool takes a template and removes elements that are unwanted when adding the agent pool
+// poolName to an existing cluster, like NormalizeForK8sVMASScalingUp but keeping the availability set of the new pool
+func (t *Transformer) NormalizeForK8sAddAgentPool(logger *logrus.Entry, templateMap map[string]interface{}, poolName string) error {
+	return t.normalizeForK8sScalingUp(logger, templateMap, fmt.Sprintf("variables('%sAvailabilitySet')", poolName))
+}
+
+// normalizeForK8sScalingUp removes the master resources, network resources and availability sets not needed to deploy
+// more agent VMs, but the availability set named keptAvailabilitySet if not empty
+func (t *Transformer) normalizeForK8sScalingUp(logger *logrus.Entry, templateMap map[string]interface{}, keptAvailabilitySet string) error {
+	isKept := func(s string) bool {
+		return keptAvailabilitySet != "" && strings.Contains(s, keptAvailabilitySet)
+	}
 	if err := t.NormalizeMasterResourcesForScaling(logger, templateMap); err != nil {
 		return err
 	}
@@ -160,8 +175,8 @@ func (t *Transformer) NormalizeForK8sVMASScalingUp(logger *logrus.Entry, templat
 			}
 			vnetIndex = index
 		}
-		if ok && resourceType == vmasResourceType {
-			// All availability sets can be removed
+		if ok && resourceType == vmasResourceType && !isKept(resourceName) {
+			// All availability sets but the kept one can be removed
 			vmasIndexes = append(vmasIndexes, index)
 		}
 
@@ -175,7 +190,7 @@ func (t *Transformer) NormalizeForK8sVMASScalingUp(logger *logrus.Entry, templat
 			if strings.Contains(dependency, nsgResourceType) || strings.Contains(dependency, nsgID) ||
 				strings.Contains(dependency, rtResourceType) || strings.Contains(dependency, rtID) ||
 				strings.Contains(dependency, vnetResourceType) || strings.Contains(dependency, vnetID) ||
-				strings.Contains(dependency, vmasResourceType) {
+				(strings.Contains(dependency, vmasResourceType) && !isKept(dependency)) {
 				dependencies = append(dependencies[:dIndex], dependencies[dIndex+1:]...)
 			}
 		}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/Azure/aks-engine/pkg/helpers"
@@ -32,6 +33,35 @@ func TestNormalizeForK8sVMASScalingUp(t *testing.T) {
 	ValidateTemplate(templateMap, expectedFileContents, "TestNormalizeForK8sVMASScalingUp")
 }
 
+func TestNormalizeForK8sAddAgentPool(t *testing.T) {
+	RegisterTestingT(t)
+	logger := logrus.New().WithField("testName", "TestNormalizeForK8sAddAgentPool")
+	fileContents, e := ioutil.ReadFile("./transformtestfiles/k8s_template.json")
+	Expect(e).To(BeNil())
+	var template interface{}
+	json.Unmarshal(fileContents, &template)
+	templateMap := template.(map[string]interface{})
+	transformer := Transformer{}
+	e = transformer.NormalizeForK8sAddAgentPool(logger, templateMap, "agentpool2")
+	Expect(e).To(BeNil())
+
+	availabilitySets := []string{}
+	for _, resource := range templateMap[resourcesFieldName].([]interface{}) {
+		resourceMap := resource.(map[string]interface{})
+		if resourceMap[typeFieldName] == vmasResourceType {
+			availabilitySets = append(availabilitySets, resourceMap[nameFieldName].(string))
+		}
+		if dependencies, ok := resourceMap[dependsOnFieldName].([]interface{}); ok {
+			for _, dependency := range dependencies {
+				if strings.Contains(dependency.(string), vmasResourceType) {
+					Expect(dependency).To(ContainSubstring("variables('agentpool2AvailabilitySet')"))
+				}
+			}
+		}
+	}
+	Expect(availabilitySets).To(Equal([]string{"[variables('agentpool2AvailabilitySet')]"}))
+}
+
 func TestNormalizeMasterResourcesForScaling(t *testing.T) {
 	RegisterTestingT(t)
 	logger := logrus.New().WithField("testName", "TestNormalizeMasterResourcesForScaling")
@@ -4,6 +4,9 @@
 package engine
 
 import (
+	"fmt"
+
+	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/azure-sdk-for-go/services/preview/msi/mgmt/2015-08-31-preview/msi"
 	"github.com/Azure/go-autorest/autorest/to"
 )
@@ -33,3 +36,23 @@ func createAppGwUserAssignedIdentities() UserAssignedIdentitiesARM {
 		},
 	}
 }
+
+// createAgentPoolUserAssignedIdentity returns the user assigned identity of the VMs of an agent pool, assigned to them
+// in addition to the identity of the cluster
+func createAgentPoolUserAssignedIdentity(profile *api.AgentPoolProfile) UserAssignedIdentitiesARM {
+	return UserAssignedIdentitiesARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionManagedIdentity')]",
+		},
+		Identity: msi.Identity{
+			Type:     "Microsoft.ManagedIdentity/userAssignedIdentities",
+			Name:     to.StringPtr(fmt.Sprintf("[variables('%sUserAssignedID')]", profile.Name)),
+			Location: to.StringPtr("[variables('location')]"),
+		},
+	}
+}
+
+// getAgentPoolUserAssignedIDDependency returns the dependency on the user assigned identity of an agent pool
+func getAgentPoolUserAssignedIDDependency(profile *api.AgentPoolProfile) string {
+	return fmt.Sprintf("[concat('Microsoft.ManagedIdentity/userAssignedIdentities/', variables('%sUserAssignedID'))]", profile.Name)
+}
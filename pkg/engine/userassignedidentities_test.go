@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 
+	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/azure-sdk-for-go/services/preview/msi/mgmt/2015-08-31-preview/msi"
 	"github.com/Azure/go-autorest/autorest/to"
 )
@@ -53,3 +54,24 @@ func TestCreateAppGwUserAssignedIdentities(t *testing.T) {
 		t.Errorf("unexpected diff while comparing structs: %s", diff)
 	}
 }
+
+func TestCreateAgentPoolUserAssignedIdentity(t *testing.T) {
+	expectedAssignedIdentity := UserAssignedIdentitiesARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionManagedIdentity')]",
+		},
+		Identity: msi.Identity{
+			Type:     "Microsoft.ManagedIdentity/userAssignedIdentities",
+			Name:     to.StringPtr("[variables('agentpool1UserAssignedID')]"),
+			Location: to.StringPtr("[variables('location')]"),
+		},
+	}
+
+	actual := createAgentPoolUserAssignedIdentity(&api.AgentPoolProfile{Name: "agentpool1", UserAssignedID: "agentpool1identity"})
+
+	diff := cmp.Diff(expectedAssignedIdentity, actual)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing structs: %s", diff)
+	}
+}
@@ -28,12 +28,28 @@ func CreateMasterVM(cs *api.ContainerService) VirtualMachineARM {
 	var dependencies []string
 	dependentNIC := "[concat('Microsoft.Network/networkInterfaces/', variables('masterVMNamePrefix'), 'nic-', copyIndex(variables('masterOffset')))]"
 	dependencies = append(dependencies, dependentNIC)
+	if cs.Properties.MasterProfile.HasDedicatedEtcdSubnet() {
+		dependencies = append(dependencies, "[concat('Microsoft.Network/networkInterfaces/', variables('masterVMNamePrefix'), 'etcd-nic-', copyIndex(variables('masterOffset')))]")
+	}
 	if !hasAvailabilityZones {
 		dependencies = append(dependencies, "[concat('Microsoft.Compute/availabilitySets/',variables('masterAvailabilitySet'))]")
 	}
 	if isStorageAccount {
 		dependencies = append(dependencies, "[variables('masterStorageAccountName')]")
 	}
+	hasUltraSSD := cs.Properties.MasterProfile.HasUltraSSD() && !cs.Properties.MasterProfile.HasCosmosEtcd()
+	if hasUltraSSD {
+		dependencies = append(dependencies, "[resourceId('Microsoft.Compute/disks', concat(variables('masterVMNamePrefix'), copyIndex(variables('masterOffset')),'-etcddisk'))]")
+	}
+	// The masters in the availability set are placed in its proximity placement group
+	var ppg *compute.SubResource
+	if hasAvailabilityZones {
+		var ppgDependency string
+		ppg, ppgDependency = getProximityPlacementGroupReference(cs.Properties.MasterProfile.ProximityPlacementGroupID, cs.Properties.MasterProfile.CreateProximityPlacementGroup)
+		if ppgDependency != "" {
+			dependencies = append(dependencies, ppgDependency)
+		}
+	}
 
 	armResource := ARMResource{
 		APIVersion: "[variables('apiVersionCompute')]",
@@ -97,6 +113,12 @@ func CreateMasterVM(cs *api.ContainerService) VirtualMachineARM {
 			ID: to.StringPtr("[resourceId('Microsoft.Compute/availabilitySets',variables('masterAvailabilitySet'))]"),
 		}
 	}
+	vmProperties.ProximityPlacementGroup = ppg
+	if hasUltraSSD {
+		vmProperties.AdditionalCapabilities = &compute.AdditionalCapabilities{
+			UltraSSDEnabled: to.BoolPtr(true),
+		}
+	}
 
 	vmProperties.HardwareProfile = &compute.HardwareProfile{
 		VMSize: compute.VirtualMachineSizeTypes(cs.Properties.MasterProfile.VMSize),
@@ -109,6 +131,23 @@ func CreateMasterVM(cs *api.ContainerService) VirtualMachineARM {
 			},
 		},
 	}
+	// With a dedicated etcd subnet the masters have a second NIC, Azure requires the primary one to be marked
+	if cs.Properties.MasterProfile.HasDedicatedEtcdSubnet() {
+		vmProperties.NetworkProfile.NetworkInterfaces = &[]compute.NetworkInterfaceReference{
+			{
+				ID: to.StringPtr("[resourceId('Microsoft.Network/networkInterfaces',concat(variables('masterVMNamePrefix'),'nic-', copyIndex(variables('masterOffset'))))]"),
+				NetworkInterfaceReferenceProperties: &compute.NetworkInterfaceReferenceProperties{
+					Primary: to.BoolPtr(true),
+				},
+			},
+			{
+				ID: to.StringPtr("[resourceId('Microsoft.Network/networkInterfaces',concat(variables('masterVMNamePrefix'),'etcd-nic-', copyIndex(variables('masterOffset'))))]"),
+				NetworkInterfaceReferenceProperties: &compute.NetworkInterfaceReferenceProperties{
+					Primary: to.BoolPtr(false),
+				},
+			},
+		}
+	}
 
 	osProfile := &compute.OSProfile{
 		AdminUsername: to.StringPtr("[parameters('linuxAdminUsername')]"),
@@ -169,10 +208,25 @@ func CreateMasterVM(cs *api.ContainerService) VirtualMachineARM {
 			Lun:          to.Int32Ptr(0),
 			Name:         to.StringPtr("[concat(variables('masterVMNamePrefix'), copyIndex(variables('masterOffset')),'-etcddisk')]"),
 		}
-		if cs.Properties.MasterProfile.IsStorageAccount() {
+		if hasUltraSSD {
+			// the Ultra SSD etcd disks are created by createMasterEtcdDisks
+			dataDisk = compute.DataDisk{
+				CreateOption: compute.DiskCreateOptionTypesAttach,
+				Caching:      compute.CachingTypesNone,
+				Lun:          to.Int32Ptr(0),
+				ManagedDisk: &compute.ManagedDiskParameters{
+					ID:                 to.StringPtr("[resourceId('Microsoft.Compute/disks', concat(variables('masterVMNamePrefix'), copyIndex(variables('masterOffset')),'-etcddisk'))]"),
+					StorageAccountType: compute.StorageAccountTypesUltraSSDLRS,
+				},
+			}
+		} else if cs.Properties.MasterProfile.IsStorageAccount() {
 			dataDisk.Vhd = &compute.VirtualHardDisk{
 				URI: to.StringPtr("[concat(reference(concat('Microsoft.Storage/storageAccounts/',variables('masterStorageAccountName')),variables('apiVersionStorage')).primaryEndpoints.blob,'vhds/', variables('masterVMNamePrefix'),copyIndex(variables('masterOffset')),'-etcddisk.vhd')]"),
 			}
+		} else if cs.Properties.MasterProfile.DataDiskStorageAccountType != "" {
+			dataDisk.ManagedDisk = &compute.ManagedDiskParameters{
+				StorageAccountType: compute.StorageAccountTypes(cs.Properties.MasterProfile.DataDiskStorageAccountType),
+			}
 		}
 		storageProfile.DataDisks = &[]compute.DataDisk{
 			dataDisk,
@@ -183,7 +237,7 @@ func CreateMasterVM(cs *api.ContainerService) VirtualMachineARM {
 		if cs.Properties.MasterProfile.HasImageGallery() {
 			imgReference.ID = to.StringPtr(fmt.Sprintf("[concat('/subscriptions/', '%s', '/resourceGroups/', parameters('osImageResourceGroup'), '/providers/Microsoft.Compute/galleries/', '%s', '/images/', parameters('osImageName'), '/versions/', '%s')]", imageRef.SubscriptionID, imageRef.Gallery, imageRef.Version))
 		} else {
-			imgReference.ID = to.StringPtr("[resourceId(parameters('osImageResourceGroup'), 'Microsoft.Compute/images', parameters('osImageName'))]")
+			imgReference.ID = to.StringPtr(imageResourceID(imageRef, "parameters('osImageResourceGroup')", "parameters('osImageName')"))
 		}
 	} else {
 		imgReference.Offer = to.StringPtr("[parameters('osImageOffer')]")
@@ -339,9 +393,22 @@ func createAgentAvailabilitySetVM(cs *api.ContainerService, profile *api.AgentPo
 	}
 
 	dependencies = append(dependencies, fmt.Sprintf("[concat('Microsoft.Network/networkInterfaces/', variables('%[1]sVMNamePrefix'), 'nic-', copyIndex(variables('%[1]sOffset')))]", profile.Name))
+	if profile.HasPodSubnet() {
+		dependencies = append(dependencies, fmt.Sprintf("[concat('Microsoft.Network/networkInterfaces/', variables('%[1]sVMNamePrefix'), 'pod-nic-', copyIndex(variables('%[1]sOffset')))]", profile.Name))
+	}
 
 	dependencies = append(dependencies, fmt.Sprintf("[concat('Microsoft.Compute/availabilitySets/', variables('%[1]sAvailabilitySet'))]", profile.Name))
 
+	if profile.HasSharedDisks() {
+		for i := range profile.DiskSizesGB {
+			dependencies = append(dependencies, fmt.Sprintf("[resourceId('Microsoft.Compute/disks', %s)]", getSharedDataDiskName(profile, i)))
+		}
+	}
+
+	if profile.HasUserAssignedID() {
+		dependencies = append(dependencies, getAgentPoolUserAssignedIDDependency(profile))
+	}
+
 	if profile.IsWindows() {
 		windowsProfile := cs.Properties.WindowsProfile
 		// Add dependency for Image resource created by createWindowsImage()
@@ -387,6 +454,23 @@ func createAgentAvailabilitySetVM(cs *api.ContainerService, profile *api.AgentPo
 		},
 		Tags: tags,
 	}
+	// With a pod subnet the nodes have a second NIC, Azure requires the primary one to be marked
+	if profile.HasPodSubnet() {
+		virtualMachine.NetworkProfile.NetworkInterfaces = &[]compute.NetworkInterfaceReference{
+			{
+				ID: to.StringPtr(fmt.Sprintf("[resourceId('Microsoft.Network/networkInterfaces',concat(variables('%[1]sVMNamePrefix'), 'nic-', copyIndex(variables('%[1]sOffset'))))]", profile.Name)),
+				NetworkInterfaceReferenceProperties: &compute.NetworkInterfaceReferenceProperties{
+					Primary: to.BoolPtr(true),
+				},
+			},
+			{
+				ID: to.StringPtr(fmt.Sprintf("[resourceId('Microsoft.Network/networkInterfaces',concat(variables('%[1]sVMNamePrefix'), 'pod-nic-', copyIndex(variables('%[1]sOffset'))))]", profile.Name)),
+				NetworkInterfaceReferenceProperties: &compute.NetworkInterfaceReferenceProperties{
+					Primary: to.BoolPtr(false),
+				},
+			},
+		}
+	}
 
 	addCustomTagsToVM(profile.CustomVMTags, &virtualMachine)
 
@@ -405,6 +489,21 @@ func createAgentAvailabilitySetVM(cs *api.ContainerService, profile *api.AgentPo
 		}
 	}
 
+	// the identity of the pool is assigned in addition to the one of the cluster
+	if profile.HasUserAssignedID() {
+		if virtualMachine.Identity == nil {
+			virtualMachine.Identity = &compute.VirtualMachineIdentity{
+				Type: compute.ResourceIdentityTypeUserAssigned,
+			}
+		} else if virtualMachine.Identity.Type == compute.ResourceIdentityTypeSystemAssigned {
+			virtualMachine.Identity.Type = compute.ResourceIdentityTypeSystemAssignedUserAssigned
+		}
+		if virtualMachine.Identity.UserAssignedIdentities == nil {
+			virtualMachine.Identity.UserAssignedIdentities = map[string]*compute.VirtualMachineIdentityUserAssignedIdentitiesValue{}
+		}
+		virtualMachine.Identity.UserAssignedIdentities[fmt.Sprintf("[variables('%sUserAssignedIDReference')]", profile.Name)] = &compute.VirtualMachineIdentityUserAssignedIdentitiesValue{}
+	}
+
 	virtualMachine.AvailabilitySet = &compute.SubResource{
 		ID: to.StringPtr(fmt.Sprintf("[resourceId('Microsoft.Compute/availabilitySets',variables('%sAvailabilitySet'))]", profile.Name)),
 	}
@@ -493,7 +592,7 @@ func createAgentAvailabilitySetVM(cs *api.ContainerService, profile *api.AgentPo
 				}
 			} else {
 				storageProfile.ImageReference = &compute.ImageReference{
-					ID: to.StringPtr(fmt.Sprintf("[resourceId(variables('%[1]sosImageResourceGroup'), 'Microsoft.Compute/images', variables('%[1]sosImageName'))]", profile.Name)),
+					ID: to.StringPtr(imageResourceID(imageRef, fmt.Sprintf("variables('%sosImageResourceGroup')", profile.Name), fmt.Sprintf("variables('%sosImageName')", profile.Name))),
 				}
 			}
 		} else {
@@ -543,6 +642,18 @@ func createAgentAvailabilitySetVM(cs *api.ContainerService, profile *api.AgentPo
 func getArmDataDisks(profile *api.AgentPoolProfile) *[]compute.DataDisk {
 	var dataDisks []compute.DataDisk
 	for i, diskSize := range profile.DiskSizesGB {
+		if profile.HasSharedDisks() {
+			// the shared disks are created by createSharedDataDisks, and do not support host caching
+			dataDisks = append(dataDisks, compute.DataDisk{
+				Lun:          to.Int32Ptr(int32(i)),
+				CreateOption: compute.DiskCreateOptionTypesAttach,
+				Caching:      compute.CachingTypesNone,
+				ManagedDisk: &compute.ManagedDiskParameters{
+					ID: to.StringPtr(fmt.Sprintf("[resourceId('Microsoft.Compute/disks', %s)]", getSharedDataDiskName(profile, i))),
+				},
+			})
+			continue
+		}
 		dataDisk := compute.DataDisk{
 			DiskSizeGB:   to.Int32Ptr(int32(diskSize)),
 			Lun:          to.Int32Ptr(int32(i)),
@@ -555,6 +666,10 @@ func getArmDataDisks(profile *api.AgentPoolProfile) *[]compute.DataDisk {
 				URI: to.StringPtr(fmt.Sprintf("[concat('http://',variables('storageAccountPrefixes')[mod(add(add(div(copyIndex(),variables('maxVMsPerStorageAccount')),variables('%sStorageAccountOffset')),variables('dataStorageAccountPrefixSeed')),variables('storageAccountPrefixesCount'))],variables('storageAccountPrefixes')[div(add(add(div(copyIndex(),variables('maxVMsPerStorageAccount')),variables('%sStorageAccountOffset')),variables('dataStorageAccountPrefixSeed')),variables('storageAccountPrefixesCount'))],variables('%sDataAccountName'),'.blob.core.windows.net/vhds/',variables('%sVMNamePrefix'),copyIndex(), '--datadisk%d.vhd')]",
 					profile.Name, profile.Name, profile.Name, profile.Name, i)),
 			}
+		} else if profile.DataDiskStorageAccountType != "" {
+			dataDisk.ManagedDisk = &compute.ManagedDiskParameters{
+				StorageAccountType: compute.StorageAccountTypes(profile.DataDiskStorageAccountType),
+			}
 		}
 		dataDisks = append(dataDisks, dataDisk)
 	}
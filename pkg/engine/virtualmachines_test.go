@@ -177,6 +177,33 @@ func TestCreateVirtualMachines(t *testing.T) {
 	if diff != "" {
 		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
 	}
+
+	// Test with Ultra SSD etcd disks
+	cs.Properties.MasterProfile.DataDiskStorageAccountType = api.UltraSSDDataDisk
+
+	actualVM = CreateMasterVM(cs)
+
+	etcdDiskID := "[resourceId('Microsoft.Compute/disks', concat(variables('masterVMNamePrefix'), copyIndex(variables('masterOffset')),'-etcddisk'))]"
+	expectedDataDisks := &[]compute.DataDisk{
+		{
+			Lun:          to.Int32Ptr(0),
+			CreateOption: compute.DiskCreateOptionTypesAttach,
+			Caching:      compute.CachingTypesNone,
+			ManagedDisk: &compute.ManagedDiskParameters{
+				ID:                 to.StringPtr(etcdDiskID),
+				StorageAccountType: compute.StorageAccountTypesUltraSSDLRS,
+			},
+		},
+	}
+	if diff = cmp.Diff(actualVM.StorageProfile.DataDisks, expectedDataDisks); diff != "" {
+		t.Errorf("unexpected diff while comparing the etcd data disks: %s", diff)
+	}
+	if !to.Bool(actualVM.AdditionalCapabilities.UltraSSDEnabled) {
+		t.Errorf("expected the master VMs to have Ultra SSDs enabled")
+	}
+	if actualVM.DependsOn[len(actualVM.DependsOn)-1] != etcdDiskID {
+		t.Errorf("expected the master VMs to depend on %s, got %v", etcdDiskID, actualVM.DependsOn)
+	}
 }
 
 func TestCreateAgentAvailabilitySetVM(t *testing.T) {
@@ -297,6 +324,131 @@ func TestCreateAgentAvailabilitySetVM(t *testing.T) {
 	if diff != "" {
 		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
 	}
+
+	// Test with shared Premium SSD data disks
+	profile.StorageProfile = api.ManagedDisks
+	profile.DiskSizesGB = []int{256, 512}
+	profile.DataDiskStorageAccountType = api.PremiumSSDDataDisk
+	profile.EnableSharedDisk = to.BoolPtr(true)
+
+	actualVM = createAgentAvailabilitySetVM(cs, profile)
+
+	expectedDataDisks := &[]compute.DataDisk{
+		{
+			Lun:          to.Int32Ptr(0),
+			CreateOption: compute.DiskCreateOptionTypesAttach,
+			Caching:      compute.CachingTypesNone,
+			ManagedDisk: &compute.ManagedDiskParameters{
+				ID: to.StringPtr("[resourceId('Microsoft.Compute/disks', concat(variables('agentpool1VMNamePrefix'), 'shareddisk0'))]"),
+			},
+		},
+		{
+			Lun:          to.Int32Ptr(1),
+			CreateOption: compute.DiskCreateOptionTypesAttach,
+			Caching:      compute.CachingTypesNone,
+			ManagedDisk: &compute.ManagedDiskParameters{
+				ID: to.StringPtr("[resourceId('Microsoft.Compute/disks', concat(variables('agentpool1VMNamePrefix'), 'shareddisk1'))]"),
+			},
+		},
+	}
+	if diff = cmp.Diff(actualVM.StorageProfile.DataDisks, expectedDataDisks); diff != "" {
+		t.Errorf("unexpected diff while comparing the shared data disks: %s", diff)
+	}
+	expectedDependencies := []string{
+		"[resourceId('Microsoft.Compute/disks', concat(variables('agentpool1VMNamePrefix'), 'shareddisk0'))]",
+		"[resourceId('Microsoft.Compute/disks', concat(variables('agentpool1VMNamePrefix'), 'shareddisk1'))]",
+	}
+	if diff = cmp.Diff(actualVM.DependsOn[len(actualVM.DependsOn)-2:], expectedDependencies); diff != "" {
+		t.Errorf("expected the VM to depend on its shared data disks: %s", diff)
+	}
+
+	// Test with Standard SSD data disks
+	profile.DataDiskStorageAccountType = api.StandardSSDDataDisk
+	profile.EnableSharedDisk = nil
+
+	actualVM = createAgentAvailabilitySetVM(cs, profile)
+
+	for _, dataDisk := range *actualVM.StorageProfile.DataDisks {
+		if dataDisk.CreateOption != compute.DiskCreateOptionTypesEmpty || dataDisk.ManagedDisk == nil || dataDisk.ManagedDisk.StorageAccountType != compute.StorageAccountTypesStandardSSDLRS {
+			t.Errorf("expected an empty StandardSSD_LRS data disk, got %v", dataDisk)
+		}
+	}
+
+	// Test with the user assigned identity of the pool
+	profile.UserAssignedID = "agentpool1identity"
+
+	actualVM = createAgentAvailabilitySetVM(cs, profile)
+
+	expectedIdentity := &compute.VirtualMachineIdentity{
+		Type: compute.ResourceIdentityTypeUserAssigned,
+		UserAssignedIdentities: map[string]*compute.VirtualMachineIdentityUserAssignedIdentitiesValue{
+			"[variables('agentpool1UserAssignedIDReference')]": {},
+		},
+	}
+	if diff = cmp.Diff(actualVM.Identity, expectedIdentity); diff != "" {
+		t.Errorf("unexpected diff while comparing the identity of the VM: %s", diff)
+	}
+	if dependency := actualVM.DependsOn[len(actualVM.DependsOn)-1]; dependency != "[concat('Microsoft.ManagedIdentity/userAssignedIdentities/', variables('agentpool1UserAssignedID'))]" {
+		t.Errorf("expected the VM to depend on the identity of the pool, got %s", dependency)
+	}
+
+	// Test with managed identity, the Windows VMs of the pool keep their system assigned identity
+	cs.Properties.OrchestratorProfile.KubernetesConfig.UseManagedIdentity = true
+	cs.Properties.OrchestratorProfile.KubernetesConfig.UserAssignedID = "fooAssignedID"
+
+	actualVM = createAgentAvailabilitySetVM(cs, profile)
+
+	expectedIdentity.Type = compute.ResourceIdentityTypeSystemAssignedUserAssigned
+	if diff = cmp.Diff(actualVM.Identity, expectedIdentity); diff != "" {
+		t.Errorf("unexpected diff while comparing the identity of the VM: %s", diff)
+	}
+}
+
+func TestCreateAgentAvailabilitySetVMWithPodSubnet(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.16.1", 1, 2, false)
+	profile := cs.Properties.AgentPoolProfiles[0]
+	profile.AvailabilityProfile = api.AvailabilitySet
+	profile.VnetSubnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/AGENT_SUBNET_NAME"
+	profile.PodSubnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/POD_SUBNET_NAME"
+	cs.Properties.MasterProfile.VnetSubnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME"
+	cs.Properties.MasterProfile.FirstConsecutiveStaticIP = "10.239.255.239"
+	if _, err := cs.SetPropertiesDefaults(false, false); err != nil {
+		t.Fatalf("unexpected error setting the defaults: %s", err)
+	}
+
+	vm := createAgentAvailabilitySetVM(cs, profile)
+
+	expectedNICs := []compute.NetworkInterfaceReference{
+		{
+			ID: to.StringPtr("[resourceId('Microsoft.Network/networkInterfaces',concat(variables('agentpool1VMNamePrefix'), 'nic-', copyIndex(variables('agentpool1Offset'))))]"),
+			NetworkInterfaceReferenceProperties: &compute.NetworkInterfaceReferenceProperties{
+				Primary: to.BoolPtr(true),
+			},
+		},
+		{
+			ID: to.StringPtr("[resourceId('Microsoft.Network/networkInterfaces',concat(variables('agentpool1VMNamePrefix'), 'pod-nic-', copyIndex(variables('agentpool1Offset'))))]"),
+			NetworkInterfaceReferenceProperties: &compute.NetworkInterfaceReferenceProperties{
+				Primary: to.BoolPtr(false),
+			},
+		},
+	}
+
+	diff := cmp.Diff(*vm.NetworkProfile.NetworkInterfaces, expectedNICs)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
+	}
+
+	podNICDependency := "[concat('Microsoft.Network/networkInterfaces/', variables('agentpool1VMNamePrefix'), 'pod-nic-', copyIndex(variables('agentpool1Offset')))]"
+	var hasPodNICDependency bool
+	for _, dependency := range vm.DependsOn {
+		if dependency == podNICDependency {
+			hasPodNICDependency = true
+		}
+	}
+	if !hasPodNICDependency {
+		t.Errorf("expected the VM to depend on its pod NIC, instead got %v", vm.DependsOn)
+	}
 }
 
 func TestCreateVmWithCustomTags(t *testing.T) {
@@ -52,6 +52,11 @@ func CreateMasterVMSS(cs *api.ContainerService) VirtualMachineScaleSetARM {
 		dependencies = append(dependencies, "[variables('masterLbID')]")
 	}
 
+	ppg, ppgDependency := getProximityPlacementGroupReference(masterProfile.ProximityPlacementGroupID, masterProfile.CreateProximityPlacementGroup)
+	if ppgDependency != "" {
+		dependencies = append(dependencies, ppgDependency)
+	}
+
 	armResource := ARMResource{
 		APIVersion: "[variables('apiVersionCompute')]",
 		DependsOn:  dependencies,
@@ -106,6 +111,7 @@ func CreateMasterVMSS(cs *api.ContainerService) VirtualMachineScaleSetARM {
 	vmProperties := &compute.VirtualMachineScaleSetProperties{}
 
 	vmProperties.SinglePlacementGroup = masterProfile.SinglePlacementGroup
+	vmProperties.ProximityPlacementGroup = ppg
 	vmProperties.Overprovision = to.BoolPtr(false)
 	vmProperties.UpgradePolicy = &compute.UpgradePolicy{
 		Mode: compute.Manual,
@@ -166,11 +172,9 @@ func CreateMasterVMSS(cs *api.ContainerService) VirtualMachineScaleSetARM {
 	}
 	netintconfig.IPConfigurations = &ipConfigurations
 
-	if linuxProfile != nil && linuxProfile.HasCustomNodesDNS() {
+	if dnsServers := getNodeDNSServers(masterProfile.DNSConfig, linuxProfile, false); dnsServers != nil {
 		netintconfig.DNSSettings = &compute.VirtualMachineScaleSetNetworkConfigurationDNSSettings{
-			DNSServers: &[]string{
-				"[parameters('dnsServer')]",
-			},
+			DNSServers: dnsServers,
 		}
 	}
 
@@ -239,6 +243,14 @@ func CreateMasterVMSS(cs *api.ContainerService) VirtualMachineScaleSetARM {
 		DiskSizeGB:   to.Int32Ptr(int32(etcdSizeGB)),
 		Lun:          to.Int32Ptr(0),
 	}
+	if masterProfile.DataDiskStorageAccountType != "" {
+		dataDisk.ManagedDisk = &compute.VirtualMachineScaleSetManagedDiskParameters{
+			StorageAccountType: compute.StorageAccountTypes(masterProfile.DataDiskStorageAccountType),
+		}
+	}
+	if masterProfile.HasUltraSSD() {
+		dataDisk.Caching = compute.CachingTypesNone
+	}
 	storageProfile.DataDisks = &[]compute.VirtualMachineScaleSetDataDisk{
 		dataDisk,
 	}
@@ -247,7 +259,7 @@ func CreateMasterVMSS(cs *api.ContainerService) VirtualMachineScaleSetARM {
 		if masterProfile.HasImageGallery() {
 			imgReference.ID = to.StringPtr(fmt.Sprintf("[concat('/subscriptions/', '%s',  '/resourceGroups/', parameters('osImageResourceGroup'), '/providers/Microsoft.Compute/galleries/', '%s', '/images/', parameters('osImageName'), '/versions/', '%s')]", imageRef.SubscriptionID, imageRef.Gallery, imageRef.Version))
 		} else {
-			imgReference.ID = to.StringPtr("[resourceId(parameters('osImageResourceGroup'), 'Microsoft.Compute/images', parameters('osImageName'))]")
+			imgReference.ID = to.StringPtr(imageResourceID(imageRef, "parameters('osImageResourceGroup')", "parameters('osImageName')"))
 		}
 	} else {
 		imgReference.Offer = to.StringPtr("[parameters('osImageOffer')]")
@@ -312,12 +324,13 @@ func CreateMasterVMSS(cs *api.ContainerService) VirtualMachineScaleSetARM {
 			AutoUpgradeMinorVersion: to.BoolPtr(true),
 			Settings:                map[string]interface{}{},
 			ProtectedSettings: map[string]interface{}{
-				"commandToExecute": fmt.Sprintf("[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; "+outBoundCmd+" for i in $(seq 1 1200); do grep -Fq \"EOF\" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),%s,variables('provisionScriptParametersMaster'), ' /usr/bin/nohup /bin/bash -c \"/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1\"')]", generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled)),
+				"commandToExecute": fmt.Sprintf("[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; "+outBoundCmd+" for i in $(seq 1 1200); do grep -Fq \"EOF\" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),%s,variables('provisionScriptParametersMaster')%s, ' /usr/bin/nohup /bin/bash -c \"/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1\"')]", generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled), generateMasterAddonsParameters(cs)),
 			},
 		},
 	}
 
 	extensions = append(extensions, vmssCSE)
+	extensions = append(extensions, createVMSSVMExtensions(masterProfile.VMExtensions, "[concat(variables('masterVMNamePrefix'), 'vmssCSE')]")...)
 
 	if cs.IsAKSBillingEnabled() {
 		aksBillingExtension := compute.VirtualMachineScaleSetExtension{
@@ -343,12 +356,22 @@ func CreateMasterVMSS(cs *api.ContainerService) VirtualMachineScaleSetARM {
 		StorageProfile:   &storageProfile,
 		ExtensionProfile: &extensionProfile,
 	}
+	if masterProfile.HasUltraSSD() {
+		vmProperties.VirtualMachineProfile.AdditionalCapabilities = &compute.AdditionalCapabilities{
+			UltraSSDEnabled: to.BoolPtr(true),
+		}
+		if masterProfile.DataDiskIOPSReadWrite != 0 || masterProfile.DataDiskMBpsReadWrite != 0 {
+			armResource.APIVersion = ultraSSDAPIVersionCompute
+		}
+	}
 
 	virtualMachine.VirtualMachineScaleSetProperties = vmProperties
 
 	return VirtualMachineScaleSetARM{
 		ARMResource:            armResource,
 		VirtualMachineScaleSet: virtualMachine,
+		DataDiskIOPSReadWrite:  masterProfile.DataDiskIOPSReadWrite,
+		DataDiskMBpsReadWrite:  masterProfile.DataDiskMBpsReadWrite,
 	}
 }
 
@@ -367,7 +390,7 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 
 	if !cs.Properties.OrchestratorProfile.IsPrivateCluster() &&
 		profile.LoadBalancerBackendAddressPoolIDs == nil &&
-		cs.Properties.OrchestratorProfile.KubernetesConfig.LoadBalancerSku == api.StandardLoadBalancerSku &&
+		cs.Properties.OrchestratorProfile.KubernetesConfig.HasOutboundLoadBalancer() &&
 		!isHostedMaster {
 		dependencies = append(dependencies, "[variables('agentLbID')]")
 	}
@@ -380,12 +403,41 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 		}
 	}
 
+	ppg, ppgDependency := getProximityPlacementGroupReference(profile.ProximityPlacementGroupID, profile.CreateProximityPlacementGroup)
+	if ppgDependency != "" {
+		dependencies = append(dependencies, ppgDependency)
+	}
+
+	if profile.HasUserAssignedID() {
+		dependencies = append(dependencies, getAgentPoolUserAssignedIDDependency(profile))
+	}
+
 	orchProfile := cs.Properties.OrchestratorProfile
 	k8sConfig := orchProfile.KubernetesConfig
 	linuxProfile := cs.Properties.LinuxProfile
 
 	armResource.DependsOn = dependencies
 
+	var terminateNotificationTimeout string
+	if profile.HasTerminateNotification() {
+		armResource.APIVersion = terminateNotificationAPIVersionCompute
+		terminateNotificationTimeout = fmt.Sprintf("PT%dM", profile.ScheduledEventsProfile.TerminateNotificationTimeoutMinutes)
+	}
+
+	var spotMaxPrice *float64
+	if profile.IsSpotScaleSet() {
+		armResource.APIVersion = spotAPIVersionCompute
+		spotMaxPrice = profile.SpotMaxPrice
+	}
+
+	if profile.HasUltraSSD() && (profile.DataDiskIOPSReadWrite != 0 || profile.DataDiskMBpsReadWrite != 0) {
+		armResource.APIVersion = ultraSSDAPIVersionCompute
+	}
+
+	if profile.HasHostGroup() {
+		armResource.APIVersion = hostGroupAPIVersionCompute
+	}
+
 	var resourceNameSuffix *string
 
 	if profile.IsWindows() {
@@ -400,6 +452,9 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 		"poolName":           to.StringPtr(profile.Name),
 		"resourceNameSuffix": resourceNameSuffix,
 	}
+	for key, value := range getClusterAutoscalerScaleSetTags(cs.Properties, profile) {
+		tags[key] = value
+	}
 
 	virtualMachineScaleSet := compute.VirtualMachineScaleSet{
 		Name:     to.StringPtr(fmt.Sprintf("[variables('%sVMNamePrefix')]", profile.Name)),
@@ -439,9 +494,25 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 		}
 	}
 
+	// the identity of the pool is assigned in addition to the one of the cluster
+	if profile.HasUserAssignedID() {
+		if virtualMachineScaleSet.Identity == nil {
+			virtualMachineScaleSet.Identity = &compute.VirtualMachineScaleSetIdentity{
+				Type: compute.ResourceIdentityTypeUserAssigned,
+			}
+		} else if virtualMachineScaleSet.Identity.Type == compute.ResourceIdentityTypeSystemAssigned {
+			virtualMachineScaleSet.Identity.Type = compute.ResourceIdentityTypeSystemAssignedUserAssigned
+		}
+		if virtualMachineScaleSet.Identity.UserAssignedIdentities == nil {
+			virtualMachineScaleSet.Identity.UserAssignedIdentities = map[string]*compute.VirtualMachineScaleSetIdentityUserAssignedIdentitiesValue{}
+		}
+		virtualMachineScaleSet.Identity.UserAssignedIdentities[fmt.Sprintf("[variables('%sUserAssignedIDReference')]", profile.Name)] = &compute.VirtualMachineScaleSetIdentityUserAssignedIdentitiesValue{}
+	}
+
 	vmssProperties := compute.VirtualMachineScaleSetProperties{
-		SinglePlacementGroup: profile.SinglePlacementGroup,
-		Overprovision:        profile.VMSSOverProvisioningEnabled,
+		SinglePlacementGroup:    profile.SinglePlacementGroup,
+		ProximityPlacementGroup: ppg,
+		Overprovision:           profile.VMSSOverProvisioningEnabled,
 		UpgradePolicy: &compute.UpgradePolicy{
 			Mode: compute.Manual,
 		},
@@ -451,9 +522,24 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 		vmssProperties.DoNotRunExtensionsOnOverprovisionedVMs = to.BoolPtr(true)
 	}
 
+	if profile.HasAvailabilityZones() && profile.ZoneBalanceStrategy != "" {
+		vmssProperties.ZoneBalance = to.BoolPtr(profile.IsZoneBalanced())
+	}
+
+	// The fault domains of the scale set are mapped to the ones of its host group
+	if profile.HasHostGroup() && profile.PlatformFaultDomainCount != nil {
+		vmssProperties.PlatformFaultDomainCount = to.Int32Ptr(int32(*profile.PlatformFaultDomainCount))
+	}
+
 	vmssVMProfile := compute.VirtualMachineScaleSetVMProfile{}
 
-	if profile.IsLowPriorityScaleSet() {
+	if profile.HasUltraSSD() {
+		vmssVMProfile.AdditionalCapabilities = &compute.AdditionalCapabilities{
+			UltraSSDEnabled: to.BoolPtr(true),
+		}
+	}
+
+	if profile.IsLowPriorityScaleSet() || profile.IsSpotScaleSet() {
 		vmssVMProfile.Priority = compute.VirtualMachinePriorityTypes(fmt.Sprintf("[variables('%sScaleSetPriority')]", profile.Name))
 		vmssVMProfile.EvictionPolicy = compute.VirtualMachineEvictionPolicyTypes(fmt.Sprintf("[variables('%sScaleSetEvictionPolicy')]", profile.Name))
 	}
@@ -476,9 +562,15 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 		}
 	}
 
+	// With a pod subnet the pod IPs are on the pod NIC, the node NIC only has the IP of the node
+	ipAddressCount := profile.IPAddressCount
+	if profile.HasPodSubnet() {
+		ipAddressCount = 1
+	}
+
 	var ipConfigurations []compute.VirtualMachineScaleSetIPConfiguration
 
-	for i := 1; i <= profile.IPAddressCount; i++ {
+	for i := 1; i <= ipAddressCount; i++ {
 		ipconfig := compute.VirtualMachineScaleSetIPConfiguration{
 			Name: to.StringPtr(fmt.Sprintf("ipconfig%d", i)),
 		}
@@ -502,7 +594,7 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 				}
 			} else {
 				if !cs.Properties.OrchestratorProfile.IsPrivateCluster() &&
-					cs.Properties.OrchestratorProfile.KubernetesConfig.LoadBalancerSku == api.StandardLoadBalancerSku &&
+					cs.Properties.OrchestratorProfile.KubernetesConfig.HasOutboundLoadBalancer() &&
 					!isHostedMaster {
 					agentLbBackendAddressPools := compute.SubResource{
 						ID: to.StringPtr("[concat(variables('agentLbID'), '/backendAddressPools/', variables('agentLbBackendPoolName'))]"),
@@ -563,11 +655,9 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 
 	vmssNICConfig.IPConfigurations = &ipConfigurations
 
-	if linuxProfile != nil && linuxProfile.HasCustomNodesDNS() && !profile.IsWindows() {
+	if dnsServers := getNodeDNSServers(profile.DNSConfig, linuxProfile, profile.IsWindows()); dnsServers != nil {
 		vmssNICConfig.DNSSettings = &compute.VirtualMachineScaleSetNetworkConfigurationDNSSettings{
-			DNSServers: &[]string{
-				"[parameters('dnsServer')]",
-			},
+			DNSServers: dnsServers,
 		}
 	}
 
@@ -581,6 +671,10 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 		},
 	}
 
+	if profile.HasPodSubnet() {
+		*vmssNetworkProfile.NetworkInterfaceConfigurations = append(*vmssNetworkProfile.NetworkInterfaceConfigurations, getVMSSPodNICConfig(profile))
+	}
+
 	vmssVMProfile.NetworkProfile = &vmssNetworkProfile
 
 	t, err := InitializeTemplateGenerator(Context{})
@@ -661,7 +755,7 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 				}
 			} else {
 				vmssStorageProfile.ImageReference = &compute.ImageReference{
-					ID: to.StringPtr(fmt.Sprintf("[resourceId(variables('%[1]sosImageResourceGroup'), 'Microsoft.Compute/images', variables('%[1]sosImageName'))]", profile.Name)),
+					ID: to.StringPtr(imageResourceID(imageRef, fmt.Sprintf("variables('%sosImageResourceGroup')", profile.Name), fmt.Sprintf("variables('%sosImageName')", profile.Name))),
 				}
 			}
 		} else {
@@ -744,8 +838,9 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 		nVidiaEnabled := strconv.FormatBool(common.IsNvidiaEnabledSKU(profile.VMSize))
 		sgxEnabled := strconv.FormatBool(common.IsSgxEnabledSKU(profile.VMSize))
 		auditDEnabled := strconv.FormatBool(to.Bool(profile.AuditDEnabled))
+		scheduledEventsHandler := strconv.FormatBool(profile.IsScheduledEventsHandlerEnabled())
 
-		commandExec := fmt.Sprintf("[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; %s for i in $(seq 1 1200); do grep -Fq \"EOF\" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),%s,' GPU_NODE=%s SGX_NODE=%s AUDITD_ENABLED=%s /usr/bin/nohup /bin/bash -c \"/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1%s\"')]", outBoundCmd, generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled), nVidiaEnabled, sgxEnabled, auditDEnabled, runInBackground)
+		commandExec := fmt.Sprintf("[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; %s for i in $(seq 1 1200); do grep -Fq \"EOF\" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),%s,' GPU_NODE=%s SGX_NODE=%s AUDITD_ENABLED=%s SCHEDULED_EVENTS_HANDLER=%s /usr/bin/nohup /bin/bash -c \"/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1%s\"')]", outBoundCmd, generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled), nVidiaEnabled, sgxEnabled, auditDEnabled, scheduledEventsHandler, runInBackground)
 		vmssCSE = compute.VirtualMachineScaleSetExtension{
 			Name: to.StringPtr("vmssCSE"),
 			VirtualMachineScaleSetExtensionProperties: &compute.VirtualMachineScaleSetExtensionProperties{
@@ -762,6 +857,7 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 	}
 
 	vmssExtensions = append(vmssExtensions, vmssCSE)
+	vmssExtensions = append(vmssExtensions, createVMSSVMExtensions(profile.VMExtensions, "vmssCSE")...)
 
 	if cs.IsAKSBillingEnabled() {
 		aksBillingExtension := compute.VirtualMachineScaleSetExtension{
@@ -802,8 +898,13 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 	virtualMachineScaleSet.VirtualMachineScaleSetProperties = &vmssProperties
 
 	return VirtualMachineScaleSetARM{
-		ARMResource:            armResource,
-		VirtualMachineScaleSet: virtualMachineScaleSet,
+		ARMResource:                  armResource,
+		VirtualMachineScaleSet:       virtualMachineScaleSet,
+		TerminateNotificationTimeout: terminateNotificationTimeout,
+		SpotMaxPrice:                 spotMaxPrice,
+		HostGroupID:                  profile.HostGroupID,
+		DataDiskIOPSReadWrite:        profile.DataDiskIOPSReadWrite,
+		DataDiskMBpsReadWrite:        profile.DataDiskMBpsReadWrite,
 	}
 }
 
@@ -818,6 +919,14 @@ func getVMSSDataDisks(profile *api.AgentPoolProfile) *[]compute.VirtualMachineSc
 		}
 		if profile.StorageProfile == api.StorageAccount {
 			dataDisk.Name = to.StringPtr(fmt.Sprintf("[concat(variables('%sVMNamePrefix'), copyIndex(),'-datadisk%d')]", profile.Name, i))
+		} else if profile.DataDiskStorageAccountType != "" {
+			dataDisk.ManagedDisk = &compute.VirtualMachineScaleSetManagedDiskParameters{
+				StorageAccountType: compute.StorageAccountTypes(profile.DataDiskStorageAccountType),
+			}
+		}
+		// Ultra SSDs do not support host caching
+		if profile.HasUltraSSD() {
+			dataDisk.Caching = compute.CachingTypesNone
 		}
 		dataDisks = append(dataDisks, dataDisk)
 	}
@@ -832,3 +941,32 @@ func addCustomTagsToVMScaleSets(tags map[string]string, vm *compute.VirtualMachi
 		}
 	}
 }
+
+// getVMSSPodNICConfig returns the second NIC of the instances of a pool with a pod subnet,
+// whose IPs in the pod subnet are allocated to the pods by Azure CNI
+func getVMSSPodNICConfig(profile *api.AgentPoolProfile) compute.VirtualMachineScaleSetNetworkConfiguration {
+	var ipConfigurations []compute.VirtualMachineScaleSetIPConfiguration
+	for i := 1; i <= profile.IPAddressCount; i++ {
+		ipConfigurations = append(ipConfigurations, compute.VirtualMachineScaleSetIPConfiguration{
+			Name: to.StringPtr(fmt.Sprintf("ipconfig%d", i)),
+			VirtualMachineScaleSetIPConfigurationProperties: &compute.VirtualMachineScaleSetIPConfigurationProperties{
+				Primary: to.BoolPtr(i == 1),
+				Subnet: &compute.APIEntityReference{
+					ID: to.StringPtr(fmt.Sprintf("[variables('%sPodSubnetID')]", profile.Name)),
+				},
+			},
+		})
+	}
+
+	return compute.VirtualMachineScaleSetNetworkConfiguration{
+		Name: to.StringPtr(fmt.Sprintf("[concat(variables('%sVMNamePrefix'), '-pod')]", profile.Name)),
+		VirtualMachineScaleSetNetworkConfigurationProperties: &compute.VirtualMachineScaleSetNetworkConfigurationProperties{
+			Primary:                     to.BoolPtr(false),
+			EnableAcceleratedNetworking: profile.AcceleratedNetworkingEnabled,
+			NetworkSecurityGroup: &compute.SubResource{
+				ID: to.StringPtr("[variables('nsgID')]"),
+			},
+			IPConfigurations: &ipConfigurations,
+		},
+	}
+}
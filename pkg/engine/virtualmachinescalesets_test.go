@@ -6,6 +6,7 @@ package engine
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/Azure/go-autorest/autorest/to"
@@ -362,7 +363,7 @@ func TestCreateAgentVMSS(t *testing.T) {
 									AutoUpgradeMinorVersion: to.BoolPtr(true),
 									Settings:                map[string]interface{}{},
 									ProtectedSettings: map[string]interface{}{
-										"commandToExecute": `[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; ERR_OUTBOUND_CONN_FAIL=50; retrycmd_if_failure 50 1 3 nc -vz aksrepos.azurecr.io 443 || exit $ERR_OUTBOUND_CONN_FAIL; for i in $(seq 1 1200); do grep -Fq "EOF" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),` + generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled) + `,' GPU_NODE=false SGX_NODE=false AUDITD_ENABLED=false /usr/bin/nohup /bin/bash -c "/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1"')]`}}}, {
+										"commandToExecute": `[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; ERR_OUTBOUND_CONN_FAIL=50; retrycmd_if_failure 50 1 3 nc -vz aksrepos.azurecr.io 443 || exit $ERR_OUTBOUND_CONN_FAIL; for i in $(seq 1 1200); do grep -Fq "EOF" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),` + generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled) + `,' GPU_NODE=false SGX_NODE=false AUDITD_ENABLED=false SCHEDULED_EVENTS_HANDLER=false /usr/bin/nohup /bin/bash -c "/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1"')]`}}}, {
 								Name: to.StringPtr("[concat(variables('agentpool1VMNamePrefix'), '-computeAksLinuxBilling')]"),
 								VirtualMachineScaleSetExtensionProperties: &compute.VirtualMachineScaleSetExtensionProperties{
 									Publisher:               to.StringPtr("Microsoft.AKS"),
@@ -546,6 +547,157 @@ func TestCreateAgentVMSS(t *testing.T) {
 	if diff != "" {
 		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
 	}
+
+	// Test with the scheduled events handler and a terminate notification timeout
+	cs.Properties.FeatureFlags = nil
+	cs.Properties.AgentPoolProfiles[0].ScheduledEventsProfile = &api.ScheduledEventsProfile{
+		Enabled:                             to.BoolPtr(true),
+		TerminateNotificationTimeoutMinutes: 5,
+	}
+	actual = CreateAgentVMSS(cs, cs.Properties.AgentPoolProfiles[0])
+
+	if actual.APIVersion != terminateNotificationAPIVersionCompute {
+		t.Errorf("expected the scale set API version to be %s, got %s", terminateNotificationAPIVersionCompute, actual.APIVersion)
+	}
+	if actual.TerminateNotificationTimeout != "PT5M" {
+		t.Errorf("expected the terminate notification timeout to be PT5M, got %s", actual.TerminateNotificationTimeout)
+	}
+	var commandToExecute string
+	for _, extension := range *actual.VirtualMachineProfile.ExtensionProfile.Extensions {
+		if to.String(extension.Name) == "vmssCSE" {
+			commandToExecute = extension.ProtectedSettings.(map[string]interface{})["commandToExecute"].(string)
+		}
+	}
+	if !strings.Contains(commandToExecute, "SCHEDULED_EVENTS_HANDLER=true") {
+		t.Errorf("expected the scheduled events handler to be enabled in the CSE command, got %s", commandToExecute)
+	}
+
+	// Without a timeout the scale set does not request terminate notifications
+	cs.Properties.AgentPoolProfiles[0].ScheduledEventsProfile.TerminateNotificationTimeoutMinutes = 0
+	actual = CreateAgentVMSS(cs, cs.Properties.AgentPoolProfiles[0])
+
+	if actual.APIVersion != "[variables('apiVersionCompute')]" || actual.TerminateNotificationTimeout != "" {
+		t.Errorf("expected no terminate notification without a timeout, got API version %s and timeout %s", actual.APIVersion, actual.TerminateNotificationTimeout)
+	}
+
+	// Test with a spot scale set
+	cs.Properties.AgentPoolProfiles[0].ScaleSetPriority = api.ScaleSetPrioritySpot
+	cs.Properties.AgentPoolProfiles[0].ScaleSetEvictionPolicy = api.ScaleSetEvictionPolicyDeallocate
+	cs.Properties.AgentPoolProfiles[0].SpotMaxPrice = to.Float64Ptr(0.05)
+	actual = CreateAgentVMSS(cs, cs.Properties.AgentPoolProfiles[0])
+
+	if actual.APIVersion != spotAPIVersionCompute {
+		t.Errorf("expected the scale set API version to be %s, got %s", spotAPIVersionCompute, actual.APIVersion)
+	}
+	if to.Float64(actual.SpotMaxPrice) != 0.05 {
+		t.Errorf("expected the spot max price to be 0.05, got %v", actual.SpotMaxPrice)
+	}
+	if actual.VirtualMachineProfile.Priority != "[variables('agentpool1ScaleSetPriority')]" || actual.VirtualMachineProfile.EvictionPolicy != "[variables('agentpool1ScaleSetEvictionPolicy')]" {
+		t.Errorf("expected the priority and eviction policy of the spot scale set to be set, got %s and %s", actual.VirtualMachineProfile.Priority, actual.VirtualMachineProfile.EvictionPolicy)
+	}
+
+	// Test with the proximity placement group of the cluster
+	cs.Properties.AgentPoolProfiles[0].CreateProximityPlacementGroup = to.BoolPtr(true)
+	actual = CreateAgentVMSS(cs, cs.Properties.AgentPoolProfiles[0])
+
+	if actual.ProximityPlacementGroup == nil || to.String(actual.ProximityPlacementGroup.ID) != "[variables('proximityPlacementGroupID')]" {
+		t.Errorf("expected the scale set to be placed in the proximity placement group of the cluster, got %v", actual.ProximityPlacementGroup)
+	}
+	ppgDependency := "[concat('Microsoft.Compute/proximityPlacementGroups/', variables('proximityPlacementGroupName'))]"
+	if actual.DependsOn[len(actual.DependsOn)-1] != ppgDependency {
+		t.Errorf("expected the scale set to depend on %s, got %v", ppgDependency, actual.DependsOn)
+	}
+
+	// Test with a scale set on dedicated hosts
+	cs.Properties.AgentPoolProfiles[0].ScaleSetPriority = api.ScaleSetPriorityRegular
+	cs.Properties.AgentPoolProfiles[0].SpotMaxPrice = nil
+	cs.Properties.AgentPoolProfiles[0].HostGroupID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/hostGroups/HG_NAME"
+	cs.Properties.AgentPoolProfiles[0].PlatformFaultDomainCount = to.IntPtr(2)
+	actual = CreateAgentVMSS(cs, cs.Properties.AgentPoolProfiles[0])
+
+	if actual.APIVersion != hostGroupAPIVersionCompute {
+		t.Errorf("expected the scale set API version to be %s, got %s", hostGroupAPIVersionCompute, actual.APIVersion)
+	}
+	if actual.HostGroupID != cs.Properties.AgentPoolProfiles[0].HostGroupID {
+		t.Errorf("expected the scale set host group to be %s, got %s", cs.Properties.AgentPoolProfiles[0].HostGroupID, actual.HostGroupID)
+	}
+	if to.Int32(actual.PlatformFaultDomainCount) != 2 {
+		t.Errorf("expected the scale set to have 2 fault domains, got %v", actual.PlatformFaultDomainCount)
+	}
+
+	// Test with Ultra SSD data disks
+	cs.Properties.AgentPoolProfiles[0].HostGroupID = ""
+	cs.Properties.AgentPoolProfiles[0].PlatformFaultDomainCount = nil
+	cs.Properties.AgentPoolProfiles[0].StorageProfile = api.ManagedDisks
+	cs.Properties.AgentPoolProfiles[0].DiskSizesGB = []int{128}
+	cs.Properties.AgentPoolProfiles[0].DataDiskStorageAccountType = api.UltraSSDDataDisk
+	cs.Properties.AgentPoolProfiles[0].DataDiskIOPSReadWrite = 10000
+	cs.Properties.AgentPoolProfiles[0].DataDiskMBpsReadWrite = 400
+	actual = CreateAgentVMSS(cs, cs.Properties.AgentPoolProfiles[0])
+
+	if actual.APIVersion != ultraSSDAPIVersionCompute {
+		t.Errorf("expected the scale set API version to be %s, got %s", ultraSSDAPIVersionCompute, actual.APIVersion)
+	}
+	if actual.DataDiskIOPSReadWrite != 10000 || actual.DataDiskMBpsReadWrite != 400 {
+		t.Errorf("expected the scale set data disks to have 10000 IOPS and 400 MB/s, got %d IOPS and %d MB/s", actual.DataDiskIOPSReadWrite, actual.DataDiskMBpsReadWrite)
+	}
+	if !to.Bool(actual.VirtualMachineProfile.AdditionalCapabilities.UltraSSDEnabled) {
+		t.Errorf("expected the scale set to have Ultra SSDs enabled")
+	}
+	expectedDataDisks := &[]compute.VirtualMachineScaleSetDataDisk{
+		{
+			DiskSizeGB:   to.Int32Ptr(128),
+			Lun:          to.Int32Ptr(0),
+			CreateOption: compute.DiskCreateOptionTypesEmpty,
+			Caching:      compute.CachingTypesNone,
+			ManagedDisk: &compute.VirtualMachineScaleSetManagedDiskParameters{
+				StorageAccountType: compute.StorageAccountTypesUltraSSDLRS,
+			},
+		},
+	}
+	if diff := cmp.Diff(actual.VirtualMachineProfile.StorageProfile.DataDisks, expectedDataDisks); diff != "" {
+		t.Errorf("unexpected diff while comparing the data disks: %s", diff)
+	}
+	if actual.ZoneBalance != nil {
+		t.Errorf("expected the scale set to have no zone balance, got %t", *actual.ZoneBalance)
+	}
+
+	// Test with a strict zone balance strategy
+	cs.Properties.AgentPoolProfiles[0].AvailabilityZones = []string{"1", "2", "3"}
+	cs.Properties.AgentPoolProfiles[0].ZoneBalanceStrategy = api.StrictZoneBalance
+	actual = CreateAgentVMSS(cs, cs.Properties.AgentPoolProfiles[0])
+
+	if !to.Bool(actual.ZoneBalance) {
+		t.Errorf("expected the scale set to be zone balanced, got %v", actual.ZoneBalance)
+	}
+
+	// Test with a best effort zone balance strategy
+	cs.Properties.AgentPoolProfiles[0].ZoneBalanceStrategy = api.BestEffortZoneBalance
+	actual = CreateAgentVMSS(cs, cs.Properties.AgentPoolProfiles[0])
+
+	if actual.ZoneBalance == nil || *actual.ZoneBalance {
+		t.Errorf("expected the scale set not to be zone balanced, got %v", actual.ZoneBalance)
+	}
+
+	// Test with the user assigned identities of the cluster and of the pool
+	cs.Properties.OrchestratorProfile.KubernetesConfig.UseManagedIdentity = true
+	cs.Properties.OrchestratorProfile.KubernetesConfig.UserAssignedID = "fooAssignedID"
+	cs.Properties.AgentPoolProfiles[0].UserAssignedID = "agentpool1identity"
+	actual = CreateAgentVMSS(cs, cs.Properties.AgentPoolProfiles[0])
+
+	expectedIdentity := &compute.VirtualMachineScaleSetIdentity{
+		Type: compute.ResourceIdentityTypeUserAssigned,
+		UserAssignedIdentities: map[string]*compute.VirtualMachineScaleSetIdentityUserAssignedIdentitiesValue{
+			"[variables('userAssignedIDReference')]":           {},
+			"[variables('agentpool1UserAssignedIDReference')]": {},
+		},
+	}
+	if diff := cmp.Diff(actual.Identity, expectedIdentity); diff != "" {
+		t.Errorf("unexpected diff while comparing the identity of the scale set: %s", diff)
+	}
+	if dependency := actual.DependsOn[len(actual.DependsOn)-1]; dependency != "[concat('Microsoft.ManagedIdentity/userAssignedIdentities/', variables('agentpool1UserAssignedID'))]" {
+		t.Errorf("expected the scale set to depend on the identity of the pool, got %s", dependency)
+	}
 }
 
 func TestCreateAgentVMSSHostedMasterProfile(t *testing.T) {
@@ -648,7 +800,7 @@ func TestCreateAgentVMSSHostedMasterProfile(t *testing.T) {
 									AutoUpgradeMinorVersion: to.BoolPtr(true),
 									Settings:                map[string]interface{}{},
 									ProtectedSettings: map[string]interface{}{
-										"commandToExecute": `[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; ERR_OUTBOUND_CONN_FAIL=50; retrycmd_if_failure 50 1 3 nc -vz aksrepos.azurecr.io 443 || exit $ERR_OUTBOUND_CONN_FAIL; for i in $(seq 1 1200); do grep -Fq "EOF" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),` + generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled) + `,' GPU_NODE=false SGX_NODE=false AUDITD_ENABLED=false /usr/bin/nohup /bin/bash -c "/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1"')]`}}}, {
+										"commandToExecute": `[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; ERR_OUTBOUND_CONN_FAIL=50; retrycmd_if_failure 50 1 3 nc -vz aksrepos.azurecr.io 443 || exit $ERR_OUTBOUND_CONN_FAIL; for i in $(seq 1 1200); do grep -Fq "EOF" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),` + generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled) + `,' GPU_NODE=false SGX_NODE=false AUDITD_ENABLED=false SCHEDULED_EVENTS_HANDLER=false /usr/bin/nohup /bin/bash -c "/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1"')]`}}}, {
 								Name: to.StringPtr("[concat(variables('agentpool1VMNamePrefix'), '-AKSLinuxBilling')]"),
 								VirtualMachineScaleSetExtensionProperties: &compute.VirtualMachineScaleSetExtensionProperties{
 									Publisher:               to.StringPtr("Microsoft.AKS"),
@@ -856,6 +1008,45 @@ func getIPConfigs(lbBackendAddresPoolID *string, isStandardLB, ipv6DualStackEnab
 	return &ipConfigs
 }
 
+func TestCreateAgentVMSSWithPodSubnet(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.16.1", 1, 2, false)
+	cs.Properties.MasterProfile.VnetSubnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME"
+	cs.Properties.MasterProfile.FirstConsecutiveStaticIP = "10.239.255.239"
+	profile := cs.Properties.AgentPoolProfiles[0]
+	profile.AvailabilityProfile = api.VirtualMachineScaleSets
+	profile.VnetSubnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/AGENT_SUBNET_NAME"
+	profile.PodSubnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/POD_SUBNET_NAME"
+	if _, err := cs.SetPropertiesDefaults(false, false); err != nil {
+		t.Fatalf("unexpected error setting the defaults: %s", err)
+	}
+
+	vmss := CreateAgentVMSS(cs, profile)
+
+	nicConfigs := *vmss.VirtualMachineProfile.NetworkProfile.NetworkInterfaceConfigurations
+	if len(nicConfigs) != 2 {
+		t.Fatalf("expected 2 network interface configurations, instead got %d", len(nicConfigs))
+	}
+	if len(*nicConfigs[0].IPConfigurations) != 1 {
+		t.Errorf("expected the node NIC to have 1 ip configuration, instead got %d", len(*nicConfigs[0].IPConfigurations))
+	}
+
+	podIPConfigs := *nicConfigs[1].IPConfigurations
+	if len(podIPConfigs) != profile.IPAddressCount {
+		t.Errorf("expected the pod NIC to have %d ip configurations, instead got %d", profile.IPAddressCount, len(podIPConfigs))
+	}
+	if to.Bool(nicConfigs[1].Primary) {
+		t.Errorf("expected the pod NIC not to be the primary NIC")
+	}
+	for i, ipConfig := range podIPConfigs {
+		if to.Bool(ipConfig.Primary) != (i == 0) {
+			t.Errorf("expected only the first ip configuration of the pod NIC to be primary")
+		}
+		if to.String(ipConfig.Subnet.ID) != "[variables('agentpool1PodSubnetID')]" {
+			t.Errorf("expected the ip configurations of the pod NIC in the pod subnet, instead got %s", to.String(ipConfig.Subnet.ID))
+		}
+	}
+}
+
 func TestCreateVmScaleSetsWithCustomTags(t *testing.T) {
 	testTags := map[string]*string{
 		"orchestrator":     to.StringPtr("k8s"),
@@ -1099,7 +1290,7 @@ func TestCreateCustomOSVMSS(t *testing.T) {
 									AutoUpgradeMinorVersion: to.BoolPtr(true),
 									Settings:                map[string]interface{}{},
 									ProtectedSettings: map[string]interface{}{
-										"commandToExecute": `[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; ERR_OUTBOUND_CONN_FAIL=50; retrycmd_if_failure 50 1 3 nc -vz aksrepos.azurecr.io 443 || exit $ERR_OUTBOUND_CONN_FAIL; for i in $(seq 1 1200); do grep -Fq "EOF" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),` + generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled) + `,' GPU_NODE=false SGX_NODE=false AUDITD_ENABLED=false /usr/bin/nohup /bin/bash -c "/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1"')]`}}}, {
+										"commandToExecute": `[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; ERR_OUTBOUND_CONN_FAIL=50; retrycmd_if_failure 50 1 3 nc -vz aksrepos.azurecr.io 443 || exit $ERR_OUTBOUND_CONN_FAIL; for i in $(seq 1 1200); do grep -Fq "EOF" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),` + generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled) + `,' GPU_NODE=false SGX_NODE=false AUDITD_ENABLED=false SCHEDULED_EVENTS_HANDLER=false /usr/bin/nohup /bin/bash -c "/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1"')]`}}}, {
 								Name: to.StringPtr("[concat(variables('agentpool1VMNamePrefix'), '-computeAksLinuxBilling')]"),
 								VirtualMachineScaleSetExtensionProperties: &compute.VirtualMachineScaleSetExtensionProperties{
 									Publisher:               to.StringPtr("Microsoft.AKS"),
@@ -81,10 +81,28 @@ func CreateVirtualNetwork(cs *api.ContainerService) VirtualNetworkARM {
 		virtualNetwork.VirtualNetworkPropertiesFormat.Subnets = &subnets
 	}
 
-	return VirtualNetworkARM{
+	if cs.Properties.MasterProfile != nil && cs.Properties.MasterProfile.HasDedicatedEtcdSubnet() {
+		subnetEtcd := network.Subnet{
+			Name: to.StringPtr("[variables('etcdSubnetName')]"),
+			SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+				AddressPrefix: to.StringPtr("[parameters('etcdSubnet')]"),
+				NetworkSecurityGroup: &network.SecurityGroup{
+					ID: to.StringPtr("[variables('etcdNsgID')]"),
+				},
+			},
+		}
+
+		subnets := append(*virtualNetwork.VirtualNetworkPropertiesFormat.Subnets, subnetEtcd)
+		virtualNetwork.VirtualNetworkPropertiesFormat.Subnets = &subnets
+		armResource.DependsOn = append(armResource.DependsOn, "[concat('Microsoft.Network/networkSecurityGroups/', variables('etcdNsgName'))]")
+	}
+
+	virtualNetwork.DhcpOptions = getVnetDhcpOptions(cs.Properties.MasterProfile)
+
+	return attachPrivateLinkSubnet(cs, attachNATGateway(cs, VirtualNetworkARM{
 		ARMResource:    armResource,
 		VirtualNetwork: virtualNetwork,
-	}
+	}))
 }
 
 func createVirtualNetworkVMSS(cs *api.ContainerService) VirtualNetworkARM {
@@ -175,10 +193,12 @@ func createVirtualNetworkVMSS(cs *api.ContainerService) VirtualNetworkARM {
 		virtualNetwork.VirtualNetworkPropertiesFormat.Subnets = &subnets
 	}
 
-	return VirtualNetworkARM{
+	virtualNetwork.DhcpOptions = getVnetDhcpOptions(cs.Properties.MasterProfile)
+
+	return attachPrivateLinkSubnet(cs, attachNATGateway(cs, VirtualNetworkARM{
 		ARMResource:    armResource,
 		VirtualNetwork: virtualNetwork,
-	}
+	}))
 }
 
 func createHostedMasterVirtualNetwork(cs *api.ContainerService) VirtualNetworkARM {
@@ -246,3 +266,66 @@ func createHostedMasterVirtualNetwork(cs *api.ContainerService) VirtualNetworkAR
 		VirtualNetwork: virtualNetwork,
 	}
 }
+
+// getVnetDhcpOptions returns the DHCP options that set the DNS servers of all VMs in the VNET,
+// or nil to use Azure-provided DNS
+func getVnetDhcpOptions(masterProfile *api.MasterProfile) *network.DhcpOptions {
+	if masterProfile == nil || len(masterProfile.VnetDNSServers) == 0 {
+		return nil
+	}
+	dnsServers := append([]string{}, masterProfile.VnetDNSServers...)
+	return &network.DhcpOptions{
+		DNSServers: &dnsServers,
+	}
+}
+
+// getNodeDNSServers returns the DNS servers for the NICs of nodes with dnsConfig, falling back to the
+// linuxProfile customNodesDNS server for Linux nodes, or nil to use the VNET DNS servers
+func getNodeDNSServers(dnsConfig *api.NodeDNSConfig, linuxProfile *api.LinuxProfile, isWindows bool) *[]string {
+	if dnsConfig.HasDNSServers() {
+		dnsServers := append([]string{}, dnsConfig.DNSServers...)
+		return &dnsServers
+	}
+	if linuxProfile != nil && linuxProfile.HasCustomNodesDNS() && !isWindows {
+		return &[]string{
+			"[parameters('dnsServer')]",
+		}
+	}
+	return nil
+}
+
+// attachNATGateway sends the egress traffic of the subnets of the cluster nodes, those with the nsgID network security
+// group, through the NAT gateway of the cluster if it has one.
+// Subnets only accept a NAT gateway from a newer network API than the one of the other network resources.
+func attachNATGateway(cs *api.ContainerService, vnet VirtualNetworkARM) VirtualNetworkARM {
+	if !cs.Properties.OrchestratorProfile.KubernetesConfig.HasNATGateway() {
+		return vnet
+	}
+	vnet.APIVersion = "[variables('apiVersionNATGateway')]"
+	vnet.DependsOn = append(vnet.DependsOn, "[concat('Microsoft.Network/natGateways/', variables('natGatewayName'))]")
+	for _, subnet := range *vnet.Subnets {
+		if subnet.NetworkSecurityGroup != nil && to.String(subnet.NetworkSecurityGroup.ID) == "[variables('nsgID')]" {
+			vnet.setSubnetProperty(to.String(subnet.Name), "natGateway", map[string]string{
+				"id": "[variables('natGatewayID')]",
+			})
+		}
+	}
+	return vnet
+}
+
+// attachPrivateLinkSubnet disables the network policies of the master subnet, which hosts the Private Link service and
+// private endpoint of the API server, if the cluster has them.
+// Subnets only accept these policies from a newer network API than the one of the other network resources.
+func attachPrivateLinkSubnet(cs *api.ContainerService, vnet VirtualNetworkARM) VirtualNetworkARM {
+	if !cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateLinkEnabled() {
+		return vnet
+	}
+	vnet.APIVersion = "[variables('apiVersionPrivateLink')]"
+	subnetName := "[variables('subnetName')]"
+	if cs.Properties.MasterProfile.IsVirtualMachineScaleSets() {
+		subnetName = "subnetmaster"
+	}
+	vnet.setSubnetProperty(subnetName, "privateEndpointNetworkPolicies", "Disabled")
+	vnet.setSubnetProperty(subnetName, "privateLinkServiceNetworkPolicies", "Disabled")
+	return vnet
+}
@@ -4,6 +4,9 @@
 package engine
 
 import (
+	"encoding/json"
+	"strings"
+
 	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
@@ -533,3 +536,232 @@ func TestCreateHostedMasterVirtualNetwork(t *testing.T) {
 		t.Errorf("Unexpected diff while comparing vnets: %s", diff)
 	}
 }
+
+func TestCreateVirtualNetworkWithNATGateway(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorType: "Kubernetes",
+				KubernetesConfig: &api.KubernetesConfig{
+					LoadBalancerSku: api.StandardLoadBalancerSku,
+					OutboundType:    api.OutboundTypeNATGateway,
+				},
+			},
+			MasterProfile: &api.MasterProfile{},
+		},
+	}
+
+	cases := []struct {
+		name            string
+		vnet            VirtualNetworkARM
+		expectedSubnets int
+	}{
+		{
+			name:            "availability set masters",
+			vnet:            CreateVirtualNetwork(cs),
+			expectedSubnets: 1,
+		},
+		{
+			name:            "scale set masters",
+			vnet:            createVirtualNetworkVMSS(cs),
+			expectedSubnets: 2,
+		},
+	}
+
+	for _, c := range cases {
+		if c.vnet.APIVersion != "[variables('apiVersionNATGateway')]" {
+			t.Errorf("%s: expected the NAT gateway API version, got %s", c.name, c.vnet.APIVersion)
+		}
+		dependsOnNATGateway := false
+		for _, dependency := range c.vnet.DependsOn {
+			if dependency == "[concat('Microsoft.Network/natGateways/', variables('natGatewayName'))]" {
+				dependsOnNATGateway = true
+			}
+		}
+		if !dependsOnNATGateway {
+			t.Errorf("%s: expected the VNET to depend on the NAT gateway, got %v", c.name, c.vnet.DependsOn)
+		}
+		bytes, err := json.Marshal(c.vnet)
+		if err != nil {
+			t.Fatalf("%s: unexpected error marshaling the VNET: %s", c.name, err)
+		}
+		if actual := strings.Count(string(bytes), `"natGateway":{"id":"[variables('natGatewayID')]"}`); actual != c.expectedSubnets {
+			t.Errorf("%s: expected the NAT gateway to be attached to %d subnets, got %d", c.name, c.expectedSubnets, actual)
+		}
+	}
+
+	cs.Properties.OrchestratorProfile.KubernetesConfig.OutboundType = api.OutboundTypeLoadBalancer
+	bytes, err := json.Marshal(CreateVirtualNetwork(cs))
+	if err != nil {
+		t.Fatalf("unexpected error marshaling the VNET: %s", err)
+	}
+	if strings.Contains(string(bytes), "natGateway") {
+		t.Errorf("expected no NAT gateway in the VNET of outboundType %s, got %s", api.OutboundTypeLoadBalancer, string(bytes))
+	}
+}
+
+func TestGetVnetDhcpOptions(t *testing.T) {
+	if dhcpOptions := getVnetDhcpOptions(nil); dhcpOptions != nil {
+		t.Errorf("expected no DHCP options without a master profile, got %v", dhcpOptions)
+	}
+	if dhcpOptions := getVnetDhcpOptions(&api.MasterProfile{}); dhcpOptions != nil {
+		t.Errorf("expected no DHCP options without vnetDnsServers, got %v", dhcpOptions)
+	}
+
+	dhcpOptions := getVnetDhcpOptions(&api.MasterProfile{
+		VnetDNSServers: []string{"10.239.0.4", "10.239.0.5"},
+	})
+	expected := &network.DhcpOptions{
+		DNSServers: &[]string{"10.239.0.4", "10.239.0.5"},
+	}
+	if diff := cmp.Diff(dhcpOptions, expected); diff != "" {
+		t.Errorf("Unexpected diff while comparing DHCP options: %s", diff)
+	}
+}
+
+func TestGetNodeDNSServers(t *testing.T) {
+	linuxProfile := &api.LinuxProfile{
+		CustomNodesDNS: &api.CustomNodesDNS{
+			DNSServer: "10.239.0.4",
+		},
+	}
+	cases := []struct {
+		name         string
+		dnsConfig    *api.NodeDNSConfig
+		linuxProfile *api.LinuxProfile
+		isWindows    bool
+		expected     *[]string
+	}{
+		{
+			name:     "no custom dns",
+			expected: nil,
+		},
+		{
+			name:         "linux customNodesDNS",
+			linuxProfile: linuxProfile,
+			expected:     &[]string{"[parameters('dnsServer')]"},
+		},
+		{
+			name:         "windows ignores customNodesDNS",
+			linuxProfile: linuxProfile,
+			isWindows:    true,
+			expected:     nil,
+		},
+		{
+			name: "pool dnsServers override customNodesDNS",
+			dnsConfig: &api.NodeDNSConfig{
+				DNSServers: []string{"10.239.0.6"},
+			},
+			linuxProfile: linuxProfile,
+			expected:     &[]string{"10.239.0.6"},
+		},
+		{
+			name: "pool searchDomains only",
+			dnsConfig: &api.NodeDNSConfig{
+				SearchDomains: []string{"contoso.com"},
+			},
+			isWindows: true,
+			expected:  nil,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			dnsServers := getNodeDNSServers(c.dnsConfig, c.linuxProfile, c.isWindows)
+			if diff := cmp.Diff(dnsServers, c.expected); diff != "" {
+				t.Errorf("Unexpected diff while comparing DNS servers: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCreateVirtualNetworkWithPrivateLink(t *testing.T) {
+	newContainerService := func(availabilityProfile string) *api.ContainerService {
+		return &api.ContainerService{
+			Properties: &api.Properties{
+				OrchestratorProfile: &api.OrchestratorProfile{
+					OrchestratorType: "Kubernetes",
+					KubernetesConfig: &api.KubernetesConfig{
+						LoadBalancerSku: api.StandardLoadBalancerSku,
+						PrivateCluster: &api.PrivateCluster{
+							Enabled: to.BoolPtr(true),
+							PrivateLink: &api.PrivateLinkProfile{
+								Enabled: to.BoolPtr(true),
+							},
+						},
+					},
+				},
+				MasterProfile: &api.MasterProfile{
+					AvailabilityProfile: availabilityProfile,
+				},
+			},
+		}
+	}
+	cases := []struct {
+		name           string
+		vnet           VirtualNetworkARM
+		expectedSubnet string
+	}{
+		{
+			name:           "availability set masters",
+			vnet:           CreateVirtualNetwork(newContainerService(api.AvailabilitySet)),
+			expectedSubnet: "[variables('subnetName')]",
+		},
+		{
+			name:           "scale set masters",
+			vnet:           createVirtualNetworkVMSS(newContainerService(api.VirtualMachineScaleSets)),
+			expectedSubnet: "subnetmaster",
+		},
+	}
+
+	for _, c := range cases {
+		if c.vnet.APIVersion != "[variables('apiVersionPrivateLink')]" {
+			t.Errorf("%s: expected the Private Link API version, got %s", c.name, c.vnet.APIVersion)
+		}
+		bytes, err := json.Marshal(c.vnet)
+		if err != nil {
+			t.Fatalf("%s: unexpected error marshaling the VNET: %s", c.name, err)
+		}
+		var vnet struct {
+			Properties struct {
+				Subnets []struct {
+					Name       string                 `json:"name"`
+					Properties map[string]interface{} `json:"properties"`
+				} `json:"subnets"`
+			} `json:"properties"`
+		}
+		if err = json.Unmarshal(bytes, &vnet); err != nil {
+			t.Fatalf("%s: unexpected error unmarshaling the VNET: %s", c.name, err)
+		}
+		if len(vnet.Properties.Subnets) == 0 {
+			t.Fatalf("%s: expected the VNET to have subnets, got %s", c.name, string(bytes))
+		}
+		for _, subnet := range vnet.Properties.Subnets {
+			expected := subnet.Name == c.expectedSubnet
+			for _, policy := range []string{"privateEndpointNetworkPolicies", "privateLinkServiceNetworkPolicies"} {
+				value, ok := subnet.Properties[policy]
+				if expected && value != "Disabled" {
+					t.Errorf("%s: expected %s of subnet %s to be Disabled, got %v", c.name, policy, subnet.Name, value)
+				}
+				if !expected && ok {
+					t.Errorf("%s: expected no %s on subnet %s, got %v", c.name, policy, subnet.Name, value)
+				}
+			}
+			if _, ok := subnet.Properties["addressPrefix"]; !ok {
+				t.Errorf("%s: expected subnet %s to keep its address prefix, got %v", c.name, subnet.Name, subnet.Properties)
+			}
+		}
+	}
+
+	cs := newContainerService(api.AvailabilitySet)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster.PrivateLink = nil
+	bytes, err := json.Marshal(CreateVirtualNetwork(cs))
+	if err != nil {
+		t.Fatalf("unexpected error marshaling the VNET: %s", err)
+	}
+	if strings.Contains(string(bytes), "NetworkPolicies") {
+		t.Errorf("expected no network policies in the VNET of a cluster without Private Link, got %s", string(bytes))
+	}
+}
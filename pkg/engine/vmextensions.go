@@ -82,7 +82,7 @@ func CreateCustomScriptExtension(cs *api.ContainerService) VirtualMachineExtensi
 			AutoUpgradeMinorVersion: to.BoolPtr(true),
 			Settings:                &map[string]interface{}{},
 			ProtectedSettings: &map[string]interface{}{
-				"commandToExecute": fmt.Sprintf("[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; "+outBoundCmd+" for i in $(seq 1 1200); do grep -Fq \"EOF\" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),%s,variables('provisionScriptParametersMaster'), ' /usr/bin/nohup /bin/bash -c \"/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1\"')]", generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled)),
+				"commandToExecute": fmt.Sprintf("[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; "+outBoundCmd+" for i in $(seq 1 1200); do grep -Fq \"EOF\" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),%s,variables('provisionScriptParametersMaster')%s, ' /usr/bin/nohup /bin/bash -c \"/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1\"')]", generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled), generateMasterAddonsParameters(cs)),
 			},
 		},
 		Type: to.StringPtr("Microsoft.Compute/virtualMachines/extensions"),
@@ -136,6 +136,7 @@ func createAgentVMASCustomScriptExtension(cs *api.ContainerService, profile *api
 	nVidiaEnabled := strconv.FormatBool(common.IsNvidiaEnabledSKU(profile.VMSize))
 	sgxEnabled := strconv.FormatBool(common.IsSgxEnabledSKU(profile.VMSize))
 	auditDEnabled := strconv.FormatBool(to.Bool(profile.AuditDEnabled))
+	scheduledEventsHandler := strconv.FormatBool(profile.IsScheduledEventsHandlerEnabled())
 
 	vmExtension := compute.VirtualMachineExtension{
 		Location: to.StringPtr(location),
@@ -158,7 +159,7 @@ func createAgentVMASCustomScriptExtension(cs *api.ContainerService, profile *api
 		vmExtension.Publisher = to.StringPtr("Microsoft.Azure.Extensions")
 		vmExtension.VirtualMachineExtensionProperties.Type = to.StringPtr("CustomScript")
 		vmExtension.TypeHandlerVersion = to.StringPtr("2.0")
-		commandExec := fmt.Sprintf("[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; %s for i in $(seq 1 1200); do grep -Fq \"EOF\" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),%s,' GPU_NODE=%s SGX_NODE=%s AUDITD_ENABLED=%s /usr/bin/nohup /bin/bash -c \"/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1%s\"')]", outBoundCmd, generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled), nVidiaEnabled, sgxEnabled, auditDEnabled, runInBackground)
+		commandExec := fmt.Sprintf("[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; %s for i in $(seq 1 1200); do grep -Fq \"EOF\" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),%s,' GPU_NODE=%s SGX_NODE=%s AUDITD_ENABLED=%s SCHEDULED_EVENTS_HANDLER=%s /usr/bin/nohup /bin/bash -c \"/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1%s\"')]", outBoundCmd, generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled), nVidiaEnabled, sgxEnabled, auditDEnabled, scheduledEventsHandler, runInBackground)
 		vmExtension.ProtectedSettings = &map[string]interface{}{
 			"commandToExecute": commandExec,
 		}
@@ -225,6 +226,93 @@ func CreateAgentVMASAKSBillingExtension(cs *api.ContainerService, profile *api.A
 	}
 }
 
+// createMasterVMExtensions returns the vmExtensions of the master VMs in an availability set
+func createMasterVMExtensions(cs *api.ContainerService) []VirtualMachineExtensionARM {
+	return createVMExtensions(cs.Properties.MasterProfile.VMExtensions,
+		"variables('masterVMNamePrefix'), copyIndex(variables('masterOffset'))",
+		"'cse-master-', copyIndex(variables('masterOffset'))",
+		"[sub(variables('masterCount'), variables('masterOffset'))]")
+}
+
+// createAgentVMASVMExtensions returns the vmExtensions of the VMs of an agent pool in an availability set
+func createAgentVMASVMExtensions(profile *api.AgentPoolProfile) []VirtualMachineExtensionARM {
+	return createVMExtensions(profile.VMExtensions,
+		fmt.Sprintf("variables('%[1]sVMNamePrefix'), copyIndex(variables('%[1]sOffset'))", profile.Name),
+		fmt.Sprintf("'cse-agent-', copyIndex(variables('%sOffset'))", profile.Name),
+		fmt.Sprintf("[sub(variables('%[1]sCount'), variables('%[1]sOffset'))]", profile.Name))
+}
+
+// createVMExtensions returns an extension resource per vmExtension, vmName and cseName being the ARM expressions of the name of
+// the VM and of its custom script extension. Each extension depends on the previous one and the first on the custom script extension,
+// so that they are installed one after the other on a VM that was bootstrapped.
+func createVMExtensions(extensions []api.VMExtension, vmName, cseName, count string) []VirtualMachineExtensionARM {
+	var extensionsARM []VirtualMachineExtensionARM
+	dependency := fmt.Sprintf("[concat('Microsoft.Compute/virtualMachines/', %s, '/extensions/', %s)]", vmName, cseName)
+	for _, e := range extensions {
+		vmExtension := compute.VirtualMachineExtension{
+			Location: to.StringPtr("[variables('location')]"),
+			Name:     to.StringPtr(fmt.Sprintf("[concat(%s, '/%s')]", vmName, e.Name)),
+			VirtualMachineExtensionProperties: &compute.VirtualMachineExtensionProperties{
+				Publisher:               to.StringPtr(e.Publisher),
+				Type:                    to.StringPtr(e.Type),
+				TypeHandlerVersion:      to.StringPtr(e.TypeHandlerVersion),
+				AutoUpgradeMinorVersion: e.AutoUpgradeMinorVersion,
+				Settings:                getVMExtensionSettings(e.Settings),
+			},
+			Type: to.StringPtr("Microsoft.Compute/virtualMachines/extensions"),
+			Tags: map[string]*string{},
+		}
+		if len(e.ProtectedSettings) > 0 {
+			vmExtension.ProtectedSettings = &e.ProtectedSettings
+		}
+		extensionsARM = append(extensionsARM, VirtualMachineExtensionARM{
+			ARMResource: ARMResource{
+				APIVersion: "[variables('apiVersionCompute')]",
+				Copy: map[string]string{
+					"count": count,
+					"name":  "vmLoopNode",
+				},
+				DependsOn: []string{dependency},
+			},
+			VirtualMachineExtension: vmExtension,
+		})
+		dependency = fmt.Sprintf("[concat('Microsoft.Compute/virtualMachines/', %s, '/extensions/%s')]", vmName, e.Name)
+	}
+	return extensionsARM
+}
+
+// createVMSSVMExtensions returns the vmExtensions of a scale set, provisioned one after the other after its custom script extension cseName
+func createVMSSVMExtensions(extensions []api.VMExtension, cseName string) []compute.VirtualMachineScaleSetExtension {
+	var vmssExtensions []compute.VirtualMachineScaleSetExtension
+	provisionAfter := cseName
+	for _, e := range extensions {
+		vmssExtension := compute.VirtualMachineScaleSetExtension{
+			Name: to.StringPtr(e.Name),
+			VirtualMachineScaleSetExtensionProperties: &compute.VirtualMachineScaleSetExtensionProperties{
+				Publisher:                to.StringPtr(e.Publisher),
+				Type:                     to.StringPtr(e.Type),
+				TypeHandlerVersion:       to.StringPtr(e.TypeHandlerVersion),
+				AutoUpgradeMinorVersion:  e.AutoUpgradeMinorVersion,
+				Settings:                 *getVMExtensionSettings(e.Settings),
+				ProvisionAfterExtensions: &[]string{provisionAfter},
+			},
+		}
+		if len(e.ProtectedSettings) > 0 {
+			vmssExtension.ProtectedSettings = e.ProtectedSettings
+		}
+		vmssExtensions = append(vmssExtensions, vmssExtension)
+		provisionAfter = e.Name
+	}
+	return vmssExtensions
+}
+
+func getVMExtensionSettings(settings map[string]interface{}) *map[string]interface{} {
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+	return &settings
+}
+
 // CreateCustomExtensions returns a list of DeploymentARM objects for the custom extensions to be deployed
 func CreateCustomExtensions(properties *api.Properties) []DeploymentARM {
 	var extensionsARM []DeploymentARM
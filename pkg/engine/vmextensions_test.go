@@ -277,6 +277,38 @@ func TestCreateCustomScriptExtension(t *testing.T) {
 	if diff != "" {
 		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
 	}
+
+	// Test with the appgw-ingress, azure-policy and open-service-mesh addons
+	cs.Properties.OrchestratorProfile = &api.OrchestratorProfile{
+		KubernetesConfig: &api.KubernetesConfig{
+			Addons: []api.KubernetesAddon{
+				{
+					Name:    AppGwIngressAddonName,
+					Enabled: to.BoolPtr(true),
+				},
+				{
+					Name:    AzurePolicyAddonName,
+					Enabled: to.BoolPtr(true),
+				},
+				{
+					Name:    OpenServiceMeshAddonName,
+					Enabled: to.BoolPtr(true),
+					Config:  map[string]string{"namespaces": "default,bookstore"},
+				},
+			},
+		},
+	}
+	cse = CreateCustomScriptExtension(cs)
+
+	expectedCSE.ProtectedSettings = &map[string]interface{}{
+		"commandToExecute": `[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; ERR_OUTBOUND_CONN_FAIL=50; retrycmd_if_failure 50 1 3 nc -vz gcr.azk8s.cn 80 || exit $ERR_OUTBOUND_CONN_FAIL; for i in $(seq 1 1200); do grep -Fq "EOF" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),` + generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled) + `,variables('provisionScriptParametersMaster'),' APPGW_INGRESS_ADDON=true APPGW_NAME=',variables('appGwName'),' APPGW_IDENTITY_RESOURCE_ID=',variables('appGwICIdentityId'),' APPGW_IDENTITY_CLIENT_ID=',reference(variables('appGwICIdentityId'), variables('apiVersionManagedIdentity')).clientId,' AZURE_POLICY_ADDON=true',' OSM_NAMESPACES=default,bookstore', ' /usr/bin/nohup /bin/bash -c "/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1"')]`,
+	}
+
+	diff = cmp.Diff(cse, expectedCSE)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
+	}
 }
 
 func TestCreateAgentVMASCustomScriptExtension(t *testing.T) {
@@ -319,7 +351,7 @@ func TestCreateAgentVMASCustomScriptExtension(t *testing.T) {
 				AutoUpgradeMinorVersion: to.BoolPtr(true),
 				Settings:                &map[string]interface{}{},
 				ProtectedSettings: &map[string]interface{}{
-					"commandToExecute": `[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; ERR_OUTBOUND_CONN_FAIL=50; retrycmd_if_failure 50 1 3 nc -vz aksrepos.azurecr.io 443 || exit $ERR_OUTBOUND_CONN_FAIL; for i in $(seq 1 1200); do grep -Fq "EOF" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),` + generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled) + `,' GPU_NODE=false SGX_NODE=false AUDITD_ENABLED=false /usr/bin/nohup /bin/bash -c "/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1"')]`,
+					"commandToExecute": `[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; ERR_OUTBOUND_CONN_FAIL=50; retrycmd_if_failure 50 1 3 nc -vz aksrepos.azurecr.io 443 || exit $ERR_OUTBOUND_CONN_FAIL; for i in $(seq 1 1200); do grep -Fq "EOF" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),` + generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled) + `,' GPU_NODE=false SGX_NODE=false AUDITD_ENABLED=false SCHEDULED_EVENTS_HANDLER=false /usr/bin/nohup /bin/bash -c "/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1"')]`,
 				},
 			},
 			Type: to.StringPtr("Microsoft.Compute/virtualMachines/extensions"),
@@ -334,7 +366,7 @@ func TestCreateAgentVMASCustomScriptExtension(t *testing.T) {
 	}
 
 	// Test with BlockOutboundInternet=true
-	cseValNoOutboundInternetCheck := `[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done };  for i in $(seq 1 1200); do grep -Fq "EOF" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),` + generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled) + `,' GPU_NODE=false SGX_NODE=false AUDITD_ENABLED=false /usr/bin/nohup /bin/bash -c "/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1"')]`
+	cseValNoOutboundInternetCheck := `[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done };  for i in $(seq 1 1200); do grep -Fq "EOF" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),` + generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled) + `,' GPU_NODE=false SGX_NODE=false AUDITD_ENABLED=false SCHEDULED_EVENTS_HANDLER=false /usr/bin/nohup /bin/bash -c "/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1"')]`
 	cs.Properties.FeatureFlags.BlockOutboundInternet = true
 	profile = &api.AgentPoolProfile{
 		Name:   "sample",
@@ -384,7 +416,7 @@ func TestCreateAgentVMASCustomScriptExtension(t *testing.T) {
 	cse = createAgentVMASCustomScriptExtension(cs, profile)
 
 	expectedCSE.ProtectedSettings = &map[string]interface{}{
-		"commandToExecute": `[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; ERR_OUTBOUND_CONN_FAIL=50; retrycmd_if_failure 50 1 3 nc -vz gcr.azk8s.cn 80 || exit $ERR_OUTBOUND_CONN_FAIL; for i in $(seq 1 1200); do grep -Fq "EOF" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),` + generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled) + `,' GPU_NODE=false SGX_NODE=false AUDITD_ENABLED=false /usr/bin/nohup /bin/bash -c "/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1 &"')]`,
+		"commandToExecute": `[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; ERR_OUTBOUND_CONN_FAIL=50; retrycmd_if_failure 50 1 3 nc -vz gcr.azk8s.cn 80 || exit $ERR_OUTBOUND_CONN_FAIL; for i in $(seq 1 1200); do grep -Fq "EOF" /opt/azure/containers/provision.sh && break; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),` + generateUserAssignedIdentityClientIDParameter(userAssignedIDEnabled) + `,' GPU_NODE=false SGX_NODE=false AUDITD_ENABLED=false SCHEDULED_EVENTS_HANDLER=false /usr/bin/nohup /bin/bash -c "/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1 &"')]`,
 	}
 
 	diff = cmp.Diff(cse, expectedCSE)
@@ -583,3 +615,92 @@ func TestCreateCustomExtensions(t *testing.T) {
 		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
 	}
 }
+
+func TestCreateVMExtensions(t *testing.T) {
+	vmExtensions := []api.VMExtension{
+		{
+			Name:                    "qualys",
+			Publisher:               "Qualys",
+			Type:                    "QualysAgentLinux",
+			TypeHandlerVersion:      "1.6",
+			AutoUpgradeMinorVersion: to.BoolPtr(true),
+			ProtectedSettings:       map[string]interface{}{"LicenseCode": "secret"},
+		},
+		{
+			Name:                    "monitoring",
+			Publisher:               "Microsoft.EnterpriseCloud.Monitoring",
+			Type:                    "OmsAgentForLinux",
+			TypeHandlerVersion:      "1.12",
+			AutoUpgradeMinorVersion: to.BoolPtr(false),
+			Settings:                map[string]interface{}{"workspaceId": "id"},
+		},
+	}
+	profile := &api.AgentPoolProfile{Name: "agentpool1", VMExtensions: vmExtensions}
+
+	extensions := createAgentVMASVMExtensions(profile)
+	if len(extensions) != 2 {
+		t.Fatalf("expected 2 extensions, got %d", len(extensions))
+	}
+	expectedDependsOn := [][]string{
+		{"[concat('Microsoft.Compute/virtualMachines/', variables('agentpool1VMNamePrefix'), copyIndex(variables('agentpool1Offset')), '/extensions/', 'cse-agent-', copyIndex(variables('agentpool1Offset')))]"},
+		{"[concat('Microsoft.Compute/virtualMachines/', variables('agentpool1VMNamePrefix'), copyIndex(variables('agentpool1Offset')), '/extensions/qualys')]"},
+	}
+	for i, ext := range extensions {
+		if diff := cmp.Diff(ext.DependsOn, expectedDependsOn[i]); diff != "" {
+			t.Errorf("unexpected dependsOn of extension %d: %s", i, diff)
+		}
+	}
+
+	expected := VirtualMachineExtensionARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionCompute')]",
+			Copy: map[string]string{
+				"count": "[sub(variables('agentpool1Count'), variables('agentpool1Offset'))]",
+				"name":  "vmLoopNode",
+			},
+			DependsOn: expectedDependsOn[1],
+		},
+		VirtualMachineExtension: compute.VirtualMachineExtension{
+			Location: to.StringPtr("[variables('location')]"),
+			Name:     to.StringPtr("[concat(variables('agentpool1VMNamePrefix'), copyIndex(variables('agentpool1Offset')), '/monitoring')]"),
+			VirtualMachineExtensionProperties: &compute.VirtualMachineExtensionProperties{
+				Publisher:               to.StringPtr("Microsoft.EnterpriseCloud.Monitoring"),
+				Type:                    to.StringPtr("OmsAgentForLinux"),
+				TypeHandlerVersion:      to.StringPtr("1.12"),
+				AutoUpgradeMinorVersion: to.BoolPtr(false),
+				Settings:                &map[string]interface{}{"workspaceId": "id"},
+			},
+			Type: to.StringPtr("Microsoft.Compute/virtualMachines/extensions"),
+			Tags: map[string]*string{},
+		},
+	}
+	if diff := cmp.Diff(extensions[1], expected); diff != "" {
+		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
+	}
+	if diff := cmp.Diff(extensions[0].ProtectedSettings, &map[string]interface{}{"LicenseCode": "secret"}); diff != "" {
+		t.Errorf("unexpected protected settings: %s", diff)
+	}
+
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			MasterProfile: &api.MasterProfile{VMExtensions: vmExtensions[:1]},
+		},
+	}
+	masterExtensions := createMasterVMExtensions(cs)
+	if len(masterExtensions) != 1 || masterExtensions[0].DependsOn[0] != "[concat('Microsoft.Compute/virtualMachines/', variables('masterVMNamePrefix'), copyIndex(variables('masterOffset')), '/extensions/', 'cse-master-', copyIndex(variables('masterOffset')))]" {
+		t.Errorf("expected the master extension to depend on the master custom script extension, got %v", masterExtensions)
+	}
+
+	vmssExtensions := createVMSSVMExtensions(vmExtensions, "vmssCSE")
+	if len(vmssExtensions) != 2 {
+		t.Fatalf("expected 2 scale set extensions, got %d", len(vmssExtensions))
+	}
+	for i, provisionAfter := range []string{"vmssCSE", "qualys"} {
+		if diff := cmp.Diff(*vmssExtensions[i].ProvisionAfterExtensions, []string{provisionAfter}); diff != "" {
+			t.Errorf("unexpected provisionAfterExtensions of scale set extension %d: %s", i, diff)
+		}
+	}
+	if vmssExtensions[1].ProtectedSettings != nil {
+		t.Errorf("expected no protected settings, got %v", vmssExtensions[1].ProtectedSettings)
+	}
+}
@@ -44,19 +44,30 @@ func CreatePkiKeyCertPair(commonName string) (*PkiKeyCertPair, error) {
 	return caPair, nil
 }
 
+// CreateServerKeyCertPair generates a pair of PKI server certificate and private key for the DNS names, signed by the CA pair
+func CreateServerKeyCertPair(commonName string, dnsNames []string, caPair *PkiKeyCertPair) (*PkiKeyCertPair, error) {
+	caCertificate, err := pemToCertificate(caPair.CertificatePem)
+	if err != nil {
+		return nil, err
+	}
+	caPrivateKey, err := pemToKey(caPair.PrivateKeyPem)
+	if err != nil {
+		return nil, err
+	}
+	certificate, privateKey, err := createCertificate(commonName, caCertificate, caPrivateKey, false, true, dnsNames, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &PkiKeyCertPair{CertificatePem: string(certificateToPem(certificate.Raw)), PrivateKeyPem: string(privateKeyToPem(privateKey))}, nil
+}
+
 // CreatePki creates PKI certificates
 func CreatePki(extraFQDNs []string, extraIPs []net.IP, clusterDomain string, caPair *PkiKeyCertPair, masterCount int) (*PkiKeyCertPair, *PkiKeyCertPair, *PkiKeyCertPair, *PkiKeyCertPair, *PkiKeyCertPair, []*PkiKeyCertPair, error) {
 	start := time.Now()
 	defer func(s time.Time) {
 		log.Debugf("pki: PKI asset creation took %s", time.Since(s))
 	}(start)
-	extraFQDNs = append(extraFQDNs, fmt.Sprintf("kubernetes"))
-	extraFQDNs = append(extraFQDNs, fmt.Sprintf("kubernetes.default"))
-	extraFQDNs = append(extraFQDNs, fmt.Sprintf("kubernetes.default.svc"))
-	extraFQDNs = append(extraFQDNs, fmt.Sprintf("kubernetes.default.svc.%s", clusterDomain))
-	extraFQDNs = append(extraFQDNs, fmt.Sprintf("kubernetes.kube-system"))
-	extraFQDNs = append(extraFQDNs, fmt.Sprintf("kubernetes.kube-system.svc"))
-	extraFQDNs = append(extraFQDNs, fmt.Sprintf("kubernetes.kube-system.svc.%s", clusterDomain))
+	extraFQDNs = apiServerFQDNs(extraFQDNs, clusterDomain)
 
 	var (
 		caCertificate         *x509.Certificate
@@ -137,7 +148,118 @@ func CreatePki(extraFQDNs []string, extraIPs []net.IP, clusterDomain string, caP
 		nil
 }
 
+// CreateAPIServerKeyCertPair generates a new apiserver certificate signed by the CA pair for the private key of the
+// apiserver, which signs the service account tokens, or for a new private key if privateKeyPem is empty
+func CreateAPIServerKeyCertPair(extraFQDNs []string, extraIPs []net.IP, clusterDomain string, caPair *PkiKeyCertPair, privateKeyPem string) (*PkiKeyCertPair, error) {
+	caCertificate, caPrivateKey, err := caKeyCertPair(caPair)
+	if err != nil {
+		return nil, err
+	}
+	var privateKey *rsa.PrivateKey
+	if privateKeyPem != "" {
+		if privateKey, err = pemToKey(privateKeyPem); err != nil {
+			return nil, err
+		}
+	}
+	certificate, privateKey, err := createCertificateForKey("apiserver", caCertificate, caPrivateKey, false, true, apiServerFQDNs(extraFQDNs, clusterDomain), extraIPs, nil, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &PkiKeyCertPair{CertificatePem: string(certificateToPem(certificate.Raw)), PrivateKeyPem: string(privateKeyToPem(privateKey))}, nil
+}
+
+// CreateClientKeyCertPair generates a new client certificate of the system:masters group signed by the CA pair, as used
+// by the kubelets
+func CreateClientKeyCertPair(caPair *PkiKeyCertPair) (*PkiKeyCertPair, error) {
+	caCertificate, caPrivateKey, err := caKeyCertPair(caPair)
+	if err != nil {
+		return nil, err
+	}
+	certificate, privateKey, err := createCertificate("client", caCertificate, caPrivateKey, false, false, nil, nil, []string{"system:masters"})
+	if err != nil {
+		return nil, err
+	}
+	return &PkiKeyCertPair{CertificatePem: string(certificateToPem(certificate.Raw)), PrivateKeyPem: string(privateKeyToPem(privateKey))}, nil
+}
+
+// CreateEtcdPki generates new etcd server, client and peer certificates signed by the CA pair
+func CreateEtcdPki(extraIPs []net.IP, caPair *PkiKeyCertPair, masterCount int) (*PkiKeyCertPair, *PkiKeyCertPair, []*PkiKeyCertPair, error) {
+	caCertificate, caPrivateKey, err := caKeyCertPair(caPair)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var group errgroup.Group
+	var etcdServerPair, etcdClientPair *PkiKeyCertPair
+	newPair := func(commonName string, pair **PkiKeyCertPair) func() error {
+		return func() error {
+			certificate, privateKey, err := createCertificate(commonName, caCertificate, caPrivateKey, true, commonName == "etcdserver", nil, extraIPs, nil)
+			if err != nil {
+				return err
+			}
+			*pair = &PkiKeyCertPair{CertificatePem: string(certificateToPem(certificate.Raw)), PrivateKeyPem: string(privateKeyToPem(privateKey))}
+			return nil
+		}
+	}
+	group.Go(newPair("etcdserver", &etcdServerPair))
+	group.Go(newPair("etcdclient", &etcdClientPair))
+	etcdPeerPairs := make([]*PkiKeyCertPair, masterCount)
+	for i := range etcdPeerPairs {
+		group.Go(newPair("etcdpeer", &etcdPeerPairs[i]))
+	}
+	if err := group.Wait(); err != nil {
+		return nil, nil, nil, err
+	}
+	return etcdServerPair, etcdClientPair, etcdPeerPairs, nil
+}
+
+// CreateFrontProxyPki generates a new front proxy CA pair, which the apiserver trusts for the requests proxied by the
+// aggregation layer, and the client pair it signs for the apiserver to proxy the requests
+func CreateFrontProxyPki() (*PkiKeyCertPair, *PkiKeyCertPair, error) {
+	caCertificate, caPrivateKey, err := createCertificate("proxyClientCA", nil, nil, false, false, nil, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	certificate, privateKey, err := createCertificate("aggregator", caCertificate, caPrivateKey, false, false, nil, nil, []string{"system:masters"})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &PkiKeyCertPair{CertificatePem: string(certificateToPem(caCertificate.Raw)), PrivateKeyPem: string(privateKeyToPem(caPrivateKey))},
+		&PkiKeyCertPair{CertificatePem: string(certificateToPem(certificate.Raw)), PrivateKeyPem: string(privateKeyToPem(privateKey))},
+		nil
+}
+
+// apiServerFQDNs returns the DNS names of the apiserver certificate, the extra FQDNs and the names of the
+// kubernetes service
+func apiServerFQDNs(extraFQDNs []string, clusterDomain string) []string {
+	fqdns := append([]string{}, extraFQDNs...)
+	fqdns = append(fqdns, "kubernetes")
+	fqdns = append(fqdns, "kubernetes.default")
+	fqdns = append(fqdns, "kubernetes.default.svc")
+	fqdns = append(fqdns, fmt.Sprintf("kubernetes.default.svc.%s", clusterDomain))
+	fqdns = append(fqdns, "kubernetes.kube-system")
+	fqdns = append(fqdns, "kubernetes.kube-system.svc")
+	fqdns = append(fqdns, fmt.Sprintf("kubernetes.kube-system.svc.%s", clusterDomain))
+	return fqdns
+}
+
+func caKeyCertPair(caPair *PkiKeyCertPair) (*x509.Certificate, *rsa.PrivateKey, error) {
+	caCertificate, err := pemToCertificate(caPair.CertificatePem)
+	if err != nil {
+		return nil, nil, err
+	}
+	caPrivateKey, err := pemToKey(caPair.PrivateKeyPem)
+	if err != nil {
+		return nil, nil, err
+	}
+	return caCertificate, caPrivateKey, nil
+}
+
 func createCertificate(commonName string, caCertificate *x509.Certificate, caPrivateKey *rsa.PrivateKey, isEtcd bool, isServer bool, extraFQDNs []string, extraIPs []net.IP, organization []string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	return createCertificateForKey(commonName, caCertificate, caPrivateKey, isEtcd, isServer, extraFQDNs, extraIPs, organization, nil)
+}
+
+// createCertificateForKey creates a certificate for the private key, or for a new private key if it is nil
+func createCertificateForKey(commonName string, caCertificate *x509.Certificate, caPrivateKey *rsa.PrivateKey, isEtcd bool, isServer bool, extraFQDNs []string, extraIPs []net.IP, organization []string, privateKey *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey, error) {
 	var err error
 
 	isCA := (caCertificate == nil)
@@ -186,7 +308,9 @@ func createCertificate(commonName string, caCertificate *x509.Certificate, caPri
 		return nil, nil, err
 	}
 
-	privateKey, _ := rsa.GenerateKey(rand.Reader, PkiKeySize)
+	if privateKey == nil {
+		privateKey, _ = rsa.GenerateKey(rand.Reader, PkiKeySize)
+	}
 
 	var privateKeyToUse *rsa.PrivateKey
 	var certificateToUse *x509.Certificate
@@ -235,3 +235,123 @@ func TestCreatePkiKeyCertPair(t *testing.T) {
 		t.Errorf("unexpected error thrown while executing CreatePkiKeyCertPair : %s", err.Error())
 	}
 }
+
+func TestCreateServerKeyCertPair(t *testing.T) {
+	caPair, err := CreatePkiKeyCertPair("ca")
+	if err != nil {
+		t.Fatalf("unexpected error thrown while executing CreatePkiKeyCertPair : %s", err.Error())
+	}
+	pair, err := CreateServerKeyCertPair("webhook", []string{"webhook.default.svc"}, caPair)
+	if err != nil {
+		t.Fatalf("unexpected error thrown while executing CreateServerKeyCertPair : %s", err.Error())
+	}
+
+	caCertificate, err := pemToCertificate(caPair.CertificatePem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := pemToCertificate(pair.CertificatePem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(caCertificate)
+	if _, err = certificate.Verify(x509.VerifyOptions{DNSName: "webhook.default.svc", Roots: roots}); err != nil {
+		t.Errorf("expected the server certificate to be valid for webhook.default.svc, got %s", err)
+	}
+	if _, err = pemToKey(pair.PrivateKeyPem); err != nil {
+		t.Errorf("unexpected error parsing the server private key : %s", err)
+	}
+
+	if _, err = CreateServerKeyCertPair("webhook", nil, &PkiKeyCertPair{}); err == nil {
+		t.Errorf("expected an error for an invalid CA pair")
+	}
+}
+
+func TestCreateAPIServerKeyCertPair(t *testing.T) {
+	caPair, err := CreatePkiKeyCertPair("ca")
+	if err != nil {
+		t.Fatalf("unexpected error thrown while executing CreatePkiKeyCertPair : %s", err.Error())
+	}
+	pair, err := CreateAPIServerKeyCertPair([]string{"mycluster.westus2.cloudapp.azure.com"}, []net.IP{net.ParseIP("10.255.255.5")}, "cluster.local", caPair, "")
+	if err != nil {
+		t.Fatalf("unexpected error thrown while executing CreateAPIServerKeyCertPair : %s", err.Error())
+	}
+	renewed, err := CreateAPIServerKeyCertPair([]string{"mycluster.westus2.cloudapp.azure.com"}, []net.IP{net.ParseIP("10.255.255.5")}, "cluster.local", caPair, pair.PrivateKeyPem)
+	if err != nil {
+		t.Fatalf("unexpected error thrown while executing CreateAPIServerKeyCertPair : %s", err.Error())
+	}
+	if renewed.PrivateKeyPem != pair.PrivateKeyPem || renewed.CertificatePem == pair.CertificatePem {
+		t.Errorf("expected a new apiserver certificate for the same private key")
+	}
+
+	caCertificate, err := pemToCertificate(caPair.CertificatePem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := pemToCertificate(renewed.CertificatePem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(caCertificate)
+	for _, name := range []string{"mycluster.westus2.cloudapp.azure.com", "kubernetes.default.svc.cluster.local"} {
+		if _, err = certificate.Verify(x509.VerifyOptions{DNSName: name, Roots: roots}); err != nil {
+			t.Errorf("expected the apiserver certificate to be valid for %s, got %s", name, err)
+		}
+	}
+
+	if _, err = CreateAPIServerKeyCertPair(nil, nil, "cluster.local", caPair, "invalid"); err == nil {
+		t.Errorf("expected an error for an invalid private key")
+	}
+}
+
+func TestCreateEtcdPki(t *testing.T) {
+	caPair, err := CreatePkiKeyCertPair("ca")
+	if err != nil {
+		t.Fatalf("unexpected error thrown while executing CreatePkiKeyCertPair : %s", err.Error())
+	}
+	server, client, peers, err := CreateEtcdPki([]net.IP{net.ParseIP("10.255.255.5")}, caPair, 1)
+	if err != nil {
+		t.Fatalf("unexpected error thrown while executing CreateEtcdPki : %s", err.Error())
+	}
+	if len(peers) != 1 {
+		t.Fatalf("expected 1 etcd peer pair, got %d", len(peers))
+	}
+	for name, pair := range map[string]*PkiKeyCertPair{"etcdserver": server, "etcdclient": client, "etcdpeer": peers[0]} {
+		certificate, err := pemToCertificate(pair.CertificatePem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if certificate.Subject.CommonName != name || len(certificate.IPAddresses) != 1 {
+			t.Errorf("expected the %s certificate to be valid for the etcd IP address, got %s for %v", name, certificate.Subject.CommonName, certificate.IPAddresses)
+		}
+	}
+
+	if _, _, _, err = CreateEtcdPki(nil, &PkiKeyCertPair{}, 1); err == nil {
+		t.Errorf("expected an error for an invalid CA pair")
+	}
+}
+
+func TestCreateFrontProxyPki(t *testing.T) {
+	caPair, clientPair, err := CreateFrontProxyPki()
+	if err != nil {
+		t.Fatalf("unexpected error thrown while executing CreateFrontProxyPki : %s", err.Error())
+	}
+	caCertificate, err := pemToCertificate(caPair.CertificatePem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := pemToCertificate(clientPair.CertificatePem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(caCertificate)
+	if _, err = certificate.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Errorf("expected the front proxy client certificate to be signed by the front proxy CA, got %s", err)
+	}
+	if certificate.Subject.CommonName != "aggregator" || len(certificate.Subject.Organization) != 1 || certificate.Subject.Organization[0] != "system:masters" {
+		t.Errorf("expected the front proxy client certificate to be aggregator of system:masters, got %v", certificate.Subject)
+	}
+}
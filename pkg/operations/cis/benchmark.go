@@ -0,0 +1,389 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package cis audits the nodes of a cluster built with AKS Engine against the CIS Kubernetes Benchmark,
+// and maps the results to the api model settings the cluster was rendered from.
+package cis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/pkg/errors"
+)
+
+// Role is the kind of node a check applies to
+type Role string
+
+const (
+	// RoleMaster checks only apply to master nodes
+	RoleMaster Role = "master"
+	// RoleNode checks apply to every node
+	RoleNode Role = "node"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	// StatusPass means the node complies with the check
+	StatusPass Status = "PASS"
+	// StatusFail means the node does not comply with the check
+	StatusFail Status = "FAIL"
+	// StatusWarn means the check could not be evaluated, e.g. because the file it audits does not exist
+	StatusWarn Status = "WARN"
+)
+
+// Component is a process whose command line arguments are audited
+type Component string
+
+const (
+	// APIServer is kube-apiserver
+	APIServer Component = "kube-apiserver"
+	// ControllerManager is kube-controller-manager
+	ControllerManager Component = "kube-controller-manager"
+	// Scheduler is kube-scheduler
+	Scheduler Component = "kube-scheduler"
+	// Etcd is etcd
+	Etcd Component = "etcd"
+	// Kubelet is kubelet
+	Kubelet Component = "kubelet"
+)
+
+// Op is the comparison a FlagTest makes
+type Op string
+
+const (
+	// OpEquals passes if the flag has the value
+	OpEquals Op = "eq"
+	// OpNotEquals passes if the flag does not have the value
+	OpNotEquals Op = "noteq"
+	// OpSet passes if the flag is set
+	OpSet Op = "set"
+	// OpNotSet passes if the flag is not set
+	OpNotSet Op = "notset"
+	// OpGreaterOrEqual passes if the flag is a number greater than or equal to the value
+	OpGreaterOrEqual Op = "gte"
+	// OpContains passes if the value is one of the comma separated values of the flag
+	OpContains Op = "has"
+	// OpNotContains passes if the value is not one of the comma separated values of the flag
+	OpNotContains Op = "nothas"
+)
+
+// Check is a benchmark recommendation, audited either by a FlagTest or by a FileTest
+type Check struct {
+	ID     string
+	Text   string
+	Role   Role
+	Scored bool
+	Flag   *FlagTest
+	File   *FileTest
+}
+
+// FlagTest audits a command line argument of a component
+type FlagTest struct {
+	Component Component
+	Flag      string
+	Op        Op
+	Value     string
+	// Default is the value the component uses when the flag is not set
+	Default string
+}
+
+// FileTest audits the permissions and ownership of a file
+type FileTest struct {
+	Path string
+	// MaxMode is the most permissive mode the file may have, 0 does not audit the mode
+	MaxMode uint32
+	// Owner is the expected user:group of the file, an empty string does not audit the ownership
+	Owner string
+}
+
+// Setting is the api model configuration a component was rendered with
+type Setting struct {
+	// Path is the location of the configuration in the api model, e.g. orchestratorProfile.kubernetesConfig.apiServerConfig
+	Path   string
+	Values map[string]string
+}
+
+// Settings are the rendered settings of the components of a node
+type Settings map[Component]Setting
+
+// Result is the outcome of a check on a node
+type Result struct {
+	ID     string `json:"id"`
+	Text   string `json:"text"`
+	Scored bool   `json:"scored"`
+	Status Status `json:"status"`
+	// Actual is the value found on the node
+	Actual string `json:"actual,omitempty"`
+	// Rendered is the value in the api model, RenderedSetting where it is set
+	Rendered        string `json:"rendered,omitempty"`
+	RenderedSetting string `json:"renderedSetting,omitempty"`
+	// Drift is true if the value found on the node is not the value in the api model
+	Drift       bool   `json:"drift,omitempty"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// AppliesTo returns true if the check runs on nodes of role
+func (c Check) AppliesTo(role Role) bool {
+	return c.Role == RoleNode || c.Role == role
+}
+
+// Audit returns the shell command that collects the data the check evaluates
+func (c Check) Audit() string {
+	if c.Flag != nil {
+		// static pods run the components through hyperkube, e.g. "/hyperkube kube-apiserver --flag=value"
+		return fmt.Sprintf(`ps -eo args --no-headers | grep -E '^(\S*/)?(hyperkube )?(\S*/)?%s( |$)' | head -n 1 || true`, c.Flag.Component)
+	}
+	return fmt.Sprintf("sudo stat -c '%%a %%U:%%G' %s 2>/dev/null || true", c.File.Path)
+}
+
+// Evaluate returns the result of the check from the output of its audit command on a node rendered with settings
+func (c Check) Evaluate(output string, settings Settings) Result {
+	r := Result{
+		ID:     c.ID,
+		Text:   c.Text,
+		Scored: c.Scored,
+	}
+	output = strings.TrimSpace(output)
+	switch {
+	case c.Flag != nil:
+		c.Flag.evaluate(output, settings[c.Flag.Component], &r)
+	case c.File != nil:
+		c.File.evaluate(output, &r)
+	default:
+		r.Status = StatusWarn
+		r.Remediation = "the check has no test"
+	}
+	return r
+}
+
+func (t *FlagTest) evaluate(output string, setting Setting, r *Result) {
+	if output == "" {
+		r.Status = StatusWarn
+		r.Remediation = fmt.Sprintf("%s is not running on the node", t.Component)
+		return
+	}
+	flags := ParseFlags(output)
+	actual, isSet := flags[t.Flag]
+	r.Actual = actual
+	if rendered, ok := setting.Values[t.Flag]; ok {
+		r.Rendered = rendered
+		r.RenderedSetting = setting.Path
+		r.Drift = !isSet || rendered != actual
+	}
+	if !isSet && t.Default != "" {
+		actual, isSet = t.Default, true
+		r.Actual = t.Default + " (default)"
+	}
+
+	if t.test(actual, isSet) {
+		r.Status = StatusPass
+		return
+	}
+	r.Status = StatusFail
+	r.Remediation = t.remediation(setting.Path)
+}
+
+// test returns true if actual, the value of the flag if isSet, passes the test
+func (t *FlagTest) test(actual string, isSet bool) bool {
+	switch t.Op {
+	case OpSet:
+		return isSet
+	case OpNotSet:
+		return !isSet
+	case OpEquals:
+		return isSet && actual == t.Value
+	case OpNotEquals:
+		return !isSet || actual != t.Value
+	case OpGreaterOrEqual:
+		want, err := strconv.Atoi(t.Value)
+		if err != nil || !isSet {
+			return false
+		}
+		got, err := strconv.Atoi(actual)
+		return err == nil && got >= want
+	case OpContains:
+		return isSet && containsValue(actual, t.Value)
+	case OpNotContains:
+		return !isSet || !containsValue(actual, t.Value)
+	}
+	return false
+}
+
+// remediation describes the api model change, or the change on the node if the component is not configured through the api model
+func (t *FlagTest) remediation(path string) string {
+	var change string
+	switch t.Op {
+	case OpSet:
+		change = fmt.Sprintf(`set "%s"`, t.Flag)
+	case OpNotSet:
+		change = fmt.Sprintf(`remove "%s"`, t.Flag)
+	case OpEquals:
+		change = fmt.Sprintf(`set "%s": "%s"`, t.Flag, t.Value)
+	case OpNotEquals:
+		change = fmt.Sprintf(`set "%s" to a value other than "%s"`, t.Flag, t.Value)
+	case OpGreaterOrEqual:
+		change = fmt.Sprintf(`set "%s" to %s or more`, t.Flag, t.Value)
+	case OpContains:
+		change = fmt.Sprintf(`add %s to "%s"`, t.Value, t.Flag)
+	case OpNotContains:
+		change = fmt.Sprintf(`remove %s from "%s"`, t.Value, t.Flag)
+	}
+	if path == "" {
+		return fmt.Sprintf("%s in the %s arguments on the node", change, t.Component)
+	}
+	return fmt.Sprintf("%s in %s", change, path)
+}
+
+func (t *FileTest) evaluate(output string, r *Result) {
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		r.Status = StatusWarn
+		r.Remediation = fmt.Sprintf("%s does not exist on the node", t.Path)
+		return
+	}
+	mode, err := strconv.ParseUint(fields[0], 8, 32)
+	if err != nil {
+		r.Status = StatusWarn
+		r.Remediation = fmt.Sprintf("unexpected mode %s of %s", fields[0], t.Path)
+		return
+	}
+
+	r.Status = StatusPass
+	if t.MaxMode != 0 {
+		r.Actual = fields[0]
+		if uint32(mode)&^t.MaxMode != 0 {
+			r.Status = StatusFail
+			r.Remediation = fmt.Sprintf("chmod %o %s on the node", t.MaxMode, t.Path)
+		}
+	}
+	if t.Owner != "" {
+		r.Actual = fields[1]
+		if fields[1] != t.Owner {
+			r.Status = StatusFail
+			r.Remediation = fmt.Sprintf("chown %s %s on the node", t.Owner, t.Path)
+		}
+	}
+}
+
+// ParseFlags returns the flags of a command line, by name including the leading dashes.
+// Flags without a value, e.g. "--allow-privileged", are returned as "true".
+func ParseFlags(commandLine string) map[string]string {
+	flags := map[string]string{}
+	fields := strings.Fields(commandLine)
+	for i := 0; i < len(fields); i++ {
+		if !strings.HasPrefix(fields[i], "-") {
+			continue
+		}
+		kv := strings.SplitN(fields[i], "=", 2)
+		switch {
+		case len(kv) == 2:
+			flags[kv[0]] = strings.Trim(kv[1], `"'`)
+		case i+1 < len(fields) && !strings.HasPrefix(fields[i+1], "-"):
+			flags[kv[0]] = strings.Trim(fields[i+1], `"'`)
+			i++
+		default:
+			flags[kv[0]] = "true"
+		}
+	}
+	return flags
+}
+
+func containsValue(list, value string) bool {
+	for _, v := range strings.Split(list, ",") {
+		if strings.TrimSpace(v) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Node is a cluster node to audit
+type Node struct {
+	Name string
+	Role Role
+	// Pool is the agent pool of the node, nil for masters
+	Pool *api.AgentPoolProfile
+}
+
+// CommandRunner runs a shell command on a node and returns its standard output
+type CommandRunner func(hostname, command string) (string, error)
+
+// RenderedSettings returns the api model settings the components of a node were rendered with
+func RenderedSettings(cs *api.ContainerService, node Node) Settings {
+	settings := Settings{}
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+	if k == nil {
+		return settings
+	}
+	const orchestratorPath = "orchestratorProfile.kubernetesConfig"
+	if node.Role == RoleMaster {
+		settings[APIServer] = Setting{Path: orchestratorPath + ".apiServerConfig", Values: k.APIServerConfig}
+		settings[ControllerManager] = Setting{Path: orchestratorPath + ".controllerManagerConfig", Values: k.ControllerManagerConfig}
+		settings[Scheduler] = Setting{Path: orchestratorPath + ".schedulerConfig", Values: k.SchedulerConfig}
+	}
+
+	kubelet := Setting{Path: orchestratorPath + ".kubeletConfig", Values: k.KubeletConfig}
+	switch {
+	case node.Role == RoleMaster && cs.Properties.MasterProfile != nil && cs.Properties.MasterProfile.KubernetesConfig != nil && len(cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig) > 0:
+		kubelet = Setting{Path: "masterProfile.kubernetesConfig.kubeletConfig", Values: cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig}
+	case node.Pool != nil && node.Pool.KubernetesConfig != nil && len(node.Pool.KubernetesConfig.KubeletConfig) > 0:
+		kubelet = Setting{Path: fmt.Sprintf("agentPoolProfiles[%s].kubernetesConfig.kubeletConfig", node.Pool.Name), Values: node.Pool.KubernetesConfig.KubeletConfig}
+	}
+	settings[Kubelet] = kubelet
+	return settings
+}
+
+// Run audits every node with the checks that apply to it and returns the scored report
+func Run(cs *api.ContainerService, nodes []Node, checks []Check, run CommandRunner) (*Report, error) {
+	report := &Report{Benchmark: Benchmark}
+	for _, node := range nodes {
+		settings := RenderedSettings(cs, node)
+		nodeReport := NodeReport{
+			Name: node.Name,
+			Role: node.Role,
+		}
+		if node.Pool != nil {
+			nodeReport.Pool = node.Pool.Name
+		}
+		// several checks audit the same process or file, run each command once per node
+		outputs := map[string]string{}
+		for _, c := range checks {
+			if !c.AppliesTo(node.Role) {
+				continue
+			}
+			audit := c.Audit()
+			output, ok := outputs[audit]
+			if !ok {
+				var err error
+				if output, err = run(node.Name, audit); err != nil {
+					return nil, errors.Wrapf(err, "running the audit of check %s on node %s", c.ID, node.Name)
+				}
+				outputs[audit] = output
+			}
+			nodeReport.Results = append(nodeReport.Results, c.Evaluate(output, settings))
+		}
+		report.AddNode(nodeReport)
+	}
+	return report, nil
+}
+
+// NodeForName returns the node to audit for a cluster node name, and false for nodes that cannot be audited over SSH
+func NodeForName(cs *api.ContainerService, name string) (Node, bool) {
+	if strings.HasPrefix(name, cs.Properties.GetMasterVMPrefix()) {
+		return Node{Name: name, Role: RoleMaster}, true
+	}
+	for _, pool := range cs.Properties.AgentPoolProfiles {
+		if pool.IsWindows() {
+			continue
+		}
+		if prefix := cs.Properties.GetAgentVMPrefix(pool, 0); strings.HasPrefix(name, prefix) {
+			return Node{Name: name, Role: RoleNode, Pool: pool}, true
+		}
+	}
+	return Node{}, false
+}
@@ -0,0 +1,236 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cis
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/pkg/errors"
+)
+
+const apiServerCommandLine = "/hyperkube kube-apiserver --anonymous-auth=false --profiling=false --enable-admission-plugins=NamespaceLifecycle,NodeRestriction --audit-log-maxage 30 --allow-privileged"
+
+func TestParseFlags(t *testing.T) {
+	expected := map[string]string{
+		"--anonymous-auth":           "false",
+		"--profiling":                "false",
+		"--enable-admission-plugins": "NamespaceLifecycle,NodeRestriction",
+		"--audit-log-maxage":         "30",
+		"--allow-privileged":         "true",
+	}
+	if flags := ParseFlags(apiServerCommandLine); !reflect.DeepEqual(flags, expected) {
+		t.Errorf("expected %v, got %v", expected, flags)
+	}
+}
+
+func TestFlagTestEvaluate(t *testing.T) {
+	cases := []struct {
+		name                string
+		check               Check
+		output              string
+		setting             Setting
+		expectedStatus      Status
+		expectedActual      string
+		expectedDrift       bool
+		expectedRemediation string
+	}{
+		{
+			name:           "equals",
+			check:          flagCheck("1.1.1", "", RoleMaster, APIServer, "--anonymous-auth", OpEquals, "false", "true"),
+			output:         apiServerCommandLine,
+			expectedStatus: StatusPass,
+			expectedActual: "false",
+		},
+		{
+			name:                "default value",
+			check:               flagCheck("1.1.6", "", RoleMaster, APIServer, "--insecure-port", OpEquals, "0", "8080"),
+			output:              apiServerCommandLine,
+			setting:             Setting{Path: "orchestratorProfile.kubernetesConfig.apiServerConfig"},
+			expectedStatus:      StatusFail,
+			expectedActual:      "8080 (default)",
+			expectedRemediation: `set "--insecure-port": "0" in orchestratorProfile.kubernetesConfig.apiServerConfig`,
+		},
+		{
+			name:           "contains",
+			check:          flagCheck("1.1.32", "", RoleMaster, APIServer, "--enable-admission-plugins", OpContains, "NodeRestriction", ""),
+			output:         apiServerCommandLine,
+			expectedStatus: StatusPass,
+			expectedActual: "NamespaceLifecycle,NodeRestriction",
+		},
+		{
+			name:                "does not contain",
+			check:               flagCheck("1.1.11", "", RoleMaster, APIServer, "--enable-admission-plugins", OpContains, "AlwaysPullImages", ""),
+			output:              apiServerCommandLine,
+			expectedStatus:      StatusFail,
+			expectedActual:      "NamespaceLifecycle,NodeRestriction",
+			expectedRemediation: `add AlwaysPullImages to "--enable-admission-plugins" in the kube-apiserver arguments on the node`,
+		},
+		{
+			name:           "greater or equal",
+			check:          flagCheck("1.1.16", "", RoleMaster, APIServer, "--audit-log-maxage", OpGreaterOrEqual, "30", ""),
+			output:         apiServerCommandLine,
+			expectedStatus: StatusPass,
+			expectedActual: "30",
+		},
+		{
+			name:           "not set",
+			check:          flagCheck("1.1.2", "", RoleMaster, APIServer, "--basic-auth-file", OpNotSet, "", ""),
+			output:         apiServerCommandLine,
+			expectedStatus: StatusPass,
+		},
+		{
+			name:           "drift from the api model",
+			check:          flagCheck("1.1.8", "", RoleMaster, APIServer, "--profiling", OpEquals, "false", "true"),
+			output:         apiServerCommandLine,
+			setting:        Setting{Path: "orchestratorProfile.kubernetesConfig.apiServerConfig", Values: map[string]string{"--profiling": "true"}},
+			expectedStatus: StatusPass,
+			expectedActual: "false",
+			expectedDrift:  true,
+		},
+		{
+			name:                "process not running",
+			check:               flagCheck("1.5.1", "", RoleMaster, Etcd, "--cert-file", OpSet, "", ""),
+			output:              "",
+			expectedStatus:      StatusWarn,
+			expectedRemediation: "etcd is not running on the node",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			r := c.check.Evaluate(c.output, Settings{c.check.Flag.Component: c.setting})
+			if r.Status != c.expectedStatus || r.Actual != c.expectedActual || r.Drift != c.expectedDrift || r.Remediation != c.expectedRemediation {
+				t.Errorf("expected %s, %q, drift %t, %q, got %s, %q, drift %t, %q", c.expectedStatus, c.expectedActual, c.expectedDrift, c.expectedRemediation, r.Status, r.Actual, r.Drift, r.Remediation)
+			}
+		})
+	}
+}
+
+func TestFileTestEvaluate(t *testing.T) {
+	cases := []struct {
+		name           string
+		check          Check
+		output         string
+		expectedStatus Status
+	}{
+		{"mode", fileCheck("1.4.1", "", RoleMaster, "/etc/kubernetes/manifests/kube-apiserver.yaml", 0644, ""), "644 root:root", StatusPass},
+		{"more restrictive mode", fileCheck("1.4.1", "", RoleMaster, "/etc/kubernetes/manifests/kube-apiserver.yaml", 0644, ""), "600 root:root", StatusPass},
+		{"more permissive mode", fileCheck("1.4.1", "", RoleMaster, "/etc/kubernetes/manifests/kube-apiserver.yaml", 0644, ""), "664 root:root", StatusFail},
+		{"owner", fileCheck("1.4.2", "", RoleMaster, "/etc/kubernetes/manifests/kube-apiserver.yaml", 0, "root:root"), "777 root:root", StatusPass},
+		{"other owner", fileCheck("1.4.2", "", RoleMaster, "/etc/kubernetes/manifests/kube-apiserver.yaml", 0, "root:root"), "644 azureuser:root", StatusFail},
+		{"missing file", fileCheck("1.4.2", "", RoleMaster, "/etc/kubernetes/manifests/kube-apiserver.yaml", 0, "root:root"), "", StatusWarn},
+	}
+
+	for _, c := range cases {
+		if r := c.check.Evaluate(c.output, nil); r.Status != c.expectedStatus {
+			t.Errorf("%s: expected %s, got %s", c.name, c.expectedStatus, r.Status)
+		}
+	}
+}
+
+func TestRenderedSettings(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 1, 1, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig = map[string]string{"--profiling": "false"}
+	cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig = map[string]string{"--read-only-port": "0"}
+	pool := cs.Properties.AgentPoolProfiles[0]
+	pool.KubernetesConfig = &api.KubernetesConfig{KubeletConfig: map[string]string{"--read-only-port": "10255"}}
+
+	master := RenderedSettings(cs, Node{Role: RoleMaster})
+	if master[APIServer].Values["--profiling"] != "false" || master[Kubelet].Path != "orchestratorProfile.kubernetesConfig.kubeletConfig" {
+		t.Errorf("unexpected master settings %v", master)
+	}
+
+	agent := RenderedSettings(cs, Node{Role: RoleNode, Pool: pool})
+	if _, ok := agent[APIServer]; ok {
+		t.Errorf("expected no kube-apiserver settings for agent nodes")
+	}
+	if agent[Kubelet].Values["--read-only-port"] != "10255" || agent[Kubelet].Path != "agentPoolProfiles["+pool.Name+"].kubernetesConfig.kubeletConfig" {
+		t.Errorf("unexpected agent settings %v", agent)
+	}
+}
+
+func TestNodeForName(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 1, 2, false)
+	cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &api.AgentPoolProfile{Name: "windowspool", OSType: api.Windows})
+	p := cs.Properties
+
+	if n, ok := NodeForName(cs, p.GetMasterVMPrefix()+"0"); !ok || n.Role != RoleMaster {
+		t.Errorf("expected a master node, got %v", n)
+	}
+	if n, ok := NodeForName(cs, p.GetAgentVMPrefix(p.AgentPoolProfiles[0], 0)+"1"); !ok || n.Role != RoleNode || n.Pool != p.AgentPoolProfiles[0] {
+		t.Errorf("expected a node of pool %s, got %v", p.AgentPoolProfiles[0].Name, n)
+	}
+	if _, ok := NodeForName(cs, p.GetAgentVMPrefix(p.AgentPoolProfiles[1], 0)+"000"); ok {
+		t.Errorf("expected Windows nodes to be skipped")
+	}
+}
+
+func TestRun(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 1, 1, false)
+	checks := []Check{
+		flagCheck("1.1.1", "Ensure that the --anonymous-auth argument is set to false", RoleMaster, APIServer, "--anonymous-auth", OpEquals, "false", "true"),
+		flagCheck("1.1.6", "Ensure that the --insecure-port argument is set to 0", RoleMaster, APIServer, "--insecure-port", OpEquals, "0", "8080"),
+		fileCheck("2.2.1", "Ensure that the kubelet kubeconfig file permissions are set to 644 or more restrictive", RoleNode, "/var/lib/kubelet/kubeconfig", 0644, ""),
+	}
+	nodes := []Node{
+		{Name: "master", Role: RoleMaster},
+		{Name: "agent", Role: RoleNode, Pool: cs.Properties.AgentPoolProfiles[0]},
+	}
+	audits := map[string]int{}
+	run := func(hostname, command string) (string, error) {
+		audits[hostname]++
+		if strings.Contains(command, "stat") {
+			return "600 root:root\n", nil
+		}
+		return apiServerCommandLine + "\n", nil
+	}
+
+	report, err := Run(cs, nodes, checks, run)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if audits["master"] != 2 || audits["agent"] != 1 {
+		t.Errorf("expected every audit command to run once per node, got %v", audits)
+	}
+	if len(report.Nodes) != 2 || len(report.Nodes[0].Results) != 3 || len(report.Nodes[1].Results) != 1 {
+		t.Fatalf("unexpected report %v", report)
+	}
+	if report.Pass != 3 || report.Fail != 1 || report.Score != 75 {
+		t.Errorf("expected 3 checks to pass and 1 to fail, got %+v", report.Summary)
+	}
+
+	var b bytes.Buffer
+	if err = report.WriteText(&b); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(b.String(), "3 checks PASS, 1 checks FAIL, 0 checks WARN, score 75.0%") {
+		t.Errorf("unexpected text report:\n%s", b.String())
+	}
+
+	failing := func(hostname, command string) (string, error) {
+		return "", errors.New("connection refused")
+	}
+	if _, err = Run(cs, nodes, checks, failing); err == nil {
+		t.Errorf("expected an error when an audit cannot run")
+	}
+}
+
+func TestChecks(t *testing.T) {
+	ids := map[string]bool{}
+	for _, c := range Checks() {
+		if ids[c.ID] {
+			t.Errorf("check %s is defined twice", c.ID)
+		}
+		ids[c.ID] = true
+		if (c.Flag == nil) == (c.File == nil) {
+			t.Errorf("check %s must have exactly one of a flag and a file test", c.ID)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cis
+
+// Benchmark is the version of the CIS Kubernetes Benchmark the checks are taken from
+const Benchmark = "CIS Kubernetes Benchmark v1.4.1"
+
+// Checks returns the benchmark checks that apply to clusters built with AKS Engine.
+// Checks of RoleNode run on every Linux node, including the masters, checks of RoleMaster only on the masters.
+func Checks() []Check {
+	return []Check{
+		// 1.1 API Server
+		flagCheck("1.1.1", "Ensure that the --anonymous-auth argument is set to false", RoleMaster, APIServer, "--anonymous-auth", OpEquals, "false", "true"),
+		flagCheck("1.1.2", "Ensure that the --basic-auth-file argument is not set", RoleMaster, APIServer, "--basic-auth-file", OpNotSet, "", ""),
+		flagCheck("1.1.3", "Ensure that the --insecure-allow-any-token argument is not set", RoleMaster, APIServer, "--insecure-allow-any-token", OpNotSet, "", ""),
+		flagCheck("1.1.4", "Ensure that the --kubelet-https argument is set to true", RoleMaster, APIServer, "--kubelet-https", OpEquals, "true", "true"),
+		flagCheck("1.1.5", "Ensure that the --insecure-bind-address argument is not set", RoleMaster, APIServer, "--insecure-bind-address", OpNotSet, "", ""),
+		flagCheck("1.1.6", "Ensure that the --insecure-port argument is set to 0", RoleMaster, APIServer, "--insecure-port", OpEquals, "0", "8080"),
+		flagCheck("1.1.7", "Ensure that the --secure-port argument is not set to 0", RoleMaster, APIServer, "--secure-port", OpNotEquals, "0", "6443"),
+		flagCheck("1.1.8", "Ensure that the --profiling argument is set to false", RoleMaster, APIServer, "--profiling", OpEquals, "false", "true"),
+		flagCheck("1.1.10", "Ensure that the admission control plugin AlwaysAdmit is not set", RoleMaster, APIServer, "--enable-admission-plugins", OpNotContains, "AlwaysAdmit", ""),
+		flagCheck("1.1.11", "Ensure that the admission control plugin AlwaysPullImages is set", RoleMaster, APIServer, "--enable-admission-plugins", OpContains, "AlwaysPullImages", ""),
+		flagCheck("1.1.15", "Ensure that the --audit-log-path argument is set as appropriate", RoleMaster, APIServer, "--audit-log-path", OpSet, "", ""),
+		flagCheck("1.1.16", "Ensure that the --audit-log-maxage argument is set to 30 or as appropriate", RoleMaster, APIServer, "--audit-log-maxage", OpGreaterOrEqual, "30", ""),
+		flagCheck("1.1.17", "Ensure that the --audit-log-maxbackup argument is set to 10 or as appropriate", RoleMaster, APIServer, "--audit-log-maxbackup", OpGreaterOrEqual, "10", ""),
+		flagCheck("1.1.18", "Ensure that the --audit-log-maxsize argument is set to 100 or as appropriate", RoleMaster, APIServer, "--audit-log-maxsize", OpGreaterOrEqual, "100", ""),
+		flagCheck("1.1.19", "Ensure that the --authorization-mode argument is not set to AlwaysAllow", RoleMaster, APIServer, "--authorization-mode", OpNotContains, "AlwaysAllow", "AlwaysAllow"),
+		flagCheck("1.1.20", "Ensure that the --token-auth-file parameter is not set", RoleMaster, APIServer, "--token-auth-file", OpNotSet, "", ""),
+		flagCheck("1.1.21", "Ensure that the --kubelet-certificate-authority argument is set as appropriate", RoleMaster, APIServer, "--kubelet-certificate-authority", OpSet, "", ""),
+		flagCheck("1.1.22", "Ensure that the --kubelet-client-certificate argument is set as appropriate", RoleMaster, APIServer, "--kubelet-client-certificate", OpSet, "", ""),
+		flagCheck("1.1.23", "Ensure that the --service-account-lookup argument is set to true", RoleMaster, APIServer, "--service-account-lookup", OpEquals, "true", "true"),
+		flagCheck("1.1.25", "Ensure that the --service-account-key-file argument is set as appropriate", RoleMaster, APIServer, "--service-account-key-file", OpSet, "", ""),
+		flagCheck("1.1.26", "Ensure that the --etcd-certfile argument is set as appropriate", RoleMaster, APIServer, "--etcd-certfile", OpSet, "", ""),
+		flagCheck("1.1.27", "Ensure that the --tls-cert-file argument is set as appropriate", RoleMaster, APIServer, "--tls-cert-file", OpSet, "", ""),
+		flagCheck("1.1.28", "Ensure that the --client-ca-file argument is set as appropriate", RoleMaster, APIServer, "--client-ca-file", OpSet, "", ""),
+		flagCheck("1.1.29", "Ensure that the --etcd-cafile argument is set as appropriate", RoleMaster, APIServer, "--etcd-cafile", OpSet, "", ""),
+		flagCheck("1.1.31", "Ensure that the --authorization-mode argument includes Node", RoleMaster, APIServer, "--authorization-mode", OpContains, "Node", "AlwaysAllow"),
+		flagCheck("1.1.32", "Ensure that the admission control plugin NodeRestriction is set", RoleMaster, APIServer, "--enable-admission-plugins", OpContains, "NodeRestriction", ""),
+
+		// 1.2 Scheduler
+		flagCheck("1.2.1", "Ensure that the --profiling argument is set to false", RoleMaster, Scheduler, "--profiling", OpEquals, "false", "true"),
+		flagCheck("1.2.2", "Ensure that the --address argument is set to 127.0.0.1", RoleMaster, Scheduler, "--address", OpEquals, "127.0.0.1", "0.0.0.0"),
+
+		// 1.3 Controller Manager
+		flagCheck("1.3.1", "Ensure that the --terminated-pod-gc-threshold argument is set as appropriate", RoleMaster, ControllerManager, "--terminated-pod-gc-threshold", OpSet, "", ""),
+		flagCheck("1.3.2", "Ensure that the --profiling argument is set to false", RoleMaster, ControllerManager, "--profiling", OpEquals, "false", "true"),
+		flagCheck("1.3.3", "Ensure that the --use-service-account-credentials argument is set to true", RoleMaster, ControllerManager, "--use-service-account-credentials", OpEquals, "true", "false"),
+		flagCheck("1.3.4", "Ensure that the --service-account-private-key-file argument is set as appropriate", RoleMaster, ControllerManager, "--service-account-private-key-file", OpSet, "", ""),
+		flagCheck("1.3.5", "Ensure that the --root-ca-file argument is set as appropriate", RoleMaster, ControllerManager, "--root-ca-file", OpSet, "", ""),
+		flagCheck("1.3.6", "Ensure that the RotateKubeletServerCertificate argument is set to true", RoleMaster, ControllerManager, "--feature-gates", OpContains, "RotateKubeletServerCertificate=true", ""),
+		flagCheck("1.3.7", "Ensure that the --address argument is set to 127.0.0.1", RoleMaster, ControllerManager, "--address", OpEquals, "127.0.0.1", "0.0.0.0"),
+
+		// 1.4 Configuration Files
+		fileCheck("1.4.1", "Ensure that the API server pod specification file permissions are set to 644 or more restrictive", RoleMaster, "/etc/kubernetes/manifests/kube-apiserver.yaml", 0644, ""),
+		fileCheck("1.4.2", "Ensure that the API server pod specification file ownership is set to root:root", RoleMaster, "/etc/kubernetes/manifests/kube-apiserver.yaml", 0, "root:root"),
+		fileCheck("1.4.3", "Ensure that the controller manager pod specification file permissions are set to 644 or more restrictive", RoleMaster, "/etc/kubernetes/manifests/kube-controller-manager.yaml", 0644, ""),
+		fileCheck("1.4.4", "Ensure that the controller manager pod specification file ownership is set to root:root", RoleMaster, "/etc/kubernetes/manifests/kube-controller-manager.yaml", 0, "root:root"),
+		fileCheck("1.4.5", "Ensure that the scheduler pod specification file permissions are set to 644 or more restrictive", RoleMaster, "/etc/kubernetes/manifests/kube-scheduler.yaml", 0644, ""),
+		fileCheck("1.4.6", "Ensure that the scheduler pod specification file ownership is set to root:root", RoleMaster, "/etc/kubernetes/manifests/kube-scheduler.yaml", 0, "root:root"),
+		fileCheck("1.4.19", "Ensure that the Kubernetes PKI directory and file ownership is set to root:root", RoleMaster, "/etc/kubernetes/certs", 0, "root:root"),
+		fileCheck("1.4.20", "Ensure that the Kubernetes PKI certificate file permissions are set to 644 or more restrictive", RoleMaster, "/etc/kubernetes/certs/apiserver.crt", 0644, ""),
+		fileCheck("1.4.21", "Ensure that the Kubernetes PKI key file permissions are set to 600", RoleMaster, "/etc/kubernetes/certs/apiserver.key", 0600, ""),
+
+		// 1.5 etcd
+		flagCheck("1.5.1", "Ensure that the --cert-file argument is set as appropriate", RoleMaster, Etcd, "--cert-file", OpSet, "", ""),
+		flagCheck("1.5.2", "Ensure that the --client-cert-auth argument is set to true", RoleMaster, Etcd, "--client-cert-auth", OpEquals, "true", "false"),
+		flagCheck("1.5.3", "Ensure that the --auto-tls argument is not set to true", RoleMaster, Etcd, "--auto-tls", OpNotEquals, "true", "false"),
+		flagCheck("1.5.4", "Ensure that the --peer-cert-file argument is set as appropriate", RoleMaster, Etcd, "--peer-cert-file", OpSet, "", ""),
+		flagCheck("1.5.5", "Ensure that the --peer-client-cert-auth argument is set to true", RoleMaster, Etcd, "--peer-client-cert-auth", OpEquals, "true", "false"),
+		flagCheck("1.5.6", "Ensure that the --peer-auto-tls argument is not set to true", RoleMaster, Etcd, "--peer-auto-tls", OpNotEquals, "true", "false"),
+
+		// 2.1 Kubelet
+		flagCheck("2.1.1", "Ensure that the --anonymous-auth argument is set to false", RoleNode, Kubelet, "--anonymous-auth", OpEquals, "false", "true"),
+		flagCheck("2.1.2", "Ensure that the --authorization-mode argument is not set to AlwaysAllow", RoleNode, Kubelet, "--authorization-mode", OpNotEquals, "AlwaysAllow", "AlwaysAllow"),
+		flagCheck("2.1.3", "Ensure that the --client-ca-file argument is set as appropriate", RoleNode, Kubelet, "--client-ca-file", OpSet, "", ""),
+		flagCheck("2.1.4", "Ensure that the --read-only-port argument is set to 0", RoleNode, Kubelet, "--read-only-port", OpEquals, "0", "10255"),
+		flagCheck("2.1.5", "Ensure that the --streaming-connection-idle-timeout argument is not set to 0", RoleNode, Kubelet, "--streaming-connection-idle-timeout", OpNotEquals, "0", "4h0m0s"),
+		flagCheck("2.1.6", "Ensure that the --protect-kernel-defaults argument is set to true", RoleNode, Kubelet, "--protect-kernel-defaults", OpEquals, "true", "false"),
+		flagCheck("2.1.7", "Ensure that the --make-iptables-util-chains argument is set to true", RoleNode, Kubelet, "--make-iptables-util-chains", OpEquals, "true", "true"),
+		flagCheck("2.1.12", "Ensure that the --rotate-certificates argument is not set to false", RoleNode, Kubelet, "--rotate-certificates", OpNotEquals, "false", "false"),
+		flagCheck("2.1.13", "Ensure that the RotateKubeletServerCertificate argument is set to true", RoleNode, Kubelet, "--feature-gates", OpContains, "RotateKubeletServerCertificate=true", ""),
+
+		// 2.2 Configuration Files
+		fileCheck("2.2.1", "Ensure that the kubelet kubeconfig file permissions are set to 644 or more restrictive", RoleNode, "/var/lib/kubelet/kubeconfig", 0644, ""),
+		fileCheck("2.2.2", "Ensure that the kubelet kubeconfig file ownership is set to root:root", RoleNode, "/var/lib/kubelet/kubeconfig", 0, "root:root"),
+		fileCheck("2.2.3", "Ensure that the kubelet service file permissions are set to 644 or more restrictive", RoleNode, "/etc/systemd/system/kubelet.service", 0644, ""),
+		fileCheck("2.2.4", "Ensure that the kubelet service file ownership is set to root:root", RoleNode, "/etc/systemd/system/kubelet.service", 0, "root:root"),
+		fileCheck("2.2.7", "Ensure that the certificate authorities file permissions are set to 644 or more restrictive", RoleNode, "/etc/kubernetes/certs/ca.crt", 0644, ""),
+		fileCheck("2.2.8", "Ensure that the client certificate authorities file ownership is set to root:root", RoleNode, "/etc/kubernetes/certs/ca.crt", 0, "root:root"),
+	}
+}
+
+func flagCheck(id, text string, role Role, component Component, flag string, op Op, value, defaultValue string) Check {
+	return Check{
+		ID:     id,
+		Text:   text,
+		Role:   role,
+		Scored: true,
+		Flag: &FlagTest{
+			Component: component,
+			Flag:      flag,
+			Op:        op,
+			Value:     value,
+			Default:   defaultValue,
+		},
+	}
+}
+
+func fileCheck(id, text string, role Role, path string, maxMode uint32, owner string) Check {
+	return Check{
+		ID:     id,
+		Text:   text,
+		Role:   role,
+		Scored: true,
+		File: &FileTest{
+			Path:    path,
+			MaxMode: maxMode,
+			Owner:   owner,
+		},
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cis
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Summary counts the results of a node or of the whole cluster.
+// Score is the percentage of the scored checks that passed, checks with a warning are not counted.
+type Summary struct {
+	Pass  int     `json:"pass"`
+	Fail  int     `json:"fail"`
+	Warn  int     `json:"warn"`
+	Score float64 `json:"score"`
+}
+
+// NodeReport holds the results of the checks that ran on a node
+type NodeReport struct {
+	Name    string   `json:"name"`
+	Role    Role     `json:"role"`
+	Pool    string   `json:"pool,omitempty"`
+	Results []Result `json:"results"`
+	Summary
+}
+
+// Report holds the results of every audited node
+type Report struct {
+	Benchmark string       `json:"benchmark"`
+	Nodes     []NodeReport `json:"nodes"`
+	Summary
+}
+
+// AddNode adds the results of a node to the report and updates the summaries
+func (r *Report) AddNode(n NodeReport) {
+	n.Summary = Summary{}
+	for _, result := range n.Results {
+		n.Summary.add(result)
+	}
+	n.Summary.score()
+	r.Nodes = append(r.Nodes, n)
+
+	r.Summary = Summary{}
+	for _, node := range r.Nodes {
+		r.Pass += node.Pass
+		r.Fail += node.Fail
+		r.Warn += node.Warn
+	}
+	r.Summary.score()
+}
+
+func (s *Summary) add(result Result) {
+	switch {
+	case result.Status == StatusWarn:
+		s.Warn++
+	case !result.Scored:
+	case result.Status == StatusPass:
+		s.Pass++
+	case result.Status == StatusFail:
+		s.Fail++
+	}
+}
+
+func (s *Summary) score() {
+	if s.Pass+s.Fail == 0 {
+		s.Score = 0
+		return
+	}
+	s.Score = float64(s.Pass) * 100 / float64(s.Pass+s.Fail)
+}
+
+// WriteText writes the report in a human readable format
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintf(tw, "%s\n", r.Benchmark)
+	for _, node := range r.Nodes {
+		fmt.Fprintf(tw, "\n== %s (%s) ==\n", node.Name, node.Role)
+		for _, result := range node.Results {
+			fmt.Fprintf(tw, "[%s]\t%s\t%s\n", result.Status, result.ID, result.Text)
+			if result.Actual != "" {
+				fmt.Fprintf(tw, "\t\tactual: %s\n", result.Actual)
+			}
+			if result.RenderedSetting != "" {
+				fmt.Fprintf(tw, "\t\trendered: %s in %s\n", result.Rendered, result.RenderedSetting)
+			}
+			if result.Drift {
+				fmt.Fprintf(tw, "\t\tthe node does not match the api model\n")
+			}
+			if result.Remediation != "" {
+				fmt.Fprintf(tw, "\t\tremediation: %s\n", result.Remediation)
+			}
+		}
+		fmt.Fprintf(tw, "%d checks PASS, %d checks FAIL, %d checks WARN, score %.1f%%\n", node.Pass, node.Fail, node.Warn, node.Score)
+	}
+	fmt.Fprintf(tw, "\n== Summary ==\n%d checks PASS, %d checks FAIL, %d checks WARN, score %.1f%%\n", r.Pass, r.Fail, r.Warn, r.Score)
+	return tw.Flush()
+}
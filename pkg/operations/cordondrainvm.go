@@ -5,6 +5,7 @@ package operations
 
 import (
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Azure/aks-engine/pkg/armhelpers"
@@ -24,11 +25,31 @@ const (
 	cordonMaxRetries                 = 5
 )
 
+// DrainOptions configure how the pods of a node are moved before the node is deleted
+type DrainOptions struct {
+	// GracePeriodSeconds overrides the termination grace period of the pods if set
+	GracePeriodSeconds *int64
+	// IgnoreDaemonSets leaves the DaemonSet pods on the node, the drain fails on them otherwise
+	IgnoreDaemonSets bool
+	// DeleteEmptyDirData evicts the pods with emptyDir volumes, whose data is lost, the drain fails on them otherwise
+	DeleteEmptyDirData bool
+	// ForceAfterTimeout deletes the pods still not evicted after the timeout, bypassing their PodDisruptionBudgets,
+	// rather than failing the drain
+	ForceAfterTimeout bool
+}
+
+// DefaultDrainOptions returns the options SafelyDrainNode drains with: the DaemonSet pods are left on the node, the other
+// pods are evicted with their own grace period, and the drain fails if they are not evicted within the timeout
+func DefaultDrainOptions() DrainOptions {
+	return DrainOptions{IgnoreDaemonSets: true, DeleteEmptyDirData: true}
+}
+
 type drainOperation struct {
 	client  armhelpers.KubernetesClient
 	node    *v1.Node
 	logger  *log.Entry
 	timeout time.Duration
+	options DrainOptions
 }
 
 type podFilter func(v1.Pod) bool
@@ -45,6 +66,11 @@ func SafelyDrainNode(az armhelpers.AKSEngineClient, logger *log.Entry, apiserver
 
 // SafelyDrainNodeWithClient safely drains a node so that it can be deleted from the cluster
 func SafelyDrainNodeWithClient(client armhelpers.KubernetesClient, logger *log.Entry, nodeName string, timeout time.Duration) error {
+	return DrainNodeWithClient(client, logger, nodeName, timeout, DefaultDrainOptions())
+}
+
+// DrainNodeWithClient cordons a node then evicts its pods as the options configure, so that it can be deleted from the cluster
+func DrainNodeWithClient(client armhelpers.KubernetesClient, logger *log.Entry, nodeName string, timeout time.Duration, options DrainOptions) error {
 	//Mark the node unschedulable
 	var node *v1.Node
 	var err error
@@ -69,7 +95,7 @@ func SafelyDrainNodeWithClient(client armhelpers.KubernetesClient, logger *log.E
 	logger.Infof("Node %s has been marked unschedulable.", nodeName)
 
 	//Evict pods in node
-	drainOp := &drainOperation{client: client, node: node, logger: logger, timeout: timeout}
+	drainOp := &drainOperation{client: client, node: node, logger: logger, timeout: timeout, options: options}
 	return drainOp.deleteOrEvictPodsSimple()
 }
 
@@ -129,6 +155,23 @@ func daemonSetPodFilter(pod v1.Pod) bool {
 	return false
 }
 
+func hasEmptyDir(pod v1.Pod) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.EmptyDir != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func podNames(pods []v1.Pod) string {
+	names := []string{}
+	for _, pod := range pods {
+		names = append(names, pod.Namespace+"/"+pod.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
 // getPodsForDeletion returns all the pods we're going to delete.  If there are
 // any pods preventing us from deleting, we return that list in an error.
 func (o *drainOperation) getPodsForDeletion() (pods []v1.Pod, err error) {
@@ -137,17 +180,27 @@ func (o *drainOperation) getPodsForDeletion() (pods []v1.Pod, err error) {
 		return pods, err
 	}
 
+	var daemonSetPods, emptyDirPods []v1.Pod
 	for _, pod := range podList.Items {
-		podOk := true
-		for _, filt := range []podFilter{
-			mirrorPodFilter,
-			daemonSetPodFilter,
-		} {
-			podOk = podOk && filt(pod)
+		if !mirrorPodFilter(pod) {
+			continue
+		}
+		if !daemonSetPodFilter(pod) {
+			if !o.options.IgnoreDaemonSets {
+				daemonSetPods = append(daemonSetPods, pod)
+			}
+			continue
 		}
-		if podOk {
-			pods = append(pods, pod)
+		if hasEmptyDir(pod) && !o.options.DeleteEmptyDirData {
+			emptyDirPods = append(emptyDirPods, pod)
 		}
+		pods = append(pods, pod)
+	}
+	if len(daemonSetPods) > 0 {
+		return pods, errors.Errorf("cannot drain node %s, it runs the DaemonSet pods %s, which the drain leaves on the node only if ignoreDaemonSets is set", o.node.Name, podNames(daemonSetPods))
+	}
+	if len(emptyDirPods) > 0 {
+		return pods, errors.Errorf("cannot drain node %s, it runs the pods %s with emptyDir volumes, whose data is lost, which the drain evicts only if deleteEmptyDirData is set", o.node.Name, podNames(emptyDirPods))
 	}
 	return pods, nil
 }
@@ -171,55 +224,95 @@ func (o *drainOperation) deleteOrEvictPods(pods []v1.Pod) error {
 }
 
 func (o *drainOperation) evictPods(pods []v1.Pod, policyGroupVersion string) error {
-	doneCh := make(chan bool, len(pods))
+	doneCh := make(chan int, len(pods))
 	errCh := make(chan error, 1)
+	// stopCh stops the evictions retried once the drain is over
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	var lock sync.Mutex
+	// disruptionBlocked are the pods whose eviction the API server refused because of their PodDisruptionBudget
+	disruptionBlocked := map[int]bool{}
 
-	for _, pod := range pods {
-		go func(pod v1.Pod, doneCh chan bool, errCh chan error) {
+	for i, pod := range pods {
+		go func(i int, pod v1.Pod, doneCh chan int, errCh chan error) {
 			var err error
 			for {
-				err = o.client.EvictPod(&pod, policyGroupVersion)
+				err = o.client.EvictPod(&pod, policyGroupVersion, o.options.GracePeriodSeconds)
 				if err == nil {
 					break
 				} else if apierrors.IsNotFound(err) {
-					doneCh <- true
+					doneCh <- i
 					return
 				} else if apierrors.IsTooManyRequests(err) {
-					time.Sleep(5 * time.Second)
+					lock.Lock()
+					disruptionBlocked[i] = true
+					lock.Unlock()
+					select {
+					case <-stopCh:
+						return
+					case <-time.After(5 * time.Second):
+					}
 				} else {
-					errCh <- errors.Wrapf(err, "error when evicting pod %q", pod.Name)
+					select {
+					case errCh <- errors.Wrapf(err, "error when evicting pod %q", pod.Name):
+					default:
+					}
 					return
 				}
 			}
 			podArray := []v1.Pod{pod}
 			_, err = o.client.WaitForDelete(o.logger, podArray, true)
 			if err == nil {
-				doneCh <- true
+				doneCh <- i
 			} else {
-				errCh <- errors.Wrapf(err, "error when waiting for pod %q terminating", pod.Name)
+				select {
+				case errCh <- errors.Wrapf(err, "error when waiting for pod %q terminating", pod.Name):
+				default:
+				}
 			}
-		}(pod, doneCh, errCh)
+		}(i, pod, doneCh, errCh)
 	}
 
-	doneCount := 0
+	done := map[int]bool{}
+	timeout := time.After(o.timeout)
 	for {
 		select {
 		case err := <-errCh:
 			return err
-		case <-doneCh:
-			doneCount++
-			if doneCount == len(pods) {
+		case i := <-doneCh:
+			done[i] = true
+			if len(done) == len(pods) {
 				return nil
 			}
-		case <-time.After(o.timeout):
-			return errors.Errorf("Drain did not complete within %v", o.timeout)
+		case <-timeout:
+			var pending, blocked []v1.Pod
+			lock.Lock()
+			for i, pod := range pods {
+				if done[i] {
+					continue
+				}
+				pending = append(pending, pod)
+				if disruptionBlocked[i] {
+					blocked = append(blocked, pod)
+				}
+			}
+			lock.Unlock()
+			if o.options.ForceAfterTimeout {
+				o.logger.Warnf("Drain of node %s did not complete within %v, deleting the pods not evicted: %s", o.node.Name, o.timeout, podNames(pending))
+				return o.deletePods(pending)
+			}
+			err := errors.Errorf("Drain did not complete within %v, the pods not evicted are %s", o.timeout, podNames(pending))
+			if len(blocked) > 0 {
+				err = errors.Errorf("%s, the PodDisruptionBudgets of %s do not allow their eviction, set forceAfterTimeout to delete them after the timeout", err, podNames(blocked))
+			}
+			return err
 		}
 	}
 }
 
 func (o *drainOperation) deletePods(pods []v1.Pod) error {
 	for _, pod := range pods {
-		err := o.client.DeletePod(&pod)
+		err := o.client.DeletePod(&pod, o.options.GracePeriodSeconds)
 		if err != nil && !apierrors.IsNotFound(err) {
 			return err
 		}
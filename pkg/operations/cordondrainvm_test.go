@@ -12,6 +12,7 @@ import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -132,9 +133,88 @@ var _ = Describe("Safely Drain node operation tests", func() {
 			},
 		}
 		mockClient.ShouldSupportEviction = true
-		o := drainOperation{client: mockClient}
+		o := drainOperation{client: mockClient, options: DefaultDrainOptions()}
 		pods, err := o.getPodsForDeletion()
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(len(pods)).Should(Equal(2))
 	})
+	It("Should return error messages for daemonSet pods unless they are ignored", func() {
+		mockClient := &armhelpers.MockKubernetesClient{}
+		truebool := true
+		mockClient.PodsList = &v1.PodList{
+			Items: []v1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kube-proxy-abcde",
+						Namespace: "kube-system",
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								Kind:       "DaemonSet",
+								Controller: &truebool,
+							},
+						},
+					},
+				},
+			},
+		}
+		err := DrainNodeWithClient(mockClient, log.NewEntry(log.New()), "node", time.Minute, DrainOptions{DeleteEmptyDirData: true})
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(ContainSubstring("it runs the DaemonSet pods kube-system/kube-proxy-abcde"))
+	})
+	It("Should return error messages for pods with emptyDir volumes unless their data can be deleted", func() {
+		mockClient := &armhelpers.MockKubernetesClient{}
+		mockClient.PodsList = &v1.PodList{
+			Items: []v1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "default"},
+					Spec: v1.PodSpec{
+						Volumes: []v1.Volume{{Name: "data", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}},
+					},
+				},
+			},
+		}
+		err := DrainNodeWithClient(mockClient, log.NewEntry(log.New()), "node", time.Minute, DrainOptions{IgnoreDaemonSets: true})
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(ContainSubstring("it runs the pods default/cache with emptyDir volumes"))
+
+		err = DrainNodeWithClient(mockClient, log.NewEntry(log.New()), "node", time.Minute, DefaultDrainOptions())
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	It("Should return error messages for pods a PodDisruptionBudget does not allow evicting within the timeout", func() {
+		mockClient := &armhelpers.MockKubernetesClient{}
+		mockClient.PodsList = &v1.PodList{Items: []v1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}}}
+		mockClient.ShouldSupportEviction = true
+		mockClient.EvictPodFunc = func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+			return apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 10)
+		}
+		err := DrainNodeWithClient(mockClient, log.NewEntry(log.New()), "node", time.Second, DefaultDrainOptions())
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(ContainSubstring("the pods not evicted are default/web"))
+		Expect(err.Error()).Should(ContainSubstring("the PodDisruptionBudgets of default/web do not allow their eviction"))
+	})
+	It("Should delete the pods not evicted within the timeout with the grace period if forced", func() {
+		mockClient := &armhelpers.MockKubernetesClient{}
+		mockClient.PodsList = &v1.PodList{Items: []v1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}}}
+		mockClient.ShouldSupportEviction = true
+		var evictGracePeriod, deleteGracePeriod *int64
+		mockClient.EvictPodFunc = func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+			evictGracePeriod = gracePeriodSeconds
+			return apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 10)
+		}
+		deleted := []string{}
+		mockClient.DeletePodFunc = func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+			deleted = append(deleted, pod.Name)
+			deleteGracePeriod = gracePeriodSeconds
+			return nil
+		}
+		gracePeriod := int64(30)
+		options := DefaultDrainOptions()
+		options.GracePeriodSeconds = &gracePeriod
+		options.ForceAfterTimeout = true
+		err := DrainNodeWithClient(mockClient, log.NewEntry(log.New()), "node", time.Second, options)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(deleted).Should(Equal([]string{"web"}))
+		Expect(evictGracePeriod).Should(Equal(&gracePeriod))
+		Expect(deleteGracePeriod).Should(Equal(&gracePeriod))
+	})
 })
@@ -0,0 +1,307 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package diff semantically compares two api models, or two ARM templates generated by AKS Engine, and reports the
+// changed fields, the added and removed agent pools and the impacted ARM resources.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/pkg/errors"
+)
+
+// Kind is the kind of a change
+type Kind string
+
+const (
+	// Added fields are only in the new document
+	Added Kind = "added"
+	// Removed fields are only in the old document
+	Removed Kind = "removed"
+	// Changed fields have a different value in the new document
+	Changed Kind = "changed"
+)
+
+// redacted replaces the values of the secrets of the api model in the changes
+const redacted = "<redacted>"
+
+// Change is a field that differs between the old and new documents. Path is the JSON path of the field, where the
+// items of lists of objects with a name, e.g. the agent pools, are identified by their name rather than their index.
+type Change struct {
+	Path string      `json:"path"`
+	Kind Kind        `json:"kind"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// ResourceChange is an ARM resource of the templates that is added, removed or changed, with the changes of its fields
+type ResourceChange struct {
+	Type    string   `json:"type"`
+	Name    string   `json:"name"`
+	Kind    Kind     `json:"kind"`
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// APIModels compares two api models, their deprecated fields are expected to be migrated
+func APIModels(old, new *api.ContainerService) (*Report, error) {
+	o, err := apiModelDocument(old)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading the old api model")
+	}
+	n, err := apiModelDocument(new)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading the new api model")
+	}
+
+	r := &Report{Document: DocumentAPIModel}
+	compare("", o, n, &r.Changes)
+	for i, c := range r.Changes {
+		if isSecret(c.Path) {
+			r.Changes[i].Old, r.Changes[i].New = redact(c.Old), redact(c.New)
+			continue
+		}
+		r.Changes[i].Old, r.Changes[i].New = redactSecrets(c.Old), redactSecrets(c.New)
+	}
+
+	oldPools, newPools := agentPoolNames(old), agentPoolNames(new)
+	for name := range newPools {
+		if !oldPools[name] {
+			r.AddedAgentPools = append(r.AddedAgentPools, name)
+		}
+	}
+	for name := range oldPools {
+		if !newPools[name] {
+			r.RemovedAgentPools = append(r.RemovedAgentPools, name)
+		}
+	}
+	sort.Strings(r.AddedAgentPools)
+	sort.Strings(r.RemovedAgentPools)
+	return r, nil
+}
+
+// Templates compares two ARM templates. The resources are identified by their type and name expression,
+// the changes of their fields are reported with their resource rather than in the changes of the report.
+func Templates(old, new map[string]interface{}) *Report {
+	r := &Report{Document: DocumentTemplate}
+	for _, section := range []string{"parameters", "variables", "outputs"} {
+		compare(section, old[section], new[section], &r.Changes)
+	}
+
+	oldResources, oldKeys := templateResources(old)
+	newResources, newKeys := templateResources(new)
+	for _, key := range oldKeys {
+		o := oldResources[key]
+		n, ok := newResources[key]
+		if !ok {
+			r.Resources = append(r.Resources, ResourceChange{Type: key.Type, Name: key.Name, Kind: Removed})
+			continue
+		}
+		var changes []Change
+		compare("", o, n, &changes)
+		if len(changes) > 0 {
+			r.Resources = append(r.Resources, ResourceChange{Type: key.Type, Name: key.Name, Kind: Changed, Changes: changes})
+		}
+	}
+	for _, key := range newKeys {
+		if _, ok := oldResources[key]; !ok {
+			r.Resources = append(r.Resources, ResourceChange{Type: key.Type, Name: key.Name, Kind: Added})
+		}
+	}
+	return r
+}
+
+// apiModelDocument returns the api model as written by aks-engine, so that models are compared regardless of
+// how their file is written, e.g. the case of its keys
+func apiModelDocument(cs *api.ContainerService) (interface{}, error) {
+	b, err := json.Marshal(api.ConvertContainerServiceToVLabs(cs))
+	if err != nil {
+		return nil, err
+	}
+	var document interface{}
+	if err = json.Unmarshal(b, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+func agentPoolNames(cs *api.ContainerService) map[string]bool {
+	names := map[string]bool{}
+	if cs.Properties != nil {
+		for _, pool := range cs.Properties.AgentPoolProfiles {
+			names[pool.Name] = true
+		}
+	}
+	return names
+}
+
+type resourceKey struct {
+	Type string
+	Name string
+}
+
+// templateResources returns the resources of a template by their type and name, and their keys in template order
+func templateResources(template map[string]interface{}) (map[resourceKey]interface{}, []resourceKey) {
+	resources := map[resourceKey]interface{}{}
+	var keys []resourceKey
+	list, _ := template["resources"].([]interface{})
+	for _, item := range list {
+		resource, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key := resourceKey{Type: fmt.Sprint(resource["type"]), Name: fmt.Sprint(resource["name"])}
+		if _, ok := resources[key]; !ok {
+			keys = append(keys, key)
+		}
+		resources[key] = resource
+	}
+	return resources, keys
+}
+
+// compare appends the differences between two JSON values to changes
+func compare(path string, old, new interface{}, changes *[]Change) {
+	switch {
+	case old == nil && new == nil:
+		return
+	case old == nil:
+		*changes = append(*changes, Change{Path: path, Kind: Added, New: new})
+		return
+	case new == nil:
+		*changes = append(*changes, Change{Path: path, Kind: Removed, Old: old})
+		return
+	}
+
+	switch o := old.(type) {
+	case map[string]interface{}:
+		n, ok := new.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := []string{}
+		for key := range o {
+			keys = append(keys, key)
+		}
+		for key := range n {
+			if _, ok := o[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			compare(join(path, key), o[key], n[key], changes)
+		}
+		return
+	case []interface{}:
+		n, ok := new.([]interface{})
+		if !ok {
+			break
+		}
+		if oldNames, newNames := itemNames(o), itemNames(n); oldNames != nil && newNames != nil {
+			for i, name := range oldNames {
+				compare(fmt.Sprintf("%s[%s]", path, name), o[i], namedItem(n, newNames, name), changes)
+			}
+			for i, name := range newNames {
+				if namedItem(o, oldNames, name) == nil {
+					compare(fmt.Sprintf("%s[%s]", path, name), nil, n[i], changes)
+				}
+			}
+			return
+		}
+		for i := 0; i < len(o) || i < len(n); i++ {
+			var oldItem, newItem interface{}
+			if i < len(o) {
+				oldItem = o[i]
+			}
+			if i < len(n) {
+				newItem = n[i]
+			}
+			compare(fmt.Sprintf("%s[%d]", path, i), oldItem, newItem, changes)
+		}
+		return
+	}
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, Change{Path: path, Kind: Changed, Old: old, New: new})
+	}
+}
+
+// itemNames returns the names of the items of a list of objects with a unique name, or nil for other lists
+func itemNames(list []interface{}) []string {
+	names := make([]string, len(list))
+	seen := map[string]bool{}
+	for i, item := range list {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		name, ok := object["name"].(string)
+		if !ok || name == "" || seen[name] {
+			return nil
+		}
+		names[i] = name
+		seen[name] = true
+	}
+	return names
+}
+
+func namedItem(list []interface{}, names []string, name string) interface{} {
+	for i, n := range names {
+		if n == name {
+			return list[i]
+		}
+	}
+	return nil
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// isSecret returns true if the field at path holds a secret, e.g. a service principal secret or a private key
+func isSecret(path string) bool {
+	field := strings.ToLower(path[strings.LastIndex(path, ".")+1:])
+	for _, secret := range []string{"secret", "password", "privatekey", "etcdencryptionkey"} {
+		if strings.Contains(field, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+func redact(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return redacted
+}
+
+// redactSecrets returns a copy of a JSON value with the secrets of its objects redacted
+func redactSecrets(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		object := map[string]interface{}{}
+		for key, item := range v {
+			if isSecret(key) {
+				object[key] = redact(item)
+				continue
+			}
+			object[key] = redactSecrets(item)
+		}
+		return object
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = redactSecrets(item)
+		}
+		return list
+	}
+	return value
+}
@@ -0,0 +1,183 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+)
+
+func TestAPIModels(t *testing.T) {
+	old := api.CreateMockContainerService("testcluster", "1.15.3", 3, 2, false)
+	old.Properties.ServicePrincipalProfile = &api.ServicePrincipalProfile{ClientID: "clientID", Secret: "oldSecret"}
+	new := api.CreateMockContainerService("testcluster", "1.15.3", 3, 2, false)
+	new.ID = old.ID
+	new.Properties.ServicePrincipalProfile = &api.ServicePrincipalProfile{ClientID: "clientID", Secret: "newSecret"}
+	new.Properties.AgentPoolProfiles[0].Count = 5
+	new.Properties.AgentPoolProfiles = append(new.Properties.AgentPoolProfiles, &api.AgentPoolProfile{
+		Name:   "pool2",
+		Count:  1,
+		VMSize: "Standard_D2_v3",
+	})
+
+	r, err := APIModels(old, new)
+	if err != nil {
+		t.Fatalf("unexpected error comparing the api models: %s", err)
+	}
+	expected := []Change{
+		{Path: "properties.agentPoolProfiles[agentpool1].count", Kind: Changed, Old: float64(2), New: float64(5)},
+		{Path: "properties.servicePrincipalProfile.secret", Kind: Changed, Old: redacted, New: redacted},
+	}
+	if len(r.Changes) != 3 || r.Changes[1].Path != "properties.agentPoolProfiles[pool2]" || r.Changes[1].Kind != Added {
+		t.Fatalf("expected the count, the agent pool and the secret to change, got %v", r.Changes)
+	}
+	if changes := []Change{r.Changes[0], r.Changes[2]}; !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes %v, got %v", expected, changes)
+	}
+	if !reflect.DeepEqual(r.AddedAgentPools, []string{"pool2"}) || len(r.RemovedAgentPools) != 0 {
+		t.Errorf("expected pool2 to be added, got added %v and removed %v", r.AddedAgentPools, r.RemovedAgentPools)
+	}
+
+	r, err = APIModels(old, old)
+	if err != nil {
+		t.Fatalf("unexpected error comparing the api models: %s", err)
+	}
+	if !r.Empty() {
+		t.Errorf("expected an api model not to differ from itself, got %v", r.Changes)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	var old, new map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+  "parameters": {"masterVMSize": {"type": "string", "defaultValue": "Standard_D2_v2"}},
+  "variables": {"orchestratorVersion": "1.14.6"},
+  "resources": [
+    {"type": "Microsoft.Network/virtualNetworks", "name": "[variables('virtualNetworkName')]", "properties": {"subnets": [{"name": "subnet", "properties": {"addressPrefix": "10.240.0.0/16"}}]}},
+    {"type": "Microsoft.Compute/availabilitySets", "name": "[variables('agentpool1AvailabilitySet')]"},
+    {"type": "Microsoft.Compute/virtualMachines", "name": "[variables('masterVMName')]", "properties": {"hardwareProfile": {"vmSize": "[parameters('masterVMSize')]"}}}
+  ]
+}`), &old); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`{
+  "parameters": {"masterVMSize": {"type": "string", "defaultValue": "Standard_D4_v3"}},
+  "variables": {"orchestratorVersion": "1.15.3"},
+  "resources": [
+    {"type": "Microsoft.Network/virtualNetworks", "name": "[variables('virtualNetworkName')]", "properties": {"subnets": [{"name": "subnet", "properties": {"addressPrefix": "10.240.0.0/12"}}]}},
+    {"type": "Microsoft.Compute/virtualMachines", "name": "[variables('masterVMName')]", "properties": {"hardwareProfile": {"vmSize": "[parameters('masterVMSize')]"}}},
+    {"type": "Microsoft.Compute/virtualMachineScaleSets", "name": "[variables('agentpool1VMNamePrefix')]"}
+  ]
+}`), &new); err != nil {
+		t.Fatal(err)
+	}
+
+	r := Templates(old, new)
+	expectedChanges := []Change{
+		{Path: "parameters.masterVMSize.defaultValue", Kind: Changed, Old: "Standard_D2_v2", New: "Standard_D4_v3"},
+		{Path: "variables.orchestratorVersion", Kind: Changed, Old: "1.14.6", New: "1.15.3"},
+	}
+	if !reflect.DeepEqual(r.Changes, expectedChanges) {
+		t.Errorf("expected changes %v, got %v", expectedChanges, r.Changes)
+	}
+	expectedResources := []ResourceChange{
+		{
+			Type: "Microsoft.Network/virtualNetworks",
+			Name: "[variables('virtualNetworkName')]",
+			Kind: Changed,
+			Changes: []Change{
+				{Path: "properties.subnets[subnet].properties.addressPrefix", Kind: Changed, Old: "10.240.0.0/16", New: "10.240.0.0/12"},
+			},
+		},
+		{Type: "Microsoft.Compute/availabilitySets", Name: "[variables('agentpool1AvailabilitySet')]", Kind: Removed},
+		{Type: "Microsoft.Compute/virtualMachineScaleSets", Name: "[variables('agentpool1VMNamePrefix')]", Kind: Added},
+	}
+	if !reflect.DeepEqual(r.Resources, expectedResources) {
+		t.Errorf("expected resources %v, got %v", expectedResources, r.Resources)
+	}
+
+	var text bytes.Buffer
+	if err := r.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	expectedText := `Changed fields:
+  ~ parameters.masterVMSize.defaultValue: "Standard_D2_v2" -> "Standard_D4_v3"
+  ~ variables.orchestratorVersion: "1.14.6" -> "1.15.3"
+Impacted ARM resources:
+  ~ Microsoft.Network/virtualNetworks [variables('virtualNetworkName')]
+      ~ properties.subnets[subnet].properties.addressPrefix: "10.240.0.0/16" -> "10.240.0.0/12"
+  - Microsoft.Compute/availabilitySets [variables('agentpool1AvailabilitySet')]
+  + Microsoft.Compute/virtualMachineScaleSets [variables('agentpool1VMNamePrefix')]
+`
+	if text.String() != expectedText {
+		t.Errorf("expected the text report\n%s\ngot\n%s", expectedText, text.String())
+	}
+}
+
+func TestCompareLists(t *testing.T) {
+	cases := []struct {
+		name     string
+		old      []interface{}
+		new      []interface{}
+		expected []Change
+	}{
+		{
+			name: "named items are matched by name",
+			old:  []interface{}{map[string]interface{}{"name": "a", "value": "1"}, map[string]interface{}{"name": "b"}},
+			new:  []interface{}{map[string]interface{}{"name": "b"}, map[string]interface{}{"name": "a", "value": "2"}},
+			expected: []Change{
+				{Path: "list[a].value", Kind: Changed, Old: "1", New: "2"},
+			},
+		},
+		{
+			name: "items without a name are matched by index",
+			old:  []interface{}{"a", "b"},
+			new:  []interface{}{"b"},
+			expected: []Change{
+				{Path: "list[0]", Kind: Changed, Old: "a", New: "b"},
+				{Path: "list[1]", Kind: Removed, Old: "b"},
+			},
+		},
+		{
+			name: "items are added to an empty list",
+			old:  []interface{}{},
+			new:  []interface{}{map[string]interface{}{"name": "a"}},
+			expected: []Change{
+				{Path: "list[a]", Kind: Added, New: map[string]interface{}{"name": "a"}},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			var changes []Change
+			compare("list", c.old, c.new, &changes)
+			if !reflect.DeepEqual(changes, c.expected) {
+				t.Errorf("expected changes %v, got %v", c.expected, changes)
+			}
+		})
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	value := map[string]interface{}{
+		"name":                    "pool",
+		"servicePrincipalProfile": map[string]interface{}{"clientId": "id", "secret": "s"},
+		"windowsProfile":          map[string]interface{}{"adminPassword": "p"},
+	}
+	expected := map[string]interface{}{
+		"name":                    "pool",
+		"servicePrincipalProfile": map[string]interface{}{"clientId": "id", "secret": redacted},
+		"windowsProfile":          map[string]interface{}{"adminPassword": redacted},
+	}
+	if redactedValue := redactSecrets(value); !reflect.DeepEqual(redactedValue, expected) {
+		t.Errorf("expected %v, got %v", expected, redactedValue)
+	}
+}
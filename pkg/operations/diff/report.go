@@ -0,0 +1,107 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/helpers"
+)
+
+// Document is the kind of the compared documents
+type Document string
+
+const (
+	// DocumentAPIModel reports compare api models
+	DocumentAPIModel Document = "apimodel"
+	// DocumentTemplate reports compare ARM templates
+	DocumentTemplate Document = "template"
+)
+
+// textValueLength is the length values are truncated to in the text report, e.g. the custom data of the VMs
+const textValueLength = 80
+
+// Report holds the differences between two api models or two templates
+type Report struct {
+	Document          Document         `json:"document"`
+	Changes           []Change         `json:"changes"`
+	AddedAgentPools   []string         `json:"addedAgentPools,omitempty"`
+	RemovedAgentPools []string         `json:"removedAgentPools,omitempty"`
+	Resources         []ResourceChange `json:"resources,omitempty"`
+}
+
+// Empty returns true if the documents do not differ
+func (r *Report) Empty() bool {
+	return len(r.Changes) == 0 && len(r.Resources) == 0
+}
+
+// WriteText writes the report in a human readable format, prefixing added fields with +, removed fields with -
+// and changed fields with ~
+func (r *Report) WriteText(w io.Writer) error {
+	if r.Empty() {
+		documents := "templates"
+		if r.Document == DocumentAPIModel {
+			documents = "api models"
+		}
+		_, err := fmt.Fprintf(w, "The %s do not differ\n", documents)
+		return err
+	}
+	if len(r.Changes) > 0 {
+		fmt.Fprintln(w, "Changed fields:")
+		writeChanges(w, "  ", r.Changes)
+	}
+	if len(r.AddedAgentPools) > 0 {
+		fmt.Fprintf(w, "Added agent pools: %s\n", strings.Join(r.AddedAgentPools, ", "))
+	}
+	if len(r.RemovedAgentPools) > 0 {
+		fmt.Fprintf(w, "Removed agent pools: %s\n", strings.Join(r.RemovedAgentPools, ", "))
+	}
+	if len(r.Resources) > 0 {
+		fmt.Fprintln(w, "Impacted ARM resources:")
+		for _, resource := range r.Resources {
+			fmt.Fprintf(w, "  %s %s %s\n", symbol(resource.Kind), resource.Type, resource.Name)
+			writeChanges(w, "      ", resource.Changes)
+		}
+	}
+	return nil
+}
+
+func writeChanges(w io.Writer, indent string, changes []Change) {
+	for _, c := range changes {
+		switch c.Kind {
+		case Added:
+			fmt.Fprintf(w, "%s+ %s: %s\n", indent, c.Path, textValue(c.New))
+		case Removed:
+			fmt.Fprintf(w, "%s- %s: %s\n", indent, c.Path, textValue(c.Old))
+		default:
+			fmt.Fprintf(w, "%s~ %s: %s -> %s\n", indent, c.Path, textValue(c.Old), textValue(c.New))
+		}
+	}
+}
+
+func symbol(kind Kind) string {
+	switch kind {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	}
+	return "~"
+}
+
+// textValue returns a value as compact JSON, truncated to textValueLength
+func textValue(value interface{}) string {
+	b, err := helpers.JSONMarshal(value, false)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	b = bytes.TrimSpace(b)
+	if len(b) > textValueLength {
+		return string(b[:textValueLength]) + "..."
+	}
+	return string(b)
+}
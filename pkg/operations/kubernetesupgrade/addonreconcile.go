@@ -0,0 +1,278 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package kubernetesupgrade
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// lastAppliedConfigAnnotation holds the manifest kube-addon-manager last applied to an addon with kubectl apply
+	lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+	addonNamespace              = "kube-system"
+)
+
+// addonDeployments are the deployments of the addons reconciled on upgrade, by addon name
+var addonDeployments = map[string]string{
+	api.AADPodIdentityAddonName:    "mic",
+	api.ACIConnectorAddonName:      "aci-connector",
+	api.ClusterAutoscalerAddonName: "cluster-autoscaler",
+	api.CoreDNSAddonName:           "coredns",
+	api.DashboardAddonName:         "kubernetes-dashboard",
+	api.HeapsterAddonName:          "heapster",
+	api.MetricsServerAddonName:     "metrics-server",
+	api.RegistryCacheAddonName:     "registry-cache",
+	api.ReschedulerAddonName:       "rescheduler",
+	api.TillerAddonName:            "tiller-deploy",
+}
+
+// AddonFieldChange is a field of an addon deployment that was changed in the cluster since its manifest was last applied
+type AddonFieldChange struct {
+	Addon      string
+	Deployment string
+	// Field is the path of the field in the deployment spec, e.g. "replicas" or "containers[coredns].resources.limits.memory"
+	Field string
+	// Original is the value in the manifest last applied, Live the value in the cluster and Upgrade the value in the upgraded manifest
+	Original string
+	Live     string
+	Upgrade  string
+}
+
+func (c AddonFieldChange) String() string {
+	return fmt.Sprintf("%s of deployment %s (addon %s)", c.Field, c.Deployment, c.Addon)
+}
+
+// AddonReconcileReport lists the addon fields changed in the cluster, and what the upgrade does with them
+type AddonReconcileReport struct {
+	// Preserved are written into the api model, the upgraded manifests keep them because the upgrade does not change their default
+	Preserved []AddonFieldChange
+	// Reset are overwritten with the upgraded manifests, the upgrade changes their default
+	Reset []AddonFieldChange
+}
+
+// addonField is a field of an addon deployment that is reconciled with the api model
+type addonField struct {
+	name string
+	// live returns the value of the field in a deployment, "" if it is not set
+	live func(d *appsv1.Deployment) string
+	// upgrade returns the value of the field in the upgraded manifest
+	upgrade func() string
+	// preserve writes a value of the field into the api model
+	preserve func(value string)
+}
+
+// ReconcileAddons merges the addon customizations made in the cluster into the api model the upgraded addon manifests are
+// generated from, the way kubectl apply merges with its last-applied-configuration annotation: a replica count or container
+// resource that differs between an addon deployment and the manifest last applied to it was changed in the cluster.
+// The change is kept when the upgrade does not change the default of the field, and reset otherwise.
+// Deployments that do not exist or were not created with kubectl apply are skipped.
+func ReconcileAddons(kubeClient armhelpers.KubernetesClient, cs *api.ContainerService) (*AddonReconcileReport, error) {
+	report := &AddonReconcileReport{}
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+	if k == nil {
+		return report, nil
+	}
+
+	addons := make([]string, 0, len(addonDeployments))
+	for addon := range addonDeployments {
+		addons = append(addons, addon)
+	}
+	sort.Strings(addons)
+	for _, addon := range addons {
+		// coredns is deployed unless kube-dns is used, which has no addon of its own
+		if addon != api.CoreDNSAddonName && !k.IsAddonEnabled(addon) {
+			continue
+		}
+		name := addonDeployments[addon]
+		live, err := kubeClient.GetDeployment(addonNamespace, name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "getting deployment %s of addon %s", name, addon)
+		}
+		lastApplied, ok := live.Annotations[lastAppliedConfigAnnotation]
+		if !ok {
+			continue
+		}
+		original := &appsv1.Deployment{}
+		if err = json.Unmarshal([]byte(lastApplied), original); err != nil {
+			return nil, errors.Wrapf(err, "parsing the last applied configuration of deployment %s", name)
+		}
+
+		for _, f := range getAddonFields(cs.Properties, addon, live) {
+			change := AddonFieldChange{
+				Addon:      addon,
+				Deployment: name,
+				Field:      f.name,
+				Original:   f.live(original),
+				Live:       f.live(live),
+				Upgrade:    f.upgrade(),
+			}
+			if equalValues(change.Live, change.Original) {
+				continue
+			}
+			if equalValues(change.Upgrade, change.Original) {
+				f.preserve(change.Live)
+				report.Preserved = append(report.Preserved, change)
+			} else {
+				report.Reset = append(report.Reset, change)
+			}
+		}
+	}
+	return report, nil
+}
+
+// getAddonFields returns the reconciled fields of the deployment of an addon: the resources of its containers
+// configured in the api model, and the coredns replicas unless the dns-autoscaler owns them
+func getAddonFields(p *api.Properties, addon string, d *appsv1.Deployment) []addonField {
+	k := p.OrchestratorProfile.KubernetesConfig
+	var fields []addonField
+	for _, c := range d.Spec.Template.Spec.Containers {
+		containerName := c.Name
+		var spec func() *api.KubernetesContainerSpec
+		var upgrade func() api.KubernetesContainerSpec
+		if addon == api.CoreDNSAddonName {
+			if containerName != api.CoreDNSAddonName {
+				continue
+			}
+			// the coredns defaults depend on the sizing profile of the cluster, only customized values are in the api model
+			spec = func() *api.KubernetesContainerSpec {
+				return addonContainer(k, addon, containerName, true)
+			}
+			upgrade = p.GetCoreDNSResources
+		} else {
+			if addonContainer(k, addon, containerName, false) == nil {
+				continue
+			}
+			spec = func() *api.KubernetesContainerSpec {
+				return addonContainer(k, addon, containerName, false)
+			}
+			upgrade = func() api.KubernetesContainerSpec {
+				return *spec()
+			}
+		}
+		fields = append(fields, containerResourceFields(containerName, spec, upgrade)...)
+	}
+
+	if addon == api.CoreDNSAddonName && p.GetCoreDNSReplicas() > 0 {
+		fields = append(fields, addonField{
+			name: "replicas",
+			live: func(d *appsv1.Deployment) string {
+				if d.Spec.Replicas == nil {
+					return ""
+				}
+				return strconv.Itoa(int(*d.Spec.Replicas))
+			},
+			upgrade: func() string {
+				return strconv.Itoa(p.GetCoreDNSReplicas())
+			},
+			preserve: func(value string) {
+				i := addonIndex(k, addon, true)
+				if k.Addons[i].Config == nil {
+					k.Addons[i].Config = map[string]string{}
+				}
+				k.Addons[i].Config["replicas"] = value
+			},
+		})
+	}
+	return fields
+}
+
+// containerResourceFields returns the resource request and limit fields of a container
+func containerResourceFields(containerName string, spec func() *api.KubernetesContainerSpec, upgrade func() api.KubernetesContainerSpec) []addonField {
+	type resourceField struct {
+		kind     string
+		resource v1.ResourceName
+		value    func(s *api.KubernetesContainerSpec) *string
+	}
+	resourceFields := []resourceField{
+		{"requests", v1.ResourceCPU, func(s *api.KubernetesContainerSpec) *string { return &s.CPURequests }},
+		{"requests", v1.ResourceMemory, func(s *api.KubernetesContainerSpec) *string { return &s.MemoryRequests }},
+		{"limits", v1.ResourceCPU, func(s *api.KubernetesContainerSpec) *string { return &s.CPULimits }},
+		{"limits", v1.ResourceMemory, func(s *api.KubernetesContainerSpec) *string { return &s.MemoryLimits }},
+	}
+
+	var fields []addonField
+	for _, rf := range resourceFields {
+		rf := rf
+		fields = append(fields, addonField{
+			name: fmt.Sprintf("containers[%s].resources.%s.%s", containerName, rf.kind, rf.resource),
+			live: func(d *appsv1.Deployment) string {
+				for _, c := range d.Spec.Template.Spec.Containers {
+					if c.Name != containerName {
+						continue
+					}
+					resources := c.Resources.Requests
+					if rf.kind == "limits" {
+						resources = c.Resources.Limits
+					}
+					if q, ok := resources[rf.resource]; ok {
+						return q.String()
+					}
+				}
+				return ""
+			},
+			upgrade: func() string {
+				s := upgrade()
+				return *rf.value(&s)
+			},
+			preserve: func(value string) {
+				*rf.value(spec()) = value
+			},
+		})
+	}
+	return fields
+}
+
+// addonContainer returns the spec of a container of an addon in the api model, adding the addon and the container if create is true
+func addonContainer(k *api.KubernetesConfig, addon, containerName string, create bool) *api.KubernetesContainerSpec {
+	i := addonIndex(k, addon, create)
+	if i < 0 {
+		return nil
+	}
+	c := k.Addons[i].GetAddonContainersIndexByName(containerName)
+	if c < 0 {
+		if !create {
+			return nil
+		}
+		k.Addons[i].Containers = append(k.Addons[i].Containers, api.KubernetesContainerSpec{Name: containerName})
+		c = len(k.Addons[i].Containers) - 1
+	}
+	return &k.Addons[i].Containers[c]
+}
+
+func addonIndex(k *api.KubernetesConfig, addon string, create bool) int {
+	for i := range k.Addons {
+		if k.Addons[i].Name == addon {
+			return i
+		}
+	}
+	if !create {
+		return -1
+	}
+	k.Addons = append(k.Addons, api.KubernetesAddon{Name: addon})
+	return len(k.Addons) - 1
+}
+
+// equalValues compares two field values, as quantities if both are, so that e.g. "0.1" and "100m" are equal
+func equalValues(a, b string) bool {
+	if a == b {
+		return true
+	}
+	qa, errA := resource.ParseQuantity(a)
+	qb, errB := resource.ParseQuantity(b)
+	return errA == nil && errB == nil && qa.Cmp(qb) == 0
+}
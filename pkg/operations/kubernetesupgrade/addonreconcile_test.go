@@ -0,0 +1,129 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package kubernetesupgrade
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/go-autorest/autorest/to"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestReconcileAddons(t *testing.T) {
+	deployment := func(name string, replicas int32, requests v1.ResourceList) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Name:      name,
+								Resources: v1.ResourceRequirements{Requests: requests},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	withLastApplied := func(live, original *appsv1.Deployment) *appsv1.Deployment {
+		b, err := json.Marshal(original)
+		if err != nil {
+			t.Fatal(err)
+		}
+		live.Annotations = map[string]string{lastAppliedConfigAnnotation: string(b)}
+		return live
+	}
+
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				KubernetesConfig: &api.KubernetesConfig{
+					Addons: []api.KubernetesAddon{
+						{
+							Name:    api.MetricsServerAddonName,
+							Enabled: to.BoolPtr(true),
+							Containers: []api.KubernetesContainerSpec{
+								{Name: api.MetricsServerAddonName, CPURequests: "10m", MemoryRequests: "20Mi"},
+							},
+						},
+						{
+							Name:    api.CoreDNSAddonName,
+							Enabled: to.BoolPtr(true),
+							Config:  map[string]string{"replicas": "2"},
+						},
+						{
+							Name:    api.TillerAddonName,
+							Enabled: to.BoolPtr(true),
+						},
+					},
+				},
+			},
+		},
+	}
+	kubeClient := &armhelpers.MockKubernetesClient{
+		Deployments: map[string]*appsv1.Deployment{
+			// the cpu request was changed in the cluster and keeps its default, the memory request changes its default
+			"metrics-server": withLastApplied(
+				deployment(api.MetricsServerAddonName, 1, v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m"), v1.ResourceMemory: resource.MustParse("50Mi")}),
+				deployment(api.MetricsServerAddonName, 1, v1.ResourceList{v1.ResourceCPU: resource.MustParse("0.01"), v1.ResourceMemory: resource.MustParse("10Mi")}),
+			),
+			// the replicas and the cpu request were changed in the cluster, the coredns defaults come from the sizing profile
+			"coredns": withLastApplied(
+				deployment(api.CoreDNSAddonName, 4, v1.ResourceList{v1.ResourceCPU: resource.MustParse("200m"), v1.ResourceMemory: resource.MustParse("70Mi")}),
+				deployment(api.CoreDNSAddonName, 2, v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m"), v1.ResourceMemory: resource.MustParse("70Mi")}),
+			),
+		},
+	}
+
+	report, err := ReconcileAddons(kubeClient, cs)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	preserved := map[string]string{}
+	for _, c := range report.Preserved {
+		preserved[c.String()] = c.Live
+	}
+	expectedPreserved := map[string]string{
+		"containers[coredns].resources.requests.cpu of deployment coredns (addon coredns)":                      "200m",
+		"replicas of deployment coredns (addon coredns)":                                                        "4",
+		"containers[metrics-server].resources.requests.cpu of deployment metrics-server (addon metrics-server)": "100m",
+	}
+	if len(preserved) != len(expectedPreserved) {
+		t.Errorf("expected preserved fields %v, got %v", expectedPreserved, preserved)
+	}
+	for field, value := range expectedPreserved {
+		if preserved[field] != value {
+			t.Errorf("expected %s to be preserved with %s, got %q", field, value, preserved[field])
+		}
+	}
+	if len(report.Reset) != 1 || report.Reset[0].Field != "containers[metrics-server].resources.requests.memory" || report.Reset[0].Upgrade != "20Mi" {
+		t.Errorf("expected the memory request of metrics-server to be reset to 20Mi, got %v", report.Reset)
+	}
+
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+	if r := cs.Properties.GetCoreDNSReplicas(); r != 4 {
+		t.Errorf("expected the coredns replicas to be 4 in the api model, got %d", r)
+	}
+	if r := cs.Properties.GetCoreDNSResources(); r.CPURequests != "200m" || r.MemoryRequests != "70Mi" {
+		t.Errorf("expected the coredns requests to be 200m and 70Mi in the api model, got %s and %s", r.CPURequests, r.MemoryRequests)
+	}
+	metricsServer := k.GetAddonByName(api.MetricsServerAddonName).Containers[0]
+	if metricsServer.CPURequests != "100m" || metricsServer.MemoryRequests != "20Mi" {
+		t.Errorf("expected the metrics-server requests to be 100m and 20Mi in the api model, got %s and %s", metricsServer.CPURequests, metricsServer.MemoryRequests)
+	}
+
+	// deployments not created with kubectl apply are not reconciled
+	kubeClient.Deployments["metrics-server"].Annotations = nil
+	if report, err = ReconcileAddons(kubeClient, cs); err != nil || len(report.Preserved)+len(report.Reset) != 2 {
+		t.Errorf("expected only the coredns changes to be reconciled again, got %v and error %v", report, err)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package kubernetesupgrade
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CheckpointFilename is the name of the file the progress of an upgrade is saved to, next to the api model
+const CheckpointFilename = "upgrade-checkpoint.json"
+
+// VMUpgradeState is how far the upgrade of a VM went
+type VMUpgradeState string
+
+const (
+	// VMUpgrading is the state of a VM the upgrade started replacing, which may be drained or deleted
+	VMUpgrading VMUpgradeState = "Upgrading"
+	// VMUpgraded is the state of a VM replaced by an upgraded VM, which a resumed upgrade skips
+	VMUpgraded VMUpgradeState = "Upgraded"
+)
+
+// Checkpoint is the progress of an upgrade to TargetVersion, saved each time a VM changes state so that an interrupted
+// upgrade can be resumed rather than started over. A nil Checkpoint records nothing.
+type Checkpoint struct {
+	TargetVersion string `json:"targetVersion"`
+	// VMs are the states of the VMs, by lower case VM name
+	VMs map[string]*VMCheckpoint `json:"vms"`
+
+	path string
+	lock sync.Mutex
+}
+
+// VMCheckpoint is the upgrade state of a VM
+type VMCheckpoint struct {
+	Pool      string         `json:"pool"`
+	State     VMUpgradeState `json:"state"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+// NewCheckpoint returns an empty checkpoint of an upgrade to targetVersion, saved to path, replacing the checkpoint
+// of a previous upgrade
+func NewCheckpoint(path, targetVersion string) (*Checkpoint, error) {
+	c := &Checkpoint{
+		TargetVersion: targetVersion,
+		VMs:           map[string]*VMCheckpoint{},
+		path:          path,
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err := c.save(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadCheckpoint loads the checkpoint saved to path by an upgrade that did not complete
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("no upgrade checkpoint found at %s", path)
+		}
+		return nil, errors.Wrap(err, "reading the upgrade checkpoint")
+	}
+	c := &Checkpoint{}
+	if err = json.Unmarshal(b, c); err != nil {
+		return nil, errors.Wrapf(err, "parsing the upgrade checkpoint %s", path)
+	}
+	if c.VMs == nil {
+		c.VMs = map[string]*VMCheckpoint{}
+	}
+	c.path = path
+	return c, nil
+}
+
+// IsUpgraded returns true if the checkpoint records that the VM was replaced by an upgraded VM
+func (c *Checkpoint) IsUpgraded(vm string) bool {
+	if c == nil {
+		return false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	s, ok := c.VMs[strings.ToLower(vm)]
+	return ok && s.State == VMUpgraded
+}
+
+// SetState records the state of a VM of pool and saves the checkpoint
+func (c *Checkpoint) SetState(pool, vm string, state VMUpgradeState) error {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.VMs[strings.ToLower(vm)] = &VMCheckpoint{Pool: pool, State: state, UpdatedAt: time.Now().UTC()}
+	return c.save()
+}
+
+// Path returns the path the checkpoint is saved to
+func (c *Checkpoint) Path() string {
+	return c.path
+}
+
+// Remove deletes the saved checkpoint, once the upgrade completed
+func (c *Checkpoint) Remove() error {
+	if c == nil {
+		return nil
+	}
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// save writes the checkpoint to a temporary file renamed to its path, so that an interrupted save keeps the
+// previous checkpoint. The caller must hold the lock, so that a save of an older state cannot replace a newer one.
+func (c *Checkpoint) save() error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(c.path), CheckpointFilename)
+	if err != nil {
+		return errors.Wrap(err, "saving the upgrade checkpoint")
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(b); err != nil {
+		f.Close()
+		return errors.Wrap(err, "saving the upgrade checkpoint")
+	}
+	if err = f.Close(); err != nil {
+		return errors.Wrap(err, "saving the upgrade checkpoint")
+	}
+	return errors.Wrap(os.Rename(f.Name(), c.path), "saving the upgrade checkpoint")
+}
@@ -0,0 +1,215 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package kubernetesupgrade
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+)
+
+// UpgradePlan lists what upgrading the cluster to TargetVersion does, without changing the cluster
+type UpgradePlan struct {
+	TargetVersion string
+	// Steps are the VMs to upgrade, in the order the upgrade replaces them
+	Steps []UpgradeStep
+	// Errors are the validation errors that block the upgrade
+	Errors []string
+}
+
+// UpgradeStep is a VM replaced by the upgrade
+type UpgradeStep struct {
+	Pool           string
+	VM             string
+	CurrentVersion string
+	TargetVersion  string
+	OSImage        string
+	// Drain is how the workloads of the VM are moved before it is replaced
+	Drain string
+}
+
+// Plan computes the VMs upgrading the cluster to the orchestrator version of the data model replaces, masters then
+// agent pools, without upgrading them. Unlike UpgradeCluster, the versions nodes cannot be upgraded from are reported
+// in the errors of the plan rather than returned.
+func (uc *UpgradeCluster) Plan(az armhelpers.AKSEngineClient, kubeConfig string) (*UpgradePlan, error) {
+	uc.MasterVMs = &[]compute.VirtualMachine{}
+	uc.UpgradedMasterVMs = &[]compute.VirtualMachine{}
+	uc.AgentPools = make(map[string]*AgentPoolTopology)
+	uc.plan = &UpgradePlan{TargetVersion: uc.DataModel.Properties.OrchestratorProfile.OrchestratorVersion}
+	uc.currentVersions = map[string]string{}
+	defer func() {
+		uc.plan = nil
+		uc.currentVersions = nil
+	}()
+
+	var kubeClient armhelpers.KubernetesClient
+	if az != nil {
+		k, err := az.GetKubernetesClient("", kubeConfig, interval, time.Minute)
+		if err != nil {
+			uc.Logger.Warnf("Failed to get a Kubernetes client: %v", err)
+		}
+		kubeClient = k
+	}
+
+	if err := uc.getClusterNodeStatus(kubeClient, uc.ResourceGroup); err != nil {
+		return nil, uc.Translator.Errorf("Error while querying ARM for resources: %+v", err)
+	}
+
+	plan := uc.plan
+	properties := uc.DataModel.Properties
+	drainTimeout := defaultCordonDrainTimeout
+	if timeout := uc.getCordonDrainTimeout(); timeout != nil {
+		drainTimeout = *timeout
+	}
+	drain := fmt.Sprintf("cordon and drain within %s", drainTimeout)
+	if uc.getDrainOptions().ForceAfterTimeout {
+		drain += ", then delete the pods left"
+	}
+
+	if properties.MasterProfile != nil {
+		if count := len(*uc.MasterVMs) + len(*uc.UpgradedMasterVMs); count > properties.MasterProfile.Count {
+			plan.addError(fmt.Sprintf("Total count of master VMs: %d exceeded expected count: %d", count, properties.MasterProfile.Count))
+		}
+		masterImage := osImage(uc.DataModel, properties.MasterProfile.Distro, properties.MasterProfile.ImageRef)
+		for _, vm := range sortedVMs(*uc.MasterVMs) {
+			plan.addStep(MasterPoolName, *vm.Name, uc.currentVersions[*vm.Name], masterImage, "none, the VM is deleted and recreated")
+		}
+	}
+
+	vmssDrain := drain + ", once the scale set is scaled out by one VM"
+	if uc.SurgeOptions != nil {
+		vmssDrain = fmt.Sprintf("%s, %d at a time, once the scale set is scaled out by up to %d VMs which are Ready",
+			drain, uc.SurgeOptions.MaxUnavailable, uc.SurgeOptions.MaxSurge)
+	}
+	for _, vmss := range uc.AgentPoolScaleSetsToUpgrade {
+		poolName := scaleSetPoolName(vmss)
+		image := agentPoolOSImage(uc.DataModel, poolName)
+		for _, vm := range vmss.VMsToUpgrade {
+			plan.addStep(poolName, vm.Name, vm.CurrentVersion, image, vmssDrain)
+		}
+	}
+
+	identifiers := []string{}
+	for identifier := range uc.AgentPools {
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+	for _, identifier := range identifiers {
+		pool := uc.AgentPools[identifier]
+		image := agentPoolOSImage(uc.DataModel, *pool.Name)
+		for _, vm := range sortedVMs(*pool.AgentVMs) {
+			plan.addStep(*pool.Name, *vm.Name, uc.currentVersions[*vm.Name], image,
+				drain+", once a replacement VM has joined the pool")
+		}
+	}
+
+	return plan, nil
+}
+
+// Next returns the plan of the next hop of an upgrade through several versions, which upgrades the VMs of the plan
+// again from its target version
+func (p *UpgradePlan) Next(version string) *UpgradePlan {
+	next := &UpgradePlan{TargetVersion: version}
+	for _, s := range p.Steps {
+		s.CurrentVersion, s.TargetVersion = p.TargetVersion, version
+		next.Steps = append(next.Steps, s)
+	}
+	return next
+}
+
+// WriteText writes the plan in a human readable format
+func (p *UpgradePlan) WriteText(w io.Writer) error {
+	if len(p.Steps) == 0 {
+		fmt.Fprintf(w, "No VMs to upgrade to Kubernetes version %s\n", p.TargetVersion)
+	} else {
+		fmt.Fprintf(w, "VMs to upgrade to Kubernetes version %s, in order:\n", p.TargetVersion)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  POOL\tVM\tCURRENT VERSION\tTARGET VERSION\tOS IMAGE\tDRAIN")
+		for _, s := range p.Steps {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t%s\n", s.Pool, s.VM, s.CurrentVersion, s.TargetVersion, s.OSImage, s.Drain)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(p.Errors) > 0 {
+		fmt.Fprintln(w, "Blocking errors:")
+		for _, e := range p.Errors {
+			fmt.Fprintf(w, "  %s\n", e)
+		}
+	}
+	return nil
+}
+
+func (p *UpgradePlan) addStep(pool, vm, currentVersion, image, drain string) {
+	if currentVersion == "" {
+		currentVersion = "Unknown"
+	}
+	p.Steps = append(p.Steps, UpgradeStep{
+		Pool:           pool,
+		VM:             vm,
+		CurrentVersion: currentVersion,
+		TargetVersion:  p.TargetVersion,
+		OSImage:        image,
+		Drain:          drain,
+	})
+}
+
+// addError adds an error to the plan, once for all the VMs it blocks
+func (p *UpgradePlan) addError(e string) {
+	for _, existing := range p.Errors {
+		if existing == e {
+			return
+		}
+	}
+	p.Errors = append(p.Errors, e)
+}
+
+func sortedVMs(vms []compute.VirtualMachine) []compute.VirtualMachine {
+	sorted := append([]compute.VirtualMachine{}, vms...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return *sorted[i].Name < *sorted[j].Name
+	})
+	return sorted
+}
+
+// agentPoolOSImage returns the OS image the VMs of an agent pool are recreated with
+func agentPoolOSImage(cs *api.ContainerService, poolName string) string {
+	for _, pool := range cs.Properties.AgentPoolProfiles {
+		if pool.Name != poolName {
+			continue
+		}
+		if pool.IsWindows() && cs.Properties.WindowsProfile != nil {
+			w := cs.Properties.WindowsProfile
+			switch {
+			case w.HasCustomImage():
+				return w.WindowsImageSourceURL
+			case w.HasImageRef():
+				return fmt.Sprintf("%s/%s", w.ImageRef.ResourceGroup, w.ImageRef.Name)
+			}
+			return strings.Join([]string{w.WindowsPublisher, w.WindowsOffer, w.GetWindowsSku(), w.ImageVersion}, ":")
+		}
+		return osImage(cs, pool.Distro, pool.ImageRef)
+	}
+	return "Unknown"
+}
+
+// osImage returns the custom image of a Linux pool, or the marketplace image of its distro
+func osImage(cs *api.ContainerService, distro api.Distro, imageRef *api.ImageReference) string {
+	if imageRef != nil && imageRef.Name != "" {
+		return fmt.Sprintf("%s/%s", imageRef.ResourceGroup, imageRef.Name)
+	}
+	image, ok := cs.GetCloudSpecConfig().OSImageConfig[distro]
+	if !ok {
+		return "Unknown"
+	}
+	return strings.Join([]string{image.ImagePublisher, image.ImageOffer, image.ImageSku, image.ImageVersion}, ":")
+}
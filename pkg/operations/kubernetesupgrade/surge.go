@@ -0,0 +1,195 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package kubernetesupgrade
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/operations"
+	"github.com/pkg/errors"
+)
+
+// SurgeOptions configure the surge upgrade of the VMSS agent pools: rather than swapping the VMs of a scale set one at
+// a time, the scale set is scaled out by MaxSurge VMs of the target model, which must join the cluster Ready before
+// as many VMs they replace are drained and deleted, MaxUnavailable at a time
+type SurgeOptions struct {
+	// MaxSurge is the number of VMs a scale set is scaled out by at a time
+	MaxSurge int
+	// MaxUnavailable is the number of the replaced VMs drained at the same time
+	MaxUnavailable int
+}
+
+// Validate returns an error if the options do not surge by at least one VM or do not drain at least one VM at a time
+func (o *SurgeOptions) Validate() error {
+	if o.MaxSurge < 1 {
+		return errors.Errorf("max surge %d must be at least 1", o.MaxSurge)
+	}
+	if o.MaxUnavailable < 1 {
+		return errors.Errorf("max unavailable %d must be at least 1", o.MaxUnavailable)
+	}
+	return nil
+}
+
+// surgeUpgradeScaleSet upgrades the VMs of a scale set in batches of surgeOptions.MaxSurge VMs: the scale set is scaled
+// out by the size of the batch, then once the new VMs are Ready the VMs of the batch are drained and deleted
+func (ku *Upgrader) surgeUpgradeScaleSet(ctx context.Context, vmssToUpgrade AgentPoolScaleSet, preserveNodesProperties bool) error {
+	cordonDrainTimeout := defaultCordonDrainTimeout
+	if ku.cordonDrainTimeout != nil {
+		cordonDrainTimeout = *ku.cordonDrainTimeout
+	}
+	client, err := ku.getKubernetesClient(cordonDrainTimeout)
+	if err != nil {
+		ku.logger.Errorf("Error getting Kubernetes client: %v", err)
+		return err
+	}
+
+	known, err := ku.listScaleSetInstances(ctx, vmssToUpgrade.Name)
+	if err != nil {
+		return err
+	}
+
+	poolName := scaleSetPoolName(vmssToUpgrade)
+	capacity := *vmssToUpgrade.Sku.Capacity
+	remaining := vmssToUpgrade.VMsToUpgrade
+	for len(remaining) > 0 {
+		surge := ku.surgeOptions.MaxSurge
+		if surge > len(remaining) {
+			surge = len(remaining)
+		}
+		batch := remaining[:surge]
+
+		newCapacity := capacity + int64(surge)
+		ku.logger.Infof("Scaling VMSS %s out from %d to %d VMs to replace %d VMs", vmssToUpgrade.Name, capacity, newCapacity, surge)
+		sku := vmssToUpgrade.Sku
+		sku.Capacity = &newCapacity
+		if err = ku.Client.SetVirtualMachineScaleSetCapacity(ctx, ku.ClusterTopology.ResourceGroup, vmssToUpgrade.Name, sku, vmssToUpgrade.Location); err != nil {
+			ku.logger.Errorf("Failure to set capacity for VMSS %s", vmssToUpgrade.Name)
+			return err
+		}
+
+		newNodes, err := ku.waitForNewScaleSetNodes(ctx, client, vmssToUpgrade.Name, known, surge)
+		if err != nil {
+			return err
+		}
+
+		for start := 0; start < len(batch); start += ku.surgeOptions.MaxUnavailable {
+			end := start + ku.surgeOptions.MaxUnavailable
+			if end > len(batch) {
+				end = len(batch)
+			}
+			errCh := make(chan error, end-start)
+			for i := start; i < end; i++ {
+				go func(vmToUpgrade AgentPoolScaleSetVM, newNodeName string) {
+					errCh <- ku.replaceScaleSetVM(ctx, client, vmssToUpgrade.Name, poolName, vmToUpgrade, newNodeName, cordonDrainTimeout, preserveNodesProperties)
+				}(batch[i], newNodes[i])
+			}
+			var replaceErr error
+			for i := start; i < end; i++ {
+				if err := <-errCh; err != nil && replaceErr == nil {
+					replaceErr = err
+				}
+			}
+			if replaceErr != nil {
+				return replaceErr
+			}
+		}
+		remaining = remaining[surge:]
+	}
+	return nil
+}
+
+// replaceScaleSetVM drains and deletes a VM replaced by the new node newNodeName
+func (ku *Upgrader) replaceScaleSetVM(ctx context.Context, client armhelpers.KubernetesClient, vmssName, poolName string, vmToUpgrade AgentPoolScaleSetVM, newNodeName string, cordonDrainTimeout time.Duration, preserveNodesProperties bool) error {
+	ku.checkpoint(poolName, vmToUpgrade.Name, VMUpgrading)
+	ku.logger.Infof("Draining node %s", vmToUpgrade.Name)
+	if err := operations.DrainNodeWithClient(client, ku.logger, strings.ToLower(vmToUpgrade.Name), cordonDrainTimeout, ku.getDrainOptions()); err != nil {
+		ku.logger.Errorf("Error draining VM in VMSS: %v", err)
+		return err
+	}
+
+	if preserveNodesProperties {
+		ku.logger.Infof("Copying custom annotations, labels, taints from old node %s to new node %s...", vmToUpgrade.Name, newNodeName)
+		if err := ku.copyCustomPropertiesToNewNode(client, strings.ToLower(vmToUpgrade.Name), newNodeName); err != nil {
+			ku.logger.Warningf("Failed to copy custom annotations, labels, taints from old node %s to new node %s: %v", vmToUpgrade.Name, newNodeName, err)
+		}
+	}
+
+	ku.logger.Infof("Deleting VM %s in VMSS %s", vmToUpgrade.Name, vmssName)
+	if err := ku.Client.DeleteVirtualMachineScaleSetVM(ctx, ku.ClusterTopology.ResourceGroup, vmssName, vmToUpgrade.InstanceID); err != nil {
+		ku.logger.Errorf("Failed to delete VM %s in VMSS %s", vmToUpgrade.Name, vmssName)
+		return err
+	}
+	ku.logger.Infof("Successfully deleted VM %s in VMSS %s", vmToUpgrade.Name, vmssName)
+	ku.checkpoint(poolName, vmToUpgrade.Name, VMUpgraded)
+	return nil
+}
+
+// listScaleSetInstances returns the computer names of the VMs of a scale set, by instance ID
+func (ku *Upgrader) listScaleSetInstances(ctx context.Context, vmssName string) (map[string]string, error) {
+	instances := map[string]string{}
+	for page, err := ku.Client.ListVirtualMachineScaleSetVMs(ctx, ku.ClusterTopology.ResourceGroup, vmssName); page.NotDone(); err = page.Next() {
+		if err != nil {
+			return nil, err
+		}
+		for _, vm := range page.Values() {
+			if vm.InstanceID == nil || vm.VirtualMachineScaleSetVMProperties == nil || vm.OsProfile == nil || vm.OsProfile.ComputerName == nil {
+				continue
+			}
+			instances[*vm.InstanceID] = *vm.OsProfile.ComputerName
+		}
+	}
+	return instances, nil
+}
+
+// waitForNewScaleSetNodes waits until count VMs of a scale set that are not in known have joined the cluster Ready,
+// adds them to known and returns their node names
+func (ku *Upgrader) waitForNewScaleSetNodes(ctx context.Context, client armhelpers.KubernetesClient, vmssName string, known map[string]string, count int) ([]string, error) {
+	timeout := defaultTimeout
+	if ku.stepTimeout != nil {
+		timeout = *ku.stepTimeout
+	}
+	ku.logger.Infof("Waiting for %d new VMs of VMSS %s to be Ready", count, vmssName)
+	deadline := time.Now().Add(timeout)
+	for {
+		instances, err := ku.listScaleSetInstances(ctx, vmssName)
+		if err != nil {
+			ku.logger.Warnf("Failed to list the VMs of VMSS %s: %v", vmssName, err)
+		}
+		var ready []string
+		newInstances := map[string]string{}
+		for instanceID, computerName := range instances {
+			if _, ok := known[instanceID]; ok {
+				continue
+			}
+			nodeName := strings.ToLower(computerName)
+			if node, err := client.GetNode(nodeName); err == nil && isNodeReady(node) {
+				ready = append(ready, nodeName)
+				newInstances[instanceID] = computerName
+			}
+			if len(ready) == count {
+				break
+			}
+		}
+		if len(ready) == count {
+			sort.Strings(ready)
+			for instanceID, computerName := range newInstances {
+				known[instanceID] = computerName
+			}
+			ku.logger.Infof("New VMs %s of VMSS %s are Ready", strings.Join(ready, ", "), vmssName)
+			return ready, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.Errorf("%d of the %d new VMs of VMSS %s were Ready after %s", len(ready), count, vmssName, timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
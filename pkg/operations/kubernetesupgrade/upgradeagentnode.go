@@ -42,6 +42,7 @@ type UpgradeAgentNode struct {
 	kubeConfig              string
 	timeout                 time.Duration
 	cordonDrainTimeout      time.Duration
+	drainOptions            operations.DrainOptions
 }
 
 // DeleteNode takes state/resources of the master/agent node from ListNodeResources
@@ -70,7 +71,7 @@ func (kan *UpgradeAgentNode) DeleteNode(vmName *string, drain bool) error {
 	}
 	// Cordon and drain the node
 	if drain {
-		err = operations.SafelyDrainNodeWithClient(client, kan.logger, nodeName, kan.cordonDrainTimeout)
+		err = operations.DrainNodeWithClient(client, kan.logger, nodeName, kan.cordonDrainTimeout, kan.drainOptions)
 		if err != nil {
 			kan.logger.Warningf("Error draining agent VM %s. Proceeding with deletion. Error: %v", *vmName, err)
 			// Proceed with deletion anyways
@@ -13,7 +13,9 @@ import (
 	"github.com/Azure/aks-engine/pkg/armhelpers"
 	"github.com/Azure/aks-engine/pkg/armhelpers/utils"
 	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/operations"
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -39,6 +41,9 @@ type ClusterTopology struct {
 	UpgradedMasterVMs *[]compute.VirtualMachine
 
 	IsVMSSToBeUpgraded IsVMSSToBeUpgradedCb
+
+	// Checkpoint records the progress of the upgrade, the VMs it records as upgraded are skipped
+	Checkpoint *Checkpoint
 }
 
 // AgentPoolScaleSet contains necessary data required to upgrade a VMSS
@@ -52,8 +57,9 @@ type AgentPoolScaleSet struct {
 
 // AgentPoolScaleSetVM represents a VM in a VMSS
 type AgentPoolScaleSetVM struct {
-	Name       string
-	InstanceID string
+	Name           string
+	InstanceID     string
+	CurrentVersion string
 }
 
 // AgentPoolTopology contains agent VMs in a single pool
@@ -73,8 +79,18 @@ type UpgradeCluster struct {
 	Client             armhelpers.AKSEngineClient
 	StepTimeout        *time.Duration
 	CordonDrainTimeout *time.Duration
-	UpgradeWorkFlow    UpgradeWorkFlow
-	Force              bool
+	// DrainOptions configure how the nodes are drained, the drainProfile of the api model configures them if nil
+	DrainOptions *operations.DrainOptions
+	// SurgeOptions configure the surge upgrade of the VMSS agent pools, their VMs are swapped one at a time if nil
+	SurgeOptions    *SurgeOptions
+	UpgradeWorkFlow UpgradeWorkFlow
+	Force           bool
+	// AddonReconcileReport lists the addon changes made in the cluster that the upgrade preserved or reset
+	AddonReconcileReport *AddonReconcileReport
+
+	// plan collects the blocking errors and the current VM versions while Plan runs
+	plan            *UpgradePlan
+	currentVersions map[string]string
 }
 
 // MasterVMNamePrefix is the prefix for all master VM names for Kubernetes clusters
@@ -125,6 +141,10 @@ func (uc *UpgradeCluster) UpgradeCluster(az armhelpers.AKSEngineClient, kubeConf
 		}
 	}
 
+	if kubeClient != nil {
+		uc.reconcileAddons(kubeClient)
+	}
+
 	upgradeVersion := uc.DataModel.Properties.OrchestratorProfile.OrchestratorVersion
 	uc.Logger.Infof("Upgrading to Kubernetes version %s", upgradeVersion)
 
@@ -136,6 +156,23 @@ func (uc *UpgradeCluster) UpgradeCluster(az armhelpers.AKSEngineClient, kubeConf
 	return nil
 }
 
+// reconcileAddons carries the addon customizations made in the cluster over into the upgraded addon manifests, and logs
+// those that are preserved and those that are reset. The upgrade goes on with the addon manifests of the api model if it fails.
+func (uc *UpgradeCluster) reconcileAddons(kubeClient armhelpers.KubernetesClient) {
+	report, err := ReconcileAddons(kubeClient, uc.DataModel)
+	if err != nil {
+		uc.Logger.Warnf("Failed to reconcile the addons with the cluster, changes made to them in the cluster may be reset: %v", err)
+		return
+	}
+	uc.AddonReconcileReport = report
+	for _, c := range report.Preserved {
+		uc.Logger.Infof("Preserving %s changed in the cluster: %s", c, c.Live)
+	}
+	for _, c := range report.Reset {
+		uc.Logger.Warnf("Resetting %s changed in the cluster from %s to the new default %s", c, c.Live, c.Upgrade)
+	}
+}
+
 // SetClusterAutoscalerReplicaCount changes the replica count of a cluster-autoscaler deployment.
 func (uc *UpgradeCluster) SetClusterAutoscalerReplicaCount(kubeClient armhelpers.KubernetesClient, replicaCount int32) (int32, error) {
 	if kubeClient == nil {
@@ -170,10 +207,60 @@ func (uc *UpgradeCluster) getUpgradeWorkflow(kubeConfig string, aksEngineVersion
 		return uc.UpgradeWorkFlow
 	}
 	u := &Upgrader{}
-	u.Init(uc.Translator, uc.Logger, uc.ClusterTopology, uc.Client, kubeConfig, uc.StepTimeout, uc.CordonDrainTimeout, aksEngineVersion)
+	u.Init(uc.Translator, uc.Logger, uc.ClusterTopology, uc.Client, kubeConfig, uc.StepTimeout, uc.getCordonDrainTimeout(), aksEngineVersion)
+	drainOptions := uc.getDrainOptions()
+	u.drainOptions = &drainOptions
+	u.surgeOptions = uc.SurgeOptions
 	return u
 }
 
+// getCordonDrainTimeout returns CordonDrainTimeout, or the timeout of the drain profile of the api model if it is not set
+func (uc *UpgradeCluster) getCordonDrainTimeout() *time.Duration {
+	if uc.CordonDrainTimeout != nil {
+		return uc.CordonDrainTimeout
+	}
+	if p := uc.drainProfile(); p != nil && p.TimeoutInMinutes > 0 {
+		timeout := time.Duration(p.TimeoutInMinutes) * time.Minute
+		return &timeout
+	}
+	return nil
+}
+
+// getDrainOptions returns DrainOptions, or the options of the drain profile of the api model if it is not set
+func (uc *UpgradeCluster) getDrainOptions() operations.DrainOptions {
+	if uc.DrainOptions != nil {
+		return *uc.DrainOptions
+	}
+	return DrainOptionsFromProfile(uc.drainProfile())
+}
+
+func (uc *UpgradeCluster) drainProfile() *api.DrainProfile {
+	if uc.DataModel == nil || uc.DataModel.Properties.OrchestratorProfile == nil || uc.DataModel.Properties.OrchestratorProfile.KubernetesConfig == nil {
+		return nil
+	}
+	return uc.DataModel.Properties.OrchestratorProfile.KubernetesConfig.DrainProfile
+}
+
+// DrainOptionsFromProfile returns the drain options a drain profile sets, and the default options for those it does not set
+func DrainOptionsFromProfile(p *api.DrainProfile) operations.DrainOptions {
+	options := operations.DefaultDrainOptions()
+	if p == nil {
+		return options
+	}
+	if p.GracePeriodSeconds != nil {
+		gracePeriod := int64(*p.GracePeriodSeconds)
+		options.GracePeriodSeconds = &gracePeriod
+	}
+	if p.IgnoreDaemonSets != nil {
+		options.IgnoreDaemonSets = *p.IgnoreDaemonSets
+	}
+	if p.DeleteEmptyDirData != nil {
+		options.DeleteEmptyDirData = *p.DeleteEmptyDirData
+	}
+	options.ForceAfterTimeout = to.Bool(p.ForceAfterTimeout)
+	return options
+}
+
 func (uc *UpgradeCluster) getClusterNodeStatus(kubeClient armhelpers.KubernetesClient, resourceGroup string) error {
 	goalVersion := uc.DataModel.Properties.OrchestratorProfile.OrchestratorVersion
 
@@ -211,6 +298,10 @@ func (uc *UpgradeCluster) getClusterNodeStatus(kubeClient armhelpers.KubernetesC
 					uc.Logger.Infof("Set isWindows flag for vmss %s.", *vmScaleSet.Name)
 				}
 				for _, vm := range vmScaleSetVMsPage.Values() {
+					if uc.Checkpoint.IsUpgraded(*vm.VirtualMachineScaleSetVMProperties.OsProfile.ComputerName) {
+						uc.Logger.Infof("Skipping VM: %s in VMSS %s as the upgrade checkpoint records it as upgraded.", *vm.Name, *vmScaleSet.Name)
+						continue
+					}
 					currentVersion := uc.getNodeVersion(kubeClient, strings.ToLower(*vm.VirtualMachineScaleSetVMProperties.OsProfile.ComputerName), vm.Tags, *vm.VirtualMachineScaleSetVMProperties.LatestModelApplied)
 					if uc.Force {
 						if currentVersion == "" {
@@ -233,8 +324,9 @@ func (uc *UpgradeCluster) getClusterNodeStatus(kubeClient armhelpers.KubernetesC
 						scaleSetToUpgrade.VMsToUpgrade = append(
 							scaleSetToUpgrade.VMsToUpgrade,
 							AgentPoolScaleSetVM{
-								Name:       *vm.VirtualMachineScaleSetVMProperties.OsProfile.ComputerName,
-								InstanceID: *vm.InstanceID,
+								Name:           *vm.VirtualMachineScaleSetVMProperties.OsProfile.ComputerName,
+								InstanceID:     *vm.InstanceID,
+								CurrentVersion: currentVersion,
 							},
 						)
 					}
@@ -264,6 +356,12 @@ func (uc *UpgradeCluster) getClusterNodeStatus(kubeClient armhelpers.KubernetesC
 				availabilitySetIDs = append(availabilitySetIDs, *vm.AvailabilitySet.ID)
 			}
 
+			if uc.Checkpoint.IsUpgraded(*vm.Name) {
+				uc.Logger.Infof("VM: %s is recorded as upgraded by the upgrade checkpoint.", *vm.Name)
+				uc.addVMToFinishedSets(vm, currentVersion)
+				continue
+			}
+
 			if uc.Force {
 				if currentVersion == "" {
 					currentVersion = "Unknown"
@@ -278,7 +376,10 @@ func (uc *UpgradeCluster) getClusterNodeStatus(kubeClient armhelpers.KubernetesC
 				if currentVersion != goalVersion {
 					if !uc.DataModel.Properties.IsHostedMasterProfile() {
 						if err := uc.upgradable(currentVersion); err != nil {
-							return err
+							if uc.plan == nil {
+								return err
+							}
+							uc.plan.addError(err.Error())
 						}
 					}
 					uc.addVMToUpgradeSets(vm, currentVersion)
@@ -437,6 +538,9 @@ func (uc *UpgradeCluster) addVMToAgentPool(vm compute.VirtualMachine, isUpgradab
 }
 
 func (uc *UpgradeCluster) addVMToUpgradeSets(vm compute.VirtualMachine, currentVersion string) {
+	if uc.currentVersions != nil {
+		uc.currentVersions[*vm.Name] = currentVersion
+	}
 	if strings.Contains(*(vm.Name), MasterVMNamePrefix) {
 		uc.Logger.Infof("Master VM name: %s, orchestrator: %s (MasterVMs)", *vm.Name, currentVersion)
 		*uc.MasterVMs = append(*uc.MasterVMs, vm)
@@ -5,18 +5,27 @@ package kubernetesupgrade
 
 import (
 	"context"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/aks-engine/pkg/api/common"
 	"github.com/Azure/aks-engine/pkg/armhelpers"
 	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/operations"
 	. "github.com/Azure/aks-engine/pkg/test"
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	logtest "github.com/sirupsen/logrus/hooks/test"
 	v1 "k8s.io/api/core/v1"
@@ -642,6 +651,94 @@ var _ = Describe("Upgrade Kubernetes cluster tests", func() {
 		})
 	})
 
+	Context("When planning an upgrade", func() {
+		var (
+			uc               UpgradeCluster
+			mockClient       armhelpers.MockAKSEngineClient
+			versionMapBackup map[string]bool
+		)
+
+		AfterEach(func() {
+			common.AllKubernetesSupportedVersions = versionMapBackup
+		})
+
+		BeforeEach(func() {
+			versionMapBackup = common.AllKubernetesSupportedVersions
+			mockClient = armhelpers.MockAKSEngineClient{}
+			cs := api.CreateMockContainerService("testcluster", "1.9.10", 1, 2, false)
+			cs.Properties.MasterProfile.Distro = api.Ubuntu
+			cs.Properties.AgentPoolProfiles[0].Distro = api.Ubuntu
+			uc = UpgradeCluster{
+				Translator: &i18n.Translator{},
+				Logger:     log.NewEntry(log.New()),
+			}
+			uc.Client = &mockClient
+			uc.ClusterTopology = ClusterTopology{}
+			uc.SubscriptionID = "DEC923E3-1EF1-4745-9516-37906D56DEC4"
+			uc.ResourceGroup = "TestRg"
+			uc.DataModel = cs
+			uc.NameSuffix = "12345678"
+			uc.AgentPoolsToUpgrade = map[string]bool{MasterPoolName: true, "agentpool1": true}
+			uc.UpgradeWorkFlow = fakeUpgradeWorkflow{RunUpgradeError: errors.New("the plan must not upgrade the cluster")}
+		})
+
+		It("Should list the masters then the agent VMs to upgrade", func() {
+			common.AllKubernetesSupportedVersions = map[string]bool{
+				"1.9.9":  true,
+				"1.9.10": true,
+			}
+			mockClient.FakeListVirtualMachineResult = func() []compute.VirtualMachine {
+				return []compute.VirtualMachine{
+					mockClient.MakeFakeVirtualMachine("k8s-agentpool1-12345678-1", "Kubernetes:1.9.9"),
+					mockClient.MakeFakeVirtualMachine("k8s-agentpool1-12345678-0", "Kubernetes:1.9.10"),
+					mockClient.MakeFakeVirtualMachine("k8s-master-12345678-0", "Kubernetes:1.9.9"),
+				}
+			}
+
+			plan, err := uc.Plan(&mockClient, "kubeConfig")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Errors).To(BeEmpty())
+			Expect(plan.Steps).To(HaveLen(2))
+			Expect(plan.Steps[0].Pool).To(Equal(MasterPoolName))
+			Expect(plan.Steps[0].VM).To(Equal("k8s-master-12345678-0"))
+			Expect(plan.Steps[0].CurrentVersion).To(Equal("1.9.9"))
+			Expect(plan.Steps[0].TargetVersion).To(Equal("1.9.10"))
+			Expect(plan.Steps[0].Drain).To(Equal("none, the VM is deleted and recreated"))
+			Expect(plan.Steps[1].Pool).To(Equal("agentpool1"))
+			Expect(plan.Steps[1].VM).To(Equal("k8s-agentpool1-12345678-1"))
+			Expect(plan.Steps[1].OSImage).To(Equal("Canonical:UbuntuServer:16.04-LTS:latest"))
+			Expect(plan.Steps[1].Drain).To(ContainSubstring("cordon and drain within 20m0s"))
+
+			next := plan.Next("1.10.13")
+			Expect(next.Steps).To(HaveLen(2))
+			Expect(next.Steps[1].CurrentVersion).To(Equal("1.9.10"))
+			Expect(next.Steps[1].TargetVersion).To(Equal("1.10.13"))
+		})
+
+		It("Should report the versions that cannot be upgraded rather than failing", func() {
+			common.AllKubernetesSupportedVersions = map[string]bool{
+				"1.9.7":  true,
+				"1.9.10": false,
+			}
+			mockClient.FakeListVirtualMachineResult = func() []compute.VirtualMachine {
+				return []compute.VirtualMachine{
+					mockClient.MakeFakeVirtualMachine("k8s-master-12345678-0", "Kubernetes:1.9.7"),
+					mockClient.MakeFakeVirtualMachine("k8s-agentpool1-12345678-0", "Kubernetes:1.9.7"),
+				}
+			}
+
+			plan, err := uc.Plan(&mockClient, "kubeConfig")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Errors).To(Equal([]string{"1.9.7 cannot be upgraded to 1.9.10"}))
+			Expect(plan.Steps).To(HaveLen(2))
+
+			var text strings.Builder
+			Expect(plan.WriteText(&text)).To(Succeed())
+			Expect(text.String()).To(ContainSubstring("VMs to upgrade to Kubernetes version 1.9.10, in order:"))
+			Expect(text.String()).To(ContainSubstring("Blocking errors:\n  1.9.7 cannot be upgraded to 1.9.10\n"))
+		})
+	})
+
 	It("Should not fail if no managed identity is returned by azure during upgrade operation", func() {
 		cs := api.CreateMockContainerService("testcluster", "1.10.13", 3, 2, false)
 		cs.Properties.OrchestratorProfile.KubernetesConfig = &api.KubernetesConfig{}
@@ -665,6 +762,85 @@ var _ = Describe("Upgrade Kubernetes cluster tests", func() {
 		Expect(err).NotTo(HaveOccurred())
 	})
 
+	It("Should record the upgraded VMs in the checkpoint and skip them when resuming", func() {
+		dir, err := ioutil.TempDir("", "upgrade-checkpoint")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		checkpoint, err := NewCheckpoint(filepath.Join(dir, CheckpointFilename), "1.10.13")
+		Expect(err).NotTo(HaveOccurred())
+
+		cs := api.CreateMockContainerService("testcluster", "1.10.13", 1, 1, false)
+		uc := UpgradeCluster{
+			Translator: &i18n.Translator{},
+			Logger:     log.NewEntry(log.New()),
+		}
+		mockClient := armhelpers.MockAKSEngineClient{MockKubernetesClient: &armhelpers.MockKubernetesClient{}}
+		mockClient.FakeListVirtualMachineResult = func() []compute.VirtualMachine {
+			return []compute.VirtualMachine{
+				mockClient.MakeFakeVirtualMachine("k8s-master-12345678-0", "Kubernetes:1.10.13"),
+				mockClient.MakeFakeVirtualMachine("k8s-agentpool1-12345678-0", "Kubernetes:1.10.13"),
+			}
+		}
+		uc.Client = &mockClient
+		uc.ClusterTopology = ClusterTopology{}
+		uc.SubscriptionID = "DEC923E3-1EF1-4745-9516-37906D56DEC4"
+		uc.ResourceGroup = "TestRg"
+		uc.DataModel = cs
+		uc.NameSuffix = "12345678"
+		uc.AgentPoolsToUpgrade = map[string]bool{MasterPoolName: true, "agentpool1": true}
+		uc.Force = true
+		uc.Checkpoint = checkpoint
+
+		err = uc.UpgradeCluster(&mockClient, "kubeConfig", TestAKSEngineVersion)
+		Expect(err).NotTo(HaveOccurred())
+
+		saved, err := LoadCheckpoint(filepath.Join(dir, CheckpointFilename))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(saved.TargetVersion).To(Equal("1.10.13"))
+		Expect(saved.IsUpgraded("k8s-master-12345678-0")).To(BeTrue())
+		Expect(saved.VMs["k8s-master-12345678-0"].Pool).To(Equal(MasterPoolName))
+		Expect(saved.IsUpgraded("k8s-agentpool1-12345678-0")).To(BeTrue())
+
+		// a forced upgrade upgrades every VM again unless it resumes from the checkpoint
+		uc.Checkpoint = saved
+		uc.UpgradeWorkFlow = fakeUpgradeWorkflow{}
+		err = uc.UpgradeCluster(&mockClient, "kubeConfig", TestAKSEngineVersion)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*uc.MasterVMs).To(BeEmpty())
+		Expect(*uc.UpgradedMasterVMs).To(HaveLen(1))
+		Expect(*uc.AgentPools["agentpool1"].AgentVMs).To(BeEmpty())
+	})
+
+	It("Should keep the states of the VMs upgraded concurrently in the checkpoint", func() {
+		dir, err := ioutil.TempDir("", "upgrade-checkpoint")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		checkpoint, err := NewCheckpoint(filepath.Join(dir, CheckpointFilename), "1.10.13")
+		Expect(err).NotTo(HaveOccurred())
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 20)
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs <- checkpoint.SetState("agentpool1", fmt.Sprintf("k8s-agentpool1-12345678-vmss%06d", i), VMUpgraded)
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		saved, err := LoadCheckpoint(filepath.Join(dir, CheckpointFilename))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(saved.VMs).To(HaveLen(20))
+		for i := 0; i < 20; i++ {
+			Expect(saved.IsUpgraded(fmt.Sprintf("k8s-agentpool1-12345678-vmss%06d", i))).To(BeTrue())
+		}
+	})
+
 	It("Should not fail if a Kubernetes client cannot be created", func() {
 		cs := api.CreateMockContainerService("testcluster", "1.10.13", 3, 2, false)
 		uc := UpgradeCluster{
@@ -958,4 +1134,104 @@ var _ = Describe("Upgrade Kubernetes cluster tests", func() {
 
 		Expect(len(newNode.Spec.Taints)).To(Equal(2))
 	})
+
+	It("Should drain the nodes with the drain profile of the api model unless drain options are set", func() {
+		cs := api.CreateMockContainerService("testcluster", "1.10.13", 3, 2, false)
+		cs.Properties.OrchestratorProfile.KubernetesConfig.DrainProfile = &api.DrainProfile{
+			TimeoutInMinutes:   45,
+			GracePeriodSeconds: to.IntPtr(10),
+			DeleteEmptyDirData: to.BoolPtr(false),
+			ForceAfterTimeout:  to.BoolPtr(true),
+		}
+		uc := UpgradeCluster{DataModel: cs}
+
+		gracePeriod := int64(10)
+		Expect(uc.getDrainOptions()).To(Equal(operations.DrainOptions{
+			GracePeriodSeconds: &gracePeriod,
+			IgnoreDaemonSets:   true,
+			ForceAfterTimeout:  true,
+		}))
+		Expect(*uc.getCordonDrainTimeout()).To(Equal(45 * time.Minute))
+
+		timeout := 5 * time.Minute
+		uc.CordonDrainTimeout = &timeout
+		uc.DrainOptions = &operations.DrainOptions{}
+		Expect(uc.getDrainOptions()).To(Equal(operations.DrainOptions{}))
+		Expect(*uc.getCordonDrainTimeout()).To(Equal(timeout))
+
+		Expect(DrainOptionsFromProfile(nil)).To(Equal(operations.DefaultDrainOptions()))
+	})
+
+	It("Should surge upgrade a scale set in batches of new VMs which are Ready before the VMs they replace are deleted", func() {
+		mockClient := armhelpers.MockAKSEngineClient{MockKubernetesClient: &armhelpers.MockKubernetesClient{}}
+		makeVM := func(instanceID string) compute.VirtualMachineScaleSetVM {
+			vm := mockClient.MakeFakeVirtualMachineScaleSetVMWithGivenName("Kubernetes:1.10.13", "aks-agentpool1-12345678-vmss00000"+instanceID)
+			vm.InstanceID = to.StringPtr(instanceID)
+			return vm
+		}
+		vms := []compute.VirtualMachineScaleSetVM{makeVM("0"), makeVM("1"), makeVM("2")}
+		nextInstanceID := 3
+		var capacities []int64
+		var deleted []string
+		mockClient.FakeListVirtualMachineScaleSetVMsResult = func() []compute.VirtualMachineScaleSetVM {
+			return append([]compute.VirtualMachineScaleSetVM{}, vms...)
+		}
+		mockClient.SetVirtualMachineScaleSetCapacityFunc = func(sku compute.Sku) error {
+			capacities = append(capacities, *sku.Capacity)
+			for int64(len(vms)) < *sku.Capacity {
+				vms = append(vms, makeVM(strconv.Itoa(nextInstanceID)))
+				nextInstanceID++
+			}
+			return nil
+		}
+		mockClient.DeleteVirtualMachineScaleSetVMFunc = func(instanceID string) error {
+			for i, vm := range vms {
+				if *vm.InstanceID == instanceID {
+					vms = append(vms[:i], vms[i+1:]...)
+					break
+				}
+			}
+			deleted = append(deleted, instanceID)
+			return nil
+		}
+
+		vmss := AgentPoolScaleSet{
+			Name:     "aks-agentpool1-12345678-vmss",
+			Sku:      compute.Sku{Capacity: to.Int64Ptr(3)},
+			Location: "westus",
+		}
+		for _, vm := range vms {
+			vmss.VMsToUpgrade = append(vmss.VMsToUpgrade, AgentPoolScaleSetVM{Name: *vm.OsProfile.ComputerName, InstanceID: *vm.InstanceID})
+		}
+
+		u := &Upgrader{}
+		stepTimeout := time.Second * 5
+		u.Init(&i18n.Translator{}, log.NewEntry(log.New()), ClusterTopology{DataModel: api.CreateMockContainerService("testcluster", "1.10.13", 3, 3, false)}, &mockClient, "", &stepTimeout, nil, TestAKSEngineVersion)
+		u.surgeOptions = &SurgeOptions{MaxSurge: 2, MaxUnavailable: 1}
+
+		err := u.surgeUpgradeScaleSet(context.Background(), vmss, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(capacities).To(Equal([]int64{5, 4}))
+		Expect(deleted).To(Equal([]string{"0", "1", "2"}))
+		Expect(vms).To(HaveLen(3))
+		for _, vm := range vms {
+			Expect([]string{"3", "4", "5"}).To(ContainElement(*vm.InstanceID))
+		}
+
+		mockClient.MockKubernetesClient.GetNodeFunc = func(name string) (*v1.Node, error) {
+			return &v1.Node{}, nil
+		}
+		stepTimeout = time.Second
+		err = u.surgeUpgradeScaleSet(context.Background(), AgentPoolScaleSet{
+			Name:         vmss.Name,
+			Sku:          compute.Sku{Capacity: to.Int64Ptr(3)},
+			VMsToUpgrade: []AgentPoolScaleSetVM{{Name: *vms[0].OsProfile.ComputerName, InstanceID: *vms[0].InstanceID}},
+		}, false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("0 of the 1 new VMs of VMSS aks-agentpool1-12345678-vmss were Ready"))
+
+		Expect((&SurgeOptions{MaxSurge: 0, MaxUnavailable: 1}).Validate()).To(HaveOccurred())
+		Expect((&SurgeOptions{MaxSurge: 1, MaxUnavailable: 0}).Validate()).To(HaveOccurred())
+		Expect((&SurgeOptions{MaxSurge: 1, MaxUnavailable: 1}).Validate()).NotTo(HaveOccurred())
+	})
 })
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,7 +34,11 @@ type Upgrader struct {
 	kubeConfig         string
 	stepTimeout        *time.Duration
 	cordonDrainTimeout *time.Duration
-	AKSEngineVersion   string
+	// drainOptions configure how the nodes are drained, the default options if nil
+	drainOptions *operations.DrainOptions
+	// surgeOptions configure the surge upgrade of the scale sets, which are upgraded one VM at a time if nil
+	surgeOptions     *SurgeOptions
+	AKSEngineVersion string
 }
 
 type vmStatus int
@@ -158,6 +163,7 @@ func (ku *Upgrader) upgradeMasterNodes(ctx context.Context) error {
 
 		masterIndex, _ := utils.GetVMNameIndex(vm.StorageProfile.OsDisk.OsType, *vm.Name)
 
+		ku.checkpoint(MasterPoolName, *vm.Name, VMUpgrading)
 		err = upgradeMasterNode.DeleteNode(vm.Name, false)
 		if err != nil {
 			ku.logger.Infof("Error deleting master VM: %s, err: %v", *vm.Name, err)
@@ -176,6 +182,7 @@ func (ku *Upgrader) upgradeMasterNodes(ctx context.Context) error {
 			return err
 		}
 
+		ku.checkpoint(MasterPoolName, *vm.Name, VMUpgraded)
 		upgradedMastersIndex[masterIndex] = true
 	}
 
@@ -212,6 +219,7 @@ func (ku *Upgrader) upgradeMasterNodes(ctx context.Context) error {
 			return err
 		}
 
+		ku.checkpoint(MasterPoolName, ku.DataModel.Properties.GetMasterVMPrefix()+strconv.Itoa(masterIndexToCreate), VMUpgraded)
 		upgradedMastersIndex[masterIndexToCreate] = true
 	}
 
@@ -285,6 +293,7 @@ func (ku *Upgrader) upgradeAgentPools(ctx context.Context) error {
 		} else {
 			upgradeAgentNode.cordonDrainTimeout = *ku.cordonDrainTimeout
 		}
+		upgradeAgentNode.drainOptions = ku.getDrainOptions()
 
 		agentVMs := make(map[int]*vmInfo)
 		// Go over upgraded VMs and verify provisioning state
@@ -370,6 +379,7 @@ func (ku *Upgrader) upgradeAgentPools(ctx context.Context) error {
 				return err
 			}
 
+			ku.checkpoint(*agentPool.Name, vmName, VMUpgraded)
 			newCreatedVMs = append(newCreatedVMs, vmName)
 			agentVMs[agentIndex] = &vmInfo{vmName, vmStatusUpgraded}
 			upgradedCount++
@@ -406,6 +416,7 @@ func (ku *Upgrader) upgradeAgentPools(ctx context.Context) error {
 				}
 			}
 
+			ku.checkpoint(*agentPool.Name, vm.name, VMUpgrading)
 			err := upgradeAgentNode.DeleteNode(&vm.name, true)
 			if err != nil {
 				ku.logger.Errorf("Error deleting agent VM %s: %v", vm.name, err)
@@ -421,6 +432,7 @@ func (ku *Upgrader) upgradeAgentPools(ctx context.Context) error {
 			// do not create last node in favor of already created extra node.
 			if upgradedCount == toBeUpgradedCount-1 {
 				ku.logger.Infof("Skipping creation of VM %s (index %d)", vmName, agentIndex)
+				ku.checkpoint(*agentPool.Name, vm.name, VMUpgraded)
 				delete(agentVMs, agentIndex)
 			} else {
 				err = upgradeAgentNode.CreateNode(ctx, *agentPool.Name, agentIndex)
@@ -434,6 +446,7 @@ func (ku *Upgrader) upgradeAgentPools(ctx context.Context) error {
 					ku.logger.Errorf("Error validating upgraded agent VM %s: %v", vmName, err)
 					return err
 				}
+				ku.checkpoint(*agentPool.Name, vmName, VMUpgraded)
 				newCreatedVMs = append(newCreatedVMs, vmName)
 				vm.status = vmStatusUpgraded
 			}
@@ -502,6 +515,23 @@ func (ku *Upgrader) upgradeAgentScaleSets(ctx context.Context) error {
 			continue
 		}
 
+		poolName := scaleSetPoolName(vmssToUpgrade)
+		// copy custom properties from old node to new node if the PreserveNodesProperties in AgentPoolProfile is not set to false explicitly.
+		preserveNodesProperties := api.DefaultPreserveNodesProperties
+		if agentPool, ok := agentPoolMap[poolName]; ok {
+			if agentPool != nil && agentPool.PreserveNodesProperties != nil {
+				preserveNodesProperties = *agentPool.PreserveNodesProperties
+			}
+		}
+
+		if ku.surgeOptions != nil {
+			if err := ku.surgeUpgradeScaleSet(ctx, vmssToUpgrade, preserveNodesProperties); err != nil {
+				return err
+			}
+			ku.logger.Infof("Completed upgrading VMSS %s", vmssToUpgrade.Name)
+			continue
+		}
+
 		newCapacity := *vmssToUpgrade.Sku.Capacity + 1
 		ku.logger.Infof(
 			"VMSS %s current capacity is %d and new capacity will be %d while each node is swapped",
@@ -540,12 +570,14 @@ func (ku *Upgrader) upgradeAgentScaleSets(ctx context.Context) error {
 				return err
 			}
 
+			ku.checkpoint(poolName, vmToUpgrade.Name, VMUpgrading)
 			ku.logger.Infof("Draining node %s", vmToUpgrade.Name)
-			err = operations.SafelyDrainNodeWithClient(
+			err = operations.DrainNodeWithClient(
 				client,
 				ku.logger,
 				strings.ToLower(vmToUpgrade.Name),
 				cordonDrainTimeout,
+				ku.getDrainOptions(),
 			)
 			if err != nil {
 				ku.logger.Errorf("Error draining VM in VMSS: %v", err)
@@ -558,20 +590,6 @@ func (ku *Upgrader) upgradeAgentScaleSets(ctx context.Context) error {
 				vmssToUpgrade.Name,
 			)
 
-			// copy custom properties from old node to new node if the PreserveNodesProperties in AgentPoolProfile is not set to false explicitly.
-			preserveNodesProperties := api.DefaultPreserveNodesProperties
-			var poolName string
-			if vmssToUpgrade.IsWindows {
-				poolName, _ = utils.WindowsVmssNameParts(vmssToUpgrade.Name)
-			} else {
-				poolName, _, _ = utils.VmssNameParts(vmssToUpgrade.Name)
-			}
-			if agentPool, ok := agentPoolMap[poolName]; ok {
-				if agentPool != nil && agentPool.PreserveNodesProperties != nil {
-					preserveNodesProperties = *agentPool.PreserveNodesProperties
-				}
-			}
-
 			if preserveNodesProperties {
 				newNodeName, err := ku.getLastVMNameInVMSS(ctx, ku.ClusterTopology.ResourceGroup, vmssToUpgrade.Name)
 				if err != nil {
@@ -603,6 +621,7 @@ func (ku *Upgrader) upgradeAgentScaleSets(ctx context.Context) error {
 				"Successfully deleted VM %s in VMSS %s",
 				vmToUpgrade.Name,
 				vmssToUpgrade.Name)
+			ku.checkpoint(poolName, vmToUpgrade.Name, VMUpgraded)
 		}
 		ku.logger.Infof("Completed upgrading VMSS %s", vmssToUpgrade.Name)
 	}
@@ -612,6 +631,31 @@ func (ku *Upgrader) upgradeAgentScaleSets(ctx context.Context) error {
 	return nil
 }
 
+// scaleSetPoolName returns the name of the agent pool of a scale set
+func scaleSetPoolName(vmss AgentPoolScaleSet) string {
+	if vmss.IsWindows {
+		poolName, _ := utils.WindowsVmssNameParts(vmss.Name)
+		return poolName
+	}
+	poolName, _, _ := utils.VmssNameParts(vmss.Name)
+	return poolName
+}
+
+// getDrainOptions returns how the nodes are drained
+func (ku *Upgrader) getDrainOptions() operations.DrainOptions {
+	if ku.drainOptions == nil {
+		return operations.DefaultDrainOptions()
+	}
+	return *ku.drainOptions
+}
+
+// checkpoint records the upgrade state of a VM, the upgrade goes on if the checkpoint cannot be saved
+func (ku *Upgrader) checkpoint(pool, vm string, state VMUpgradeState) {
+	if err := ku.ClusterTopology.Checkpoint.SetState(pool, vm, state); err != nil {
+		ku.logger.Warnf("Failed to save the upgrade checkpoint of VM %s: %v", vm, err)
+	}
+}
+
 func (ku *Upgrader) generateUpgradeTemplate(upgradeContainerService *api.ContainerService, aksEngineVersion string) (map[string]interface{}, map[string]interface{}, error) {
 	var err error
 	ctx := engine.Context{
@@ -0,0 +1,342 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package reconcile plans the reconciliation of a cluster deployed by AKS Engine with its api model: the ARM
+// resources of the template generated from the api model an incremental deployment can apply to the cluster, the
+// changes it cannot apply, and the addon manifests to re-apply on the masters.
+package reconcile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/engine"
+	"github.com/Azure/aks-engine/pkg/operations/diff"
+)
+
+const (
+	vmResourceType          = "Microsoft.Compute/virtualMachines"
+	vmExtensionResourceType = "Microsoft.Compute/virtualMachines/extensions"
+	vmssResourceType        = "Microsoft.Compute/virtualMachineScaleSets"
+
+	// vmssOSProfilePath is the path of the OS profile of the VMs of a scale set, which cannot be changed once deployed
+	vmssOSProfilePath = "properties.virtualMachineProfile.osProfile"
+
+	// expansionRounds is how many levels of variables are expanded to match the dependencies of the resources
+	expansionRounds = 5
+)
+
+var (
+	// referenceRegexp matches the references of template expressions to variables and parameters
+	referenceRegexp = regexp.MustCompile(`(variables|parameters)\('([^']+)'\)`)
+	// placeholderRegexp matches the placeholders of the addon manifests completed on the masters when they are
+	// provisioned, e.g. the service principal of the cluster autoscaler
+	placeholderRegexp = regexp.MustCompile(`<[a-zA-Z][a-zA-Z0-9]*>`)
+)
+
+// Skipped is a change the reconciliation does not apply, with the reason why
+type Skipped struct {
+	Type   string    `json:"type"`
+	Name   string    `json:"name"`
+	Kind   diff.Kind `json:"kind"`
+	Reason string    `json:"reason"`
+}
+
+// Plan is the reconciliation of a cluster with its api model
+type Plan struct {
+	// Template deploys the resources the reconciliation applies, with the parameters of the desired template
+	Template map[string]interface{} `json:"-"`
+	// Resources are the added and changed resources the template deploys. The changes of a resource referencing a
+	// changed variable or parameter are the references, e.g. variables('nameSuffix').
+	Resources []diff.ResourceChange `json:"resources,omitempty"`
+	// Addons are the addon manifests that differ from the manifests on the masters
+	Addons []engine.AddonManifest `json:"addons,omitempty"`
+	// Skipped are the changes the reconciliation does not apply
+	Skipped []Skipped `json:"skipped,omitempty"`
+	// UncheckedAddons are the addons whose manifest is completed on the masters, which cannot be compared
+	UncheckedAddons []string `json:"uncheckedAddons,omitempty"`
+}
+
+// NewPlan plans the deployment of the resources of the desired template that differ from the deployed template,
+// either by their definition or by the values of the variables and parameters they reference. The parameters are
+// the values of the parameters files of the templates.
+func NewPlan(deployed, desired, deployedParameters, desiredParameters map[string]interface{}) *Plan {
+	p := &Plan{}
+	report := diff.Templates(deployed, desired)
+	changes := map[string]diff.ResourceChange{}
+	for _, resource := range report.Resources {
+		switch resource.Kind {
+		case diff.Removed:
+			p.Skipped = append(p.Skipped, Skipped{
+				Type:   resource.Type,
+				Name:   resource.Name,
+				Kind:   resource.Kind,
+				Reason: "an incremental deployment does not delete resources",
+			})
+		default:
+			changes[resource.Type+"/"+resource.Name] = resource
+		}
+	}
+	changed := changedReferences(deployed, desired, deployedParameters, desiredParameters)
+
+	var applied []map[string]interface{}
+	resources, _ := desired["resources"].([]interface{})
+	for _, item := range resources {
+		resource, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		resourceType, name := fmt.Sprint(resource["type"]), fmt.Sprint(resource["name"])
+		change, ok := changes[resourceType+"/"+name]
+		if !ok {
+			refs := references(resource, changed)
+			if len(refs) == 0 {
+				continue
+			}
+			change = diff.ResourceChange{Type: resourceType, Name: name, Kind: diff.Changed}
+			for _, ref := range refs {
+				change.Changes = append(change.Changes, diff.Change{Path: ref, Kind: diff.Changed})
+			}
+		}
+		if reason := skipReason(resource, change, changed); reason != "" {
+			p.Skipped = append(p.Skipped, Skipped{Type: resourceType, Name: name, Kind: change.Kind, Reason: reason})
+			continue
+		}
+		p.Resources = append(p.Resources, change)
+		applied = append(applied, resource)
+	}
+	p.Template = deltaTemplate(desired, applied)
+	return p
+}
+
+// PlanAddons plans the addons to re-apply on the masters, those whose manifest differs from the manifest current
+// returns for their destination file, which is empty if the addon is not on the masters
+func (p *Plan) PlanAddons(addons []engine.AddonManifest, current func(destinationFile string) (string, error)) error {
+	for _, addon := range addons {
+		if placeholderRegexp.MatchString(addon.Manifest) {
+			p.UncheckedAddons = append(p.UncheckedAddons, addon.Name)
+			continue
+		}
+		manifest, err := current(addon.DestinationFile)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(manifest) != strings.TrimSpace(addon.Manifest) {
+			p.Addons = append(p.Addons, addon)
+		}
+	}
+	return nil
+}
+
+// Empty returns true if the cluster is up to date with the api model
+func (p *Plan) Empty() bool {
+	return len(p.Resources) == 0 && len(p.Addons) == 0 && len(p.Skipped) == 0
+}
+
+// WriteText writes the plan in a human readable format, prefixing added resources with + and changed resources
+// with ~
+func (p *Plan) WriteText(w io.Writer) error {
+	if p.Empty() {
+		_, err := fmt.Fprintln(w, "The cluster is up to date with the api model")
+		return err
+	}
+	if len(p.Resources) > 0 {
+		fmt.Fprintln(w, "ARM resources to deploy:")
+		for _, resource := range p.Resources {
+			symbol := "~"
+			if resource.Kind == diff.Added {
+				symbol = "+"
+			}
+			fmt.Fprintf(w, "  %s %s %s\n", symbol, resource.Type, resource.Name)
+			for _, c := range resource.Changes {
+				fmt.Fprintf(w, "      %s\n", c.Path)
+			}
+		}
+	}
+	if len(p.Addons) > 0 {
+		fmt.Fprintln(w, "Addons to re-apply:")
+		for _, addon := range p.Addons {
+			fmt.Fprintf(w, "  ~ %s (%s)\n", addon.Name, addon.DestinationFile)
+		}
+	}
+	if len(p.Skipped) > 0 {
+		fmt.Fprintln(w, "Changes not applied:")
+		for _, s := range p.Skipped {
+			fmt.Fprintf(w, "  %s %s: %s\n", s.Type, s.Name, s.Reason)
+		}
+	}
+	if len(p.UncheckedAddons) > 0 {
+		fmt.Fprintf(w, "Addons not checked, their manifest is completed on the masters: %s\n", strings.Join(p.UncheckedAddons, ", "))
+	}
+	return nil
+}
+
+// skipReason returns why a change of a resource cannot be applied by an incremental deployment, or "" if it can
+func skipReason(resource map[string]interface{}, change diff.ResourceChange, changed map[string]bool) string {
+	resourceType := fmt.Sprint(resource["type"])
+	switch {
+	case resource["copy"] != nil:
+		return "the resource is deployed for each VM, aks-engine scale and upgrade apply it when they provision the VMs"
+	case resourceType == vmResourceType || resourceType == vmExtensionResourceType:
+		if change.Kind == diff.Added {
+			return "add VMs with aks-engine scale"
+		}
+		return "aks-engine upgrade applies it when it provisions the VMs again"
+	case resourceType == vmssResourceType && change.Kind == diff.Changed && osProfileChanged(resource, change, changed):
+		return "the OS profile of a scale set cannot be changed, upgrade the pool with aks-engine upgrade"
+	}
+	return ""
+}
+
+// osProfileChanged returns true if the OS profile of a scale set changes, e.g. its custom data
+func osProfileChanged(resource map[string]interface{}, change diff.ResourceChange, changed map[string]bool) bool {
+	for _, c := range change.Changes {
+		if strings.HasPrefix(c.Path, vmssOSProfilePath) {
+			return true
+		}
+	}
+	properties, _ := resource["properties"].(map[string]interface{})
+	profile, _ := properties["virtualMachineProfile"].(map[string]interface{})
+	return len(references(profile["osProfile"], changed)) > 0
+}
+
+// changedReferences returns the references to the parameters and variables whose value changes, including the
+// variables referencing them
+func changedReferences(deployed, desired, deployedParameters, desiredParameters map[string]interface{}) map[string]bool {
+	changed := map[string]bool{}
+	oldParameters, _ := deployed["parameters"].(map[string]interface{})
+	newParameters, _ := desired["parameters"].(map[string]interface{})
+	for name, definition := range newParameters {
+		if !reflect.DeepEqual(parameterValue(oldParameters[name], deployedParameters[name]), parameterValue(definition, desiredParameters[name])) {
+			changed[fmt.Sprintf("parameters('%s')", name)] = true
+		}
+	}
+	oldVariables, _ := deployed["variables"].(map[string]interface{})
+	newVariables, _ := desired["variables"].(map[string]interface{})
+	for name, value := range newVariables {
+		if !reflect.DeepEqual(oldVariables[name], value) {
+			changed[fmt.Sprintf("variables('%s')", name)] = true
+		}
+	}
+	for found := true; found; {
+		found = false
+		for name, value := range newVariables {
+			ref := fmt.Sprintf("variables('%s')", name)
+			if !changed[ref] && len(references(value, changed)) > 0 {
+				changed[ref] = true
+				found = true
+			}
+		}
+	}
+	return changed
+}
+
+// parameterValue returns the value of a parameter in the parameters file, or its default value
+func parameterValue(definition, value interface{}) interface{} {
+	if v, ok := value.(map[string]interface{}); ok {
+		if v, ok := v["value"]; ok {
+			return v
+		}
+	}
+	if d, ok := definition.(map[string]interface{}); ok {
+		return d["defaultValue"]
+	}
+	return nil
+}
+
+// references returns the references of a JSON value among refs, in sorted order
+func references(value interface{}, refs map[string]bool) []string {
+	if value == nil || len(refs) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	found := map[string]bool{}
+	for _, ref := range referenceRegexp.FindAllString(string(b), -1) {
+		if refs[ref] {
+			found[ref] = true
+		}
+	}
+	var result []string
+	for ref := range found {
+		result = append(result, ref)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// deltaTemplate returns the desired template deploying the resources only, with the dependencies on the resources
+// not deployed removed. Its outputs are removed as they reference resources which are not deployed.
+func deltaTemplate(desired map[string]interface{}, resources []map[string]interface{}) map[string]interface{} {
+	template := map[string]interface{}{}
+	for key, value := range desired {
+		template[key] = value
+	}
+	template["outputs"] = map[string]interface{}{}
+
+	variables, _ := desired["variables"].(map[string]interface{})
+	type deployed struct {
+		resourceType string
+		name         string
+	}
+	var names []deployed
+	for _, resource := range resources {
+		names = append(names, deployed{fmt.Sprint(resource["type"]), expand(fmt.Sprint(resource["name"]), variables)})
+	}
+	isDeployed := func(dependency string) bool {
+		dependency = expand(dependency, variables)
+		for _, n := range names {
+			if strings.Contains(dependency, n.resourceType) && strings.Contains(dependency, n.name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	list := make([]interface{}, 0, len(resources))
+	for _, resource := range resources {
+		r := map[string]interface{}{}
+		for key, value := range resource {
+			r[key] = value
+		}
+		if dependencies, ok := resource["dependsOn"].([]interface{}); ok {
+			var kept []interface{}
+			for _, dependency := range dependencies {
+				if isDeployed(fmt.Sprint(dependency)) {
+					kept = append(kept, dependency)
+				}
+			}
+			if len(kept) > 0 {
+				r["dependsOn"] = kept
+			} else {
+				delete(r, "dependsOn")
+			}
+		}
+		list = append(list, r)
+	}
+	template["resources"] = list
+	return template
+}
+
+// expand replaces the references of an expression to string variables with their expression
+func expand(expression string, variables map[string]interface{}) string {
+	expression = strings.TrimSuffix(strings.TrimPrefix(expression, "["), "]")
+	for i := 0; i < expansionRounds && strings.Contains(expression, "variables('"); i++ {
+		expression = referenceRegexp.ReplaceAllStringFunc(expression, func(ref string) string {
+			match := referenceRegexp.FindStringSubmatch(ref)
+			value, ok := variables[match[2]].(string)
+			if match[1] != "variables" || !ok {
+				return ref
+			}
+			return strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+		})
+	}
+	return expression
+}
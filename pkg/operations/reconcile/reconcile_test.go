@@ -0,0 +1,238 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package reconcile
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/engine"
+	"github.com/Azure/aks-engine/pkg/operations/diff"
+	"github.com/pkg/errors"
+)
+
+const deployedTemplate = `{
+  "parameters": {
+    "kubernetesVersion": {"type": "string"},
+    "location": {"type": "string", "defaultValue": "westus2"}
+  },
+  "variables": {
+    "availabilitySetName": "[concat('agentpool1-availabilitySet-', variables('nameSuffix'))]",
+    "customData": "[concat('#cloud-config ', parameters('kubernetesVersion'))]",
+    "nameSuffix": "12345678",
+    "vnetName": "k8s-vnet"
+  },
+  "resources": [
+    {
+      "type": "Microsoft.Network/virtualNetworks",
+      "name": "[variables('vnetName')]",
+      "properties": {"addressSpace": "10.0.0.0/8"}
+    },
+    {
+      "type": "Microsoft.Compute/availabilitySets",
+      "name": "[variables('availabilitySetName')]",
+      "properties": {"platformFaultDomainCount": 2}
+    },
+    {
+      "type": "Microsoft.Compute/virtualMachines",
+      "name": "[concat('k8s-agentpool1-', copyIndex())]",
+      "copy": {"count": 2, "name": "vmLoopNode"},
+      "properties": {"osProfile": {"customData": "[variables('customData')]"}}
+    },
+    {
+      "type": "Microsoft.Compute/virtualMachineScaleSets",
+      "name": "k8s-agentpool2-vmss",
+      "dependsOn": ["[concat('Microsoft.Network/virtualNetworks/', variables('vnetName'))]"],
+      "properties": {"virtualMachineProfile": {"osProfile": {"customData": "[variables('customData')]"}}}
+    },
+    {
+      "type": "Microsoft.Network/publicIPAddresses",
+      "name": "jumpbox-ip",
+      "properties": {}
+    }
+  ],
+  "outputs": {
+    "vnetName": {"type": "string", "value": "[variables('vnetName')]"}
+  }
+}`
+
+func templates(t *testing.T, edit func(map[string]interface{})) (map[string]interface{}, map[string]interface{}) {
+	var deployed, desired map[string]interface{}
+	if err := json.Unmarshal([]byte(deployedTemplate), &deployed); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(deployedTemplate), &desired); err != nil {
+		t.Fatal(err)
+	}
+	edit(desired)
+	return deployed, desired
+}
+
+func parameters(version string) map[string]interface{} {
+	return map[string]interface{}{"kubernetesVersion": map[string]interface{}{"value": version}}
+}
+
+func TestNewPlanUpToDate(t *testing.T) {
+	deployed, desired := templates(t, func(map[string]interface{}) {})
+	p := NewPlan(deployed, desired, parameters("1.15.7"), parameters("1.15.7"))
+	if !p.Empty() {
+		t.Fatalf("expected an empty plan for identical templates, but instead got %+v", p)
+	}
+	var b bytes.Buffer
+	if err := p.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "The cluster is up to date with the api model\n" {
+		t.Fatalf("unexpected text for an empty plan: %s", b.String())
+	}
+	if resources := p.Template["resources"].([]interface{}); len(resources) != 0 {
+		t.Fatalf("expected the template of an empty plan to deploy no resources, but instead got %d", len(resources))
+	}
+}
+
+func TestNewPlan(t *testing.T) {
+	deployed, desired := templates(t, func(desired map[string]interface{}) {
+		desired["variables"].(map[string]interface{})["nameSuffix"] = "87654321"
+		resources := desired["resources"].([]interface{})
+		// the jumpbox public IP is removed and a network security group depending on the availability set and the
+		// virtual network is added
+		desired["resources"] = append(resources[:4], map[string]interface{}{
+			"type": "Microsoft.Network/networkSecurityGroups",
+			"name": "k8s-nsg",
+			"dependsOn": []interface{}{
+				"[concat('Microsoft.Compute/availabilitySets/', variables('availabilitySetName'))]",
+				"[concat('Microsoft.Network/virtualNetworks/', variables('vnetName'))]",
+			},
+			"properties": map[string]interface{}{},
+		})
+	})
+	// the location parameter keeps its default value
+	deployedParameters := parameters("1.15.7")
+	desiredParameters := parameters("1.16.4")
+	desiredParameters["location"] = map[string]interface{}{"value": "westus2"}
+	p := NewPlan(deployed, desired, deployedParameters, desiredParameters)
+
+	var applied []string
+	for _, r := range p.Resources {
+		applied = append(applied, string(r.Kind)+" "+r.Name)
+	}
+	expected := []string{"changed [variables('availabilitySetName')]", "added k8s-nsg"}
+	if !reflect.DeepEqual(applied, expected) {
+		t.Fatalf("expected the plan to deploy %v, but instead got %v", expected, applied)
+	}
+	if changes := p.Resources[0].Changes; len(changes) != 1 || changes[0].Path != "variables('availabilitySetName')" {
+		t.Fatalf("expected the availability set to change by its name variable, but instead got %v", changes)
+	}
+
+	skipped := map[string]string{}
+	for _, s := range p.Skipped {
+		skipped[s.Name] = s.Reason
+	}
+	for name, reason := range map[string]string{
+		"jumpbox-ip": "does not delete",
+		"[concat('k8s-agentpool1-', copyIndex())]": "deployed for each VM",
+		"k8s-agentpool2-vmss":                      "OS profile of a scale set",
+	} {
+		if !strings.Contains(skipped[name], reason) {
+			t.Fatalf("expected the change of %s to be skipped as %q, but instead got %q", name, reason, skipped[name])
+		}
+	}
+	if _, ok := skipped["[variables('vnetName')]"]; ok || len(p.Skipped) != 3 {
+		t.Fatalf("expected 3 skipped changes, but instead got %v", p.Skipped)
+	}
+
+	resources := p.Template["resources"].([]interface{})
+	if len(resources) != 2 {
+		t.Fatalf("expected the template to deploy 2 resources, but instead got %d", len(resources))
+	}
+	dependencies := resources[1].(map[string]interface{})["dependsOn"].([]interface{})
+	if len(dependencies) != 1 || !strings.Contains(dependencies[0].(string), "availabilitySets") {
+		t.Fatalf("expected the dependency on the virtual network which is not deployed to be removed, but instead got %v", dependencies)
+	}
+	if outputs := p.Template["outputs"].(map[string]interface{}); len(outputs) != 0 {
+		t.Fatalf("expected the template to have no outputs, but instead got %v", outputs)
+	}
+	if len(desired["resources"].([]interface{})[4].(map[string]interface{})["dependsOn"].([]interface{})) != 2 {
+		t.Fatalf("expected the desired template not to be modified")
+	}
+
+	var b bytes.Buffer
+	if err := p.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"  ~ Microsoft.Compute/availabilitySets [variables('availabilitySetName')]",
+		"  + Microsoft.Network/networkSecurityGroups k8s-nsg",
+		"  Microsoft.Network/publicIPAddresses jumpbox-ip: an incremental deployment does not delete resources",
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Fatalf("expected the text of the plan to have line %q, but instead got:\n%s", line, b.String())
+		}
+	}
+}
+
+func TestChangedReferences(t *testing.T) {
+	deployed, desired := templates(t, func(map[string]interface{}) {})
+	changed := changedReferences(deployed, desired, parameters("1.15.7"), parameters("1.16.4"))
+	expected := map[string]bool{"parameters('kubernetesVersion')": true, "variables('customData')": true}
+	if !reflect.DeepEqual(changed, expected) {
+		t.Fatalf("expected the changed references %v, but instead got %v", expected, changed)
+	}
+}
+
+func TestPlanAddons(t *testing.T) {
+	addons := []engine.AddonManifest{
+		{Name: "coredns", DestinationFile: "coredns.yaml", Manifest: "kind: Deployment\n"},
+		{Name: "metrics-server", DestinationFile: "metrics-server.yaml", Manifest: "kind: Deployment\n"},
+		{Name: "cluster-autoscaler", DestinationFile: "cluster-autoscaler.yaml", Manifest: "clientID: <clientID>\n"},
+		{Name: "blobfuse-flexvolume", DestinationFile: "blobfuse-flexvolume.yaml", Manifest: "kind: DaemonSet\n"},
+	}
+	current := map[string]string{
+		"coredns.yaml":        "kind: Deployment",
+		"metrics-server.yaml": "kind: DaemonSet\n",
+	}
+	p := &Plan{}
+	err := p.PlanAddons(addons, func(destinationFile string) (string, error) {
+		return current[destinationFile], nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error planning the addons: %s", err)
+	}
+	var names []string
+	for _, addon := range p.Addons {
+		names = append(names, addon.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"metrics-server", "blobfuse-flexvolume"}) {
+		t.Fatalf("expected the changed and missing addons to be re-applied, but instead got %v", names)
+	}
+	if !reflect.DeepEqual(p.UncheckedAddons, []string{"cluster-autoscaler"}) {
+		t.Fatalf("expected the addons with placeholders not to be checked, but instead got %v", p.UncheckedAddons)
+	}
+
+	err = (&Plan{}).PlanAddons(addons, func(string) (string, error) { return "", errors.New("ssh failure") })
+	if err == nil || err.Error() != "ssh failure" {
+		t.Fatalf("expected the error reading the manifests on the masters, but instead got %v", err)
+	}
+}
+
+func TestSkipReason(t *testing.T) {
+	cases := []struct {
+		resource map[string]interface{}
+		kind     diff.Kind
+		expected string
+	}{
+		{resource: map[string]interface{}{"type": vmResourceType}, kind: diff.Added, expected: "add VMs with aks-engine scale"},
+		{resource: map[string]interface{}{"type": vmExtensionResourceType}, kind: diff.Changed, expected: "aks-engine upgrade applies it when it provisions the VMs again"},
+		{resource: map[string]interface{}{"type": vmssResourceType}, kind: diff.Added},
+		{resource: map[string]interface{}{"type": "Microsoft.Network/loadBalancers"}, kind: diff.Changed},
+	}
+	for _, c := range cases {
+		if reason := skipReason(c.resource, diff.ResourceChange{Kind: c.kind}, nil); reason != c.expected {
+			t.Fatalf("expected the change of %v to be skipped as %q, but instead got %q", c.resource, c.expected, reason)
+		}
+	}
+}
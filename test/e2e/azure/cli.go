@@ -26,7 +26,9 @@ type Storage interface {
 	SetConnectionString() error
 	CreateFileShare(name string) error
 	UploadFiles(source, destination string) error
+	UploadFilesToPath(source, destination, path string) error
 	DownloadFiles(source, destination string) error
+	DownloadBlobs(container, destination string) error
 	DeleteFiles(source string) error
 }
 
@@ -189,7 +191,7 @@ func (a *Account) DeleteGroup(name string, wait bool) error {
 }
 
 // CreateDeployment will deploy a cluster to a given resource group using the template and parameters on disk
-func (a *Account) CreateDeployment(name string, e *engine.Engine) error {
+func (a *Account) CreateDeployment(ctx context.Context, name string, e *engine.Engine) error {
 	d := Deployment{
 		Name:              name,
 		TemplateDirectory: e.Config.GeneratedDefinitionPath,
@@ -210,7 +212,7 @@ func (a *Account) CreateDeployment(name string, e *engine.Engine) error {
 		}
 	}()
 
-	cmd := exec.Command("az", "group", "deployment", "create",
+	cmd := exec.CommandContext(ctx, "az", "group", "deployment", "create",
 		"--name", d.Name,
 		"--resource-group", a.ResourceGroup.Name,
 		"--template-file", e.Config.GeneratedTemplatePath,
@@ -481,6 +483,61 @@ func (a *Account) FetchActivityLog(rg string) (string, error) {
 	return string(out), nil
 }
 
+// QueryLogAnalytics runs a Kusto query against a Log Analytics workspace and returns the rows it found as JSON
+func (a *Account) QueryLogAnalytics(workspaceID, query string) (string, error) {
+	cmd := exec.Command("az", "monitor", "log-analytics", "query", "--workspace", workspaceID, "--analytics-query", query, "-o", "json")
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error while trying to query log analytics workspace %s:%s\n", workspaceID, out)
+		return "", err
+	}
+	return string(out), nil
+}
+
+// CreateKeyVault creates a Key Vault in the resource group of the account, whose secrets the service principal of the account can get
+func (a *Account) CreateKeyVault(name string) error {
+	cmd := exec.Command("az", "keyvault", "create", "--name", name, "--resource-group", a.ResourceGroup.Name, "--location", a.ResourceGroup.Location)
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error while trying to create key vault %s in resource group %s:%s\n", name, a.ResourceGroup.Name, out)
+		return err
+	}
+	cmd = exec.Command("az", "keyvault", "set-policy", "--name", name, "--spn", a.User.ID, "--secret-permissions", "get")
+	util.PrintCommand(cmd)
+	out, err = cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error while trying to give %s access to the secrets of key vault %s:%s\n", a.User.ID, name, out)
+		return err
+	}
+	return nil
+}
+
+// SetKeyVaultSecret sets the value of a secret of a Key Vault
+func (a *Account) SetKeyVaultSecret(vault, name, value string) error {
+	cmd := exec.Command("az", "keyvault", "secret", "set", "--vault-name", vault, "--name", name, "--value", value)
+	// the command is not printed, it has the value of the secret
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error while trying to set secret %s of key vault %s:%s\n", name, vault, out)
+		return err
+	}
+	return nil
+}
+
+// DeleteKeyVault deletes a Key Vault of the resource group of the account
+func (a *Account) DeleteKeyVault(name string) error {
+	cmd := exec.Command("az", "keyvault", "delete", "--name", name, "--resource-group", a.ResourceGroup.Name)
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error while trying to delete key vault %s:%s\n", name, out)
+		return err
+	}
+	return nil
+}
+
 // CreateStorageAccount will create a new Azure Storage Account
 func (sa *StorageAccount) CreateStorageAccount() error {
 	var cmd *exec.Cmd
@@ -521,6 +578,55 @@ func (sa *StorageAccount) SetConnectionString() error {
 	return nil
 }
 
+// DeleteStorageAccount deletes the storage account
+func (sa *StorageAccount) DeleteStorageAccount() error {
+	cmd := exec.Command("az", "storage", "account", "delete", "--name", sa.Name, "--resource-group", sa.ResourceGroup.Name, "--yes")
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error while trying to delete storage account %s:%s\n", sa.Name, out)
+		return err
+	}
+	return nil
+}
+
+// CreateQueue creates a queue in the storage account
+func (sa *StorageAccount) CreateQueue(name string) error {
+	cmd := exec.Command("az", "storage", "queue", "create", "--name", name, "--account-name", sa.Name, "--connection-string", sa.ConnectionString)
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error while trying to create queue %s in storage account %s:%s\n", name, sa.Name, out)
+		return err
+	}
+	return nil
+}
+
+// PutQueueMessages puts count messages in a queue of the storage account
+func (sa *StorageAccount) PutQueueMessages(queue string, count int) error {
+	for i := 0; i < count; i++ {
+		cmd := exec.Command("az", "storage", "message", "put", "--queue-name", queue, "--content", fmt.Sprintf("message-%d", i), "--account-name", sa.Name, "--connection-string", sa.ConnectionString)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			log.Printf("Error while trying to put a message in queue %s of storage account %s:%s\n", queue, sa.Name, out)
+			return err
+		}
+	}
+	return nil
+}
+
+// ClearQueue deletes all the messages of a queue of the storage account
+func (sa *StorageAccount) ClearQueue(queue string) error {
+	cmd := exec.Command("az", "storage", "message", "clear", "--queue-name", queue, "--account-name", sa.Name, "--connection-string", sa.ConnectionString)
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error while trying to clear queue %s of storage account %s:%s\n", queue, sa.Name, out)
+		return err
+	}
+	return nil
+}
+
 // CreateFileShare will create a file share in a storage account if it doesn't already exist
 func (sa *StorageAccount) CreateFileShare(name string) error {
 	var cmd *exec.Cmd
@@ -550,6 +656,18 @@ func (sa *StorageAccount) UploadFiles(source, destination string) error {
 	return nil
 }
 
+// UploadFilesToPath will upload a directory to a path of a file share of storage
+func (sa *StorageAccount) UploadFilesToPath(source, destination, path string) error {
+	cmd := exec.Command("az", "storage", "file", "upload-batch", "--destination", destination, "--destination-path", path, "--source", source, "--account-name", sa.Name, "--connection-string", sa.ConnectionString)
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error while trying upload files to %s of file share %s:%s\n", path, destination, out)
+		return err
+	}
+	return nil
+}
+
 // DownloadFiles will download the output directory from storage
 func (sa *StorageAccount) DownloadFiles(source, destination string) error {
 	cmd := exec.Command("az", "storage", "file", "download-batch", "--destination", destination, "--source", source, "--account-name", sa.Name, "--connection-string", sa.ConnectionString)
@@ -562,6 +680,18 @@ func (sa *StorageAccount) DownloadFiles(source, destination string) error {
 	return nil
 }
 
+// DownloadBlobs will download the blobs of a container of storage
+func (sa *StorageAccount) DownloadBlobs(container, destination string) error {
+	cmd := exec.Command("az", "storage", "blob", "download-batch", "--destination", destination, "--source", container, "--account-name", sa.Name, "--connection-string", sa.ConnectionString)
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error while trying download blobs from container %s in storage account %s: %s\n", container, sa.Name, out)
+		return err
+	}
+	return nil
+}
+
 // DeleteFiles deletes files from an Azure storage file share
 func (sa *StorageAccount) DeleteFiles(source string) error {
 	cmd := exec.Command("az", "storage", "file", "delete-batch", "--source", source, "--account-name", sa.Name, "--connection-string", sa.ConnectionString)
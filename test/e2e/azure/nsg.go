@@ -0,0 +1,276 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
+	autorestazure "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/pkg/errors"
+)
+
+// restrictedInboundPorts are the management ports no inbound rule may open unless the cluster definition asks for it
+var restrictedInboundPorts = map[int]string{
+	22:   "SSH",
+	3389: "RDP",
+	5985: "WinRM",
+	5986: "WinRM over HTTPS",
+}
+
+// cloudProviderRuleRegex matches the names of the rules the Azure cloud provider manages for services of type LoadBalancer,
+// which are prefixed with the name of the load balancer frontend IP configuration of the service
+var cloudProviderRuleRegex = regexp.MustCompile(`^a[0-9a-f]{32}-`)
+
+// SecurityRuleExpectation is a network security group rule the cluster is expected to have
+type SecurityRuleExpectation struct {
+	Name                 string
+	Direction            network.SecurityRuleDirection
+	Access               network.SecurityRuleAccess
+	Protocol             network.SecurityRuleProtocol
+	DestinationPortRange string
+	SourceAddressPrefix  string
+}
+
+// SecurityRuleMatrix is the set of network security group rules expected in the resource group of a cluster
+type SecurityRuleMatrix struct {
+	// Groups are the expected rules of every network security group aks-engine creates, by network security group name
+	Groups map[string][]SecurityRuleExpectation
+	// AllowedInboundPorts are the restricted ports rules outside of Groups may open, e.g. WinRM when the winrm extension is enabled
+	AllowedInboundPorts []int
+}
+
+// ExpectedSecurityRules derives the network security group rules of a cluster from its expanded api model
+func ExpectedSecurityRules(cs *api.ContainerService) SecurityRuleMatrix {
+	p := cs.Properties
+	m := SecurityRuleMatrix{
+		Groups: map[string][]SecurityRuleExpectation{},
+	}
+
+	kubeTLSSource := "*"
+	if p.OrchestratorProfile.IsPrivateCluster() {
+		kubeTLSSource = "VirtualNetwork"
+	}
+	rules := []SecurityRuleExpectation{
+		inboundTCPRule("allow_ssh", "22-22", "*"),
+		inboundTCPRule("allow_kube_tls", "443-443", kubeTLSSource),
+	}
+	if p.HasWindows() {
+		rules = append(rules, inboundTCPRule("allow_rdp", "3389-3389", "*"))
+	}
+	if p.FeatureFlags.IsFeatureEnabled("BlockOutboundInternet") {
+		rules = append(rules,
+			SecurityRuleExpectation{Name: "allow_vnet", Direction: network.SecurityRuleDirectionOutbound, Access: network.SecurityRuleAccessAllow, Protocol: network.SecurityRuleProtocolAsterisk, DestinationPortRange: "*", SourceAddressPrefix: "VirtualNetwork"},
+			SecurityRuleExpectation{Name: "block_outbound", Direction: network.SecurityRuleDirectionOutbound, Access: network.SecurityRuleAccessDeny, Protocol: network.SecurityRuleProtocolAsterisk, DestinationPortRange: "*", SourceAddressPrefix: "*"})
+	}
+	if p.IsAzureStackCloud() {
+		rules = append(rules,
+			SecurityRuleExpectation{Name: "allow_vnet_inbound", Direction: network.SecurityRuleDirectionInbound, Access: network.SecurityRuleAccessAllow, Protocol: network.SecurityRuleProtocolAsterisk, DestinationPortRange: "*", SourceAddressPrefix: "10.0.0.0/8"},
+			SecurityRuleExpectation{Name: "allow_vnet_outbound", Direction: network.SecurityRuleDirectionOutbound, Access: network.SecurityRuleAccessAllow, Protocol: network.SecurityRuleProtocolAsterisk, DestinationPortRange: "*", SourceAddressPrefix: "10.0.0.0/8"})
+	}
+	m.Groups[p.GetMasterVMPrefix()+"nsg"] = rules
+
+	if k := p.OrchestratorProfile.KubernetesConfig; k.PrivateJumpboxProvision() {
+		m.Groups[k.PrivateCluster.JumpboxProfile.Name+"-nsg"] = []SecurityRuleExpectation{
+			inboundTCPRule("default-allow-ssh", "22", "*"),
+		}
+	}
+
+	for _, pool := range p.AgentPoolProfiles {
+		for _, extension := range pool.Extensions {
+			if extension.Name == "winrm" {
+				m.AllowedInboundPorts = []int{5985, 5986}
+			}
+		}
+	}
+	return m
+}
+
+func inboundTCPRule(name, portRange, source string) SecurityRuleExpectation {
+	return SecurityRuleExpectation{
+		Name:                 name,
+		Direction:            network.SecurityRuleDirectionInbound,
+		Access:               network.SecurityRuleAccessAllow,
+		Protocol:             network.SecurityRuleProtocolTCP,
+		DestinationPortRange: portRange,
+		SourceAddressPrefix:  source,
+	}
+}
+
+// NewARMClient returns an Azure Resource Manager client for the cloud of location, authenticated as the service principal of the account
+func (a *Account) NewARMClient(location string) (armhelpers.AKSEngineClient, error) {
+	env, err := autorestazure.EnvironmentFromName(helpers.GetCloudTargetEnv(location))
+	if err != nil {
+		return nil, err
+	}
+	return armhelpers.NewAzureClientWithClientSecret(env, a.SubscriptionID, a.User.ID, a.User.Secret)
+}
+
+// ValidateNetworkSecurityGroups fetches the network security groups in resourceGroup and validates them against
+// the rules ExpectedSecurityRules derives from cs
+func ValidateNetworkSecurityGroups(ctx context.Context, client armhelpers.AKSEngineClient, resourceGroup string, cs *api.ContainerService) error {
+	nsgs, err := client.ListNetworkSecurityGroups(ctx, resourceGroup)
+	if err != nil {
+		return errors.Wrapf(err, "listing the network security groups in resource group %s", resourceGroup)
+	}
+	return ValidateSecurityGroups(nsgs, ExpectedSecurityRules(cs))
+}
+
+// ValidateSecurityGroups returns an error listing every difference between the network security groups of a cluster and m:
+// missing groups or rules, rules that differ from their expectation, unexpected rules, and rules that open restricted ports
+func ValidateSecurityGroups(nsgs []network.SecurityGroup, m SecurityRuleMatrix) error {
+	var problems []string
+	found := map[string]bool{}
+	for _, nsg := range nsgs {
+		nsgName := to.String(nsg.Name)
+		expected, isManaged := m.Groups[nsgName]
+		found[nsgName] = isManaged
+		expectedByName := map[string]SecurityRuleExpectation{}
+		for _, e := range expected {
+			expectedByName[e.Name] = e
+		}
+
+		seen := map[string]bool{}
+		for _, rule := range securityRules(nsg) {
+			ruleName := to.String(rule.Name)
+			e, isExpected := expectedByName[ruleName]
+			switch {
+			case isExpected:
+				seen[ruleName] = true
+				problems = append(problems, compareSecurityRule(nsgName, rule, e)...)
+				continue
+			case isManaged && !cloudProviderRuleRegex.MatchString(ruleName):
+				problems = append(problems, fmt.Sprintf("NSG %s has unexpected rule %s", nsgName, ruleName))
+			}
+			if port, opens := opensRestrictedPort(rule, m.AllowedInboundPorts); opens {
+				problems = append(problems, fmt.Sprintf("rule %s in NSG %s opens %s (port %d) to %s", ruleName, nsgName, restrictedInboundPorts[port], port, sourceAddressPrefixes(rule)))
+			}
+		}
+		for _, e := range expected {
+			if !seen[e.Name] {
+				problems = append(problems, fmt.Sprintf("NSG %s is missing rule %s", nsgName, e.Name))
+			}
+		}
+	}
+	for nsgName := range m.Groups {
+		if !found[nsgName] {
+			problems = append(problems, fmt.Sprintf("NSG %s not found", nsgName))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.Errorf("network security groups do not match the expected rules: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// compareSecurityRule returns the differences between rule and its expectation
+func compareSecurityRule(nsgName string, rule network.SecurityRule, e SecurityRuleExpectation) []string {
+	var problems []string
+	compare := func(field, expected, actual string) {
+		if !strings.EqualFold(expected, actual) {
+			problems = append(problems, fmt.Sprintf("rule %s in NSG %s has %s '%s', expected '%s'", e.Name, nsgName, field, actual, expected))
+		}
+	}
+	compare("direction", string(e.Direction), string(rule.Direction))
+	compare("access", string(e.Access), string(rule.Access))
+	compare("protocol", string(e.Protocol), string(rule.Protocol))
+	compare("destination port range", e.DestinationPortRange, strings.Join(destinationPortRanges(rule), ","))
+	compare("source address prefix", e.SourceAddressPrefix, strings.Join(sourceAddressPrefixes(rule), ","))
+	return problems
+}
+
+// opensRestrictedPort returns the first restricted port that rule allows inbound traffic to, unless it is in allowedPorts
+func opensRestrictedPort(rule network.SecurityRule, allowedPorts []int) (int, bool) {
+	if rule.SecurityRulePropertiesFormat == nil || rule.Direction != network.SecurityRuleDirectionInbound || rule.Access != network.SecurityRuleAccessAllow {
+		return 0, false
+	}
+	var ports []int
+	for port := range restrictedInboundPorts {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	for _, port := range ports {
+		if containsPort(allowedPorts, port) {
+			continue
+		}
+		for _, portRange := range destinationPortRanges(rule) {
+			if portRangeContains(portRange, port) {
+				return port, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func containsPort(ports []int, port int) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+// portRangeContains returns true if port is in portRange, which is "*", a single port or a range like "100-400"
+func portRangeContains(portRange string, port int) bool {
+	if portRange == "*" {
+		return true
+	}
+	bounds := strings.SplitN(portRange, "-", 2)
+	low, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	if err != nil {
+		return false
+	}
+	high := low
+	if len(bounds) == 2 {
+		if high, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil {
+			return false
+		}
+	}
+	return port >= low && port <= high
+}
+
+func securityRules(nsg network.SecurityGroup) []network.SecurityRule {
+	if nsg.SecurityGroupPropertiesFormat == nil || nsg.SecurityRules == nil {
+		return nil
+	}
+	return *nsg.SecurityRules
+}
+
+func destinationPortRanges(rule network.SecurityRule) []string {
+	if rule.SecurityRulePropertiesFormat == nil {
+		return nil
+	}
+	if rule.DestinationPortRange != nil && *rule.DestinationPortRange != "" {
+		return []string{*rule.DestinationPortRange}
+	}
+	if rule.DestinationPortRanges != nil {
+		return *rule.DestinationPortRanges
+	}
+	return nil
+}
+
+func sourceAddressPrefixes(rule network.SecurityRule) []string {
+	if rule.SecurityRulePropertiesFormat == nil {
+		return nil
+	}
+	if rule.SourceAddressPrefix != nil && *rule.SourceAddressPrefix != "" {
+		return []string{*rule.SourceAddressPrefix}
+	}
+	if rule.SourceAddressPrefixes != nil {
+		return *rule.SourceAddressPrefixes
+	}
+	return nil
+}
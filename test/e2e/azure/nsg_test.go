@@ -0,0 +1,213 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/engine"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+func getNSGTestContainerService() *api.ContainerService {
+	return &api.ContainerService{
+		Properties: &api.Properties{
+			ClusterID: "12345678",
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorType: api.Kubernetes,
+				KubernetesConfig: &api.KubernetesConfig{},
+			},
+			MasterProfile: &api.MasterProfile{
+				DNSPrefix: "nsgtest",
+			},
+			AgentPoolProfiles: []*api.AgentPoolProfile{
+				{
+					Name:   "linuxpool",
+					OSType: api.Linux,
+				},
+			},
+		},
+	}
+}
+
+// generatedNSG returns the master network security group of the ARM template generated for cs, as deployed
+func generatedNSG(cs *api.ContainerService) network.SecurityGroup {
+	nsg := engine.CreateNetworkSecurityGroup(cs).SecurityGroup
+	nsg.Name = to.StringPtr(cs.Properties.GetMasterVMPrefix() + "nsg")
+	return nsg
+}
+
+func TestValidateNetworkSecurityGroups(t *testing.T) {
+	cases := []struct {
+		name        string
+		setup       func(cs *api.ContainerService)
+		modify      func(nsg *network.SecurityGroup)
+		expectedErr string
+	}{
+		{
+			name: "linux cluster",
+		},
+		{
+			name: "windows cluster",
+			setup: func(cs *api.ContainerService) {
+				cs.Properties.AgentPoolProfiles[0].OSType = api.Windows
+			},
+		},
+		{
+			name: "private cluster",
+			setup: func(cs *api.ContainerService) {
+				cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster = &api.PrivateCluster{
+					Enabled: to.BoolPtr(true),
+				}
+			},
+		},
+		{
+			name: "kube-apiserver exposed in a private cluster",
+			setup: func(cs *api.ContainerService) {
+				cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster = &api.PrivateCluster{
+					Enabled: to.BoolPtr(true),
+				}
+			},
+			modify: func(nsg *network.SecurityGroup) {
+				(*nsg.SecurityRules)[1].SourceAddressPrefix = to.StringPtr("*")
+			},
+			expectedErr: "rule allow_kube_tls in NSG k8s-master-12345678-nsg has source address prefix '*', expected 'VirtualNetwork'",
+		},
+		{
+			name: "SSH port range widened",
+			modify: func(nsg *network.SecurityGroup) {
+				(*nsg.SecurityRules)[0].DestinationPortRange = to.StringPtr("1-1024")
+			},
+			expectedErr: "rule allow_ssh in NSG k8s-master-12345678-nsg has destination port range '1-1024', expected '22-22'",
+		},
+		{
+			name: "WinRM opened",
+			modify: func(nsg *network.SecurityGroup) {
+				rules := append(*nsg.SecurityRules, winRMRule())
+				nsg.SecurityRules = &rules
+			},
+			expectedErr: "NSG k8s-master-12345678-nsg has unexpected rule allow_winrm; rule allow_winrm in NSG k8s-master-12345678-nsg opens WinRM (port 5985) to [*]",
+		},
+		{
+			name: "WinRM opened with the winrm extension",
+			setup: func(cs *api.ContainerService) {
+				cs.Properties.AgentPoolProfiles[0].OSType = api.Windows
+				cs.Properties.AgentPoolProfiles[0].Extensions = []api.Extension{{Name: "winrm"}}
+			},
+			modify: func(nsg *network.SecurityGroup) {
+				rules := append(*nsg.SecurityRules, winRMRule())
+				nsg.SecurityRules = &rules
+			},
+			expectedErr: "NSG k8s-master-12345678-nsg has unexpected rule allow_winrm",
+		},
+		{
+			name: "cloud provider load balancer rule",
+			modify: func(nsg *network.SecurityGroup) {
+				rules := append(*nsg.SecurityRules, network.SecurityRule{
+					Name: to.StringPtr("a0123456789abcdef0123456789abcdef-TCP-80-Internet"),
+					SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+						Access:                network.SecurityRuleAccessAllow,
+						DestinationPortRange:  to.StringPtr("80"),
+						Direction:             network.SecurityRuleDirectionInbound,
+						Protocol:              network.SecurityRuleProtocolTCP,
+						SourceAddressPrefixes: &[]string{"Internet"},
+					},
+				})
+				nsg.SecurityRules = &rules
+			},
+		},
+		{
+			name: "missing rule",
+			modify: func(nsg *network.SecurityGroup) {
+				rules := (*nsg.SecurityRules)[1:]
+				nsg.SecurityRules = &rules
+			},
+			expectedErr: "NSG k8s-master-12345678-nsg is missing rule allow_ssh",
+		},
+		{
+			name: "missing nsg",
+			modify: func(nsg *network.SecurityGroup) {
+				nsg.Name = to.StringPtr("other-nsg")
+			},
+			expectedErr: "NSG k8s-master-12345678-nsg not found; rule allow_ssh in NSG other-nsg opens SSH (port 22) to [*]",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			cs := getNSGTestContainerService()
+			if c.setup != nil {
+				c.setup(cs)
+			}
+			nsg := generatedNSG(cs)
+			if c.modify != nil {
+				c.modify(&nsg)
+			}
+			client := &armhelpers.MockAKSEngineClient{
+				FakeNetworkSecurityGroups: []network.SecurityGroup{nsg},
+			}
+			err := ValidateNetworkSecurityGroups(context.Background(), client, "rg", cs)
+			if c.expectedErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasSuffix(err.Error(), ": "+c.expectedErr) {
+				t.Errorf("expected error %q, got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateNetworkSecurityGroupsListFailure(t *testing.T) {
+	client := &armhelpers.MockAKSEngineClient{
+		FailListNetworkSecurityGroups: true,
+	}
+	if err := ValidateNetworkSecurityGroups(context.Background(), client, "rg", getNSGTestContainerService()); err == nil {
+		t.Error("expected an error when the network security groups cannot be listed")
+	}
+}
+
+func TestPortRangeContains(t *testing.T) {
+	cases := []struct {
+		portRange string
+		port      int
+		expected  bool
+	}{
+		{"*", 22, true},
+		{"22", 22, true},
+		{"22-22", 22, true},
+		{"1-1024", 22, true},
+		{"443-443", 22, false},
+		{"invalid", 22, false},
+	}
+	for _, c := range cases {
+		if actual := portRangeContains(c.portRange, c.port); actual != c.expected {
+			t.Errorf("portRangeContains(%q, %d): expected %t, got %t", c.portRange, c.port, c.expected, actual)
+		}
+	}
+}
+
+func winRMRule() network.SecurityRule {
+	return network.SecurityRule{
+		Name: to.StringPtr("allow_winrm"),
+		SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+			Access:                   network.SecurityRuleAccessAllow,
+			DestinationAddressPrefix: to.StringPtr("*"),
+			DestinationPortRange:     to.StringPtr("5985-5986"),
+			Direction:                network.SecurityRuleDirectionInbound,
+			Priority:                 to.Int32Ptr(103),
+			Protocol:                 network.SecurityRuleProtocolTCP,
+			SourceAddressPrefix:      to.StringPtr("*"),
+			SourcePortRange:          to.StringPtr("*"),
+		},
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/pkg/errors"
+)
+
+const (
+	// NodeDenyRuleName is the name of the rule the network outage scenario adds to deny the traffic between the nodes
+	NodeDenyRuleName = "e2e-deny-node-traffic"
+	minRulePriority  = 100
+	maxRulePriority  = 4096
+)
+
+// NodeDenyRule returns a rule denying the inbound traffic from the virtual network to the virtual network, with the
+// highest inbound priority nsg leaves free so that it takes precedence over all the rules but those with a higher priority
+func NodeDenyRule(nsg network.SecurityGroup) (network.SecurityRule, error) {
+	used := map[int32]bool{}
+	for _, rule := range securityRules(nsg) {
+		if rule.SecurityRulePropertiesFormat != nil && rule.Direction == network.SecurityRuleDirectionInbound && rule.Priority != nil {
+			used[*rule.Priority] = true
+		}
+	}
+	priority := int32(minRulePriority)
+	for used[priority] {
+		priority++
+	}
+	if priority > maxRulePriority {
+		return network.SecurityRule{}, errors.Errorf("NSG %s has no free inbound priority", to.String(nsg.Name))
+	}
+	return network.SecurityRule{
+		Name: to.StringPtr(NodeDenyRuleName),
+		SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+			Description:              to.StringPtr("Denies the traffic between the nodes to simulate a network outage"),
+			Protocol:                 network.SecurityRuleProtocolAsterisk,
+			SourceAddressPrefix:      to.StringPtr("VirtualNetwork"),
+			SourcePortRange:          to.StringPtr("*"),
+			DestinationAddressPrefix: to.StringPtr("VirtualNetwork"),
+			DestinationPortRange:     to.StringPtr("*"),
+			Access:                   network.SecurityRuleAccessDeny,
+			Priority:                 to.Int32Ptr(priority),
+			Direction:                network.SecurityRuleDirectionInbound,
+		},
+	}, nil
+}
+
+// ApplyNodeDenyRule adds NodeDenyRule to the network security group of the nodes of cs in resourceGroup, and returns
+// the name of the network security group
+func ApplyNodeDenyRule(ctx context.Context, client armhelpers.AKSEngineClient, resourceGroup string, cs *api.ContainerService) (string, error) {
+	nsgName := cs.Properties.GetMasterVMPrefix() + "nsg"
+	nsgs, err := client.ListNetworkSecurityGroups(ctx, resourceGroup)
+	if err != nil {
+		return "", errors.Wrapf(err, "listing the network security groups in resource group %s", resourceGroup)
+	}
+	for _, nsg := range nsgs {
+		if !strings.EqualFold(to.String(nsg.Name), nsgName) {
+			continue
+		}
+		rule, err := NodeDenyRule(nsg)
+		if err != nil {
+			return "", err
+		}
+		if err = client.CreateOrUpdateSecurityRule(ctx, resourceGroup, nsgName, rule); err != nil {
+			return "", errors.Wrapf(err, "adding rule %s to NSG %s", NodeDenyRuleName, nsgName)
+		}
+		return nsgName, nil
+	}
+	return "", errors.Errorf("NSG %s not found in resource group %s", nsgName, resourceGroup)
+}
+
+// RemoveNodeDenyRule removes NodeDenyRule from the network security group nsgName
+func RemoveNodeDenyRule(ctx context.Context, client armhelpers.AKSEngineClient, resourceGroup, nsgName string) error {
+	return errors.Wrapf(client.DeleteSecurityRule(ctx, resourceGroup, nsgName, NodeDenyRuleName), "removing rule %s from NSG %s", NodeDenyRuleName, nsgName)
+}
+
+// ValidateLoadBalancersConverged fetches the load balancers in resourceGroup and validates that they have converged,
+// see ValidateLoadBalancers
+func ValidateLoadBalancersConverged(ctx context.Context, client armhelpers.AKSEngineClient, resourceGroup string) error {
+	lbs, err := client.ListLoadBalancers(ctx, resourceGroup)
+	if err != nil {
+		return errors.Wrapf(err, "listing the load balancers in resource group %s", resourceGroup)
+	}
+	return ValidateLoadBalancers(lbs)
+}
+
+// ValidateLoadBalancers returns an error listing the load balancers, and their rules and probes, whose provisioning did
+// not succeed, such as rules stuck updating after the cloud provider failed to reconcile them
+func ValidateLoadBalancers(lbs []network.LoadBalancer) error {
+	var problems []string
+	check := func(kind, name string, state *string) {
+		if !strings.EqualFold(to.String(state), "Succeeded") {
+			problems = append(problems, fmt.Sprintf("%s %s is in provisioning state '%s'", kind, name, to.String(state)))
+		}
+	}
+	for _, lb := range lbs {
+		lbName := to.String(lb.Name)
+		if lb.LoadBalancerPropertiesFormat == nil {
+			continue
+		}
+		check("load balancer", lbName, lb.ProvisioningState)
+		if lb.LoadBalancingRules != nil {
+			for _, rule := range *lb.LoadBalancingRules {
+				if rule.LoadBalancingRulePropertiesFormat != nil {
+					check("rule", lbName+"/"+to.String(rule.Name), rule.ProvisioningState)
+				}
+			}
+		}
+		if lb.Probes != nil {
+			for _, probe := range *lb.Probes {
+				if probe.ProbePropertiesFormat != nil {
+					check("probe", lbName+"/"+to.String(probe.Name), probe.ProvisioningState)
+				}
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.Errorf("load balancers have not converged: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
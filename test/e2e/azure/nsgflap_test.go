@@ -0,0 +1,103 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+func TestNodeDenyRule(t *testing.T) {
+	nsg := generatedNSG(getNSGTestContainerService())
+	rule, err := NodeDenyRule(nsg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if to.String(rule.Name) != NodeDenyRuleName || rule.Access != network.SecurityRuleAccessDeny || rule.Direction != network.SecurityRuleDirectionInbound {
+		t.Errorf("expected an inbound deny rule named %s, got %+v", NodeDenyRuleName, rule)
+	}
+	if to.String(rule.SourceAddressPrefix) != "VirtualNetwork" || to.String(rule.DestinationAddressPrefix) != "VirtualNetwork" {
+		t.Errorf("expected the rule to deny the traffic within the virtual network, got %s to %s", to.String(rule.SourceAddressPrefix), to.String(rule.DestinationAddressPrefix))
+	}
+	for _, existing := range securityRules(nsg) {
+		if existing.Direction == network.SecurityRuleDirectionInbound && *existing.Priority == *rule.Priority {
+			t.Errorf("expected the rule to have a free priority, %s has priority %d", to.String(existing.Name), *rule.Priority)
+		}
+	}
+	if *rule.Priority != 102 {
+		t.Errorf("expected the highest free priority 102, got %d", *rule.Priority)
+	}
+
+	full := network.SecurityGroup{Name: to.StringPtr("full"), SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{SecurityRules: &[]network.SecurityRule{}}}
+	for p := int32(minRulePriority); p <= maxRulePriority; p++ {
+		*full.SecurityRules = append(*full.SecurityRules, network.SecurityRule{SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{Direction: network.SecurityRuleDirectionInbound, Priority: to.Int32Ptr(p)}})
+	}
+	if _, err = NodeDenyRule(full); err == nil || !strings.Contains(err.Error(), "no free inbound priority") {
+		t.Errorf("expected an error for an NSG without a free priority, got %v", err)
+	}
+}
+
+func TestApplyNodeDenyRule(t *testing.T) {
+	cs := getNSGTestContainerService()
+	client := &armhelpers.MockAKSEngineClient{
+		FakeNetworkSecurityGroups: []network.SecurityGroup{generatedNSG(cs)},
+	}
+	nsgName, err := ApplyNodeDenyRule(context.Background(), client, "rg", cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nsgName != cs.Properties.GetMasterVMPrefix()+"nsg" {
+		t.Errorf("expected the rule to be added to the NSG of the nodes, got %s", nsgName)
+	}
+
+	client.FailCreateOrUpdateSecurityRule = true
+	if _, err = ApplyNodeDenyRule(context.Background(), client, "rg", cs); err == nil {
+		t.Error("expected an error when the rule cannot be added")
+	}
+
+	client.FakeNetworkSecurityGroups = nil
+	if _, err = ApplyNodeDenyRule(context.Background(), client, "rg", cs); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected an error when the NSG of the nodes is not found, got %v", err)
+	}
+}
+
+func TestValidateLoadBalancers(t *testing.T) {
+	lb := func(state, ruleState string) network.LoadBalancer {
+		return network.LoadBalancer{
+			Name: to.StringPtr("kubernetes"),
+			LoadBalancerPropertiesFormat: &network.LoadBalancerPropertiesFormat{
+				ProvisioningState: to.StringPtr(state),
+				LoadBalancingRules: &[]network.LoadBalancingRule{
+					{Name: to.StringPtr("a0123456789abcdef0123456789abcdef-TCP-80"), LoadBalancingRulePropertiesFormat: &network.LoadBalancingRulePropertiesFormat{ProvisioningState: to.StringPtr(ruleState)}},
+				},
+				Probes: &[]network.Probe{
+					{Name: to.StringPtr("a0123456789abcdef0123456789abcdef-TCP-80"), ProbePropertiesFormat: &network.ProbePropertiesFormat{ProvisioningState: to.StringPtr("Succeeded")}},
+				},
+			},
+		}
+	}
+
+	if err := ValidateLoadBalancers([]network.LoadBalancer{lb("Succeeded", "Succeeded")}); err != nil {
+		t.Errorf("expected provisioned load balancers to be valid, got %v", err)
+	}
+	err := ValidateLoadBalancers([]network.LoadBalancer{lb("Updating", "Updating")})
+	if err == nil {
+		t.Fatal("expected an error for load balancers still updating")
+	}
+	for _, expected := range []string{"load balancer kubernetes is in provisioning state 'Updating'", "rule kubernetes/a0123456789abcdef0123456789abcdef-TCP-80 is in provisioning state 'Updating'"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %q, got %v", expected, err)
+		}
+	}
+
+	client := &armhelpers.MockAKSEngineClient{FailListLoadBalancers: true}
+	if err = ValidateLoadBalancersConverged(context.Background(), client, "rg"); err == nil {
+		t.Error("expected an error when the load balancers cannot be listed")
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package capability detects what a cluster is capable of from its apimodel, so that the e2e specs requiring a capability
+// only run against the clusters that have it.
+package capability
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+// Capability is something a cluster has, e.g. a Windows agent pool or an enabled addon
+type Capability string
+
+const (
+	Linux                   Capability = "linux"                     // Linux is a cluster with a Linux agent pool
+	Windows                 Capability = "windows"                   // Windows is a cluster with a Windows agent pool
+	AzureCNI                Capability = "azure-cni"                 // AzureCNI is a cluster networked by the Azure CNI plugin
+	Kubenet                 Capability = "kubenet"                   // Kubenet is a cluster networked by kubenet
+	NetworkPolicy           Capability = "network-policy"            // NetworkPolicy is a cluster enforcing network policies with calico, azure, cilium or antrea
+	VMSS                    Capability = "vmss"                      // VMSS is a cluster with an agent pool of virtual machine scale sets
+	AvailabilitySet         Capability = "availability-set"          // AvailabilitySet is a cluster with an agent pool of availability sets
+	AvailabilityZones       Capability = "availability-zones"        // AvailabilityZones is a cluster whose agent pools are all zoned
+	MasterAvailabilityZones Capability = "master-availability-zones" // MasterAvailabilityZones is a cluster whose masters are zoned
+	LowPriority             Capability = "low-priority"              // LowPriority is a cluster with a low-priority or spot scale set, whose nodes may be evicted
+	Spot                    Capability = "spot"                      // Spot is a cluster with a spot scale set
+	ScheduledEventsHandler  Capability = "scheduled-events-handler"  // ScheduledEventsHandler is a cluster draining the nodes Azure schedules the preemption or deletion of
+	GPU                     Capability = "gpu"                       // GPU is a cluster with an N-series agent pool
+	Docker                  Capability = "docker"                    // Docker is a cluster whose container runtime is docker
+	VHD                     Capability = "vhd"                       // VHD is a cluster whose nodes all run a VHD distro
+	AzureStack              Capability = "azure-stack"               // AzureStack is a cluster on Azure Stack
+	AdmissionWebhooks       Capability = "admission-webhooks"        // AdmissionWebhooks is a cluster whose API server calls validating and mutating admission webhooks
+
+	addonPrefix = "addon:"
+	notPrefix   = "!"
+	tagPrefix   = `[Requires:`
+)
+
+// detectors detect the capabilities that are not addons
+var detectors = map[Capability]func(p *api.Properties) bool{
+	Linux:   func(p *api.Properties) bool { return p.AnyAgentIsLinux() },
+	Windows: func(p *api.Properties) bool { return p.HasWindows() },
+	AzureCNI: func(p *api.Properties) bool {
+		return kubernetesConfig(p).NetworkPlugin == api.NetworkPluginAzure
+	},
+	Kubenet: func(p *api.Properties) bool {
+		return kubernetesConfig(p).NetworkPlugin == api.NetworkPluginKubenet
+	},
+	NetworkPolicy: func(p *api.Properties) bool {
+		switch kubernetesConfig(p).NetworkPolicy {
+		case api.NetworkPolicyCalico, api.NetworkPolicyAzure, api.NetworkPolicyCilium, api.NetworkPolicyAntrea:
+			return true
+		}
+		// the cilium and antrea network plugins enforce the network policies without a networkPolicy
+		switch kubernetesConfig(p).NetworkPlugin {
+		case api.NetworkPluginCilium, api.NetworkPluginAntrea:
+			return true
+		}
+		return false
+	},
+	VMSS:              func(p *api.Properties) bool { return p.AnyAgentUsesVirtualMachineScaleSets() },
+	AvailabilitySet:   func(p *api.Properties) bool { return p.AnyAgentUsesAvailabilitySets() },
+	AvailabilityZones: func(p *api.Properties) bool { return p.HasZonesForAllAgentPools() },
+	MasterAvailabilityZones: func(p *api.Properties) bool {
+		return p.MasterProfile != nil && p.MasterProfile.HasAvailabilityZones()
+	},
+	LowPriority:            func(p *api.Properties) bool { return p.HasLowPriorityScaleset() },
+	Spot:                   func(p *api.Properties) bool { return p.HasSpotScaleset() },
+	ScheduledEventsHandler: func(p *api.Properties) bool { return p.AnyAgentHasScheduledEventsHandler() },
+	GPU:                    func(p *api.Properties) bool { return p.HasNSeriesSKU() },
+	Docker:                 func(p *api.Properties) bool { return kubernetesConfig(p).RequiresDocker() },
+	VHD:                    func(p *api.Properties) bool { return p.IsVHDDistroForAllNodes() },
+	AzureStack:             func(p *api.Properties) bool { return p.IsAzureStackCloud() },
+	AdmissionWebhooks: func(p *api.Properties) bool {
+		c := kubernetesConfig(p).APIServerConfig
+		// --admission-control replaces the default admission plugins, --enable-admission-plugins adds to them
+		if plugins, ok := c["--admission-control"]; ok {
+			return hasPlugin(plugins, "ValidatingAdmissionWebhook") && hasPlugin(plugins, "MutatingAdmissionWebhook")
+		}
+		disabled := c["--disable-admission-plugins"]
+		return !hasPlugin(disabled, "ValidatingAdmissionWebhook") && !hasPlugin(disabled, "MutatingAdmissionWebhook")
+	},
+}
+
+// Addon is a cluster with the addon enabled
+func Addon(name string) Capability {
+	return Capability(addonPrefix + name)
+}
+
+// Not is a cluster without the capability, e.g. the specs that cannot run against a low-priority scale set require Not(LowPriority)
+func Not(c Capability) Capability {
+	if strings.HasPrefix(string(c), notPrefix) {
+		return Capability(strings.TrimPrefix(string(c), notPrefix))
+	}
+	return Capability(notPrefix + string(c))
+}
+
+// Tags returns the tags to add to the text of a spec requiring the capabilities, e.g. "[Requires:windows] [Requires:!low-priority]"
+func Tags(required ...Capability) string {
+	tags := make([]string, len(required))
+	for i, c := range required {
+		tags[i] = tagPrefix + string(c) + "]"
+	}
+	return strings.Join(tags, " ")
+}
+
+// Set is what a cluster is capable of, the capabilities it was checked for are false if it does not have them
+type Set map[Capability]bool
+
+// Detect returns the capabilities of the cluster described by the apimodel. Only the addons the apimodel lists are checked for,
+// the addons of a generated apimodel are all listed once defaulted.
+func Detect(cs *api.ContainerService) Set {
+	s := Set{}
+	p := cs.Properties
+	for c, detect := range detectors {
+		s[c] = p != nil && detect(p)
+	}
+	if p != nil && p.OrchestratorProfile != nil && p.OrchestratorProfile.KubernetesConfig != nil {
+		for _, addon := range p.OrchestratorProfile.KubernetesConfig.Addons {
+			s[Addon(addon.Name)] = to.Bool(addon.Enabled)
+		}
+	}
+	return s
+}
+
+// Has returns true if the cluster has the capability, or does not have it for Not(c). A capability it was not checked for is missing.
+func (s Set) Has(c Capability) bool {
+	if strings.HasPrefix(string(c), notPrefix) {
+		return !s[Not(c)]
+	}
+	return s[c]
+}
+
+// Missing returns the required capabilities the cluster does not have
+func (s Set) Missing(required ...Capability) []Capability {
+	var missing []Capability
+	for _, c := range required {
+		if !s.Has(c) {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// Skip returns a regular expression matching the specs requiring a capability the cluster does not have, to skip them.
+// It is empty if no spec can be skipped.
+func (s Set) Skip() string {
+	var missing []Capability
+	for c, has := range s {
+		if has {
+			missing = append(missing, Not(c))
+		} else {
+			missing = append(missing, c)
+		}
+	}
+	return tagsPattern(missing)
+}
+
+// Focus returns a regular expression matching the specs requiring a capability the cluster has, the specs that are relevant to it.
+// It is empty if the cluster has none of the capabilities.
+func (s Set) Focus() string {
+	var capabilities []Capability
+	for c, has := range s {
+		if has {
+			capabilities = append(capabilities, c)
+		}
+	}
+	return tagsPattern(capabilities)
+}
+
+func (s Set) String() string {
+	var capabilities []string
+	for c, has := range s {
+		if has {
+			capabilities = append(capabilities, string(c))
+		}
+	}
+	sort.Strings(capabilities)
+	return strings.Join(capabilities, ",")
+}
+
+// tagsPattern returns a regular expression matching the tag of any of the capabilities
+func tagsPattern(capabilities []Capability) string {
+	if len(capabilities) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(capabilities))
+	for i, c := range capabilities {
+		quoted[i] = regexp.QuoteMeta(string(c))
+	}
+	sort.Strings(quoted)
+	return fmt.Sprintf("%s(?:%s)\\]", regexp.QuoteMeta(tagPrefix), strings.Join(quoted, "|"))
+}
+
+// hasPlugin returns true if the comma separated admission plugins include plugin
+func hasPlugin(plugins, plugin string) bool {
+	for _, p := range strings.Split(plugins, ",") {
+		if strings.TrimSpace(p) == plugin {
+			return true
+		}
+	}
+	return false
+}
+
+func kubernetesConfig(p *api.Properties) *api.KubernetesConfig {
+	if p.OrchestratorProfile == nil || p.OrchestratorProfile.KubernetesConfig == nil {
+		return &api.KubernetesConfig{}
+	}
+	return p.OrchestratorProfile.KubernetesConfig
+}
@@ -0,0 +1,134 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package capability
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+func TestDetect(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.16.1", 1, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin = api.NetworkPluginAzure
+	cs.Properties.OrchestratorProfile.KubernetesConfig.NetworkPolicy = api.NetworkPolicyCalico
+	cs.Properties.OrchestratorProfile.KubernetesConfig.Addons = []api.KubernetesAddon{
+		{Name: "tiller", Enabled: to.BoolPtr(true)},
+		{Name: "kubernetes-dashboard", Enabled: to.BoolPtr(false)},
+	}
+	cs.Properties.AgentPoolProfiles[0].AvailabilityProfile = api.VirtualMachineScaleSets
+	cs.Properties.AgentPoolProfiles[0].ScaleSetPriority = api.ScaleSetPriorityLow
+	cs.Properties.AgentPoolProfiles[0].ScheduledEventsProfile = &api.ScheduledEventsProfile{Enabled: to.BoolPtr(true)}
+	cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &api.AgentPoolProfile{
+		Name:                "spot",
+		AvailabilityProfile: api.VirtualMachineScaleSets,
+		ScaleSetPriority:    api.ScaleSetPrioritySpot,
+	})
+
+	s := Detect(cs)
+	for _, c := range []Capability{Linux, AzureCNI, NetworkPolicy, VMSS, LowPriority, Spot, ScheduledEventsHandler, Docker, Addon("tiller"), Not(Windows), Not(AzureStack), Not(Addon("kubernetes-dashboard"))} {
+		if !s.Has(c) {
+			t.Errorf("expected the cluster to have %s, it has %s", c, s)
+		}
+	}
+	for _, c := range []Capability{Windows, Kubenet, GPU, AzureStack, Addon("kubernetes-dashboard"), Addon("not-an-addon"), Not(Linux)} {
+		if s.Has(c) {
+			t.Errorf("expected the cluster not to have %s", c)
+		}
+	}
+	if missing := s.Missing(Linux, Windows, Not(LowPriority)); len(missing) != 2 || missing[0] != Windows || missing[1] != Not(LowPriority) {
+		t.Errorf("expected windows and !low-priority to be missing, got %v", missing)
+	}
+
+	if s := Detect(&api.ContainerService{}); s.Has(Linux) || !s.Has(Not(Windows)) {
+		t.Errorf("expected a cluster without properties to have no capability, it has %s", s)
+	}
+}
+
+func TestDetectNetworkPolicy(t *testing.T) {
+	cases := []struct {
+		networkPlugin string
+		networkPolicy string
+		expected      bool
+	}{
+		{networkPlugin: api.NetworkPluginKubenet},
+		{networkPlugin: api.NetworkPluginKubenet, networkPolicy: api.NetworkPolicyCalico, expected: true},
+		{networkPlugin: api.NetworkPluginCilium, expected: true},
+		{networkPlugin: api.NetworkPluginAntrea, expected: true},
+		{networkPlugin: api.NetworkPluginAntrea, networkPolicy: api.NetworkPolicyAntrea, expected: true},
+	}
+	for _, c := range cases {
+		cs := api.CreateMockContainerService("testcluster", "1.16.1", 1, 2, false)
+		cs.Properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin = c.networkPlugin
+		cs.Properties.OrchestratorProfile.KubernetesConfig.NetworkPolicy = c.networkPolicy
+		if actual := Detect(cs).Has(NetworkPolicy); actual != c.expected {
+			t.Errorf("expected networkPlugin %q and networkPolicy %q to enforce network policies: %t, got %t", c.networkPlugin, c.networkPolicy, c.expected, actual)
+		}
+	}
+}
+
+func TestDetectAdmissionWebhooks(t *testing.T) {
+	cases := []struct {
+		apiServerConfig map[string]string
+		expected        bool
+	}{
+		{expected: true},
+		{apiServerConfig: map[string]string{"--enable-admission-plugins": "NamespaceLifecycle,PodSecurityPolicy"}, expected: true},
+		{apiServerConfig: map[string]string{"--disable-admission-plugins": "MutatingAdmissionWebhook"}},
+		{apiServerConfig: map[string]string{"--admission-control": "NamespaceLifecycle,ValidatingAdmissionWebhook"}},
+		{apiServerConfig: map[string]string{"--admission-control": "MutatingAdmissionWebhook, ValidatingAdmissionWebhook"}, expected: true},
+	}
+	for _, c := range cases {
+		cs := api.CreateMockContainerService("testcluster", "1.15.3", 1, 2, false)
+		cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig = c.apiServerConfig
+		if s := Detect(cs); s.Has(AdmissionWebhooks) != c.expected {
+			t.Errorf("expected admission-webhooks to be %v with the API server config %v", c.expected, c.apiServerConfig)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	if tags := Tags(Windows, Not(LowPriority), Addon("tiller")); tags != "[Requires:windows] [Requires:!low-priority] [Requires:addon:tiller]" {
+		t.Errorf("unexpected tags %s", tags)
+	}
+	if Not(Not(Windows)) != Windows {
+		t.Errorf("expected Not(Not(windows)) to be windows, got %s", Not(Not(Windows)))
+	}
+}
+
+func TestSkipAndFocus(t *testing.T) {
+	s := Set{Linux: true, Windows: false, LowPriority: true, Addon("tiller"): true}
+	skip := regexp.MustCompile(s.Skip())
+	focus := regexp.MustCompile(s.Focus())
+	cases := []struct {
+		spec    string
+		skipped bool
+		focused bool
+	}{
+		{spec: "with a linux agent pool should be able to run jobs " + Tags(Linux), focused: true},
+		{spec: "with a windows agent pool should be able to deploy iis " + Tags(Windows), skipped: true},
+		{spec: "should have node labels " + Tags(Not(LowPriority)), skipped: true},
+		{spec: "should be able to recover " + Tags(Linux, Not(LowPriority)), skipped: true, focused: true},
+		{spec: "should have tiller " + Tags(Addon("tiller")), focused: true},
+		{spec: "should have the expected k8s version"},
+		{spec: "should have an unknown addon " + Tags(Addon("not-an-addon"))},
+	}
+	for _, c := range cases {
+		if skip.MatchString(c.spec) != c.skipped {
+			t.Errorf("expected %q skipped to be %v with %s", c.spec, c.skipped, s.Skip())
+		}
+		if focus.MatchString(c.spec) != c.focused {
+			t.Errorf("expected %q focused to be %v with %s", c.spec, c.focused, s.Focus())
+		}
+	}
+
+	if skip := (Set{}).Skip(); skip != "" {
+		t.Errorf("expected no specs to be skipped without capabilities, got %s", skip)
+	}
+	if focus := (Set{Windows: false}).Focus(); focus != "" {
+		t.Errorf("expected no specs to be focused without capabilities, got %s", focus)
+	}
+}
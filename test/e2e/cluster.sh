@@ -80,6 +80,16 @@ docker run --rm \
 -e IS_JENKINS="${IS_JENKINS}" \
 -e SKIP_TEST="${SKIP_TESTS}" \
 -e GINKGO_FOCUS="${GINKGO_FOCUS}" \
+-e GINKGO_NODES="${GINKGO_NODES:-1}" \
+-e ARTIFACTS_STORAGE_ACCOUNT="${ARTIFACTS_STORAGE_ACCOUNT}" \
+-e ARTIFACTS_STORAGE_RESOURCE_GROUP="${ARTIFACTS_STORAGE_RESOURCE_GROUP}" \
+-e METRICS_FORMAT="${METRICS_FORMAT}" \
+-e RESULTS_DIR="${RESULTS_DIR}" \
+-e FOCUS_PROFILE="${FOCUS_PROFILE}" \
+-e SPOT_EVICTION_SCENARIO="${SPOT_EVICTION_SCENARIO}" \
+-e NETWORK_OUTAGE_SCENARIO="${NETWORK_OUTAGE_SCENARIO}" \
+-e NETWORK_OUTAGE_WINDOW="${NETWORK_OUTAGE_WINDOW}" \
+-e NETWORK_OUTAGE_SLO="${NETWORK_OUTAGE_SLO}" \
 -e GINKGO_SKIP="${GINKGO_SKIP}" \
 "${DEV_IMAGE}" make test-kubernetes || exit 1
 
@@ -148,6 +158,16 @@ if [ "${SCALE_CLUSTER}" = "true" ]; then
     -e SKIP_LOGS_COLLECTION=true \
     -e GINKGO_SKIP="${SKIP_AFTER_SCALE_DOWN}" \
     -e GINKGO_FOCUS="${GINKGO_FOCUS}" \
+    -e GINKGO_NODES="${GINKGO_NODES:-1}" \
+    -e ARTIFACTS_STORAGE_ACCOUNT="${ARTIFACTS_STORAGE_ACCOUNT}" \
+    -e ARTIFACTS_STORAGE_RESOURCE_GROUP="${ARTIFACTS_STORAGE_RESOURCE_GROUP}" \
+    -e METRICS_FORMAT="${METRICS_FORMAT}" \
+    -e RESULTS_DIR="${RESULTS_DIR}" \
+    -e FOCUS_PROFILE="${FOCUS_PROFILE}" \
+    -e SPOT_EVICTION_SCENARIO="${SPOT_EVICTION_SCENARIO}" \
+    -e NETWORK_OUTAGE_SCENARIO="${NETWORK_OUTAGE_SCENARIO}" \
+    -e NETWORK_OUTAGE_WINDOW="${NETWORK_OUTAGE_WINDOW}" \
+    -e NETWORK_OUTAGE_SLO="${NETWORK_OUTAGE_SLO}" \
     -e SKIP_TEST=${SKIP_TESTS_AFTER_SCALE_DOWN} \
     ${DEV_IMAGE} make test-kubernetes || exit 1
 fi
@@ -188,6 +208,16 @@ if [ "${UPGRADE_CLUSTER}" = "true" ]; then
       -e SKIP_LOGS_COLLECTION=${SKIP_LOGS_COLLECTION}  \
       -e GINKGO_SKIP="${SKIP_AFTER_UPGRADE}" \
       -e GINKGO_FOCUS="${GINKGO_FOCUS}" \
+      -e GINKGO_NODES="${GINKGO_NODES:-1}" \
+      -e ARTIFACTS_STORAGE_ACCOUNT="${ARTIFACTS_STORAGE_ACCOUNT}" \
+      -e ARTIFACTS_STORAGE_RESOURCE_GROUP="${ARTIFACTS_STORAGE_RESOURCE_GROUP}" \
+      -e METRICS_FORMAT="${METRICS_FORMAT}" \
+      -e RESULTS_DIR="${RESULTS_DIR}" \
+      -e FOCUS_PROFILE="${FOCUS_PROFILE}" \
+      -e SPOT_EVICTION_SCENARIO="${SPOT_EVICTION_SCENARIO}" \
+      -e NETWORK_OUTAGE_SCENARIO="${NETWORK_OUTAGE_SCENARIO}" \
+      -e NETWORK_OUTAGE_WINDOW="${NETWORK_OUTAGE_WINDOW}" \
+      -e NETWORK_OUTAGE_SLO="${NETWORK_OUTAGE_SLO}" \
       -e SKIP_TEST=${SKIP_TESTS_AFTER_UPGRADE} \
       ${DEV_IMAGE} make test-kubernetes || exit 1
   done
@@ -231,6 +261,16 @@ if [ "${SCALE_CLUSTER}" = "true" ]; then
     -e SKIP_LOGS_COLLECTION=${SKIP_LOGS_COLLECTION} \
     -e GINKGO_SKIP="${SKIP_AFTER_SCALE_UP}" \
     -e GINKGO_FOCUS="${GINKGO_FOCUS}" \
+    -e GINKGO_NODES="${GINKGO_NODES:-1}" \
+    -e ARTIFACTS_STORAGE_ACCOUNT="${ARTIFACTS_STORAGE_ACCOUNT}" \
+    -e ARTIFACTS_STORAGE_RESOURCE_GROUP="${ARTIFACTS_STORAGE_RESOURCE_GROUP}" \
+    -e METRICS_FORMAT="${METRICS_FORMAT}" \
+    -e RESULTS_DIR="${RESULTS_DIR}" \
+    -e FOCUS_PROFILE="${FOCUS_PROFILE}" \
+    -e SPOT_EVICTION_SCENARIO="${SPOT_EVICTION_SCENARIO}" \
+    -e NETWORK_OUTAGE_SCENARIO="${NETWORK_OUTAGE_SCENARIO}" \
+    -e NETWORK_OUTAGE_WINDOW="${NETWORK_OUTAGE_WINDOW}" \
+    -e NETWORK_OUTAGE_SLO="${NETWORK_OUTAGE_SLO}" \
     -e SKIP_TEST=${SKIP_TESTS_AFTER_SCALE_DOWN} \
     ${DEV_IMAGE} make test-kubernetes || exit 1
 fi
@@ -18,6 +18,7 @@ import (
 
 	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/aks-engine/pkg/api/vlabs"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/pod"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
 	"github.com/kelseyhightower/envconfig"
 )
@@ -36,12 +37,39 @@ type Config struct {
 	RetainSSH           bool          `envconfig:"RETAIN_SSH" default:"true"`
 	StabilityIterations int           `envconfig:"STABILITY_ITERATIONS"`
 	Timeout             time.Duration `envconfig:"TIMEOUT" default:"10m"`
+	ARMDeployTimeout    time.Duration `envconfig:"ARM_DEPLOY_TIMEOUT" default:"45m"`         // ARMDeployTimeout is the budget of the ARM deployment of the cluster
+	MastersTimeout      time.Duration `envconfig:"MASTERS_REGISTERED_TIMEOUT" default:"10m"` // MastersTimeout is the budget for the masters to register as nodes once the cluster is deployed
+	NodesReadyTimeout   time.Duration `envconfig:"NODES_READY_TIMEOUT"`                      // NodesReadyTimeout is the budget for all the nodes to be Ready once the masters registered, Timeout if unset
+	AddonsTimeout       time.Duration `envconfig:"ADDONS_HEALTHY_TIMEOUT" default:"10m"`     // AddonsTimeout is the budget for the pods of the addons to be Ready once the nodes are
 	CurrentWorkingDir   string
-	SoakClusterName     string `envconfig:"SOAK_CLUSTER_NAME"`
-	ForceDeploy         bool   `envconfig:"FORCE_DEPLOY"`
-	UseDeployCommand    bool   `envconfig:"USE_DEPLOY_COMMAND"`
-	GinkgoFocus         string `envconfig:"GINKGO_FOCUS"`
-	GinkgoSkip          string `envconfig:"GINKGO_SKIP"`
+	SoakClusterName     string        `envconfig:"SOAK_CLUSTER_NAME"`
+	ForceDeploy         bool          `envconfig:"FORCE_DEPLOY"`
+	UseDeployCommand    bool          `envconfig:"USE_DEPLOY_COMMAND"`
+	GinkgoFocus         string        `envconfig:"GINKGO_FOCUS"`
+	GinkgoSkip          string        `envconfig:"GINKGO_SKIP"`
+	RetryMaxAttempts    int           `envconfig:"RETRY_MAX_ATTEMPTS" default:"5"`                  // RetryMaxAttempts is the number of times kubernetes helpers will attempt an operation before giving up
+	RetryInitialBackoff time.Duration `envconfig:"RETRY_INITIAL_BACKOFF" default:"1s"`              // RetryInitialBackoff is how long kubernetes helpers wait after the first failed attempt
+	RetryMaxBackoff     time.Duration `envconfig:"RETRY_MAX_BACKOFF" default:"30s"`                 // RetryMaxBackoff caps the wait between attempts
+	RetryMultiplier     float64       `envconfig:"RETRY_BACKOFF_MULTIPLIER" default:"2"`            // RetryMultiplier is applied to the backoff after every failed attempt
+	RetryJitter         float64       `envconfig:"RETRY_JITTER" default:"0.2"`                      // RetryJitter randomizes each backoff by up to +/- this fraction of its value
+	RecordAPICalls      bool          `envconfig:"RECORD_API_CALLS" default:"false"`                // RecordAPICalls sends kubectl through a proxy that logs every Kubernetes API call to the logs directory
+	OutboundURLs        []string      `envconfig:"OUTBOUND_URLS" default:"www.bing.com,google.com"` // OutboundURLs are requested to check the outbound internet connection of pods
+	OutboundStatusCodes []int         `envconfig:"OUTBOUND_STATUS_CODES"`                           // OutboundStatusCodes are the HTTP status codes that count as an outbound connection, any response counts if empty
+	OutboundHTTPS       bool          `envconfig:"OUTBOUND_HTTPS" default:"false"`                  // OutboundHTTPS requests OutboundURLs without a scheme over HTTPS
+	OutboundProxy       string        `envconfig:"OUTBOUND_PROXY"`                                  // OutboundProxy is the HTTP(S) proxy outbound connection checks go through
+	DNSPerfImage        string        `envconfig:"DNSPERF_IMAGE" default:"guessi/dnsperf:alpine"`   // DNSPerfImage generates the DNS query load, it must have dnsperf and a shell
+	DNSLoadMaxErrorRate float64       `envconfig:"DNS_LOAD_MAX_ERROR_RATE" default:"0.01"`          // DNSLoadMaxErrorRate is the fraction of the DNS load queries allowed to be lost or to fail
+	CollectArtifacts    bool          `envconfig:"COLLECT_ARTIFACTS" default:"true"`                // CollectArtifacts collects pod logs, events, node journals and ARM deployment operations when a WaitOn* helper fails
+	ArtifactsStorage    string        `envconfig:"ARTIFACTS_STORAGE_ACCOUNT"`                       // ArtifactsStorage is the storage account the artifacts are uploaded to, they are only kept in the logs directory if empty
+	ArtifactsStorageRG  string        `envconfig:"ARTIFACTS_STORAGE_RESOURCE_GROUP"`                // ArtifactsStorageRG is the resource group of ArtifactsStorage
+	ArtifactsFileShare  string        `envconfig:"ARTIFACTS_FILE_SHARE" default:"e2e-artifacts"`    // ArtifactsFileShare is the file share of ArtifactsStorage the artifacts are uploaded to
+	MetricsFormat       string        `envconfig:"METRICS_FORMAT"`                                  // MetricsFormat is "prometheus" or "json" to emit the timing metrics of the run to the metrics directory, none are emitted if empty
+	ResultsDir          string        `envconfig:"RESULTS_DIR"`                                     // ResultsDir is where the JUnit XML of the suite and the JSON summary of the run are written, relative to the root of the project unless absolute
+	FocusProfile        string        `envconfig:"FOCUS_PROFILE"`                                   // FocusProfile is a cluster definition, only the specs requiring one of its capabilities run if set, relative to the root of the project unless absolute
+	SpotEviction        bool          `envconfig:"SPOT_EVICTION_SCENARIO" default:"false"`          // SpotEviction runs the scenario evicting a low-priority instance, the autoscaler backfilling its capacity takes up to 20 minutes
+	NetworkOutage       bool          `envconfig:"NETWORK_OUTAGE_SCENARIO" default:"false"`         // NetworkOutage runs the scenario denying the traffic between the nodes in their NSG for NetworkOutageWindow
+	NetworkOutageWindow time.Duration `envconfig:"NETWORK_OUTAGE_WINDOW" default:"3m"`              // NetworkOutageWindow is how long the network outage scenario denies the traffic between the nodes
+	NetworkOutageSLO    time.Duration `envconfig:"NETWORK_OUTAGE_SLO" default:"10m"`                // NetworkOutageSLO is how long the cluster may take to converge once the traffic between the nodes is allowed again
 }
 
 // CustomCloudConfig holds configurations for custom clould
@@ -220,6 +248,41 @@ func (c *Config) SetKubeConfig() {
 	log.Printf("\nKubeconfig:%s\n", c.GetKubeConfig())
 }
 
+// GetLogsPath returns the absolute path to the directory test artifacts and logs are collected in
+func (c *Config) GetLogsPath() string {
+	hostname := fmt.Sprintf("%s.%s.cloudapp.azure.com", c.Name, c.Location)
+	return filepath.Join(c.CurrentWorkingDir, "_logs", hostname)
+}
+
+// GetArtifactsPath will return the absolute path to the directory of the artifacts collected on failure
+func (c *Config) GetArtifactsPath() string {
+	return filepath.Join(c.GetLogsPath(), "artifacts")
+}
+
+// GetMetricsPath will return the absolute path to the directory the timing metrics of the run are emitted to
+func (c *Config) GetMetricsPath() string {
+	return filepath.Join(c.GetLogsPath(), "metrics")
+}
+
+// GetResultsPath will return the absolute path to the directory of the JUnit XML and the JSON summary of the run, _logs/<cluster>/results unless ResultsDir is set
+func (c *Config) GetResultsPath() string {
+	if c.ResultsDir == "" {
+		return filepath.Join(c.GetLogsPath(), "results")
+	}
+	if filepath.IsAbs(c.ResultsDir) {
+		return c.ResultsDir
+	}
+	return filepath.Join(c.CurrentWorkingDir, c.ResultsDir)
+}
+
+// GetFocusProfilePath returns the path of the cluster definition the specs are focused on
+func (c *Config) GetFocusProfilePath() string {
+	if filepath.IsAbs(c.FocusProfile) {
+		return c.FocusProfile
+	}
+	return filepath.Join(c.CurrentWorkingDir, c.FocusProfile)
+}
+
 // GetSSHKeyPath will return the absolute path to the ssh private key
 func (c *Config) GetSSHKeyPath() string {
 	if c.UseDeployCommand {
@@ -287,6 +350,27 @@ func (c *Config) SetSSHKeyPermissions() error {
 	return nil
 }
 
+// GetRetrier returns the retry policy the kubernetes helpers should use
+func (c *Config) GetRetrier() util.Retrier {
+	return util.Retrier{
+		MaxAttempts:    c.RetryMaxAttempts,
+		InitialBackoff: c.RetryInitialBackoff,
+		MaxBackoff:     c.RetryMaxBackoff,
+		Multiplier:     c.RetryMultiplier,
+		Jitter:         c.RetryJitter,
+	}
+}
+
+// GetOutboundConnectionConfig returns the requests the kubernetes helpers should use to check outbound internet connection
+func (c *Config) GetOutboundConnectionConfig() pod.OutboundConnectionConfig {
+	return pod.OutboundConnectionConfig{
+		URLs:        c.OutboundURLs,
+		StatusCodes: c.OutboundStatusCodes,
+		HTTPS:       c.OutboundHTTPS,
+		Proxy:       c.OutboundProxy,
+	}
+}
+
 // IsKubernetes will return true if the ORCHESTRATOR env var is set to kubernetes or not set at all
 func (c *Config) IsKubernetes() bool {
 	return c.Orchestrator == kubernetesOrchestrator
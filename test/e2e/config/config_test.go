@@ -59,3 +59,20 @@ func TestSetRandomRegion(t *testing.T) {
 	}
 
 }
+
+func TestGetResultsPath(t *testing.T) {
+	cases := []struct {
+		resultsDir string
+		expected   string
+	}{
+		{"", "/src/aks-engine/_logs/e2e-test.westus2.cloudapp.azure.com/results"},
+		{"test/results", "/src/aks-engine/test/results"},
+		{"/tmp/results", "/tmp/results"},
+	}
+	for _, c := range cases {
+		cfg := Config{Name: "e2e-test", Location: "westus2", CurrentWorkingDir: "/src/aks-engine", ResultsDir: c.resultsDir}
+		if path := cfg.GetResultsPath(); path != c.expected {
+			t.Errorf("expected results path %s for ResultsDir %q, got %s", c.expected, c.resultsDir, path)
+		}
+	}
+}
@@ -4,6 +4,7 @@
 package engine
 
 import (
+	"context"
 	"log"
 	"os/exec"
 
@@ -25,8 +26,8 @@ func (e *Engine) Generate() error {
 }
 
 // Deploy will run aks-engine deploy on a given cluster definition
-func (e *Engine) Deploy(location string) error {
-	cmd := exec.Command("./bin/aks-engine", "deploy",
+func (e *Engine) Deploy(ctx context.Context, location string) error {
+	cmd := exec.CommandContext(ctx, "./bin/aks-engine", "deploy",
 		"--location", location,
 		"--api-model", e.Config.ClusterDefinitionPath,
 		"--dns-prefix", e.Config.DefinitionName,
@@ -227,6 +227,42 @@ func (e *Engine) AnyAgentIsLinux() bool {
 	return false
 }
 
+// LinuxAgentSubnet returns the address range of the subnet of the first linux agent pool, internal load balancers get their frontend IP from it
+func (e *Engine) LinuxAgentSubnet() string {
+	p := e.ExpandedDefinition.Properties
+	if p.MasterProfile != nil && p.MasterProfile.IsVirtualMachineScaleSets() && p.MasterProfile.AgentSubnet != "" {
+		return p.MasterProfile.AgentSubnet
+	}
+	for _, ap := range p.AgentPoolProfiles {
+		if ap.OSType == "" || ap.OSType == "Linux" {
+			if ap.Subnet != "" {
+				return ap.Subnet
+			}
+			break
+		}
+	}
+	// the subnets of a custom VNET are not known, use the address range of the VNET
+	if p.MasterProfile != nil {
+		return p.MasterProfile.VnetCidr
+	}
+	return ""
+}
+
+// GetAgentPoolTaints returns the taints the kubelets of each agent pool register their nodes with, by pool name.
+// Pools without --register-with-taints in their kubeletConfig are left out.
+func (e *Engine) GetAgentPoolTaints() map[string]string {
+	taints := map[string]string{}
+	for _, ap := range e.ExpandedDefinition.Properties.AgentPoolProfiles {
+		if ap.KubernetesConfig == nil {
+			continue
+		}
+		if t := ap.KubernetesConfig.KubeletConfig["--register-with-taints"]; t != "" {
+			taints[ap.Name] = t
+		}
+	}
+	return taints
+}
+
 // HasWindowsAgents will return true is there is at least 1 windows agent pool
 func (e *Engine) HasWindowsAgents() bool {
 	for _, ap := range e.ExpandedDefinition.Properties.AgentPoolProfiles {
@@ -268,6 +304,28 @@ func (e *Engine) GetWindowsTestImages() (*WindowsTestImages, error) {
 	return nil, errors.New("Unknown Windows version. GetWindowsSku() = " + windowsSku)
 }
 
+// GetWindowsPrePulledImages returns the container images Windows nodes are expected to have before any workload is scheduled:
+// the infra container image kubelet runs every pod with, the base image it is built from, and the servercore image used by the test workloads.
+// These are the images packer/configure-windows-vhd.ps1 pre-pulls into the Windows VHD.
+func (e *Engine) GetWindowsPrePulledImages() ([]string, error) {
+	testImages, err := e.GetWindowsTestImages()
+	if err != nil {
+		return nil, err
+	}
+	// kubletwin/pause is tagged or built on the node by New-InfraContainer in parts/k8s/windowskubeletfunc.ps1
+	images := []string{"kubletwin/pause:latest", testImages.ServerCore}
+	windowsSku := e.ExpandedDefinition.Properties.WindowsProfile.GetWindowsSku()
+	switch {
+	case strings.Contains(windowsSku, "1903"):
+		images = append(images, "mcr.microsoft.com/windows/nanoserver:1903")
+	case strings.Contains(windowsSku, "1809"), strings.Contains(windowsSku, "2019"):
+		images = append(images, "mcr.microsoft.com/windows/nanoserver:1809", "mcr.microsoft.com/k8s/core/pause:1.2.0")
+	default:
+		images = append(images, "mcr.microsoft.com/k8s/core/pause:1.2.0")
+	}
+	return images, nil
+}
+
 // HasAddon will return true if an addon is enabled
 func (e *Engine) HasAddon(name string) (bool, api.KubernetesAddon) {
 	for _, addon := range e.ExpandedDefinition.Properties.OrchestratorProfile.KubernetesConfig.Addons {
@@ -278,6 +336,14 @@ func (e *Engine) HasAddon(name string) (bool, api.KubernetesAddon) {
 	return false, api.KubernetesAddon{}
 }
 
+// ClusterDomain returns the DNS domain of the cluster kubelet configures pods with
+func (e *Engine) ClusterDomain() string {
+	if clusterDomain := e.ExpandedDefinition.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig["--cluster-domain"]; clusterDomain != "" {
+		return clusterDomain
+	}
+	return api.DefaultKubernetesClusterDomain
+}
+
 // HasNetworkPolicy will return true if the specified network policy is enabled
 func (e *Engine) HasNetworkPolicy(name string) bool {
 	return strings.Contains(e.ExpandedDefinition.Properties.OrchestratorProfile.KubernetesConfig.NetworkPolicy, name)
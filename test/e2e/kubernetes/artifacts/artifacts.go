@@ -0,0 +1,260 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package artifacts
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/aks-engine/test/e2e/azure"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/node"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/pod"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/Azure/aks-engine/test/e2e/remote"
+	"github.com/pkg/errors"
+)
+
+const (
+	commandTimeout = 1 * time.Minute
+	// journalSince bounds the node journals collected to the last hour
+	journalSince = "-1h"
+	// DefaultMaxCollections is the number of failures collected by a Collector, a cluster that keeps failing does not add anything
+	DefaultMaxCollections = 10
+)
+
+// journalUnits are the node services whose journal is collected
+var journalUnits = []string{"kubelet", "containerd", "docker"}
+
+var unsafeNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// Collector collects what is needed to triage WaitOn* failures into a directory per failure:
+// the logs and descriptions of the pods involved, the events of their namespace, the journals of their nodes and the ARM deployment operations
+type Collector struct {
+	Dir            string             // Dir is the directory of the failure directories
+	SSH            *remote.Connection // SSH reaches the nodes, the journals are not collected without it
+	ResourceGroup  string             // ResourceGroup holds the ARM deployments of the cluster, the operations are not collected without it
+	Storage        azure.Storage      // Storage receives a copy of the failure directories in FileShare, if set
+	FileShare      string
+	MaxCollections int // MaxCollections defaults to DefaultMaxCollections
+
+	mu          sync.Mutex
+	collections int
+}
+
+// Register makes the collector collect every failure the WaitOn* helpers report
+func (c *Collector) Register() {
+	util.OnWaitFailure(func(f util.WaitFailure) {
+		dir, err := c.Collect(f)
+		if err != nil {
+			log.Printf("Error collecting the artifacts of the failure to wait on %s:%s\n", f, err)
+			return
+		}
+		if dir != "" {
+			log.Printf("Collected the artifacts of the failure to wait on %s in %s\n", f, dir)
+		}
+	})
+}
+
+// Collect collects the artifacts of a failure and returns their directory, "" once MaxCollections failures were collected.
+// Every artifact is collected on a best-effort basis, those that cannot be collected are listed in collection-errors.txt.
+func (c *Collector) Collect(f util.WaitFailure) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	max := c.MaxCollections
+	if max == 0 {
+		max = DefaultMaxCollections
+	}
+	if c.collections >= max {
+		log.Printf("Not collecting the artifacts of the failure to wait on %s, %d failures were collected already\n", f, c.collections)
+		return "", nil
+	}
+	c.collections++
+
+	dir := filepath.Join(c.Dir, failureDirName(f, time.Now()))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	a := &artifactDir{path: dir}
+	a.write("failure.txt", []byte(fmt.Sprintf("%s\n%s\n", f, f.Err)), nil)
+	a.kubectl("describe.txt", describeArgs(f)...)
+	if f.Namespace != "" {
+		a.kubectl("events.txt", "get", "events", "-n", f.Namespace, "-o", "wide", "--sort-by=.lastTimestamp")
+	} else {
+		a.kubectl("events.txt", "get", "events", "--all-namespaces", "-o", "wide", "--sort-by=.lastTimestamp")
+	}
+
+	nodes := map[string]bool{}
+	for _, p := range a.pods(f) {
+		a.kubectl(filepath.Join("pods", p.Metadata.Name+".log"), "logs", p.Metadata.Name, "-n", p.Metadata.Namespace, "--all-containers=true")
+		if hasRestarts(p) {
+			a.kubectl(filepath.Join("pods", p.Metadata.Name+".previous.log"), "logs", p.Metadata.Name, "-n", p.Metadata.Namespace, "--all-containers=true", "--previous")
+		}
+		if f.Kind != "pod" {
+			a.kubectl(filepath.Join("pods", p.Metadata.Name+".describe.txt"), "describe", "pod", p.Metadata.Name, "-n", p.Metadata.Namespace)
+		}
+		if p.Spec.NodeName != "" {
+			nodes[p.Spec.NodeName] = true
+		}
+	}
+	if c.SSH != nil {
+		a.journals(c.SSH, nodes, f.Kind == "node")
+	}
+	if c.ResourceGroup != "" {
+		a.deploymentOperations(c.ResourceGroup)
+	}
+	a.writeErrors()
+
+	if c.Storage != nil {
+		if err := c.Storage.UploadFilesToPath(dir, c.FileShare, filepath.Base(dir)); err != nil {
+			return dir, errors.Wrapf(err, "uploading %s to file share %s", dir, c.FileShare)
+		}
+	}
+	return dir, nil
+}
+
+// failureDirName names the directory of a failure after when it happened and what was waited on
+func failureDirName(f util.WaitFailure, t time.Time) string {
+	what := f.Kind
+	if f.Name != "" {
+		what = fmt.Sprintf("%s-%s", f.Kind, f.Name)
+	} else if f.Selector != "" {
+		what = fmt.Sprintf("%s-%s", f.Kind, f.Selector)
+	}
+	what = strings.Trim(unsafeNameChars.ReplaceAllString(strings.ToLower(what), "-"), "-.")
+	return fmt.Sprintf("%s-%s", t.UTC().Format("20060102-150405"), util.UniqueName(what))
+}
+
+func describeArgs(f util.WaitFailure) []string {
+	args := []string{"describe", f.Kind}
+	if f.Name != "" {
+		// kubectl describe matches the resources by name prefix
+		args = append(args, f.Name)
+	}
+	if f.Selector != "" {
+		args = append(args, "-l", f.Selector)
+	}
+	if f.Namespace != "" {
+		args = append(args, "-n", f.Namespace)
+	}
+	return args
+}
+
+// artifactDir writes the artifacts of a failure and keeps track of those that could not be collected
+type artifactDir struct {
+	path   string
+	errors []string
+}
+
+func (a *artifactDir) write(name string, data []byte, err error) {
+	if err != nil {
+		a.errors = append(a.errors, fmt.Sprintf("%s: %s", name, err))
+		if len(data) == 0 {
+			return
+		}
+	}
+	path := filepath.Join(a.path, name)
+	if mkErr := os.MkdirAll(filepath.Dir(path), 0755); mkErr != nil {
+		a.errors = append(a.errors, fmt.Sprintf("%s: %s", name, mkErr))
+		return
+	}
+	if writeErr := ioutil.WriteFile(path, data, 0644); writeErr != nil {
+		a.errors = append(a.errors, fmt.Sprintf("%s: %s", name, writeErr))
+	}
+}
+
+func (a *artifactDir) kubectl(name string, args ...string) {
+	cmd := exec.Command("k", args...)
+	out, err := util.RunAndLogCommand(cmd, commandTimeout)
+	a.write(name, out, err)
+}
+
+// pods returns the pods of the resources waited on, none for cluster-scoped resources
+func (a *artifactDir) pods(f util.WaitFailure) []pod.Pod {
+	if f.Namespace == "" {
+		return nil
+	}
+	var pods []pod.Pod
+	var err error
+	switch {
+	case f.Selector != "":
+		pods, err = pod.GetAllBySelector(f.Selector, f.Namespace)
+	case f.Name != "":
+		// the pods of workloads are named after them
+		pods, err = pod.GetAllByPrefix(f.Name, f.Namespace)
+	default:
+		var list *pod.List
+		if list, err = pod.GetAll(f.Namespace); err == nil {
+			pods = list.Pods
+		}
+	}
+	if err != nil {
+		a.errors = append(a.errors, fmt.Sprintf("pods: %s", err))
+	}
+	return pods
+}
+
+func hasRestarts(p pod.Pod) bool {
+	for _, s := range p.Status.ContainerStatuses {
+		if s.RestartCount > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// journals collects the journals of the Linux nodes in names, or of every Linux node if all is true
+func (a *artifactDir) journals(sshConn *remote.Connection, names map[string]bool, all bool) {
+	if len(names) == 0 && !all {
+		return
+	}
+	nodeList, err := node.Get()
+	if err != nil {
+		a.errors = append(a.errors, fmt.Sprintf("nodes: %s", err))
+		return
+	}
+	for _, n := range nodeList.Nodes {
+		if !all && !names[n.Metadata.Name] {
+			continue
+		}
+		if !n.IsLinux() {
+			a.errors = append(a.errors, fmt.Sprintf("%s: the journals of Windows nodes are not collected", n.Metadata.Name))
+			continue
+		}
+		for _, unit := range journalUnits {
+			cmd := fmt.Sprintf("sudo journalctl -u %s --since %s --no-pager", unit, journalSince)
+			out, err := sshConn.Run(n.Metadata.Name, cmd, commandTimeout)
+			a.write(filepath.Join("nodes", n.Metadata.Name, unit+".log"), out, err)
+		}
+	}
+}
+
+// deploymentOperations collects the operations of the ARM deployments of a resource group
+func (a *artifactDir) deploymentOperations(resourceGroup string) {
+	cmd := exec.Command("az", "group", "deployment", "list", "-g", resourceGroup, "--query", "[].name", "-o", "tsv")
+	out, err := util.RunAndLogCommand(cmd, commandTimeout)
+	if err != nil {
+		a.errors = append(a.errors, fmt.Sprintf("deployments: %s: %s", err, out))
+		return
+	}
+	for _, deployment := range strings.Fields(string(out)) {
+		cmd = exec.Command("az", "group", "deployment", "operation", "list", "-g", resourceGroup, "-n", deployment)
+		ops, opsErr := util.RunAndLogCommand(cmd, commandTimeout)
+		a.write(filepath.Join("arm", deployment+"-operations.json"), ops, opsErr)
+	}
+}
+
+func (a *artifactDir) writeErrors() {
+	if len(a.errors) == 0 {
+		return
+	}
+	a.write("collection-errors.txt", []byte(strings.Join(a.errors, "\n")+"\n"), nil)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package artifacts
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+)
+
+func TestFailureDirName(t *testing.T) {
+	at := time.Date(2019, 7, 1, 12, 30, 5, 0, time.UTC)
+	cases := []struct {
+		failure util.WaitFailure
+		prefix  string
+	}{
+		{util.WaitFailure{Kind: "pod", Name: "php-apache", Namespace: "default"}, "20190701-123005-pod-php-apache-"},
+		{util.WaitFailure{Kind: "pod", Selector: "app=Web,tier in (a)", Namespace: "default"}, "20190701-123005-pod-app-web-tier-in-a-"},
+		{util.WaitFailure{Kind: "node"}, "20190701-123005-node-"},
+	}
+	for _, c := range cases {
+		name := failureDirName(c.failure, at)
+		if !regexp.MustCompile("^" + regexp.QuoteMeta(c.prefix) + "[0-9a-f]+-[0-9a-f]+$").MatchString(name) {
+			t.Errorf("expected the directory of %s to start with %s and end with the run ID and a random suffix, got %s", c.failure, c.prefix, name)
+		}
+	}
+	f := util.WaitFailure{Kind: "pod", Name: "php-apache", Namespace: "default"}
+	if failureDirName(f, at) == failureDirName(f, at) {
+		t.Errorf("expected failures to wait on the same pods at the same time to be collected in different directories")
+	}
+}
+
+func TestDescribeArgs(t *testing.T) {
+	cases := []struct {
+		failure  util.WaitFailure
+		expected []string
+	}{
+		{util.WaitFailure{Kind: "pod", Name: "php-apache", Namespace: "default"}, []string{"describe", "pod", "php-apache", "-n", "default"}},
+		{util.WaitFailure{Kind: "pod", Selector: "app=web", Namespace: "default"}, []string{"describe", "pod", "-l", "app=web", "-n", "default"}},
+		{util.WaitFailure{Kind: "node"}, []string{"describe", "node"}},
+	}
+	for _, c := range cases {
+		if args := describeArgs(c.failure); !reflect.DeepEqual(args, c.expected) {
+			t.Errorf("expected %v to describe %s, got %v", c.expected, c.failure, args)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cronjob
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os/exec"
+	"regexp"
+	"time"
+
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/job"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/pod"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/pkg/errors"
+)
+
+const deleteTimeout = 5 * time.Minute
+
+// List is a container that holds all cronjobs returned from doing a kubectl get cronjobs
+type List struct {
+	CronJobs []CronJob `json:"items"`
+}
+
+// CronJob is used to parse data from kubectl get cronjobs
+type CronJob struct {
+	Metadata pod.Metadata `json:"metadata"`
+	Spec     Spec         `json:"spec"`
+	Status   Status       `json:"status"`
+}
+
+// Spec holds cronjob spec metadata
+type Spec struct {
+	ConcurrencyPolicy string `json:"concurrencyPolicy"`
+	Schedule          string `json:"schedule"`
+	Suspend           bool   `json:"suspend"`
+}
+
+// Status holds cronjob status information
+type Status struct {
+	Active           []ObjectReference `json:"active"`
+	LastScheduleTime *time.Time        `json:"lastScheduleTime"`
+}
+
+// ObjectReference refers to a job of the cronjob
+type ObjectReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// CreateCronJobFromFile will create a CronJob from file with a name
+func CreateCronJobFromFile(filename, name, namespace string) (*CronJob, error) {
+	cmd := exec.Command("k", "create", "-f", filename)
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error trying to create CronJob %s:%s\n", name, string(out))
+		return nil, err
+	}
+	cj, err := Get(name, namespace)
+	if err != nil {
+		log.Printf("Error while trying to fetch CronJob %s:%s\n", name, err)
+		return nil, err
+	}
+	return cj, nil
+}
+
+// CreateCronJobFromFileDeleteIfExists will create a CronJob from file, deleting any pre-existing cronjob with the same name
+func CreateCronJobFromFileDeleteIfExists(filename, name, namespace string) (*CronJob, error) {
+	cj, err := Get(name, namespace)
+	if err == nil {
+		err := cj.Delete(util.DefaultDeleteRetries)
+		if err != nil {
+			return nil, err
+		}
+		_, err = WaitOnDeleted(cj.Metadata.Name, cj.Metadata.Namespace, 5*time.Second, 1*time.Minute)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return CreateCronJobFromFile(filename, name, namespace)
+}
+
+// GetAll will return all cronjobs in a given namespace
+func GetAll(namespace string) (*List, error) {
+	cmd := exec.Command("k", "get", "cronjobs", "-n", namespace, "-o", "json")
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, err
+	}
+	cl := List{}
+	err = json.Unmarshal(out, &cl)
+	if err != nil {
+		log.Printf("Error unmarshalling cronjobs json:%s\n", err)
+		return nil, err
+	}
+	return &cl, nil
+}
+
+// GetAllByPrefix will return all cronjobs in a given namespace that match a prefix
+func GetAllByPrefix(prefix, namespace string) ([]CronJob, error) {
+	cl, err := GetAll(namespace)
+	if err != nil {
+		return nil, err
+	}
+	cronJobs := []CronJob{}
+	for _, cj := range cl.CronJobs {
+		matched, err := regexp.MatchString(prefix+"-.*", cj.Metadata.Name)
+		if err != nil {
+			log.Printf("Error trying to match cronjob name:%s\n", err)
+			return nil, err
+		}
+		if matched {
+			cronJobs = append(cronJobs, cj)
+		}
+	}
+	return cronJobs, nil
+}
+
+// Get will return a cronjob with a given name and namespace
+func Get(name, namespace string) (*CronJob, error) {
+	cmd := exec.Command("k", "get", "cronjobs", name, "-n", namespace, "-o", "json")
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, err
+	}
+	cj := CronJob{}
+	err = json.Unmarshal(out, &cj)
+	if err != nil {
+		log.Printf("Error unmarshalling cronjob json:%s\n", err)
+		return nil, err
+	}
+	return &cj, nil
+}
+
+// Jobs will return the jobs the CronJob has scheduled and the cronjob controller has not cleaned up yet
+func (cj *CronJob) Jobs() ([]job.Job, error) {
+	// the cronjob controller names the jobs it schedules after the CronJob and the scheduled time
+	return job.GetAllByPrefix(cj.Metadata.Name, cj.Metadata.Namespace)
+}
+
+// WaitOnScheduled will wait for the CronJob to schedule a job and return it
+func (cj *CronJob) WaitOnScheduled(sleep, duration time.Duration) (*job.Job, error) {
+	var scheduled *job.Job
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		jobs, err := cj.Jobs()
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return errors.Errorf("CronJob %s has not scheduled any job yet", cj.Metadata.Name)
+		}
+		scheduled = &jobs[0]
+		return nil
+	})
+	if err != nil {
+		err = errors.Wrapf(err, "waiting for CronJob %s in namespace %s to schedule a job", cj.Metadata.Name, cj.Metadata.Namespace)
+		util.ReportWaitFailure(util.WaitFailure{Kind: "cronjob", Name: cj.Metadata.Name, Namespace: cj.Metadata.Namespace, Err: err})
+		return nil, err
+	}
+	return scheduled, nil
+}
+
+// WaitOnSucceeded will wait for at least successes of the jobs scheduled by the CronJob to succeed
+func (cj *CronJob) WaitOnSucceeded(successes int, sleep, duration time.Duration) (bool, error) {
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		jobs, err := cj.Jobs()
+		if err != nil {
+			return err
+		}
+		var succeeded int
+		for _, j := range jobs {
+			completions := j.Spec.Completions
+			if completions == 0 {
+				completions = 1
+			}
+			if j.Status.Succeeded >= completions {
+				succeeded++
+			}
+		}
+		if succeeded < successes {
+			return errors.Errorf("%d of %d jobs of CronJob %s succeeded", succeeded, successes, cj.Metadata.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		util.ReportWaitFailure(util.WaitFailure{Kind: "cronjob", Name: cj.Metadata.Name, Namespace: cj.Metadata.Namespace, Err: err})
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete will delete a CronJob in a given namespace, its jobs and their pods are deleted in the background
+func (cj *CronJob) Delete(retries int) error {
+	return cj.DeleteWithPropagation(retries, job.PropagationBackground)
+}
+
+// DeleteWithPropagation will delete a CronJob in a given namespace, and its jobs and their pods according to policy
+func (cj *CronJob) DeleteWithPropagation(retries int, policy job.PropagationPolicy) error {
+	cascade := "--cascade=true"
+	if policy == job.PropagationOrphan {
+		cascade = "--cascade=false"
+	}
+	err := util.DefaultRetrier().WithMaxAttempts(retries).Do(context.Background(), func() error {
+		cmd := exec.Command("k", "delete", "cronjob", "-n", cj.Metadata.Namespace, cj.Metadata.Name, cascade)
+		kubectlOutput, kubectlError := util.RunAndLogCommand(cmd, deleteTimeout)
+		if kubectlError != nil {
+			log.Printf("Error while trying to delete CronJob %s in namespace %s:%s\n", cj.Metadata.Namespace, cj.Metadata.Name, string(kubectlOutput))
+		}
+		return kubectlError
+	})
+	if err != nil || policy != job.PropagationForeground {
+		return err
+	}
+	// kubectl cannot ask the API server for a foreground deletion, wait for the garbage collector instead
+	return util.DefaultRetrier().WithConstantBackoff(5*time.Second).DoWithTimeout(deleteTimeout, func() error {
+		jobs, err := cj.Jobs()
+		if err != nil {
+			return err
+		}
+		if len(jobs) > 0 {
+			return errors.Errorf("%d jobs of CronJob %s are not deleted yet", len(jobs), cj.Metadata.Name)
+		}
+		return nil
+	})
+}
+
+// WaitOnDeleted returns when a cronjob is successfully deleted
+func WaitOnDeleted(cronJobPrefix, namespace string, sleep, duration time.Duration) (bool, error) {
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		cronJobs, err := GetAllByPrefix(cronJobPrefix, namespace)
+		if err != nil {
+			return util.Permanent(errors.Errorf("Got error while getting CronJobs with prefix \"%s\" in namespace \"%s\"", cronJobPrefix, namespace))
+		}
+		if len(cronJobs) > 0 {
+			return errors.Errorf("%d CronJobs with prefix %s still exist in namespace %s", len(cronJobs), cronJobPrefix, namespace)
+		}
+		return nil
+	})
+	if err != nil {
+		util.ReportWaitFailure(util.WaitFailure{Kind: "cronjob", Name: cronJobPrefix, Namespace: namespace, Err: err})
+		return false, err
+	}
+	return true, nil
+}
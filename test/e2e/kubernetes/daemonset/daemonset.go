@@ -0,0 +1,310 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package daemonset
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/node"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/pod"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/pkg/errors"
+)
+
+// defaultTolerations are the taints the DaemonSet controller tolerates for every DaemonSet pod
+var defaultTolerations = []Toleration{
+	{Key: "node.kubernetes.io/not-ready", Operator: "Exists", Effect: "NoExecute"},
+	{Key: "node.kubernetes.io/unreachable", Operator: "Exists", Effect: "NoExecute"},
+	{Key: "node.kubernetes.io/disk-pressure", Operator: "Exists", Effect: "NoSchedule"},
+	{Key: "node.kubernetes.io/memory-pressure", Operator: "Exists", Effect: "NoSchedule"},
+	{Key: "node.kubernetes.io/pid-pressure", Operator: "Exists", Effect: "NoSchedule"},
+	{Key: "node.kubernetes.io/unschedulable", Operator: "Exists", Effect: "NoSchedule"},
+}
+
+// List is a container that holds all daemonsets returned from doing a kubectl get daemonsets
+type List struct {
+	DaemonSets []DaemonSet `json:"items"`
+}
+
+// DaemonSet is used to parse data from kubectl get daemonsets
+type DaemonSet struct {
+	Metadata pod.Metadata `json:"metadata"`
+	Spec     Spec         `json:"spec"`
+	Status   Status       `json:"status"`
+}
+
+// Spec holds information like the selector and the pod template
+type Spec struct {
+	Selector Selector `json:"selector"`
+	Template Template `json:"template"`
+}
+
+// Selector holds the label query over pods that are managed by the daemonset
+type Selector struct {
+	MatchLabels map[string]string `json:"matchLabels"`
+}
+
+// Template holds the spec of the pods of the daemonset
+type Template struct {
+	Spec TemplateSpec `json:"spec"`
+}
+
+// TemplateSpec holds the scheduling constraints of the pods of the daemonset
+type TemplateSpec struct {
+	NodeSelector map[string]string `json:"nodeSelector"`
+	Affinity     Affinity          `json:"affinity"`
+	Tolerations  []Toleration      `json:"tolerations"`
+}
+
+// Affinity holds the node affinity of the pods of the daemonset
+type Affinity struct {
+	NodeAffinity NodeAffinity `json:"nodeAffinity"`
+}
+
+// NodeAffinity holds the node selector terms the pods of the daemonset require
+type NodeAffinity struct {
+	Required *NodeSelector `json:"requiredDuringSchedulingIgnoredDuringExecution"`
+}
+
+// NodeSelector is satisfied by a node that matches any of its terms
+type NodeSelector struct {
+	NodeSelectorTerms []NodeSelectorTerm `json:"nodeSelectorTerms"`
+}
+
+// NodeSelectorTerm is matched by a node that matches all of its expressions
+type NodeSelectorTerm struct {
+	MatchExpressions []NodeSelectorRequirement `json:"matchExpressions"`
+}
+
+// NodeSelectorRequirement is a node label query
+type NodeSelectorRequirement struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"`
+	Values   []string `json:"values"`
+}
+
+// Toleration allows the pods of the daemonset to run on nodes with a matching taint
+type Toleration struct {
+	Key      string `json:"key"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+	Effect   string `json:"effect"`
+}
+
+// Status holds the scheduling and readiness counts of the daemonset
+type Status struct {
+	DesiredNumberScheduled int `json:"desiredNumberScheduled"`
+	CurrentNumberScheduled int `json:"currentNumberScheduled"`
+	NumberReady            int `json:"numberReady"`
+	NumberAvailable        int `json:"numberAvailable"`
+	UpdatedNumberScheduled int `json:"updatedNumberScheduled"`
+}
+
+// Get will return a daemonset with a given name and namespace
+func Get(name, namespace string) (*DaemonSet, error) {
+	cmd := exec.Command("k", "get", "daemonset", name, "-n", namespace, "-o", "json")
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, err
+	}
+	ds := DaemonSet{}
+	err = json.Unmarshal(out, &ds)
+	if err != nil {
+		log.Printf("Error unmarshalling daemonset json:%s\n", err)
+		return nil, err
+	}
+	return &ds, nil
+}
+
+// GetAll will return all daemonsets in a given namespace
+func GetAll(namespace string) (*List, error) {
+	cmd := exec.Command("k", "get", "daemonsets", "-n", namespace, "-o", "json")
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, err
+	}
+	dsl := List{}
+	err = json.Unmarshal(out, &dsl)
+	if err != nil {
+		log.Printf("Error unmarshalling daemonsets json:%s\n", err)
+		return nil, err
+	}
+	return &dsl, nil
+}
+
+// WaitOnReady waits until the daemonset with a given name and namespace runs exactly one ready pod on every node it can be scheduled on,
+// Linux and Windows alike. It returns the number of nodes the daemonset runs on, by operating system.
+func WaitOnReady(name, namespace string, sleep, duration time.Duration) (map[string]int, error) {
+	var counts map[string]int
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		ds, err := Get(name, namespace)
+		if err != nil {
+			return err
+		}
+		counts, err = ds.validateOnePodPerNode()
+		return err
+	})
+	if err != nil {
+		err = errors.Wrapf(err, "waiting for DaemonSet %s in namespace %s to be ready on every node", name, namespace)
+		util.ReportWaitFailure(util.WaitFailure{Kind: "daemonset", Name: name, Namespace: namespace, Err: err})
+		return nil, err
+	}
+	log.Printf("DaemonSet %s in namespace %s is ready on %s\n", name, namespace, formatCounts(counts))
+	return counts, nil
+}
+
+// validateOnePodPerNode returns the number of eligible nodes by operating system, and an error unless each of them runs one ready pod of the daemonset
+func (ds *DaemonSet) validateOnePodPerNode() (map[string]int, error) {
+	nodeList, err := node.Get()
+	if err != nil {
+		return nil, err
+	}
+	pods, err := ds.Pods()
+	if err != nil {
+		return nil, err
+	}
+	podsByNode := map[string][]pod.Pod{}
+	for _, p := range pods {
+		podsByNode[p.Spec.NodeName] = append(podsByNode[p.Spec.NodeName], p)
+	}
+
+	counts := map[string]int{}
+	var problems []string
+	for _, n := range nodeList.Nodes {
+		if !ds.CanRunOn(n) {
+			continue
+		}
+		counts[n.Status.NodeInfo.OperatingSystem]++
+		nodePods := podsByNode[n.Metadata.Name]
+		switch {
+		case len(nodePods) == 0:
+			problems = append(problems, fmt.Sprintf("no pod on %s node %s", n.Status.NodeInfo.OperatingSystem, n.Metadata.Name))
+		case len(nodePods) > 1:
+			problems = append(problems, fmt.Sprintf("%d pods on %s node %s", len(nodePods), n.Status.NodeInfo.OperatingSystem, n.Metadata.Name))
+		case !nodePods[0].IsReady():
+			problems = append(problems, fmt.Sprintf("pod %s on %s node %s is not ready", nodePods[0].Metadata.Name, n.Status.NodeInfo.OperatingSystem, n.Metadata.Name))
+		}
+	}
+	if len(problems) > 0 {
+		return counts, errors.Errorf("DaemonSet %s is expected on %s: %s", ds.Metadata.Name, formatCounts(counts), strings.Join(problems, ", "))
+	}
+	return counts, nil
+}
+
+// Pods will return the pods of the daemonset
+func (ds *DaemonSet) Pods() ([]pod.Pod, error) {
+	var selectors []string
+	for k, v := range ds.Spec.Selector.MatchLabels {
+		selectors = append(selectors, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(selectors)
+	return pod.GetAllBySelector(strings.Join(selectors, ","), ds.Metadata.Namespace)
+}
+
+// CanRunOn returns true if the pods of the daemonset can be scheduled on node n: its labels match the node selector and the
+// required node affinity of the pod template, and the pods tolerate all of its NoSchedule and NoExecute taints
+func (ds *DaemonSet) CanRunOn(n node.Node) bool {
+	spec := ds.Spec.Template.Spec
+	for k, v := range spec.NodeSelector {
+		if n.Metadata.Labels[k] != v {
+			return false
+		}
+	}
+	if required := spec.Affinity.NodeAffinity.Required; required != nil && len(required.NodeSelectorTerms) > 0 {
+		var matched bool
+		for _, term := range required.NodeSelectorTerms {
+			if term.matches(n.Metadata.Labels) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	tolerations := append(append([]Toleration{}, spec.Tolerations...), defaultTolerations...)
+	for _, taint := range n.Spec.Taints {
+		if taint.Effect == "PreferNoSchedule" {
+			continue
+		}
+		var tolerated bool
+		for _, t := range tolerations {
+			if t.tolerates(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+func (term NodeSelectorTerm) matches(labels map[string]string) bool {
+	for _, r := range term.MatchExpressions {
+		value, ok := labels[r.Key]
+		switch r.Operator {
+		case "In":
+			if !ok || !contains(r.Values, value) {
+				return false
+			}
+		case "NotIn":
+			if ok && contains(r.Values, value) {
+				return false
+			}
+		case "Exists":
+			if !ok {
+				return false
+			}
+		case "DoesNotExist":
+			if ok {
+				return false
+			}
+		default:
+			// Gt and Lt are not used by the addons, treat them as unsatisfiable rather than guess
+			return false
+		}
+	}
+	return true
+}
+
+func (t Toleration) tolerates(taint node.Taint) bool {
+	if t.Effect != "" && t.Effect != taint.Effect {
+		return false
+	}
+	if t.Operator == "Exists" {
+		return t.Key == "" || t.Key == taint.Key
+	}
+	return t.Key == taint.Key && t.Value == taint.Value
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func formatCounts(counts map[string]int) string {
+	var s []string
+	for os, n := range counts {
+		s = append(s, fmt.Sprintf("%d %s nodes", n, os))
+	}
+	sort.Strings(s)
+	if len(s) == 0 {
+		return "no nodes"
+	}
+	return strings.Join(s, " and ")
+}
@@ -0,0 +1,101 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package daemonset
+
+import (
+	"testing"
+
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/node"
+)
+
+func TestCanRunOn(t *testing.T) {
+	linuxAgent := node.Node{
+		Metadata: node.Metadata{Name: "k8s-agentpool1-12345678-0", Labels: map[string]string{"beta.kubernetes.io/os": "linux", "kubernetes.io/role": "agent"}},
+	}
+	windowsAgent := node.Node{
+		Metadata: node.Metadata{Name: "1234k8s010", Labels: map[string]string{"beta.kubernetes.io/os": "windows", "kubernetes.io/role": "agent"}},
+	}
+	master := node.Node{
+		Metadata: node.Metadata{Name: "k8s-master-12345678-0", Labels: map[string]string{"beta.kubernetes.io/os": "linux", "kubernetes.io/role": "master"}},
+		Spec:     node.Spec{Taints: []node.Taint{{Key: "node-role.kubernetes.io/master", Value: "true", Effect: "NoSchedule"}}},
+	}
+	gpuAgent := node.Node{
+		Metadata: node.Metadata{Name: "k8s-gpupool-12345678-0", Labels: map[string]string{"beta.kubernetes.io/os": "linux", "accelerator": "nvidia"}},
+		Spec:     node.Spec{Taints: []node.Taint{{Key: "nvidia.com/gpu", Effect: "PreferNoSchedule"}}},
+	}
+	cordoned := node.Node{
+		Metadata: node.Metadata{Name: "k8s-agentpool1-12345678-1", Labels: map[string]string{"beta.kubernetes.io/os": "linux"}},
+		Spec:     node.Spec{Unschedulable: true, Taints: []node.Taint{{Key: "node.kubernetes.io/unschedulable", Effect: "NoSchedule"}}},
+	}
+
+	cases := []struct {
+		name     string
+		spec     TemplateSpec
+		expected map[string]bool
+	}{
+		{
+			name: "linux node selector",
+			spec: TemplateSpec{NodeSelector: map[string]string{"beta.kubernetes.io/os": "linux"}},
+			expected: map[string]bool{
+				linuxAgent.Metadata.Name:   true,
+				windowsAgent.Metadata.Name: false,
+				master.Metadata.Name:       false,
+				gpuAgent.Metadata.Name:     true,
+				cordoned.Metadata.Name:     true,
+			},
+		},
+		{
+			name: "tolerate everything",
+			spec: TemplateSpec{Tolerations: []Toleration{{Operator: "Exists", Effect: "NoSchedule"}, {Operator: "Exists", Effect: "NoExecute"}}},
+			expected: map[string]bool{
+				linuxAgent.Metadata.Name:   true,
+				windowsAgent.Metadata.Name: true,
+				master.Metadata.Name:       true,
+			},
+		},
+		{
+			name: "master toleration",
+			spec: TemplateSpec{Tolerations: []Toleration{{Key: "node-role.kubernetes.io/master", Operator: "Equal", Value: "true", Effect: "NoSchedule"}}},
+			expected: map[string]bool{
+				master.Metadata.Name: true,
+			},
+		},
+		{
+			name: "required node affinity",
+			spec: TemplateSpec{Affinity: Affinity{NodeAffinity: NodeAffinity{Required: &NodeSelector{NodeSelectorTerms: []NodeSelectorTerm{
+				{MatchExpressions: []NodeSelectorRequirement{{Key: "accelerator", Operator: "In", Values: []string{"nvidia"}}}},
+			}}}}},
+			expected: map[string]bool{
+				linuxAgent.Metadata.Name: false,
+				gpuAgent.Metadata.Name:   true,
+			},
+		},
+		{
+			name: "node affinity terms are ORed",
+			spec: TemplateSpec{Affinity: Affinity{NodeAffinity: NodeAffinity{Required: &NodeSelector{NodeSelectorTerms: []NodeSelectorTerm{
+				{MatchExpressions: []NodeSelectorRequirement{{Key: "accelerator", Operator: "Exists"}}},
+				{MatchExpressions: []NodeSelectorRequirement{{Key: "beta.kubernetes.io/os", Operator: "NotIn", Values: []string{"linux"}}}},
+			}}}}},
+			expected: map[string]bool{
+				linuxAgent.Metadata.Name:   false,
+				windowsAgent.Metadata.Name: true,
+				gpuAgent.Metadata.Name:     true,
+			},
+		},
+	}
+
+	nodes := []node.Node{linuxAgent, windowsAgent, master, gpuAgent, cordoned}
+	for _, c := range cases {
+		ds := DaemonSet{Spec: Spec{Template: Template{Spec: c.spec}}}
+		for _, n := range nodes {
+			expected, ok := c.expected[n.Metadata.Name]
+			if !ok {
+				continue
+			}
+			if canRun := ds.CanRunOn(n); canRun != expected {
+				t.Errorf("%s: expected CanRunOn(%s) to be %t, got %t", c.name, n.Metadata.Name, expected, canRun)
+			}
+		}
+	}
+}
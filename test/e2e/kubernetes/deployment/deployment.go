@@ -7,10 +7,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/hpa"
@@ -30,6 +35,7 @@ type List struct {
 // Deployment repesentes a kubernetes deployment
 type Deployment struct {
 	Metadata Metadata `json:"metadata"`
+	Spec     Spec     `json:"spec"`
 }
 
 // Metadata holds information like labels, name, and namespace
@@ -44,9 +50,15 @@ type Metadata struct {
 // Spec holds information the deployment strategy and number of replicas
 type Spec struct {
 	Replicas int      `json:"replicas"`
+	Selector Selector `json:"selector"`
 	Template Template `json:"template"`
 }
 
+// Selector holds the label query over pods that are managed by the deployment
+type Selector struct {
+	MatchLabels map[string]string `json:"matchLabels"`
+}
+
 // Template is used for fetching the deployment spec -> containers
 type Template struct {
 	TemplateSpec TemplateSpec `json:"spec"`
@@ -89,6 +101,42 @@ func CreateLinuxDeploy(image, name, namespace, miscOpts string) (*Deployment, er
 	return d, nil
 }
 
+// CreateDeploymentFromFile will create a deployment from a file with a name in a namespace
+func CreateDeploymentFromFile(filename, name, namespace string) (*Deployment, error) {
+	cmd := exec.Command("k", "create", "-f", filename, "-n", namespace)
+	out, err := util.RunAndLogCommand(cmd, commandTimeout)
+	if err != nil {
+		log.Printf("Error trying to create Deployment %s from %s in namespace %s:%s\n", name, filename, namespace, string(out))
+		return nil, err
+	}
+	d, err := Get(name, namespace)
+	if err != nil {
+		log.Printf("Error while trying to fetch Deployment %s in namespace %s:%s\n", name, namespace, err)
+		return nil, err
+	}
+	return d, nil
+}
+
+// CreateDeploymentFromTemplate will create a deployment from a file executed as a template with data, the file can
+// have other objects the deployment needs
+func CreateDeploymentFromTemplate(filename, name, namespace string, data interface{}) (*Deployment, error) {
+	t, err := template.ParseFiles(filename)
+	if err != nil {
+		return nil, err
+	}
+	tempfile, err := ioutil.TempFile("", "*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	// the executed template can have secrets
+	defer os.Remove(tempfile.Name())
+	defer tempfile.Close()
+	if err = t.Execute(tempfile, data); err != nil {
+		return nil, err
+	}
+	return CreateDeploymentFromFile(tempfile.Name(), name, namespace)
+}
+
 // CreateLinuxDeployIfNotExist first checks if a deployment already exists, and return it if so
 // If not, we call CreateLinuxDeploy
 func CreateLinuxDeployIfNotExist(image, name, namespace, miscOpts string) (*Deployment, error) {
@@ -381,11 +429,33 @@ func (d *Deployment) CreateDeploymentHPADeleteIfExist(cpuPercent, min, max int)
 	return d.CreateDeploymentHPA(cpuPercent, min, max)
 }
 
-// Pods will return all pods related to a deployment
+// Pods will return all pods related to a deployment, preferring the deployment's label selector over name prefix matching
 func (d *Deployment) Pods() ([]pod.Pod, error) {
+	if selector := d.LabelSelector(); selector != "" {
+		return pod.GetAllBySelector(selector, d.Metadata.Namespace)
+	}
 	return pod.GetAllByPrefix(d.Metadata.Name, d.Metadata.Namespace)
 }
 
+// LabelSelector returns the deployment's matchLabels in kubectl -l format, or an empty string if it has none
+func (d *Deployment) LabelSelector() string {
+	var selectors []string
+	for k, v := range d.Spec.Selector.MatchLabels {
+		selectors = append(selectors, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(selectors)
+	return strings.Join(selectors, ",")
+}
+
+// WaitOnReady waits until all pods related to a deployment are in a Ready state,
+// preferring the deployment's label selector over name prefix matching
+func (d *Deployment) WaitOnReady(successesNeeded int, sleep, duration time.Duration) (bool, error) {
+	if selector := d.LabelSelector(); selector != "" {
+		return pod.WaitOnReadyBySelector(selector, d.Metadata.Namespace, successesNeeded, sleep, duration)
+	}
+	return pod.WaitOnReady(d.Metadata.Name, d.Metadata.Namespace, successesNeeded, sleep, duration)
+}
+
 // WaitForReplicas waits for a pod replica count between min and max
 func (d *Deployment) WaitForReplicas(min, max int, sleep, duration time.Duration) ([]pod.Pod, error) {
 	readyCh := make(chan bool, 1)
@@ -400,7 +470,7 @@ func (d *Deployment) WaitForReplicas(min, max int, sleep, duration time.Duration
 				errCh <- errors.Errorf("Timeout exceeded (%s) while waiting for minimum %d and maximum %d Pod replicas from Deployment %s", duration.String(), min, max, d.Metadata.Name)
 			default:
 				var err error
-				pods, err = pod.GetAllByPrefix(d.Metadata.Name, d.Metadata.Namespace)
+				pods, err = d.Pods()
 				if err != nil {
 					errCh <- err
 					return
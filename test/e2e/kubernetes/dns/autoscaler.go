@@ -0,0 +1,155 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package dns
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"os/exec"
+	"time"
+
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/deployment"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/node"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// the dns-autoscaler addon reads its parameters from this configmap and scales the coredns deployment
+	autoscalerConfigMap = "dns-autoscaler"
+	autoscalerNamespace = "kube-system"
+	coreDNSDeployment   = "coredns"
+	commandTimeout      = 1 * time.Minute
+)
+
+// LinearParams are the parameters of the linear mode of the cluster-proportional-autoscaler run by the dns-autoscaler addon
+type LinearParams struct {
+	CoresPerReplica           float64 `json:"coresPerReplica,omitempty"`
+	NodesPerReplica           float64 `json:"nodesPerReplica,omitempty"`
+	Min                       int     `json:"min,omitempty"`
+	Max                       int     `json:"max,omitempty"`
+	PreventSinglePointFailure bool    `json:"preventSinglePointFailure,omitempty"`
+	IncludeUnschedulableNodes bool    `json:"includeUnschedulableNodes,omitempty"`
+}
+
+type configMap struct {
+	Data map[string]string `json:"data"`
+}
+
+// Replicas returns the replicas the autoscaler sets for nodes and cores, the larger of the replicas for the cores and for the nodes
+func (p LinearParams) Replicas(nodes, cores int) int {
+	fromCores := p.replicasFor(cores, p.CoresPerReplica)
+	fromNodes := p.replicasFor(nodes, p.NodesPerReplica)
+	if p.PreventSinglePointFailure && nodes > 1 && fromNodes < 2 {
+		fromNodes = 2
+	}
+	if fromCores > fromNodes {
+		return fromCores
+	}
+	return fromNodes
+}
+
+func (p LinearParams) replicasFor(resources int, perReplica float64) int {
+	if perReplica == 0 {
+		return 1
+	}
+	replicas := math.Ceil(float64(resources) / perReplica)
+	if p.Max != 0 {
+		replicas = math.Min(float64(p.Max), replicas)
+	}
+	return int(math.Max(float64(p.Min), replicas))
+}
+
+// GetAutoscalerParams returns the linear parameters the dns-autoscaler addon scales coredns with
+func GetAutoscalerParams() (*LinearParams, error) {
+	cmd := exec.Command("k", "get", "configmap", autoscalerConfigMap, "-n", autoscalerNamespace, "-o", "json")
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error trying to get configmap %s in namespace %s:%s\n", autoscalerConfigMap, autoscalerNamespace, string(out))
+		return nil, err
+	}
+	cm := configMap{}
+	if err = json.Unmarshal(out, &cm); err != nil {
+		log.Printf("Error unmarshalling configmap json:%s\n", err)
+		return nil, err
+	}
+	linear, ok := cm.Data["linear"]
+	if !ok {
+		return nil, errors.Errorf("configmap %s in namespace %s has no linear parameters", autoscalerConfigMap, autoscalerNamespace)
+	}
+	p := LinearParams{}
+	if err = json.Unmarshal([]byte(linear), &p); err != nil {
+		return nil, errors.Wrapf(err, "parsing the linear parameters of configmap %s", autoscalerConfigMap)
+	}
+	return &p, nil
+}
+
+// SetAutoscalerParams replaces the linear parameters the dns-autoscaler addon scales coredns with
+func SetAutoscalerParams(p LinearParams) error {
+	linear, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(configMap{Data: map[string]string{"linear": string(linear)}})
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("k", "patch", "configmap", autoscalerConfigMap, "-n", autoscalerNamespace, "--type", "merge", "-p", string(patch))
+	out, err := util.RunAndLogCommand(cmd, commandTimeout)
+	if err != nil {
+		log.Printf("Error trying to patch configmap %s in namespace %s:%s\n", autoscalerConfigMap, autoscalerNamespace, string(out))
+		return err
+	}
+	return nil
+}
+
+// ExpectedReplicas returns the coredns replicas the autoscaler sets with p for the current nodes of the cluster
+func ExpectedReplicas(p LinearParams) (int, error) {
+	list, err := node.Get()
+	if err != nil {
+		return 0, err
+	}
+	var nodes int
+	var cores int64
+	for _, n := range list.Nodes {
+		if n.Spec.Unschedulable && !p.IncludeUnschedulableNodes {
+			continue
+		}
+		nodes++
+		q, err := resource.ParseQuantity(n.Status.Allocatable.CPU)
+		if err != nil {
+			return 0, errors.Wrapf(err, "parsing the allocatable cpu of node %s", n.Metadata.Name)
+		}
+		cores += q.Value()
+	}
+	return p.Replicas(nodes, int(cores)), nil
+}
+
+// WaitOnAutoscaledReplicas waits for the autoscaler to scale the coredns deployment to the replicas expected with p, and returns them
+func WaitOnAutoscaledReplicas(p LinearParams, sleep, duration time.Duration) (int, error) {
+	var expected int
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		var err error
+		if expected, err = ExpectedReplicas(p); err != nil {
+			return err
+		}
+		d, err := deployment.Get(coreDNSDeployment, autoscalerNamespace)
+		if err != nil {
+			return err
+		}
+		if d.Spec.Replicas != expected {
+			return errors.Errorf("deployment %s has %d replicas, expected %d", coreDNSDeployment, d.Spec.Replicas, expected)
+		}
+		return nil
+	})
+	if err != nil {
+		err = errors.Wrap(err, "waiting for the dns-autoscaler to scale coredns")
+		util.ReportWaitFailure(util.WaitFailure{Kind: "deployment", Name: coreDNSDeployment, Namespace: autoscalerNamespace, Err: err})
+		return 0, err
+	}
+	return expected, nil
+}
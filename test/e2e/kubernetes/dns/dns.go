@@ -0,0 +1,269 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"path"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/pod"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/pkg/errors"
+)
+
+// RecordType is a DNS resource record type
+type RecordType string
+
+const (
+	// A is an IPv4 address record
+	A RecordType = "A"
+	// AAAA is an IPv6 address record
+	AAAA RecordType = "AAAA"
+	// CNAME is a canonical name record
+	CNAME RecordType = "CNAME"
+	// SRV is a service locator record
+	SRV RecordType = "SRV"
+)
+
+// lookupFailed is printed by the lookup commands when the resolver finds no records, so that the
+// output of a failed lookup is not dropped by kubectl exec
+const lookupFailed = "LOOKUP_FAILED"
+
+// Lookup is a DNS query and its expected outcome
+type Lookup struct {
+	Name string
+	Type RecordType
+	// NotFound is true if the name is expected not to resolve, e.g. to check that the cluster DNS does not answer for every name
+	NotFound bool
+}
+
+func (l Lookup) String() string {
+	return fmt.Sprintf("%s %s", l.Type, l.Name)
+}
+
+// Result is the outcome of a Lookup, Answers are the records of the requested type returned by the last attempt
+type Result struct {
+	Lookup  Lookup
+	Answers []string
+	Err     error
+}
+
+// ServiceFQDN returns the fully qualified name of a service in the cluster DNS
+func ServiceFQDN(name, namespace, clusterDomain string) string {
+	return fmt.Sprintf("%s.%s.svc.%s", name, namespace, clusterDomain)
+}
+
+// ClusterLookups returns the lookups of the kubernetes API server service that the cluster DNS must answer,
+// and of a service that does not exist, which it must not
+func ClusterLookups(clusterDomain string) []Lookup {
+	fqdn := ServiceFQDN("kubernetes", "default", clusterDomain)
+	return []Lookup{
+		{Name: fqdn, Type: A},
+		{Name: "_https._tcp." + fqdn, Type: SRV},
+		{Name: ServiceFQDN("does-not-exist", "default", clusterDomain), Type: A, NotFound: true},
+	}
+}
+
+// ServiceLookups returns the lookups of a service by its fully qualified name and through the pod DNS search path
+func ServiceLookups(name, namespace, clusterDomain string) []Lookup {
+	return []Lookup{
+		{Name: ServiceFQDN(name, namespace, clusterDomain), Type: A},
+		{Name: fmt.Sprintf("%s.%s", name, namespace), Type: A},
+	}
+}
+
+// ExternalLookups returns lookups of names outside the cluster, which the cluster DNS forwards upstream
+func ExternalLookups() []Lookup {
+	return []Lookup{
+		{Name: "google.com", Type: A},
+		{Name: "www.bing.com", Type: CNAME},
+	}
+}
+
+// Validate runs every lookup in p, retrying each one with r until it has the expected outcome or ctx is done.
+// It returns the result of every lookup, and an error listing the lookups that failed.
+func Validate(ctx context.Context, p *pod.Pod, osType api.OSType, lookups []Lookup, r util.Retrier) ([]Result, error) {
+	var resolver string
+	var shell pod.WindowsShell
+	if osType == api.Windows {
+		if len(p.Spec.Containers) > 0 {
+			shell = pod.GetWindowsShell(p.Spec.Containers[0].Image)
+		} else {
+			shell = pod.WindowsShellPowershell
+		}
+	} else {
+		var err error
+		if resolver, err = detectResolver(p); err != nil {
+			return nil, err
+		}
+		if resolver == "" {
+			return nil, errors.Errorf("Pod %s has neither dig nor nslookup", p.Metadata.Name)
+		}
+	}
+
+	var results []Result
+	var failed []string
+	for _, l := range lookups {
+		l := l
+		result := Result{Lookup: l}
+		result.Err = r.Do(ctx, func() error {
+			var command []string
+			if osType == api.Windows {
+				command = shell.Command(windowsLookup(shell, l))
+			} else {
+				command = []string{"/bin/sh", "-c", linuxLookup(resolver, l)}
+			}
+			out, err := p.Exec(append([]string{"--"}, command...)...)
+			if err != nil {
+				return err
+			}
+			if osType == api.Windows && shell != pod.WindowsShellCmd {
+				result.Answers = parseResolveDNSName(string(out), l.Type)
+			} else if resolver == "dig" {
+				result.Answers = parseDig(string(out), l.Type)
+			} else {
+				result.Answers = parseNslookup(string(out), l.Type)
+			}
+			return l.check(result.Answers)
+		})
+		if result.Err != nil {
+			log.Printf("DNS lookup %s from pod %s failed:%s\n", l, p.Metadata.Name, result.Err)
+			failed = append(failed, l.String())
+		} else {
+			log.Printf("DNS lookup %s from pod %s returned %v\n", l, p.Metadata.Name, result.Answers)
+		}
+		results = append(results, result)
+	}
+	if len(failed) > 0 {
+		return results, errors.Errorf("DNS lookups from pod %s failed: %s", p.Metadata.Name, strings.Join(failed, ", "))
+	}
+	return results, nil
+}
+
+// check returns an error unless answers is the expected outcome of l
+func (l Lookup) check(answers []string) error {
+	if l.NotFound && len(answers) > 0 {
+		return errors.Errorf("expected no %s records for %s, got %v", l.Type, l.Name, answers)
+	}
+	if !l.NotFound && len(answers) == 0 {
+		return errors.Errorf("no %s records found for %s", l.Type, l.Name)
+	}
+	return nil
+}
+
+// detectResolver returns the first of dig and nslookup that is available in the pod, or an empty string if there is none
+func detectResolver(p *pod.Pod) (string, error) {
+	out, err := p.Exec("--", "/bin/sh", "-c", "command -v dig || command -v nslookup || true")
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	return path.Base(strings.TrimSpace(lines[0])), nil
+}
+
+// linuxLookup returns the shell command that looks up l with resolver, which is one of dig and nslookup
+func linuxLookup(resolver string, l Lookup) string {
+	if resolver == "dig" {
+		return fmt.Sprintf("dig +short +search -t %s '%s' 2>&1 || echo %s", l.Type, l.Name, lookupFailed)
+	}
+	// older busybox nslookup only takes a name and returns its A and AAAA records
+	if l.Type == A || l.Type == AAAA {
+		return fmt.Sprintf("nslookup '%s' 2>&1 || echo %s", l.Name, lookupFailed)
+	}
+	return fmt.Sprintf("nslookup -type=%s '%s' 2>&1 || echo %s", l.Type, l.Name, lookupFailed)
+}
+
+// windowsLookup returns the command that looks up l in shell, nslookup for cmd and Resolve-DnsName otherwise
+func windowsLookup(shell pod.WindowsShell, l Lookup) string {
+	if shell == pod.WindowsShellCmd {
+		return fmt.Sprintf("nslookup -type=%s %s 2>&1 || echo %s", l.Type, l.Name, lookupFailed)
+	}
+	// print one "<type> <value>" line per record, Resolve-DnsName returns the CNAME chain and the SOA of negative answers as well
+	return fmt.Sprintf(`try { Resolve-DnsName -Name '%s' -Type %s -DnsOnly -ErrorAction Stop | ForEach-Object { "$($_.Type) $($_.IPAddress)$($_.NameHost)$($_.NameTarget)" } } catch { '%s' }`, l.Name, l.Type, lookupFailed)
+}
+
+// parseDig returns the records of type t in the output of dig +short, which prints the CNAME chain before the addresses
+func parseDig(out string, t RecordType) []string {
+	var answers []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == lookupFailed || strings.HasPrefix(line, ";") {
+			continue
+		}
+		switch t {
+		case A, AAAA:
+			if addressType(line) == t {
+				answers = append(answers, line)
+			}
+		default:
+			answers = append(answers, line)
+		}
+	}
+	return answers
+}
+
+// parseNslookup returns the records of type t in the output of busybox, bind or Windows nslookup
+func parseNslookup(out string, t RecordType) []string {
+	var answers []string
+	// the first block describes the server that answered the query
+	blocks := strings.SplitN(strings.TrimSpace(strings.Replace(out, "\r\n", "\n", -1)), "\n\n", 2)
+	if len(blocks) < 2 {
+		return nil
+	}
+	for _, line := range strings.Split(blocks[1], "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.Contains(line, "canonical name ="):
+			if t == CNAME {
+				answers = append(answers, strings.TrimSpace(strings.SplitN(line, "=", 2)[1]))
+			}
+		case strings.Contains(line, "service ="):
+			if t == SRV {
+				answers = append(answers, strings.TrimSpace(strings.SplitN(line, "=", 2)[1]))
+			}
+		case strings.HasPrefix(line, "Address"), strings.HasPrefix(line, "Addresses:"):
+			// "Address: 10.0.0.1", "Address 1: 10.0.0.1 kubernetes.default.svc.cluster.local" or "Addresses:  10.0.0.1"
+			fields := strings.Fields(strings.SplitN(line, ":", 2)[1])
+			if len(fields) > 0 && addressType(fields[0]) == t {
+				answers = append(answers, fields[0])
+			}
+		case addressType(line) != "":
+			// Windows nslookup prints further addresses on their own lines
+			if addressType(line) == t {
+				answers = append(answers, line)
+			}
+		}
+	}
+	return answers
+}
+
+// parseResolveDNSName returns the records of type t in the "<type> <value>" lines printed by windowsLookup
+func parseResolveDNSName(out string, t RecordType) []string {
+	var answers []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && RecordType(fields[0]) == t {
+			answers = append(answers, fields[1])
+		}
+	}
+	return answers
+}
+
+// addressType returns A or AAAA for an IP address, or an empty RecordType if s is not one
+func addressType(s string) RecordType {
+	ip := net.ParseIP(s)
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return A
+	default:
+		return AAAA
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package dns
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNslookup(t *testing.T) {
+	cases := []struct {
+		name       string
+		out        string
+		recordType RecordType
+		expected   []string
+	}{
+		{
+			name: "old busybox",
+			out: `Server:    10.0.0.10
+Address 1: 10.0.0.10 kube-dns.kube-system.svc.cluster.local
+
+Name:      kubernetes.default.svc.cluster.local
+Address 1: 10.0.0.1 kubernetes.default.svc.cluster.local
+`,
+			recordType: A,
+			expected:   []string{"10.0.0.1"},
+		},
+		{
+			name: "bind with a CNAME chain",
+			out: `Server:		10.0.0.10
+Address:	10.0.0.10#53
+
+Non-authoritative answer:
+www.bing.com	canonical name = a-0001.a-afdentry.net.trafficmanager.net.
+Name:	a-0001.a-afdentry.net.trafficmanager.net
+Address: 13.107.21.200
+Name:	a-0001.a-afdentry.net.trafficmanager.net
+Address: 2620:1ec:c11::200
+`,
+			recordType: CNAME,
+			expected:   []string{"a-0001.a-afdentry.net.trafficmanager.net."},
+		},
+		{
+			name: "bind AAAA",
+			out: `Server:		10.0.0.10
+Address:	10.0.0.10#53
+
+Name:	a-0001.a-afdentry.net.trafficmanager.net
+Address: 13.107.21.200
+Name:	a-0001.a-afdentry.net.trafficmanager.net
+Address: 2620:1ec:c11::200
+`,
+			recordType: AAAA,
+			expected:   []string{"2620:1ec:c11::200"},
+		},
+		{
+			name: "SRV",
+			out: `Server:		10.0.0.10
+Address:	10.0.0.10#53
+
+_https._tcp.kubernetes.default.svc.cluster.local	service = 0 100 443 kubernetes.default.svc.cluster.local.
+`,
+			recordType: SRV,
+			expected:   []string{"0 100 443 kubernetes.default.svc.cluster.local."},
+		},
+		{
+			name:       "windows",
+			out:        "Server:  kube-dns.kube-system.svc.cluster.local\r\nAddress:  10.0.0.10\r\n\r\nName:    google.com\r\nAddresses:  2a00:1450:4009:80b::200e\r\n          172.217.169.14\r\n",
+			recordType: A,
+			expected:   []string{"172.217.169.14"},
+		},
+		{
+			name: "NXDOMAIN",
+			out: `Server:		10.0.0.10
+Address:	10.0.0.10#53
+
+** server can't find does-not-exist.default.svc.cluster.local: NXDOMAIN
+
+LOOKUP_FAILED
+`,
+			recordType: A,
+			expected:   nil,
+		},
+	}
+
+	for _, c := range cases {
+		if answers := parseNslookup(c.out, c.recordType); !reflect.DeepEqual(answers, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, answers)
+		}
+	}
+}
+
+func TestParseDig(t *testing.T) {
+	out := "a-0001.a-afdentry.net.trafficmanager.net.\n13.107.21.200\n"
+	if answers := parseDig(out, A); !reflect.DeepEqual(answers, []string{"13.107.21.200"}) {
+		t.Errorf("expected the A record, got %v", answers)
+	}
+	if answers := parseDig(";; connection timed out; no servers could be reached\nLOOKUP_FAILED\n", A); answers != nil {
+		t.Errorf("expected no records, got %v", answers)
+	}
+}
+
+func TestParseResolveDNSName(t *testing.T) {
+	out := "CNAME a-0001.a-afdentry.net.trafficmanager.net\r\nA 13.107.21.200\r\n"
+	if answers := parseResolveDNSName(out, CNAME); !reflect.DeepEqual(answers, []string{"a-0001.a-afdentry.net.trafficmanager.net"}) {
+		t.Errorf("expected the CNAME record, got %v", answers)
+	}
+	if answers := parseResolveDNSName("LOOKUP_FAILED\r\n", A); answers != nil {
+		t.Errorf("expected no records, got %v", answers)
+	}
+}
+
+func TestLookupCheck(t *testing.T) {
+	found := Lookup{Name: "kubernetes.default.svc.cluster.local", Type: A}
+	if err := found.check([]string{"10.0.0.1"}); err != nil {
+		t.Errorf("expected %s to pass, got %s", found, err)
+	}
+	if err := found.check(nil); err == nil {
+		t.Errorf("expected %s to fail without answers", found)
+	}
+	notFound := Lookup{Name: "does-not-exist.default.svc.cluster.local", Type: A, NotFound: true}
+	if err := notFound.check(nil); err != nil {
+		t.Errorf("expected %s to pass, got %s", notFound, err)
+	}
+	if err := notFound.check([]string{"10.0.0.1"}); err == nil {
+		t.Errorf("expected %s to fail with answers", notFound)
+	}
+}
+
+func TestParseDNSPerf(t *testing.T) {
+	out := `DNS Performance Testing Tool
+Version 2.2.1
+
+[Status] Command line: dnsperf -s 10.0.0.10 -d /tmp/queries -l 60 -Q 500
+[Status] Sending queries (to 10.0.0.10)
+[Status] Started at: Mon Jun  3 10:00:00 2019
+[Status] Testing complete (time limit)
+
+Statistics:
+
+  Queries sent:         30000
+  Queries completed:    29970 (99.90%)
+  Queries lost:         30 (0.10%)
+
+  Response codes:       NOERROR 29940 (99.90%), SERVFAIL 30 (0.10%)
+  Average packet size:  request 54, response 70
+  Run time (s):         60.002315
+  Queries per second:   499.480728
+`
+	r, err := parseDNSPerf(out)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := &LoadResult{
+		Sent:             30000,
+		Completed:        29970,
+		Lost:             30,
+		ResponseCodes:    map[string]int{"NOERROR": 29940, "SERVFAIL": 30},
+		QueriesPerSecond: 499.480728,
+	}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+	if rate := r.ErrorRate(); rate != 0.002 {
+		t.Errorf("expected an error rate of 0.002, got %v", rate)
+	}
+
+	if _, err = parseDNSPerf("dnsperf: command not found"); err == nil {
+		t.Errorf("expected an error without statistics")
+	}
+}
+
+func TestLinearParamsReplicas(t *testing.T) {
+	cases := []struct {
+		name     string
+		params   LinearParams
+		nodes    int
+		cores    int
+		expected int
+	}{
+		{"addon defaults", LinearParams{CoresPerReplica: 256, NodesPerReplica: 16, Min: 1}, 6, 24, 1},
+		{"nodes dominate", LinearParams{CoresPerReplica: 256, NodesPerReplica: 1, Min: 1}, 6, 24, 6},
+		{"cores dominate", LinearParams{CoresPerReplica: 4, NodesPerReplica: 16, Min: 1}, 6, 24, 6},
+		{"max", LinearParams{NodesPerReplica: 1, Max: 3}, 6, 24, 3},
+		{"min", LinearParams{NodesPerReplica: 16, Min: 2}, 6, 24, 2},
+		{"prevent single point of failure", LinearParams{NodesPerReplica: 16, PreventSinglePointFailure: true}, 6, 24, 2},
+		{"single node", LinearParams{NodesPerReplica: 16, PreventSinglePointFailure: true}, 1, 4, 1},
+	}
+	for _, c := range cases {
+		if replicas := c.params.Replicas(c.nodes, c.cores); replicas != c.expected {
+			t.Errorf("%s: expected %d replicas, got %d", c.name, c.expected, replicas)
+		}
+	}
+}
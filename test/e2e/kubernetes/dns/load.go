@@ -0,0 +1,139 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package dns
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/pod"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/pkg/errors"
+)
+
+// LoadConfig is a sustained query load sent by dnsperf to a DNS server
+type LoadConfig struct {
+	// Image must have dnsperf and a shell
+	Image string
+	// Server is the address of the DNS server, e.g. the cluster IP of the kube-dns service
+	Server string
+	// Names are queried for A records in turn
+	Names    []string
+	Duration time.Duration
+	// QPS caps the queries per second, dnsperf sends as many as it can if 0
+	QPS     int
+	Clients int
+}
+
+// LoadResult holds the statistics dnsperf reports at the end of a run
+type LoadResult struct {
+	Sent             int
+	Completed        int
+	Lost             int
+	ResponseCodes    map[string]int
+	QueriesPerSecond float64
+}
+
+var (
+	dnsperfCount         = regexp.MustCompile(`(?m)^\s*Queries (sent|completed|lost):\s+(\d+)`)
+	dnsperfResponseCodes = regexp.MustCompile(`(?m)^\s*Response codes:\s+(.*)$`)
+	dnsperfResponseCode  = regexp.MustCompile(`([A-Z]+) (\d+)`)
+	dnsperfQPS           = regexp.MustCompile(`(?m)^\s*Queries per second:\s+([0-9.]+)`)
+)
+
+// ErrorRate returns the fraction of the queries sent that were lost or answered with an error, names that do not exist count as errors
+func (r *LoadResult) ErrorRate() float64 {
+	if r.Sent == 0 {
+		return 1
+	}
+	failed := r.Lost
+	for code, n := range r.ResponseCodes {
+		if code != "NOERROR" {
+			failed += n
+		}
+	}
+	return float64(failed) / float64(r.Sent)
+}
+
+func (r *LoadResult) String() string {
+	return fmt.Sprintf("%d queries sent, %d completed, %d lost, response codes %v, %.1f queries per second", r.Sent, r.Completed, r.Lost, r.ResponseCodes, r.QueriesPerSecond)
+}
+
+// RunLoad runs dnsperf in a linux pod with a name until c.Duration elapsed, and returns its statistics.
+// The pod is deleted once it completed.
+func RunLoad(name, namespace string, c LoadConfig, sleep, timeout time.Duration) (*LoadResult, error) {
+	if len(c.Names) == 0 {
+		return nil, errors.New("a DNS load needs names to query")
+	}
+	var queries []string
+	for _, n := range c.Names {
+		queries = append(queries, fmt.Sprintf("%s A", n))
+	}
+	args := []string{"dnsperf", "-s", c.Server, "-d", "/tmp/queries", "-l", strconv.Itoa(int(c.Duration.Seconds()))}
+	if c.QPS > 0 {
+		args = append(args, "-Q", strconv.Itoa(c.QPS))
+	}
+	if c.Clients > 0 {
+		args = append(args, "-c", strconv.Itoa(c.Clients))
+	}
+	command := fmt.Sprintf("printf '%s\\n' > /tmp/queries && %s", strings.Join(queries, "\\n"), strings.Join(args, " "))
+
+	p, err := pod.RunLinuxPod(c.Image, name, namespace, command, true, sleep, timeout, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Delete(util.DefaultDeleteRetries)
+	if _, err = p.WaitOnSucceeded(sleep, c.Duration+timeout); err != nil {
+		p.Logs()
+		return nil, errors.Wrapf(err, "waiting for dnsperf pod %s to complete", name)
+	}
+
+	cmd := exec.Command("k", "logs", p.Metadata.Name, "-n", p.Metadata.Namespace)
+	out, err := util.RunAndLogCommand(cmd, commandTimeout)
+	if err != nil {
+		log.Printf("Error trying to get the logs of pod %s in namespace %s:%s\n", p.Metadata.Name, p.Metadata.Namespace, string(out))
+		return nil, err
+	}
+	result, err := parseDNSPerf(string(out))
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("DNS load from pod %s: %s\n", name, result)
+	return result, nil
+}
+
+// parseDNSPerf parses the statistics dnsperf prints at the end of a run
+func parseDNSPerf(out string) (*LoadResult, error) {
+	r := &LoadResult{ResponseCodes: map[string]int{}}
+	matches := dnsperfCount.FindAllStringSubmatch(out, -1)
+	if len(matches) == 0 {
+		return nil, errors.Errorf("no dnsperf statistics found in output:\n%s", out)
+	}
+	for _, m := range matches {
+		n, _ := strconv.Atoi(m[2])
+		switch m[1] {
+		case "sent":
+			r.Sent = n
+		case "completed":
+			r.Completed = n
+		case "lost":
+			r.Lost = n
+		}
+	}
+	if m := dnsperfResponseCodes.FindStringSubmatch(out); m != nil {
+		for _, code := range dnsperfResponseCode.FindAllStringSubmatch(m[1], -1) {
+			n, _ := strconv.Atoi(code[2])
+			r.ResponseCodes[code[1]] = n
+		}
+	}
+	if m := dnsperfQPS.FindStringSubmatch(out); m != nil {
+		r.QueriesPerSecond, _ = strconv.ParseFloat(m[1], 64)
+	}
+	return r, nil
+}
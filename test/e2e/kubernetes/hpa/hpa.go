@@ -6,6 +6,7 @@ package hpa
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os/exec"
 	"regexp"
@@ -42,16 +43,13 @@ type Spec struct {
 	TargetCPUUtilizationPercentage int `json:"targetCPUUtilizationPercentage"`
 }
 
-// Status holds the load balancer definition
+// Status holds the observed replicas and CPU utilization of the scale target
 type Status struct {
-	LoadBalancer LoadBalancer `json:"loadBalancer"`
-}
-
-// LoadBalancer holds the ingress definitions
-type LoadBalancer struct {
-	CurrentCPUUtilizationPercentage int `json:"currentCPUUtilizationPercentage"`
-	CurrentReplicas                 int `json:"currentReplicas"`
-	DesiredReplicas                 int `json:"desiredReplicas"`
+	// CurrentCPUUtilizationPercentage is nil until the HPA controller got metrics for the pods of the scale target
+	CurrentCPUUtilizationPercentage *int       `json:"currentCPUUtilizationPercentage"`
+	CurrentReplicas                 int        `json:"currentReplicas"`
+	DesiredReplicas                 int        `json:"desiredReplicas"`
+	LastScaleTime                   *time.Time `json:"lastScaleTime"`
 }
 
 // Get returns the HPA definition specified in a given namespace
@@ -110,6 +108,56 @@ func GetAllByPrefix(prefix, namespace string) ([]HPA, error) {
 	return hpas, nil
 }
 
+// WaitOnMetrics waits until the HPA controller reports the CPU utilization of the scale target, i.e. until the metrics API serves its pods
+func WaitOnMetrics(name, namespace string, sleep, duration time.Duration) (*HPA, error) {
+	return waitOn(name, namespace, sleep, duration, "CPU utilization metrics", func(h *HPA) bool {
+		return h.Status.CurrentCPUUtilizationPercentage != nil
+	})
+}
+
+// WaitOnScaleUp waits until the HPA scaled its target to at least replicas
+func WaitOnScaleUp(name, namespace string, replicas int, sleep, duration time.Duration) (*HPA, error) {
+	return waitOn(name, namespace, sleep, duration, fmt.Sprintf("a scale up to at least %d replicas", replicas), func(h *HPA) bool {
+		return h.Status.CurrentReplicas >= replicas
+	})
+}
+
+// WaitOnScaleDown waits until the HPA scaled its target down to at most replicas
+func WaitOnScaleDown(name, namespace string, replicas int, sleep, duration time.Duration) (*HPA, error) {
+	return waitOn(name, namespace, sleep, duration, fmt.Sprintf("a scale down to at most %d replicas", replicas), func(h *HPA) bool {
+		return h.Status.CurrentReplicas <= replicas && h.Status.DesiredReplicas <= replicas
+	})
+}
+
+func waitOn(name, namespace string, sleep, duration time.Duration, condition string, done func(*HPA) bool) (*HPA, error) {
+	var h *HPA
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		var err error
+		if h, err = Get(name, namespace); err != nil {
+			return err
+		}
+		if !done(h) {
+			return errors.Errorf("HPA %s in namespace %s: %s", name, namespace, h.Status)
+		}
+		return nil
+	})
+	if err != nil {
+		err = errors.Wrapf(err, "waiting for %s", condition)
+		util.ReportWaitFailure(util.WaitFailure{Kind: "hpa", Name: name, Namespace: namespace, Err: err})
+		return nil, err
+	}
+	log.Printf("HPA %s in namespace %s reached %s: %s\n", name, namespace, condition, h.Status)
+	return h, nil
+}
+
+func (s Status) String() string {
+	cpu := "unknown"
+	if s.CurrentCPUUtilizationPercentage != nil {
+		cpu = fmt.Sprintf("%d%%", *s.CurrentCPUUtilizationPercentage)
+	}
+	return fmt.Sprintf("%d current replicas, %d desired replicas, CPU utilization %s", s.CurrentReplicas, s.DesiredReplicas, cpu)
+}
+
 // Delete will delete a HPA in a given namespace
 func (h *HPA) Delete(retries int) error {
 	var kubectlOutput []byte
@@ -153,6 +201,7 @@ func WaitOnDeleted(hpaPrefix, namespace string, sleep, duration time.Duration) (
 	for {
 		select {
 		case err := <-errCh:
+			util.ReportWaitFailure(util.WaitFailure{Kind: "hpa", Name: hpaPrefix, Namespace: namespace, Err: err})
 			return false, err
 		case deleted := <-succeededCh:
 			return deleted, nil
@@ -0,0 +1,68 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package hpa
+
+import (
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LoadFunc starts sending load to the scale target of an HPA, and returns a function that stops it
+type LoadFunc func() (stop func() error, err error)
+
+// Scenario scales the target of an existing HPA up under load and back down once the load stopped
+type Scenario struct {
+	Name      string
+	Namespace string
+	// ScaleUpReplicas is the number of replicas the target must reach under load
+	ScaleUpReplicas int
+	// ScaleDownReplicas is the number of replicas the target must return to without load, usually the minimum of the HPA
+	ScaleDownReplicas int
+	// MetricsTimeout bounds the wait for the HPA to get the CPU utilization of the target from metrics-server
+	MetricsTimeout   time.Duration
+	ScaleUpTimeout   time.Duration
+	ScaleDownTimeout time.Duration
+}
+
+// Run waits for the HPA to get metrics, starts the load, waits for the scale up, stops the load and waits for the scale down.
+// The load is stopped when Run returns, whether the scenario succeeded or not.
+func (s Scenario) Run(startLoad LoadFunc, sleep time.Duration) error {
+	if s.ScaleUpReplicas <= s.ScaleDownReplicas {
+		return errors.Errorf("HPA scenario %s scales up to %d replicas, it must be more than the %d replicas it scales down to", s.Name, s.ScaleUpReplicas, s.ScaleDownReplicas)
+	}
+	if _, err := WaitOnMetrics(s.Name, s.Namespace, sleep, s.MetricsTimeout); err != nil {
+		return err
+	}
+
+	stop, err := startLoad()
+	if err != nil {
+		return errors.Wrapf(err, "starting the load of HPA scenario %s", s.Name)
+	}
+	defer func() {
+		if stop != nil {
+			if err := stop(); err != nil {
+				log.Printf("Error stopping the load of HPA scenario %s:%s\n", s.Name, err)
+			}
+		}
+	}()
+	start := time.Now()
+	if _, err = WaitOnScaleUp(s.Name, s.Namespace, s.ScaleUpReplicas, sleep, s.ScaleUpTimeout); err != nil {
+		return err
+	}
+	log.Printf("HPA %s scaled up to %d replicas in %s\n", s.Name, s.ScaleUpReplicas, time.Since(start))
+
+	err = stop()
+	stop = nil
+	if err != nil {
+		return errors.Wrapf(err, "stopping the load of HPA scenario %s", s.Name)
+	}
+	start = time.Now()
+	if _, err = WaitOnScaleDown(s.Name, s.Namespace, s.ScaleDownReplicas, sleep, s.ScaleDownTimeout); err != nil {
+		return err
+	}
+	log.Printf("HPA %s scaled down to %d replicas in %s\n", s.Name, s.ScaleDownReplicas, time.Since(start))
+	return nil
+}
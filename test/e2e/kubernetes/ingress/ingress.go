@@ -0,0 +1,144 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package ingress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"time"
+
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/pkg/errors"
+)
+
+const commandTimeout = 1 * time.Minute
+
+// Ingress is used to parse data from kubectl get ingress
+type Ingress struct {
+	Metadata Metadata `json:"metadata"`
+	Status   Status   `json:"status"`
+}
+
+// Metadata holds information like name, namespace, and annotations
+type Metadata struct {
+	Annotations map[string]string `json:"annotations"`
+	CreatedAt   time.Time         `json:"creationTimestamp"`
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+}
+
+// Status holds the load balancer the ingress controller published for the Ingress
+type Status struct {
+	LoadBalancer LoadBalancer `json:"loadBalancer"`
+}
+
+// LoadBalancer holds the ingress points of the load balancer
+type LoadBalancer struct {
+	Ingress []map[string]string `json:"ingress"`
+}
+
+// CreateIngressFromFile will create an Ingress, with the backends of the file, from file with a name
+func CreateIngressFromFile(filename, name, namespace string) (*Ingress, error) {
+	cmd := exec.Command("k", "apply", "-f", filename)
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error trying to create Ingress %s:%s\n", name, string(out))
+		return nil, err
+	}
+	i, err := Get(name, namespace)
+	if err != nil {
+		log.Printf("Error while trying to fetch Ingress %s:%s\n", name, err)
+		return nil, err
+	}
+	return i, nil
+}
+
+// Get returns the Ingress specified in a given namespace
+func Get(name, namespace string) (*Ingress, error) {
+	cmd := exec.Command("k", "get", "ingress", "-o", "json", "-n", namespace, name)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error getting ingress:\n")
+		util.PrintCommand(cmd)
+		return nil, err
+	}
+	i := Ingress{}
+	err = json.Unmarshal(out, &i)
+	if err != nil {
+		log.Printf("Error unmarshalling ingress json:%s\n", err)
+		return nil, err
+	}
+	return &i, nil
+}
+
+// Delete will delete an Ingress in a given namespace
+func (i *Ingress) Delete(retries int) error {
+	var kubectlOutput []byte
+	var kubectlError error
+	for attempt := 0; attempt < retries; attempt++ {
+		cmd := exec.Command("k", "delete", "ingress", "-n", i.Metadata.Namespace, i.Metadata.Name)
+		kubectlOutput, kubectlError = util.RunAndLogCommand(cmd, commandTimeout)
+		if kubectlError != nil {
+			log.Printf("Error while trying to delete ingress %s in namespace %s:%s\n", i.Metadata.Name, i.Metadata.Namespace, string(kubectlOutput))
+			continue
+		}
+		break
+	}
+
+	return kubectlError
+}
+
+// WaitOnIP waits for the ingress controller to publish the IP of its load balancer for the Ingress and returns it
+func (i *Ingress) WaitOnIP(sleep, duration time.Duration) (string, error) {
+	var ip string
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		ing, err := Get(i.Metadata.Name, i.Metadata.Namespace)
+		if err != nil {
+			return err
+		}
+		if len(ing.Status.LoadBalancer.Ingress) == 0 || ing.Status.LoadBalancer.Ingress[0]["ip"] == "" {
+			return errors.Errorf("no IP published yet for Ingress %s", i.Metadata.Name)
+		}
+		*i = *ing
+		ip = ing.Status.LoadBalancer.Ingress[0]["ip"]
+		return nil
+	})
+	if err != nil {
+		err = errors.Wrapf(err, "waiting for the IP of Ingress %s in namespace %s", i.Metadata.Name, i.Metadata.Namespace)
+		util.ReportWaitFailure(util.WaitFailure{Kind: "ingress", Name: i.Metadata.Name, Namespace: i.Metadata.Namespace, Err: err})
+		return "", err
+	}
+	log.Printf("Ingress %s has IP %s\n", i.Metadata.Name, ip)
+	return ip, nil
+}
+
+// Validate will attempt to run an http.Get against the path of the Ingress on its IP until the body matches check
+func (i *Ingress) Validate(path, check string, sleep, duration time.Duration) error {
+	ip, err := i.WaitOnIP(sleep, duration)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("http://%s%s", ip, path)
+	return util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		resp, err := http.Get(url)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		if matched, _ := regexp.MatchString(check, string(body)); !matched {
+			return errors.Errorf("unexpected body from %s, expected to find %s, got:\n%s", url, check, string(body))
+		}
+		return nil
+	})
+}
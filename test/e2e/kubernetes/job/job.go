@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"log"
+	"os"
 	"os/exec"
 	"regexp"
 	"text/template"
@@ -20,6 +21,20 @@ import (
 	"github.com/pkg/errors"
 )
 
+const deleteTimeout = 5 * time.Minute
+
+// PropagationPolicy decides what happens to the pods of a deleted Job
+type PropagationPolicy string
+
+const (
+	// PropagationBackground deletes the Job right away and lets the garbage collector delete its pods
+	PropagationBackground PropagationPolicy = "Background"
+	// PropagationForeground returns once the pods of the Job are deleted
+	PropagationForeground PropagationPolicy = "Foreground"
+	// PropagationOrphan leaves the pods of the Job running
+	PropagationOrphan PropagationPolicy = "Orphan"
+)
+
 // List is a container that holds all jobs returned from doing a kubectl get jobs
 type List struct {
 	Jobs []Job `json:"items"`
@@ -34,14 +49,25 @@ type Job struct {
 
 // Spec holds job spec metadata
 type Spec struct {
-	Completions int `json:"completions"`
-	Parallelism int `json:"parallelism"`
+	BackoffLimit *int `json:"backoffLimit"`
+	Completions  int  `json:"completions"`
+	Parallelism  int  `json:"parallelism"`
 }
 
 // Status holds job status information
 type Status struct {
-	Active    int `json:"active"`
-	Succeeded int `json:"succeeded"`
+	Active     int         `json:"active"`
+	Conditions []Condition `json:"conditions"`
+	Failed     int         `json:"failed"`
+	Succeeded  int         `json:"succeeded"`
+}
+
+// Condition holds a job condition, e.g. Complete or Failed
+type Condition struct {
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+	Status  string `json:"status"`
+	Type    string `json:"type"`
 }
 
 // CreateJobFromFile will create a Job from file with a name
@@ -61,6 +87,68 @@ func CreateJobFromFile(filename, name, namespace string) (*Job, error) {
 	return job, nil
 }
 
+// RunLinuxJob will create a Job that runs a shell command on linux nodes until it succeeds
+func RunLinuxJob(image, name, namespace, command string) (*Job, error) {
+	return runJob(image, name, namespace, "linux", []string{"/bin/sh", "-c", command})
+}
+
+// RunWindowsJob will create a Job that runs a command on windows nodes until it succeeds, in the shell detected from the image
+func RunWindowsJob(image, name, namespace, command string) (*Job, error) {
+	return RunWindowsJobWithShell(image, name, namespace, command, pod.WindowsShellAuto)
+}
+
+// RunWindowsJobWithShell will create a Job that runs a command on windows nodes until it succeeds, in the given shell
+func RunWindowsJobWithShell(image, name, namespace, command string, shell pod.WindowsShell) (*Job, error) {
+	if shell == pod.WindowsShellAuto {
+		shell = pod.GetWindowsShell(image)
+	}
+	return runJob(image, name, namespace, "windows", shell.Command(command))
+}
+
+// runJob creates a Job running command in a single container of image, on nodes of osType, with the same nodeSelector as pod.RunLinuxPod and pod.RunWindowsPod
+func runJob(image, name, namespace, osType string, command []string) (*Job, error) {
+	manifest := map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"restartPolicy": "OnFailure",
+					"containers": []map[string]interface{}{
+						{
+							"name":            name,
+							"image":           image,
+							"imagePullPolicy": "IfNotPresent",
+							"command":         command,
+						},
+					},
+					"nodeSelector": map[string]string{
+						"beta.kubernetes.io/os": osType,
+					},
+				},
+			},
+		},
+	}
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	tempfile, err := ioutil.TempFile("", "*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tempfile.Name())
+	defer tempfile.Close()
+	if _, err = tempfile.Write(b); err != nil {
+		return nil, err
+	}
+	return CreateJobFromFile(tempfile.Name(), name, namespace)
+}
+
 // CreateWindowsJobFromTemplate will create a Job from file with a name
 func CreateWindowsJobFromTemplate(filename, name, namespace string, windowsTestImages *engine.WindowsTestImages) (*Job, error) {
 	t, err := template.ParseFiles(filename)
@@ -237,6 +325,7 @@ func WaitOnReady(jobPrefix, namespace string, sleep, duration time.Duration) (bo
 			for _, p := range pods {
 				p.Logs()
 			}
+			util.ReportWaitFailure(util.WaitFailure{Kind: "job", Name: jobPrefix, Namespace: namespace, Err: err})
 			return false, err
 		case ready := <-readyCh:
 			return ready, nil
@@ -249,22 +338,102 @@ func (j *Job) WaitOnReady(sleep, duration time.Duration) (bool, error) {
 	return WaitOnReady(j.Metadata.Name, j.Metadata.Namespace, sleep, duration)
 }
 
-// Delete will delete a Job in a given namespace
+// Delete will delete a Job in a given namespace, its pods are deleted in the background
 func (j *Job) Delete(retries int) error {
-	var kubectlOutput []byte
-	var kubectlError error
-	for i := 0; i < retries; i++ {
-		cmd := exec.Command("k", "delete", "job", "-n", j.Metadata.Namespace, j.Metadata.Name)
-		util.PrintCommand(cmd)
-		kubectlOutput, kubectlError = cmd.CombinedOutput()
+	return j.DeleteWithPropagation(retries, PropagationBackground)
+}
+
+// DeleteWithPropagation will delete a Job in a given namespace, and its pods according to policy
+func (j *Job) DeleteWithPropagation(retries int, policy PropagationPolicy) error {
+	cascade := "--cascade=true"
+	if policy == PropagationOrphan {
+		cascade = "--cascade=false"
+	}
+	err := util.DefaultRetrier().WithMaxAttempts(retries).Do(context.Background(), func() error {
+		cmd := exec.Command("k", "delete", "job", "-n", j.Metadata.Namespace, j.Metadata.Name, cascade)
+		kubectlOutput, kubectlError := util.RunAndLogCommand(cmd, deleteTimeout)
 		if kubectlError != nil {
 			log.Printf("Error while trying to delete Job %s in namespace %s:%s\n", j.Metadata.Namespace, j.Metadata.Name, string(kubectlOutput))
-			continue
 		}
-		break
+		return kubectlError
+	})
+	if err != nil || policy != PropagationForeground {
+		return err
+	}
+	// kubectl cannot ask the API server for a foreground deletion, wait for the garbage collector instead
+	return util.DefaultRetrier().WithConstantBackoff(5*time.Second).DoWithTimeout(deleteTimeout, func() error {
+		pods, err := j.Pods()
+		if err != nil {
+			return err
+		}
+		if len(pods) > 0 {
+			return errors.Errorf("%d pods of Job %s are not deleted yet", len(pods), j.Metadata.Name)
+		}
+		return nil
+	})
+}
+
+// Pods will return the pods of a Job
+func (j *Job) Pods() ([]pod.Pod, error) {
+	return pod.GetAllBySelector("job-name="+j.Metadata.Name, j.Metadata.Namespace)
+}
+
+// GetCompletions will return the number of pods of a Job that succeeded, and the number of completions the Job needs to succeed
+func (j *Job) GetCompletions() (int, int, error) {
+	job, err := Get(j.Metadata.Name, j.Metadata.Namespace)
+	if err != nil {
+		return 0, 0, err
+	}
+	*j = *job
+	return j.Status.Succeeded, j.completions(), nil
+}
+
+// completions returns the number of completions the Job needs to succeed, 1 if it does not set any
+func (j *Job) completions() int {
+	if j.Spec.Completions == 0 {
+		return 1
+	}
+	return j.Spec.Completions
+}
+
+// failed returns the failed condition of the Job, or nil if it has not failed
+func (j *Job) failed() *Condition {
+	for i, c := range j.Status.Conditions {
+		if c.Type == "Failed" && c.Status == "True" {
+			return &j.Status.Conditions[i]
+		}
 	}
+	return nil
+}
 
-	return kubectlError
+// WaitOnSucceeded will wait for a Job to have the number of completions it needs to succeed,
+// and returns an error right away if the Job fails, e.g. when it reaches its backoffLimit
+func (j *Job) WaitOnSucceeded(sleep, duration time.Duration) (bool, error) {
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		succeeded, completions, err := j.GetCompletions()
+		if err != nil {
+			return err
+		}
+		if c := j.failed(); c != nil {
+			return util.Permanent(errors.Errorf("Job %s failed (%s): %s", j.Metadata.Name, c.Reason, c.Message))
+		}
+		if succeeded < completions {
+			return errors.Errorf("Job %s has %d of %d completions", j.Metadata.Name, succeeded, completions)
+		}
+		return nil
+	})
+	if err != nil {
+		pods, getPodsErr := j.Pods()
+		if getPodsErr != nil {
+			log.Printf("Error trying to get job pods: %s\n", getPodsErr)
+		}
+		for _, p := range pods {
+			p.Logs()
+		}
+		util.ReportWaitFailure(util.WaitFailure{Kind: "job", Name: j.Metadata.Name, Namespace: j.Metadata.Namespace, Err: err})
+		return false, err
+	}
+	return true, nil
 }
 
 // WaitOnDeleted returns when a job is successfully deleted
@@ -293,6 +462,7 @@ func WaitOnDeleted(jobPrefix, namespace string, sleep, duration time.Duration) (
 	for {
 		select {
 		case err := <-errCh:
+			util.ReportWaitFailure(util.WaitFailure{Kind: "job", Name: jobPrefix, Namespace: namespace, Err: err})
 			return false, err
 		case deleted := <-succeededCh:
 			return deleted, nil
@@ -4,15 +4,39 @@
 package kubernetes_test
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/Azure/aks-engine/test/e2e/config"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/Azure/aks-engine/test/e2e/metrics"
+
 	. "github.com/onsi/ginkgo"
+	ginkgoconfig "github.com/onsi/ginkgo/config"
 	"github.com/onsi/ginkgo/reporters"
 	. "github.com/onsi/gomega"
 )
 
 func TestKubernetes(t *testing.T) {
 	RegisterFailHandler(Fail)
-	junitReporter := reporters.NewJUnitReporter("junit.xml")
-	RunSpecsWithDefaultAndCustomReporters(t, "Kubernetes Suite", []Reporter{junitReporter})
+	junitReporter := reporters.NewJUnitReporter(junitPath())
+	metricsReporter := &metrics.SpecReporter{Recorder: metrics.SuiteRecorder()}
+	RunSpecsWithDefaultAndCustomReporters(t, "Kubernetes Suite", []Reporter{junitReporter, metricsReporter})
+}
+
+// junitPath returns where this ginkgo node writes its JUnit XML: a file of its own in the results directory of the run
+func junitPath() string {
+	c, err := config.ParseConfig()
+	if err != nil {
+		return "junit.xml"
+	}
+	cwd, _ := os.Getwd()
+	c.CurrentWorkingDir = filepath.Join(cwd, "../../..")
+	dir := c.GetResultsPath()
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return "junit.xml"
+	}
+	return filepath.Join(dir, fmt.Sprintf("junit_%02d_%s.xml", ginkgoconfig.GinkgoConfig.ParallelNode, util.RunID()))
 }
@@ -4,28 +4,37 @@
 package kubernetes
 
 import (
-	"bufio"
+	"context"
+	"encoding/base64"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"log"
-	"math/rand"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
-	"syscall"
+	"sync"
 	"time"
 
 	"github.com/Azure/go-autorest/autorest/to"
 
 	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/aks-engine/pkg/api/common"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/test/e2e/azure"
+	"github.com/Azure/aks-engine/test/e2e/capability"
 	"github.com/Azure/aks-engine/test/e2e/config"
 	"github.com/Azure/aks-engine/test/e2e/engine"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/artifacts"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/cronjob"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/daemonset"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/deployment"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/dns"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/hpa"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/ingress"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/job"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/namespace"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/networkpolicy"
@@ -34,10 +43,15 @@ import (
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/persistentvolumeclaims"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/pod"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/service"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/statefulset"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/storageclass"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/webhook"
+	"github.com/Azure/aks-engine/test/e2e/metrics"
+	"github.com/Azure/aks-engine/test/e2e/plugin"
 	"github.com/Azure/aks-engine/test/e2e/remote"
 	. "github.com/onsi/ginkgo"
+	ginkgoconfig "github.com/onsi/ginkgo/config"
 	. "github.com/onsi/gomega"
 )
 
@@ -54,26 +68,72 @@ const (
 	validateDNSTimeout                     = 2 * time.Minute
 	firstMasterRegexStr                    = "^k8s-master-"
 	podLookupRetries                       = 5
+	spotEvictionBackfillTimeout            = 20 * time.Minute
 )
 
 var (
 	cfg                             config.Config
 	eng                             engine.Engine
+	caps                            capability.Set
 	masterSSHPort                   string
 	masterSSHPrivateKeyFilepath     string
 	longRunningApacheDeploymentName string
 	sshConn                         *remote.Connection
 	kubeConfig                      *Config
 	firstMasterRegexp               *regexp.Regexp
+	apiRecorder                     *util.APIRecorder
+	suiteSetup                      sync.Once
 )
 
-var _ = BeforeSuite(func() {
+// With ginkgo -p every parallel node runs the suite setup, the first node also creates the long-running workloads
+// the specs share before any spec runs, and deletes them after all the nodes are done.
+var _ = SynchronizedBeforeSuite(func() []byte {
+	suiteSetup.Do(setupSuite)
+	createLongRunningWorkloads()
+	return nil
+}, func([]byte) {
+	suiteSetup.Do(setupSuite)
+})
+
+var _ = SynchronizedAfterSuite(func() {
+	writeMetrics()
+	// the first node stops its recorder once it deleted the long-running workloads
+	if ginkgoconfig.GinkgoConfig.ParallelNode != 1 {
+		stopAPIRecorder()
+	}
+}, func() {
+	deleteLongRunningWorkloads()
+	stopAPIRecorder()
+})
+
+// requirement is the capabilities the cluster must have for specs to run against it
+type requirement []capability.Capability
+
+// requires declares specs that only run against the clusters with the capabilities
+func requires(required ...capability.Capability) requirement {
+	return requirement(required)
+}
+
+// It declares a spec tagged with the capabilities it requires, the runner skips the specs the cluster lacks a capability for
+// and the specs skip themselves when the suite is run on its own
+func (r requirement) It(text string, body func()) bool {
+	return It(text+" "+capability.Tags(r...), func() {
+		if missing := caps.Missing(r...); len(missing) > 0 {
+			Skip(fmt.Sprintf("the cluster does not have the capabilities %v", missing))
+		}
+		body()
+	})
+}
+
+func setupSuite() {
 	cwd, _ := os.Getwd()
 	rootPath := filepath.Join(cwd, "../../..") // The current working dir of these tests is down a few levels from the root of the project. We should traverse up that path so we can find the _output dir
 	c, err := config.ParseConfig()
 	c.CurrentWorkingDir = rootPath
 	Expect(err).NotTo(HaveOccurred())
 	cfg = *c // We have to do this because golang anon functions and scoping and stuff
+	util.SetDefaultRetrier(cfg.GetRetrier())
+	pod.SetOutboundConnectionConfig(cfg.GetOutboundConnectionConfig())
 
 	engCfg, err := engine.ParseConfig(c.CurrentWorkingDir, c.ClusterDefinition, c.Name)
 	Expect(err).NotTo(HaveOccurred())
@@ -88,6 +148,7 @@ var _ = BeforeSuite(func() {
 		ClusterDefinition:  csInput,
 		ExpandedDefinition: csGenerated,
 	}
+	caps = capability.Detect(csGenerated)
 	masterNodes, err := node.GetByRegex("^k8s-master-")
 	Expect(err).NotTo(HaveOccurred())
 	masterName := masterNodes[0].Metadata.Name
@@ -102,22 +163,165 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 	sshConn, err = remote.NewConnection(kubeConfig.GetServerName(), masterSSHPort, eng.ExpandedDefinition.Properties.LinuxProfile.AdminUsername, masterSSHPrivateKeyFilepath)
 	Expect(err).NotTo(HaveOccurred())
-	success := false
-	for i := 0; i < 3; i++ {
-		sshAddErr := util.AddToSSHKeyChain(masterSSHPrivateKeyFilepath)
-		if sshAddErr == nil {
-			success = true
-			break
+	firstMasterRegexp, err = regexp.Compile(firstMasterRegexStr)
+	Expect(err).NotTo(HaveOccurred())
+	var collector *artifacts.Collector
+	if cfg.CollectArtifacts {
+		collector = &artifacts.Collector{
+			Dir:           cfg.GetArtifactsPath(),
+			SSH:           sshConn,
+			ResourceGroup: cfg.Name,
 		}
-		if i > 1 {
-			log.Printf("Error while setting up ssh key forwarding:%s\n", sshAddErr)
+		if cfg.ArtifactsStorage != "" {
+			sa := &azure.StorageAccount{
+				Name:          cfg.ArtifactsStorage,
+				ResourceGroup: azure.ResourceGroup{Name: cfg.ArtifactsStorageRG},
+			}
+			err = sa.SetConnectionString()
+			Expect(err).NotTo(HaveOccurred())
+			err = sa.CreateFileShare(cfg.ArtifactsFileShare)
+			Expect(err).NotTo(HaveOccurred())
+			collector.Storage = sa
+			collector.FileShare = cfg.ArtifactsFileShare
 		}
-		time.Sleep(10 * time.Second)
+		collector.Register()
 	}
-	Expect(success).To(BeTrue())
-	firstMasterRegexp, err = regexp.Compile(firstMasterRegexStr)
+	if cfg.RecordAPICalls {
+		recordFile := "api-calls.json"
+		if ginkgoconfig.GinkgoConfig.ParallelTotal > 1 {
+			recordFile = fmt.Sprintf("api-calls-%d.json", ginkgoconfig.GinkgoConfig.ParallelNode)
+		}
+		apiRecorder, err = util.StartAPIRecorder(cfg.GetKubeConfig(), filepath.Join(cfg.GetLogsPath(), recordFile))
+		Expect(err).NotTo(HaveOccurred())
+		os.Setenv("KUBECONFIG", apiRecorder.KubeConfigPath)
+	}
+	err = plugin.SetUp(&plugin.Context{
+		Config:       &cfg,
+		Engine:       &eng,
+		Capabilities: caps,
+		Collector:    collector,
+	})
 	Expect(err).NotTo(HaveOccurred())
-})
+}
+
+func stopAPIRecorder() {
+	if apiRecorder != nil {
+		os.Setenv("KUBECONFIG", cfg.GetKubeConfig())
+		if err := apiRecorder.Stop(); err != nil {
+			log.Printf("Error stopping the Kubernetes API recorder:%s\n", err)
+		}
+	}
+}
+
+// writeMetrics writes the samples of this ginkgo node for the runner to emit, with the number of retries of its helpers
+func writeMetrics() {
+	if cfg.MetricsFormat == "" {
+		return
+	}
+	recorder := metrics.SuiteRecorder()
+	recorder.Record("e2e_retries", "Number of operations retried by the e2e helpers.", map[string]string{"ginkgo_node": strconv.Itoa(ginkgoconfig.GinkgoConfig.ParallelNode)}, float64(util.Retries()))
+	if err := recorder.WriteSuiteSamples(cfg.GetMetricsPath()); err != nil {
+		log.Printf("Error writing the metrics of the suite:%s\n", err)
+	}
+}
+
+// createLongRunningWorkloads creates the DNS liveness pod and the php-apache deployment and service, which specs connect to
+// and which are checked once the cluster has been up for awhile
+func createLongRunningWorkloads() {
+	dnsLiveness, err := pod.CreatePodFromFileIfNotExist(filepath.Join(WorkloadDir, "dns-liveness.yaml"), "dns-liveness", "default", 1*time.Second, cfg.Timeout)
+	Expect(err).NotTo(HaveOccurred())
+	running, err := dnsLiveness.WaitOnReady(retryTimeWhenWaitingForPodReady, cfg.Timeout)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(running).To(Equal(true))
+
+	phpApacheDeploy, err := deployment.CreateLinuxDeployIfNotExist("deis/hpa-example", longRunningApacheDeploymentName, "default", "--requests=cpu=10m,memory=10M")
+	Expect(err).NotTo(HaveOccurred())
+	running, err = phpApacheDeploy.WaitOnReady(3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(running).To(Equal(true))
+	err = phpApacheDeploy.ExposeIfNotExist("ClusterIP", 80, 80)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+// fluentBitBackendHasLog returns true if the logs forwarded by the fluent-bit addon to its backend contain the text
+func fluentBitBackendHasLog(account *azure.Account, addon api.KubernetesAddon, text string) (bool, error) {
+	if addon.Config["output"] == api.FluentBitOutputStorage {
+		key, err := base64.StdEncoding.DecodeString(addon.Config["storageAccountKey"])
+		if err != nil {
+			return false, err
+		}
+		sa := &azure.StorageAccount{
+			Name:             addon.Config["storageAccountName"],
+			ConnectionString: fmt.Sprintf("DefaultEndpointsProtocol=https;AccountName=%s;AccountKey=%s;EndpointSuffix=core.windows.net", addon.Config["storageAccountName"], key),
+		}
+		dir, err := ioutil.TempDir("", "fluent-bit")
+		if err != nil {
+			return false, err
+		}
+		defer os.RemoveAll(dir)
+		if err = sa.DownloadBlobs(addon.Config["storageContainer"], dir); err != nil {
+			return false, err
+		}
+		found := false
+		err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || found {
+				return err
+			}
+			b, err := ioutil.ReadFile(path)
+			found = err == nil && strings.Contains(string(b), text)
+			return err
+		})
+		return found, err
+	}
+	workspaceID, err := base64.StdEncoding.DecodeString(addon.Config["workspaceGuid"])
+	if err != nil {
+		return false, err
+	}
+	out, err := account.QueryLogAnalytics(string(workspaceID), fmt.Sprintf("%s_CL | where log_s contains '%s' | take 1", addon.Config["logType"], text))
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(out, text), nil
+}
+
+// validateIngress creates the Ingress of the ingress controller of an addon, with its nginx backend, from the workload file,
+// and checks that the controller routes HTTP requests through its IP to the backend
+func validateIngress(filename, name string) {
+	By("Creating an Ingress with an nginx backend")
+	ing, err := ingress.CreateIngressFromFile(filename, name, "default")
+	Expect(err).NotTo(HaveOccurred())
+	defer func() {
+		cmd := exec.Command("k", "delete", "-f", filename)
+		util.PrintCommand(cmd)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			log.Printf("Error deleting the Ingress %s and its backend:%s\n", name, out)
+		}
+	}()
+
+	By("Ensuring we can connect to the nginx backend through the IP of the Ingress")
+	err = ing.Validate("/", "(Welcome to nginx)", 5*time.Second, cfg.Timeout)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+// deleteLongRunningWorkloads deletes the workloads of createLongRunningWorkloads, soak clusters keep them running
+func deleteLongRunningWorkloads() {
+	if cfg.SoakClusterName != "" {
+		return
+	}
+	dnsLiveness, err := pod.Get("dns-liveness", "default", podLookupRetries)
+	Expect(err).NotTo(HaveOccurred())
+	err = dnsLiveness.Delete(util.DefaultDeleteRetries)
+	Expect(err).NotTo(HaveOccurred())
+
+	phpApacheDeploy, err := deployment.Get(longRunningApacheDeploymentName, "default")
+	Expect(err).NotTo(HaveOccurred())
+	s, err := service.Get(longRunningApacheDeploymentName, "default")
+	Expect(err).NotTo(HaveOccurred())
+	err = s.Delete(util.DefaultDeleteRetries)
+	Expect(err).NotTo(HaveOccurred())
+	err = phpApacheDeploy.Delete(util.DefaultDeleteRetries)
+	Expect(err).NotTo(HaveOccurred())
+}
 
 var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", func() {
 	Describe("regardless of agent pool type", func() {
@@ -156,7 +360,6 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 				}
 			}
 			Expect(success).To(BeTrue())
-			hostOSDNSValidationCommand = fmt.Sprintf("\"%s /tmp/%s\"", envString, hostOSDNSValidateScript)
 			for _, node := range nodeList.Nodes {
 				if node.IsLinux() && !firstMasterRegexp.MatchString(node.Metadata.Name) {
 					err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+hostOSDNSValidateScript)
@@ -167,6 +370,17 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 			}
 		})
 
+		requires(capability.Not(capability.AzureStack)).It("should have the expected network security group rules", func() {
+			account, err := azure.NewAccount()
+			Expect(err).NotTo(HaveOccurred())
+			client, err := account.NewARMClient(eng.ExpandedDefinition.Location)
+			Expect(err).NotTo(HaveOccurred())
+			ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+			defer cancel()
+			err = azure.ValidateNetworkSecurityGroups(ctx, client, cfg.Name, eng.ExpandedDefinition)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
 		It("should have the expected k8s version", func() {
 			nodeList, err := node.GetReady()
 			Expect(err).NotTo(HaveOccurred())
@@ -188,237 +402,169 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 			}
 		})
 
-		It("should display the installed docker runtime on all nodes", func() {
-			if !eng.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
-				if eng.ExpandedDefinition.Properties.OrchestratorProfile.KubernetesConfig.RequiresDocker() {
-					nodeList, err := node.GetReady()
-					Expect(err).NotTo(HaveOccurred())
-					dockerVersionCmd := fmt.Sprintf("\"docker version\"")
-					for _, node := range nodeList.Nodes {
-						err = sshConn.ExecuteRemote(node.Metadata.Name, dockerVersionCmd, true)
-						Expect(err).NotTo(HaveOccurred())
-					}
-				} else {
-					Skip("Skip docker validations on non-docker-backed clusters")
-				}
-			} else {
-				Skip("Skip per-node tests in low-priority VMSS cluster configuration scenario")
+		requires(capability.Not(capability.LowPriority), capability.Docker).It("should display the installed docker runtime on all nodes", func() {
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			dockerVersionCmd := "docker version"
+			for _, node := range nodeList.Nodes {
+				err = sshConn.ExecuteRemote(node.Metadata.Name, dockerVersionCmd, true)
+				Expect(err).NotTo(HaveOccurred())
 			}
 		})
 
-		It("should validate that every linux node has a root password", func() {
-			if !eng.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
-				if eng.ExpandedDefinition.Properties.IsVHDDistroForAllNodes() {
-					nodeList, err := node.GetReady()
+		requires(capability.Not(capability.LowPriority), capability.VHD).It("should validate that every linux node has a root password", func() {
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			rootPasswdCmd := "sudo grep '^root:[!*]:' /etc/shadow && exit 1 || exit 0"
+			for _, node := range nodeList.Nodes {
+				if node.IsUbuntu() {
+					err = sshConn.ExecuteRemote(node.Metadata.Name, rootPasswdCmd, true)
 					Expect(err).NotTo(HaveOccurred())
-					rootPasswdCmd := fmt.Sprintf("\"sudo grep '^root:[!*]:' /etc/shadow\" && exit 1 || exit 0")
-					for _, node := range nodeList.Nodes {
-						if node.IsUbuntu() {
-							err = sshConn.ExecuteRemote(node.Metadata.Name, rootPasswdCmd, true)
-							Expect(err).NotTo(HaveOccurred())
-						}
-					}
-				} else {
-					Skip("This config is only available on VHD")
 				}
-			} else {
-				Skip("Skip per-node tests in low-priority VMSS cluster configuration scenario")
 			}
 		})
 
-		It("should validate Ubuntu host OS network configuration on all nodes", func() {
-			if !eng.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
-				if eng.ExpandedDefinition.Properties.IsVHDDistroForAllNodes() {
-					nodeList, err := node.GetReady()
-					Expect(err).NotTo(HaveOccurred())
-					netConfigValidateScript := "net-config-validate.sh"
-					err = sshConn.CopyTo(netConfigValidateScript)
+		requires(capability.Not(capability.LowPriority), capability.VHD).It("should validate Ubuntu host OS network configuration on all nodes", func() {
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			netConfigValidateScript := "net-config-validate.sh"
+			err = sshConn.CopyTo(netConfigValidateScript)
+			Expect(err).NotTo(HaveOccurred())
+			netConfigValidationCommand := fmt.Sprintf("/tmp/%s", netConfigValidateScript)
+			err = sshConn.Execute(netConfigValidationCommand, false)
+			Expect(err).NotTo(HaveOccurred())
+			for _, node := range nodeList.Nodes {
+				if node.IsUbuntu() && !firstMasterRegexp.MatchString(node.Metadata.Name) {
+					err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+netConfigValidateScript)
 					Expect(err).NotTo(HaveOccurred())
-					netConfigValidationCommand := fmt.Sprintf("\"/tmp/%s\"", netConfigValidateScript)
-					err = sshConn.Execute(netConfigValidationCommand, false)
+					err = sshConn.ExecuteRemote(node.Metadata.Name, netConfigValidationCommand, false)
 					Expect(err).NotTo(HaveOccurred())
-					for _, node := range nodeList.Nodes {
-						if node.IsUbuntu() && !firstMasterRegexp.MatchString(node.Metadata.Name) {
-							err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+netConfigValidateScript)
-							Expect(err).NotTo(HaveOccurred())
-							err = sshConn.ExecuteRemote(node.Metadata.Name, netConfigValidationCommand, false)
-							Expect(err).NotTo(HaveOccurred())
-						}
-					}
-				} else {
-					Skip("This config is only available on VHD")
 				}
-			} else {
-				Skip("Skip per-node tests in low-priority VMSS cluster configuration scenario")
 			}
 		})
 
-		It("should validate all CIS VHD-paved files", func() {
-			if !eng.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
-				if eng.ExpandedDefinition.Properties.IsVHDDistroForAllNodes() {
-					nodeList, err := node.GetReady()
-					Expect(err).NotTo(HaveOccurred())
-					CISFilesValidateScript := "CIS-files-validate.sh"
-					err = sshConn.CopyTo(CISFilesValidateScript)
+		requires(capability.Not(capability.LowPriority), capability.VHD).It("should validate all CIS VHD-paved files", func() {
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			CISFilesValidateScript := "CIS-files-validate.sh"
+			err = sshConn.CopyTo(CISFilesValidateScript)
+			Expect(err).NotTo(HaveOccurred())
+			CISValidationCommand := fmt.Sprintf("/tmp/%s", CISFilesValidateScript)
+			err = sshConn.Execute(CISValidationCommand, false)
+			Expect(err).NotTo(HaveOccurred())
+			for _, node := range nodeList.Nodes {
+				if !firstMasterRegexp.MatchString(node.Metadata.Name) {
+					err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+CISFilesValidateScript)
 					Expect(err).NotTo(HaveOccurred())
-					CISValidationCommand := fmt.Sprintf("\"/tmp/%s\"", CISFilesValidateScript)
-					err = sshConn.Execute(CISValidationCommand, false)
+					err = sshConn.ExecuteRemote(node.Metadata.Name, CISValidationCommand, false)
 					Expect(err).NotTo(HaveOccurred())
-					for _, node := range nodeList.Nodes {
-						if !firstMasterRegexp.MatchString(node.Metadata.Name) {
-							err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+CISFilesValidateScript)
-							Expect(err).NotTo(HaveOccurred())
-							err = sshConn.ExecuteRemote(node.Metadata.Name, CISValidationCommand, false)
-							Expect(err).NotTo(HaveOccurred())
-						}
-					}
-				} else {
-					Skip("This config is only available on VHD")
 				}
-			} else {
-				Skip("Skip per-node tests in low-priority VMSS cluster configuration scenario")
 			}
 		})
 
-		It("should validate kernel module configuration", func() {
-			if !eng.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
-				if eng.ExpandedDefinition.Properties.IsVHDDistroForAllNodes() {
-					nodeList, err := node.GetReady()
-					Expect(err).NotTo(HaveOccurred())
-					modprobeConfigValidateScript := "modprobe-config-validate.sh"
-					err = sshConn.CopyTo(modprobeConfigValidateScript)
+		requires(capability.Not(capability.LowPriority), capability.VHD).It("should validate kernel module configuration", func() {
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			modprobeConfigValidateScript := "modprobe-config-validate.sh"
+			err = sshConn.CopyTo(modprobeConfigValidateScript)
+			Expect(err).NotTo(HaveOccurred())
+			netConfigValidationCommand := fmt.Sprintf("/tmp/%s", modprobeConfigValidateScript)
+			err = sshConn.Execute(netConfigValidationCommand, false)
+			Expect(err).NotTo(HaveOccurred())
+			for _, node := range nodeList.Nodes {
+				if node.IsUbuntu() && !firstMasterRegexp.MatchString(node.Metadata.Name) {
+					err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+modprobeConfigValidateScript)
 					Expect(err).NotTo(HaveOccurred())
-					netConfigValidationCommand := fmt.Sprintf("\"/tmp/%s\"", modprobeConfigValidateScript)
-					err = sshConn.Execute(netConfigValidationCommand, false)
+					err = sshConn.ExecuteRemote(node.Metadata.Name, netConfigValidationCommand, false)
 					Expect(err).NotTo(HaveOccurred())
-					for _, node := range nodeList.Nodes {
-						if node.IsUbuntu() && !firstMasterRegexp.MatchString(node.Metadata.Name) {
-							err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+modprobeConfigValidateScript)
-							Expect(err).NotTo(HaveOccurred())
-							err = sshConn.ExecuteRemote(node.Metadata.Name, netConfigValidationCommand, false)
-							Expect(err).NotTo(HaveOccurred())
-						}
-					}
-				} else {
-					Skip("This config is only available on VHD")
 				}
-			} else {
-				Skip("Skip per-node tests in low-priority VMSS cluster configuration scenario")
 			}
 		})
 
-		It("should validate installed software packages", func() {
-			if !eng.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
-				nodeList, err := node.GetReady()
-				Expect(err).NotTo(HaveOccurred())
-				installedPackagesValidateScript := "ubuntu-installed-packages-validate.sh"
-				err = sshConn.CopyTo(installedPackagesValidateScript)
-				Expect(err).NotTo(HaveOccurred())
-				installedPackagesValidationCommand := fmt.Sprintf("\"/tmp/%s\"", installedPackagesValidateScript)
-				err = sshConn.Execute(installedPackagesValidationCommand, false)
-				Expect(err).NotTo(HaveOccurred())
-				for _, node := range nodeList.Nodes {
-					if node.IsUbuntu() && !firstMasterRegexp.MatchString(node.Metadata.Name) {
-						err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+installedPackagesValidateScript)
-						Expect(err).NotTo(HaveOccurred())
-						err = sshConn.ExecuteRemote(node.Metadata.Name, installedPackagesValidationCommand, false)
-						Expect(err).NotTo(HaveOccurred())
-					}
+		requires(capability.Not(capability.LowPriority)).It("should validate installed software packages", func() {
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			installedPackagesValidateScript := "ubuntu-installed-packages-validate.sh"
+			err = sshConn.CopyTo(installedPackagesValidateScript)
+			Expect(err).NotTo(HaveOccurred())
+			installedPackagesValidationCommand := fmt.Sprintf("/tmp/%s", installedPackagesValidateScript)
+			err = sshConn.Execute(installedPackagesValidationCommand, false)
+			Expect(err).NotTo(HaveOccurred())
+			for _, node := range nodeList.Nodes {
+				if node.IsUbuntu() && !firstMasterRegexp.MatchString(node.Metadata.Name) {
+					err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+installedPackagesValidateScript)
+					Expect(err).NotTo(HaveOccurred())
+					err = sshConn.ExecuteRemote(node.Metadata.Name, installedPackagesValidationCommand, false)
+					Expect(err).NotTo(HaveOccurred())
 				}
-			} else {
-				Skip("Skip per-node tests in low-priority VMSS cluster configuration scenario")
 			}
 		})
 
-		It("should validate that every linux node has the right sshd config", func() {
-			if !eng.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
-				if eng.ExpandedDefinition.Properties.IsVHDDistroForAllNodes() {
-					nodeList, err := node.GetReady()
-					Expect(err).NotTo(HaveOccurred())
-					sshdConfigValidateScript := "sshd-config-validate.sh"
-					err = sshConn.CopyTo(sshdConfigValidateScript)
+		requires(capability.Not(capability.LowPriority), capability.VHD).It("should validate that every linux node has the right sshd config", func() {
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			sshdConfigValidateScript := "sshd-config-validate.sh"
+			err = sshConn.CopyTo(sshdConfigValidateScript)
+			Expect(err).NotTo(HaveOccurred())
+			sshdConfigValidationCommand := fmt.Sprintf("/tmp/%s", sshdConfigValidateScript)
+			err = sshConn.Execute(sshdConfigValidationCommand, false)
+			Expect(err).NotTo(HaveOccurred())
+			for _, node := range nodeList.Nodes {
+				if node.IsUbuntu() && !firstMasterRegexp.MatchString(node.Metadata.Name) {
+					err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+sshdConfigValidateScript)
 					Expect(err).NotTo(HaveOccurred())
-					sshdConfigValidationCommand := fmt.Sprintf("\"/tmp/%s\"", sshdConfigValidateScript)
-					err = sshConn.Execute(sshdConfigValidationCommand, false)
+					err = sshConn.ExecuteRemote(node.Metadata.Name, sshdConfigValidationCommand, false)
 					Expect(err).NotTo(HaveOccurred())
-					for _, node := range nodeList.Nodes {
-						if node.IsUbuntu() && !firstMasterRegexp.MatchString(node.Metadata.Name) {
-							err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+sshdConfigValidateScript)
-							Expect(err).NotTo(HaveOccurred())
-							err = sshConn.ExecuteRemote(node.Metadata.Name, sshdConfigValidationCommand, false)
-							Expect(err).NotTo(HaveOccurred())
-						}
-					}
-				} else {
-					Skip("This config is only available on VHD")
 				}
-			} else {
-				Skip("Skip per-node tests in low-priority VMSS cluster configuration scenario")
 			}
 		})
 
-		It("should validate password enforcement configuration", func() {
-			if !eng.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
-				if eng.ExpandedDefinition.Properties.IsVHDDistroForAllNodes() {
-					nodeList, err := node.GetReady()
-					Expect(err).NotTo(HaveOccurred())
-					pwQualityValidateScript := "pwquality-validate.sh"
-					err = sshConn.CopyTo(pwQualityValidateScript)
+		requires(capability.Not(capability.LowPriority), capability.VHD).It("should validate password enforcement configuration", func() {
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			pwQualityValidateScript := "pwquality-validate.sh"
+			err = sshConn.CopyTo(pwQualityValidateScript)
+			Expect(err).NotTo(HaveOccurred())
+			pwQualityValidationCommand := fmt.Sprintf("/tmp/%s", pwQualityValidateScript)
+			err = sshConn.Execute(pwQualityValidationCommand, false)
+			Expect(err).NotTo(HaveOccurred())
+			for _, node := range nodeList.Nodes {
+				if node.IsUbuntu() && !firstMasterRegexp.MatchString(node.Metadata.Name) {
+					err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+pwQualityValidateScript)
 					Expect(err).NotTo(HaveOccurred())
-					pwQualityValidationCommand := fmt.Sprintf("\"/tmp/%s\"", pwQualityValidateScript)
-					err = sshConn.Execute(pwQualityValidationCommand, false)
+					err = sshConn.ExecuteRemote(node.Metadata.Name, pwQualityValidationCommand, false)
 					Expect(err).NotTo(HaveOccurred())
-					for _, node := range nodeList.Nodes {
-						if node.IsUbuntu() && !firstMasterRegexp.MatchString(node.Metadata.Name) {
-							err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+pwQualityValidateScript)
-							Expect(err).NotTo(HaveOccurred())
-							err = sshConn.ExecuteRemote(node.Metadata.Name, pwQualityValidationCommand, false)
-							Expect(err).NotTo(HaveOccurred())
-						}
-					}
-				} else {
-					Skip("This config is only available on VHD")
 				}
-			} else {
-				Skip("Skip per-node tests in low-priority VMSS cluster configuration scenario")
 			}
 		})
 
-		It("should validate auditd configuration", func() {
-			if !eng.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
-				if eng.ExpandedDefinition.Properties.IsVHDDistroForAllNodes() {
-					var auditDNodePrefixes []string
-					if eng.ExpandedDefinition.Properties.MasterProfile != nil {
-						if to.Bool(eng.ExpandedDefinition.Properties.MasterProfile.AuditDEnabled) {
-							auditDNodePrefixes = append(auditDNodePrefixes, "k8s-master-")
-						}
-					}
-					for _, profile := range eng.ExpandedDefinition.Properties.AgentPoolProfiles {
-						if to.Bool(profile.AuditDEnabled) {
-							auditDNodePrefixes = append(auditDNodePrefixes, profile.Name)
-						}
-					}
-					nodeList, err := node.GetReady()
-					Expect(err).NotTo(HaveOccurred())
-					auditdValidateScript := "auditd-validate.sh"
-					err = sshConn.CopyTo(auditdValidateScript)
-					Expect(err).NotTo(HaveOccurred())
-					for _, node := range nodeList.Nodes {
-						var enabled bool
-						if node.HasSubstring(auditDNodePrefixes) {
-							enabled = true
-						}
-						err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+auditdValidateScript)
-						Expect(err).NotTo(HaveOccurred())
-						auditdValidationCommand := fmt.Sprintf("\"ENABLED=%t /tmp/%s\"", enabled, auditdValidateScript)
-						err = sshConn.ExecuteRemote(node.Metadata.Name, auditdValidationCommand, false)
-						Expect(err).NotTo(HaveOccurred())
-					}
-				} else {
-					Skip("This config is only available on VHD")
+		requires(capability.Not(capability.LowPriority), capability.VHD).It("should validate auditd configuration", func() {
+			var auditDNodePrefixes []string
+			if eng.ExpandedDefinition.Properties.MasterProfile != nil {
+				if to.Bool(eng.ExpandedDefinition.Properties.MasterProfile.AuditDEnabled) {
+					auditDNodePrefixes = append(auditDNodePrefixes, "k8s-master-")
 				}
-			} else {
-				Skip("Skip per-node tests in low-priority VMSS cluster configuration scenario")
+			}
+			for _, profile := range eng.ExpandedDefinition.Properties.AgentPoolProfiles {
+				if to.Bool(profile.AuditDEnabled) {
+					auditDNodePrefixes = append(auditDNodePrefixes, profile.Name)
+				}
+			}
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			auditdValidateScript := "auditd-validate.sh"
+			err = sshConn.CopyTo(auditdValidateScript)
+			Expect(err).NotTo(HaveOccurred())
+			for _, node := range nodeList.Nodes {
+				var enabled bool
+				if node.HasSubstring(auditDNodePrefixes) {
+					enabled = true
+				}
+				err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+auditdValidateScript)
+				Expect(err).NotTo(HaveOccurred())
+				auditdValidationCommand := fmt.Sprintf("ENABLED=%t /tmp/%s", enabled, auditdValidateScript)
+				err = sshConn.ExecuteRemote(node.Metadata.Name, auditdValidationCommand, false)
+				Expect(err).NotTo(HaveOccurred())
 			}
 		})
 
@@ -430,7 +576,7 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 			var pods []pod.Pod
 
 			testPortForward := func(deploymentName string) {
-				running, podWaitErr := pod.WaitOnReady(deploymentName, deploymentNamespace, 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+				running, podWaitErr := deploy.WaitOnReady(3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
 				Expect(podWaitErr).NotTo(HaveOccurred())
 				Expect(running).To(Equal(true))
 				pods, err = deploy.Pods()
@@ -444,48 +590,27 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 						Expect(err).NotTo(HaveOccurred())
 						Expect(running).To(Equal(true))
 						By("Running kubectl port-forward")
-						var proxyCmd *exec.Cmd
-						var proxyStdout, proxyStderr io.ReadCloser
-						var proxyStdoutReader, proxyStderrReader *bufio.Reader
 						success := false
 						for i := 0; i < 5; i++ {
 							if i > 1 {
 								log.Printf("Waiting for retry...\n")
 								time.Sleep(10 * time.Second)
 							}
-							proxyCmd = exec.Command("k", "port-forward", p.Metadata.Name, "8123:80")
-							proxyCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-							proxyStdout, err = proxyCmd.StdoutPipe()
-							Expect(err).NotTo(HaveOccurred())
-							proxyStderr, err = proxyCmd.StderrPipe()
-							Expect(err).NotTo(HaveOccurred())
-							util.PrintCommand(proxyCmd)
-							err = proxyCmd.Start()
-							if err != nil {
-								log.Printf("kubectl port-forward start error: %v\n", err)
-								continue
+							ctx, cancel := context.WithCancel(context.Background())
+							ready, errc := p.PortForward(ctx, 8123, 80)
+							select {
+							case <-ready:
+								success = true
+							case err = <-errc:
+								log.Printf("kubectl port-forward error: %v\n", err)
+							case <-time.After(time.Minute):
+								log.Printf("kubectl port-forward was not ready after 1 minute\n")
 							}
-							proxyStdoutReader = bufio.NewReader(proxyStdout)
-							proxyStderrReader = bufio.NewReader(proxyStderr)
-							proxyOutStr, outErr := proxyStdoutReader.ReadString('\n')
-							log.Printf("kubectl port-forward stdout: %s\n", proxyOutStr)
-							if outErr != nil {
-								proxyErrStr, _ := proxyStderrReader.ReadString('\n') // returns EOF error, ignore it
-								log.Printf("kubectl port-forward stderr: %s\n", proxyErrStr)
-								continue
+							if success {
+								defer cancel()
+								break
 							}
-							defer func() {
-								syscall.Kill(-proxyCmd.Process.Pid, syscall.SIGKILL)
-								_, waiterr := proxyCmd.Process.Wait()
-								if waiterr != nil {
-									log.Printf("kubectl port-forward - no wait error\n")
-								} else {
-									log.Printf("kubectl port-forward - wait returned err: %v\n", waiterr)
-								}
-							}()
-							log.Printf("kubectl port-forward running as pid: %d\n", proxyCmd.Process.Pid)
-							success = true
-							break
+							cancel()
 						}
 						Expect(success).To(Equal(true))
 						By("Running curl to access the forwarded port")
@@ -499,12 +624,11 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 					}()
 				}
 			}
-			r := rand.New(rand.NewSource(time.Now().UnixNano()))
 			if eng.AnyAgentIsLinux() {
 				By("Creating a Linux nginx deployment")
 				deploymentPrefix := "portforwardlinux"
-				deploymentName := fmt.Sprintf("%s-%v", deploymentPrefix, r.Intn(9999))
-				deploy, err = deployment.CreateLinuxDeployDeleteIfExists(deploymentPrefix, "library/nginx:latest", deploymentName, deploymentNamespace, "")
+				deploymentName := util.UniqueName(deploymentPrefix)
+				deploy, err = deployment.CreateLinuxDeployDeleteIfExists(util.RunPrefix(deploymentPrefix), "library/nginx:latest", deploymentName, deploymentNamespace, "")
 				Expect(err).NotTo(HaveOccurred())
 				testPortForward(deploymentName)
 				err = deploy.Delete(util.DefaultDeleteRetries)
@@ -516,8 +640,8 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 					windowsImages, err := eng.GetWindowsTestImages()
 					Expect(err).NotTo(HaveOccurred())
 					deploymentPrefix := "portforwardwindows"
-					deploymentName := fmt.Sprintf("%s-%v", deploymentPrefix, r.Intn(9999))
-					deploy, err = deployment.CreateWindowsDeployDeleteIfExist(deploymentPrefix, windowsImages.IIS, deploymentName, deploymentNamespace, "")
+					deploymentName := util.UniqueName(deploymentPrefix)
+					deploy, err = deployment.CreateWindowsDeployDeleteIfExist(util.RunPrefix(deploymentPrefix), windowsImages.IIS, deploymentName, deploymentNamespace, "")
 					Expect(err).NotTo(HaveOccurred())
 					testPortForward(deploymentName)
 					err = deploy.Delete(util.DefaultDeleteRetries)
@@ -556,21 +680,17 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 			Expect(ready).To(Equal(true))
 		})
 
-		It("should have node labels and annotations", func() {
-			if !eng.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
-				totalNodeCount := eng.NodeCount()
-				masterNodes, err := node.GetByRegex(firstMasterRegexStr)
-				Expect(err).NotTo(HaveOccurred())
-				nodes := totalNodeCount - len(masterNodes)
-				nodeList, err := node.GetByLabel("foo")
-				Expect(err).NotTo(HaveOccurred())
-				Expect(len(nodeList)).To(Equal(nodes))
-				nodeList, err = node.GetByAnnotations("foo", "bar")
-				Expect(err).NotTo(HaveOccurred())
-				Expect(len(nodeList)).To(Equal(nodes))
-			} else {
-				Skip("Skip per-node tests in low-priority VMSS cluster configuration scenario")
-			}
+		requires(capability.Not(capability.LowPriority)).It("should have node labels and annotations", func() {
+			totalNodeCount := eng.NodeCount()
+			masterNodes, err := node.GetByRegex(firstMasterRegexStr)
+			Expect(err).NotTo(HaveOccurred())
+			nodes := totalNodeCount - len(masterNodes)
+			nodeList, err := node.GetByLabel("foo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(nodeList)).To(Equal(nodes))
+			nodeList, err = node.GetByAnnotations("foo", "bar")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(nodeList)).To(Equal(nodes))
 		})
 
 		It("should have node labels specific to masters or agents", func() {
@@ -599,61 +719,373 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 			}
 		})
 
-		It("should print cluster resources", func() {
-			cmd := exec.Command("k", "get", "deployments,pods,svc,daemonsets,configmaps,endpoints,jobs,clusterroles,clusterrolebindings,roles,rolebindings,storageclasses", "--all-namespaces", "-o", "wide")
-			out, err := cmd.CombinedOutput()
-			log.Printf("%s\n", out)
-			if err != nil {
-				log.Printf("Error: Unable to print all pods\n")
-			}
-		})
-
-		It("should have DNS pod running", func() {
-			var err error
-			var running bool
-			if common.IsKubernetesVersionGe(eng.ExpandedDefinition.Properties.OrchestratorProfile.OrchestratorVersion, "1.12.0") {
-				By("Ensuring that coredns is running")
-				running, err = pod.WaitOnReady("coredns", "kube-system", kubeSystemPodsReadinessChecks, retryTimeWhenWaitingForPodReady, cfg.Timeout)
-
-			} else {
-				By("Ensuring that kube-dns is running")
-				running, err = pod.WaitOnReady("kube-dns", "kube-system", kubeSystemPodsReadinessChecks, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+		It("should have the taints defined in the apimodel on the nodes of tainted pools", func() {
+			poolTaints := eng.GetAgentPoolTaints()
+			if len(poolTaints) == 0 {
+				Skip("No agent pool registers its nodes with taints")
 			}
+			nodeList, err := node.Get()
 			Expect(err).NotTo(HaveOccurred())
-			Expect(running).To(Equal(true))
-		})
-
-		It("should have core kube-system componentry running", func() {
-			coreComponents := []string{"kube-proxy", "kube-addon-manager", "kube-apiserver", "kube-controller-manager", "kube-scheduler"}
-			if !common.IsKubernetesVersionGe(eng.ExpandedDefinition.Properties.OrchestratorProfile.OrchestratorVersion, "1.13.0") {
-				coreComponents = append(coreComponents, "heapster")
-			}
-			for _, componentName := range coreComponents {
-				By(fmt.Sprintf("Ensuring that %s is Running", componentName))
-				running, err := pod.WaitOnReady(componentName, "kube-system", kubeSystemPodsReadinessChecks, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			for pool, t := range poolTaints {
+				taints, err := node.ParseTaints(t)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(running).To(Equal(true))
+				var poolNodes int
+				for _, n := range nodeList.Nodes {
+					if n.Metadata.Labels["agentpool"] != pool {
+						continue
+					}
+					poolNodes++
+					for _, taint := range taints {
+						Expect(n.HasTaint(taint)).To(BeTrue(), "node %s of pool %s does not have taint %s", n.Metadata.Name, pool, taint)
+					}
+				}
+				Expect(poolNodes).NotTo(BeZero(), "no nodes found for pool %s", pool)
 			}
 		})
 
-		It("Should not have any unready or crashing pods right after deployment", func() {
-			if eng.Config.DebugCrashingPods {
-				By("Checking ready status of each pod in kube-system")
-				pods, err := pod.GetAll("kube-system")
-				Expect(err).NotTo(HaveOccurred())
-				Expect(len(pods.Pods)).ToNot(BeZero())
-				for _, currentPod := range pods.Pods {
-					log.Printf("Checking %s - ready: %t, restarts: %d", currentPod.Metadata.Name, currentPod.Status.ContainerStatuses[0].Ready, currentPod.Status.ContainerStatuses[0].RestartCount)
-					Expect(currentPod.Status.ContainerStatuses[0].Ready).To(BeTrue())
-					tooManyRestarts := 5
-					if strings.Contains(currentPod.Metadata.Name, "cluster-autoscaler") {
-						log.Print("need to investigate cluster-autoscaler restarts!")
-						tooManyRestarts = 10
+		requires(capability.Spot).It("should label and taint the nodes of the spot pools", func() {
+			nodeList, err := node.Get()
+			Expect(err).NotTo(HaveOccurred())
+			for _, pool := range eng.ExpandedDefinition.Properties.AgentPoolProfiles {
+				if !pool.IsSpotScaleSet() {
+					continue
+				}
+				var poolNodes int
+				for _, n := range nodeList.Nodes {
+					if n.Metadata.Labels["agentpool"] != pool.Name {
+						continue
 					}
-					Expect(currentPod.Status.ContainerStatuses[0].RestartCount).To(BeNumerically("<", tooManyRestarts))
+					poolNodes++
+					Expect(n.Metadata.Labels).To(HaveKeyWithValue(api.ScaleSetPriorityLabelKey, api.ScaleSetPrioritySpotLabelValue))
+					var tainted bool
+					for _, t := range n.Spec.Taints {
+						tainted = tainted || t.Key == api.ScaleSetPriorityLabelKey
+					}
+					Expect(tainted).To(BeTrue(), "node %s of spot pool %s does not have a %s taint", n.Metadata.Name, pool.Name, api.ScaleSetPriorityLabelKey)
 				}
-			} else {
-				Skip("Skipping this DEBUG test")
+				Expect(poolNodes).NotTo(BeZero(), "no nodes found for spot pool %s", pool.Name)
+			}
+		})
+
+		requires(capability.Linux).It("should keep pods that do not tolerate a taint off a tainted node", func() {
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			var candidates []node.Node
+			for _, n := range nodeList.Nodes {
+				if n.IsLinux() && !firstMasterRegexp.MatchString(n.Metadata.Name) && !n.Spec.Unschedulable && len(n.Spec.Taints) == 0 {
+					candidates = append(candidates, n)
+				}
+			}
+			if len(candidates) == 0 {
+				Skip("No schedulable linux agent without taints was found")
+			}
+			By("Reserving a node that no other spec taints or cordons in the meantime")
+			owner := util.UniqueName("node-maintenance")
+			nodeName, err := node.ReserveAny(candidates, owner)
+			Expect(err).NotTo(HaveOccurred())
+			defer node.Release(nodeName, owner)
+			// should be the same as in pod-node-maintenance.yaml
+			maintenanceLabel := "e2e-maintenance"
+			taint := node.Taint{Key: maintenanceLabel, Value: "true", Effect: "NoSchedule"}
+
+			By(fmt.Sprintf("Labeling and tainting node %s", nodeName))
+			err = node.Label(nodeName, map[string]string{maintenanceLabel: "true"})
+			Expect(err).NotTo(HaveOccurred())
+			defer node.RemoveLabels(nodeName, maintenanceLabel)
+			err = node.AddTaint(nodeName, taint)
+			Expect(err).NotTo(HaveOccurred())
+			defer node.RemoveTaint(nodeName, taint)
+			n, err := node.GetByName(nodeName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n.HasTaint(taint)).To(BeTrue())
+
+			By("Creating a pod that can only run on the tainted node")
+			p, err := pod.CreatePodFromFile(filepath.Join(WorkloadDir, "pod-node-maintenance.yaml"), "node-maintenance", "default", 1*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			defer p.Delete(util.DefaultDeleteRetries)
+
+			By("Ensuring that the pod is not scheduled while the node is tainted")
+			time.Sleep(30 * time.Second)
+			p, err = pod.Get(p.Metadata.Name, p.Metadata.Namespace, podLookupRetries)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Spec.NodeName).To(BeEmpty())
+			Expect(p.Status.Phase).To(Equal("Pending"))
+
+			By("Removing the taint and ensuring the pod is scheduled on the node")
+			err = node.RemoveTaint(nodeName, taint)
+			Expect(err).NotTo(HaveOccurred())
+			ready, err := p.WaitOnReady(retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready).To(BeTrue())
+			p, err = pod.Get(p.Metadata.Name, p.Metadata.Namespace, podLookupRetries)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Spec.NodeName).To(Equal(nodeName))
+
+			By("Cordoning the node and ensuring it is unschedulable")
+			err = node.Cordon(nodeName)
+			Expect(err).NotTo(HaveOccurred())
+			defer node.Uncordon(nodeName)
+			n, err = node.GetByName(nodeName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n.Spec.Unschedulable).To(BeTrue())
+			err = node.Uncordon(nodeName)
+			Expect(err).NotTo(HaveOccurred())
+			n, err = node.GetByName(nodeName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n.Spec.Unschedulable).To(BeFalse())
+		})
+
+		requires(capability.LowPriority, capability.ScheduledEventsHandler, capability.Addon("cluster-autoscaler")).It("should drain an evicted low-priority instance, reschedule its pods and backfill its capacity [Serial]", func() {
+			if !cfg.SpotEviction {
+				Skip("SPOT_EVICTION_SCENARIO is not set")
+			}
+			spotPools := map[string]bool{}
+			for _, pool := range eng.ExpandedDefinition.Properties.AgentPoolProfiles {
+				if pool.IsLowPriorityScaleSet() && pool.IsScheduledEventsHandlerEnabled() {
+					spotPools[pool.Name] = true
+				}
+			}
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			var schedulable []node.Node
+			otherPools := map[string]bool{}
+			for _, n := range nodeList.Nodes {
+				if n.IsLinux() && !firstMasterRegexp.MatchString(n.Metadata.Name) && !n.Spec.Unschedulable && len(n.Spec.Taints) == 0 {
+					schedulable = append(schedulable, n)
+					if !spotPools[n.Metadata.Labels["agentpool"]] {
+						otherPools[n.Metadata.Labels["agentpool"]] = true
+					}
+				}
+			}
+			if len(otherPools) == 0 {
+				Skip("No schedulable linux agent outside of the low-priority pools was found")
+			}
+			readyNodes := len(nodeList.Nodes)
+
+			By("Creating a deployment with a pod on every schedulable linux agent")
+			d, err := deployment.CreateDeploymentFromFile(filepath.Join(WorkloadDir, "deployment-spot-eviction.yaml"), "spot-eviction", "default")
+			Expect(err).NotTo(HaveOccurred())
+			defer d.Delete(util.DefaultDeleteRetries)
+			err = d.ScaleDeployment(len(schedulable))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = d.WaitForReplicas(len(schedulable), len(schedulable), retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			running, err := d.WaitOnReady(3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(BeTrue())
+
+			By("Choosing a low-priority instance running a pod of the deployment")
+			pods, err := d.Pods()
+			Expect(err).NotTo(HaveOccurred())
+			var victim string
+			for _, p := range pods {
+				for _, n := range schedulable {
+					if n.Metadata.Name == p.Spec.NodeName && spotPools[n.Metadata.Labels["agentpool"]] {
+						victim = n.Metadata.Name
+					}
+				}
+			}
+			Expect(victim).NotTo(BeEmpty(), "no pod of the deployment runs on a low-priority instance")
+			account, err := azure.NewAccount()
+			Expect(err).NotTo(HaveOccurred())
+			client, err := account.NewARMClient(eng.ExpandedDefinition.Location)
+			Expect(err).NotTo(HaveOccurred())
+			ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+			defer cancel()
+			var vmss, instanceID string
+			for vmssPage, err := client.ListVirtualMachineScaleSets(ctx, cfg.Name); vmssPage.NotDone() && instanceID == ""; err = vmssPage.NextWithContext(ctx) {
+				Expect(err).NotTo(HaveOccurred())
+				for _, s := range vmssPage.Values() {
+					for vmPage, err := client.ListVirtualMachineScaleSetVMs(ctx, cfg.Name, *s.Name); vmPage.NotDone(); err = vmPage.NextWithContext(ctx) {
+						Expect(err).NotTo(HaveOccurred())
+						for _, vm := range vmPage.Values() {
+							if vm.OsProfile != nil && strings.EqualFold(to.String(vm.OsProfile.ComputerName), victim) {
+								vmss, instanceID = *s.Name, *vm.InstanceID
+							}
+						}
+					}
+				}
+			}
+			Expect(instanceID).NotTo(BeEmpty(), "no instance of a scale set is node %s", victim)
+
+			By(fmt.Sprintf("Simulating the eviction of instance %s of %s, node %s", instanceID, vmss, victim))
+			err = client.SimulateEvictionVirtualMachineScaleSetVM(ctx, cfg.Name, vmss, instanceID)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Ensuring that the scheduled events handler cordons the node before it is evicted")
+			Eventually(func() bool {
+				n, err := node.GetByName(victim)
+				// the node may already be gone once evicted, that it was cordoned is then checked by the pods having moved
+				return err != nil || n.Spec.Unschedulable
+			}, 2*time.Minute, 5*time.Second).Should(BeTrue())
+
+			By("Ensuring that the pods of the evicted node are rescheduled onto other nodes")
+			Eventually(func() bool {
+				pods, err := d.Pods()
+				if err != nil {
+					return false
+				}
+				ready := 0
+				for _, p := range pods {
+					if p.Spec.NodeName == victim {
+						return false
+					}
+					if p.IsReady() {
+						ready++
+					}
+				}
+				return ready == len(schedulable)
+			}, spotEvictionBackfillTimeout, 30*time.Second).Should(BeTrue())
+
+			By("Ensuring that the cluster-autoscaler backfills the evicted capacity")
+			ready := node.WaitOnReady(readyNodes, 30*time.Second, spotEvictionBackfillTimeout)
+			Expect(ready).To(BeTrue())
+		})
+
+		requires(capability.Linux).It("should converge after the traffic between the nodes is denied for a while [Serial]", func() {
+			if !cfg.NetworkOutage {
+				Skip("NETWORK_OUTAGE_SCENARIO is not set")
+			}
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			readyNodes := len(nodeList.Nodes)
+
+			By("Creating a nginx deployment exposed by a LoadBalancer service")
+			deploymentPrefix := fmt.Sprintf("network-outage-nginx-%s", cfg.Name)
+			deploy, err := deployment.CreateLinuxDeployDeleteIfExists(util.RunPrefix(deploymentPrefix), "library/nginx:latest", util.UniqueName(deploymentPrefix), "default", "--replicas=2")
+			Expect(err).NotTo(HaveOccurred())
+			defer deploy.Delete(util.DefaultDeleteRetries)
+			running, err := deploy.WaitOnReady(3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(BeTrue())
+			err = deploy.ExposeDeleteIfExist(util.RunPrefix(deploymentPrefix), "default", "LoadBalancer", 80, 80)
+			Expect(err).NotTo(HaveOccurred())
+			s, err := service.Get(deploy.Metadata.Name, "default")
+			Expect(err).NotTo(HaveOccurred())
+			defer s.Delete(util.DefaultDeleteRetries)
+			_, err = s.WaitOnExternalIP(5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Ensuring that pods can connect to the deployment through its service")
+			deploymentPrefix = "network-outage-curl"
+			curlDeploy, err := deployment.CreateLinuxDeployDeleteIfExists(util.RunPrefix(deploymentPrefix), "library/nginx:latest", util.UniqueName(deploymentPrefix), "default", "--replicas=2")
+			Expect(err).NotTo(HaveOccurred())
+			defer curlDeploy.Delete(util.DefaultDeleteRetries)
+			running, err = curlDeploy.WaitOnReady(3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(BeTrue())
+			curlPods, err := curlDeploy.Pods()
+			Expect(err).NotTo(HaveOccurred())
+			for _, curlPod := range curlPods {
+				pass, err := curlPod.ValidateCurlConnection(deploy.Metadata.Name, 5*time.Second, 3*time.Minute)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pass).To(BeTrue())
+			}
+
+			By(fmt.Sprintf("Denying the traffic between the nodes for %s", cfg.NetworkOutageWindow))
+			account, err := azure.NewAccount()
+			Expect(err).NotTo(HaveOccurred())
+			client, err := account.NewARMClient(eng.ExpandedDefinition.Location)
+			Expect(err).NotTo(HaveOccurred())
+			ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+			defer cancel()
+			nsgName, err := azure.ApplyNodeDenyRule(ctx, client, cfg.Name, eng.ExpandedDefinition)
+			Expect(err).NotTo(HaveOccurred())
+			removed := false
+			defer func() {
+				// leave the cluster usable by the next specs if the scenario fails before the outage ends
+				if !removed {
+					if err := azure.RemoveNodeDenyRule(ctx, client, cfg.Name, nsgName); err != nil {
+						log.Printf("Error: %s\n", err)
+					}
+				}
+			}()
+			time.Sleep(cfg.NetworkOutageWindow)
+
+			By("Allowing the traffic between the nodes again")
+			err = azure.RemoveNodeDenyRule(ctx, client, cfg.Name, nsgName)
+			Expect(err).NotTo(HaveOccurred())
+			removed = true
+			recovered := time.Now()
+			deadline := recovered.Add(cfg.NetworkOutageSLO)
+
+			By(fmt.Sprintf("Ensuring that the cluster converges within %s", cfg.NetworkOutageSLO))
+			ready := node.WaitOnReady(readyNodes, 10*time.Second, time.Until(deadline))
+			Expect(ready).To(BeTrue(), "the nodes are not all Ready")
+			running, err = deploy.WaitOnReady(3, retryTimeWhenWaitingForPodReady, time.Until(deadline))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(BeTrue())
+			for _, curlPod := range curlPods {
+				pass, err := curlPod.ValidateCurlConnection(deploy.Metadata.Name, 5*time.Second, time.Until(deadline))
+				Expect(err).NotTo(HaveOccurred(), "pod %s did not reconnect to the deployment", curlPod.Metadata.Name)
+				Expect(pass).To(BeTrue())
+			}
+			valid := s.Validate("(Welcome to nginx)", 5, 30*time.Second, time.Until(deadline))
+			Expect(valid).To(BeTrue(), "the LoadBalancer service is not reachable")
+			Eventually(func() error {
+				return azure.ValidateLoadBalancersConverged(ctx, client, cfg.Name)
+			}, time.Until(deadline), 15*time.Second).Should(Succeed())
+			log.Printf("The cluster converged %s after the network outage\n", time.Since(recovered))
+		})
+
+		It("should print cluster resources", func() {
+			cmd := exec.Command("k", "get", "deployments,pods,svc,daemonsets,configmaps,endpoints,jobs,clusterroles,clusterrolebindings,roles,rolebindings,storageclasses", "--all-namespaces", "-o", "wide")
+			out, err := cmd.CombinedOutput()
+			log.Printf("%s\n", out)
+			if err != nil {
+				log.Printf("Error: Unable to print all pods\n")
+			}
+		})
+
+		It("should have DNS pod running", func() {
+			var err error
+			var running bool
+			if common.IsKubernetesVersionGe(eng.ExpandedDefinition.Properties.OrchestratorProfile.OrchestratorVersion, "1.12.0") {
+				By("Ensuring that coredns is running")
+				running, err = pod.WaitOnReady("coredns", "kube-system", kubeSystemPodsReadinessChecks, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+
+			} else {
+				By("Ensuring that kube-dns is running")
+				running, err = pod.WaitOnReady("kube-dns", "kube-system", kubeSystemPodsReadinessChecks, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			}
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(Equal(true))
+		})
+
+		It("should have core kube-system componentry running", func() {
+			coreComponents := []string{"kube-addon-manager", "kube-apiserver", "kube-controller-manager", "kube-scheduler"}
+			if !eng.ExpandedDefinition.Properties.OrchestratorProfile.KubernetesConfig.IsKubeProxyReplaced() {
+				coreComponents = append(coreComponents, "kube-proxy")
+			}
+			if !common.IsKubernetesVersionGe(eng.ExpandedDefinition.Properties.OrchestratorProfile.OrchestratorVersion, "1.13.0") {
+				coreComponents = append(coreComponents, "heapster")
+			}
+			for _, componentName := range coreComponents {
+				By(fmt.Sprintf("Ensuring that %s is Running", componentName))
+				running, err := pod.WaitOnReady(componentName, "kube-system", kubeSystemPodsReadinessChecks, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(running).To(Equal(true))
+			}
+		})
+
+		It("Should not have any unready or crashing pods right after deployment", func() {
+			if eng.Config.DebugCrashingPods {
+				By("Checking ready status of each pod in kube-system")
+				pods, err := pod.GetAll("kube-system")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(pods.Pods)).ToNot(BeZero())
+				for _, currentPod := range pods.Pods {
+					log.Printf("Checking %s - ready: %t, restarts: %d", currentPod.Metadata.Name, currentPod.Status.ContainerStatuses[0].Ready, currentPod.Status.ContainerStatuses[0].RestartCount)
+					Expect(currentPod.Status.ContainerStatuses[0].Ready).To(BeTrue())
+					tooManyRestarts := 5
+					if strings.Contains(currentPod.Metadata.Name, "cluster-autoscaler") {
+						log.Print("need to investigate cluster-autoscaler restarts!")
+						tooManyRestarts = 10
+					}
+					Expect(currentPod.Status.ContainerStatuses[0].RestartCount).To(BeNumerically("<", tooManyRestarts))
+				}
+			} else {
+				Skip("Skipping this DEBUG test")
 			}
 		})
 
@@ -677,6 +1109,17 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 		})
 
 		It("should have addons running", func() {
+			// addons deployed as a DaemonSet, by the name of their DaemonSet
+			addonDaemonSets := map[string]string{
+				"blobfuse-flexvolume":      "blobfuse-flexvol-installer",
+				"smb-flexvolume":           "smb-flexvol-installer",
+				"keyvault-flexvolume":      "keyvault-flexvolume",
+				"nvidia-device-plugin":     "nvidia-device-plugin",
+				"container-monitoring":     "omsagent",
+				"azure-cni-networkmonitor": "azure-cni-networkmonitor",
+				"azure-npm-daemonset":      "azure-npm",
+				"ip-masq-agent":            "azure-ip-masq-agent",
+			}
 			for _, addonName := range []string{"tiller", "aci-connector", "cluster-autoscaler", "blobfuse-flexvolume", "smb-flexvolume", "keyvault-flexvolume", "kubernetes-dashboard", "rescheduler", "metrics-server", "nvidia-device-plugin", "container-monitoring", "azure-cni-networkmonitor", "azure-npm-daemonset", "ip-masq-agent"} {
 				var addonPods = []string{addonName}
 				var addonNamespace = "kube-system"
@@ -699,6 +1142,11 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 						By(fmt.Sprintf("Ensuring that the correct resources have been applied for %s", addonPod))
 						pods, err := pod.GetAllByPrefix(addonPod, addonNamespace)
 						Expect(err).NotTo(HaveOccurred())
+						for _, p := range pods {
+							if readySince, ready := p.ReadySince(); ready {
+								metrics.SuiteRecorder().Record("e2e_addon_ready_seconds", "Time taken by an addon pod to become ready once created.", map[string]string{"addon": addonName, "pod": p.Metadata.Name}, readySince.Sub(p.Metadata.CreatedAt).Seconds())
+							}
+						}
 						for i, c := range addon.Containers {
 							pod := pods[0]
 							container := pod.Spec.Containers[i]
@@ -706,6 +1154,11 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 							Expect(err).NotTo(HaveOccurred())
 						}
 					}
+					if daemonSetName, ok := addonDaemonSets[addonName]; ok {
+						By(fmt.Sprintf("Ensuring that the %s addon runs a ready pod on every node it can be scheduled on", addonName))
+						_, err := daemonset.WaitOnReady(daemonSetName, addonNamespace, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+						Expect(err).NotTo(HaveOccurred())
+					}
 				} else {
 					fmt.Printf("%s disabled for this cluster, will not test\n", addonName)
 				}
@@ -730,56 +1183,51 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 			}
 		})
 
-		It("should have the expected omsagent cluster footprint", func() {
-			if hasContainerMonitoring, _ := eng.HasAddon("container-monitoring"); hasContainerMonitoring {
-				By("Validating the omsagent replicaset")
-				running, err := pod.WaitOnReady("omsagent-rs", "kube-system", kubeSystemPodsReadinessChecks, retryTimeWhenWaitingForPodReady, cfg.Timeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(running).To(Equal(true))
-				pods, err := pod.GetAllByPrefix("omsagent-rs", "kube-system")
-				Expect(err).NotTo(HaveOccurred())
-				By("Ensuring that the kubepodinventory plugin is writing data successfully")
-				pass, err := pods[0].ValidateOmsAgentLogs("kubePodInventoryEmitStreamSuccess", 1*time.Second, cfg.Timeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(pass).To(BeTrue())
-				By("Ensuring that the kubenodeinventory plugin is writing data successfully")
-				pass, err = pods[0].ValidateOmsAgentLogs("kubeNodeInventoryEmitStreamSuccess", 1*time.Second, cfg.Timeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(pass).To(BeTrue())
-				By("Validating the omsagent daemonset")
-				running, err = pod.WaitOnReady("omsagent", "kube-system", kubeSystemPodsReadinessChecks, retryTimeWhenWaitingForPodReady, cfg.Timeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(running).To(Equal(true))
-				pods, err = pod.GetAllByPrefix("omsagent", "kube-system")
-				Expect(err).NotTo(HaveOccurred())
-				By("Ensuring that the cadvisor_perf plugin is writing data successfully")
-				pass, err = pods[0].ValidateOmsAgentLogs("cAdvisorPerfEmitStreamSuccess", 1*time.Second, cfg.Timeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(pass).To(BeTrue())
-				By("Ensuring that the containerinventory plugin is writing data successfully")
-				pass, err = pods[0].ValidateOmsAgentLogs("containerInventoryEmitStreamSuccess", 1*time.Second, cfg.Timeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(pass).To(BeTrue())
-			} else {
-				Skip("container monitoring disabled for this cluster, will not test")
-			}
+		requires(capability.Addon("container-monitoring")).It("should have the expected omsagent cluster footprint", func() {
+			By("Validating the omsagent replicaset")
+			running, err := pod.WaitOnReady("omsagent-rs", "kube-system", kubeSystemPodsReadinessChecks, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(Equal(true))
+			pods, err := pod.GetAllByPrefix("omsagent-rs", "kube-system")
+			Expect(err).NotTo(HaveOccurred())
+			By("Ensuring that the kubepodinventory plugin is writing data successfully")
+			pass, err := pods[0].ValidateOmsAgentLogs("kubePodInventoryEmitStreamSuccess", 1*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pass).To(BeTrue())
+			By("Ensuring that the kubenodeinventory plugin is writing data successfully")
+			pass, err = pods[0].ValidateOmsAgentLogs("kubeNodeInventoryEmitStreamSuccess", 1*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pass).To(BeTrue())
+			By("Validating the omsagent daemonset")
+			running, err = pod.WaitOnReady("omsagent", "kube-system", kubeSystemPodsReadinessChecks, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(Equal(true))
+			pods, err = pod.GetAllByPrefix("omsagent", "kube-system")
+			Expect(err).NotTo(HaveOccurred())
+			By("Ensuring that the cadvisor_perf plugin is writing data successfully")
+			pass, err = pods[0].ValidateOmsAgentLogs("cAdvisorPerfEmitStreamSuccess", 1*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pass).To(BeTrue())
+			By("Ensuring that the containerinventory plugin is writing data successfully")
+			pass, err = pods[0].ValidateOmsAgentLogs("containerInventoryEmitStreamSuccess", 1*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pass).To(BeTrue())
 		})
 
-		It("should be able to launch a long-running container networking DNS liveness pod", func() {
-			p, err := pod.CreatePodFromFileIfNotExist(filepath.Join(WorkloadDir, "dns-liveness.yaml"), "dns-liveness", "default", 1*time.Second, cfg.Timeout)
+		It("should have a long-running container networking DNS liveness pod running", func() {
+			p, err := pod.Get("dns-liveness", "default", podLookupRetries)
 			Expect(err).NotTo(HaveOccurred())
 			running, err := p.WaitOnReady(retryTimeWhenWaitingForPodReady, cfg.Timeout)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(running).To(Equal(true))
 		})
 
-		It("should be able to launch a long running HTTP listener and svc endpoint", func() {
-			By("Creating a php-apache deployment")
-			phpApacheDeploy, err := deployment.CreateLinuxDeployIfNotExist("deis/hpa-example", longRunningApacheDeploymentName, "default", "--requests=cpu=10m,memory=10M")
+		It("should have a long running HTTP listener and svc endpoint", func() {
+			phpApacheDeploy, err := deployment.Get(longRunningApacheDeploymentName, "default")
 			Expect(err).NotTo(HaveOccurred())
 
 			By("Ensuring that php-apache pod is running")
-			running, err := pod.WaitOnReady(longRunningApacheDeploymentName, "default", 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			running, err := phpApacheDeploy.WaitOnReady(3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(running).To(Equal(true))
 
@@ -792,8 +1240,8 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 				Expect(pass).To(BeTrue())
 			}
 
-			By("Exposing TCP 80 internally on the php-apache deployment")
-			err = phpApacheDeploy.ExposeIfNotExist("ClusterIP", 80, 80)
+			By("Ensuring that TCP 80 is exposed internally on the php-apache deployment")
+			_, err = service.Get(longRunningApacheDeploymentName, "default")
 			Expect(err).NotTo(HaveOccurred())
 		})
 
@@ -818,19 +1266,14 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 			Expect(successes).To(Equal(cfg.StabilityIterations))
 		})
 
-		It("should have stable pod-to-pod networking", func() {
-			if eng.AnyAgentIsLinux() {
-				By("Creating a test php-apache deployment")
-				r := rand.New(rand.NewSource(time.Now().UnixNano()))
-				By("Creating another pod that will connect to the php-apache pod")
-				commandString := fmt.Sprintf("nc -vz %s.default.svc.cluster.local 80", longRunningApacheDeploymentName)
-				consumerPodName := fmt.Sprintf("consumer-pod-%s-%v", cfg.Name, r.Intn(99999))
-				successes, err := pod.RunCommandMultipleTimes(pod.RunLinuxPod, "busybox", consumerPodName, commandString, cfg.StabilityIterations, 1*time.Second, retryCommandsTimeout, stabilityCommandTimeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(successes).To(Equal(cfg.StabilityIterations))
-			} else {
-				Skip("Pod-to-pod network tests only valid on Linux clusters")
-			}
+		requires(capability.Linux).It("should have stable pod-to-pod networking", func() {
+			By("Creating a test php-apache deployment")
+			By("Creating another pod that will connect to the php-apache pod")
+			commandString := fmt.Sprintf("nc -vz %s.default.svc.cluster.local 80", longRunningApacheDeploymentName)
+			consumerPodName := fmt.Sprintf("consumer-pod-%s", cfg.Name)
+			successes, err := pod.RunCommandMultipleTimes(pod.RunLinuxPod, "busybox", consumerPodName, commandString, cfg.StabilityIterations, 1*time.Second, retryCommandsTimeout, stabilityCommandTimeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(successes).To(Equal(cfg.StabilityIterations))
 		})
 
 		It("should have functional container networking DNS", func() {
@@ -846,6 +1289,17 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 			Expect(err).NotTo(HaveOccurred())
 			Expect(ready).To(Equal(true))
 
+			By("Ensuring that cluster-internal and external names resolve from a linux container")
+			p, err := pod.Get("dns-liveness", "default", podLookupRetries)
+			Expect(err).NotTo(HaveOccurred())
+			clusterDomain := eng.ClusterDomain()
+			lookups := append(dns.ClusterLookups(clusterDomain), dns.ServiceLookups(longRunningApacheDeploymentName, "default", clusterDomain)...)
+			lookups = append(lookups, dns.ExternalLookups()...)
+			ctx, cancel := context.WithTimeout(context.Background(), validateDNSTimeout)
+			defer cancel()
+			_, err = dns.Validate(ctx, p, api.Linux, lookups, util.DefaultRetrier().WithConstantBackoff(5*time.Second))
+			Expect(err).NotTo(HaveOccurred())
+
 			if eng.HasWindowsAgents() {
 				By("Ensuring that we have functional DNS resolution from a windows container")
 				windowsImages, imgErr := eng.GetWindowsTestImages()
@@ -870,172 +1324,620 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 			Expect(successes).To(Equal(cfg.StabilityIterations))
 		})
 
-		It("should be able to access the dashboard", func() {
-			if hasDashboard, _ := eng.HasAddon("kubernetes-dashboard"); hasDashboard {
-				By("Ensuring that the kubernetes-dashboard service is Running")
-				s, err := service.Get("kubernetes-dashboard", "kube-system")
+		requires(capability.Addon("dns-autoscaler")).It("should scale coredns with the dns-autoscaler and sustain a DNS query load [Serial]", func() {
+			if !common.IsKubernetesVersionGe(eng.ExpandedDefinition.Properties.OrchestratorProfile.OrchestratorVersion, "1.12.0") {
+				Skip("dns-autoscaler scales coredns, which requires Kubernetes 1.12 or newer")
+			}
+			By("Ensuring that coredns is scaled as the dns-autoscaler is configured")
+			params, err := dns.GetAutoscalerParams()
+			Expect(err).NotTo(HaveOccurred())
+			_, err = dns.WaitOnAutoscaledReplicas(*params, 5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Reconfiguring the dns-autoscaler to run a coredns replica per node")
+			scaled := *params
+			scaled.NodesPerReplica = 1
+			err = dns.SetAutoscalerParams(scaled)
+			Expect(err).NotTo(HaveOccurred())
+			defer func() {
+				By("Restoring the dns-autoscaler configuration")
+				err = dns.SetAutoscalerParams(*params)
 				Expect(err).NotTo(HaveOccurred())
-				By("Ensuring that we can connect via HTTPS to the dashboard on any one node")
-				dashboardPort := 443
-				port := s.GetNodePort(dashboardPort)
-				nodeList, err := node.GetReady()
+				_, err = dns.WaitOnAutoscaledReplicas(*params, 5*time.Second, cfg.Timeout)
 				Expect(err).NotTo(HaveOccurred())
-				var success bool
-				for _, node := range nodeList.Nodes {
-					if success {
-						break
-					}
-					if node.IsLinux() {
-						// Allow 3 retries for each node
-						for i := 0; i < 3; i++ {
-							address := node.Status.GetAddressByType("InternalIP")
-							if address == nil {
-								log.Printf("One of our nodes does not have an InternalIP value!: %s\n", node.Metadata.Name)
-							}
-							Expect(address).NotTo(BeNil())
-							dashboardURL := fmt.Sprintf("http://%s:%v", address.Address, port)
-							curlCMD := fmt.Sprintf("curl --max-time 60 %s", dashboardURL)
-							err := sshConn.Execute(curlCMD, false)
-							if err == nil {
-								success = true
-								break
-							}
-							time.Sleep(1 * time.Second)
-						}
-					}
-				}
-				Expect(success).To(BeTrue())
-			} else {
-				Skip("kubernetes-dashboard disabled for this cluster, will not test")
-			}
-		})
-	})
+			}()
+			replicas, err := dns.WaitOnAutoscaledReplicas(scaled, 5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			running, err := pod.WaitOnReady("coredns", "kube-system", replicas, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(BeTrue())
 
-	Describe("with a windows agent pool", func() {
-		It("kubelet service should be able to recover when the docker service is stopped", func() {
-			if !eng.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
-				if eng.HasWindowsAgents() {
-					nodeList, err := node.GetReady()
-					Expect(err).NotTo(HaveOccurred())
-					simulateDockerdCrashScript := "simulate-dockerd-crash.cmd"
-					err = sshConn.CopyTo(simulateDockerdCrashScript)
-					Expect(err).NotTo(HaveOccurred())
-					for _, node := range nodeList.Nodes {
-						if node.IsWindows() {
-							By(fmt.Sprintf("simulating docker and subsequent kubelet service crash on node: %s", node.Metadata.Name))
-							err = sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+simulateDockerdCrashScript)
-							Expect(err).NotTo(HaveOccurred())
-							simulateDockerCrashCommand := fmt.Sprintf("\"/tmp/%s\"", simulateDockerdCrashScript)
-							err = sshConn.ExecuteRemote(node.Metadata.Name, simulateDockerCrashCommand, true)
-							Expect(err).NotTo(HaveOccurred())
-						}
-					}
+			By("Sending a sustained DNS query load to the cluster DNS")
+			s, err := service.Get("kube-dns", "kube-system")
+			Expect(err).NotTo(HaveOccurred())
+			clusterDomain := eng.ClusterDomain()
+			result, err := dns.RunLoad(fmt.Sprintf("dnsperf-%s", cfg.Name), "default", dns.LoadConfig{
+				Image:    cfg.DNSPerfImage,
+				Server:   s.Spec.ClusterIP,
+				Names:    []string{dns.ServiceFQDN("kubernetes", "default", clusterDomain), dns.ServiceFQDN("kube-dns", "kube-system", clusterDomain)},
+				Duration: 2 * time.Minute,
+				QPS:      1000,
+				Clients:  4,
+			}, 5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.ErrorRate()).To(BeNumerically("<=", cfg.DNSLoadMaxErrorRate))
 
-					log.Print("Waiting 1 minute to allow nodes to report not ready state after the crash occurred\n")
-					time.Sleep(1 * time.Minute)
+			By("Ensuring that coredns kept the replicas the dns-autoscaler is configured with")
+			_, err = dns.WaitOnAutoscaledReplicas(scaled, 5*time.Second, 1*time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+		})
 
-					for _, node := range nodeList.Nodes {
-						if node.IsWindows() {
-							By(fmt.Sprintf("restarting kubelet service on node: %s", node.Metadata.Name))
-							restartKubeletCommand := fmt.Sprintf("\"Powershell Start-Service kubelet\"")
-							err = sshConn.ExecuteRemote(node.Metadata.Name, restartKubeletCommand, true)
-							Expect(err).NotTo(HaveOccurred())
+		requires(capability.Addon("kubernetes-dashboard")).It("should be able to access the dashboard", func() {
+			By("Ensuring that the kubernetes-dashboard service is Running")
+			s, err := service.Get("kubernetes-dashboard", "kube-system")
+			Expect(err).NotTo(HaveOccurred())
+			By("Ensuring that we can connect via HTTPS to the dashboard on any one node")
+			dashboardPort := 443
+			port := s.GetNodePort(dashboardPort)
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			var success bool
+			for _, node := range nodeList.Nodes {
+				if success {
+					break
+				}
+				if node.IsLinux() {
+					// Allow 3 retries for each node
+					for i := 0; i < 3; i++ {
+						address := node.Status.GetAddressByType("InternalIP")
+						if address == nil {
+							log.Printf("One of our nodes does not have an InternalIP value!: %s\n", node.Metadata.Name)
 						}
+						Expect(address).NotTo(BeNil())
+						dashboardURL := fmt.Sprintf("http://%s:%v", address.Address, port)
+						curlCMD := fmt.Sprintf("curl --max-time 60 %s", dashboardURL)
+						err := sshConn.Execute(curlCMD, false)
+						if err == nil {
+							success = true
+							break
+						}
+						time.Sleep(1 * time.Second)
 					}
-
-					var expectedReadyNodes int
-					if !eng.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
-						expectedReadyNodes = eng.NodeCount()
-						log.Printf("Checking for %d Ready nodes\n", expectedReadyNodes)
-					} else {
-						expectedReadyNodes = -1
-					}
-					ready := node.WaitOnReady(expectedReadyNodes, 1*time.Minute, cfg.Timeout)
-					cmd2 := exec.Command("k", "get", "nodes", "-o", "wide")
-					out2, _ := cmd2.CombinedOutput()
-					log.Printf("%s\n", out2)
-					if !ready {
-						log.Printf("Error: Not all nodes in a healthy state\n")
-					}
-					Expect(ready).To(Equal(true))
-				} else {
-					Skip("Docker service recovery test is Windows only")
 				}
-			} else {
-				Skip("Skip per-node tests in low-priority VMSS cluster configuration scenario")
 			}
+			Expect(success).To(BeTrue())
 		})
-	})
 
-	Describe("with a linux agent pool", func() {
-		It("should be able to produce working LoadBalancers", func() {
-			if eng.AnyAgentIsLinux() {
-				By("Creating a nginx deployment")
-				r := rand.New(rand.NewSource(time.Now().UnixNano()))
-				serviceName := "ingress-nginx"
-				deploymentPrefix := fmt.Sprintf("%s-%s", serviceName, cfg.Name)
-				deploymentName := fmt.Sprintf("%s-%v", deploymentPrefix, r.Intn(99999))
-				deploy, err := deployment.CreateLinuxDeployDeleteIfExists(deploymentPrefix, "library/nginx:latest", deploymentName, "default", "--labels=app="+serviceName)
-				Expect(err).NotTo(HaveOccurred())
+		requires(capability.Linux, capability.Addon("fluent-bit"), capability.Not(capability.AzureStack)).It("should forward the logs of a pod to the fluent-bit backend", func() {
+			running, err := pod.WaitOnReady("fluent-bit", "kube-system", 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(BeTrue())
 
-				By("Ensuring we can create an ILB service attachment")
-				sILB, err := service.CreateServiceFromFileDeleteIfExist(filepath.Join(WorkloadDir, "ingress-nginx-ilb.yaml"), serviceName+"-ilb", "default")
-				Expect(err).NotTo(HaveOccurred())
-				svc, err := sILB.WaitForIngress(cfg.Timeout, 5*time.Second)
-				Expect(err).NotTo(HaveOccurred())
+			By("Running a job that logs a known line")
+			marker := util.UniqueName("fluent-bit-e2e")
+			j, err := job.RunLinuxJob("library/busybox", marker, "default", "echo "+marker)
+			Expect(err).NotTo(HaveOccurred())
+			defer j.Delete(util.DefaultDeleteRetries)
+			succeeded, err := j.WaitOnSucceeded(retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(succeeded).To(BeTrue())
+
+			addon := eng.ExpandedDefinition.Properties.OrchestratorProfile.KubernetesConfig.GetAddonByName("fluent-bit")
+			By(fmt.Sprintf("Ensuring that the line arrives at the %s backend", addon.Config["output"]))
+			account, err := azure.NewAccount()
+			Expect(err).NotTo(HaveOccurred())
+			// Log Analytics takes several minutes to ingest a record, fluent-bit flushes the logs every 5 seconds
+			Eventually(func() bool {
+				found, err := fluentBitBackendHasLog(account, addon, marker)
+				if err != nil {
+					log.Printf("Error looking for %s in the fluent-bit backend: %s\n", marker, err)
+				}
+				return found
+			}, 20*time.Minute, 30*time.Second).Should(BeTrue())
+		})
+
+		requires(capability.Linux, capability.Addon("node-problem-detector")).It("should export the conditions of node-problem-detector to the linux nodes", func() {
+			By("Ensuring that the node-problem-detector DaemonSet runs a ready pod on every linux node")
+			_, err := daemonset.WaitOnReady("node-problem-detector", "kube-system", retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Ensuring that every linux node has conditions set by node-problem-detector")
+			// the conditions of the monitors depend on their configs, the kubelet sets the others
+			kubeletConditions := map[string]bool{"Ready": true, "MemoryPressure": true, "DiskPressure": true, "PIDPressure": true, "NetworkUnavailable": true, "OutOfDisk": true}
+			Eventually(func() bool {
+				nodeList, err := node.GetReady()
+				if err != nil {
+					log.Printf("Error getting the ready nodes: %s\n", err)
+					return false
+				}
+				for _, n := range nodeList.Nodes {
+					if !n.IsLinux() {
+						continue
+					}
+					exported := false
+					for _, condition := range n.Status.Conditions {
+						if !kubeletConditions[condition.Type] {
+							exported = true
+							break
+						}
+					}
+					if !exported {
+						log.Printf("Node %s has no node-problem-detector condition yet\n", n.Metadata.Name)
+						return false
+					}
+				}
+				return true
+			}, 5*time.Minute, 15*time.Second).Should(BeTrue())
+		})
 
-				By("Ensuring we can create a curl pod to connect to the service")
-				deploymentPrefix = fmt.Sprintf("ilb-test-curl-deployment")
-				curlDeploymentName := fmt.Sprintf("%s-%v", deploymentPrefix, r.Intn(99999))
-				curlDeploy, err := deployment.CreateLinuxDeployDeleteIfExists(deploymentPrefix, "library/nginx:latest", curlDeploymentName, "default", "--replicas=2")
+		requires(capability.Linux, capability.Addon("csi-secrets-store"), capability.Not(capability.AzureStack)).It("should mount a Key Vault secret into a pod with the csi-secrets-store driver", func() {
+			By("Ensuring that the csi-secrets-store DaemonSets run a ready pod on every linux node")
+			for _, name := range []string{"csi-secrets-store", "csi-secrets-store-provider-azure"} {
+				_, err := daemonset.WaitOnReady(name, "kube-system", retryTimeWhenWaitingForPodReady, cfg.Timeout)
 				Expect(err).NotTo(HaveOccurred())
-				running, err := pod.WaitOnReady(curlDeploymentName, "default", 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			}
+
+			By("Creating a Key Vault secret the service principal of the tests can get")
+			account, err := azure.NewAccount()
+			Expect(err).NotTo(HaveOccurred())
+			err = account.SetResourceGroup(cfg.Name)
+			Expect(err).NotTo(HaveOccurred())
+			vaultName := util.UniqueName("kv")
+			err = account.CreateKeyVault(vaultName)
+			Expect(err).NotTo(HaveOccurred())
+			defer account.DeleteKeyVault(vaultName)
+			secretName, secretValue := "e2e-secret", util.UniqueName("value")
+			err = account.SetKeyVaultSecret(vaultName, secretName, secretValue)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Mounting the secret into a pod with a SecretProviderClass of the azure provider")
+			podName := "secrets-store-inline" // should be the same as in secrets-store-azure-keyvault.yaml
+			p, err := pod.CreatePodFromTemplate(filepath.Join(WorkloadDir, "secrets-store-azure-keyvault.yaml"), podName, "default", struct {
+				KeyVaultName, TenantID, ClientID, ClientSecret, SecretName string
+			}{vaultName, account.TenantID, account.User.ID, account.User.Secret, secretName}, 1*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			defer func() {
+				p.Delete(util.DefaultDeleteRetries)
+				cmd := exec.Command("k", "delete", "secret/secrets-store-creds", "secretproviderclass/azure-keyvault", "-n", "default")
+				util.PrintCommand(cmd)
+				if out, err := cmd.CombinedOutput(); err != nil {
+					log.Printf("Error deleting the csi-secrets-store test objects:%s\n", out)
+				}
+			}()
+			ready, err := p.WaitOnReady(retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready).To(BeTrue())
+
+			By("Ensuring that the mounted file has the value of the secret")
+			out, err := p.Exec("--", "cat", "/mnt/secrets-store/"+secretName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.TrimSpace(string(out))).To(Equal(secretValue))
+		})
+
+		requires(capability.Linux, capability.Addon("nginx-ingress")).It("should route HTTP requests through the nginx-ingress controller", func() {
+			By("Ensuring that the nginx-ingress controller is ready")
+			d, err := deployment.Get("nginx-ingress-controller", "ingress-nginx")
+			Expect(err).NotTo(HaveOccurred())
+			ready, err := d.WaitOnReady(1, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready).To(BeTrue())
+			s, err := service.Get("ingress-nginx", "ingress-nginx")
+			Expect(err).NotTo(HaveOccurred())
+			if s.Spec.Type != "LoadBalancer" {
+				Skip("the nginx-ingress controller is not exposed by a LoadBalancer Service")
+			}
+
+			validateIngress(filepath.Join(WorkloadDir, "ingress-nginx.yaml"), "nginx-ingress")
+		})
+
+		requires(capability.Linux, capability.Addon("appgw-ingress")).It("should route HTTP requests through the Application Gateway of the appgw-ingress controller", func() {
+			By("Ensuring that the appgw-ingress controller is ready")
+			d, err := deployment.Get("appgw-ingress", "kube-system")
+			Expect(err).NotTo(HaveOccurred())
+			ready, err := d.WaitOnReady(1, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready).To(BeTrue())
+
+			validateIngress(filepath.Join(WorkloadDir, "ingress-appgw.yaml"), "appgw-ingress")
+		})
+
+		requires(capability.Linux, capability.Addon("azure-policy"), capability.AdmissionWebhooks).It("should deny the requests violating the gatekeeper constraints of the azure-policy addon", func() {
+			By("Ensuring that gatekeeper and the azure-policy addon are ready")
+			for _, d := range []struct{ name, namespace string }{
+				{"gatekeeper-controller-manager", "gatekeeper-system"},
+				{"azure-policy", "kube-system"},
+			} {
+				deploy, err := deployment.Get(d.name, d.namespace)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(running).To(Equal(true))
-				curlPods, err := curlDeploy.Pods()
+				ready, err := deploy.WaitOnReady(1, retryTimeWhenWaitingForPodReady, cfg.Timeout)
 				Expect(err).NotTo(HaveOccurred())
-				By("Ensuring we can connect to the ILB service from another pod")
-				var success bool
-				for _, curlPod := range curlPods {
-					pass, curlErr := curlPod.ValidateCurlConnection(svc.Status.LoadBalancer.Ingress[0]["ip"], 5*time.Second, 3*time.Minute)
-					if curlErr == nil && pass {
-						success = true
-						break
-					}
+				Expect(ready).To(BeTrue())
+			}
+
+			By("Creating a constraint requiring an owner label on the namespaces labeled for the test")
+			filename := filepath.Join(WorkloadDir, "gatekeeper-required-labels.yaml")
+			defer exec.Command("k", "delete", "--ignore-not-found", "-f", filename).Run()
+			// the kind of the constraint is served once gatekeeper created the CRD of its template
+			Eventually(func() error {
+				cmd := exec.Command("k", "apply", "-f", filename)
+				util.PrintCommand(cmd)
+				out, err := cmd.CombinedOutput()
+				if err != nil {
+					log.Printf("Error while trying to apply %s:%s\n", filename, out)
+				}
+				return err
+			}, 3*time.Minute, 10*time.Second).Should(Succeed())
 
+			By("Ensuring that a namespace without an owner label is denied")
+			ns, err := namespace.Create(util.UniqueName("gatekeeper"))
+			Expect(err).NotTo(HaveOccurred())
+			defer ns.Delete()
+			Eventually(func() error {
+				err := ns.Label("e2e-gatekeeper=true")
+				if err == nil {
+					// the constraint is not enforced yet, unlabel the namespace so that the next attempt changes it again
+					Expect(ns.Label("e2e-gatekeeper-")).To(Succeed())
 				}
-				Expect(success).To(BeTrue())
-				By("Ensuring we can create an ELB service attachment")
-				sELB, err := service.CreateServiceFromFileDeleteIfExist(filepath.Join(WorkloadDir, "ingress-nginx-elb.yaml"), serviceName+"-elb", "default")
+				return err
+			}, 3*time.Minute, 10*time.Second).Should(HaveOccurred())
+
+			By("Ensuring that a namespace with an owner label is admitted")
+			err = ns.Label("owner=aks-engine-e2e")
+			Expect(err).NotTo(HaveOccurred())
+			err = ns.Label("e2e-gatekeeper=true")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		requires(capability.Linux, capability.Addon("keda"), capability.Not(capability.AzureStack)).It("should scale a deployment off the length of an Azure Storage queue with the keda addon", func() {
+			By("Ensuring that the KEDA operator and its external metrics server are ready")
+			for _, name := range []string{"keda-operator", "keda-metrics-apiserver"} {
+				d, err := deployment.Get(name, "keda")
 				Expect(err).NotTo(HaveOccurred())
-				svc, err = sELB.WaitForIngress(cfg.Timeout, 5*time.Second)
+				ready, err := d.WaitOnReady(1, retryTimeWhenWaitingForPodReady, cfg.Timeout)
 				Expect(err).NotTo(HaveOccurred())
+				Expect(ready).To(BeTrue())
+			}
 
-				By("Ensuring we can connect to the ELB service on the service IP")
-				valid := sELB.Validate("(Welcome to nginx)", 5, 30*time.Second, cfg.Timeout)
-				Expect(valid).To(BeTrue())
-				By("Ensuring we can connect to the ELB service from another pod")
-				success = false
-				for _, curlPod := range curlPods {
-					pass, curlErr := curlPod.ValidateCurlConnection(svc.Status.LoadBalancer.Ingress[0]["ip"], 5*time.Second, 3*time.Minute)
-					if curlErr == nil && pass {
-						success = true
-						break
+			By("Creating an Azure Storage queue")
+			account, err := azure.NewAccount()
+			Expect(err).NotTo(HaveOccurred())
+			err = account.SetResourceGroup(cfg.Name)
+			Expect(err).NotTo(HaveOccurred())
+			sa := &azure.StorageAccount{
+				// storage account names are lowercase letters and numbers only
+				Name:          strings.Replace(util.UniqueName("keda"), "-", "", -1),
+				ResourceGroup: account.ResourceGroup,
+			}
+			err = sa.CreateStorageAccount()
+			Expect(err).NotTo(HaveOccurred())
+			defer sa.DeleteStorageAccount()
+			err = sa.SetConnectionString()
+			Expect(err).NotTo(HaveOccurred())
+			queueName := "keda-e2e"
+			err = sa.CreateQueue(queueName)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Creating a deployment scaled by KEDA off the length of the queue")
+			deploymentName := "keda-azure-queue-consumer" // should be the same as in keda-azure-queue.yaml
+			d, err := deployment.CreateDeploymentFromTemplate(filepath.Join(WorkloadDir, "keda-azure-queue.yaml"), deploymentName, "default", struct {
+				ConnectionString, QueueName string
+			}{sa.ConnectionString, queueName})
+			Expect(err).NotTo(HaveOccurred())
+			defer func() {
+				cmd := exec.Command("k", "delete", "scaledobject/"+deploymentName, "deployment/"+deploymentName, "secret/keda-azure-queue", "-n", "default")
+				util.PrintCommand(cmd)
+				if out, err := cmd.CombinedOutput(); err != nil {
+					log.Printf("Error deleting the keda test objects:%s\n", out)
+				}
+			}()
+
+			By("Ensuring that the deployment scales up from zero when the queue has messages")
+			// 20 messages with a target queue length of 5 scale the deployment to its 4 max replicas
+			err = sa.PutQueueMessages(queueName, 20)
+			Expect(err).NotTo(HaveOccurred())
+			pods, err := d.WaitForReplicas(2, -1, 10*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			log.Printf("Deployment %s scaled up to %d replicas\n", deploymentName, len(pods))
+
+			By("Ensuring that the deployment scales back to zero when the queue is empty")
+			err = sa.ClearQueue(queueName)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = d.WaitForReplicas(-1, 0, 10*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		requires(capability.Linux, capability.Addon("open-service-mesh"), capability.AdmissionWebhooks).It("should inject the envoy sidecar of the open-service-mesh addon into the pods of its namespaces", func() {
+			By("Ensuring that the osm-controller is ready with the resources of the addon")
+			d, err := deployment.Get("osm-controller", "osm-system")
+			Expect(err).NotTo(HaveOccurred())
+			ready, err := d.WaitOnReady(1, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready).To(BeTrue())
+			pods, err := d.Pods()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pods).NotTo(BeEmpty())
+			_, addon := eng.HasAddon("open-service-mesh")
+			for _, c := range addon.Containers {
+				found := false
+				for _, container := range pods[0].Spec.Containers {
+					if container.Name == c.Name {
+						found = true
+						err = container.ValidateResources(c)
+						Expect(err).NotTo(HaveOccurred())
 					}
+				}
+				Expect(found).To(BeTrue(), "the osm-controller pod has no %s container", c.Name)
+			}
 
+			if addon.Config["namespaces"] == "" {
+				Skip("the open-service-mesh addon has no namespaces with sidecar injection enabled")
+			}
+			namespace := strings.TrimSpace(strings.Split(addon.Config["namespaces"], ",")[0])
+			By(fmt.Sprintf("Ensuring that a pod created in the %s namespace of the mesh gets the envoy sidecar", namespace))
+			// the controller registers the sidecar injection webhook once it is up
+			Eventually(func() ([]string, error) {
+				p, err := pod.RunLinuxPod("library/nginx:latest", util.UniqueName("osm"), namespace, "sleep 3600", true, 1*time.Second, cfg.Timeout, retryCommandsTimeout)
+				if err != nil {
+					return nil, err
 				}
-				Expect(success).To(BeTrue())
-				err = sILB.Delete(util.DefaultDeleteRetries)
-				Expect(err).NotTo(HaveOccurred())
-				err = sELB.Delete(util.DefaultDeleteRetries)
-				Expect(err).NotTo(HaveOccurred())
-				err = curlDeploy.Delete(util.DefaultDeleteRetries)
+				defer p.Delete(util.DefaultDeleteRetries)
+				var names []string
+				for _, container := range p.Spec.Containers {
+					names = append(names, container.Name)
+				}
+				return names, nil
+			}, 5*time.Minute, 30*time.Second).Should(ContainElement("envoy"))
+		})
+	})
+
+	Describe("with a windows agent pool", func() {
+		requires(capability.Windows).It("should have the expected container images pre-pulled", func() {
+			expectedImages, err := eng.GetWindowsPrePulledImages()
+			Expect(err).NotTo(HaveOccurred())
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			for _, n := range nodeList.Nodes {
+				if !n.IsWindows() {
+					continue
+				}
+				report := n.GetImageReport(expectedImages)
+				log.Printf("Node %s has %d MB of container images, %d MB of them expected\n", n.Metadata.Name, report.TotalSizeBytes/(1024*1024), report.ExpectedSizeBytes/(1024*1024))
+				if len(report.Extra) > 0 {
+					log.Printf("Node %s has images that are not pre-pulled: %s\n", n.Metadata.Name, strings.Join(report.Extra, " "))
+				}
+				Expect(report.Missing).To(BeEmpty(), "node %s is missing pre-pulled images", n.Metadata.Name)
+			}
+		})
+
+		requires(capability.Not(capability.LowPriority), capability.Windows).It("kubelet service should be able to recover when the docker service is stopped [Serial]", func() {
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			simulateDockerdCrashScript := "simulate-dockerd-crash.cmd"
+			err = sshConn.CopyTo(simulateDockerdCrashScript)
+			Expect(err).NotTo(HaveOccurred())
+			for _, node := range nodeList.Nodes {
+				if node.IsWindows() {
+					By(fmt.Sprintf("simulating docker and subsequent kubelet service crash on node: %s", node.Metadata.Name))
+					err = sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+simulateDockerdCrashScript)
+					Expect(err).NotTo(HaveOccurred())
+					simulateDockerCrashCommand := fmt.Sprintf("/tmp/%s", simulateDockerdCrashScript)
+					err = sshConn.ExecuteRemote(node.Metadata.Name, simulateDockerCrashCommand, true)
+					Expect(err).NotTo(HaveOccurred())
+				}
+			}
+
+			log.Print("Waiting 1 minute to allow nodes to report not ready state after the crash occurred\n")
+			time.Sleep(1 * time.Minute)
+
+			for _, node := range nodeList.Nodes {
+				if node.IsWindows() {
+					By(fmt.Sprintf("restarting kubelet service on node: %s", node.Metadata.Name))
+					restartKubeletCommand := "Powershell Start-Service kubelet"
+					err = sshConn.ExecuteRemote(node.Metadata.Name, restartKubeletCommand, true)
+					Expect(err).NotTo(HaveOccurred())
+				}
+			}
+
+			var expectedReadyNodes int
+			if !eng.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
+				expectedReadyNodes = eng.NodeCount()
+				log.Printf("Checking for %d Ready nodes\n", expectedReadyNodes)
+			} else {
+				expectedReadyNodes = -1
+			}
+			ready := node.WaitOnReady(expectedReadyNodes, 1*time.Minute, cfg.Timeout)
+			cmd2 := exec.Command("k", "get", "nodes", "-o", "wide")
+			out2, _ := cmd2.CombinedOutput()
+			log.Printf("%s\n", out2)
+			if !ready {
+				log.Printf("Error: Not all nodes in a healthy state\n")
+			}
+			Expect(ready).To(Equal(true))
+		})
+	})
+
+	Describe("with a linux agent pool", func() {
+		requires(capability.Linux).It("should be able to produce working LoadBalancers", func() {
+			By("Creating a nginx deployment")
+			serviceName := "ingress-nginx"
+			deploymentPrefix := fmt.Sprintf("%s-%s", serviceName, cfg.Name)
+			deploymentName := util.UniqueName(deploymentPrefix)
+			deploy, err := deployment.CreateLinuxDeployDeleteIfExists(util.RunPrefix(deploymentPrefix), "library/nginx:latest", deploymentName, "default", "--labels=app="+serviceName)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Ensuring we can create a curl pod to connect to the service")
+			deploymentPrefix = "ilb-test-curl-deployment"
+			curlDeploymentName := util.UniqueName(deploymentPrefix)
+			curlDeploy, err := deployment.CreateLinuxDeployDeleteIfExists(util.RunPrefix(deploymentPrefix), "library/nginx:latest", curlDeploymentName, "default", "--replicas=2")
+			Expect(err).NotTo(HaveOccurred())
+			running, err := curlDeploy.WaitOnReady(3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(Equal(true))
+			curlPods, err := curlDeploy.Pods()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Ensuring we can create an ILB service attachment")
+			sILB, err := service.CreateServiceFromFileDeleteIfExist(filepath.Join(WorkloadDir, "ingress-nginx-ilb.yaml"), serviceName+"-ilb", "default")
+			Expect(err).NotTo(HaveOccurred())
+			By("Ensuring the ILB gets an IP of the agent subnet and we can connect to it from another pod")
+			_, err = sILB.ValidateInternalLoadBalancer(eng.LinuxAgentSubnet(), curlPods, 5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			err = sILB.ValidateSessionAffinity("ClientIP")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Ensuring we can create an ELB service attachment")
+			sELB, err := service.CreateServiceFromFileDeleteIfExist(filepath.Join(WorkloadDir, "ingress-nginx-elb.yaml"), serviceName+"-elb", "default")
+			Expect(err).NotTo(HaveOccurred())
+			elbIP, err := sELB.WaitOnExternalIP(5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Ensuring we can connect to the ELB service on the service IP")
+			valid := sELB.Validate("(Welcome to nginx)", 5, 30*time.Second, cfg.Timeout)
+			Expect(valid).To(BeTrue())
+			By("Ensuring we can connect to the ELB service from another pod")
+			var success bool
+			for _, curlPod := range curlPods {
+				pass, curlErr := curlPod.ValidateCurlConnection(elbIP, 5*time.Second, 3*time.Minute)
+				if curlErr == nil && pass {
+					success = true
+					break
+				}
+
+			}
+			Expect(success).To(BeTrue())
+
+			if len(curlPods) > 1 {
+				By("Ensuring the ELB service only accepts connections from its source ranges")
+				err = sELB.SetLoadBalancerSourceRanges([]string{curlPods[0].Status.PodIP + "/32"})
 				Expect(err).NotTo(HaveOccurred())
-				err = deploy.Delete(util.DefaultDeleteRetries)
+				err = sELB.ValidateLoadBalancerSourceRanges(curlPods[:1], curlPods[1:], 5*time.Second, 2*time.Minute)
 				Expect(err).NotTo(HaveOccurred())
-			} else {
-				Skip("No linux agent was provisioned for this Cluster Definition")
 			}
+			err = sILB.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			err = sELB.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			err = curlDeploy.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			err = deploy.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		requires(capability.Linux).It("should be able to run jobs and cronjobs", func() {
+			By("Running a job to completion on a linux node")
+			jobName := util.UniqueName("job-linux")
+			j, err := job.RunLinuxJob("library/busybox", jobName, "default", "echo completed")
+			Expect(err).NotTo(HaveOccurred())
+			succeeded, err := j.WaitOnSucceeded(retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(succeeded).To(BeTrue())
+			completions, needed, err := j.GetCompletions()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(completions).To(Equal(needed))
+			By("Deleting the job and its pods")
+			err = j.DeleteWithPropagation(util.DefaultDeleteRetries, job.PropagationForeground)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Scheduling jobs with a cronjob")
+			cj, err := cronjob.CreateCronJobFromFileDeleteIfExists(filepath.Join(WorkloadDir, "cronjob-linux.yaml"), "cronjob-linux", "default")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = cj.WaitOnScheduled(retryTimeWhenWaitingForPodReady, 3*time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+			succeeded, err = cj.WaitOnSucceeded(2, retryTimeWhenWaitingForPodReady, 5*time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(succeeded).To(BeTrue())
+			err = cj.DeleteWithPropagation(util.DefaultDeleteRetries, job.PropagationForeground)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		requires(capability.Linux, capability.Not(capability.LowPriority)).It("should be able to roll out a statefulset with a volume per replica", func() {
+			By("Creating a statefulset with a volume claim template")
+			s, err := statefulset.CreateFromFileDeleteIfExists(filepath.Join(WorkloadDir, "statefulset-linux.yaml"), "statefulset-linux", "default")
+			Expect(err).NotTo(HaveOccurred())
+			ready, err := s.WaitOnReadyReplicas(s.Spec.Replicas, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready).To(BeTrue())
+
+			By("Ensuring that the replicas were started in order")
+			err = s.ValidateOrderedStartup()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Ensuring that each replica has its own persistent volume")
+			err = s.ValidatePVCPerReplica("/mnt/azure", 10*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Cleaning up after ourselves")
+			err = s.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			err = s.WaitOnDeleted(5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			err = s.DeletePersistentVolumeClaims(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		requires(capability.Linux, capability.AdmissionWebhooks).It("should call admission webhooks and apply their failure policy", func() {
+			By("Creating a namespace the webhooks are scoped to")
+			ns, err := namespace.Create(util.UniqueName("webhook"))
+			Expect(err).NotTo(HaveOccurred())
+			defer ns.Delete()
+			err = ns.Label("aks-engine-e2e-webhook=" + ns.Metadata.Name)
+			Expect(err).NotTo(HaveOccurred())
+			selector := map[string]string{"aks-engine-e2e-webhook": ns.Metadata.Name}
+
+			By("Deploying a sample webhook server with a generated serving certificate")
+			server, err := webhook.Deploy(filepath.Join(WorkloadDir, "webhook-server.yaml"), "sample-webhook", ns.Metadata.Name)
+			Expect(err).NotTo(HaveOccurred())
+			defer server.Delete()
+			running, err := server.Deployment.WaitOnReady(3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(BeTrue())
+
+			By("Registering a validating and a mutating webhook of the config maps")
+			validating, err := webhook.Register(webhook.Validating, server.Webhook("deny-configmaps-"+ns.Metadata.Name, "/configmaps", webhook.Fail, []string{"configmaps"}, selector))
+			Expect(err).NotTo(HaveOccurred())
+			defer validating.Delete()
+			mutating, err := webhook.Register(webhook.Mutating, server.Webhook("mutate-configmaps-"+ns.Metadata.Name, "/mutating-configmaps", webhook.Fail, []string{"configmaps"}, selector))
+			Expect(err).NotTo(HaveOccurred())
+			defer mutating.Delete()
+
+			By("Ensuring that the API server reaches the webhook server to mutate an allowed config map")
+			Eventually(func() (map[string]string, error) {
+				return webhook.AdmitConfigMap("allowed", ns.Metadata.Name, map[string]string{"webhook-e2e-test": "webhook-allow"})
+			}, 2*time.Minute, 10*time.Second).Should(HaveKeyWithValue("mutation-stage-1", "yes"))
+
+			By("Ensuring that the validating webhook denies a disallowed config map")
+			_, err = webhook.AdmitConfigMap("disallowed", ns.Metadata.Name, map[string]string{"webhook-e2e-test": "webhook-disallow"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("denied"))
+			err = validating.Delete()
+			Expect(err).NotTo(HaveOccurred())
+			err = mutating.Delete()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Ensuring that a request is denied when an unreachable webhook fails closed")
+			unreachable := server.Webhook("unreachable-"+ns.Metadata.Name, "/configmaps", webhook.Fail, []string{"configmaps"}, selector)
+			unreachable.Service = server.Name + "-unreachable"
+			closed, err := webhook.Register(webhook.Validating, unreachable)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() string {
+				_, err := webhook.AdmitConfigMap("fail-closed", ns.Metadata.Name, nil)
+				if err == nil {
+					return ""
+				}
+				return err.Error()
+			}, 2*time.Minute, 10*time.Second).Should(ContainSubstring("failed calling"))
+			err = closed.Delete()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Ensuring that a request is admitted when an unreachable webhook fails open")
+			unreachable.FailurePolicy = webhook.Ignore
+			open, err := webhook.Register(webhook.Validating, unreachable)
+			Expect(err).NotTo(HaveOccurred())
+			defer open.Delete()
+			Eventually(func() error {
+				_, err := webhook.AdmitConfigMap("fail-open", ns.Metadata.Name, nil)
+				return err
+			}, 2*time.Minute, 10*time.Second).Should(Succeed())
 		})
 
 		It("should be able to get nodes metrics", func() {
@@ -1068,7 +1970,6 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 		It("should be able to autoscale", func() {
 			if eng.AnyAgentIsLinux() && eng.ExpandedDefinition.Properties.OrchestratorProfile.KubernetesConfig.EnableAggregatedAPIs {
 				// Inspired by http://blog.kubernetes.io/2016/07/autoscaling-in-kubernetes.html
-				r := rand.New(rand.NewSource(time.Now().UnixNano()))
 				By("Creating a php-apache deployment")
 				phpApacheDeploy, err := deployment.CreateLinuxDeployIfNotExist("deis/hpa-example", longRunningApacheDeploymentName, "default", "--requests=cpu=10m,memory=10M")
 				Expect(err).NotTo(HaveOccurred())
@@ -1096,35 +1997,29 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 				err = phpApacheDeploy.CreateDeploymentHPADeleteIfExist(5, 1, 10)
 				Expect(err).NotTo(HaveOccurred())
 
-				By("Sending load to the php-apache service by creating a 3 replica deployment")
-				// Launch a simple busybox pod that wget's continuously to the apache serviceto simulate load
-				commandString := fmt.Sprintf("while true; do wget -q -O- http://%s.default.svc.cluster.local; done", longRunningApacheDeploymentName)
-				loadTestPrefix := fmt.Sprintf("load-test-%s", cfg.Name)
-				loadTestName := fmt.Sprintf("%s-%v", loadTestPrefix, r.Intn(99999))
-				numLoadTestPods := 3
-				loadTestDeploy, err := deployment.RunLinuxDeployDeleteIfExists(loadTestPrefix, "busybox", loadTestName, "default", commandString, numLoadTestPods)
-				Expect(err).NotTo(HaveOccurred())
-
-				By("Ensuring there are 3 load test pods")
-				running, err = pod.WaitOnReady(loadTestName, "default", 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(running).To(Equal(true))
-
-				// We should have three load tester pods running
-				loadTestPods, err := loadTestDeploy.Pods()
-				Expect(err).NotTo(HaveOccurred())
-				Expect(len(loadTestPods)).To(Equal(numLoadTestPods))
-
-				By("Ensuring we have more than 1 apache-php pods due to hpa enforcement")
-				_, err = phpApacheDeploy.WaitForReplicas(2, -1, 5*time.Second, cfg.Timeout)
-				Expect(err).NotTo(HaveOccurred())
-
-				By("Stopping load")
-				err = loadTestDeploy.Delete(util.DefaultDeleteRetries)
+				By("Ensuring the hpa scales php-apache up under load and back down once the load stops")
+				loadTestName := util.UniqueName(fmt.Sprintf("load-test-%s", cfg.Name))
+				scenario := hpa.Scenario{
+					Name:              longRunningApacheDeploymentName,
+					Namespace:         "default",
+					ScaleUpReplicas:   2,
+					ScaleDownReplicas: 1,
+					MetricsTimeout:    cfg.Timeout,
+					ScaleUpTimeout:    cfg.Timeout,
+					ScaleDownTimeout:  20 * time.Minute,
+				}
+				err = scenario.Run(func() (func() error, error) {
+					// Launch simple busybox pods that wget continuously from the apache service to simulate load
+					loadTestPods, err := pod.GenerateCPULoad("busybox", loadTestName, "default", fmt.Sprintf("http://%s.default.svc.cluster.local", longRunningApacheDeploymentName), 3, 5*time.Second, cfg.Timeout)
+					if err != nil {
+						return nil, err
+					}
+					return func() error {
+						return pod.StopCPULoad(loadTestPods)
+					}, nil
+				}, 5*time.Second)
 				Expect(err).NotTo(HaveOccurred())
-
-				By("Ensuring we only have 1 apache-php pod after stopping load")
-				_, err = phpApacheDeploy.WaitForReplicas(-1, 1, 5*time.Second, 20*time.Minute)
+				_, err = phpApacheDeploy.WaitForReplicas(-1, 1, 5*time.Second, cfg.Timeout)
 				Expect(err).NotTo(HaveOccurred())
 				h, err := hpa.Get(longRunningApacheDeploymentName, "default")
 				Expect(err).NotTo(HaveOccurred())
@@ -1160,528 +2055,536 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 	})
 
 	Describe("with a GPU-enabled agent pool", func() {
-		It("should be able to run a nvidia-gpu job", func() {
-			if eng.ExpandedDefinition.Properties.HasNSeriesSKU() {
-				version := common.RationalizeReleaseAndVersion(
-					common.Kubernetes,
-					eng.ClusterDefinition.Properties.OrchestratorProfile.OrchestratorRelease,
-					eng.ClusterDefinition.Properties.OrchestratorProfile.OrchestratorVersion,
-					false,
-					eng.HasWindowsAgents())
-				if common.IsKubernetesVersionGe(version, "1.10.0") {
-					j, err := job.CreateJobFromFile(filepath.Join(WorkloadDir, "cuda-vector-add.yaml"), "cuda-vector-add", "default")
-					Expect(err).NotTo(HaveOccurred())
-					ready, err := j.WaitOnReady(30*time.Second, cfg.Timeout)
-					delErr := j.Delete(util.DefaultDeleteRetries)
-					if delErr != nil {
-						fmt.Printf("could not delete job %s\n", j.Metadata.Name)
-						fmt.Println(delErr)
-					}
-					Expect(err).NotTo(HaveOccurred())
-					Expect(ready).To(Equal(true))
-				} else {
-					j, err := job.CreateJobFromFile(filepath.Join(WorkloadDir, "nvidia-smi.yaml"), "nvidia-smi", "default")
-					Expect(err).NotTo(HaveOccurred())
-					ready, err := j.WaitOnReady(30*time.Second, cfg.Timeout)
-					delErr := j.Delete(util.DefaultDeleteRetries)
-					if delErr != nil {
-						fmt.Printf("could not delete job %s\n", j.Metadata.Name)
-						fmt.Println(delErr)
-					}
-					Expect(err).NotTo(HaveOccurred())
-					Expect(ready).To(Equal(true))
+		requires(capability.GPU).It("should be able to run a nvidia-gpu job", func() {
+			version := common.RationalizeReleaseAndVersion(
+				common.Kubernetes,
+				eng.ClusterDefinition.Properties.OrchestratorProfile.OrchestratorRelease,
+				eng.ClusterDefinition.Properties.OrchestratorProfile.OrchestratorVersion,
+				false,
+				eng.HasWindowsAgents())
+			if common.IsKubernetesVersionGe(version, "1.10.0") {
+				j, err := job.CreateJobFromFile(filepath.Join(WorkloadDir, "cuda-vector-add.yaml"), "cuda-vector-add", "default")
+				Expect(err).NotTo(HaveOccurred())
+				ready, err := j.WaitOnReady(30*time.Second, cfg.Timeout)
+				delErr := j.Delete(util.DefaultDeleteRetries)
+				if delErr != nil {
+					fmt.Printf("could not delete job %s\n", j.Metadata.Name)
+					fmt.Println(delErr)
 				}
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ready).To(Equal(true))
 			} else {
-				Skip("This is not a GPU-enabled cluster")
+				j, err := job.CreateJobFromFile(filepath.Join(WorkloadDir, "nvidia-smi.yaml"), "nvidia-smi", "default")
+				Expect(err).NotTo(HaveOccurred())
+				ready, err := j.WaitOnReady(30*time.Second, cfg.Timeout)
+				delErr := j.Delete(util.DefaultDeleteRetries)
+				if delErr != nil {
+					fmt.Printf("could not delete job %s\n", j.Metadata.Name)
+					fmt.Println(delErr)
+				}
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ready).To(Equal(true))
 			}
 		})
 	})
 
 	Describe("with zoned master profile", func() {
-		It("should be labeled with zones for each masternode", func() {
-			if eng.ExpandedDefinition.Properties.MasterProfile.HasAvailabilityZones() {
-				nodeList, err := node.Get()
-				Expect(err).NotTo(HaveOccurred())
-				for _, node := range nodeList.Nodes {
-					role := node.Metadata.Labels["kubernetes.io/role"]
-					if role == "master" {
-						By("Ensuring that we get zones for each master node")
-						zones := node.Metadata.Labels["failure-domain.beta.kubernetes.io/zone"]
-						contains := strings.Contains(zones, "-")
-						Expect(contains).To(Equal(true))
-					}
+		requires(capability.MasterAvailabilityZones).It("should be labeled with zones for each masternode", func() {
+			nodeList, err := node.Get()
+			Expect(err).NotTo(HaveOccurred())
+			for _, node := range nodeList.Nodes {
+				role := node.Metadata.Labels["kubernetes.io/role"]
+				if role == "master" {
+					By("Ensuring that we get zones for each master node")
+					zones := node.Metadata.Labels["failure-domain.beta.kubernetes.io/zone"]
+					contains := strings.Contains(zones, "-")
+					Expect(contains).To(Equal(true))
 				}
-			} else {
-				Skip("Availability zones was not configured for master profile for this Cluster Definition")
 			}
 		})
 	})
 
 	Describe("with all zoned agent pools", func() {
-		It("should be labeled with zones for each node", func() {
-			if eng.ExpandedDefinition.Properties.HasZonesForAllAgentPools() {
-				nodeList, err := node.Get()
-				Expect(err).NotTo(HaveOccurred())
-				for _, node := range nodeList.Nodes {
-					role := node.Metadata.Labels["kubernetes.io/role"]
-					if role == "agent" {
-						By("Ensuring that we get zones for each agent node")
-						zones := node.Metadata.Labels["failure-domain.beta.kubernetes.io/zone"]
-						contains := strings.Contains(zones, "-")
-						Expect(contains).To(Equal(true))
-					}
-				}
-			} else {
-				Skip("Availability zones was not configured for this Cluster Definition")
+		requires(capability.AvailabilityZones).It("should be labeled with zones for each node", func() {
+			nodeList, err := node.Get()
+			Expect(err).NotTo(HaveOccurred())
+			for _, node := range nodeList.Nodes {
+				role := node.Metadata.Labels["kubernetes.io/role"]
+				if role == "agent" {
+					By("Ensuring that we get zones for each agent node")
+					zones := node.Metadata.Labels["failure-domain.beta.kubernetes.io/zone"]
+					contains := strings.Contains(zones, "-")
+					Expect(contains).To(Equal(true))
+				}
 			}
 		})
 
-		It("should create pv with zone labels and node affinity", func() {
-			if !eng.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
-				if eng.ExpandedDefinition.Properties.HasZonesForAllAgentPools() {
-					By("Creating a persistent volume claim")
-					pvcName := "azure-managed-disk" // should be the same as in pvc-standard.yaml
-					pvc, err := persistentvolumeclaims.CreatePersistentVolumeClaimsFromFile(filepath.Join(WorkloadDir, "pvc-standard.yaml"), pvcName, "default")
-					Expect(err).NotTo(HaveOccurred())
-					ready, err := pvc.WaitOnReady("default", 5*time.Second, cfg.Timeout)
-					Expect(err).NotTo(HaveOccurred())
-					Expect(ready).To(Equal(true))
+		requires(capability.Not(capability.LowPriority), capability.AvailabilityZones).It("should create pv with zone labels and node affinity", func() {
+			By("Creating a persistent volume claim")
+			pvcName := "azure-managed-disk" // should be the same as in pvc-standard.yaml
+			pvc, err := persistentvolumeclaims.CreatePersistentVolumeClaimsFromFile(filepath.Join(WorkloadDir, "pvc-standard.yaml"), pvcName, "default")
+			Expect(err).NotTo(HaveOccurred())
+			ready, err := pvc.WaitOnReady("default", 5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready).To(Equal(true))
 
-					pvList, err := persistentvolume.Get()
-					Expect(err).NotTo(HaveOccurred())
-					pvZone := ""
-					for _, pv := range pvList.PersistentVolumes {
-						By("Ensuring that we get zones for the pv")
-						// zone is chosen by round-robin across all zones
-						pvZone = pv.Metadata.Labels["failure-domain.beta.kubernetes.io/zone"]
-						fmt.Printf("pvZone: %s\n", pvZone)
-						contains := strings.Contains(pvZone, "-")
+			pvList, err := persistentvolume.Get()
+			Expect(err).NotTo(HaveOccurred())
+			pvZone := ""
+			for _, pv := range pvList.PersistentVolumes {
+				By("Ensuring that we get zones for the pv")
+				// zone is chosen by round-robin across all zones
+				pvZone = pv.Metadata.Labels["failure-domain.beta.kubernetes.io/zone"]
+				fmt.Printf("pvZone: %s\n", pvZone)
+				contains := strings.Contains(pvZone, "-")
+				Expect(contains).To(Equal(true))
+				// VolumeScheduling feature gate is set to true by default starting v1.10+
+				for _, expression := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms[0].MatchExpressions {
+					if expression.Key == "failure-domain.beta.kubernetes.io/zone" {
+						By("Ensuring that we get nodeAffinity for each pv")
+						value := expression.Values[0]
+						fmt.Printf("NodeAffinity value: %s\n", value)
+						contains := strings.Contains(value, "-")
 						Expect(contains).To(Equal(true))
-						// VolumeScheduling feature gate is set to true by default starting v1.10+
-						for _, expression := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms[0].MatchExpressions {
-							if expression.Key == "failure-domain.beta.kubernetes.io/zone" {
-								By("Ensuring that we get nodeAffinity for each pv")
-								value := expression.Values[0]
-								fmt.Printf("NodeAffinity value: %s\n", value)
-								contains := strings.Contains(value, "-")
-								Expect(contains).To(Equal(true))
-							}
-						}
 					}
+				}
+			}
 
-					By("Launching a pod using the volume claim")
-					podName := "zone-pv-pod" // should be the same as in pod-pvc.yaml
-					testPod, err := pod.CreatePodFromFile(filepath.Join(WorkloadDir, "pod-pvc.yaml"), podName, "default", 1*time.Second, cfg.Timeout)
-					Expect(err).NotTo(HaveOccurred())
-					ready, err = testPod.WaitOnReady(retryTimeWhenWaitingForPodReady, cfg.Timeout)
-					Expect(err).NotTo(HaveOccurred())
-					Expect(ready).To(Equal(true))
+			By("Launching a pod using the volume claim")
+			podName := "zone-pv-pod" // should be the same as in pod-pvc.yaml
+			testPod, err := pod.CreatePodFromFile(filepath.Join(WorkloadDir, "pod-pvc.yaml"), podName, "default", 1*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			ready, err = testPod.WaitOnReady(retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready).To(Equal(true))
 
-					By("Checking that the pod can access volume")
-					valid, err := testPod.ValidatePVC("/mnt/azure", 10, 10*time.Second)
-					Expect(valid).To(BeTrue())
-					Expect(err).NotTo(HaveOccurred())
+			By("Checking that the pod can access volume")
+			valid, err := testPod.ValidatePVC("/mnt/azure", 10, 10*time.Second)
+			Expect(valid).To(BeTrue())
+			Expect(err).NotTo(HaveOccurred())
 
-					By("Ensuring that attached volume pv has the same zone as the zone of the node")
-					nodeName := testPod.Spec.NodeName
-					nodeList, err := node.GetByRegex(nodeName)
-					Expect(err).NotTo(HaveOccurred())
-					nodeZone := nodeList[0].Metadata.Labels["failure-domain.beta.kubernetes.io/zone"]
-					fmt.Printf("pvZone: %s\n", pvZone)
-					fmt.Printf("nodeZone: %s\n", nodeZone)
-					Expect(nodeZone == pvZone).To(Equal(true))
+			By("Ensuring that attached volume pv has the same zone as the zone of the node")
+			nodeName := testPod.Spec.NodeName
+			nodeList, err := node.GetByRegex(nodeName)
+			Expect(err).NotTo(HaveOccurred())
+			nodeZone := nodeList[0].Metadata.Labels["failure-domain.beta.kubernetes.io/zone"]
+			fmt.Printf("pvZone: %s\n", pvZone)
+			fmt.Printf("nodeZone: %s\n", nodeZone)
+			Expect(nodeZone == pvZone).To(Equal(true))
 
-					By("Cleaning up after ourselves")
-					err = testPod.Delete(util.DefaultDeleteRetries)
-					Expect(err).NotTo(HaveOccurred())
-					err = pvc.Delete(util.DefaultDeleteRetries)
-					Expect(err).NotTo(HaveOccurred())
-				} else {
-					Skip("Availability zones was not configured for this Cluster Definition")
-				}
-			} else {
-				Skip("Skip per-node tests in low-priority VMSS cluster configuration scenario")
-			}
+			By("Cleaning up after ourselves")
+			err = testPod.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			err = pvc.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
 		})
 	})
 
 	Describe("with NetworkPolicy enabled", func() {
-		It("should apply various network policies and enforce access to nginx pod", func() {
-			if eng.HasNetworkPolicy("calico") || eng.HasNetworkPolicy("azure") || eng.HasNetworkPolicy("cilium") {
-				nsDev, nsProd := "development", "production"
-				By("Creating development namespace")
-				namespaceDev, err := namespace.CreateIfNotExist(nsDev)
-				Expect(err).NotTo(HaveOccurred())
-				By("Creating production namespace")
-				namespaceProd, err := namespace.CreateIfNotExist(nsProd)
-				Expect(err).NotTo(HaveOccurred())
-				By("Labelling development namespace")
-				err = namespaceDev.Label("purpose=development")
-				Expect(err).NotTo(HaveOccurred())
-				By("Labelling production namespace")
-				err = namespaceProd.Label("purpose=production")
-				Expect(err).NotTo(HaveOccurred())
-				By("Creating frontendProd, backend and network-policy pod deployments")
-				r := rand.New(rand.NewSource(time.Now().UnixNano()))
-				randInt := r.Intn(99999)
-				frontendProdDeploymentName := fmt.Sprintf("frontend-prod-%s-%v", cfg.Name, randInt)
-				frontendProdDeployment, err := deployment.CreateLinuxDeploy("library/nginx:latest", frontendProdDeploymentName, nsProd, "--labels=app=webapp,role=frontend")
-				Expect(err).NotTo(HaveOccurred())
-				frontendDevDeploymentName := fmt.Sprintf("frontend-dev-%s-%v", cfg.Name, randInt+100000)
-				frontendDevDeployment, err := deployment.CreateLinuxDeploy("library/nginx:latest", frontendDevDeploymentName, nsDev, "--labels=app=webapp,role=frontend")
-				Expect(err).NotTo(HaveOccurred())
-				backendDeploymentName := fmt.Sprintf("backend-%s-%v", cfg.Name, randInt+200000)
-				backendDeployment, err := deployment.CreateLinuxDeploy("library/nginx:latest", backendDeploymentName, nsDev, "--labels=app=webapp,role=backend")
-				Expect(err).NotTo(HaveOccurred())
-				nwpolicyDeploymentName := fmt.Sprintf("network-policy-%s-%v", cfg.Name, randInt+300000)
-				nwpolicyDeployment, err := deployment.CreateLinuxDeploy("library/nginx:latest", nwpolicyDeploymentName, nsDev, "")
-				Expect(err).NotTo(HaveOccurred())
-
-				By("Ensure there is a running frontend-prod pod")
-				running, err := pod.WaitOnReady(frontendProdDeploymentName, nsProd, 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(running).To(Equal(true))
-
-				By("Ensure there is a running frontend-dev pod")
-				running, err = pod.WaitOnReady(frontendDevDeploymentName, nsDev, 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(running).To(Equal(true))
-
-				By("Ensure there is a running backend pod")
-				running, err = pod.WaitOnReady(backendDeploymentName, nsDev, 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(running).To(Equal(true))
+		requires(capability.NetworkPolicy).It("should apply various network policies and enforce access to nginx pod", func() {
+			nsDev, nsProd := util.UniqueName("development"), util.UniqueName("production")
+			By("Creating development namespace")
+			namespaceDev, err := namespace.CreateIfNotExist(nsDev)
+			Expect(err).NotTo(HaveOccurred())
+			By("Creating production namespace")
+			namespaceProd, err := namespace.CreateIfNotExist(nsProd)
+			Expect(err).NotTo(HaveOccurred())
+			By("Labelling development namespace")
+			err = namespaceDev.Label("purpose=development")
+			Expect(err).NotTo(HaveOccurred())
+			By("Labelling production namespace")
+			err = namespaceProd.Label("purpose=production")
+			Expect(err).NotTo(HaveOccurred())
+			By("Creating frontendProd, backend and network-policy pod deployments")
+			frontendProdDeploymentName := util.UniqueName(fmt.Sprintf("frontend-prod-%s", cfg.Name))
+			frontendProdDeployment, err := deployment.CreateLinuxDeploy("library/nginx:latest", frontendProdDeploymentName, nsProd, "--labels=app=webapp,role=frontend")
+			Expect(err).NotTo(HaveOccurred())
+			frontendDevDeploymentName := util.UniqueName(fmt.Sprintf("frontend-dev-%s", cfg.Name))
+			frontendDevDeployment, err := deployment.CreateLinuxDeploy("library/nginx:latest", frontendDevDeploymentName, nsDev, "--labels=app=webapp,role=frontend")
+			Expect(err).NotTo(HaveOccurred())
+			backendDeploymentName := util.UniqueName(fmt.Sprintf("backend-%s", cfg.Name))
+			backendDeployment, err := deployment.CreateLinuxDeploy("library/nginx:latest", backendDeploymentName, nsDev, "--labels=app=webapp,role=backend")
+			Expect(err).NotTo(HaveOccurred())
+			nwpolicyDeploymentName := util.UniqueName(fmt.Sprintf("network-policy-%s", cfg.Name))
+			nwpolicyDeployment, err := deployment.CreateLinuxDeploy("library/nginx:latest", nwpolicyDeploymentName, nsDev, "")
+			Expect(err).NotTo(HaveOccurred())
 
-				By("Ensure there is a running network-policy pod")
-				running, err = pod.WaitOnReady(nwpolicyDeploymentName, nsDev, 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(running).To(Equal(true))
+			By("Ensure there is a running frontend-prod pod")
+			running, err := frontendProdDeployment.WaitOnReady(3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(Equal(true))
 
-				By("Ensuring we have outbound internet access from the frontend-prod pods")
-				frontendProdPods, err := frontendProdDeployment.Pods()
-				Expect(err).NotTo(HaveOccurred())
-				Expect(len(frontendProdPods)).ToNot(BeZero())
-				pl := pod.List{Pods: frontendProdPods}
-				pass, err := pl.CheckOutboundConnection(5*time.Second, cfg.Timeout, api.Linux)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(pass).To(BeTrue())
+			By("Ensure there is a running frontend-dev pod")
+			running, err = frontendDevDeployment.WaitOnReady(3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(Equal(true))
 
-				By("Ensuring we have outbound internet access from the frontend-dev pods")
-				frontendDevPods, err := frontendDevDeployment.Pods()
-				Expect(err).NotTo(HaveOccurred())
-				Expect(len(frontendDevPods)).ToNot(BeZero())
-				pl = pod.List{Pods: frontendDevPods}
-				pass, err = pl.CheckOutboundConnection(5*time.Second, cfg.Timeout, api.Linux)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(pass).To(BeTrue())
+			By("Ensure there is a running backend pod")
+			running, err = backendDeployment.WaitOnReady(3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(Equal(true))
 
-				By("Ensuring we have outbound internet access from the backend pods")
-				backendPods, err := backendDeployment.Pods()
-				Expect(err).NotTo(HaveOccurred())
-				Expect(len(backendPods)).ToNot(BeZero())
-				pl = pod.List{Pods: backendPods}
-				pass, err = pl.CheckOutboundConnection(5*time.Second, cfg.Timeout, api.Linux)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(pass).To(BeTrue())
+			By("Ensure there is a running network-policy pod")
+			running, err = nwpolicyDeployment.WaitOnReady(3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(Equal(true))
 
-				By("Ensuring we have outbound internet access from the network-policy pods")
-				nwpolicyPods, err := nwpolicyDeployment.Pods()
-				Expect(err).NotTo(HaveOccurred())
-				Expect(len(nwpolicyPods)).ToNot(BeZero())
-				pl = pod.List{Pods: nwpolicyPods}
-				pass, err = pl.CheckOutboundConnection(5*time.Second, cfg.Timeout, api.Linux)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(pass).To(BeTrue())
+			By("Ensuring we have outbound internet access from the frontend-prod pods")
+			frontendProdPods, err := frontendProdDeployment.Pods()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(frontendProdPods)).ToNot(BeZero())
+			pl := pod.List{Pods: frontendProdPods}
+			_, err = pl.CheckOutboundConnection(context.Background(), 5*time.Second, cfg.Timeout, api.Linux, 0)
+			Expect(err).NotTo(HaveOccurred())
 
-				By("Ensuring we have connectivity from network-policy pods to frontend-prod pods")
-				pl = pod.List{Pods: nwpolicyPods}
-				for _, frontendProdPod := range frontendProdPods {
-					pass, err = pl.ValidateCurlConnection(frontendProdPod.Status.PodIP, 5*time.Second, cfg.Timeout)
-					Expect(err).NotTo(HaveOccurred())
-					Expect(pass).To(BeTrue())
-				}
+			By("Ensuring we have outbound internet access from the frontend-dev pods")
+			frontendDevPods, err := frontendDevDeployment.Pods()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(frontendDevPods)).ToNot(BeZero())
+			pl = pod.List{Pods: frontendDevPods}
+			_, err = pl.CheckOutboundConnection(context.Background(), 5*time.Second, cfg.Timeout, api.Linux, 0)
+			Expect(err).NotTo(HaveOccurred())
 
-				By("Ensuring we have connectivity from network-policy pods to backend pods")
-				for _, backendPod := range backendPods {
-					pass, err = pl.ValidateCurlConnection(backendPod.Status.PodIP, 5*time.Second, cfg.Timeout)
-					Expect(err).NotTo(HaveOccurred())
-					Expect(pass).To(BeTrue())
-				}
+			By("Ensuring we have outbound internet access from the backend pods")
+			backendPods, err := backendDeployment.Pods()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(backendPods)).ToNot(BeZero())
+			pl = pod.List{Pods: backendPods}
+			_, err = pl.CheckOutboundConnection(context.Background(), 5*time.Second, cfg.Timeout, api.Linux, 0)
+			Expect(err).NotTo(HaveOccurred())
 
-				By("Applying a network policy to deny ingress access to app: webapp, role: backend pods in development namespace")
-				nwpolicyName, namespace := "backend-deny-ingress", nsDev
-				err = networkpolicy.CreateNetworkPolicyFromFile(filepath.Join(PolicyDir, "backend-policy-deny-ingress.yaml"), nwpolicyName, namespace)
-				Expect(err).NotTo(HaveOccurred())
+			By("Ensuring we have outbound internet access from the network-policy pods")
+			nwpolicyPods, err := nwpolicyDeployment.Pods()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(nwpolicyPods)).ToNot(BeZero())
+			pl = pod.List{Pods: nwpolicyPods}
+			_, err = pl.CheckOutboundConnection(context.Background(), 5*time.Second, cfg.Timeout, api.Linux, 0)
+			Expect(err).NotTo(HaveOccurred())
 
-				By("Ensuring we no longer have ingress access from the network-policy pods to backend pods")
-				for _, backendPod := range backendPods {
-					pass, err = pl.ValidateCurlConnection(backendPod.Status.PodIP, 5*time.Second, validateNetworkPolicyTimeout)
-					Expect(err).Should(HaveOccurred())
-					Expect(pass).To(BeFalse())
-				}
+			By("Ensuring we have connectivity from network-policy pods to frontend-prod pods")
+			err = networkpolicy.ValidatePolicies(nwpolicyPods, frontendProdPods, 80, true, 5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
 
-				By("Cleaning up after ourselves")
-				networkpolicy.DeleteNetworkPolicy(nwpolicyName, namespace)
+			By("Ensuring we have connectivity from network-policy pods to backend pods")
+			err = networkpolicy.ValidatePolicies(nwpolicyPods, backendPods, 80, true, 5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
 
-				if common.IsKubernetesVersionGe(eng.ExpandedDefinition.Properties.OrchestratorProfile.OrchestratorVersion, "1.11.0") {
-					By("Applying a network policy to only allow ingress access to app: webapp, role: backend pods in development namespace from pods in any namespace with the same labels")
-					nwpolicyName, namespace = "backend-allow-ingress-pod-label", nsDev
-					err = networkpolicy.CreateNetworkPolicyFromFile(filepath.Join(PolicyDir, "backend-policy-allow-ingress-pod-label.yaml"), nwpolicyName, namespace)
-					Expect(err).NotTo(HaveOccurred())
+			By("Applying a network policy to deny ingress access to app: webapp, role: backend pods in development namespace")
+			nwpolicy, err := networkpolicy.CreateFromFile(filepath.Join(PolicyDir, "backend-policy-deny-ingress.yaml"), "backend-deny-ingress", nsDev)
+			Expect(err).NotTo(HaveOccurred())
+			err = nwpolicy.WaitOnApplied(5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
 
-					By("Ensuring we have ingress access from pods with matching labels")
-					pl = pod.List{Pods: backendPods}
-					for _, backendDstPod := range backendPods {
-						pass, err = pl.ValidateCurlConnection(backendDstPod.Status.PodIP, 5*time.Second, cfg.Timeout)
-						Expect(err).NotTo(HaveOccurred())
-						Expect(pass).To(BeTrue())
-					}
+			By("Ensuring we no longer have ingress access from the network-policy pods to backend pods")
+			err = networkpolicy.ValidatePolicies(nwpolicyPods, backendPods, 80, false, 5*time.Second, validateNetworkPolicyTimeout)
+			Expect(err).NotTo(HaveOccurred())
 
-					By("Ensuring we don't have ingress access from pods without matching labels")
-					pl = pod.List{Pods: nwpolicyPods}
-					for _, backendPod := range backendPods {
-						pass, err = pl.ValidateCurlConnection(backendPod.Status.PodIP, 5*time.Second, validateNetworkPolicyTimeout)
-						Expect(err).Should(HaveOccurred())
-						Expect(pass).To(BeFalse())
-					}
+			By("Ensuring we still have egress access from the backend pods to frontend-prod pods")
+			err = networkpolicy.ValidatePolicies(backendPods, frontendProdPods, 80, true, 5*time.Second, validateNetworkPolicyTimeout)
+			Expect(err).NotTo(HaveOccurred())
 
-					By("Cleaning up after ourselves")
-					networkpolicy.DeleteNetworkPolicy(nwpolicyName, namespace)
+			By("Cleaning up after ourselves")
+			err = nwpolicy.Delete()
+			Expect(err).NotTo(HaveOccurred())
 
-					By("Applying a network policy to only allow ingress access to app: webapp role:backends in development namespace from pods with label app:webapp, role: frontendProd within namespace with label purpose: development")
-					nwpolicyName, namespace = "backend-policy-allow-ingress-pod-namespace-label", nsDev
-					err = networkpolicy.CreateNetworkPolicyFromFile(filepath.Join(PolicyDir, "backend-policy-allow-ingress-pod-namespace-label.yaml"), nwpolicyName, namespace)
-					Expect(err).NotTo(HaveOccurred())
+			By("Ensuring we have ingress access from the network-policy pods to backend pods again")
+			err = networkpolicy.ValidatePolicies(nwpolicyPods, backendPods, 80, true, 5*time.Second, validateNetworkPolicyTimeout)
+			Expect(err).NotTo(HaveOccurred())
 
-					By("Ensuring we don't have ingress access from role:frontend pods in production namespace")
-					pl = pod.List{Pods: frontendProdPods}
-					for _, backendPod := range backendPods {
-						pass, err = pl.ValidateCurlConnection(backendPod.Status.PodIP, 5*time.Second, validateNetworkPolicyTimeout)
-						Expect(err).Should(HaveOccurred())
-						Expect(pass).To(BeFalse())
-					}
+			if common.IsKubernetesVersionGe(eng.ExpandedDefinition.Properties.OrchestratorProfile.OrchestratorVersion, "1.11.0") {
+				By("Applying a network policy to only allow ingress access to app: webapp, role: backend pods in development namespace from pods in any namespace with the same labels")
+				nwpolicy, err = networkpolicy.CreateFromFile(filepath.Join(PolicyDir, "backend-policy-allow-ingress-pod-label.yaml"), "backend-allow-ingress-pod-label", nsDev)
+				Expect(err).NotTo(HaveOccurred())
+				err = nwpolicy.WaitOnApplied(5*time.Second, cfg.Timeout)
+				Expect(err).NotTo(HaveOccurred())
 
-					By("Ensuring we have ingress access from role:frontend pods in development namespace")
-					pl = pod.List{Pods: frontendDevPods}
-					for _, backendPod := range backendPods {
-						pass, err = pl.ValidateCurlConnection(backendPod.Status.PodIP, 5*time.Second, cfg.Timeout)
-						Expect(err).NotTo(HaveOccurred())
-						Expect(pass).To(BeTrue())
-					}
+				By("Ensuring we have ingress access from pods with matching labels")
+				err = networkpolicy.ValidatePolicies(frontendProdPods, backendPods, 80, true, 5*time.Second, validateNetworkPolicyTimeout)
+				Expect(err).NotTo(HaveOccurred())
 
-					By("Cleaning up after ourselves")
-					networkpolicy.DeleteNetworkPolicy(nwpolicyName, namespace)
-				}
+				By("Ensuring we don't have ingress access from pods without matching labels")
+				err = networkpolicy.ValidatePolicies(nwpolicyPods, backendPods, 80, false, 5*time.Second, validateNetworkPolicyTimeout)
+				Expect(err).NotTo(HaveOccurred())
 
 				By("Cleaning up after ourselves")
-				err = frontendProdDeployment.Delete(util.DefaultDeleteRetries)
+				err = nwpolicy.Delete()
 				Expect(err).NotTo(HaveOccurred())
-				err = frontendDevDeployment.Delete(util.DefaultDeleteRetries)
+
+				By("Applying a network policy to only allow ingress access to app: webapp role:backends in development namespace from pods with label app:webapp, role: frontendProd within namespace with label purpose: development")
+				nwpolicy, err = networkpolicy.CreateFromFile(filepath.Join(PolicyDir, "backend-policy-allow-ingress-pod-namespace-label.yaml"), "backend-policy-allow-ingress-pod-namespace-label", nsDev)
 				Expect(err).NotTo(HaveOccurred())
-				err = backendDeployment.Delete(util.DefaultDeleteRetries)
+				err = nwpolicy.WaitOnApplied(5*time.Second, cfg.Timeout)
 				Expect(err).NotTo(HaveOccurred())
-				err = nwpolicyDeployment.Delete(util.DefaultDeleteRetries)
+
+				By("Ensuring we don't have ingress access from role:frontend pods in production namespace")
+				err = networkpolicy.ValidatePolicies(frontendProdPods, backendPods, 80, false, 5*time.Second, validateNetworkPolicyTimeout)
 				Expect(err).NotTo(HaveOccurred())
-				err = namespaceDev.Delete()
+
+				By("Ensuring we have ingress access from role:frontend pods in development namespace")
+				err = networkpolicy.ValidatePolicies(frontendDevPods, backendPods, 80, true, 5*time.Second, validateNetworkPolicyTimeout)
 				Expect(err).NotTo(HaveOccurred())
-				err = namespaceProd.Delete()
+
+				By("Cleaning up after ourselves")
+				err = nwpolicy.Delete()
 				Expect(err).NotTo(HaveOccurred())
-			} else {
-				Skip("Calico or Azure network policy was not provisioned for this Cluster Definition")
 			}
-		})
-	})
 
-	Describe("with a windows agent pool", func() {
-		It("should be able to deploy and scale an iis webserver", func() {
-			if eng.HasWindowsAgents() {
-				windowsImages, err := eng.GetWindowsTestImages()
+			// the default deny and egress policies of the upstream NetworkPolicy conformance tests
+			if common.IsKubernetesVersionGe(eng.ExpandedDefinition.Properties.OrchestratorProfile.OrchestratorVersion, "1.12.0") {
+				By("Applying a network policy to deny ingress access to all the pods in development namespace")
+				nwpolicy, err = networkpolicy.CreateFromFile(filepath.Join(PolicyDir, "namespace-default-deny-ingress.yaml"), "default-deny-ingress", nsDev)
 				Expect(err).NotTo(HaveOccurred())
-				r := rand.New(rand.NewSource(time.Now().UnixNano()))
-				deploymentPrefix := fmt.Sprintf("iis-%s", cfg.Name)
-				deploymentName := fmt.Sprintf("%s-%v", deploymentPrefix, r.Intn(99999))
-				By("Creating a deployment with 1 pod running IIS")
-				iisDeploy, err := deployment.CreateWindowsDeployWithHostportDeleteIfExist(deploymentPrefix, windowsImages.IIS, deploymentName, "default", 80, -1)
+				err = nwpolicy.WaitOnApplied(5*time.Second, cfg.Timeout)
 				Expect(err).NotTo(HaveOccurred())
 
-				By("Waiting on pod to be Ready")
-				running, err := pod.WaitOnReady(deploymentName, "default", 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+				By("Ensuring we don't have ingress access from pods in the same namespace")
+				err = networkpolicy.ValidatePolicies(nwpolicyPods, append(backendPods, frontendDevPods...), 80, false, 5*time.Second, validateNetworkPolicyTimeout)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(running).To(Equal(true))
 
-				By("Exposing a LoadBalancer for the pod")
-				err = iisDeploy.ExposeDeleteIfExist(deploymentPrefix, "default", "LoadBalancer", 80, 80)
-				Expect(err).NotTo(HaveOccurred())
-				iisService, err := service.Get(deploymentName, "default")
+				By("Ensuring we don't have ingress access from pods in another namespace")
+				err = networkpolicy.ValidatePolicies(frontendProdPods, frontendDevPods, 80, false, 5*time.Second, validateNetworkPolicyTimeout)
 				Expect(err).NotTo(HaveOccurred())
 
-				By("Verifying that the service is reachable and returns the default IIS start page")
-				valid := iisService.Validate("(IIS Windows Server)", 10, retryTimeWhenWaitingForPodReady, cfg.Timeout)
-				Expect(valid).To(BeTrue())
+				By("Ensuring we still have egress access from development namespace to production namespace")
+				err = networkpolicy.ValidatePolicies(frontendDevPods, frontendProdPods, 80, true, 5*time.Second, validateNetworkPolicyTimeout)
+				Expect(err).NotTo(HaveOccurred())
 
-				By("Checking that each pod can reach the internet")
-				var iisPods []pod.Pod
-				iisPods, err = iisDeploy.Pods()
+				By("Cleaning up after ourselves")
+				err = nwpolicy.Delete()
 				Expect(err).NotTo(HaveOccurred())
-				Expect(len(iisPods)).ToNot(BeZero())
-				for _, iisPod := range iisPods {
-					var pass bool
-					pass, err = iisPod.CheckWindowsOutboundConnection(retryTimeWhenWaitingForPodReady, timeoutWhenWaitingForPodOutboundAccess)
-					Expect(err).NotTo(HaveOccurred())
-					Expect(pass).To(BeTrue())
-				}
 
-				By("Scaling deployment to 5 pods")
-				err = iisDeploy.ScaleDeployment(5)
+				By("Applying a network policy to deny egress access from app: webapp, role: backend pods in development namespace")
+				nwpolicy, err = networkpolicy.CreateFromFile(filepath.Join(PolicyDir, "backend-policy-deny-egress.yaml"), "backend-deny-egress", nsDev)
 				Expect(err).NotTo(HaveOccurred())
-				_, err = iisDeploy.WaitForReplicas(5, 5, 2*time.Second, cfg.Timeout)
+				err = nwpolicy.WaitOnApplied(5*time.Second, cfg.Timeout)
 				Expect(err).NotTo(HaveOccurred())
 
-				By("Waiting on 5 pods to be Ready")
-				running, err = pod.WaitOnReady(deploymentName, "default", 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+				By("Ensuring we no longer have egress access from the backend pods to frontend-prod pods")
+				err = networkpolicy.ValidatePolicies(backendPods, frontendProdPods, 80, false, 5*time.Second, validateNetworkPolicyTimeout)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(running).To(Equal(true))
-				iisPods, err = iisDeploy.Pods()
+
+				By("Ensuring we still have egress access from the network-policy pods to frontend-prod pods")
+				err = networkpolicy.ValidatePolicies(nwpolicyPods, frontendProdPods, 80, true, 5*time.Second, validateNetworkPolicyTimeout)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(len(iisPods)).To(Equal(5))
 
-				By("Verifying that the service is reachable and returns the default IIS start page")
-				valid = iisService.Validate("(IIS Windows Server)", 10, retryTimeWhenWaitingForPodReady, cfg.Timeout)
-				Expect(valid).To(BeTrue())
+				By("Ensuring we still have ingress access from the network-policy pods to backend pods")
+				err = networkpolicy.ValidatePolicies(nwpolicyPods, backendPods, 80, true, 5*time.Second, validateNetworkPolicyTimeout)
+				Expect(err).NotTo(HaveOccurred())
 
-				By("Checking that each pod can reach the internet")
-				iisPods, err = iisDeploy.Pods()
+				By("Cleaning up after ourselves")
+				err = nwpolicy.Delete()
 				Expect(err).NotTo(HaveOccurred())
-				Expect(len(iisPods)).ToNot(BeZero())
-				for _, iisPod := range iisPods {
-					var pass bool
-					pass, err = iisPod.CheckWindowsOutboundConnection(retryTimeWhenWaitingForPodReady, timeoutWhenWaitingForPodOutboundAccess)
-					Expect(err).NotTo(HaveOccurred())
-					Expect(pass).To(BeTrue())
-				}
+			}
 
-				By("Checking that no pods restart")
-				for _, iisPod := range iisPods {
-					log.Printf("Checking %s", iisPod.Metadata.Name)
-					Expect(iisPod.Status.ContainerStatuses[0].Ready).To(BeTrue())
-					Expect(iisPod.Status.ContainerStatuses[0].RestartCount).To(Equal(0))
-				}
+			By("Cleaning up after ourselves")
+			err = frontendProdDeployment.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			err = frontendDevDeployment.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			err = backendDeployment.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			err = nwpolicyDeployment.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			err = namespaceDev.Delete()
+			Expect(err).NotTo(HaveOccurred())
+			err = namespaceProd.Delete()
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
 
-				By("Scaling deployment to 2 pods")
-				err = iisDeploy.ScaleDeployment(2)
-				Expect(err).NotTo(HaveOccurred())
-				_, err = iisDeploy.WaitForReplicas(2, 2, 2*time.Second, cfg.Timeout)
-				Expect(err).NotTo(HaveOccurred())
-				iisPods, err = iisDeploy.Pods()
-				Expect(err).NotTo(HaveOccurred())
-				Expect(len(iisPods)).To(Equal(2))
+	Describe("with a windows agent pool", func() {
+		requires(capability.Windows).It("should be able to deploy and scale an iis webserver", func() {
+			windowsImages, err := eng.GetWindowsTestImages()
+			Expect(err).NotTo(HaveOccurred())
+			deploymentPrefix := fmt.Sprintf("iis-%s", cfg.Name)
+			deploymentName := util.UniqueName(deploymentPrefix)
+			By("Creating a deployment with 1 pod running IIS")
+			iisDeploy, err := deployment.CreateWindowsDeployWithHostportDeleteIfExist(util.RunPrefix(deploymentPrefix), windowsImages.IIS, deploymentName, "default", 80, -1)
+			Expect(err).NotTo(HaveOccurred())
 
-				By("Verifying that the service is reachable and returns the default IIS start page")
-				valid = iisService.Validate("(IIS Windows Server)", 10, retryTimeWhenWaitingForPodReady, cfg.Timeout)
-				Expect(valid).To(BeTrue())
+			By("Waiting on pod to be Ready")
+			running, err := pod.WaitOnReady(deploymentName, "default", 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(Equal(true))
+
+			By("Exposing a LoadBalancer for the pod")
+			err = iisDeploy.ExposeDeleteIfExist(util.RunPrefix(deploymentPrefix), "default", "LoadBalancer", 80, 80)
+			Expect(err).NotTo(HaveOccurred())
+			iisService, err := service.Get(deploymentName, "default")
+			Expect(err).NotTo(HaveOccurred())
 
-				By("Checking that each pod can reach the internet")
-				iisPods, err = iisDeploy.Pods()
+			By("Verifying that the service is reachable and returns the default IIS start page")
+			valid := iisService.Validate("(IIS Windows Server)", 10, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(valid).To(BeTrue())
+
+			By("Checking that each pod can reach the internet")
+			var iisPods []pod.Pod
+			iisPods, err = iisDeploy.Pods()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(iisPods)).ToNot(BeZero())
+			for _, iisPod := range iisPods {
+				var pass bool
+				pass, err = iisPod.CheckWindowsOutboundConnection(retryTimeWhenWaitingForPodReady, timeoutWhenWaitingForPodOutboundAccess)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(len(iisPods)).ToNot(BeZero())
-				for _, iisPod := range iisPods {
-					var pass bool
-					pass, err = iisPod.CheckWindowsOutboundConnection(retryTimeWhenWaitingForPodReady, cfg.Timeout)
-					Expect(err).NotTo(HaveOccurred())
-					Expect(pass).To(BeTrue())
-				}
+				Expect(pass).To(BeTrue())
+			}
+
+			By("Scaling deployment to 5 pods")
+			err = iisDeploy.ScaleDeployment(5)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = iisDeploy.WaitForReplicas(5, 5, 2*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
 
-				By("Verifying pods & services can be deleted")
-				err = iisDeploy.Delete(util.DefaultDeleteRetries)
+			By("Waiting on 5 pods to be Ready")
+			running, err = pod.WaitOnReady(deploymentName, "default", 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(Equal(true))
+			iisPods, err = iisDeploy.Pods()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(iisPods)).To(Equal(5))
+
+			By("Verifying that the service is reachable and returns the default IIS start page")
+			valid = iisService.Validate("(IIS Windows Server)", 10, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(valid).To(BeTrue())
+
+			By("Checking that each pod can reach the internet")
+			iisPods, err = iisDeploy.Pods()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(iisPods)).ToNot(BeZero())
+			for _, iisPod := range iisPods {
+				var pass bool
+				pass, err = iisPod.CheckWindowsOutboundConnection(retryTimeWhenWaitingForPodReady, timeoutWhenWaitingForPodOutboundAccess)
 				Expect(err).NotTo(HaveOccurred())
-				err = iisService.Delete(util.DefaultDeleteRetries)
+				Expect(pass).To(BeTrue())
+			}
+
+			By("Checking that no pods restart")
+			for _, iisPod := range iisPods {
+				log.Printf("Checking %s", iisPod.Metadata.Name)
+				Expect(iisPod.Status.ContainerStatuses[0].Ready).To(BeTrue())
+				Expect(iisPod.Status.ContainerStatuses[0].RestartCount).To(Equal(0))
+			}
+
+			By("Scaling deployment to 2 pods")
+			err = iisDeploy.ScaleDeployment(2)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = iisDeploy.WaitForReplicas(2, 2, 2*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			iisPods, err = iisDeploy.Pods()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(iisPods)).To(Equal(2))
+
+			By("Verifying that the service is reachable and returns the default IIS start page")
+			valid = iisService.Validate("(IIS Windows Server)", 10, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(valid).To(BeTrue())
+
+			By("Checking that each pod can reach the internet")
+			iisPods, err = iisDeploy.Pods()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(iisPods)).ToNot(BeZero())
+			for _, iisPod := range iisPods {
+				var pass bool
+				pass, err = iisPod.CheckWindowsOutboundConnection(retryTimeWhenWaitingForPodReady, cfg.Timeout)
 				Expect(err).NotTo(HaveOccurred())
-			} else {
-				Skip("No windows agent was provisioned for this Cluster Definition")
+				Expect(pass).To(BeTrue())
 			}
+
+			By("Verifying pods & services can be deleted")
+			err = iisDeploy.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			err = iisService.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
 		})
 
-		It("should be able to resolve DNS across windows and linux deployments", func() {
-			if eng.HasWindowsAgents() {
-				windowsImages, err := eng.GetWindowsTestImages()
-				Expect(err).NotTo(HaveOccurred())
-				r := rand.New(rand.NewSource(time.Now().UnixNano()))
-				deploymentPrefix := fmt.Sprintf("iis-dns-%s", cfg.Name)
-				windowsDeploymentName := fmt.Sprintf("%s-%v", deploymentPrefix, r.Intn(99999))
-				By("Creating a deployment running IIS")
-				windowsIISDeployment, err := deployment.CreateWindowsDeployWithHostportDeleteIfExist(deploymentPrefix, windowsImages.IIS, windowsDeploymentName, "default", 80, -1)
-				Expect(err).NotTo(HaveOccurred())
+		requires(capability.Windows).It("should be able to resolve DNS across windows and linux deployments", func() {
+			windowsImages, err := eng.GetWindowsTestImages()
+			Expect(err).NotTo(HaveOccurred())
+			deploymentPrefix := fmt.Sprintf("iis-dns-%s", cfg.Name)
+			windowsDeploymentName := util.UniqueName(deploymentPrefix)
+			By("Creating a deployment running IIS")
+			windowsIISDeployment, err := deployment.CreateWindowsDeployWithHostportDeleteIfExist(util.RunPrefix(deploymentPrefix), windowsImages.IIS, windowsDeploymentName, "default", 80, -1)
+			Expect(err).NotTo(HaveOccurred())
 
-				deploymentPrefix = fmt.Sprintf("nginx-dns-%s", cfg.Name)
-				nginxDeploymentName := fmt.Sprintf("%s-%v", deploymentPrefix, r.Intn(99999))
-				By("Creating a nginx deployment")
-				linuxNginxDeploy, err := deployment.CreateLinuxDeployDeleteIfExists(deploymentPrefix, "library/nginx:latest", nginxDeploymentName, "default", "")
-				Expect(err).NotTo(HaveOccurred())
+			deploymentPrefix = fmt.Sprintf("nginx-dns-%s", cfg.Name)
+			nginxDeploymentName := util.UniqueName(deploymentPrefix)
+			By("Creating a nginx deployment")
+			linuxNginxDeploy, err := deployment.CreateLinuxDeployDeleteIfExists(util.RunPrefix(deploymentPrefix), "library/nginx:latest", nginxDeploymentName, "default", "")
+			Expect(err).NotTo(HaveOccurred())
 
-				By("Ensure there is a Running nginx pod")
-				running, err := pod.WaitOnReady(nginxDeploymentName, "default", 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(running).To(Equal(true))
+			By("Ensure there is a Running nginx pod")
+			running, err := pod.WaitOnReady(nginxDeploymentName, "default", 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(Equal(true))
 
-				By("Ensure there is a Running iis pod")
-				running, err = pod.WaitOnReady(windowsDeploymentName, "default", 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(running).To(Equal(true))
+			By("Ensure there is a Running iis pod")
+			running, err = pod.WaitOnReady(windowsDeploymentName, "default", 3, retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running).To(Equal(true))
 
-				By("Exposing a internal service for the linux nginx deployment")
-				err = linuxNginxDeploy.ExposeIfNotExist("ClusterIP", 80, 80)
-				Expect(err).NotTo(HaveOccurred())
-				linuxService, err := service.Get(nginxDeploymentName, "default")
-				Expect(err).NotTo(HaveOccurred())
+			By("Exposing a internal service for the linux nginx deployment")
+			err = linuxNginxDeploy.ExposeIfNotExist("ClusterIP", 80, 80)
+			Expect(err).NotTo(HaveOccurred())
+			linuxService, err := service.Get(nginxDeploymentName, "default")
+			Expect(err).NotTo(HaveOccurred())
 
-				By("Exposing a internal service for the windows iis deployment")
-				err = windowsIISDeployment.ExposeIfNotExist("ClusterIP", 80, 80)
-				Expect(err).NotTo(HaveOccurred())
-				windowsService, err := service.Get(windowsDeploymentName, "default")
-				Expect(err).NotTo(HaveOccurred())
+			By("Exposing a internal service for the windows iis deployment")
+			err = windowsIISDeployment.ExposeIfNotExist("ClusterIP", 80, 80)
+			Expect(err).NotTo(HaveOccurred())
+			windowsService, err := service.Get(windowsDeploymentName, "default")
+			Expect(err).NotTo(HaveOccurred())
 
-				By("Connecting to Windows from another Windows deployment")
-				name := fmt.Sprintf("windows-2-windows-%s", cfg.Name)
-				command := fmt.Sprintf("iwr -UseBasicParsing -TimeoutSec 60 %s", windowsService.Metadata.Name)
-				successes, err := pod.RunCommandMultipleTimes(pod.RunWindowsPod, windowsImages.ServerCore, name, command, cfg.StabilityIterations, 1*time.Second, retryCommandsTimeout, windowsCommandTimeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(successes).To(Equal(cfg.StabilityIterations))
+			By("Ensuring that the linux and windows service names resolve from a windows container")
+			windowsPods, err := windowsIISDeployment.Pods()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(windowsPods)).NotTo(BeZero())
+			clusterDomain := eng.ClusterDomain()
+			lookups := append(dns.ClusterLookups(clusterDomain), dns.ExternalLookups()...)
+			lookups = append(lookups,
+				dns.Lookup{Name: dns.ServiceFQDN(linuxService.Metadata.Name, "default", clusterDomain), Type: dns.A},
+				dns.Lookup{Name: dns.ServiceFQDN(windowsService.Metadata.Name, "default", clusterDomain), Type: dns.A})
+			ctx, cancel := context.WithTimeout(context.Background(), validateDNSTimeout)
+			defer cancel()
+			_, err = dns.Validate(ctx, &windowsPods[0], api.Windows, lookups, util.DefaultRetrier().WithConstantBackoff(5*time.Second))
+			Expect(err).NotTo(HaveOccurred())
 
-				By("Connecting to Linux from Windows deployment")
-				name = fmt.Sprintf("windows-2-linux-%s", cfg.Name)
-				command = fmt.Sprintf("iwr -UseBasicParsing -TimeoutSec 60 %s", linuxService.Metadata.Name)
-				successes, err = pod.RunCommandMultipleTimes(pod.RunWindowsPod, windowsImages.ServerCore, name, command, cfg.StabilityIterations, 1*time.Second, retryCommandsTimeout, windowsCommandTimeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(successes).To(Equal(cfg.StabilityIterations))
+			By("Connecting to Windows from another Windows deployment")
+			name := fmt.Sprintf("windows-2-windows-%s", cfg.Name)
+			command := fmt.Sprintf("iwr -UseBasicParsing -TimeoutSec 60 %s", windowsService.Metadata.Name)
+			successes, err := pod.RunCommandMultipleTimes(pod.RunWindowsPod, windowsImages.ServerCore, name, command, cfg.StabilityIterations, 1*time.Second, retryCommandsTimeout, windowsCommandTimeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(successes).To(Equal(cfg.StabilityIterations))
 
-				By("Connecting to Windows from Linux deployment")
-				name = fmt.Sprintf("linux-2-windows-%s", cfg.Name)
-				command = fmt.Sprintf("wget %s", windowsService.Metadata.Name)
-				successes, err = pod.RunCommandMultipleTimes(pod.RunLinuxPod, "alpine", name, command, cfg.StabilityIterations, 1*time.Second, retryCommandsTimeout, windowsCommandTimeout)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(successes).To(Equal(cfg.StabilityIterations))
+			By("Connecting to Linux from Windows deployment")
+			name = fmt.Sprintf("windows-2-linux-%s", cfg.Name)
+			command = fmt.Sprintf("iwr -UseBasicParsing -TimeoutSec 60 %s", linuxService.Metadata.Name)
+			successes, err = pod.RunCommandMultipleTimes(pod.RunWindowsPod, windowsImages.ServerCore, name, command, cfg.StabilityIterations, 1*time.Second, retryCommandsTimeout, windowsCommandTimeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(successes).To(Equal(cfg.StabilityIterations))
 
-				By("Cleaning up after ourselves")
-				err = windowsIISDeployment.Delete(util.DefaultDeleteRetries)
-				Expect(err).NotTo(HaveOccurred())
-				err = linuxNginxDeploy.Delete(util.DefaultDeleteRetries)
-				Expect(err).NotTo(HaveOccurred())
-				err = windowsService.Delete(util.DefaultDeleteRetries)
-				Expect(err).NotTo(HaveOccurred())
-				err = linuxService.Delete(util.DefaultDeleteRetries)
-				Expect(err).NotTo(HaveOccurred())
-			} else {
-				Skip("No windows agent was provisioned for this Cluster Definition")
-			}
+			By("Connecting to Windows from Linux deployment")
+			name = fmt.Sprintf("linux-2-windows-%s", cfg.Name)
+			command = fmt.Sprintf("wget %s", windowsService.Metadata.Name)
+			successes, err = pod.RunCommandMultipleTimes(pod.RunLinuxPod, "alpine", name, command, cfg.StabilityIterations, 1*time.Second, retryCommandsTimeout, windowsCommandTimeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(successes).To(Equal(cfg.StabilityIterations))
+
+			By("Cleaning up after ourselves")
+			err = windowsIISDeployment.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			err = linuxNginxDeploy.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			err = windowsService.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			err = linuxService.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
 		})
 
 		// Windows Bug 18213017: Kubernetes Hostport mappings don't work
 		/*
 			It("should be able to reach hostport in an iis webserver", func() {
 				if eng.HasWindowsAgents() {
-					r := rand.New(rand.NewSource(time.Now().UnixNano()))
 					hostport := 8123
-					deploymentName := fmt.Sprintf("iis-%s-%v", cfg.Name, r.Intn(99999))
+					deploymentName := util.UniqueName(fmt.Sprintf("iis-%s", cfg.Name))
 					iisDeploy, err := deployment.CreateWindowsDeployIfNotExist(iisImage, deploymentName, "default", 80, hostport)
 					Expect(err).NotTo(HaveOccurred())
 					running, err := pod.WaitOnReady(deploymentName, "default", 3, 30*time.Second, cfg.Timeout)
@@ -1690,11 +2593,8 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 					iisPods, err := iisDeploy.Pods()
 					Expect(err).NotTo(HaveOccurred())
 					Expect(len(iisPods)).ToNot(BeZero())
-					kubeConfig, err := GetConfig()
-					Expect(err).NotTo(HaveOccurred())
-					master := fmt.Sprintf("azureuser@%s", kubeConfig.GetServerName())
 					for _, iisPod := range iisPods {
-						valid := iisPod.ValidateHostPort("(IIS Windows Server)", 10, 10*time.Second, master, masterSSHPrivateKeyFilepath)
+						valid := iisPod.ValidateHostPort("(IIS Windows Server)", 10, 10*time.Second, sshConn)
 						Expect(valid).To(BeTrue())
 					}
 					err = iisDeploy.Delete(kubectlOutput)
@@ -1703,59 +2603,180 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 					Skip("No windows agent was provisioned for this Cluster Definition")
 				}
 			})*/
-		It("should be able to attach azure file", func() {
-			if eng.HasWindowsAgents() {
-				if eng.ExpandedDefinition.Properties.OrchestratorProfile.OrchestratorVersion == "1.11.0" {
-					// Failure in 1.11.0 - https://github.com/kubernetes/kubernetes/issues/65845, fixed in 1.11.1
-					Skip("Kubernetes 1.11.0 has a known issue creating Azure PersistentVolumeClaim")
-				} else if common.IsKubernetesVersionGe(eng.ExpandedDefinition.Properties.OrchestratorProfile.OrchestratorVersion, "1.8.0") {
-					windowsImages, err := eng.GetWindowsTestImages()
-					Expect(err).NotTo(HaveOccurred())
+		requires(capability.Windows).It("should be able to attach azure file", func() {
+			if eng.ExpandedDefinition.Properties.OrchestratorProfile.OrchestratorVersion == "1.11.0" {
+				// Failure in 1.11.0 - https://github.com/kubernetes/kubernetes/issues/65845, fixed in 1.11.1
+				Skip("Kubernetes 1.11.0 has a known issue creating Azure PersistentVolumeClaim")
+			} else if common.IsKubernetesVersionGe(eng.ExpandedDefinition.Properties.OrchestratorProfile.OrchestratorVersion, "1.8.0") {
+				windowsImages, err := eng.GetWindowsTestImages()
+				Expect(err).NotTo(HaveOccurred())
 
-					iisAzurefileYaml, err := pod.ReplaceContainerImageFromFile(filepath.Join(WorkloadDir, "iis-azurefile.yaml"), windowsImages.IIS)
-					Expect(err).NotTo(HaveOccurred())
-					defer os.Remove(iisAzurefileYaml)
+				iisAzurefileYaml, err := pod.ReplaceContainerImageFromFile(filepath.Join(WorkloadDir, "iis-azurefile.yaml"), windowsImages.IIS)
+				Expect(err).NotTo(HaveOccurred())
+				defer os.Remove(iisAzurefileYaml)
 
-					By("Creating an AzureFile storage class")
-					storageclassName := "azurefile" // should be the same as in storageclass-azurefile.yaml
-					sc, err := storageclass.CreateStorageClassFromFile(filepath.Join(WorkloadDir, "storageclass-azurefile.yaml"), storageclassName)
-					Expect(err).NotTo(HaveOccurred())
-					ready, err := sc.WaitOnReady(5*time.Second, cfg.Timeout)
-					Expect(err).NotTo(HaveOccurred())
-					Expect(ready).To(Equal(true))
+				By("Creating an AzureFile storage class")
+				storageclassName := "azurefile" // should be the same as in storageclass-azurefile.yaml
+				sc, err := storageclass.CreateStorageClassFromFile(filepath.Join(WorkloadDir, "storageclass-azurefile.yaml"), storageclassName)
+				Expect(err).NotTo(HaveOccurred())
+				ready, err := sc.WaitOnReady(5*time.Second, cfg.Timeout)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ready).To(Equal(true))
 
-					By("Creating a persistent volume claim")
-					pvcName := "pvc-azurefile" // should be the same as in pvc-azurefile.yaml
-					pvc, err := persistentvolumeclaims.CreatePVCFromFileDeleteIfExist(filepath.Join(WorkloadDir, "pvc-azurefile.yaml"), pvcName, "default")
-					Expect(err).NotTo(HaveOccurred())
-					ready, err = pvc.WaitOnReady("default", 5*time.Second, cfg.Timeout)
-					Expect(err).NotTo(HaveOccurred())
-					Expect(ready).To(Equal(true))
+				By("Creating a persistent volume claim")
+				pvcName := "pvc-azurefile" // should be the same as in pvc-azurefile.yaml
+				pvc, err := persistentvolumeclaims.CreatePVCFromFileDeleteIfExist(filepath.Join(WorkloadDir, "pvc-azurefile.yaml"), pvcName, "default")
+				Expect(err).NotTo(HaveOccurred())
+				ready, err = pvc.WaitOnReady("default", 5*time.Second, cfg.Timeout)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ready).To(Equal(true))
 
-					By("Launching an IIS pod using the volume claim")
-					podName := "iis-azurefile" // should be the same as in iis-azurefile.yaml
-					iisPod, err := pod.CreatePodFromFile(iisAzurefileYaml, podName, "default", 1*time.Second, cfg.Timeout)
-					Expect(err).NotTo(HaveOccurred())
-					ready, err = iisPod.WaitOnReady(retryTimeWhenWaitingForPodReady, cfg.Timeout)
-					Expect(err).NotTo(HaveOccurred())
-					Expect(ready).To(Equal(true))
+				By("Launching an IIS pod using the volume claim")
+				podName := "iis-azurefile" // should be the same as in iis-azurefile.yaml
+				iisPod, err := pod.CreatePodFromFile(iisAzurefileYaml, podName, "default", 1*time.Second, cfg.Timeout)
+				Expect(err).NotTo(HaveOccurred())
+				ready, err = iisPod.WaitOnReady(retryTimeWhenWaitingForPodReady, cfg.Timeout)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ready).To(Equal(true))
 
-					By("Checking that the pod can access volume")
-					valid, err := iisPod.ValidateAzureFile("mnt\\azure", 10, 10*time.Second)
-					Expect(valid).To(BeTrue())
-					Expect(err).NotTo(HaveOccurred())
+				By("Checking that the pod can access volume")
+				valid, err := iisPod.ValidateAzureFile("mnt\\azure", 10, 10*time.Second)
+				Expect(valid).To(BeTrue())
+				Expect(err).NotTo(HaveOccurred())
 
-					err = iisPod.Delete(util.DefaultDeleteRetries)
-					Expect(err).NotTo(HaveOccurred())
-					err = pvc.Delete(util.DefaultDeleteRetries)
-					Expect(err).NotTo(HaveOccurred())
-				} else {
-					Skip("Kubernetes version needs to be 1.8 and up for Azure File test")
-				}
+				err = iisPod.Delete(util.DefaultDeleteRetries)
+				Expect(err).NotTo(HaveOccurred())
+				err = pvc.Delete(util.DefaultDeleteRetries)
+				Expect(err).NotTo(HaveOccurred())
 			} else {
-				Skip("No windows agent was provisioned for this Cluster Definition")
+				Skip("Kubernetes version needs to be 1.8 and up for Azure File test")
 			}
 		})
+
+		requires(capability.Linux).It("should be able to expand, snapshot and restore an azure disk csi volume", func() {
+			if !storageclass.HasCSIDriver("disk.csi.azure.com") {
+				Skip("The azure disk csi driver is not installed in this cluster")
+			}
+			By("Creating an azure disk csi storage class allowing volume expansion")
+			sc, err := storageclass.CreateStorageClassFromFile(filepath.Join(WorkloadDir, "storageclass-csi-azuredisk.yaml"), "csi-azuredisk")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sc.AllowVolumeExpansion).To(BeTrue())
+
+			By("Creating a 5Gi persistent volume claim and a pod using it")
+			pvcName := "csi-azuredisk" // should be the same as in pvc-csi-azuredisk.yaml and pod-pvc-csi-azuredisk.yaml
+			pvc, err := persistentvolumeclaims.CreatePVCFromFileDeleteIfExist(filepath.Join(WorkloadDir, "pvc-csi-azuredisk.yaml"), pvcName, "default")
+			Expect(err).NotTo(HaveOccurred())
+			testPod, err := pod.CreatePodFromFile(filepath.Join(WorkloadDir, "pod-pvc-csi-azuredisk.yaml"), pvcName, "default", 1*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			ready, err := testPod.WaitOnReady(retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready).To(Equal(true))
+			err = persistentvolumeclaims.WaitAllBound([]string{pvcName}, "default", 5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			pvc, err = persistentvolumeclaims.Get(pvcName, "default")
+			Expect(err).NotTo(HaveOccurred())
+			pv, err := pvc.PersistentVolume()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pv.Spec.CSI).NotTo(BeNil())
+			Expect(pv.Spec.CSI.Driver).To(Equal("disk.csi.azure.com"))
+
+			By("Checking that the pod can access volume")
+			valid, err := testPod.ValidatePVC("/mnt/azure", 10, 10*time.Second)
+			Expect(valid).To(BeTrue())
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Expanding the volume from 5Gi to 10Gi while it is detached")
+			err = testPod.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = pvc.ValidateExpansion("10Gi", 5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Ensuring the file system of the volume is resized and keeps its data when mounted again")
+			testPod, err = pod.CreatePodFromFile(filepath.Join(WorkloadDir, "pod-pvc-csi-azuredisk.yaml"), pvcName, "default", 1*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			ready, err = testPod.WaitOnReady(retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready).To(Equal(true))
+			err = pvc.WaitOnCapacity("10Gi", 5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			out, err := testPod.Exec("--", "ls", "/mnt/azure")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(out)).To(ContainSubstring("testdirectory"))
+
+			if persistentvolumeclaims.AreSnapshotsSupported() {
+				By("Taking a snapshot of the volume")
+				snapshot, err := persistentvolumeclaims.CreateSnapshotFromFile(filepath.Join(WorkloadDir, "volumesnapshot-csi-azuredisk.yaml"), pvcName, "default")
+				Expect(err).NotTo(HaveOccurred())
+				err = snapshot.WaitOnReady(5*time.Second, cfg.Timeout)
+				Expect(err).NotTo(HaveOccurred())
+
+				By("Restoring the snapshot to a new volume and ensuring it has the data of the snapshot")
+				restoreName := "csi-azuredisk-restore" // should be the same as in pvc-csi-azuredisk-restore.yaml and pod-pvc-csi-azuredisk-restore.yaml
+				restored, err := persistentvolumeclaims.CreatePVCFromFileDeleteIfExist(filepath.Join(WorkloadDir, "pvc-csi-azuredisk-restore.yaml"), restoreName, "default")
+				Expect(err).NotTo(HaveOccurred())
+				restorePod, err := pod.CreatePodFromFile(filepath.Join(WorkloadDir, "pod-pvc-csi-azuredisk-restore.yaml"), restoreName, "default", 1*time.Second, cfg.Timeout)
+				Expect(err).NotTo(HaveOccurred())
+				ready, err = restorePod.WaitOnReady(retryTimeWhenWaitingForPodReady, cfg.Timeout)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ready).To(Equal(true))
+				out, err = restorePod.Exec("--", "ls", "/mnt/azure")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(out)).To(ContainSubstring("testdirectory"))
+
+				err = restorePod.Delete(util.DefaultDeleteRetries)
+				Expect(err).NotTo(HaveOccurred())
+				err = restored.Delete(util.DefaultDeleteRetries)
+				Expect(err).NotTo(HaveOccurred())
+				err = snapshot.Delete(util.DefaultDeleteRetries)
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				log.Printf("The VolumeSnapshot CRD is not installed, skipping the snapshot and restore of the azure disk csi volume\n")
+			}
+
+			By("Cleaning up after ourselves")
+			err = testPod.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			err = pvc.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		requires(capability.Linux).It("should be able to expand an azure file csi volume while it is mounted", func() {
+			if !storageclass.HasCSIDriver("file.csi.azure.com") {
+				Skip("The azure file csi driver is not installed in this cluster")
+			}
+			By("Creating an azure file csi storage class allowing volume expansion")
+			sc, err := storageclass.CreateStorageClassFromFile(filepath.Join(WorkloadDir, "storageclass-csi-azurefile.yaml"), "csi-azurefile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sc.AllowVolumeExpansion).To(BeTrue())
+
+			By("Creating a 5Gi persistent volume claim and a pod using it")
+			pvcName := "csi-azurefile" // should be the same as in pvc-csi-azurefile.yaml and pod-pvc-csi-azurefile.yaml
+			pvc, err := persistentvolumeclaims.CreatePVCFromFileDeleteIfExist(filepath.Join(WorkloadDir, "pvc-csi-azurefile.yaml"), pvcName, "default")
+			Expect(err).NotTo(HaveOccurred())
+			err = persistentvolumeclaims.WaitAllBound([]string{pvcName}, "default", 5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			testPod, err := pod.CreatePodFromFile(filepath.Join(WorkloadDir, "pod-pvc-csi-azurefile.yaml"), pvcName, "default", 1*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			ready, err := testPod.WaitOnReady(retryTimeWhenWaitingForPodReady, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready).To(Equal(true))
+
+			By("Checking that the pod can access volume")
+			valid, err := testPod.ValidatePVC("/mnt/azure", 10, 10*time.Second)
+			Expect(valid).To(BeTrue())
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Expanding the volume from 5Gi to 10Gi")
+			resizePending, err := pvc.ValidateExpansion("10Gi", 5*time.Second, cfg.Timeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resizePending).To(BeFalse())
+
+			By("Cleaning up after ourselves")
+			err = testPod.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+			err = pvc.Delete(util.DefaultDeleteRetries)
+			Expect(err).NotTo(HaveOccurred())
+		})
 	})
 
 	Describe("after the cluster has been up for awhile", func() {
@@ -1767,54 +2788,41 @@ var _ = Describe("Azure Container Cluster using the Kubernetes Orchestrator", fu
 			Expect(running).To(Equal(true))
 			restarts := pod.Status.ContainerStatuses[0].RestartCount
 			if cfg.SoakClusterName == "" {
-				err = pod.Delete(util.DefaultDeleteRetries)
-				Expect(err).NotTo(HaveOccurred())
 				Expect(restarts).To(Equal(0))
 			} else {
 				log.Printf("%d DNS livenessProbe restarts since this cluster was created...\n", restarts)
 			}
 		})
 
-		It("should have healthy time synchronization", func() {
-			if !eng.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
-				nodeList, err := node.GetReady()
-				Expect(err).NotTo(HaveOccurred())
-				timeSyncValidateScript := "time-sync-validate.sh"
-				err = sshConn.CopyTo(timeSyncValidateScript)
-				Expect(err).NotTo(HaveOccurred())
-				timeSyncValidationCommand := fmt.Sprintf("\"/tmp/%s\"", timeSyncValidateScript)
-				err = sshConn.Execute(timeSyncValidationCommand, false)
-				Expect(err).NotTo(HaveOccurred())
-				for _, node := range nodeList.Nodes {
-					if node.IsUbuntu() && !firstMasterRegexp.MatchString(node.Metadata.Name) {
-						err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+timeSyncValidateScript)
-						Expect(err).NotTo(HaveOccurred())
-						err = sshConn.ExecuteRemote(node.Metadata.Name, timeSyncValidationCommand, false)
-						Expect(err).NotTo(HaveOccurred())
-					}
-				}
-			} else {
-				Skip("Skip per-node tests in low-priority VMSS cluster configuration scenario")
-			}
-		})
-
-		It("should be able to cleanup the long running php-apache stuff", func() {
-			if cfg.SoakClusterName == "" {
-				phpApacheDeploy, err := deployment.Get(longRunningApacheDeploymentName, "default")
-				if err != nil {
-					fmt.Println(err)
+		requires(capability.Not(capability.LowPriority)).It("should have healthy time synchronization", func() {
+			nodeList, err := node.GetReady()
+			Expect(err).NotTo(HaveOccurred())
+			timeSyncValidateScript := "time-sync-validate.sh"
+			err = sshConn.CopyTo(timeSyncValidateScript)
+			Expect(err).NotTo(HaveOccurred())
+			timeSyncValidationCommand := fmt.Sprintf("/tmp/%s", timeSyncValidateScript)
+			err = sshConn.Execute(timeSyncValidationCommand, false)
+			Expect(err).NotTo(HaveOccurred())
+			for _, node := range nodeList.Nodes {
+				if node.IsUbuntu() && !firstMasterRegexp.MatchString(node.Metadata.Name) {
+					err := sshConn.CopyToRemote(node.Metadata.Name, "/tmp/"+timeSyncValidateScript)
+					Expect(err).NotTo(HaveOccurred())
+					err = sshConn.ExecuteRemote(node.Metadata.Name, timeSyncValidationCommand, false)
+					Expect(err).NotTo(HaveOccurred())
 				}
-				Expect(err).NotTo(HaveOccurred())
-				s, err := service.Get(longRunningApacheDeploymentName, "default")
-				Expect(err).NotTo(HaveOccurred())
-
-				err = s.Delete(util.DefaultDeleteRetries)
-				Expect(err).NotTo(HaveOccurred())
-				err = phpApacheDeploy.Delete(util.DefaultDeleteRetries)
-				Expect(err).NotTo(HaveOccurred())
-			} else {
-				Skip("Keep long-running php-apache workloads running for soak clusters")
 			}
 		})
 	})
 })
+
+// The validators of the plugins registered by downstream forks run as specs of their own, see package plugin
+var _ = Describe("Plugins", func() {
+	for _, p := range plugin.Registered() {
+		for _, v := range p.Validators {
+			v := v
+			requires(v.Requires...).It(fmt.Sprintf("should pass the %s validator of plugin %s", v.Name, p.Name), func() {
+				Expect(v.Validate(plugin.Current())).To(Succeed())
+			})
+		}
+	}
+})
@@ -4,25 +4,91 @@
 package networkpolicy
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"os/exec"
+	"path"
+	"strings"
+	"time"
 
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/pod"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/pkg/errors"
 )
 
-// CreateNetworkPolicyFromFile will create a NetworkPolicy from file with a name
-func CreateNetworkPolicyFromFile(filename, name, namespace string) error {
-	cmd := exec.Command("k", "create", "-f", filename)
+const (
+	// probeConnected and probeBlocked are printed by the connectivity probes, so that the outcome of a
+	// blocked connection is not dropped by kubectl exec
+	probeConnected = "PROBE_CONNECTED"
+	probeBlocked   = "PROBE_BLOCKED"
+	// probeTimeoutSeconds is how long a single probe waits for the connection to be established
+	probeTimeoutSeconds = 3
+	// consecutiveProbes is how many probes in a row must have the expected outcome, so that a connection
+	// dropped while the network policy controller programs the node is not taken for enforcement
+	consecutiveProbes = 3
+)
+
+// NetworkPolicy holds network policy metadata
+type NetworkPolicy struct {
+	Metadata Metadata `json:"metadata"`
+	Spec     Spec     `json:"spec"`
+}
+
+// Metadata holds information like name, namespace and created timestamp
+type Metadata struct {
+	CreatedAt time.Time `json:"creationTimestamp"`
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
+}
+
+// Spec holds the pod selector and policy types of a network policy
+type Spec struct {
+	PodSelector PodSelector `json:"podSelector"`
+	PolicyTypes []string    `json:"policyTypes"`
+}
+
+// PodSelector holds the labels of the pods a network policy applies to
+type PodSelector struct {
+	MatchLabels map[string]string `json:"matchLabels"`
+}
+
+// CreateFromFile will create a NetworkPolicy from file with a name
+func CreateFromFile(filename, name, namespace string) (*NetworkPolicy, error) {
+	cmd := exec.Command("k", "apply", "-f", filename, "-n", namespace)
 	util.PrintCommand(cmd)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		log.Printf("Error trying to create NetworkPolicy %s:%s\n", name, string(out))
-		return err
+		return nil, err
 	}
-	return nil
+	return Get(name, namespace)
 }
 
-// DeleteNetworkPolicy will create a NetworkPolicy from file with a name
+// Get returns the NetworkPolicy with a given name in namespace
+func Get(name, namespace string) (*NetworkPolicy, error) {
+	cmd := exec.Command("k", "get", "networkpolicy", name, "-n", namespace, "-o", "json")
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error trying to get NetworkPolicy %s in namespace %s:%s\n", name, namespace, string(out))
+		return nil, err
+	}
+	n := NetworkPolicy{}
+	err = json.Unmarshal(out, &n)
+	if err != nil {
+		log.Printf("Error unmarshalling NetworkPolicy json:%s\n", err)
+		return nil, err
+	}
+	return &n, nil
+}
+
+// Delete will delete a NetworkPolicy
+func (n *NetworkPolicy) Delete() error {
+	return DeleteNetworkPolicy(n.Metadata.Name, n.Metadata.Namespace)
+}
+
+// DeleteNetworkPolicy will delete a NetworkPolicy with a name in namespace
 func DeleteNetworkPolicy(name, namespace string) error {
 	cmd := exec.Command("k", "delete", "networkpolicy", "-n", namespace, name)
 	util.PrintCommand(cmd)
@@ -33,3 +99,140 @@ func DeleteNetworkPolicy(name, namespace string) error {
 	}
 	return nil
 }
+
+// WaitOnApplied waits until the policy is served by the API server and selects at least one pod in its namespace.
+// Network policy controllers do not report when they have programmed the nodes, ValidatePolicy retries until they have.
+func (n *NetworkPolicy) WaitOnApplied(sleep, duration time.Duration) error {
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		if _, err := Get(n.Metadata.Name, n.Metadata.Namespace); err != nil {
+			return err
+		}
+		args := []string{"get", "pods", "-n", n.Metadata.Namespace, "-o", "name"}
+		if selector := n.Spec.PodSelector.String(); selector != "" {
+			args = append(args, "-l", selector)
+		}
+		cmd := exec.Command("k", args...)
+		util.PrintCommand(cmd)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			log.Printf("Error trying to get the pods selected by NetworkPolicy %s:%s\n", n.Metadata.Name, string(out))
+			return err
+		}
+		if strings.TrimSpace(string(out)) == "" {
+			return errors.Errorf("NetworkPolicy %s in namespace %s does not select any pods", n.Metadata.Name, n.Metadata.Namespace)
+		}
+		return nil
+	})
+	util.ReportWaitFailure(util.WaitFailure{Kind: "networkpolicy", Name: n.Metadata.Name, Namespace: n.Metadata.Namespace, Err: err})
+	return err
+}
+
+// String returns the pod selector as a kubectl label selector
+func (s PodSelector) String() string {
+	var labels []string
+	for k, v := range s.MatchLabels {
+		labels = append(labels, fmt.Sprintf("%s=%s", k, v))
+	}
+	return strings.Join(labels, ",")
+}
+
+// ValidatePolicy probes a TCP connection from fromPod to port on toPod until the outcome equals shouldConnect for
+// several probes in a row, or the timeout occurs. The probe uses nc, curl or wget if fromPod has one of them, and
+// installs curl otherwise.
+func ValidatePolicy(fromPod, toPod *pod.Pod, port int, shouldConnect bool, sleep, duration time.Duration) error {
+	if toPod.Status.PodIP == "" {
+		return errors.Errorf("Pod %s has no IP address", toPod.Metadata.Name)
+	}
+	var client string
+	matched := 0
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		if client == "" {
+			var err error
+			if client, err = detectProbeClient(fromPod); err != nil {
+				return err
+			}
+			if client == "" {
+				if _, err = fromPod.Exec("--", "/bin/sh", "-c", "apt update && apt install -y curl"); err != nil {
+					return err
+				}
+				client = "curl"
+			}
+		}
+		out, err := fromPod.Exec("--", "/bin/sh", "-c", probe(client, toPod.Status.PodIP, port))
+		if err != nil {
+			return err
+		}
+		connected, err := parseProbe(string(out))
+		if err != nil {
+			return err
+		}
+		if connected != shouldConnect {
+			matched = 0
+			return errors.Errorf("Pod %s connected to %s:%d: %t, expected %t", fromPod.Metadata.Name, toPod.Status.PodIP, port, connected, shouldConnect)
+		}
+		matched++
+		if matched < consecutiveProbes {
+			return errors.Errorf("Pod %s connected to %s:%d: %t in %d of %d probes", fromPod.Metadata.Name, toPod.Status.PodIP, port, connected, matched, consecutiveProbes)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Network policy probe from pod %s to pod %s failed:%s\n", fromPod.Metadata.Name, toPod.Metadata.Name, err)
+		return err
+	}
+	log.Printf("Pod %s connected to pod %s on port %d: %t, as expected\n", fromPod.Metadata.Name, toPod.Metadata.Name, port, shouldConnect)
+	return nil
+}
+
+// ValidatePolicies runs ValidatePolicy from every pod in fromPods to every pod in toPods, returning the first failure
+func ValidatePolicies(fromPods, toPods []pod.Pod, port int, shouldConnect bool, sleep, duration time.Duration) error {
+	for i := range fromPods {
+		for j := range toPods {
+			if err := ValidatePolicy(&fromPods[i], &toPods[j], port, shouldConnect, sleep, duration); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// detectProbeClient returns the first of nc, curl and wget that is available in the pod, or an empty string if there is none
+func detectProbeClient(p *pod.Pod) (string, error) {
+	out, err := p.Exec("--", "/bin/sh", "-c", "command -v nc || command -v curl || command -v wget || true")
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if strings.TrimSpace(lines[0]) == "" {
+		return "", nil
+	}
+	return path.Base(strings.TrimSpace(lines[0])), nil
+}
+
+// probe returns the shell command that opens a TCP connection to ip:port with client, which is one of nc, curl and wget,
+// and prints probeConnected if it was established or probeBlocked if it was not.
+// curl and wget succeed on any HTTP response, and wget -S prints the response headers even for error status codes.
+func probe(client, ip string, port int) string {
+	var connect string
+	switch client {
+	case "nc":
+		connect = fmt.Sprintf("nc -z -w %d %s %d", probeTimeoutSeconds, ip, port)
+	case "wget":
+		connect = fmt.Sprintf("wget -S -T %d -O /dev/null 'http://%s:%d/' 2>&1 | grep -q 'HTTP/'", probeTimeoutSeconds, ip, port)
+	default:
+		connect = fmt.Sprintf("curl -s -o /dev/null --connect-timeout %d --max-time %d 'http://%s:%d/'", probeTimeoutSeconds, 2*probeTimeoutSeconds, ip, port)
+	}
+	return fmt.Sprintf("if %s; then echo %s; else echo %s; fi", connect, probeConnected, probeBlocked)
+}
+
+// parseProbe returns whether the probe printed probeConnected, or an error if it printed neither outcome
+func parseProbe(out string) (bool, error) {
+	switch {
+	case strings.Contains(out, probeConnected):
+		return true, nil
+	case strings.Contains(out, probeBlocked):
+		return false, nil
+	default:
+		return false, errors.Errorf("unexpected network policy probe output: %s", out)
+	}
+}
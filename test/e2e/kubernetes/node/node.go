@@ -6,6 +6,7 @@ package node
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os/exec"
 	"regexp"
@@ -19,6 +20,10 @@ import (
 const (
 	//ServerVersion is used to parse out the version of the API running
 	ServerVersion = `(Server Version:\s)+(.*)`
+	cordonTimeout = 1 * time.Minute
+	// ReservedByAnnotation is set on a node by the spec that has exclusive use of it, e.g. to taint or cordon it,
+	// so that the specs running in parallel leave the node alone
+	ReservedByAnnotation = "e2e.aks-engine.io/reserved-by"
 )
 
 // Node represents the kubernetes Node Resource
@@ -30,15 +35,19 @@ type Node struct {
 
 // Metadata contains things like name and created at
 type Metadata struct {
-	Name        string            `json:"name"`
-	CreatedAt   time.Time         `json:"creationTimestamp"`
-	Labels      map[string]string `json:"labels"`
-	Annotations map[string]string `json:"annotations"`
+	Name            string            `json:"name"`
+	CreatedAt       time.Time         `json:"creationTimestamp"`
+	Labels          map[string]string `json:"labels"`
+	Annotations     map[string]string `json:"annotations"`
+	ResourceVersion string            `json:"resourceVersion"`
+	// DeletionTimestamp is set once the node is being deleted
+	DeletionTimestamp *time.Time `json:"deletionTimestamp"`
 }
 
 // Spec contains things like taints
 type Spec struct {
-	Taints []Taint `json:"taints"`
+	Taints        []Taint `json:"taints"`
+	Unschedulable bool    `json:"unschedulable"`
 }
 
 // Taint defines a Node Taint
@@ -53,6 +62,30 @@ type Status struct {
 	NodeInfo      Info        `json:"nodeInfo"`
 	NodeAddresses []Address   `json:"addresses"`
 	Conditions    []Condition `json:"conditions"`
+	Images        []Image     `json:"images"`
+	Allocatable   Resources   `json:"allocatable"`
+}
+
+// Resources are the quantities of the resources of a node, like cpu and memory
+type Resources struct {
+	CPU    string `json:"cpu"`
+	Memory string `json:"memory"`
+}
+
+// Image is a container image present on a node, as reported by the kubelet
+type Image struct {
+	Names     []string `json:"names"`
+	SizeBytes int64    `json:"sizeBytes"`
+}
+
+// ImageReport compares the container images present on a node with the ones it is expected to have pre-pulled
+type ImageReport struct {
+	Missing []string
+	Extra   []string
+	// ExpectedSizeBytes is the size of the expected images present on the node
+	ExpectedSizeBytes int64
+	// TotalSizeBytes is the size of all images present on the node
+	TotalSizeBytes int64
 }
 
 // Address contains an address and a type
@@ -113,6 +146,42 @@ func (n *Node) IsUbuntu() bool {
 	return false
 }
 
+// GetImageReport reports which of the expected images are missing from the node, which images on the node were
+// not expected, and the size of the images on the node. Digest references reported by the kubelet are ignored.
+func (n *Node) GetImageReport(expected []string) ImageReport {
+	report := ImageReport{}
+	present := make(map[string]bool)
+	for _, image := range n.Status.Images {
+		report.TotalSizeBytes += image.SizeBytes
+		var tags []string
+		for _, name := range image.Names {
+			if !strings.Contains(name, "@") {
+				tags = append(tags, name)
+				present[name] = true
+			}
+		}
+		isExpected := false
+		for _, e := range expected {
+			for _, tag := range tags {
+				if tag == e {
+					isExpected = true
+				}
+			}
+		}
+		if isExpected {
+			report.ExpectedSizeBytes += image.SizeBytes
+		} else if len(tags) > 0 {
+			report.Extra = append(report.Extra, strings.Join(tags, ","))
+		}
+	}
+	for _, e := range expected {
+		if !present[e] {
+			report.Missing = append(report.Missing, e)
+		}
+	}
+	return report
+}
+
 // HasSubstring determines if a node name matches includes the passed in substring
 func (n *Node) HasSubstring(substrings []string) bool {
 	for _, substring := range substrings {
@@ -155,39 +224,61 @@ func AreNNodesReady(nodeCount int) bool {
 	return false
 }
 
-// WaitOnReady will block until all nodes are in ready state
+// WaitOnReady will block until all nodes are in ready state, or until exactly nodeCount nodes are if nodeCount is not -1.
+// The nodes are watched so that it returns as soon as the last one becomes ready, sleep is how long to wait before starting the watch again if it stops.
 func WaitOnReady(nodeCount int, sleep, duration time.Duration) bool {
-	readyCh := make(chan bool, 1)
-	errCh := make(chan error)
 	ctx, cancel := context.WithTimeout(context.Background(), duration)
 	defer cancel()
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				errCh <- errors.Errorf("Timeout exceeded (%s) while waiting for Nodes to become ready", duration.String())
-			default:
-				if nodeCount == -1 {
-					if AreAllReady() {
-						readyCh <- true
-					}
-				} else {
-					if AreNNodesReady(nodeCount) {
-						readyCh <- true
-					}
-				}
-				time.Sleep(sleep)
-			}
+	nodes := map[string]Node{}
+	list := func() {
+		l, err := Get()
+		if err != nil {
+			return
+		}
+		nodes = map[string]Node{}
+		for _, n := range l.Nodes {
+			nodes[n.Metadata.Name] = n
+		}
+	}
+	list()
+	events := util.Watch(ctx, sleep, "nodes")
+	for !areReady(nodes, nodeCount) {
+		e, ok := <-events
+		if !ok {
+			err := errors.Errorf("Timeout exceeded (%s) while waiting for Nodes to become ready", duration.String())
+			util.ReportWaitFailure(util.WaitFailure{Kind: "node", Err: err})
+			return false
+		}
+		if e.Restarted {
+			// changes may have been missed while kubectl was not running
+			list()
+			continue
+		}
+		var n Node
+		if err := json.Unmarshal(e.Object, &n); err != nil {
+			log.Printf("Error unmarshalling node json:%s\n", err)
+			continue
 		}
-	}()
-	for {
-		select {
-		case <-errCh:
+		if n.Metadata.DeletionTimestamp != nil {
+			delete(nodes, n.Metadata.Name)
+		} else {
+			nodes[n.Metadata.Name] = n
+		}
+	}
+	return true
+}
+
+// areReady returns true if there are nodes and all of them are ready, and if there are exactly nodeCount of them unless it is -1
+func areReady(nodes map[string]Node, nodeCount int) bool {
+	if len(nodes) == 0 || (nodeCount != -1 && len(nodes) != nodeCount) {
+		return false
+	}
+	for _, n := range nodes {
+		if !n.IsReady() {
 			return false
-		case ready := <-readyCh:
-			return ready
 		}
 	}
+	return true
 }
 
 // Get returns the current nodes for a given kubeconfig
@@ -328,3 +419,190 @@ func GetByTaint(key, value, effect string) ([]Node, error) {
 	}
 	return nodes, nil
 }
+
+// String returns the taint in the key=value:effect format of kubectl taint and the --register-with-taints kubelet flag
+func (t Taint) String() string {
+	if t.Value == "" {
+		return fmt.Sprintf("%s:%s", t.Key, t.Effect)
+	}
+	return fmt.Sprintf("%s=%s:%s", t.Key, t.Value, t.Effect)
+}
+
+// ParseTaints parses a comma separated list of taints in the key=value:effect format of the --register-with-taints kubelet flag
+func ParseTaints(s string) ([]Taint, error) {
+	var taints []Taint
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		i := strings.LastIndex(item, ":")
+		if i < 1 || i == len(item)-1 {
+			return nil, errors.Errorf("invalid taint %q, expected key=value:effect", item)
+		}
+		t := Taint{Key: item[:i], Effect: item[i+1:]}
+		if kv := strings.SplitN(t.Key, "=", 2); len(kv) == 2 {
+			t.Key, t.Value = kv[0], kv[1]
+		}
+		taints = append(taints, t)
+	}
+	return taints, nil
+}
+
+// HasTaint returns true if the node has a taint with the same key, value and effect
+func (n *Node) HasTaint(t Taint) bool {
+	for _, taint := range n.Spec.Taints {
+		if taint == t {
+			return true
+		}
+	}
+	return false
+}
+
+// GetByName will return the node with a given name
+func GetByName(name string) (*Node, error) {
+	cmd := exec.Command("k", "get", "node", name, "-o", "json")
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error trying to get node %s:%s\n", name, string(out))
+		return nil, err
+	}
+	n := Node{}
+	err = json.Unmarshal(out, &n)
+	if err != nil {
+		log.Printf("Error unmarshalling node json:%s\n", err)
+		return nil, err
+	}
+	return &n, nil
+}
+
+// Cordon marks a node as unschedulable
+func Cordon(name string) error {
+	return runWithRetries(fmt.Sprintf("cordon node %s", name), cordonTimeout, "cordon", name)
+}
+
+// Uncordon marks a node as schedulable
+func Uncordon(name string) error {
+	return runWithRetries(fmt.Sprintf("uncordon node %s", name), cordonTimeout, "uncordon", name)
+}
+
+// Drain evicts all pods not managed by a DaemonSet from a node, leaving it cordoned
+func Drain(name string, timeout time.Duration) error {
+	return runWithRetries(fmt.Sprintf("drain node %s", name), timeout, "drain", name, "--ignore-daemonsets", "--delete-local-data", "--force", fmt.Sprintf("--timeout=%s", timeout))
+}
+
+// AddTaint adds a taint to a node, replacing the value of a taint with the same key and effect
+func AddTaint(name string, t Taint) error {
+	return runWithRetries(fmt.Sprintf("add taint %s to node %s", t, name), cordonTimeout, "taint", "nodes", name, t.String(), "--overwrite")
+}
+
+// RemoveTaint removes the taints with the key and effect of t from a node, it is not an error if the node does not have them
+func RemoveTaint(name string, t Taint) error {
+	n, err := GetByName(name)
+	if err != nil {
+		return err
+	}
+	var found bool
+	for _, taint := range n.Spec.Taints {
+		if taint.Key == t.Key && taint.Effect == t.Effect {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+	return runWithRetries(fmt.Sprintf("remove taint %s from node %s", t, name), cordonTimeout, "taint", "nodes", name, fmt.Sprintf("%s:%s-", t.Key, t.Effect))
+}
+
+// Label sets labels on a node, replacing the values of existing labels
+func Label(name string, labels map[string]string) error {
+	args := []string{"label", "nodes", name, "--overwrite"}
+	for k, v := range labels {
+		args = append(args, fmt.Sprintf("%s=%s", k, v))
+	}
+	return runWithRetries(fmt.Sprintf("label node %s", name), cordonTimeout, args...)
+}
+
+// RemoveLabels removes labels from a node, it is not an error if the node does not have them
+func RemoveLabels(name string, keys ...string) error {
+	args := []string{"label", "nodes", name}
+	for _, k := range keys {
+		args = append(args, fmt.Sprintf("%s-", k))
+	}
+	return runWithRetries(fmt.Sprintf("remove labels from node %s", name), cordonTimeout, args...)
+}
+
+// IsReserved returns true if a spec has exclusive use of the node
+func (n *Node) IsReserved() bool {
+	_, ok := n.Metadata.Annotations[ReservedByAnnotation]
+	return ok
+}
+
+// Reserve gives owner exclusive use of a node until Release, it fails if the node is reserved by another owner.
+// The annotation is written with the resource version of the node it was found unreserved in, two owners cannot both reserve it.
+func Reserve(name, owner string) error {
+	err := util.DefaultRetrier().DoWithTimeout(cordonTimeout, func() error {
+		n, err := GetByName(name)
+		if err != nil {
+			return err
+		}
+		if reservedBy, ok := n.Metadata.Annotations[ReservedByAnnotation]; ok {
+			if reservedBy == owner {
+				return nil
+			}
+			return util.Permanent(errors.Errorf("node %s is reserved by %s", name, reservedBy))
+		}
+		// a conflict means the node changed since it was read, it is read again
+		cmd := exec.Command("k", "annotate", "node", name, fmt.Sprintf("%s=%s", ReservedByAnnotation, owner), fmt.Sprintf("--resource-version=%s", n.Metadata.ResourceVersion))
+		out, err := util.RunAndLogCommand(cmd, cordonTimeout)
+		if err != nil {
+			log.Printf("Error trying to reserve node %s:%s\n", name, string(out))
+			return err
+		}
+		return nil
+	})
+	return errors.Wrapf(err, "trying to reserve node %s for %s", name, owner)
+}
+
+// ReserveAny reserves the first node of nodes that is not reserved yet for owner, and returns its name
+func ReserveAny(nodes []Node, owner string) (string, error) {
+	for _, n := range nodes {
+		if n.IsReserved() {
+			continue
+		}
+		if err := Reserve(n.Metadata.Name, owner); err != nil {
+			log.Printf("Error trying to reserve node %s:%s\n", n.Metadata.Name, err)
+			continue
+		}
+		return n.Metadata.Name, nil
+	}
+	return "", errors.Errorf("none of the %d nodes could be reserved for %s", len(nodes), owner)
+}
+
+// Release ends the exclusive use of a node by owner, it is not an error if owner does not have it
+func Release(name, owner string) error {
+	n, err := GetByName(name)
+	if err != nil {
+		return err
+	}
+	if n.Metadata.Annotations[ReservedByAnnotation] != owner {
+		return nil
+	}
+	return runWithRetries(fmt.Sprintf("release node %s", name), cordonTimeout, "annotate", "node", name, fmt.Sprintf("%s-", ReservedByAnnotation))
+}
+
+// runWithRetries runs kubectl with args until it succeeds, retrying with the default retry policy for up to timeout
+func runWithRetries(operation string, timeout time.Duration, args ...string) error {
+	err := util.DefaultRetrier().DoWithTimeout(timeout, func() error {
+		cmd := exec.Command("k", args...)
+		out, err := util.RunAndLogCommand(cmd, timeout)
+		if err != nil {
+			log.Printf("Error trying to %s:%s\n", operation, string(out))
+			return err
+		}
+		return nil
+	})
+	return errors.Wrapf(err, "trying to %s", operation)
+}
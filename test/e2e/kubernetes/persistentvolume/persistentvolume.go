@@ -32,6 +32,26 @@ type Metadata struct {
 type Spec struct {
 	StorageClassName string       `json:"storageClassName"`
 	NodeAffinity     NodeAffinity `json:"nodeAffinity"`
+	Capacity         Capacity     `json:"capacity"`
+	CSI              *CSI         `json:"csi"`
+	ClaimRef         *ClaimRef    `json:"claimRef"`
+}
+
+// Capacity holds the size of the volume
+type Capacity struct {
+	Storage string `json:"storage"`
+}
+
+// CSI holds the driver and the handle of a volume provisioned by a CSI driver
+type CSI struct {
+	Driver       string `json:"driver"`
+	VolumeHandle string `json:"volumeHandle"`
+}
+
+// ClaimRef holds the name and namespace of the PersistentVolumeClaim bound to the volume
+type ClaimRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
 }
 
 // NodeAffinity holds information like required nodeselector
@@ -84,6 +104,24 @@ func Get() (*List, error) {
 	return &pvl, nil
 }
 
+// GetByName will return the pv with a given name
+func GetByName(name string) (*PersistentVolume, error) {
+	cmd := exec.Command("k", "get", "pv", name, "-o", "json")
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error trying to run 'kubectl get pv %s':%s", name, string(out))
+		return nil, err
+	}
+	pv := PersistentVolume{}
+	err = json.Unmarshal(out, &pv)
+	if err != nil {
+		log.Printf("Error unmarshalling pv json:%s", err)
+		return nil, err
+	}
+	return &pv, nil
+}
+
 // WaitOnReady will block until all pvs are in ready state
 func WaitOnReady(pvCount int, sleep, duration time.Duration) bool {
 	readyCh := make(chan bool, 1)
@@ -105,7 +143,8 @@ func WaitOnReady(pvCount int, sleep, duration time.Duration) bool {
 	}()
 	for {
 		select {
-		case <-errCh:
+		case err := <-errCh:
+			util.ReportWaitFailure(util.WaitFailure{Kind: "pv", Err: err})
 			return false
 		case ready := <-readyCh:
 			return ready
@@ -10,10 +10,13 @@ import (
 	"log"
 	"os/exec"
 	"regexp"
+	"strings"
 	"time"
 
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/persistentvolume"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 const commandTimeout = 1 * time.Minute
@@ -38,13 +41,40 @@ type Metadata struct {
 
 // Spec holds information like storageClassName, volumeName
 type Spec struct {
-	StorageClassName string `json:"storageClassName"`
-	VolumeName       string `json:"volumeName"`
+	StorageClassName string      `json:"storageClassName"`
+	VolumeName       string      `json:"volumeName"`
+	Resources        Resources   `json:"resources"`
+	DataSource       *DataSource `json:"dataSource"`
+}
+
+// Resources holds the storage requested by the claim
+type Resources struct {
+	Requests Storage `json:"requests"`
+}
+
+// Storage holds a storage size
+type Storage struct {
+	Storage string `json:"storage"`
+}
+
+// DataSource is the object a claim is populated from, e.g. a VolumeSnapshot
+type DataSource struct {
+	APIGroup string `json:"apiGroup"`
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
 }
 
 // Status holds information like phase
 type Status struct {
-	Phase string `json:"phase"`
+	Phase      string      `json:"phase"`
+	Capacity   Storage     `json:"capacity"`
+	Conditions []Condition `json:"conditions"`
+}
+
+// Condition holds a condition of the claim, e.g. FileSystemResizePending
+type Condition struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
 }
 
 // CreatePersistentVolumeClaimsFromFile will create a PVC from file with a name
@@ -189,6 +219,7 @@ func (pvc *PersistentVolumeClaim) WaitOnReady(namespace string, sleep, duration
 	for {
 		select {
 		case err := <-errCh:
+			util.ReportWaitFailure(util.WaitFailure{Kind: "pvc", Name: pvc.Metadata.Name, Namespace: namespace, Err: err})
 			return false, err
 		case ready := <-readyCh:
 			return ready, nil
@@ -196,6 +227,118 @@ func (pvc *PersistentVolumeClaim) WaitOnReady(namespace string, sleep, duration
 	}
 }
 
+// WaitAllBound waits until all PersistentVolumeClaims with a given name in a namespace are bound to a volume
+func WaitAllBound(names []string, namespace string, sleep, duration time.Duration) error {
+	return util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		var unbound []string
+		for _, name := range names {
+			pvc, err := Get(name, namespace)
+			if err != nil {
+				return err
+			}
+			if pvc.Status.Phase != "Bound" {
+				unbound = append(unbound, fmt.Sprintf("%s is %s", name, pvc.Status.Phase))
+			}
+		}
+		if len(unbound) > 0 {
+			return errors.Errorf("PersistentVolumeClaims in namespace %s are not bound: %s", namespace, strings.Join(unbound, ", "))
+		}
+		return nil
+	})
+}
+
+// PersistentVolume returns the volume the PersistentVolumeClaim is bound to
+func (pvc *PersistentVolumeClaim) PersistentVolume() (*persistentvolume.PersistentVolume, error) {
+	if pvc.Spec.VolumeName == "" {
+		return nil, errors.Errorf("PersistentVolumeClaim %s in namespace %s is not bound to a volume", pvc.Metadata.Name, pvc.Metadata.Namespace)
+	}
+	return persistentvolume.GetByName(pvc.Spec.VolumeName)
+}
+
+// Resize requests a new storage size for the PersistentVolumeClaim, its storage class must allow volume expansion
+func (pvc *PersistentVolumeClaim) Resize(size string) error {
+	patch := fmt.Sprintf(`{"spec":{"resources":{"requests":{"storage":"%s"}}}}`, size)
+	cmd := exec.Command("k", "patch", "pvc", pvc.Metadata.Name, "-n", pvc.Metadata.Namespace, "-p", patch)
+	out, err := util.RunAndLogCommand(cmd, commandTimeout)
+	if err != nil {
+		log.Printf("Error trying to resize PersistentVolumeClaim %s in namespace %s to %s:%s\n", pvc.Metadata.Name, pvc.Metadata.Namespace, size, string(out))
+		return err
+	}
+	return nil
+}
+
+// IsFileSystemResizePending returns true if the volume of the PersistentVolumeClaim was expanded,
+// and its file system is resized the next time a pod mounts it
+func (pvc *PersistentVolumeClaim) IsFileSystemResizePending() bool {
+	for _, c := range pvc.Status.Conditions {
+		if c.Type == "FileSystemResizePending" && c.Status == "True" {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateExpansion resizes the PersistentVolumeClaim and waits until its volume has at least size.
+// It returns true if the file system of the volume is resized on the next mount, e.g. for azure disks that
+// are expanded detached, and false once the file system was resized and the claim has the new capacity.
+func (pvc *PersistentVolumeClaim) ValidateExpansion(size string, sleep, duration time.Duration) (bool, error) {
+	expected, err := resource.ParseQuantity(size)
+	if err != nil {
+		return false, errors.Wrapf(err, "parsing the size %s", size)
+	}
+	if err = pvc.Resize(size); err != nil {
+		return false, err
+	}
+	var resizePending bool
+	err = util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		query, err := Get(pvc.Metadata.Name, pvc.Metadata.Namespace)
+		if err != nil {
+			return err
+		}
+		pv, err := query.PersistentVolume()
+		if err != nil {
+			return err
+		}
+		if !hasAtLeast(pv.Spec.Capacity.Storage, expected) {
+			return errors.Errorf("PersistentVolume %s has %s", pv.Metadata.Name, pv.Spec.Capacity.Storage)
+		}
+		resizePending = query.IsFileSystemResizePending()
+		if !resizePending && !hasAtLeast(query.Status.Capacity.Storage, expected) {
+			return errors.Errorf("PersistentVolumeClaim %s has %s", query.Metadata.Name, query.Status.Capacity.Storage)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "waiting for PersistentVolumeClaim %s to expand to %s", pvc.Metadata.Name, size)
+	}
+	return resizePending, nil
+}
+
+// WaitOnCapacity waits until the PersistentVolumeClaim has at least size, e.g. once the file system of an expanded volume was resized
+func (pvc *PersistentVolumeClaim) WaitOnCapacity(size string, sleep, duration time.Duration) error {
+	expected, err := resource.ParseQuantity(size)
+	if err != nil {
+		return errors.Wrapf(err, "parsing the size %s", size)
+	}
+	err = util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		query, err := Get(pvc.Metadata.Name, pvc.Metadata.Namespace)
+		if err != nil {
+			return err
+		}
+		if !hasAtLeast(query.Status.Capacity.Storage, expected) {
+			return errors.Errorf("PersistentVolumeClaim %s has %s, expected %s", query.Metadata.Name, query.Status.Capacity.Storage, size)
+		}
+		return nil
+	})
+	util.ReportWaitFailure(util.WaitFailure{Kind: "pvc", Name: pvc.Metadata.Name, Namespace: pvc.Metadata.Namespace, Err: err})
+	return err
+}
+
+func hasAtLeast(capacity string, expected resource.Quantity) bool {
+	q, err := resource.ParseQuantity(capacity)
+	return err == nil && q.Cmp(expected) >= 0
+}
+
 // WaitOnDeleted returns when a pvc is successfully deleted
 func WaitOnDeleted(pvcPrefix, namespace string, sleep, duration time.Duration) (bool, error) {
 	succeededCh := make(chan bool, 1)
@@ -222,6 +365,7 @@ func WaitOnDeleted(pvcPrefix, namespace string, sleep, duration time.Duration) (
 	for {
 		select {
 		case err := <-errCh:
+			util.ReportWaitFailure(util.WaitFailure{Kind: "pvc", Name: pvcPrefix, Namespace: namespace, Err: err})
 			return false, err
 		case deleted := <-succeededCh:
 			return deleted, nil
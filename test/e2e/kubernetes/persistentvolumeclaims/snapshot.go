@@ -0,0 +1,126 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package persistentvolumeclaims
+
+import (
+	"encoding/json"
+	"log"
+	"os/exec"
+	"time"
+
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/pkg/errors"
+)
+
+// volumeSnapshotCRD is installed with the CSI external-snapshotter, VolumeSnapshots cannot be taken without it
+const volumeSnapshotCRD = "volumesnapshots.snapshot.storage.k8s.io"
+
+// VolumeSnapshot is used to parse data from kubectl get volumesnapshot
+type VolumeSnapshot struct {
+	Metadata Metadata             `json:"metadata"`
+	Spec     VolumeSnapshotSpec   `json:"spec"`
+	Status   VolumeSnapshotStatus `json:"status"`
+}
+
+// VolumeSnapshotSpec holds information like the snapshot class and the source claim
+type VolumeSnapshotSpec struct {
+	VolumeSnapshotClassName string               `json:"volumeSnapshotClassName"`
+	Source                  VolumeSnapshotSource `json:"source"`
+}
+
+// VolumeSnapshotSource holds the name of the claim the snapshot is taken of
+type VolumeSnapshotSource struct {
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName"`
+}
+
+// VolumeSnapshotStatus holds information like readyToUse and restoreSize
+type VolumeSnapshotStatus struct {
+	ReadyToUse  bool                 `json:"readyToUse"`
+	RestoreSize string               `json:"restoreSize"`
+	Error       *VolumeSnapshotError `json:"error"`
+}
+
+// VolumeSnapshotError holds the last error taking the snapshot
+type VolumeSnapshotError struct {
+	Message string `json:"message"`
+}
+
+// AreSnapshotsSupported returns true if the VolumeSnapshot CRD is installed in the cluster
+func AreSnapshotsSupported() bool {
+	cmd := exec.Command("k", "get", "crd", volumeSnapshotCRD)
+	util.PrintCommand(cmd)
+	_, err := cmd.CombinedOutput()
+	return err == nil
+}
+
+// CreateSnapshotFromFile will create a VolumeSnapshot from file with a name
+func CreateSnapshotFromFile(filename, name, namespace string) (*VolumeSnapshot, error) {
+	cmd := exec.Command("k", "apply", "-f", filename)
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error trying to create VolumeSnapshot %s in namespace %s:%s\n", name, namespace, string(out))
+		return nil, err
+	}
+	s, err := GetSnapshot(name, namespace)
+	if err != nil {
+		log.Printf("Error while trying to fetch VolumeSnapshot %s in namespace %s:%s\n", name, namespace, err)
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetSnapshot will return a VolumeSnapshot with a given name and namespace
+func GetSnapshot(name, namespace string) (*VolumeSnapshot, error) {
+	cmd := exec.Command("k", "get", "volumesnapshot", name, "-n", namespace, "-o", "json")
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, err
+	}
+	s := VolumeSnapshot{}
+	err = json.Unmarshal(out, &s)
+	if err != nil {
+		log.Printf("Error unmarshalling VolumeSnapshot json:%s\n", err)
+		return nil, err
+	}
+	return &s, nil
+}
+
+// WaitOnReady will block until the VolumeSnapshot can be restored
+func (s *VolumeSnapshot) WaitOnReady(sleep, duration time.Duration) error {
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		query, err := GetSnapshot(s.Metadata.Name, s.Metadata.Namespace)
+		if err != nil {
+			return err
+		}
+		if query.Status.Error != nil {
+			log.Printf("VolumeSnapshot %s in namespace %s has an error:%s\n", s.Metadata.Name, s.Metadata.Namespace, query.Status.Error.Message)
+		}
+		if !query.Status.ReadyToUse {
+			return errors.Errorf("VolumeSnapshot %s in namespace %s is not ready to use", s.Metadata.Name, s.Metadata.Namespace)
+		}
+		*s = *query
+		return nil
+	})
+	util.ReportWaitFailure(util.WaitFailure{Kind: "volumesnapshot", Name: s.Metadata.Name, Namespace: s.Metadata.Namespace, Err: err})
+	return err
+}
+
+// Delete will delete a VolumeSnapshot in a given namespace
+func (s *VolumeSnapshot) Delete(retries int) error {
+	var kubectlOutput []byte
+	var kubectlError error
+	for i := 0; i < retries; i++ {
+		cmd := exec.Command("k", "delete", "volumesnapshot", "-n", s.Metadata.Namespace, s.Metadata.Name)
+		kubectlOutput, kubectlError = util.RunAndLogCommand(cmd, commandTimeout)
+		if kubectlError != nil {
+			log.Printf("Error while trying to delete VolumeSnapshot %s in namespace %s:%s\n", s.Metadata.Name, s.Metadata.Namespace, string(kubectlOutput))
+			continue
+		}
+		break
+	}
+
+	return kubectlError
+}
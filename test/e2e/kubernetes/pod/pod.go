@@ -5,22 +5,30 @@ package pod
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
-	"math/rand"
 	"os"
 	"os/exec"
 	"path"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"text/template"
 	"time"
 
 	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/node"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/Azure/aks-engine/test/e2e/remote"
 	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -28,8 +36,94 @@ const (
 	commandTimeout          = 1 * time.Minute
 	deleteTimeout           = 5 * time.Minute
 	podLookupRetries        = 5
+	// httpClientDetectAttempts is how many times detecting the HTTP client of a pod is attempted before assuming it has no shell
+	httpClientDetectAttempts = 3
+	// connectivityTestImage is used to check outbound connectivity from a node when the pod under test has no HTTP client
+	connectivityTestImage = "busybox"
 )
 
+// WindowsShell is a shell used to run commands in a Windows container
+type WindowsShell string
+
+const (
+	// WindowsShellAuto picks the shell from the container image, see GetWindowsShell
+	WindowsShellAuto WindowsShell = ""
+	// WindowsShellCmd runs commands with cmd /c
+	WindowsShellCmd WindowsShell = "cmd"
+	// WindowsShellPowershell runs commands with Windows PowerShell
+	WindowsShellPowershell WindowsShell = "powershell"
+	// WindowsShellPwsh runs commands with PowerShell Core
+	WindowsShellPwsh WindowsShell = "pwsh"
+)
+
+// OutboundConnectionConfig describes the requests that check the outbound internet connection of pods
+type OutboundConnectionConfig struct {
+	// URLs are requested in order until one of them responds with an expected status code
+	URLs []string
+	// StatusCodes are the HTTP status codes that count as a connection, after following redirects. Any response counts if empty
+	StatusCodes []int
+	// HTTPS requests URLs that have no scheme over HTTPS instead of HTTP
+	HTTPS bool
+	// Proxy is the HTTP(S) proxy the requests are sent through, if set
+	Proxy string
+}
+
+var (
+	outboundConnectionConfig = OutboundConnectionConfig{
+		URLs: []string{"www.bing.com", "google.com"},
+	}
+	outboundConnectionConfigLock sync.RWMutex
+	statusCodePattern            = regexp.MustCompile(`\b[1-5]\d\d\b`)
+)
+
+// GetOutboundConnectionConfig returns the package-wide configuration of outbound internet connection checks
+func GetOutboundConnectionConfig() OutboundConnectionConfig {
+	outboundConnectionConfigLock.RLock()
+	defer outboundConnectionConfigLock.RUnlock()
+	return outboundConnectionConfig
+}
+
+// SetOutboundConnectionConfig replaces the package-wide configuration of outbound internet connection checks
+func SetOutboundConnectionConfig(c OutboundConnectionConfig) {
+	outboundConnectionConfigLock.Lock()
+	defer outboundConnectionConfigLock.Unlock()
+	outboundConnectionConfig = c
+}
+
+// GetURLs returns c.URLs with the scheme they are requested with
+func (c OutboundConnectionConfig) GetURLs() []string {
+	scheme := "http://"
+	if c.HTTPS {
+		scheme = "https://"
+	}
+	var urls []string
+	for _, url := range c.URLs {
+		if !strings.Contains(url, "://") {
+			url = scheme + url
+		}
+		urls = append(urls, url)
+	}
+	return urls
+}
+
+// IsExpectedResponse returns true if out, the output of a request command, ends with one of the expected status codes
+func (c OutboundConnectionConfig) IsExpectedResponse(out string) bool {
+	codes := statusCodePattern.FindAllString(out, -1)
+	if len(codes) == 0 {
+		return false
+	}
+	if len(c.StatusCodes) == 0 {
+		return true
+	}
+	code, _ := strconv.Atoi(codes[len(codes)-1])
+	for _, expected := range c.StatusCodes {
+		if code == expected {
+			return true
+		}
+	}
+	return false
+}
+
 // List is a container that holds all pods returned from doing a kubectl get pods
 type List struct {
 	Pods []Pod `json:"items"`
@@ -48,6 +142,8 @@ type Metadata struct {
 	Labels    map[string]string `json:"labels"`
 	Name      string            `json:"name"`
 	Namespace string            `json:"namespace"`
+	// DeletionTimestamp is set once the pod is being deleted
+	DeletionTimestamp *time.Time `json:"deletionTimestamp"`
 }
 
 // Spec holds information like containers
@@ -129,6 +225,14 @@ type Status struct {
 	PodIP             string            `json:"podIP"`
 	StartTime         time.Time         `json:"startTime"`
 	ContainerStatuses []ContainerStatus `json:"containerStatuses"`
+	Conditions        []Condition       `json:"conditions"`
+}
+
+// Condition holds a condition of a pod, like Ready, and when it last changed
+type Condition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
 }
 
 // ReplaceContainerImageFromFile loads in a YAML, finds the image: line, and replaces it with the value of containerImage
@@ -186,6 +290,26 @@ func CreatePodFromFileIfNotExist(filename, name, namespace string, sleep, durati
 	return p, nil
 }
 
+// CreatePodFromTemplate will create a Pod from a file executed as a template with data, the file can have other
+// objects the pod needs
+func CreatePodFromTemplate(filename, name, namespace string, data interface{}, sleep, duration time.Duration) (*Pod, error) {
+	t, err := template.ParseFiles(filename)
+	if err != nil {
+		return nil, err
+	}
+	tempfile, err := ioutil.TempFile("", "*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	// the executed template can have secrets
+	defer os.Remove(tempfile.Name())
+	defer tempfile.Close()
+	if err = t.Execute(tempfile, data); err != nil {
+		return nil, err
+	}
+	return CreatePodFromFile(tempfile.Name(), name, namespace, sleep, duration)
+}
+
 // RunLinuxPod will create a pod that runs a bash command
 // --overrides := `"spec": {"nodeSelector":{"beta.kubernetes.io/os":"windows"}}}`
 func RunLinuxPod(image, name, namespace, command string, printOutput bool, sleep, duration, timeout time.Duration) (*Pod, error) {
@@ -210,11 +334,20 @@ func RunLinuxPod(image, name, namespace, command string, printOutput bool, sleep
 	return p, nil
 }
 
-// RunWindowsPod will create a pod that runs a powershell command
+// RunWindowsPod will create a pod that runs a command in the shell detected from the image
 // --overrides := `"spec": {"nodeSelector":{"beta.kubernetes.io/os":"windows"}}}`
 func RunWindowsPod(image, name, namespace, command string, printOutput bool, sleep, duration time.Duration, timeout time.Duration) (*Pod, error) {
+	return RunWindowsPodWithShell(image, name, namespace, command, WindowsShellAuto, printOutput, sleep, duration, timeout)
+}
+
+// RunWindowsPodWithShell will create a pod that runs a command in the given shell
+func RunWindowsPodWithShell(image, name, namespace, command string, shell WindowsShell, printOutput bool, sleep, duration time.Duration, timeout time.Duration) (*Pod, error) {
+	if shell == WindowsShellAuto {
+		shell = GetWindowsShell(image)
+	}
 	overrides := `{ "spec": {"nodeSelector":{"beta.kubernetes.io/os":"windows"}}}`
-	cmd := exec.Command("k", "run", name, "-n", namespace, "--image", image, "--image-pull-policy=IfNotPresent", "--restart=Never", "--overrides", overrides, "--command", "--", "powershell", command)
+	args := append([]string{"run", name, "-n", namespace, "--image", image, "--image-pull-policy=IfNotPresent", "--restart=Never", "--overrides", overrides, "--command", "--"}, shell.Command(command)...)
+	cmd := exec.Command("k", args...)
 	var out []byte
 	var err error
 	if printOutput {
@@ -234,6 +367,40 @@ func RunWindowsPod(image, name, namespace, command string, printOutput bool, sle
 	return p, nil
 }
 
+// GetWindowsShell returns the shell to run commands with in a Windows container image:
+// pwsh for PowerShell Core images, cmd for nanoserver images, which do not ship Windows PowerShell, and powershell otherwise
+func GetWindowsShell(image string) WindowsShell {
+	image = strings.ToLower(image)
+	switch {
+	case strings.Contains(image, "powershell") || strings.Contains(image, "pwsh"):
+		return WindowsShellPwsh
+	case strings.Contains(image, "nanoserver"):
+		return WindowsShellCmd
+	default:
+		return WindowsShellPowershell
+	}
+}
+
+// Command returns the container command line that runs command in the shell
+func (s WindowsShell) Command(command string) []string {
+	switch s {
+	case WindowsShellCmd:
+		return []string{"cmd", "/c", command}
+	case WindowsShellPwsh:
+		return []string{"pwsh", "-Command", command}
+	default:
+		return []string{"powershell", command}
+	}
+}
+
+// windowsShell returns the shell to exec commands with in the pod, based on the image of its first container
+func (p *Pod) windowsShell() WindowsShell {
+	if len(p.Spec.Containers) == 0 {
+		return WindowsShellPowershell
+	}
+	return GetWindowsShell(p.Spec.Containers[0].Image)
+}
+
 type podRunnerCmd func(string, string, string, string, bool, time.Duration, time.Duration, time.Duration) (*Pod, error)
 
 // RunCommandMultipleTimes runs the same command 'desiredAttempts' times
@@ -246,8 +413,7 @@ func RunCommandMultipleTimes(podRunnerCmd podRunnerCmd, image, name, command str
 	defer logResults()
 	for i := 0; i < desiredAttempts; i++ {
 		actualAttempts++
-		r := rand.New(rand.NewSource(time.Now().UnixNano()))
-		podName := fmt.Sprintf("%s-%d", name, r.Intn(99999))
+		podName := util.UniqueName(name)
 		var p *Pod
 		var err error
 		p, err = podRunnerCmd(image, podName, "default", command, true, sleep, duration, timeout)
@@ -277,6 +443,38 @@ func RunCommandMultipleTimes(podRunnerCmd podRunnerCmd, image, name, command str
 	return successfulAttempts, nil
 }
 
+// GenerateCPULoad creates count linux pods named <name>-<i> that request url in a loop, driving the CPU load of the pods serving it.
+// It returns once all load pods are ready, the load stops when they are deleted.
+func GenerateCPULoad(image, name, namespace, url string, count int, sleep, duration time.Duration) ([]*Pod, error) {
+	command := fmt.Sprintf("while true; do wget -q -O- %s > /dev/null; done", url)
+	var pods []*Pod
+	for i := 0; i < count; i++ {
+		p, err := RunLinuxPod(image, fmt.Sprintf("%s-%d", name, i), namespace, command, true, sleep, duration, commandTimeout)
+		if err == nil {
+			pods = append(pods, p)
+			_, err = p.WaitOnReady(sleep, duration)
+		}
+		if err != nil {
+			StopCPULoad(pods)
+			return nil, errors.Wrapf(err, "starting load pod %d of %d against %s", i+1, count, url)
+		}
+	}
+	log.Printf("%d pods are sending load to %s\n", count, url)
+	return pods, nil
+}
+
+// StopCPULoad deletes the load pods created by GenerateCPULoad
+func StopCPULoad(pods []*Pod) error {
+	var g errgroup.Group
+	for _, p := range pods {
+		p := p
+		g.Go(func() error {
+			return p.Delete(util.DefaultDeleteRetries)
+		})
+	}
+	return g.Wait()
+}
+
 // GetAll will return all pods in a given namespace
 func GetAll(namespace string) (*List, error) {
 	cmd := exec.Command("k", "get", "pods", "-n", namespace, "-o", "json")
@@ -297,59 +495,42 @@ func GetAll(namespace string) (*List, error) {
 
 // GetWithRetry gets a pod, allowing for retries
 func GetWithRetry(podPrefix, namespace string, sleep, duration time.Duration) (*Pod, error) {
-	podCh := make(chan *Pod, 1)
-	errCh := make(chan error)
-	ctx, cancel := context.WithTimeout(context.Background(), duration)
-	defer cancel()
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				errCh <- errors.Errorf("Timeout exceeded (%s) while waiting for Pod (%s) in namespace (%s)", duration.String(), podPrefix, namespace)
-			default:
-				p, err := Get(podPrefix, namespace, podLookupRetries)
-				if err != nil {
-					log.Printf("Error getting pod %s in namespace %s: %s\n", podPrefix, namespace, err)
-				} else if p != nil {
-					podCh <- p
-				}
-				fmt.Print(".")
-				time.Sleep(sleep)
-			}
-		}
-	}()
-	for {
-		select {
-		case err := <-errCh:
-			fmt.Print("\n")
-			return nil, err
-		case p := <-podCh:
-			fmt.Print("\n")
-			return p, nil
+	var p *Pod
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		var err error
+		p, err = Get(podPrefix, namespace, podLookupRetries)
+		if err != nil {
+			log.Printf("Error getting pod %s in namespace %s: %s\n", podPrefix, namespace, err)
 		}
+		return err
+	})
+	if err != nil {
+		return nil, errors.Errorf("Timeout exceeded (%s) while waiting for Pod (%s) in namespace (%s)", duration.String(), podPrefix, namespace)
 	}
+	return p, nil
 }
 
 // Get will return a pod with a given name and namespace
 func Get(podName, namespace string, retries int) (*Pod, error) {
-	cmd := exec.Command("k", "get", "pods", podName, "-n", namespace, "-o", "json")
 	p := Pod{}
-	var out []byte
-	var err error
-	for i := 0; i < retries; i++ {
-		out, err = cmd.CombinedOutput()
+	var jsonErr error
+	err := util.DefaultRetrier().WithMaxAttempts(retries).Do(context.Background(), func() error {
+		cmd := exec.Command("k", "get", "pods", podName, "-n", namespace, "-o", "json")
+		out, err := cmd.CombinedOutput()
 		if err != nil {
 			util.PrintCommand(cmd)
 			log.Printf("Error getting pod: %s\n", err)
-			continue
-		} else {
-			jsonErr := json.Unmarshal(out, &p)
-			if jsonErr != nil {
-				log.Printf("Error unmarshalling pods json:%s\n", jsonErr)
-				return nil, jsonErr
-			}
-			break
+			return err
+		}
+		jsonErr = json.Unmarshal(out, &p)
+		if jsonErr != nil {
+			log.Printf("Error unmarshalling pods json:%s\n", jsonErr)
+			return util.Permanent(jsonErr)
 		}
+		return nil
+	})
+	if jsonErr != nil {
+		return nil, jsonErr
 	}
 	return &p, err
 }
@@ -391,6 +572,24 @@ func GetAllByPrefix(prefix, namespace string) ([]Pod, error) {
 	return pods, nil
 }
 
+// GetAllBySelector will return all pods in a given namespace that match a label selector
+func GetAllBySelector(selector, namespace string) ([]Pod, error) {
+	cmd := exec.Command("k", "get", "pods", "-n", namespace, "-l", selector, "-o", "json")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error getting pods by selector %s:\n", selector)
+		util.PrintCommand(cmd)
+		return nil, err
+	}
+	pl := List{}
+	err = json.Unmarshal(out, &pl)
+	if err != nil {
+		log.Printf("Error unmarshalling pods json:%s\n", err)
+		return nil, err
+	}
+	return pl.Pods, nil
+}
+
 // AreAllPodsRunning will return true if all pods in a given namespace are in a Running State
 func AreAllPodsRunning(podPrefix, namespace string) (bool, error) {
 	pl, err := GetAll(namespace)
@@ -398,7 +597,7 @@ func AreAllPodsRunning(podPrefix, namespace string) (bool, error) {
 		return false, err
 	}
 
-	var status []bool
+	var pods []Pod
 	for _, pod := range pl.Pods {
 		matched, err := regexp.MatchString(podPrefix, pod.Metadata.Name)
 		if err != nil {
@@ -406,25 +605,33 @@ func AreAllPodsRunning(podPrefix, namespace string) (bool, error) {
 			return false, err
 		}
 		if matched {
-			if pod.Status.Phase != "Running" {
-				status = append(status, false)
-			} else {
-				status = append(status, true)
-			}
+			pods = append(pods, pod)
 		}
 	}
 
-	if len(status) == 0 {
-		return false, nil
+	return areAllRunning(pods), nil
+}
+
+// AreAllPodsRunningBySelector will return true if all pods in a given namespace that match a label selector are in a Running State
+func AreAllPodsRunningBySelector(selector, namespace string) (bool, error) {
+	pods, err := GetAllBySelector(selector, namespace)
+	if err != nil {
+		return false, err
 	}
+	return areAllRunning(pods), nil
+}
 
-	for _, s := range status {
-		if !s {
-			return false, nil
+// areAllRunning returns true if pods is non-empty and every pod is in a Running State
+func areAllRunning(pods []Pod) bool {
+	if len(pods) == 0 {
+		return false
+	}
+	for _, pod := range pods {
+		if pod.Status.Phase != "Running" {
+			return false
 		}
 	}
-
-	return true, nil
+	return true
 }
 
 // AreAllPodsSucceeded returns true, false if all pods in a given namespace are in a Running State
@@ -470,98 +677,248 @@ func AreAllPodsSucceeded(podPrefix, namespace string) (bool, bool, error) {
 // WaitOnReady is used when you dont have a handle on a pod but want to wait until its in a Ready state.
 // successesNeeded is used to make sure we return the correct value even if the pod is in a CrashLoop
 func WaitOnReady(podPrefix, namespace string, successesNeeded int, sleep, duration time.Duration) (bool, error) {
-	successCount := 0
-	failureCount := 0
-	readyCh := make(chan bool, 1)
-	errCh := make(chan error)
+	ready, err := waitOnReady(podPrefix, namespace, successesNeeded, sleep, duration, prefixWatch(podPrefix, namespace),
+		func() ([]Pod, error) {
+			return GetAllByPrefix(podPrefix, namespace)
+		})
+	util.ReportWaitFailure(util.WaitFailure{Kind: "pod", Name: podPrefix, Namespace: namespace, Err: err})
+	return ready, err
+}
+
+// WaitOnReadyBySelector is used when you want to wait until all pods that match a label selector are in a Ready state.
+// successesNeeded is used to make sure we return the correct value even if a pod is in a CrashLoop
+func WaitOnReadyBySelector(selector, namespace string, successesNeeded int, sleep, duration time.Duration) (bool, error) {
+	ready, err := waitOnReady(selector, namespace, successesNeeded, sleep, duration, selectorWatch(selector, namespace),
+		func() ([]Pod, error) {
+			return GetAllBySelector(selector, namespace)
+		})
+	util.ReportWaitFailure(util.WaitFailure{Kind: "pod", Selector: selector, Namespace: namespace, Err: err})
+	return ready, err
+}
+
+// podWatch is what a waiter watches: the kubectl get arguments and which of the pods printed are waited on
+type podWatch struct {
+	args    []string
+	matches func(name string) (bool, error)
+}
+
+// prefixWatch watches the pods of a namespace whose name matches podPrefix, as AreAllPodsRunning does
+func prefixWatch(podPrefix, namespace string) podWatch {
+	return podWatch{
+		args: []string{"pods", "-n", namespace},
+		matches: func(name string) (bool, error) {
+			return regexp.MatchString(podPrefix, name)
+		},
+	}
+}
+
+// selectorWatch watches the pods of a namespace that match a label selector
+func selectorWatch(selector, namespace string) podWatch {
+	return podWatch{
+		args: []string{"pods", "-n", namespace, "-l", selector},
+		matches: func(string) (bool, error) {
+			return true, nil
+		},
+	}
+}
+
+// list returns the pods waited on
+func (w podWatch) list() ([]Pod, error) {
+	cmd := exec.Command("k", append(append([]string{"get"}, w.args...), "-o", "json")...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error getting pods (%s):%s\n", strings.Join(w.args, " "), out)
+		util.PrintCommand(cmd)
+		return nil, err
+	}
+	pl := List{}
+	if err = json.Unmarshal(out, &pl); err != nil {
+		log.Printf("Error unmarshalling pods json:%s\n", err)
+		return nil, err
+	}
+	var pods []Pod
+	for _, p := range pl.Pods {
+		matched, err := w.matches(p.Metadata.Name)
+		if err != nil {
+			log.Printf("Error trying to match pod name:%s\n", err)
+			return nil, err
+		}
+		if matched && p.Metadata.DeletionTimestamp == nil {
+			pods = append(pods, p)
+		}
+	}
+	return pods, nil
+}
+
+// watchPods keeps the pods waited on up to date from a watch, listing them whenever the watch (re)starts so
+// that check never sees a partial list, and calls check after every change until it is done or returns an error.
+// check returns how long to wait before calling it again if nothing changes in the meantime, 0 to wait for a change.
+// timeoutErr is returned once duration is exceeded.
+func watchPods(w podWatch, sleep, duration time.Duration, check func(pods map[string]Pod, now time.Time) (bool, time.Duration, error), timeoutErr func() error) error {
 	ctx, cancel := context.WithTimeout(context.Background(), duration)
 	defer cancel()
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				errCh <- errors.Errorf("Timeout exceeded (%s) while waiting for Pods (%s) to become ready in namespace (%s), got %d of %d required successful pods ready results", duration.String(), podPrefix, namespace, successCount, successesNeeded)
-			default:
-				ready, err := AreAllPodsRunning(podPrefix, namespace)
-				if err != nil {
-					errCh <- err
-					return
-				}
-				if ready {
-					successCount++
-					if successCount >= successesNeeded {
-						readyCh <- true
-					}
-				} else {
-					if successCount > 1 {
-						failureCount++
-						if failureCount >= successesNeeded {
-							errCh <- errors.Errorf("Pods from deployment (%s) in namespace (%s) have been checked out as all Ready %d times, but NotReady %d times. This behavior may mean it is in a crashloop", podPrefix, namespace, successCount, failureCount)
-						}
-					}
-					time.Sleep(sleep)
-				}
-			}
+	pods := map[string]Pod{}
+	list := func() error {
+		current, err := w.list()
+		if err != nil {
+			return err
 		}
-	}()
+		pods = map[string]Pod{}
+		for _, p := range current {
+			pods[p.Metadata.Name] = p
+		}
+		return nil
+	}
+	if err := list(); err != nil {
+		return err
+	}
+	events := util.Watch(ctx, sleep, w.args...)
+	var recheck <-chan time.Time
 	for {
+		done, after, err := check(pods, time.Now())
+		if err != nil || done {
+			return err
+		}
+		recheck = nil
+		if after > 0 {
+			recheck = time.After(after)
+		}
 		select {
-		case err := <-errCh:
-			pods, _ := GetAllByPrefix(podPrefix, namespace)
-			if len(pods) != 0 {
-				for _, p := range pods {
-					e := p.Logs()
-					if e != nil {
-						log.Printf("Unable to print pod logs for pod %s: %s", p.Metadata.Name, e)
-					}
-					e = p.Describe()
-					if e != nil {
-						log.Printf("Unable to describe pod %s: %s", p.Metadata.Name, e)
-					}
+		case <-recheck:
+		case e, ok := <-events:
+			if !ok {
+				return timeoutErr()
+			}
+			if e.Restarted {
+				// changes may have been missed while kubectl was not running
+				if err := list(); err != nil {
+					log.Printf("Error listing pods (%s):%s\n", strings.Join(w.args, " "), err)
 				}
+				continue
+			}
+			var p Pod
+			if err := json.Unmarshal(e.Object, &p); err != nil {
+				return util.Permanent(errors.Wrap(err, "unmarshalling pod json"))
+			}
+			matched, err := w.matches(p.Metadata.Name)
+			if err != nil {
+				log.Printf("Error trying to match pod name:%s\n", err)
+				return err
+			}
+			if !matched {
+				continue
+			}
+			if p.Metadata.DeletionTimestamp != nil {
+				delete(pods, p.Metadata.Name)
+			} else {
+				pods[p.Metadata.Name] = p
 			}
-			return false, err
-		case ready := <-readyCh:
-			return ready, nil
 		}
 	}
 }
 
-// WaitOnSucceeded is used when you dont have a handle on a pod but want to wait until its in a Succeeded state.
-func WaitOnSucceeded(podPrefix, namespace string, sleep, duration time.Duration) (bool, error) {
-	succeededCh := make(chan bool, 1)
-	errCh := make(chan error)
-	ctx, cancel := context.WithTimeout(context.Background(), duration)
-	defer cancel()
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				errCh <- errors.Errorf("Timeout exceeded (%s) while waiting for Pods (%s) to succeed in namespace (%s)", duration.String(), podPrefix, namespace)
-			default:
-				succeeded, failed, err := AreAllPodsSucceeded(podPrefix, namespace)
-				if err != nil {
-					errCh <- err
-					return
+// podProgress is what waitOnReady knows of a pod it waits on
+type podProgress struct {
+	runningSince time.Time // runningSince is zero while the pod is not Running
+	restarts     int
+}
+
+func restartCount(p Pod) int {
+	count := 0
+	for _, s := range p.Status.ContainerStatuses {
+		count += s.RestartCount
+	}
+	return count
+}
+
+// waitOnReady waits until the pods waited on have all been Running for as long as successesNeeded checks sleep apart would take,
+// printing logs and describe output for the pods returned by getPods on failure.
+// Pods that stop running or restart successesNeeded times after running for sleep are considered to be in a crashloop.
+func waitOnReady(match, namespace string, successesNeeded int, sleep, duration time.Duration, w podWatch, getPods func() ([]Pod, error)) (bool, error) {
+	stableFor := time.Duration(successesNeeded-1) * sleep
+	progress := map[string]podProgress{}
+	failureCount := 0
+	running := 0
+	err := watchPods(w, sleep, duration, func(pods map[string]Pod, now time.Time) (bool, time.Duration, error) {
+		running = 0
+		var readySince time.Time
+		for name, p := range pods {
+			prev, seen := progress[name]
+			cur := podProgress{restarts: restartCount(p)}
+			if p.Status.Phase == "Running" {
+				cur.runningSince = now
+				if seen && !prev.runningSince.IsZero() && cur.restarts == prev.restarts {
+					cur.runningSince = prev.runningSince
+				} else if since, ready := p.ReadySince(); ready && !seen && since.Before(now) {
+					cur.runningSince = since
 				}
-				if failed {
-					errCh <- errors.New("At least one pod in a Failed state")
+				running++
+				if cur.runningSince.After(readySince) {
+					readySince = cur.runningSince
 				}
-				if succeeded {
-					succeededCh <- true
+			}
+			if seen && !prev.runningSince.IsZero() && now.Sub(prev.runningSince) >= sleep && (cur.runningSince.IsZero() || cur.restarts > prev.restarts) {
+				failureCount++
+				if failureCount >= successesNeeded {
+					return false, 0, util.Permanent(errors.Errorf("Pods from deployment (%s) in namespace (%s) have been Running, but stopped running or restarted %d times. This behavior may mean it is in a crashloop", match, namespace, failureCount))
 				}
-				time.Sleep(sleep)
 			}
+			progress[name] = cur
 		}
-	}()
-	for {
-		select {
-		case err := <-errCh:
-			return false, err
-		case ready := <-succeededCh:
-			return ready, nil
+		for name := range progress {
+			if _, ok := pods[name]; !ok {
+				delete(progress, name)
+			}
+		}
+		if len(pods) == 0 || running < len(pods) {
+			return false, 0, nil
+		}
+		if elapsed := now.Sub(readySince); elapsed < stableFor {
+			return false, stableFor - elapsed, nil
 		}
+		return true, 0, nil
+	}, func() error {
+		return errors.Errorf("Timeout exceeded (%s) while waiting for Pods (%s) to become ready in namespace (%s), got %d of %d pods running for %s", duration.String(), match, namespace, running, len(progress), stableFor)
+	})
+	if err != nil {
+		pods, _ := getPods()
+		for _, p := range pods {
+			e := p.Logs()
+			if e != nil {
+				log.Printf("Unable to print pod logs for pod %s: %s", p.Metadata.Name, e)
+			}
+			e = p.Describe()
+			if e != nil {
+				log.Printf("Unable to describe pod %s: %s", p.Metadata.Name, e)
+			}
+		}
+		return false, err
 	}
+	return true, nil
+}
+
+// WaitOnSucceeded is used when you dont have a handle on a pod but want to wait until its in a Succeeded state.
+func WaitOnSucceeded(podPrefix, namespace string, sleep, duration time.Duration) (bool, error) {
+	err := watchPods(prefixWatch(podPrefix, namespace), sleep, duration,
+		func(pods map[string]Pod, now time.Time) (bool, time.Duration, error) {
+			for _, p := range pods {
+				if p.Status.Phase == "Failed" {
+					return false, 0, errors.New("At least one pod in a Failed state")
+				}
+				if p.Status.Phase != "Succeeded" {
+					return false, 0, nil
+				}
+			}
+			return len(pods) > 0, 0, nil
+		},
+		func() error {
+			return errors.Errorf("Timeout exceeded (%s) while waiting for Pods (%s) to succeed in namespace (%s)", duration.String(), podPrefix, namespace)
+		})
+	if err != nil {
+		util.ReportWaitFailure(util.WaitFailure{Kind: "pod", Name: podPrefix, Namespace: namespace, Err: err})
+		return false, err
+	}
+	return true, nil
 }
 
 // WaitOnReady will call the static method WaitOnReady passing in p.Metadata.Name and p.Metadata.Namespace
@@ -589,64 +946,262 @@ func (p *Pod) Exec(c ...string) ([]byte, error) {
 	return out, nil
 }
 
+// PortForward runs kubectl port-forward from localPort to podPort of the pod in the background.
+// The ready channel is closed once kubectl is forwarding. The err channel receives the error that
+// ends the port-forward process early, and is closed when the process exits.
+// Cancelling ctx stops the process without sending an error.
+func (p *Pod) PortForward(ctx context.Context, localPort, podPort int) (<-chan struct{}, <-chan error) {
+	ready := make(chan struct{})
+	errc := make(chan error, 1)
+	cmd := exec.Command("k", "port-forward", "-n", p.Metadata.Namespace, p.Metadata.Name, fmt.Sprintf("%d:%d", localPort, podPort))
+	// k is a shell script wrapping kubectl, run it in its own process group so that kubectl is stopped along with it
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err == nil {
+		util.PrintCommand(cmd)
+		err = cmd.Start()
+	}
+	if err != nil {
+		errc <- errors.Wrap(err, "starting kubectl port-forward")
+		close(errc)
+		return ready, errc
+	}
+	log.Printf("kubectl port-forward running as pid: %d\n", cmd.Process.Pid)
+
+	exited := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		case <-exited:
+		}
+	}()
+
+	go func() {
+		defer close(errc)
+		isReady := false
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			log.Printf("kubectl port-forward stdout: %s\n", scanner.Text())
+			if !isReady && strings.HasPrefix(scanner.Text(), "Forwarding from") {
+				isReady = true
+				close(ready)
+			}
+		}
+		waitErr := cmd.Wait()
+		close(exited)
+		if ctx.Err() != nil {
+			log.Printf("kubectl port-forward pid %d stopped\n", cmd.Process.Pid)
+			return
+		}
+		if waitErr == nil {
+			waitErr = errors.New("process exited")
+		}
+		errc <- errors.Wrapf(waitErr, "kubectl port-forward to pod %s in namespace %s:%s", p.Metadata.Name, p.Metadata.Namespace, stderr.String())
+	}()
+
+	return ready, errc
+}
+
+// CopyTo copies localPath to remotePath in container with kubectl cp, container may be empty for single-container pods
+func (p *Pod) CopyTo(localPath, remotePath, container string) error {
+	return p.copy(localPath, p.remoteCopyPath(remotePath), container)
+}
+
+// CopyFrom copies remotePath in container to localPath with kubectl cp, container may be empty for single-container pods
+func (p *Pod) CopyFrom(remotePath, localPath, container string) error {
+	return p.copy(p.remoteCopyPath(remotePath), localPath, container)
+}
+
+func (p *Pod) copy(src, dst, container string) error {
+	args := []string{"cp", src, dst}
+	if container != "" {
+		args = append(args, "-c", container)
+	}
+	return util.DefaultRetrier().Do(context.Background(), func() error {
+		cmd := exec.Command("k", args...)
+		out, err := util.RunAndLogCommand(cmd, commandTimeout)
+		if err != nil {
+			log.Printf("Error trying to run 'kubectl cp %s %s':%s\n", src, dst, string(out))
+		}
+		return err
+	})
+}
+
+// remoteCopyPath returns remotePath in the namespace/pod:path form kubectl cp expects.
+// kubectl cp cannot parse drive letters or backslashes, so a Windows path like C:\tmp\file
+// is passed as /tmp/file, which the container resolves against its system drive.
+func (p *Pod) remoteCopyPath(remotePath string) string {
+	if len(remotePath) >= 2 && remotePath[1] == ':' {
+		remotePath = remotePath[2:]
+	}
+	remotePath = strings.Replace(remotePath, `\`, "/", -1)
+	return fmt.Sprintf("%s/%s:%s", p.Metadata.Namespace, p.Metadata.Name, remotePath)
+}
+
 // Delete will delete a Pod in a given namespace
 func (p *Pod) Delete(retries int) error {
-	var kubectlOutput []byte
-	var kubectlError error
-	for i := 0; i < retries; i++ {
+	return util.DefaultRetrier().WithMaxAttempts(retries).Do(context.Background(), func() error {
 		cmd := exec.Command("k", "delete", "po", "-n", p.Metadata.Namespace, p.Metadata.Name)
-		kubectlOutput, kubectlError = util.RunAndLogCommand(cmd, deleteTimeout)
+		kubectlOutput, kubectlError := util.RunAndLogCommand(cmd, deleteTimeout)
 		if kubectlError != nil {
 			log.Printf("Error while trying to delete Pod %s in namespace %s:%s\n", p.Metadata.Namespace, p.Metadata.Name, string(kubectlOutput))
-			continue
 		}
-		break
+		return kubectlError
+	})
+}
+
+// Evict will evict a Pod in a given namespace through the eviction API, honoring any PodDisruptionBudget
+func Evict(name, namespace string) error {
+	eviction := fmt.Sprintf(`{"apiVersion":"policy/v1beta1","kind":"Eviction","metadata":{"name":"%s","namespace":"%s"}}`, name, namespace)
+	cmd := exec.Command("k", "create", "--raw", fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/eviction", namespace, name), "-f", "-")
+	cmd.Stdin = strings.NewReader(eviction)
+	out, err := util.RunAndLogCommand(cmd, commandTimeout)
+	if err != nil {
+		log.Printf("Error while trying to evict Pod %s in namespace %s:%s\n", name, namespace, string(out))
+		return err
 	}
+	return nil
+}
 
-	return kubectlError
+// Evict will call the static method Evict passing in p.Metadata.Name and p.Metadata.Namespace
+func (p *Pod) Evict() error {
+	return Evict(p.Metadata.Name, p.Metadata.Namespace)
 }
 
-// CheckOutboundConnection checks outbound connection for a list of pods.
-func (l *List) CheckOutboundConnection(sleep, duration time.Duration, osType api.OSType) (bool, error) {
-	readyCh := make(chan bool)
-	errCh := make(chan error)
-	ctx, cancel := context.WithTimeout(context.Background(), 2*duration)
-	defer cancel()
+// IsReady returns true if the pod is Running and all of its containers are ready
+func (p *Pod) IsReady() bool {
+	if p.Status.Phase != "Running" {
+		return false
+	}
+	for _, c := range p.Status.ContainerStatuses {
+		if !c.Ready {
+			return false
+		}
+	}
+	return true
+}
 
-	ready := false
-	err := errors.Errorf("Unspecified error")
+// ReadySince returns when the pod last became Ready, and false if it is not Ready
+func (p *Pod) ReadySince() (time.Time, bool) {
+	for _, c := range p.Status.Conditions {
+		if c.Type == "Ready" {
+			return c.LastTransitionTime, c.Status == "True"
+		}
+	}
+	return time.Time{}, false
+}
+
+// SurviveNodeDrain drains nodeName and waits until the pods in the list that were scheduled on it have been
+// rescheduled and are Ready on other nodes. Pods are matched to their replacements by label, so pods without
+// labels are not tracked. The node is uncordoned before returning.
+func (l *List) SurviveNodeDrain(nodeName string, sleep, duration time.Duration) (bool, error) {
+	type group struct {
+		selector  string
+		namespace string
+	}
+	expected := make(map[group]int)
 	for _, p := range l.Pods {
-		localPod := p
-		go func() {
-			switch osType {
-			case api.Linux:
-				ready, err = localPod.CheckLinuxOutboundConnection(sleep, duration)
-			case api.Windows:
-				ready, err = localPod.CheckWindowsOutboundConnection(sleep, duration)
-			default:
-				ready, err = false, errors.Errorf("Invalid osType for Pod (%s)", localPod.Metadata.Name)
-			}
-			readyCh <- ready
-			errCh <- err
-		}()
+		if len(p.Metadata.Labels) == 0 {
+			continue
+		}
+		expected[group{selector: labelSelector(p.Metadata.Labels), namespace: p.Metadata.Namespace}]++
 	}
 
-	readyCount := 0
-	for {
-		select {
-		case <-ctx.Done():
-			return false, errors.Errorf("Timeout exceeded (%s) while waiting for PodList to check outbound internet connection", duration.String())
-		case err = <-errCh:
-			return false, err
-		case ready = <-readyCh:
-			if ready {
-				readyCount++
-				if readyCount == len(l.Pods) {
-					return true, nil
+	if err := node.Drain(nodeName, duration); err != nil {
+		// drain cordons the node before evicting anything, make sure it doesn't stay that way
+		node.Uncordon(nodeName)
+		return false, err
+	}
+	defer node.Uncordon(nodeName)
+
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		for g, count := range expected {
+			pods, err := GetAllBySelector(g.selector, g.namespace)
+			if err != nil {
+				return err
+			}
+			var ready int
+			for _, p := range pods {
+				if p.Spec.NodeName != nodeName && p.IsReady() {
+					ready++
 				}
 			}
+			if ready < count {
+				return errors.Errorf("%d of %d pods matching %s in namespace %s are Ready on nodes other than %s", ready, count, g.selector, g.namespace, nodeName)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Pods did not survive the drain of node %s:%s\n", nodeName, err)
+		return false, err
 	}
+	return true, nil
+}
+
+// labelSelector returns a label selector that matches all of labels
+func labelSelector(labels map[string]string) string {
+	var pairs []string
+	for k, v := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// CheckOutboundConnection checks outbound connection for a list of pods concurrently until each check passes, its duration elapses or ctx is done.
+// It returns each pod's check result by pod name, a nil error meaning the pod has outbound connectivity,
+// and an error if more than tolerateFailures pods failed the check.
+func (l *List) CheckOutboundConnection(ctx context.Context, sleep, duration time.Duration, osType api.OSType, tolerateFailures int) (map[string]error, error) {
+	results := make(map[string]error, len(l.Pods))
+	var lock sync.Mutex
+	var failures int
+	g, gctx := errgroup.WithContext(ctx)
+	for _, p := range l.Pods {
+		p := p
+		g.Go(func() error {
+			errCh := make(chan error, 1)
+			go func() {
+				var ready bool
+				var err error
+				switch osType {
+				case api.Linux:
+					ready, err = p.CheckLinuxOutboundConnection(sleep, duration)
+				case api.Windows:
+					ready, err = p.CheckWindowsOutboundConnection(sleep, duration)
+				default:
+					err = errors.Errorf("Invalid osType for Pod (%s)", p.Metadata.Name)
+				}
+				if err == nil && !ready {
+					err = errors.Errorf("Pod (%s) does not have outbound internet connection", p.Metadata.Name)
+				}
+				errCh <- err
+			}()
+
+			var err error
+			select {
+			case err = <-errCh:
+			case <-gctx.Done():
+				err = errors.Wrapf(gctx.Err(), "checking outbound internet connection from pod (%s)", p.Metadata.Name)
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			results[p.Metadata.Name] = err
+			if err != nil {
+				failures++
+				log.Printf("Pod (%s) failed the outbound internet connection check:%s\n", p.Metadata.Name, err)
+				if failures > tolerateFailures {
+					return errors.Errorf("%d of %d pods failed the outbound internet connection check, tolerating %d", failures, len(l.Pods), tolerateFailures)
+				}
+			}
+			return nil
+		})
+	}
+	err := g.Wait()
+	return results, err
 }
 
 //ValidateCurlConnection checks curl connection for a list of Linux pods to a specified uri.
@@ -684,10 +1239,15 @@ func (l *List) ValidateCurlConnection(uri string, sleep, duration time.Duration)
 }
 
 // CheckLinuxOutboundConnection will keep retrying the check if an error is received until the timeout occurs or it passes. This helps us when DNS may not be available for some time after a pod starts.
+// The check uses curl, wget or nc if the pod image has one of them. Otherwise it runs a connectivity test pod on the same node, the pod itself is never modified.
+// The requests are described by GetOutboundConnectionConfig.
 func (p *Pod) CheckLinuxOutboundConnection(sleep, duration time.Duration) (bool, error) {
 	readyCh := make(chan bool, 1)
 	errCh := make(chan error)
-	var installedCurl bool
+	var client string
+	var detected bool
+	var detectAttempts int
+	c := GetOutboundConnectionConfig()
 	ctx, cancel := context.WithTimeout(context.Background(), duration)
 	defer cancel()
 	go func() {
@@ -695,28 +1255,38 @@ func (p *Pod) CheckLinuxOutboundConnection(sleep, duration time.Duration) (bool,
 			select {
 			case <-ctx.Done():
 				errCh <- errors.Errorf("Timeout exceeded (%s) while waiting for Pod (%s) to check outbound internet connection", duration.String(), p.Metadata.Name)
+				return
 			default:
-				if !installedCurl {
-					_, err := p.Exec("--", "/usr/bin/apt", "update")
-					if err != nil {
-						break
+				if !detected {
+					var err error
+					client, err = p.detectHTTPClient()
+					detectAttempts++
+					detected = err == nil || detectAttempts >= httpClientDetectAttempts
+					// nc can neither speak HTTPS nor go through a proxy
+					if client == "nc" && (c.HTTPS || c.Proxy != "") {
+						client = ""
 					}
-					_, err = p.Exec("--", "/usr/bin/apt", "install", "-y", "curl")
-					if err != nil {
-						break
-					}
-					installedCurl = true
 				}
-				// if we can curl an external URL we have outbound internet access
-				urls := getExternalURLs()
-				for i, url := range urls {
-					out, err := p.Exec("--", "curl", url)
-					if err == nil {
+				if detected && client == "" {
+					log.Printf("Pod %s has no suitable HTTP client, checking outbound internet connection from node %s\n", p.Metadata.Name, p.Spec.NodeName)
+					ready, err := checkOutboundConnectionFromNode(p.Spec.NodeName, p.Metadata.Namespace, c, sleep, duration)
+					if ready {
 						readyCh <- true
-					} else {
+						return
+					}
+					log.Printf("Error:%s\n", err)
+				} else if detected {
+					// if we can reach an external URL we have outbound internet access
+					urls := c.GetURLs()
+					for i, url := range urls {
+						out, err := p.Exec(httpRequestCommand(client, url, c.Proxy)...)
+						if err == nil && c.IsExpectedResponse(string(out)) {
+							readyCh <- true
+							return
+						}
 						if i == (len(urls) - 1) {
 							// if all are down let's say we don't have outbound internet access
-							log.Printf("Error:%s\n", err)
+							log.Printf("Error:%v\n", err)
 							log.Printf("Out:%s\n", out)
 						}
 					}
@@ -736,10 +1306,12 @@ func (p *Pod) CheckLinuxOutboundConnection(sleep, duration time.Duration) (bool,
 }
 
 // ValidateCurlConnection connects to a URI on TCP 80
+// curl is only installed in the pod if it has none of curl, wget and nc.
 func (p *Pod) ValidateCurlConnection(uri string, sleep, duration time.Duration) (bool, error) {
 	readyCh := make(chan bool, 1)
 	errCh := make(chan error)
-	var installedCurl bool
+	var client string
+	anyResponse := OutboundConnectionConfig{}
 	ctx, cancel := context.WithTimeout(context.Background(), duration)
 	defer cancel()
 	go func() {
@@ -747,8 +1319,16 @@ func (p *Pod) ValidateCurlConnection(uri string, sleep, duration time.Duration)
 			select {
 			case <-ctx.Done():
 				errCh <- errors.Errorf("Timeout exceeded (%s) while waiting for Pod (%s) to curl uri %s", duration.String(), p.Metadata.Name, uri)
+				return
 			default:
-				if !installedCurl {
+				if client == "" {
+					var err error
+					client, err = p.detectHTTPClient()
+					if err != nil {
+						break
+					}
+				}
+				if client == "" {
 					_, err := p.Exec("--", "/usr/bin/apt", "update")
 					if err != nil {
 						break
@@ -757,14 +1337,15 @@ func (p *Pod) ValidateCurlConnection(uri string, sleep, duration time.Duration)
 					if err != nil {
 						break
 					}
-					installedCurl = true
+					client = "curl"
 				}
-				_, err := p.Exec("--", "curl", uri)
-				if err == nil {
+				out, err := p.Exec(httpRequestCommand(client, httpURL(uri), "")...)
+				if err == nil && anyResponse.IsExpectedResponse(string(out)) {
 					readyCh <- true
+					return
 				}
-				time.Sleep(sleep)
 			}
+			time.Sleep(sleep)
 		}
 	}()
 	for {
@@ -777,6 +1358,91 @@ func (p *Pod) ValidateCurlConnection(uri string, sleep, duration time.Duration)
 	}
 }
 
+// detectHTTPClient returns the first of curl, wget and nc that is available in the pod, or an empty string if there is none
+func (p *Pod) detectHTTPClient() (string, error) {
+	out, err := p.Exec("--", "/bin/sh", "-c", "command -v curl || command -v wget || command -v nc || true")
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	return path.Base(strings.TrimSpace(lines[0])), nil
+}
+
+// httpRequestCommand returns the kubectl exec arguments that run httpRequest in the pod
+func httpRequestCommand(client, url, proxy string) []string {
+	return []string{"--", "/bin/sh", "-c", httpRequest(client, url, proxy)}
+}
+
+// httpRequest returns the shell command that requests url with client, which is one of curl, wget and nc,
+// through proxy if it is set, and prints the HTTP status code of the response
+func httpRequest(client, url, proxy string) string {
+	var request string
+	switch client {
+	case "wget":
+		// busybox wget cannot print the status code, take it from the last response of the server
+		request = fmt.Sprintf("wget -S -O /dev/null -T 30 '%s' 2>&1 | grep 'HTTP/' | tail -n 1", url)
+		if proxy != "" {
+			request = fmt.Sprintf("http_proxy='%s' https_proxy='%s' %s", proxy, proxy, request)
+		}
+	case "nc":
+		host := strings.SplitN(strings.TrimPrefix(url, "http://"), "/", 2)[0]
+		request = fmt.Sprintf("printf 'HEAD / HTTP/1.0\\r\\nHost: %s\\r\\n\\r\\n' | nc -w 30 %s 80 | head -n 1", host, host)
+	default:
+		request = fmt.Sprintf("curl -sSL -o /dev/null -w '%%{http_code}' --max-time 30 '%s'", url)
+		if proxy != "" {
+			request = fmt.Sprintf("%s --proxy '%s'", request, proxy)
+		}
+	}
+	return request
+}
+
+// httpURL prefixes url with http:// unless it already has a scheme, which busybox wget requires
+func httpURL(url string) string {
+	if strings.Contains(url, "://") {
+		return url
+	}
+	return "http://" + url
+}
+
+// checkOutboundConnectionFromNode runs a busybox pod on nodeName that requests the URLs of c with wget, and deletes it afterwards
+func checkOutboundConnectionFromNode(nodeName, namespace string, c OutboundConnectionConfig, sleep, duration time.Duration) (bool, error) {
+	name := util.UniqueName("outbound-check")
+	var requests []string
+	for _, url := range c.GetURLs() {
+		requests = append(requests, httpRequest("wget", url, c.Proxy))
+	}
+	// print one status code per URL and always succeed, the status codes are checked in the pod logs
+	command := strings.Join(requests, "; ")
+	overrides := fmt.Sprintf(`{ "spec": {"nodeSelector":{"kubernetes.io/hostname":"%s"}}}`, nodeName)
+	cmd := exec.Command("k", "run", name, "-n", namespace, "--image", connectivityTestImage, "--image-pull-policy=IfNotPresent", "--restart=Never", "--overrides", overrides, "--command", "--", "/bin/sh", "-c", command)
+	out, err := util.RunAndLogCommand(cmd, commandTimeout)
+	if err != nil {
+		log.Printf("Error trying to deploy %s [%s] in namespace %s:%s\n", name, connectivityTestImage, namespace, string(out))
+		return false, err
+	}
+	defer func() {
+		cmd := exec.Command("k", "delete", "po", "-n", namespace, name)
+		if out, err := util.RunAndLogCommand(cmd, deleteTimeout); err != nil {
+			log.Printf("Error while trying to delete Pod %s in namespace %s:%s\n", name, namespace, string(out))
+		}
+	}()
+	if succeeded, err := WaitOnSucceeded(name, namespace, sleep, duration); !succeeded {
+		return false, err
+	}
+	cmd = exec.Command("k", "logs", name, "-n", namespace)
+	out, err = util.RunAndLogCommand(cmd, commandTimeout)
+	if err != nil {
+		log.Printf("Error trying to get logs from Pod %s in namespace %s:%s\n", name, namespace, string(out))
+		return false, err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if c.IsExpectedResponse(line) {
+			return true, nil
+		}
+	}
+	return false, errors.Errorf("none of %s responded with an expected status code:%s", strings.Join(c.URLs, ", "), string(out))
+}
+
 // ValidateOmsAgentLogs validates omsagent logs
 func (p *Pod) ValidateOmsAgentLogs(execCmdString string, sleep, duration time.Duration) (bool, error) {
 	readyCh := make(chan bool, 1)
@@ -808,12 +1474,10 @@ func (p *Pod) ValidateOmsAgentLogs(execCmdString string, sleep, duration time.Du
 }
 
 // CheckWindowsOutboundConnection will keep retrying the check if an error is received until the timeout occurs or it passes. This helps us when DNS may not be available for some time after a pod starts.
+// The requests are described by GetOutboundConnectionConfig.
 func (p *Pod) CheckWindowsOutboundConnection(sleep, duration time.Duration) (bool, error) {
-	exp, err := regexp.Compile(`(Connected\s*:\s*True)`)
-	if err != nil {
-		log.Printf("Error while trying to create regex for windows outbound check:%s\n", err)
-		return false, err
-	}
+	shell := p.windowsShell()
+	c := GetOutboundConnectionConfig()
 	readyCh := make(chan bool, 1)
 	errCh := make(chan error)
 	ctx, cancel := context.WithTimeout(context.Background(), duration)
@@ -823,11 +1487,14 @@ func (p *Pod) CheckWindowsOutboundConnection(sleep, duration time.Duration) (boo
 			select {
 			case <-ctx.Done():
 				errCh <- errors.Errorf("Timeout exceeded (%s) while waiting for Pod (%s) to check outbound internet connection", duration.String(), p.Metadata.Name)
+				return
 			default:
-				out, err := p.Exec("--", "powershell", "New-Object", "System.Net.Sockets.TcpClient('8.8.8.8', 443)")
-				matched := exp.MatchString(string(out))
-				if err == nil && matched {
-					readyCh <- true
+				for _, url := range c.GetURLs() {
+					out, err := p.Exec(append([]string{"--"}, shell.Command(windowsHTTPRequest(shell, url, c.Proxy))...)...)
+					if err == nil && c.IsExpectedResponse(string(out)) {
+						readyCh <- true
+						return
+					}
 				}
 				time.Sleep(sleep)
 			}
@@ -843,8 +1510,26 @@ func (p *Pod) CheckWindowsOutboundConnection(sleep, duration time.Duration) (boo
 	}
 }
 
-// ValidateHostPort will attempt to run curl against the POD's hostIP and hostPort
-func (p *Pod) ValidateHostPort(check string, attempts int, sleep time.Duration, master, sshKeyPath string) bool {
+// windowsHTTPRequest returns the command that requests url in shell, through proxy if it is set, and prints the HTTP status code of the response
+func windowsHTTPRequest(shell WindowsShell, url, proxy string) string {
+	if shell == WindowsShellCmd {
+		// cmd has no HTTP client of its own, use the curl.exe shipped with Windows
+		request := fmt.Sprintf(`curl.exe -sSL -o NUL -w "%%{http_code}" --max-time 30 "%s"`, url)
+		if proxy != "" {
+			request = fmt.Sprintf(`%s --proxy "%s"`, request, proxy)
+		}
+		return request
+	}
+	var proxyArg string
+	if proxy != "" {
+		proxyArg = fmt.Sprintf(" -Proxy '%s'", proxy)
+	}
+	// Invoke-WebRequest throws on error status codes, the response is then attached to the exception
+	return fmt.Sprintf("try { (Invoke-WebRequest -UseBasicParsing -TimeoutSec 30%s -Uri '%s').StatusCode } catch { [int]$_.Exception.Response.StatusCode }", proxyArg, url)
+}
+
+// ValidateHostPort will attempt to run curl against the POD's hostIP and hostPort from the master
+func (p *Pod) ValidateHostPort(check string, attempts int, sleep time.Duration, conn *remote.Connection) bool {
 	hostIP := p.Status.HostIP
 	if len(p.Spec.Containers) == 0 || len(p.Spec.Containers[0].Ports) == 0 {
 		log.Printf("Unexpected POD container spec: %v. Should have hostPort.\n", p.Spec)
@@ -856,8 +1541,7 @@ func (p *Pod) ValidateHostPort(check string, attempts int, sleep time.Duration,
 	curlCMD := fmt.Sprintf("curl --max-time 60 %s", url)
 
 	for i := 0; i < attempts; i++ {
-		cmd := exec.Command("ssh", "-i", sshKeyPath, "-o", "ConnectTimeout=10", "-o", "StrictHostKeyChecking=no", "-o", "UserKnownHostsFile=/dev/null", master, curlCMD)
-		out, err := util.RunAndLogCommand(cmd, commandTimeout)
+		out, err := conn.Run("", curlCMD, commandTimeout)
 		if err == nil {
 			matched, _ := regexp.MatchString(check, string(out))
 			if matched {
@@ -892,6 +1576,14 @@ func (p *Pod) Describe() error {
 
 // ValidateAzureFile will keep retrying the check if azure file is mounted in Pod
 func (p *Pod) ValidateAzureFile(mountPath string, sleep, duration time.Duration) (bool, error) {
+	shell := p.windowsShell()
+	dir := mountPath + "\\" + testDir
+	mkdirCommand := fmt.Sprintf("mkdir -force %s", dir)
+	lsCommand := fmt.Sprintf("ls %s", mountPath)
+	if shell == WindowsShellCmd {
+		mkdirCommand = fmt.Sprintf("if not exist %s mkdir %s", dir, dir)
+		lsCommand = fmt.Sprintf("dir %s", mountPath)
+	}
 	readyCh := make(chan bool, 1)
 	errCh := make(chan error)
 	ctx, cancel := context.WithTimeout(context.Background(), duration)
@@ -902,9 +1594,9 @@ func (p *Pod) ValidateAzureFile(mountPath string, sleep, duration time.Duration)
 			case <-ctx.Done():
 				errCh <- errors.Errorf("Timeout exceeded (%s) while waiting for Pod (%s) to check azure file mounted", duration.String(), p.Metadata.Name)
 			default:
-				out, err := p.Exec("--", "powershell", "mkdir", "-force", mountPath+"\\"+testDir)
-				if err == nil && strings.Contains(string(out), testDir) {
-					out, err = p.Exec("--", "powershell", "ls", mountPath)
+				out, err := p.Exec(append([]string{"--"}, shell.Command(mkdirCommand)...)...)
+				if err == nil {
+					out, err = p.Exec(append([]string{"--"}, shell.Command(lsCommand)...)...)
 					if err == nil && strings.Contains(string(out), testDir) {
 						readyCh <- true
 					} else {
@@ -1034,8 +1726,3 @@ func (c *Container) getMemoryRequests() string {
 func (c *Container) getMemoryLimits() string {
 	return c.Resources.Limits.Memory
 }
-
-// getExternalURLs returns a list of external URLs
-func getExternalURLs() []string {
-	return []string{"www.bing.com", "google.com"}
-}
@@ -9,16 +9,22 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"os/exec"
 	"regexp"
 	"time"
 
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/pod"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
 	"github.com/pkg/errors"
 )
 
-const commandTimeout = 1 * time.Minute
+const (
+	commandTimeout = 1 * time.Minute
+	// internalLoadBalancerAnnotation makes the Azure cloud provider create an internal load balancer for a Service
+	internalLoadBalancerAnnotation = "service.beta.kubernetes.io/azure-load-balancer-internal"
+)
 
 // List holds a list of services returned from kubectl get svc
 type List struct {
@@ -34,17 +40,20 @@ type Service struct {
 
 // Metadata holds information like name, namespace, and labels
 type Metadata struct {
-	CreatedAt time.Time         `json:"creationTimestamp"`
-	Labels    map[string]string `json:"labels"`
-	Name      string            `json:"name"`
-	Namespace string            `json:"namespace"`
+	Annotations map[string]string `json:"annotations"`
+	CreatedAt   time.Time         `json:"creationTimestamp"`
+	Labels      map[string]string `json:"labels"`
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
 }
 
 // Spec holds information like clusterIP and port
 type Spec struct {
-	ClusterIP string `json:"clusterIP"`
-	Ports     []Port `json:"ports"`
-	Type      string `json:"type"`
+	ClusterIP                string   `json:"clusterIP"`
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges"`
+	Ports                    []Port   `json:"ports"`
+	SessionAffinity          string   `json:"sessionAffinity"`
+	Type                     string   `json:"type"`
 }
 
 // Port represents a service port definition
@@ -178,6 +187,152 @@ func (s *Service) WaitForIngress(wait, sleep time.Duration) (*Service, error) {
 	}
 }
 
+// WaitOnExternalIP waits for the load balancer of the service to be provisioned and returns its frontend IP
+func (s *Service) WaitOnExternalIP(sleep, duration time.Duration) (string, error) {
+	var ip string
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		svc, err := Get(s.Metadata.Name, s.Metadata.Namespace)
+		if err != nil {
+			return err
+		}
+		if svc.Spec.Type != "LoadBalancer" {
+			return util.Permanent(errors.Errorf("Service %s is of type %s, not LoadBalancer", s.Metadata.Name, svc.Spec.Type))
+		}
+		if len(svc.Status.LoadBalancer.Ingress) == 0 || svc.Status.LoadBalancer.Ingress[0]["ip"] == "" {
+			return errors.Errorf("no frontend IP provisioned yet for Service %s", s.Metadata.Name)
+		}
+		*s = *svc
+		ip = svc.Status.LoadBalancer.Ingress[0]["ip"]
+		return nil
+	})
+	if err != nil {
+		err = errors.Wrapf(err, "waiting for the external IP of Service %s in namespace %s", s.Metadata.Name, s.Metadata.Namespace)
+		util.ReportWaitFailure(util.WaitFailure{Kind: "service", Name: s.Metadata.Name, Namespace: s.Metadata.Namespace, Err: err})
+		return "", err
+	}
+	log.Printf("Service %s has external IP %s\n", s.Metadata.Name, ip)
+	return ip, nil
+}
+
+// ValidateInternalLoadBalancer waits for the internal load balancer of the service, validates its frontend IP is in subnetCIDR,
+// and that at least one of pods can connect to it
+func (s *Service) ValidateInternalLoadBalancer(subnetCIDR string, pods []pod.Pod, sleep, duration time.Duration) (string, error) {
+	if s.Metadata.Annotations[internalLoadBalancerAnnotation] != "true" {
+		return "", errors.Errorf("Service %s does not have the annotation %s: \"true\"", s.Metadata.Name, internalLoadBalancerAnnotation)
+	}
+	ip, err := s.WaitOnExternalIP(sleep, duration)
+	if err != nil {
+		return "", err
+	}
+	if err = validateInternalIP(ip, subnetCIDR); err != nil {
+		return "", errors.Wrapf(err, "validating the internal load balancer of Service %s", s.Metadata.Name)
+	}
+	for _, p := range pods {
+		pass, err := p.ValidateCurlConnection(ip, sleep, duration)
+		if err == nil && pass {
+			return ip, nil
+		}
+		log.Printf("Pod %s could not connect to the internal load balancer %s:%v\n", p.Metadata.Name, ip, err)
+	}
+	return "", errors.Errorf("none of the pods could connect to the internal load balancer %s of Service %s", ip, s.Metadata.Name)
+}
+
+// ValidateSessionAffinity validates the session affinity of the service is affinity, e.g. ClientIP
+func (s *Service) ValidateSessionAffinity(affinity string) error {
+	svc, err := Get(s.Metadata.Name, s.Metadata.Namespace)
+	if err != nil {
+		return err
+	}
+	if svc.Spec.SessionAffinity != affinity {
+		return errors.Errorf("expected Service %s to have session affinity %s, got %s", s.Metadata.Name, affinity, svc.Spec.SessionAffinity)
+	}
+	return nil
+}
+
+// ValidateLoadBalancerSourceRanges validates the load balancer of the service only accepts connections from its loadBalancerSourceRanges:
+// every pod of allowed must connect to the frontend IP, and none of denied may
+func (s *Service) ValidateLoadBalancerSourceRanges(allowed, denied []pod.Pod, sleep, duration time.Duration) error {
+	ip, err := s.WaitOnExternalIP(sleep, duration)
+	if err != nil {
+		return err
+	}
+	ranges := s.Spec.LoadBalancerSourceRanges
+	if len(ranges) == 0 {
+		return errors.Errorf("Service %s has no loadBalancerSourceRanges", s.Metadata.Name)
+	}
+	for _, p := range allowed {
+		if in, err := inSourceRanges(p.Status.PodIP, ranges); err != nil || !in {
+			return errors.Errorf("Pod %s with IP %s is not in the source ranges %v of Service %s, it cannot be expected to connect", p.Metadata.Name, p.Status.PodIP, ranges, s.Metadata.Name)
+		}
+		if pass, err := p.ValidateCurlConnection(ip, sleep, duration); err != nil || !pass {
+			return errors.Errorf("Pod %s in the source ranges %v could not connect to %s:%v", p.Metadata.Name, ranges, ip, err)
+		}
+	}
+	for _, p := range denied {
+		if in, err := inSourceRanges(p.Status.PodIP, ranges); err != nil || in {
+			return errors.Errorf("Pod %s with IP %s is in the source ranges %v of Service %s, it cannot be expected to be denied", p.Metadata.Name, p.Status.PodIP, ranges, s.Metadata.Name)
+		}
+		if pass, _ := p.ValidateCurlConnection(ip, sleep, duration); pass {
+			return errors.Errorf("Pod %s outside of the source ranges %v could connect to %s", p.Metadata.Name, ranges, ip)
+		}
+	}
+	return nil
+}
+
+// SetLoadBalancerSourceRanges restricts the clients of the load balancer of the service to ranges
+func (s *Service) SetLoadBalancerSourceRanges(ranges []string) error {
+	patch, err := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"loadBalancerSourceRanges": ranges}})
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("k", "patch", "svc", s.Metadata.Name, "-n", s.Metadata.Namespace, "-p", string(patch))
+	out, err := util.RunAndLogCommand(cmd, commandTimeout)
+	if err != nil {
+		log.Printf("Error trying to set the source ranges of Service %s:%s\n", s.Metadata.Name, string(out))
+		return err
+	}
+	svc, err := Get(s.Metadata.Name, s.Metadata.Namespace)
+	if err != nil {
+		return err
+	}
+	*s = *svc
+	return nil
+}
+
+// validateInternalIP returns an error unless ip is in subnetCIDR
+func validateInternalIP(ip, subnetCIDR string) error {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return errors.Errorf("%s is not a valid IP", ip)
+	}
+	_, subnet, err := net.ParseCIDR(subnetCIDR)
+	if err != nil {
+		return errors.Wrapf(err, "parsing subnet %s", subnetCIDR)
+	}
+	if !subnet.Contains(addr) {
+		return errors.Errorf("IP %s is not in subnet %s", ip, subnetCIDR)
+	}
+	return nil
+}
+
+// inSourceRanges returns true if ip is in one of the CIDRs of ranges
+func inSourceRanges(ip string, ranges []string) (bool, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false, errors.Errorf("%s is not a valid IP", ip)
+	}
+	for _, r := range ranges {
+		_, cidr, err := net.ParseCIDR(r)
+		if err != nil {
+			return false, errors.Wrapf(err, "parsing source range %s", r)
+		}
+		if cidr.Contains(addr) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // WaitOnDeleted returns when a service resource is successfully deleted
 func WaitOnDeleted(servicePrefix, namespace string, sleep, duration time.Duration) (bool, error) {
 	succeededCh := make(chan bool, 1)
@@ -204,6 +359,7 @@ func WaitOnDeleted(servicePrefix, namespace string, sleep, duration time.Duratio
 	for {
 		select {
 		case err := <-errCh:
+			util.ReportWaitFailure(util.WaitFailure{Kind: "service", Name: servicePrefix, Namespace: namespace, Err: err})
 			return false, err
 		case deleted := <-succeededCh:
 			return deleted, nil
@@ -0,0 +1,306 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package statefulset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/persistentvolumeclaims"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/pod"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	commandTimeout = 1 * time.Minute
+	// PodManagementOrderedReady starts the pods of a StatefulSet one at a time, in ordinal order
+	PodManagementOrderedReady = "OrderedReady"
+)
+
+// StatefulSet is used to parse data from kubectl get statefulsets
+type StatefulSet struct {
+	Metadata pod.Metadata `json:"metadata"`
+	Spec     Spec         `json:"spec"`
+	Status   Status       `json:"status"`
+}
+
+// Spec holds information like replicas, the governing service and the volume claim templates
+type Spec struct {
+	Replicas             int                   `json:"replicas"`
+	ServiceName          string                `json:"serviceName"`
+	PodManagementPolicy  string                `json:"podManagementPolicy"`
+	Selector             Selector              `json:"selector"`
+	VolumeClaimTemplates []VolumeClaimTemplate `json:"volumeClaimTemplates"`
+}
+
+// Selector holds the label query over pods that are managed by the statefulset
+type Selector struct {
+	MatchLabels map[string]string `json:"matchLabels"`
+}
+
+// VolumeClaimTemplate is the template of the PersistentVolumeClaim created for each replica
+type VolumeClaimTemplate struct {
+	Metadata pod.Metadata            `json:"metadata"`
+	Spec     VolumeClaimTemplateSpec `json:"spec"`
+}
+
+// VolumeClaimTemplateSpec holds information like storageClassName
+type VolumeClaimTemplateSpec struct {
+	StorageClassName string `json:"storageClassName"`
+}
+
+// Status holds information like the number of ready replicas
+type Status struct {
+	Replicas        int `json:"replicas"`
+	ReadyReplicas   int `json:"readyReplicas"`
+	CurrentReplicas int `json:"currentReplicas"`
+}
+
+// CreateFromFile will create a StatefulSet from file with a name
+func CreateFromFile(filename, name, namespace string) (*StatefulSet, error) {
+	cmd := exec.Command("k", "apply", "-f", filename)
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Error trying to create StatefulSet %s in namespace %s:%s\n", name, namespace, string(out))
+		return nil, err
+	}
+	s, err := Get(name, namespace)
+	if err != nil {
+		log.Printf("Error while trying to fetch StatefulSet %s in namespace %s:%s\n", name, namespace, err)
+		return nil, err
+	}
+	return s, nil
+}
+
+// CreateFromFileDeleteIfExists will create a StatefulSet from file, deleting any pre-existing statefulset with the same name and its PersistentVolumeClaims
+func CreateFromFileDeleteIfExists(filename, name, namespace string) (*StatefulSet, error) {
+	s, err := Get(name, namespace)
+	if err == nil {
+		if err = s.Delete(util.DefaultDeleteRetries); err != nil {
+			return nil, err
+		}
+		if err = s.WaitOnDeleted(5*time.Second, 3*time.Minute); err != nil {
+			return nil, err
+		}
+		if err = s.DeletePersistentVolumeClaims(util.DefaultDeleteRetries); err != nil {
+			return nil, err
+		}
+	}
+	return CreateFromFile(filename, name, namespace)
+}
+
+// Get will return a StatefulSet with a given name and namespace
+func Get(name, namespace string) (*StatefulSet, error) {
+	cmd := exec.Command("k", "get", "statefulset", name, "-n", namespace, "-o", "json")
+	util.PrintCommand(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, err
+	}
+	s := StatefulSet{}
+	err = json.Unmarshal(out, &s)
+	if err != nil {
+		log.Printf("Error unmarshalling StatefulSet json:%s\n", err)
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Delete will delete a StatefulSet in a given namespace, the PersistentVolumeClaims of its replicas are kept
+func (s *StatefulSet) Delete(retries int) error {
+	return util.DefaultRetrier().WithMaxAttempts(retries).Do(context.Background(), func() error {
+		cmd := exec.Command("k", "delete", "statefulset", "-n", s.Metadata.Namespace, s.Metadata.Name)
+		out, err := util.RunAndLogCommand(cmd, commandTimeout)
+		if err != nil {
+			log.Printf("Error while trying to delete StatefulSet %s in namespace %s:%s\n", s.Metadata.Name, s.Metadata.Namespace, string(out))
+		}
+		return err
+	})
+}
+
+// DeletePersistentVolumeClaims will delete the PersistentVolumeClaims created for the replicas of the StatefulSet
+func (s *StatefulSet) DeletePersistentVolumeClaims(retries int) error {
+	for _, name := range s.PersistentVolumeClaimNames() {
+		pvc, err := persistentvolumeclaims.Get(name, s.Metadata.Namespace)
+		if err != nil {
+			// the replica was never created
+			continue
+		}
+		if err = pvc.Delete(retries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WaitOnDeleted returns when the StatefulSet and its pods are deleted
+func (s *StatefulSet) WaitOnDeleted(sleep, duration time.Duration) error {
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		if _, err := Get(s.Metadata.Name, s.Metadata.Namespace); err == nil {
+			return errors.Errorf("StatefulSet %s still exists in namespace %s", s.Metadata.Name, s.Metadata.Namespace)
+		}
+		pods, err := s.Pods()
+		if err != nil {
+			return err
+		}
+		if len(pods) > 0 {
+			return errors.Errorf("%d pods of StatefulSet %s still exist", len(pods), s.Metadata.Name)
+		}
+		return nil
+	})
+	util.ReportWaitFailure(util.WaitFailure{Kind: "statefulset", Name: s.Metadata.Name, Namespace: s.Metadata.Namespace, Err: err})
+	return err
+}
+
+// LabelSelector returns the statefulset's matchLabels in kubectl -l format
+func (s *StatefulSet) LabelSelector() string {
+	var selectors []string
+	for k, v := range s.Spec.Selector.MatchLabels {
+		selectors = append(selectors, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(selectors)
+	return strings.Join(selectors, ",")
+}
+
+// PodName returns the name of the replica with a given ordinal
+func (s *StatefulSet) PodName(ordinal int) string {
+	return fmt.Sprintf("%s-%d", s.Metadata.Name, ordinal)
+}
+
+// PersistentVolumeClaimNames returns the names of the PersistentVolumeClaims of every replica, for every volume claim template
+func (s *StatefulSet) PersistentVolumeClaimNames() []string {
+	var names []string
+	for _, t := range s.Spec.VolumeClaimTemplates {
+		for i := 0; i < s.Spec.Replicas; i++ {
+			// the statefulset controller names the claims <template>-<pod>
+			names = append(names, fmt.Sprintf("%s-%s", t.Metadata.Name, s.PodName(i)))
+		}
+	}
+	return names
+}
+
+// Pods will return the pods of the StatefulSet, sorted by ordinal
+func (s *StatefulSet) Pods() ([]pod.Pod, error) {
+	pods, err := pod.GetAllBySelector(s.LabelSelector(), s.Metadata.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	ordinals := map[string]int{}
+	for _, p := range pods {
+		ordinal, err := strconv.Atoi(strings.TrimPrefix(p.Metadata.Name, s.Metadata.Name+"-"))
+		if err != nil {
+			return nil, errors.Errorf("pod %s is not a replica of StatefulSet %s", p.Metadata.Name, s.Metadata.Name)
+		}
+		ordinals[p.Metadata.Name] = ordinal
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return ordinals[pods[i].Metadata.Name] < ordinals[pods[j].Metadata.Name]
+	})
+	return pods, nil
+}
+
+// WaitOnReadyReplicas will wait for replicas pods of the StatefulSet to be ready
+func (s *StatefulSet) WaitOnReadyReplicas(replicas int, sleep, duration time.Duration) (bool, error) {
+	err := util.DefaultRetrier().WithConstantBackoff(sleep).DoWithTimeout(duration, func() error {
+		current, err := Get(s.Metadata.Name, s.Metadata.Namespace)
+		if err != nil {
+			return err
+		}
+		s.Status = current.Status
+		if current.Status.ReadyReplicas < replicas {
+			return errors.Errorf("%d of %d replicas of StatefulSet %s are ready", current.Status.ReadyReplicas, replicas, s.Metadata.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		err = errors.Wrapf(err, "waiting for StatefulSet %s in namespace %s to have %d ready replicas", s.Metadata.Name, s.Metadata.Namespace, replicas)
+		util.ReportWaitFailure(util.WaitFailure{Kind: "statefulset", Name: s.Metadata.Name, Namespace: s.Metadata.Namespace, Err: err})
+		return false, err
+	}
+	return true, nil
+}
+
+// ValidateOrderedStartup checks that every replica was created after the replica before it became ready, as the OrderedReady pod management policy requires.
+// Call it right after the rollout, a replica that restarted since would be reported as ready too late.
+func (s *StatefulSet) ValidateOrderedStartup() error {
+	if s.Spec.PodManagementPolicy != "" && s.Spec.PodManagementPolicy != PodManagementOrderedReady {
+		return errors.Errorf("StatefulSet %s uses the %s pod management policy, its pods are not started in order", s.Metadata.Name, s.Spec.PodManagementPolicy)
+	}
+	pods, err := s.Pods()
+	if err != nil {
+		return err
+	}
+	if len(pods) < s.Spec.Replicas {
+		return errors.Errorf("StatefulSet %s has %d of %d pods", s.Metadata.Name, len(pods), s.Spec.Replicas)
+	}
+	for i := 1; i < len(pods); i++ {
+		previous, current := pods[i-1], pods[i]
+		readySince, ready := previous.ReadySince()
+		if !ready {
+			return errors.Errorf("pod %s is not ready", previous.Metadata.Name)
+		}
+		// the timestamps have a resolution of one second, a pod created in the same second is in order
+		if current.Metadata.CreatedAt.Before(readySince) {
+			return errors.Errorf("pod %s was created at %s, before pod %s became ready at %s", current.Metadata.Name, current.Metadata.CreatedAt, previous.Metadata.Name, readySince)
+		}
+	}
+	return nil
+}
+
+// ValidatePVCPerReplica checks that every replica has its own bound PersistentVolumeClaim of the storage class of the template,
+// and that each pod can write to the volume mounted at mountPath
+func (s *StatefulSet) ValidatePVCPerReplica(mountPath string, sleep, duration time.Duration) error {
+	if len(s.Spec.VolumeClaimTemplates) == 0 {
+		return errors.Errorf("StatefulSet %s has no volume claim templates", s.Metadata.Name)
+	}
+	volumes := map[string]string{}
+	for _, t := range s.Spec.VolumeClaimTemplates {
+		for i := 0; i < s.Spec.Replicas; i++ {
+			name := fmt.Sprintf("%s-%s", t.Metadata.Name, s.PodName(i))
+			pvc, err := persistentvolumeclaims.Get(name, s.Metadata.Namespace)
+			if err != nil {
+				return errors.Wrapf(err, "getting PersistentVolumeClaim %s of StatefulSet %s", name, s.Metadata.Name)
+			}
+			if _, err = pvc.WaitOnReady(s.Metadata.Namespace, sleep, duration); err != nil {
+				return err
+			}
+			pvc, err = persistentvolumeclaims.Get(name, s.Metadata.Namespace)
+			if err != nil {
+				return errors.Wrapf(err, "getting PersistentVolumeClaim %s of StatefulSet %s", name, s.Metadata.Name)
+			}
+			if t.Spec.StorageClassName != "" && pvc.Spec.StorageClassName != t.Spec.StorageClassName {
+				return errors.Errorf("PersistentVolumeClaim %s has storage class %s, expected %s", name, pvc.Spec.StorageClassName, t.Spec.StorageClassName)
+			}
+			if other, ok := volumes[pvc.Spec.VolumeName]; ok {
+				return errors.Errorf("PersistentVolumeClaims %s and %s are bound to the same volume %s", other, name, pvc.Spec.VolumeName)
+			}
+			volumes[pvc.Spec.VolumeName] = name
+		}
+	}
+
+	pods, err := s.Pods()
+	if err != nil {
+		return err
+	}
+	for _, p := range pods {
+		p := p
+		valid, err := p.ValidatePVC(mountPath, sleep, duration)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return errors.Errorf("pod %s cannot write to %s", p.Metadata.Name, mountPath)
+		}
+	}
+	return nil
+}
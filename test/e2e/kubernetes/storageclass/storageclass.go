@@ -16,8 +16,10 @@ import (
 
 // StorageClass is used to parse data from kubectl get storageclass
 type StorageClass struct {
-	Metadata   Metadata   `json:"metadata"`
-	Parameters Parameters `json:"parameters"`
+	Metadata             Metadata   `json:"metadata"`
+	Provisioner          string     `json:"provisioner"`
+	AllowVolumeExpansion bool       `json:"allowVolumeExpansion"`
+	Parameters           Parameters `json:"parameters"`
 }
 
 // Metadata holds information like name, create time
@@ -48,6 +50,14 @@ func CreateStorageClassFromFile(filename, name string) (*StorageClass, error) {
 	return sc, nil
 }
 
+// HasCSIDriver returns true if a CSI driver with a given name is registered in the cluster, e.g. disk.csi.azure.com
+func HasCSIDriver(name string) bool {
+	cmd := exec.Command("k", "get", "csidriver", name)
+	util.PrintCommand(cmd)
+	_, err := cmd.CombinedOutput()
+	return err == nil
+}
+
 // Get will return a StorageClass with a given name and namespace
 func Get(scName string) (*StorageClass, error) {
 	cmd := exec.Command("k", "get", "storageclass", scName, "-o", "json")
@@ -89,6 +99,7 @@ func (sc *StorageClass) WaitOnReady(sleep, duration time.Duration) (bool, error)
 	for {
 		select {
 		case err := <-errCh:
+			util.ReportWaitFailure(util.WaitFailure{Kind: "storageclass", Name: sc.Metadata.Name, Err: err})
 			return false, err
 		case ready := <-readyCh:
 			return ready, nil
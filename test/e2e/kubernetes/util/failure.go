@@ -0,0 +1,58 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package util
+
+import (
+	"fmt"
+	"sync"
+)
+
+// WaitFailure describes the resources a WaitOn* helper gave up waiting on
+type WaitFailure struct {
+	Kind      string // Kind is the kubectl resource type, e.g. "pod" or "deployment"
+	Name      string // Name is the name or name prefix of the resources, all the resources of Kind are meant if it and Selector are empty
+	Selector  string // Selector is the label selector of the resources, instead of Name
+	Namespace string // Namespace is empty for cluster-scoped resources
+	Err       error
+}
+
+func (f WaitFailure) String() string {
+	what := f.Kind
+	switch {
+	case f.Selector != "":
+		what = fmt.Sprintf("%s %s", f.Kind, f.Selector)
+	case f.Name != "":
+		what = fmt.Sprintf("%s %s", f.Kind, f.Name)
+	}
+	if f.Namespace != "" {
+		what = fmt.Sprintf("%s in namespace %s", what, f.Namespace)
+	}
+	return what
+}
+
+var (
+	waitFailureHandlersMu sync.Mutex
+	waitFailureHandlers   []func(WaitFailure)
+)
+
+// OnWaitFailure registers handler to be called with every failure the WaitOn* helpers report
+func OnWaitFailure(handler func(WaitFailure)) {
+	waitFailureHandlersMu.Lock()
+	defer waitFailureHandlersMu.Unlock()
+	waitFailureHandlers = append(waitFailureHandlers, handler)
+}
+
+// ReportWaitFailure calls the handlers registered with OnWaitFailure, before the WaitOn* helper returns f.Err
+func ReportWaitFailure(f WaitFailure) {
+	if f.Err == nil {
+		return
+	}
+	waitFailureHandlersMu.Lock()
+	handlers := make([]func(WaitFailure), len(waitFailureHandlers))
+	copy(handlers, waitFailureHandlers)
+	waitFailureHandlersMu.Unlock()
+	for _, handler := range handlers {
+		handler(f)
+	}
+}
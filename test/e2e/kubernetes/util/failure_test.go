@@ -0,0 +1,42 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package util
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReportWaitFailure(t *testing.T) {
+	var reported []WaitFailure
+	OnWaitFailure(func(f WaitFailure) {
+		reported = append(reported, f)
+	})
+	defer func() {
+		waitFailureHandlers = nil
+	}()
+
+	ReportWaitFailure(WaitFailure{Kind: "pod", Name: "nginx"})
+	if len(reported) != 0 {
+		t.Fatalf("expected a failure without an error not to be reported, got %v", reported)
+	}
+	ReportWaitFailure(WaitFailure{Kind: "pod", Name: "nginx", Namespace: "default", Err: errors.New("timeout")})
+	if len(reported) != 1 || reported[0].Name != "nginx" {
+		t.Fatalf("expected the failure to be reported once, got %v", reported)
+	}
+
+	cases := []struct {
+		failure  WaitFailure
+		expected string
+	}{
+		{WaitFailure{Kind: "pod", Name: "nginx", Namespace: "default"}, "pod nginx in namespace default"},
+		{WaitFailure{Kind: "pod", Selector: "app=nginx", Namespace: "default"}, "pod app=nginx in namespace default"},
+		{WaitFailure{Kind: "node"}, "node"},
+	}
+	for _, c := range cases {
+		if s := c.failure.String(); s != c.expected {
+			t.Errorf("expected %q, got %q", c.expected, s)
+		}
+	}
+}
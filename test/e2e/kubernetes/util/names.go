@@ -0,0 +1,51 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package util
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	// maxNameLength is the longest DNS-1123 label, which service and namespace names must be
+	maxNameLength = 63
+	// nameSuffixBytes random bytes make the suffix of a unique name
+	nameSuffixBytes = 4
+)
+
+// runID identifies the suite run in the names of the resources it creates, so that the resources of parallel runs against a cluster are told apart
+var runID = randomHex(2)
+
+// RunID returns the ID of the suite run
+func RunID() string {
+	return runID
+}
+
+// RunPrefix returns prefix qualified with the ID of the suite run, with which all the names UniqueName returns for prefix start.
+// Cleaning up resources by RunPrefix(prefix) rather than prefix leaves the resources of other runs alone.
+func RunPrefix(prefix string) string {
+	maxPrefixLength := maxNameLength - len(runID) - 2*nameSuffixBytes - 2
+	prefix = strings.ToLower(prefix)
+	if len(prefix) > maxPrefixLength {
+		prefix = prefix[:maxPrefixLength]
+	}
+	return fmt.Sprintf("%s-%s-", strings.TrimRight(prefix, "-."), runID)
+}
+
+// UniqueName returns a Kubernetes resource name starting with prefix that no other call returns, in this run or another:
+// RunPrefix(prefix) followed by a random suffix from crypto/rand, which concurrent callers do not share a seed of
+func UniqueName(prefix string) string {
+	return RunPrefix(prefix) + randomHex(nameSuffixBytes)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("reading random bytes: %s", err))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,50 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package util
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestUniqueName(t *testing.T) {
+	dns1123Label := regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	cases := []struct {
+		prefix         string
+		expectedPrefix string
+	}{
+		{prefix: "nginx", expectedPrefix: "nginx-" + RunID() + "-"},
+		{prefix: "Ingress-Nginx-", expectedPrefix: "ingress-nginx-" + RunID() + "-"},
+		{prefix: strings.Repeat("a", 70), expectedPrefix: strings.Repeat("a", 49) + "-" + RunID() + "-"},
+	}
+	for _, c := range cases {
+		name := UniqueName(c.prefix)
+		if !strings.HasPrefix(name, c.expectedPrefix) || RunPrefix(c.prefix) != c.expectedPrefix {
+			t.Errorf("expected %s to start with %s", name, c.expectedPrefix)
+		}
+		if len(name) > maxNameLength || !dns1123Label.MatchString(name) {
+			t.Errorf("expected %s to be a DNS-1123 label", name)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	names := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := UniqueName("pod")
+			mu.Lock()
+			defer mu.Unlock()
+			if names[name] {
+				t.Errorf("UniqueName returned %s twice", name)
+			}
+			names[name] = true
+		}()
+	}
+	wg.Wait()
+}
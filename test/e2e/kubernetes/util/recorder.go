@@ -0,0 +1,237 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const recorderKubeConfigTemplate = `{
+  "apiVersion": "v1",
+  "kind": "Config",
+  "clusters": [{"name": "recorder", "cluster": {"server": "%s"}}],
+  "contexts": [{"name": "recorder", "context": {"cluster": "recorder", "user": "recorder"}}],
+  "current-context": "recorder",
+  "users": [{"name": "recorder", "user": {}}]
+}
+`
+
+// APICall is a Kubernetes API call recorded by an APIRecorder
+type APICall struct {
+	Time        time.Time `json:"time"`
+	Verb        string    `json:"verb"`
+	Resource    string    `json:"resource"`
+	Subresource string    `json:"subresource,omitempty"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Path        string    `json:"path"`
+	StatusCode  int       `json:"statusCode"`
+	LatencyMs   int64     `json:"latencyMs"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// APIRecorder is a local proxy in front of the Kubernetes apiserver that records every API call made through it
+type APIRecorder struct {
+	// KubeConfigPath is the path to a kubeconfig that sends requests through the recorder
+	KubeConfigPath string
+
+	server    *http.Server
+	transport http.RoundTripper
+	out       *os.File
+	encoder   *json.Encoder
+	lock      sync.Mutex
+	calls     int
+	failures  int
+	total     time.Duration
+	slowest   time.Duration
+}
+
+// StartAPIRecorder starts a recording proxy for the cluster in kubeconfigPath and appends the recorded calls,
+// one JSON object per line, to outputPath. Point KUBECONFIG to the returned recorder's KubeConfigPath to record kubectl calls.
+func StartAPIRecorder(kubeconfigPath, outputPath string) (*APIRecorder, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading kubeconfig %s", kubeconfigPath)
+	}
+	target, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing apiserver address %s", config.Host)
+	}
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating apiserver transport")
+	}
+
+	if err = os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return nil, err
+	}
+	out, err := os.OpenFile(outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		out.Close()
+		return nil, err
+	}
+
+	r := &APIRecorder{
+		transport: transport,
+		out:       out,
+		encoder:   json.NewEncoder(out),
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = r
+	// flush immediately so that watches and logs -f are streamed through the proxy
+	proxy.FlushInterval = -1
+	r.server = &http.Server{Handler: proxy}
+
+	kubeconfig, err := ioutil.TempFile("", "recorder-kubeconfig")
+	if err == nil {
+		_, err = fmt.Fprintf(kubeconfig, recorderKubeConfigTemplate, "http://"+listener.Addr().String())
+		kubeconfig.Close()
+	}
+	if err != nil {
+		listener.Close()
+		out.Close()
+		return nil, errors.Wrap(err, "writing recorder kubeconfig")
+	}
+	r.KubeConfigPath = kubeconfig.Name()
+
+	go func() {
+		if serveErr := r.server.Serve(listener); serveErr != nil && serveErr != http.ErrServerClosed {
+			log.Printf("Kubernetes API recorder stopped:%s\n", serveErr)
+		}
+	}()
+	log.Printf("Recording Kubernetes API calls to %s through %s\n", target.Host, listener.Addr())
+	return r, nil
+}
+
+// RoundTrip forwards req to the apiserver and records the call
+func (r *APIRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := r.transport.RoundTrip(req)
+	call := newAPICall(req.Method, req.URL)
+	call.Time = start
+	latency := time.Since(start)
+	call.LatencyMs = int64(latency / time.Millisecond)
+	if err != nil {
+		call.Error = err.Error()
+	} else {
+		call.StatusCode = resp.StatusCode
+	}
+	r.record(call, latency)
+	return resp, err
+}
+
+func (r *APIRecorder) record(call APICall, latency time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.calls++
+	if call.Error != "" || call.StatusCode >= http.StatusBadRequest {
+		r.failures++
+	}
+	r.total += latency
+	if latency > r.slowest {
+		r.slowest = latency
+	}
+	if err := r.encoder.Encode(call); err != nil {
+		log.Printf("Error recording Kubernetes API call %s %s:%s\n", call.Verb, call.Path, err)
+	}
+}
+
+// Stop shuts the proxy down, logs a summary of the recorded calls and closes the output file
+func (r *APIRecorder) Stop() error {
+	err := r.server.Close()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var average time.Duration
+	if r.calls > 0 {
+		average = r.total / time.Duration(r.calls)
+	}
+	log.Printf("Recorded %d Kubernetes API calls, %d failed, average latency %s, slowest %s\n", r.calls, r.failures, average, r.slowest)
+	os.Remove(r.KubeConfigPath)
+	if closeErr := r.out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// newAPICall describes a request by its Kubernetes verb and the resource it targets, see
+// https://kubernetes.io/docs/reference/access-authn-authz/authorization/#determine-the-request-verb
+func newAPICall(method string, u *url.URL) APICall {
+	call := APICall{Path: u.Path}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	// strip the /api/v1 or /apis/group/version prefix
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		parts = parts[3:]
+	default:
+		call.Verb = strings.ToLower(method)
+		return call
+	}
+	if len(parts) >= 2 && parts[0] == "namespaces" {
+		call.Namespace = parts[1]
+		parts = parts[2:]
+		if len(parts) == 0 {
+			// the namespace itself
+			parts = []string{"namespaces", call.Namespace}
+			call.Namespace = ""
+		}
+	}
+	if len(parts) > 0 {
+		call.Resource = parts[0]
+	}
+	if len(parts) > 1 {
+		call.Name = parts[1]
+	}
+	if len(parts) > 2 {
+		call.Subresource = strings.Join(parts[2:], "/")
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		switch {
+		case u.Query().Get("watch") == "true" || u.Query().Get("watch") == "1":
+			call.Verb = "watch"
+		case call.Name == "":
+			call.Verb = "list"
+		default:
+			call.Verb = "get"
+		}
+	case http.MethodPost:
+		call.Verb = "create"
+	case http.MethodPut:
+		call.Verb = "update"
+	case http.MethodPatch:
+		call.Verb = "patch"
+	case http.MethodDelete:
+		if call.Name == "" {
+			call.Verb = "deletecollection"
+		} else {
+			call.Verb = "delete"
+		}
+	default:
+		call.Verb = strings.ToLower(method)
+	}
+	return call
+}
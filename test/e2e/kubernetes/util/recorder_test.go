@@ -0,0 +1,156 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package util
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewAPICall(t *testing.T) {
+	cases := []struct {
+		method   string
+		path     string
+		expected APICall
+	}{
+		{
+			method:   http.MethodGet,
+			path:     "/api/v1/namespaces/default/pods",
+			expected: APICall{Verb: "list", Resource: "pods", Namespace: "default"},
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/api/v1/namespaces/default/pods?watch=true",
+			expected: APICall{Verb: "watch", Resource: "pods", Namespace: "default"},
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/api/v1/namespaces/kube-system/pods/coredns/log",
+			expected: APICall{Verb: "get", Resource: "pods", Subresource: "log", Namespace: "kube-system", Name: "coredns"},
+		},
+		{
+			method:   http.MethodPost,
+			path:     "/apis/apps/v1/namespaces/default/deployments",
+			expected: APICall{Verb: "create", Resource: "deployments", Namespace: "default"},
+		},
+		{
+			method:   http.MethodPatch,
+			path:     "/api/v1/nodes/k8s-master-0",
+			expected: APICall{Verb: "patch", Resource: "nodes", Name: "k8s-master-0"},
+		},
+		{
+			method:   http.MethodDelete,
+			path:     "/api/v1/namespaces/e2e",
+			expected: APICall{Verb: "delete", Resource: "namespaces", Name: "e2e"},
+		},
+		{
+			method:   http.MethodDelete,
+			path:     "/apis/batch/v1/namespaces/default/jobs",
+			expected: APICall{Verb: "deletecollection", Resource: "jobs", Namespace: "default"},
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/version",
+			expected: APICall{Verb: "get"},
+		},
+	}
+
+	for _, c := range cases {
+		u, err := url.Parse(c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.expected.Path = u.Path
+		if call := newAPICall(c.method, u); call != c.expected {
+			t.Errorf("%s %s: expected %+v, got %+v", c.method, c.path, c.expected, call)
+		}
+	}
+}
+
+func TestAPIRecorder(t *testing.T) {
+	apiserver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/namespaces/default/pods/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, "{}")
+	}))
+	defer apiserver.Close()
+
+	dir, err := ioutil.TempDir("", "recorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	kubeconfigPath := filepath.Join(dir, "kubeconfig")
+	if err = ioutil.WriteFile(kubeconfigPath, []byte(fmt.Sprintf(recorderKubeConfigTemplate, apiserver.URL)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outputPath := filepath.Join(dir, "logs", "api-calls.json")
+
+	r, err := StartAPIRecorder(kubeconfigPath, outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kubeconfig, err := ioutil.ReadFile(r.KubeConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var proxyConfig struct {
+		Clusters []struct {
+			Cluster struct {
+				Server string `json:"server"`
+			} `json:"cluster"`
+		} `json:"clusters"`
+	}
+	if err = json.Unmarshal(kubeconfig, &proxyConfig); err != nil {
+		t.Fatal(err)
+	}
+	proxyURL := proxyConfig.Clusters[0].Cluster.Server
+	for _, path := range []string{"/api/v1/namespaces/default/pods", "/api/v1/namespaces/default/pods/missing"} {
+		resp, err := http.Get(proxyURL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if err = r.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var calls []APICall
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var call APICall
+		if err = json.Unmarshal(scanner.Bytes(), &call); err != nil {
+			t.Fatal(err)
+		}
+		calls = append(calls, call)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 recorded calls, got %d", len(calls))
+	}
+	if calls[0].Verb != "list" || calls[0].StatusCode != http.StatusOK {
+		t.Errorf("expected a successful list call, got %+v", calls[0])
+	}
+	if calls[1].Verb != "get" || calls[1].Name != "missing" || calls[1].StatusCode != http.StatusNotFound {
+		t.Errorf("expected a failed get call, got %+v", calls[1])
+	}
+	if r.calls != 2 || r.failures != 1 {
+		t.Errorf("expected 2 calls and 1 failure, got %d calls and %d failures", r.calls, r.failures)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package util
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Retrier describes how an operation should be retried
+type Retrier struct {
+	// MaxAttempts is the maximum number of times the operation is run, 0 means no limit
+	MaxAttempts int
+	// InitialBackoff is how long to wait after the first failed attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts, 0 means no cap
+	MaxBackoff time.Duration
+	// Multiplier is applied to the backoff after every failed attempt, values <= 1 mean a constant backoff
+	Multiplier float64
+	// Jitter randomizes each backoff by up to +/- this fraction of its value
+	Jitter float64
+	// IsRetryable decides whether an error should be retried, all non-permanent errors are retried if nil
+	IsRetryable func(error) bool
+}
+
+var (
+	defaultRetrier = Retrier{
+		MaxAttempts:    5,
+		InitialBackoff: 1 * time.Second,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+	defaultRetrierLock sync.RWMutex
+	// retries counts the attempts retried by every Retrier of the process
+	retries int64
+)
+
+// DefaultRetrier returns the package-wide retry policy used by the e2e kubernetes helpers
+func DefaultRetrier() Retrier {
+	defaultRetrierLock.RLock()
+	defer defaultRetrierLock.RUnlock()
+	return defaultRetrier
+}
+
+// SetDefaultRetrier replaces the package-wide retry policy used by the e2e kubernetes helpers
+func SetDefaultRetrier(r Retrier) {
+	defaultRetrierLock.Lock()
+	defer defaultRetrierLock.Unlock()
+	defaultRetrier = r
+}
+
+// WithMaxAttempts returns a copy of r that runs the operation at most attempts times
+func (r Retrier) WithMaxAttempts(attempts int) Retrier {
+	r.MaxAttempts = attempts
+	return r
+}
+
+// WithConstantBackoff returns a copy of r that waits sleep (plus jitter) between every attempt, with no attempt limit
+func (r Retrier) WithConstantBackoff(sleep time.Duration) Retrier {
+	r.MaxAttempts = 0
+	r.InitialBackoff = sleep
+	r.MaxBackoff = 0
+	r.Multiplier = 1
+	return r
+}
+
+type permanentError struct {
+	err error
+}
+
+func (p *permanentError) Error() string {
+	return p.err.Error()
+}
+
+// Retries returns the number of attempts the Retriers of the process have retried so far
+func Retries() int64 {
+	return atomic.LoadInt64(&retries)
+}
+
+// Permanent wraps err so that a Retrier will stop retrying and return it immediately
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Backoff returns how long to wait after the given failed attempt, starting at 1
+func (r Retrier) Backoff(attempt int) time.Duration {
+	multiplier := r.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	backoff := float64(r.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if r.MaxBackoff > 0 && backoff > float64(r.MaxBackoff) {
+		backoff = float64(r.MaxBackoff)
+	}
+	if r.Jitter > 0 {
+		backoff += backoff * r.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(backoff)
+}
+
+// Do runs fn until it succeeds, returns a non-retryable error, MaxAttempts is reached or ctx is done,
+// returning the last error returned by fn
+func (r Retrier) Do(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+		if p, ok := err.(*permanentError); ok {
+			return p.err
+		}
+		if r.IsRetryable != nil && !r.IsRetryable(err) {
+			return err
+		}
+		if r.MaxAttempts > 0 && attempt >= r.MaxAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "%s", err)
+		case <-time.After(r.Backoff(attempt)):
+			atomic.AddInt64(&retries, 1)
+		}
+	}
+}
+
+// DoWithTimeout is a convenience wrapper around Do that gives up after timeout
+func (r Retrier) DoWithTimeout(timeout time.Duration, fn func() error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return r.Do(ctx, fn)
+}
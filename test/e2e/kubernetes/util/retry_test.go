@@ -0,0 +1,104 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestRetrierDo(t *testing.T) {
+	cases := []struct {
+		name             string
+		retrier          Retrier
+		failures         int
+		permanent        bool
+		expectedAttempts int
+		expectErr        bool
+	}{
+		{
+			name:             "succeeds after retries",
+			retrier:          Retrier{MaxAttempts: 5, InitialBackoff: time.Millisecond},
+			failures:         2,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "gives up after max attempts",
+			retrier:          Retrier{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			failures:         10,
+			expectedAttempts: 3,
+			expectErr:        true,
+		},
+		{
+			name:             "permanent error is not retried",
+			retrier:          Retrier{MaxAttempts: 5, InitialBackoff: time.Millisecond},
+			failures:         10,
+			permanent:        true,
+			expectedAttempts: 1,
+			expectErr:        true,
+		},
+		{
+			name: "non-retryable error is not retried",
+			retrier: Retrier{MaxAttempts: 5, InitialBackoff: time.Millisecond, IsRetryable: func(error) bool {
+				return false
+			}},
+			failures:         10,
+			expectedAttempts: 1,
+			expectErr:        true,
+		},
+	}
+
+	for _, c := range cases {
+		attempts := 0
+		retriesBefore := Retries()
+		err := c.retrier.DoWithTimeout(time.Minute, func() error {
+			attempts++
+			if attempts <= c.failures {
+				if c.permanent {
+					return Permanent(errors.New("permanent failure"))
+				}
+				return errors.New("failure")
+			}
+			return nil
+		})
+		if c.expectErr != (err != nil) {
+			t.Errorf("%s: expected error %t, got %v", c.name, c.expectErr, err)
+		}
+		if attempts != c.expectedAttempts {
+			t.Errorf("%s: expected %d attempts, got %d", c.name, c.expectedAttempts, attempts)
+		}
+		if retried := Retries() - retriesBefore; retried != int64(c.expectedAttempts-1) {
+			t.Errorf("%s: expected %d retries to be counted, got %d", c.name, c.expectedAttempts-1, retried)
+		}
+	}
+}
+
+func TestRetrierDoTimeout(t *testing.T) {
+	r := Retrier{InitialBackoff: 10 * time.Millisecond, Multiplier: 1}
+	err := r.DoWithTimeout(50*time.Millisecond, func() error {
+		return errors.New("failure")
+	})
+	if err == nil {
+		t.Fatalf("expected a timeout error")
+	}
+}
+
+func TestRetrierBackoff(t *testing.T) {
+	r := Retrier{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i, e := range expected {
+		if b := r.Backoff(i + 1); b != e {
+			t.Errorf("expected backoff %s for attempt %d, got %s", e, i+1, b)
+		}
+	}
+
+	r.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if b := r.Backoff(1); b < 500*time.Millisecond || b > 1500*time.Millisecond {
+			t.Fatalf("backoff %s is outside the jitter range", b)
+		}
+	}
+}
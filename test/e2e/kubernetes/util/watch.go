@@ -0,0 +1,89 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WatchEvent is an object printed by a kubectl watch, or the notice that the watch was started again
+type WatchEvent struct {
+	Object json.RawMessage
+	// Restarted is set on an event without an object sent when kubectl was started again:
+	// what was known of the objects is stale, kubectl prints them all again
+	Restarted bool
+}
+
+// Watch runs `k get <args> --watch -o json` until ctx is done and sends the objects it prints on the returned channel,
+// the current objects first and then every change to them, which the waiters react to without polling the API server.
+// kubectl is started again restartDelay after it exits, e.g. when the API server closes the watch. The channel is closed once ctx is done.
+func Watch(ctx context.Context, restartDelay time.Duration, args ...string) <-chan WatchEvent {
+	events := make(chan WatchEvent)
+	go func() {
+		defer close(events)
+		for {
+			err := watchOnce(ctx, events, args)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Watch of %s exited, starting it again:%s\n", strings.Join(args, " "), err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(restartDelay):
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case events <- WatchEvent{Restarted: true}:
+			}
+		}
+	}()
+	return events
+}
+
+func watchOnce(ctx context.Context, events chan<- WatchEvent, args []string) error {
+	cmd := exec.CommandContext(ctx, "k", append(append([]string{"get"}, args...), "--watch", "-o", "json")...)
+	PrintCommand(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	// kubectl prints the objects one after the other, each as a JSON document
+	decoder := json.NewDecoder(stdout)
+	for {
+		var object json.RawMessage
+		if err = decoder.Decode(&object); err != nil {
+			break
+		}
+		select {
+		case events <- WatchEvent{Object: object}:
+		case <-ctx.Done():
+			cmd.Wait()
+			return ctx.Err()
+		}
+	}
+	waitErr := cmd.Wait()
+	if err == io.EOF {
+		err = waitErr
+	}
+	if err == nil {
+		return errors.Errorf("kubectl exited:%s", stderr.String())
+	}
+	return errors.Wrapf(err, "kubectl:%s", stderr.String())
+}
@@ -0,0 +1,66 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package util
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake kubectl is a shell script")
+	}
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	argsFile := filepath.Join(dir, "args")
+	// the fake kubectl prints two objects and exits, as kubectl does when the API server closes the watch
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\nprintf '{\"name\": \"a\"}\\n{\"name\":\\n \"b\"}'\n"
+	if err = ioutil.WriteFile(filepath.Join(dir, "k"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	events := Watch(ctx, 10*time.Millisecond, "pods", "-n", "default")
+	var got []string
+	for e := range events {
+		if e.Restarted {
+			got = append(got, "restarted")
+		} else {
+			got = append(got, strings.Join(strings.Fields(string(e.Object)), ""))
+		}
+		if len(got) == 5 {
+			cancel()
+		}
+	}
+	expected := []string{`{"name":"a"}`, `{"name":"b"}`, "restarted", `{"name":"a"}`, `{"name":"b"}`}
+	if len(got) < len(expected) {
+		t.Fatalf("expected events %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected events %v, got %v", expected, got)
+		}
+	}
+
+	args, err := ioutil.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(args)) != "get pods -n default --watch -o json" {
+		t.Errorf("expected kubectl to be run with get pods -n default --watch -o json, got %s", args)
+	}
+}
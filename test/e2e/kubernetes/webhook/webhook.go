@@ -0,0 +1,233 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/deployment"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	commandTimeout = 1 * time.Minute
+	// webhookNameSuffix qualifies the names of the webhooks, which must be fully qualified
+	webhookNameSuffix = ".e2e.aks-engine.io"
+)
+
+// Kind is the kind of a webhook configuration
+type Kind string
+
+const (
+	// Validating webhooks admit or deny the requests
+	Validating Kind = "ValidatingWebhookConfiguration"
+	// Mutating webhooks patch the objects of the requests
+	Mutating Kind = "MutatingWebhookConfiguration"
+)
+
+// FailurePolicy is what the API server does with a request when it fails calling a webhook
+type FailurePolicy string
+
+const (
+	// Fail denies the request
+	Fail FailurePolicy = "Fail"
+	// Ignore admits the request
+	Ignore FailurePolicy = "Ignore"
+)
+
+// Server is a sample admission webhook server running in the cluster, serving a certificate signed by a CA generated for it
+type Server struct {
+	Name       string
+	Namespace  string
+	Deployment *deployment.Deployment
+	filename   string
+	caBundle   []byte
+}
+
+// Webhook is an admission webhook calling Path of Service for the requests creating or updating Resources, in the
+// namespaces matching NamespaceSelector so that a failing webhook cannot break the rest of the cluster
+type Webhook struct {
+	Name              string
+	Service           string
+	Namespace         string
+	Path              string
+	FailurePolicy     FailurePolicy
+	Resources         []string
+	NamespaceSelector map[string]string
+	CABundle          []byte
+}
+
+// Configuration is a webhook configuration registered with the API server
+type Configuration struct {
+	Kind Kind
+	Name string
+}
+
+// Deploy generates a serving certificate for the service of the webhook server of filename, stores it in the secret
+// <name>-certs the server mounts, then creates the deployment and service of the server
+func Deploy(filename, name, namespace string) (*Server, error) {
+	caPair, err := helpers.CreatePkiKeyCertPair(name + "-ca")
+	if err != nil {
+		return nil, errors.Wrap(err, "generating the webhook CA")
+	}
+	pair, err := helpers.CreateServerKeyCertPair(name, []string{fmt.Sprintf("%s.%s.svc", name, namespace)}, caPair)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating the webhook serving certificate")
+	}
+	dir, err := ioutil.TempDir("", name)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err = ioutil.WriteFile(certFile, []byte(pair.CertificatePem), 0600); err != nil {
+		return nil, err
+	}
+	if err = ioutil.WriteFile(keyFile, []byte(pair.PrivateKeyPem), 0600); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("k", "create", "secret", "tls", name+"-certs", "--cert", certFile, "--key", keyFile, "-n", namespace)
+	out, err := util.RunAndLogCommand(cmd, commandTimeout)
+	if err != nil {
+		log.Printf("Error trying to create the secret of webhook %s in namespace %s:%s\n", name, namespace, string(out))
+		return nil, err
+	}
+
+	d, err := deployment.CreateDeploymentFromFile(filename, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		Name:       name,
+		Namespace:  namespace,
+		Deployment: d,
+		filename:   filename,
+		caBundle:   []byte(caPair.CertificatePem),
+	}, nil
+}
+
+// Webhook returns a webhook calling path of the server
+func (s *Server) Webhook(name, path string, failurePolicy FailurePolicy, resources []string, namespaceSelector map[string]string) Webhook {
+	return Webhook{
+		Name:              name,
+		Service:           s.Name,
+		Namespace:         s.Namespace,
+		Path:              path,
+		FailurePolicy:     failurePolicy,
+		Resources:         resources,
+		NamespaceSelector: namespaceSelector,
+		CABundle:          s.caBundle,
+	}
+}
+
+// Delete deletes the deployment, service and secret of the server
+func (s *Server) Delete() error {
+	cmd := exec.Command("k", "delete", "-f", s.filename, "-n", s.Namespace)
+	out, err := util.RunAndLogCommand(cmd, commandTimeout)
+	if err != nil {
+		log.Printf("Error while trying to delete webhook %s in namespace %s:%s\n", s.Name, s.Namespace, string(out))
+		return err
+	}
+	cmd = exec.Command("k", "delete", "secret", s.Name+"-certs", "-n", s.Namespace)
+	out, err = util.RunAndLogCommand(cmd, commandTimeout)
+	if err != nil {
+		log.Printf("Error while trying to delete the secret of webhook %s in namespace %s:%s\n", s.Name, s.Namespace, string(out))
+		return err
+	}
+	return nil
+}
+
+// Register registers a webhook configuration of kind holding the webhook
+func Register(kind Kind, w Webhook) (*Configuration, error) {
+	configuration := map[string]interface{}{
+		"apiVersion": "admissionregistration.k8s.io/v1beta1",
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": w.Name},
+		"webhooks": []interface{}{
+			map[string]interface{}{
+				"name": w.Name + webhookNameSuffix,
+				"clientConfig": map[string]interface{}{
+					"service":  map[string]interface{}{"name": w.Service, "namespace": w.Namespace, "path": w.Path},
+					"caBundle": w.CABundle,
+				},
+				"rules": []interface{}{
+					map[string]interface{}{
+						"operations":  []string{"CREATE", "UPDATE"},
+						"apiGroups":   []string{""},
+						"apiVersions": []string{"v1"},
+						"resources":   w.Resources,
+					},
+				},
+				"failurePolicy":     w.FailurePolicy,
+				"namespaceSelector": map[string]interface{}{"matchLabels": w.NamespaceSelector},
+			},
+		},
+	}
+	data, err := json.Marshal(configuration)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ioutil.TempFile("", w.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+	f.Close()
+
+	cmd := exec.Command("k", "apply", "-f", f.Name())
+	out, err := util.RunAndLogCommand(cmd, commandTimeout)
+	if err != nil {
+		log.Printf("Error trying to register %s %s:%s\n", kind, w.Name, string(out))
+		return nil, err
+	}
+	return &Configuration{Kind: kind, Name: w.Name}, nil
+}
+
+// Delete deletes the webhook configuration
+func (c *Configuration) Delete() error {
+	cmd := exec.Command("k", "delete", string(c.Kind), c.Name)
+	out, err := util.RunAndLogCommand(cmd, commandTimeout)
+	if err != nil {
+		log.Printf("Error while trying to delete %s %s:%s\n", c.Kind, c.Name, string(out))
+		return err
+	}
+	return nil
+}
+
+// AdmitConfigMap creates a config map with data in namespace then deletes it, and returns the data the API server admitted it
+// with, or an error holding why it was denied
+func AdmitConfigMap(name, namespace string, data map[string]string) (map[string]string, error) {
+	args := []string{"create", "configmap", name, "-n", namespace, "-o", "json"}
+	for k, v := range data {
+		args = append(args, fmt.Sprintf("--from-literal=%s=%s", k, v))
+	}
+	cmd := exec.Command("k", args...)
+	out, err := util.RunAndLogCommand(cmd, commandTimeout)
+	if err != nil {
+		return nil, errors.Errorf("creating config map %s in namespace %s: %s", name, namespace, string(out))
+	}
+	defer exec.Command("k", "delete", "configmap", name, "-n", namespace).Run()
+
+	cm := struct {
+		Data map[string]string `json:"data"`
+	}{}
+	if err = json.Unmarshal(out, &cm); err != nil {
+		log.Printf("Error unmarshalling config map json:%s\n", err)
+		return nil, err
+	}
+	return cm.Data, nil
+}
@@ -42,7 +42,11 @@ type Point struct {
 	TestErrorCount      float64
 	ProvisionErrorCount float64
 	NodeWaitErrorCount  float64
-	Tags                map[string]string
+	// PhaseDurations are the durations of the phases of the cluster creation, by phase
+	PhaseDurations map[string]time.Duration
+	// FailedPhase is the phase of the cluster creation that failed, empty if none did
+	FailedPhase string
+	Tags        map[string]string
 }
 
 // ParseConfig will parse needed environment variables for running the tests
@@ -73,6 +77,7 @@ func BuildPoint(orchestrator, location, clusterDefinition, subscriptionID string
 		ProvisionErrorCount: 0,
 		TestErrorCount:      0,
 		NodeWaitErrorCount:  0,
+		PhaseDurations:      map[string]time.Duration{},
 		Tags: map[string]string{
 			"orchestrator":    orchestrator,
 			"location":        location,
@@ -117,6 +122,20 @@ func (p *Point) RecordNodeWait(err error) {
 	p.NodeWaitDuration = time.Since(p.NodeWaitStart)
 }
 
+// RecordPhase records the duration of a phase of the cluster creation, and the phase as the failed phase if err is
+// set. A phase that succeeds when the provisioning is retried is no longer the failed phase.
+func (p *Point) RecordPhase(phase string, d time.Duration, err error) {
+	if p.PhaseDurations == nil {
+		p.PhaseDurations = map[string]time.Duration{}
+	}
+	p.PhaseDurations[phase] = d
+	if err != nil {
+		p.FailedPhase = phase
+	} else if p.FailedPhase == phase {
+		p.FailedPhase = ""
+	}
+}
+
 // RecordTestError sets appropriate values for when a test error occurs
 func (p *Point) RecordTestError() {
 	p.TestDuration = time.Since(p.TestStart)
@@ -0,0 +1,269 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	ginkgoconfig "github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// FormatPrometheus emits the samples in the Prometheus text format, for the node exporter textfile collector
+	FormatPrometheus = "prometheus"
+	// FormatJSON emits the samples as a JSON array
+	FormatJSON = "json"
+	// suiteSamplesPattern matches the files the test suite processes write their samples to
+	suiteSamplesPattern = "suite-*.json"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// Sample is a value of a metric, like the duration of a spec
+type Sample struct {
+	Name   string            `json:"name"`
+	Help   string            `json:"help,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+// Samples returns the durations and error counts of the point, labelled with its tags
+func (p *Point) Samples() []Sample {
+	sample := func(name, help string, value float64) Sample {
+		return Sample{Name: name, Help: help, Labels: p.Tags, Value: value}
+	}
+	samples := []Sample{
+		sample("e2e_provision_duration_seconds", "Time taken to deploy the cluster.", p.ProvisionDuration.Seconds()),
+		sample("e2e_node_wait_duration_seconds", "Time taken for the nodes of the cluster to become ready.", p.NodeWaitDuration.Seconds()),
+		sample("e2e_test_duration_seconds", "Time taken to run the test suite.", p.TestDuration.Seconds()),
+		sample("e2e_total_duration_seconds", "Time taken by the whole e2e run.", p.OverallDuration.Seconds()),
+		sample("e2e_provision_errors", "Number of failed cluster deployments.", p.ProvisionErrorCount),
+		sample("e2e_node_wait_errors", "Number of times the nodes did not become ready.", p.NodeWaitErrorCount),
+		sample("e2e_test_errors", "Number of failed test suite runs.", p.TestErrorCount),
+	}
+	phases := make([]string, 0, len(p.PhaseDurations))
+	for phase := range p.PhaseDurations {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+	for _, phase := range phases {
+		labels := map[string]string{"phase": phase}
+		for k, v := range p.Tags {
+			labels[k] = v
+		}
+		samples = append(samples, Sample{
+			Name:   "e2e_phase_duration_seconds",
+			Help:   "Time taken by a phase of the cluster creation.",
+			Labels: labels,
+			Value:  p.PhaseDurations[phase].Seconds(),
+		})
+	}
+	return samples
+}
+
+// Recorder collects the samples of a test suite process, it is safe for concurrent use
+type Recorder struct {
+	mu      sync.Mutex
+	samples []Sample
+}
+
+var suiteRecorder = &Recorder{}
+
+// SuiteRecorder returns the recorder of the test suite process, the spec reporter and the specs record their samples to it
+func SuiteRecorder() *Recorder {
+	return suiteRecorder
+}
+
+// Record adds a sample to the recorder
+func (r *Recorder) Record(name, help string, labels map[string]string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples = append(r.samples, Sample{Name: name, Help: help, Labels: labels, Value: value})
+}
+
+// Samples returns the samples recorded so far
+func (r *Recorder) Samples() []Sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	samples := make([]Sample, len(r.samples))
+	copy(samples, r.samples)
+	return samples
+}
+
+// WriteSuiteSamples writes the samples of a test suite process to dir, for the runner to emit them with its own.
+// Every parallel ginkgo node has its own file.
+func (r *Recorder) WriteSuiteSamples(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(r.Samples())
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("suite-%d.json", ginkgoconfig.GinkgoConfig.ParallelNode))
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// ReadSuiteSamples reads the samples the test suite processes wrote to dir, there are none if it does not exist
+func ReadSuiteSamples(dir string) ([]Sample, error) {
+	files, err := filepath.Glob(filepath.Join(dir, suiteSamplesPattern))
+	if err != nil {
+		return nil, err
+	}
+	var samples []Sample
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var s []Sample
+		if err = json.Unmarshal(data, &s); err != nil {
+			return nil, errors.Wrapf(err, "unmarshalling %s", file)
+		}
+		samples = append(samples, s...)
+	}
+	return samples, nil
+}
+
+// WithLabels returns the samples with the labels added, the labels the samples already have are kept
+func WithLabels(samples []Sample, labels map[string]string) []Sample {
+	labelled := make([]Sample, len(samples))
+	for i, s := range samples {
+		l := map[string]string{}
+		for k, v := range labels {
+			l[k] = v
+		}
+		for k, v := range s.Labels {
+			l[k] = v
+		}
+		s.Labels = l
+		labelled[i] = s
+	}
+	return labelled
+}
+
+// Emit writes the samples to path in format, FormatPrometheus or FormatJSON
+func Emit(path, format string, samples []Sample) error {
+	var data []byte
+	var err error
+	switch format {
+	case FormatPrometheus:
+		data = Prometheus(samples)
+	case FormatJSON:
+		data, err = json.MarshalIndent(samples, "", "  ")
+	default:
+		err = errors.Errorf("unknown metrics format %q, expected %q or %q", format, FormatPrometheus, FormatJSON)
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// the textfile collector may read the file at any time, it must never see it half written
+	tmp := path + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Prometheus formats the samples in the Prometheus text format, as gauges grouped by metric name
+func Prometheus(samples []Sample) []byte {
+	var names []string
+	byName := map[string][]Sample{}
+	for _, s := range samples {
+		name := sanitizeName(s.Name)
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], s)
+	}
+	sort.Strings(names)
+	var b bytes.Buffer
+	for _, name := range names {
+		group := byName[name]
+		if group[0].Help != "" {
+			fmt.Fprintf(&b, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(group[0].Help))
+		}
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", name)
+		for _, s := range group {
+			fmt.Fprintf(&b, "%s%s %s\n", name, formatLabels(s.Labels), strconv.FormatFloat(s.Value, 'g', -1, 64))
+		}
+	}
+	return b.Bytes()
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var keys []string
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, sanitizeName(k), escape.Replace(labels[k]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// sanitizeName replaces the characters Prometheus does not allow in metric and label names, like the dashes of the Jenkins tags
+func sanitizeName(name string) string {
+	name = invalidNameChars.ReplaceAllString(name, "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// SpecReporter is a ginkgo reporter that records the duration of every spec that ran
+type SpecReporter struct {
+	Recorder *Recorder
+}
+
+// SpecSuiteWillBegin implements the ginkgo Reporter interface
+func (r *SpecReporter) SpecSuiteWillBegin(config ginkgoconfig.GinkgoConfigType, summary *types.SuiteSummary) {
+}
+
+// BeforeSuiteDidRun implements the ginkgo Reporter interface
+func (r *SpecReporter) BeforeSuiteDidRun(setupSummary *types.SetupSummary) {}
+
+// SpecWillRun implements the ginkgo Reporter interface
+func (r *SpecReporter) SpecWillRun(specSummary *types.SpecSummary) {}
+
+// SpecDidComplete records the duration of the spec, unless it was skipped
+func (r *SpecReporter) SpecDidComplete(specSummary *types.SpecSummary) {
+	if specSummary.Skipped() || specSummary.Pending() {
+		return
+	}
+	state := "passed"
+	if specSummary.HasFailureState() {
+		state = "failed"
+	}
+	// the first component text is the name of the suite
+	spec := strings.Join(specSummary.ComponentTexts[1:], " ")
+	r.Recorder.Record("e2e_spec_duration_seconds", "Time taken to run a spec.", map[string]string{"spec": spec, "state": state}, specSummary.RunTime.Seconds())
+}
+
+// AfterSuiteDidRun implements the ginkgo Reporter interface
+func (r *SpecReporter) AfterSuiteDidRun(setupSummary *types.SetupSummary) {}
+
+// SpecSuiteDidEnd implements the ginkgo Reporter interface
+func (r *SpecReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {}
@@ -0,0 +1,67 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package metrics
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPrometheus(t *testing.T) {
+	samples := []Sample{
+		{Name: "e2e_spec_duration_seconds", Help: "Time taken to run a spec.", Labels: map[string]string{"state": "passed", "spec": `should "work"`}, Value: 1.5},
+		{Name: "e2e_provision_duration_seconds", Labels: map[string]string{"commit-sha": "abc"}, Value: 600},
+		{Name: "e2e_spec_duration_seconds", Labels: map[string]string{"spec": "other", "state": "failed"}, Value: 2},
+	}
+	expected := `# TYPE e2e_provision_duration_seconds gauge
+e2e_provision_duration_seconds{commit_sha="abc"} 600
+# HELP e2e_spec_duration_seconds Time taken to run a spec.
+# TYPE e2e_spec_duration_seconds gauge
+e2e_spec_duration_seconds{spec="should \"work\"",state="passed"} 1.5
+e2e_spec_duration_seconds{spec="other",state="failed"} 2
+`
+	if out := string(Prometheus(samples)); out != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
+func TestSuiteSamples(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	samples, err := ReadSuiteSamples(filepath.Join(dir, "missing"))
+	if err != nil || len(samples) != 0 {
+		t.Fatalf("expected no samples from a missing directory, got %v, %v", samples, err)
+	}
+
+	r := &Recorder{}
+	r.Record("e2e_retries", "", map[string]string{"ginkgo_node": "1"}, 3)
+	if err = r.WriteSuiteSamples(dir); err != nil {
+		t.Fatal(err)
+	}
+	samples, err = ReadSuiteSamples(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples = WithLabels(samples, map[string]string{"location": "westus2", "ginkgo_node": "0"})
+	if len(samples) != 1 || samples[0].Value != 3 || samples[0].Labels["location"] != "westus2" || samples[0].Labels["ginkgo_node"] != "1" {
+		t.Errorf("expected the recorded sample with the location label added, got %v", samples)
+	}
+
+	path := filepath.Join(dir, "e2e.prom")
+	if err = Emit(path, FormatPrometheus, samples); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(path); err != nil {
+		t.Errorf("expected %s to be emitted: %s", path, err)
+	}
+	if err = Emit(path, "csv", samples); err == nil {
+		t.Errorf("expected an unknown format to be an error")
+	}
+}
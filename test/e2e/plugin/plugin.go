@@ -0,0 +1,132 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package plugin lets downstream forks add their own specs and validators to the e2e suite without patching its files.
+// A fork registers its plugins from the init functions of its packages, declares its specs with Requires, and adds
+// a file of its own to test/e2e/kubernetes importing its packages, so that they are built into the suite.
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Azure/aks-engine/test/e2e/capability"
+	"github.com/Azure/aks-engine/test/e2e/config"
+	"github.com/Azure/aks-engine/test/e2e/engine"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/artifacts"
+	"github.com/onsi/ginkgo"
+	"github.com/pkg/errors"
+)
+
+// Context is what the suite shares with the plugins once it is set up
+type Context struct {
+	Config       *config.Config
+	Engine       *engine.Engine
+	Capabilities capability.Set
+	Collector    *artifacts.Collector // Collector collects the artifacts of a failure, nil unless the suite collects artifacts
+}
+
+// Validator checks the cluster in a spec of its own, once the suite is set up
+type Validator struct {
+	Name     string
+	Requires []capability.Capability // Requires are the capabilities the cluster must have for the validator to run
+	Validate func(ctx *Context) error
+}
+
+// Plugin is a set of validators a fork adds to the suite, with the setup they need
+type Plugin struct {
+	Name       string
+	Setup      func(ctx *Context) error // Setup, if set, runs on every ginkgo node once the suite is set up, before any spec
+	Validators []Validator
+}
+
+var (
+	mu      sync.Mutex
+	plugins = map[string]Plugin{}
+	current *Context
+)
+
+// Register adds a plugin to the suite. It panics if the plugin has no name, if a plugin of the same name is already
+// registered or if a validator has no name or no Validate function, since these are programming errors of the fork.
+func Register(p Plugin) {
+	if p.Name == "" {
+		panic("plugin: Register of a plugin without a name")
+	}
+	for _, v := range p.Validators {
+		if v.Name == "" || v.Validate == nil {
+			panic(fmt.Sprintf("plugin: Register of plugin %s with a validator without a name or a Validate function", p.Name))
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, dup := plugins[p.Name]; dup {
+		panic(fmt.Sprintf("plugin: Register called twice for plugin %s", p.Name))
+	}
+	plugins[p.Name] = p
+}
+
+// Registered returns the registered plugins, by name
+func Registered() []Plugin {
+	mu.Lock()
+	defer mu.Unlock()
+	registered := make([]Plugin, 0, len(plugins))
+	for _, p := range plugins {
+		registered = append(registered, p)
+	}
+	sort.Slice(registered, func(i, j int) bool {
+		return registered[i].Name < registered[j].Name
+	})
+	return registered
+}
+
+// SetUp shares ctx with the plugins and runs their Setup, the suite calls it once it is set up
+func SetUp(ctx *Context) error {
+	mu.Lock()
+	current = ctx
+	mu.Unlock()
+	for _, p := range Registered() {
+		if p.Setup == nil {
+			continue
+		}
+		if err := p.Setup(ctx); err != nil {
+			return errors.Wrapf(err, "setting up plugin %s", p.Name)
+		}
+	}
+	return nil
+}
+
+// Current returns the context shared by the suite, nil until the suite is set up. The specs of the plugins read it from
+// their bodies, since they are declared before the suite is set up.
+func Current() *Context {
+	mu.Lock()
+	defer mu.Unlock()
+	return current
+}
+
+// Requirement is the capabilities the cluster must have for the specs of a plugin to run against it
+type Requirement []capability.Capability
+
+// Requires declares specs that only run against the clusters with the capabilities
+func Requires(required ...capability.Capability) Requirement {
+	return Requirement(required)
+}
+
+// It declares a spec tagged with the capabilities it requires, like the specs of the suite: the runner skips the specs
+// the cluster lacks a capability for, and the specs skip themselves when the suite is run on its own
+func (r Requirement) It(text string, body func()) bool {
+	return ginkgo.It(text+" "+capability.Tags(r...), func() {
+		if missing := r.missing(Current()); len(missing) > 0 {
+			ginkgo.Skip(fmt.Sprintf("the cluster does not have the capabilities %v", missing))
+		}
+		body()
+	})
+}
+
+// missing returns the capabilities of r the cluster of ctx lacks, all of them if the suite is not set up
+func (r Requirement) missing(ctx *Context) []capability.Capability {
+	if ctx == nil {
+		return r
+	}
+	return ctx.Capabilities.Missing(r...)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package plugin
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/test/e2e/capability"
+)
+
+func resetPlugins() {
+	plugins = map[string]Plugin{}
+	current = nil
+}
+
+func expectPanic(t *testing.T, substring string, f func()) {
+	defer func() {
+		r := recover()
+		if r == nil || !strings.Contains(r.(string), substring) {
+			t.Errorf("expected a panic containing %q, got %v", substring, r)
+		}
+	}()
+	f()
+}
+
+func TestRegister(t *testing.T) {
+	defer resetPlugins()
+	validate := func(ctx *Context) error { return nil }
+	Register(Plugin{Name: "contoso-storage", Validators: []Validator{{Name: "disks", Validate: validate}}})
+	Register(Plugin{Name: "contoso-network"})
+
+	var names []string
+	for _, p := range Registered() {
+		names = append(names, p.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"contoso-network", "contoso-storage"}) {
+		t.Errorf("expected the plugins to be registered by name, got %v", names)
+	}
+
+	expectPanic(t, "called twice for plugin contoso-network", func() { Register(Plugin{Name: "contoso-network"}) })
+	expectPanic(t, "without a name", func() { Register(Plugin{}) })
+	expectPanic(t, "validator without a name or a Validate function", func() {
+		Register(Plugin{Name: "contoso-dns", Validators: []Validator{{Name: "resolution"}}})
+	})
+}
+
+func TestSetUp(t *testing.T) {
+	defer resetPlugins()
+	var setUp []string
+	Register(Plugin{Name: "b", Setup: func(ctx *Context) error {
+		setUp = append(setUp, "b")
+		return nil
+	}})
+	Register(Plugin{Name: "a", Setup: func(ctx *Context) error {
+		if Current() != ctx {
+			t.Error("expected the context to be shared before the plugins are set up")
+		}
+		setUp = append(setUp, "a")
+		return nil
+	}})
+	Register(Plugin{Name: "c"})
+
+	if Current() != nil {
+		t.Error("expected no context before the suite is set up")
+	}
+	ctx := &Context{Capabilities: capability.Set{capability.Linux: true}}
+	if err := SetUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(setUp, []string{"a", "b"}) {
+		t.Errorf("expected the plugins to be set up by name, got %v", setUp)
+	}
+
+	Register(Plugin{Name: "d", Setup: func(ctx *Context) error { return errors.New("no quota") }})
+	if err := SetUp(ctx); err == nil || err.Error() != "setting up plugin d: no quota" {
+		t.Errorf("expected the error of the plugin that failed to set up, got %v", err)
+	}
+}
+
+func TestRequirementMissing(t *testing.T) {
+	r := Requires(capability.Linux, capability.Windows)
+	if missing := r.missing(nil); !reflect.DeepEqual(missing, []capability.Capability{capability.Linux, capability.Windows}) {
+		t.Errorf("expected all the capabilities to be missing before the suite is set up, got %v", missing)
+	}
+	ctx := &Context{Capabilities: capability.Set{capability.Linux: true}}
+	if missing := r.missing(ctx); !reflect.DeepEqual(missing, []capability.Capability{capability.Windows}) {
+		t.Errorf("expected the capabilities the cluster lacks to be missing, got %v", missing)
+	}
+}
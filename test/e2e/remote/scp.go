@@ -0,0 +1,138 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package remote
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// The files are transferred with the scp protocol, which the OpenSSH servers of Linux and Windows nodes both support:
+// the remote side runs scp in sink (-t) or source (-f) mode and both sides exchange a header, the content and acknowledgements.
+
+// scpUpload writes data to remotePath with the permissions mode in session s
+func scpUpload(s *ssh.Session, data []byte, mode uint32, remotePath string) error {
+	stdin, err := s.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := s.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err = s.Start(fmt.Sprintf("scp -t %s", remotePath)); err != nil {
+		return err
+	}
+	r := bufio.NewReader(stdout)
+	if err = readAck(r); err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(stdin, "C%04o %d %s\n", mode, len(data), path.Base(remotePath)); err != nil {
+		return err
+	}
+	if err = readAck(r); err != nil {
+		return err
+	}
+	if _, err = stdin.Write(data); err != nil {
+		return err
+	}
+	if _, err = stdin.Write([]byte{0}); err != nil {
+		return err
+	}
+	if err = readAck(r); err != nil {
+		return err
+	}
+	stdin.Close()
+	return s.Wait()
+}
+
+// scpDownload returns the content of remotePath in session s
+func scpDownload(s *ssh.Session, remotePath string) ([]byte, error) {
+	stdin, err := s.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := s.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = s.Start(fmt.Sprintf("scp -f %s", remotePath)); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(stdout)
+	if _, err = stdin.Write([]byte{0}); err != nil {
+		return nil, err
+	}
+	header, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	// C<mode> <size> <name>
+	fields := strings.SplitN(header, " ", 3)
+	if len(fields) != 3 || !strings.HasPrefix(fields[0], "C") {
+		return nil, errors.Errorf("unexpected scp header %q for %s", header, remotePath)
+	}
+	size, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing the size of %s in scp header %q", remotePath, header)
+	}
+	if _, err = stdin.Write([]byte{0}); err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != size {
+		return nil, errors.Errorf("read %d bytes of %s, expected %d", len(data), remotePath, size)
+	}
+	if err = readAck(r); err != nil {
+		return nil, err
+	}
+	if _, err = stdin.Write([]byte{0}); err != nil {
+		return nil, err
+	}
+	stdin.Close()
+	return data, s.Wait()
+}
+
+// readAck reads an acknowledgement, a 0 byte, or an error message
+func readAck(r *bufio.Reader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b == 0 {
+		return nil
+	}
+	msg, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	return errors.New(strings.TrimSpace(msg))
+}
+
+// readLine reads a header line, unless the remote side sent an error message instead
+func readLine(r *bufio.Reader) (string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if b == 1 || b == 2 {
+		return "", errors.New(strings.TrimSpace(line))
+	}
+	return string(b) + strings.TrimSuffix(line, "\n"), nil
+}
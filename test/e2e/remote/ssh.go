@@ -4,25 +4,30 @@
 package remote
 
 import (
-	"fmt"
 	"io/ioutil"
 	"log"
 	"net"
-	"os"
-	"os/exec"
 	"path/filepath"
+	"sync"
+	"time"
 
-	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
+	"github.com/pkg/errors"
 	"golang.org/x/crypto/ssh"
-	"golang.org/x/crypto/ssh/agent"
 )
 
 const (
 	sshRetries = 20
 	scriptsDir = "scripts"
+	// nodeSSHPort is the port sshd listens on on the nodes, which are reached through the master
+	nodeSSHPort = "22"
+	dialTimeout = 30 * time.Second
+	// DefaultCommandTimeout bounds the commands and file transfers that do not take a timeout
+	DefaultCommandTimeout = 5 * time.Minute
+	retrySleep            = 5 * time.Second
 )
 
-// Connection is
+// Connection is a persistent SSH connection to the master, through which the other nodes of the cluster are reached.
+// The connections to the nodes are opened on first use and reused until Close.
 type Connection struct {
 	Host           string
 	Port           string
@@ -30,177 +35,283 @@ type Connection struct {
 	PrivateKeyPath string
 	ClientConfig   *ssh.ClientConfig
 	Client         *ssh.Client
+
+	mu    sync.Mutex
+	nodes map[string]*ssh.Client
 }
 
 // NewConnection will build and return a new Connection object
 func NewConnection(host, port, user, keyPath string) (*Connection, error) {
-	conn, err := net.Dial("unix", os.Getenv("SSH_AUTH_SOCK"))
-	if err != nil {
-		log.Printf("unable to establish net connection $SSH_AUTH_SOCK has value %s\n", os.Getenv("SSH_AUTH_SOCK"))
-		return nil, err
-	}
-	defer conn.Close()
-	ag := agent.NewClient(conn)
-
 	privateKeyBytes, err := ioutil.ReadFile(keyPath)
 	if err != nil {
 		return nil, err
 	}
-
-	privateKey, err := ssh.ParseRawPrivateKey(privateKeyBytes)
+	signer, err := ssh.ParsePrivateKey(privateKeyBytes)
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrapf(err, "parsing the ssh private key %s", keyPath)
 	}
-	addKey := agent.AddedKey{
-		PrivateKey: privateKey,
-	}
-
-	ag.Add(addKey)
-	signers, err := ag.Signers()
-	if err != nil {
-		log.Println("unable to add key to agent")
-		return nil, err
-	}
-	auths := []ssh.AuthMethod{ssh.PublicKeys(signers...)}
 
 	cfg := &ssh.ClientConfig{
 		User:            user,
-		Auth:            auths,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         dialTimeout,
 	}
 
-	cnctStr := fmt.Sprintf("%s:%s", host, port)
-	sshClient, err := ssh.Dial("tcp", cnctStr, cfg)
-	if err != nil {
-		return nil, err
-	}
-
-	return &Connection{
+	c := &Connection{
 		Host:           host,
 		Port:           port,
 		User:           user,
 		PrivateKeyPath: keyPath,
 		ClientConfig:   cfg,
-		Client:         sshClient,
-	}, nil
+		nodes:          map[string]*ssh.Client{},
+	}
+	if c.Client, err = c.dialMaster(); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
-// Execute will execute a given cmd on a remote host
-func (c *Connection) Execute(cmd string, printStdout bool) error {
-	session, err := c.Client.NewSession()
+func (c *Connection) dialMaster() (*ssh.Client, error) {
+	client, err := ssh.Dial("tcp", net.JoinHostPort(c.Host, c.Port), c.ClientConfig)
 	if err != nil {
-		return err
+		return nil, errors.Wrapf(err, "connecting to %s@%s:%s", c.User, c.Host, c.Port)
 	}
-	defer session.Close()
+	return client, nil
+}
 
-	fmt.Printf("\n$ %s\n", cmd)
-	out, err := session.CombinedOutput(cmd)
+// dialNode opens a connection to a node, tunneled through the connection to the master
+func (c *Connection) dialNode(node string) (*ssh.Client, error) {
+	addr := net.JoinHostPort(node, nodeSSHPort)
+	conn, err := c.Client.Dial("tcp", addr)
 	if err != nil {
-		log.Printf("Error output:%s\n", out)
-		return err
+		return nil, errors.Wrapf(err, "connecting to %s through %s", addr, c.Host)
 	}
-	if printStdout {
-		log.Printf("%s\n", out)
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, addr, c.ClientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrapf(err, "opening an ssh connection to %s through %s", addr, c.Host)
+	}
+	return ssh.NewClient(clientConn, chans, reqs), nil
+}
+
+// client returns the connection to a node, "" being the master, and opens it if it is not yet
+func (c *Connection) client(node string) (*ssh.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if node == "" {
+		if c.Client == nil {
+			client, err := c.dialMaster()
+			if err != nil {
+				return nil, err
+			}
+			c.Client = client
+		}
+		return c.Client, nil
+	}
+	if client, ok := c.nodes[node]; ok {
+		return client, nil
+	}
+	if c.Client == nil {
+		client, err := c.dialMaster()
+		if err != nil {
+			return nil, err
+		}
+		c.Client = client
+	}
+	client, err := c.dialNode(node)
+	if err != nil {
+		return nil, err
+	}
+	c.nodes[node] = client
+	return client, nil
+}
+
+// reset closes the connection to a node after it broke, so that it is opened again on next use.
+// The connections to the nodes go through the master, they all break with the connection to the master.
+func (c *Connection) reset(node string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if node != "" {
+		if client, ok := c.nodes[node]; ok {
+			client.Close()
+			delete(c.nodes, node)
+		}
+		return
+	}
+	for n, client := range c.nodes {
+		client.Close()
+		delete(c.nodes, n)
 	}
+	if c.Client != nil {
+		c.Client.Close()
+		c.Client = nil
+	}
+}
+
+// Close closes the connections to the nodes and to the master
+func (c *Connection) Close() error {
+	c.reset("")
 	return nil
 }
 
-func (c *Connection) Write(data, path string) error {
-	remoteCommand := fmt.Sprintf("echo %s > %s", data, path)
-	connectString := fmt.Sprintf("%s@%s", c.User, c.Host)
-	cmd := exec.Command("ssh", "-i", c.PrivateKeyPath, "-o", "ConnectTimeout=30", "-o", "StrictHostKeyChecking=no", connectString, "-p", c.Port, remoteCommand)
-	util.PrintCommand(cmd)
-	out, err := cmd.CombinedOutput()
+// session runs fn in a new session on a node, "" being the master. The session is closed after timeout, which fails fn.
+// A connection that cannot open sessions is broken, it is opened again once.
+func (c *Connection) session(node string, timeout time.Duration, fn func(s *ssh.Session) error) error {
+	var s *ssh.Session
+	for i := 0; i < 2; i++ {
+		client, err := c.client(node)
+		if err != nil {
+			return err
+		}
+		if s, err = client.NewSession(); err == nil {
+			break
+		}
+		if i > 0 {
+			return errors.Wrapf(err, "opening an ssh session on %s", c.hostname(node))
+		}
+		c.reset(node)
+	}
+	defer s.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(s)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		s.Signal(ssh.SIGKILL)
+		s.Close()
+		return errors.Errorf("timed out after %s on %s", timeout, c.hostname(node))
+	}
+}
+
+// Run runs a command on a node, "" being the master, and returns its combined output. The command fails after timeout.
+func (c *Connection) Run(node, cmd string, timeout time.Duration) ([]byte, error) {
+	var out []byte
+	start := time.Now()
+	log.Printf("$ %s %s", c.hostname(node), cmd)
+	err := c.session(node, timeout, func(s *ssh.Session) error {
+		var err error
+		out, err = s.CombinedOutput(cmd)
+		return err
+	})
+	log.Printf("#### %s %s completed in %s", c.hostname(node), cmd, time.Since(start))
+	return out, err
+}
+
+// Upload writes a local file to remotePath on a node, "" being the master
+func (c *Connection) Upload(node, localPath, remotePath string, timeout time.Duration) error {
+	data, err := ioutil.ReadFile(localPath)
 	if err != nil {
-		log.Printf("Error output:%s\n", out)
 		return err
 	}
-	return nil
+	return c.write(node, data, 0755, remotePath, timeout)
 }
 
-func (c *Connection) Read(path string) ([]byte, error) {
-	remoteCommand := fmt.Sprintf("cat %s", path)
-	connectString := fmt.Sprintf("%s@%s", c.User, c.Host)
-	cmd := exec.Command("ssh", "-i", c.PrivateKeyPath, "-o", "ConnectTimeout=30", "-o", "StrictHostKeyChecking=no", connectString, "-p", c.Port, remoteCommand)
-	util.PrintCommand(cmd)
-	out, err := cmd.CombinedOutput()
+// Download writes remotePath on a node, "" being the master, to a local file
+func (c *Connection) Download(node, remotePath, localPath string, timeout time.Duration) error {
+	data, err := c.read(node, remotePath, timeout)
 	if err != nil {
-		log.Printf("Error output:%s\n", out)
-		return nil, err
+		return err
 	}
-	return out, nil
+	return ioutil.WriteFile(localPath, data, 0644)
 }
 
-// CopyTo uses this ssh connection to send files to the remote ssh listener's underlying file system
-func (c *Connection) CopyTo(filename string) error {
-	var scpError error
-	var scpOut []byte
+func (c *Connection) write(node string, data []byte, mode uint32, remotePath string, timeout time.Duration) error {
+	err := c.session(node, timeout, func(s *ssh.Session) error {
+		return scpUpload(s, data, mode, remotePath)
+	})
+	return errors.Wrapf(err, "writing %s on %s", remotePath, c.hostname(node))
+}
+
+func (c *Connection) read(node, remotePath string, timeout time.Duration) ([]byte, error) {
+	var data []byte
+	err := c.session(node, timeout, func(s *ssh.Session) error {
+		var err error
+		data, err = scpDownload(s, remotePath)
+		return err
+	})
+	return data, errors.Wrapf(err, "reading %s on %s", remotePath, c.hostname(node))
+}
+
+func (c *Connection) hostname(node string) string {
+	if node == "" {
+		return c.Host
+	}
+	return node
+}
+
+// retry calls fn up to sshRetries times while it fails without running, e.g. because the node cannot be reached yet.
+// A command that ran and exited with an error is not retried.
+func retry(fn func() error) error {
+	var err error
 	for i := 0; i < sshRetries; i++ {
-		connectString := fmt.Sprintf("%s@%s", c.User, c.Host)
-		cmd := exec.Command("scp", "-i", c.PrivateKeyPath, "-P", c.Port, "-o", "StrictHostKeyChecking=no", filepath.Join(scriptsDir, filename), connectString+":/tmp/"+filename)
-		util.PrintCommand(cmd)
-		scpOut, scpError = cmd.CombinedOutput()
-		if scpError != nil {
-			log.Printf("Error output:%s\n", scpOut)
-			continue
-		} else {
-			break
+		if err = fn(); err == nil {
+			return nil
+		}
+		if _, ok := errors.Cause(err).(*ssh.ExitError); ok {
+			return err
 		}
+		time.Sleep(retrySleep)
 	}
-	return scpError
+	return err
 }
 
-// CopyFrom uses this ssh connection to get remote files via scp
-func (c *Connection) CopyFrom(hostname, path string) error {
-	remoteCommand := fmt.Sprintf("scp -o StrictHostKeyChecking=no %s:%s /tmp/%s-%s", hostname, path, hostname, filepath.Base(path))
-	connectString := fmt.Sprintf("%s@%s", c.User, c.Host)
-	cmd := exec.Command("ssh", "-A", "-i", c.PrivateKeyPath, "-o", "ConnectTimeout=30", "-o", "StrictHostKeyChecking=no", connectString, "-p", c.Port, remoteCommand)
-	util.PrintCommand(cmd)
-	out, err := cmd.CombinedOutput()
+// Execute will execute a given cmd on the master
+func (c *Connection) Execute(cmd string, printStdout bool) error {
+	out, err := c.Run("", cmd, DefaultCommandTimeout)
 	if err != nil {
 		log.Printf("Error output:%s\n", out)
 		return err
 	}
+	if printStdout {
+		log.Printf("%s\n", out)
+	}
 	return nil
 }
 
-// CopyToRemote uses this ssh connection to send files via scp to a remote host
+// Write writes data to path on the master
+func (c *Connection) Write(data, path string) error {
+	return c.write("", []byte(data), 0644, path, DefaultCommandTimeout)
+}
+
+// Read returns the content of path on the master
+func (c *Connection) Read(path string) ([]byte, error) {
+	return c.read("", path, DefaultCommandTimeout)
+}
+
+// CopyTo sends a file of the scripts directory to /tmp on the master
+func (c *Connection) CopyTo(filename string) error {
+	return retry(func() error {
+		return c.Upload("", filepath.Join(scriptsDir, filename), "/tmp/"+filename, DefaultCommandTimeout)
+	})
+}
+
+// CopyToRemote copies path on the master to the same path on a node
 func (c *Connection) CopyToRemote(hostname, path string) error {
-	var sshError error
-	var sshOut []byte
-	for i := 0; i < sshRetries; i++ {
-		remoteCommand := fmt.Sprintf("scp -o StrictHostKeyChecking=no %s %s:%s", path, hostname, path)
-		connectString := fmt.Sprintf("%s@%s", c.User, c.Host)
-		cmd := exec.Command("ssh", "-A", "-i", c.PrivateKeyPath, "-o", "ConnectTimeout=30", "-o", "StrictHostKeyChecking=no", connectString, "-p", c.Port, remoteCommand)
-		util.PrintCommand(cmd)
-		sshOut, sshError = cmd.CombinedOutput()
-		if sshError != nil {
-			log.Printf("Error output:%s\n", sshOut)
-			continue
-		} else {
-			break
+	return retry(func() error {
+		data, err := c.read("", path, DefaultCommandTimeout)
+		if err != nil {
+			return err
 		}
-	}
-	return sshError
+		return c.write(hostname, data, 0755, path, DefaultCommandTimeout)
+	})
 }
 
-// ExecuteRemote uses this ssh connection to run a remote command from the primary master node
+// ExecuteRemote runs a command on a node, which is reached through the master
 func (c *Connection) ExecuteRemote(node, command string, printStdout bool) error {
-	var sshError error
-	var sshOut []byte
-	for i := 0; i < sshRetries; i++ {
-		cmd := exec.Command("ssh", "-A", "-i", c.PrivateKeyPath, "-p", c.Port, "-o", "ConnectTimeout=10", "-o", "StrictHostKeyChecking=no", "-o", "UserKnownHostsFile=/dev/null", "-o", "LogLevel=ERROR", fmt.Sprintf("%s@%s", c.User, c.Host), "ssh", "-o", "ConnectTimeout=10", "-o", "StrictHostKeyChecking=no", "-o", "UserKnownHostsFile=/dev/null", "-o", "LogLevel=ERROR", node, command)
-		util.PrintCommand(cmd)
-		sshOut, sshError = cmd.CombinedOutput()
-		if sshError != nil {
-			log.Printf("Error output:%s\n", sshOut)
-			continue
-		} else {
-			if printStdout {
-				log.Printf("%s\n", sshOut)
-			}
-			break
+	return retry(func() error {
+		out, err := c.Run(node, command, DefaultCommandTimeout)
+		if err != nil {
+			log.Printf("Error output:%s\n", out)
+			return err
 		}
-	}
-	return sshError
+		if printStdout {
+			log.Printf("%s\n", out)
+		}
+		return nil
+	})
 }
@@ -4,7 +4,7 @@
 package main
 
 import (
-	"fmt"
+	"flag"
 	"log"
 	"os"
 	"os/signal"
@@ -37,6 +37,8 @@ func main() {
 		log.Fatalf("Error while trying to parse configuration: %s\n", err)
 	}
 	cfg.CurrentWorkingDir = cwd
+	flag.StringVar(&cfg.FocusProfile, "focus-profile", cfg.FocusProfile, "run only the specs relevant to this cluster definition, defaults to FOCUS_PROFILE")
+	flag.Parse()
 
 	if cfg.IsAzureStackCloud() {
 		cccfg, err = config.ParseCustomCloudConfig()
@@ -216,8 +218,11 @@ func trap() {
 func teardown() {
 	pt.RecordTotalTime()
 	pt.Write()
-	hostname := fmt.Sprintf("%s.%s.cloudapp.azure.com", cfg.Name, cfg.Location)
-	logsPath := filepath.Join(cfg.CurrentWorkingDir, "_logs", hostname)
+	if cfg.MetricsFormat != "" {
+		emitMetrics()
+	}
+	writeSummary()
+	logsPath := cfg.GetLogsPath()
 	err := os.MkdirAll(logsPath, 0755)
 	if err != nil {
 		log.Printf("cannot create directory for logs: %s", err)
@@ -254,3 +259,38 @@ func teardown() {
 		}
 	}
 }
+
+// emitMetrics emits the timing metrics of the run with those the test suite recorded, labelled with the tags of the run
+func emitMetrics() {
+	samples, err := metrics.ReadSuiteSamples(cfg.GetMetricsPath())
+	if err != nil {
+		log.Printf("cannot read the metrics of the test suite: %s", err)
+	}
+	samples = metrics.WithLabels(append(pt.Samples(), samples...), pt.Tags)
+	file := "e2e.json"
+	if cfg.MetricsFormat == metrics.FormatPrometheus {
+		// the node exporter textfile collector only reads .prom files
+		file = "e2e.prom"
+	}
+	path := filepath.Join(cfg.GetMetricsPath(), file)
+	if err = metrics.Emit(path, cfg.MetricsFormat, samples); err != nil {
+		log.Printf("cannot emit the metrics: %s", err)
+		return
+	}
+	log.Printf("Emitted the metrics of the run to %s\n", path)
+}
+
+// writeSummary writes the JSON summary of the run to the results directory, with the JUnit XML of the test suite
+func writeSummary() {
+	resultsPath := cfg.GetResultsPath()
+	summary, err := runner.BuildSummary(cfg, eng, pt, resultsPath)
+	if err != nil {
+		log.Printf("cannot summarize the results: %s", err)
+		return
+	}
+	if err = summary.Write(resultsPath); err != nil {
+		log.Printf("cannot write the summary of the results: %s", err)
+		return
+	}
+	log.Printf("Wrote the summary of the results to %s\n", filepath.Join(resultsPath, runner.SummaryFile))
+}
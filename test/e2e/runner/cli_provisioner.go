@@ -4,6 +4,7 @@
 package runner
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -17,10 +18,12 @@ import (
 
 	"github.com/Azure/go-autorest/autorest/to"
 
+	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/aks-engine/test/e2e/azure"
 	"github.com/Azure/aks-engine/test/e2e/config"
 	"github.com/Azure/aks-engine/test/e2e/engine"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/node"
+	"github.com/Azure/aks-engine/test/e2e/kubernetes/pod"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
 	"github.com/Azure/aks-engine/test/e2e/metrics"
 	"github.com/Azure/aks-engine/test/e2e/remote"
@@ -242,7 +245,10 @@ func (cli *CLIProvisioner) provision() error {
 func (cli *CLIProvisioner) generateAndDeploy() error {
 	if cli.Config.UseDeployCommand {
 		fmt.Printf("Provisionning with the Deploy Command\n")
-		err := cli.Engine.Deploy(cli.Config.Location)
+		err := runPhase(cli.Point, PhaseARMDeployment, cli.Config.ARMDeployTimeout, func(ctx context.Context, report func(string)) error {
+			report(fmt.Sprintf("deploying resource group %s with aks-engine deploy", cli.Config.Name))
+			return cli.Engine.Deploy(ctx, cli.Config.Location)
+		})
 		if err != nil {
 			return errors.Wrap(err, "Error while trying to deploy aks-engine template")
 		}
@@ -277,7 +283,10 @@ func (cli *CLIProvisioner) generateAndDeploy() error {
 
 	//if we use Generate, then we need to call CreateDeployment
 	if !cli.Config.UseDeployCommand {
-		err = cli.Account.CreateDeployment(cli.Config.Name, cli.Engine)
+		err = runPhase(cli.Point, PhaseARMDeployment, cli.Config.ARMDeployTimeout, func(ctx context.Context, report func(string)) error {
+			report(fmt.Sprintf("waiting for deployment %s of resource group %s to succeed", cli.Config.Name, cli.Config.Name))
+			return cli.Account.CreateDeployment(ctx, cli.Config.Name, cli.Engine)
+		})
 		if err != nil {
 			return errors.Wrap(err, "Error while trying to create deployment")
 		}
@@ -296,6 +305,9 @@ func (cli *CLIProvisioner) generateName() string {
 func (cli *CLIProvisioner) waitForNodes() error {
 	if cli.Config.IsKubernetes() {
 		if !cli.IsPrivate() {
+			if err := runPhase(cli.Point, PhaseMastersRegistered, cli.Config.MastersTimeout, cli.waitForMasters); err != nil {
+				return err
+			}
 			log.Println("Waiting on nodes to go into ready state...")
 			var expectedReadyNodes int
 			if !cli.Engine.ExpandedDefinition.Properties.HasLowPriorityScaleset() {
@@ -304,15 +316,26 @@ func (cli *CLIProvisioner) waitForNodes() error {
 			} else {
 				expectedReadyNodes = -1
 			}
-			ready := node.WaitOnReady(expectedReadyNodes, 10*time.Second, cli.Config.Timeout)
-			cmd := exec.Command("k", "get", "nodes", "-o", "wide")
-			out, _ := cmd.CombinedOutput()
-			log.Printf("%s\n", out)
-			if !ready {
-				return errors.New("Error: Not all nodes in a healthy state")
+			nodesReadyTimeout := cli.Config.NodesReadyTimeout
+			if nodesReadyTimeout == 0 {
+				nodesReadyTimeout = cli.Config.Timeout
+			}
+			err := runPhase(cli.Point, PhaseNodesReady, nodesReadyTimeout, func(ctx context.Context, report func(string)) error {
+				report(fmt.Sprintf("waiting for %d Ready nodes", expectedReadyNodes))
+				ready := node.WaitOnReady(expectedReadyNodes, 10*time.Second, remaining(ctx))
+				cmd := exec.Command("k", "get", "nodes", "-o", "wide")
+				out, _ := cmd.CombinedOutput()
+				log.Printf("%s\n", out)
+				if !ready {
+					report(notReadyNodes())
+					return errors.New("Error: Not all nodes in a healthy state")
+				}
+				return nil
+			})
+			if err != nil {
+				return err
 			}
 			var version string
-			var err error
 			if cli.Config.IsKubernetes() {
 				version, err = node.Version()
 			}
@@ -348,6 +371,9 @@ func (cli *CLIProvisioner) waitForNodes() error {
 					}
 				}
 			}
+			if err := runPhase(cli.Point, PhaseAddonsHealthy, cli.Config.AddonsTimeout, waitForAddons); err != nil {
+				return err
+			}
 		} else {
 			log.Println("This cluster is private")
 			if cli.Engine.ClusterDefinition.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster.JumpboxProfile == nil {
@@ -355,13 +381,146 @@ func (cli *CLIProvisioner) waitForNodes() error {
 				return errors.New("Error: cannot test a private cluster without provisioning a jumpbox")
 			}
 			log.Printf("Testing a %s private cluster...", cli.Config.Orchestrator)
-			// TODO: create SSH connection and get nodes and k8s version
+			nodesReadyTimeout := cli.Config.NodesReadyTimeout
+			if nodesReadyTimeout == 0 {
+				nodesReadyTimeout = cli.Config.Timeout
+			}
+			if err := runPhase(cli.Point, PhaseJumpboxNodesReady, nodesReadyTimeout, cli.waitForNodesThroughJumpbox); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+// waitForMasters waits until all the masters of the cluster have registered as nodes
+func (cli *CLIProvisioner) waitForMasters(ctx context.Context, report func(string)) error {
+	expected := cli.Engine.ExpandedDefinition.Properties.MasterProfile.Count
+	for {
+		nodes, err := node.GetByRegex("k8s-master")
+		if err == nil {
+			report(fmt.Sprintf("%d of %d masters registered", len(nodes), expected))
+			if len(nodes) >= expected {
+				return nil
+			}
+		} else {
+			report(fmt.Sprintf("the nodes cannot be listed: %s", err))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+// waitForNodesThroughJumpbox waits until kubectl on the jumpbox of a private cluster lists all the nodes as Ready.
+// The jumpbox is the only host with a public IP, and its kubeconfig reaches the API server inside the VNET, through
+// the fqdn its private DNS zone resolves to the private endpoint of the API server if the cluster has Private Link.
+func (cli *CLIProvisioner) waitForNodesThroughJumpbox(ctx context.Context, report func(string)) error {
+	properties := cli.Engine.ExpandedDefinition.Properties
+	kubernetesConfig := properties.OrchestratorProfile.KubernetesConfig
+	jumpbox := fmt.Sprintf("%s.%s.cloudapp.azure.com", cli.Config.Name, cli.Config.Location)
+	var privateLinkFQDN string
+	if kubernetesConfig.PrivateLinkEnabled() {
+		privateLinkFQDN = api.FormatPrivateLinkFQDNByLocation(properties.MasterProfile.DNSPrefix, cli.Config.Location, properties.GetCustomCloudName())
+	}
+	expected := cli.Engine.NodeCount()
+
+	var conn *remote.Connection
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+	for {
+		var err error
+		if conn == nil {
+			conn, err = remote.NewConnection(jumpbox, "22", kubernetesConfig.PrivateCluster.JumpboxProfile.Username, cli.Config.GetSSHKeyPath())
+		}
+		if err != nil {
+			report(fmt.Sprintf("the jumpbox %s cannot be reached: %s", jumpbox, err))
+		} else {
+			status, done := jumpboxNodesStatus(conn, privateLinkFQDN, expected)
+			report(status)
+			if done {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+// jumpboxNodesStatus returns how many nodes kubectl on the jumpbox lists as Ready, and whether they are all Ready
+func jumpboxNodesStatus(conn *remote.Connection, privateLinkFQDN string, expected int) (string, bool) {
+	if privateLinkFQDN != "" {
+		out, err := conn.Run("", fmt.Sprintf("getent hosts %s", privateLinkFQDN), remote.DefaultCommandTimeout)
+		if err != nil {
+			return fmt.Sprintf("the jumpbox cannot resolve the private link fqdn %s of the API server: %s", privateLinkFQDN, strings.TrimSpace(string(out))), false
+		}
+	}
+	out, err := conn.Run("", "kubectl get nodes --no-headers", remote.DefaultCommandTimeout)
+	if err != nil {
+		return fmt.Sprintf("kubectl on the jumpbox cannot list the nodes: %s", strings.TrimSpace(string(out))), false
+	}
+	var ready int
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if fields := strings.Fields(line); len(fields) > 1 && fields[1] == "Ready" {
+			ready++
+		}
+	}
+	return fmt.Sprintf("%d of %d nodes are Ready through the jumpbox", ready, expected), ready >= expected
+}
+
+// waitForAddons waits until all the pods in kube-system are Ready, or have succeeded if they are the pods of jobs
+func waitForAddons(ctx context.Context, report func(string)) error {
+	for {
+		pods, err := pod.GetAll("kube-system")
+		if err == nil {
+			var unhealthy []string
+			for _, p := range pods.Pods {
+				if p.Status.Phase != "Succeeded" && !p.IsReady() {
+					unhealthy = append(unhealthy, fmt.Sprintf("%s is %s", p.Metadata.Name, p.Status.Phase))
+				}
+			}
+			if len(pods.Pods) > 0 && len(unhealthy) == 0 {
+				return nil
+			}
+			report(fmt.Sprintf("%d of %d kube-system pods are not Ready: %s", len(unhealthy), len(pods.Pods), strings.Join(unhealthy, ", ")))
+		} else {
+			report(fmt.Sprintf("the kube-system pods cannot be listed: %s", err))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+// notReadyNodes describes the nodes that are not Ready
+func notReadyNodes() string {
+	list, err := node.Get()
+	if err != nil {
+		return fmt.Sprintf("the nodes cannot be listed: %s", err)
+	}
+	var notReady []string
+	for _, n := range list.Nodes {
+		if !n.IsReady() {
+			notReady = append(notReady, n.Metadata.Name)
+		}
+	}
+	if len(notReady) == 0 {
+		return fmt.Sprintf("%d nodes are Ready", len(list.Nodes))
+	}
+	return fmt.Sprintf("%d of %d nodes are not Ready: %s", len(notReady), len(list.Nodes), strings.Join(notReady, ", "))
+}
+
 // FetchProvisioningMetrics gets provisioning files from all hosts in a cluster
 func (cli *CLIProvisioner) FetchProvisioningMetrics(path string, cfg *config.Config, acct *azure.Account) error {
 	agentFiles := []string{"/var/log/azure/cluster-provision.log", "/var/log/cloud-init.log",
@@ -372,43 +531,28 @@ func (cli *CLIProvisioner) FetchProvisioningMetrics(path string, cfg *config.Con
 	masterFiles := agentFiles
 	masterFiles = append(masterFiles, "/opt/azure/containers/mountetcd.sh", "/opt/azure/containers/setup-etcd.sh", "/opt/azure/containers/setup-etcd.log")
 	hostname := fmt.Sprintf("%s.%s.cloudapp.azure.com", cli.Config.Name, cli.Config.Location)
-	cmd := exec.Command("ssh-agent", "-s")
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return errors.Wrapf(err, "Error while trying to start ssh agent \nOutput:%s", out)
-	}
-	authSock := strings.Split(strings.Split(string(out), "=")[1], ";")
-	os.Setenv("SSH_AUTH_SOCK", authSock[0])
-	var conn *remote.Connection
-	conn, err = remote.NewConnection(hostname, "22", cli.Engine.ClusterDefinition.Properties.LinuxProfile.AdminUsername, cli.Config.GetSSHKeyPath())
+	conn, err := remote.NewConnection(hostname, "22", cli.Engine.ClusterDefinition.Properties.LinuxProfile.AdminUsername, cli.Config.GetSSHKeyPath())
 	if err != nil {
 		return err
 	}
-	for _, master := range cli.Masters {
-		for _, fp := range masterFiles {
-			err = conn.CopyFrom(master.Name, fp)
-			if err != nil {
-				log.Printf("Error reading file from path (%s):%s", path, err)
-			}
-		}
+	defer conn.Close()
+	logsPath := filepath.Join(cfg.CurrentWorkingDir, "_logs", hostname)
+	if err = os.MkdirAll(logsPath, 0755); err != nil {
+		return errors.Wrapf(err, "creating the logs directory %s", logsPath)
 	}
-
-	for _, agent := range cli.Agents {
-		for _, fp := range agentFiles {
-			err = conn.CopyFrom(agent.Name, fp)
-			if err != nil {
-				log.Printf("Error reading file from path (%s):%s", path, err)
+	download := func(node string, files []string) {
+		for _, fp := range files {
+			localPath := filepath.Join(logsPath, fmt.Sprintf("%s-%s", node, filepath.Base(fp)))
+			if err := conn.Download(node, fp, localPath, remote.DefaultCommandTimeout); err != nil {
+				log.Printf("Error reading file from path (%s):%s", fp, err)
 			}
 		}
 	}
-	connectString := fmt.Sprintf("%s@%s:/tmp/k8s-*", conn.User, hostname)
-	logsPath := filepath.Join(cfg.CurrentWorkingDir, "_logs", hostname)
-	cmd = exec.Command("scp", "-i", conn.PrivateKeyPath, "-o", "ConnectTimeout=30", "-o", "StrictHostKeyChecking=no", connectString, logsPath)
-	util.PrintCommand(cmd)
-	out, err = cmd.CombinedOutput()
-	if err != nil {
-		log.Printf("Error output:%s\n", out)
-		return err
+	for _, master := range cli.Masters {
+		download(master.Name, masterFiles)
+	}
+	for _, agent := range cli.Agents {
+		download(agent.Name, agentFiles)
 	}
 
 	return nil
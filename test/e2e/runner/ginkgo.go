@@ -8,16 +8,24 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 
+	"github.com/Azure/aks-engine/test/e2e/capability"
 	"github.com/Azure/aks-engine/test/e2e/config"
+	"github.com/Azure/aks-engine/test/e2e/engine"
 	"github.com/Azure/aks-engine/test/e2e/kubernetes/util"
 	"github.com/Azure/aks-engine/test/e2e/metrics"
 	"github.com/kelseyhightower/envconfig"
 )
 
+// serialSpecs matches the specs that must not run alongside other specs, e.g. because they disrupt every node of a pool.
+// With more than one ginkgo node they run on their own once the other specs ran in parallel.
+const serialSpecs = `\[Serial\]`
+
 // Ginkgo contains all of the information needed to run the ginkgo suite of tests
 type Ginkgo struct {
-	GinkgoNodes string `envconfig:"GINKGO_NODES" default:"6"`
+	GinkgoNodes int `envconfig:"GINKGO_NODES" default:"1"` // GinkgoNodes is the number of specs run in parallel
 	Config      *config.Config
 	Point       *metrics.Point
 }
@@ -36,19 +44,40 @@ func BuildGinkgoRunner(cfg *config.Config, pt *metrics.Point) (*Ginkgo, error) {
 // Run will execute an orchestrator suite of tests
 func (g *Ginkgo) Run() error {
 	g.Point.SetTestStart()
+	// the summary of the run counts every JUnit XML file in the results directory, those of an earlier run against the cluster must go
+	stale, _ := filepath.Glob(filepath.Join(g.Config.GetResultsPath(), "junit*.xml"))
+	for _, file := range stale {
+		if err := os.Remove(file); err != nil {
+			log.Printf("Error removing the results of an earlier run %s:%s\n", file, err)
+		}
+	}
 	testDir := fmt.Sprintf("test/e2e/%s", g.Config.Orchestrator)
-	var cmd = exec.Command("ginkgo", "-slowSpecThreshold", "180", "-failFast", "-r", "-v", "--focus", g.Config.GinkgoFocus, "--skip", g.Config.GinkgoSkip, testDir)
-	util.PrintCommand(cmd)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err := cmd.Start()
-	if err != nil {
-		g.Point.RecordTestError()
-		log.Printf("Error while trying to start ginkgo:%s\n", err)
-		return err
+	focus, skip := g.Config.GinkgoFocus, g.Config.GinkgoSkip
+	// the specs requiring what the cluster lacks skip themselves, skipping them here keeps them out of the report
+	if caps, err := g.clusterCapabilities(); err != nil {
+		log.Printf("Error detecting the capabilities of the cluster, the specs will skip themselves:%s\n", err)
+	} else {
+		log.Printf("Cluster capabilities:%s\n", caps)
+		skip = anyOf(skip, caps.Skip())
+	}
+	if g.Config.FocusProfile != "" {
+		profile, err := profileCapabilities(g.Config.GetFocusProfilePath())
+		if err != nil {
+			log.Printf("Error detecting the capabilities of the focus profile %s:%s\n", g.Config.FocusProfile, err)
+			return err
+		}
+		log.Printf("Focusing on the specs relevant to %s:%s\n", g.Config.FocusProfile, profile)
+		focus = allOf(focus, profile.Focus())
+	}
+	var err error
+	if g.GinkgoNodes > 1 {
+		err = g.run(testDir, g.GinkgoNodes, focus, anyOf(skip, serialSpecs))
+		if err == nil {
+			err = g.run(testDir, 1, allOf(focus, serialSpecs), skip)
+		}
+	} else {
+		err = g.run(testDir, 1, focus, skip)
 	}
-
-	err = cmd.Wait()
 	if err != nil {
 		g.Point.RecordTestError()
 		if g.Config.IsKubernetes() {
@@ -64,3 +93,61 @@ func (g *Ginkgo) Run() error {
 	g.Point.RecordTestSuccess()
 	return nil
 }
+
+// run runs the specs matching focus and not skip on nodes parallel ginkgo nodes
+func (g *Ginkgo) run(testDir string, nodes int, focus, skip string) error {
+	var cmd = exec.Command("ginkgo", "-slowSpecThreshold", "180", "-failFast", "-r", "-v", "-nodes", strconv.Itoa(nodes), "--focus", focus, "--skip", skip, testDir)
+	util.PrintCommand(cmd)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Start()
+	if err != nil {
+		log.Printf("Error while trying to start ginkgo:%s\n", err)
+		return err
+	}
+	return cmd.Wait()
+}
+
+// clusterCapabilities detects the capabilities of the cluster under test from its generated apimodel
+func (g *Ginkgo) clusterCapabilities() (capability.Set, error) {
+	engCfg, err := engine.ParseConfig(g.Config.CurrentWorkingDir, g.Config.ClusterDefinition, g.Config.Name)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := engine.ParseOutput(filepath.Join(engCfg.GeneratedDefinitionPath, "apimodel.json"), false, false)
+	if err != nil {
+		return nil, err
+	}
+	return capability.Detect(cs), nil
+}
+
+// profileCapabilities detects the capabilities a cluster definition declares, it is not defaulted like a generated apimodel is
+func profileCapabilities(path string) (capability.Set, error) {
+	cs, err := engine.ParseOutput(path, false, false)
+	if err != nil {
+		return nil, err
+	}
+	return capability.Detect(cs), nil
+}
+
+// anyOf returns a regular expression matching a or b, an empty one is left out
+func anyOf(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	return fmt.Sprintf("(?:%s)|(?:%s)", a, b)
+}
+
+// allOf returns a regular expression matching the texts that both a and b match, an empty one is left out
+func allOf(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	return fmt.Sprintf("(?:%s).*(?:%s)|(?:%s).*(?:%s)", a, b, b, a)
+}
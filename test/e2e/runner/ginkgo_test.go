@@ -0,0 +1,43 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package runner
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestAllOf(t *testing.T) {
+	cases := []struct {
+		a, b    string
+		text    string
+		matches bool
+	}{
+		{a: "dns", b: serialSpecs, text: "should scale coredns with the dns-autoscaler [Serial]", matches: true},
+		{a: "dns", b: serialSpecs, text: "should have functional container networking DNS", matches: false},
+		{a: "dns", b: serialSpecs, text: "should drain a node [Serial]", matches: false},
+		{a: "", b: serialSpecs, text: "should drain a node [Serial]", matches: true},
+		{a: `\[Requires:(?:linux|windows)\]`, b: "", text: "should run jobs [Requires:linux]", matches: true},
+	}
+	for _, c := range cases {
+		if matches := regexp.MustCompile(allOf(c.a, c.b)).MatchString(c.text); matches != c.matches {
+			t.Errorf("expected allOf(%q, %q) matching %q to be %v", c.a, c.b, c.text, c.matches)
+		}
+	}
+	if allOf("", "") != "" {
+		t.Errorf("expected allOf of empty expressions to be empty")
+	}
+}
+
+func TestAnyOf(t *testing.T) {
+	if expr := anyOf("a", "b"); expr != "(?:a)|(?:b)" {
+		t.Errorf("unexpected expression %s", expr)
+	}
+	if expr := anyOf("", "b"); expr != "b" {
+		t.Errorf("expected an empty expression to be left out, got %s", expr)
+	}
+	if expr := anyOf("a", ""); expr != "a" {
+		t.Errorf("expected an empty expression to be left out, got %s", expr)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Azure/aks-engine/test/e2e/metrics"
+)
+
+// Phase is a step of the cluster creation with its own time budget
+type Phase string
+
+const (
+	// PhaseARMDeployment is the ARM deployment of the cluster, until it succeeds
+	PhaseARMDeployment Phase = "ARM deployment"
+	// PhaseMastersRegistered lasts until all the masters have registered as nodes
+	PhaseMastersRegistered Phase = "masters registered"
+	// PhaseNodesReady lasts until all the nodes are Ready
+	PhaseNodesReady Phase = "nodes Ready"
+	// PhaseAddonsHealthy lasts until all the pods of the addons in kube-system are Ready
+	PhaseAddonsHealthy Phase = "addons healthy"
+	// PhaseJumpboxNodesReady lasts until kubectl on the jumpbox of a private cluster lists all the nodes as Ready
+	PhaseJumpboxNodesReady Phase = "nodes Ready through the jumpbox"
+)
+
+// phaseProgressInterval is how often the progress of a phase is logged
+var phaseProgressInterval = time.Minute
+
+// PhaseError is the error of a phase of the cluster creation that failed or ran out of its budget
+type PhaseError struct {
+	Phase    Phase
+	Budget   time.Duration
+	Elapsed  time.Duration
+	TimedOut bool
+	// Status is the last progress the phase reported before it failed
+	Status string
+	Err    error
+}
+
+func (e *PhaseError) Error() string {
+	s := fmt.Sprintf("cluster creation failed in phase %q after %s", e.Phase, e.Elapsed.Round(time.Second))
+	if e.TimedOut {
+		s = fmt.Sprintf("cluster creation timed out in phase %q, its %s budget is spent", e.Phase, e.Budget)
+	}
+	if e.Status != "" {
+		s += fmt.Sprintf(" (%s)", e.Status)
+	}
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+// runPhase runs fn with a context cancelled once the budget of the phase is spent and records its duration in pt.
+// fn reports its progress, which is logged every phaseProgressInterval with the time left of the budget, and is
+// part of the error of the phase.
+func runPhase(pt *metrics.Point, phase Phase, budget time.Duration, fn func(ctx context.Context, report func(string)) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), budget)
+	defer cancel()
+
+	var mu sync.Mutex
+	var status string
+	report := func(s string) {
+		mu.Lock()
+		defer mu.Unlock()
+		status = s
+	}
+	lastStatus := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return status
+	}
+
+	start := time.Now()
+	log.Printf("Phase %q started, its budget is %s\n", phase, budget)
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx, report)
+	}()
+
+	ticker := time.NewTicker(phaseProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			elapsed := time.Since(start)
+			if err == nil {
+				log.Printf("Phase %q succeeded after %s\n", phase, elapsed.Round(time.Second))
+				pt.RecordPhase(string(phase), elapsed, nil)
+				return nil
+			}
+			phaseErr := &PhaseError{
+				Phase:    phase,
+				Budget:   budget,
+				Elapsed:  elapsed,
+				TimedOut: ctx.Err() == context.DeadlineExceeded,
+				Status:   lastStatus(),
+				Err:      err,
+			}
+			pt.RecordPhase(string(phase), elapsed, phaseErr)
+			return phaseErr
+		case <-ticker.C:
+			elapsed := time.Since(start)
+			progress := fmt.Sprintf("Phase %q: %s elapsed, %s left of its budget", phase, elapsed.Round(time.Second), (budget - elapsed).Round(time.Second))
+			if s := lastStatus(); s != "" {
+				progress += ", " + s
+			}
+			log.Println(progress)
+		}
+	}
+}
+
+// remaining returns the time left until the deadline of ctx
+func remaining(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	return time.Until(deadline)
+}
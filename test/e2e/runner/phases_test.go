@@ -0,0 +1,63 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/aks-engine/test/e2e/metrics"
+	"github.com/pkg/errors"
+)
+
+func TestRunPhase(t *testing.T) {
+	defer func(interval time.Duration) { phaseProgressInterval = interval }(phaseProgressInterval)
+	phaseProgressInterval = 10 * time.Millisecond
+	pt := metrics.BuildPoint("kubernetes", "westus2", "kubernetes.json", "")
+
+	err := runPhase(pt, PhaseARMDeployment, time.Minute, func(ctx context.Context, report func(string)) error {
+		report("deploying")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected the phase to succeed, but instead got %s", err)
+	}
+	if _, ok := pt.PhaseDurations[string(PhaseARMDeployment)]; !ok || pt.FailedPhase != "" {
+		t.Fatalf("expected the duration of the phase to be recorded without a failed phase, but instead got %v and %q", pt.PhaseDurations, pt.FailedPhase)
+	}
+
+	err = runPhase(pt, PhaseNodesReady, time.Minute, func(ctx context.Context, report func(string)) error {
+		report("1 of 3 nodes are not Ready: k8s-agentpool1-12345678-0")
+		return errors.New("Not all nodes in a healthy state")
+	})
+	phaseErr, ok := err.(*PhaseError)
+	if !ok || phaseErr.Phase != PhaseNodesReady || phaseErr.TimedOut {
+		t.Fatalf("expected the phase to fail without timing out, but instead got %v", err)
+	}
+	if !strings.Contains(err.Error(), `failed in phase "nodes Ready"`) || !strings.Contains(err.Error(), "k8s-agentpool1-12345678-0") {
+		t.Fatalf("expected the error to name the phase and its last progress, but instead got %s", err)
+	}
+	if pt.FailedPhase != string(PhaseNodesReady) {
+		t.Fatalf("expected the failed phase to be recorded, but instead got %q", pt.FailedPhase)
+	}
+
+	err = runPhase(pt, PhaseAddonsHealthy, 50*time.Millisecond, func(ctx context.Context, report func(string)) error {
+		report("1 of 10 kube-system pods are not Ready: coredns-1 is Pending")
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	phaseErr, ok = err.(*PhaseError)
+	if !ok || !phaseErr.TimedOut || !strings.Contains(err.Error(), `timed out in phase "addons healthy"`) || !strings.Contains(err.Error(), "coredns-1 is Pending") {
+		t.Fatalf("expected the phase to time out with its last progress, but instead got %v", err)
+	}
+
+	err = runPhase(pt, PhaseNodesReady, time.Minute, func(ctx context.Context, report func(string)) error {
+		return nil
+	})
+	if err != nil || pt.FailedPhase != string(PhaseAddonsHealthy) {
+		t.Fatalf("expected a retried phase that succeeds not to clear the failure of another phase, but instead got %q", pt.FailedPhase)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/aks-engine/test/e2e/config"
+	"github.com/Azure/aks-engine/test/e2e/engine"
+	"github.com/Azure/aks-engine/test/e2e/metrics"
+	"github.com/onsi/ginkgo/reporters"
+	"github.com/pkg/errors"
+)
+
+// SummaryFile is the name of the JSON summary of a run, in the results directory
+const SummaryFile = "summary.json"
+
+// Summary is the machine-readable result of an e2e run, written next to the JUnit XML of the test suite for CI dashboards
+type Summary struct {
+	Cluster             string    `json:"cluster"`
+	Orchestrator        string    `json:"orchestrator"`
+	OrchestratorVersion string    `json:"orchestratorVersion,omitempty"`
+	Location            string    `json:"location"`
+	ClusterDefinition   string    `json:"clusterDefinition"`
+	ClusterConfigHash   string    `json:"clusterConfigHash,omitempty"` // ClusterConfigHash is the SHA-256 of the cluster definition, runs of the same definition have the same hash
+	Passed              bool      `json:"passed"`
+	Start               time.Time `json:"start"`
+	DurationSeconds     float64   `json:"durationSeconds"`
+	ProvisionSeconds    float64   `json:"provisionSeconds"`
+	TestSeconds         float64   `json:"testSeconds"`
+	FailedPhase         string    `json:"failedPhase,omitempty"` // FailedPhase is the phase of the cluster creation that failed or ran out of its budget
+	Tests               int       `json:"tests"`
+	Failures            int       `json:"failures"`
+	Errors              int       `json:"errors"`
+	Skipped             int       `json:"skipped"`
+	FailedTests         []string  `json:"failedTests,omitempty"`
+}
+
+// BuildSummary summarizes a run from its configuration, its timings and the JUnit XML the test suite wrote to resultsDir.
+// eng is nil if the run did not get as far as provisioning the cluster.
+func BuildSummary(cfg *config.Config, eng *engine.Engine, pt *metrics.Point, resultsDir string) (*Summary, error) {
+	s := &Summary{
+		Cluster:           cfg.Name,
+		Orchestrator:      cfg.Orchestrator,
+		Location:          cfg.Location,
+		ClusterDefinition: cfg.ClusterDefinition,
+		Start:             pt.OverallStart,
+		DurationSeconds:   pt.OverallDuration.Seconds(),
+		ProvisionSeconds:  pt.ProvisionDuration.Seconds(),
+		TestSeconds:       pt.TestDuration.Seconds(),
+		FailedPhase:       pt.FailedPhase,
+	}
+	s.OrchestratorVersion = orchestratorVersion(eng)
+	if definition, err := ioutil.ReadFile(filepath.Join(cfg.CurrentWorkingDir, cfg.ClusterDefinition)); err == nil {
+		hash := sha256.Sum256(definition)
+		s.ClusterConfigHash = hex.EncodeToString(hash[:])
+	}
+
+	files, err := filepath.Glob(filepath.Join(resultsDir, "junit*.xml"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var suite reporters.JUnitTestSuite
+		if err = xml.Unmarshal(data, &suite); err != nil {
+			return nil, errors.Wrapf(err, "unmarshalling %s", file)
+		}
+		s.Tests += suite.Tests
+		s.Failures += suite.Failures
+		s.Errors += suite.Errors
+		for _, c := range suite.TestCases {
+			if c.Skipped != nil {
+				s.Skipped++
+			}
+			if c.FailureMessage != nil {
+				s.FailedTests = append(s.FailedTests, c.Name)
+			}
+		}
+	}
+	s.Passed = pt.ProvisionErrorCount == 0 && pt.NodeWaitErrorCount == 0 && pt.TestErrorCount == 0 && s.Failures == 0 && s.Errors == 0
+	return s, nil
+}
+
+// orchestratorVersion returns the orchestrator version of the cluster, as generated if known or as requested otherwise
+func orchestratorVersion(eng *engine.Engine) string {
+	if eng == nil {
+		return ""
+	}
+	if eng.ExpandedDefinition != nil && eng.ExpandedDefinition.Properties != nil && eng.ExpandedDefinition.Properties.OrchestratorProfile != nil {
+		return eng.ExpandedDefinition.Properties.OrchestratorProfile.OrchestratorVersion
+	}
+	if eng.ClusterDefinition != nil && eng.ClusterDefinition.Properties != nil && eng.ClusterDefinition.Properties.OrchestratorProfile != nil {
+		p := eng.ClusterDefinition.Properties.OrchestratorProfile
+		if p.OrchestratorVersion != "" {
+			return p.OrchestratorVersion
+		}
+		return p.OrchestratorRelease
+	}
+	return ""
+}
+
+// Write writes the summary to SummaryFile in dir
+func (s *Summary) Write(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, SummaryFile), data, 0644)
+}
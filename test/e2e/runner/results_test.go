@@ -0,0 +1,71 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package runner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/vlabs"
+	"github.com/Azure/aks-engine/test/e2e/config"
+	"github.com/Azure/aks-engine/test/e2e/engine"
+	"github.com/Azure/aks-engine/test/e2e/metrics"
+)
+
+const junitXML = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="Kubernetes Suite" tests="3" failures="1" errors="0" time="10">
+  <testcase name="should have addons running" classname="Kubernetes Suite" time="4"></testcase>
+  <testcase name="should be able to scale an iis webserver" classname="Kubernetes Suite" time="0"><skipped></skipped></testcase>
+  <testcase name="should have healthy time synchronization" classname="Kubernetes Suite" time="6"><failure type="Failure">timeout</failure></testcase>
+</testsuite>`
+
+func TestBuildSummary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = ioutil.WriteFile(filepath.Join(dir, "junit_01_abcd.xml"), []byte(junitXML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "kubernetes.json"), []byte(`{"apiVersion": "vlabs"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Name: "e2e-test", Orchestrator: "kubernetes", Location: "westus2", ClusterDefinition: "kubernetes.json", CurrentWorkingDir: dir}
+	eng := &engine.Engine{
+		ClusterDefinition: &api.VlabsARMContainerService{
+			ContainerService: &vlabs.ContainerService{
+				Properties: &vlabs.Properties{OrchestratorProfile: &vlabs.OrchestratorProfile{OrchestratorRelease: "1.15"}},
+			},
+		},
+	}
+	pt := metrics.BuildPoint("kubernetes", "westus2", "kubernetes.json", "subscription")
+	s, err := BuildSummary(cfg, eng, pt, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Tests != 3 || s.Failures != 1 || s.Skipped != 1 || s.Passed {
+		t.Errorf("expected a failed run of 3 tests with 1 failure and 1 skipped, got %+v", s)
+	}
+	if len(s.FailedTests) != 1 || s.FailedTests[0] != "should have healthy time synchronization" {
+		t.Errorf("expected the failed test to be listed, got %v", s.FailedTests)
+	}
+	if s.OrchestratorVersion != "1.15" {
+		t.Errorf("expected the requested orchestrator release 1.15, got %s", s.OrchestratorVersion)
+	}
+	if len(s.ClusterConfigHash) != 64 {
+		t.Errorf("expected a SHA-256 hash of the cluster definition, got %q", s.ClusterConfigHash)
+	}
+
+	if err = s.Write(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, SummaryFile)); err != nil {
+		t.Errorf("expected the summary to be written: %s", err)
+	}
+}